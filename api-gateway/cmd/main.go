@@ -6,9 +6,12 @@ import (
 
     "api-gateway/internal/config"
     "api-gateway/internal/server"
+    "erp-system/shared/utils"
 )
 
 func main() {
+    utils.InitLogger("api-gateway")
+
     cfg, err := config.Load()
     if err != nil {
         log.Fatalf("Failed to load config: %v", err)
@@ -2,52 +2,332 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Port      string
-	JWTSecret string
 	Services  ServiceConfig
+	Upstreams []UpstreamConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	Redis     RedisConfig
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For is
+	// believed for the client IP. Empty trusts none.
+	TrustedProxies []string
 }
 
+// ServiceConfig holds the backends the gateway calls itself rather than
+// proxies to; AuthService is the URL of the "auth" upstream.
 type ServiceConfig struct {
 	AuthService string
-	FMService   string
-	HRService   string
-	SCMService  string
-	MService    string
-	CRMService  string
-	PMService   string
-	EAMService  string
-	PLMService  string
-	QMSService  string
-	BFFService  string
+}
+
+// AuthConfig controls how the gateway checks tokens. Signatures are verified
+// locally against the keys auth-service publishes at JWKSURL, refetched every
+// JWKSRefresh. With Introspect the token is also confirmed with auth-service;
+// results are cached for CacheTTL and events on the auth Kafka topics evict
+// cached entries earlier.
+type AuthConfig struct {
+	JWKSURL      string
+	JWKSRefresh  time.Duration
+	Issuer       string
+	Introspect   bool
+	CacheTTL     time.Duration
+	Timeout      time.Duration
+	KafkaBrokers []string
+}
+
+// UpstreamConfig maps a gateway path prefix onto a backend service. Requests
+// under PathPrefix are forwarded with that prefix replaced by UpstreamPrefix.
+type UpstreamConfig struct {
+	Name           string
+	URL            string
+	PathPrefix     string
+	UpstreamPrefix string
+	// Permission is the RBAC service any read grant of which opens the
+	// upstream's route group; empty lets every authenticated caller in
+	Permission string
+	// Timeout bounds each attempt until the backend sends response headers
+	Timeout time.Duration
+	// Retries is the number of extra attempts for idempotent methods
+	Retries int
+	// The circuit opens after FailureThreshold consecutive failures and lets a
+	// probe request through once OpenTimeout has passed
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+// RateLimitConfig holds the token-bucket quotas applied per user, legal entity
+// and route group. Routes overrides the default quota for a route group such
+// as "finance" or "auth".
+type RateLimitConfig struct {
+	Enabled           bool
+	RequestsPerMinute int
+	Burst             int
+	Routes            map[string]RouteQuota
+	IdleTTL           time.Duration
+}
+
+type RouteQuota struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// RedisConfig points the rate limiter at a shared redis; an empty Host keeps
+// the counters in gateway memory.
+type RedisConfig struct {
+	Host     string
+	Port     string
+	Password string
+	DB       int
 }
 
 func Load() (*Config, error) {
-	return &Config{
-		Port:      getEnv("PORT", "8080"),
-		JWTSecret: getEnv("JWT_SECRET", "your-super-secret-key"),
-		Services: ServiceConfig{
-			AuthService: getEnv("AUTH_SERVICE_URL", "http://auth-service:8000"),
-			FMService:   getEnv("FM_SERVICE_URL", "http://fm-service:8001"),
-			HRService:   getEnv("HR_SERVICE_URL", "http://hr-service:8003"),
-			SCMService:  getEnv("SCM_SERVICE_URL", "http://scm-service:8006"),
-			MService:    getEnv("M_SERVICE_URL", "http://m-service:8004"),
-			CRMService:  getEnv("CRM_SERVICE_URL", "http://crm-service:8002"),
-			PMService:   getEnv("PM_SERVICE_URL", "http://pm-service:8006"),
-			EAMService:  getEnv("EAM_SERVICE_URL", "http://eam-service:8007"),
-			PLMService:  getEnv("PLM_SERVICE_URL", "http://plm-service:8008"),
-			QMSService:  getEnv("QMS_SERVICE_URL", "http://qms-service:8009"),
-			BFFService:  getEnv("BFF_SERVICE_URL", "http://api-gateway-bff:8085"),
+	routes, err := parseRouteQuotas(getEnv("RATE_LIMIT_ROUTES", "auth=30:10"))
+	if err != nil {
+		return nil, err
+	}
+	idleTTL, err := getEnvDuration("RATE_LIMIT_IDLE_TTL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Port: getEnv("PORT", "8080"),
+		RateLimit: RateLimitConfig{
+			Enabled:           getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+			RequestsPerMinute: getEnvInt("RATE_LIMIT_PER_MINUTE", 300),
+			Burst:             getEnvInt("RATE_LIMIT_BURST", 60),
+			Routes:            routes,
+			IdleTTL:           idleTTL,
+		},
+		Redis: RedisConfig{
+			Host:     getEnv("REDIS_HOST", ""),
+			Port:     getEnv("REDIS_PORT", "6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvInt("REDIS_DB", 0),
 		},
+	}
+	if value := getEnv("TRUSTED_PROXIES", ""); value != "" {
+		cfg.TrustedProxies = strings.Split(value, ",")
+	}
+	if cfg.Upstreams, err = loadUpstreams(); err != nil {
+		return nil, err
+	}
+	for _, up := range cfg.Upstreams {
+		if up.Name == "auth" {
+			cfg.Services.AuthService = up.URL
+		}
+	}
+	if cfg.Services.AuthService == "" {
+		return nil, fmt.Errorf("UPSTREAMS must include the auth upstream")
+	}
+	if cfg.Auth, err = loadAuth(cfg.Services.AuthService); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.RequestsPerMinute <= 0 || cfg.RateLimit.Burst <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_PER_MINUTE and RATE_LIMIT_BURST must be positive")
+	}
+	return cfg, nil
+}
+
+func loadAuth(authServiceURL string) (AuthConfig, error) {
+	jwksRefresh, err := getEnvDuration("AUTH_JWKS_REFRESH", 10*time.Minute)
+	if err != nil {
+		return AuthConfig{}, err
+	}
+	cacheTTL, err := getEnvDuration("AUTH_CACHE_TTL", 10*time.Second)
+	if err != nil {
+		return AuthConfig{}, err
+	}
+	timeout, err := getEnvDuration("AUTH_VALIDATE_TIMEOUT", 3*time.Second)
+	if err != nil {
+		return AuthConfig{}, err
+	}
+	issuer := getEnv("AUTH_ISSUER", "")
+	if issuer == "" {
+		return AuthConfig{}, fmt.Errorf("AUTH_ISSUER must be set to auth-service's OIDC_ISSUER")
+	}
+	var brokers []string
+	if value := getEnv("KAFKA_BROKERS", ""); value != "" {
+		brokers = strings.Split(value, ",")
+	}
+	return AuthConfig{
+		JWKSURL:      getEnv("AUTH_JWKS_URL", authServiceURL+"/api/v1/auth/.well-known/jwks.json"),
+		JWKSRefresh:  jwksRefresh,
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		Introspect:   getEnv("AUTH_INTROSPECTION", "true") == "true",
+		CacheTTL:     cacheTTL,
+		Timeout:      timeout,
+		KafkaBrokers: brokers,
 	}, nil
 }
 
+// defaultUpstreams is the routing table used without UPSTREAMS. Backends that
+// register their routes under /api/v1 get the gateway's service prefix
+// stripped; mfg serves under /api/v1/mfg, and prj, eam, plm and qms under the
+// gateway's own prefix. Each URL can be set with its urlEnv variable.
+var defaultUpstreams = []struct {
+	name, urlEnv, url, prefix, upstreamPrefix, permission string
+}{
+	{"auth", "AUTH_SERVICE_URL", "http://auth-service:8000", "/api/v1/auth", "/api/v1/auth", ""},
+	{"fm", "FM_SERVICE_URL", "http://fm-service:8001", "/api/v1/finance", "/api/v1", "fm"},
+	{"hr", "HR_SERVICE_URL", "http://hr-service:8003", "/api/v1/hr", "/api/v1", "hr"},
+	{"scm", "SCM_SERVICE_URL", "http://scm-service:8006", "/api/v1/scm", "/api/v1", "scm"},
+	{"mfg", "M_SERVICE_URL", "http://mfg-service:8004", "/api/v1/manufacturing", "/api/v1/mfg", "m"},
+	{"crm", "CRM_SERVICE_URL", "http://crm-service:8002", "/api/v1/crm", "/api/v1", "crm"},
+	{"prj", "PM_SERVICE_URL", "http://prj-service:8005", "/api/v1/projects", "/api/v1/projects", "pm"},
+	{"eam", "EAM_SERVICE_URL", "http://eam-service:8007", "/api/v1/eam", "/api/v1/eam", "eam"},
+	{"plm", "PLM_SERVICE_URL", "http://plm-service:8008", "/api/v1/plm", "/api/v1/plm", "plm"},
+	{"qms", "QMS_SERVICE_URL", "http://qms-service:8009", "/api/v1/qms", "/api/v1/qms", "qms"},
+	{"ui", "BFF_SERVICE_URL", "http://api-gateway-bff:8085", "/api/v1/ui", "/api/v1/ui", ""},
+}
+
+// loadUpstreams builds the routing table from UPSTREAMS, or from
+// defaultUpstreams when it is unset. Timeouts and retries can be tuned per
+// upstream with <NAME>_SERVICE_TIMEOUT and <NAME>_SERVICE_RETRIES, NAME being
+// the upper-cased upstream name.
+func loadUpstreams() ([]UpstreamConfig, error) {
+	var upstreams []UpstreamConfig
+	if spec := getEnv("UPSTREAMS", ""); spec != "" {
+		var err error
+		if upstreams, err = parseUpstreams(spec); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, d := range defaultUpstreams {
+			upstreams = append(upstreams, UpstreamConfig{
+				Name:           d.name,
+				URL:            getEnv(d.urlEnv, d.url),
+				PathPrefix:     d.prefix,
+				UpstreamPrefix: d.upstreamPrefix,
+				Permission:     d.permission,
+			})
+		}
+	}
+
+	defaultTimeout, err := getEnvDuration("PROXY_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	openTimeout, err := getEnvDuration("CIRCUIT_OPEN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defaultRetries := getEnvInt("PROXY_RETRIES", 2)
+	threshold := getEnvInt("CIRCUIT_FAILURE_THRESHOLD", 5)
+
+	for i := range upstreams {
+		env := strings.ToUpper(upstreams[i].Name)
+		if upstreams[i].Timeout, err = getEnvDuration(env+"_SERVICE_TIMEOUT", defaultTimeout); err != nil {
+			return nil, err
+		}
+		upstreams[i].Retries = getEnvInt(env+"_SERVICE_RETRIES", defaultRetries)
+		upstreams[i].FailureThreshold = threshold
+		upstreams[i].OpenTimeout = openTimeout
+	}
+	return upstreams, nil
+}
+
+// parseUpstreams reads "name=url|pathPrefix|upstreamPrefix|permission"
+// entries separated by commas, e.g.
+// "hr=http://hr-service:8003|/api/v1/hr|/api/v1|hr". The upstream prefix
+// defaults to the path prefix and the permission to none. Path prefixes must
+// lie under /api/v1/.
+func parseUpstreams(spec string) ([]UpstreamConfig, error) {
+	var upstreams []UpstreamConfig
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(entry, "=")
+		fields := strings.Split(target, "|")
+		if !ok || name == "" || len(fields) < 2 || len(fields) > 4 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid UPSTREAMS entry %q", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate upstream %q in UPSTREAMS", name)
+		}
+		seen[name] = true
+		up := UpstreamConfig{Name: name, URL: fields[0], PathPrefix: fields[1], UpstreamPrefix: fields[1]}
+		if !strings.HasPrefix(up.PathPrefix, "/api/v1/") || strings.HasSuffix(up.PathPrefix, "/") {
+			return nil, fmt.Errorf("invalid path prefix in UPSTREAMS entry %q", entry)
+		}
+		if len(fields) > 2 && fields[2] != "" {
+			up.UpstreamPrefix = fields[2]
+		}
+		if len(fields) > 3 {
+			up.Permission = fields[3]
+		}
+		upstreams = append(upstreams, up)
+	}
+	if len(upstreams) == 0 {
+		return nil, fmt.Errorf("UPSTREAMS lists no upstreams")
+	}
+	return upstreams, nil
+}
+
+// parseRouteQuotas reads "group=perMinute:burst" pairs separated by commas,
+// e.g. "auth=30:10,finance=600:100". The burst part is optional and defaults
+// to the per-minute rate.
+func parseRouteQuotas(spec string) (map[string]RouteQuota, error) {
+	routes := make(map[string]RouteQuota)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, quota, ok := strings.Cut(entry, "=")
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES entry %q", entry)
+		}
+		rate, burst, hasBurst := strings.Cut(quota, ":")
+		perMinute, err := strconv.Atoi(rate)
+		if err != nil || perMinute <= 0 {
+			return nil, fmt.Errorf("invalid rate in RATE_LIMIT_ROUTES entry %q", entry)
+		}
+		q := RouteQuota{RequestsPerMinute: perMinute, Burst: perMinute}
+		if hasBurst {
+			if q.Burst, err = strconv.Atoi(burst); err != nil || q.Burst <= 0 {
+				return nil, fmt.Errorf("invalid burst in RATE_LIMIT_ROUTES entry %q", entry)
+			}
+		}
+		routes[strings.TrimSpace(group)] = q
+	}
+	return routes, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
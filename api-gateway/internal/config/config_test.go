@@ -0,0 +1,85 @@
+package config
+
+import "testing"
+
+func TestParseUpstreams(t *testing.T) {
+	ups, err := parseUpstreams("auth=http://auth:8000|/api/v1/auth, hr=http://hr:8003|/api/v1/hr|/api/v1|hr,mfg=http://mfg:8004|/api/v1/manufacturing|/api/v1/mfg|m")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []UpstreamConfig{
+		{Name: "auth", URL: "http://auth:8000", PathPrefix: "/api/v1/auth", UpstreamPrefix: "/api/v1/auth"},
+		{Name: "hr", URL: "http://hr:8003", PathPrefix: "/api/v1/hr", UpstreamPrefix: "/api/v1", Permission: "hr"},
+		{Name: "mfg", URL: "http://mfg:8004", PathPrefix: "/api/v1/manufacturing", UpstreamPrefix: "/api/v1/mfg", Permission: "m"},
+	}
+	if len(ups) != len(want) {
+		t.Fatalf("expected %d upstreams, got %+v", len(want), ups)
+	}
+	for i := range want {
+		if ups[i] != want[i] {
+			t.Errorf("upstream %d: expected %+v, got %+v", i, want[i], ups[i])
+		}
+	}
+
+	for _, spec := range []string{
+		"",
+		"hr",
+		"hr=http://hr:8003",
+		"hr=|/api/v1/hr",
+		"hr=http://hr:8003|/hr",
+		"hr=http://hr:8003|/api/v1/hr/",
+		"hr=http://hr:8003|/api/v1/hr|/api/v1|hr|extra",
+		"hr=http://hr:8003|/api/v1/hr,hr=http://hr:8003|/api/v1/people",
+	} {
+		if _, err := parseUpstreams(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestLoadUpstreams(t *testing.T) {
+	t.Setenv("HR_SERVICE_URL", "http://hr.internal:9003")
+	t.Setenv("MFG_SERVICE_RETRIES", "4")
+	ups, err := loadUpstreams()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	byName := make(map[string]UpstreamConfig)
+	for _, up := range ups {
+		byName[up.Name] = up
+	}
+	if byName["hr"].URL != "http://hr.internal:9003" || byName["hr"].Permission != "hr" {
+		t.Errorf("unexpected hr upstream %+v", byName["hr"])
+	}
+	if byName["mfg"].Retries != 4 || byName["mfg"].Permission != "m" {
+		t.Errorf("unexpected mfg upstream %+v", byName["mfg"])
+	}
+	if byName["ui"].Permission != "" {
+		t.Errorf("expected the ui upstream to need no permission, got %q", byName["ui"].Permission)
+	}
+
+	t.Setenv("UPSTREAMS", "auth=http://auth:8000|/api/v1/auth,wms=http://wms:8010|/api/v1/wms|/api/v1|wms")
+	ups, err = loadUpstreams()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(ups) != 2 || ups[1].Name != "wms" || ups[1].Permission != "wms" || ups[1].Timeout == 0 {
+		t.Errorf("expected UPSTREAMS to replace the defaults, got %+v", ups)
+	}
+}
+
+func TestLoadAuthRequiresIssuer(t *testing.T) {
+	t.Setenv("AUTH_ISSUER", "")
+	if _, err := loadAuth("http://auth-service:8000"); err == nil {
+		t.Error("expected a missing AUTH_ISSUER to be refused")
+	}
+
+	t.Setenv("AUTH_ISSUER", "http://localhost:8080/api/v1/auth/")
+	auth, err := loadAuth("http://auth-service:8000")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if auth.Issuer != "http://localhost:8080/api/v1/auth" {
+		t.Errorf("expected the issuer without its trailing slash, got %q", auth.Issuer)
+	}
+}
@@ -0,0 +1,99 @@
+// File: api-gateway/internal/handlers/circuit_breaker.go
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreaker stops traffic to a backend after a run of consecutive
+// failures. Once the open timeout has passed a single probe is let through;
+// its outcome closes the circuit again or restarts the timeout.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	threshold   int
+	openTimeout time.Duration
+	openedAt    time.Time
+	probing     bool
+	now         func() time.Time
+}
+
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		state:       CircuitClosed,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// Allow reports whether a request may be sent. When it may not, it returns
+// how long until the next probe is allowed.
+func (b *CircuitBreaker) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		wait := b.openTimeout - b.now().Sub(b.openedAt)
+		if wait > 0 {
+			return false, wait
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true, 0
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, b.openTimeout
+		}
+		b.probing = true
+		return true, 0
+	}
+	return true, 0
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// Release frees a probe slot without judging the backend, e.g. when the
+// client went away before the backend answered.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -2,43 +2,98 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
+
+	"api-gateway/internal/config"
+	"erp-system/shared/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
+// maxRetryBody caps how much of a request body is buffered so the request
+// can be replayed; larger bodies are sent once.
+const maxRetryBody = 1 << 20
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type upstream struct {
+	cfg     config.UpstreamConfig
+	proxy   *httputil.ReverseProxy
+	breaker *CircuitBreaker
+}
+
 type ProxyHandler struct {
-	services map[string]*httputil.ReverseProxy
+	upstreams map[string]*upstream
+	response  *utils.ResponseHelper
 }
 
-func NewProxyHandler(serviceURLs map[string]string) *ProxyHandler {
-	proxies := make(map[string]*httputil.ReverseProxy)
-	
-	for service, serviceURL := range serviceURLs {
-		target, _ := url.Parse(serviceURL)
+type ginContextKey struct{}
+
+func NewProxyHandler(upstreams []config.UpstreamConfig, response *utils.ResponseHelper) (*ProxyHandler, error) {
+	p := &ProxyHandler{
+		upstreams: make(map[string]*upstream, len(upstreams)),
+		response:  response,
+	}
+
+	for _, cfg := range upstreams {
+		target, err := url.Parse(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for service %s: %w", cfg.Name, err)
+		}
+
+		u := &upstream{
+			cfg:     cfg,
+			breaker: NewCircuitBreaker(cfg.FailureThreshold, cfg.OpenTimeout),
+		}
+
+		base := http.DefaultTransport.(*http.Transport).Clone()
+		base.ResponseHeaderTimeout = cfg.Timeout
+
 		proxy := httputil.NewSingleHostReverseProxy(target)
-		
-		// Custom director to modify requests
 		originalDirector := proxy.Director
 		proxy.Director = func(req *http.Request) {
 			originalDirector(req)
-			// Add custom headers if needed
 			req.Header.Set("X-Forwarded-By", "api-gateway")
 		}
-		
-		proxies[service] = proxy
+		proxy.Transport = &retryTransport{
+			base:    base,
+			retries: cfg.Retries,
+			breaker: u.breaker,
+		}
+		proxy.ErrorHandler = p.proxyError(cfg.Name)
+		u.proxy = proxy
+
+		p.upstreams[cfg.Name] = u
 	}
-	
-	return &ProxyHandler{services: proxies}
+
+	return p, nil
 }
 
 func (p *ProxyHandler) ProxyToService(serviceName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		proxy, exists := p.services[serviceName]
+		u, exists := p.upstreams[serviceName]
 		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			p.response.NotFound(c, "Service not found")
+			return
+		}
+
+		if ok, wait := u.breaker.Allow(); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			p.response.Error(c, http.StatusServiceUnavailable,
+				"Service "+serviceName+" is temporarily unavailable",
+				fmt.Errorf("%w for %s", ErrCircuitOpen, serviceName))
 			return
 		}
 
@@ -50,25 +105,155 @@ func (p *ProxyHandler) ProxyToService(serviceName string) gin.HandlerFunc {
 			c.Request.Header.Set("X-Username", username.(string))
 		}
 
-		// Rewrite path for backend services that do not expect the service name prefix
-		originalPath := c.Request.URL.Path
-		switch serviceName {
-		case "fm":
-			c.Request.URL.Path = strings.Replace(c.Request.URL.Path, "/api/v1/finance", "/api/v1", 1)
-		case "hr":
-			c.Request.URL.Path = strings.Replace(c.Request.URL.Path, "/api/v1/hr", "/api/v1", 1)
-		case "scm":
-			c.Request.URL.Path = strings.Replace(c.Request.URL.Path, "/api/v1/scm", "/api/v1", 1)
-		case "m":
-			c.Request.URL.Path = strings.Replace(c.Request.URL.Path, "/api/v1/manufacturing", "/api/v1", 1)
-		case "crm":
-			c.Request.URL.Path = strings.Replace(c.Request.URL.Path, "/api/v1/crm", "/api/v1", 1)
+		// Map the gateway prefix onto the prefix the backend serves under
+		originalURL := *c.Request.URL
+		c.Request.URL.Path = rewritePrefix(c.Request.URL.Path, u.cfg.PathPrefix, u.cfg.UpstreamPrefix)
+		if c.Request.URL.RawPath != "" {
+			c.Request.URL.RawPath = rewritePrefix(c.Request.URL.RawPath, u.cfg.PathPrefix, u.cfg.UpstreamPrefix)
 		}
 
-		// Proxy the request
-		proxy.ServeHTTP(c.Writer, c.Request)
+		req := c.Request
+		ctx := context.WithValue(req.Context(), ginContextKey{}, c)
+		u.proxy.ServeHTTP(c.Writer, req.WithContext(ctx))
 
 		// Restore original path
-		c.Request.URL.Path = originalPath
+		c.Request.URL.Path = originalURL.Path
+		c.Request.URL.RawPath = originalURL.RawPath
 	}
-}
\ No newline at end of file
+}
+
+// CircuitState reports the breaker state of a service for status pages
+func (p *ProxyHandler) CircuitState(serviceName string) CircuitState {
+	if u, ok := p.upstreams[serviceName]; ok {
+		return u.breaker.State()
+	}
+	return ""
+}
+
+func rewritePrefix(path, from, to string) string {
+	if from == to || !strings.HasPrefix(path, from) {
+		return path
+	}
+	rest := path[len(from):]
+	if rest != "" && rest[0] != '/' {
+		return path
+	}
+	return to + rest
+}
+
+// proxyError answers failed upstream calls with the shared response envelope:
+// 504 when the backend timed out, 502 otherwise.
+func (p *ProxyHandler) proxyError(serviceName string) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		status := http.StatusBadGateway
+		message := "Service " + serviceName + " is unreachable"
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			status = http.StatusGatewayTimeout
+			message = "Service " + serviceName + " timed out"
+		}
+
+		c, ok := r.Context().Value(ginContextKey{}).(*gin.Context)
+		if !ok {
+			w.WriteHeader(status)
+			return
+		}
+		p.response.Error(c, status, message, err)
+	}
+}
+
+// retryTransport replays idempotent requests on connection errors and on
+// 502/503/504 answers, with exponential backoff, and feeds the final outcome
+// to the circuit breaker.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	breaker *CircuitBreaker
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	var body []byte
+	if isIdempotent(req.Method) && t.retries > 0 {
+		replayable := true
+		if req.Body != nil && req.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(req.Body, maxRetryBody+1))
+			if err != nil {
+				t.breaker.Release()
+				return nil, err
+			}
+			if len(body) > maxRetryBody {
+				// Too large to buffer: stitch the read part back and send once
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+				body = nil
+				replayable = false
+			}
+		}
+		if replayable {
+			attempts += t.retries
+		}
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(100*(1<<(attempt-1))) * time.Millisecond
+			select {
+			case <-req.Context().Done():
+				t.breaker.Release()
+				return nil, req.Context().Err()
+			case <-time.After(backoff):
+			}
+		}
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err = t.base.RoundTrip(req)
+		if !shouldRetry(resp, err) || attempt == attempts-1 {
+			break
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		t.breaker.Release()
+	case err != nil, isUnavailable(resp.StatusCode):
+		t.breaker.Failure()
+	default:
+		t.breaker.Success()
+	}
+	return resp, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isUnavailable(status int) bool {
+	return status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return isUnavailable(resp.StatusCode)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+	"erp-system/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestProxy serves the gateway over a real listener; ReverseProxy needs a
+// CloseNotifier, which ResponseRecorder does not implement.
+func newTestProxy(t *testing.T, backend *httptest.Server, cfg config.UpstreamConfig) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg.URL = backend.URL
+	proxy, err := NewProxyHandler([]config.UpstreamConfig{cfg}, utils.NewResponseHelper("api-gateway"))
+	if err != nil {
+		t.Fatalf("NewProxyHandler: %v", err)
+	}
+	router := gin.New()
+	router.Any(cfg.PathPrefix+"/*path", proxy.ProxyToService(cfg.Name))
+	gateway := httptest.NewServer(router)
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+func send(gateway *httptest.Server, method, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, gateway.URL+path, strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	w := httptest.NewRecorder()
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w.Body, resp.Body)
+	return w
+}
+
+func TestProxyRewritesConfiguredPrefix(t *testing.T) {
+	var gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}))
+	defer backend.Close()
+
+	cases := []struct {
+		cfg      config.UpstreamConfig
+		path     string
+		upstream string
+	}{
+		{config.UpstreamConfig{Name: "mfg", PathPrefix: "/api/v1/manufacturing", UpstreamPrefix: "/api/v1/mfg"}, "/api/v1/manufacturing/work-orders/1", "/api/v1/mfg/work-orders/1"},
+		{config.UpstreamConfig{Name: "prj", PathPrefix: "/api/v1/projects", UpstreamPrefix: "/api/v1/projects"}, "/api/v1/projects/1", "/api/v1/projects/1"},
+		{config.UpstreamConfig{Name: "hr", PathPrefix: "/api/v1/hr", UpstreamPrefix: "/api/v1"}, "/api/v1/hr/departments", "/api/v1/departments"},
+	}
+	for _, tc := range cases {
+		tc.cfg.Timeout = time.Second
+		router := newTestProxy(t, backend, tc.cfg)
+		if w := send(router, http.MethodGet, tc.path, ""); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", tc.cfg.Name, w.Code)
+		}
+		if gotPath != tc.upstream {
+			t.Errorf("%s: backend saw %q, want %q", tc.cfg.Name, gotPath, tc.upstream)
+		}
+	}
+}
+
+func TestProxyRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	var bodies []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	router := newTestProxy(t, backend, config.UpstreamConfig{
+		Name: "eam", PathPrefix: "/api/v1/eam", UpstreamPrefix: "/api/v1/eam",
+		Timeout: time.Second, Retries: 2, FailureThreshold: 5, OpenTimeout: time.Minute,
+	})
+
+	if w := send(router, http.MethodPut, "/api/v1/eam/assets/1", `{"name":"pump"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT should succeed on retry, got %d", w.Code)
+	}
+	if len(bodies) != 2 || bodies[1] != `{"name":"pump"}` {
+		t.Fatalf("retry did not replay the body: %q", bodies)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	if w := send(router, http.MethodPost, "/api/v1/eam/assets", `{}`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("POST must not be retried, got %d", w.Code)
+	}
+	if calls != 1 {
+		t.Fatalf("POST reached the backend %d times", calls)
+	}
+}
+
+func TestProxyCircuitOpensAfterFailures(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer backend.Close()
+
+	router := newTestProxy(t, backend, config.UpstreamConfig{
+		Name: "qms", PathPrefix: "/api/v1/qms", UpstreamPrefix: "/api/v1/qms",
+		Timeout: time.Second, FailureThreshold: 2, OpenTimeout: time.Minute,
+	})
+
+	send(router, http.MethodGet, "/api/v1/qms/inspections", "")
+	send(router, http.MethodGet, "/api/v1/qms/inspections", "")
+
+	w := send(router, http.MethodGet, "/api/v1/qms/inspections", "")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected open circuit 503, got %d %v", w.Code, w.Header())
+	}
+	if calls != 2 {
+		t.Fatalf("open circuit still reached the backend: %d calls", calls)
+	}
+	var resp utils.StandardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Success || resp.Service != "api-gateway" {
+		t.Fatalf("expected shared error envelope, got %s", w.Body.String())
+	}
+}
+
+func TestProxyUnreachableBackendUsesEnvelope(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	backend.Close()
+
+	router := newTestProxy(t, backend, config.UpstreamConfig{
+		Name: "plm", PathPrefix: "/api/v1/plm", UpstreamPrefix: "/api/v1/plm",
+		Timeout: time.Second, FailureThreshold: 5, OpenTimeout: time.Minute,
+	})
+	w := send(router, http.MethodPost, "/api/v1/plm/products", `{}`)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var resp utils.StandardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error == "" {
+		t.Fatalf("expected shared error envelope, got %s", w.Body.String())
+	}
+}
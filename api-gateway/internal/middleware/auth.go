@@ -2,6 +2,12 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"erp-system/shared/rbac"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 	"github.com/gin-gonic/gin"
@@ -9,8 +15,13 @@ import (
 )
 
 type AuthMiddleware struct {
-	jwtSecret      string
+	keys           *JWKS
+	issuer         string
 	authServiceURL string
+	client         *AuthClient
+	cache          *TokenCache
+	keyClient      *AuthClient
+	keyCache       *TokenCache
 }
 
 type JWTClaims struct {
@@ -19,20 +30,50 @@ type JWTClaims struct {
 	Email       string   `json:"email"`
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+	TenantID    string   `json:"tenant_id"`
+	ClientID    string   `json:"client_id,omitempty"` // Service account of client_credentials tokens
 	jwt.RegisteredClaims
 }
 
-func NewAuthMiddleware(jwtSecret, authServiceURL string) *AuthMiddleware {
+// accessTokenType is the JOSE "typ" auth-service gives access tokens
+// (RFC 9068). ID tokens are signed with the same keys and issuer but typed
+// plain "JWT", so they are refused here.
+const accessTokenType = "at+jwt"
+
+// NewAuthMiddleware verifies token signatures with the keys in keys. The
+// token's iss claim must match issuer.
+func NewAuthMiddleware(keys *JWKS, issuer, authServiceURL string) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtSecret:      jwtSecret,
+		keys:           keys,
+		issuer:         issuer,
 		authServiceURL: authServiceURL,
 	}
 }
 
+// UseIntrospection makes ValidateToken confirm every token with auth-service
+// (session revocation, security stamp, current permissions), caching the
+// verdicts in cache.
+func (m *AuthMiddleware) UseIntrospection(client *AuthClient, cache *TokenCache) {
+	m.client = client
+	m.cache = cache
+}
+
+// AcceptAPIKeys lets service accounts authenticate with an X-API-Key header
+// instead of a bearer token. Keys are opaque, so every one is resolved by
+// auth-service and the verdict cached in cache.
+func (m *AuthMiddleware) AcceptAPIKeys(client *AuthClient, cache *TokenCache) {
+	m.keyClient = client
+	m.keyCache = cache
+}
+
 func (m *AuthMiddleware) ValidateToken() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from header
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && m.keyClient != nil && c.GetHeader("X-API-Key") != "" {
+			m.validateAPIKey(c)
+			return
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
@@ -55,43 +96,291 @@ func (m *AuthMiddleware) ValidateToken() gin.HandlerFunc {
 			return
 		}
 
+		if m.client != nil {
+			info, err := m.introspect(c.Request.Context(), tokenString, claims.UserID)
+			if errors.Is(err, ErrTokenRejected) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked or no longer valid"})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication service unavailable"})
+				c.Abort()
+				return
+			}
+			// auth-service's answer reflects RBAC changes made after login
+			claims.Roles = info.Roles
+			claims.Permissions = info.Permissions
+		}
+
 		// Set user context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
 		c.Set("roles", claims.Roles)
 		c.Set("permissions", claims.Permissions)
+		c.Set("tenant_id", claims.TenantID)
 		c.Set("token", tokenString)
+		if claims.ClientID != "" {
+			c.Set("client_id", claims.ClientID)
+		}
+		// Service accounts are bound to their tenant
+		if !resolveLegalEntity(c, claims.ClientID != "") {
+			return
+		}
 
 		c.Next()
 	}
 }
 
-func (m *AuthMiddleware) RequirePermission(service, resource, action string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		permissions, exists := c.Get("permissions")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+func (m *AuthMiddleware) validateAPIKey(c *gin.Context) {
+	key := c.GetHeader("X-API-Key")
+	// Backends never see the key itself
+	c.Request.Header.Del("X-API-Key")
+
+	info, found := m.keyCache.Get(key)
+	if !found {
+		var err error
+		info, err = m.keyClient.ValidateAPIKey(c.Request.Context(), key, c.ClientIP())
+		switch {
+		case errors.Is(err, ErrTokenRejected):
+			m.keyCache.Put(key, "", nil)
+		case err != nil:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication service unavailable"})
 			c.Abort()
 			return
+		default:
+			m.keyCache.Put(key, info.UserID, info)
 		}
+	}
+	if info == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		c.Abort()
+		return
+	}
 
-		permissionList, ok := permissions.([]string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid permissions format"})
+	c.Set("user_id", info.UserID)
+	c.Set("username", info.Username)
+	c.Set("email", info.Email)
+	c.Set("roles", info.Roles)
+	c.Set("permissions", info.Permissions)
+	c.Set("tenant_id", info.TenantID)
+	c.Set("client_id", info.ClientID)
+	if !resolveLegalEntity(c, true) {
+		return
+	}
+
+	c.Next()
+}
+
+func (m *AuthMiddleware) introspect(ctx context.Context, token, userID string) (*TokenInfo, error) {
+	if info, found := m.cache.Get(token); found {
+		if info == nil {
+			return nil, ErrTokenRejected
+		}
+		return info, nil
+	}
+
+	info, err := m.client.Validate(ctx, token)
+	switch {
+	case errors.Is(err, ErrTokenRejected):
+		m.cache.Put(token, userID, nil)
+		return nil, err
+	case err != nil:
+		// Outages are not cached so the next request tries again
+		return nil, err
+	}
+	m.cache.Put(token, userID, info)
+	return info, nil
+}
+
+// maxInspectedBody caps how much of a request body is read to find the
+// legal entities it names
+const maxInspectedBody = 8 << 20
+
+// resolveLegalEntity settles the legal entity the request acts in and sets it
+// as "legal_entity_id". A client may pick one with X-Legal-Entity-ID, the
+// legal_entity_id query parameter or a legal_entity_id in its JSON body, but
+// only its token's tenant or an entity it holds grants in, or tenantOnly its
+// tenant alone. Backends read the entity from any of the three, so they must
+// all name the same one. Other entities the body names, such as intercompany
+// counterparties or consolidation members, pass the same check. Backends get
+// the resolved entity in X-Legal-Entity-ID. Aborts with 403 otherwise.
+func resolveLegalEntity(c *gin.Context, tenantOnly bool) bool {
+	tenant := c.GetString("tenant_id")
+	fromBody, others, ok := bodyLegalEntities(c)
+	if !ok {
+		return false
+	}
+	requested := ""
+	named := append([]string{c.GetHeader("X-Legal-Entity-ID"), c.Query("legal_entity_id")}, fromBody...)
+	for _, entity := range named {
+		if entity == "" {
+			continue
+		}
+		if requested != "" && entity != requested {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":           "Request names more than one legal entity",
+				"legal_entity_id": requested,
+			})
 			c.Abort()
-			return
+			return false
 		}
+		requested = entity
+	}
 
-		// Check if user has required permission
-		requiredPermission := service + ":" + resource + ":" + action
-		hasPermission := false
-		for _, perm := range permissionList {
-			if perm == requiredPermission {
-				hasPermission = true
-				break
+	permissions, _ := c.Get("permissions")
+	grants, _ := permissions.([]string)
+	permitted := func(entity string) bool {
+		if entity == tenant {
+			return true
+		}
+		if tenantOnly || !rbac.HasScope(grants, rbac.ScopeLegalEntity, entity) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":           "Legal entity not permitted",
+				"legal_entity_id": entity,
+			})
+			c.Abort()
+			return false
+		}
+		return true
+	}
+
+	resolved := tenant
+	if requested != "" {
+		if !permitted(requested) {
+			return false
+		}
+		resolved = requested
+	}
+	for _, entity := range others {
+		if !permitted(entity) {
+			return false
+		}
+	}
+	c.Set("legal_entity_id", resolved)
+	c.Request.Header.Del("X-Legal-Entity-ID")
+	if resolved != "" {
+		c.Request.Header.Set("X-Legal-Entity-ID", resolved)
+	}
+	return true
+}
+
+// bodyLegalEntities returns the legal entities a JSON request body names:
+// its top-level legal_entity_id, and every other *legal_entity_id or
+// legal_entity_ids field at any depth. It puts the body back for the backend.
+// Backends bind JSON whatever the Content-Type, matching field names without
+// regard to case and ignoring trailing data, so every body is decoded the same
+// way. Bodies too large to inspect are refused, as they could name any entity.
+func bodyLegalEntities(c *gin.Context) (primary, others []string, ok bool) {
+	req := c.Request
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil, true
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxInspectedBody+1))
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		c.Abort()
+		return nil, nil, false
+	}
+	if len(body) > maxInspectedBody {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		c.Abort()
+		return nil, nil, false
+	}
+
+	// Malformed bodies are left for the backend to reject
+	var doc interface{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&doc); err != nil {
+		return nil, nil, true
+	}
+	fields, isObject := doc.(map[string]interface{})
+	if !isObject {
+		return nil, collectLegalEntities("", doc, nil), true
+	}
+	for key, value := range fields {
+		if strings.EqualFold(key, "legal_entity_id") {
+			if entity, _ := value.(string); entity != "" {
+				primary = append(primary, entity)
 			}
+			continue
 		}
+		others = collectLegalEntities(key, value, others)
+	}
+	return primary, others, true
+}
+
+// collectLegalEntities appends the legal entities value names under key, and
+// those of any fields nested in it, to found
+func collectLegalEntities(key string, value interface{}, found []string) []string {
+	name := strings.ToLower(key)
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.HasSuffix(name, "legal_entity_id") {
+			found = append(found, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if entity, isString := item.(string); isString {
+				if entity != "" && strings.HasSuffix(name, "legal_entity_ids") {
+					found = append(found, entity)
+				}
+				continue
+			}
+			found = collectLegalEntities("", item, found)
+		}
+	case map[string]interface{}:
+		for field, item := range v {
+			found = collectLegalEntities(field, item, found)
+		}
+	}
+	return found
+}
+
+// permissionScope is the legal entity and store a request acts in, for
+// grants limited to one. The legal entity is the one ValidateToken settled.
+func permissionScope(c *gin.Context) rbac.Scope {
+	scope := rbac.Scope{
+		LegalEntityID: c.GetString("legal_entity_id"),
+		StoreID:       c.GetHeader("X-Store-ID"),
+	}
+	if scope.StoreID == "" {
+		scope.StoreID = c.Query("store_id")
+	}
+	return scope
+}
+
+// grantedPermissions returns the caller's grants, or aborts when there are none
+func grantedPermissions(c *gin.Context) ([]string, bool) {
+	permissions, exists := c.Get("permissions")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Abort()
+		return nil, false
+	}
+
+	permissionList, ok := permissions.([]string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid permissions format"})
+		c.Abort()
+		return nil, false
+	}
+	return permissionList, true
+}
+
+func (m *AuthMiddleware) RequirePermission(service, resource, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissionList, ok := grantedPermissions(c)
+		if !ok {
+			return
+		}
+
+		// Wildcards, implied actions and scoped grants are evaluated the
+		// same way auth-service's validate-permission does
+		requiredPermission := service + ":" + resource + ":" + action
+		hasPermission := rbac.Allows(permissionList, requiredPermission, permissionScope(c))
 
 		if !hasPermission {
 			c.JSON(http.StatusForbidden, gin.H{
@@ -106,6 +395,29 @@ func (m *AuthMiddleware) RequirePermission(service, resource, action string) gin
 	}
 }
 
+// RequireAnyPermission admits callers that hold action on at least one
+// resource of service, as the gate of the service's route group. Routes in
+// the group still check the resource they serve.
+func (m *AuthMiddleware) RequireAnyPermission(service, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissionList, ok := grantedPermissions(c)
+		if !ok {
+			return
+		}
+
+		if !rbac.AllowsAny(permissionList, service, action, permissionScope(c)) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Insufficient permissions",
+				"required_permission": service + ":<any>:" + action,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func (m *AuthMiddleware) RequireRole(roleName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roles, exists := c.Get("roles")
@@ -145,13 +457,17 @@ func (m *AuthMiddleware) RequireRole(roleName string) gin.HandlerFunc {
 }
 
 func (m *AuthMiddleware) validateJWT(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(m.jwtSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, m.keys.Keyfunc,
+		jwt.WithValidMethods(SigningMethods), jwt.WithIssuer(m.issuer))
 
 	if err != nil {
 		return nil, err
 	}
+	// RFC 9068 allows the "application/" prefix and any letter case
+	typ, _ := token.Header["typ"].(string)
+	if typ = strings.ToLower(typ); typ != accessTokenType && typ != "application/"+accessTokenType {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
 
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
 		return claims, nil
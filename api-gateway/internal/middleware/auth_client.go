@@ -1,36 +1,90 @@
-// File: shared/middleware/auth_client.go (for individual services)
+// File: api-gateway/internal/middleware/auth_client.go
 package middleware
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
-	"github.com/gin-gonic/gin"
+	"time"
 )
 
-// JWTMiddleware for individual services (validates tokens via headers from gateway)
-func JWTMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get user context from headers set by API Gateway
-		userIDStr := c.GetHeader("X-User-ID")
-		username := c.GetHeader("X-Username")
-		
-		if userIDStr == "" || username == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
-			c.Abort()
-			return
-		}
-		
-		userID, err := strconv.ParseUint(userIDStr, 10, 32)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
-			c.Abort()
-			return
-		}
-		
-		// Set user context
-		c.Set("user_id", uint(userID))
-		c.Set("username", username)
-		
-		c.Next()
+// ErrTokenRejected means auth-service looked at the token and refused it:
+// the session was revoked, the user's security stamp changed or the user is
+// gone. It is distinct from auth-service being unreachable.
+var ErrTokenRejected = errors.New("token rejected by auth service")
+
+// TokenInfo is auth-service's current view of a token or API key. Roles and
+// Permissions are resolved at validation time, not copied from the JWT.
+// ClientID is set for service accounts.
+type TokenInfo struct {
+	Active      bool      `json:"active"`
+	UserID      string    `json:"user_id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	TenantID    string    `json:"tenant_id"`
+	SessionID   string    `json:"session_id"`
+	Roles       []string  `json:"roles"`
+	Permissions []string  `json:"permissions"`
+	ClientID    string    `json:"client_id"`
+	APIKeyID    string    `json:"api_key_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// AuthClient calls auth-service's token introspection endpoint
+type AuthClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewAuthClient(baseURL string, timeout time.Duration) *AuthClient {
+	return &AuthClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (a *AuthClient) Validate(ctx context.Context, token string) (*TokenInfo, error) {
+	return a.validate(ctx, map[string]string{"Authorization": "Bearer " + token})
+}
+
+// ValidateAPIKey resolves a service account key. clientIP is passed on so
+// auth-service records where the key was used from.
+func (a *AuthClient) ValidateAPIKey(ctx context.Context, key, clientIP string) (*TokenInfo, error) {
+	return a.validate(ctx, map[string]string{"X-API-Key": key, "X-Forwarded-For": clientIP})
+}
+
+func (a *AuthClient) validate(ctx context.Context, headers map[string]string) (*TokenInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/api/v1/auth/validate", nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("X-Forwarded-By", "api-gateway")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrTokenRejected
+	default:
+		return nil, fmt.Errorf("auth service returned %d", resp.StatusCode)
+	}
+
+	var info TokenInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("invalid auth service response: %w", err)
+	}
+	if !info.Active {
+		return nil, ErrTokenRejected
 	}
+	return &info, nil
 }
@@ -0,0 +1,117 @@
+// File: api-gateway/internal/middleware/auth_events.go
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Topics published by auth-service that make cached token verdicts stale
+const (
+	topicAuthSessionRevoked         = "auth.session.revoked"
+	topicAuthPasswordChanged        = "auth.password.changed"
+	topicAuthUserSuspended          = "auth.user.suspended"
+	topicAuthUserRoleAssigned       = "auth.user.role.assigned"
+	topicAuthUserRoleRevoked        = "auth.user.role.revoked"
+	topicAuthUserStoreAssigned      = "auth.user.store.assigned" // @store:<id> grants
+	topicAuthUserStoreRemoved       = "auth.user.store.removed"
+	topicAuthUserMFAReset           = "auth.user.mfa.reset"
+	topicAuthRolePermissionAssigned = "auth.role.permission.assigned"
+	topicAuthRolePermissionRevoked  = "auth.role.permission.revoked"
+	topicAuthAPIKeyRevoked          = "auth.api_key.revoked"
+	topicAuthServiceAccountDisabled = "auth.service_account.disabled"
+)
+
+// AuthEventListener evicts cached tokens when auth-service announces a
+// revocation or an RBAC change. Every gateway replica keeps its own cache, so
+// each one must join with its own consumer group.
+type AuthEventListener struct {
+	reader *kafka.Reader
+	cache  *TokenCache
+}
+
+func NewAuthEventListener(brokers []string, groupID string, cache *TokenCache) *AuthEventListener {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		GroupID: groupID,
+		GroupTopics: []string{
+			topicAuthSessionRevoked,
+			topicAuthPasswordChanged,
+			topicAuthUserSuspended,
+			topicAuthUserRoleAssigned,
+			topicAuthUserRoleRevoked,
+			topicAuthUserStoreAssigned,
+			topicAuthUserStoreRemoved,
+			topicAuthUserMFAReset,
+			topicAuthRolePermissionAssigned,
+			topicAuthRolePermissionRevoked,
+			topicAuthAPIKeyRevoked,
+			topicAuthServiceAccountDisabled,
+		},
+		// Only changes made after start-up matter; older ones are covered by
+		// the cache TTL.
+		StartOffset: kafka.LastOffset,
+	})
+	return &AuthEventListener{reader: reader, cache: cache}
+}
+
+func (l *AuthEventListener) Start(ctx context.Context) {
+	log.Println("Starting auth event listener for token cache invalidation...")
+	for {
+		msg, err := l.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Auth event listener: error reading message: %v", err)
+			// Without events the cache still expires after its TTL
+			time.Sleep(2 * time.Second)
+			continue
+		}
+		l.Handle(msg.Topic, msg.Value)
+	}
+}
+
+// Handle applies one auth event to the cache
+func (l *AuthEventListener) Handle(topic string, value []byte) {
+	switch topic {
+	case topicAuthRolePermissionAssigned, topicAuthRolePermissionRevoked:
+		// Mapping a role back to its users would need auth-service's data
+		l.cache.Flush()
+		return
+	}
+
+	var payload struct {
+		ID               string `json:"id"`
+		UserID           string `json:"user_id"`
+		ServiceAccountID string `json:"service_account_id"`
+	}
+	if err := json.Unmarshal(value, &payload); err != nil {
+		log.Printf("Auth event listener: invalid %s payload, flushing cache: %v", topic, err)
+		l.cache.Flush()
+		return
+	}
+	userID := payload.UserID
+	if userID == "" {
+		// auth.user.suspended carries the user ID as "id"
+		userID = payload.ID
+	}
+	if userID == "" {
+		// Key and service account events; cached keys and tokens of a
+		// service account are filed under its ID
+		userID = payload.ServiceAccountID
+	}
+	if userID == "" {
+		l.cache.Flush()
+		return
+	}
+	l.cache.EvictUser(userID)
+}
+
+func (l *AuthEventListener) Close() error {
+	return l.reader.Close()
+}
@@ -0,0 +1,441 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testKey = newTestSigningKey("test-key")
+
+func signTestToken(t *testing.T, userID string, permissions []string) string {
+	t.Helper()
+	return testKey.sign(t, JWTClaims{
+		UserID:      userID,
+		Username:    userID,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+}
+
+// fakeAuthService publishes testKey and answers /validate from the revoked
+// and permissions state. Only /validate calls are counted.
+func fakeAuthService(revoked *atomic.Bool, permissions *atomic.Value, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == jwksPath {
+			serveJWKS(w, testKey)
+			return
+		}
+		atomic.AddInt32(calls, 1)
+		if revoked.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"active":false,"error":"token invalid: session revoked"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenInfo{
+			Active:      true,
+			UserID:      "u1",
+			Permissions: permissions.Load().([]string),
+		})
+	}))
+}
+
+func TestValidateTokenWithIntrospection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var revoked atomic.Bool
+	var permissions atomic.Value
+	permissions.Store([]string{"fm:*:read", "fm:journal:post"})
+	var calls int32
+	authSvc := fakeAuthService(&revoked, &permissions, &calls)
+	defer authSvc.Close()
+
+	cache := NewTokenCache(time.Minute)
+	m := NewAuthMiddleware(NewJWKS(authSvc.URL+jwksPath, time.Hour, time.Second), testIssuer, authSvc.URL)
+	m.UseIntrospection(NewAuthClient(authSvc.URL, time.Second), cache)
+
+	router := gin.New()
+	router.Use(m.ValidateToken())
+	router.POST("/post", m.RequirePermission("fm", "journal", "post"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// The JWT was minted before the permission was granted
+	token := signTestToken(t, "u1", []string{"fm:*:read"})
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/post", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(); code != http.StatusOK {
+		t.Fatalf("expected current permissions from auth-service to apply, got %d", code)
+	}
+	if code := send(); code != http.StatusOK || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected cached verdict, got %d after %d calls", code, calls)
+	}
+
+	// A permission change is announced; the listener flushes the cache
+	permissions.Store([]string{"fm:*:read"})
+	listener := &AuthEventListener{cache: cache}
+	listener.Handle(topicAuthRolePermissionRevoked, []byte(`{"role_id":"role_1","permission_id":"perm_1"}`))
+	if code := send(); code != http.StatusForbidden {
+		t.Fatalf("expected 403 after permission revoke, got %d", code)
+	}
+
+	// Logout revokes the session; the event evicts this user's tokens
+	revoked.Store(true)
+	listener.Handle(topicAuthSessionRevoked, []byte(`{"session_id":"sess_1","user_id":"u1"}`))
+	if code := send(); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after session revoke, got %d", code)
+	}
+	before := atomic.LoadInt32(&calls)
+	if code := send(); code != http.StatusUnauthorized || atomic.LoadInt32(&calls) != before {
+		t.Fatalf("expected cached rejection, got %d", code)
+	}
+
+	// An unreachable auth-service must not let tokens through
+	authSvc.Close()
+	cache.Flush()
+	if code := send(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 with auth-service down, got %d", code)
+	}
+}
+
+func TestTokenCacheExpiryAndEviction(t *testing.T) {
+	cache := NewTokenCache(10 * time.Second)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return clock }
+
+	cache.Put("t1", "u1", &TokenInfo{UserID: "u1"})
+	cache.Put("t2", "u1", &TokenInfo{UserID: "u1"})
+	cache.Put("t3", "u2", &TokenInfo{UserID: "u2"})
+
+	listener := &AuthEventListener{cache: cache}
+	listener.Handle(topicAuthUserSuspended, []byte(`{"id":"u1","username":"alice"}`))
+	if _, found := cache.Get("t1"); found {
+		t.Error("suspended user's token should be evicted")
+	}
+	if _, found := cache.Get("t3"); !found {
+		t.Error("other users' tokens should stay cached")
+	}
+
+	clock = clock.Add(11 * time.Second)
+	if _, found := cache.Get("t3"); found {
+		t.Error("entry should expire after the TTL")
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("expected empty cache, got %d entries", n)
+	}
+}
+
+// Store assignments change the user's @store grants. Their payload's id is
+// the assignment, so the user is taken from user_id.
+func TestStoreAssignmentEventsEvictTheUser(t *testing.T) {
+	cache := NewTokenCache(time.Minute)
+	listener := &AuthEventListener{cache: cache}
+	for _, topic := range []string{topicAuthUserStoreAssigned, topicAuthUserStoreRemoved} {
+		cache.Put("t1", "u1", &TokenInfo{UserID: "u1"})
+		cache.Put("t2", "u2", &TokenInfo{UserID: "u2"})
+		listener.Handle(topic, []byte(`{"id":"us_1","user_id":"u1","store_id":"store_1"}`))
+		if _, found := cache.Get("t1"); found {
+			t.Errorf("%s: expected the user's token to be evicted", topic)
+		}
+		if _, found := cache.Get("t2"); !found {
+			t.Errorf("%s: expected other users' tokens to stay cached", topic)
+		}
+	}
+}
+
+func TestValidateTokenWithAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls int32
+	var revoked atomic.Bool
+	authSvc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if revoked.Load() || r.Header.Get("X-API-Key") != "erpk_good" || r.Header.Get("X-Forwarded-For") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenInfo{
+			Active:      true,
+			UserID:      "sa_1",
+			TenantID:    "le-1",
+			ClientID:    "sa_1",
+			Permissions: []string{"fm:journal:post@le:le-1", "fm:journal:read@le:le-2"},
+		})
+	}))
+	defer authSvc.Close()
+
+	cache := NewTokenCache(time.Minute)
+	// Introspection of bearer tokens stays off; keys are still resolved
+	m := NewAuthMiddleware(NewJWKS(authSvc.URL+jwksPath, time.Hour, time.Second), testIssuer, authSvc.URL)
+	m.AcceptAPIKeys(NewAuthClient(authSvc.URL, time.Second), cache)
+
+	router := gin.New()
+	router.Use(m.ValidateToken())
+	router.POST("/post", m.RequirePermission("fm", "journal", "post"), func(c *gin.Context) {
+		if c.GetHeader("X-API-Key") != "" || c.GetString("tenant_id") != "le-1" {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	send := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/post", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("erpk_good"); code != http.StatusOK {
+		t.Fatalf("expected the key to authenticate and be stripped, got %d", code)
+	}
+	if code := send("erpk_good"); code != http.StatusOK || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected cached verdict, got %d after %d calls", code, calls)
+	}
+	// A key is bound to its account's legal entity, even where it holds grants elsewhere
+	req := httptest.NewRequest(http.MethodPost, "/post", nil)
+	req.Header.Set("X-API-Key", "erpk_good")
+	req.Header.Set("X-Legal-Entity-ID", "le-2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another legal entity, got %d", w.Code)
+	}
+	if code := send("erpk_bad"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown key, got %d", code)
+	}
+
+	// Revoking the key evicts the service account's cached verdicts
+	revoked.Store(true)
+	listener := &AuthEventListener{cache: cache}
+	listener.Handle(topicAuthAPIKeyRevoked, []byte(`{"service_account_id":"sa_1","key_id":"key_1"}`))
+	if code := send("erpk_good"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after the key was revoked, got %d", code)
+	}
+}
+
+func TestRequirePermissionWildcardsAndScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewAuthMiddleware(nil, testIssuer, "")
+	cases := []struct {
+		name        string
+		permissions []string
+		tenant      string
+		header      map[string]string
+		route       [3]string
+		want        int
+	}{
+		{"write implies read", []string{"fm:*:write"}, "", nil, [3]string{"fm", "*", "read"}, http.StatusOK},
+		{"one resource does not cover all", []string{"fm:accounts:write"}, "", nil, [3]string{"fm", "*", "read"}, http.StatusForbidden},
+		{"read does not imply write", []string{"fm:accounts:read"}, "", nil, [3]string{"fm", "*", "write"}, http.StatusForbidden},
+		{"service wildcard", []string{"fm:*"}, "", nil, [3]string{"fm", "journal", "post"}, http.StatusOK},
+		{"grant in the token's tenant", []string{"fm:invoices:write@le:le-1"}, "le-1", nil, [3]string{"fm", "invoices", "write"}, http.StatusOK},
+		{"grant in another tenant", []string{"fm:invoices:write@le:le-1"}, "le-1", map[string]string{"X-Legal-Entity-ID": "le-2"}, [3]string{"fm", "invoices", "write"}, http.StatusForbidden},
+		{"grant in a chosen entity", []string{"fm:invoices:write@le:le-2"}, "le-1", map[string]string{"X-Legal-Entity-ID": "le-2"}, [3]string{"fm", "invoices", "write"}, http.StatusOK},
+		{"unscoped grant in another entity", []string{"fm:invoices:write"}, "le-1", map[string]string{"X-Legal-Entity-ID": "le-2"}, [3]string{"fm", "invoices", "write"}, http.StatusForbidden},
+		{"store grant", []string{"scm:stock:write@store:s-1"}, "le-1", map[string]string{"X-Store-ID": "s-1"}, [3]string{"scm", "stock", "read"}, http.StatusOK},
+		{"store grant without a store", []string{"scm:stock:write@store:s-1"}, "le-1", nil, [3]string{"scm", "stock", "read"}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/x", func(c *gin.Context) {
+				c.Set("permissions", tc.permissions)
+				c.Set("tenant_id", tc.tenant)
+				resolveLegalEntity(c, false)
+			}, m.RequirePermission(tc.route[0], tc.route[1], tc.route[2]), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/x", nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// The finance group is open to any fm read grant, as in server.go, while
+// each route checks its own resource: a grant on one resource does not open
+// the others, and grants limited to the token's tenant count.
+func TestRequirePermissionFinanceGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewAuthMiddleware(nil, testIssuer, "")
+	send := func(permissions []string, path string) int {
+		r := gin.New()
+		fmGroup := r.Group("/finance", func(c *gin.Context) {
+			c.Set("permissions", permissions)
+			c.Set("tenant_id", "le-1")
+			resolveLegalEntity(c, false)
+		}, m.RequireAnyPermission("fm", "read"))
+		fmGroup.GET("/accounts", m.RequirePermission("fm", "accounts", "read"), func(c *gin.Context) { c.Status(http.StatusOK) })
+		fmGroup.GET("/invoices", m.RequirePermission("fm", "invoices", "read"), func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	if code := send([]string{"fm:accounts:read"}, "/finance/accounts"); code != http.StatusOK {
+		t.Errorf("expected fm:accounts:read to reach /finance/accounts, got %d", code)
+	}
+	if code := send([]string{"fm:accounts:read"}, "/finance/invoices"); code != http.StatusForbidden {
+		t.Errorf("expected fm:accounts:read to be refused /finance/invoices, got %d", code)
+	}
+	if code := send([]string{"fm:invoices:write@le:le-1"}, "/finance/invoices"); code != http.StatusOK {
+		t.Errorf("expected a grant in the token's tenant to reach /finance/invoices, got %d", code)
+	}
+	if code := send([]string{"fm:invoices:write@le:le-2"}, "/finance/invoices"); code != http.StatusForbidden {
+		t.Errorf("expected a grant in another entity to be refused, got %d", code)
+	}
+	if code := send([]string{"scm:stock:read"}, "/finance/accounts"); code != http.StatusForbidden {
+		t.Errorf("expected a caller without fm grants to be refused, got %d", code)
+	}
+}
+
+// Service groups other than finance are open to any read grant of their
+// service, not only to the service-wide wildcard.
+func TestRequireAnyPermissionServiceGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewAuthMiddleware(nil, testIssuer, "")
+	send := func(permissions []string, path string) int {
+		r := gin.New()
+		hrGroup := r.Group("/hr", func(c *gin.Context) {
+			c.Set("permissions", permissions)
+			c.Set("tenant_id", "le-1")
+			resolveLegalEntity(c, false)
+		}, m.RequireAnyPermission("hr", "read"))
+		hrGroup.Any("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	if code := send([]string{"hr:employees:read"}, "/hr/employees"); code != http.StatusOK {
+		t.Errorf("expected hr:employees:read to reach /hr/employees, got %d", code)
+	}
+	if code := send([]string{"hr:payroll:write@le:le-1"}, "/hr/payroll"); code != http.StatusOK {
+		t.Errorf("expected a write grant in the token's tenant to open the hr group, got %d", code)
+	}
+	if code := send([]string{"hr:employees:read@le:le-2"}, "/hr/employees"); code != http.StatusForbidden {
+		t.Errorf("expected a grant in another entity to be refused, got %d", code)
+	}
+	if code := send([]string{"fm:*:read"}, "/hr/employees"); code != http.StatusForbidden {
+		t.Errorf("expected a caller without hr grants to be refused, got %d", code)
+	}
+}
+
+// Backends read legal_entity_id from the query or the JSON body, so those
+// must pass the same check as the header and reach the backend intact.
+func TestResolveLegalEntityFromQueryAndBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewAuthMiddleware(nil, testIssuer, "")
+	cases := []struct {
+		name   string
+		header string
+		query  string
+		body   string
+		want   int
+	}{
+		{"body in the token's tenant", "", "", `{"legal_entity_id":"le-1"}`, http.StatusOK},
+		{"body in a granted entity", "", "", `{"legal_entity_id":"le-2"}`, http.StatusOK},
+		{"body in another entity", "", "", `{"legal_entity_id":"le-3"}`, http.StatusForbidden},
+		{"query in another entity", "", "le-3", "", http.StatusForbidden},
+		{"header and body disagree", "le-2", "", `{"legal_entity_id":"le-1"}`, http.StatusForbidden},
+		{"header and query disagree", "le-1", "le-3", "", http.StatusForbidden},
+		{"header and body agree", "le-2", "", `{"legal_entity_id":"le-2"}`, http.StatusOK},
+		{"body without an entity", "", "", `{"amount":"10.00"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			var forwarded string
+			r.POST("/x", func(c *gin.Context) {
+				c.Set("permissions", []string{"fm:invoices:write", "fm:invoices:write@le:le-2"})
+				c.Set("tenant_id", "le-1")
+				resolveLegalEntity(c, false)
+			}, m.RequirePermission("fm", "invoices", "write"), func(c *gin.Context) {
+				body, _ := io.ReadAll(c.Request.Body)
+				forwarded = string(body)
+				c.Status(http.StatusOK)
+			})
+			target := "/x"
+			if tc.query != "" {
+				target += "?legal_entity_id=" + tc.query
+			}
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.header != "" {
+				req.Header.Set("X-Legal-Entity-ID", tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusOK && forwarded != tc.body {
+				t.Errorf("expected the body %q to be forwarded, got %q", tc.body, forwarded)
+			}
+		})
+	}
+}
+
+// Backends bind JSON whatever the Content-Type and take counterparties and
+// group members from the body too, so each entity a body names is checked.
+func TestResolveLegalEntityChecksEveryEntityInBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"text/plain body in another entity", "text/plain", `{"legal_entity_id":"le-3"}`, http.StatusForbidden},
+		{"body without a Content-Type", "", `{"legal_entity_id":"le-3"}`, http.StatusForbidden},
+		{"field name in another case", "application/json", `{"Legal_Entity_ID":"le-3"}`, http.StatusForbidden},
+		{"trailing data after the body", "application/json", `{"legal_entity_id":"le-3"} x`, http.StatusForbidden},
+		{"intercompany into a granted entity", "application/json", `{"from_legal_entity_id":"le-1","to_legal_entity_id":"le-2"}`, http.StatusOK},
+		{"intercompany into another entity", "application/json", `{"from_legal_entity_id":"le-1","to_legal_entity_id":"le-3"}`, http.StatusForbidden},
+		{"consolidation of granted entities", "application/json", `{"legal_entity_ids":["le-1","le-2"]}`, http.StatusOK},
+		{"consolidation including another entity", "application/json", `{"legal_entity_ids":["le-1","le-3"]}`, http.StatusForbidden},
+		{"nested line in another entity", "application/json", `{"lines":[{"legal_entity_id":"le-3"}]}`, http.StatusForbidden},
+		{"body that is not JSON", "text/csv", "a,b\n1,2\n", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/x", func(c *gin.Context) {
+				c.Set("permissions", []string{"fm:invoices:write", "fm:invoices:write@le:le-2"})
+				c.Set("tenant_id", "le-1")
+				if resolveLegalEntity(c, false) {
+					c.Status(http.StatusOK)
+				}
+			})
+			req := httptest.NewRequest(http.MethodPost, "/x", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// File: api-gateway/internal/middleware/jwks.go
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minJWKSRefresh limits how often an unknown kid can trigger a fetch, so
+// tokens with made-up kids cannot turn the gateway into a load generator
+// against auth-service.
+const minJWKSRefresh = 10 * time.Second
+
+// SigningMethods are the algorithms auth-service signs access tokens with
+var SigningMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}
+
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// JWKS caches the public keys auth-service publishes at its JWKS endpoint.
+// The set is refetched every refreshInterval, and early when a token names a
+// kid the cache does not know, which is what a key rotation looks like from
+// the gateway.
+type JWKS struct {
+	url             string
+	httpClient      *http.Client
+	refreshInterval time.Duration
+
+	fetchMu     sync.Mutex // one fetch at a time
+	mu          sync.RWMutex
+	keys        map[string]verificationKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	now         func() time.Time
+}
+
+func NewJWKS(url string, refreshInterval, timeout time.Duration) *JWKS {
+	return &JWKS{
+		url:             url,
+		httpClient:      &http.Client{Timeout: timeout},
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]verificationKey),
+		now:             time.Now,
+	}
+}
+
+// Keyfunc resolves a token's verification key by kid for jwt.Parse
+func (j *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid")
+	}
+
+	key, found, stale := j.lookup(kid)
+	if !found || stale {
+		if err := j.refreshIfAllowed(context.Background()); err != nil {
+			log.Printf("JWKS refresh from %s failed: %v", j.url, err)
+		}
+		key, found, _ = j.lookup(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.alg {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key, nil
+}
+
+func (j *JWKS) lookup(kid string) (verificationKey, bool, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, found := j.keys[kid]
+	stale := j.refreshInterval > 0 && j.now().Sub(j.fetchedAt) >= j.refreshInterval
+	return key, found, stale
+}
+
+func (j *JWKS) refreshIfAllowed(ctx context.Context) error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+
+	j.mu.RLock()
+	recent := j.now().Sub(j.lastAttempt) < minJWKSRefresh
+	j.mu.RUnlock()
+	if recent {
+		return nil
+	}
+	return j.fetch(ctx)
+}
+
+// Refresh fetches the key set now. Keys already cached stay in use when the
+// fetch fails.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+	return j.fetch(ctx)
+}
+
+func (j *JWKS) fetch(ctx context.Context) error {
+	j.mu.Lock()
+	j.lastAttempt = j.now()
+	j.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected JWKS response status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.verificationKey()
+		if err != nil {
+			log.Printf("Skipping JWKS key %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s has no usable signing keys", j.url)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = j.now()
+	j.mu.Unlock()
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) verificationKey() (verificationKey, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.Alg != "" && k.Alg != jwt.SigningMethodRS256.Alg() {
+			return verificationKey{}, fmt.Errorf("unsupported alg %s for RSA key", k.Alg)
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return verificationKey{}, fmt.Errorf("malformed RSA key")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return verificationKey{}, fmt.Errorf("RSA key shorter than 2048 bits")
+		}
+		return verificationKey{alg: jwt.SigningMethodRS256.Alg(), key: pub}, nil
+	case "EC":
+		if k.Crv != "P-256" || (k.Alg != "" && k.Alg != jwt.SigningMethodES256.Alg()) {
+			return verificationKey{}, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return verificationKey{}, fmt.Errorf("malformed EC key")
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return verificationKey{}, fmt.Errorf("EC key is not on P-256")
+		}
+		return verificationKey{alg: jwt.SigningMethodES256.Alg(), key: pub}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksPath   = "/api/v1/auth/.well-known/jwks.json"
+	testIssuer = "http://auth.test"
+)
+
+type testSigningKey struct {
+	kid  string
+	priv *ecdsa.PrivateKey
+}
+
+func newTestSigningKey(kid string) testSigningKey {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return testSigningKey{kid: kid, priv: priv}
+}
+
+// sign signs claims as an access token; signTyped picks the JOSE typ.
+func (k testSigningKey) sign(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	return k.signTyped(t, accessTokenType, claims)
+}
+
+func (k testSigningKey) signTyped(t *testing.T, typ string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = k.kid
+	token.Header["typ"] = typ
+	signed, err := token.SignedString(k.priv)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func serveJWKS(w http.ResponseWriter, keys ...testSigningKey) {
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, jsonWebKey{
+			Kty: "EC", Use: "sig", Alg: "ES256", Kid: k.kid, Crv: "P-256",
+			X: base64.RawURLEncoding.EncodeToString(k.priv.X.FillBytes(make([]byte, 32))),
+			Y: base64.RawURLEncoding.EncodeToString(k.priv.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func TestJWKSPicksUpRotatedKeys(t *testing.T) {
+	first, second := newTestSigningKey("k1"), newTestSigningKey("k2")
+	var published atomic.Value
+	published.Store([]testSigningKey{first})
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		serveJWKS(w, published.Load().([]testSigningKey)...)
+	}))
+	defer srv.Close()
+
+	keys := NewJWKS(srv.URL, time.Hour, time.Second)
+	clock := time.Now()
+	keys.now = func() time.Time { return clock }
+	m := NewAuthMiddleware(keys, testIssuer, srv.URL)
+	claims := JWTClaims{UserID: "u1", RegisteredClaims: jwt.RegisteredClaims{Issuer: testIssuer, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+
+	if _, err := m.validateJWT(first.sign(t, claims)); err != nil {
+		t.Fatalf("first key: %v", err)
+	}
+
+	// auth-service rotates; the unknown kid makes the gateway refetch
+	clock = clock.Add(minJWKSRefresh)
+	published.Store([]testSigningKey{second, first})
+	if _, err := m.validateJWT(second.sign(t, claims)); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if _, err := m.validateJWT(first.sign(t, claims)); err != nil {
+		t.Errorf("retired key should still verify: %v", err)
+	}
+
+	// Made-up kids cannot force a fetch per request
+	before := atomic.LoadInt32(&fetches)
+	for i := 0; i < 5; i++ {
+		if _, err := m.validateJWT(newTestSigningKey("forged").sign(t, claims)); err == nil {
+			t.Fatal("expected a token from an unpublished key to be refused")
+		}
+	}
+	if n := atomic.LoadInt32(&fetches) - before; n > 1 {
+		t.Errorf("expected at most one fetch for unknown kids, got %d", n)
+	}
+}
+
+func TestValidateJWTRefusesOtherAlgorithmsAndIssuers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveJWKS(w, testKey)
+	}))
+	defer srv.Close()
+	m := NewAuthMiddleware(NewJWKS(srv.URL, time.Hour, time.Second), testIssuer, srv.URL)
+	expiry := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	good := testKey.sign(t, JWTClaims{UserID: "u1", RegisteredClaims: jwt.RegisteredClaims{Issuer: testIssuer, ExpiresAt: expiry}})
+	if _, err := m.validateJWT(good); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+
+	foreign := testKey.sign(t, JWTClaims{UserID: "u1", RegisteredClaims: jwt.RegisteredClaims{Issuer: "http://evil.test", ExpiresAt: expiry}})
+	if _, err := m.validateJWT(foreign); err == nil {
+		t.Error("expected a token from another issuer to be refused")
+	}
+
+	// The old shared-secret tokens no longer pass, whatever key they name
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{UserID: "u1", RegisteredClaims: jwt.RegisteredClaims{Issuer: testIssuer, ExpiresAt: expiry}})
+	hmac.Header["kid"] = testKey.kid
+	signed, _ := hmac.SignedString([]byte("your-super-secret-key"))
+	if _, err := m.validateJWT(signed); err == nil {
+		t.Error("expected an HS256 token to be refused")
+	}
+}
+
+func TestValidateJWTRefusesIDTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveJWKS(w, testKey)
+	}))
+	defer srv.Close()
+	m := NewAuthMiddleware(NewJWKS(srv.URL, time.Hour, time.Second), testIssuer, srv.URL)
+	claims := JWTClaims{UserID: "u1", RegisteredClaims: jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Audience:  jwt.ClaimStrings{"erp-frontend"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+
+	for _, typ := range []string{"at+jwt", "application/at+JWT"} {
+		if _, err := m.validateJWT(testKey.signTyped(t, typ, claims)); err != nil {
+			t.Errorf("typ %q: %v", typ, err)
+		}
+	}
+	// An OIDC ID token: same keys, issuer and subject, typed plain JWT
+	for _, typ := range []string{"JWT", ""} {
+		if _, err := m.validateJWT(testKey.signTyped(t, typ, claims)); err == nil {
+			t.Errorf("expected a token typed %q to be refused", typ)
+		}
+	}
+}
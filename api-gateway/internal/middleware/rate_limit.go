@@ -2,65 +2,238 @@
 package middleware
 
 import (
+	"context"
+	"hash/fnv"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
-type RateLimiter struct {
-	clients map[string]*ClientInfo
-	mutex   sync.RWMutex
-	limit   int
-	window  time.Duration
+// RateQuota is a token bucket: it refills at RequestsPerMinute and holds at
+// most Burst tokens.
+type RateQuota struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+func (q RateQuota) perSecond() float64 {
+	return float64(q.RequestsPerMinute) / 60
+}
+
+// RateDecision is the outcome of taking one token from a bucket.
+type RateDecision struct {
+	Allowed bool
+	Tokens  float64
+}
+
+// RateStore keeps the token buckets. The memory store is per gateway process;
+// the redis store shares buckets across replicas.
+type RateStore interface {
+	Take(ctx context.Context, key string, quota RateQuota) (RateDecision, error)
 }
 
-type ClientInfo struct {
-	requests  int
-	lastReset time.Time
+type RateLimiter struct {
+	store        RateStore
+	defaultQuota RateQuota
+	routes       map[string]RateQuota
 }
 
-func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+// NewRateLimiter builds a limiter that applies defaultQuota to every route
+// group without an entry in routes.
+func NewRateLimiter(store RateStore, defaultQuota RateQuota, routes map[string]RateQuota) *RateLimiter {
+	if routes == nil {
+		routes = make(map[string]RateQuota)
+	}
 	return &RateLimiter{
-		clients: make(map[string]*ClientInfo),
-		limit:   requestsPerMinute,
-		window:  time.Minute,
+		store:        store,
+		defaultQuota: defaultQuota,
+		routes:       routes,
 	}
 }
 
+// Middleware limits each user (or client IP before authentication) per legal
+// entity and route group, and reports the bucket in X-RateLimit-* headers.
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		
-		rl.mutex.Lock()
-		defer rl.mutex.Unlock()
-		
-		client, exists := rl.clients[clientIP]
-		if !exists {
-			client = &ClientInfo{
-				requests:  0,
-				lastReset: time.Now(),
-			}
-			rl.clients[clientIP] = client
+		group := routeGroup(c.Request.URL.Path)
+		quota, ok := rl.routes[group]
+		if !ok {
+			quota = rl.defaultQuota
 		}
-		
-		// Reset counter if window has passed
-		if time.Since(client.lastReset) > rl.window {
-			client.requests = 0
-			client.lastReset = time.Now()
+
+		key := strings.Join([]string{"ratelimit", rateSubject(c), rateTenant(c), group}, ":")
+		decision, err := rl.store.Take(c.Request.Context(), key, quota)
+		if err != nil {
+			// A broken store must not take the gateway down with it
+			c.Next()
+			return
 		}
-		
-		// Check if limit exceeded
-		if client.requests >= rl.limit {
+
+		rate := quota.perSecond()
+		remaining := int(math.Floor(decision.Tokens))
+		reset := int(math.Ceil((float64(quota.Burst) - decision.Tokens) / rate))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(quota.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(reset))
+
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil((1 - decision.Tokens) / rate))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-				"retry_after": int(rl.window.Seconds()),
+				"error":       "Rate limit exceeded",
+				"retry_after": retryAfter,
 			})
 			c.Abort()
 			return
 		}
-		
-		client.requests++
+
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// routeGroup returns the first path segment after /api/v1, e.g. "finance"
+// for /api/v1/finance/invoices.
+func routeGroup(path string) string {
+	path = strings.TrimPrefix(path, "/api/v1")
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		return "root"
+	}
+	return path
+}
+
+func rateSubject(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateTenant is the tenant of the authenticated caller. Request input never
+// selects a bucket: a client could otherwise get a fresh one per request.
+func rateTenant(c *gin.Context) string {
+	if tenant := c.GetString("tenant_id"); tenant != "" {
+		return tenant
+	}
+	return "-"
+}
+
+const rateShardCount = 32
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateShard struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// MemoryRateStore keeps buckets in sharded maps so concurrent requests only
+// contend on the shard of their own key. Buckets idle for longer than the
+// idle TTL are swept in the background.
+type MemoryRateStore struct {
+	shards  [rateShardCount]*rateShard
+	idleTTL time.Duration
+	now     func() time.Time
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func NewMemoryRateStore(idleTTL time.Duration) *MemoryRateStore {
+	s := &MemoryRateStore{
+		idleTTL: idleTTL,
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+	for i := range s.shards {
+		s.shards[i] = &rateShard{buckets: make(map[string]*memoryBucket)}
+	}
+	if idleTTL > 0 {
+		go s.sweepLoop()
+	}
+	return s
+}
+
+func (s *MemoryRateStore) shard(key string) *rateShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%rateShardCount]
+}
+
+func (s *MemoryRateStore) Take(_ context.Context, key string, quota RateQuota) (RateDecision, error) {
+	now := s.now()
+	shard := s.shard(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	b, ok := shard.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(quota.Burst), last: now}
+		shard.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(quota.Burst), b.tokens+elapsed*quota.perSecond())
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return RateDecision{Allowed: false, Tokens: b.tokens}, nil
+	}
+	b.tokens--
+	return RateDecision{Allowed: true, Tokens: b.tokens}, nil
+}
+
+// Sweep drops buckets that have not been touched within the idle TTL. An idle
+// bucket has refilled anyway, so dropping it does not change any decision as
+// long as the TTL is at least the time to refill a full burst.
+func (s *MemoryRateStore) Sweep() {
+	cutoff := s.now().Add(-s.idleTTL)
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for key, b := range shard.buckets {
+			if b.last.Before(cutoff) {
+				delete(shard.buckets, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// Len reports the number of live buckets.
+func (s *MemoryRateStore) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		n += len(shard.buckets)
+		shard.mu.Unlock()
+	}
+	return n
+}
+
+func (s *MemoryRateStore) Close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *MemoryRateStore) sweepLoop() {
+	ticker := time.NewTicker(s.idleTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Sweep()
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// File: api-gateway/internal/middleware/rate_limit_redis.go
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes from a bucket in one round trip. It uses
+// the redis clock so replicas with skewed clocks share the same view.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateStore keeps buckets in redis so limits hold across gateway
+// replicas. Keys expire after the idle TTL. When redis is unreachable it
+// degrades to the local fallback store instead of failing requests.
+type RedisRateStore struct {
+	client   redis.UniversalClient
+	idleTTL  time.Duration
+	fallback RateStore
+
+	mu         sync.Mutex
+	lastFailed time.Time
+}
+
+func NewRedisRateStore(client redis.UniversalClient, idleTTL time.Duration, fallback RateStore) *RedisRateStore {
+	return &RedisRateStore{
+		client:   client,
+		idleTTL:  idleTTL,
+		fallback: fallback,
+	}
+}
+
+func (s *RedisRateStore) Take(ctx context.Context, key string, quota RateQuota) (RateDecision, error) {
+	// Keep a bucket at least as long as it takes to refill, otherwise expiry
+	// would hand out a fresh burst early
+	ttl := s.idleTTL
+	if refill := time.Duration(float64(quota.Burst) / quota.perSecond() * float64(time.Second)); refill > ttl {
+		ttl = refill
+	}
+
+	res, err := tokenBucketScript.Run(ctx, s.client, []string{key},
+		quota.perSecond()/1000, quota.Burst, ttl.Milliseconds()).Slice()
+	if err == nil {
+		if len(res) != 2 {
+			err = fmt.Errorf("unexpected token bucket reply %v", res)
+		} else {
+			allowed, _ := res[0].(int64)
+			tokensStr, _ := res[1].(string)
+			tokens, perr := strconv.ParseFloat(tokensStr, 64)
+			if perr == nil {
+				return RateDecision{Allowed: allowed == 1, Tokens: tokens}, nil
+			}
+			err = perr
+		}
+	}
+
+	s.logFailure(err)
+	if s.fallback == nil {
+		return RateDecision{}, err
+	}
+	return s.fallback.Take(ctx, key, quota)
+}
+
+// logFailure reports redis errors at most once a minute
+func (s *RedisRateStore) logFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.lastFailed) < time.Minute {
+		return
+	}
+	s.lastFailed = time.Now()
+	log.Printf("rate limiter: redis unavailable, using local buckets: %v", err)
+}
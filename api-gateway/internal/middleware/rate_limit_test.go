@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemoryRateStoreRefillsAndEvicts(t *testing.T) {
+	store := NewMemoryRateStore(0)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return clock }
+	store.idleTTL = time.Minute
+	quota := RateQuota{RequestsPerMinute: 60, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if d, _ := store.Take(ctx, "k", quota); !d.Allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+	if d, _ := store.Take(ctx, "k", quota); d.Allowed {
+		t.Fatal("third request should be limited")
+	}
+
+	// One token per second at 60/min
+	clock = clock.Add(time.Second)
+	if d, _ := store.Take(ctx, "k", quota); !d.Allowed {
+		t.Fatal("bucket should have refilled one token")
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	store.Sweep()
+	if n := store.Len(); n != 0 {
+		t.Fatalf("idle bucket not evicted, %d left", n)
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryRateStore(0)
+	limiter := NewRateLimiter(store, RateQuota{RequestsPerMinute: 600, Burst: 5},
+		map[string]RateQuota{"auth": {RequestsPerMinute: 60, Burst: 1}})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("user_id", user)
+			c.Set("tenant_id", c.GetHeader("X-Test-Tenant"))
+		}
+		c.Next()
+	}, limiter.Middleware())
+	router.Any("/api/v1/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(path, user, legalEntity string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-User", user)
+		req.Header.Set("X-Test-Tenant", legalEntity)
+		// Client-chosen entities must not open new buckets
+		req.Header.Set("X-Legal-Entity-ID", time.Now().Format(time.RFC3339Nano))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/api/v1/auth/login", "", "")
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected first auth response: %d %v", w.Code, w.Header())
+	}
+	w = send("/api/v1/auth/login?legal_entity_id=le_9", "", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+
+	// Other route groups, users and legal entities have their own buckets
+	if w := send("/api/v1/finance/invoices", "", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "5" {
+		t.Fatalf("finance should use the default quota, got %d %v", w.Code, w.Header())
+	}
+	if w := send("/api/v1/auth/profile", "u1", "le_1"); w.Code != http.StatusOK {
+		t.Fatalf("user bucket should be separate from the client IP, got %d", w.Code)
+	}
+	if w := send("/api/v1/auth/profile", "u1", "le_2"); w.Code != http.StatusOK {
+		t.Fatalf("tenant bucket should be separate, got %d", w.Code)
+	}
+	if w := send("/api/v1/auth/profile", "u1", "le_1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected u1/le_1 to be limited, got %d", w.Code)
+	}
+}
@@ -0,0 +1,126 @@
+// File: api-gateway/internal/middleware/token_cache.go
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many Puts pass between purges of expired entries
+const sweepEvery = 1024
+
+type tokenCacheEntry struct {
+	info      *TokenInfo // nil when auth-service rejected the token
+	userID    string
+	expiresAt time.Time
+}
+
+// TokenCache remembers auth-service verdicts for a short TTL so the gateway
+// does not call auth-service on every request. Tokens are stored by hash and
+// indexed by user so revocation events can evict a user's entries.
+type TokenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*tokenCacheEntry
+	byUser  map[string]map[string]struct{}
+	puts    int
+	now     func() time.Time
+}
+
+func NewTokenCache(ttl time.Duration) *TokenCache {
+	return &TokenCache{
+		ttl:     ttl,
+		entries: make(map[string]*tokenCacheEntry),
+		byUser:  make(map[string]map[string]struct{}),
+		now:     time.Now,
+	}
+}
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached verdict for a token. found is false on a miss;
+// a found entry with a nil info is a cached rejection.
+func (tc *TokenCache) Get(token string) (info *TokenInfo, found bool) {
+	key := tokenKey(token)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	e, ok := tc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !tc.now().Before(e.expiresAt) {
+		tc.remove(key, e)
+		return nil, false
+	}
+	return e.info, true
+}
+
+// Put caches a verdict; pass a nil info to remember a rejection
+func (tc *TokenCache) Put(token, userID string, info *TokenInfo) {
+	key := tokenKey(token)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if old, ok := tc.entries[key]; ok {
+		tc.remove(key, old)
+	}
+	tc.entries[key] = &tokenCacheEntry{info: info, userID: userID, expiresAt: tc.now().Add(tc.ttl)}
+	if tc.byUser[userID] == nil {
+		tc.byUser[userID] = make(map[string]struct{})
+	}
+	tc.byUser[userID][key] = struct{}{}
+
+	tc.puts++
+	if tc.puts%sweepEvery == 0 {
+		tc.sweep()
+	}
+}
+
+// EvictUser drops every cached token of a user
+func (tc *TokenCache) EvictUser(userID string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for key := range tc.byUser[userID] {
+		delete(tc.entries, key)
+	}
+	delete(tc.byUser, userID)
+}
+
+// Flush drops everything, e.g. after a role's permissions changed
+func (tc *TokenCache) Flush() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.entries = make(map[string]*tokenCacheEntry)
+	tc.byUser = make(map[string]map[string]struct{})
+}
+
+func (tc *TokenCache) Len() int {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return len(tc.entries)
+}
+
+func (tc *TokenCache) remove(key string, e *tokenCacheEntry) {
+	delete(tc.entries, key)
+	if keys := tc.byUser[e.userID]; keys != nil {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(tc.byUser, e.userID)
+		}
+	}
+}
+
+func (tc *TokenCache) sweep() {
+	now := tc.now()
+	for key, e := range tc.entries {
+		if !now.Before(e.expiresAt) {
+			tc.remove(key, e)
+		}
+	}
+}
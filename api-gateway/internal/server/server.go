@@ -2,27 +2,41 @@
 package server
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"api-gateway/internal/config"
 	"api-gateway/internal/handlers"
 	"api-gateway/internal/middleware"
+	"erp-system/shared/utils"
 )
 
 type Server struct {
 	config *config.Config
 	router *gin.Engine
+	proxy  *handlers.ProxyHandler
 }
 
 func New(cfg *config.Config) *Server {
 	router := gin.Default()
+	// ClientIP keys rate limits and login throttling; only listed proxies
+	// may set it through X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 	
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Legal-Entity-ID, X-API-Key")
+		// Let browser clients see the rate limiter's headers
+		c.Header("Access-Control-Expose-Headers", "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -39,28 +53,23 @@ func New(cfg *config.Config) *Server {
 }
 
 func (s *Server) Start() error {
-	s.setupRoutes()
+	if err := s.setupRoutes(); err != nil {
+		return err
+	}
 	return s.router.Run(":" + s.config.Port)
 }
 
-func (s *Server) setupRoutes() {
-	// Auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(s.config.JWTSecret, s.config.Services.AuthService)
+func (s *Server) setupRoutes() error {
+	// Auth middleware; signatures are checked against auth-service's JWKS
+	authMiddleware := middleware.NewAuthMiddleware(s.newJWKS(), s.config.Auth.Issuer, s.config.Services.AuthService)
+	s.enableAuthService(authMiddleware)
 	
 	// Proxy handler
-	proxyHandler := handlers.NewProxyHandler(map[string]string{
-		"auth": s.config.Services.AuthService,
-		"fm":   s.config.Services.FMService,
-		"hr":   s.config.Services.HRService,
-		"scm":  s.config.Services.SCMService,
-		"m":    s.config.Services.MService,
-		"crm":  s.config.Services.CRMService,
-		"pm":   s.config.Services.PMService,
-		"eam":  s.config.Services.EAMService,
-		"plm":  s.config.Services.PLMService,
-		"qms":  s.config.Services.QMSService,
-		"ui":   s.config.Services.BFFService,
-	})
+	proxyHandler, err := handlers.NewProxyHandler(s.config.Upstreams, utils.NewResponseHelper("api-gateway"))
+	if err != nil {
+		return err
+	}
+	s.proxy = proxyHandler
 
 	// API Gateway health check
 	s.router.GET("/health", func(c *gin.Context) {
@@ -82,18 +91,37 @@ func (s *Server) setupRoutes() {
 		c.File("openapi.yaml")
 	})
 
+	// Rate limiting per user (or client IP), legal entity and route group
+	rateLimit := func(c *gin.Context) { c.Next() }
+	if s.config.RateLimit.Enabled {
+		rateLimit = s.newRateLimiter().Middleware()
+	}
+
 	// Public routes (no authentication required)
 	public := s.router.Group("/api/v1")
+	public.Use(rateLimit)
 	{
 		// Auth routes
 		public.POST("/auth/login", proxyHandler.ProxyToService("auth"))
 		public.POST("/auth/register", proxyHandler.ProxyToService("auth"))
 		public.POST("/auth/refresh", proxyHandler.ProxyToService("auth"))
+		// Second login step; enrollment during login authenticates with the
+		// MFA challenge token, which auth-service checks itself.
+		public.POST("/auth/login/mfa", proxyHandler.ProxyToService("auth"))
+		public.POST("/auth/mfa/enroll", proxyHandler.ProxyToService("auth"))
+		public.POST("/auth/mfa/confirm", proxyHandler.ProxyToService("auth"))
+		// OpenID Connect provider; the authorize POST is the login page's
+		// submit and authenticates with the credentials it carries.
+		public.GET("/auth/.well-known/openid-configuration", proxyHandler.ProxyToService("auth"))
+		public.GET("/auth/.well-known/jwks.json", proxyHandler.ProxyToService("auth"))
+		public.GET("/auth/oauth/authorize", proxyHandler.ProxyToService("auth"))
+		public.POST("/auth/oauth/authorize", proxyHandler.ProxyToService("auth"))
+		public.POST("/auth/oauth/token", proxyHandler.ProxyToService("auth"))
 	}
 
 	// Protected routes (authentication required)
 	protected := s.router.Group("/api/v1")
-	protected.Use(authMiddleware.ValidateToken())
+	protected.Use(authMiddleware.ValidateToken(), rateLimit)
 	{
 		// Auth routes
 		authGroup := protected.Group("/auth")
@@ -101,14 +129,19 @@ func (s *Server) setupRoutes() {
 			authGroup.POST("/logout", proxyHandler.ProxyToService("auth"))
 			authGroup.GET("/profile", proxyHandler.ProxyToService("auth"))
 			authGroup.GET("/validate", proxyHandler.ProxyToService("auth"))
+			authGroup.POST("/mfa/recovery-codes", proxyHandler.ProxyToService("auth"))
+			authGroup.GET("/oauth/userinfo", proxyHandler.ProxyToService("auth"))
 		}
 
-		// Financial Management routes
+		// Financial Management routes. Any fm read grant opens the group;
+		// each route then checks the resource it serves.
 		fmGroup := protected.Group("/finance")
-		fmGroup.Use(authMiddleware.RequirePermission("fm", "*", "read"))
+		fmGroup.Use(authMiddleware.RequireAnyPermission("fm", "read"))
 		{
 			// Accounts
-			fmGroup.GET("/accounts", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/accounts",
+				authMiddleware.RequirePermission("fm", "accounts", "read"),
+				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/accounts", 
 				authMiddleware.RequirePermission("fm", "accounts", "write"),
 				proxyHandler.ProxyToService("fm"))
@@ -118,10 +151,20 @@ func (s *Server) setupRoutes() {
 			fmGroup.DELETE("/accounts/:id", 
 				authMiddleware.RequirePermission("fm", "accounts", "delete"),
 				proxyHandler.ProxyToService("fm"))
-			fmGroup.GET("/accounts/:id/balance", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/accounts/:id/balance",
+				authMiddleware.RequirePermission("fm", "accounts", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/account-determinations",
+				authMiddleware.RequirePermission("fm", "accounts", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/account-determinations/:key",
+				authMiddleware.RequirePermission("fm", "accounts", "write"),
+				proxyHandler.ProxyToService("fm"))
 
 			// Parties (Customers/Vendors)
-			fmGroup.GET("/parties", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/parties",
+				authMiddleware.RequirePermission("fm", "parties", "read"),
+				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/parties", 
 				authMiddleware.RequirePermission("fm", "parties", "write"),
 				proxyHandler.ProxyToService("fm"))
@@ -130,127 +173,424 @@ func (s *Server) setupRoutes() {
 				proxyHandler.ProxyToService("fm"))
 
 			// Invoices
-			fmGroup.GET("/invoices", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/invoices",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/invoices", 
 				authMiddleware.RequirePermission("fm", "invoices", "write"),
 				proxyHandler.ProxyToService("fm"))
 			fmGroup.PUT("/invoices/:id", 
 				authMiddleware.RequirePermission("fm", "invoices", "write"),
 				proxyHandler.ProxyToService("fm"))
-			fmGroup.GET("/invoices/:id/lines", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/invoices/:id/lines",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/invoices/:id/allocations",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/invoices/:id/credit-memos",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/invoices/:id/credit-memos",
+				authMiddleware.RequirePermission("fm", "invoices", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/invoices/:id/dunning-notices",
+				authMiddleware.RequirePermission("fm", "dunning", "read"),
+				proxyHandler.ProxyToService("fm"))
+
+			// Dunning
+			fmGroup.GET("/dunning/levels",
+				authMiddleware.RequirePermission("fm", "dunning", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/dunning/levels",
+				authMiddleware.RequirePermission("fm", "dunning", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.DELETE("/dunning/levels/:level",
+				authMiddleware.RequirePermission("fm", "dunning", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/dunning/runs",
+				authMiddleware.RequirePermission("fm", "dunning", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/dunning/runs",
+				authMiddleware.RequirePermission("fm", "dunning", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/dunning/runs/:id",
+				authMiddleware.RequirePermission("fm", "dunning", "read"),
+				proxyHandler.ProxyToService("fm"))
+
+			// Customer credit
+			fmGroup.GET("/customers/:id/credit",
+				authMiddleware.RequirePermission("fm", "credit", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/customers/:id/credit/exposure",
+				authMiddleware.RequirePermission("fm", "credit", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/customers/:id/credit/check",
+				authMiddleware.RequirePermission("fm", "credit", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/customers/:id/credit",
+				authMiddleware.RequirePermission("fm", "credit", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/customers/:id/credit/hold",
+				authMiddleware.RequirePermission("fm", "credit", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/customers/:id/credit/release",
+				authMiddleware.RequirePermission("fm", "credit", "override"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/customers/:id/credit/overrides",
+				authMiddleware.RequirePermission("fm", "credit", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/customers/:id/credit/overrides",
+				authMiddleware.RequirePermission("fm", "credit", "override"),
+				proxyHandler.ProxyToService("fm"))
 
 			// Vendor Bills
-			fmGroup.GET("/vendor-bills", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/vendor-bills",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/vendor-bills",
 				authMiddleware.RequirePermission("fm", "invoices", "write"),
 				proxyHandler.ProxyToService("fm"))
-			fmGroup.GET("/vendor-bills/:id/lines", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/vendor-bills/:id/lines",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/vendor-bills/:id/lines",
+				authMiddleware.RequirePermission("fm", "invoices", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/vendor-bills/:id/match",
+				authMiddleware.RequirePermission("fm", "invoices", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/vendor-bills/:id/match-override",
+				authMiddleware.RequirePermission("fm", "invoices", "override"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/vendor-bills/match-exceptions",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/vendor-bills/match-tolerances",
+				authMiddleware.RequirePermission("fm", "invoices", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/vendor-bills/match-tolerances",
+				authMiddleware.RequirePermission("fm", "invoices", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/vendor-bills/:id/allocations",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
 
 			// Bank Statements
-			fmGroup.GET("/bank-statements/:id/lines", proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/bank-statements/import",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/bank-statements/:id/lines",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/bank-statements/:id/reconcile",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/bank-statements/:id/matches",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/bank-statements/:id/matches",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.DELETE("/bank-statements/:id/matches/:groupId",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/recurring-cash-items",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/recurring-cash-items",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/recurring-cash-items/:id",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.DELETE("/recurring-cash-items/:id",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/reconciliation-exceptions",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/reconciliation-exceptions/:id/journal-entry",
+				authMiddleware.RequirePermission("fm", "journal", "post"),
+				proxyHandler.ProxyToService("fm"))
 
 			// Payments
-			fmGroup.GET("/payments", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/payments",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/payments", 
 				authMiddleware.RequirePermission("fm", "payments", "write"),
 				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/payments/:id/allocations",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/on-account-credits",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/on-account-credits/:id/apply",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+
+			// Payment Runs
+			fmGroup.GET("/vendors/:id/bank-account",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/vendors/:id/bank-account",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/payment-runs",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/payment-runs",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/payment-runs/:id",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/payment-runs/:id/approve",
+				authMiddleware.RequirePermission("fm", "payments", "approve"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/payment-runs/:id/cancel",
+				authMiddleware.RequirePermission("fm", "payments", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/payment-runs/:id/files/:fileId",
+				authMiddleware.RequirePermission("fm", "payments", "read"),
+				proxyHandler.ProxyToService("fm"))
 
 			// Journal Entries
-			fmGroup.GET("/journal-entries", proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/journal-entries",
+				authMiddleware.RequirePermission("fm", "journal", "read"),
+				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/journal-entries", 
 				authMiddleware.RequirePermission("fm", "journal", "write"),
 				proxyHandler.ProxyToService("fm"))
 			fmGroup.POST("/journal-entries/:id/post", 
 				authMiddleware.RequirePermission("fm", "journal", "post"),
 				proxyHandler.ProxyToService("fm"))
-
-			// Reports
-			fmGroup.GET("/reports/*path", 
-				authMiddleware.RequirePermission("fm", "reports", "read"),
+			fmGroup.POST("/journal-entries/accruals",
+				authMiddleware.RequirePermission("fm", "journal", "write"),
 				proxyHandler.ProxyToService("fm"))
 
-			// Legal Entities
-			fmGroup.GET("/legal-entities", proxyHandler.ProxyToService("fm"))
-			fmGroup.POST("/legal-entities", proxyHandler.ProxyToService("fm"))
-			fmGroup.GET("/legal-entities/:id", proxyHandler.ProxyToService("fm"))
+			// FX Revaluation
+			fmGroup.GET("/fx-revaluations",
+				authMiddleware.RequirePermission("fm", "journal", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/fx-revaluations",
+				authMiddleware.RequirePermission("fm", "journal", "post"),
+				proxyHandler.ProxyToService("fm"))
 
-			// Assets
-			fmGroup.GET("/assets", proxyHandler.ProxyToService("fm"))
-			fmGroup.POST("/assets/capitalize", proxyHandler.ProxyToService("fm"))
-			fmGroup.GET("/assets/:id", proxyHandler.ProxyToService("fm"))
-			fmGroup.POST("/assets/:id/depreciation-schedule", proxyHandler.ProxyToService("fm"))
-			fmGroup.POST("/assets/depreciate", proxyHandler.ProxyToService("fm"))
-		}
+			// Fiscal Period Close
+			fmGroup.GET("/fiscal-periods",
+				authMiddleware.RequirePermission("fm", "periods", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/fiscal-periods/:period/checklist",
+				authMiddleware.RequirePermission("fm", "periods", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/fiscal-periods/:period/soft-close",
+				authMiddleware.RequirePermission("fm", "periods", "close"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/fiscal-periods/:period/close",
+				authMiddleware.RequirePermission("fm", "periods", "close"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/fiscal-periods/:period/reopen",
+				authMiddleware.RequirePermission("fm", "periods", "close"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/fiscal-years/:id/close",
+				authMiddleware.RequirePermission("fm", "periods", "close"),
+				proxyHandler.ProxyToService("fm"))
 
-		// HR routes
-		hrGroup := protected.Group("/hr")
-		hrGroup.Use(authMiddleware.RequirePermission("hr", "*", "read"))
-		{
-			hrGroup.Any("", proxyHandler.ProxyToService("hr"))
-			hrGroup.Any("/*path", proxyHandler.ProxyToService("hr"))
-		}
+			// Recurring Journal Templates
+			fmGroup.GET("/journal-templates",
+				authMiddleware.RequirePermission("fm", "journal", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/journal-templates/:id",
+				authMiddleware.RequirePermission("fm", "journal", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/journal-templates",
+				authMiddleware.RequirePermission("fm", "journal", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/journal-templates/:id",
+				authMiddleware.RequirePermission("fm", "journal", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/journal-templates/:id/generate",
+				authMiddleware.RequirePermission("fm", "journal", "post"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/journal-templates/run",
+				authMiddleware.RequirePermission("fm", "journal", "post"),
+				proxyHandler.ProxyToService("fm"))
 
-		// SCM routes
-		scmGroup := protected.Group("/scm")
-		scmGroup.Use(authMiddleware.RequirePermission("scm", "*", "read"))
-		{
-			scmGroup.Any("", proxyHandler.ProxyToService("scm"))
-			scmGroup.Any("/*path", proxyHandler.ProxyToService("scm"))
-		}
+			// Cost Centers & Overhead Allocation
+			fmGroup.GET("/cost-centers",
+				authMiddleware.RequirePermission("fm", "allocations", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/cost-centers",
+				authMiddleware.RequirePermission("fm", "allocations", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/allocation-drivers",
+				authMiddleware.RequirePermission("fm", "allocations", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/allocation-drivers",
+				authMiddleware.RequirePermission("fm", "allocations", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/allocation-cycles",
+				authMiddleware.RequirePermission("fm", "allocations", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/allocation-cycles/:id",
+				authMiddleware.RequirePermission("fm", "allocations", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/allocation-cycles",
+				authMiddleware.RequirePermission("fm", "allocations", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/allocation-cycles/:id",
+				authMiddleware.RequirePermission("fm", "allocations", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/allocation-cycles/:id/run",
+				authMiddleware.RequirePermission("fm", "journal", "post"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/allocation-runs/:id/reverse",
+				authMiddleware.RequirePermission("fm", "journal", "post"),
+				proxyHandler.ProxyToService("fm"))
 
-		// Enterprise Asset Management (EAM) routes
-		eamGroup := protected.Group("/eam")
-		eamGroup.Use(authMiddleware.RequirePermission("eam", "*", "read"))
-		{
-			eamGroup.Any("", proxyHandler.ProxyToService("eam"))
-			eamGroup.Any("/*path", proxyHandler.ProxyToService("eam"))
-		}
+			// Reports
+			fmGroup.GET("/reports/*path", 
+				authMiddleware.RequirePermission("fm", "reports", "read"),
+				proxyHandler.ProxyToService("fm"))
 
-		// Product Lifecycle Management (PLM) routes
-		plmGroup := protected.Group("/plm")
-		plmGroup.Use(authMiddleware.RequirePermission("plm", "*", "read"))
-		{
-			plmGroup.Any("", proxyHandler.ProxyToService("plm"))
-			plmGroup.Any("/*path", proxyHandler.ProxyToService("plm"))
-		}
+			// Legal Entities
+			fmGroup.GET("/legal-entities",
+				authMiddleware.RequirePermission("fm", "legal_entities", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/legal-entities",
+				authMiddleware.RequirePermission("fm", "legal_entities", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/legal-entities/:id",
+				authMiddleware.RequirePermission("fm", "legal_entities", "read"),
+				proxyHandler.ProxyToService("fm"))
 
-		// Quality Management System (QMS) routes
-		qmsGroup := protected.Group("/qms")
-		qmsGroup.Use(authMiddleware.RequirePermission("qms", "*", "read"))
-		{
-			qmsGroup.Any("", proxyHandler.ProxyToService("qms"))
-			qmsGroup.Any("/*path", proxyHandler.ProxyToService("qms"))
-		}
+			// Intercompany & Consolidation
+			fmGroup.GET("/intercompany-transactions",
+				authMiddleware.RequirePermission("fm", "intercompany", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/intercompany-transactions",
+				authMiddleware.RequirePermission("fm", "intercompany", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/intercompany-transactions/:id",
+				authMiddleware.RequirePermission("fm", "intercompany", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/consolidation-groups",
+				authMiddleware.RequirePermission("fm", "consolidation", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/consolidation-groups",
+				authMiddleware.RequirePermission("fm", "consolidation", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/consolidation-groups/:id",
+				authMiddleware.RequirePermission("fm", "consolidation", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/consolidation-groups/:id/statements",
+				authMiddleware.RequirePermission("fm", "consolidation", "read"),
+				proxyHandler.ProxyToService("fm"))
 
-		// Manufacturing routes
-		mGroup := protected.Group("/manufacturing")
-		mGroup.Use(authMiddleware.RequirePermission("m", "*", "read"))
-		{
-			mGroup.Any("", proxyHandler.ProxyToService("m"))
-			mGroup.Any("/*path", proxyHandler.ProxyToService("m"))
-		}
+			// Assets
+			fmGroup.GET("/assets",
+				authMiddleware.RequirePermission("fm", "assets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/assets/capitalize",
+				authMiddleware.RequirePermission("fm", "assets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/assets/:id",
+				authMiddleware.RequirePermission("fm", "assets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/assets/:id/depreciation-schedule",
+				authMiddleware.RequirePermission("fm", "assets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/assets/depreciate",
+				authMiddleware.RequirePermission("fm", "assets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/assets/:id/dispose",
+				authMiddleware.RequirePermission("fm", "assets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/assets/:id/usage",
+				authMiddleware.RequirePermission("fm", "assets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/assets/:id/usage",
+				authMiddleware.RequirePermission("fm", "assets", "read"),
+				proxyHandler.ProxyToService("fm"))
 
-		// CRM routes
-		crmGroup := protected.Group("/crm")
-		crmGroup.Use(authMiddleware.RequirePermission("crm", "*", "read"))
-		{
-			crmGroup.Any("", proxyHandler.ProxyToService("crm"))
-			crmGroup.Any("/*path", proxyHandler.ProxyToService("crm"))
-		}
+			// Budgets
+			fmGroup.GET("/budgets",
+				authMiddleware.RequirePermission("fm", "budgets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/budgets",
+				authMiddleware.RequirePermission("fm", "budgets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/budgets/:id/versions/:version",
+				authMiddleware.RequirePermission("fm", "budgets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/budgets/vs-actual",
+				authMiddleware.RequirePermission("fm", "budgets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/budgets/check",
+				authMiddleware.RequirePermission("fm", "budgets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/budgets/expenses",
+				authMiddleware.RequirePermission("fm", "budgets", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/budgets/commitments",
+				authMiddleware.RequirePermission("fm", "budgets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/budgets/commitments/:id/approve",
+				authMiddleware.RequirePermission("fm", "budgets", "approve"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/budgets/commitments/:id/reject",
+				authMiddleware.RequirePermission("fm", "budgets", "approve"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/budgets/policies",
+				authMiddleware.RequirePermission("fm", "budgets", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.PUT("/budgets/policies/:cost_center_id",
+				authMiddleware.RequirePermission("fm", "budgets", "write"),
+				proxyHandler.ProxyToService("fm"))
 
-		// Project Management routes
-		pmGroup := protected.Group("/projects")
-		pmGroup.Use(authMiddleware.RequirePermission("pm", "*", "read"))
-		{
-			pmGroup.Any("", proxyHandler.ProxyToService("pm"))
-			pmGroup.Any("/*path", proxyHandler.ProxyToService("pm"))
+			// Tax
+			fmGroup.GET("/tax/rates",
+				authMiddleware.RequirePermission("fm", "tax", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/tax/rates",
+				authMiddleware.RequirePermission("fm", "tax", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/tax/jurisdictions",
+				authMiddleware.RequirePermission("fm", "tax", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/tax/jurisdictions",
+				authMiddleware.RequirePermission("fm", "tax", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/tax/exemptions",
+				authMiddleware.RequirePermission("fm", "tax", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/tax/exemptions",
+				authMiddleware.RequirePermission("fm", "tax", "write"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.POST("/tax/determine",
+				authMiddleware.RequirePermission("fm", "tax", "read"),
+				proxyHandler.ProxyToService("fm"))
+			fmGroup.GET("/tax/returns",
+				authMiddleware.RequirePermission("fm", "tax", "read"),
+				proxyHandler.ProxyToService("fm"))
 		}
 
-		// UI / BFF routes (protected)
-		uiGroup := protected.Group("/ui")
-		{
-			uiGroup.Any("", proxyHandler.ProxyToService("ui"))
-			uiGroup.Any("/*path", proxyHandler.ProxyToService("ui"))
+		// Every other upstream gets a catch-all group under its path prefix,
+		// open to any read grant of its permission service; the backends
+		// check the resource.
+		for _, up := range s.config.Upstreams {
+			if up.Name == "auth" || up.Name == "fm" {
+				continue // routed explicitly above
+			}
+			group := protected.Group(strings.TrimPrefix(up.PathPrefix, "/api/v1"))
+			if up.Permission != "" {
+				group.Use(authMiddleware.RequireAnyPermission(up.Permission, "read"))
+			}
+			group.Any("", proxyHandler.ProxyToService(up.Name))
+			group.Any("/*path", proxyHandler.ProxyToService(up.Name))
 		}
 
 		// Admin routes (require admin role)
@@ -261,36 +601,89 @@ func (s *Server) setupRoutes() {
 			adminGroup.GET("/services/status", s.getServicesStatus)
 		}
 	}
+	return nil
 }
 
-func (s *Server) getServicesStatus(c *gin.Context) {
-	services := map[string]string{
-		"auth": s.config.Services.AuthService,
-		"fm":   s.config.Services.FMService,
-		"hr":   s.config.Services.HRService,
-		"scm":  s.config.Services.SCMService,
-		"m":    s.config.Services.MService,
-		"crm":  s.config.Services.CRMService,
-		"pm":   s.config.Services.PMService,
-		"eam":  s.config.Services.EAMService,
-		"plm":  s.config.Services.PLMService,
-		"qms":  s.config.Services.QMSService,
+// newJWKS loads auth-service's signing keys. A failed first fetch is not
+// fatal: tokens with a kid the gateway has not seen trigger another fetch.
+func (s *Server) newJWKS() *middleware.JWKS {
+	cfg := s.config.Auth
+	keys := middleware.NewJWKS(cfg.JWKSURL, cfg.JWKSRefresh, cfg.Timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	if err := keys.Refresh(ctx); err != nil {
+		log.Printf("Could not load signing keys from %s yet: %v", cfg.JWKSURL, err)
+	}
+	return keys
+}
+
+// enableAuthService has the auth middleware resolve API keys, and with
+// AUTH_INTROSPECTION confirm bearer tokens, with auth-service.
+// With Kafka configured, revocations and RBAC changes evict cached verdicts
+// immediately; otherwise they take effect once the cache TTL runs out.
+func (s *Server) enableAuthService(authMiddleware *middleware.AuthMiddleware) {
+	cfg := s.config.Auth
+	cache := middleware.NewTokenCache(cfg.CacheTTL)
+	client := middleware.NewAuthClient(s.config.Services.AuthService, cfg.Timeout)
+	// API keys are opaque; there is nothing to check without auth-service
+	authMiddleware.AcceptAPIKeys(client, cache)
+	if cfg.Introspect {
+		authMiddleware.UseIntrospection(client, cache)
+	}
+
+	if len(cfg.KafkaBrokers) == 0 {
+		log.Printf("KAFKA_BROKERS not set: token cache relies on its %s TTL", cfg.CacheTTL)
+		return
+	}
+	hostname, _ := os.Hostname()
+	listener := middleware.NewAuthEventListener(cfg.KafkaBrokers, "api-gateway-"+hostname, cache)
+	go listener.Start(context.Background())
+}
+
+// newRateLimiter keeps buckets in redis when REDIS_HOST is set so every
+// gateway replica enforces the same limits, and in process memory otherwise.
+func (s *Server) newRateLimiter() *middleware.RateLimiter {
+	cfg := s.config.RateLimit
+	routes := make(map[string]middleware.RateQuota, len(cfg.Routes))
+	for group, q := range cfg.Routes {
+		routes[group] = middleware.RateQuota{RequestsPerMinute: q.RequestsPerMinute, Burst: q.Burst}
+	}
+	defaultQuota := middleware.RateQuota{RequestsPerMinute: cfg.RequestsPerMinute, Burst: cfg.Burst}
+
+	var store middleware.RateStore = middleware.NewMemoryRateStore(cfg.IdleTTL)
+	if s.config.Redis.Host != "" {
+		client := redis.NewClient(&redis.Options{
+			Addr:     s.config.Redis.Host + ":" + s.config.Redis.Port,
+			Password: s.config.Redis.Password,
+			DB:       s.config.Redis.DB,
+		})
+		store = middleware.NewRedisRateStore(client, cfg.IdleTTL, store)
+		log.Printf("Rate limiter using redis at %s:%s", s.config.Redis.Host, s.config.Redis.Port)
 	}
+	return middleware.NewRateLimiter(store, defaultQuota, routes)
+}
 
+func (s *Server) getServicesStatus(c *gin.Context) {
 	status := make(map[string]interface{})
-	for name, url := range services {
+	for _, up := range s.config.Upstreams {
+		if up.Name == "ui" {
+			continue
+		}
+		circuit := s.proxy.CircuitState(up.Name)
 		// Simple health check
-		resp, err := http.Get(url + "/health")
+		resp, err := http.Get(up.URL + "/health")
 		if err != nil {
-			status[name] = map[string]interface{}{
-				"status": "down",
-				"error":  err.Error(),
+			status[up.Name] = map[string]interface{}{
+				"status":  "down",
+				"error":   err.Error(),
+				"circuit": circuit,
 			}
 		} else {
 			resp.Body.Close()
-			status[name] = map[string]interface{}{
-				"status": "up",
-				"url":    url,
+			status[up.Name] = map[string]interface{}{
+				"status":  "up",
+				"url":     up.URL,
+				"circuit": circuit,
 			}
 		}
 	}
@@ -18,11 +18,7 @@ func GenerateSQLMigrations(service *parser.Service, outputDir string) error {
 	buf.WriteString(fmt.Sprintf("-- Database schema for %s Service\n\n", service.Name))
 
 	for _, entity := range service.Entities {
-		tableName := toSnakeCase(entity.Name) + "s"
-		// Plural exceptions
-		if strings.HasSuffix(tableName, "ys") {
-			tableName = tableName[:len(tableName)-2] + "ies"
-		}
+		tableName := toTableName(entity.Name)
 
 		buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", tableName))
 		
@@ -45,10 +41,7 @@ func GenerateSQLMigrations(service *parser.Service, outputDir string) error {
 			if f.ReferenceTo != "" {
 				// Parse reference: e.g. "User.id" -> "users(id)"
 				parts := strings.Split(f.ReferenceTo, ".")
-				refTable := toSnakeCase(parts[0]) + "s"
-				if strings.HasSuffix(refTable, "ys") {
-					refTable = refTable[:len(refTable)-2] + "ies"
-				}
+				refTable := toTableName(parts[0])
 				refCol := toSnakeCase(parts[1])
 				constraints += fmt.Sprintf(" REFERENCES %s(%s)", refTable, refCol)
 			}
@@ -70,6 +63,16 @@ func GenerateSQLMigrations(service *parser.Service, outputDir string) error {
 	return nil
 }
 
+// toTableName pluralizes the snake_case entity name: a consonant before a
+// trailing "y" becomes "ies" (company -> companies), a vowel keeps it (key -> keys).
+func toTableName(entityName string) string {
+	name := toSnakeCase(entityName)
+	if n := len(name); n > 1 && name[n-1] == 'y' && !strings.ContainsRune("aeiou", rune(name[n-2])) {
+		return name[:n-1] + "ies"
+	}
+	return name + "s"
+}
+
 func mapCDDTypeToSQL(fieldName, cddType string) string {
 	switch strings.ToLower(cddType) {
 	case "uuid":
@@ -67,17 +67,6 @@ generate_password() {
     fi
 }
 
-# Generate JWT secret (32+ character hex string)
-generate_jwt_secret() {
-    if command -v openssl &> /dev/null; then
-        openssl rand -hex 32
-    elif command -v python3 &> /dev/null; then
-        python3 -c "import secrets; print(secrets.token_hex(32))"
-    else
-        head -c 32 /dev/urandom | base64 | tr -d '\n'
-    fi
-}
-
 # Validate password strength
 validate_password() {
     local password=$1
@@ -122,11 +111,6 @@ setup_interactive() {
         fi
     done
     
-    # JWT Secret
-    print_info "\nGenerating JWT secret..."
-    jwt_secret=$(generate_jwt_secret)
-    print_success "JWT secret generated: ${jwt_secret:0:16}..."
-    
     # Admin Credentials
     read -p "Admin username (default: admin): " admin_username
     admin_username=${admin_username:-admin}
@@ -152,7 +136,7 @@ setup_interactive() {
     echo "  REDIS_PASSWORD: ****"
     echo ""
     echo "Security:"
-    echo "  JWT_SECRET: ${jwt_secret:0:16}..."
+    echo "  JWT signing keys: generated and rotated by auth-service"
     echo ""
     echo "Admin User:"
     echo "  ADMIN_USERNAME: $admin_username"
@@ -167,7 +151,7 @@ setup_interactive() {
     fi
     
     create_env_file "$postgres_user" "$postgres_password" "$redis_password" \
-                    "$jwt_secret" "$admin_username" "$admin_password" "$admin_email"
+                    "$admin_username" "$admin_password" "$admin_email"
 }
 
 ###############################################################################
@@ -183,7 +167,6 @@ setup_auto() {
     postgres_user="postgres"
     postgres_password=$(generate_password 32)
     redis_password=$(generate_password 32)
-    jwt_secret=$(generate_jwt_secret)
     admin_username="admin"
     admin_password=$(generate_password 20)
     admin_email="admin@erp.local"
@@ -191,13 +174,12 @@ setup_auto() {
     print_success "PostgreSQL user: $postgres_user"
     print_success "PostgreSQL password: Generated (32 chars)"
     print_success "Redis password: Generated (32 chars)"
-    print_success "JWT secret: Generated (64 hex chars)"
     print_success "Admin username: $admin_username"
     print_success "Admin password: Generated (20 chars)"
     print_success "Admin email: $admin_email"
     
     create_env_file "$postgres_user" "$postgres_password" "$redis_password" \
-                    "$jwt_secret" "$admin_username" "$admin_password" "$admin_email"
+                    "$admin_username" "$admin_password" "$admin_email"
 }
 
 ###############################################################################
@@ -208,10 +190,9 @@ create_env_file() {
     local postgres_user=$1
     local postgres_password=$2
     local redis_password=$3
-    local jwt_secret=$4
-    local admin_username=$5
-    local admin_password=$6
-    local admin_email=$7
+    local admin_username=$4
+    local admin_password=$5
+    local admin_email=$6
     
     if [ -f "$ENV_FILE" ]; then
         print_warning ".env already exists!"
@@ -248,8 +229,11 @@ REDIS_PASSWORD=$redis_password
 # ============================================================================
 # AUTHENTICATION & SECURITY
 # ============================================================================
-# JWT secret for token signing (generated via openssl rand)
-JWT_SECRET=$jwt_secret
+# auth-service signs tokens with asymmetric keys it generates and rotates;
+# the gateway verifies them against its JWKS. Running more than one
+# auth-service replica needs a shared PEM key in JWT_SIGNING_KEY_FILE.
+JWT_SIGNING_ALG=RS256
+OIDC_ISSUER=http://localhost:8080/api/v1/auth
 
 # ============================================================================
 # INITIAL ADMIN USER
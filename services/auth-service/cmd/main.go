@@ -49,30 +49,62 @@ func main() {
 	urRepo := memory.NewUserRoleRepository()
 	usRepo := memory.NewUserStoreRepository()
 	rpRepo := memory.NewRolePermissionRepository()
+	mfaRepo := memory.NewUserMFARepository()
+	challengeRepo := memory.NewMFAChallengeRepository()
+	attemptRepo := memory.NewLoginAttemptRepository()
+	outboxRepo := memory.NewTransactionalOutboxRepository()
+	codeRepo := memory.NewAuthorizationCodeRepository()
+	accountRepo := memory.NewServiceAccountRepository()
+	keyRepo := memory.NewAPIKeyRepository()
+	signingKeyRepo := memory.NewSigningKeyRepository()
+	tm := memory.NewTransactionManager(userRepo, attemptRepo, outboxRepo)
 
 	// 4. Initialize business services (split components)
 	rbacSvc := service.NewRBACService(
 		roleRepo,
 		permRepo,
+		userRepo,
 		urRepo,
+		usRepo,
 		rpRepo,
-		publisher,
+		outboxRepo,
+		tm,
 	)
 
 	userSvc := service.NewUserService(
 		userRepo,
 		usRepo,
 		urRepo,
+		mfaRepo,
+		attemptRepo,
+		outboxRepo,
+		tm,
 		publisher,
 	)
 
-	authSvc := service.NewAuthService(
-		userRepo,
-		sessRepo,
-		rbacSvc,
-		publisher,
-		cfg,
-	)
+	// Token signing keys; the public halves are served as the JWKS
+	keys, err := service.NewKeyManager(cfg.JWT, signingKeyRepo)
+	if err != nil {
+		log.Fatalf("Failed to set up token signing keys: %v", err)
+	}
+
+	authSvc := service.NewAuthService(service.AuthServiceDeps{
+		Users:           userRepo,
+		Sessions:        sessRepo,
+		MFA:             mfaRepo,
+		MFAChallenges:   challengeRepo,
+		LoginAttempts:   attemptRepo,
+		Outbox:          outboxRepo,
+		TM:              tm,
+		ServiceAccounts: accountRepo,
+		APIKeys:         keyRepo,
+		RBAC:            rbacSvc,
+		Publisher:       publisher,
+		Config:          cfg,
+		Keys:            keys,
+	})
+
+	oidcSvc := service.NewOIDCService(authSvc, codeRepo, cfg)
 
 	// 5. Seed initial roles, permissions, and users
 	seedAuthData(userSvc, rbacSvc)
@@ -90,11 +122,23 @@ func main() {
 	}()
 	go consumer.Start(consumerCtx)
 
+	// 5c. Relay events written to the outbox (lockouts, RBAC and session changes)
+	relay := kafka.NewOutboxRelayWorker(outboxRepo, publisher, 5*time.Second, 100)
+	go relay.Start(consumerCtx)
+
 	// 6. Setup Gin routing
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
+	// Failed logins are counted per ClientIP; only the gateway may set it
+	// through X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	if cfg.Lockout.IPBlockingDisabled {
+		log.Printf("TRUSTED_PROXIES not set: failed logins are not blocked per IP address")
+	}
 	r.Use(utils.TracingMiddleware("auth-service"))
 
 	// Health check endpoint
@@ -108,7 +152,9 @@ func main() {
 
 	handler := handlers.NewIdentityHandler(authSvc, userSvc, rbacSvc, responseHelper)
 	rbacHandler := handlers.NewRBACHandler(rbacSvc, responseHelper)
-	routes.SetupAuthRoutes(r, handler, rbacHandler)
+	oidcHandler := handlers.NewOIDCHandler(oidcSvc, responseHelper)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(authSvc, responseHelper)
+	routes.SetupAuthRoutes(r, handler, rbacHandler, oidcHandler, serviceAccountHandler)
 
 	// 7. Start HTTP server with graceful shutdown
 	server := &http.Server{
@@ -168,6 +214,20 @@ func seedAuthData(userSvc *service.UserService, rbacSvc *service.RBACService) {
 	pWriteFMJournal, _ := rbacSvc.CreatePermission(ctx, "fm:journal:write", "Write Finance Journal")
 	pPostFMJournal, _ := rbacSvc.CreatePermission(ctx, "fm:journal:post", "Post Finance Journal")
 	pReadFMReports, _ := rbacSvc.CreatePermission(ctx, "fm:reports:read", "Read Finance Reports")
+	pWriteFMCredit, _ := rbacSvc.CreatePermission(ctx, "fm:credit:write", "Manage Customer Credit Limits and Holds")
+	pOverrideFMCredit, _ := rbacSvc.CreatePermission(ctx, "fm:credit:override", "Release Credit Holds and Override Credit Checks")
+	pApproveFMPayments, _ := rbacSvc.CreatePermission(ctx, "fm:payments:approve", "Approve and Execute Payment Runs")
+	pWriteFMAllocations, _ := rbacSvc.CreatePermission(ctx, "fm:allocations:write", "Manage Cost Centers, Allocation Cycles and Drivers")
+	pWriteFMLegalEntities, _ := rbacSvc.CreatePermission(ctx, "fm:legal_entities:write", "Create Legal Entities")
+	pWriteFMAssets, _ := rbacSvc.CreatePermission(ctx, "fm:assets:write", "Capitalize and Depreciate Assets")
+	pCloseFMPeriods, _ := rbacSvc.CreatePermission(ctx, "fm:periods:close", "Close, Reopen and Year-End Close Fiscal Periods")
+	pWriteFMIntercompany, _ := rbacSvc.CreatePermission(ctx, "fm:intercompany:write", "Post Intercompany Transactions")
+	pWriteFMConsolidation, _ := rbacSvc.CreatePermission(ctx, "fm:consolidation:write", "Manage Consolidation Groups")
+	pWriteFMBudgets, _ := rbacSvc.CreatePermission(ctx, "fm:budgets:write", "Manage Budgets and Budget Policies")
+	pApproveFMBudgets, _ := rbacSvc.CreatePermission(ctx, "fm:budgets:approve", "Approve Commitments Over Budget")
+	pWriteFMTax, _ := rbacSvc.CreatePermission(ctx, "fm:tax:write", "Manage Tax Rates, Jurisdictions and Exemptions")
+	pOverrideFMInvoices, _ := rbacSvc.CreatePermission(ctx, "fm:invoices:override", "Release Vendor Bills Held by Three-Way Match")
+	pWriteFMDunning, _ := rbacSvc.CreatePermission(ctx, "fm:dunning:write", "Manage Dunning Levels and Run Dunning")
 
 	// Link permissions to Admin Role
 	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pCreateProduct.ID)
@@ -188,6 +248,20 @@ func seedAuthData(userSvc *service.UserService, rbacSvc *service.RBACService) {
 	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMJournal.ID)
 	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pPostFMJournal.ID)
 	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pReadFMReports.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMCredit.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pOverrideFMCredit.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pApproveFMPayments.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMAllocations.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMLegalEntities.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMAssets.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMIntercompany.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMConsolidation.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMBudgets.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pApproveFMBudgets.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMTax.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pOverrideFMInvoices.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pWriteFMDunning.ID)
+	_ = rbacSvc.AssignPermissionToRole(ctx, adminRole.ID, pCloseFMPeriods.ID)
 
 	// Link permissions to Manager Role
 	_ = rbacSvc.AssignPermissionToRole(ctx, managerRole.ID, pReadProduct.ID)
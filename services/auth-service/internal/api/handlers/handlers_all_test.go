@@ -3,10 +3,18 @@ package handlers_test
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"erp-system/shared/utils"
 	"github.com/erp-system/auth-service/internal/api/handlers"
@@ -24,15 +32,28 @@ func init() {
 }
 
 type testEnv struct {
-	router    *gin.Engine
-	userRepo  *memory.UserRepository
-	sessRepo  *memory.SessionRepository
-	roleRepo  *memory.RoleRepository
-	permRepo  *memory.PermissionRepository
-	urRepo    *memory.UserRoleRepository
-	usRepo    *memory.UserStoreRepository
-	rpRepo    *memory.RolePermissionRepository
-	publisher *mockPublisher
+	router     *gin.Engine
+	userRepo   *memory.UserRepository
+	sessRepo   *memory.SessionRepository
+	roleRepo   *memory.RoleRepository
+	permRepo   *memory.PermissionRepository
+	urRepo     *memory.UserRoleRepository
+	usRepo     *memory.UserStoreRepository
+	rpRepo     *memory.RolePermissionRepository
+	mfaRepo    *memory.UserMFARepository
+	outboxRepo *memory.TransactionalOutboxRepository
+	publisher  *mockPublisher
+}
+
+// written reports whether an event on topic is waiting in the outbox
+func (env *testEnv) written(topic string) bool {
+	records, _ := env.outboxRepo.GetPending(context.Background(), 1000)
+	for _, rec := range records {
+		if rec.EventType == topic {
+			return true
+		}
+	}
+	return false
 }
 
 type mockPublisher struct {
@@ -58,11 +79,23 @@ func setupTestEnv() *testEnv {
 	urRepo := memory.NewUserRoleRepository()
 	usRepo := memory.NewUserStoreRepository()
 	rpRepo := memory.NewRolePermissionRepository()
+	mfaRepo := memory.NewUserMFARepository()
+	attemptRepo := memory.NewLoginAttemptRepository()
+	outboxRepo := memory.NewTransactionalOutboxRepository()
 	publisher := &mockPublisher{}
 
 	cfg := &config.Config{}
 	cfg.JWT.AccessExpiry = 15
 	cfg.JWT.RefreshExpiry = 10080
+	cfg.OIDC = config.OIDCConfig{
+		Issuer:   "http://auth.test/api/v1/auth",
+		LoginURL: "http://app.test/login",
+		Clients:  []config.OAuthClient{{ID: "erp-frontend", RedirectURIs: []string{"http://app.test/callback"}}},
+	}
+	keys, err := service.NewKeyManager(config.JWTConfig{SigningAlgorithm: "ES256", AccessExpiry: cfg.JWT.AccessExpiry}, memory.NewSigningKeyRepository())
+	if err != nil {
+		panic(err)
+	}
 
 	wrappedUserRepo := &errorInjectingUserRepo{delegate: userRepo}
 	wrappedSessRepo := &errorInjectingSessionRepo{delegate: sessRepo}
@@ -72,28 +105,49 @@ func setupTestEnv() *testEnv {
 	wrappedUsRepo := &errorInjectingUserStoreRepo{delegate: usRepo}
 	wrappedRpRepo := &errorInjectingRolePermissionRepo{delegate: rpRepo}
 
-	rbacSvc := service.NewRBACService(wrappedRoleRepo, wrappedPermRepo, wrappedUrRepo, wrappedRpRepo, publisher)
-	userSvc := service.NewUserService(wrappedUserRepo, wrappedUsRepo, wrappedUrRepo, publisher)
-	authSvc := service.NewAuthService(wrappedUserRepo, wrappedSessRepo, rbacSvc, publisher, cfg)
+	tm := memory.NewTransactionManager(userRepo, attemptRepo, outboxRepo)
+
+	rbacSvc := service.NewRBACService(wrappedRoleRepo, wrappedPermRepo, wrappedUserRepo, wrappedUrRepo, wrappedUsRepo, wrappedRpRepo, outboxRepo, tm)
+	userSvc := service.NewUserService(wrappedUserRepo, wrappedUsRepo, wrappedUrRepo, mfaRepo, attemptRepo, outboxRepo, tm, publisher)
+	authSvc := service.NewAuthService(service.AuthServiceDeps{
+		Users:           wrappedUserRepo,
+		Sessions:        wrappedSessRepo,
+		MFA:             mfaRepo,
+		MFAChallenges:   memory.NewMFAChallengeRepository(),
+		LoginAttempts:   attemptRepo,
+		Outbox:          outboxRepo,
+		TM:              tm,
+		ServiceAccounts: memory.NewServiceAccountRepository(),
+		APIKeys:         memory.NewAPIKeyRepository(),
+		RBAC:            rbacSvc,
+		Publisher:       publisher,
+		Config:          cfg,
+		Keys:            keys,
+	})
+	oidcSvc := service.NewOIDCService(authSvc, memory.NewAuthorizationCodeRepository(), cfg)
 
 	response := utils.NewResponseHelper("auth-service")
 
 	identityHandler := handlers.NewIdentityHandler(authSvc, userSvc, rbacSvc, response)
 	rbacHandler := handlers.NewRBACHandler(rbacSvc, response)
+	oidcHandler := handlers.NewOIDCHandler(oidcSvc, response)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(authSvc, response)
 
 	router := gin.New()
-	routes.SetupAuthRoutes(router, identityHandler, rbacHandler)
+	routes.SetupAuthRoutes(router, identityHandler, rbacHandler, oidcHandler, serviceAccountHandler)
 
 	return &testEnv{
-		router:    router,
-		userRepo:  userRepo,
-		sessRepo:  sessRepo,
-		roleRepo:  roleRepo,
-		permRepo:  permRepo,
-		urRepo:    urRepo,
-		usRepo:    usRepo,
-		rpRepo:    rpRepo,
-		publisher: publisher,
+		router:     router,
+		userRepo:   userRepo,
+		sessRepo:   sessRepo,
+		roleRepo:   roleRepo,
+		permRepo:   permRepo,
+		urRepo:     urRepo,
+		usRepo:     usRepo,
+		rpRepo:     rpRepo,
+		mfaRepo:    mfaRepo,
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
 	}
 }
 
@@ -693,6 +747,13 @@ func (r *errorInjectingRoleRepo) List(ctx context.Context) ([]domain.Role, error
 	return r.delegate.List(ctx)
 }
 
+func (r *errorInjectingRoleRepo) Update(ctx context.Context, role *domain.Role) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	return r.delegate.Update(ctx, role)
+}
+
 func (r *errorInjectingRoleRepo) Delete(ctx context.Context, id string) error {
 	if err := checkCtx(ctx); err != nil {
 		return err
@@ -813,3 +874,457 @@ func (r *errorInjectingRolePermissionRepo) Delete(ctx context.Context, roleID st
 	}
 	return r.delegate.Delete(ctx, roleID, permissionID)
 }
+
+func TestValidateEndpoint(t *testing.T) {
+	env := setupTestEnv()
+
+	send := func(method, url string, payload interface{}, token string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&buf).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, url, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	var role domain.Role
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/roles", map[string]string{"name": "Clerk"}, "").Body.Bytes(), &role)
+	var perm domain.Permission
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/permissions", map[string]string{"code": "scm:product:read"}, "").Body.Bytes(), &perm)
+	send(http.MethodPost, "/api/v1/auth/roles/"+role.ID+"/permissions", map[string]string{"permission_id": perm.ID}, "")
+
+	send(http.MethodPost, "/api/v1/auth/register", map[string]interface{}{
+		"username": "bob", "email": "bob@example.com", "password": "password123",
+		"first_name": "Bob", "last_name": "Jones", "role_ids": []string{role.ID},
+	}, "")
+	var login struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/login", map[string]string{"username": "bob", "password": "password123"}, "").Body.Bytes(), &login)
+
+	if w := send(http.MethodGet, "/api/v1/auth/validate", nil, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", w.Code)
+	}
+
+	var resp struct {
+		Active      bool     `json:"active"`
+		SessionID   string   `json:"session_id"`
+		Permissions []string `json:"permissions"`
+	}
+	w := send(http.MethodGet, "/api/v1/auth/validate", nil, login.AccessToken)
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || !resp.Active || resp.SessionID == "" || len(resp.Permissions) != 1 {
+		t.Fatalf("unexpected validate response %d: %s", w.Code, w.Body.String())
+	}
+
+	// Permission changes show up on the existing token and are announced
+	send(http.MethodDelete, "/api/v1/auth/roles/"+role.ID+"/permissions/"+perm.ID, nil, "")
+	resp.Permissions = nil
+	_ = json.Unmarshal(send(http.MethodGet, "/api/v1/auth/validate", nil, login.AccessToken).Body.Bytes(), &resp)
+	if len(resp.Permissions) != 0 {
+		t.Errorf("expected revoked permission to disappear, got %v", resp.Permissions)
+	}
+	if !env.written(domain.TopicAuthRolePermissionRevoked) {
+		t.Error("expected a role permission revoked event")
+	}
+
+	// Logging out revokes the session behind the access token
+	send(http.MethodPost, "/api/v1/auth/logout", map[string]string{"refresh_token": login.RefreshToken}, "")
+	if w := send(http.MethodGet, "/api/v1/auth/validate", nil, login.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after logout, got %d", w.Code)
+	}
+	if !env.written(domain.TopicAuthSessionRevoked) {
+		t.Error("expected a session revoked event")
+	}
+}
+
+// currentTOTP computes the RFC 6238 code an authenticator app would show
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestMFAEndpoints(t *testing.T) {
+	env := setupTestEnv()
+
+	send := func(method, url string, payload interface{}, token string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&buf).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, url, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	type loginResp struct {
+		AccessToken           string `json:"access_token"`
+		MFARequired           bool   `json:"mfa_required"`
+		MFAEnrollmentRequired bool   `json:"mfa_enrollment_required"`
+		MFAToken              string `json:"mfa_token"`
+	}
+	login := func() loginResp {
+		var resp loginResp
+		w := send(http.MethodPost, "/api/v1/auth/login", map[string]string{"username": "carol", "password": "password123"}, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("login: %d %s", w.Code, w.Body.String())
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	var role domain.Role
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/roles", map[string]string{"name": "Accountant"}, "").Body.Bytes(), &role)
+	if w := send(http.MethodPut, "/api/v1/auth/roles/"+role.ID+"/mfa-policy", map[string]bool{"mfa_required": true}, ""); w.Code != http.StatusOK {
+		t.Fatalf("set mfa policy: %d %s", w.Code, w.Body.String())
+	}
+	var registered domain.User
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/register", map[string]interface{}{
+		"username": "carol", "email": "carol@example.com", "password": "password123",
+		"first_name": "Carol", "last_name": "King", "role_ids": []string{role.ID},
+	}, "").Body.Bytes(), &registered)
+
+	// The role requires MFA, so the password alone yields an enrollment challenge
+	first := login()
+	if !first.MFARequired || !first.MFAEnrollmentRequired || first.AccessToken != "" || first.MFAToken == "" {
+		t.Fatalf("expected an enrollment challenge, got %+v", first)
+	}
+	if w := send(http.MethodPost, "/api/v1/auth/mfa/enroll", nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 enrolling without credentials, got %d", w.Code)
+	}
+
+	var enrollment service.MFAEnrollment
+	w := send(http.MethodPost, "/api/v1/auth/mfa/enroll", map[string]string{"mfa_token": first.MFAToken}, "")
+	_ = json.Unmarshal(w.Body.Bytes(), &enrollment)
+	if w.Code != http.StatusOK || enrollment.Secret == "" || enrollment.ProvisioningURI == "" {
+		t.Fatalf("enroll: %d %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/auth/mfa/confirm", map[string]string{"mfa_token": first.MFAToken, "code": "abc"}, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad code, got %d", w.Code)
+	}
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	w = send(http.MethodPost, "/api/v1/auth/mfa/confirm", map[string]string{"mfa_token": first.MFAToken, "code": currentTOTP(t, enrollment.Secret)}, "")
+	_ = json.Unmarshal(w.Body.Bytes(), &confirmed)
+	if w.Code != http.StatusOK || len(confirmed.RecoveryCodes) == 0 {
+		t.Fatalf("confirm: %d %s", w.Code, w.Body.String())
+	}
+
+	// Second step with a recovery code, as after a lost phone
+	var tokens loginResp
+	w = send(http.MethodPost, "/api/v1/auth/login/mfa", map[string]string{"mfa_token": first.MFAToken, "code": confirmed.RecoveryCodes[0]}, "")
+	_ = json.Unmarshal(w.Body.Bytes(), &tokens)
+	if w.Code != http.StatusOK || tokens.AccessToken == "" {
+		t.Fatalf("login/mfa: %d %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/auth/login/mfa", map[string]string{"mfa_token": first.MFAToken, "code": confirmed.RecoveryCodes[1]}, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 reusing a completed challenge, got %d", w.Code)
+	}
+
+	second := login()
+	if !second.MFARequired || second.MFAEnrollmentRequired {
+		t.Fatalf("expected a verification challenge, got %+v", second)
+	}
+
+	// An administrator resets MFA; the user has to enroll again
+	if w := send(http.MethodPost, "/api/v1/auth/users/"+registered.ID+"/mfa/reset", nil, ""); w.Code != http.StatusOK {
+		t.Fatalf("reset: %d %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/auth/validate", nil, tokens.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected token issued before the reset to be rejected, got %d", w.Code)
+	}
+	if third := login(); !third.MFAEnrollmentRequired {
+		t.Errorf("expected an enrollment challenge after reset, got %+v", third)
+	}
+	if !env.written(domain.TopicAuthUserMfaReset) {
+		t.Error("expected an MFA reset event")
+	}
+}
+
+func TestLoginLockoutEndpoints(t *testing.T) {
+	env := setupTestEnv()
+
+	send := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		_ = json.NewEncoder(&buf).Encode(payload)
+		req, _ := http.NewRequest(method, url, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(password string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/api/v1/auth/login", map[string]string{"username": "dave", "password": password})
+	}
+
+	var registered domain.User
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/register", map[string]interface{}{
+		"username": "dave", "email": "dave@example.com", "password": "password123",
+		"first_name": "Dave", "last_name": "D",
+	}).Body.Bytes(), &registered)
+
+	for i := 0; i < 3; i++ {
+		if w := login("wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i+1, w.Code)
+		}
+	}
+	w := login("password123")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after repeated failures, got %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	if w := send(http.MethodPost, "/api/v1/auth/users/"+registered.ID+"/unlock", nil); w.Code == http.StatusOK {
+		t.Error("expected unlocking an active user to fail")
+	}
+
+	user, _ := env.userRepo.GetByID(context.Background(), registered.ID)
+	until := time.Now().Add(time.Hour)
+	user.Status = domain.UserStatusLOCKED
+	user.LockedUntil = &until
+	_ = env.userRepo.Update(context.Background(), user)
+
+	if w := send(http.MethodPost, "/api/v1/auth/users/"+registered.ID+"/unlock", nil); w.Code != http.StatusOK {
+		t.Fatalf("unlock: %d %s", w.Code, w.Body.String())
+	}
+	if w := login("password123"); w.Code != http.StatusOK {
+		t.Errorf("expected login after unlock, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// Behind the gateway, failed logins count against the client in
+// X-Forwarded-For, so one client's failures do not block the others.
+func TestLoginBlocksClientBehindProxy(t *testing.T) {
+	env := setupTestEnv()
+	if err := env.router.SetTrustedProxies([]string{"172.28.0.10"}); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(username, password, client string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", client)
+		req.RemoteAddr = "172.28.0.10:41000"
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"username": "erin", "email": "erin@example.com", "password": "password123",
+		"first_name": "Erin", "last_name": "E",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", w.Code, w.Body.String())
+	}
+
+	// Default policy: 20 failures per address, spread over usernames
+	for i := 0; i < 20; i++ {
+		_ = login(fmt.Sprintf("guess%d", i), "wrong", "203.0.113.7")
+	}
+	if w := login("erin", "password123", "203.0.113.7"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the failing client to be blocked, got %d %s", w.Code, w.Body.String())
+	}
+	if w := login("erin", "password123", "198.51.100.4"); w.Code != http.StatusOK {
+		t.Errorf("expected another client through the same proxy to log in, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOIDCEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	const challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	var meta service.ProviderMetadata
+	_ = json.Unmarshal(get("/api/v1/auth/.well-known/openid-configuration").Body.Bytes(), &meta)
+	if meta.TokenEndpoint != "http://auth.test/api/v1/auth/oauth/token" || meta.IDTokenSigningAlgValuesSupported[0] != "ES256" {
+		t.Errorf("unexpected discovery document %+v", meta)
+	}
+	var jwks service.JSONWebKeySet
+	_ = json.Unmarshal(get("/api/v1/auth/.well-known/jwks.json").Body.Bytes(), &jwks)
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kty != "EC" || jwks.Keys[0].Kid == "" {
+		t.Errorf("unexpected jwks %+v", jwks)
+	}
+
+	query := "response_type=code&client_id=erp-frontend&redirect_uri=http%3A%2F%2Fapp.test%2Fcallback" +
+		"&scope=openid&state=s1&code_challenge=" + challenge + "&code_challenge_method=S256"
+	if w := get("/api/v1/auth/oauth/authorize?" + query); w.Code != http.StatusFound || w.Header().Get("Location") != "http://app.test/login?"+query {
+		t.Errorf("expected a redirect to the login page, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if w := get("/api/v1/auth/oauth/authorize?response_type=code&client_id=erp-frontend&redirect_uri=http%3A%2F%2Fevil.test"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unregistered redirect_uri, got %d", w.Code)
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"username": "erin", "email": "erin@example.com", "password": "password123", "first_name": "Erin", "last_name": "E",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	body, _ = json.Marshal(map[string]string{
+		"response_type": "code", "client_id": "erp-frontend", "redirect_uri": "http://app.test/callback",
+		"scope": "openid", "state": "s1", "code_challenge": challenge, "code_challenge_method": "S256",
+		"username": "erin", "password": "password123",
+	})
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/auth/oauth/authorize", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	var authorized struct {
+		RedirectURI string `json:"redirect_uri"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &authorized)
+	redirect, err := url.Parse(authorized.RedirectURI)
+	if w.Code != http.StatusOK || err != nil || redirect.Query().Get("code") == "" {
+		t.Fatalf("authorize: %d %s", w.Code, w.Body.String())
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"erp-frontend"},
+		"redirect_uri":  {"http://app.test/callback"},
+		"code":          {redirect.Query().Get("code")},
+		"code_verifier": {verifier},
+	}
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/auth/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	var tokens service.OIDCTokens
+	_ = json.Unmarshal(w.Body.Bytes(), &tokens)
+	if w.Code != http.StatusOK || tokens.IDToken == "" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("token: %d %s", w.Code, w.Body.String())
+	}
+
+	// The code is single-use
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/auth/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_grant") {
+		t.Errorf("expected invalid_grant on reuse, got %d %s", w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/auth/oauth/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"preferred_username":"erin"`) {
+		t.Errorf("userinfo: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServiceAccountEndpoints(t *testing.T) {
+	env := setupTestEnv()
+
+	send := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&buf).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, url, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	postToken := func(form url.Values, basicID, basicSecret string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/auth/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basicID != "" {
+			req.SetBasicAuth(basicID, basicSecret)
+		}
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	var role domain.Role
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/roles", map[string]string{"name": "Integration"}).Body.Bytes(), &role)
+	var perm domain.Permission
+	_ = json.Unmarshal(send(http.MethodPost, "/api/v1/auth/permissions", map[string]string{"code": "scm:product:read"}).Body.Bytes(), &perm)
+	send(http.MethodPost, "/api/v1/auth/roles/"+role.ID+"/permissions", map[string]string{"permission_id": perm.ID})
+
+	var account domain.ServiceAccount
+	w := send(http.MethodPost, "/api/v1/auth/service-accounts", map[string]interface{}{
+		"legal_entity_id": "le-1", "name": "bi-export", "role_ids": []string{role.ID},
+	})
+	_ = json.Unmarshal(w.Body.Bytes(), &account)
+	if w.Code != http.StatusCreated || account.Status != domain.ServiceAccountStatusACTIVE {
+		t.Fatalf("create service account: %d %s", w.Code, w.Body.String())
+	}
+
+	var key service.CreatedAPIKey
+	w = send(http.MethodPost, "/api/v1/auth/service-accounts/"+account.ID+"/keys", map[string]interface{}{"name": "nightly", "expires_in_days": 30})
+	_ = json.Unmarshal(w.Body.Bytes(), &key)
+	if w.Code != http.StatusCreated || key.Key == "" {
+		t.Fatalf("create key: %d %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/auth/service-accounts/"+account.ID+"/keys", nil); strings.Contains(w.Body.String(), key.Key) || !strings.Contains(w.Body.String(), key.Prefix) {
+		t.Errorf("expected keys to be listed by prefix only, got %s", w.Body.String())
+	}
+
+	// The gateway validates a raw key through /validate
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/auth/validate", nil)
+	req.Header.Set("X-API-Key", key.Key)
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"tenant_id":"le-1"`) || !strings.Contains(w.Body.String(), "scm:product:read") {
+		t.Fatalf("validate api key: %d %s", w.Code, w.Body.String())
+	}
+
+	// client_credentials, with the key as client secret in a Basic header
+	if w := postToken(url.Values{"grant_type": {"client_credentials"}}, account.ID, "erpk_wrong"); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "invalid_client") {
+		t.Errorf("expected invalid_client for a wrong secret, got %d %s", w.Code, w.Body.String())
+	}
+	var tokens service.OIDCTokens
+	w = postToken(url.Values{"grant_type": {"client_credentials"}}, account.ID, key.Key)
+	_ = json.Unmarshal(w.Body.Bytes(), &tokens)
+	if w.Code != http.StatusOK || tokens.AccessToken == "" || tokens.RefreshToken != "" {
+		t.Fatalf("client credentials: %d %s", w.Code, w.Body.String())
+	}
+
+	send(http.MethodDelete, "/api/v1/auth/service-accounts/"+account.ID+"/keys/"+key.ID, nil)
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/auth/validate", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token of a revoked key, got %d", w.Code)
+	}
+}
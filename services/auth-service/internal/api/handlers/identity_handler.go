@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"erp-system/shared/rbac"
 	"erp-system/shared/utils"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/erp-system/auth-service/internal/business/domain"
 	"github.com/erp-system/auth-service/internal/business/service"
@@ -77,6 +83,62 @@ func (h *IdentityHandler) Login(c *gin.Context) {
 	userAgent := c.GetHeader("User-Agent")
 
 	accessToken, refreshToken, err := h.authSvc.AuthenticateUser(c.Request.Context(), req.Username, req.Password, ipAddress, userAgent)
+	var challenge *service.MFAChallengeRequired
+	if errors.As(err, &challenge) {
+		// Password accepted; the client continues at /login/mfa, enrolling
+		// first through /mfa/enroll and /mfa/confirm when required.
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":            true,
+			"mfa_enrollment_required": challenge.EnrollmentRequired,
+			"mfa_token":               challenge.Token,
+			"expires_at":              challenge.ExpiresAt,
+		})
+		return
+	}
+	var blocked *service.LoginBlockedError
+	if errors.As(err, &blocked) {
+		respondLoginBlocked(c, blocked)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+	})
+}
+
+func respondLoginBlocked(c *gin.Context, blocked *service.LoginBlockedError) {
+	retryAfter := int(math.Ceil(blocked.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": blocked.Error(), "retry_after": retryAfter})
+}
+
+type LoginMFAReq struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+func (h *IdentityHandler) LoginMFA(c *gin.Context) {
+	var req LoginMFAReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	accessToken, refreshToken, err := h.authSvc.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code)
+	var blocked *service.LoginBlockedError
+	if errors.As(err, &blocked) {
+		respondLoginBlocked(c, blocked)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -139,6 +201,176 @@ func (h *IdentityHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
+// Validate introspects the bearer token for the API gateway: it checks the
+// signature, the user's security stamp and the session, and returns the
+// user's current roles and permissions.
+func (h *IdentityHandler) Validate(c *gin.Context) {
+	var claims *service.TokenClaims
+	var err error
+	tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	switch {
+	case c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") != "":
+		// Service account key; the gateway forwards the caller's address
+		claims, err = h.authSvc.AuthenticateAPIKey(c.Request.Context(), c.GetHeader("X-API-Key"), c.ClientIP())
+	case tokenStr == "" || tokenStr == c.GetHeader("Authorization"):
+		c.JSON(http.StatusUnauthorized, gin.H{"active": false, "error": "bearer token or api key required"})
+		return
+	default:
+		claims, err = h.authSvc.Introspect(c.Request.Context(), tokenStr)
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"active": false, "error": err.Error()})
+		return
+	}
+
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"active":      true,
+		"user_id":     claims.UserID,
+		"username":    claims.Username,
+		"email":       claims.Email,
+		"tenant_id":   claims.TenantID,
+		"session_id":  claims.SessionID,
+		"roles":       claims.Roles,
+		"permissions": claims.Permissions,
+		"client_id":   claims.ClientID,
+		"api_key_id":  claims.APIKeyID,
+		"expires_at":  expiresAt,
+	})
+}
+
+// mfaSubject identifies whose MFA is being managed: the holder of the bearer
+// access token, or, during a login that requires enrollment, the user behind
+// the MFA challenge token.
+func (h *IdentityHandler) mfaSubject(c *gin.Context, mfaToken string) (string, bool) {
+	if mfaToken != "" {
+		userID, err := h.authSvc.EnrollmentChallengeUser(c.Request.Context(), mfaToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return "", false
+		}
+		return userID, true
+	}
+
+	tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenStr == "" || tokenStr == c.GetHeader("Authorization") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "bearer token or mfa_token required"})
+		return "", false
+	}
+	claims, err := h.authSvc.ValidateToken(c.Request.Context(), tokenStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return claims.UserID, true
+}
+
+type EnrollMFAReq struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+func (h *IdentityHandler) EnrollMFA(c *gin.Context) {
+	var req EnrollMFAReq
+	// The body is optional when a bearer token is sent
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	userID, ok := h.mfaSubject(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.authSvc.EnrollMFA(c.Request.Context(), userID)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+type ConfirmMFAReq struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func (h *IdentityHandler) ConfirmMFA(c *gin.Context) {
+	var req ConfirmMFAReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, ok := h.mfaSubject(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	codes, err := h.authSvc.ConfirmMFA(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+type RegenerateRecoveryCodesReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (h *IdentityHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req RegenerateRecoveryCodesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	// Only a signed-in user may replace their codes, never a login challenge
+	userID, ok := h.mfaSubject(c, "")
+	if !ok {
+		return
+	}
+
+	codes, err := h.authSvc.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *IdentityHandler) ResetMFA(c *gin.Context) {
+	id := c.Param("id")
+	err := h.userSvc.ResetMFA(c.Request.Context(), id)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA reset successfully"})
+}
+
+// UnlockUser clears a lockout from failed logins before it expires
+func (h *IdentityHandler) UnlockUser(c *gin.Context) {
+	id := c.Param("id")
+	user, err := h.userSvc.UnlockUser(c.Request.Context(), id)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
 type AssignStoreReq struct {
 	StoreID string `json:"store_id" binding:"required"`
 }
@@ -160,8 +392,12 @@ func (h *IdentityHandler) AssignStore(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User assigned to store successfully"})
 }
 
+// ValidatePermissionReq names a permission and, optionally, the legal entity
+// and store the user acts in; scoped grants only match a given scope.
 type ValidatePermissionReq struct {
-	Permission string `json:"permission" binding:"required"`
+	Permission    string `json:"permission" binding:"required"`
+	LegalEntityID string `json:"legal_entity_id"`
+	StoreID       string `json:"store_id"`
 }
 
 func (h *IdentityHandler) ValidatePermission(c *gin.Context) {
@@ -172,7 +408,10 @@ func (h *IdentityHandler) ValidatePermission(c *gin.Context) {
 		return
 	}
 
-	valid, err := h.rbacSvc.ValidatePermissions(c.Request.Context(), id, req.Permission)
+	valid, err := h.rbacSvc.ValidatePermissions(c.Request.Context(), id, req.Permission, rbac.Scope{
+		LegalEntityID: req.LegalEntityID,
+		StoreID:       req.StoreID,
+	})
 	if err != nil {
 		h.response.InternalErr(c, err)
 		return
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/erp-system/auth-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcSvc  *service.OIDCService
+	response *utils.ResponseHelper
+}
+
+func NewOIDCHandler(oidcSvc *service.OIDCService, response *utils.ResponseHelper) *OIDCHandler {
+	return &OIDCHandler{
+		oidcSvc:  oidcSvc,
+		response: response,
+	}
+}
+
+func (h *OIDCHandler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.oidcSvc.Discovery())
+}
+
+func (h *OIDCHandler) JWKS(c *gin.Context) {
+	// Short enough that a rotated-in key is picked up without a kid miss
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.oidcSvc.JWKS())
+}
+
+// Authorize starts the authorization code flow by sending the browser to the
+// login page with the request parameters.
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req service.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	if err := h.oidcSvc.CheckClient(req.ClientID, req.RedirectURI); err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+	var oauthErr *service.OAuthError
+	if err := h.oidcSvc.ValidateAuthorizeRequest(req); errors.As(err, &oauthErr) {
+		c.Redirect(http.StatusFound, h.oidcSvc.ErrorRedirect(req, oauthErr))
+		return
+	}
+
+	c.Redirect(http.StatusFound, h.oidcSvc.LoginURL(c.Request.URL.RawQuery))
+}
+
+type AuthorizeSubmitReq struct {
+	service.AuthorizeRequest
+	Username string `json:"username"`
+	Password string `json:"password"`
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // TOTP or recovery code, with mfa_token
+}
+
+// AuthorizeSubmit is posted by the login page. It answers like /login, except
+// that success returns the redirect URI carrying the authorization code.
+func (h *OIDCHandler) AuthorizeSubmit(c *gin.Context) {
+	var req AuthorizeSubmitReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	var redirectURI string
+	var err error
+	if req.MFAToken != "" {
+		redirectURI, err = h.oidcSvc.AuthorizeWithMFA(c.Request.Context(), req.AuthorizeRequest, req.MFAToken, req.Code)
+	} else {
+		redirectURI, err = h.oidcSvc.AuthorizeWithPassword(c.Request.Context(), req.AuthorizeRequest, req.Username, req.Password, c.ClientIP(), c.GetHeader("User-Agent"))
+	}
+
+	var challenge *service.MFAChallengeRequired
+	var blocked *service.LoginBlockedError
+	var oauthErr *service.OAuthError
+	switch {
+	case errors.As(err, &challenge):
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":            true,
+			"mfa_enrollment_required": challenge.EnrollmentRequired,
+			"mfa_token":               challenge.Token,
+			"expires_at":              challenge.ExpiresAt,
+		})
+	case errors.As(err, &blocked):
+		respondLoginBlocked(c, blocked)
+	case errors.As(err, &oauthErr):
+		writeOAuthError(c, oauthErr)
+	case err != nil:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"redirect_uri": redirectURI})
+	}
+}
+
+// Token is the token endpoint. Parameters are form encoded per RFC 6749.
+func (h *OIDCHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var tokens *service.OIDCTokens
+	var err error
+	switch c.PostForm("grant_type") {
+	case "authorization_code":
+		tokens, err = h.oidcSvc.ExchangeCode(c.Request.Context(), c.PostForm("client_id"), c.PostForm("redirect_uri"), c.PostForm("code"), c.PostForm("code_verifier"))
+	case "refresh_token":
+		tokens, err = h.oidcSvc.Refresh(c.Request.Context(), c.PostForm("refresh_token"))
+	case "client_credentials":
+		// client_secret_basic, or client_secret_post as form fields
+		clientID, clientSecret, ok := c.Request.BasicAuth()
+		if !ok {
+			clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+		}
+		tokens, err = h.oidcSvc.ClientCredentials(c.Request.Context(), clientID, clientSecret, c.ClientIP())
+	default:
+		writeOAuthError(c, &service.OAuthError{Code: "unsupported_grant_type", Description: "grant_type must be authorization_code, refresh_token or client_credentials"})
+		return
+	}
+
+	var oauthErr *service.OAuthError
+	if errors.As(err, &oauthErr) {
+		writeOAuthError(c, oauthErr)
+		return
+	}
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenStr == "" || tokenStr == c.GetHeader("Authorization") {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+		return
+	}
+
+	info, err := h.oidcSvc.UserInfo(c.Request.Context(), tokenStr)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func writeOAuthError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		oauthErr = &service.OAuthError{Code: "invalid_request", Description: err.Error()}
+	}
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="auth-service"`)
+	}
+	c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
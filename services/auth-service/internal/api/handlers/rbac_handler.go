@@ -49,6 +49,26 @@ func (h *RBACHandler) CreateRole(c *gin.Context) {
 	c.JSON(http.StatusCreated, role)
 }
 
+type RoleMFAPolicyReq struct {
+	MFARequired *bool `json:"mfa_required" binding:"required"`
+}
+
+func (h *RBACHandler) SetRoleMFAPolicy(c *gin.Context) {
+	var req RoleMFAPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	role, err := h.svc.SetRoleMFARequired(c.Request.Context(), c.Param("id"), *req.MFARequired)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
 func (h *RBACHandler) DeleteRole(c *gin.Context) {
 	id := c.Param("id")
 	err := h.svc.DeleteRole(c.Request.Context(), id)
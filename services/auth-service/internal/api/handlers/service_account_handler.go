@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"net/http"
+
+	"github.com/erp-system/auth-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountHandler struct {
+	authSvc  *service.AuthService
+	response *utils.ResponseHelper
+}
+
+func NewServiceAccountHandler(authSvc *service.AuthService, response *utils.ResponseHelper) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		authSvc:  authSvc,
+		response: response,
+	}
+}
+
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	var req service.CreateServiceAccountInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	account, err := h.authSvc.CreateServiceAccount(c.Request.Context(), req)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, account)
+}
+
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.authSvc.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": accounts})
+}
+
+func (h *ServiceAccountHandler) GetServiceAccount(c *gin.Context) {
+	account, err := h.authSvc.GetServiceAccount(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *ServiceAccountHandler) DisableServiceAccount(c *gin.Context) {
+	if err := h.authSvc.DisableServiceAccount(c.Request.Context(), c.Param("id")); err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Service account disabled successfully"})
+}
+
+// CreateAPIKey returns the key itself. It cannot be retrieved again.
+func (h *ServiceAccountHandler) CreateAPIKey(c *gin.Context) {
+	var req service.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	key, err := h.authSvc.CreateAPIKey(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *ServiceAccountHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.authSvc.ListAPIKeys(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+func (h *ServiceAccountHandler) RevokeAPIKey(c *gin.Context) {
+	if err := h.authSvc.RevokeAPIKey(c.Request.Context(), c.Param("id"), c.Param("keyId")); err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -9,23 +9,43 @@ func SetupAuthRoutes(
 	r *gin.Engine,
 	handler *handlers.IdentityHandler,
 	rbacHandler *handlers.RBACHandler,
+	oidcHandler *handlers.OIDCHandler,
+	serviceAccountHandler *handlers.ServiceAccountHandler,
 ) {
 	v1 := r.Group("/api/v1/auth")
 	{
 		v1.POST("/register", handler.Register)
 		v1.POST("/login", handler.Login)
+		v1.POST("/login/mfa", handler.LoginMFA)
 		v1.POST("/refresh", handler.Refresh)
 		v1.POST("/logout", handler.Logout)
+		v1.GET("/validate", handler.Validate)
+
+		// OpenID Connect provider; the issuer is this group's public URL
+		v1.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+		v1.GET("/.well-known/jwks.json", oidcHandler.JWKS)
+		v1.GET("/oauth/authorize", oidcHandler.Authorize)
+		v1.POST("/oauth/authorize", oidcHandler.AuthorizeSubmit)
+		v1.POST("/oauth/token", oidcHandler.Token)
+		v1.GET("/oauth/userinfo", oidcHandler.UserInfo)
+
+		// MFA enrollment (bearer token, or mfa_token while enrolling at login)
+		v1.POST("/mfa/enroll", handler.EnrollMFA)
+		v1.POST("/mfa/confirm", handler.ConfirmMFA)
+		v1.POST("/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
 
 		v1.PUT("/users/:id", handler.UpdateUser)
 		v1.POST("/users/:id/store", handler.AssignStore)
 		v1.POST("/users/:id/validate-permission", handler.ValidatePermission)
 		v1.POST("/users/:id/deactivate", handler.Deactivate)
+		v1.POST("/users/:id/mfa/reset", handler.ResetMFA)
+		v1.POST("/users/:id/unlock", handler.UnlockUser)
 
 		// Roles CRUD
 		v1.GET("/roles", rbacHandler.GetRoles)
 		v1.POST("/roles", rbacHandler.CreateRole)
 		v1.DELETE("/roles/:id", rbacHandler.DeleteRole)
+		v1.PUT("/roles/:id/mfa-policy", rbacHandler.SetRoleMFAPolicy)
 
 		// Permissions CRUD
 		v1.GET("/permissions", rbacHandler.GetPermissions)
@@ -36,5 +56,14 @@ func SetupAuthRoutes(
 		v1.GET("/roles/:id/permissions", rbacHandler.GetRolePermissions)
 		v1.POST("/roles/:id/permissions", rbacHandler.AssignPermissionToRole)
 		v1.DELETE("/roles/:id/permissions/:permissionId", rbacHandler.RemovePermissionFromRole)
+
+		// Service accounts and their API keys, for machine-to-machine callers
+		v1.GET("/service-accounts", serviceAccountHandler.ListServiceAccounts)
+		v1.POST("/service-accounts", serviceAccountHandler.CreateServiceAccount)
+		v1.GET("/service-accounts/:id", serviceAccountHandler.GetServiceAccount)
+		v1.POST("/service-accounts/:id/disable", serviceAccountHandler.DisableServiceAccount)
+		v1.GET("/service-accounts/:id/keys", serviceAccountHandler.ListAPIKeys)
+		v1.POST("/service-accounts/:id/keys", serviceAccountHandler.CreateAPIKey)
+		v1.DELETE("/service-accounts/:id/keys/:keyId", serviceAccountHandler.RevokeAPIKey)
 	}
 }
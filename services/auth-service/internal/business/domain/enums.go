@@ -8,6 +8,7 @@ const (
 	UserStatusACTIVE    UserStatus = "ACTIVE"
 	UserStatusINACTIVE  UserStatus = "INACTIVE"
 	UserStatusSUSPENDED UserStatus = "SUSPENDED"
+	UserStatusLOCKED    UserStatus = "LOCKED"
 )
 
 // IsValid returns true if the UserStatus is valid
@@ -19,6 +20,8 @@ func (e UserStatus) IsValid() bool {
 		return true
 	case UserStatusSUSPENDED:
 		return true
+	case UserStatusLOCKED:
+		return true
 	}
 	return false
 }
@@ -63,3 +66,22 @@ func (e EventProcessingStatus) IsValid() bool {
 	}
 	return false
 }
+
+// ServiceAccountStatus represents the ServiceAccountStatus enum
+type ServiceAccountStatus string
+
+const (
+	ServiceAccountStatusACTIVE   ServiceAccountStatus = "ACTIVE"
+	ServiceAccountStatusDISABLED ServiceAccountStatus = "DISABLED"
+)
+
+// IsValid returns true if the ServiceAccountStatus is valid
+func (e ServiceAccountStatus) IsValid() bool {
+	switch e {
+	case ServiceAccountStatusACTIVE:
+		return true
+	case ServiceAccountStatusDISABLED:
+		return true
+	}
+	return false
+}
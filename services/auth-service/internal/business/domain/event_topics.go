@@ -3,13 +3,23 @@ package domain
 
 const (
 	// Producer Events
-	TopicAuthUserCreated       = "auth.user.created"
-	TopicAuthUserSuspended     = "auth.user.suspended"
-	TopicAuthUserRoleAssigned  = "auth.user.role.assigned"
-	TopicAuthUserRoleRevoked   = "auth.user.role.revoked"
-	TopicAuthUserStoreAssigned = "auth.user.store.assigned"
-	TopicAuthPasswordChanged   = "auth.password.changed"
-	TopicAuthSessionRevoked    = "auth.session.revoked"
+	TopicAuthUserCreated            = "auth.user.created"
+	TopicAuthUserSuspended          = "auth.user.suspended"
+	TopicAuthUserRoleAssigned       = "auth.user.role.assigned"
+	TopicAuthUserRoleRevoked        = "auth.user.role.revoked"
+	TopicAuthUserStoreAssigned      = "auth.user.store.assigned"
+	TopicAuthUserStoreRemoved       = "auth.user.store.removed"
+	TopicAuthPasswordChanged        = "auth.password.changed"
+	TopicAuthSessionRevoked         = "auth.session.revoked"
+	TopicAuthUserMfaEnabled         = "auth.user.mfa.enabled"
+	TopicAuthUserMfaReset           = "auth.user.mfa.reset"
+	TopicAuthUserLocked             = "auth.user.locked"
+	TopicAuthUserUnlocked           = "auth.user.unlocked"
+	TopicAuthLoginIpBlocked         = "auth.login.ip.blocked"
+	TopicAuthRolePermissionAssigned = "auth.role.permission.assigned"
+	TopicAuthRolePermissionRevoked  = "auth.role.permission.revoked"
+	TopicAuthServiceAccountDisabled = "auth.service_account.disabled"
+	TopicAuthApiKeyRevoked          = "auth.api_key.revoked"
 
 	// Consumer Events
 	TopicHrEmployeeCreated    = "hr.employee.created"
@@ -39,6 +39,60 @@ type PasswordChangedEventPayload struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+type SessionRevokedEventPayload struct {
+	SessionID string    `json:"session_id"`
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RolePermissionEventPayload announces a change to what a role grants. A
+// deleted role or permission is published as a revocation with the other ID
+// left empty.
+type RolePermissionEventPayload struct {
+	RoleID       string    `json:"role_id"`
+	PermissionID string    `json:"permission_id"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// MFAEventPayload announces that a user turned on a second factor or that an
+// administrator reset it.
+type MFAEventPayload struct {
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UserLockEventPayload is written to the outbox when a user is locked out
+// after repeated failed logins and when the lock is lifted. Reason is
+// "failed_logins" for a lock, "expired" or "admin" for an unlock.
+type UserLockEventPayload struct {
+	UserID         string     `json:"user_id"`
+	Username       string     `json:"username"`
+	Reason         string     `json:"reason"`
+	FailedAttempts int        `json:"failed_attempts,omitempty"`
+	IpAddress      string     `json:"ip_address,omitempty"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	Timestamp      time.Time  `json:"timestamp"`
+}
+
+// LoginIPBlockedEventPayload is written to the outbox when one address keeps
+// failing logins, possibly across many usernames.
+type LoginIPBlockedEventPayload struct {
+	IpAddress      string    `json:"ip_address"`
+	FailedAttempts int       `json:"failed_attempts"`
+	BlockedUntil   time.Time `json:"blocked_until"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// APIKeyEventPayload is written to the outbox when a key is revoked or its
+// service account disabled; KeyID is empty for the latter. Gateways drop the
+// account's cached verdicts on either.
+type APIKeyEventPayload struct {
+	ServiceAccountID string    `json:"service_account_id"`
+	KeyID            string    `json:"key_id,omitempty"`
+	LegalEntityID    string    `json:"legal_entity_id"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
 // HREmployeeTerminatedEvent is the cross-service payload published by HR when
 // an employee is terminated. Per the cross-service @reference convention
 // (see master PRD 2.10), EmployeeID is treated as the Auth User ID for
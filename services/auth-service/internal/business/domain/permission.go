@@ -8,7 +8,7 @@ import (
 type Permission struct {
 	ID            string    `json:"id"`
 	LegalEntityID string    `json:"legal_entity_id"`
-	Code          string    `json:"code"` // e.g., "invoices:write", "employees:read"
+	Code          string    `json:"code"` // e.g., "fm:invoices:write", "fm:*:read"; a trailing "@store" grants it per assigned store
 	Description   string    `json:"description"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
@@ -1,6 +1,10 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
@@ -25,6 +29,7 @@ type RoleRepository interface {
 	GetByID(ctx context.Context, id string) (*Role, error)
 	GetByName(ctx context.Context, name string) (*Role, error)
 	List(ctx context.Context) ([]Role, error)
+	Update(ctx context.Context, role *Role) error
 	Delete(ctx context.Context, id string) error
 }
 
@@ -53,3 +58,82 @@ type RolePermissionRepository interface {
 	ListByRoleID(ctx context.Context, roleID string) ([]RolePermission, error)
 	Delete(ctx context.Context, roleID string, permissionID string) error
 }
+
+type UserMFARepository interface {
+	Create(ctx context.Context, mfa *UserMFA) error
+	GetByUserID(ctx context.Context, userID string) (*UserMFA, error)
+	Update(ctx context.Context, mfa *UserMFA) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type LoginAttemptRepository interface {
+	GetByKey(ctx context.Context, key string) (*LoginAttempt, error)
+	// RecordFailure adds one failure to the counter under key in a single
+	// atomic write, creating it or restarting the window once window has
+	// passed since WindowStartedAt, and returns the counter as stored.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*LoginAttempt, error)
+	// Block refuses attempts under key until the given time. It leaves the
+	// failure count alone so concurrent failures are not lost.
+	Block(ctx context.Context, key string, until time.Time) error
+	DeleteByKey(ctx context.Context, key string) error
+}
+
+type TransactionalOutboxRepository interface {
+	Create(ctx context.Context, record *TransactionalOutbox) error
+	GetPending(ctx context.Context, limit int) ([]TransactionalOutbox, error)
+	UpdateStatus(ctx context.Context, id string, status OutboxStatus) error
+}
+
+type MFAChallengeRepository interface {
+	Create(ctx context.Context, challenge *MfaChallenge) error
+	GetByToken(ctx context.Context, token string) (*MfaChallenge, error)
+	// RecordAttempt adds one to Attempts in a single atomic write and returns
+	// the new count, so parallel codes against one challenge all count.
+	RecordAttempt(ctx context.Context, id string) (int, error)
+	// Delete fails once the challenge is gone, so of two parallel
+	// completions only one consumes it.
+	Delete(ctx context.Context, id string) error
+}
+
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *AuthorizationCode) error
+	GetByCode(ctx context.Context, code string) (*AuthorizationCode, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type ServiceAccountRepository interface {
+	Create(ctx context.Context, account *ServiceAccount) error
+	GetByID(ctx context.Context, id string) (*ServiceAccount, error)
+	GetByName(ctx context.Context, name string) (*ServiceAccount, error)
+	List(ctx context.Context) ([]ServiceAccount, error)
+	Update(ctx context.Context, account *ServiceAccount) error
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *ApiKey) error
+	GetByID(ctx context.Context, id string) (*ApiKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*ApiKey, error)
+	ListByServiceAccountID(ctx context.Context, accountID string) ([]ApiKey, error)
+	Update(ctx context.Context, key *ApiKey) error
+}
+
+// TransactionManager runs fn so that every repository write made through
+// txCtx is committed together or not at all
+type TransactionManager interface {
+	WithinTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
+
+// ErrSigningKeyRotated is returned by SigningKeyRepository.Rotate when the
+// key it was asked to retire is no longer the current one
+var ErrSigningKeyRotated = errors.New("signing key was already rotated")
+
+// SigningKeyRepository is the key store shared by all auth-service replicas
+type SigningKeyRepository interface {
+	// List returns the stored keys, oldest first
+	List(ctx context.Context) ([]SigningKey, error)
+	// Rotate retires the current key currentID and stores next in its place,
+	// atomically. An empty currentID stores the first key. It fails with
+	// ErrSigningKeyRotated when another replica rotated first.
+	Rotate(ctx context.Context, currentID string, next *SigningKey) error
+	DeleteRetiredBefore(ctx context.Context, cutoff time.Time) error
+}
@@ -10,7 +10,8 @@ type Role struct {
 	LegalEntityID string    `json:"legal_entity_id"` // Roles are scoped per tenant
 	Name          string    `json:"name"`            // e.g., "ADMIN", "MANAGER", "VIEWER"
 	Description   string    `json:"description"`
-	Version       int       `json:"version"` // OCC Shield
+	MfaRequired   bool      `json:"mfa_required"` // Members must pass a second factor to get tokens
+	Version       int       `json:"version"`      // OCC Shield
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
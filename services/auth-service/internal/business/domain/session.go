@@ -12,6 +12,7 @@ type Session struct {
 	IpAddress    *string   `json:"ip_address,omitempty"` // Audit trail for security forensics
 	UserAgent    *string   `json:"user_agent,omitempty"` // Device fingerprint for anomaly detection
 	IsRevoked    bool      `json:"is_revoked"`           // Explicitly revoked sessions (logout / admin force)
+	MfaVerified  bool      `json:"mfa_verified"`         // Login passed a second factor — carried across refreshes
 	ExpiresAt    time.Time `json:"expires_at"`           // Hard expiry — cron purges stale sessions
 	CreatedAt    time.Time `json:"created_at"`
 }
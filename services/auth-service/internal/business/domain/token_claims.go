@@ -7,9 +7,13 @@ import (
 
 // TokenClaims represents the event payload for TokenClaims
 type TokenClaims struct {
+	Iss           string    `json:"iss"`
+	Sub           string    `json:"sub"`
 	UserID        string    `json:"user_id"`
 	LegalEntityID string    `json:"legal_entity_id"`
 	Roles         []string  `json:"roles"`
 	Permissions   []string  `json:"permissions"`
+	ClientID      *string   `json:"client_id,omitempty"`
+	ApiKeyID      *string   `json:"api_key_id,omitempty"`
 	ExpiresAt     time.Time `json:"expires_at"`
 }
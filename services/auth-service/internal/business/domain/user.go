@@ -13,9 +13,10 @@ type User struct {
 	PasswordHash  string     `json:"password_hash"`   // bcrypt hash — never returned in API responses
 	FirstName     string     `json:"first_name"`
 	LastName      string     `json:"last_name"`
-	Status        UserStatus `json:"status"`         // ACTIVE / INACTIVE / SUSPENDED
-	SecurityStamp string     `json:"security_stamp"` // Rotated on every password change — invalidates all sessions
-	Version       int        `json:"version"`        // Concurrency Control Shield (Optimistic Locking)
+	Status        UserStatus `json:"status"`                 // ACTIVE / INACTIVE / SUSPENDED / LOCKED
+	LockedUntil   *time.Time `json:"locked_until,omitempty"` // End of a failed-login lockout; cleared on unlock
+	SecurityStamp string     `json:"security_stamp"`         // Rotated on every password change — invalidates all sessions
+	Version       int        `json:"version"`                // Concurrency Control Shield (Optimistic Locking)
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	DeletedAt     *time.Time `json:"deleted_at,omitempty"` // GORM Native Soft Delete Support
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"erp-system/shared/utils"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,8 +18,10 @@ import (
 //
 //	{ user_id, tenant_id, roles }
 //
-// Plus internal-only fields (Username, Email, Permissions, SecurityStamp)
-// used by ValidateToken and downstream consumers.
+// Plus internal-only fields (Username, Email, Permissions, SecurityStamp,
+// SessionID, AMR) used by ValidateToken and downstream consumers. Tokens
+// issued to a service account carry ClientID and APIKeyID instead of a
+// session; UserID is then the account ID.
 type TokenClaims struct {
 	UserID        string   `json:"user_id"`
 	TenantID      string   `json:"tenant_id"`
@@ -27,6 +30,10 @@ type TokenClaims struct {
 	Email         string   `json:"email"`
 	Permissions   []string `json:"permissions"`
 	SecurityStamp string   `json:"security_stamp"`
+	SessionID     string   `json:"sid,omitempty"`
+	AMR           []string `json:"amr,omitempty"` // RFC 8176 methods: "pwd", plus "mfa" after a second factor
+	ClientID      string   `json:"client_id,omitempty"`
+	APIKeyID      string   `json:"api_key_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -37,74 +44,174 @@ type TokenClaims struct {
 // Deprecated: use TokenClaims.
 type JWTClaims = TokenClaims
 
+// ErrMFARequired is returned by token issuance when one of the user's roles
+// requires MFA and the login did not pass a second factor.
+var ErrMFARequired = errors.New("multi-factor authentication required by role policy")
+
 type AuthService struct {
-	userRepo  domain.UserRepository
-	sessRepo  domain.SessionRepository
-	rbacSvc   *RBACService
-	publisher domain.EventPublisher
-	cfg       *config.Config
+	userRepo      domain.UserRepository
+	sessRepo      domain.SessionRepository
+	mfaRepo       domain.UserMFARepository
+	challengeRepo domain.MFAChallengeRepository
+	attemptRepo   domain.LoginAttemptRepository
+	outboxRepo    domain.TransactionalOutboxRepository
+	tm            domain.TransactionManager
+	accountRepo   domain.ServiceAccountRepository
+	keyRepo       domain.APIKeyRepository
+	rbacSvc       *RBACService
+	publisher     domain.EventPublisher
+	cfg           *config.Config
+	keys          *KeyManager
+}
+
+// AuthServiceDeps lists the repositories and collaborators AuthService needs.
+// Every field is required.
+type AuthServiceDeps struct {
+	Users           domain.UserRepository
+	Sessions        domain.SessionRepository
+	MFA             domain.UserMFARepository
+	MFAChallenges   domain.MFAChallengeRepository
+	LoginAttempts   domain.LoginAttemptRepository
+	Outbox          domain.TransactionalOutboxRepository
+	TM              domain.TransactionManager
+	ServiceAccounts domain.ServiceAccountRepository
+	APIKeys         domain.APIKeyRepository
+	RBAC            *RBACService
+	Publisher       domain.EventPublisher
+	Config          *config.Config
+	Keys            *KeyManager
 }
 
-func NewAuthService(
-	userRepo domain.UserRepository,
-	sessRepo domain.SessionRepository,
-	rbacSvc *RBACService,
-	publisher domain.EventPublisher,
-	cfg *config.Config,
-) *AuthService {
+func NewAuthService(deps AuthServiceDeps) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		sessRepo:  sessRepo,
-		rbacSvc:   rbacSvc,
-		publisher: publisher,
-		cfg:       cfg,
+		userRepo:      deps.Users,
+		sessRepo:      deps.Sessions,
+		mfaRepo:       deps.MFA,
+		challengeRepo: deps.MFAChallenges,
+		attemptRepo:   deps.LoginAttempts,
+		outboxRepo:    deps.Outbox,
+		tm:            deps.TM,
+		accountRepo:   deps.ServiceAccounts,
+		keyRepo:       deps.APIKeys,
+		rbacSvc:       deps.RBAC,
+		publisher:     deps.Publisher,
+		cfg:           deps.Config,
+		keys:          deps.Keys,
 	}
 }
 
+// AuthenticateUser checks the password and issues tokens. When the user has
+// MFA enabled, or a role requires it, no tokens are issued; the returned error
+// is an *MFAChallengeRequired carrying the token for VerifyMFA. Repeated
+// failures for a username or address return a *LoginBlockedError until the
+// delay or lockout has passed.
 func (s *AuthService) AuthenticateUser(ctx context.Context, username, password, ipAddress, userAgent string) (string, string, error) {
+	user, err := s.checkPassword(ctx, username, password, ipAddress, userAgent)
+	if err != nil {
+		return "", "", err
+	}
+	return s.generateTokens(ctx, user, ipAddress, userAgent, false)
+}
+
+// checkPassword is the first login step, shared by AuthenticateUser and the
+// OIDC authorization endpoint. It returns the user only when the password
+// alone is enough; otherwise it issues the MFA challenge and returns it.
+func (s *AuthService) checkPassword(ctx context.Context, username, password, ipAddress, userAgent string) (*domain.User, error) {
+	now := time.Now()
+	if err := s.checkLoginAllowed(ctx, username, ipAddress, now); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid credentials")
+		s.recordLoginFailure(ctx, nil, username, ipAddress, now)
+		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	if user.Status == domain.UserStatusLOCKED && user.LockedUntil != nil && !now.Before(*user.LockedUntil) {
+		if err := s.releaseExpiredLock(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	if user.Status == domain.UserStatusLOCKED {
+		return nil, &LoginBlockedError{Reason: reasonAccountLocked, RetryAfter: lockRemaining(user, now)}
+	}
 	if user.Status != domain.UserStatusACTIVE {
-		return "", "", fmt.Errorf("user account is deactivated")
+		return nil, fmt.Errorf("user account is deactivated")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", "", fmt.Errorf("invalid credentials")
+		s.recordLoginFailure(ctx, user, username, ipAddress, now)
+		return nil, fmt.Errorf("invalid credentials")
 	}
 
-	return s.generateTokens(ctx, user, ipAddress, userAgent)
+	// With a second factor pending the failure count stays until it is
+	// passed, so a right password does not buy a fresh round of code guesses
+	if mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID); err == nil && mfa.IsEnabled {
+		return nil, s.issueMFAChallenge(ctx, user, false, ipAddress, userAgent)
+	}
+	required, err := s.rbacSvc.RequiresMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		// The role policy applies but the user never enrolled: the challenge
+		// only lets them enroll and then complete the login.
+		return nil, s.issueMFAChallenge(ctx, user, true, ipAddress, userAgent)
+	}
+	_ = s.attemptRepo.DeleteByKey(ctx, usernameAttemptKey(username))
+	return user, nil
 }
 
-func (s *AuthService) generateTokens(ctx context.Context, user *domain.User, ipAddress, userAgent string) (string, string, error) {
+// generateTokens is the single place tokens are minted, so the per-role MFA
+// policy is enforced here for logins and refreshes alike.
+func (s *AuthService) generateTokens(ctx context.Context, user *domain.User, ipAddress, userAgent string, mfaVerified bool) (string, string, error) {
 	// Resolve Roles and Permissions via RBACService
 	roles, permissions, err := s.rbacSvc.GetUserRolesAndPermissions(ctx, user.ID)
 	if err != nil {
 		return "", "", err
 	}
 
+	amr := []string{"pwd"}
+	if mfaVerified {
+		amr = append(amr, "mfa")
+	} else {
+		required, err := s.rbacSvc.RequiresMFA(ctx, user.ID)
+		if err != nil {
+			return "", "", err
+		}
+		if required {
+			return "", "", ErrMFARequired
+		}
+	}
+
+	// The session is created up front so the access token can carry its ID;
+	// revoking the session then invalidates the access token as well.
+	sessionID := utils.NewID("sess")
+
 	// Generate Access Token (JWT) — embeds the user's current security_stamp
 	// so that any subsequent deactivation / password change / role change can
 	// be detected by ValidateToken simply by reloading the user.
 	claims := TokenClaims{
 		UserID:        user.ID,
-		TenantID:      "default", // single-tenant MVP; multi-tenant deferred
+		TenantID:      user.LegalEntityID, // the gateway's legal entity when the client names none
 		Roles:         roles,
 		Username:      user.Username,
 		Email:         user.Email,
 		Permissions:   permissions,
 		SecurityStamp: user.SecurityStamp,
+		SessionID:     sessionID,
+		AMR:           amr,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.cfg.OIDC.Issuer,
+			Subject:   user.ID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.cfg.JWT.AccessExpiry) * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessToken, err := token.SignedString([]byte(s.cfg.JWT.Secret))
+	accessToken, err := s.keys.signAccessToken(claims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign access token: %w", err)
 	}
@@ -112,11 +219,12 @@ func (s *AuthService) generateTokens(ctx context.Context, user *domain.User, ipA
 	// Generate Refresh Token
 	refreshToken := fmt.Sprintf("rt_%s_%s", utils.NewID("rt"), user.ID)
 	session := &domain.Session{
-		ID:           utils.NewID("sess"),
+		ID:           sessionID,
 		UserID:       user.ID,
 		RefreshToken: refreshToken,
 		IpAddress:    &ipAddress,
 		UserAgent:    &userAgent,
+		MfaVerified:  mfaVerified,
 		ExpiresAt:    time.Now().Add(time.Duration(s.cfg.JWT.RefreshExpiry) * time.Hour),
 		CreatedAt:    time.Now(),
 	}
@@ -135,16 +243,31 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (st
 		return "", "", fmt.Errorf("session expired or invalid")
 	}
 
+	// A revoked session is kept for the audit trail; its refresh token must
+	// not mint a new one.
+	if session.IsRevoked {
+		return "", "", fmt.Errorf("session revoked")
+	}
+
 	if session.ExpiresAt.Before(time.Now()) {
 		_ = s.sessRepo.Delete(ctx, session.ID)
 		return "", "", fmt.Errorf("session expired")
 	}
 
 	user, err := s.userRepo.GetByID(ctx, session.UserID)
-	if err != nil || user.Status != domain.UserStatusACTIVE {
+	if err != nil || !canUseSessions(user.Status) {
 		return "", "", fmt.Errorf("user account inactive or invalid")
 	}
 
+	// A session that passed MFA outlives an admin reset of that MFA only
+	// until its next refresh.
+	if session.MfaVerified {
+		if mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID); err != nil || !mfa.IsEnabled {
+			_ = s.sessRepo.Delete(ctx, session.ID)
+			return "", "", fmt.Errorf("mfa was reset, please sign in again")
+		}
+	}
+
 	// Delete old session
 	_ = s.sessRepo.Delete(ctx, session.ID)
 
@@ -156,7 +279,7 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (st
 		ua = *session.UserAgent
 	}
 
-	return s.generateTokens(ctx, user, ip, ua)
+	return s.generateTokens(ctx, user, ip, ua, session.MfaVerified)
 }
 
 func (s *AuthService) RevokeToken(ctx context.Context, sessionID string) error {
@@ -168,16 +291,23 @@ func (s *AuthService) RevokeToken(ctx context.Context, sessionID string) error {
 		return err
 	}
 	session.IsRevoked = true
-	return s.sessRepo.Update(ctx, session)
-}
 
-func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	// Gateways cache validated tokens briefly; the event lets them drop the
+	// session's token right away instead of waiting for the cache to expire.
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.sessRepo.Update(txCtx, session); err != nil {
+			return err
 		}
-		return []byte(s.cfg.JWT.Secret), nil
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthSessionRevoked, session.ID, domain.SessionRevokedEventPayload{
+			SessionID: session.ID,
+			UserID:    session.UserID,
+			Timestamp: time.Now(),
+		})
 	})
+}
+
+func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &TokenClaims{}, s.keys.keyfunc, jwt.WithValidMethods(s.keys.methods()))
 
 	if err != nil {
 		return nil, fmt.Errorf("token invalid: %w", err)
@@ -187,13 +317,23 @@ func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*Toke
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("token invalid")
 	}
+	if typ, _ := token.Header["typ"].(string); !isAccessTokenType(typ) {
+		return nil, fmt.Errorf("token invalid: not an access token")
+	}
+
+	if claims.ClientID != "" {
+		if err := s.validateServiceToken(ctx, claims); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
 
 	// Reject tokens for deactivated users.
 	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("token invalid: user no longer exists")
 	}
-	if user.Status != domain.UserStatusACTIVE {
+	if !canUseSessions(user.Status) {
 		return nil, fmt.Errorf("token invalid: user account is deactivated")
 	}
 
@@ -203,6 +343,41 @@ func (s *AuthService) ValidateToken(ctx context.Context, tokenStr string) (*Toke
 		return nil, fmt.Errorf("token invalid: security stamp mismatch (user state changed)")
 	}
 
+	// Reject tokens whose session was logged out or revoked. Tokens minted
+	// before sessions were embedded carry no sid and rely on the stamp alone.
+	if claims.SessionID != "" {
+		session, err := s.sessRepo.GetByID(ctx, claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("token invalid: session no longer exists")
+		}
+		if session.IsRevoked {
+			return nil, fmt.Errorf("token invalid: session revoked")
+		}
+	}
+
+	return claims, nil
+}
+
+// Introspect validates a token like ValidateToken and replaces the roles and
+// permissions captured at login with the user's current ones, so RBAC changes
+// apply to tokens that are already in circulation.
+func (s *AuthService) Introspect(ctx context.Context, tokenStr string) (*TokenClaims, error) {
+	claims, err := s.ValidateToken(ctx, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles, permissions []string
+	if claims.ClientID != "" {
+		roles, permissions, err = s.currentServicePermissions(ctx, claims)
+	} else {
+		roles, permissions, err = s.rbacSvc.GetUserRolesAndPermissions(ctx, claims.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	claims.Roles = roles
+	claims.Permissions = permissions
 	return claims, nil
 }
 
@@ -91,10 +91,10 @@ func TestAuthService_AuthenticateUser_Success(t *testing.T) {
 	usRepo := memory.NewUserStoreRepository()
 
 	pub := &dummyPublisher{}
-	rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+	rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, usRepo, rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 	cfg := newTestConfig()
-	authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, cfg)
-	userSvc := NewUserService(userRepo, usRepo, urRepo, pub)
+	authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, cfg))
+	userSvc := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 	ctx := context.Background()
 
@@ -143,8 +143,8 @@ func TestAuthService_AuthenticateUser_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		_, _, err := authSvc.AuthenticateUser(ctx, "nonexistent", "pw", "ip", "ua")
 		if err == nil || err.Error() != "invalid credentials" {
@@ -160,8 +160,8 @@ func TestAuthService_AuthenticateUser_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		u := &domain.User{
 			ID:           "u_1",
@@ -185,8 +185,8 @@ func TestAuthService_AuthenticateUser_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		pwdBytes, _ := bcrypt.GenerateFromPassword([]byte("correct"), bcrypt.DefaultCost)
 		u := &domain.User{
@@ -214,8 +214,8 @@ func TestAuthService_AuthenticateUser_Failures(t *testing.T) {
 		}
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		pwdBytes, _ := bcrypt.GenerateFromPassword([]byte("correct"), bcrypt.DefaultCost)
 		u := &domain.User{
@@ -243,8 +243,8 @@ func TestAuthService_AuthenticateUser_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		pwdBytes, _ := bcrypt.GenerateFromPassword([]byte("correct"), bcrypt.DefaultCost)
 		u := &domain.User{
@@ -273,8 +273,8 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		u := &domain.User{
 			ID:     "u_1",
@@ -317,8 +317,8 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		_, _, err := authSvc.RefreshToken(ctx, "nonexistent")
 		if err == nil || err.Error() != "session expired or invalid" {
@@ -334,8 +334,8 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		sess := &domain.Session{
 			ID:           "sess_1",
@@ -357,6 +357,46 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		}
 	})
 
+	t.Run("RevokedSession", func(t *testing.T) {
+		userRepo := memory.NewUserRepository()
+		sessRepo := memory.NewSessionRepository()
+		roleRepo := memory.NewRoleRepository()
+		permRepo := memory.NewPermissionRepository()
+		urRepo := memory.NewUserRoleRepository()
+		rpRepo := memory.NewRolePermissionRepository()
+		pub := &dummyPublisher{}
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
+
+		u := &domain.User{
+			ID:     "u_1",
+			Status: domain.UserStatusACTIVE,
+		}
+		_ = userRepo.Create(ctx, u)
+
+		sess := &domain.Session{
+			ID:           "sess_1",
+			UserID:       "u_1",
+			RefreshToken: "rt_revoked",
+			ExpiresAt:    time.Now().Add(1 * time.Hour),
+		}
+		_ = sessRepo.Create(ctx, sess)
+		if err := authSvc.RevokeToken(ctx, "sess_1"); err != nil {
+			t.Fatalf("failed to revoke session: %v", err)
+		}
+
+		_, _, err := authSvc.RefreshToken(ctx, "rt_revoked")
+		if err == nil || err.Error() != "session revoked" {
+			t.Errorf("expected 'session revoked', got %v", err)
+		}
+
+		// The revoked session stays on record
+		stored, err := sessRepo.GetByID(ctx, "sess_1")
+		if err != nil || !stored.IsRevoked {
+			t.Errorf("expected the revoked session to be kept, got %+v (%v)", stored, err)
+		}
+	})
+
 	t.Run("UserInactiveOrInvalid", func(t *testing.T) {
 		userRepo := memory.NewUserRepository()
 		sessRepo := memory.NewSessionRepository()
@@ -365,8 +405,8 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		// Case 1: User does not exist
 		sess1 := &domain.Session{
@@ -413,8 +453,8 @@ func TestAuthService_RevokeToken(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		sess := &domain.Session{
 			ID:           "sess_1",
@@ -443,8 +483,8 @@ func TestAuthService_RevokeToken(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 		err := authSvc.RevokeToken(ctx, "nonexistent")
 		if err == nil {
@@ -465,8 +505,8 @@ func TestAuthService_ValidateToken_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, cfg)
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, cfg))
 
 		// Create a token with 'none' signing method
 		token := jwt.NewWithClaims(jwt.SigningMethodNone, TokenClaims{
@@ -494,8 +534,8 @@ func TestAuthService_ValidateToken_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, cfg)
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, cfg))
 
 		_, err := authSvc.ValidateToken(ctx, "not-a-token")
 		if err == nil {
@@ -514,8 +554,8 @@ func TestAuthService_ValidateToken_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, cfg)
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, cfg))
 
 		claims := TokenClaims{
 			UserID: "u_1",
@@ -523,8 +563,7 @@ func TestAuthService_ValidateToken_Failures(t *testing.T) {
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
 			},
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenStr, _ := token.SignedString([]byte(cfg.JWT.Secret))
+		tokenStr, _ := authSvc.keys.signAccessToken(claims)
 
 		_, err := authSvc.ValidateToken(ctx, tokenStr)
 		if err == nil || err.Error() != "token invalid: user no longer exists" {
@@ -540,8 +579,8 @@ func TestAuthService_ValidateToken_Failures(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
 		pub := &dummyPublisher{}
-		rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-		authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, cfg)
+		rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+		authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, cfg))
 
 		u := &domain.User{
 			ID:     "u_deactivated",
@@ -555,8 +594,7 @@ func TestAuthService_ValidateToken_Failures(t *testing.T) {
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
 			},
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenStr, _ := token.SignedString([]byte(cfg.JWT.Secret))
+		tokenStr, _ := authSvc.keys.signAccessToken(claims)
 
 		_, err := authSvc.ValidateToken(ctx, tokenStr)
 		if err == nil || err.Error() != "token invalid: user account is deactivated" {
@@ -573,8 +611,8 @@ func TestAuthService_GetSessionByRefreshToken(t *testing.T) {
 	urRepo := memory.NewUserRoleRepository()
 	rpRepo := memory.NewRolePermissionRepository()
 	pub := &dummyPublisher{}
-	rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-	authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+	rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+	authSvc := NewAuthService(newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig()))
 
 	ctx := context.Background()
 	sess := &domain.Session{
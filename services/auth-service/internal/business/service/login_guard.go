@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/config"
+)
+
+// LoginBlockedError is returned by AuthenticateUser while a username or a
+// client address is held back by a progressive delay or a lockout. The
+// password is not checked while blocked.
+type LoginBlockedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Reason
+}
+
+const (
+	reasonLoginDelayed = "too many failed login attempts, try again later"
+	// Unknown usernames are blocked with the same message as real accounts so
+	// the response does not reveal which usernames exist.
+	reasonAccountLocked = "account temporarily locked after repeated failed logins"
+	reasonAddressLocked = "too many failed login attempts from this address"
+)
+
+func usernameAttemptKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (s *AuthService) lockoutPolicy() config.LockoutConfig {
+	return s.cfg.Lockout.WithDefaults()
+}
+
+// checkLoginAllowed refuses the attempt while the username or the address is
+// blocked
+func (s *AuthService) checkLoginAllowed(ctx context.Context, username, ipAddress string, now time.Time) error {
+	policy := s.lockoutPolicy()
+
+	if a, err := s.attemptRepo.GetByKey(ctx, usernameAttemptKey(username)); err == nil && a.BlockedUntil != nil && now.Before(*a.BlockedUntil) {
+		reason := reasonLoginDelayed
+		if a.FailureCount >= policy.MaxFailures {
+			reason = reasonAccountLocked
+		}
+		return &LoginBlockedError{Reason: reason, RetryAfter: a.BlockedUntil.Sub(now)}
+	}
+
+	if ipAddress != "" && !policy.IPBlockingDisabled {
+		if a, err := s.attemptRepo.GetByKey(ctx, ipAttemptKey(ipAddress)); err == nil && a.BlockedUntil != nil && now.Before(*a.BlockedUntil) {
+			return &LoginBlockedError{Reason: reasonAddressLocked, RetryAfter: a.BlockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// countFailure adds a failure to the counter under key. The repository
+// increments in place, so concurrent failures from parallel logins all count.
+func (s *AuthService) countFailure(ctx context.Context, key string, now time.Time, window time.Duration) *domain.LoginAttempt {
+	a, err := s.attemptRepo.RecordFailure(ctx, key, now, window)
+	if err != nil {
+		log.Printf("[LoginGuard] Failed to count login failure for %s: %v", key, err)
+		return nil
+	}
+	return a
+}
+
+// progressiveDelay doubles BaseDelay for every failure past DelayAfter
+func progressiveDelay(policy config.LockoutConfig, failures int) time.Duration {
+	delay := policy.BaseDelay
+	for i := policy.DelayAfter; i < failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
+
+// recordLoginFailure counts a failed password check against the username
+// and the client address. user is nil when the username does not exist.
+func (s *AuthService) recordLoginFailure(ctx context.Context, user *domain.User, username, ipAddress string, now time.Time) {
+	policy := s.lockoutPolicy()
+
+	if ua := s.countFailure(ctx, usernameAttemptKey(username), now, policy.FailureWindow); ua != nil {
+		switch {
+		case ua.FailureCount >= policy.MaxFailures:
+			until := now.Add(policy.LockoutDuration)
+			s.blockAttempts(ctx, ua.Key, until)
+			if user != nil && user.Status == domain.UserStatusACTIVE {
+				s.lockUser(ctx, user, ua.FailureCount, ipAddress, until)
+			}
+		case ua.FailureCount >= policy.DelayAfter:
+			s.blockAttempts(ctx, ua.Key, now.Add(progressiveDelay(policy, ua.FailureCount)))
+		}
+	}
+
+	if ipAddress == "" || policy.IPBlockingDisabled {
+		return
+	}
+	// No progressive delay per address: many users may share one behind NAT
+	ia := s.countFailure(ctx, ipAttemptKey(ipAddress), now, policy.FailureWindow)
+	if ia == nil || ia.FailureCount < policy.IPMaxFailures {
+		return
+	}
+	until := now.Add(policy.LockoutDuration)
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.attemptRepo.Block(txCtx, ia.Key, until); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthLoginIpBlocked, ipAddress, domain.LoginIPBlockedEventPayload{
+			IpAddress:      ipAddress,
+			FailedAttempts: ia.FailureCount,
+			BlockedUntil:   until,
+			Timestamp:      now,
+		})
+	})
+	if err != nil {
+		log.Printf("[LoginGuard] Failed to block address %s: %v", ipAddress, err)
+	}
+}
+
+func (s *AuthService) blockAttempts(ctx context.Context, key string, until time.Time) {
+	if err := s.attemptRepo.Block(ctx, key, until); err != nil {
+		log.Printf("[LoginGuard] Failed to block login attempts for %s: %v", key, err)
+	}
+}
+
+// lockUser puts the account in LOCKED until the lockout ends. Existing
+// sessions keep working; otherwise anyone could sign a user out by failing
+// logins under their name.
+func (s *AuthService) lockUser(ctx context.Context, user *domain.User, failures int, ipAddress string, until time.Time) {
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		user.Status = domain.UserStatusLOCKED
+		user.LockedUntil = &until
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserLocked, user.ID, domain.UserLockEventPayload{
+			UserID:         user.ID,
+			Username:       user.Username,
+			Reason:         "failed_logins",
+			FailedAttempts: failures,
+			IpAddress:      ipAddress,
+			LockedUntil:    &until,
+			Timestamp:      time.Now(),
+		})
+	})
+	if err != nil {
+		log.Printf("[LoginGuard] Failed to lock user %s: %v", user.ID, err)
+	}
+}
+
+// canUseSessions reports whether tokens and refresh tokens stay valid for a
+// user in this status. A lockout only stops new password logins.
+func canUseSessions(status domain.UserStatus) bool {
+	return status == domain.UserStatusACTIVE || status == domain.UserStatusLOCKED
+}
+
+func lockRemaining(user *domain.User, now time.Time) time.Duration {
+	if user.LockedUntil == nil {
+		return 0
+	}
+	return user.LockedUntil.Sub(now)
+}
+
+// releaseExpiredLock returns a LOCKED user whose lockout has ended to ACTIVE
+func (s *AuthService) releaseExpiredLock(ctx context.Context, user *domain.User) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		user.Status = domain.UserStatusACTIVE
+		user.LockedUntil = nil
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserUnlocked, user.ID, domain.UserLockEventPayload{
+			UserID:    user.ID,
+			Username:  user.Username,
+			Reason:    "expired",
+			Timestamp: time.Now(),
+		})
+	})
+}
+
+// writeOutbox records an event for the OutboxRelayWorker to publish
+func writeOutbox(ctx context.Context, repo domain.TransactionalOutboxRepository, topic, aggregateID string, payload interface{}) error {
+	return repo.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: aggregateID,
+		Payload:     payload,
+		Status:      domain.OutboxStatusPENDING,
+		CreatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/erp-system/auth-service/internal/data/memory"
+)
+
+func lockoutTestConfig(lockout config.LockoutConfig) *config.Config {
+	cfg := newTestConfig()
+	cfg.Lockout = lockout
+	return cfg
+}
+
+func blockedFrom(t *testing.T, err error) *LoginBlockedError {
+	t.Helper()
+	var blocked *LoginBlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected a blocked login, got %v", err)
+	}
+	return blocked
+}
+
+func pendingTopics(t *testing.T, env *mfaTestEnv) []string {
+	t.Helper()
+	records, err := env.outboxRepo.GetPending(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+	var topics []string
+	for _, r := range records {
+		topics = append(topics, r.EventType)
+	}
+	return topics
+}
+
+func TestLoginGuard_ProgressiveDelay(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures: 10,
+		DelayAfter:  2,
+		BaseDelay:   time.Second,
+		MaxDelay:    4 * time.Second,
+	}))
+	ctx := context.Background()
+	env.createUser(t, "kate")
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := env.authSvc.AuthenticateUser(ctx, "kate", "wrong", "10.0.0.1", ""); err == nil || errors.As(err, new(*LoginBlockedError)) {
+			t.Fatalf("attempt %d: expected invalid credentials, got %v", i+1, err)
+		}
+	}
+
+	// Even the right password waits out the delay
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "kate", "password-123", "10.0.0.1", "")
+	blocked := blockedFrom(t, err)
+	if blocked.RetryAfter <= 0 || blocked.RetryAfter > time.Second {
+		t.Errorf("retry after = %v, want up to 1s", blocked.RetryAfter)
+	}
+
+	if got := progressiveDelay(env.authSvc.lockoutPolicy(), 4); got != 4*time.Second {
+		t.Errorf("delay after 4 failures = %v, want 4s", got)
+	}
+	if got := progressiveDelay(env.authSvc.lockoutPolicy(), 9); got != 4*time.Second {
+		t.Errorf("delay should be capped at MaxDelay, got %v", got)
+	}
+}
+
+func TestLoginGuard_LocksAccountAndExpires(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures: 3,
+		DelayAfter:  3,
+	}))
+	ctx := context.Background()
+	user := env.createUser(t, "liam")
+
+	_, refresh, err := env.authSvc.AuthenticateUser(ctx, "liam", "password-123", "", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _, _ = env.authSvc.AuthenticateUser(ctx, "liam", "wrong", "10.0.0.2", "")
+	}
+
+	locked, _ := env.userRepo.GetByID(ctx, user.ID)
+	if locked.Status != domain.UserStatusLOCKED || locked.LockedUntil == nil {
+		t.Fatalf("expected LOCKED with an expiry, got %s", locked.Status)
+	}
+	_, _, err = env.authSvc.AuthenticateUser(ctx, "liam", "password-123", "", "")
+	if blocked := blockedFrom(t, err); blocked.Reason != reasonAccountLocked {
+		t.Errorf("reason = %q", blocked.Reason)
+	}
+	if topics := pendingTopics(t, env); len(topics) != 1 || topics[0] != domain.TopicAuthUserLocked {
+		t.Errorf("expected a locked event in the outbox, got %v", topics)
+	}
+
+	// Sessions from before the lockout are untouched
+	if _, _, err := env.authSvc.RefreshToken(ctx, refresh); err != nil {
+		t.Errorf("expected refresh to work while locked, got %v", err)
+	}
+
+	// Once the lockout has passed the next login releases the account
+	past := time.Now().Add(-time.Second)
+	locked.LockedUntil = &past
+	_ = env.userRepo.Update(ctx, locked)
+	_ = env.attemptRepo.Block(ctx, usernameAttemptKey("liam"), past)
+
+	if _, _, err := env.authSvc.AuthenticateUser(ctx, "liam", "password-123", "", ""); err != nil {
+		t.Fatalf("expected login after lockout, got %v", err)
+	}
+	if topics := pendingTopics(t, env); len(topics) != 2 || topics[1] != domain.TopicAuthUserUnlocked {
+		t.Errorf("expected an unlocked event in the outbox, got %v", topics)
+	}
+}
+
+func TestLoginGuard_UnknownUsernameLooksLocked(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures: 2,
+		DelayAfter:  2,
+	}))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, _, _ = env.authSvc.AuthenticateUser(ctx, "nobody", "wrong", "", "")
+	}
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "nobody", "wrong", "", "")
+	if blocked := blockedFrom(t, err); blocked.Reason != reasonAccountLocked {
+		t.Errorf("reason = %q", blocked.Reason)
+	}
+	if topics := pendingTopics(t, env); len(topics) != 0 {
+		t.Errorf("expected no events for an unknown user, got %v", topics)
+	}
+}
+
+func TestLoginGuard_BlocksAddressAcrossUsernames(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures:   10,
+		DelayAfter:    10,
+		IPMaxFailures: 3,
+	}))
+	ctx := context.Background()
+	env.createUser(t, "mia")
+
+	for _, name := range []string{"a", "b", "c"} {
+		_, _, _ = env.authSvc.AuthenticateUser(ctx, name, "wrong", "10.0.0.3", "")
+	}
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "mia", "password-123", "10.0.0.3", "")
+	if blocked := blockedFrom(t, err); blocked.Reason != reasonAddressLocked {
+		t.Errorf("reason = %q", blocked.Reason)
+	}
+	if _, _, err := env.authSvc.AuthenticateUser(ctx, "mia", "password-123", "10.0.0.4", ""); err != nil {
+		t.Errorf("expected other addresses to log in, got %v", err)
+	}
+	if topics := pendingTopics(t, env); len(topics) != 1 || topics[0] != domain.TopicAuthLoginIpBlocked {
+		t.Errorf("expected an ip blocked event in the outbox, got %v", topics)
+	}
+}
+
+// Without a trusted proxy every login carries the proxy's address, so
+// blocking it would lock everyone out
+func TestLoginGuard_IPBlockingDisabled(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures:        10,
+		DelayAfter:         10,
+		IPMaxFailures:      3,
+		IPBlockingDisabled: true,
+	}))
+	ctx := context.Background()
+	env.createUser(t, "mia")
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		_, _, _ = env.authSvc.AuthenticateUser(ctx, name, "wrong", "172.28.0.10", "")
+	}
+	if _, _, err := env.authSvc.AuthenticateUser(ctx, "mia", "password-123", "172.28.0.10", ""); err != nil {
+		t.Errorf("expected the proxy's address not to be blocked, got %v", err)
+	}
+	if topics := pendingTopics(t, env); len(topics) != 0 {
+		t.Errorf("expected no ip blocked event, got %v", topics)
+	}
+}
+
+func TestUser_UnlockUser(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures: 2,
+		DelayAfter:  2,
+	}))
+	ctx := context.Background()
+	user := env.createUser(t, "noah")
+
+	if _, err := env.userSvc.UnlockUser(ctx, user.ID); err == nil {
+		t.Error("expected an error unlocking an active user")
+	}
+	for i := 0; i < 2; i++ {
+		_, _, _ = env.authSvc.AuthenticateUser(ctx, "noah", "wrong", "", "")
+	}
+
+	unlocked, err := env.userSvc.UnlockUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if unlocked.Status != domain.UserStatusACTIVE || unlocked.LockedUntil != nil {
+		t.Errorf("expected ACTIVE without expiry, got %s", unlocked.Status)
+	}
+	if _, _, err := env.authSvc.AuthenticateUser(ctx, "noah", "password-123", "", ""); err != nil {
+		t.Errorf("expected login after unlock, got %v", err)
+	}
+	if topics := pendingTopics(t, env); len(topics) != 2 || topics[1] != domain.TopicAuthUserUnlocked {
+		t.Errorf("expected locked and unlocked events, got %v", topics)
+	}
+}
+
+func TestLoginGuard_CountsConcurrentFailures(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures:   1000,
+		IPMaxFailures: 1000,
+		DelayAfter:    1000,
+	}))
+	ctx := context.Background()
+	env.createUser(t, "olivia")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = env.authSvc.AuthenticateUser(ctx, "olivia", "wrong", "10.0.0.9", "")
+		}()
+	}
+	wg.Wait()
+
+	for _, key := range []string{usernameAttemptKey("olivia"), ipAttemptKey("10.0.0.9")} {
+		a, err := env.attemptRepo.GetByKey(ctx, key)
+		if err != nil || a.FailureCount != 20 {
+			t.Errorf("%s: expected 20 failures, got %+v (%v)", key, a, err)
+		}
+	}
+}
+
+type failingOutboxRepo struct {
+	domain.TransactionalOutboxRepository
+}
+
+func (r failingOutboxRepo) Create(ctx context.Context, rec *domain.TransactionalOutbox) error {
+	return errors.New("outbox unavailable")
+}
+
+func TestUser_UnlockUserRollsBackWithoutEvent(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures: 2,
+		DelayAfter:  2,
+	}))
+	ctx := context.Background()
+	user := env.createUser(t, "paul")
+	for i := 0; i < 2; i++ {
+		_, _, _ = env.authSvc.AuthenticateUser(ctx, "paul", "wrong", "", "")
+	}
+
+	tm := memory.NewTransactionManager(env.userRepo, env.attemptRepo)
+	userSvc := NewUserService(env.userRepo, memory.NewUserStoreRepository(), memory.NewUserRoleRepository(), env.mfaRepo, env.attemptRepo, failingOutboxRepo{env.outboxRepo}, tm, env.authSvc.publisher)
+	if _, err := userSvc.UnlockUser(ctx, user.ID); err == nil {
+		t.Fatal("expected the unlock to fail with the outbox")
+	}
+
+	stored, _ := env.userRepo.GetByID(ctx, user.ID)
+	if stored.Status != domain.UserStatusLOCKED {
+		t.Errorf("expected the user to stay LOCKED, got %s", stored.Status)
+	}
+	if _, err := env.attemptRepo.GetByKey(ctx, usernameAttemptKey("paul")); err != nil {
+		t.Errorf("expected the failure counter to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"erp-system/shared/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	mfaMaxAttempts    = 5
+	recoveryCodeCount = 10
+	defaultMFAIssuer  = "ERP"
+)
+
+// MFAChallengeRequired is what AuthenticateUser returns instead of tokens
+// when a second factor is needed. Token is exchanged for tokens by VerifyMFA.
+// With EnrollmentRequired set the user has no authenticator yet and must
+// enroll with the token first.
+type MFAChallengeRequired struct {
+	Token              string
+	EnrollmentRequired bool
+	ExpiresAt          time.Time
+}
+
+func (e *MFAChallengeRequired) Error() string {
+	if e.EnrollmentRequired {
+		return "mfa enrollment required"
+	}
+	return "mfa verification required"
+}
+
+// MFAEnrollment is handed to the user once, to be loaded into an
+// authenticator app either by QR code or by typing in the secret.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *AuthService) issueMFAChallenge(ctx context.Context, user *domain.User, enrollmentRequired bool, ipAddress, userAgent string) error {
+	token, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+	now := time.Now()
+	challenge := &domain.MfaChallenge{
+		ID:                 utils.NewID("mfac"),
+		UserID:             user.ID,
+		Token:              "mfa_" + token,
+		EnrollmentRequired: enrollmentRequired,
+		IpAddress:          &ipAddress,
+		UserAgent:          &userAgent,
+		ExpiresAt:          now.Add(mfaChallengeTTL),
+		CreatedAt:          now,
+	}
+	if err := s.challengeRepo.Create(ctx, challenge); err != nil {
+		return err
+	}
+	return &MFAChallengeRequired{
+		Token:              challenge.Token,
+		EnrollmentRequired: enrollmentRequired,
+		ExpiresAt:          challenge.ExpiresAt,
+	}
+}
+
+func (s *AuthService) getChallenge(ctx context.Context, token string) (*domain.MfaChallenge, error) {
+	challenge, err := s.challengeRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("mfa challenge invalid or expired")
+	}
+	if !challenge.ExpiresAt.After(time.Now()) {
+		_ = s.challengeRepo.Delete(ctx, challenge.ID)
+		return nil, fmt.Errorf("mfa challenge invalid or expired")
+	}
+	return challenge, nil
+}
+
+// EnrollmentChallengeUser returns the user behind an unexpired challenge
+// issued to a user whose role requires MFA but who has not enrolled. It lets
+// that user reach EnrollMFA and ConfirmMFA without an access token.
+func (s *AuthService) EnrollmentChallengeUser(ctx context.Context, challengeToken string) (string, error) {
+	challenge, err := s.getChallenge(ctx, challengeToken)
+	if err != nil {
+		return "", err
+	}
+	if !challenge.EnrollmentRequired {
+		return "", fmt.Errorf("mfa challenge is not an enrollment challenge")
+	}
+	return challenge.UserID, nil
+}
+
+// VerifyMFA completes a two-step login. code is either the current TOTP code
+// or one of the user's unused recovery codes.
+func (s *AuthService) VerifyMFA(ctx context.Context, challengeToken, code string) (string, string, error) {
+	user, challenge, err := s.completeMFAChallenge(ctx, challengeToken, code)
+	if err != nil {
+		return "", "", err
+	}
+
+	var ip, ua string
+	if challenge.IpAddress != nil {
+		ip = *challenge.IpAddress
+	}
+	if challenge.UserAgent != nil {
+		ua = *challenge.UserAgent
+	}
+	return s.generateTokens(ctx, user, ip, ua, true)
+}
+
+// completeMFAChallenge checks the second factor and consumes the challenge.
+// A wrong code counts as a failed login for the username and the address
+// the challenge was issued to, like a wrong password.
+func (s *AuthService) completeMFAChallenge(ctx context.Context, challengeToken, code string) (*domain.User, *domain.MfaChallenge, error) {
+	challenge, err := s.getChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil || user.Status != domain.UserStatusACTIVE {
+		_ = s.challengeRepo.Delete(ctx, challenge.ID)
+		return nil, nil, fmt.Errorf("user account inactive or invalid")
+	}
+
+	mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil || !mfa.IsEnabled {
+		return nil, nil, fmt.Errorf("mfa enrollment required")
+	}
+
+	var ipAddress string
+	if challenge.IpAddress != nil {
+		ipAddress = *challenge.IpAddress
+	}
+	now := time.Now()
+	if err := s.checkLoginAllowed(ctx, user.Username, ipAddress, now); err != nil {
+		return nil, nil, err
+	}
+
+	// The attempt is counted before the code is checked, so parallel
+	// guesses against one challenge cannot get past the limit
+	attempts, err := s.challengeRepo.RecordAttempt(ctx, challenge.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mfa challenge invalid or expired")
+	}
+	if attempts > mfaMaxAttempts {
+		_ = s.challengeRepo.Delete(ctx, challenge.ID)
+		return nil, nil, fmt.Errorf("invalid mfa code, too many attempts, please sign in again")
+	}
+
+	if !s.checkSecondFactor(mfa, code) {
+		s.recordLoginFailure(ctx, user, user.Username, ipAddress, now)
+		if attempts == mfaMaxAttempts {
+			_ = s.challengeRepo.Delete(ctx, challenge.ID)
+			return nil, nil, fmt.Errorf("invalid mfa code, too many attempts, please sign in again")
+		}
+		return nil, nil, fmt.Errorf("invalid mfa code")
+	}
+
+	if err := s.challengeRepo.Delete(ctx, challenge.ID); err != nil {
+		return nil, nil, fmt.Errorf("mfa challenge invalid or expired")
+	}
+	mfa.UpdatedAt = now
+	if err := s.mfaRepo.Update(ctx, mfa); err != nil {
+		return nil, nil, err
+	}
+	// Failures only reset once the whole login has succeeded
+	_ = s.attemptRepo.DeleteByKey(ctx, usernameAttemptKey(user.Username))
+	return user, challenge, nil
+}
+
+// checkSecondFactor accepts a TOTP code or consumes a recovery code, updating
+// mfa in place; the caller persists it.
+func (s *AuthService) checkSecondFactor(mfa *domain.UserMFA, code string) bool {
+	if step, ok := verifyTOTP(mfa.Secret, code, time.Now(), int64(mfa.LastUsedStep)); ok {
+		mfa.LastUsedStep = int(step)
+		return true
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, h := range mfa.RecoveryCodeHashes {
+		if h == hash {
+			mfa.RecoveryCodeHashes = append(mfa.RecoveryCodeHashes[:i], mfa.RecoveryCodeHashes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// EnrollMFA starts TOTP enrollment with a fresh secret. MFA is not enforced
+// for the user until ConfirmMFA proves the authenticator works. Starting over
+// replaces a pending secret; an enabled MFA must be reset by an administrator.
+func (s *AuthService) EnrollMFA(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != domain.UserStatusACTIVE {
+		return nil, fmt.Errorf("user account is deactivated")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	existing, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err == nil {
+		if existing.IsEnabled {
+			return nil, fmt.Errorf("mfa is already enabled for this user")
+		}
+		existing.Secret = secret
+		existing.LastUsedStep = 0
+		existing.UpdatedAt = now
+		if err := s.mfaRepo.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+	} else {
+		mfa := &domain.UserMFA{
+			ID:        utils.NewID("mfa"),
+			UserID:    userID,
+			Secret:    secret,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.mfaRepo.Create(ctx, mfa); err != nil {
+			return nil, err
+		}
+	}
+
+	issuer := s.cfg.MFA.Issuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmMFA enables MFA once the user proves their authenticator produces
+// valid codes, and returns the recovery codes. They are only stored hashed,
+// so this is the only time they can be shown.
+func (s *AuthService) ConfirmMFA(ctx context.Context, userID, code string) ([]string, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("mfa enrollment not started")
+	}
+	if mfa.IsEnabled {
+		return nil, fmt.Errorf("mfa is already enabled for this user")
+	}
+
+	step, ok := verifyTOTP(mfa.Secret, code, time.Now(), int64(mfa.LastUsedStep))
+	if !ok {
+		return nil, fmt.Errorf("invalid mfa code")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	mfa.IsEnabled = true
+	mfa.EnabledAt = &now
+	mfa.LastUsedStep = int(step)
+	mfa.RecoveryCodeHashes = hashes
+	mfa.UpdatedAt = now
+	if err := s.mfaRepo.Update(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	if err := s.publisher.Publish(ctx, domain.TopicAuthUserMfaEnabled, userID, domain.MFAEventPayload{
+		UserID:    userID,
+		Timestamp: now,
+	}); err != nil {
+		utils.LogPublishErr("auth-service", domain.TopicAuthUserMfaEnabled, err)
+	}
+
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes. It asks for a current
+// TOTP code, not a recovery code, so a leaked recovery code cannot be used to
+// mint new ones.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil || !mfa.IsEnabled {
+		return nil, fmt.Errorf("mfa is not enabled for this user")
+	}
+
+	step, ok := verifyTOTP(mfa.Secret, code, time.Now(), int64(mfa.LastUsedStep))
+	if !ok {
+		return nil, fmt.Errorf("invalid mfa code")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	mfa.LastUsedStep = int(step)
+	mfa.RecoveryCodeHashes = hashes
+	mfa.UpdatedAt = time.Now()
+	if err := s.mfaRepo.Update(ctx, mfa); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes like "k3m7q-x2tbn" and their hashes. The
+// codes carry 50 random bits, so a plain SHA-256 is enough to store them.
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(buf))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalizes case and separators so users can type codes
+// the way they read them.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sharedtesting "erp-system/shared/testing"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/erp-system/auth-service/internal/data/memory"
+)
+
+type mfaTestEnv struct {
+	authSvc     *AuthService
+	userSvc     *UserService
+	rbacSvc     *RBACService
+	mfaRepo     *memory.UserMFARepository
+	attemptRepo *memory.LoginAttemptRepository
+	outboxRepo  *memory.TransactionalOutboxRepository
+	userRepo    *memory.UserRepository
+}
+
+func newMFATestEnv(t *testing.T) *mfaTestEnv {
+	t.Helper()
+	return newMFATestEnvWithConfig(t, newTestConfig())
+}
+
+func newMFATestEnvWithConfig(t *testing.T, cfg *config.Config) *mfaTestEnv {
+	t.Helper()
+	userRepo := memory.NewUserRepository()
+	urRepo := memory.NewUserRoleRepository()
+	usRepo := memory.NewUserStoreRepository()
+	mfaRepo := memory.NewUserMFARepository()
+	attemptRepo := memory.NewLoginAttemptRepository()
+	outboxRepo := memory.NewTransactionalOutboxRepository()
+	tm := memory.NewTransactionManager(userRepo, attemptRepo, outboxRepo)
+	pub := &sharedtesting.MockPublisher{}
+	rbacSvc := NewRBACService(memory.NewRoleRepository(), memory.NewPermissionRepository(), userRepo, urRepo, usRepo, memory.NewRolePermissionRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+	deps := newTestAuthDeps(userRepo, memory.NewSessionRepository(), rbacSvc, pub, cfg)
+	deps.MFA, deps.LoginAttempts, deps.Outbox, deps.TM = mfaRepo, attemptRepo, outboxRepo, tm
+	return &mfaTestEnv{
+		authSvc:     NewAuthService(deps),
+		userSvc:     NewUserService(userRepo, usRepo, urRepo, mfaRepo, attemptRepo, outboxRepo, tm, pub),
+		rbacSvc:     rbacSvc,
+		mfaRepo:     mfaRepo,
+		attemptRepo: attemptRepo,
+		outboxRepo:  outboxRepo,
+		userRepo:    userRepo,
+	}
+}
+
+func (e *mfaTestEnv) createUser(t *testing.T, username string, roleIDs ...string) *domain.User {
+	t.Helper()
+	u := &domain.User{Username: username, Email: username + "@e.com", PasswordHash: "password-123", FirstName: "F", LastName: "L"}
+	created, err := e.userSvc.CreateUser(context.Background(), u, "", roleIDs)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return created
+}
+
+// codeAt returns the TOTP code for the step offset steps away from now
+func codeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	return totpCode(key, totpStep(time.Now())+offset)
+}
+
+func (e *mfaTestEnv) enroll(t *testing.T, userID string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, err := e.authSvc.EnrollMFA(ctx, userID)
+	if err != nil {
+		t.Fatalf("enroll: %v", err)
+	}
+	codes, err := e.authSvc.ConfirmMFA(ctx, userID, codeAt(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	return enrollment.Secret, codes
+}
+
+func challengeFrom(t *testing.T, err error) *MFAChallengeRequired {
+	t.Helper()
+	var challenge *MFAChallengeRequired
+	if !errors.As(err, &challenge) {
+		t.Fatalf("expected an MFA challenge, got %v", err)
+	}
+	return challenge
+}
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 seed, truncated to our 6 digits
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		if got := totpCode(key, totpStep(time.Unix(unix, 0))); got != want {
+			t.Errorf("T=%d: got %s, want %s", unix, got, want)
+		}
+	}
+
+	secret := totpEncoding.EncodeToString(key)
+	now := time.Unix(1111111111, 0)
+	step, ok := verifyTOTP(secret, "050471", now, 0)
+	if !ok {
+		t.Fatal("expected current code to verify")
+	}
+	if _, ok := verifyTOTP(secret, "050471", now, step); ok {
+		t.Error("expected a used step to be refused")
+	}
+}
+
+func TestMFA_EnrollReturnsProvisioningURI(t *testing.T) {
+	env := newMFATestEnv(t)
+	user := env.createUser(t, "erin")
+
+	enrollment, err := env.authSvc.EnrollMFA(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("enroll: %v", err)
+	}
+	want := "otpauth://totp/ERP:erin?algorithm=SHA1&digits=6&issuer=ERP&period=30&secret=" + enrollment.Secret
+	if enrollment.ProvisioningURI != want {
+		t.Errorf("uri = %s", enrollment.ProvisioningURI)
+	}
+
+	// Pending enrollment is not enforced at login
+	if _, _, err := env.authSvc.AuthenticateUser(context.Background(), "erin", "password-123", "", ""); err != nil {
+		t.Errorf("expected tokens before confirmation, got %v", err)
+	}
+}
+
+func TestMFA_TwoStepLogin(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "frank")
+	secret, recoveryCodes := env.enroll(t, user.ID)
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(recoveryCodes))
+	}
+
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "frank", "password-123", "127.0.0.1", "test")
+	challenge := challengeFrom(t, err)
+	if challenge.EnrollmentRequired {
+		t.Fatal("enrolled user should not be asked to enroll")
+	}
+
+	// The confirmation code's step is spent; the next one is accepted
+	mfa, _ := env.mfaRepo.GetByUserID(ctx, user.ID)
+	key, _ := totpEncoding.DecodeString(secret)
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, totpCode(key, int64(mfa.LastUsedStep))); err == nil {
+		t.Fatal("expected the confirmation code to be refused as a replay")
+	}
+	access, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, codeAt(t, secret, 1))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	claims, err := env.authSvc.ValidateToken(ctx, access)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(claims.AMR) != 2 || claims.AMR[1] != "mfa" {
+		t.Errorf("expected amr [pwd mfa], got %v", claims.AMR)
+	}
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, codeAt(t, secret, 1)); err == nil {
+		t.Error("expected a used challenge to be refused")
+	}
+
+	// A recovery code works exactly once
+	_, _, err = env.authSvc.AuthenticateUser(ctx, "frank", "password-123", "", "")
+	challenge = challengeFrom(t, err)
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, recoveryCodes[0]); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	_, _, err = env.authSvc.AuthenticateUser(ctx, "frank", "password-123", "", "")
+	challenge = challengeFrom(t, err)
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, recoveryCodes[0]); err == nil {
+		t.Error("expected a used recovery code to be refused")
+	}
+}
+
+func TestMFA_ChallengeDroppedAfterTooManyAttempts(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "gina")
+	secret, _ := env.enroll(t, user.ID)
+
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "gina", "password-123", "", "")
+	challenge := challengeFrom(t, err)
+	for i := 0; i < mfaMaxAttempts; i++ {
+		_, _, _ = env.authSvc.VerifyMFA(ctx, challenge.Token, "000000")
+	}
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, codeAt(t, secret, 1)); err == nil {
+		t.Error("expected the challenge to be gone after too many attempts")
+	}
+}
+
+func TestMFA_WrongCodesCountTowardLockout(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures:   3,
+		IPMaxFailures: 1000,
+		DelayAfter:    1000,
+	}))
+	ctx := context.Background()
+	user := env.createUser(t, "ivan")
+	secret, _ := env.enroll(t, user.ID)
+
+	// A right password does not reset the count while the code is pending
+	_, _, _ = env.authSvc.AuthenticateUser(ctx, "ivan", "wrong", "10.0.0.3", "")
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "ivan", "password-123", "10.0.0.3", "")
+	challenge := challengeFrom(t, err)
+	_, _, err = env.authSvc.AuthenticateUser(ctx, "ivan", "password-123", "10.0.0.3", "")
+	fresh := challengeFrom(t, err)
+
+	_, _, _ = env.authSvc.VerifyMFA(ctx, challenge.Token, "000000")
+	_, _, _ = env.authSvc.VerifyMFA(ctx, fresh.Token, "000000")
+
+	locked, _ := env.userRepo.GetByID(ctx, user.ID)
+	if locked.Status != domain.UserStatusLOCKED {
+		t.Fatalf("expected wrong codes to lock the account, got %s", locked.Status)
+	}
+	if a, err := env.attemptRepo.GetByKey(ctx, ipAttemptKey("10.0.0.3")); err != nil || a.FailureCount != 3 {
+		t.Errorf("expected the address to be charged for the codes, got %+v (%v)", a, err)
+	}
+	if _, _, err := env.authSvc.VerifyMFA(ctx, fresh.Token, codeAt(t, secret, 1)); err == nil {
+		t.Error("expected the challenge to be refused once the account is locked")
+	}
+}
+
+func TestMFA_SuccessResetsFailures(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "jane")
+	secret, _ := env.enroll(t, user.ID)
+
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "jane", "password-123", "", "")
+	challenge := challengeFrom(t, err)
+	_, _, _ = env.authSvc.VerifyMFA(ctx, challenge.Token, "000000")
+	if a, err := env.attemptRepo.GetByKey(ctx, usernameAttemptKey("jane")); err != nil || a.FailureCount != 1 {
+		t.Fatalf("expected one failure, got %+v (%v)", a, err)
+	}
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, codeAt(t, secret, 1)); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if _, err := env.attemptRepo.GetByKey(ctx, usernameAttemptKey("jane")); err == nil {
+		t.Error("expected the failures to be cleared after the second factor")
+	}
+}
+
+func TestMFA_ParallelCodesStayWithinAttempts(t *testing.T) {
+	env := newMFATestEnvWithConfig(t, lockoutTestConfig(config.LockoutConfig{
+		MaxFailures:   1000,
+		IPMaxFailures: 1000,
+		DelayAfter:    1000,
+	}))
+	ctx := context.Background()
+	user := env.createUser(t, "karl")
+	env.enroll(t, user.ID)
+
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "karl", "password-123", "", "")
+	challenge := challengeFrom(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = env.authSvc.VerifyMFA(ctx, challenge.Token, "000000")
+		}()
+	}
+	wg.Wait()
+
+	a, err := env.attemptRepo.GetByKey(ctx, usernameAttemptKey("karl"))
+	if err != nil || a.FailureCount != mfaMaxAttempts {
+		t.Errorf("expected %d codes checked, got %+v (%v)", mfaMaxAttempts, a, err)
+	}
+}
+
+func TestMFA_RolePolicyEnforced(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	role, _ := env.rbacSvc.CreateRole(ctx, "Accountant", "Finance")
+	if _, err := env.rbacSvc.SetRoleMFARequired(ctx, role.ID, true); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+	user := env.createUser(t, "hank", role.ID)
+
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "hank", "password-123", "", "")
+	challenge := challengeFrom(t, err)
+	if !challenge.EnrollmentRequired {
+		t.Fatal("expected an enrollment challenge for an unenrolled user")
+	}
+	if _, _, err := env.authSvc.VerifyMFA(ctx, challenge.Token, "123456"); err == nil {
+		t.Fatal("expected verification to fail before enrollment")
+	}
+
+	userID, err := env.authSvc.EnrollmentChallengeUser(ctx, challenge.Token)
+	if err != nil || userID != user.ID {
+		t.Fatalf("challenge user = %q, %v", userID, err)
+	}
+	secret, _ := env.enroll(t, userID)
+
+	_, refresh, err := env.authSvc.VerifyMFA(ctx, challenge.Token, codeAt(t, secret, 1))
+	if err != nil {
+		t.Fatalf("verify after enrollment: %v", err)
+	}
+	if _, _, err := env.authSvc.RefreshToken(ctx, refresh); err != nil {
+		t.Errorf("expected an MFA session to refresh, got %v", err)
+	}
+}
+
+func TestMFA_PolicyBlocksRefreshOfPasswordOnlySession(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	role, _ := env.rbacSvc.CreateRole(ctx, "HR", "Human resources")
+	env.createUser(t, "ivy", role.ID)
+
+	_, refresh, err := env.authSvc.AuthenticateUser(ctx, "ivy", "password-123", "", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	_, _ = env.rbacSvc.SetRoleMFARequired(ctx, role.ID, true)
+
+	if _, _, err := env.authSvc.RefreshToken(ctx, refresh); !errors.Is(err, ErrMFARequired) {
+		t.Errorf("expected ErrMFARequired, got %v", err)
+	}
+}
+
+func TestUser_ResetMFA(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "jack")
+	secret, _ := env.enroll(t, user.ID)
+
+	_, _, err := env.authSvc.AuthenticateUser(ctx, "jack", "password-123", "", "")
+	challenge := challengeFrom(t, err)
+	access, refresh, err := env.authSvc.VerifyMFA(ctx, challenge.Token, codeAt(t, secret, 1))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if err := env.userSvc.ResetMFA(ctx, user.ID); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if _, err := env.mfaRepo.GetByUserID(ctx, user.ID); err == nil {
+		t.Error("expected MFA to be removed")
+	}
+	if _, err := env.authSvc.ValidateToken(ctx, access); err == nil {
+		t.Error("expected access token to be invalid after reset")
+	}
+	if _, _, err := env.authSvc.RefreshToken(ctx, refresh); err == nil {
+		t.Error("expected MFA session to be refused after reset")
+	}
+	if _, _, err := env.authSvc.AuthenticateUser(ctx, "jack", "password-123", "", ""); err != nil {
+		t.Errorf("expected password login after reset, got %v", err)
+	}
+}
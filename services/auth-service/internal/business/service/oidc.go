@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"erp-system/shared/utils"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const authorizationCodeTTL = time.Minute
+
+// OAuthError is an error response defined by RFC 6749, e.g. invalid_grant
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// AuthorizeRequest carries the parameters of an authorization request.
+// The client generates code_verifier and sends only its S256 challenge.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// ProviderMetadata is the OpenID Connect discovery document
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// IDTokenClaims is the OpenID Connect ID token. Profile and email claims are
+// only filled when the matching scope was granted.
+type IDTokenClaims struct {
+	Nonce             string   `json:"nonce,omitempty"`
+	AuthTime          int64    `json:"auth_time"`
+	AMR               []string `json:"amr,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	GivenName         string   `json:"given_name,omitempty"`
+	FamilyName        string   `json:"family_name,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserInfo is the userinfo endpoint response
+type UserInfo struct {
+	Sub               string `json:"sub"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	Email             string `json:"email"`
+}
+
+// OIDCTokens is the token endpoint response
+type OIDCTokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OIDCService makes auth-service an OpenID Connect provider for public
+// clients such as the frontend, using the authorization code flow with PKCE.
+// Logins go through the same password, lockout and MFA checks as /login.
+type OIDCService struct {
+	auth     *AuthService
+	codeRepo domain.AuthorizationCodeRepository
+	cfg      *config.Config
+}
+
+func NewOIDCService(auth *AuthService, codeRepo domain.AuthorizationCodeRepository, cfg *config.Config) *OIDCService {
+	return &OIDCService{
+		auth:     auth,
+		codeRepo: codeRepo,
+		cfg:      cfg,
+	}
+}
+
+func (s *OIDCService) Discovery() ProviderMetadata {
+	issuer := s.cfg.OIDC.Issuer
+	return ProviderMetadata{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.auth.keys.Algorithm()},
+		ScopesSupported:                   []string{"openid", "profile", "email"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "amr", "preferred_username", "name", "given_name", "family_name", "email"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+	}
+}
+
+func (s *OIDCService) JWKS() JSONWebKeySet {
+	return s.auth.keys.JWKS()
+}
+
+// CheckClient verifies the client and its redirect URI. Until both are known
+// good, errors must be shown to the user instead of sent to the redirect URI.
+func (s *OIDCService) CheckClient(clientID, redirectURI string) error {
+	for _, c := range s.cfg.OIDC.Clients {
+		if c.ID != clientID {
+			continue
+		}
+		for _, uri := range c.RedirectURIs {
+			if uri == redirectURI {
+				return nil
+			}
+		}
+		return oauthError("invalid_request", "redirect_uri is not registered for this client")
+	}
+	return oauthError("invalid_client", "unknown client_id")
+}
+
+// ValidateAuthorizeRequest checks everything besides the client and its
+// redirect URI; see CheckClient.
+func (s *OIDCService) ValidateAuthorizeRequest(req AuthorizeRequest) error {
+	if req.ResponseType != "code" {
+		return oauthError("unsupported_response_type", "only response_type=code is supported")
+	}
+	if req.CodeChallengeMethod != "S256" {
+		return oauthError("invalid_request", "code_challenge_method must be S256")
+	}
+	// base64url of a SHA-256 digest, without padding
+	if len(req.CodeChallenge) != 43 {
+		return oauthError("invalid_request", "code_challenge is missing or malformed")
+	}
+	return nil
+}
+
+// LoginURL is where GET /oauth/authorize sends the browser. The login page
+// collects credentials and posts them back with the original parameters.
+func (s *OIDCService) LoginURL(rawQuery string) string {
+	sep := "?"
+	if strings.Contains(s.cfg.OIDC.LoginURL, "?") {
+		sep = "&"
+	}
+	return s.cfg.OIDC.LoginURL + sep + rawQuery
+}
+
+// ErrorRedirect builds the redirect that reports err to the client
+func (s *OIDCService) ErrorRedirect(req AuthorizeRequest, err *OAuthError) string {
+	q := url.Values{}
+	q.Set("error", err.Code)
+	q.Set("error_description", err.Description)
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	return appendQuery(req.RedirectURI, q)
+}
+
+// AuthorizeWithPassword runs the password step for an authorization request
+// and returns the redirect URI carrying the code. Users that need a second
+// factor get an *MFAChallengeRequired to complete with AuthorizeWithMFA.
+func (s *OIDCService) AuthorizeWithPassword(ctx context.Context, req AuthorizeRequest, username, password, ipAddress, userAgent string) (string, error) {
+	if err := s.validate(req); err != nil {
+		return "", err
+	}
+	user, err := s.auth.checkPassword(ctx, username, password, ipAddress, userAgent)
+	if err != nil {
+		return "", err
+	}
+	return s.issueCode(ctx, req, user, false, ipAddress, userAgent)
+}
+
+func (s *OIDCService) AuthorizeWithMFA(ctx context.Context, req AuthorizeRequest, challengeToken, code string) (string, error) {
+	if err := s.validate(req); err != nil {
+		return "", err
+	}
+	user, challenge, err := s.auth.completeMFAChallenge(ctx, challengeToken, code)
+	if err != nil {
+		return "", err
+	}
+	var ip, ua string
+	if challenge.IpAddress != nil {
+		ip = *challenge.IpAddress
+	}
+	if challenge.UserAgent != nil {
+		ua = *challenge.UserAgent
+	}
+	return s.issueCode(ctx, req, user, true, ip, ua)
+}
+
+func (s *OIDCService) validate(req AuthorizeRequest) error {
+	if err := s.CheckClient(req.ClientID, req.RedirectURI); err != nil {
+		return err
+	}
+	return s.ValidateAuthorizeRequest(req)
+}
+
+func (s *OIDCService) issueCode(ctx context.Context, req AuthorizeRequest, user *domain.User, mfaVerified bool, ipAddress, userAgent string) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to create authorization code: %w", err)
+	}
+	now := time.Now()
+	code := &domain.AuthorizationCode{
+		ID:                  utils.NewID("ac"),
+		UserID:              user.ID,
+		Code:                "ac_" + token,
+		ClientID:            req.ClientID,
+		RedirectUri:         req.RedirectURI,
+		Scope:               req.Scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		MfaVerified:         mfaVerified,
+		IpAddress:           &ipAddress,
+		UserAgent:           &userAgent,
+		AuthTime:            now,
+		ExpiresAt:           now.Add(authorizationCodeTTL),
+		CreatedAt:           now,
+	}
+	if req.Nonce != "" {
+		code.Nonce = &req.Nonce
+	}
+	if err := s.codeRepo.Create(ctx, code); err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("code", code.Code)
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	return appendQuery(req.RedirectURI, q), nil
+}
+
+// ExchangeCode is the authorization_code grant. The code is consumed whether
+// or not the exchange succeeds.
+func (s *OIDCService) ExchangeCode(ctx context.Context, clientID, redirectURI, code, codeVerifier string) (*OIDCTokens, error) {
+	ac, err := s.codeRepo.GetByCode(ctx, code)
+	if err != nil || s.codeRepo.Delete(ctx, ac.ID) != nil {
+		return nil, oauthError("invalid_grant", "authorization code is invalid or already used")
+	}
+	if !ac.ExpiresAt.After(time.Now()) {
+		return nil, oauthError("invalid_grant", "authorization code expired")
+	}
+	if ac.ClientID != clientID || ac.RedirectUri != redirectURI {
+		return nil, oauthError("invalid_grant", "client_id or redirect_uri does not match the authorization request")
+	}
+	if !verifyPKCE(ac.CodeChallenge, codeVerifier) {
+		return nil, oauthError("invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	user, err := s.auth.userRepo.GetByID(ctx, ac.UserID)
+	if err != nil || user.Status != domain.UserStatusACTIVE {
+		return nil, oauthError("invalid_grant", "user account inactive or invalid")
+	}
+
+	var ip, ua string
+	if ac.IpAddress != nil {
+		ip = *ac.IpAddress
+	}
+	if ac.UserAgent != nil {
+		ua = *ac.UserAgent
+	}
+	accessToken, refreshToken, err := s.auth.generateTokens(ctx, user, ip, ua, ac.MfaVerified)
+	if errors.Is(err, ErrMFARequired) {
+		// A role started requiring MFA after the code was issued
+		return nil, oauthError("invalid_grant", err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := &OIDCTokens{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.cfg.JWT.AccessExpiry * 60,
+		RefreshToken: refreshToken,
+		Scope:        ac.Scope,
+	}
+	if hasScope(ac.Scope, "openid") {
+		if tokens.IDToken, err = s.idToken(user, ac); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// Refresh is the refresh_token grant. Refreshed responses carry no new ID
+// token; the client keeps the one from the code exchange.
+func (s *OIDCService) Refresh(ctx context.Context, refreshToken string) (*OIDCTokens, error) {
+	accessToken, newRefresh, err := s.auth.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, oauthError("invalid_grant", err.Error())
+	}
+	return &OIDCTokens{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.cfg.JWT.AccessExpiry * 60,
+		RefreshToken: newRefresh,
+	}, nil
+}
+
+// ClientCredentials is the client_credentials grant for service accounts.
+// Whatever is wrong with the credentials, the client only learns that they
+// were refused.
+func (s *OIDCService) ClientCredentials(ctx context.Context, clientID, clientSecret, ipAddress string) (*OIDCTokens, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, oauthError("invalid_client", "client_id and client_secret are required")
+	}
+	accessToken, expiresIn, err := s.auth.IssueClientCredentialsToken(ctx, clientID, clientSecret, ipAddress)
+	if errors.Is(err, ErrInvalidClientCredentials) {
+		return nil, oauthError("invalid_client", "client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCTokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(expiresIn.Seconds()),
+	}, nil
+}
+
+func (s *OIDCService) idToken(user *domain.User, ac *domain.AuthorizationCode) (string, error) {
+	now := time.Now()
+	claims := IDTokenClaims{
+		AuthTime: ac.AuthTime.Unix(),
+		AMR:      []string{"pwd"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.cfg.OIDC.Issuer,
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{ac.ClientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(s.cfg.JWT.AccessExpiry) * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	if ac.MfaVerified {
+		claims.AMR = append(claims.AMR, "mfa")
+	}
+	if ac.Nonce != nil {
+		claims.Nonce = *ac.Nonce
+	}
+	if hasScope(ac.Scope, "profile") {
+		claims.PreferredUsername = user.Username
+		claims.GivenName = user.FirstName
+		claims.FamilyName = user.LastName
+		claims.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	if hasScope(ac.Scope, "email") {
+		claims.Email = user.Email
+	}
+	return s.auth.keys.sign(claims)
+}
+
+func (s *OIDCService) UserInfo(ctx context.Context, accessToken string) (*UserInfo, error) {
+	claims, err := s.auth.ValidateToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.auth.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserInfo{
+		Sub:               user.ID,
+		PreferredUsername: user.Username,
+		Name:              strings.TrimSpace(user.FirstName + " " + user.LastName),
+		GivenName:         user.FirstName,
+		FamilyName:        user.LastName,
+		Email:             user.Email,
+	}, nil
+}
+
+// verifyPKCE checks code_verifier against an S256 code_challenge (RFC 7636)
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func hasScope(scope, want string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+func appendQuery(rawURL string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + q.Encode()
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/erp-system/auth-service/internal/data/memory"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk" // RFC 7636 appendix B
+	testRedirect = "http://app.test/callback"
+)
+
+func newOIDCTestEnv(t *testing.T) (*mfaTestEnv, *OIDCService) {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.OIDC = config.OIDCConfig{
+		Issuer:  "http://auth.test/api/v1/auth",
+		Clients: []config.OAuthClient{{ID: "erp-frontend", RedirectURIs: []string{testRedirect}}},
+	}
+	env := newMFATestEnvWithConfig(t, cfg)
+	return env, NewOIDCService(env.authSvc, memory.NewAuthorizationCodeRepository(), cfg)
+}
+
+func authorizeRequest() AuthorizeRequest {
+	sum := sha256.Sum256([]byte(testVerifier))
+	return AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "erp-frontend",
+		RedirectURI:         testRedirect,
+		Scope:               "openid profile email",
+		State:               "xyz",
+		Nonce:               "n-0S6_WzA2Mj",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func codeFrom(t *testing.T, redirect string) string {
+	t.Helper()
+	u, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatalf("redirect: %v", err)
+	}
+	if u.Query().Get("state") != "xyz" {
+		t.Errorf("state not echoed in %s", redirect)
+	}
+	return u.Query().Get("code")
+}
+
+func TestOIDC_AuthorizationCodeWithPKCE(t *testing.T) {
+	env, oidc := newOIDCTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "olga")
+
+	if challenge := authorizeRequest().CodeChallenge; challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Fatalf("S256 challenge = %s", challenge)
+	}
+
+	redirect, err := oidc.AuthorizeWithPassword(ctx, authorizeRequest(), "olga", "password-123", "", "")
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	code := codeFrom(t, redirect)
+
+	if _, err := oidc.ExchangeCode(ctx, "erp-frontend", testRedirect, code, "wrong-verifier-wrong-verifier-wrong-verifier"); err == nil {
+		t.Fatal("expected a wrong code_verifier to be refused")
+	}
+	// A failed exchange still uses up the code
+	var oauthErr *OAuthError
+	if _, err := oidc.ExchangeCode(ctx, "erp-frontend", testRedirect, code, testVerifier); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Fatalf("expected invalid_grant for a used code, got %v", err)
+	}
+
+	redirect, _ = oidc.AuthorizeWithPassword(ctx, authorizeRequest(), "olga", "password-123", "", "")
+	tokens, err := oidc.ExchangeCode(ctx, "erp-frontend", testRedirect, codeFrom(t, redirect), testVerifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if _, err := env.authSvc.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Errorf("access token: %v", err)
+	}
+
+	var id IDTokenClaims
+	if _, err := jwt.ParseWithClaims(tokens.IDToken, &id, env.authSvc.keys.keyfunc,
+		jwt.WithAudience("erp-frontend"), jwt.WithIssuer("http://auth.test/api/v1/auth")); err != nil {
+		t.Fatalf("id token: %v", err)
+	}
+	if id.Subject != user.ID || id.Nonce != "n-0S6_WzA2Mj" || id.Email != "olga@e.com" || id.PreferredUsername != "olga" {
+		t.Errorf("unexpected id token claims %+v", id)
+	}
+	// Same keys and issuer, but the ID token is not an access token
+	if _, err := env.authSvc.ValidateToken(ctx, tokens.IDToken); err == nil {
+		t.Error("expected the ID token to be refused as an access token")
+	}
+	if _, err := oidc.UserInfo(ctx, tokens.IDToken); err == nil {
+		t.Error("expected userinfo to refuse the ID token")
+	}
+
+	info, err := oidc.UserInfo(ctx, tokens.AccessToken)
+	if err != nil || info.Sub != user.ID {
+		t.Errorf("userinfo = %+v, %v", info, err)
+	}
+	if _, err := oidc.Refresh(ctx, tokens.RefreshToken); err != nil {
+		t.Errorf("refresh: %v", err)
+	}
+}
+
+func TestOIDC_RejectsUnregisteredClientsAndRedirects(t *testing.T) {
+	_, oidc := newOIDCTestEnv(t)
+
+	if err := oidc.CheckClient("erp-frontend", "http://evil.test/callback"); err == nil {
+		t.Error("expected an unregistered redirect_uri to be refused")
+	}
+	if err := oidc.CheckClient("other", testRedirect); err == nil {
+		t.Error("expected an unknown client to be refused")
+	}
+
+	req := authorizeRequest()
+	req.CodeChallengeMethod = "plain"
+	if err := oidc.ValidateAuthorizeRequest(req); err == nil {
+		t.Error("expected the plain PKCE method to be refused")
+	}
+	req = authorizeRequest()
+	req.CodeChallenge = ""
+	if err := oidc.ValidateAuthorizeRequest(req); err == nil {
+		t.Error("expected a request without PKCE to be refused")
+	}
+}
+
+func TestOIDC_SecondFactorBeforeCode(t *testing.T) {
+	env, oidc := newOIDCTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "pia")
+	secret, _ := env.enroll(t, user.ID)
+
+	_, err := oidc.AuthorizeWithPassword(ctx, authorizeRequest(), "pia", "password-123", "", "")
+	challenge := challengeFrom(t, err)
+
+	redirect, err := oidc.AuthorizeWithMFA(ctx, authorizeRequest(), challenge.Token, codeAt(t, secret, 1))
+	if err != nil {
+		t.Fatalf("authorize with mfa: %v", err)
+	}
+	tokens, err := oidc.ExchangeCode(ctx, "erp-frontend", testRedirect, codeFrom(t, redirect), testVerifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	claims, err := env.authSvc.ValidateToken(ctx, tokens.AccessToken)
+	if err != nil || len(claims.AMR) != 2 {
+		t.Errorf("expected an MFA access token, got %+v, %v", claims, err)
+	}
+}
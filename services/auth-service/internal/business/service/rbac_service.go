@@ -2,61 +2,174 @@ package service
 
 import (
 	"context"
+	"erp-system/shared/rbac"
 	"erp-system/shared/utils"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/erp-system/auth-service/internal/business/domain"
 )
 
 type RBACService struct {
-	roleRepo  domain.RoleRepository
-	permRepo  domain.PermissionRepository
-	urRepo    domain.UserRoleRepository
-	rpRepo    domain.RolePermissionRepository
-	publisher domain.EventPublisher
+	roleRepo   domain.RoleRepository
+	permRepo   domain.PermissionRepository
+	userRepo   domain.UserRepository
+	urRepo     domain.UserRoleRepository
+	usRepo     domain.UserStoreRepository
+	rpRepo     domain.RolePermissionRepository
+	outboxRepo domain.TransactionalOutboxRepository
+	tm         domain.TransactionManager
 }
 
 func NewRBACService(
 	roleRepo domain.RoleRepository,
 	permRepo domain.PermissionRepository,
+	userRepo domain.UserRepository,
 	urRepo domain.UserRoleRepository,
+	usRepo domain.UserStoreRepository,
 	rpRepo domain.RolePermissionRepository,
-	publisher domain.EventPublisher,
+	outboxRepo domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
 ) *RBACService {
 	return &RBACService{
-		roleRepo:  roleRepo,
-		permRepo:  permRepo,
-		urRepo:    urRepo,
-		rpRepo:    rpRepo,
-		publisher: publisher,
+		roleRepo:   roleRepo,
+		permRepo:   permRepo,
+		userRepo:   userRepo,
+		urRepo:     urRepo,
+		usRepo:     usRepo,
+		rpRepo:     rpRepo,
+		outboxRepo: outboxRepo,
+		tm:         tm,
 	}
 }
 
+// storeScopePlaceholder ends a permission code that applies per store, e.g.
+// "scm:stock:write@store". It becomes one grant per store the user is
+// assigned to.
+const storeScopePlaceholder = "@" + rbac.ScopeStore
+
+// GetUserRolesAndPermissions resolves the user's role names and permission
+// grants. Grants are evaluated with rbac.Allows; see rolesAndPermissions for
+// how they are scoped.
 func (s *RBACService) GetUserRolesAndPermissions(ctx context.Context, userID string) ([]string, []string, error) {
+	roles, permissions, _, err := s.userGrants(ctx, userID)
+	return roles, permissions, err
+}
+
+// userGrants resolves the user's grants and, when any of them is limited to
+// a legal entity, the legal entity the user belongs to.
+func (s *RBACService) userGrants(ctx context.Context, userID string) ([]string, []string, string, error) {
 	urLinks, err := s.urRepo.ListByUserID(ctx, userID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
+	}
+	stores, err := s.usRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	roleIDs := make([]string, 0, len(urLinks))
+	for _, ur := range urLinks {
+		roleIDs = append(roleIDs, ur.RoleID)
+	}
+	storeIDs := make([]string, 0, len(stores))
+	for _, us := range stores {
+		storeIDs = append(storeIDs, us.StoreID)
+	}
+	roles, permissions, err := s.rolesAndPermissions(ctx, roleIDs, storeIDs)
+	if err != nil || !hasLegalEntityGrant(permissions) {
+		return roles, permissions, "", err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if user.LegalEntityID == "" {
+		// Users without a legal entity predate scoped roles. No request
+		// ever acts in an entity of theirs, so @le grants would never
+		// match; they keep the unscoped codes they held before.
+		permissions = withoutLegalEntityScope(permissions)
+	}
+	return roles, permissions, user.LegalEntityID, nil
+}
+
+func hasLegalEntityGrant(grants []string) bool {
+	for _, grant := range grants {
+		if rbac.ParseGrant(grant).ScopeKind == rbac.ScopeLegalEntity {
+			return true
+		}
 	}
+	return false
+}
 
+func withoutLegalEntityScope(grants []string) []string {
+	seen := make(map[string]bool, len(grants))
+	unscoped := make([]string, 0, len(grants))
+	for _, grant := range grants {
+		if rbac.ParseGrant(grant).ScopeKind == rbac.ScopeLegalEntity {
+			grant = rbac.CodeOf(grant)
+		}
+		if !seen[grant] {
+			seen[grant] = true
+			unscoped = append(unscoped, grant)
+		}
+	}
+	return unscoped
+}
+
+// GetRolesAndPermissions resolves role names and the flattened permission
+// grants for a set of roles, e.g. a service account's. Unknown roles are
+// skipped. Without stores, per-store permissions grant nothing.
+func (s *RBACService) GetRolesAndPermissions(ctx context.Context, roleIDs []string) ([]string, []string, error) {
+	return s.rolesAndPermissions(ctx, roleIDs, nil)
+}
+
+// rolesAndPermissions scopes each permission code to where it applies: the
+// legal entity of its role (or of the permission itself), or, for per-store
+// codes, each of storeIDs. Codes of unscoped roles stay global.
+func (s *RBACService) rolesAndPermissions(ctx context.Context, roleIDs, storeIDs []string) ([]string, []string, error) {
 	var roles []string
 	var permissions []string
 	seenPerms := make(map[string]bool)
+	grant := func(code string) {
+		if !seenPerms[code] {
+			seenPerms[code] = true
+			permissions = append(permissions, code)
+		}
+	}
 
-	for _, ur := range urLinks {
-		role, err := s.roleRepo.GetByID(ctx, ur.RoleID)
-		if err == nil {
-			roles = append(roles, role.Name)
-
-			// Get permissions for this role
-			rpLinks, err := s.rpRepo.ListByRoleID(ctx, role.ID)
-			if err == nil {
-				for _, rp := range rpLinks {
-					p, err := s.permRepo.GetByID(ctx, rp.PermissionID)
-					if err == nil && !seenPerms[p.Code] {
-						seenPerms[p.Code] = true
-						permissions = append(permissions, p.Code)
-					}
+	for _, roleID := range roleIDs {
+		role, err := s.roleRepo.GetByID(ctx, roleID)
+		if err != nil {
+			continue
+		}
+		roles = append(roles, role.Name)
+
+		rpLinks, err := s.rpRepo.ListByRoleID(ctx, role.ID)
+		if err != nil {
+			continue
+		}
+		for _, rp := range rpLinks {
+			p, err := s.permRepo.GetByID(ctx, rp.PermissionID)
+			if err != nil {
+				continue
+			}
+			if code, ok := strings.CutSuffix(p.Code, storeScopePlaceholder); ok {
+				for _, storeID := range storeIDs {
+					grant(rbac.Scoped(code, rbac.ScopeStore, storeID))
 				}
+				continue
+			}
+			legalEntityID := role.LegalEntityID
+			if legalEntityID == "" {
+				legalEntityID = p.LegalEntityID
+			}
+			if legalEntityID != "" {
+				grant(rbac.Scoped(p.Code, rbac.ScopeLegalEntity, legalEntityID))
+			} else {
+				grant(p.Code)
 			}
 		}
 	}
@@ -76,7 +189,42 @@ func (s *RBACService) CreateRole(ctx context.Context, name, description string)
 	return role, err
 }
 
+// RequiresMFA reports whether any of the user's roles has the MFA policy set
+func (s *RBACService) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	urLinks, err := s.urRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, ur := range urLinks {
+		role, err := s.roleRepo.GetByID(ctx, ur.RoleID)
+		if err == nil && role.MfaRequired {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetRoleMFARequired turns the MFA policy of a role on or off. Members whose
+// sessions did not pass MFA are refused tokens on their next login or refresh.
+func (s *RBACService) SetRoleMFARequired(ctx context.Context, roleID string, required bool) (*domain.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	role.MfaRequired = required
+	role.UpdatedAt = time.Now()
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// CreatePermission stores a permission code. Scopes are derived when roles
+// are resolved, so the only suffix a code may carry is "@store".
 func (s *RBACService) CreatePermission(ctx context.Context, code, description string) (*domain.Permission, error) {
+	if at := strings.Index(code, "@"); at >= 0 && code[at:] != storeScopePlaceholder {
+		return nil, fmt.Errorf("invalid permission code %q: only the %s suffix is allowed", code, storeScopePlaceholder)
+	}
 	perm := &domain.Permission{
 		ID:          utils.NewID("perm"),
 		Code:        code,
@@ -95,22 +243,41 @@ func (s *RBACService) AssignPermissionToRole(ctx context.Context, roleID, permis
 		PermissionID: permissionID,
 		CreatedAt:    time.Now(),
 	}
-	return s.rpRepo.Create(ctx, link)
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.rpRepo.Create(txCtx, link); err != nil {
+			return err
+		}
+		return s.writeRolePermissionChange(txCtx, domain.TopicAuthRolePermissionAssigned, roleID, permissionID)
+	})
+}
+
+// writeRolePermissionChange tells permission caches (the gateway's token
+// cache in particular) that the permissions behind a role changed. It is
+// written to the outbox in the transaction of the change itself.
+func (s *RBACService) writeRolePermissionChange(ctx context.Context, topic, roleID, permissionID string) error {
+	key := roleID
+	if key == "" {
+		key = permissionID
+	}
+	return writeOutbox(ctx, s.outboxRepo, topic, key, domain.RolePermissionEventPayload{
+		RoleID:       roleID,
+		PermissionID: permissionID,
+		Timestamp:    time.Now(),
+	})
 }
 
-func (s *RBACService) ValidatePermissions(ctx context.Context, userID string, requiredPermission string) (bool, error) {
-	_, permissions, err := s.GetUserRolesAndPermissions(ctx, userID)
+// ValidatePermissions reports whether the user holds required in scope, with
+// the same evaluation the gateway applies to routes. Without a legal entity
+// the user's own applies, as the gateway falls back to the token's tenant.
+func (s *RBACService) ValidatePermissions(ctx context.Context, userID string, required string, scope rbac.Scope) (bool, error) {
+	_, permissions, legalEntityID, err := s.userGrants(ctx, userID)
 	if err != nil {
 		return false, err
 	}
-
-	for _, perm := range permissions {
-		if perm == requiredPermission {
-			return true, nil
-		}
+	if scope.LegalEntityID == "" {
+		scope.LegalEntityID = legalEntityID
 	}
-
-	return false, nil
+	return rbac.Allows(permissions, required, scope), nil
 }
 
 func (s *RBACService) ListRoles(ctx context.Context) ([]domain.Role, error) {
@@ -138,13 +305,28 @@ func (s *RBACService) GetRolePermissions(ctx context.Context, roleID string) ([]
 }
 
 func (s *RBACService) RemovePermissionFromRole(ctx context.Context, roleID string, permissionID string) error {
-	return s.rpRepo.Delete(ctx, roleID, permissionID)
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.rpRepo.Delete(txCtx, roleID, permissionID); err != nil {
+			return err
+		}
+		return s.writeRolePermissionChange(txCtx, domain.TopicAuthRolePermissionRevoked, roleID, permissionID)
+	})
 }
 
 func (s *RBACService) DeleteRole(ctx context.Context, id string) error {
-	return s.roleRepo.Delete(ctx, id)
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.roleRepo.Delete(txCtx, id); err != nil {
+			return err
+		}
+		return s.writeRolePermissionChange(txCtx, domain.TopicAuthRolePermissionRevoked, id, "")
+	})
 }
 
 func (s *RBACService) DeletePermission(ctx context.Context, id string) error {
-	return s.permRepo.Delete(ctx, id)
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.permRepo.Delete(txCtx, id); err != nil {
+			return err
+		}
+		return s.writeRolePermissionChange(txCtx, domain.TopicAuthRolePermissionRevoked, "", id)
+	})
 }
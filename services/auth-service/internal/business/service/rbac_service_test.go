@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"erp-system/shared/rbac"
 	"errors"
 	"testing"
 
@@ -69,9 +70,8 @@ func TestRBACService_GetUserRolesAndPermissions_SuccessAndEdgeCases(t *testing.T
 		permRepo := memory.NewPermissionRepository()
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
 		role, _ := s.CreateRole(ctx, "Admin", "Admin Role")
 		perm, _ := s.CreatePermission(ctx, "users.create", "Create users")
@@ -104,9 +104,8 @@ func TestRBACService_GetUserRolesAndPermissions_SuccessAndEdgeCases(t *testing.T
 			listErr:            errors.New("db error"),
 		}
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 		_, _, err := s.GetUserRolesAndPermissions(ctx, "u_1")
 		if err == nil || err.Error() != "db error" {
 			t.Errorf("expected 'db error', got %v", err)
@@ -121,9 +120,8 @@ func TestRBACService_GetUserRolesAndPermissions_SuccessAndEdgeCases(t *testing.T
 		permRepo := memory.NewPermissionRepository()
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
 		// Link user to role
 		_ = urRepo.Create(ctx, &domain.UserRole{
@@ -152,9 +150,8 @@ func TestRBACService_GetUserRolesAndPermissions_SuccessAndEdgeCases(t *testing.T
 			RolePermissionRepository: memory.NewRolePermissionRepository(),
 			listErr:                  errors.New("db error"),
 		}
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
 		role, _ := s.CreateRole(ctx, "Admin", "Admin Role")
 
@@ -185,9 +182,8 @@ func TestRBACService_GetUserRolesAndPermissions_SuccessAndEdgeCases(t *testing.T
 		}
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
 		role, _ := s.CreateRole(ctx, "Admin", "Admin Role")
 		_ = s.AssignPermissionToRole(ctx, role.ID, "perm_1")
@@ -220,9 +216,8 @@ func TestRBACService_ValidatePermissions(t *testing.T) {
 		permRepo := memory.NewPermissionRepository()
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
 		role, _ := s.CreateRole(ctx, "Admin", "Admin Role")
 		perm, _ := s.CreatePermission(ctx, "users.create", "Create users")
@@ -235,7 +230,7 @@ func TestRBACService_ValidatePermissions(t *testing.T) {
 			RoleID: role.ID,
 		})
 
-		ok, err := s.ValidatePermissions(ctx, "u_1", "users.create")
+		ok, err := s.ValidatePermissions(ctx, "u_1", "users.create", rbac.Scope{})
 		if err != nil {
 			t.Fatalf("expected nil err, got %v", err)
 		}
@@ -249,11 +244,10 @@ func TestRBACService_ValidatePermissions(t *testing.T) {
 		permRepo := memory.NewPermissionRepository()
 		urRepo := memory.NewUserRoleRepository()
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
-		ok, err := s.ValidatePermissions(ctx, "u_1", "users.create")
+		ok, err := s.ValidatePermissions(ctx, "u_1", "users.create", rbac.Scope{})
 		if err != nil {
 			t.Fatalf("expected nil err, got %v", err)
 		}
@@ -270,11 +264,10 @@ func TestRBACService_ValidatePermissions(t *testing.T) {
 			listErr:            errors.New("db error"),
 		}
 		rpRepo := memory.NewRolePermissionRepository()
-		pub := &dummyPublisher{}
 
-		s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+		s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 
-		ok, err := s.ValidatePermissions(ctx, "u_1", "users.create")
+		ok, err := s.ValidatePermissions(ctx, "u_1", "users.create", rbac.Scope{})
 		if err == nil || err.Error() != "db error" {
 			t.Errorf("expected 'db error', got %v", err)
 		}
@@ -284,15 +277,117 @@ func TestRBACService_ValidatePermissions(t *testing.T) {
 	})
 }
 
+func TestRBACService_ValidatePermissionsScoped(t *testing.T) {
+	ctx := context.Background()
+	roleRepo := memory.NewRoleRepository()
+	urRepo := memory.NewUserRoleRepository()
+	usRepo := memory.NewUserStoreRepository()
+	userRepo := memory.NewUserRepository()
+	s := NewRBACService(roleRepo, memory.NewPermissionRepository(), userRepo, urRepo, usRepo, memory.NewRolePermissionRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+	// u_1 belongs to the accountant role's entity, u_2 to another one and
+	// u_3 predates legal entities
+	for id, legalEntityID := range map[string]string{"u_1": "le-1", "u_2": "le-2", "u_3": ""} {
+		_ = userRepo.Create(ctx, &domain.User{ID: id, Username: id, LegalEntityID: legalEntityID})
+	}
+
+	accountant, _ := s.CreateRole(ctx, "Accountant", "Finance of one entity")
+	accountant.LegalEntityID = "le-1"
+	_ = roleRepo.Update(ctx, accountant)
+	clerk, _ := s.CreateRole(ctx, "Clerk", "Stock of assigned stores")
+	for roleID, code := range map[string]string{accountant.ID: "fm:invoices:write", clerk.ID: "scm:stock:write@store"} {
+		p, err := s.CreatePermission(ctx, code, code)
+		if err != nil {
+			t.Fatalf("create permission %s: %v", code, err)
+		}
+		_ = s.AssignPermissionToRole(ctx, roleID, p.ID)
+	}
+	_ = urRepo.Create(ctx, &domain.UserRole{ID: "ur_1", UserID: "u_1", RoleID: accountant.ID})
+	_ = urRepo.Create(ctx, &domain.UserRole{ID: "ur_2", UserID: "u_1", RoleID: clerk.ID})
+	_ = usRepo.Create(ctx, &domain.UserStore{ID: "us_1", UserID: "u_1", StoreID: "s-1"})
+	_ = urRepo.Create(ctx, &domain.UserRole{ID: "ur_3", UserID: "u_2", RoleID: accountant.ID})
+	_ = urRepo.Create(ctx, &domain.UserRole{ID: "ur_4", UserID: "u_3", RoleID: accountant.ID})
+
+	_, permissions, _ := s.GetUserRolesAndPermissions(ctx, "u_1")
+	granted := map[string]bool{}
+	for _, p := range permissions {
+		granted[p] = true
+	}
+	if len(permissions) != 2 || !granted["fm:invoices:write@le:le-1"] || !granted["scm:stock:write@store:s-1"] {
+		t.Fatalf("unexpected grants %v", permissions)
+	}
+
+	if _, legacy, _ := s.GetUserRolesAndPermissions(ctx, "u_3"); len(legacy) != 1 || legacy[0] != "fm:invoices:write" {
+		t.Errorf("expected a user without a legal entity to keep the unscoped grant, got %v", legacy)
+	}
+
+	cases := []struct {
+		userID   string
+		required string
+		scope    rbac.Scope
+		want     bool
+	}{
+		{"u_1", "fm:invoices:read", rbac.Scope{LegalEntityID: "le-1"}, true},
+		{"u_1", "fm:*:read", rbac.Scope{LegalEntityID: "le-1"}, false},
+		{"u_1", "fm:invoices:read", rbac.Scope{LegalEntityID: "le-2"}, false},
+		{"u_1", "fm:invoices:read", rbac.Scope{}, true}, // the user's own entity
+		{"u_2", "fm:invoices:read", rbac.Scope{}, false},
+		{"u_3", "fm:invoices:read", rbac.Scope{}, true},
+		{"u_1", "scm:stock:write", rbac.Scope{StoreID: "s-1"}, true},
+		{"u_1", "scm:stock:write", rbac.Scope{StoreID: "s-2"}, false},
+	}
+	for _, tc := range cases {
+		ok, err := s.ValidatePermissions(ctx, tc.userID, tc.required, tc.scope)
+		if err != nil || ok != tc.want {
+			t.Errorf("ValidatePermissions(%s, %s, %+v) = %v, %v; want %v", tc.userID, tc.required, tc.scope, ok, err, tc.want)
+		}
+	}
+
+	if _, err := s.CreatePermission(ctx, "fm:invoices:write@le:le-1", ""); err == nil {
+		t.Error("expected explicit scopes in a permission code to be refused")
+	}
+}
+
+// The gateway checks a request that names no legal entity against the
+// token's tenant; auth-service has to give the same answer for the user.
+func TestRBACService_TokenTenantMatchesValidatePermissions(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	accountant, _ := env.rbacSvc.CreateRole(ctx, "Accountant", "Finance of one entity")
+	accountant.LegalEntityID = "le-1"
+	_ = env.rbacSvc.roleRepo.Update(ctx, accountant)
+	p, _ := env.rbacSvc.CreatePermission(ctx, "fm:invoices:write", "")
+	_ = env.rbacSvc.AssignPermissionToRole(ctx, accountant.ID, p.ID)
+	user := env.createUser(t, "nora", accountant.ID)
+	user.LegalEntityID = "le-1"
+	_ = env.userRepo.Update(ctx, user)
+
+	access, _, err := env.authSvc.AuthenticateUser(ctx, "nora", "password-123", "", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	claims, err := env.authSvc.ValidateToken(ctx, access)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	for required, want := range map[string]bool{"fm:invoices:read": true, "fm:invoices:write": true, "fm:journals:read": false} {
+		gateway := rbac.Allows(claims.Permissions, required, rbac.Scope{LegalEntityID: claims.TenantID})
+		auth, err := env.rbacSvc.ValidatePermissions(ctx, user.ID, required, rbac.Scope{})
+		if err != nil || gateway != want || auth != want {
+			t.Errorf("%s: gateway %v, auth-service %v (%v); want %v", required, gateway, auth, err, want)
+		}
+	}
+}
+
 func TestRBACService_ListsAndDeletes(t *testing.T) {
 	ctx := context.Background()
 	roleRepo := memory.NewRoleRepository()
 	permRepo := memory.NewPermissionRepository()
 	urRepo := memory.NewUserRoleRepository()
 	rpRepo := memory.NewRolePermissionRepository()
-	pub := &dummyPublisher{}
+	outboxRepo := memory.NewTransactionalOutboxRepository()
 
-	s := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
+	s := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, outboxRepo, memory.NewTransactionManager(outboxRepo))
 
 	role, _ := s.CreateRole(ctx, "Role1", "Desc1")
 	perm, _ := s.CreatePermission(ctx, "Perm1", "Desc1")
@@ -333,7 +428,7 @@ func TestRBACService_ListsAndDeletes(t *testing.T) {
 			RolePermissionRepository: memory.NewRolePermissionRepository(),
 			listErr:                  errors.New("db error"),
 		}
-		sMock := NewRBACService(roleRepo, permRepo, urRepo, rpRepoMock, pub)
+		sMock := NewRBACService(roleRepo, permRepo, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepoMock, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 		_, err := sMock.GetRolePermissions(ctx, "role_id")
 		if err == nil || err.Error() != "db error" {
 			t.Errorf("expected 'db error', got %v", err)
@@ -345,7 +440,7 @@ func TestRBACService_ListsAndDeletes(t *testing.T) {
 			PermissionRepository: memory.NewPermissionRepository(),
 			getIDErr:             errors.New("perm not found"),
 		}
-		sMock := NewRBACService(roleRepo, permRepoMock, urRepo, rpRepo, pub)
+		sMock := NewRBACService(roleRepo, permRepoMock, memory.NewUserRepository(), urRepo, memory.NewUserStoreRepository(), rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
 		perms, err := sMock.GetRolePermissions(ctx, role.ID)
 		if err != nil {
 			t.Fatalf("expected nil err, got %v", err)
@@ -387,4 +482,16 @@ func TestRBACService_ListsAndDeletes(t *testing.T) {
 			t.Errorf("expected 0 permissions, got %d", len(list))
 		}
 	})
+
+	t.Run("ChangesGoThroughTheOutbox", func(t *testing.T) {
+		records, _ := outboxRepo.GetPending(ctx, 10)
+		counts := make(map[string]int)
+		for _, rec := range records {
+			counts[rec.EventType]++
+		}
+		// One assignment; the removal and both deletes revoke
+		if counts[domain.TopicAuthRolePermissionAssigned] != 1 || counts[domain.TopicAuthRolePermissionRevoked] != 3 {
+			t.Errorf("unexpected outbox events %v", counts)
+		}
+	})
 }
@@ -14,13 +14,41 @@ import (
 func newTestConfig() *config.Config {
 	return &config.Config{
 		JWT: config.JWTConfig{
-			Secret:        "test-secret-key-for-unit-tests-only",
 			AccessExpiry:  60,
 			RefreshExpiry: 24,
 		},
 	}
 }
 
+// newTestKeys signs with ES256, which is much faster to generate than RSA
+func newTestKeys() *KeyManager {
+	keys, err := NewKeyManager(config.JWTConfig{SigningAlgorithm: "ES256", AccessExpiry: 60}, memory.NewSigningKeyRepository())
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+// newTestAuthDeps wires AuthService to fresh in-memory repositories; tests
+// override the fields they need to inspect.
+func newTestAuthDeps(userRepo domain.UserRepository, sessRepo domain.SessionRepository, rbacSvc *RBACService, pub domain.EventPublisher, cfg *config.Config) AuthServiceDeps {
+	return AuthServiceDeps{
+		Users:           userRepo,
+		Sessions:        sessRepo,
+		MFA:             memory.NewUserMFARepository(),
+		MFAChallenges:   memory.NewMFAChallengeRepository(),
+		LoginAttempts:   memory.NewLoginAttemptRepository(),
+		Outbox:          memory.NewTransactionalOutboxRepository(),
+		TM:              memory.NewTransactionManager(),
+		ServiceAccounts: memory.NewServiceAccountRepository(),
+		APIKeys:         memory.NewAPIKeyRepository(),
+		RBAC:            rbacSvc,
+		Publisher:       pub,
+		Config:          cfg,
+		Keys:            newTestKeys(),
+	}
+}
+
 func newAuthService(t *testing.T) (*AuthService, *UserService, *memory.UserRepository, *memory.SessionRepository) {
 	t.Helper()
 	userRepo := memory.NewUserRepository()
@@ -30,10 +58,13 @@ func newAuthService(t *testing.T) (*AuthService, *UserService, *memory.UserRepos
 	urRepo := memory.NewUserRoleRepository()
 	rpRepo := memory.NewRolePermissionRepository()
 	usRepo := memory.NewUserStoreRepository()
+	mfaRepo := memory.NewUserMFARepository()
 	pub := &sharedtesting.MockPublisher{}
-	rbacSvc := NewRBACService(roleRepo, permRepo, urRepo, rpRepo, pub)
-	authSvc := NewAuthService(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
-	userSvc := NewUserService(userRepo, usRepo, urRepo, pub)
+	rbacSvc := NewRBACService(roleRepo, permRepo, userRepo, urRepo, usRepo, rpRepo, memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager())
+	deps := newTestAuthDeps(userRepo, sessRepo, rbacSvc, pub, newTestConfig())
+	deps.MFA = mfaRepo
+	authSvc := NewAuthService(deps)
+	userSvc := NewUserService(userRepo, usRepo, urRepo, mfaRepo, memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 	return authSvc, userSvc, userRepo, sessRepo
 }
 
@@ -196,3 +227,36 @@ func TestAuth_RevokeToken_SetsIsRevoked(t *testing.T) {
 		t.Errorf("expected IsRevoked=true after RevokeToken, got false")
 	}
 }
+
+// TestAuth_ValidateToken_RejectsRevokedSession verifies that the access token
+// dies with its session: after logout the sid claim points at a revoked
+// session and ValidateToken refuses it.
+func TestAuth_ValidateToken_RejectsRevokedSession(t *testing.T) {
+	authSvc, userSvc, _, _ := newAuthService(t)
+	ctx := context.Background()
+
+	u := &domain.User{Username: "frank", Email: "f@e.com", PasswordHash: "password-123", FirstName: "F", LastName: "F"}
+	if _, err := userSvc.CreateUser(ctx, u, "", nil); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	token, refresh, err := authSvc.AuthenticateUser(ctx, "frank", "password-123", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("auth: %v", err)
+	}
+	claims, err := authSvc.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("expected valid token, got: %v", err)
+	}
+	sess, _ := authSvc.GetSessionByRefreshToken(ctx, refresh)
+	if claims.SessionID == "" || claims.SessionID != sess.ID {
+		t.Fatalf("expected sid %q, got %q", sess.ID, claims.SessionID)
+	}
+
+	if err := authSvc.RevokeToken(ctx, sess.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := authSvc.ValidateToken(ctx, token); err == nil {
+		t.Error("expected ValidateToken to reject token of a revoked session")
+	}
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"erp-system/shared/rbac"
+	"erp-system/shared/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	apiKeyPrefix        = "erpk_"
+	apiKeyDisplayLength = 12
+	// apiKeyUsageInterval throttles last-used writes for busy integrations
+	apiKeyUsageInterval = time.Minute
+)
+
+// ErrInvalidClientCredentials is returned by the client_credentials grant for
+// an unknown, revoked or expired key, or one of another account.
+var ErrInvalidClientCredentials = errors.New("invalid client credentials")
+
+// CreateServiceAccountInput describes a new non-human identity
+type CreateServiceAccountInput struct {
+	LegalEntityID string   `json:"legal_entity_id" binding:"required"`
+	Name          string   `json:"name" binding:"required"`
+	Description   string   `json:"description"`
+	RoleIDs       []string `json:"role_ids"`
+}
+
+// CreateAPIKeyInput describes a new key. Scopes must be a subset of the
+// account's permissions; ExpiresInDays of zero means the configured default.
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// APIKeyInfo is an API key as shown to clients, without its hash.
+type APIKeyInfo struct {
+	ID               string     `json:"id"`
+	ServiceAccountID string     `json:"service_account_id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	Scopes           []string   `json:"scopes"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP       *string    `json:"last_used_ip,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func apiKeyInfo(k domain.ApiKey) APIKeyInfo {
+	return APIKeyInfo{
+		ID:               k.ID,
+		ServiceAccountID: k.ServiceAccountID,
+		Name:             k.Name,
+		Prefix:           k.Prefix,
+		Scopes:           k.Scopes,
+		ExpiresAt:        k.ExpiresAt,
+		LastUsedAt:       k.LastUsedAt,
+		LastUsedIP:       k.LastUsedIp,
+		RevokedAt:        k.RevokedAt,
+		CreatedAt:        k.CreatedAt,
+	}
+}
+
+// CreatedAPIKey carries the raw key. It is only available in the response
+// to the create call; afterwards only its hash is stored.
+type CreatedAPIKey struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *AuthService) apiKeyPolicy() config.APIKeyConfig {
+	return s.cfg.APIKeys.WithDefaults()
+}
+
+func (s *AuthService) CreateServiceAccount(ctx context.Context, in CreateServiceAccountInput) (*domain.ServiceAccount, error) {
+	if _, err := s.accountRepo.GetByName(ctx, in.Name); err == nil {
+		return nil, fmt.Errorf("service account %q already exists", in.Name)
+	}
+	for _, roleID := range in.RoleIDs {
+		role, err := s.rbacSvc.roleRepo.GetByID(ctx, roleID)
+		if err != nil {
+			return nil, fmt.Errorf("role not found: %s", roleID)
+		}
+		if role.LegalEntityID != "" && role.LegalEntityID != in.LegalEntityID {
+			return nil, fmt.Errorf("role %s belongs to another legal entity", roleID)
+		}
+	}
+
+	now := time.Now()
+	account := &domain.ServiceAccount{
+		ID:            utils.NewID("sa"),
+		LegalEntityID: in.LegalEntityID,
+		Name:          in.Name,
+		Description:   in.Description,
+		RoleIds:       in.RoleIDs,
+		Status:        domain.ServiceAccountStatusACTIVE,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.accountRepo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *AuthService) ListServiceAccounts(ctx context.Context) ([]domain.ServiceAccount, error) {
+	return s.accountRepo.List(ctx)
+}
+
+func (s *AuthService) GetServiceAccount(ctx context.Context, id string) (*domain.ServiceAccount, error) {
+	return s.accountRepo.GetByID(ctx, id)
+}
+
+// DisableServiceAccount stops every key of the account from working. Tokens
+// already issued to it are refused by ValidateToken from then on.
+func (s *AuthService) DisableServiceAccount(ctx context.Context, id string) error {
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if account.Status == domain.ServiceAccountStatusDISABLED {
+		return nil
+	}
+	account.Status = domain.ServiceAccountStatusDISABLED
+	account.UpdatedAt = time.Now()
+	if err := s.accountRepo.Update(ctx, account); err != nil {
+		return err
+	}
+	return writeOutbox(ctx, s.outboxRepo, domain.TopicAuthServiceAccountDisabled, account.ID, domain.APIKeyEventPayload{
+		ServiceAccountID: account.ID,
+		LegalEntityID:    account.LegalEntityID,
+		Timestamp:        time.Now(),
+	})
+}
+
+func (s *AuthService) CreateAPIKey(ctx context.Context, accountID string, in CreateAPIKeyInput) (*CreatedAPIKey, error) {
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != domain.ServiceAccountStatusACTIVE {
+		return nil, fmt.Errorf("service account is disabled")
+	}
+
+	policy := s.apiKeyPolicy()
+	ttl := policy.DefaultTTL
+	if in.ExpiresInDays < 0 {
+		return nil, fmt.Errorf("expires_in_days must be positive")
+	}
+	if in.ExpiresInDays > 0 {
+		ttl = time.Duration(in.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl > policy.MaxTTL {
+		return nil, fmt.Errorf("api keys expire after at most %d days", int(policy.MaxTTL/(24*time.Hour)))
+	}
+
+	if len(in.Scopes) > 0 {
+		_, permissions, err := s.rbacSvc.GetRolesAndPermissions(ctx, account.RoleIds)
+		if err != nil {
+			return nil, err
+		}
+		granted := make(map[string]bool, len(permissions))
+		for _, p := range permissions {
+			granted[rbac.CodeOf(p)] = true
+		}
+		for _, scope := range in.Scopes {
+			if !granted[scope] {
+				return nil, fmt.Errorf("scope %s is not granted to the service account", scope)
+			}
+		}
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	rawKey := apiKeyPrefix + secret
+	now := time.Now()
+	key := domain.ApiKey{
+		ID:               utils.NewID("key"),
+		ServiceAccountID: account.ID,
+		Name:             in.Name,
+		Prefix:           rawKey[:apiKeyDisplayLength],
+		KeyHash:          hashAPIKey(rawKey),
+		Scopes:           in.Scopes,
+		ExpiresAt:        now.Add(ttl),
+		CreatedAt:        now,
+	}
+	if err := s.keyRepo.Create(ctx, &key); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{APIKeyInfo: apiKeyInfo(key), Key: rawKey}, nil
+}
+
+func (s *AuthService) ListAPIKeys(ctx context.Context, accountID string) ([]APIKeyInfo, error) {
+	if _, err := s.accountRepo.GetByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+	keys, err := s.keyRepo.ListByServiceAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]APIKeyInfo, 0, len(keys))
+	for _, k := range keys {
+		infos = append(infos, apiKeyInfo(k))
+	}
+	return infos, nil
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, accountID, keyID string) error {
+	key, err := s.keyRepo.GetByID(ctx, keyID)
+	if err != nil || key.ServiceAccountID != accountID {
+		return fmt.Errorf("api key not found: %s", keyID)
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := s.keyRepo.Update(ctx, key); err != nil {
+		return err
+	}
+
+	var legalEntityID string
+	if account, err := s.accountRepo.GetByID(ctx, accountID); err == nil {
+		legalEntityID = account.LegalEntityID
+	}
+	return writeOutbox(ctx, s.outboxRepo, domain.TopicAuthApiKeyRevoked, key.ID, domain.APIKeyEventPayload{
+		ServiceAccountID: accountID,
+		KeyID:            key.ID,
+		LegalEntityID:    legalEntityID,
+		Timestamp:        now,
+	})
+}
+
+// AuthenticateAPIKey resolves a raw X-API-Key to the claims it acts with,
+// the way ValidateToken does for a bearer token.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, rawKey, ipAddress string) (*TokenClaims, error) {
+	key, account, err := s.checkAPIKey(ctx, rawKey)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.serviceClaims(ctx, key, account)
+	if err != nil {
+		return nil, err
+	}
+	s.recordKeyUsage(ctx, key, ipAddress)
+	return claims, nil
+}
+
+// IssueClientCredentialsToken is the client_credentials grant: the client ID
+// is the service account ID and the client secret one of its API keys. The
+// token never outlives the key and comes without a refresh token.
+func (s *AuthService) IssueClientCredentialsToken(ctx context.Context, clientID, clientSecret, ipAddress string) (string, time.Duration, error) {
+	key, account, err := s.checkAPIKey(ctx, clientSecret)
+	if err != nil || account.ID != clientID {
+		return "", 0, ErrInvalidClientCredentials
+	}
+	claims, err := s.serviceClaims(ctx, key, account)
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(s.cfg.JWT.AccessExpiry) * time.Minute)
+	if key.ExpiresAt.Before(expiresAt) {
+		expiresAt = key.ExpiresAt
+	}
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    s.cfg.OIDC.Issuer,
+		Subject:   account.ID,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+	accessToken, err := s.keys.signAccessToken(claims)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	s.recordKeyUsage(ctx, key, ipAddress)
+	return accessToken, expiresAt.Sub(now), nil
+}
+
+// checkAPIKey looks a key up by hash and checks it and its account are
+// still usable.
+func (s *AuthService) checkAPIKey(ctx context.Context, rawKey string) (*domain.ApiKey, *domain.ServiceAccount, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, nil, fmt.Errorf("api key invalid")
+	}
+	key, err := s.keyRepo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("api key invalid")
+	}
+	return s.checkKeyAndAccount(ctx, key)
+}
+
+func (s *AuthService) checkKeyAndAccount(ctx context.Context, key *domain.ApiKey) (*domain.ApiKey, *domain.ServiceAccount, error) {
+	if key.RevokedAt != nil {
+		return nil, nil, fmt.Errorf("api key revoked")
+	}
+	if !key.ExpiresAt.After(time.Now()) {
+		return nil, nil, fmt.Errorf("api key expired")
+	}
+	account, err := s.accountRepo.GetByID(ctx, key.ServiceAccountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service account no longer exists")
+	}
+	if account.Status != domain.ServiceAccountStatusACTIVE {
+		return nil, nil, fmt.Errorf("service account is disabled")
+	}
+	return key, account, nil
+}
+
+// serviceClaims builds the claims a key acts with: the account's roles, and
+// their permissions narrowed to the key's scopes and limited to the account's
+// legal entity.
+func (s *AuthService) serviceClaims(ctx context.Context, key *domain.ApiKey, account *domain.ServiceAccount) (*TokenClaims, error) {
+	roles, permissions, err := s.rbacSvc.GetRolesAndPermissions(ctx, account.RoleIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(key.Scopes) > 0 {
+		scoped := make(map[string]bool, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scoped[scope] = true
+		}
+		narrowed := make([]string, 0, len(key.Scopes))
+		for _, p := range permissions {
+			if scoped[rbac.CodeOf(p)] {
+				narrowed = append(narrowed, p)
+			}
+		}
+		permissions = narrowed
+	}
+	// The account acts in its legal entity only, whatever its roles allow
+	for i, p := range permissions {
+		permissions[i] = rbac.Scoped(rbac.CodeOf(p), rbac.ScopeLegalEntity, account.LegalEntityID)
+	}
+	return &TokenClaims{
+		UserID:      account.ID,
+		TenantID:    account.LegalEntityID,
+		Roles:       roles,
+		Username:    account.Name,
+		Permissions: permissions,
+		ClientID:    account.ID,
+		APIKeyID:    key.ID,
+	}, nil
+}
+
+// validateServiceToken is ValidateToken for client_credentials tokens: they
+// have no user or session, so the key and account are checked instead.
+func (s *AuthService) validateServiceToken(ctx context.Context, claims *TokenClaims) error {
+	key, err := s.keyRepo.GetByID(ctx, claims.APIKeyID)
+	if err != nil || key.ServiceAccountID != claims.ClientID {
+		return fmt.Errorf("token invalid: api key no longer exists")
+	}
+	if _, _, err := s.checkKeyAndAccount(ctx, key); err != nil {
+		return fmt.Errorf("token invalid: %w", err)
+	}
+	return nil
+}
+
+// currentServicePermissions is what Introspect reports for a service token:
+// the account's current roles, narrowed by the key's scopes.
+func (s *AuthService) currentServicePermissions(ctx context.Context, claims *TokenClaims) ([]string, []string, error) {
+	key, err := s.keyRepo.GetByID(ctx, claims.APIKeyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, err := s.accountRepo.GetByID(ctx, key.ServiceAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := s.serviceClaims(ctx, key, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current.Roles, current.Permissions, nil
+}
+
+// recordKeyUsage keeps last-used details current without a write per
+// request: only when the address changes or the stored time is stale.
+func (s *AuthService) recordKeyUsage(ctx context.Context, key *domain.ApiKey, ipAddress string) {
+	now := time.Now()
+	sameIP := key.LastUsedIp != nil && *key.LastUsedIp == ipAddress
+	if sameIP && key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < apiKeyUsageInterval {
+		return
+	}
+	key.LastUsedAt = &now
+	key.LastUsedIp = &ipAddress
+	_ = s.keyRepo.Update(ctx, key)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+)
+
+// newIntegrationAccount creates an account acting with one role that holds
+// scm:product:read and scm:product:create.
+func newIntegrationAccount(t *testing.T, env *mfaTestEnv) *domain.ServiceAccount {
+	t.Helper()
+	ctx := context.Background()
+	role, _ := env.rbacSvc.CreateRole(ctx, "Integration", "EDI importer")
+	for _, code := range []string{"scm:product:read", "scm:product:create"} {
+		p, _ := env.rbacSvc.CreatePermission(ctx, code, code)
+		if err := env.rbacSvc.AssignPermissionToRole(ctx, role.ID, p.ID); err != nil {
+			t.Fatalf("assign permission: %v", err)
+		}
+	}
+	account, err := env.authSvc.CreateServiceAccount(ctx, CreateServiceAccountInput{LegalEntityID: "le-1", Name: "edi-importer", RoleIDs: []string{role.ID}})
+	if err != nil {
+		t.Fatalf("create service account: %v", err)
+	}
+	return account
+}
+
+func TestServiceAccounts_APIKeyIsScopedHashedAndTracked(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	account := newIntegrationAccount(t, env)
+
+	if _, err := env.authSvc.CreateAPIKey(ctx, account.ID, CreateAPIKeyInput{Name: "too-wide", Scopes: []string{"fm:accounts:write"}}); err == nil {
+		t.Fatal("expected a scope outside the account's roles to be refused")
+	}
+	if _, err := env.authSvc.CreateAPIKey(ctx, account.ID, CreateAPIKeyInput{Name: "too-long", ExpiresInDays: 400}); err == nil {
+		t.Fatal("expected an expiry beyond the maximum to be refused")
+	}
+
+	created, err := env.authSvc.CreateAPIKey(ctx, account.ID, CreateAPIKeyInput{Name: "read-only", Scopes: []string{"scm:product:read"}})
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("unexpected key material %+v", created)
+	}
+	if body, _ := json.Marshal(created); strings.Contains(string(body), "key_hash") {
+		t.Errorf("the key hash must not be returned: %s", body)
+	}
+	if d := time.Until(created.ExpiresAt); d < 89*24*time.Hour || d > 90*24*time.Hour {
+		t.Errorf("expected the default 90 day expiry, got %s", d)
+	}
+
+	claims, err := env.authSvc.AuthenticateAPIKey(ctx, created.Key, "10.0.0.5")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if claims.UserID != account.ID || claims.TenantID != "le-1" || claims.APIKeyID != created.ID {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if len(claims.Permissions) != 1 || claims.Permissions[0] != "scm:product:read@le:le-1" {
+		t.Errorf("expected the key's scope only, got %v", claims.Permissions)
+	}
+
+	stored, _ := env.authSvc.keyRepo.GetByID(ctx, created.ID)
+	if stored.LastUsedAt == nil || stored.LastUsedIp == nil || *stored.LastUsedIp != "10.0.0.5" {
+		t.Errorf("expected usage to be recorded, got %+v", stored)
+	}
+
+	if _, err := env.authSvc.AuthenticateAPIKey(ctx, created.Key+"x", ""); err == nil {
+		t.Error("expected an unknown key to be refused")
+	}
+}
+
+func TestServiceAccounts_ClientCredentialsToken(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	account := newIntegrationAccount(t, env)
+	created, _ := env.authSvc.CreateAPIKey(ctx, account.ID, CreateAPIKeyInput{Name: "bi", ExpiresInDays: 1})
+	other, _ := env.authSvc.CreateServiceAccount(ctx, CreateServiceAccountInput{LegalEntityID: "le-1", Name: "bi-export"})
+
+	if _, _, err := env.authSvc.IssueClientCredentialsToken(ctx, other.ID, created.Key, ""); !errors.Is(err, ErrInvalidClientCredentials) {
+		t.Fatalf("expected another account's key to be refused, got %v", err)
+	}
+
+	token, expiresIn, err := env.authSvc.IssueClientCredentialsToken(ctx, account.ID, created.Key, "10.0.0.6")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if expiresIn <= 0 || expiresIn > time.Hour {
+		t.Errorf("expires in %s", expiresIn)
+	}
+	claims, err := env.authSvc.Introspect(ctx, token)
+	if err != nil {
+		t.Fatalf("introspect: %v", err)
+	}
+	if claims.ClientID != account.ID || claims.Subject != account.ID || len(claims.Permissions) != 2 {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	// Revoking the key invalidates the tokens issued with it
+	if err := env.authSvc.RevokeAPIKey(ctx, account.ID, created.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := env.authSvc.ValidateToken(ctx, token); err == nil {
+		t.Error("expected a token of a revoked key to be refused")
+	}
+	if _, err := env.authSvc.AuthenticateAPIKey(ctx, created.Key, ""); err == nil {
+		t.Error("expected a revoked key to be refused")
+	}
+	if topics := pendingTopics(t, env); len(topics) != 1 || topics[0] != domain.TopicAuthApiKeyRevoked {
+		t.Errorf("expected a revocation event, got %v", topics)
+	}
+}
+
+func TestServiceAccounts_DisableStopsEveryKey(t *testing.T) {
+	env := newMFATestEnv(t)
+	ctx := context.Background()
+	account := newIntegrationAccount(t, env)
+	created, _ := env.authSvc.CreateAPIKey(ctx, account.ID, CreateAPIKeyInput{Name: "edi"})
+	token, _, err := env.authSvc.IssueClientCredentialsToken(ctx, account.ID, created.Key, "")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if err := env.authSvc.DisableServiceAccount(ctx, account.ID); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if _, err := env.authSvc.ValidateToken(ctx, token); err == nil {
+		t.Error("expected a token of a disabled account to be refused")
+	}
+	if _, err := env.authSvc.AuthenticateAPIKey(ctx, created.Key, ""); err == nil {
+		t.Error("expected a key of a disabled account to be refused")
+	}
+	if _, err := env.authSvc.CreateAPIKey(ctx, account.ID, CreateAPIKeyInput{Name: "another"}); err == nil {
+		t.Error("expected no new keys for a disabled account")
+	}
+	if topics := pendingTopics(t, env); len(topics) != 1 || topics[0] != domain.TopicAuthServiceAccountDisabled {
+		t.Errorf("expected a disabled event, got %v", topics)
+	}
+}
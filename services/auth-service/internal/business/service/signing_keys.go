@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const rsaKeyBits = 2048
+
+var errNoSigningKey = errors.New("no current signing key in the key store")
+
+// JSONWebKey is the public half of a signing key as published in the JWKS
+// (RFC 7517). RSA keys fill N and E, P-256 keys Crv, X and Y.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   interface{}
+	public    interface{}
+	createdAt time.Time
+	retiredAt *time.Time
+}
+
+const (
+	// keyReloadInterval bounds how long a replica signs with a key another
+	// replica has already retired
+	keyReloadInterval = time.Minute
+	// unknownKidReloadInterval limits store reads caused by tokens naming a
+	// kid this replica has not loaded
+	unknownKidReloadInterval = 5 * time.Second
+)
+
+// KeyManager holds the asymmetric keys tokens are signed with. The keys live
+// in a store shared by all replicas: the newest key signs; retired keys stay
+// published in the JWKS until every token they signed has expired, so
+// verifiers never see a token whose key they cannot fetch. Whichever replica
+// first finds the current key due rotates it for all of them.
+type KeyManager struct {
+	mu        sync.RWMutex
+	store     domain.SigningKeyRepository
+	algorithm string
+	rotation  time.Duration
+	retention time.Duration
+	keys      []*signingKey // oldest first
+	loadedAt  time.Time
+	now       func() time.Time
+}
+
+// NewKeyManager loads the keys from store. An empty store is seeded with
+// the key in cfg.SigningKeyFile, or a generated one.
+func NewKeyManager(cfg config.JWTConfig, store domain.SigningKeyRepository) (*KeyManager, error) {
+	m := &KeyManager{
+		store:     store,
+		algorithm: cfg.SigningAlgorithm,
+		rotation:  cfg.KeyRotation,
+		retention: time.Duration(cfg.AccessExpiry) * time.Minute,
+		now:       time.Now,
+	}
+	if m.algorithm == "" {
+		m.algorithm = jwt.SigningMethodRS256.Alg()
+	}
+	if m.algorithm != jwt.SigningMethodRS256.Alg() && m.algorithm != jwt.SigningMethodES256.Alg() {
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q, want RS256 or ES256", m.algorithm)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.reloadLocked(); err != nil {
+		return nil, err
+	}
+	if len(m.keys) > 0 {
+		return m, nil
+	}
+
+	var key *signingKey
+	var err error
+	if cfg.SigningKeyFile != "" {
+		key, err = loadSigningKey(cfg.SigningKeyFile)
+	} else {
+		key, err = generateSigningKey(m.algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := m.storeLocked("", key); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// reloadLocked replaces the cached keys with those in the store
+func (m *KeyManager) reloadLocked() error {
+	stored, err := m.store.List(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	now := m.now()
+	keys := make([]*signingKey, 0, len(stored))
+	for _, sk := range stored {
+		if sk.RetiredAt != nil && now.Sub(*sk.RetiredAt) >= m.retention {
+			continue
+		}
+		key, err := parseSigningKey([]byte(sk.PrivateKeyPem), sk.ID)
+		if err != nil {
+			return err
+		}
+		key.createdAt = sk.CreatedAt
+		key.retiredAt = sk.RetiredAt
+		keys = append(keys, key)
+	}
+	m.keys = keys
+	m.loadedAt = now
+	return nil
+}
+
+// storeLocked makes key the signing key in place of currentID. When another
+// replica got there first, its key is loaded instead.
+func (m *KeyManager) storeLocked(currentID string, key *signingKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	now := m.now()
+	err = m.store.Rotate(context.Background(), currentID, &domain.SigningKey{
+		ID:            key.id,
+		Algorithm:     key.method.Alg(),
+		PrivateKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:     now,
+	})
+	if err != nil && !errors.Is(err, domain.ErrSigningKeyRotated) {
+		return fmt.Errorf("failed to store signing key: %w", err)
+	}
+	if err := m.store.DeleteRetiredBefore(context.Background(), now.Add(-m.retention)); err != nil {
+		return fmt.Errorf("failed to prune signing keys: %w", err)
+	}
+	if err := m.reloadLocked(); err != nil {
+		return err
+	}
+	if len(m.keys) == 0 {
+		return errNoSigningKey
+	}
+	return nil
+}
+
+// Rotate retires the current key and signs with a fresh one from now on
+func (m *KeyManager) Rotate() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.reloadLocked(); err != nil {
+		return err
+	}
+	if len(m.keys) == 0 {
+		return errNoSigningKey
+	}
+	return m.rotateLocked()
+}
+
+// rotateLocked replaces the current key with a new one of the same algorithm
+func (m *KeyManager) rotateLocked() error {
+	current := m.keys[len(m.keys)-1]
+	key, err := generateSigningKey(current.method.Alg())
+	if err != nil {
+		return err
+	}
+	return m.storeLocked(current.id, key)
+}
+
+// current returns the signing key, picking up keys other replicas stored
+// and rotating first when it is due
+func (m *KeyManager) current() (*signingKey, error) {
+	m.mu.RLock()
+	key := m.keys[len(m.keys)-1]
+	now := m.now()
+	stale := now.Sub(m.loadedAt) >= keyReloadInterval
+	due := m.rotation > 0 && now.Sub(key.createdAt) >= m.rotation
+	m.mu.RUnlock()
+	if !stale && !due {
+		return key, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.reloadLocked(); err != nil {
+		return nil, err
+	}
+	if len(m.keys) == 0 {
+		return nil, errNoSigningKey
+	}
+	key = m.keys[len(m.keys)-1]
+	if m.rotation > 0 && m.now().Sub(key.createdAt) >= m.rotation {
+		if err := m.rotateLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return m.keys[len(m.keys)-1], nil
+}
+
+// AccessTokenType is the JOSE "typ" of access tokens (RFC 9068). ID tokens
+// are signed with the same keys as plain JWTs, so checking typ keeps one from
+// being presented as an access token.
+const AccessTokenType = "at+jwt"
+
+// isAccessTokenType reports whether a JOSE typ header marks an access token;
+// RFC 9068 allows the "application/" prefix and any letter case.
+func isAccessTokenType(typ string) bool {
+	typ = strings.ToLower(typ)
+	return typ == AccessTokenType || typ == "application/"+AccessTokenType
+}
+
+func (m *KeyManager) sign(claims jwt.Claims) (string, error) {
+	return m.signWithType(claims, "JWT")
+}
+
+// signAccessToken signs claims as an access token.
+func (m *KeyManager) signAccessToken(claims jwt.Claims) (string, error) {
+	return m.signWithType(claims, AccessTokenType)
+}
+
+func (m *KeyManager) signWithType(claims jwt.Claims, typ string) (string, error) {
+	key, err := m.current()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	token.Header["typ"] = typ
+	return token.SignedString(key.private)
+}
+
+// keyfunc resolves the verification key from the token's kid and refuses
+// any algorithm other than the one that key signs with. A kid this replica
+// has not seen yet may belong to a key another replica just stored.
+func (m *KeyManager) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	m.mu.RLock()
+	key := m.findKey(kid)
+	reload := key == nil && m.now().Sub(m.loadedAt) >= unknownKidReloadInterval
+	m.mu.RUnlock()
+
+	if reload {
+		m.mu.Lock()
+		if m.now().Sub(m.loadedAt) >= unknownKidReloadInterval {
+			if err := m.reloadLocked(); err != nil {
+				m.mu.Unlock()
+				return nil, err
+			}
+		}
+		key = m.findKey(kid)
+		m.mu.Unlock()
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+func (m *KeyManager) findKey(kid string) *signingKey {
+	for _, k := range m.keys {
+		if k.id == kid {
+			return k
+		}
+	}
+	return nil
+}
+
+func (m *KeyManager) methods() []string {
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}
+}
+
+// JWKS returns the public keys verifiers should accept
+func (m *KeyManager) JWKS() JSONWebKeySet {
+	// Rotate here too, so a newly due key is published before it signs
+	_, _ = m.current()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(m.keys))}
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		set.Keys = append(set.Keys, publicJWK(m.keys[i]))
+	}
+	return set
+}
+
+// Algorithm is the alg the current key signs with
+func (m *KeyManager) Algorithm() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[len(m.keys)-1].method.Alg()
+}
+
+func generateSigningKey(algorithm string) (*signingKey, error) {
+	switch algorithm {
+	case jwt.SigningMethodES256.Alg():
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		return newSigningKey(priv)
+	default:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		return newSigningKey(priv)
+	}
+}
+
+// loadSigningKey reads a PKCS#8, PKCS#1 or SEC 1 PEM private key
+func loadSigningKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	return parseSigningKey(data, path)
+}
+
+// parseSigningKey decodes a PEM private key; name identifies it in errors
+func parseSigningKey(data []byte, name string) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", name)
+	}
+
+	var err error
+	var priv interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		priv, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	return newSigningKey(priv)
+}
+
+func newSigningKey(priv interface{}) (*signingKey, error) {
+	key := &signingKey{private: priv}
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < rsaKeyBits {
+			return nil, fmt.Errorf("rsa signing key must have at least %d bits", rsaKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.public = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ecdsa signing key must use P-256")
+		}
+		key.method = jwt.SigningMethodES256
+		key.public = &k.PublicKey
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", priv)
+	}
+	key.id = thumbprint(publicJWK(key))
+	return key, nil
+}
+
+func publicJWK(k *signingKey) JSONWebKey {
+	jwk := JSONWebKey{Use: "sig", Alg: k.method.Alg(), Kid: k.id}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64url(pub.N.Bytes())
+		jwk.E = b64url(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = b64url(pub.X.FillBytes(make([]byte, 32)))
+		jwk.Y = b64url(pub.Y.FillBytes(make([]byte, 32)))
+	}
+	return jwk
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint, used as kid so that replicas
+// loading the same key file agree on it.
+func thumbprint(jwk JSONWebKey) string {
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	}
+	raw, _ := json.Marshal(members)
+	sum := sha256.Sum256(raw)
+	return b64url(sum[:])
+}
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
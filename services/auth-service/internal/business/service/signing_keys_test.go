@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/config"
+	"github.com/erp-system/auth-service/internal/data/memory"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func parseWith(keys *KeyManager, tokenStr string) error {
+	_, err := jwt.Parse(tokenStr, keys.keyfunc, jwt.WithValidMethods(keys.methods()))
+	return err
+}
+
+func TestKeyManager_RotationKeepsOldTokensVerifiable(t *testing.T) {
+	keys, err := NewKeyManager(config.JWTConfig{SigningAlgorithm: "ES256", AccessExpiry: 60, KeyRotation: time.Hour}, memory.NewSigningKeyRepository())
+	if err != nil {
+		t.Fatalf("new key manager: %v", err)
+	}
+	clock := time.Now()
+	keys.now = func() time.Time { return clock }
+
+	old, err := keys.sign(jwt.MapClaims{"sub": "u1"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	oldKid := keys.JWKS().Keys[0].Kid
+
+	// The key is due; the JWKS publishes the new one before it signs
+	clock = clock.Add(time.Hour)
+	set := keys.JWKS()
+	if len(set.Keys) != 2 || set.Keys[1].Kid != oldKid {
+		t.Fatalf("expected the new key first and the retired one kept, got %+v", set.Keys)
+	}
+	fresh, _ := keys.sign(jwt.MapClaims{"sub": "u1"})
+	for name, tok := range map[string]string{"old": old, "fresh": fresh} {
+		if err := parseWith(keys, tok); err != nil {
+			t.Errorf("%s token: %v", name, err)
+		}
+	}
+
+	// Once every token the retired key signed has expired, it is dropped
+	clock = clock.Add(2 * time.Hour)
+	_ = keys.Rotate()
+	if err := parseWith(keys, old); err == nil {
+		t.Error("expected a token from a dropped key to be refused")
+	}
+	for _, k := range keys.JWKS().Keys {
+		if k.Kid == oldKid {
+			t.Error("dropped key is still published")
+		}
+	}
+}
+
+func TestKeyManager_RefusesOtherAlgorithms(t *testing.T) {
+	keys := newTestKeys()
+	kid := keys.JWKS().Keys[0].Kid
+
+	// An HMAC token keyed with public material must not pass as ES256
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1"})
+	token.Header["kid"] = kid
+	signed, _ := token.SignedString([]byte(kid))
+	if err := parseWith(keys, signed); err == nil {
+		t.Error("expected an HS256 token to be refused")
+	}
+
+	other := newTestKeys() // a separate key store
+	foreign, _ := other.sign(jwt.MapClaims{"sub": "u1"})
+	if err := parseWith(keys, foreign); err == nil {
+		t.Error("expected a token from an unknown key to be refused")
+	}
+}
+
+func TestKeyManager_LoadsKeyFile(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file seeds the shared store and decides the algorithm
+	store := memory.NewSigningKeyRepository()
+	cfg := config.JWTConfig{SigningAlgorithm: "RS256", SigningKeyFile: path, AccessExpiry: 60, KeyRotation: time.Hour}
+	a, err := NewKeyManager(cfg, store)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	b, _ := NewKeyManager(cfg, store)
+	if a.Algorithm() != "ES256" {
+		t.Errorf("algorithm = %s", a.Algorithm())
+	}
+	tok, _ := a.sign(jwt.MapClaims{"sub": "u1"})
+	if err := parseWith(b, tok); err != nil {
+		t.Errorf("expected another replica to verify the token: %v", err)
+	}
+
+	// A seeded key rotates like a generated one, keeping its algorithm
+	if err := a.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if n := len(a.JWKS().Keys); n != 2 || a.Algorithm() != "ES256" {
+		t.Errorf("expected an ES256 key next to the file's, got %d keys of %s", n, a.Algorithm())
+	}
+}
+
+func TestKeyManager_SharesKeysAcrossReplicas(t *testing.T) {
+	store := memory.NewSigningKeyRepository()
+	cfg := config.JWTConfig{SigningAlgorithm: "ES256", AccessExpiry: 60, KeyRotation: time.Hour}
+	a, err := NewKeyManager(cfg, store)
+	if err != nil {
+		t.Fatalf("new key manager: %v", err)
+	}
+	b, _ := NewKeyManager(cfg, store)
+	if a.JWKS().Keys[0].Kid != b.JWKS().Keys[0].Kid {
+		t.Fatal("expected replicas to start from the same key")
+	}
+
+	// Both replicas find the key due at once; only one new key is stored
+	clock := time.Now().Add(time.Hour)
+	a.now = func() time.Time { return clock }
+	b.now = func() time.Time { return clock }
+	fromA, _ := a.sign(jwt.MapClaims{"sub": "u1"})
+	fromB, _ := b.sign(jwt.MapClaims{"sub": "u1"})
+	if stored, _ := store.List(context.Background()); len(stored) != 2 {
+		t.Fatalf("expected one rotation in the store, got %d keys", len(stored))
+	}
+	if a.JWKS().Keys[0].Kid != b.JWKS().Keys[0].Kid {
+		t.Error("expected replicas to sign with the same new key")
+	}
+	for name, tok := range map[string]string{"a": fromA, "b": fromB} {
+		if err := parseWith(a, tok); err != nil {
+			t.Errorf("token from %s: %v", name, err)
+		}
+	}
+
+	// A rotation on one replica is verified by the other, and a restarted
+	// replica signs with the stored key instead of generating its own
+	clock = clock.Add(time.Minute)
+	_ = a.Rotate()
+	fresh, _ := a.sign(jwt.MapClaims{"sub": "u1"})
+	if err := parseWith(b, fresh); err != nil {
+		t.Errorf("expected the other replica to load the rotated key: %v", err)
+	}
+	restarted, _ := NewKeyManager(cfg, store)
+	if restarted.JWKS().Keys[0].Kid != a.JWKS().Keys[0].Kid {
+		t.Error("expected a restart to keep the current key")
+	}
+	if err := parseWith(restarted, fromA); err != nil {
+		t.Errorf("expected tokens from before the restart to verify: %v", err)
+	}
+}
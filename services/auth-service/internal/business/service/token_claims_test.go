@@ -96,8 +96,8 @@ func TestAuthService_ValidateToken_ReturnsTypedClaims(t *testing.T) {
 	if claims.UserID != created.ID {
 		t.Errorf("UserID: got %q, want %q", claims.UserID, created.ID)
 	}
-	if claims.TenantID == "" {
-		t.Errorf("TenantID should be populated (defaulted to \"default\")")
+	if claims.TenantID != created.LegalEntityID {
+		t.Errorf("TenantID: got %q, want the user's legal entity %q", claims.TenantID, created.LegalEntityID)
 	}
 	if claims.Roles == nil {
 		// nil slice is acceptable for CDD `list<string>`; we only require
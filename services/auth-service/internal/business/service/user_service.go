@@ -11,23 +11,35 @@ import (
 )
 
 type UserService struct {
-	userRepo  domain.UserRepository
-	usRepo    domain.UserStoreRepository
-	urRepo    domain.UserRoleRepository
-	publisher domain.EventPublisher
+	userRepo    domain.UserRepository
+	usRepo      domain.UserStoreRepository
+	urRepo      domain.UserRoleRepository
+	mfaRepo     domain.UserMFARepository
+	attemptRepo domain.LoginAttemptRepository
+	outboxRepo  domain.TransactionalOutboxRepository
+	tm          domain.TransactionManager
+	publisher   domain.EventPublisher
 }
 
 func NewUserService(
 	userRepo domain.UserRepository,
 	usRepo domain.UserStoreRepository,
 	urRepo domain.UserRoleRepository,
+	mfaRepo domain.UserMFARepository,
+	attemptRepo domain.LoginAttemptRepository,
+	outboxRepo domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
 	publisher domain.EventPublisher,
 ) *UserService {
 	return &UserService{
-		userRepo:  userRepo,
-		usRepo:    usRepo,
-		urRepo:    urRepo,
-		publisher: publisher,
+		userRepo:    userRepo,
+		usRepo:      usRepo,
+		urRepo:      urRepo,
+		mfaRepo:     mfaRepo,
+		attemptRepo: attemptRepo,
+		outboxRepo:  outboxRepo,
+		tm:          tm,
+		publisher:   publisher,
 	}
 }
 
@@ -69,17 +81,20 @@ func (s *UserService) CreateUser(ctx context.Context, u *domain.User, initialSto
 			RoleID:    roleID,
 			CreatedAt: time.Now(),
 		}
-		_ = s.urRepo.Create(ctx, ur)
-
-		// Publish user role assigned event
-		if err := s.publisher.Publish(ctx, domain.TopicAuthUserRoleAssigned, ur.ID, domain.UserRoleEventPayload{
-			ID:         ur.ID,
-			UserID:     ur.UserID,
-			RoleID:     ur.RoleID,
-			AssignedBy: "",
-			Timestamp:  time.Now(),
-		}); err != nil {
-			utils.LogPublishErr("auth-service", domain.TopicAuthUserRoleAssigned, err)
+		err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			if err := s.urRepo.Create(txCtx, ur); err != nil {
+				return err
+			}
+			return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserRoleAssigned, ur.ID, domain.UserRoleEventPayload{
+				ID:         ur.ID,
+				UserID:     ur.UserID,
+				RoleID:     ur.RoleID,
+				AssignedBy: "",
+				Timestamp:  time.Now(),
+			})
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -149,19 +164,19 @@ func (s *UserService) UpdateCredentials(ctx context.Context, userID string, newP
 	user.SecurityStamp = utils.NewID("ss")
 	user.UpdatedAt = time.Now()
 
-	err = s.userRepo.Update(ctx, user)
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthPasswordChanged, user.ID, domain.PasswordChangedEventPayload{
+			UserID:    user.ID,
+			Timestamp: time.Now(),
+		})
+	})
 	if err != nil {
 		return false, err
 	}
 
-	// Publish password changed event
-	if err := s.publisher.Publish(ctx, domain.TopicAuthPasswordChanged, user.ID, domain.PasswordChangedEventPayload{
-		UserID:    user.ID,
-		Timestamp: time.Now(),
-	}); err != nil {
-		utils.LogPublishErr("auth-service", domain.TopicAuthPasswordChanged, err)
-	}
-
 	return true, nil
 }
 
@@ -177,25 +192,80 @@ func (s *UserService) DeactivateUser(ctx context.Context, userID string) error {
 	// employee could keep using their old token until natural expiration.
 	user.SecurityStamp = utils.NewID("ss")
 	user.UpdatedAt = time.Now()
-	err = s.userRepo.Update(ctx, user)
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserSuspended, user.ID, domain.UserEventPayload{
+			ID:        user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			IsActive:  user.Status == domain.UserStatusACTIVE,
+			Timestamp: time.Now(),
+		})
+	})
+}
+
+// ResetMFA removes a user's authenticator and recovery codes, e.g. after a
+// lost phone. The user enrolls again on next login if a role requires MFA.
+func (s *UserService) ResetMFA(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
 	}
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.mfaRepo.DeleteByUserID(txCtx, userID); err != nil {
+			return err
+		}
 
-	// Publish user deactivated event
-	if err := s.publisher.Publish(ctx, domain.TopicAuthUserSuspended, user.ID, domain.UserEventPayload{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		IsActive:  user.Status == domain.UserStatusACTIVE,
-		Timestamp: time.Now(),
-	}); err != nil {
-		utils.LogPublishErr("auth-service", domain.TopicAuthUserSuspended, err)
+		// Bump the stamp so access tokens obtained with the old factor stop
+		// working; RefreshToken refuses their MFA-verified sessions.
+		user.SecurityStamp = utils.NewID("ss")
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserMfaReset, user.ID, domain.MFAEventPayload{
+			UserID:    user.ID,
+			Timestamp: time.Now(),
+		})
+	})
+}
+
+// UnlockUser lets a user locked out by failed logins sign in again before the
+// lockout expires. The failure counter for the username is cleared too.
+func (s *UserService) UnlockUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != domain.UserStatusLOCKED {
+		return nil, fmt.Errorf("user is not locked")
 	}
 
-	return nil
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		user.Status = domain.UserStatusACTIVE
+		user.LockedUntil = nil
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		if err := s.attemptRepo.DeleteByKey(txCtx, usernameAttemptKey(user.Username)); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserUnlocked, user.ID, domain.UserLockEventPayload{
+			UserID:    user.ID,
+			Username:  user.Username,
+			Reason:    "admin",
+			Timestamp: time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 func (s *UserService) AssignUserToStore(ctx context.Context, userID, storeID string) error {
@@ -206,24 +276,30 @@ func (s *UserService) AssignUserToStore(ctx context.Context, userID, storeID str
 		StoreID:    storeID,
 		AssignedAt: time.Now(),
 	}
-	err := s.usRepo.Create(ctx, us)
-	if err != nil {
-		return err
-	}
-
-	// Publish user store assigned event
-	if err := s.publisher.Publish(ctx, domain.TopicAuthUserStoreAssigned, us.ID, domain.UserStoreEventPayload{
-		ID:        us.ID,
-		UserID:    us.UserID,
-		StoreID:   us.StoreID,
-		Timestamp: time.Now(),
-	}); err != nil {
-		utils.LogPublishErr("auth-service", domain.TopicAuthUserStoreAssigned, err)
-	}
-
-	return nil
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.usRepo.Create(txCtx, us); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserStoreAssigned, us.ID, domain.UserStoreEventPayload{
+			ID:        us.ID,
+			UserID:    us.UserID,
+			StoreID:   us.StoreID,
+			Timestamp: time.Now(),
+		})
+	})
 }
 
+// RemoveUserFromStore drops the user's per-store grants for storeID; the
+// removal event lets token caches forget them right away.
 func (s *UserService) RemoveUserFromStore(ctx context.Context, userID, storeID string) error {
-	return s.usRepo.Delete(ctx, userID, storeID)
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.usRepo.Delete(txCtx, userID, storeID); err != nil {
+			return err
+		}
+		return writeOutbox(txCtx, s.outboxRepo, domain.TopicAuthUserStoreRemoved, userID, domain.UserStoreEventPayload{
+			UserID:    userID,
+			StoreID:   storeID,
+			Timestamp: time.Now(),
+		})
+	})
 }
@@ -58,9 +58,10 @@ func TestUserService_CreateUser(t *testing.T) {
 		userRepo := memory.NewUserRepository()
 		usRepo := memory.NewUserStoreRepository()
 		urRepo := memory.NewUserRoleRepository()
+		outboxRepo := memory.NewTransactionalOutboxRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), outboxRepo, memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -79,9 +80,12 @@ func TestUserService_CreateUser(t *testing.T) {
 			t.Error("expected SecurityStamp to be generated")
 		}
 
-		// Verify event publications
-		if len(pub.Events) != 4 {
-			t.Errorf("expected 4 events published (1 store assignment, 2 role assignments, 1 user created), got %d", len(pub.Events))
+		// Grant changes go through the outbox; user created is published
+		if records, _ := outboxRepo.GetPending(ctx, 10); len(records) != 3 {
+			t.Errorf("expected 3 outbox events (1 store assignment, 2 role assignments), got %d", len(records))
+		}
+		if len(pub.Events) != 1 || pub.Events[0].Topic != domain.TopicAuthUserCreated {
+			t.Errorf("expected the user created event to be published, got %+v", pub.Events)
 		}
 	})
 
@@ -91,7 +95,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		// bcrypt password length limit is 72 bytes
 		longPassword := strings.Repeat("a", 100)
@@ -116,7 +120,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -139,7 +143,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -161,7 +165,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			FailPublish: true,
 		}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -185,7 +189,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -230,7 +234,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		_, err := s.UpdateUser(ctx, "nonexistent", nil, nil, nil, nil)
 		if err == nil {
@@ -246,7 +250,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepoMock, usRepo, urRepo, pub)
+		s := NewUserService(userRepoMock, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -271,9 +275,10 @@ func TestUserService_UpdateCredentials(t *testing.T) {
 		userRepo := memory.NewUserRepository()
 		usRepo := memory.NewUserStoreRepository()
 		urRepo := memory.NewUserRoleRepository()
+		outboxRepo := memory.NewTransactionalOutboxRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), outboxRepo, memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -292,8 +297,9 @@ func TestUserService_UpdateCredentials(t *testing.T) {
 		if fresh.SecurityStamp == originalStamp {
 			t.Error("expected security stamp to be bumped")
 		}
-		if len(pub.Events) != 2 { // 1 user-created, 1 password-changed
-			t.Errorf("expected 2 events, got %d", len(pub.Events))
+		records, _ := outboxRepo.GetPending(ctx, 10)
+		if len(records) != 1 || records[0].EventType != domain.TopicAuthPasswordChanged {
+			t.Errorf("expected a password changed event in the outbox, got %+v", records)
 		}
 	})
 
@@ -303,7 +309,7 @@ func TestUserService_UpdateCredentials(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		_, err := s.UpdateCredentials(ctx, "nonexistent", "pw")
 		if err == nil {
@@ -317,7 +323,7 @@ func TestUserService_UpdateCredentials(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -341,7 +347,7 @@ func TestUserService_UpdateCredentials(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepoMock, usRepo, urRepo, pub)
+		s := NewUserService(userRepoMock, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -367,7 +373,7 @@ func TestUserService_DeactivateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		err := s.DeactivateUser(ctx, "nonexistent")
 		if err == nil {
@@ -383,7 +389,7 @@ func TestUserService_DeactivateUser(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepoMock, usRepo, urRepo, pub)
+		s := NewUserService(userRepoMock, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		u := &domain.User{
 			Username:     "john",
@@ -407,9 +413,10 @@ func TestUserService_AssignRemoveStore(t *testing.T) {
 		userRepo := memory.NewUserRepository()
 		usRepo := memory.NewUserStoreRepository()
 		urRepo := memory.NewUserRoleRepository()
+		outboxRepo := memory.NewTransactionalOutboxRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), outboxRepo, memory.NewTransactionManager(), pub)
 
 		err := s.AssignUserToStore(ctx, "u_1", "store_1")
 		if err != nil {
@@ -420,6 +427,22 @@ func TestUserService_AssignRemoveStore(t *testing.T) {
 		if err != nil {
 			t.Fatalf("remove from store failed: %v", err)
 		}
+
+		// Both changes alter the user's @store grants and go through the outbox
+		records, _ := outboxRepo.GetPending(ctx, 10)
+		written := make(map[string]domain.UserStoreEventPayload)
+		for _, rec := range records {
+			written[rec.EventType] = rec.Payload.(domain.UserStoreEventPayload)
+		}
+		if len(records) != 2 || written[domain.TopicAuthUserStoreAssigned].StoreID != "store_1" {
+			t.Fatalf("expected store assigned and removed events in the outbox, got %+v", records)
+		}
+		if removed := written[domain.TopicAuthUserStoreRemoved]; removed.UserID != "u_1" || removed.StoreID != "store_1" {
+			t.Errorf("unexpected store removed payload %+v", removed)
+		}
+		if len(pub.Events) != 0 {
+			t.Errorf("expected nothing published directly, got %d events", len(pub.Events))
+		}
 	})
 
 	t.Run("AssignError", func(t *testing.T) {
@@ -431,7 +454,7 @@ func TestUserService_AssignRemoveStore(t *testing.T) {
 		urRepo := memory.NewUserRoleRepository()
 		pub := &sharedtesting.MockPublisher{}
 
-		s := NewUserService(userRepo, usRepo, urRepo, pub)
+		s := NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), pub)
 
 		err := s.AssignUserToStore(ctx, "u_1", "store_1")
 		if err == nil || err.Error() != "db error" {
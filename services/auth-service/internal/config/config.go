@@ -1,23 +1,31 @@
 package config
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"log"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	Server ServerConfig
-	JWT    JWTConfig
-	Kafka  KafkaConfig
-	TLS    TLSConfig
+	Server  ServerConfig
+	JWT     JWTConfig
+	Kafka   KafkaConfig
+	TLS     TLSConfig
+	MFA     MFAConfig
+	Lockout LockoutConfig
+	OIDC    OIDCConfig
+	APIKeys APIKeyConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// TrustedProxies are the addresses or CIDRs, normally the API gateway,
+	// whose X-Forwarded-For is believed for the client IP that login
+	// throttling counts against. Empty trusts none.
+	TrustedProxies []string
 }
 
 type TLSConfig struct {
@@ -27,9 +35,111 @@ type TLSConfig struct {
 }
 
 type JWTConfig struct {
-	Secret        string
 	AccessExpiry  int // in minutes
 	RefreshExpiry int // in hours
+	// SigningAlgorithm is RS256 or ES256 for generated keys. A key loaded
+	// from SigningKeyFile signs with the algorithm that fits its type, and
+	// so do the keys that replace it.
+	SigningAlgorithm string
+	SigningKeyFile   string        // PEM private key stored as the first key when the key store is empty
+	KeyRotation      time.Duration // Age at which the current key is replaced; 0 never rotates
+}
+
+// OIDCConfig describes auth-service as an OpenID Connect provider. Issuer is
+// the public base URL of the auth API; the discovery document is served at
+// Issuer + "/.well-known/openid-configuration".
+type OIDCConfig struct {
+	Issuer   string
+	LoginURL string // Page that collects credentials for /oauth/authorize
+	Clients  []OAuthClient
+}
+
+// OAuthClient is a public client using the authorization code flow with
+// PKCE. Redirect URIs must match exactly.
+type OAuthClient struct {
+	ID           string
+	RedirectURIs []string
+}
+
+type MFAConfig struct {
+	Issuer string // Shown as the account label prefix in authenticator apps
+}
+
+// LockoutConfig controls brute-force protection on /login. Failures are
+// counted per username and per client IP within FailureWindow.
+type LockoutConfig struct {
+	MaxFailures     int           // Failures per username before the account is locked
+	IPMaxFailures   int           // Failures per IP, across usernames, before the IP is blocked
+	DelayAfter      int           // Failures before progressive delays start
+	BaseDelay       time.Duration // First delay; doubles with every further failure
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration // How long a lock or IP block lasts unless an admin clears it
+	FailureWindow   time.Duration // Failures older than this are forgotten
+	// IPBlockingDisabled turns off per-IP blocking. Load sets it when no
+	// proxy is trusted: behind the gateway every login would then count
+	// against the gateway's address and one client could block everyone.
+	IPBlockingDisabled bool
+}
+
+// DefaultLockoutConfig returns the settings used when none are configured
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		MaxFailures:     5,
+		IPMaxFailures:   20,
+		DelayAfter:      3,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutDuration: 15 * time.Minute,
+		FailureWindow:   15 * time.Minute,
+	}
+}
+
+// WithDefaults fills unset fields from DefaultLockoutConfig
+func (c LockoutConfig) WithDefaults() LockoutConfig {
+	d := DefaultLockoutConfig()
+	if c.MaxFailures <= 0 {
+		c.MaxFailures = d.MaxFailures
+	}
+	if c.IPMaxFailures <= 0 {
+		c.IPMaxFailures = d.IPMaxFailures
+	}
+	if c.DelayAfter <= 0 {
+		c.DelayAfter = d.DelayAfter
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = d.BaseDelay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = d.MaxDelay
+	}
+	if c.LockoutDuration <= 0 {
+		c.LockoutDuration = d.LockoutDuration
+	}
+	if c.FailureWindow <= 0 {
+		c.FailureWindow = d.FailureWindow
+	}
+	return c
+}
+
+// APIKeyConfig bounds the lifetime of service account API keys. Every key
+// expires; DefaultTTL applies when the request names no expiry.
+type APIKeyConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// WithDefaults fills unset fields with 90 days and a year
+func (c APIKeyConfig) WithDefaults() APIKeyConfig {
+	if c.DefaultTTL <= 0 {
+		c.DefaultTTL = 90 * 24 * time.Hour
+	}
+	if c.MaxTTL <= 0 {
+		c.MaxTTL = 365 * 24 * time.Hour
+	}
+	if c.DefaultTTL > c.MaxTTL {
+		c.DefaultTTL = c.MaxTTL
+	}
+	return c
 }
 
 type KafkaConfig struct {
@@ -42,15 +152,44 @@ func Load() (*Config, error) {
 		brokers = "localhost:9092"
 	}
 
+	lockout, err := loadLockoutConfig()
+	if err != nil {
+		return nil, err
+	}
+	keyRotation, err := getEnvDuration("JWT_KEY_ROTATION", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys := APIKeyConfig{}.WithDefaults()
+	if apiKeys.DefaultTTL, err = getEnvDuration("API_KEY_DEFAULT_TTL", apiKeys.DefaultTTL); err != nil {
+		return nil, err
+	}
+	if apiKeys.MaxTTL, err = getEnvDuration("API_KEY_MAX_TTL", apiKeys.MaxTTL); err != nil {
+		return nil, err
+	}
+	clients, err := parseOAuthClients(getEnv("OIDC_CLIENTS", "erp-frontend=http://localhost:3000/auth/callback"))
+	if err != nil {
+		return nil, err
+	}
+
+	var trustedProxies []string
+	if value := getEnv("TRUSTED_PROXIES", ""); value != "" {
+		trustedProxies = strings.Split(value, ",")
+	}
+	lockout.IPBlockingDisabled = len(trustedProxies) == 0
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8000"),
-			Env:  getEnv("ENV", "development"),
+			Port:           getEnv("PORT", "8000"),
+			Env:            getEnv("ENV", "development"),
+			TrustedProxies: trustedProxies,
 		},
 		JWT: JWTConfig{
-			Secret:        getJWTSecret(),
-			AccessExpiry:  60, // 1 hour
-			RefreshExpiry: 24, // 24 hours
+			AccessExpiry:     60, // 1 hour
+			RefreshExpiry:    24, // 24 hours
+			SigningAlgorithm: getEnv("JWT_SIGNING_ALG", "RS256"),
+			SigningKeyFile:   getEnv("JWT_SIGNING_KEY_FILE", ""),
+			KeyRotation:      keyRotation,
 		},
 		Kafka: KafkaConfig{
 			Brokers: strings.Split(brokers, ","),
@@ -60,26 +199,91 @@ func Load() (*Config, error) {
 			CertFile: getEnv("TLS_CERT_FILE", ""),
 			KeyFile:  getEnv("TLS_KEY_FILE", ""),
 		},
+		MFA: MFAConfig{
+			Issuer: getEnv("MFA_ISSUER", "ERP"),
+		},
+		Lockout: lockout,
+		OIDC: OIDCConfig{
+			Issuer:   strings.TrimSuffix(getEnv("OIDC_ISSUER", "http://localhost:8000/api/v1/auth"), "/"),
+			LoginURL: getEnv("OIDC_LOGIN_URL", "http://localhost:3000/login"),
+			Clients:  clients,
+		},
+		APIKeys: apiKeys.WithDefaults(),
 	}, nil
 }
 
-func getJWTSecret() string {
-	if val := os.Getenv("JWT_SECRET"); val != "" {
+func getEnv(key, defaultValue string) string {
+	if val := os.Getenv(key); val != "" {
 		return val
 	}
-	// Generate a random per-run secret for development
-	buf := make([]byte, 32)
-	if _, err := rand.Read(buf); err != nil {
-		log.Fatalf("Failed to generate random JWT secret: %v", err)
+	return defaultValue
+}
+
+func loadLockoutConfig() (LockoutConfig, error) {
+	c := DefaultLockoutConfig()
+	var err error
+	if c.MaxFailures, err = getEnvInt("LOGIN_MAX_FAILURES", c.MaxFailures); err != nil {
+		return c, err
+	}
+	if c.IPMaxFailures, err = getEnvInt("LOGIN_IP_MAX_FAILURES", c.IPMaxFailures); err != nil {
+		return c, err
+	}
+	if c.DelayAfter, err = getEnvInt("LOGIN_DELAY_AFTER", c.DelayAfter); err != nil {
+		return c, err
+	}
+	if c.BaseDelay, err = getEnvDuration("LOGIN_BASE_DELAY", c.BaseDelay); err != nil {
+		return c, err
 	}
-	secret := hex.EncodeToString(buf)
-	log.Printf("WARNING: JWT_SECRET not set. Generated random per-run secret. Tokens will be invalid after restart.")
-	return secret
+	if c.MaxDelay, err = getEnvDuration("LOGIN_MAX_DELAY", c.MaxDelay); err != nil {
+		return c, err
+	}
+	if c.LockoutDuration, err = getEnvDuration("LOGIN_LOCKOUT_DURATION", c.LockoutDuration); err != nil {
+		return c, err
+	}
+	if c.FailureWindow, err = getEnvDuration("LOGIN_FAILURE_WINDOW", c.FailureWindow); err != nil {
+		return c, err
+	}
+	return c, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
+func getEnvInt(key string, defaultValue int) (int, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
 	}
-	return defaultValue
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", key, val)
+	}
+	return n, nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration", key, val)
+	}
+	return d, nil
+}
+
+// parseOAuthClients reads "client=uri|uri" entries separated by commas, e.g.
+// "erp-frontend=https://erp.example.com/auth/callback|http://localhost:3000/auth/callback".
+func parseOAuthClients(spec string) ([]OAuthClient, error) {
+	var clients []OAuthClient
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, uris, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || uris == "" {
+			return nil, fmt.Errorf("invalid OIDC_CLIENTS entry %q", entry)
+		}
+		clients = append(clients, OAuthClient{ID: strings.TrimSpace(id), RedirectURIs: strings.Split(uris, "|")})
+	}
+	return clients, nil
 }
@@ -16,7 +16,7 @@ func newConsumerWithUser(t *testing.T, userID string) (*KafkaConsumer, *memory.U
 	userRepo := memory.NewUserRepository()
 	usRepo := memory.NewUserStoreRepository()
 	urRepo := memory.NewUserRoleRepository()
-	userSvc := service.NewUserService(userRepo, usRepo, urRepo, &silentPub{})
+	userSvc := service.NewUserService(userRepo, usRepo, urRepo, memory.NewUserMFARepository(), memory.NewLoginAttemptRepository(), memory.NewTransactionalOutboxRepository(), memory.NewTransactionManager(), &silentPub{})
 
 	// Create a user that maps to the HR employee.
 	u := &domain.User{
@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/auth-service/internal/business/domain"
+	"github.com/erp-system/auth-service/internal/data/memory"
+)
+
+type recordingPub struct {
+	fail   bool
+	topics []string
+}
+
+func (p *recordingPub) Publish(ctx context.Context, topic string, key string, payload interface{}) error {
+	if p.fail {
+		return errors.New("broker down")
+	}
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestOutboxRelayWorker_ProcessPending(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTransactionalOutboxRepository()
+	_ = repo.Create(ctx, &domain.TransactionalOutbox{
+		ID:          "outbox_1",
+		EventType:   domain.TopicAuthUserLocked,
+		AggregateID: "user_1",
+		Payload:     domain.UserLockEventPayload{UserID: "user_1", Reason: "failed_logins"},
+		Status:      domain.OutboxStatusPENDING,
+		CreatedAt:   time.Now(),
+	})
+
+	pub := &recordingPub{fail: true}
+	worker := NewOutboxRelayWorker(repo, pub, 0, 0)
+	worker.processPending(ctx)
+	pending, _ := repo.GetPending(ctx, 10)
+	if len(pending) != 1 || pending[0].Status != domain.OutboxStatusFAILED {
+		t.Fatalf("expected the record to be kept as FAILED, got %+v", pending)
+	}
+
+	// The broker recovers; the failed record goes out on the next tick
+	pub.fail = false
+	worker.processPending(ctx)
+	if len(pub.topics) != 1 || pub.topics[0] != domain.TopicAuthUserLocked {
+		t.Fatalf("expected one locked event, got %v", pub.topics)
+	}
+	if pending, _ := repo.GetPending(ctx, 10); len(pending) != 0 {
+		t.Errorf("expected nothing pending after a successful relay, got %d", len(pending))
+	}
+}
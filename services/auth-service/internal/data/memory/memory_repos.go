@@ -2,15 +2,19 @@ package memory
 
 import (
 	"context"
+	"erp-system/shared/utils"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/erp-system/auth-service/internal/business/domain"
 )
 
 type UserRepository struct {
-	mu    sync.RWMutex
-	users map[string]domain.User
+	mu        sync.RWMutex
+	users     map[string]domain.User
+	snapshots []map[string]domain.User
 }
 
 func NewUserRepository() *UserRepository {
@@ -19,6 +23,34 @@ func NewUserRepository() *UserRepository {
 	}
 }
 
+func (r *UserRepository) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.User, len(r.users))
+	for k, v := range r.users {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *UserRepository) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.users = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *UserRepository) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+}
+
 func (r *UserRepository) Create(ctx context.Context, u *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -190,6 +222,16 @@ func (r *RoleRepository) List(ctx context.Context) ([]domain.Role, error) {
 	return list, nil
 }
 
+func (r *RoleRepository) Update(ctx context.Context, role *domain.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[role.ID]; !ok {
+		return fmt.Errorf("role not found: %s", role.ID)
+	}
+	r.roles[role.ID] = *role
+	return nil
+}
+
 func (r *RoleRepository) Delete(ctx context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -375,3 +417,553 @@ func (r *RolePermissionRepository) Delete(ctx context.Context, roleID string, pe
 	}
 	return nil
 }
+
+type UserMFARepository struct {
+	mu      sync.RWMutex
+	records map[string]domain.UserMFA // keyed by user ID
+}
+
+func NewUserMFARepository() *UserMFARepository {
+	return &UserMFARepository{
+		records: make(map[string]domain.UserMFA),
+	}
+}
+
+// cloneMFA copies the recovery code slice so callers never share it with the store
+func cloneMFA(m domain.UserMFA) domain.UserMFA {
+	m.RecoveryCodeHashes = append([]string(nil), m.RecoveryCodeHashes...)
+	return m
+}
+
+func (r *UserMFARepository) Create(ctx context.Context, m *domain.UserMFA) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[m.UserID]; ok {
+		return fmt.Errorf("mfa already exists for user: %s", m.UserID)
+	}
+	r.records[m.UserID] = cloneMFA(*m)
+	return nil
+}
+
+func (r *UserMFARepository) GetByUserID(ctx context.Context, userID string) (*domain.UserMFA, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.records[userID]
+	if !ok {
+		return nil, fmt.Errorf("mfa not found: %s", userID)
+	}
+	m = cloneMFA(m)
+	return &m, nil
+}
+
+func (r *UserMFARepository) Update(ctx context.Context, m *domain.UserMFA) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[m.UserID]; !ok {
+		return fmt.Errorf("mfa not found: %s", m.UserID)
+	}
+	r.records[m.UserID] = cloneMFA(*m)
+	return nil
+}
+
+func (r *UserMFARepository) DeleteByUserID(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, userID)
+	return nil
+}
+
+type MFAChallengeRepository struct {
+	mu         sync.RWMutex
+	challenges map[string]domain.MfaChallenge
+}
+
+func NewMFAChallengeRepository() *MFAChallengeRepository {
+	return &MFAChallengeRepository{
+		challenges: make(map[string]domain.MfaChallenge),
+	}
+}
+
+func (r *MFAChallengeRepository) Create(ctx context.Context, c *domain.MfaChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.challenges[c.ID] = *c
+	return nil
+}
+
+func (r *MFAChallengeRepository) GetByToken(ctx context.Context, token string) (*domain.MfaChallenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.challenges {
+		if c.Token == token {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("mfa challenge not found")
+}
+
+// RecordAttempt reads and increments Attempts under one lock, the in-memory
+// counterpart of UPDATE ... SET attempts = attempts + 1 RETURNING attempts
+func (r *MFAChallengeRepository) RecordAttempt(ctx context.Context, id string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.challenges[id]
+	if !ok {
+		return 0, fmt.Errorf("mfa challenge not found: %s", id)
+	}
+	c.Attempts++
+	r.challenges[id] = c
+	return c.Attempts, nil
+}
+
+func (r *MFAChallengeRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.challenges[id]; !ok {
+		return fmt.Errorf("mfa challenge not found: %s", id)
+	}
+	delete(r.challenges, id)
+	return nil
+}
+
+type AuthorizationCodeRepository struct {
+	mu    sync.RWMutex
+	codes map[string]domain.AuthorizationCode
+}
+
+func NewAuthorizationCodeRepository() *AuthorizationCodeRepository {
+	return &AuthorizationCodeRepository{
+		codes: make(map[string]domain.AuthorizationCode),
+	}
+}
+
+func (r *AuthorizationCodeRepository) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[c.ID] = *c
+	return nil
+}
+
+func (r *AuthorizationCodeRepository) GetByCode(ctx context.Context, code string) (*domain.AuthorizationCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.codes {
+		if c.Code == code {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("authorization code not found")
+}
+
+// Delete fails when the code is already gone, so of two concurrent
+// exchanges of the same code only one succeeds.
+func (r *AuthorizationCodeRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codes[id]; !ok {
+		return fmt.Errorf("authorization code not found: %s", id)
+	}
+	delete(r.codes, id)
+	return nil
+}
+
+type LoginAttemptRepository struct {
+	mu        sync.RWMutex
+	attempts  map[string]domain.LoginAttempt // keyed by LoginAttempt.Key
+	snapshots []map[string]domain.LoginAttempt
+}
+
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		attempts: make(map[string]domain.LoginAttempt),
+	}
+}
+
+func (r *LoginAttemptRepository) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.LoginAttempt, len(r.attempts))
+	for k, v := range r.attempts {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *LoginAttemptRepository) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.attempts = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *LoginAttemptRepository) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+}
+
+func (r *LoginAttemptRepository) Create(ctx context.Context, a *domain.LoginAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[a.Key] = *a
+	return nil
+}
+
+func (r *LoginAttemptRepository) GetByKey(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.attempts[key]
+	if !ok {
+		return nil, fmt.Errorf("login attempt not found: %s", key)
+	}
+	return &a, nil
+}
+
+func (r *LoginAttemptRepository) Update(ctx context.Context, a *domain.LoginAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.attempts[a.Key]; !ok {
+		return fmt.Errorf("login attempt not found: %s", a.Key)
+	}
+	r.attempts[a.Key] = *a
+	return nil
+}
+
+// RecordFailure reads and increments the counter under one lock, the
+// in-memory counterpart of an upsert with failure_count = failure_count + 1
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[key]
+	if !ok {
+		a = domain.LoginAttempt{ID: utils.NewID("la"), Key: key, WindowStartedAt: now}
+	}
+	if now.Sub(a.WindowStartedAt) > window {
+		a.FailureCount = 0
+		a.WindowStartedAt = now
+	}
+	a.FailureCount++
+	a.LastFailureAt = now
+	r.attempts[key] = a
+	return &a, nil
+}
+
+func (r *LoginAttemptRepository) Block(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[key]
+	if !ok {
+		return fmt.Errorf("login attempt not found: %s", key)
+	}
+	a.BlockedUntil = &until
+	r.attempts[key] = a
+	return nil
+}
+
+func (r *LoginAttemptRepository) DeleteByKey(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, key)
+	return nil
+}
+
+type TransactionalOutboxRepository struct {
+	mu        sync.RWMutex
+	records   map[string]domain.TransactionalOutbox
+	snapshots []map[string]domain.TransactionalOutbox
+}
+
+func NewTransactionalOutboxRepository() *TransactionalOutboxRepository {
+	return &TransactionalOutboxRepository{
+		records: make(map[string]domain.TransactionalOutbox),
+	}
+}
+
+func (r *TransactionalOutboxRepository) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.TransactionalOutbox, len(r.records))
+	for k, v := range r.records {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *TransactionalOutboxRepository) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.records = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *TransactionalOutboxRepository) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+}
+
+func (r *TransactionalOutboxRepository) Create(ctx context.Context, rec *domain.TransactionalOutbox) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[rec.ID] = *rec
+	return nil
+}
+
+// GetPending returns PENDING and FAILED records, oldest first
+func (r *TransactionalOutboxRepository) GetPending(ctx context.Context, limit int) ([]domain.TransactionalOutbox, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.TransactionalOutbox
+	for _, rec := range r.records {
+		if rec.Status == domain.OutboxStatusPENDING || rec.Status == domain.OutboxStatusFAILED {
+			list = append(list, rec)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r *TransactionalOutboxRepository) UpdateStatus(ctx context.Context, id string, status domain.OutboxStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.records[id]
+	if !ok {
+		return fmt.Errorf("outbox record not found: %s", id)
+	}
+	rec.Status = status
+	if status == domain.OutboxStatusFAILED {
+		rec.RetryCount++
+	}
+	r.records[id] = rec
+	return nil
+}
+
+type ServiceAccountRepository struct {
+	mu       sync.RWMutex
+	accounts map[string]domain.ServiceAccount
+}
+
+func NewServiceAccountRepository() *ServiceAccountRepository {
+	return &ServiceAccountRepository{
+		accounts: make(map[string]domain.ServiceAccount),
+	}
+}
+
+// Create refuses a second account with the same name
+func (r *ServiceAccountRepository) Create(ctx context.Context, a *domain.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.accounts {
+		if existing.Name == a.Name {
+			return fmt.Errorf("service account already exists: %s", a.Name)
+		}
+	}
+	r.accounts[a.ID] = *a
+	return nil
+}
+
+func (r *ServiceAccountRepository) GetByID(ctx context.Context, id string) (*domain.ServiceAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("service account not found: %s", id)
+	}
+	return &a, nil
+}
+
+func (r *ServiceAccountRepository) GetByName(ctx context.Context, name string) (*domain.ServiceAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range r.accounts {
+		if a.Name == name {
+			return &a, nil
+		}
+	}
+	return nil, fmt.Errorf("service account not found: %s", name)
+}
+
+func (r *ServiceAccountRepository) List(ctx context.Context) ([]domain.ServiceAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.ServiceAccount, 0, len(r.accounts))
+	for _, a := range r.accounts {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (r *ServiceAccountRepository) Update(ctx context.Context, a *domain.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.accounts[a.ID]; !ok {
+		return fmt.Errorf("service account not found: %s", a.ID)
+	}
+	r.accounts[a.ID] = *a
+	return nil
+}
+
+type APIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]domain.ApiKey
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys: make(map[string]domain.ApiKey),
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *domain.ApiKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[k.ID] = *k
+	return nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (*domain.ApiKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("api key not found: %s", id)
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.ApiKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash {
+			return &k, nil
+		}
+	}
+	return nil, fmt.Errorf("api key not found")
+}
+
+func (r *APIKeyRepository) ListByServiceAccountID(ctx context.Context, accountID string) ([]domain.ApiKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.ApiKey
+	for _, k := range r.keys {
+		if k.ServiceAccountID == accountID {
+			list = append(list, k)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (r *APIKeyRepository) Update(ctx context.Context, k *domain.ApiKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[k.ID]; !ok {
+		return fmt.Errorf("api key not found: %s", k.ID)
+	}
+	r.keys[k.ID] = *k
+	return nil
+}
+
+type SigningKeyRepository struct {
+	mu   sync.RWMutex
+	keys []domain.SigningKey // oldest first
+}
+
+func NewSigningKeyRepository() *SigningKeyRepository {
+	return &SigningKeyRepository{}
+}
+
+func (r *SigningKeyRepository) List(ctx context.Context) ([]domain.SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.SigningKey(nil), r.keys...), nil
+}
+
+func (r *SigningKeyRepository) Rotate(ctx context.Context, currentID string, next *domain.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := ""
+	if n := len(r.keys); n > 0 && r.keys[n-1].RetiredAt == nil {
+		current = r.keys[n-1].ID
+	}
+	if current != currentID {
+		return domain.ErrSigningKeyRotated
+	}
+	if current != "" {
+		retiredAt := next.CreatedAt
+		r.keys[len(r.keys)-1].RetiredAt = &retiredAt
+	}
+	r.keys = append(r.keys, *next)
+	return nil
+}
+
+func (r *SigningKeyRepository) DeleteRetiredBefore(ctx context.Context, cutoff time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.keys[:0]
+	for _, k := range r.keys {
+		if k.RetiredAt == nil || !k.RetiredAt.Before(cutoff) {
+			kept = append(kept, k)
+		}
+	}
+	r.keys = kept
+	return nil
+}
+
+// Snapshotable repositories can take part in a TransactionManager transaction
+type Snapshotable interface {
+	TakeSnapshot()
+	RollbackSnapshot()
+	CommitSnapshot()
+}
+
+// TransactionManager implements domain.TransactionManager in memory. Writes
+// to the repositories it was given are rolled back when fn fails; calls are
+// serialized so one transaction never rolls back another's writes.
+type TransactionManager struct {
+	mu    sync.Mutex
+	repos []Snapshotable
+}
+
+func NewTransactionManager(repos ...interface{}) *TransactionManager {
+	tm := &TransactionManager{}
+	for _, repo := range repos {
+		if snap, ok := repo.(Snapshotable); ok {
+			tm.repos = append(tm.repos, snap)
+		}
+	}
+	return tm
+}
+
+func (m *TransactionManager) WithinTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, repo := range m.repos {
+		repo.TakeSnapshot()
+	}
+	if err := fn(ctx); err != nil {
+		for _, repo := range m.repos {
+			repo.RollbackSnapshot()
+		}
+		return err
+	}
+	for _, repo := range m.repos {
+		repo.CommitSnapshot()
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ CREATE TABLE IF NOT EXISTS users (
     first_name VARCHAR(255) NOT NULL,
     last_name VARCHAR(255) NOT NULL,
     status VARCHAR(255) NOT NULL,
+    locked_until TIMESTAMP,
     security_stamp VARCHAR(255) NOT NULL,
     version VARCHAR(255) NOT NULL,
     created_at TIMESTAMP NOT NULL,
@@ -24,6 +25,7 @@ CREATE TABLE IF NOT EXISTS sessions (
     ip_address VARCHAR(255),
     user_agent VARCHAR(255),
     is_revoked BOOLEAN NOT NULL,
+    mfa_verified BOOLEAN NOT NULL,
     expires_at TIMESTAMP NOT NULL,
     created_at TIMESTAMP NOT NULL
 );
@@ -33,11 +35,96 @@ CREATE TABLE IF NOT EXISTS roles (
     legal_entity_id UUID NOT NULL,
     name VARCHAR(255) NOT NULL,
     description TEXT NOT NULL,
+    mfa_required BOOLEAN NOT NULL,
     version VARCHAR(255) NOT NULL,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS user_mfas (
+    id UUID PRIMARY KEY NOT NULL,
+    user_id UUID UNIQUE NOT NULL REFERENCES users(id),
+    secret VARCHAR(255) NOT NULL,
+    is_enabled BOOLEAN NOT NULL,
+    last_used_step VARCHAR(255) NOT NULL,
+    recovery_code_hashes VARCHAR(255) NOT NULL,
+    enabled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+    id UUID PRIMARY KEY NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id),
+    token VARCHAR(255) UNIQUE NOT NULL,
+    enrollment_required BOOLEAN NOT NULL,
+    attempts VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(255),
+    user_agent VARCHAR(255),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id UUID PRIMARY KEY NOT NULL,
+    key VARCHAR(255) UNIQUE NOT NULL,
+    failure_count VARCHAR(255) NOT NULL,
+    window_started_at TIMESTAMP NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS authorization_codes (
+    id UUID PRIMARY KEY NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id),
+    code VARCHAR(255) UNIQUE NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    redirect_uri VARCHAR(255) NOT NULL,
+    scope VARCHAR(255) NOT NULL,
+    nonce VARCHAR(255),
+    code_challenge VARCHAR(255) NOT NULL,
+    code_challenge_method VARCHAR(255) NOT NULL,
+    mfa_verified BOOLEAN NOT NULL,
+    ip_address VARCHAR(255),
+    user_agent VARCHAR(255),
+    auth_time TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    role_ids VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY NOT NULL,
+    service_account_id UUID NOT NULL REFERENCES service_accounts(id),
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(255) UNIQUE NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(255),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    algorithm VARCHAR(255) NOT NULL,
+    private_key_pem VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    retired_at TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS permissions (
     id UUID PRIMARY KEY NOT NULL,
     legal_entity_id UUID NOT NULL,
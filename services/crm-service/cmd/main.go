@@ -15,6 +15,7 @@ import (
 	"github.com/erp-system/crm-service/internal/api/routes"
 	"github.com/erp-system/crm-service/internal/business/service"
 	"github.com/erp-system/crm-service/internal/config"
+	"github.com/erp-system/crm-service/internal/data/fm"
 	"github.com/erp-system/crm-service/internal/data/kafka"
 	"github.com/erp-system/crm-service/internal/data/sql"
 	"github.com/gin-gonic/gin"
@@ -61,7 +62,7 @@ func main() {
 	custSvc := service.NewCustomerService(custRepo, kafkaPub)
 	oppSvc := service.NewOpportunityService(oppRepo, oppStageHistoryRepo, kafkaPub)
 	leadSvc := service.NewLeadService(leadRepo, custSvc, oppSvc, kafkaPub)
-	orderSvc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, kafkaPub)
+	orderSvc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, kafkaPub, fm.NewCreditClient(cfg.Services.FMServiceURL))
 	quoteSvc := service.NewQuoteService(quoteRepo, quoteItemRepo, kafkaPub)
 	ticketSvc := service.NewServiceTicketService(ticketRepo, kafkaPub)
 	campSvc := service.NewCampaignService(campaignRepo, kafkaPub)
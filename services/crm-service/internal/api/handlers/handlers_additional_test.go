@@ -243,7 +243,7 @@ func setupFailingTestEnv() *gin.Engine {
 	custSvc := service.NewCustomerService(custRepo, publisher)
 	oppSvc := service.NewOpportunityService(oppRepo, historyRepo, publisher)
 	leadSvc := service.NewLeadService(leadRepo, custSvc, oppSvc, publisher)
-	orderSvc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, publisher)
+	orderSvc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, publisher, nil)
 	quoteSvc := service.NewQuoteService(quoteRepo, quoteLineRepo, publisher)
 	ticketSvc := service.NewServiceTicketService(ticketRepo, publisher)
 	campSvc := service.NewCampaignService(campRepo, publisher)
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrCreditRefused          = errors.New("credit check refused")
+	ErrCreditCheckUnavailable = errors.New("credit check unavailable")
+)
+
+// CreditDecision is the financial management verdict on a sales order.
+type CreditDecision struct {
+	Approved  bool            `json:"approved"`
+	Reason    string          `json:"reason"`
+	Available decimal.Decimal `json:"available"`
+}
+
+// CreditChecker asks financial management whether a customer may take on an order of the
+// given legal entity.
+type CreditChecker interface {
+	CheckCredit(ctx context.Context, legalEntityID, customerID, salesOrderID string, orderValue decimal.Decimal) (*CreditDecision, error)
+}
@@ -28,7 +28,7 @@ func setupConfirmFixtures(t *testing.T) (svc *service.SalesOrderService, orderRe
 	orderItemRepo = memory.NewSalesOrderLineRepository()
 	custRepo = memory.NewCustomerRepository()
 	pub = &sharedtesting.MockPublisher{}
-	svc = service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub)
+	svc = service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub, nil)
 	return
 }
 
@@ -157,7 +157,7 @@ func TestConfirmSalesOrder_CustomerNotFound(t *testing.T) {
 	orderItemRepo := memory.NewSalesOrderLineRepository()
 	custRepo := memory.NewCustomerRepository()
 	pub := &sharedtesting.MockPublisher{}
-	svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub)
+	svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub, nil)
 
 	ctx := context.Background()
 	order := &domain.SalesOrder{
@@ -200,7 +200,7 @@ func TestConfirmSalesOrder_EmptyItems(t *testing.T) {
 	orderItemRepo := memory.NewSalesOrderLineRepository()
 	custRepo := memory.NewCustomerRepository()
 	pub := &sharedtesting.MockPublisher{}
-	svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub)
+	svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub, nil)
 
 	ctx := context.Background()
 	cust := &domain.CustomerProfile{
@@ -243,7 +243,7 @@ func TestConfirmSalesOrder_InvalidItemQuantity(t *testing.T) {
 	orderItemRepo := memory.NewSalesOrderLineRepository()
 	custRepo := memory.NewCustomerRepository()
 	pub := &sharedtesting.MockPublisher{}
-	svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub)
+	svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub, nil)
 
 	ctx := context.Background()
 	cust := &domain.CustomerProfile{
@@ -294,3 +294,56 @@ func TestConfirmSalesOrder_InvalidItemQuantity(t *testing.T) {
 		t.Errorf("err = %v, want ErrInvalidItemQuantity", err)
 	}
 }
+
+type stubCreditChecker struct {
+	decision      *domain.CreditDecision
+	err           error
+	calls         int
+	legalEntityID string
+}
+
+func (s *stubCreditChecker) CheckCredit(ctx context.Context, legalEntityID, customerID, salesOrderID string, orderValue decimal.Decimal) (*domain.CreditDecision, error) {
+	s.calls++
+	s.legalEntityID = legalEntityID
+	return s.decision, s.err
+}
+
+func TestConfirmSalesOrder_CreditCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		checker *stubCreditChecker
+		wantErr error
+	}{
+		{"approved", &stubCreditChecker{decision: &domain.CreditDecision{Approved: true}}, nil},
+		{"refused", &stubCreditChecker{decision: &domain.CreditDecision{Reason: "order value exceeds available credit"}}, domain.ErrCreditRefused},
+		{"unavailable", &stubCreditChecker{err: domain.ErrCreditCheckUnavailable}, domain.ErrCreditCheckUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			orderRepo := memory.NewSalesOrderRepository()
+			orderItemRepo := memory.NewSalesOrderLineRepository()
+			custRepo := memory.NewCustomerRepository()
+			pub := &sharedtesting.MockPublisher{}
+			svc := service.NewSalesOrderService(orderRepo, orderItemRepo, custRepo, pub, tc.checker)
+			orderID, _ := seedDraftOrderWithCustomer(t, orderRepo, orderItemRepo, custRepo)
+
+			_, err := svc.ConfirmSalesOrder(context.Background(), orderID)
+			if !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.checker.calls != 1 {
+				t.Errorf("credit checker called %d times, want 1", tc.checker.calls)
+			}
+			if tc.checker.legalEntityID != "default_entity_id" {
+				t.Errorf("expected the order's legal entity to be checked, got %q", tc.checker.legalEntityID)
+			}
+			if tc.wantErr == nil {
+				return
+			}
+			order, _ := orderRepo.GetByID(context.Background(), orderID)
+			if order.Status != domain.SalesOrderStateDRAFT || len(pub.Events) != 0 {
+				t.Errorf("expected the order to stay DRAFT without events, got %s and %d events", order.Status, len(pub.Events))
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"erp-system/shared/utils"
+	"fmt"
 	"time"
 
 	"github.com/erp-system/crm-service/internal/business/domain"
@@ -21,6 +22,7 @@ type SalesOrderService struct {
 	orderItemRepo domain.SalesOrderLineRepository
 	customerRepo  domain.CustomerRepository
 	publisher     domain.EventPublisher
+	credit        domain.CreditChecker
 }
 
 func NewSalesOrderService(
@@ -28,12 +30,14 @@ func NewSalesOrderService(
 	orderItemRepo domain.SalesOrderLineRepository,
 	customerRepo domain.CustomerRepository,
 	publisher domain.EventPublisher,
+	credit domain.CreditChecker,
 ) *SalesOrderService {
 	return &SalesOrderService{
 		orderRepo:     orderRepo,
 		orderItemRepo: orderItemRepo,
 		customerRepo:  customerRepo,
 		publisher:     publisher,
+		credit:        credit,
 	}
 }
 
@@ -192,6 +196,17 @@ func (s *SalesOrderService) ConfirmSalesOrder(ctx context.Context, id string) (*
 		}
 	}
 
+	// Finance has the last word on credit; an order is not confirmed when the check cannot run
+	if s.credit != nil {
+		decision, err := s.credit.CheckCredit(ctx, order.LegalEntityID, order.CustomerID, order.ID, order.TotalGrossValue)
+		if err != nil {
+			return nil, err
+		}
+		if !decision.Approved {
+			return nil, fmt.Errorf("%w: %s", domain.ErrCreditRefused, decision.Reason)
+		}
+	}
+
 	order.MarkConfirmed(time.Now())
 	if err := s.orderRepo.Update(ctx, order); err != nil {
 		return nil, err
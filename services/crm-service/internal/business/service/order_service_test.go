@@ -16,7 +16,7 @@ func TestSalesOrderService_All(t *testing.T) {
 	orderLineRepo := memory.NewSalesOrderLineRepository()
 	custRepo := memory.NewCustomerRepository()
 	pub := &sharedtesting.MockPublisher{}
-	svc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, pub)
+	svc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, pub, nil)
 
 	ctx := context.Background()
 
@@ -187,7 +187,7 @@ func TestSalesOrderService_Errors(t *testing.T) {
 	orderLineRepo := memory.NewSalesOrderLineRepository()
 	custRepo := memory.NewCustomerRepository()
 	pub := &sharedtesting.MockPublisher{}
-	svc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, pub)
+	svc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, pub, nil)
 
 	ctx := context.Background()
 
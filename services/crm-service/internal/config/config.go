@@ -11,6 +11,7 @@ type Config struct {
 	Database DatabaseConfig
 	Kafka    KafkaConfig
 	TLS      TLSConfig
+	Services ServicesConfig
 }
 
 type ServerConfig struct {
@@ -33,6 +34,11 @@ type TLSConfig struct {
 	KeyFile  string
 }
 
+// ServicesConfig holds the base URLs of services called synchronously.
+type ServicesConfig struct {
+	FMServiceURL string
+}
+
 type KafkaConfig struct {
 	Brokers []string
 	GroupID string
@@ -66,6 +72,9 @@ func Load() (*Config, error) {
 			CertFile: getEnv("TLS_CERT_FILE", ""),
 			KeyFile:  getEnv("TLS_KEY_FILE", ""),
 		},
+		Services: ServicesConfig{
+			FMServiceURL: getEnv("FM_SERVICE_URL", "http://localhost:8001"),
+		},
 	}, nil
 }
 
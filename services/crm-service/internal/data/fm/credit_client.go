@@ -0,0 +1,68 @@
+package fm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erp-system/crm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// CreditClient runs the synchronous credit check of fm-service over HTTP.
+type CreditClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewCreditClient(baseURL string) *CreditClient {
+	return &CreditClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// CheckCredit names the order's legal entity in the body and in X-Legal-Entity-ID, so
+// fm-service reserves the approved order in that entity.
+func (c *CreditClient) CheckCredit(ctx context.Context, legalEntityID, customerID, salesOrderID string, orderValue decimal.Decimal) (*domain.CreditDecision, error) {
+	body, err := json.Marshal(map[string]string{
+		"legal_entity_id": legalEntityID,
+		"sales_order_id":  salesOrderID,
+		"order_value":     orderValue.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/customers/%s/credit/check", c.baseURL, url.PathEscape(customerID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if legalEntityID != "" {
+		req.Header.Set("X-Legal-Entity-ID", legalEntityID)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrCreditCheckUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: fm-service responded %d", domain.ErrCreditCheckUnavailable, resp.StatusCode)
+	}
+
+	var out struct {
+		Data domain.CreditDecision `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrCreditCheckUnavailable, err)
+	}
+	return &out.Data, nil
+}
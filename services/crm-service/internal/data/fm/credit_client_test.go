@@ -0,0 +1,36 @@
+package fm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCreditClientSendsTheOrdersLegalEntity(t *testing.T) {
+	var body map[string]string
+	var header, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, header = r.URL.Path, r.Header.Get("X-Legal-Entity-ID")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"data":{"approved":true,"available":"400"}}`))
+	}))
+	defer srv.Close()
+
+	decision, err := NewCreditClient(srv.URL).CheckCredit(context.Background(), "le_1", "cust_1", "so_1", decimal.NewFromInt(600))
+	if err != nil {
+		t.Fatalf("credit check failed: %v", err)
+	}
+	if !decision.Approved || !decision.Available.Equal(decimal.NewFromInt(400)) {
+		t.Errorf("unexpected decision %+v", decision)
+	}
+	if path != "/api/v1/customers/cust_1/credit/check" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if header != "le_1" || body["legal_entity_id"] != "le_1" || body["sales_order_id"] != "so_1" || body["order_value"] != "600" {
+		t.Errorf("expected the order's legal entity in header and body, got %q and %v", header, body)
+	}
+}
@@ -54,7 +54,7 @@ func setupTestEnv() *testEnv {
 	custSvc := service.NewCustomerService(custRepo, publisher)
 	oppSvc := service.NewOpportunityService(oppRepo, historyRepo, publisher)
 	leadSvc := service.NewLeadService(leadRepo, custSvc, oppSvc, publisher)
-	orderSvc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, publisher)
+	orderSvc := service.NewSalesOrderService(orderRepo, orderLineRepo, custRepo, publisher, nil)
 	interactionSvc := service.NewCustomerInteractionService(interactRepo, publisher)
 
 	consumer := NewKafkaConsumer([]string{"localhost:9092"}, "crm-group", publisher, orderSvc, leadSvc, oppSvc, interactionSvc)
@@ -60,8 +60,17 @@ func main() {
 	entryRepo := sql.NewSQLUniversalJournalEntryRepo(db)
 	invoiceRepo := sql.NewSQLArInvoiceRepo(db)
 	paymentRepo := sql.NewSQLPaymentRepo(db)
+	allocationRepo := sql.NewSQLPaymentAllocationRepo(db)
+	creditMemoRepo := sql.NewSQLArCreditMemoRepo(db)
+	onAccountCreditRepo := sql.NewSQLOnAccountCreditRepo(db)
 	budgetRepo := sql.NewSQLBudgetRepo(db)
+	budgetCommitmentRepo := sql.NewSQLBudgetCommitmentRepo(db)
+	budgetPolicyRepo := sql.NewSQLBudgetPolicyRepo(db)
 	vendorBillRepo := sql.NewSQLApVendorBillRepo(db)
+	vendorBillLineRepo := sql.NewSQLApVendorBillLineRepo(db)
+	purchaseOrderLineRepo := sql.NewSQLPurchaseOrderLineRepo(db)
+	goodsReceiptLineRepo := sql.NewSQLGoodsReceiptLineRepo(db)
+	matchToleranceRepo := sql.NewSQLMatchToleranceRepo(db)
 	outboxRepo := sql.NewSQLTransactionalOutboxRepo(db)
 
 	currencyRateRepo := sql.NewSQLCurrencyRateRepo(db)
@@ -70,64 +79,209 @@ func main() {
 	bankAccountRepo := sql.NewSQLBankAccountRepo(db)
 	customerCreditRepo := sql.NewSQLCustomerCreditRepo(db)
 	bankStatementRepo := sql.NewSQLBankStatementRepo(db)
+	reconMatchRepo := sql.NewSQLBankReconciliationMatchRepo(db)
+	reconExceptionRepo := sql.NewSQLBankReconciliationExceptionRepo(db)
+	payrollRunRepo := sql.NewSQLPayrollRunSnapshotRepo(db)
+	recurringCashItemRepo := sql.NewSQLRecurringCashItemRepo(db)
 
 	legalEntityRepo := sql.NewSQLLegalEntityRepo(db)
 	assetRepo := sql.NewSQLCapitalAssetRepo(db)
 	lineRepo := sql.NewSQLDepreciationScheduleLineRepo(db)
+	usageRepo := sql.NewSQLAssetUsageRecordRepo(db)
 	inboxRepo := sql.NewSQLKafkaEventInboxRepo(db)
 
+	fxRevaluationRepo := sql.NewSQLFxRevaluationRepo(db)
+	fiscalPeriodRepo := sql.NewSQLFiscalPeriodRepo(db)
+	intercompanyRepo := sql.NewSQLIntercompanyTransactionRepo(db)
+	consolidationGroupRepo := sql.NewSQLConsolidationGroupRepo(db)
+	taxRateRepo := sql.NewSQLTaxRateRepo(db)
+	taxJurisdictionRepo := sql.NewSQLTaxJurisdictionRepo(db)
+	taxExemptionRepo := sql.NewSQLTaxExemptionRepo(db)
+	taxTransactionRepo := sql.NewSQLTaxTransactionRepo(db)
+	dunningLevelRepo := sql.NewSQLDunningLevelRepo(db)
+	dunningRunRepo := sql.NewSQLDunningRunRepo(db)
+	dunningNoticeRepo := sql.NewSQLDunningNoticeRepo(db)
+	salesOrderExposureRepo := sql.NewSQLSalesOrderExposureRepo(db)
+	creditOverrideRepo := sql.NewSQLCreditOverrideRepo(db)
+	paymentRunRepo := sql.NewSQLPaymentRunRepo(db)
+	paymentRunLineRepo := sql.NewSQLPaymentRunLineRepo(db)
+	paymentFileRepo := sql.NewSQLPaymentFileRepo(db)
+	vendorBankAccountRepo := sql.NewSQLVendorBankAccountRepo(db)
+	journalTemplateRepo := sql.NewSQLJournalTemplateRepo(db)
+	allocationCycleRepo := sql.NewSQLAllocationCycleRepo(db)
+	allocationRunRepo := sql.NewSQLAllocationRunRepo(db)
+	keyFigureRepo := sql.NewSQLStatisticalKeyFigureRepo(db)
+	accountDeterminationRepo := sql.NewSQLAccountDeterminationRepo(db)
+
 	// Suppress unused variables to avoid compile errors
-	_ = currencyRateRepo
-	_ = fiscalYearRepo
-	_ = costCenterRepo
-	_ = bankAccountRepo
 	_ = customerCreditRepo
-	_ = bankStatementRepo
 
 	// Initialize application services
+	currencyConverter := service.NewCurrencyConverter(legalEntityRepo, currencyRateRepo)
 	generalLedgerSvc := service.NewGeneralLedgerService(
 		accountRepo,
+		accountDeterminationRepo,
 		entryRepo,
+		fiscalPeriodRepo,
+		currencyConverter,
 		outboxRepo,
 		tm,
 	)
+	taxSvc := service.NewTaxService(
+		taxRateRepo,
+		taxJurisdictionRepo,
+		taxExemptionRepo,
+		taxTransactionRepo,
+		generalLedgerSvc,
+		tm,
+	)
 	accountsReceivableSvc := service.NewAccountsReceivableService(
 		invoiceRepo,
 		customerCreditRepo,
+		salesOrderExposureRepo,
+		creditOverrideRepo,
+		currencyConverter,
+		taxSvc,
 		outboxRepo,
 		tm,
 	)
-	cashManagementSvc := service.NewCashManagementService(
-		paymentRepo,
+	foreignExchangeSvc := service.NewForeignExchangeService(
+		currencyConverter,
 		invoiceRepo,
-		bankStatementRepo,
+		vendorBillRepo,
+		bankAccountRepo,
+		fxRevaluationRepo,
+		generalLedgerSvc,
 		outboxRepo,
 		tm,
 	)
+	cashManagementSvc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         paymentRepo,
+		Invoices:         invoiceRepo,
+		Bills:            vendorBillRepo,
+		Allocations:      allocationRepo,
+		CreditMemos:      creditMemoRepo,
+		OnAccountCredits: onAccountCreditRepo,
+		Statements:       bankStatementRepo,
+		BankAccounts:     bankAccountRepo,
+		Matches:          reconMatchRepo,
+		Exceptions:       reconExceptionRepo,
+		PayrollRuns:      payrollRunRepo,
+		RecurringItems:   recurringCashItemRepo,
+		GL:               generalLedgerSvc,
+		FX:               foreignExchangeSvc,
+		Outbox:           outboxRepo,
+		TM:               tm,
+	})
 	accountsPayableSvc := service.NewAccountsPayableService(
 		vendorBillRepo,
+		vendorBillLineRepo,
+		purchaseOrderLineRepo,
+		goodsReceiptLineRepo,
+		matchToleranceRepo,
+		currencyConverter,
+		taxSvc,
 		outboxRepo,
 		tm,
 	)
 	budgetingSvc := service.NewBudgetingService(
 		budgetRepo,
+		budgetCommitmentRepo,
+		budgetPolicyRepo,
+		fiscalYearRepo,
 		accountRepo,
 		entryRepo,
 		outboxRepo,
 		tm,
 	)
+	financialReportSvc := service.NewFinancialReportService(
+		accountRepo,
+		entryRepo,
+		budgetRepo,
+	)
 	legalEntitySvc := service.NewLegalEntityService(
 		legalEntityRepo,
 		tm,
 	)
+	periodCloseSvc := service.NewPeriodCloseService(
+		fiscalPeriodRepo,
+		fiscalYearRepo,
+		accountRepo,
+		entryRepo,
+		bankStatementRepo,
+		bankAccountRepo,
+		assetRepo,
+		lineRepo,
+		generalLedgerSvc,
+		outboxRepo,
+		tm,
+	)
+	intercompanySvc := service.NewIntercompanyService(
+		intercompanyRepo,
+		accountRepo,
+		legalEntityRepo,
+		generalLedgerSvc,
+		outboxRepo,
+		tm,
+	)
+	consolidationSvc := service.NewConsolidationService(
+		consolidationGroupRepo,
+		legalEntityRepo,
+		accountRepo,
+		entryRepo,
+		currencyConverter,
+		tm,
+	)
 	capitalAssetSvc := service.NewCapitalAssetService(
 		assetRepo,
 		lineRepo,
+		usageRepo,
 		accountRepo,
 		entryRepo,
 		outboxRepo,
 		tm,
 	)
+	dunningSvc := service.NewDunningService(
+		dunningLevelRepo,
+		dunningRunRepo,
+		dunningNoticeRepo,
+		invoiceRepo,
+		accountsReceivableSvc,
+		generalLedgerSvc,
+		outboxRepo,
+		tm,
+	)
+	paymentRunSvc := service.NewPaymentRunService(
+		paymentRunRepo,
+		paymentRunLineRepo,
+		paymentFileRepo,
+		vendorBankAccountRepo,
+		vendorBillRepo,
+		bankAccountRepo,
+		legalEntityRepo,
+		cashManagementSvc,
+		currencyConverter,
+		generalLedgerSvc,
+		outboxRepo,
+		tm,
+	)
+	recurringJournalSvc := service.NewRecurringJournalService(
+		journalTemplateRepo,
+		entryRepo,
+		accountRepo,
+		generalLedgerSvc,
+		tm,
+	)
+	costAllocationSvc := service.NewCostAllocationService(
+		allocationCycleRepo,
+		allocationRunRepo,
+		keyFigureRepo,
+		costCenterRepo,
+		accountRepo,
+		entryRepo,
+		generalLedgerSvc,
+		tm,
+	)
 
 	// Context for background processes
 	ctx, cancel := context.WithCancel(context.Background())
@@ -137,6 +291,14 @@ func main() {
 	outboxWorker := kafkaData.NewOutboxRelayWorker(outboxRepo, kafkaPublisher, 5*time.Second, 100)
 	go outboxWorker.Start(ctx)
 
+	// Run dunning daily for every legal entity with dunning levels
+	dunningScheduler := service.NewDunningScheduler(dunningSvc, legalEntityRepo, 24*time.Hour)
+	go dunningScheduler.Start(ctx)
+
+	// Generate recurring journals and reverse accruals daily
+	recurringJournalScheduler := service.NewRecurringJournalScheduler(recurringJournalSvc, 24*time.Hour)
+	go recurringJournalScheduler.Start(ctx)
+
 	// Initialize and start Kafka Consumer in the background
 	kafkaConsumer := kafkaData.NewKafkaConsumer(
 		cfg.Kafka.Brokers,
@@ -147,7 +309,10 @@ func main() {
 		accountsReceivableSvc,
 		cashManagementSvc,
 		budgetingSvc,
+		capitalAssetSvc,
+		costAllocationSvc,
 		inboxRepo,
+		tm,
 	)
 	go kafkaConsumer.Start(ctx)
 	defer kafkaConsumer.Close()
@@ -155,19 +320,30 @@ func main() {
 	// Initialize handlers
 	accHandler := handlers.NewAccountHandler(generalLedgerSvc, responseHelper)
 	txHandler := handlers.NewTransactionHandler(generalLedgerSvc, responseHelper)
-	repHandler := handlers.NewReportHandler(generalLedgerSvc, responseHelper)
+	repHandler := handlers.NewReportHandler(financialReportSvc, responseHelper)
 	invHandler := handlers.NewInvoiceHandler(accountsReceivableSvc, responseHelper)
 	payHandler := handlers.NewPaymentHandler(cashManagementSvc, responseHelper)
 	billHandler := handlers.NewVendorBillHandler(accountsPayableSvc, responseHelper)
 	leHandler := handlers.NewLegalEntityHandler(legalEntitySvc, responseHelper)
 	assetHandler := handlers.NewAssetHandler(capitalAssetSvc, responseHelper)
+	reconHandler := handlers.NewReconciliationHandler(cashManagementSvc, responseHelper)
+	fxHandler := handlers.NewFxRevaluationHandler(foreignExchangeSvc, responseHelper)
+	periodHandler := handlers.NewPeriodHandler(periodCloseSvc, responseHelper)
+	icHandler := handlers.NewIntercompanyHandler(intercompanySvc, responseHelper)
+	consolidationHandler := handlers.NewConsolidationHandler(consolidationSvc, responseHelper)
+	budgetHandler := handlers.NewBudgetHandler(budgetingSvc, responseHelper)
+	taxHandler := handlers.NewTaxHandler(taxSvc, responseHelper)
+	dunningHandler := handlers.NewDunningHandler(dunningSvc, responseHelper)
+	paymentRunHandler := handlers.NewPaymentRunHandler(paymentRunSvc, responseHelper)
+	journalTemplateHandler := handlers.NewJournalTemplateHandler(recurringJournalSvc, responseHelper)
+	allocationHandler := handlers.NewCostAllocationHandler(costAllocationSvc, responseHelper)
 
 	// Initialize Gin router
 	router := gin.Default()
 	router.Use(utils.TracingMiddleware("fm-service"))
 
 	// Setup routes
-	routes.SetupRoutes(router, cfg, accHandler, txHandler, repHandler, invHandler, payHandler, billHandler, leHandler, assetHandler)
+	routes.SetupRoutes(router, cfg, accHandler, txHandler, repHandler, invHandler, payHandler, billHandler, leHandler, assetHandler, reconHandler, fxHandler, periodHandler, icHandler, consolidationHandler, budgetHandler, taxHandler, dunningHandler, paymentRunHandler, journalTemplateHandler, allocationHandler)
 
 	// Start server
 	log.Printf("Financial Management Service starting on port %s", cfg.Server.Port)
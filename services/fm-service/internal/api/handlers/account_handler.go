@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"erp-system/shared/utils"
+	"errors"
 	"net/http"
 
+	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/gin-gonic/gin"
 )
@@ -100,5 +102,47 @@ func (h *AccountHandler) GetAccountBalance(c *gin.Context) {
 		h.response.NotFound(c, "account not found")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"balance": balance})
+	byCurrency, err := h.svc.GetAccountBalancesByCurrency(c.Request.Context(), id)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"balance": balance, "balances_by_currency": byCurrency})
+}
+
+func (h *AccountHandler) GetAccountDeterminations(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	if legalEntityID == "" {
+		h.response.BadRequest(c, "legal_entity_id is required")
+		return
+	}
+	determinations, err := h.svc.ListAccountDeterminations(c.Request.Context(), legalEntityID)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": determinations})
+}
+
+func (h *AccountHandler) SetAccountDetermination(c *gin.Context) {
+	var req struct {
+		LegalEntityID string `json:"legal_entity_id"`
+		AccountCode   string `json:"account_code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	determination, err := h.svc.SetAccountDetermination(c.Request.Context(), req.LegalEntityID, domain.PostingKey(c.Param("key")), req.AccountCode)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAccountDetermination) {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": determination})
 }
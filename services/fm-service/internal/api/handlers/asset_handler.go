@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"erp-system/shared/utils"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -23,11 +28,17 @@ func NewAssetHandler(svc *service.CapitalAssetService, response *utils.ResponseH
 
 func (h *AssetHandler) CapitalizeAsset(c *gin.Context) {
 	var req struct {
-		LegalEntityID    string  `json:"legal_entity_id"`
-		AssetTag         string  `json:"asset_tag"`
-		AcquisitionCost  string  `json:"acquisition_cost"`
-		UsefulLifeMonths int     `json:"useful_life_months"`
-		EamEquipmentID   *string `json:"eam_equipment_id,omitempty"`
+		LegalEntityID          string    `json:"legal_entity_id"`
+		AssetTag               string    `json:"asset_tag"`
+		AcquisitionCost        string    `json:"acquisition_cost"`
+		UsefulLifeMonths       int       `json:"useful_life_months"`
+		EamEquipmentID         *string   `json:"eam_equipment_id,omitempty"`
+		CapitalizationDate     time.Time `json:"capitalization_date"`
+		DepreciationMethod     string    `json:"depreciation_method"`
+		DepreciationConvention string    `json:"depreciation_convention"`
+		SalvageValue           string    `json:"salvage_value"`
+		DecliningBalanceRate   string    `json:"declining_balance_rate"`
+		EstimatedTotalUnits    string    `json:"estimated_total_units"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,8 +51,34 @@ func (h *AssetHandler) CapitalizeAsset(c *gin.Context) {
 		h.response.BadRequest(c, "invalid acquisition cost format")
 		return
 	}
+	var optional [3]decimal.Decimal
+	for i, field := range []struct{ name, value string }{
+		{"salvage value", req.SalvageValue},
+		{"declining balance rate", req.DecliningBalanceRate},
+		{"estimated total units", req.EstimatedTotalUnits},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if optional[i], err = decimal.NewFromString(field.value); err != nil {
+			h.response.BadRequest(c, "invalid "+field.name+" format")
+			return
+		}
+	}
 
-	asset, err := h.svc.CapitalizeAsset(c.Request.Context(), req.LegalEntityID, req.AssetTag, costDec, req.UsefulLifeMonths, req.EamEquipmentID)
+	asset, err := h.svc.CapitalizeAsset(c.Request.Context(), service.CapitalizeAssetRequest{
+		LegalEntityID:        req.LegalEntityID,
+		AssetTag:             req.AssetTag,
+		AcquisitionCost:      costDec,
+		UsefulLifeMonths:     req.UsefulLifeMonths,
+		EquipmentID:          req.EamEquipmentID,
+		CapitalizationDate:   req.CapitalizationDate,
+		Method:               domain.DepreciationMethod(strings.ToUpper(req.DepreciationMethod)),
+		Convention:           domain.DepreciationConvention(strings.ToUpper(req.DepreciationConvention)),
+		SalvageValue:         optional[0],
+		DecliningBalanceRate: optional[1],
+		EstimatedTotalUnits:  optional[2],
+	})
 	if err != nil {
 		h.response.BadRequest(c, err.Error())
 		return
@@ -72,7 +109,7 @@ func (h *AssetHandler) PostMonthlyDepreciation(c *gin.Context) {
 		return
 	}
 
-	err := h.svc.PostMonthlyStraightLineDepreciation(c.Request.Context(), req.LegalEntityID, req.FiscalYear, req.PeriodNumber)
+	err := h.svc.PostMonthlyDepreciation(c.Request.Context(), req.LegalEntityID, req.FiscalYear, req.PeriodNumber)
 	if err != nil {
 		h.response.BadRequest(c, err.Error())
 		return
@@ -81,6 +118,93 @@ func (h *AssetHandler) PostMonthlyDepreciation(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "depreciation posted successfully"})
 }
 
+func (h *AssetHandler) DisposeAsset(c *gin.Context) {
+	var req struct {
+		DisposalDate      time.Time `json:"disposal_date"`
+		Proceeds          string    `json:"proceeds"`
+		ProceedsAccountID string    `json:"proceeds_account_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	proceeds := decimal.Zero
+	if req.Proceeds != "" {
+		var err error
+		if proceeds, err = decimal.NewFromString(req.Proceeds); err != nil {
+			h.response.BadRequest(c, "invalid proceeds format")
+			return
+		}
+	}
+
+	asset, err := h.svc.DisposeAsset(c.Request.Context(), c.Param("id"), service.DisposeAssetRequest{
+		DisposalDate:      req.DisposalDate,
+		Proceeds:          proceeds,
+		ProceedsAccountID: req.ProceedsAccountID,
+	})
+	if err != nil {
+		h.assetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": asset})
+}
+
+func (h *AssetHandler) RecordUsage(c *gin.Context) {
+	var req struct {
+		UsageDate time.Time `json:"usage_date"`
+		Units     string    `json:"units" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	units, err := decimal.NewFromString(req.Units)
+	if err != nil {
+		h.response.BadRequest(c, "invalid units format")
+		return
+	}
+	if req.UsageDate.IsZero() {
+		req.UsageDate = time.Now()
+	}
+
+	record, err := h.svc.RecordUsage(c.Request.Context(), c.Param("id"), req.UsageDate, units, "MANUAL")
+	if err != nil {
+		h.assetError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": record})
+}
+
+func (h *AssetHandler) GetUsage(c *gin.Context) {
+	usage, err := h.svc.ListUsage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+func (h *AssetHandler) assetError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrAssetNotActive) {
+		h.response.ConflictErr(c, err)
+		return
+	}
+	h.response.BadRequest(c, err.Error())
+}
+
+// AssetScope keeps /assets/:id routes to the caller's legal entity
+func (h *AssetHandler) AssetScope() gin.HandlerFunc {
+	return entityScope(h.response, func(ctx context.Context, id string) (string, error) {
+		asset, err := h.svc.GetAsset(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return asset.LegalEntityID, nil
+	})
+}
+
 func (h *AssetHandler) GetAsset(c *gin.Context) {
 	id := c.Param("id")
 	asset, err := h.svc.GetAsset(c.Request.Context(), id)
@@ -92,7 +216,7 @@ func (h *AssetHandler) GetAsset(c *gin.Context) {
 }
 
 func (h *AssetHandler) GetAssets(c *gin.Context) {
-	list, err := h.svc.ListAssets(c.Request.Context())
+	list, err := h.svc.ListAssets(c.Request.Context(), c.GetHeader(legalEntityHeader))
 	if err != nil {
 		h.response.InternalErr(c, err)
 		return
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type BudgetHandler struct {
+	svc      *service.BudgetingService
+	response *utils.ResponseHelper
+}
+
+func NewBudgetHandler(svc *service.BudgetingService, response *utils.ResponseHelper) *BudgetHandler {
+	return &BudgetHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *BudgetHandler) GetBudgets(c *gin.Context) {
+	budgets, err := h.svc.ListBudgets(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": budgets})
+}
+
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	var req struct {
+		AccountID       string `json:"account_id" binding:"required"`
+		CostCenterID    string `json:"cost_center_id"`
+		FiscalYear      int    `json:"fiscal_year" binding:"required"`
+		Period          int    `json:"period" binding:"required"`
+		AllocatedAmount string `json:"allocated_amount" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	amount, err := decimal.NewFromString(req.AllocatedAmount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid allocated amount")
+		return
+	}
+
+	budget, err := h.svc.CreateBudget(c.Request.Context(), req.AccountID, req.CostCenterID, req.FiscalYear, req.Period, amount)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": budget})
+}
+
+func (h *BudgetHandler) SetBudgetVersion(c *gin.Context) {
+	var req struct {
+		AllocatedAmount string `json:"allocated_amount" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	amount, err := decimal.NewFromString(req.AllocatedAmount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid allocated amount")
+		return
+	}
+
+	version := domain.BudgetVersion(strings.ToUpper(c.Param("version")))
+	budget, err := h.svc.SetBudgetVersion(c.Request.Context(), c.Param("id"), version, amount)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": budget})
+}
+
+func (h *BudgetHandler) GetBudgetVsActual(c *gin.Context) {
+	accountID := c.Query("account_id")
+	fiscalYear, err := strconv.Atoi(c.Query("fiscal_year"))
+	if accountID == "" || err != nil {
+		h.response.BadRequest(c, "account_id and fiscal_year are required")
+		return
+	}
+
+	report, err := h.svc.GetBudgetVsActualReport(c.Request.Context(), accountID, c.Query("cost_center_id"), fiscalYear)
+	if err != nil {
+		h.response.NotFound(c, "account not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func (h *BudgetHandler) CheckBudget(c *gin.Context) {
+	req, ok := h.bindCheck(c)
+	if !ok {
+		return
+	}
+	result, err := h.svc.CheckBudget(c.Request.Context(), req)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *BudgetHandler) TrackExpense(c *gin.Context) {
+	req, ok := h.bindCheck(c)
+	if !ok {
+		return
+	}
+	result, err := h.svc.TrackBudgetExpense(c.Request.Context(), req)
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *BudgetHandler) GetCommitments(c *gin.Context) {
+	status := domain.BudgetCommitmentStatus(strings.ToUpper(c.Query("status")))
+	commitments, err := h.svc.ListCommitments(c.Request.Context(), status)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": commitments})
+}
+
+func (h *BudgetHandler) ApproveCommitment(c *gin.Context) {
+	commitment, err := h.svc.ApproveCommitment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": commitment})
+}
+
+func (h *BudgetHandler) RejectCommitment(c *gin.Context) {
+	commitment, err := h.svc.RejectCommitment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": commitment})
+}
+
+func (h *BudgetHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.svc.ListBudgetPolicies(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+func (h *BudgetHandler) SetPolicy(c *gin.Context) {
+	var req struct {
+		Enforcement      string `json:"enforcement" binding:"required"`
+		TolerancePercent string `json:"tolerance_percent"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	tolerance := decimal.Zero
+	if req.TolerancePercent != "" {
+		var err error
+		if tolerance, err = decimal.NewFromString(req.TolerancePercent); err != nil {
+			h.response.BadRequest(c, "invalid tolerance percent")
+			return
+		}
+	}
+
+	policy, err := h.svc.SetBudgetPolicy(c.Request.Context(), c.Param("cost_center_id"), domain.BudgetEnforcement(strings.ToUpper(req.Enforcement)), tolerance)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+func (h *BudgetHandler) bindCheck(c *gin.Context) (service.BudgetCheckRequest, bool) {
+	var req struct {
+		AccountID        string `json:"account_id" binding:"required"`
+		CostCenterID     string `json:"cost_center_id"`
+		FiscalYear       int    `json:"fiscal_year" binding:"required"`
+		Period           int    `json:"period" binding:"required"`
+		Amount           string `json:"amount" binding:"required"`
+		SourceDocumentID string `json:"source_document_id"`
+		Approved         bool   `json:"approved"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return service.BudgetCheckRequest{}, false
+	}
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid amount")
+		return service.BudgetCheckRequest{}, false
+	}
+	return service.BudgetCheckRequest{
+		AccountID:        req.AccountID,
+		CostCenterID:     req.CostCenterID,
+		FiscalYear:       req.FiscalYear,
+		Period:           req.Period,
+		Amount:           amount,
+		SourceDocumentID: req.SourceDocumentID,
+		Approved:         req.Approved,
+	}, true
+}
+
+func (h *BudgetHandler) budgetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBudgetExceeded), errors.Is(err, domain.ErrBudgetApprovalRequired), errors.Is(err, domain.ErrCommitmentNotApprovable):
+		h.response.ConflictErr(c, err)
+	default:
+		h.response.BadRequest(c, err.Error())
+	}
+}
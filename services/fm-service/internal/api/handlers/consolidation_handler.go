@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConsolidationHandler struct {
+	svc      *service.ConsolidationService
+	response *utils.ResponseHelper
+}
+
+func NewConsolidationHandler(svc *service.ConsolidationService, response *utils.ResponseHelper) *ConsolidationHandler {
+	return &ConsolidationHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *ConsolidationHandler) GetGroups(c *gin.Context) {
+	groups, err := h.svc.ListGroups(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+func (h *ConsolidationHandler) GetGroup(c *gin.Context) {
+	group, members, err := h.svc.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.NotFound(c, "consolidation group not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":    group,
+		"members": members,
+	})
+}
+
+func (h *ConsolidationHandler) CreateGroup(c *gin.Context) {
+	var req struct {
+		Code              string   `json:"code" binding:"required"`
+		Name              string   `json:"name" binding:"required"`
+		ReportingCurrency string   `json:"reporting_currency" binding:"required"`
+		LegalEntityIDs    []string `json:"legal_entity_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	group, members, err := h.svc.CreateGroup(c.Request.Context(), req.Code, req.Name, req.ReportingCurrency, req.LegalEntityIDs)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    group,
+		"members": members,
+	})
+}
+
+func (h *ConsolidationHandler) GetConsolidatedStatements(c *gin.Context) {
+	asOf := time.Now()
+	if v := c.Query("as_of"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			h.response.BadRequest(c, "invalid as_of date, expected YYYY-MM-DD")
+			return
+		}
+		asOf = parsed
+	}
+
+	result, err := h.svc.RunConsolidation(c.Request.Context(), c.Param("id"), asOf)
+	if err != nil {
+		if errors.Is(err, domain.ErrConsolidationGroupNotFound) {
+			h.response.NotFound(c, "consolidation group not found")
+			return
+		}
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": result})
+}
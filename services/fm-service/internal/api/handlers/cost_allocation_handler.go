@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type CostAllocationHandler struct {
+	svc      *service.CostAllocationService
+	response *utils.ResponseHelper
+}
+
+func NewCostAllocationHandler(svc *service.CostAllocationService, response *utils.ResponseHelper) *CostAllocationHandler {
+	return &CostAllocationHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *CostAllocationHandler) GetCostCenters(c *gin.Context) {
+	centers, err := h.svc.ListCostCenters(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": centers})
+}
+
+func (h *CostAllocationHandler) CreateCostCenter(c *gin.Context) {
+	var req struct {
+		Code        string  `json:"code" binding:"required"`
+		Name        string  `json:"name" binding:"required"`
+		Description string  `json:"description"`
+		ManagerID   *string `json:"manager_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	cc, err := h.svc.CreateCostCenter(c.Request.Context(), req.Code, req.Name, req.Description, req.ManagerID)
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": cc})
+}
+
+type allocationCycleBody struct {
+	LegalEntityID string   `json:"legal_entity_id"`
+	Name          string   `json:"name" binding:"required"`
+	Description   string   `json:"description"`
+	Method        string   `json:"method" binding:"required"`
+	Driver        string   `json:"driver"`
+	IsActive      *bool    `json:"is_active"`
+	Senders       []string `json:"senders"`
+	Receivers     []struct {
+		CostCenterID string `json:"cost_center_id"`
+		Percentage   string `json:"percentage"`
+	} `json:"receivers"`
+}
+
+// bindCycle reads a cycle body, parsing the receiver percentages
+func (h *CostAllocationHandler) bindCycle(c *gin.Context) (service.AllocationCycleRequest, bool) {
+	var body allocationCycleBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return service.AllocationCycleRequest{}, false
+	}
+
+	req := service.AllocationCycleRequest{
+		LegalEntityID: body.LegalEntityID,
+		Name:          body.Name,
+		Description:   body.Description,
+		Method:        domain.AllocationMethod(body.Method),
+		Driver:        domain.AllocationDriver(body.Driver),
+		IsActive:      body.IsActive,
+		Senders:       body.Senders,
+	}
+	for _, r := range body.Receivers {
+		percentage := decimal.Zero
+		if r.Percentage != "" {
+			var err error
+			if percentage, err = decimal.NewFromString(r.Percentage); err != nil {
+				h.response.BadRequest(c, "invalid receiver percentage: "+r.Percentage)
+				return req, false
+			}
+		}
+		req.Receivers = append(req.Receivers, service.AllocationReceiverRequest{
+			CostCenterID: r.CostCenterID,
+			Percentage:   percentage,
+		})
+	}
+	return req, true
+}
+
+func (h *CostAllocationHandler) GetAllocationCycles(c *gin.Context) {
+	cycles, err := h.svc.ListCycles(c.Request.Context(), c.Query("legal_entity_id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cycles})
+}
+
+func (h *CostAllocationHandler) CreateAllocationCycle(c *gin.Context) {
+	req, ok := h.bindCycle(c)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.CreateCycle(c.Request.Context(), req)
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": detail})
+}
+
+func (h *CostAllocationHandler) GetAllocationCycle(c *gin.Context) {
+	detail, err := h.svc.GetCycle(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+func (h *CostAllocationHandler) UpdateAllocationCycle(c *gin.Context) {
+	req, ok := h.bindCycle(c)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.UpdateCycle(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+// RunAllocationCycle posts the cycle for a period, replacing an earlier run of the same period
+func (h *CostAllocationHandler) RunAllocationCycle(c *gin.Context) {
+	var req struct {
+		Period string `json:"period" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	run, err := h.svc.RunCycle(c.Request.Context(), c.Param("id"), req.Period, c.GetHeader("X-Username"))
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": run})
+}
+
+func (h *CostAllocationHandler) ReverseAllocationRun(c *gin.Context) {
+	run, err := h.svc.ReverseRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
+}
+
+func (h *CostAllocationHandler) GetKeyFigures(c *gin.Context) {
+	figures, err := h.svc.ListKeyFigures(c.Request.Context(), domain.AllocationDriver(c.Query("driver")), c.Query("period"))
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": figures})
+}
+
+// RecordKeyFigures stores driver values such as headcount or machine hours per cost center and period
+func (h *CostAllocationHandler) RecordKeyFigures(c *gin.Context) {
+	var req struct {
+		Values []struct {
+			CostCenterID string `json:"cost_center_id"`
+			Driver       string `json:"driver"`
+			Period       string `json:"period"`
+			Value        string `json:"value"`
+			Source       string `json:"source"`
+		} `json:"values" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	figures := make([]domain.StatisticalKeyFigure, 0, len(req.Values))
+	for _, v := range req.Values {
+		value, err := decimal.NewFromString(v.Value)
+		if err != nil {
+			h.response.BadRequest(c, "invalid value: "+v.Value)
+			return
+		}
+		figures = append(figures, domain.StatisticalKeyFigure{
+			CostCenterID:    v.CostCenterID,
+			Driver:          domain.AllocationDriver(v.Driver),
+			FinancialPeriod: v.Period,
+			Value:           value,
+			Source:          v.Source,
+		})
+	}
+	recorded, err := h.svc.RecordKeyFigures(c.Request.Context(), figures)
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": recorded})
+}
+
+func (h *CostAllocationHandler) allocationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCostCenter),
+		errors.Is(err, domain.ErrInvalidAllocationCycle),
+		errors.Is(err, domain.ErrInvalidKeyFigure),
+		errors.Is(err, domain.ErrNothingToAllocate),
+		errors.Is(err, domain.ErrMissingAllocationDriver):
+		h.response.BadRequest(c, err.Error())
+	case errors.Is(err, domain.ErrAllocationCycleNotFound), errors.Is(err, domain.ErrAllocationRunNotFound):
+		h.response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrAllocationCycleInactive),
+		errors.Is(err, domain.ErrAllocationRunReversed),
+		errors.Is(err, domain.ErrPeriodClosed):
+		h.response.ConflictErr(c, err)
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
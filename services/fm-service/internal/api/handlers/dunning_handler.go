@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type DunningHandler struct {
+	svc      *service.DunningService
+	response *utils.ResponseHelper
+}
+
+func NewDunningHandler(svc *service.DunningService, response *utils.ResponseHelper) *DunningHandler {
+	return &DunningHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *DunningHandler) GetDunningLevels(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	if legalEntityID == "" {
+		h.response.BadRequest(c, "legal_entity_id is required")
+		return
+	}
+	levels, err := h.svc.ListDunningLevels(c.Request.Context(), legalEntityID)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": levels})
+}
+
+func (h *DunningHandler) SetDunningLevel(c *gin.Context) {
+	var req struct {
+		LegalEntityID       string `json:"legal_entity_id" binding:"required"`
+		Level               int    `json:"level" binding:"required"`
+		Name                string `json:"name" binding:"required"`
+		DaysOverdue         int    `json:"days_overdue"`
+		FeeAmount           string `json:"fee_amount"`
+		InterestRatePercent string `json:"interest_rate_percent"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	fee, err := optionalDecimal(req.FeeAmount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid fee_amount")
+		return
+	}
+	interest, err := optionalDecimal(req.InterestRatePercent)
+	if err != nil {
+		h.response.BadRequest(c, "invalid interest_rate_percent")
+		return
+	}
+
+	level, err := h.svc.SetDunningLevel(c.Request.Context(), service.DunningLevelRequest{
+		LegalEntityID:       req.LegalEntityID,
+		Level:               req.Level,
+		Name:                req.Name,
+		DaysOverdue:         req.DaysOverdue,
+		FeeAmount:           fee,
+		InterestRatePercent: interest,
+	})
+	if err != nil {
+		h.dunningError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": level})
+}
+
+func (h *DunningHandler) DeleteDunningLevel(c *gin.Context) {
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil {
+		h.response.BadRequest(c, "invalid level")
+		return
+	}
+	if err := h.svc.DeleteDunningLevel(c.Request.Context(), c.Query("legal_entity_id"), level); err != nil {
+		if errors.Is(err, domain.ErrInvalidDunningLevel) {
+			h.response.NotFound(c, err.Error())
+			return
+		}
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "dunning level deleted successfully"})
+}
+
+func (h *DunningHandler) RunDunning(c *gin.Context) {
+	var req struct {
+		LegalEntityID string `json:"legal_entity_id" binding:"required"`
+		AsOf          string `json:"as_of"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	var asOf time.Time
+	if req.AsOf != "" {
+		parsed, err := time.Parse("2006-01-02", req.AsOf)
+		if err != nil {
+			h.response.BadRequest(c, "invalid as_of date, expected YYYY-MM-DD")
+			return
+		}
+		asOf = parsed
+	}
+
+	result, err := h.svc.RunDunning(c.Request.Context(), req.LegalEntityID, asOf)
+	if err != nil {
+		h.dunningError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+func (h *DunningHandler) GetDunningRuns(c *gin.Context) {
+	runs, err := h.svc.ListDunningRuns(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
+
+func (h *DunningHandler) GetDunningRun(c *gin.Context) {
+	result, err := h.svc.GetDunningRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.NotFound(c, "dunning run not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *DunningHandler) GetInvoiceNotices(c *gin.Context) {
+	notices, err := h.svc.ListInvoiceNotices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": notices})
+}
+
+func (h *DunningHandler) dunningError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidDunningLevel), errors.Is(err, domain.ErrNoDunningLevels):
+		h.response.BadRequest(c, err.Error())
+	case errors.Is(err, domain.ErrPeriodClosed):
+		h.response.ConflictErr(c, err)
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
+
+func optionalDecimal(v string) (decimal.Decimal, error) {
+	if v == "" {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(v)
+}
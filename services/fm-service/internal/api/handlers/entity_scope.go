@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"erp-system/shared/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legalEntityHeader carries the legal entity the gateway resolved and
+// authorized for the caller. Calls that bypass the gateway have none.
+const legalEntityHeader = "X-Legal-Entity-ID"
+
+// entityScope answers 404 when the record named by the :id parameter belongs
+// to another legal entity than the one in legalEntityHeader, so a grant
+// scoped to one entity cannot reach another entity's records by ID.
+// Unknown IDs pass through for the handler to report.
+func entityScope(response *utils.ResponseHelper, owner func(ctx context.Context, id string) (string, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, scope := c.Param("id"), c.GetHeader(legalEntityHeader)
+		if id == "" || scope == "" {
+			c.Next()
+			return
+		}
+		legalEntityID, err := owner(c.Request.Context(), id)
+		if err == nil && legalEntityID != scope {
+			response.NotFound(c, "record not found in legal entity "+scope)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
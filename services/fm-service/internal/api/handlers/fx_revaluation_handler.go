@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type FxRevaluationHandler struct {
+	svc      *service.ForeignExchangeService
+	response *utils.ResponseHelper
+}
+
+func NewFxRevaluationHandler(svc *service.ForeignExchangeService, response *utils.ResponseHelper) *FxRevaluationHandler {
+	return &FxRevaluationHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *FxRevaluationHandler) GetRevaluations(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	if legalEntityID == "" {
+		h.response.BadRequest(c, "legal_entity_id is required")
+		return
+	}
+	revaluations, err := h.svc.ListRevaluations(c.Request.Context(), legalEntityID)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": revaluations})
+}
+
+func (h *FxRevaluationHandler) RunRevaluation(c *gin.Context) {
+	var req struct {
+		LegalEntityID   string `json:"legal_entity_id" binding:"required"`
+		RevaluationDate string `json:"revaluation_date" binding:"required"` // YYYY-MM-DD
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.RevaluationDate)
+	if err != nil {
+		h.response.BadRequest(c, "revaluation_date must be YYYY-MM-DD")
+		return
+	}
+
+	run, err := h.svc.RunRevaluation(c.Request.Context(), req.LegalEntityID, date)
+	if err != nil {
+		if errors.Is(err, domain.ErrFxRevaluationAlreadyRun) {
+			h.response.ConflictErr(c, err)
+			return
+		}
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": run})
+}
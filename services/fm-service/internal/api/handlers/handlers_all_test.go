@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -32,12 +34,19 @@ type testEnv struct {
 	invoices      *memory.MemoryArInvoiceRepo
 	payments      *memory.MemoryPaymentRepo
 	statements    *memory.MemoryBankStatementRepo
+	bankAccounts  *memory.MemoryBankAccountRepo
 	bills         *memory.MemoryApVendorBillRepo
+	poLines       *memory.MemoryPurchaseOrderLineRepo
+	receiptLines  *memory.MemoryGoodsReceiptLineRepo
 	outbox        *memory.MemoryTransactionalOutboxRepo
 	legalEntities *memory.MemoryLegalEntityRepo
+	rates         *memory.MemoryCurrencyRateRepo
 	assets        *memory.MemoryCapitalAssetRepo
 	scheduleLines *memory.MemoryDepreciationScheduleLineRepo
 	inbox         *memory.MemoryKafkaEventInboxRepo
+	fiscalYears   *memory.MemoryFiscalYearRepo
+	periods       *memory.MemoryFiscalPeriodRepo
+	budgets       *memory.MemoryBudgetRepo
 }
 
 func setupTestEnv() *testEnv {
@@ -53,38 +62,127 @@ func setupTestEnv() *testEnv {
 	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
 	inbox := memory.NewMemoryKafkaEventInboxRepo()
 	credits := memory.NewMemoryCustomerCreditRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	converter := service.NewCurrencyConverter(legalEntities, rates)
 
 	tmGL := memory.NewMemoryTransactionManager(accounts, entries, outbox)
-	glSvc := service.NewGeneralLedgerService(accounts, entries, outbox, tmGL)
-
-	tmAR := memory.NewMemoryTransactionManager(invoices, outbox)
-	arSvc := service.NewAccountsReceivableService(invoices, credits, outbox, tmAR)
-
-	tmAP := memory.NewMemoryTransactionManager(bills, outbox)
-	apSvc := service.NewAccountsPayableService(bills, outbox, tmAP)
-
-	tmCM := memory.NewMemoryTransactionManager(payments, invoices, outbox)
-	cmSvc := service.NewCashManagementService(payments, invoices, statements, outbox, tmCM)
+	glSvc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, converter, outbox, tmGL)
+
+	taxRates := memory.NewMemoryTaxRateRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	tmTax := memory.NewMemoryTransactionManager(taxTransactions, accounts, entries, outbox)
+	taxSvc := service.NewTaxService(taxRates, memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, glSvc, tmTax)
+
+	tmAR := memory.NewMemoryTransactionManager(invoices, taxTransactions, accounts, entries, outbox)
+	arSvc := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), converter, taxSvc, outbox, tmAR)
+
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	tmAP := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, taxTransactions, accounts, entries, outbox)
+	apSvc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), converter, taxSvc, outbox, tmAP)
+
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	reconMatches := memory.NewMemoryBankReconciliationMatchRepo()
+	reconExceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	payrollRuns := memory.NewMemoryPayrollRunSnapshotRepo()
+	recurringItems := memory.NewMemoryRecurringCashItemRepo()
+	revaluations := memory.NewMemoryFxRevaluationRepo()
+	tmFX := memory.NewMemoryTransactionManager(revaluations, accounts, entries, outbox)
+	fxSvc := service.NewForeignExchangeService(converter, invoices, bills, bankAccounts, revaluations, glSvc, outbox, tmFX)
+
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	onAccount := memory.NewMemoryOnAccountCreditRepo()
+	tmCM := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, onAccount, reconMatches, reconExceptions, recurringItems, accounts, entries, outbox)
+	cmSvc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: onAccount,
+		Statements:       statements,
+		BankAccounts:     bankAccounts,
+		Matches:          reconMatches,
+		Exceptions:       reconExceptions,
+		PayrollRuns:      payrollRuns,
+		RecurringItems:   recurringItems,
+		GL:               glSvc,
+		FX:               fxSvc,
+		Outbox:           outbox,
+		TM:               tmCM,
+	})
 
 	tmLE := memory.NewMemoryTransactionManager(legalEntities)
 	leSvc := service.NewLegalEntityService(legalEntities, tmLE)
 
-	tmAsset := memory.NewMemoryTransactionManager(assets, scheduleLines, accounts, entries, outbox)
-	assetSvc := service.NewCapitalAssetService(assets, scheduleLines, accounts, entries, outbox, tmAsset)
+	usage := memory.NewMemoryAssetUsageRecordRepo()
+	tmAsset := memory.NewMemoryTransactionManager(assets, scheduleLines, usage, accounts, entries, outbox)
+	assetSvc := service.NewCapitalAssetService(assets, scheduleLines, usage, accounts, entries, outbox, tmAsset)
+
+	tmPeriod := memory.NewMemoryTransactionManager(periods, accounts, entries, outbox)
+	periodSvc := service.NewPeriodCloseService(periods, fiscalYears, accounts, entries, statements, bankAccounts, assets, scheduleLines, glSvc, outbox, tmPeriod)
+
+	icTransactions := memory.NewMemoryIntercompanyTransactionRepo()
+	tmIC := memory.NewMemoryTransactionManager(icTransactions, accounts, entries, outbox)
+	icSvc := service.NewIntercompanyService(icTransactions, accounts, legalEntities, glSvc, outbox, tmIC)
+	consolidationSvc := service.NewConsolidationService(memory.NewMemoryConsolidationGroupRepo(), legalEntities, accounts, entries, converter, tmLE)
+
+	budgets := memory.NewMemoryBudgetRepo()
+	reportSvc := service.NewFinancialReportService(accounts, entries, budgets)
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	tmBudget := memory.NewMemoryTransactionManager(budgets, commitments, policies, outbox)
+	budgetSvc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tmBudget)
+
+	dunningRuns := memory.NewMemoryDunningRunRepo()
+	dunningNotices := memory.NewMemoryDunningNoticeRepo()
+	tmDunning := memory.NewMemoryTransactionManager(invoices, dunningRuns, dunningNotices, credits, accounts, entries, outbox)
+	dunningSvc := service.NewDunningService(memory.NewMemoryDunningLevelRepo(), dunningRuns, dunningNotices, invoices, arSvc, glSvc, outbox, tmDunning)
+
+	paymentRuns := memory.NewMemoryPaymentRunRepo()
+	paymentRunLines := memory.NewMemoryPaymentRunLineRepo()
+	paymentFiles := memory.NewMemoryPaymentFileRepo()
+	tmPaymentRun := memory.NewMemoryTransactionManager(paymentRuns, paymentRunLines, paymentFiles, payments, bills, allocations, accounts, entries, outbox)
+	paymentRunSvc := service.NewPaymentRunService(paymentRuns, paymentRunLines, paymentFiles, memory.NewMemoryVendorBankAccountRepo(), bills, bankAccounts, legalEntities, cmSvc, converter, glSvc, outbox, tmPaymentRun)
+
+	journalTemplates := memory.NewMemoryJournalTemplateRepo()
+	tmRecurring := memory.NewMemoryTransactionManager(journalTemplates, accounts, entries, outbox)
+	recurringSvc := service.NewRecurringJournalService(journalTemplates, entries, accounts, glSvc, tmRecurring)
+
+	allocationCycles := memory.NewMemoryAllocationCycleRepo()
+	allocationRuns := memory.NewMemoryAllocationRunRepo()
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	tmAllocation := memory.NewMemoryTransactionManager(allocationCycles, allocationRuns, keyFigures, accounts, entries, outbox)
+	allocationSvc := service.NewCostAllocationService(allocationCycles, allocationRuns, keyFigures, memory.NewMemoryCostCenterRepo(), accounts, entries, glSvc, tmAllocation)
 
 	response := utils.NewResponseHelper("fm-service")
 
 	accHandler := handlers.NewAccountHandler(glSvc, response)
 	txHandler := handlers.NewTransactionHandler(glSvc, response)
-	repHandler := handlers.NewReportHandler(glSvc, response)
+	repHandler := handlers.NewReportHandler(reportSvc, response)
 	invHandler := handlers.NewInvoiceHandler(arSvc, response)
 	payHandler := handlers.NewPaymentHandler(cmSvc, response)
 	billHandler := handlers.NewVendorBillHandler(apSvc, response)
 	leHandler := handlers.NewLegalEntityHandler(leSvc, response)
 	assetHandler := handlers.NewAssetHandler(assetSvc, response)
+	reconHandler := handlers.NewReconciliationHandler(cmSvc, response)
+	fxHandler := handlers.NewFxRevaluationHandler(fxSvc, response)
+	periodHandler := handlers.NewPeriodHandler(periodSvc, response)
+	icHandler := handlers.NewIntercompanyHandler(icSvc, response)
+	consolidationHandler := handlers.NewConsolidationHandler(consolidationSvc, response)
+	budgetHandler := handlers.NewBudgetHandler(budgetSvc, response)
+	taxHandler := handlers.NewTaxHandler(taxSvc, response)
+	dunningHandler := handlers.NewDunningHandler(dunningSvc, response)
+	paymentRunHandler := handlers.NewPaymentRunHandler(paymentRunSvc, response)
+	journalTemplateHandler := handlers.NewJournalTemplateHandler(recurringSvc, response)
+	allocationHandler := handlers.NewCostAllocationHandler(allocationSvc, response)
 
 	router := gin.New()
-	routes.SetupRoutes(router, &config.Config{}, accHandler, txHandler, repHandler, invHandler, payHandler, billHandler, leHandler, assetHandler)
+	routes.SetupRoutes(router, &config.Config{}, accHandler, txHandler, repHandler, invHandler, payHandler, billHandler, leHandler, assetHandler, reconHandler, fxHandler, periodHandler, icHandler, consolidationHandler, budgetHandler, taxHandler, dunningHandler, paymentRunHandler, journalTemplateHandler, allocationHandler)
 
 	return &testEnv{
 		router:        router,
@@ -93,12 +191,19 @@ func setupTestEnv() *testEnv {
 		invoices:      invoices,
 		payments:      payments,
 		statements:    statements,
+		bankAccounts:  bankAccounts,
 		bills:         bills,
+		poLines:       poLines,
+		receiptLines:  receiptLines,
 		outbox:        outbox,
 		legalEntities: legalEntities,
+		rates:         rates,
 		assets:        assets,
 		scheduleLines: scheduleLines,
 		inbox:         inbox,
+		fiscalYears:   fiscalYears,
+		periods:       periods,
+		budgets:       budgets,
 	}
 }
 
@@ -215,7 +320,38 @@ func TestAccountEndpoints(t *testing.T) {
 		t.Errorf("expected 200, got %d", w.Code)
 	}
 
-	// 10. Delete Account
+	// 10. Post cash at bank to the account
+	body, _ = json.Marshal(map[string]interface{}{"legal_entity_id": "legal_123", "account_code": "1000"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/account-determinations/CASH_AT_BANK", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 11. Determination of an unknown posting key
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/account-determinations/ROUNDING", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 12. List determinations
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/account-determinations?legal_entity_id=legal_123", nil)
+	env.router.ServeHTTP(w, req)
+	var determinations struct {
+		Data []domain.AccountDetermination `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &determinations)
+	if w.Code != http.StatusOK || len(determinations.Data) != 11 {
+		t.Errorf("expected 200 with every posting key, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// 13. Delete Account
 	w = httptest.NewRecorder()
 	req, _ = http.NewRequest(http.MethodDelete, "/api/v1/accounts/"+accID, nil)
 	env.router.ServeHTTP(w, req)
@@ -706,6 +842,54 @@ func TestReportEndpoints(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("expected 200, got %d", w.Code)
 	}
+
+	// 4. Filtered report with comparatives
+	_ = env.entries.Create(context.Background(), &domain.UniversalJournalEntry{
+		ID: "je_march", LegalEntityID: "legal_123", SourceModule: "AR", PostingDate: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		FinancialPeriod: "2025-03", Status: domain.LedgerStatePOSTED,
+	}, []domain.UniversalJournalLine{
+		{ID: "l1", JournalEntryID: "je_march", AccountID: "acc_asset", AmountFunctional: decimal.NewFromInt(400)},
+		{ID: "l2", JournalEntryID: "je_march", AccountID: "acc_revenue", AmountFunctional: decimal.NewFromInt(-400)},
+	})
+	_ = env.budgets.Create(context.Background(), &domain.Budget{ID: "b1", AccountID: "acc_revenue", FiscalYear: 2025, Period: 3, AllocatedAmount: decimal.NewFromInt(500)})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/reports/income-statement?legal_entity_id=legal_123&from_period=2025-03&to_period=2025-03&source_module=ar&compare=prior_period,budget", nil)
+	env.router.ServeHTTP(w, req)
+	var income struct {
+		Report service.IncomeStatementReport `json:"report"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &income)
+	if w.Code != http.StatusOK || !income.Report.Revenues.Total.Equal(decimal.NewFromInt(400)) ||
+		!income.Report.Revenues.Comparatives[service.CompareBudget].Equal(decimal.NewFromInt(500)) {
+		t.Errorf("unexpected income statement %d: %s", w.Code, w.Body.String())
+	}
+
+	// 5. Drill-down to journal lines
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/reports/drill-down?account_id=acc_revenue&from=2025-03-01&to=2025-03-31", nil)
+	env.router.ServeHTTP(w, req)
+	var drill struct {
+		Data []service.ReportDrillDownLine `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &drill)
+	if w.Code != http.StatusOK || len(drill.Data) != 1 || drill.Data[0].JournalEntryID != "je_march" {
+		t.Errorf("unexpected drill-down %d: %s", w.Code, w.Body.String())
+	}
+
+	// 6. Invalid filters
+	for _, url := range []string{
+		"/api/v1/reports/balance-sheet?from=03/01/2025",
+		"/api/v1/reports/income-statement?compare=yesterday",
+		"/api/v1/reports/drill-down",
+		"/api/v1/reports/drill-down?account_id=acc_missing",
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, url, nil)
+		env.router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", url, w.Code)
+		}
+	}
 }
 
 func TestLegalEntityEndpoints(t *testing.T) {
@@ -807,6 +991,15 @@ func TestAssetEndpoints(t *testing.T) {
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
 	assetID := resp.Data.ID
 
+	// Callers of another legal entity cannot reach the asset
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/assets/"+assetID, nil)
+	req.Header.Set("X-Legal-Entity-ID", "legal_999")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another entity's asset, got %d", w.Code)
+	}
+
 	// 2. Generate schedule
 	w = httptest.NewRecorder()
 	req, _ = http.NewRequest(http.MethodPost, "/api/v1/assets/"+assetID+"/depreciation-schedule", nil)
@@ -909,3 +1102,1480 @@ func TestAssetEndpoints(t *testing.T) {
 		t.Error("expected depreciation posting failure for bad JSON")
 	}
 }
+
+func TestAssetDisposalAndUsageEndpoints(t *testing.T) {
+	env := setupTestEnv()
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Units-of-production asset with usage
+	w := post("/api/v1/assets/capitalize", map[string]interface{}{
+		"legal_entity_id":         "legal_123",
+		"asset_tag":               "CRANE-1",
+		"acquisition_cost":        "10000",
+		"useful_life_months":      120,
+		"capitalization_date":     time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		"depreciation_method":     "units_of_production",
+		"estimated_total_units":   "1000",
+		"depreciation_convention": "FULL_MONTH",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data domain.CapitalAsset `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.DepreciationMethod != domain.DepreciationMethodUNITS_OF_PRODUCTION {
+		t.Errorf("expected units-of-production method, got %s", created.Data.DepreciationMethod)
+	}
+	assetID := created.Data.ID
+
+	w = post("/api/v1/assets/"+assetID+"/usage", map[string]interface{}{
+		"usage_date": time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+		"units":      "100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	if w := post("/api/v1/assets/"+assetID+"/usage", map[string]interface{}{"units": "abc"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid units, got %d", w.Code)
+	}
+	if w := get("/api/v1/assets/" + assetID + "/usage"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "MANUAL") {
+		t.Errorf("expected usage listing, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	// 2. Invalid depreciation terms
+	w = post("/api/v1/assets/capitalize", map[string]interface{}{
+		"legal_entity_id":    "legal_123",
+		"asset_tag":          "EQ-BAD",
+		"acquisition_cost":   "1000",
+		"useful_life_months": 12,
+		"salvage_value":      "not-a-number",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid salvage value, got %d", w.Code)
+	}
+
+	// 3. Dispose with proceeds, then again
+	w = post("/api/v1/assets/"+assetID+"/dispose", map[string]interface{}{
+		"disposal_date": time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+		"proceeds":      "9000",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var disposed struct {
+		Data domain.CapitalAsset `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &disposed)
+	if disposed.Data.Status != domain.AssetStateDISPOSED || !disposed.Data.AccumulatedDepreciation.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("expected disposed asset with 1000 depreciation, got %s / %s", disposed.Data.Status, disposed.Data.AccumulatedDepreciation)
+	}
+	if w := post("/api/v1/assets/"+assetID+"/dispose", map[string]interface{}{}); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 disposing twice, got %d", w.Code)
+	}
+	if w := post("/api/v1/assets/"+assetID+"/dispose", map[string]interface{}{"proceeds": "x"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid proceeds, got %d", w.Code)
+	}
+	if w := post("/api/v1/assets/"+assetID+"/usage", map[string]interface{}{"units": "5"}); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 recording usage on a disposed asset, got %d", w.Code)
+	}
+}
+
+func TestReconciliationEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+	day := time.Now()
+
+	_ = env.legalEntities.Create(ctx, &domain.LegalEntity{ID: "legal_123", CompanyCode: "CORP_US", FunctionalCurrency: "USD"})
+	_ = env.bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", Currency: "USD"})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_bank", AccountCode: "1010", AccountName: "Bank", Type: domain.AccountTypeASSET, IsActive: true})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_fees", AccountCode: "6100", AccountName: "Bank Fees", Type: domain.AccountTypeEXPENSE, IsActive: true})
+
+	bank := "ba_1"
+	_ = env.payments.Create(ctx, &domain.Payment{ID: "pay_1", PaymentNumber: "PAY-1", PaymentDate: day, Amount: decimal.NewFromInt(100), Status: "COMPLETED", BankAccountID: &bank})
+	_ = env.statements.Create(ctx, &domain.BankStatement{ID: "stmt_1", BankAccountID: "ba_1"}, []domain.BankStatementLine{
+		{ID: "line_1", StatementID: "stmt_1", TransactionDate: day, Description: "PAY-1", Amount: decimal.NewFromInt(100)},
+		{ID: "line_2", StatementID: "stmt_1", TransactionDate: day, Description: "Fee", Amount: decimal.NewFromInt(-5)},
+	})
+
+	// 1. Reconcile missing statement
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/bank-statements/non-existent/reconcile", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 2. Reconcile success
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/bank-statements/stmt_1/reconcile", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var recon struct {
+		Data service.ReconciliationResult `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &recon)
+	if recon.Data.MatchedLines != 1 || len(recon.Data.Exceptions) != 1 {
+		t.Fatalf("unexpected reconciliation result: %+v", recon.Data)
+	}
+
+	// 3. List matches
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/bank-statements/stmt_1/matches", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	// 4. Manual match on an already matched line conflicts
+	body, _ := json.Marshal(map[string]interface{}{
+		"statement_line_ids": []string{"line_1"},
+		"payment_ids":        []string{"pay_1"},
+	})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/bank-statements/stmt_1/matches", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+
+	// 5. Unmatch unknown group
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/api/v1/bank-statements/stmt_1/matches/missing", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+
+	// 6. List open exceptions
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/reconciliation-exceptions?status=OPEN", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	// 6b. Callers of another legal entity neither list nor reach the statement's records
+	excID := recon.Data.Exceptions[0].ID
+	inEntity := func(method, path, legalEntityID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(`{"offset_account_id":"acc_fees"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Legal-Entity-ID", legalEntityID)
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	if w := inEntity(http.MethodGet, "/api/v1/reconciliation-exceptions", "legal_123"); !strings.Contains(w.Body.String(), excID) {
+		t.Errorf("expected the entity's exception to be listed, got %s", w.Body.String())
+	}
+	if w := inEntity(http.MethodGet, "/api/v1/reconciliation-exceptions", "legal_999"); strings.Contains(w.Body.String(), excID) {
+		t.Errorf("expected another entity's exception to be hidden, got %s", w.Body.String())
+	}
+	if w := inEntity(http.MethodGet, "/api/v1/bank-statements/stmt_1/lines", "legal_999"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another entity's statement, got %d", w.Code)
+	}
+	if w := inEntity(http.MethodPost, "/api/v1/reconciliation-exceptions/"+excID+"/journal-entry", "legal_999"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 posting another entity's exception, got %d", w.Code)
+	}
+
+	// 7. Post exception bad JSON
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/reconciliation-exceptions/"+excID+"/journal-entry", bytes.NewBuffer([]byte("{bad")))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 8. Post exception to GL success
+	body, _ = json.Marshal(map[string]interface{}{
+		"bank_gl_account_id": "acc_bank",
+		"offset_account_id":  "acc_fees",
+	})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/reconciliation-exceptions/"+excID+"/journal-entry", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 9. Posting again conflicts
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/reconciliation-exceptions/"+excID+"/journal-entry", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func newStatementUpload(t *testing.T, fields map[string]string, content string) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	if content != "" {
+		fw, err := mw.CreateFormFile("file", "statement.sta")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = fw.Write([]byte(content))
+	}
+	_ = mw.Close()
+	return body, mw.FormDataContentType()
+}
+
+func TestBankStatementImportEndpoint(t *testing.T) {
+	env := setupTestEnv()
+	_ = env.bankAccounts.Create(context.Background(), &domain.BankAccount{ID: "ba_1", AccountNumber: "DE001", Currency: "EUR"})
+	mt940 := ":20:STMT1\n:25:DE001\n:60F:C260309EUR0,\n:61:260310C40,00NTRFPAY-001\n:62F:C260310EUR40,\n-"
+
+	// 1. Missing bank account
+	body, ct := newStatementUpload(t, nil, mt940)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/bank-statements/import", body)
+	req.Header.Set("Content-Type", ct)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 2. Missing file
+	body, ct = newStatementUpload(t, map[string]string{"bank_account_id": "ba_1"}, "")
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/bank-statements/import", body)
+	req.Header.Set("Content-Type", ct)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 3. Invalid lines
+	body, ct = newStatementUpload(t, map[string]string{"bank_account_id": "ba_1", "format": "MT940"}, ":20:BAD\n:61:BROKEN\n-")
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/bank-statements/import", body)
+	req.Header.Set("Content-Type", ct)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 4. Success
+	body, ct = newStatementUpload(t, map[string]string{"bank_account_id": "ba_1"}, mt940)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/bank-statements/import", body)
+	req.Header.Set("Content-Type", ct)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 5. Duplicate
+	body, ct = newStatementUpload(t, map[string]string{"bank_account_id": "ba_1"}, mt940)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/bank-statements/import", body)
+	req.Header.Set("Content-Type", ct)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestCashFlowForecastEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+	asOf := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	_ = env.bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR", LiquidBalance: decimal.NewFromInt(1000)})
+	_ = env.invoices.Create(ctx, &domain.ArInvoice{ID: "inv_1", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(250), DueDate: asOf.AddDate(0, 0, 3), Status: domain.PaymentStatusOPEN})
+
+	// 1. Create recurring item
+	body, _ := json.Marshal(map[string]interface{}{
+		"legal_entity_id": "legal_123",
+		"description":     "Software subscription",
+		"amount":          "-100",
+		"frequency":       "monthly",
+		"next_occurrence": asOf.AddDate(0, 0, 10),
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/recurring-cash-items", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data domain.RecurringCashItem `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+
+	// 2. Invalid recurring item
+	body, _ = json.Marshal(map[string]interface{}{"legal_entity_id": "legal_123", "description": "Bad", "amount": "abc", "frequency": "MONTHLY"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/recurring-cash-items", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 3. List recurring items
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/recurring-cash-items?legal_entity_id=legal_123", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	// 4. Forecast
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/reports/cash-flow-forecast?months_ahead=1&granularity=week&as_of=2026-03-02", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var forecast struct {
+		Report service.CashFlowForecast `json:"report"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &forecast)
+	if len(forecast.Report.LegalEntities) != 1 || len(forecast.Report.LegalEntities[0].Periods) != 5 {
+		t.Fatalf("unexpected forecast: %+v", forecast.Report)
+	}
+	periods := forecast.Report.LegalEntities[0].Periods
+	if !periods[0].Receivables.Equal(decimal.NewFromInt(250)) || !periods[1].RecurringOutflows.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("unexpected forecast periods: %+v", periods)
+	}
+
+	// 5. Invalid forecast parameters
+	for _, query := range []string{"months_ahead=abc", "granularity=DAY", "as_of=03/02/2026", "bank_account_id=missing"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/api/v1/reports/cash-flow-forecast?"+query, nil)
+		env.router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+
+	// 6. Update and delete
+	body, _ = json.Marshal(map[string]interface{}{
+		"legal_entity_id": "legal_123",
+		"description":     "Software subscription",
+		"amount":          "-120",
+		"frequency":       "MONTHLY",
+		"next_occurrence": asOf.AddDate(0, 0, 10),
+		"is_active":       false,
+	})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/recurring-cash-items/"+created.Data.ID, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/recurring-cash-items/missing", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/api/v1/recurring-cash-items/"+created.Data.ID, nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/api/v1/recurring-cash-items/"+created.Data.ID, nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestFxRevaluationEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+
+	_ = env.legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	_ = env.rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)})
+	_ = env.rates.Create(ctx, &domain.CurrencyRate{ID: "r2", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.05"), EffectiveDate: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)})
+
+	// 1. Foreign-currency invoice is booked at the current rate
+	body, _ := json.Marshal(map[string]interface{}{
+		"legal_entity_id": "le_us",
+		"customer_id":     "cust_1",
+		"currency":        "EUR",
+		"total_amount":    "1000",
+		"due_date":        time.Now().AddDate(0, 1, 0),
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/invoices", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data domain.ArInvoice `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.Currency != "EUR" || !created.Data.ExchangeRate.Equal(decimal.RequireFromString("1.05")) {
+		t.Fatalf("expected EUR invoice at 1.05, got %s at %s", created.Data.Currency, created.Data.ExchangeRate)
+	}
+	// Book it at the older rate so the revaluation has a difference to post
+	created.Data.ExchangeRate = decimal.RequireFromString("1.10")
+	_ = env.invoices.Update(ctx, &created.Data)
+
+	// 2. Run revaluation
+	body, _ = json.Marshal(map[string]string{"legal_entity_id": "le_us", "revaluation_date": "2025-03-31"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/fx-revaluations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var run struct {
+		Data service.FxRevaluationRun `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &run)
+	if !run.Data.NetGainLoss.Equal(decimal.NewFromInt(-50)) || run.Data.JournalEntryID == nil {
+		t.Errorf("expected net loss of 50 with a journal entry, got %+v", run.Data)
+	}
+
+	// 3. Same period again conflicts
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/fx-revaluations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+
+	// 4. Invalid date
+	body, _ = json.Marshal(map[string]string{"legal_entity_id": "le_us", "revaluation_date": "31.03.2025"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/fx-revaluations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	// 5. List revaluations and check the per-currency account balance
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/fx-revaluations?legal_entity_id=le_us", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), created.Data.ID) {
+		t.Errorf("expected revaluation of the invoice, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	loss, _ := env.accounts.GetByCode(ctx, "le_us", "8910-001")
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/accounts/"+loss.ID+"/balance", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"balances_by_currency":{"USD":"50"}`) {
+		t.Errorf("unexpected balance response %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPeriodCloseEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+
+	_ = env.fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2025", Year: 2025, StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)})
+	_ = env.entries.Create(ctx, &domain.UniversalJournalEntry{ID: "je_draft", LegalEntityID: "le_1", SourceModule: "FM",
+		PostingDate: time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC), FinancialPeriod: "2025-04", Status: domain.LedgerStateDRAFT}, nil)
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	le := map[string]string{"legal_entity_id": "le_1"}
+
+	// 1. Checklist shows the open draft
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/fiscal-periods/2025-04/checklist?legal_entity_id=le_1", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var checklist struct {
+		Data service.PeriodCloseChecklist `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &checklist)
+	if checklist.Data.ReadyToClose || checklist.Data.Checks[0].Passed {
+		t.Errorf("expected unposted drafts to block the close, got %+v", checklist.Data)
+	}
+
+	// 2. Close is blocked by the checklist
+	if w := post("/api/v1/fiscal-periods/2025-04/close", le); w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 3. Soft-close, then a sub-ledger posting is rejected
+	if w := post("/api/v1/fiscal-periods/2025-03/soft-close", le); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := post("/api/v1/fiscal-periods/2025-03/soft-close", map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without legal_entity_id, got %d", w.Code)
+	}
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_cash", LegalEntityID: "le_1", AccountCode: "1010-001", AccountName: "Bank", Type: domain.AccountTypeASSET, IsActive: true})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_sales", LegalEntityID: "le_1", AccountCode: "4000-001", AccountName: "Sales", Type: domain.AccountTypeREVENUE, IsActive: true})
+	w = post("/api/v1/journal-entries", map[string]interface{}{
+		"legal_entity_id": "le_1",
+		"source_module":   "AR",
+		"posting_date":    time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		"lines": []map[string]string{
+			{"account_id": "acc_cash", "amount_functional": "100"},
+			{"account_id": "acc_sales", "amount_functional": "-100"},
+		},
+	})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 posting into soft-closed period, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 4. Reopen and list
+	if w := post("/api/v1/fiscal-periods/2025-03/reopen", le); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/fiscal-periods?legal_entity_id=le_1", nil)
+	env.router.ServeHTTP(w, req)
+	var periods struct {
+		Data []domain.FiscalPeriod `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &periods)
+	if w.Code != http.StatusOK || len(periods.Data) != 1 || periods.Data[0].State != domain.PeriodStateOPEN {
+		t.Errorf("expected one open period, got %d %+v", w.Code, periods.Data)
+	}
+
+	// 5. Year-end close is blocked until the draft is gone
+	if w := post("/api/v1/fiscal-years/fy_2025/close", le); w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	_ = env.entries.Delete(ctx, "je_draft")
+	w = post("/api/v1/fiscal-years/fy_2025/close", le)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var closed struct {
+		Data service.YearEndCloseResult `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &closed)
+	if closed.Data.FiscalYear != 2025 || len(closed.Data.Periods) != 12 {
+		t.Errorf("unexpected year-end result %+v", closed.Data)
+	}
+	if w := post("/api/v1/fiscal-years/missing/close", le); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown fiscal year, got %d", w.Code)
+	}
+}
+
+func TestIntercompanyAndConsolidationEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+
+	_ = env.legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	_ = env.legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_ca", CompanyCode: "CA", FunctionalCurrency: "USD"})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_us_rev", LegalEntityID: "le_us", AccountCode: "4000-001", AccountName: "Sales", Type: domain.AccountTypeREVENUE, IsActive: true})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_ca_exp", LegalEntityID: "le_ca", AccountCode: "6000-001", AccountName: "Services", Type: domain.AccountTypeEXPENSE, IsActive: true})
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Intercompany charge
+	w := post("/api/v1/intercompany-transactions", map[string]interface{}{
+		"from_legal_entity_id": "le_us",
+		"to_legal_entity_id":   "le_ca",
+		"from_account_id":      "acc_us_rev",
+		"to_account_id":        "acc_ca_exp",
+		"amount":               "250",
+		"posting_date":         time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data domain.IntercompanyTransaction `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.FromJournalEntryID == "" || created.Data.ToJournalEntryID == "" {
+		t.Fatalf("expected both journal entries, got %+v", created.Data)
+	}
+	if w := get("/api/v1/intercompany-transactions/" + created.Data.ID); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := get("/api/v1/intercompany-transactions?legal_entity_id=le_ca"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), created.Data.ID) {
+		t.Errorf("expected transaction in list, got %d %s", w.Code, w.Body.String())
+	}
+	if w := post("/api/v1/intercompany-transactions", map[string]interface{}{
+		"from_legal_entity_id": "le_us", "to_legal_entity_id": "le_us", "from_account_id": "acc_us_rev", "to_account_id": "acc_us_rev", "amount": "1",
+	}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for same-entity charge, got %d", w.Code)
+	}
+
+	// 2. Entity-level report
+	w = get("/api/v1/reports/income-statement?legal_entity_id=le_ca")
+	var income struct {
+		Report service.IncomeStatementReport `json:"report"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &income)
+	if w.Code != http.StatusOK || !income.Report.Expenses.Total.Equal(decimal.NewFromInt(250)) || !income.Report.Revenues.Total.IsZero() {
+		t.Errorf("expected le_ca expense only, got %d %+v", w.Code, income.Report)
+	}
+
+	// 3. Consolidation eliminates the charge
+	w = post("/api/v1/consolidation-groups", map[string]interface{}{
+		"code": "NA", "name": "North America", "reporting_currency": "USD", "legal_entity_ids": []string{"le_us", "le_ca"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var group struct {
+		Data domain.ConsolidationGroup `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &group)
+
+	w = get("/api/v1/consolidation-groups/" + group.Data.ID + "/statements?as_of=2025-12-31")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var consolidated struct {
+		Report service.ConsolidationResult `json:"report"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &consolidated)
+	if !consolidated.Report.IncomeStatement.NetIncome.IsZero() || !consolidated.Report.BalanceSheet.TotalAssets.IsZero() || len(consolidated.Report.Eliminations) != 4 {
+		t.Errorf("expected the intercompany charge to be fully eliminated, got %+v", consolidated.Report)
+	}
+
+	if w := get("/api/v1/consolidation-groups/missing/statements"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if w := get("/api/v1/consolidation-groups/" + group.Data.ID + "/statements?as_of=bad"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if w := get("/api/v1/consolidation-groups"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "North America") {
+		t.Errorf("expected group in list, got %d", w.Code)
+	}
+}
+
+func TestBudgetEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_supplies", LegalEntityID: "le_1", AccountCode: "6100-001", AccountName: "Office Supplies", Type: domain.AccountTypeEXPENSE, IsActive: true})
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Create the original budget and a revision
+	w := send(http.MethodPost, "/api/v1/budgets", map[string]interface{}{
+		"account_id": "acc_supplies", "cost_center_id": "cc_ops", "fiscal_year": 2026, "period": 5, "allocated_amount": "1000",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data domain.Budget `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if w := send(http.MethodPut, "/api/v1/budgets/"+created.Data.ID+"/versions/revised", map[string]string{"allocated_amount": "800"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPut, "/api/v1/budgets/"+created.Data.ID+"/versions/draft", map[string]string{"allocated_amount": "800"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown version, got %d", w.Code)
+	}
+
+	// 2. Block overruns for the cost center
+	if w := send(http.MethodPut, "/api/v1/budgets/policies/cc_ops", map[string]string{"enforcement": "block"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	expense := map[string]interface{}{"account_id": "acc_supplies", "cost_center_id": "cc_ops", "fiscal_year": 2026, "period": 5, "amount": "900"}
+	w = send(http.MethodPost, "/api/v1/budgets/check", expense)
+	var check struct {
+		Data service.BudgetCheckResult `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &check)
+	if w.Code != http.StatusOK || !check.Data.Exceeded || !check.Data.Allocated.Equal(decimal.NewFromInt(800)) {
+		t.Errorf("expected revised budget to be exceeded, got %d %+v", w.Code, check.Data)
+	}
+	if w := send(http.MethodPost, "/api/v1/budgets/expenses", expense); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for blocked spend, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	expense["amount"] = "500"
+	if w := send(http.MethodPost, "/api/v1/budgets/expenses", expense); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 3. Deciding a commitment that is not held for approval conflicts
+	if w := send(http.MethodPost, "/api/v1/budgets/commitments/missing/approve", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown commitment, got %d", w.Code)
+	}
+
+	// 4. Budget vs actual shows budget, committed, actual and remaining
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/budgets/vs-actual?account_id=acc_supplies&fiscal_year=2026", nil)
+	env.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var report struct {
+		Report map[string]interface{} `json:"report"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	for _, key := range []string{"budget_amount", "committed", "actual_spent", "remaining"} {
+		if _, ok := report.Report[key]; !ok {
+			t.Errorf("expected %s in report, got %+v", key, report.Report)
+		}
+	}
+}
+
+func TestTaxEndpoints(t *testing.T) {
+	env := setupTestEnv()
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Set up a country and a province with a compound rate
+	for _, j := range []map[string]string{{"code": "CA", "name": "Canada"}, {"code": "CA-QC", "name": "Quebec", "parent_code": "CA"}} {
+		if w := send(http.MethodPost, "/api/v1/tax/jurisdictions", j); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+	}
+	if w := send(http.MethodPost, "/api/v1/tax/jurisdictions", map[string]string{"code": "CA-ON", "name": "Ontario", "parent_code": "XX"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown parent, got %d", w.Code)
+	}
+	rates := []map[string]interface{}{
+		{"code": "GST", "name": "GST", "rate": "0.05", "jurisdiction_code": "CA"},
+		{"code": "QST", "name": "QST", "rate": "0.09975", "jurisdiction_code": "CA-QC", "is_compound": true, "sequence": 2},
+	}
+	for _, r := range rates {
+		if w := send(http.MethodPost, "/api/v1/tax/rates", r); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// 2. Determine the tax of a sale
+	w := send(http.MethodPost, "/api/v1/tax/determine", map[string]interface{}{
+		"direction": "sales", "ship_to": "CA-QC", "lines": []map[string]string{{"amount": "100"}},
+	})
+	var determined struct {
+		Data service.TaxDetermination `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &determined)
+	if w.Code != http.StatusOK || !determined.Data.TaxAmount.Equal(decimal.RequireFromString("15.47")) {
+		t.Errorf("expected tax 15.47, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/tax/determine", map[string]interface{}{"direction": "sales", "ship_to": "CA-QC"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without lines, got %d", w.Code)
+	}
+
+	// 3. An invoice with lines is taxed and posted
+	w = send(http.MethodPost, "/api/v1/invoices", map[string]interface{}{
+		"legal_entity_id": "le_1", "customer_id": "cust_1", "ship_to": "CA-QC", "due_date": time.Now().AddDate(0, 0, 30),
+		"lines": []map[string]string{{"amount": "100"}},
+	})
+	var invoice struct {
+		Data domain.ArInvoice `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &invoice)
+	if w.Code != http.StatusCreated || !invoice.Data.TotalAmount.Equal(decimal.RequireFromString("115.47")) {
+		t.Fatalf("expected taxed invoice of 115.47, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 4. The tax return shows the output tax
+	period := time.Now().Format("2006-01")
+	w = send(http.MethodGet, "/api/v1/tax/returns?legal_entity_id=le_1&from_period="+period, nil)
+	var ret struct {
+		Data service.TaxReturn `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &ret)
+	if w.Code != http.StatusOK || !ret.Data.NetTaxPayable.Equal(decimal.RequireFromString("15.47")) {
+		t.Errorf("expected net tax payable 15.47, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/tax/returns?legal_entity_id=le_1", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without period, got %d", w.Code)
+	}
+}
+
+func TestVendorBillMatchEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. A default tolerance and a purchase order of which half has been received
+	if w := send(http.MethodPut, "/api/v1/vendor-bills/match-tolerances", map[string]string{"price_tolerance_percent": "5", "quantity_tolerance_percent": "0"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	_ = env.poLines.ReplaceForPurchaseOrder(ctx, "po_1", []domain.PurchaseOrderLine{{ID: "pol_1", PurchaseOrderID: "po_1", VendorID: "vendor_1", MaterialID: "mat_1", QuantityOrdered: decimal.NewFromInt(10), UnitPrice: decimal.NewFromInt(20)}})
+	_ = env.receiptLines.CreateMany(ctx, []domain.GoodsReceiptLine{{ID: "grl_1", ReceiptID: "gr_1", PurchaseOrderID: "po_1", MaterialID: "mat_1", QuantityReceived: decimal.NewFromInt(5)}})
+
+	// 2. Billing the full order holds the bill
+	w := send(http.MethodPost, "/api/v1/vendor-bills", map[string]interface{}{
+		"legal_entity_id": "le_1", "vendor_id": "vendor_1", "bill_number": "BILL-M1", "purchase_order_id": "po_1",
+		"total_amount": "200", "items": []map[string]string{{"material_id": "mat_1", "quantity": "10", "unit_price": "20"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data domain.ApVendorBill `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.MatchStatus != domain.BillMatchStatusEXCEPTION || !created.Data.PaymentHold {
+		t.Fatalf("expected the bill on payment hold, got %+v", created.Data)
+	}
+
+	w = send(http.MethodGet, "/api/v1/vendor-bills/match-exceptions", nil)
+	var queue struct {
+		Data []service.VendorBillMatch `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &queue)
+	if len(queue.Data) != 1 || len(queue.Data[0].Variances) != 1 || queue.Data[0].Variances[0].Type != service.MatchVarianceQuantity {
+		t.Errorf("expected one quantity exception in the review queue, got %s", w.Body.String())
+	}
+	w = send(http.MethodGet, "/api/v1/vendor-bills/"+created.Data.ID+"/lines", nil)
+	if !strings.Contains(w.Body.String(), "mat_1") {
+		t.Errorf("expected the bill lines, got %s", w.Body.String())
+	}
+
+	payment := map[string]string{"bill_id": created.Data.ID, "amount": "200", "payment_method": "ACH"}
+	if w := send(http.MethodPost, "/api/v1/payments", payment); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 paying a held bill, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 3. A clerk overrides the match with a reason, which releases the hold
+	if w := send(http.MethodPost, "/api/v1/vendor-bills/"+created.Data.ID+"/match-override", map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a reason, got %d", w.Code)
+	}
+	w = send(http.MethodPost, "/api/v1/vendor-bills/"+created.Data.ID+"/match-override", map[string]string{"reason": "Remainder delivered to site", "overridden_by": "clerk_1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/vendor-bills/"+created.Data.ID+"/match-override", map[string]string{"reason": "again"}); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 overriding a released bill, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/api/v1/payments", payment); w.Code != http.StatusCreated {
+		t.Errorf("expected 201 paying the released bill, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/vendor-bills/bill_unknown/match", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown bill, got %d", w.Code)
+	}
+}
+
+func TestPaymentAllocationEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, inv := range []domain.ArInvoice{
+		{ID: "inv_a", LegalEntityID: "le_1", InvoiceNumber: "INV-A", CustomerID: "cust_1", TotalAmount: decimal.NewFromInt(300), Currency: "USD", DueDate: time.Now().AddDate(0, 0, -40), Status: domain.PaymentStatusOPEN},
+		{ID: "inv_b", LegalEntityID: "le_1", InvoiceNumber: "INV-B", CustomerID: "cust_1", TotalAmount: decimal.NewFromInt(500), Currency: "USD", DueDate: time.Now().AddDate(0, 0, 10), Status: domain.PaymentStatusOPEN},
+	} {
+		inv := inv
+		_ = env.invoices.Create(ctx, &inv)
+	}
+
+	// 1. One payment settles INV-A, part of INV-B and keeps the rest on account
+	w := send(http.MethodPost, "/api/v1/payments", map[string]interface{}{
+		"legal_entity_id": "le_1", "counterparty_type": "customer", "counterparty_id": "cust_1", "currency": "USD",
+		"amount": "900", "payment_method": "WIRE",
+		"allocations": []map[string]string{{"invoice_id": "inv_a", "amount": "300"}, {"invoice_id": "inv_b", "amount": "400"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var paid struct {
+		Data            domain.Payment             `json:"data"`
+		Allocations     []domain.PaymentAllocation `json:"allocations"`
+		OnAccountCredit *domain.OnAccountCredit    `json:"on_account_credit"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &paid)
+	if len(paid.Allocations) != 2 || paid.OnAccountCredit == nil || !paid.OnAccountCredit.RemainingAmount.Equal(decimal.NewFromInt(200)) {
+		t.Fatalf("expected two allocations and 200 on account, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/payments/"+paid.Data.ID+"/allocations", nil); !strings.Contains(w.Body.String(), "inv_b") {
+		t.Errorf("expected the payment allocations, got %s", w.Body.String())
+	}
+	inv, _ := env.invoices.GetByID(ctx, "inv_b")
+	if inv.Status != domain.PaymentStatusPARTIAL {
+		t.Errorf("expected INV-B to be partially paid, got %s", inv.Status)
+	}
+
+	// 2. The aging report shows the remaining 100 as current and the credit as unapplied
+	w = send(http.MethodGet, "/api/v1/reports/ar-aging?legal_entity_id=le_1", nil)
+	var aging struct {
+		Report service.AgingReport `json:"report"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &aging)
+	if !aging.Report.Totals.Days0To30.Equal(decimal.NewFromInt(100)) || !aging.Report.UnappliedCredits.Equal(decimal.NewFromInt(200)) {
+		t.Errorf("unexpected aging report: %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/reports/ap-aging", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a legal entity, got %d", w.Code)
+	}
+
+	// 3. The credit settles INV-B, then a credit memo goes on account in full
+	w = send(http.MethodPost, "/api/v1/on-account-credits/"+paid.OnAccountCredit.ID+"/apply", map[string]interface{}{
+		"allocations": []map[string]string{{"invoice_id": "inv_b"}},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/on-account-credits/missing/apply", map[string]interface{}{"allocations": []map[string]string{{"invoice_id": "inv_b"}}}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown credit, got %d", w.Code)
+	}
+	w = send(http.MethodPost, "/api/v1/invoices/inv_b/credit-memos", map[string]string{"amount": "50", "reason": "Goodwill"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/invoices/inv_missing/credit-memos", map[string]string{"amount": "50", "reason": "Goodwill"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown invoice, got %d", w.Code)
+	}
+	w = send(http.MethodGet, "/api/v1/on-account-credits?counterparty_type=CUSTOMER&counterparty_id=cust_1&open=true", nil)
+	var credits struct {
+		Data []domain.OnAccountCredit `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &credits)
+	if len(credits.Data) != 2 {
+		t.Errorf("expected the payment and credit memo credits to be open, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/invoices/inv_b/allocations", nil); strings.Count(w.Body.String(), `"source_type"`) != 2 {
+		t.Errorf("expected a payment and a credit allocation on INV-B, got %s", w.Body.String())
+	}
+}
+
+func TestDunningEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	_ = env.invoices.Create(ctx, &domain.ArInvoice{
+		ID: "inv_late", LegalEntityID: "le_1", InvoiceNumber: "INV-LATE", CustomerID: "cust_1", TotalAmount: decimal.NewFromInt(1000),
+		Currency: "USD", DueDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Status: domain.PaymentStatusOPEN,
+	})
+
+	// 1. A run without levels is rejected
+	if w := send(http.MethodPost, "/api/v1/dunning/runs", map[string]string{"legal_entity_id": "le_1", "as_of": "2024-02-15"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without dunning levels, got %d", w.Code)
+	}
+
+	// 2. Configure two levels; a level with a lower threshold than the one below is rejected
+	for _, level := range []map[string]interface{}{
+		{"legal_entity_id": "le_1", "level": 1, "name": "Reminder", "days_overdue": 7},
+		{"legal_entity_id": "le_1", "level": 2, "name": "Final Notice", "days_overdue": 30, "fee_amount": "25"},
+	} {
+		if w := send(http.MethodPut, "/api/v1/dunning/levels", level); w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+	}
+	w := send(http.MethodPut, "/api/v1/dunning/levels", map[string]interface{}{"legal_entity_id": "le_1", "level": 3, "name": "Collections", "days_overdue": 10})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a falling threshold, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/v1/dunning/levels?legal_entity_id=le_1", nil); strings.Count(w.Body.String(), `"days_overdue"`) != 2 {
+		t.Errorf("expected two levels, got %s", w.Body.String())
+	}
+
+	// 3. The run issues the final notice and charges the fee
+	w = send(http.MethodPost, "/api/v1/dunning/runs", map[string]string{"legal_entity_id": "le_1", "as_of": "2024-02-15"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var run struct {
+		Data service.DunningRunResult `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &run)
+	if len(run.Data.Notices) != 1 || run.Data.Notices[0].Level != 2 || !run.Data.Notices[0].IsFinal {
+		t.Fatalf("expected one final notice, got %s", w.Body.String())
+	}
+	inv, _ := env.invoices.GetByID(ctx, "inv_late")
+	if inv.Status != domain.PaymentStatusOVERDUE || !inv.FeesCharged.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("expected an overdue invoice with a 25 fee, got %s and %s", inv.Status, inv.FeesCharged)
+	}
+
+	if w := send(http.MethodGet, "/api/v1/dunning/runs/"+run.Data.Run.ID, nil); !strings.Contains(w.Body.String(), "inv_late") {
+		t.Errorf("expected the run notices, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/dunning/runs/missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/v1/invoices/inv_late/dunning-notices", nil); strings.Count(w.Body.String(), `"run_id"`) != 1 {
+		t.Errorf("expected one notice on the invoice, got %s", w.Body.String())
+	}
+
+	// 4. Levels can be removed
+	if w := send(http.MethodDelete, "/api/v1/dunning/levels/2?legal_entity_id=le_1", nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := send(http.MethodDelete, "/api/v1/dunning/levels/2?legal_entity_id=le_1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a removed level, got %d", w.Code)
+	}
+}
+
+func TestCustomerCreditEndpoints(t *testing.T) {
+	env := setupTestEnv()
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Username", "credit.manager")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Raise the limit at the version read; repeating with the stale version conflicts
+	var credit struct {
+		Data domain.CustomerCredit `json:"data"`
+	}
+	_ = json.Unmarshal(send(http.MethodGet, "/api/v1/customers/cust_9/credit", nil).Body.Bytes(), &credit)
+	if w := send(http.MethodPut, "/api/v1/customers/cust_9/credit", map[string]interface{}{"credit_limit": "2000", "version": credit.Data.Version}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 when setting limit, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPut, "/api/v1/customers/cust_9/credit", map[string]interface{}{"credit_limit": "3000", "version": credit.Data.Version}); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a stale version, got %d", w.Code)
+	}
+
+	// 2. An order above the limit is refused, then approved by an override
+	var check struct {
+		Data service.CreditCheckResult `json:"data"`
+	}
+	w := send(http.MethodPost, "/api/v1/customers/cust_9/credit/check", map[string]string{"sales_order_id": "so_9", "order_value": "2500"})
+	_ = json.Unmarshal(w.Body.Bytes(), &check)
+	if w.Code != http.StatusOK || check.Data.Approved {
+		t.Fatalf("expected a refused check, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	// The approver is the authenticated user, whatever the body claims
+	w = send(http.MethodPost, "/api/v1/customers/cust_9/credit/overrides", map[string]string{"sales_order_id": "so_9", "amount": "2500", "reason": "year-end deal", "approved_by": "cfo"})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "credit.manager") || strings.Contains(w.Body.String(), "cfo") {
+		t.Fatalf("expected 201 with the approving user, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	w = send(http.MethodPost, "/api/v1/customers/cust_9/credit/check", map[string]string{"sales_order_id": "so_9", "order_value": "2500"})
+	_ = json.Unmarshal(w.Body.Bytes(), &check)
+	if !check.Data.Approved || check.Data.OverrideID == "" {
+		t.Errorf("expected the override to approve the order, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/customers/cust_9/credit/overrides", nil); !strings.Contains(w.Body.String(), "so_9") {
+		t.Errorf("expected the override to be listed, got %s", w.Body.String())
+	}
+
+	// 3. Hold and release; releasing twice conflicts
+	if w := send(http.MethodPost, "/api/v1/customers/cust_9/credit/hold", map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a hold without reason, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/api/v1/customers/cust_9/credit/hold", map[string]string{"reason": "bounced cheque"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on hold, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/customers/cust_9/credit/exposure", nil); !strings.Contains(w.Body.String(), "bounced cheque") {
+		t.Errorf("expected the hold reason in the exposure, got %s", w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/customers/cust_9/credit/release", map[string]string{"reason": "cleared"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on release, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/customers/cust_9/credit/release", map[string]string{"reason": "cleared"}); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 releasing a customer not on hold, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/v1/customers/cust_9/credit", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"credit_limit":"2000"`) {
+		t.Errorf("expected the stored credit record, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPaymentRunEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+	_ = env.legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme GmbH", FunctionalCurrency: "EUR"})
+	_ = env.bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "le_1", AccountNumber: "DE89370400440532013000", Bic: "COBADEFFXXX", Currency: "EUR"})
+	_ = env.bills.Create(ctx, &domain.ApVendorBill{ID: "bill_1", LegalEntityID: "le_1", BillNumber: "BILL-1", VendorID: "vend_1", Currency: "EUR",
+		TotalAmount: decimal.NewFromInt(750), ExchangeRate: decimal.NewFromInt(1), DueDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Status: domain.PaymentStatusOPEN})
+
+	send := func(method, path, user string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("X-Username", user)
+		}
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Vendor bank details are validated before they are stored
+	if w := send(http.MethodPut, "/api/v1/vendors/vend_1/bank-account", "ap.clerk", map[string]string{"account_name": "Vendor", "iban": "DE00370400440532013000"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad IBAN, got %d", w.Code)
+	}
+	if w := send(http.MethodPut, "/api/v1/vendors/vend_1/bank-account", "ap.clerk", map[string]string{"account_name": "Vendor GmbH", "iban": "FR1420041010050500013M02606"}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 when setting bank details, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/vendors/vend_1/bank-account", "", nil); !strings.Contains(w.Body.String(), "FR1420041010050500013M02606") {
+		t.Errorf("expected the stored IBAN, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/vendors/vend_2/bank-account", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a vendor without bank details, got %d", w.Code)
+	}
+
+	// 2. Propose a run for the bills due by the horizon
+	if w := send(http.MethodPost, "/api/v1/payment-runs", "ap.clerk", map[string]string{"legal_entity_id": "le_1", "due_by": "01/05/2024"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed date, got %d", w.Code)
+	}
+	w := send(http.MethodPost, "/api/v1/payment-runs", "ap.clerk", map[string]string{"legal_entity_id": "le_1", "due_by": "2024-05-15", "payment_date": "2024-05-10"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var proposal struct {
+		Data service.PaymentRunDetail `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &proposal)
+	if proposal.Data.Run.BillCount != 1 || proposal.Data.Run.ProposedBy != "ap.clerk" {
+		t.Fatalf("expected one proposed bill, got %s", w.Body.String())
+	}
+	runPath := "/api/v1/payment-runs/" + proposal.Data.Run.ID
+
+	// 3. Approval needs a user and executes the run once
+	if w := send(http.MethodPost, runPath+"/approve", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an approver, got %d", w.Code)
+	}
+	w = send(http.MethodPost, runPath+"/approve", "ap.manager", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on approval, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var executed struct {
+		Data service.PaymentRunDetail `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &executed)
+	if executed.Data.Run.Status != domain.PaymentRunStatusEXECUTED || len(executed.Data.Files) != 1 {
+		t.Fatalf("expected an executed run with one file, got %s", w.Body.String())
+	}
+	if w := send(http.MethodPost, runPath+"/approve", "ap.manager", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 approving an executed run, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, runPath+"/cancel", "ap.manager", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 cancelling an executed run, got %d", w.Code)
+	}
+
+	// 4. The SEPA file downloads as an attachment
+	w = send(http.MethodGet, runPath+"/files/"+executed.Data.Files[0].ID, "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/xml" ||
+		!strings.Contains(w.Header().Get("Content-Disposition"), executed.Data.Files[0].FileName) ||
+		!strings.Contains(w.Body.String(), `<InstdAmt Ccy="EUR">750.00</InstdAmt>`) {
+		t.Errorf("unexpected file download: %d %v\n%s", w.Code, w.Header(), w.Body.String())
+	}
+	if w := send(http.MethodGet, runPath+"/files/missing", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown file, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/v1/payment-runs/missing", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/v1/payment-runs", "", nil); !strings.Contains(w.Body.String(), proposal.Data.Run.ID) {
+		t.Errorf("expected the run to be listed, got %s", w.Body.String())
+	}
+
+	// 5. Callers of another legal entity neither list nor reach the run
+	inEntity := func(path, legalEntityID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Legal-Entity-ID", legalEntityID)
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	if w := inEntity(runPath, "le_1"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for the entity's own run, got %d", w.Code)
+	}
+	if w := inEntity(runPath, "le_2"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another entity's run, got %d", w.Code)
+	}
+	if w := inEntity("/api/v1/payment-runs", "le_2"); strings.Contains(w.Body.String(), proposal.Data.Run.ID) {
+		t.Errorf("expected another entity's run to be hidden, got %s", w.Body.String())
+	}
+}
+
+func TestJournalTemplateEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+	_ = env.legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme GmbH", FunctionalCurrency: "EUR"})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_rent", LegalEntityID: "le_1", AccountCode: "6100-001", AccountName: "Rent", Type: domain.AccountTypeEXPENSE, IsActive: true})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_accrued", LegalEntityID: "le_1", AccountCode: "2300-001", AccountName: "Accrued Liabilities", Type: domain.AccountTypeLIABILITY, IsActive: true})
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	template := map[string]interface{}{
+		"legal_entity_id": "le_1",
+		"name":            "Office rent",
+		"frequency":       "MONTHLY",
+		"start_date":      "2025-01-31",
+		"auto_reverse":    true,
+		"lines": []map[string]string{
+			{"account_id": "acc_rent", "amount": "1000"},
+			{"account_id": "acc_accrued", "amount": "-1000"},
+		},
+	}
+
+	// 1. Create the template; unbalanced lines are refused
+	bad := map[string]interface{}{"legal_entity_id": "le_1", "name": "Broken", "frequency": "MONTHLY", "start_date": "2025-01-31",
+		"lines": []map[string]string{{"account_id": "acc_rent", "amount": "1000"}, {"account_id": "acc_accrued", "amount": "-10"}}}
+	if w := send(http.MethodPost, "/api/v1/journal-templates", bad); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unbalanced lines, got %d", w.Code)
+	}
+	w := send(http.MethodPost, "/api/v1/journal-templates", template)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data service.JournalTemplateDetail `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	id := created.Data.Template.ID
+
+	// 2. Generate the January accrual, then let the run reverse it on 1 February
+	w = send(http.MethodPost, "/api/v1/journal-templates/"+id+"/generate", map[string]string{"as_of": "2025-01-31"})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"reversal_date":"2025-02-01T00:00:00Z"`) {
+		t.Fatalf("expected the accrual to be generated, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	w = send(http.MethodPost, "/api/v1/journal-templates/run", map[string]string{"as_of": "2025-02-05"})
+	var run struct {
+		Data struct {
+			Generated []domain.UniversalJournalEntry `json:"generated"`
+			Reversed  []domain.UniversalJournalEntry `json:"reversed"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &run)
+	if w.Code != http.StatusOK || len(run.Data.Generated) != 0 || len(run.Data.Reversed) != 1 {
+		t.Fatalf("expected one reversal, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 3. Generated entries show up in the journal entry endpoints
+	w = send(http.MethodGet, "/api/v1/journal-entries?template_id="+id, nil)
+	var listed struct {
+		Data []domain.UniversalJournalEntry `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed.Data) != 2 {
+		t.Errorf("expected the accrual and its reversal, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/journal-entries/"+run.Data.Reversed[0].ID, nil); !strings.Contains(w.Body.String(), `"reversal_of_id"`) {
+		t.Errorf("expected the reversal to link to its accrual, got %s", w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/v1/journal-templates/"+id, nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"next_run_date":"2025-02-28T00:00:00Z"`) {
+		t.Errorf("expected the next run at February month end, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 4. Deactivated templates do not generate
+	template["is_active"] = false
+	if w := send(http.MethodPut, "/api/v1/journal-templates/"+id, template); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on update, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/journal-templates/"+id+"/generate", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an inactive template, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/v1/journal-templates/missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown template, got %d", w.Code)
+	}
+
+	// 5. One-off accruals through the journal entry endpoints
+	w = send(http.MethodPost, "/api/v1/journal-entries/accruals", map[string]interface{}{
+		"legal_entity_id": "le_1",
+		"posting_date":    "2025-03-20T00:00:00Z",
+		"lines": []map[string]string{
+			{"account_id": "acc_rent", "amount_functional": "250"},
+			{"account_id": "acc_accrued", "amount_functional": "-250"},
+		},
+	})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"reversal_date":"2025-04-01T00:00:00Z"`) {
+		t.Errorf("expected an accrual reversing on 2025-04-01, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCostAllocationEndpoints(t *testing.T) {
+	env := setupTestEnv()
+	ctx := context.Background()
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_rent", LegalEntityID: "le_1", AccountCode: "6100-001", AccountName: "Rent", Type: domain.AccountTypeEXPENSE, IsActive: true})
+	_ = env.accounts.Create(ctx, &domain.ChartOfAccounts{ID: "acc_cash", LegalEntityID: "le_1", AccountCode: "1000-001", AccountName: "Cash", Type: domain.AccountTypeASSET, IsActive: true})
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Username", "controller")
+		env.router.ServeHTTP(w, req)
+		return w
+	}
+	costCenter := func(code string) string {
+		w := send(http.MethodPost, "/api/v1/cost-centers", map[string]string{"code": code, "name": code})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201 for cost center %s, got %d. Body: %s", code, w.Code, w.Body.String())
+		}
+		var created struct {
+			Data domain.CostCenter `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &created)
+		return created.Data.ID
+	}
+
+	// 1. Cost centers; codes are unique
+	maintenance, assembly, paint := costCenter("MAINT"), costCenter("ASSY"), costCenter("PAINT")
+	if w := send(http.MethodPost, "/api/v1/cost-centers", map[string]string{"code": "maint", "name": "Duplicate"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a duplicate code, got %d", w.Code)
+	}
+	_ = env.entries.Create(ctx, &domain.UniversalJournalEntry{
+		ID: "je_maint", LegalEntityID: "le_1", SourceModule: "FM", PostingDate: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		FinancialPeriod: "2025-03", Status: domain.LedgerStatePOSTED,
+	}, []domain.UniversalJournalLine{
+		{ID: "jel_1", AccountID: "acc_rent", AmountFunctional: decimal.NewFromInt(900), TrackingDimensions: map[string]interface{}{"cost_center_id": maintenance}},
+		{ID: "jel_2", AccountID: "acc_cash", AmountFunctional: decimal.NewFromInt(-900)},
+	})
+
+	// 2. Maintenance is allocated by machine hours
+	w := send(http.MethodPost, "/api/v1/allocation-cycles", map[string]interface{}{
+		"legal_entity_id": "le_1",
+		"name":            "Maintenance by machine hours",
+		"method":          "STATISTICAL",
+		"driver":          "MACHINE_HOURS",
+		"senders":         []string{maintenance},
+		"receivers":       []map[string]string{{"cost_center_id": assembly}, {"cost_center_id": paint}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data service.AllocationCycleDetail `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	id := created.Data.Cycle.ID
+
+	if w := send(http.MethodPost, "/api/v1/allocation-cycles/"+id+"/run", map[string]string{"period": "2025-03"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without machine hours, got %d", w.Code)
+	}
+	w = send(http.MethodPut, "/api/v1/allocation-drivers", map[string]interface{}{
+		"values": []map[string]string{
+			{"cost_center_id": assembly, "driver": "MACHINE_HOURS", "period": "2025-03", "value": "120", "source": "MFG"},
+			{"cost_center_id": paint, "driver": "MACHINE_HOURS", "period": "2025-03", "value": "60", "source": "MFG"},
+		},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 recording driver values, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 3. Run the period, then run it again
+	w = send(http.MethodPost, "/api/v1/allocation-cycles/"+id+"/run", map[string]string{"period": "2025-03"})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"total_allocated":"900"`) || !strings.Contains(w.Body.String(), `"posted_by":"controller"`) {
+		t.Fatalf("expected 900 allocated, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	w = send(http.MethodPost, "/api/v1/allocation-cycles/"+id+"/run", map[string]string{"period": "2025-03"})
+	var run struct {
+		Data domain.AllocationRun `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &run)
+	if w.Code != http.StatusCreated || !run.Data.TotalAllocated.Equal(decimal.NewFromInt(900)) {
+		t.Fatalf("expected the re-run to allocate 900 again, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	_, lines, _ := env.entries.GetByID(ctx, run.Data.JournalEntryID)
+	shares := map[string]string{}
+	for _, l := range lines {
+		dims, _ := l.TrackingDimensions.(map[string]interface{})
+		cc, _ := dims["cost_center_id"].(string)
+		shares[cc] = l.AmountFunctional.String()
+	}
+	if shares[maintenance] != "-900" || shares[assembly] != "600" || shares[paint] != "300" {
+		t.Errorf("expected 600/300 by machine hours, got %v", shares)
+	}
+
+	// 4. Reverse the run
+	if w := send(http.MethodPost, "/api/v1/allocation-runs/"+run.Data.ID+"/reverse", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"REVERSED"`) {
+		t.Errorf("expected the run to be reversed, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/allocation-runs/"+run.Data.ID+"/reverse", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 reversing twice, got %d", w.Code)
+	}
+	w = send(http.MethodGet, "/api/v1/allocation-cycles/"+id, nil)
+	if w.Code != http.StatusOK || strings.Count(w.Body.String(), `"status":"REVERSED"`) != 2 {
+		t.Errorf("expected both runs reversed, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/v1/allocation-runs/missing/reverse", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", w.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type IntercompanyHandler struct {
+	svc      *service.IntercompanyService
+	response *utils.ResponseHelper
+}
+
+func NewIntercompanyHandler(svc *service.IntercompanyService, response *utils.ResponseHelper) *IntercompanyHandler {
+	return &IntercompanyHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *IntercompanyHandler) GetTransactions(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	if legalEntityID == "" {
+		h.response.BadRequest(c, "legal_entity_id is required")
+		return
+	}
+	transactions, err := h.svc.ListTransactions(c.Request.Context(), legalEntityID)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": transactions})
+}
+
+func (h *IntercompanyHandler) GetTransaction(c *gin.Context) {
+	tx, err := h.svc.GetTransaction(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.NotFound(c, "intercompany transaction not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tx})
+}
+
+func (h *IntercompanyHandler) CreateTransaction(c *gin.Context) {
+	var req struct {
+		FromLegalEntityID string    `json:"from_legal_entity_id" binding:"required"`
+		ToLegalEntityID   string    `json:"to_legal_entity_id" binding:"required"`
+		FromAccountID     string    `json:"from_account_id" binding:"required"`
+		ToAccountID       string    `json:"to_account_id" binding:"required"`
+		Currency          string    `json:"currency"`
+		Amount            string    `json:"amount" binding:"required"`
+		PostingDate       time.Time `json:"posting_date"`
+		Description       string    `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid amount")
+		return
+	}
+	if req.PostingDate.IsZero() {
+		req.PostingDate = time.Now()
+	}
+
+	tx, err := h.svc.CreateTransaction(c.Request.Context(), service.IntercompanyRequest{
+		FromLegalEntityID: req.FromLegalEntityID,
+		ToLegalEntityID:   req.ToLegalEntityID,
+		FromAccountID:     req.FromAccountID,
+		ToAccountID:       req.ToAccountID,
+		Currency:          req.Currency,
+		Amount:            amount,
+		PostingDate:       req.PostingDate,
+		Description:       req.Description,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			h.response.ConflictErr(c, err)
+			return
+		}
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": tx})
+}
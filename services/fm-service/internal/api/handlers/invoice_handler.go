@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"erp-system/shared/utils"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -36,9 +38,17 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 		LegalEntityID string    `json:"legal_entity_id"`
 		CustomerID    string    `json:"customer_id"`
 		SalesOrderID  string    `json:"sales_order_id"`
+		Currency      string    `json:"currency"`
 		TotalAmount   string    `json:"total_amount"`
 		TaxAmount     string    `json:"tax_amount"`
 		DueDate       time.Time `json:"due_date"`
+
+		// Invoices with lines have their tax determined by the tax engine
+		ShipFrom         string                   `json:"ship_from"`
+		ShipTo           string                   `json:"ship_to"`
+		PricesIncludeTax bool                     `json:"prices_include_tax"`
+		InvoiceDate      time.Time                `json:"invoice_date"`
+		Lines            []service.TaxLineRequest `json:"lines"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,6 +56,27 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 		return
 	}
 
+	if len(req.Lines) > 0 {
+		invoice, det, err := h.svc.CreateInvoiceWithTax(c.Request.Context(), service.InvoiceRequest{
+			LegalEntityID:    req.LegalEntityID,
+			CustomerID:       req.CustomerID,
+			SalesOrderID:     req.SalesOrderID,
+			Currency:         req.Currency,
+			ShipFrom:         req.ShipFrom,
+			ShipTo:           req.ShipTo,
+			PricesIncludeTax: req.PricesIncludeTax,
+			InvoiceDate:      req.InvoiceDate,
+			DueDate:          req.DueDate,
+			Lines:            req.Lines,
+		})
+		if err != nil {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": invoice, "tax": det})
+		return
+	}
+
 	totalDec, err := decimal.NewFromString(req.TotalAmount)
 	if err != nil {
 		totalDec = decimal.Zero
@@ -55,7 +86,7 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 		taxDec = decimal.Zero
 	}
 
-	invoice, err := h.svc.CreateInvoice(c.Request.Context(), req.LegalEntityID, req.CustomerID, req.SalesOrderID, totalDec, taxDec, req.DueDate)
+	invoice, err := h.svc.CreateInvoice(c.Request.Context(), req.LegalEntityID, req.CustomerID, req.SalesOrderID, req.Currency, totalDec, taxDec, req.DueDate)
 	if err != nil {
 		h.response.BadRequest(c, err.Error())
 		return
@@ -136,3 +167,156 @@ func (h *InvoiceHandler) GetCustomerCredit(c *gin.Context) {
 		"data": cc,
 	})
 }
+
+func (h *InvoiceHandler) GetCreditExposure(c *gin.Context) {
+	exposure, err := h.svc.GetCreditExposure(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.creditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": exposure})
+}
+
+func (h *InvoiceHandler) SetCreditLimit(c *gin.Context) {
+	var req struct {
+		CreditLimit string `json:"credit_limit" binding:"required"`
+		Version     *int   `json:"version" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	limit, err := decimal.NewFromString(req.CreditLimit)
+	if err != nil {
+		h.response.BadRequest(c, "invalid credit_limit")
+		return
+	}
+
+	cc, err := h.svc.SetCreditLimit(c.Request.Context(), c.Param("id"), limit, *req.Version, c.GetHeader("X-Username"))
+	if err != nil {
+		h.creditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cc})
+}
+
+func (h *InvoiceHandler) CheckCredit(c *gin.Context) {
+	var req struct {
+		LegalEntityID string `json:"legal_entity_id"`
+		SalesOrderID  string `json:"sales_order_id"`
+		OrderValue    string `json:"order_value" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	orderValue, err := decimal.NewFromString(req.OrderValue)
+	if err != nil {
+		h.response.BadRequest(c, "invalid order_value")
+		return
+	}
+	legalEntityID := req.LegalEntityID
+	if legalEntityID == "" {
+		legalEntityID = c.GetHeader(legalEntityHeader)
+	}
+
+	result, err := h.svc.CheckCredit(c.Request.Context(), service.CreditCheckRequest{
+		CustomerID:    c.Param("id"),
+		LegalEntityID: legalEntityID,
+		SalesOrderID:  req.SalesOrderID,
+		OrderValue:    orderValue,
+	})
+	if err != nil {
+		h.creditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *InvoiceHandler) HoldCustomerCredit(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	cc, err := h.svc.HoldCustomerCredit(c.Request.Context(), c.Param("id"), req.Reason, c.GetHeader("X-Username"))
+	if err != nil {
+		h.creditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cc})
+}
+
+func (h *InvoiceHandler) ReleaseCustomerCredit(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	cc, err := h.svc.ReleaseCreditHold(c.Request.Context(), c.Param("id"), req.Reason, c.GetHeader("X-Username"))
+	if err != nil {
+		h.creditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cc})
+}
+
+func (h *InvoiceHandler) GetCreditOverrides(c *gin.Context) {
+	overrides, err := h.svc.ListCreditOverrides(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": overrides})
+}
+
+func (h *InvoiceHandler) CreateCreditOverride(c *gin.Context) {
+	var req struct {
+		SalesOrderID string `json:"sales_order_id" binding:"required"`
+		Amount       string `json:"amount" binding:"required"`
+		Reason       string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid amount")
+		return
+	}
+	override, err := h.svc.OverrideCreditCheck(c.Request.Context(), service.CreditOverrideRequest{
+		CustomerID:   c.Param("id"),
+		SalesOrderID: req.SalesOrderID,
+		Amount:       amount,
+		ApprovedBy:   c.GetHeader("X-Username"),
+		Reason:       req.Reason,
+	})
+	if err != nil {
+		h.creditError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": override})
+}
+
+func (h *InvoiceHandler) creditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCreditRequest):
+		h.response.BadRequest(c, err.Error())
+	case errors.Is(err, domain.ErrOptimisticLock), errors.Is(err, domain.ErrCustomerNotOnHold):
+		h.response.ConflictErr(c, err)
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type JournalTemplateHandler struct {
+	svc      *service.RecurringJournalService
+	response *utils.ResponseHelper
+}
+
+func NewJournalTemplateHandler(svc *service.RecurringJournalService, response *utils.ResponseHelper) *JournalTemplateHandler {
+	return &JournalTemplateHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+type journalTemplateBody struct {
+	LegalEntityID string `json:"legal_entity_id"`
+	Name          string `json:"name" binding:"required"`
+	Description   string `json:"description"`
+	Currency      string `json:"currency"`
+	Frequency     string `json:"frequency" binding:"required"`
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	AutoReverse   bool   `json:"auto_reverse"`
+	IsActive      *bool  `json:"is_active"`
+	Lines         []struct {
+		AccountID   string `json:"account_id"`
+		Amount      string `json:"amount"`
+		Description string `json:"description"`
+	} `json:"lines"`
+}
+
+// bindTemplate reads a template body, parsing its dates (YYYY-MM-DD) and amounts.
+func (h *JournalTemplateHandler) bindTemplate(c *gin.Context) (service.JournalTemplateRequest, bool) {
+	var body journalTemplateBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return service.JournalTemplateRequest{}, false
+	}
+
+	req := service.JournalTemplateRequest{
+		LegalEntityID: body.LegalEntityID,
+		Name:          body.Name,
+		Description:   body.Description,
+		Currency:      body.Currency,
+		Frequency:     domain.RecurrenceFrequency(body.Frequency),
+		AutoReverse:   body.AutoReverse,
+		IsActive:      body.IsActive,
+	}
+	if body.StartDate != "" {
+		start, err := time.Parse("2006-01-02", body.StartDate)
+		if err != nil {
+			h.response.BadRequest(c, "invalid start_date, expected YYYY-MM-DD")
+			return req, false
+		}
+		req.StartDate = start
+	}
+	if body.EndDate != "" {
+		end, err := time.Parse("2006-01-02", body.EndDate)
+		if err != nil {
+			h.response.BadRequest(c, "invalid end_date, expected YYYY-MM-DD")
+			return req, false
+		}
+		req.EndDate = &end
+	}
+	for _, l := range body.Lines {
+		amount, err := decimal.NewFromString(l.Amount)
+		if err != nil {
+			h.response.BadRequest(c, "invalid line amount: "+l.Amount)
+			return req, false
+		}
+		req.Lines = append(req.Lines, service.JournalTemplateLineRequest{
+			AccountID:   l.AccountID,
+			Amount:      amount,
+			Description: l.Description,
+		})
+	}
+	return req, true
+}
+
+func (h *JournalTemplateHandler) GetJournalTemplates(c *gin.Context) {
+	templates, err := h.svc.ListTemplates(c.Request.Context(), c.Query("legal_entity_id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+func (h *JournalTemplateHandler) CreateJournalTemplate(c *gin.Context) {
+	req, ok := h.bindTemplate(c)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.CreateTemplate(c.Request.Context(), req)
+	if err != nil {
+		h.journalTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": detail})
+}
+
+func (h *JournalTemplateHandler) GetJournalTemplate(c *gin.Context) {
+	detail, err := h.svc.GetTemplate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.journalTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+func (h *JournalTemplateHandler) UpdateJournalTemplate(c *gin.Context) {
+	req, ok := h.bindTemplate(c)
+	if !ok {
+		return
+	}
+	detail, err := h.svc.UpdateTemplate(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.journalTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+// GenerateJournalEntries posts a template's entries due by as_of (today when omitted) without
+// waiting for the scheduler.
+func (h *JournalTemplateHandler) GenerateJournalEntries(c *gin.Context) {
+	asOf, ok := h.bindAsOf(c)
+	if !ok {
+		return
+	}
+	entries, err := h.svc.GenerateEntries(c.Request.Context(), c.Param("id"), asOf)
+	if err != nil {
+		h.journalTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": entries})
+}
+
+// RunJournalTemplates does what the scheduler does for as_of: generates the due entries of all
+// templates and reverses due accruals. Failures of single templates are reported alongside the
+// entries that were posted.
+func (h *JournalTemplateHandler) RunJournalTemplates(c *gin.Context) {
+	asOf, ok := h.bindAsOf(c)
+	if !ok {
+		return
+	}
+	generated, reversed, err := h.svc.RunDueTemplates(c.Request.Context(), asOf)
+	resp := gin.H{"data": gin.H{"generated": generated, "reversed": reversed}}
+	if err != nil {
+		if generated == nil {
+			h.response.InternalErr(c, err)
+			return
+		}
+		resp["error"] = err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *JournalTemplateHandler) bindAsOf(c *gin.Context) (time.Time, bool) {
+	var req struct {
+		AsOf string `json:"as_of"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.response.BadRequest(c, err.Error())
+			return time.Time{}, false
+		}
+	}
+	if req.AsOf == "" {
+		return time.Now(), true
+	}
+	asOf, err := time.Parse("2006-01-02", req.AsOf)
+	if err != nil {
+		h.response.BadRequest(c, "invalid as_of date, expected YYYY-MM-DD")
+		return time.Time{}, false
+	}
+	return asOf, true
+}
+
+func (h *JournalTemplateHandler) journalTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidJournalTemplate):
+		h.response.BadRequest(c, err.Error())
+	case errors.Is(err, domain.ErrJournalTemplateNotFound):
+		h.response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrJournalTemplateInactive), errors.Is(err, domain.ErrPeriodClosed):
+		h.response.ConflictErr(c, err)
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
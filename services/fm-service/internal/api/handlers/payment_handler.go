@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"erp-system/shared/utils"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -37,6 +44,14 @@ func (h *PaymentHandler) RecordPayment(c *gin.Context) {
 		BankAccountID string `json:"bank_account_id"`
 		Amount        string `json:"amount"`
 		PaymentMethod string `json:"payment_method"`
+
+		// Payments covering several documents, or kept on account, name the counterparty
+		LegalEntityID    string                      `json:"legal_entity_id"`
+		CounterpartyType string                      `json:"counterparty_type"`
+		CounterpartyID   string                      `json:"counterparty_id"`
+		Currency         string                      `json:"currency"`
+		AutoAllocate     bool                        `json:"auto_allocate"`
+		Allocations      []service.AllocationRequest `json:"allocations"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -50,13 +65,37 @@ func (h *PaymentHandler) RecordPayment(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.svc.RecordPayment(c.Request.Context(), req.InvoiceID, req.BillID, req.BankAccountID, amountDec, req.PaymentMethod)
+	allocations := req.Allocations
+	if len(allocations) == 0 {
+		switch {
+		case req.InvoiceID != "":
+			allocations = []service.AllocationRequest{{InvoiceID: req.InvoiceID}}
+		case req.BillID != "":
+			allocations = []service.AllocationRequest{{BillID: req.BillID}}
+		}
+	}
+
+	result, err := h.svc.RecordAllocatedPayment(c.Request.Context(), service.PaymentRequest{
+		LegalEntityID:    req.LegalEntityID,
+		CounterpartyType: domain.CounterpartyType(strings.ToUpper(req.CounterpartyType)),
+		CounterpartyID:   req.CounterpartyID,
+		BankAccountID:    req.BankAccountID,
+		Amount:           amountDec,
+		Currency:         req.Currency,
+		PaymentMethod:    req.PaymentMethod,
+		AutoAllocate:     req.AutoAllocate,
+		Allocations:      allocations,
+	})
 	if err != nil {
+		if errors.Is(err, domain.ErrBillOnPaymentHold) {
+			h.response.ConflictErr(c, err)
+			return
+		}
 		h.response.BadRequest(c, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"data": payment})
+	c.JSON(http.StatusCreated, gin.H{"data": result.Payment, "allocations": result.Allocations, "on_account_credit": result.OnAccountCredit})
 }
 
 func (h *PaymentHandler) GetPayment(c *gin.Context) {
@@ -80,3 +119,314 @@ func (h *PaymentHandler) GetBankStatementLines(c *gin.Context) {
 		"data": lines,
 	})
 }
+
+// maxStatementFileSize bounds uploaded statement files (10 MiB)
+const maxStatementFileSize = 10 << 20
+
+func (h *PaymentHandler) ImportBankStatement(c *gin.Context) {
+	bankAccountID := c.PostForm("bank_account_id")
+	if bankAccountID == "" {
+		h.response.BadRequest(c, "bank_account_id is required")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		h.response.BadRequest(c, "statement file is required")
+		return
+	}
+	if file.Size > maxStatementFileSize {
+		h.response.BadRequest(c, "statement file is too large")
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxStatementFileSize))
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.svc.ImportBankStatements(c.Request.Context(), bankAccountID, c.PostForm("format"), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidBankStatementFile):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "data": result})
+		case errors.Is(err, domain.ErrDuplicateBankStatement):
+			h.response.ConflictErr(c, err)
+		default:
+			h.response.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+func (h *PaymentHandler) GetCashFlowForecast(c *gin.Context) {
+	req := service.CashFlowForecastRequest{
+		Granularity:   service.ForecastGranularity(strings.ToUpper(c.Query("granularity"))),
+		LegalEntityID: c.Query("legal_entity_id"),
+		BankAccountID: c.Query("bank_account_id"),
+	}
+	if v := c.Query("months_ahead"); v != "" {
+		months, err := strconv.Atoi(v)
+		if err != nil {
+			h.response.BadRequest(c, "invalid months_ahead")
+			return
+		}
+		req.MonthsAhead = months
+	}
+	if v := c.Query("as_of"); v != "" {
+		asOf, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			h.response.BadRequest(c, "invalid as_of date, expected YYYY-MM-DD")
+			return
+		}
+		req.AsOf = asOf
+	}
+
+	forecast, err := h.svc.GetCashFlowForecast(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidForecastRequest) {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": forecast})
+}
+
+type recurringCashItemRequest struct {
+	LegalEntityID  string     `json:"legal_entity_id"`
+	BankAccountID  *string    `json:"bank_account_id"`
+	Description    string     `json:"description"`
+	Amount         string     `json:"amount"`
+	Frequency      string     `json:"frequency"`
+	NextOccurrence time.Time  `json:"next_occurrence"`
+	EndDate        *time.Time `json:"end_date"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+func (r recurringCashItemRequest) toDomain() (*domain.RecurringCashItem, error) {
+	amount, err := decimal.NewFromString(r.Amount)
+	if err != nil {
+		return nil, errors.New("invalid amount")
+	}
+	item := &domain.RecurringCashItem{
+		LegalEntityID:  r.LegalEntityID,
+		BankAccountID:  r.BankAccountID,
+		Description:    r.Description,
+		Amount:         amount,
+		Frequency:      domain.RecurrenceFrequency(strings.ToUpper(r.Frequency)),
+		NextOccurrence: r.NextOccurrence,
+		EndDate:        r.EndDate,
+		IsActive:       true,
+	}
+	if r.IsActive != nil {
+		item.IsActive = *r.IsActive
+	}
+	return item, nil
+}
+
+func (h *PaymentHandler) GetRecurringCashItems(c *gin.Context) {
+	items, err := h.svc.ListRecurringCashItems(c.Request.Context(), c.Query("legal_entity_id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (h *PaymentHandler) CreateRecurringCashItem(c *gin.Context) {
+	var req recurringCashItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	item, err := req.toDomain()
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	if err := h.svc.CreateRecurringCashItem(c.Request.Context(), item); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": item})
+}
+
+func (h *PaymentHandler) UpdateRecurringCashItem(c *gin.Context) {
+	var req recurringCashItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	item, err := req.toDomain()
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	item.ID = c.Param("id")
+	if _, err := h.svc.GetRecurringCashItem(c.Request.Context(), item.ID); err != nil {
+		h.response.NotFound(c, "recurring cash item not found")
+		return
+	}
+	if err := h.svc.UpdateRecurringCashItem(c.Request.Context(), item); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
+func (h *PaymentHandler) DeleteRecurringCashItem(c *gin.Context) {
+	if err := h.svc.DeleteRecurringCashItem(c.Request.Context(), c.Param("id")); err != nil {
+		h.response.NotFound(c, "recurring cash item not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "recurring cash item deleted successfully"})
+}
+
+func (h *PaymentHandler) GetPaymentAllocations(c *gin.Context) {
+	allocations, err := h.svc.ListPaymentAllocations(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.NotFound(c, "payment not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": allocations})
+}
+
+func (h *PaymentHandler) GetInvoiceAllocations(c *gin.Context) {
+	allocations, err := h.svc.ListDocumentAllocations(c.Request.Context(), c.Param("id"), "")
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": allocations})
+}
+
+func (h *PaymentHandler) GetVendorBillAllocations(c *gin.Context) {
+	allocations, err := h.svc.ListDocumentAllocations(c.Request.Context(), "", c.Param("id"))
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": allocations})
+}
+
+func (h *PaymentHandler) GetCreditMemos(c *gin.Context) {
+	memos, err := h.svc.ListCreditMemos(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": memos})
+}
+
+func (h *PaymentHandler) CreateCreditMemo(c *gin.Context) {
+	var req struct {
+		Amount string `json:"amount" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		h.response.BadRequest(c, "invalid amount")
+		return
+	}
+
+	result, err := h.svc.IssueCreditMemo(c.Request.Context(), c.Param("id"), amount, req.Reason)
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+func (h *PaymentHandler) GetOnAccountCredits(c *gin.Context) {
+	credits, err := h.svc.ListOnAccountCredits(
+		c.Request.Context(),
+		domain.CounterpartyType(strings.ToUpper(c.Query("counterparty_type"))),
+		c.Query("counterparty_id"),
+		c.Query("open") == "true",
+	)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": credits})
+}
+
+func (h *PaymentHandler) ApplyOnAccountCredit(c *gin.Context) {
+	var req struct {
+		Allocations []service.AllocationRequest `json:"allocations" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	application, err := h.svc.ApplyOnAccountCredit(c.Request.Context(), c.Param("id"), req.Allocations)
+	if err != nil {
+		h.allocationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": application})
+}
+
+func (h *PaymentHandler) GetReceivablesAging(c *gin.Context) {
+	h.agingReport(c, h.svc.GetReceivablesAging)
+}
+
+func (h *PaymentHandler) GetPayablesAging(c *gin.Context) {
+	h.agingReport(c, h.svc.GetPayablesAging)
+}
+
+func (h *PaymentHandler) agingReport(c *gin.Context, run func(context.Context, service.AgingRequest) (*service.AgingReport, error)) {
+	req := service.AgingRequest{
+		LegalEntityID:  c.Query("legal_entity_id"),
+		CounterpartyID: c.Query("counterparty_id"),
+	}
+	if v := c.Query("as_of"); v != "" {
+		asOf, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			h.response.BadRequest(c, "invalid as_of date, expected YYYY-MM-DD")
+			return
+		}
+		req.AsOf = asOf
+	}
+
+	report, err := run(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAgingRequest) {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func (h *PaymentHandler) allocationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvoiceNotFound), errors.Is(err, domain.ErrVendorBillNotFound), errors.Is(err, domain.ErrOnAccountCreditNotFound):
+		h.response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrBillOnPaymentHold):
+		h.response.ConflictErr(c, err)
+	case errors.Is(err, domain.ErrInvalidPaymentAllocation):
+		h.response.BadRequest(c, err.Error())
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
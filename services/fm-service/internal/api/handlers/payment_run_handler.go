@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentRunHandler struct {
+	svc      *service.PaymentRunService
+	response *utils.ResponseHelper
+}
+
+func NewPaymentRunHandler(svc *service.PaymentRunService, response *utils.ResponseHelper) *PaymentRunHandler {
+	return &PaymentRunHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+// RunScope keeps /payment-runs/:id routes to the caller's legal entity
+func (h *PaymentRunHandler) RunScope() gin.HandlerFunc {
+	return entityScope(h.response, func(ctx context.Context, id string) (string, error) {
+		detail, err := h.svc.GetPaymentRun(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return detail.Run.LegalEntityID, nil
+	})
+}
+
+func (h *PaymentRunHandler) GetPaymentRuns(c *gin.Context) {
+	runs, err := h.svc.ListPaymentRuns(c.Request.Context(), c.GetHeader(legalEntityHeader))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
+
+func (h *PaymentRunHandler) ProposePaymentRun(c *gin.Context) {
+	var req struct {
+		LegalEntityID  string   `json:"legal_entity_id" binding:"required"`
+		DueBy          string   `json:"due_by" binding:"required"`
+		PaymentDate    string   `json:"payment_date"`
+		BankAccountIDs []string `json:"bank_account_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	dueBy, err := time.Parse("2006-01-02", req.DueBy)
+	if err != nil {
+		h.response.BadRequest(c, "invalid due_by date, expected YYYY-MM-DD")
+		return
+	}
+	var paymentDate time.Time
+	if req.PaymentDate != "" {
+		if paymentDate, err = time.Parse("2006-01-02", req.PaymentDate); err != nil {
+			h.response.BadRequest(c, "invalid payment_date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	detail, err := h.svc.ProposePaymentRun(c.Request.Context(), service.PaymentProposalRequest{
+		LegalEntityID:  req.LegalEntityID,
+		DueBy:          dueBy,
+		PaymentDate:    paymentDate,
+		BankAccountIDs: req.BankAccountIDs,
+	}, c.GetHeader("X-Username"))
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": detail})
+}
+
+func (h *PaymentRunHandler) GetPaymentRun(c *gin.Context) {
+	detail, err := h.svc.GetPaymentRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+// ApprovePaymentRun executes the run on behalf of the approving user
+func (h *PaymentRunHandler) ApprovePaymentRun(c *gin.Context) {
+	detail, err := h.svc.ApprovePaymentRun(c.Request.Context(), c.Param("id"), c.GetHeader("X-Username"))
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+func (h *PaymentRunHandler) CancelPaymentRun(c *gin.Context) {
+	run, err := h.svc.CancelPaymentRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
+}
+
+// DownloadPaymentFile serves a generated file as an attachment for upload to the bank
+func (h *PaymentRunHandler) DownloadPaymentFile(c *gin.Context) {
+	file, err := h.svc.GetPaymentFile(c.Request.Context(), c.Param("id"), c.Param("fileId"))
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	contentType := "application/xml"
+	if file.Format == domain.PaymentFileFormatNACHA {
+		contentType = "text/plain"
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+file.FileName+`"`)
+	c.Data(http.StatusOK, contentType, []byte(file.Content))
+}
+
+func (h *PaymentRunHandler) GetVendorBankAccount(c *gin.Context) {
+	account, err := h.svc.GetVendorBankAccount(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": account})
+}
+
+func (h *PaymentRunHandler) SetVendorBankAccount(c *gin.Context) {
+	var req service.VendorBankAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	account, err := h.svc.SetVendorBankAccount(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.paymentRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": account})
+}
+
+func (h *PaymentRunHandler) paymentRunError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPaymentRun), errors.Is(err, domain.ErrInvalidVendorBankAccount), errors.Is(err, domain.ErrInvalidPaymentAllocation):
+		h.response.BadRequest(c, err.Error())
+	case errors.Is(err, domain.ErrPaymentRunNotFound), errors.Is(err, domain.ErrPaymentFileNotFound),
+		errors.Is(err, domain.ErrVendorBankAccountNotFound), errors.Is(err, domain.ErrVendorBillNotFound):
+		h.response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrPaymentRunNotProposed), errors.Is(err, domain.ErrBillOnPaymentHold), errors.Is(err, domain.ErrPeriodClosed):
+		h.response.ConflictErr(c, err)
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PeriodHandler struct {
+	svc      *service.PeriodCloseService
+	response *utils.ResponseHelper
+}
+
+func NewPeriodHandler(svc *service.PeriodCloseService, response *utils.ResponseHelper) *PeriodHandler {
+	return &PeriodHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+type periodRequest struct {
+	LegalEntityID string `json:"legal_entity_id" binding:"required"`
+}
+
+func (h *PeriodHandler) GetPeriods(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	if legalEntityID == "" {
+		h.response.BadRequest(c, "legal_entity_id is required")
+		return
+	}
+	periods, err := h.svc.ListPeriods(c.Request.Context(), legalEntityID)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": periods})
+}
+
+func (h *PeriodHandler) GetCloseChecklist(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	if legalEntityID == "" {
+		h.response.BadRequest(c, "legal_entity_id is required")
+		return
+	}
+	checklist, err := h.svc.GetCloseChecklist(c.Request.Context(), legalEntityID, c.Param("period"))
+	if err != nil {
+		h.periodError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": checklist})
+}
+
+func (h *PeriodHandler) SoftClosePeriod(c *gin.Context) {
+	h.changeState(c, h.svc.SoftClosePeriod)
+}
+
+func (h *PeriodHandler) ClosePeriod(c *gin.Context) {
+	h.changeState(c, h.svc.ClosePeriod)
+}
+
+func (h *PeriodHandler) ReopenPeriod(c *gin.Context) {
+	h.changeState(c, h.svc.ReopenPeriod)
+}
+
+func (h *PeriodHandler) CloseFiscalYear(c *gin.Context) {
+	var req periodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	result, err := h.svc.CloseFiscalYear(c.Request.Context(), req.LegalEntityID, c.Param("id"))
+	if err != nil {
+		h.periodError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *PeriodHandler) changeState(c *gin.Context, change func(ctx context.Context, legalEntityID, period string) (*domain.FiscalPeriod, error)) {
+	var req periodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	period, err := change(c.Request.Context(), req.LegalEntityID, c.Param("period"))
+	if err != nil {
+		h.periodError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": period})
+}
+
+// periodError maps close workflow conflicts to 409; anything else is a bad request.
+func (h *PeriodHandler) periodError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrPeriodCloseBlocked) || errors.Is(err, domain.ErrFiscalYearClosed) ||
+		errors.Is(err, domain.ErrPeriodClosed) {
+		h.response.ConflictErr(c, err)
+		return
+	}
+	h.response.BadRequest(c, err.Error())
+}
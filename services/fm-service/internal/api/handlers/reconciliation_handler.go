@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	svc      *service.CashManagementService
+	response *utils.ResponseHelper
+}
+
+func NewReconciliationHandler(svc *service.CashManagementService, response *utils.ResponseHelper) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+// StatementScope keeps /bank-statements/:id routes to the caller's legal entity
+func (h *ReconciliationHandler) StatementScope() gin.HandlerFunc {
+	return entityScope(h.response, h.svc.StatementLegalEntity)
+}
+
+// ExceptionScope keeps /reconciliation-exceptions/:id routes to the caller's legal entity
+func (h *ReconciliationHandler) ExceptionScope() gin.HandlerFunc {
+	return entityScope(h.response, h.svc.ExceptionLegalEntity)
+}
+
+func (h *ReconciliationHandler) ReconcileBankStatement(c *gin.Context) {
+	id := c.Param("id")
+	result, err := h.svc.ReconcileBankStatement(c.Request.Context(), id)
+	if err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *ReconciliationHandler) GetMatches(c *gin.Context) {
+	id := c.Param("id")
+	matches, err := h.svc.ListReconciliationMatches(c.Request.Context(), id)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": matches})
+}
+
+func (h *ReconciliationHandler) CreateManualMatch(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		StatementLineIDs []string `json:"statement_line_ids" binding:"required"`
+		PaymentIDs       []string `json:"payment_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	matches, err := h.svc.ManualMatch(c.Request.Context(), id, req.StatementLineIDs, req.PaymentIDs)
+	if err != nil {
+		if errors.Is(err, domain.ErrStatementLineAlreadyMatched) || errors.Is(err, domain.ErrPaymentAlreadyMatched) {
+			h.response.ConflictErr(c, err)
+			return
+		}
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": matches})
+}
+
+func (h *ReconciliationHandler) DeleteMatch(c *gin.Context) {
+	id := c.Param("id")
+	groupID := c.Param("groupId")
+	if err := h.svc.UnmatchGroup(c.Request.Context(), id, groupID); err != nil {
+		h.response.NotFound(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "reconciliation match removed successfully"})
+}
+
+func (h *ReconciliationHandler) GetExceptions(c *gin.Context) {
+	excs, err := h.svc.ListReconciliationExceptions(c.Request.Context(), c.GetHeader(legalEntityHeader), c.Query("status"))
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": excs})
+}
+
+func (h *ReconciliationHandler) PostExceptionJournalEntry(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		BankGLAccountID string `json:"bank_gl_account_id"` // Defaults to the bank account's GL account
+		OffsetAccountID string `json:"offset_account_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	exc, err := h.svc.PostExceptionToLedger(c.Request.Context(), id, req.BankGLAccountID, req.OffsetAccountID)
+	if err != nil {
+		if errors.Is(err, domain.ErrReconciliationExceptionClosed) {
+			h.response.ConflictErr(c, err)
+			return
+		}
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": exc})
+}
@@ -2,47 +2,134 @@ package handlers
 
 import (
 	"erp-system/shared/utils"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/gin-gonic/gin"
 )
 
 type ReportHandler struct {
-	svc      *service.GeneralLedgerService
+	svc      *service.FinancialReportService
 	response *utils.ResponseHelper
 }
 
-func NewReportHandler(svc *service.GeneralLedgerService, response *utils.ResponseHelper) *ReportHandler {
+func NewReportHandler(svc *service.FinancialReportService, response *utils.ResponseHelper) *ReportHandler {
 	return &ReportHandler{
 		svc:      svc,
 		response: response,
 	}
 }
 
+func (h *ReportHandler) GetTrialBalance(c *gin.Context) {
+	filter, ok := h.reportFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.svc.GetTrialBalance(c.Request.Context(), filter)
+	if err != nil {
+		h.reportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
 func (h *ReportHandler) GetBalanceSheet(c *gin.Context) {
-	report, err := h.svc.GetBalanceSheet(c.Request.Context())
+	filter, ok := h.reportFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.svc.GetBalanceSheet(c.Request.Context(), filter)
 	if err != nil {
-		h.response.InternalErr(c, err)
+		h.reportError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"report": report})
 }
 
 func (h *ReportHandler) GetIncomeStatement(c *gin.Context) {
-	report, err := h.svc.GetIncomeStatement(c.Request.Context())
+	filter, ok := h.reportFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.svc.GetIncomeStatement(c.Request.Context(), filter)
 	if err != nil {
-		h.response.InternalErr(c, err)
+		h.reportError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"report": report})
 }
 
 func (h *ReportHandler) GetCashFlow(c *gin.Context) {
-	report, err := h.svc.GetCashFlow(c.Request.Context())
+	filter, ok := h.reportFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.svc.GetCashFlow(c.Request.Context(), filter)
 	if err != nil {
-		h.response.InternalErr(c, err)
+		h.reportError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"report": report})
 }
+
+func (h *ReportHandler) GetDrillDown(c *gin.Context) {
+	accountID := c.Query("account_id")
+	if accountID == "" {
+		h.response.BadRequest(c, "account_id is required")
+		return
+	}
+	filter, ok := h.reportFilter(c)
+	if !ok {
+		return
+	}
+	lines, err := h.svc.DrillDown(c.Request.Context(), accountID, filter)
+	if err != nil {
+		h.reportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lines})
+}
+
+// reportFilter reads the shared report query parameters; compare is a comma separated list.
+func (h *ReportHandler) reportFilter(c *gin.Context) (service.ReportFilter, bool) {
+	filter := service.ReportFilter{
+		LegalEntityID: c.Query("legal_entity_id"),
+		FromPeriod:    c.Query("from_period"),
+		ToPeriod:      c.Query("to_period"),
+		CostCenterID:  c.Query("cost_center_id"),
+		SourceModule:  strings.ToUpper(c.Query("source_module")),
+	}
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(param.name)
+		if v == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			h.response.BadRequest(c, "invalid "+param.name+" date, expected YYYY-MM-DD")
+			return filter, false
+		}
+		*param.target = &date
+	}
+	if v := c.Query("compare"); v != "" {
+		for _, comparison := range strings.Split(v, ",") {
+			filter.Compare = append(filter.Compare, service.ReportComparison(strings.ToUpper(strings.TrimSpace(comparison))))
+		}
+	}
+	return filter, true
+}
+
+func (h *ReportHandler) reportError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidReportRequest) {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	h.response.InternalErr(c, err)
+}
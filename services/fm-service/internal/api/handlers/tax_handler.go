@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"erp-system/shared/utils"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type TaxHandler struct {
+	svc      *service.TaxService
+	response *utils.ResponseHelper
+}
+
+func NewTaxHandler(svc *service.TaxService, response *utils.ResponseHelper) *TaxHandler {
+	return &TaxHandler{
+		svc:      svc,
+		response: response,
+	}
+}
+
+func (h *TaxHandler) GetTaxRates(c *gin.Context) {
+	rates, err := h.svc.ListTaxRates(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rates})
+}
+
+func (h *TaxHandler) CreateTaxRate(c *gin.Context) {
+	var req struct {
+		Code                 string     `json:"code" binding:"required"`
+		Name                 string     `json:"name" binding:"required"`
+		Rate                 string     `json:"rate" binding:"required"`
+		JurisdictionCode     string     `json:"jurisdiction_code"`
+		TaxCategory          string     `json:"tax_category"`
+		IsCompound           bool       `json:"is_compound"`
+		Sequence             int        `json:"sequence"`
+		LiabilityAccountCode string     `json:"liability_account_code"`
+		ValidFrom            *time.Time `json:"valid_from"`
+		ValidTo              *time.Time `json:"valid_to"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	rate, err := decimal.NewFromString(req.Rate)
+	if err != nil {
+		h.response.BadRequest(c, "invalid rate")
+		return
+	}
+
+	var taxRate *domain.TaxRate
+	if req.JurisdictionCode == "" {
+		taxRate, err = h.svc.CreateTaxRate(c.Request.Context(), req.Code, req.Name, rate)
+	} else {
+		taxRate, err = h.svc.CreateJurisdictionRate(c.Request.Context(), service.TaxRateRequest{
+			Code:                 req.Code,
+			Name:                 req.Name,
+			Rate:                 rate,
+			JurisdictionCode:     req.JurisdictionCode,
+			TaxCategory:          req.TaxCategory,
+			IsCompound:           req.IsCompound,
+			Sequence:             req.Sequence,
+			LiabilityAccountCode: req.LiabilityAccountCode,
+			ValidFrom:            req.ValidFrom,
+			ValidTo:              req.ValidTo,
+		})
+	}
+	if err != nil {
+		h.taxError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": taxRate})
+}
+
+func (h *TaxHandler) GetJurisdictions(c *gin.Context) {
+	jurisdictions, err := h.svc.ListJurisdictions(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": jurisdictions})
+}
+
+func (h *TaxHandler) CreateJurisdiction(c *gin.Context) {
+	var req struct {
+		Code       string `json:"code" binding:"required"`
+		Name       string `json:"name" binding:"required"`
+		ParentCode string `json:"parent_code"`
+		Sourcing   string `json:"sourcing"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	j, err := h.svc.CreateJurisdiction(c.Request.Context(), req.Code, req.Name, req.ParentCode, domain.TaxSourcing(strings.ToUpper(req.Sourcing)))
+	if err != nil {
+		h.taxError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": j})
+}
+
+func (h *TaxHandler) GetExemptions(c *gin.Context) {
+	customerID := c.Query("customer_id")
+	if customerID == "" {
+		h.response.BadRequest(c, "customer_id is required")
+		return
+	}
+	exemptions, err := h.svc.ListExemptions(c.Request.Context(), customerID)
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": exemptions})
+}
+
+func (h *TaxHandler) CreateExemption(c *gin.Context) {
+	var req struct {
+		CustomerID        string     `json:"customer_id" binding:"required"`
+		JurisdictionCode  string     `json:"jurisdiction_code" binding:"required"`
+		CertificateNumber string     `json:"certificate_number" binding:"required"`
+		ValidFrom         time.Time  `json:"valid_from"`
+		ValidTo           *time.Time `json:"valid_to"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	exemption, err := h.svc.CreateExemption(c.Request.Context(), req.CustomerID, req.JurisdictionCode, req.CertificateNumber, req.ValidFrom, req.ValidTo)
+	if err != nil {
+		h.taxError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": exemption})
+}
+
+func (h *TaxHandler) DetermineTax(c *gin.Context) {
+	var req service.TaxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	req.Direction = domain.TaxDirection(strings.ToUpper(string(req.Direction)))
+
+	det, err := h.svc.DetermineTax(c.Request.Context(), req)
+	if err != nil {
+		h.taxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": det})
+}
+
+func (h *TaxHandler) GetTaxReturn(c *gin.Context) {
+	legalEntityID := c.Query("legal_entity_id")
+	fromPeriod := c.Query("from_period")
+	if legalEntityID == "" || fromPeriod == "" {
+		h.response.BadRequest(c, "legal_entity_id and from_period are required")
+		return
+	}
+
+	ret, err := h.svc.GenerateTaxReturn(c.Request.Context(), legalEntityID, fromPeriod, c.Query("to_period"))
+	if err != nil {
+		h.taxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ret})
+}
+
+func (h *TaxHandler) taxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTaxJurisdictionNotFound):
+		h.response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrInvalidTaxRequest):
+		h.response.BadRequest(c, err.Error())
+	default:
+		h.response.InternalErr(c, err)
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"erp-system/shared/utils"
+	"errors"
 	"net/http"
 	"time"
 
@@ -29,6 +30,16 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 		h.response.InternalErr(c, err)
 		return
 	}
+	// template_id narrows the list to the entries generated from a recurring journal template
+	if templateID := c.Query("template_id"); templateID != "" {
+		filtered := make([]domain.UniversalJournalEntry, 0, len(entries))
+		for _, e := range entries {
+			if e.TemplateID != nil && *e.TemplateID == templateID {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
 	c.JSON(http.StatusOK, gin.H{"data": entries})
 }
 
@@ -72,6 +83,65 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 
 	entry, err := h.svc.CreateJournalEntry(c.Request.Context(), req.LegalEntityID, req.SourceModule, req.SourceDocumentID, req.PostingDate, domainLines)
 	if err != nil {
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			h.response.ConflictErr(c, err)
+			return
+		}
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": entry})
+}
+
+// CreateAccrual posts an entry that reverses itself on the first day of the next period.
+func (h *TransactionHandler) CreateAccrual(c *gin.Context) {
+	var req struct {
+		LegalEntityID    string    `json:"legal_entity_id" binding:"required"`
+		SourceModule     string    `json:"source_module"`
+		SourceDocumentID string    `json:"source_document_id"`
+		PostingDate      time.Time `json:"posting_date" binding:"required"`
+		Lines            []struct {
+			AccountID             string `json:"account_id"`
+			AmountFunctional      string `json:"amount_functional"`
+			AmountTransactional   string `json:"amount_transactional"`
+			CurrencyTransactional string `json:"currency_transactional"`
+		} `json:"lines"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	if req.SourceModule == "" {
+		req.SourceModule = "FM"
+	}
+
+	domainLines := make([]domain.UniversalJournalLine, len(req.Lines))
+	for i, l := range req.Lines {
+		amtFunc, err := decimal.NewFromString(l.AmountFunctional)
+		if err != nil {
+			amtFunc = decimal.Zero
+		}
+		amtTrans, err := decimal.NewFromString(l.AmountTransactional)
+		if err != nil {
+			amtTrans = decimal.Zero
+		}
+
+		domainLines[i] = domain.UniversalJournalLine{
+			AccountID:             l.AccountID,
+			AmountFunctional:      amtFunc,
+			AmountTransactional:   amtTrans,
+			CurrencyTransactional: l.CurrencyTransactional,
+		}
+	}
+
+	entry, err := h.svc.CreateAccrual(c.Request.Context(), req.LegalEntityID, req.SourceModule, req.SourceDocumentID, req.PostingDate, domainLines)
+	if err != nil {
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			h.response.ConflictErr(c, err)
+			return
+		}
 		h.response.BadRequest(c, err.Error())
 		return
 	}
@@ -133,6 +203,10 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 
 	entry, err := h.svc.UpdateJournalEntry(c.Request.Context(), id, req.LegalEntityID, req.SourceModule, req.SourceDocumentID, req.PostingDate, domainLines)
 	if err != nil {
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			h.response.ConflictErr(c, err)
+			return
+		}
 		h.response.BadRequest(c, err.Error())
 		return
 	}
@@ -144,6 +218,10 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 	id := c.Param("id")
 	err := h.svc.DeleteJournalEntry(c.Request.Context(), id)
 	if err != nil {
+		if errors.Is(err, domain.ErrPeriodClosed) {
+			h.response.ConflictErr(c, err)
+			return
+		}
 		h.response.InternalErr(c, err)
 		return
 	}
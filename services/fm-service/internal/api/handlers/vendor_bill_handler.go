@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"erp-system/shared/utils"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -37,9 +39,20 @@ func (h *VendorBillHandler) CreateVendorBill(c *gin.Context) {
 		VendorID        string    `json:"vendor_id"`
 		BillNumber      string    `json:"bill_number"`
 		PurchaseOrderID string    `json:"purchase_order_id"`
+		Currency        string    `json:"currency"`
 		DueDate         time.Time `json:"due_date"`
 		TotalAmount     string    `json:"total_amount"`
 		TaxAmount       string    `json:"tax_amount"`
+
+		// Bills with lines have their input tax determined by the tax engine
+		ShipFrom         string                   `json:"ship_from"`
+		ShipTo           string                   `json:"ship_to"`
+		PricesIncludeTax bool                     `json:"prices_include_tax"`
+		BillDate         time.Time                `json:"bill_date"`
+		Lines            []service.TaxLineRequest `json:"lines"`
+
+		// Billed materials for the three-way match against the purchase order
+		Items []service.VendorBillLineRequest `json:"items"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -47,6 +60,29 @@ func (h *VendorBillHandler) CreateVendorBill(c *gin.Context) {
 		return
 	}
 
+	if len(req.Lines) > 0 {
+		bill, det, err := h.svc.CreateVendorBillWithTax(c.Request.Context(), service.VendorBillRequest{
+			LegalEntityID:    req.LegalEntityID,
+			VendorID:         req.VendorID,
+			BillNumber:       req.BillNumber,
+			PurchaseOrderID:  req.PurchaseOrderID,
+			Currency:         req.Currency,
+			ShipFrom:         req.ShipFrom,
+			ShipTo:           req.ShipTo,
+			PricesIncludeTax: req.PricesIncludeTax,
+			BillDate:         req.BillDate,
+			DueDate:          req.DueDate,
+			Lines:            req.Lines,
+			Items:            req.Items,
+		})
+		if err != nil {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": bill, "tax": det})
+		return
+	}
+
 	totalDec, err := decimal.NewFromString(req.TotalAmount)
 	if err != nil {
 		totalDec = decimal.Zero
@@ -56,18 +92,20 @@ func (h *VendorBillHandler) CreateVendorBill(c *gin.Context) {
 		taxDec = decimal.Zero
 	}
 
-	bill, err := h.svc.CreateVendorBill(
+	bill, err := h.svc.CreateVendorBillWithLines(
 		c.Request.Context(),
 		req.LegalEntityID,
 		req.VendorID,
 		req.BillNumber,
 		req.PurchaseOrderID,
+		req.Currency,
 		req.DueDate,
 		totalDec,
 		taxDec,
+		req.Items,
 	)
 	if err != nil {
-		h.response.InternalErr(c, err)
+		h.matchError(c, err)
 		return
 	}
 
@@ -75,13 +113,117 @@ func (h *VendorBillHandler) CreateVendorBill(c *gin.Context) {
 }
 
 func (h *VendorBillHandler) GetVendorBillLines(c *gin.Context) {
-	id := c.Param("id")
-	_, err := h.svc.GetVendorBill(c.Request.Context(), id)
+	lines, err := h.svc.ListVendorBillLines(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.matchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lines})
+}
+
+func (h *VendorBillHandler) SetVendorBillLines(c *gin.Context) {
+	var req struct {
+		Items []service.VendorBillLineRequest `json:"items" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	match, err := h.svc.SetVendorBillLines(c.Request.Context(), c.Param("id"), req.Items)
 	if err != nil {
-		h.response.NotFound(c, "vendor bill not found")
+		h.matchError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"data": []string{},
-	})
+	c.JSON(http.StatusOK, gin.H{"data": match})
+}
+
+func (h *VendorBillHandler) MatchVendorBill(c *gin.Context) {
+	match, err := h.svc.MatchVendorBill(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.matchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": match})
+}
+
+func (h *VendorBillHandler) GetMatchExceptions(c *gin.Context) {
+	queue, err := h.svc.ListMatchExceptions(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": queue})
+}
+
+func (h *VendorBillHandler) OverrideMatch(c *gin.Context) {
+	var req struct {
+		Reason       string `json:"reason" binding:"required"`
+		OverriddenBy string `json:"overridden_by"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+
+	bill, err := h.svc.OverrideMatch(c.Request.Context(), c.Param("id"), req.Reason, req.OverriddenBy)
+	if err != nil {
+		h.matchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": bill})
+}
+
+func (h *VendorBillHandler) GetMatchTolerances(c *gin.Context) {
+	tolerances, err := h.svc.ListMatchTolerances(c.Request.Context())
+	if err != nil {
+		h.response.InternalErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tolerances})
+}
+
+func (h *VendorBillHandler) SetMatchTolerance(c *gin.Context) {
+	var req struct {
+		VendorID                 string `json:"vendor_id"`
+		PriceTolerancePercent    string `json:"price_tolerance_percent"`
+		QuantityTolerancePercent string `json:"quantity_tolerance_percent"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.response.BadRequest(c, err.Error())
+		return
+	}
+	price, err := decimal.NewFromString(req.PriceTolerancePercent)
+	if err != nil {
+		h.response.BadRequest(c, "invalid price_tolerance_percent")
+		return
+	}
+	quantity, err := decimal.NewFromString(req.QuantityTolerancePercent)
+	if err != nil {
+		h.response.BadRequest(c, "invalid quantity_tolerance_percent")
+		return
+	}
+
+	tolerance, err := h.svc.SetMatchTolerance(c.Request.Context(), req.VendorID, price, quantity)
+	if err != nil {
+		h.matchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tolerance})
+}
+
+func (h *VendorBillHandler) matchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrVendorBillNotFound):
+		h.response.NotFound(c, err.Error())
+	case errors.Is(err, domain.ErrBillNotInReview):
+		h.response.ConflictErr(c, err)
+	case errors.Is(err, domain.ErrInvalidMatchRequest):
+		h.response.BadRequest(c, err.Error())
+	default:
+		h.response.InternalErr(c, err)
+	}
 }
@@ -17,6 +17,17 @@ func SetupRoutes(
 	billHandler *handlers.VendorBillHandler,
 	leHandler *handlers.LegalEntityHandler,
 	assetHandler *handlers.AssetHandler,
+	reconHandler *handlers.ReconciliationHandler,
+	fxHandler *handlers.FxRevaluationHandler,
+	periodHandler *handlers.PeriodHandler,
+	icHandler *handlers.IntercompanyHandler,
+	consolidationHandler *handlers.ConsolidationHandler,
+	budgetHandler *handlers.BudgetHandler,
+	taxHandler *handlers.TaxHandler,
+	dunningHandler *handlers.DunningHandler,
+	paymentRunHandler *handlers.PaymentRunHandler,
+	journalTemplateHandler *handlers.JournalTemplateHandler,
+	allocationHandler *handlers.CostAllocationHandler,
 ) {
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -40,27 +51,72 @@ func SetupRoutes(
 			accounts.GET("/:id/balance", accHandler.GetAccountBalance)
 		}
 
+		// Accounts posted per posting key
+		accountDeterminations := v1.Group("/account-determinations")
+		{
+			accountDeterminations.GET("", accHandler.GetAccountDeterminations)
+			accountDeterminations.PUT("/:key", accHandler.SetAccountDetermination)
+		}
+
 		// Journal Entries routes
 		journalEntries := v1.Group("/journal-entries")
 		{
 			journalEntries.GET("", txHandler.GetTransactions)
 			journalEntries.POST("", txHandler.CreateTransaction)
+			journalEntries.POST("/accruals", txHandler.CreateAccrual)
 			journalEntries.GET("/:id", txHandler.GetTransaction)
 			journalEntries.PUT("/:id", txHandler.UpdateTransaction)
 			journalEntries.DELETE("/:id", txHandler.DeleteTransaction)
 		}
 
+		// Recurring journal templates
+		journalTemplates := v1.Group("/journal-templates")
+		{
+			journalTemplates.GET("", journalTemplateHandler.GetJournalTemplates)
+			journalTemplates.POST("", journalTemplateHandler.CreateJournalTemplate)
+			journalTemplates.POST("/run", journalTemplateHandler.RunJournalTemplates)
+			journalTemplates.GET("/:id", journalTemplateHandler.GetJournalTemplate)
+			journalTemplates.PUT("/:id", journalTemplateHandler.UpdateJournalTemplate)
+			journalTemplates.POST("/:id/generate", journalTemplateHandler.GenerateJournalEntries)
+		}
+
+		// Cost centers and overhead allocation
+		v1.GET("/cost-centers", allocationHandler.GetCostCenters)
+		v1.POST("/cost-centers", allocationHandler.CreateCostCenter)
+		v1.GET("/allocation-drivers", allocationHandler.GetKeyFigures)
+		v1.PUT("/allocation-drivers", allocationHandler.RecordKeyFigures)
+		allocationCycles := v1.Group("/allocation-cycles")
+		{
+			allocationCycles.GET("", allocationHandler.GetAllocationCycles)
+			allocationCycles.POST("", allocationHandler.CreateAllocationCycle)
+			allocationCycles.GET("/:id", allocationHandler.GetAllocationCycle)
+			allocationCycles.PUT("/:id", allocationHandler.UpdateAllocationCycle)
+			allocationCycles.POST("/:id/run", allocationHandler.RunAllocationCycle)
+		}
+		v1.POST("/allocation-runs/:id/reverse", allocationHandler.ReverseAllocationRun)
+
 		// Invoices routes
 		invoices := v1.Group("/invoices")
 		{
 			invoices.GET("", invHandler.GetInvoices)
 			invoices.POST("", invHandler.CreateInvoice)
 			v1.GET("/customers/:id/credit", invHandler.GetCustomerCredit)
+			v1.PUT("/customers/:id/credit", invHandler.SetCreditLimit)
+			v1.GET("/customers/:id/credit/exposure", invHandler.GetCreditExposure)
+			v1.POST("/customers/:id/credit/check", invHandler.CheckCredit)
+			v1.POST("/customers/:id/credit/hold", invHandler.HoldCustomerCredit)
+			v1.POST("/customers/:id/credit/release", invHandler.ReleaseCustomerCredit)
+			v1.GET("/customers/:id/credit/overrides", invHandler.GetCreditOverrides)
+			v1.POST("/customers/:id/credit/overrides", invHandler.CreateCreditOverride)
 			invoices.GET("/:id", invHandler.GetInvoice)
 			invoices.PUT("/:id", invHandler.UpdateInvoice)
 			invoices.DELETE("/:id", invHandler.DeleteInvoice)
 			invoices.POST("/:id/send", invHandler.SendInvoice)
 			invoices.GET("/:id/lines", invHandler.GetInvoiceLines)
+			invoices.GET("/:id/allocations", payHandler.GetInvoiceAllocations)
+			invoices.GET("/:id/credit-memos", payHandler.GetCreditMemos)
+			invoices.POST("/:id/credit-memos", payHandler.CreateCreditMemo)
+			invoices.GET("/:id/dunning-notices", dunningHandler.GetInvoiceNotices)
 		}
 
 		// Payments routes
@@ -69,12 +125,41 @@ func SetupRoutes(
 			payments.GET("", payHandler.GetPayments)
 			payments.POST("", payHandler.RecordPayment)
 			payments.GET("/:id", payHandler.GetPayment)
+			payments.GET("/:id/allocations", payHandler.GetPaymentAllocations)
+		}
+
+		// On-account credits routes
+		onAccountCredits := v1.Group("/on-account-credits")
+		{
+			onAccountCredits.GET("", payHandler.GetOnAccountCredits)
+			onAccountCredits.POST("/:id/apply", payHandler.ApplyOnAccountCredit)
 		}
 
 		// Bank Statements routes
-		bankStatements := v1.Group("/bank-statements")
+		bankStatements := v1.Group("/bank-statements", reconHandler.StatementScope())
 		{
+			bankStatements.POST("/import", payHandler.ImportBankStatement)
 			bankStatements.GET("/:id/lines", payHandler.GetBankStatementLines)
+			bankStatements.POST("/:id/reconcile", reconHandler.ReconcileBankStatement)
+			bankStatements.GET("/:id/matches", reconHandler.GetMatches)
+			bankStatements.POST("/:id/matches", reconHandler.CreateManualMatch)
+			bankStatements.DELETE("/:id/matches/:groupId", reconHandler.DeleteMatch)
+		}
+
+		// Recurring cash items routes
+		recurringCashItems := v1.Group("/recurring-cash-items")
+		{
+			recurringCashItems.GET("", payHandler.GetRecurringCashItems)
+			recurringCashItems.POST("", payHandler.CreateRecurringCashItem)
+			recurringCashItems.PUT("/:id", payHandler.UpdateRecurringCashItem)
+			recurringCashItems.DELETE("/:id", payHandler.DeleteRecurringCashItem)
+		}
+
+		// Reconciliation exceptions routes
+		reconExceptions := v1.Group("/reconciliation-exceptions", reconHandler.ExceptionScope())
+		{
+			reconExceptions.GET("", reconHandler.GetExceptions)
+			reconExceptions.POST("/:id/journal-entry", reconHandler.PostExceptionJournalEntry)
 		}
 
 		// Vendor Bills routes
@@ -82,15 +167,40 @@ func SetupRoutes(
 		{
 			vendorBills.GET("", billHandler.GetVendorBills)
 			vendorBills.POST("", billHandler.CreateVendorBill)
+			vendorBills.GET("/match-exceptions", billHandler.GetMatchExceptions)
+			vendorBills.GET("/match-tolerances", billHandler.GetMatchTolerances)
+			vendorBills.PUT("/match-tolerances", billHandler.SetMatchTolerance)
 			vendorBills.GET("/:id/lines", billHandler.GetVendorBillLines)
+			vendorBills.PUT("/:id/lines", billHandler.SetVendorBillLines)
+			vendorBills.POST("/:id/match", billHandler.MatchVendorBill)
+			vendorBills.POST("/:id/match-override", billHandler.OverrideMatch)
+			vendorBills.GET("/:id/allocations", payHandler.GetVendorBillAllocations)
+		}
+		v1.GET("/vendors/:id/bank-account", paymentRunHandler.GetVendorBankAccount)
+		v1.PUT("/vendors/:id/bank-account", paymentRunHandler.SetVendorBankAccount)
+
+		// Payment run routes
+		paymentRuns := v1.Group("/payment-runs", paymentRunHandler.RunScope())
+		{
+			paymentRuns.GET("", paymentRunHandler.GetPaymentRuns)
+			paymentRuns.POST("", paymentRunHandler.ProposePaymentRun)
+			paymentRuns.GET("/:id", paymentRunHandler.GetPaymentRun)
+			paymentRuns.POST("/:id/approve", paymentRunHandler.ApprovePaymentRun)
+			paymentRuns.POST("/:id/cancel", paymentRunHandler.CancelPaymentRun)
+			paymentRuns.GET("/:id/files/:fileId", paymentRunHandler.DownloadPaymentFile)
 		}
 
 		// Reports routes
 		reports := v1.Group("/reports")
 		{
+			reports.GET("/trial-balance", repHandler.GetTrialBalance)
 			reports.GET("/balance-sheet", repHandler.GetBalanceSheet)
 			reports.GET("/income-statement", repHandler.GetIncomeStatement)
 			reports.GET("/cash-flow", repHandler.GetCashFlow)
+			reports.GET("/drill-down", repHandler.GetDrillDown)
+			reports.GET("/cash-flow-forecast", payHandler.GetCashFlowForecast)
+			reports.GET("/ar-aging", payHandler.GetReceivablesAging)
+			reports.GET("/ap-aging", payHandler.GetPayablesAging)
 		}
 
 		// Legal Entity routes
@@ -102,13 +212,89 @@ func SetupRoutes(
 		}
 
 		// Asset routes
-		assets := v1.Group("/assets")
+		assets := v1.Group("/assets", assetHandler.AssetScope())
 		{
 			assets.GET("", assetHandler.GetAssets)
 			assets.POST("/capitalize", assetHandler.CapitalizeAsset)
 			assets.GET("/:id", assetHandler.GetAsset)
 			assets.POST("/:id/depreciation-schedule", assetHandler.GenerateDepreciationSchedule)
 			assets.POST("/depreciate", assetHandler.PostMonthlyDepreciation)
+			assets.POST("/:id/dispose", assetHandler.DisposeAsset)
+			assets.POST("/:id/usage", assetHandler.RecordUsage)
+			assets.GET("/:id/usage", assetHandler.GetUsage)
+		}
+
+		// FX revaluation routes
+		fxRevaluations := v1.Group("/fx-revaluations")
+		{
+			fxRevaluations.GET("", fxHandler.GetRevaluations)
+			fxRevaluations.POST("", fxHandler.RunRevaluation)
+		}
+
+		// Period close routes
+		fiscalPeriods := v1.Group("/fiscal-periods")
+		{
+			fiscalPeriods.GET("", periodHandler.GetPeriods)
+			fiscalPeriods.GET("/:period/checklist", periodHandler.GetCloseChecklist)
+			fiscalPeriods.POST("/:period/soft-close", periodHandler.SoftClosePeriod)
+			fiscalPeriods.POST("/:period/close", periodHandler.ClosePeriod)
+			fiscalPeriods.POST("/:period/reopen", periodHandler.ReopenPeriod)
+		}
+		v1.POST("/fiscal-years/:id/close", periodHandler.CloseFiscalYear)
+
+		// Intercompany and consolidation routes
+		intercompany := v1.Group("/intercompany-transactions")
+		{
+			intercompany.GET("", icHandler.GetTransactions)
+			intercompany.POST("", icHandler.CreateTransaction)
+			intercompany.GET("/:id", icHandler.GetTransaction)
+		}
+		consolidationGroups := v1.Group("/consolidation-groups")
+		{
+			consolidationGroups.GET("", consolidationHandler.GetGroups)
+			consolidationGroups.POST("", consolidationHandler.CreateGroup)
+			consolidationGroups.GET("/:id", consolidationHandler.GetGroup)
+			consolidationGroups.GET("/:id/statements", consolidationHandler.GetConsolidatedStatements)
+		}
+
+		// Budget routes
+		budgets := v1.Group("/budgets")
+		{
+			budgets.GET("", budgetHandler.GetBudgets)
+			budgets.POST("", budgetHandler.CreateBudget)
+			budgets.PUT("/:id/versions/:version", budgetHandler.SetBudgetVersion)
+			budgets.GET("/vs-actual", budgetHandler.GetBudgetVsActual)
+			budgets.POST("/check", budgetHandler.CheckBudget)
+			budgets.POST("/expenses", budgetHandler.TrackExpense)
+			budgets.GET("/commitments", budgetHandler.GetCommitments)
+			budgets.POST("/commitments/:id/approve", budgetHandler.ApproveCommitment)
+			budgets.POST("/commitments/:id/reject", budgetHandler.RejectCommitment)
+			budgets.GET("/policies", budgetHandler.GetPolicies)
+			budgets.PUT("/policies/:cost_center_id", budgetHandler.SetPolicy)
+		}
+
+		// Tax routes
+		tax := v1.Group("/tax")
+		{
+			tax.GET("/rates", taxHandler.GetTaxRates)
+			tax.POST("/rates", taxHandler.CreateTaxRate)
+			tax.GET("/jurisdictions", taxHandler.GetJurisdictions)
+			tax.POST("/jurisdictions", taxHandler.CreateJurisdiction)
+			tax.GET("/exemptions", taxHandler.GetExemptions)
+			tax.POST("/exemptions", taxHandler.CreateExemption)
+			tax.POST("/determine", taxHandler.DetermineTax)
+			tax.GET("/returns", taxHandler.GetTaxReturn)
+		}
+
+		// Dunning routes
+		dunning := v1.Group("/dunning")
+		{
+			dunning.GET("/levels", dunningHandler.GetDunningLevels)
+			dunning.PUT("/levels", dunningHandler.SetDunningLevel)
+			dunning.DELETE("/levels/:level", dunningHandler.DeleteDunningLevel)
+			dunning.GET("/runs", dunningHandler.GetDunningRuns)
+			dunning.POST("/runs", dunningHandler.RunDunning)
+			dunning.GET("/runs/:id", dunningHandler.GetDunningRun)
 		}
 	}
 }
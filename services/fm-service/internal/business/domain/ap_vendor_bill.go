@@ -14,8 +14,16 @@ type ApVendorBill struct {
 	PurchaseOrderID string          `json:"purchase_order_id"` // Loose primitive document token (SCM Boundary)
 	TotalAmount     decimal.Decimal `json:"total_amount"`
 	TaxAmount       decimal.Decimal `json:"tax_amount"`
+	AmountPaid      decimal.Decimal `json:"amount_paid"`   // Payments, credit memos and on-account credits applied
+	Currency        string          `json:"currency"`      // ISO 4217 document currency; empty means functional
+	ExchangeRate    decimal.Decimal `json:"exchange_rate"` // Document -> functional rate at booking
 	DueDate         time.Time       `json:"due_date"`
 	Status          PaymentStatus   `json:"status"`
+	MatchStatus     BillMatchStatus `json:"match_status"`
+	PaymentHold     bool            `json:"payment_hold"`              // Held from payment until matched or overridden
+	OverrideReason  *string         `json:"override_reason,omitempty"` // Why an AP clerk released a mismatched bill
+	OverriddenBy    *string         `json:"overridden_by,omitempty"`
+	MatchedAt       *time.Time      `json:"matched_at,omitempty"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
 }
@@ -14,8 +14,14 @@ type ArInvoice struct {
 	SalesOrderID  string          `json:"sales_order_id"` // Loose primitive document token (CRM Boundary)
 	TotalAmount   decimal.Decimal `json:"total_amount"`
 	TaxAmount     decimal.Decimal `json:"tax_amount"`
+	AmountPaid    decimal.Decimal `json:"amount_paid"`   // Payments, credit memos and on-account credits applied
+	FeesCharged   decimal.Decimal `json:"fees_charged"`  // Dunning fees and late interest added to the amount due
+	Currency      string          `json:"currency"`      // ISO 4217 document currency; empty means functional
+	ExchangeRate  decimal.Decimal `json:"exchange_rate"` // Document -> functional rate at booking
 	DueDate       time.Time       `json:"due_date"`
 	Status        PaymentStatus   `json:"status"`
+	DunningLevel  int             `json:"dunning_level"` // Highest dunning level issued; 0 when never dunned
+	LastDunnedAt  *time.Time      `json:"last_dunned_at,omitempty"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 }
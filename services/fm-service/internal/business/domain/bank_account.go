@@ -9,10 +9,13 @@ import (
 type BankAccount struct {
 	ID            string          `json:"id"`
 	LegalEntityID string          `json:"legal_entity_id"`
-	AccountNumber string          `json:"account_number"`
-	Currency      string          `json:"currency"` // Local currency of the physical bank branch
+	AccountNumber string          `json:"account_number"` // IBAN for SEPA accounts
+	Bic           string          `json:"bic"`
+	RoutingNumber string          `json:"routing_number"` // ABA routing number of US accounts
+	Currency      string          `json:"currency"`       // Local currency of the physical bank branch
 	LiquidBalance decimal.Decimal `json:"liquid_balance"`
-	Version       int             `json:"version"` // ADDED: Protects against double-spend
+	GlAccountID   *string         `json:"gl_account_id,omitempty"` // Ledger account carrying the balance in the functional currency
+	Version       int             `json:"version"`                 // ADDED: Protects against double-spend
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 }
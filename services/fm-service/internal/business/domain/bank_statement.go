@@ -7,9 +7,12 @@ import (
 )
 
 type BankStatement struct {
-	ID            string          `json:"id"`
-	BankAccountID string          `json:"bank_account_id"`
-	StatementDate time.Time       `json:"statement_date"`
-	EndingBalance decimal.Decimal `json:"ending_balance"`
-	IsReconciled  bool            `json:"is_reconciled"`
+	ID                 string          `json:"id"`
+	BankAccountID      string          `json:"bank_account_id"`
+	StatementReference string          `json:"statement_reference"` // Bank-issued statement ID; guards against duplicate imports
+	SourceFormat       string          `json:"source_format"`       // CAMT053, MT940, BAI2 or empty when captured manually
+	StatementDate      time.Time       `json:"statement_date"`
+	OpeningBalance     decimal.Decimal `json:"opening_balance"`
+	EndingBalance      decimal.Decimal `json:"ending_balance"`
+	IsReconciled       bool            `json:"is_reconciled"`
 }
@@ -11,6 +11,7 @@ type BankStatementLine struct {
 	StatementID     string          `json:"statement_id"`
 	TransactionDate time.Time       `json:"transaction_date"`
 	Description     string          `json:"description"`
+	Reference       string          `json:"reference"` // Bank or end-to-end reference from the statement file
 	Amount          decimal.Decimal `json:"amount"`
 	IsMatched       bool            `json:"is_matched"`
 }
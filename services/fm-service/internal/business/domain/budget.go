@@ -2,17 +2,17 @@
 package domain
 
 import (
-	"time"
-
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 type Budget struct {
 	ID              string          `json:"id"`
 	AccountID       string          `json:"account_id"`
-	CostCenterID    *string         `json:"cost_center_id"`
+	CostCenterID    *string         `json:"cost_center_id,omitempty"`
 	FiscalYear      int             `json:"fiscal_year"`
-	Period          int             `json:"period"` // Month 1-12
+	Period          int             `json:"period"`  // Month 1-12
+	Version         BudgetVersion   `json:"version"` // ORIGINAL carries spend; REVISED supersedes its allocation
 	AllocatedAmount decimal.Decimal `json:"allocated_amount"`
 	SpentAmount     decimal.Decimal `json:"spent_amount"`
 	CreatedAt       time.Time       `json:"created_at"`
@@ -7,15 +7,23 @@ import (
 )
 
 type CapitalAsset struct {
-	ID                      string          `json:"id"`
-	LegalEntityID           string          `json:"legal_entity_id"`
-	AssetTag                string          `json:"asset_tag"`
-	EamEquipmentID          *string         `json:"eam_equipment_id,omitempty"` // Loose primitive identity token (EAM Boundary)
-	AcquisitionCost         decimal.Decimal `json:"acquisition_cost"`
-	AccumulatedDepreciation decimal.Decimal `json:"accumulated_depreciation"`
-	UsefulLifeMonths        int             `json:"useful_life_months"`
-	CapitalizationDate      time.Time       `json:"capitalization_date"`
-	Status                  AssetState      `json:"status"`
-	CreatedAt               time.Time       `json:"created_at"`
-	UpdatedAt               time.Time       `json:"updated_at"`
+	ID                      string                 `json:"id"`
+	LegalEntityID           string                 `json:"legal_entity_id"`
+	AssetTag                string                 `json:"asset_tag"`
+	EamEquipmentID          *string                `json:"eam_equipment_id,omitempty"` // Loose primitive identity token (EAM Boundary)
+	AcquisitionCost         decimal.Decimal        `json:"acquisition_cost"`
+	AccumulatedDepreciation decimal.Decimal        `json:"accumulated_depreciation"`
+	UsefulLifeMonths        int                    `json:"useful_life_months"`
+	CapitalizationDate      time.Time              `json:"capitalization_date"`
+	Status                  AssetState             `json:"status"`
+	DepreciationMethod      DepreciationMethod     `json:"depreciation_method"`
+	DepreciationConvention  DepreciationConvention `json:"depreciation_convention"` // How the acquisition month is depreciated
+	SalvageValue            decimal.Decimal        `json:"salvage_value"`
+	DecliningBalanceRate    decimal.Decimal        `json:"declining_balance_rate"` // Multiple of the straight-line rate, e.g. 2 for double-declining
+	EstimatedTotalUnits     decimal.Decimal        `json:"estimated_total_units"`  // Lifetime output driving units-of-production depreciation
+	DisposalDate            *time.Time             `json:"disposal_date,omitempty"`
+	DisposalProceeds        decimal.Decimal        `json:"disposal_proceeds"`
+	DisposalEntryID         *string                `json:"disposal_entry_id,omitempty"`
+	CreatedAt               time.Time              `json:"created_at"`
+	UpdatedAt               time.Time              `json:"updated_at"`
 }
@@ -1,11 +1,13 @@
 // Code generated by CDD Engine. DO NOT EDIT.
 package domain
 
+import ()
+
 type CostCenter struct {
 	ID          string  `json:"id"`
 	Code        string  `json:"code"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
-	ManagerID   *string `json:"manager_id"`
+	ManagerID   *string `json:"manager_id,omitempty"`
 	IsActive    bool    `json:"is_active"`
 }
@@ -57,6 +57,7 @@ type PaymentStatus string
 const (
 	PaymentStatusOPEN    PaymentStatus = "OPEN"
 	PaymentStatusPARTIAL PaymentStatus = "PARTIAL"
+	PaymentStatusOVERDUE PaymentStatus = "OVERDUE"
 	PaymentStatusPAID    PaymentStatus = "PAID"
 )
 
@@ -67,6 +68,8 @@ func (e PaymentStatus) IsValid() bool {
 		return true
 	case PaymentStatusPARTIAL:
 		return true
+	case PaymentStatusOVERDUE:
+		return true
 	case PaymentStatusPAID:
 		return true
 	}
@@ -135,3 +138,568 @@ func (e EventProcessingStatus) IsValid() bool {
 	}
 	return false
 }
+
+// ReconciliationMatchType represents the ReconciliationMatchType enum
+type ReconciliationMatchType string
+
+const (
+	ReconciliationMatchTypeONE_TO_ONE  ReconciliationMatchType = "ONE_TO_ONE"
+	ReconciliationMatchTypeONE_TO_MANY ReconciliationMatchType = "ONE_TO_MANY"
+	ReconciliationMatchTypeMANY_TO_ONE ReconciliationMatchType = "MANY_TO_ONE"
+	ReconciliationMatchTypeMANUAL      ReconciliationMatchType = "MANUAL"
+)
+
+// IsValid returns true if the ReconciliationMatchType is valid
+func (e ReconciliationMatchType) IsValid() bool {
+	switch e {
+	case ReconciliationMatchTypeONE_TO_ONE:
+		return true
+	case ReconciliationMatchTypeONE_TO_MANY:
+		return true
+	case ReconciliationMatchTypeMANY_TO_ONE:
+		return true
+	case ReconciliationMatchTypeMANUAL:
+		return true
+	}
+	return false
+}
+
+// ReconciliationExceptionStatus represents the ReconciliationExceptionStatus enum
+type ReconciliationExceptionStatus string
+
+const (
+	ReconciliationExceptionStatusOPEN     ReconciliationExceptionStatus = "OPEN"
+	ReconciliationExceptionStatusRESOLVED ReconciliationExceptionStatus = "RESOLVED"
+)
+
+// IsValid returns true if the ReconciliationExceptionStatus is valid
+func (e ReconciliationExceptionStatus) IsValid() bool {
+	switch e {
+	case ReconciliationExceptionStatusOPEN:
+		return true
+	case ReconciliationExceptionStatusRESOLVED:
+		return true
+	}
+	return false
+}
+
+// RecurrenceFrequency represents the RecurrenceFrequency enum
+type RecurrenceFrequency string
+
+const (
+	RecurrenceFrequencyWEEKLY    RecurrenceFrequency = "WEEKLY"
+	RecurrenceFrequencyMONTHLY   RecurrenceFrequency = "MONTHLY"
+	RecurrenceFrequencyQUARTERLY RecurrenceFrequency = "QUARTERLY"
+	RecurrenceFrequencyYEARLY    RecurrenceFrequency = "YEARLY"
+)
+
+// IsValid returns true if the RecurrenceFrequency is valid
+func (e RecurrenceFrequency) IsValid() bool {
+	switch e {
+	case RecurrenceFrequencyWEEKLY:
+		return true
+	case RecurrenceFrequencyMONTHLY:
+		return true
+	case RecurrenceFrequencyQUARTERLY:
+		return true
+	case RecurrenceFrequencyYEARLY:
+		return true
+	}
+	return false
+}
+
+// FxRevaluationSource represents the FxRevaluationSource enum
+type FxRevaluationSource string
+
+const (
+	FxRevaluationSourceAR_INVOICE   FxRevaluationSource = "AR_INVOICE"
+	FxRevaluationSourceAP_BILL      FxRevaluationSource = "AP_BILL"
+	FxRevaluationSourceBANK_ACCOUNT FxRevaluationSource = "BANK_ACCOUNT"
+)
+
+// IsValid returns true if the FxRevaluationSource is valid
+func (e FxRevaluationSource) IsValid() bool {
+	switch e {
+	case FxRevaluationSourceAR_INVOICE:
+		return true
+	case FxRevaluationSourceAP_BILL:
+		return true
+	case FxRevaluationSourceBANK_ACCOUNT:
+		return true
+	}
+	return false
+}
+
+// PeriodState represents the PeriodState enum
+type PeriodState string
+
+const (
+	PeriodStateOPEN        PeriodState = "OPEN"
+	PeriodStateSOFT_CLOSED PeriodState = "SOFT_CLOSED"
+	PeriodStateCLOSED      PeriodState = "CLOSED"
+)
+
+// IsValid returns true if the PeriodState is valid
+func (e PeriodState) IsValid() bool {
+	switch e {
+	case PeriodStateOPEN:
+		return true
+	case PeriodStateSOFT_CLOSED:
+		return true
+	case PeriodStateCLOSED:
+		return true
+	}
+	return false
+}
+
+// DepreciationMethod represents the DepreciationMethod enum
+type DepreciationMethod string
+
+const (
+	DepreciationMethodSTRAIGHT_LINE       DepreciationMethod = "STRAIGHT_LINE"
+	DepreciationMethodDECLINING_BALANCE   DepreciationMethod = "DECLINING_BALANCE"
+	DepreciationMethodSUM_OF_YEARS_DIGITS DepreciationMethod = "SUM_OF_YEARS_DIGITS"
+	DepreciationMethodUNITS_OF_PRODUCTION DepreciationMethod = "UNITS_OF_PRODUCTION"
+)
+
+// IsValid returns true if the DepreciationMethod is valid
+func (e DepreciationMethod) IsValid() bool {
+	switch e {
+	case DepreciationMethodSTRAIGHT_LINE:
+		return true
+	case DepreciationMethodDECLINING_BALANCE:
+		return true
+	case DepreciationMethodSUM_OF_YEARS_DIGITS:
+		return true
+	case DepreciationMethodUNITS_OF_PRODUCTION:
+		return true
+	}
+	return false
+}
+
+// DepreciationConvention represents the DepreciationConvention enum
+type DepreciationConvention string
+
+const (
+	DepreciationConventionFULL_MONTH DepreciationConvention = "FULL_MONTH"
+	DepreciationConventionHALF_MONTH DepreciationConvention = "HALF_MONTH"
+	DepreciationConventionNEXT_MONTH DepreciationConvention = "NEXT_MONTH"
+)
+
+// IsValid returns true if the DepreciationConvention is valid
+func (e DepreciationConvention) IsValid() bool {
+	switch e {
+	case DepreciationConventionFULL_MONTH:
+		return true
+	case DepreciationConventionHALF_MONTH:
+		return true
+	case DepreciationConventionNEXT_MONTH:
+		return true
+	}
+	return false
+}
+
+// BudgetVersion represents the BudgetVersion enum
+type BudgetVersion string
+
+const (
+	BudgetVersionORIGINAL BudgetVersion = "ORIGINAL"
+	BudgetVersionREVISED  BudgetVersion = "REVISED"
+	BudgetVersionFORECAST BudgetVersion = "FORECAST"
+)
+
+// IsValid returns true if the BudgetVersion is valid
+func (e BudgetVersion) IsValid() bool {
+	switch e {
+	case BudgetVersionORIGINAL:
+		return true
+	case BudgetVersionREVISED:
+		return true
+	case BudgetVersionFORECAST:
+		return true
+	}
+	return false
+}
+
+// BudgetEnforcement represents the BudgetEnforcement enum
+type BudgetEnforcement string
+
+const (
+	BudgetEnforcementWARN             BudgetEnforcement = "WARN"
+	BudgetEnforcementBLOCK            BudgetEnforcement = "BLOCK"
+	BudgetEnforcementREQUIRE_APPROVAL BudgetEnforcement = "REQUIRE_APPROVAL"
+)
+
+// IsValid returns true if the BudgetEnforcement is valid
+func (e BudgetEnforcement) IsValid() bool {
+	switch e {
+	case BudgetEnforcementWARN:
+		return true
+	case BudgetEnforcementBLOCK:
+		return true
+	case BudgetEnforcementREQUIRE_APPROVAL:
+		return true
+	}
+	return false
+}
+
+// BudgetCommitmentSource represents the BudgetCommitmentSource enum
+type BudgetCommitmentSource string
+
+const (
+	BudgetCommitmentSourcePURCHASE_REQUISITION BudgetCommitmentSource = "PURCHASE_REQUISITION"
+	BudgetCommitmentSourcePURCHASE_ORDER       BudgetCommitmentSource = "PURCHASE_ORDER"
+)
+
+// IsValid returns true if the BudgetCommitmentSource is valid
+func (e BudgetCommitmentSource) IsValid() bool {
+	switch e {
+	case BudgetCommitmentSourcePURCHASE_REQUISITION:
+		return true
+	case BudgetCommitmentSourcePURCHASE_ORDER:
+		return true
+	}
+	return false
+}
+
+// BudgetCommitmentStatus represents the BudgetCommitmentStatus enum
+type BudgetCommitmentStatus string
+
+const (
+	BudgetCommitmentStatusPENDING_APPROVAL BudgetCommitmentStatus = "PENDING_APPROVAL"
+	BudgetCommitmentStatusOPEN             BudgetCommitmentStatus = "OPEN"
+	BudgetCommitmentStatusCONSUMED         BudgetCommitmentStatus = "CONSUMED"
+	BudgetCommitmentStatusRELEASED         BudgetCommitmentStatus = "RELEASED"
+	BudgetCommitmentStatusREJECTED         BudgetCommitmentStatus = "REJECTED"
+)
+
+// IsValid returns true if the BudgetCommitmentStatus is valid
+func (e BudgetCommitmentStatus) IsValid() bool {
+	switch e {
+	case BudgetCommitmentStatusPENDING_APPROVAL:
+		return true
+	case BudgetCommitmentStatusOPEN:
+		return true
+	case BudgetCommitmentStatusCONSUMED:
+		return true
+	case BudgetCommitmentStatusRELEASED:
+		return true
+	case BudgetCommitmentStatusREJECTED:
+		return true
+	}
+	return false
+}
+
+// TaxSourcing represents the TaxSourcing enum
+type TaxSourcing string
+
+const (
+	TaxSourcingDESTINATION TaxSourcing = "DESTINATION"
+	TaxSourcingORIGIN      TaxSourcing = "ORIGIN"
+)
+
+// IsValid returns true if the TaxSourcing is valid
+func (e TaxSourcing) IsValid() bool {
+	switch e {
+	case TaxSourcingDESTINATION:
+		return true
+	case TaxSourcingORIGIN:
+		return true
+	}
+	return false
+}
+
+// TaxDirection represents the TaxDirection enum
+type TaxDirection string
+
+const (
+	TaxDirectionSALES    TaxDirection = "SALES"
+	TaxDirectionPURCHASE TaxDirection = "PURCHASE"
+)
+
+// IsValid returns true if the TaxDirection is valid
+func (e TaxDirection) IsValid() bool {
+	switch e {
+	case TaxDirectionSALES:
+		return true
+	case TaxDirectionPURCHASE:
+		return true
+	}
+	return false
+}
+
+// BillMatchStatus represents the BillMatchStatus enum
+type BillMatchStatus string
+
+const (
+	BillMatchStatusNOT_REQUIRED BillMatchStatus = "NOT_REQUIRED"
+	BillMatchStatusPENDING      BillMatchStatus = "PENDING"
+	BillMatchStatusMATCHED      BillMatchStatus = "MATCHED"
+	BillMatchStatusEXCEPTION    BillMatchStatus = "EXCEPTION"
+	BillMatchStatusOVERRIDDEN   BillMatchStatus = "OVERRIDDEN"
+)
+
+// IsValid returns true if the BillMatchStatus is valid
+func (e BillMatchStatus) IsValid() bool {
+	switch e {
+	case BillMatchStatusNOT_REQUIRED:
+		return true
+	case BillMatchStatusPENDING:
+		return true
+	case BillMatchStatusMATCHED:
+		return true
+	case BillMatchStatusEXCEPTION:
+		return true
+	case BillMatchStatusOVERRIDDEN:
+		return true
+	}
+	return false
+}
+
+// CounterpartyType represents the CounterpartyType enum
+type CounterpartyType string
+
+const (
+	CounterpartyTypeCUSTOMER CounterpartyType = "CUSTOMER"
+	CounterpartyTypeVENDOR   CounterpartyType = "VENDOR"
+)
+
+// IsValid returns true if the CounterpartyType is valid
+func (e CounterpartyType) IsValid() bool {
+	switch e {
+	case CounterpartyTypeCUSTOMER:
+		return true
+	case CounterpartyTypeVENDOR:
+		return true
+	}
+	return false
+}
+
+// SalesOrderExposureStatus represents the SalesOrderExposureStatus enum
+type SalesOrderExposureStatus string
+
+const (
+	SalesOrderExposureStatusOPEN      SalesOrderExposureStatus = "OPEN"
+	SalesOrderExposureStatusINVOICED  SalesOrderExposureStatus = "INVOICED"
+	SalesOrderExposureStatusCANCELLED SalesOrderExposureStatus = "CANCELLED"
+)
+
+// IsValid returns true if the SalesOrderExposureStatus is valid
+func (e SalesOrderExposureStatus) IsValid() bool {
+	switch e {
+	case SalesOrderExposureStatusOPEN:
+		return true
+	case SalesOrderExposureStatusINVOICED:
+		return true
+	case SalesOrderExposureStatusCANCELLED:
+		return true
+	}
+	return false
+}
+
+// AllocationSource represents the AllocationSource enum
+type AllocationSource string
+
+const (
+	AllocationSourcePAYMENT           AllocationSource = "PAYMENT"
+	AllocationSourceCREDIT_MEMO       AllocationSource = "CREDIT_MEMO"
+	AllocationSourceON_ACCOUNT_CREDIT AllocationSource = "ON_ACCOUNT_CREDIT"
+)
+
+// IsValid returns true if the AllocationSource is valid
+func (e AllocationSource) IsValid() bool {
+	switch e {
+	case AllocationSourcePAYMENT:
+		return true
+	case AllocationSourceCREDIT_MEMO:
+		return true
+	case AllocationSourceON_ACCOUNT_CREDIT:
+		return true
+	}
+	return false
+}
+
+// PaymentFileFormat represents the PaymentFileFormat enum
+type PaymentFileFormat string
+
+const (
+	PaymentFileFormatSEPA_PAIN_001 PaymentFileFormat = "SEPA_PAIN_001"
+	PaymentFileFormatNACHA         PaymentFileFormat = "NACHA"
+)
+
+// IsValid returns true if the PaymentFileFormat is valid
+func (e PaymentFileFormat) IsValid() bool {
+	switch e {
+	case PaymentFileFormatSEPA_PAIN_001:
+		return true
+	case PaymentFileFormatNACHA:
+		return true
+	}
+	return false
+}
+
+// PaymentRunStatus represents the PaymentRunStatus enum
+type PaymentRunStatus string
+
+const (
+	PaymentRunStatusPROPOSED  PaymentRunStatus = "PROPOSED"
+	PaymentRunStatusEXECUTED  PaymentRunStatus = "EXECUTED"
+	PaymentRunStatusCANCELLED PaymentRunStatus = "CANCELLED"
+)
+
+// IsValid returns true if the PaymentRunStatus is valid
+func (e PaymentRunStatus) IsValid() bool {
+	switch e {
+	case PaymentRunStatusPROPOSED:
+		return true
+	case PaymentRunStatusEXECUTED:
+		return true
+	case PaymentRunStatusCANCELLED:
+		return true
+	}
+	return false
+}
+
+// PaymentRunLineStatus represents the PaymentRunLineStatus enum
+type PaymentRunLineStatus string
+
+const (
+	PaymentRunLineStatusPROPOSED PaymentRunLineStatus = "PROPOSED"
+	PaymentRunLineStatusPAID     PaymentRunLineStatus = "PAID"
+	PaymentRunLineStatusEXCLUDED PaymentRunLineStatus = "EXCLUDED"
+)
+
+// IsValid returns true if the PaymentRunLineStatus is valid
+func (e PaymentRunLineStatus) IsValid() bool {
+	switch e {
+	case PaymentRunLineStatusPROPOSED:
+		return true
+	case PaymentRunLineStatusPAID:
+		return true
+	case PaymentRunLineStatusEXCLUDED:
+		return true
+	}
+	return false
+}
+
+// AllocationMethod represents the AllocationMethod enum
+type AllocationMethod string
+
+const (
+	AllocationMethodFIXED_PERCENT AllocationMethod = "FIXED_PERCENT"
+	AllocationMethodSTATISTICAL   AllocationMethod = "STATISTICAL"
+)
+
+// IsValid returns true if the AllocationMethod is valid
+func (e AllocationMethod) IsValid() bool {
+	switch e {
+	case AllocationMethodFIXED_PERCENT:
+		return true
+	case AllocationMethodSTATISTICAL:
+		return true
+	}
+	return false
+}
+
+// AllocationDriver represents the AllocationDriver enum
+type AllocationDriver string
+
+const (
+	AllocationDriverHEADCOUNT     AllocationDriver = "HEADCOUNT"
+	AllocationDriverMACHINE_HOURS AllocationDriver = "MACHINE_HOURS"
+)
+
+// IsValid returns true if the AllocationDriver is valid
+func (e AllocationDriver) IsValid() bool {
+	switch e {
+	case AllocationDriverHEADCOUNT:
+		return true
+	case AllocationDriverMACHINE_HOURS:
+		return true
+	}
+	return false
+}
+
+// AllocationRole represents the AllocationRole enum
+type AllocationRole string
+
+const (
+	AllocationRoleSENDER   AllocationRole = "SENDER"
+	AllocationRoleRECEIVER AllocationRole = "RECEIVER"
+)
+
+// IsValid returns true if the AllocationRole is valid
+func (e AllocationRole) IsValid() bool {
+	switch e {
+	case AllocationRoleSENDER:
+		return true
+	case AllocationRoleRECEIVER:
+		return true
+	}
+	return false
+}
+
+// AllocationRunStatus represents the AllocationRunStatus enum
+type AllocationRunStatus string
+
+const (
+	AllocationRunStatusPOSTED   AllocationRunStatus = "POSTED"
+	AllocationRunStatusREVERSED AllocationRunStatus = "REVERSED"
+)
+
+// IsValid returns true if the AllocationRunStatus is valid
+func (e AllocationRunStatus) IsValid() bool {
+	switch e {
+	case AllocationRunStatusPOSTED:
+		return true
+	case AllocationRunStatusREVERSED:
+		return true
+	}
+	return false
+}
+
+// PostingKey represents the PostingKey enum
+type PostingKey string
+
+const (
+	PostingKeyAR_CONTROL              PostingKey = "AR_CONTROL"
+	PostingKeyAP_CONTROL              PostingKey = "AP_CONTROL"
+	PostingKeyCASH_AT_BANK            PostingKey = "CASH_AT_BANK"
+	PostingKeyPAYMENT_CLEARING        PostingKey = "PAYMENT_CLEARING"
+	PostingKeyTAX_LIABILITY           PostingKey = "TAX_LIABILITY"
+	PostingKeyFX_UNREALIZED_GAIN      PostingKey = "FX_UNREALIZED_GAIN"
+	PostingKeyFX_UNREALIZED_LOSS      PostingKey = "FX_UNREALIZED_LOSS"
+	PostingKeyFX_REALIZED_GAIN        PostingKey = "FX_REALIZED_GAIN"
+	PostingKeyFX_REALIZED_LOSS        PostingKey = "FX_REALIZED_LOSS"
+	PostingKeyDUNNING_FEE_INCOME      PostingKey = "DUNNING_FEE_INCOME"
+	PostingKeyDUNNING_INTEREST_INCOME PostingKey = "DUNNING_INTEREST_INCOME"
+)
+
+// IsValid returns true if the PostingKey is valid
+func (e PostingKey) IsValid() bool {
+	switch e {
+	case PostingKeyAR_CONTROL:
+		return true
+	case PostingKeyAP_CONTROL:
+		return true
+	case PostingKeyCASH_AT_BANK:
+		return true
+	case PostingKeyPAYMENT_CLEARING:
+		return true
+	case PostingKeyTAX_LIABILITY:
+		return true
+	case PostingKeyFX_UNREALIZED_GAIN:
+		return true
+	case PostingKeyFX_UNREALIZED_LOSS:
+		return true
+	case PostingKeyFX_REALIZED_GAIN:
+		return true
+	case PostingKeyFX_REALIZED_LOSS:
+		return true
+	case PostingKeyDUNNING_FEE_INCOME:
+		return true
+	case PostingKeyDUNNING_INTEREST_INCOME:
+		return true
+	}
+	return false
+}
@@ -4,4 +4,80 @@ import "errors"
 
 var (
 	ErrJournalEntryNotMutable = errors.New("journal entry is not mutable")
+
+	ErrInvalidJournalTemplate  = errors.New("invalid journal template")
+	ErrJournalTemplateNotFound = errors.New("journal template not found")
+	ErrJournalTemplateInactive = errors.New("journal template is inactive")
+
+	ErrStatementLineAlreadyMatched   = errors.New("bank statement line is already matched")
+	ErrPaymentAlreadyMatched         = errors.New("payment is already matched to a bank statement line")
+	ErrPaymentNotOnBankAccount       = errors.New("payment was not made through the statement's bank account")
+	ErrReconciliationAmountMismatch  = errors.New("matched statement lines and payments do not balance")
+	ErrReconciliationExceptionClosed = errors.New("reconciliation exception is already resolved")
+
+	ErrDuplicateBankStatement   = errors.New("bank statement has already been imported")
+	ErrInvalidBankStatementFile = errors.New("bank statement file contains invalid entries")
+
+	ErrInvalidForecastRequest = errors.New("invalid cash flow forecast request")
+
+	ErrInvalidAccountDetermination = errors.New("invalid account determination")
+
+	ErrExchangeRateNotFound    = errors.New("no effective exchange rate")
+	ErrFxRevaluationAlreadyRun = errors.New("fx revaluation already posted for this period")
+
+	ErrPeriodClosed            = errors.New("financial period is closed for posting")
+	ErrPeriodCloseBlocked      = errors.New("period close checklist has open items")
+	ErrInvalidPeriodTransition = errors.New("invalid period state transition")
+	ErrFiscalYearClosed        = errors.New("fiscal year is already closed")
+
+	ErrInvalidIntercompanyTransaction = errors.New("invalid intercompany transaction")
+	ErrInvalidConsolidationGroup      = errors.New("invalid consolidation group")
+	ErrConsolidationGroupNotFound     = errors.New("consolidation group not found")
+
+	ErrInvalidReportRequest = errors.New("invalid report request")
+
+	ErrInvalidCapitalAsset = errors.New("invalid capital asset")
+	ErrAssetNotActive      = errors.New("capital asset is not active")
+
+	ErrInvalidBudget           = errors.New("invalid budget")
+	ErrBudgetExceeded          = errors.New("budget exceeded")
+	ErrBudgetApprovalRequired  = errors.New("budget overrun requires approval")
+	ErrCommitmentNotApprovable = errors.New("budget commitment is not awaiting approval")
+
+	ErrInvalidTaxRequest       = errors.New("invalid tax request")
+	ErrTaxJurisdictionNotFound = errors.New("tax jurisdiction not found")
+
+	ErrInvalidMatchRequest = errors.New("invalid three-way match request")
+	ErrVendorBillNotFound  = errors.New("vendor bill not found")
+	ErrBillNotInReview     = errors.New("vendor bill is not awaiting match review")
+	ErrBillOnPaymentHold   = errors.New("vendor bill is on payment hold")
+
+	ErrInvalidPaymentAllocation = errors.New("invalid payment allocation")
+	ErrInvoiceNotFound          = errors.New("invoice not found")
+	ErrOnAccountCreditNotFound  = errors.New("on-account credit not found")
+	ErrInvalidAgingRequest      = errors.New("invalid aging request")
+
+	ErrInvalidDunningLevel = errors.New("invalid dunning level")
+	ErrNoDunningLevels     = errors.New("no dunning levels configured")
+	ErrInvoiceAlreadyPaid  = errors.New("invoice is already paid")
+
+	ErrInvalidCreditRequest = errors.New("invalid credit request")
+	ErrCustomerNotOnHold    = errors.New("customer is not on credit hold")
+
+	ErrInvalidPaymentRun         = errors.New("invalid payment run")
+	ErrPaymentRunNotFound        = errors.New("payment run not found")
+	ErrPaymentRunNotProposed     = errors.New("payment run is not awaiting approval")
+	ErrPaymentFileNotFound       = errors.New("payment file not found")
+	ErrInvalidVendorBankAccount  = errors.New("invalid vendor bank account")
+	ErrVendorBankAccountNotFound = errors.New("vendor bank account not found")
+
+	ErrInvalidCostCenter       = errors.New("invalid cost center")
+	ErrInvalidAllocationCycle  = errors.New("invalid allocation cycle")
+	ErrAllocationCycleNotFound = errors.New("allocation cycle not found")
+	ErrAllocationCycleInactive = errors.New("allocation cycle is inactive")
+	ErrAllocationRunNotFound   = errors.New("allocation run not found")
+	ErrAllocationRunReversed   = errors.New("allocation run is already reversed")
+	ErrNothingToAllocate       = errors.New("nothing to allocate")
+	ErrMissingAllocationDriver = errors.New("no driver values for the receivers")
+	ErrInvalidKeyFigure        = errors.New("invalid statistical key figure")
 )
@@ -9,12 +9,31 @@ const (
 	TopicFmBudgetExceeded              = "fm.budget.exceeded"
 	TopicFmAccountBalanceChanged       = "fm.account.balance.changed"
 	TopicFmBudgetApproved              = "fm.budget.approved"
+	TopicFmBudgetApprovalRequired      = "fm.budget.approval.required"
+	TopicFmBudgetCommitmentApproved    = "fm.budget.commitment.approved"
+	TopicFmBudgetCommitmentRejected    = "fm.budget.commitment.rejected"
+	TopicFmFxRevaluationPosted         = "fm.fx.revaluation.posted"
+	TopicFmPeriodClosed                = "fm.period.closed"
+	TopicFmFiscalYearClosed            = "fm.fiscal_year.closed"
+	TopicFmAssetDisposed               = "fm.asset.disposed"
+	TopicFmIntercompanyPosted          = "fm.intercompany.posted"
+	TopicFmCreditMemoIssued            = "fm.credit.memo.issued"
+	TopicFmInvoiceOverdue              = "fm.invoice.overdue"
+	TopicFmDunningLetterIssued         = "fm.dunning.letter.issued"
+	TopicFmPaymentRunExecuted          = "fm.payment.run.executed"
+	TopicFmBankStatementReconciled     = "fm.bank.statement.reconciled"
+	TopicFmVendorBillHeld              = "fm.vendor.bill.held"
+	TopicFmVendorBillReleased          = "fm.vendor.bill.released"
 
 	// Consumer Events
-	TopicScmReceiptStaged     = "scm.receipt.staged"
-	TopicScmOrderShipped      = "scm.order.shipped"
-	TopicCrmOrderConfirmed    = "crm.order.confirmed"
-	TopicHrPayrollProcessed   = "hr.payroll.processed"
-	TopicMfgYieldProduced     = "mfg.yield.produced"
-	TopicPrjMilestoneAchieved = "prj.milestone.achieved"
+	TopicScmReceiptStaged               = "scm.receipt.staged"
+	TopicScmOrderShipped                = "scm.order.shipped"
+	TopicCrmOrderConfirmed              = "crm.order.confirmed"
+	TopicCrmOrderCancelled              = "crm.order.cancelled"
+	TopicHrPayrollProcessed             = "hr.payroll.processed"
+	TopicMfgYieldProduced               = "mfg.yield.produced"
+	TopicScmPurchaseRequisitionApproved = "scm.purchase.requisition.approved"
+	TopicScmPurchaseOrderApproved       = "scm.purchase.order.approved"
+	TopicEamEquipmentUsageRecorded      = "eam.equipment.usage.recorded"
+	TopicPrjMilestoneAchieved           = "prj.milestone.achieved"
 )
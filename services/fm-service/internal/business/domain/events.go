@@ -26,16 +26,84 @@ type InvoiceEventPayload struct {
 }
 
 type PaymentEventPayload struct {
-	ID            string          `json:"id"`
-	InvoiceID     *string         `json:"invoice_id,omitempty"`
-	BillID        *string         `json:"bill_id,omitempty"`
-	PaymentNumber string          `json:"payment_number"`
-	Amount        decimal.Decimal `json:"amount"`
-	PaymentMethod string          `json:"payment_method"`
-	Status        string          `json:"status"`
+	ID              string                `json:"id"`
+	InvoiceID       *string               `json:"invoice_id,omitempty"`
+	BillID          *string               `json:"bill_id,omitempty"`
+	PaymentNumber   string                `json:"payment_number"`
+	Amount          decimal.Decimal       `json:"amount"`
+	PaymentMethod   string                `json:"payment_method"`
+	Status          string                `json:"status"`
+	Allocations     []AllocationEventLine `json:"allocations,omitempty"`
+	UnappliedAmount decimal.Decimal       `json:"unapplied_amount"` // Overpayment kept as on-account credit
+	Timestamp       time.Time             `json:"timestamp"`
+}
+
+type VendorPaidEventPayload struct {
+	BillID        string          `json:"bill_id"`
+	LegalEntityID string          `json:"legal_entity_id"`
+	VendorID      string          `json:"vendor_id"`
+	BillNumber    string          `json:"bill_number"`
+	TotalAmount   decimal.Decimal `json:"total_amount"`
 	Timestamp     time.Time       `json:"timestamp"`
 }
 
+type AllocationEventLine struct {
+	InvoiceID *string         `json:"invoice_id,omitempty"`
+	BillID    *string         `json:"bill_id,omitempty"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+type CreditMemoEventPayload struct {
+	ID               string          `json:"id"`
+	CreditMemoNumber string          `json:"credit_memo_number"`
+	CustomerID       string          `json:"customer_id"`
+	InvoiceID        string          `json:"invoice_id"`
+	Amount           decimal.Decimal `json:"amount"`
+	AppliedAmount    decimal.Decimal `json:"applied_amount"`
+	Reason           string          `json:"reason"`
+	Timestamp        time.Time       `json:"timestamp"`
+}
+
+type DunningLetterEventPayload struct {
+	NoticeID       string          `json:"notice_id"`
+	RunID          string          `json:"run_id"`
+	LegalEntityID  string          `json:"legal_entity_id"`
+	InvoiceID      string          `json:"invoice_id"`
+	InvoiceNumber  string          `json:"invoice_number"`
+	CustomerID     string          `json:"customer_id"`
+	Level          int             `json:"level"`
+	LevelName      string          `json:"level_name"`
+	DaysOverdue    int             `json:"days_overdue"`
+	Currency       string          `json:"currency"`
+	OpenAmount     decimal.Decimal `json:"open_amount"`
+	FeeAmount      decimal.Decimal `json:"fee_amount"`
+	InterestAmount decimal.Decimal `json:"interest_amount"`
+	TotalDue       decimal.Decimal `json:"total_due"`
+	IsFinal        bool            `json:"is_final"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
+type PaymentRunEventPayload struct {
+	RunID         string    `json:"run_id"`
+	LegalEntityID string    `json:"legal_entity_id"`
+	PaymentDate   time.Time `json:"payment_date"`
+	PaymentCount  int       `json:"payment_count"`
+	BillCount     int       `json:"bill_count"`
+	FileIDs       []string  `json:"file_ids"`
+	ApprovedBy    string    `json:"approved_by"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type CustomerCreditStatusEventPayload struct {
+	CustomerID     string          `json:"customer_id"`
+	CreditLimit    decimal.Decimal `json:"credit_limit"`
+	CurrentBalance decimal.Decimal `json:"current_balance"`
+	IsOnHold       bool            `json:"is_on_hold"`
+	Reason         string          `json:"reason,omitempty"`
+	ChangedBy      string          `json:"changed_by,omitempty"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
 type BudgetEventPayload struct {
 	AccountID       string          `json:"account_id"`
 	CostCenterID    *string         `json:"cost_center_id,omitempty"`
@@ -46,6 +114,19 @@ type BudgetEventPayload struct {
 	Timestamp       time.Time       `json:"timestamp"`
 }
 
+// BudgetCommitmentEventPayload is published when a commitment needs approval or is rejected
+type BudgetCommitmentEventPayload struct {
+	CommitmentID     string                 `json:"commitment_id"`
+	SourceType       BudgetCommitmentSource `json:"source_type"`
+	SourceDocumentID string                 `json:"source_document_id"`
+	AccountID        string                 `json:"account_id"`
+	CostCenterID     *string                `json:"cost_center_id,omitempty"`
+	Amount           decimal.Decimal        `json:"amount"`
+	Available        decimal.Decimal        `json:"available"`
+	Status           BudgetCommitmentStatus `json:"status"`
+	Timestamp        time.Time              `json:"timestamp"`
+}
+
 type AccountEventPayload struct {
 	ID            string          `json:"id"`
 	AccountNumber string          `json:"account_number"`
@@ -73,6 +154,24 @@ type VendorBillEventPayload struct {
 	Timestamp  time.Time       `json:"timestamp"`
 }
 
+// MatchVariance is a deviation of a vendor bill from its purchase order or goods receipts
+type MatchVariance struct {
+	MaterialID string          `json:"material_id,omitempty"`
+	Type       string          `json:"type"` // PRICE, QUANTITY, NOT_ON_ORDER, AMOUNT or PO_NOT_FOUND
+	Expected   decimal.Decimal `json:"expected"`
+	Actual     decimal.Decimal `json:"actual"`
+}
+
+type VendorBillMatchEventPayload struct {
+	BillID          string          `json:"bill_id"`
+	VendorID        string          `json:"vendor_id"`
+	PurchaseOrderID string          `json:"purchase_order_id"`
+	MatchStatus     BillMatchStatus `json:"match_status"`
+	Variances       []MatchVariance `json:"variances,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
+
 type BudgetApprovedEvent struct {
 	ProjectID    string          `json:"project_id"`
 	TotalBudget  decimal.Decimal `json:"total_budget"`
@@ -80,6 +179,57 @@ type BudgetApprovedEvent struct {
 	Timestamp    time.Time       `json:"timestamp"`
 }
 
+type BankStatementReconciledEventPayload struct {
+	StatementID    string    `json:"statement_id"`
+	BankAccountID  string    `json:"bank_account_id"`
+	MatchedLines   int       `json:"matched_lines"`
+	ExceptionLines int       `json:"exception_lines"`
+	IsReconciled   bool      `json:"is_reconciled"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+type FxRevaluationPostedEventPayload struct {
+	LegalEntityID   string          `json:"legal_entity_id"`
+	FinancialPeriod string          `json:"financial_period"`
+	JournalEntryID  string          `json:"journal_entry_id"`
+	NetGainLoss     decimal.Decimal `json:"net_gain_loss"`
+	ItemCount       int             `json:"item_count"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
+
+type PeriodStateChangedEventPayload struct {
+	LegalEntityID   string    `json:"legal_entity_id"`
+	FinancialPeriod string    `json:"financial_period"`
+	State           string    `json:"state"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+type FiscalYearClosedEventPayload struct {
+	LegalEntityID  string          `json:"legal_entity_id"`
+	FiscalYear     int             `json:"fiscal_year"`
+	ClosingEntryID *string         `json:"closing_entry_id,omitempty"`
+	NetIncome      decimal.Decimal `json:"net_income"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
+type IntercompanyPostedEventPayload struct {
+	IntercompanyTransactionID string          `json:"intercompany_transaction_id"`
+	FromLegalEntityID         string          `json:"from_legal_entity_id"`
+	ToLegalEntityID           string          `json:"to_legal_entity_id"`
+	Currency                  string          `json:"currency"`
+	Amount                    decimal.Decimal `json:"amount"`
+	Timestamp                 time.Time       `json:"timestamp"`
+}
+
+type AssetDisposedEventPayload struct {
+	AssetID        string          `json:"asset_id"`
+	LegalEntityID  string          `json:"legal_entity_id"`
+	Proceeds       decimal.Decimal `json:"proceeds"`
+	GainLoss       decimal.Decimal `json:"gain_loss"`
+	JournalEntryID string          `json:"journal_entry_id"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
 // -----------------------------------------------------------------
 // CONSUMED EVENTS PAYLOADS
 // -----------------------------------------------------------------
@@ -94,13 +244,37 @@ type EmployeeCreatedEvent struct {
 	Timestamp    time.Time       `json:"timestamp"`
 }
 
+// PurchaseOrderLineEvent is an ordered material of a purchase order
+type PurchaseOrderLineEvent struct {
+	LineID          string          `json:"line_id"`
+	MaterialID      string          `json:"material_id"`
+	QuantityOrdered decimal.Decimal `json:"quantity_ordered"`
+	UnitPrice       decimal.Decimal `json:"unit_price"`
+}
+
 // PurchaseOrderCreatedEvent from SCM
 type PurchaseOrderCreatedEvent struct {
-	PurchaseOrderID string          `json:"purchase_order_id"`
-	PONumber        string          `json:"po_number"`
-	SupplierID      string          `json:"supplier_id"`
-	TotalAmount     decimal.Decimal `json:"total_amount"`
-	Timestamp       time.Time       `json:"timestamp"`
+	PurchaseOrderID string                   `json:"purchase_order_id"`
+	PONumber        string                   `json:"po_number"`
+	SupplierID      string                   `json:"supplier_id"`
+	TotalAmount     decimal.Decimal          `json:"total_amount"`
+	Lines           []PurchaseOrderLineEvent `json:"lines,omitempty"`
+	Timestamp       time.Time                `json:"timestamp"`
+}
+
+// ReceiptLineEvent is a received material of a goods receipt
+type ReceiptLineEvent struct {
+	MaterialID       string          `json:"material_id"`
+	QuantityReceived decimal.Decimal `json:"quantity_received"`
+}
+
+// ReceiptStagedEvent from SCM, published when a goods receipt against a purchase order is staged
+type ReceiptStagedEvent struct {
+	ReceiptID       string             `json:"receipt_id"`
+	PurchaseOrderID string             `json:"purchase_order_id"`
+	ReceivedDate    time.Time          `json:"received_date"`
+	Lines           []ReceiptLineEvent `json:"lines"`
+	Timestamp       time.Time          `json:"timestamp"`
 }
 
 // SalesOrderConfirmedEvent from CRM
@@ -111,6 +285,13 @@ type SalesOrderConfirmedEvent struct {
 	Timestamp    time.Time       `json:"timestamp"`
 }
 
+// SalesOrderCancelledEvent from CRM
+type SalesOrderCancelledEvent struct {
+	SalesOrderID string    `json:"sales_order_id"`
+	Reason       string    `json:"reason"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
 // MaterialConsumedEvent from Manufacturing
 type MaterialConsumedEvent struct {
 	ProductionOrderID string          `json:"production_order_id"`
@@ -123,12 +304,71 @@ type MaterialConsumedEvent struct {
 
 // PayrollProcessedEvent from HR
 type PayrollProcessedEvent struct {
-	PayrollID   string          `json:"payroll_id"`
-	PeriodStart time.Time       `json:"period_start"`
-	PeriodEnd   time.Time       `json:"period_end"`
-	TotalGross  decimal.Decimal `json:"total_gross"`
-	TotalNet    decimal.Decimal `json:"total_net"`
-	Timestamp   time.Time       `json:"timestamp"`
+	EventID       string          `json:"event_id"`
+	LegalEntityID string          `json:"legal_entity_id"`
+	PayrollRunID  string          `json:"payroll_run_id"`
+	FiscalYear    int             `json:"fiscal_year"`
+	PeriodNumber  int             `json:"period_number"`
+	TotalNetPay   decimal.Decimal `json:"total_net_pay"`
+	TotalGrossPay decimal.Decimal `json:"total_gross_pay"`
+	// Headcount lists the active employees per cost center when the run was approved
+	Headcount []CostCenterHeadcount `json:"headcount,omitempty"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+// CostCenterHeadcount is the number of active employees in departments charged to a cost center
+type CostCenterHeadcount struct {
+	CostCenterID string `json:"cost_center_id"`
+	Headcount    int    `json:"headcount"`
+}
+
+// YieldProducedEvent from MFG; machine hours are the station's standard run time for the yield
+type YieldProducedEvent struct {
+	EventID          string          `json:"event_id"`
+	LegalEntityID    string          `json:"legal_entity_id"`
+	WorkOrderID      string          `json:"work_order_id"`
+	RoutingStationID string          `json:"routing_station_id"`
+	CostCenterID     string          `json:"cost_center_id,omitempty"`
+	QuantityGood     decimal.Decimal `json:"quantity_good"`
+	QuantityScrap    decimal.Decimal `json:"quantity_scrap"`
+	MachineHours     decimal.Decimal `json:"machine_hours"`
+	Timestamp        time.Time       `json:"timestamp"`
+}
+
+// PurchaseCommitmentLine is a budgeted line of an approved requisition or purchase order
+type PurchaseCommitmentLine struct {
+	AccountID    string          `json:"account_id"`
+	CostCenterID string          `json:"cost_center_id,omitempty"`
+	Amount       decimal.Decimal `json:"amount"`
+}
+
+// PurchaseRequisitionApprovedEvent from SCM
+type PurchaseRequisitionApprovedEvent struct {
+	EventID       string                   `json:"event_id"`
+	RequisitionID string                   `json:"requisition_id"`
+	NeedByDate    time.Time                `json:"need_by_date"`
+	Lines         []PurchaseCommitmentLine `json:"lines"`
+	Timestamp     time.Time                `json:"timestamp"`
+}
+
+// PurchaseOrderApprovedEvent from SCM; the order replaces the commitment of its requisition
+type PurchaseOrderApprovedEvent struct {
+	EventID         string                   `json:"event_id"`
+	PurchaseOrderID string                   `json:"purchase_order_id"`
+	RequisitionID   string                   `json:"requisition_id,omitempty"`
+	DeliveryDate    time.Time                `json:"delivery_date"`
+	Lines           []PurchaseCommitmentLine `json:"lines"`
+	Timestamp       time.Time                `json:"timestamp"`
+}
+
+// EquipmentUsageRecordedEvent from EAM, e.g. machine hours or output from equipment telemetry
+type EquipmentUsageRecordedEvent struct {
+	EventID       string          `json:"event_id"`
+	LegalEntityID string          `json:"legal_entity_id"`
+	EquipmentID   string          `json:"equipment_id"`
+	UsageDate     time.Time       `json:"usage_date"`
+	Units         decimal.Decimal `json:"units"`
+	Timestamp     time.Time       `json:"timestamp"`
 }
 
 // ExpenseSubmittedEvent from HR
@@ -142,12 +382,20 @@ type ExpenseSubmittedEvent struct {
 
 // InvoiceReceivedEvent from SCM
 type InvoiceReceivedEvent struct {
-	VendorID    string          `json:"vendor_id"`
-	InvoiceNo   string          `json:"invoice_no"`
-	POID        string          `json:"po_id"`
-	TotalAmount decimal.Decimal `json:"total_amount"`
-	DueDate     time.Time       `json:"due_date"`
-	Timestamp   time.Time       `json:"timestamp"`
+	VendorID    string             `json:"vendor_id"`
+	InvoiceNo   string             `json:"invoice_no"`
+	POID        string             `json:"po_id"`
+	TotalAmount decimal.Decimal    `json:"total_amount"`
+	DueDate     time.Time          `json:"due_date"`
+	Lines       []InvoiceLineEvent `json:"lines,omitempty"`
+	Timestamp   time.Time          `json:"timestamp"`
+}
+
+// InvoiceLineEvent is a billed material of a supplier invoice
+type InvoiceLineEvent struct {
+	MaterialID string          `json:"material_id"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	UnitPrice  decimal.Decimal `json:"unit_price"`
 }
 
 // InventoryValuedEvent from SCM
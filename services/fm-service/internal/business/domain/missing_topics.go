@@ -5,7 +5,6 @@ const (
 	TopicFmInvoiceCreated        = "fm.invoice.created"
 	TopicFmInvoiceUpdated        = "fm.invoice.updated"
 	TopicFmInvoiceSent           = "fm.invoice.sent"
-	TopicFmInvoiceOverdue        = "fm.invoice.overdue"
 	TopicFmBudgetCreated         = "fm.budget.created"
 	TopicFmBudgetUpdated         = "fm.budget.updated"
 	TopicFmPaymentReceived       = "fm.payment.received"
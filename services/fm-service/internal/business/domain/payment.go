@@ -7,15 +7,20 @@ import (
 )
 
 type Payment struct {
-	ID            string          `json:"id"`
-	InvoiceID     *string         `json:"invoice_id,omitempty"`
-	BillID        *string         `json:"bill_id,omitempty"`
-	BankAccountID *string         `json:"bank_account_id,omitempty"`
-	PaymentNumber string          `json:"payment_number"`
-	PaymentDate   time.Time       `json:"payment_date"`
-	Amount        decimal.Decimal `json:"amount"`
-	PaymentMethod string          `json:"payment_method"`
-	Status        string          `json:"status"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+	ID                 string           `json:"id"`
+	InvoiceID          *string          `json:"invoice_id,omitempty"`
+	BillID             *string          `json:"bill_id,omitempty"`
+	BankAccountID      *string          `json:"bank_account_id,omitempty"`
+	CounterpartyType   CounterpartyType `json:"counterparty_type"` // Customer receipt or vendor disbursement
+	CounterpartyID     string           `json:"counterparty_id"`   // Customer or vendor paying or being paid
+	PaymentNumber      string           `json:"payment_number"`
+	PaymentDate        time.Time        `json:"payment_date"`
+	Amount             decimal.Decimal  `json:"amount"`
+	PaymentMethod      string           `json:"payment_method"`
+	Status             string           `json:"status"`
+	Currency           string           `json:"currency"`              // Currency of the settled document
+	ExchangeRate       decimal.Decimal  `json:"exchange_rate"`         // Settlement rate to functional currency
+	RealizedFxGainLoss decimal.Decimal  `json:"realized_fx_gain_loss"` // Functional-currency gain (+) or loss (-) on settlement
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
 }
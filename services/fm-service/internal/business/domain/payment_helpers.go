@@ -0,0 +1,36 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+// IsOutgoing reports whether the payment settles a vendor bill, i.e. money
+// leaving the bank account. Anything tied to a customer invoice is a receipt.
+func (p Payment) IsOutgoing() bool {
+	if p.CounterpartyType != "" {
+		return p.CounterpartyType == CounterpartyTypeVENDOR
+	}
+	return p.BillID != nil && p.InvoiceID == nil
+}
+
+// SignedAmount returns the payment amount as it appears on a bank statement:
+// positive for receipts, negative for disbursements.
+func (p Payment) SignedAmount() decimal.Decimal {
+	if p.IsOutgoing() {
+		return p.Amount.Neg()
+	}
+	return p.Amount
+}
+
+// AmountDue is the invoice total plus any late fees and interest charged by dunning.
+func (i ArInvoice) AmountDue() decimal.Decimal {
+	return i.TotalAmount.Add(i.FeesCharged)
+}
+
+// OpenAmount is what the customer still owes on the invoice.
+func (i ArInvoice) OpenAmount() decimal.Decimal {
+	return i.AmountDue().Sub(i.AmountPaid)
+}
+
+// OpenAmount is what is still owed to the vendor on the bill.
+func (b ApVendorBill) OpenAmount() decimal.Decimal {
+	return b.TotalAmount.Sub(b.AmountPaid)
+}
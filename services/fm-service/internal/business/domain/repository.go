@@ -3,10 +3,14 @@ package domain
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var ErrOptimisticLock = errors.New("optimistic lock conflict")
 
+// ErrAccountNotFound is returned by ChartOfAccountsRepository lookups that match no account
+var ErrAccountNotFound = errors.New("chart of accounts not found")
+
 // ChartOfAccountsRepository defines operations for chart of accounts
 type ChartOfAccountsRepository interface {
 	Create(ctx context.Context, coa *ChartOfAccounts) error
@@ -17,6 +21,16 @@ type ChartOfAccountsRepository interface {
 	List(ctx context.Context) ([]ChartOfAccounts, error)
 }
 
+// ErrAccountDeterminationNotFound is returned when a legal entity has not configured a posting key
+var ErrAccountDeterminationNotFound = errors.New("account determination not found")
+
+// AccountDeterminationRepository defines operations for the accounts configured per posting key
+type AccountDeterminationRepository interface {
+	Upsert(ctx context.Context, determination *AccountDetermination) error
+	GetByKey(ctx context.Context, legalEntityID string, key PostingKey) (*AccountDetermination, error)
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]AccountDetermination, error)
+}
+
 // UniversalJournalEntryRepository defines operations for universal journal entries
 type UniversalJournalEntryRepository interface {
 	Create(ctx context.Context, entry *UniversalJournalEntry, lines []UniversalJournalLine) error
@@ -26,6 +40,14 @@ type UniversalJournalEntryRepository interface {
 	List(ctx context.Context) ([]UniversalJournalEntry, error)
 }
 
+// JournalTemplateRepository defines operations for recurring journal templates
+type JournalTemplateRepository interface {
+	Create(ctx context.Context, template *JournalTemplate, lines []JournalTemplateLine) error
+	GetByID(ctx context.Context, id string) (*JournalTemplate, []JournalTemplateLine, error)
+	Update(ctx context.Context, template *JournalTemplate, lines []JournalTemplateLine) error
+	List(ctx context.Context) ([]JournalTemplate, error)
+}
+
 // ArInvoiceRepository defines operations for customer invoices (Accounts Receivable)
 type ArInvoiceRepository interface {
 	Create(ctx context.Context, invoice *ArInvoice) error
@@ -50,7 +72,24 @@ type BudgetRepository interface {
 	Update(ctx context.Context, budget *Budget) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context) ([]Budget, error)
-	GetByAccountAndPeriod(ctx context.Context, accountID string, fiscalYear int, period int) (*Budget, error)
+	GetByAccountAndPeriod(ctx context.Context, accountID string, fiscalYear int, period int) (*Budget, error) // ORIGINAL version
+}
+
+// BudgetCommitmentRepository defines operations for budget commitments of requisitions and purchase orders
+type BudgetCommitmentRepository interface {
+	Create(ctx context.Context, commitment *BudgetCommitment) error
+	GetByID(ctx context.Context, id string) (*BudgetCommitment, error)
+	Update(ctx context.Context, commitment *BudgetCommitment) error
+	List(ctx context.Context) ([]BudgetCommitment, error)
+	ListBySourceDocument(ctx context.Context, sourceDocumentID string) ([]BudgetCommitment, error)
+}
+
+// BudgetPolicyRepository defines operations for per cost center budget enforcement
+type BudgetPolicyRepository interface {
+	Create(ctx context.Context, policy *BudgetPolicy) error
+	Update(ctx context.Context, policy *BudgetPolicy) error
+	GetByCostCenter(ctx context.Context, costCenterID string) (*BudgetPolicy, error)
+	List(ctx context.Context) ([]BudgetPolicy, error)
 }
 
 // ApVendorBillRepository defines operations for vendor bills (Accounts Payable)
@@ -63,6 +102,55 @@ type ApVendorBillRepository interface {
 	List(ctx context.Context) ([]ApVendorBill, error)
 }
 
+// ApVendorBillLineRepository defines operations for the material lines of vendor bills
+type ApVendorBillLineRepository interface {
+	ReplaceForBill(ctx context.Context, billID string, lines []ApVendorBillLine) error
+	ListByBill(ctx context.Context, billID string) ([]ApVendorBillLine, error)
+}
+
+// PurchaseOrderLineRepository defines operations for the local copy of scm-service order lines
+type PurchaseOrderLineRepository interface {
+	ReplaceForPurchaseOrder(ctx context.Context, purchaseOrderID string, lines []PurchaseOrderLine) error
+	ListByPurchaseOrder(ctx context.Context, purchaseOrderID string) ([]PurchaseOrderLine, error)
+}
+
+// GoodsReceiptLineRepository defines operations for the local copy of scm-service receipt lines
+type GoodsReceiptLineRepository interface {
+	CreateMany(ctx context.Context, lines []GoodsReceiptLine) error
+	ListByPurchaseOrder(ctx context.Context, purchaseOrderID string) ([]GoodsReceiptLine, error)
+}
+
+// MatchToleranceRepository defines operations for per vendor three-way match tolerances
+type MatchToleranceRepository interface {
+	Create(ctx context.Context, tolerance *MatchTolerance) error
+	Update(ctx context.Context, tolerance *MatchTolerance) error
+	GetByVendor(ctx context.Context, vendorID string) (*MatchTolerance, error)
+	List(ctx context.Context) ([]MatchTolerance, error)
+}
+
+// PaymentAllocationRepository defines operations for amounts applied to invoices and bills
+type PaymentAllocationRepository interface {
+	CreateMany(ctx context.Context, allocations []PaymentAllocation) error
+	ListBySource(ctx context.Context, sourceID string) ([]PaymentAllocation, error)
+	ListByInvoice(ctx context.Context, invoiceID string) ([]PaymentAllocation, error)
+	ListByBill(ctx context.Context, billID string) ([]PaymentAllocation, error)
+}
+
+// ArCreditMemoRepository defines operations for customer credit memos
+type ArCreditMemoRepository interface {
+	Create(ctx context.Context, memo *ArCreditMemo) error
+	GetByID(ctx context.Context, id string) (*ArCreditMemo, error)
+	List(ctx context.Context) ([]ArCreditMemo, error)
+}
+
+// OnAccountCreditRepository defines operations for unapplied customer and vendor credits
+type OnAccountCreditRepository interface {
+	Create(ctx context.Context, credit *OnAccountCredit) error
+	GetByID(ctx context.Context, id string) (*OnAccountCredit, error)
+	Update(ctx context.Context, credit *OnAccountCredit) error
+	List(ctx context.Context) ([]OnAccountCredit, error)
+}
+
 // TaxRateRepository defines operations for tax rates
 type TaxRateRepository interface {
 	Create(ctx context.Context, tr *TaxRate) error
@@ -70,11 +158,33 @@ type TaxRateRepository interface {
 	List(ctx context.Context) ([]TaxRate, error)
 }
 
+// TaxJurisdictionRepository defines operations for the tax jurisdiction hierarchy
+type TaxJurisdictionRepository interface {
+	Create(ctx context.Context, j *TaxJurisdiction) error
+	GetByID(ctx context.Context, id string) (*TaxJurisdiction, error)
+	GetByCode(ctx context.Context, code string) (*TaxJurisdiction, error)
+	List(ctx context.Context) ([]TaxJurisdiction, error)
+}
+
+// TaxExemptionRepository defines operations for customer tax exemption certificates
+type TaxExemptionRepository interface {
+	Create(ctx context.Context, e *TaxExemption) error
+	ListByCustomer(ctx context.Context, customerID string) ([]TaxExemption, error)
+}
+
+// TaxTransactionRepository defines operations for the posted tax ledger behind tax returns
+type TaxTransactionRepository interface {
+	CreateMany(ctx context.Context, txs []TaxTransaction) error
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]TaxTransaction, error)
+}
+
 // CurrencyRateRepository defines operations for currency rates
 type CurrencyRateRepository interface {
 	Create(ctx context.Context, rate *CurrencyRate) error
 	GetByID(ctx context.Context, id string) (*CurrencyRate, error)
 	List(ctx context.Context) ([]CurrencyRate, error)
+	// GetEffective returns the latest from->to rate effective on or before at
+	GetEffective(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*CurrencyRate, error)
 }
 
 // FiscalYearRepository defines operations for fiscal years
@@ -94,6 +204,28 @@ type CostCenterRepository interface {
 	List(ctx context.Context) ([]CostCenter, error)
 }
 
+// AllocationCycleRepository defines operations for cost center allocation cycles and their senders and receivers
+type AllocationCycleRepository interface {
+	Create(ctx context.Context, cycle *AllocationCycle, members []AllocationCycleMember) error
+	GetByID(ctx context.Context, id string) (*AllocationCycle, []AllocationCycleMember, error)
+	Update(ctx context.Context, cycle *AllocationCycle, members []AllocationCycleMember) error
+	List(ctx context.Context) ([]AllocationCycle, error)
+}
+
+// AllocationRunRepository defines operations for the postings of allocation cycles
+type AllocationRunRepository interface {
+	Create(ctx context.Context, run *AllocationRun) error
+	GetByID(ctx context.Context, id string) (*AllocationRun, error)
+	Update(ctx context.Context, run *AllocationRun) error
+	ListByCycle(ctx context.Context, cycleID string) ([]AllocationRun, error)
+}
+
+// StatisticalKeyFigureRepository defines operations for allocation driver values per cost center and period
+type StatisticalKeyFigureRepository interface {
+	Upsert(ctx context.Context, kf *StatisticalKeyFigure) error
+	ListByPeriod(ctx context.Context, driver AllocationDriver, period string) ([]StatisticalKeyFigure, error)
+}
+
 // BankAccountRepository defines operations for bank accounts
 type BankAccountRepository interface {
 	Create(ctx context.Context, ba *BankAccount) error
@@ -111,12 +243,138 @@ type CustomerCreditRepository interface {
 	List(ctx context.Context) ([]CustomerCredit, error)
 }
 
+// SalesOrderExposureRepository defines operations for confirmed sales orders awaiting invoicing
+type SalesOrderExposureRepository interface {
+	Create(ctx context.Context, e *SalesOrderExposure) error
+	Update(ctx context.Context, e *SalesOrderExposure) error
+	GetBySalesOrderID(ctx context.Context, salesOrderID string) (*SalesOrderExposure, error)
+	ListOpenByCustomer(ctx context.Context, customerID string) ([]SalesOrderExposure, error)
+}
+
+// CreditOverrideRepository defines operations for approved credit check overrides
+type CreditOverrideRepository interface {
+	Create(ctx context.Context, o *CreditOverride) error
+	Update(ctx context.Context, o *CreditOverride) error
+	ListByCustomer(ctx context.Context, customerID string) ([]CreditOverride, error)
+}
+
+// DunningLevelRepository defines operations for the dunning levels of a legal entity
+type DunningLevelRepository interface {
+	Create(ctx context.Context, level *DunningLevel) error
+	Update(ctx context.Context, level *DunningLevel) error
+	Delete(ctx context.Context, id string) error
+	GetByLevel(ctx context.Context, legalEntityID string, level int) (*DunningLevel, error)
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]DunningLevel, error)
+}
+
+// DunningRunRepository defines operations for dunning run history
+type DunningRunRepository interface {
+	Create(ctx context.Context, run *DunningRun) error
+	GetByID(ctx context.Context, id string) (*DunningRun, error)
+	List(ctx context.Context) ([]DunningRun, error)
+}
+
+// DunningNoticeRepository defines operations for the dunning letters issued per invoice
+type DunningNoticeRepository interface {
+	CreateMany(ctx context.Context, notices []DunningNotice) error
+	ListByRun(ctx context.Context, runID string) ([]DunningNotice, error)
+	ListByInvoice(ctx context.Context, invoiceID string) ([]DunningNotice, error)
+}
+
+// PaymentRunRepository defines operations for vendor payment runs
+type PaymentRunRepository interface {
+	Create(ctx context.Context, run *PaymentRun) error
+	Update(ctx context.Context, run *PaymentRun) error
+	GetByID(ctx context.Context, id string) (*PaymentRun, error)
+	List(ctx context.Context) ([]PaymentRun, error)
+}
+
+// PaymentRunLineRepository defines operations for the bills proposed in a payment run
+type PaymentRunLineRepository interface {
+	CreateMany(ctx context.Context, lines []PaymentRunLine) error
+	Update(ctx context.Context, line *PaymentRunLine) error
+	ListByRun(ctx context.Context, runID string) ([]PaymentRunLine, error)
+}
+
+// PaymentFileRepository defines operations for generated bank payment files
+type PaymentFileRepository interface {
+	Create(ctx context.Context, file *PaymentFile) error
+	GetByID(ctx context.Context, id string) (*PaymentFile, error)
+	ListByRun(ctx context.Context, runID string) ([]PaymentFile, error)
+}
+
+// VendorBankAccountRepository defines operations for the bank details vendors are paid to
+type VendorBankAccountRepository interface {
+	Create(ctx context.Context, account *VendorBankAccount) error
+	Update(ctx context.Context, account *VendorBankAccount) error
+	GetByVendorID(ctx context.Context, vendorID string) (*VendorBankAccount, error)
+}
+
 // BankStatementRepository defines operations for bank statements
 type BankStatementRepository interface {
 	Create(ctx context.Context, bs *BankStatement, lines []BankStatementLine) error
 	GetByID(ctx context.Context, id string) (*BankStatement, []BankStatementLine, error)
 	Update(ctx context.Context, bs *BankStatement, lines []BankStatementLine) error
 	List(ctx context.Context) ([]BankStatement, error)
+	ExistsByReference(ctx context.Context, bankAccountID, reference string) (bool, error)
+}
+
+// BankReconciliationMatchRepository defines operations for bank reconciliation matches
+type BankReconciliationMatchRepository interface {
+	CreateMany(ctx context.Context, matches []BankReconciliationMatch) error
+	ListByStatement(ctx context.Context, statementID string) ([]BankReconciliationMatch, error)
+	DeleteByGroup(ctx context.Context, groupID string) error
+	List(ctx context.Context) ([]BankReconciliationMatch, error)
+}
+
+// BankReconciliationExceptionRepository defines operations for the reconciliation exceptions queue
+type BankReconciliationExceptionRepository interface {
+	Create(ctx context.Context, exc *BankReconciliationException) error
+	GetByID(ctx context.Context, id string) (*BankReconciliationException, error)
+	Update(ctx context.Context, exc *BankReconciliationException) error
+	ListByStatement(ctx context.Context, statementID string) ([]BankReconciliationException, error)
+	List(ctx context.Context) ([]BankReconciliationException, error)
+}
+
+// PayrollRunSnapshotRepository stores processed hr-service payroll runs used for cash forecasting
+type PayrollRunSnapshotRepository interface {
+	Upsert(ctx context.Context, snap *PayrollRunSnapshot) error
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]PayrollRunSnapshot, error)
+}
+
+// RecurringCashItemRepository defines operations for scheduled recurring receipts and disbursements
+type RecurringCashItemRepository interface {
+	Create(ctx context.Context, item *RecurringCashItem) error
+	GetByID(ctx context.Context, id string) (*RecurringCashItem, error)
+	Update(ctx context.Context, item *RecurringCashItem) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]RecurringCashItem, error)
+}
+
+// FxRevaluationRepository stores per-item results of period-end FX revaluation runs
+type FxRevaluationRepository interface {
+	CreateMany(ctx context.Context, revaluations []FxRevaluation) error
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]FxRevaluation, error)
+}
+
+// FiscalPeriodRepository stores per-legal-entity period states
+type FiscalPeriodRepository interface {
+	Upsert(ctx context.Context, period *FiscalPeriod) error
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]FiscalPeriod, error)
+}
+
+// IntercompanyTransactionRepository stores intercompany charges and their mirrored journal entries
+type IntercompanyTransactionRepository interface {
+	Create(ctx context.Context, tx *IntercompanyTransaction) error
+	GetByID(ctx context.Context, id string) (*IntercompanyTransaction, error)
+	ListByLegalEntity(ctx context.Context, legalEntityID string) ([]IntercompanyTransaction, error)
+}
+
+// ConsolidationGroupRepository stores consolidation groups and their member legal entities
+type ConsolidationGroupRepository interface {
+	Create(ctx context.Context, group *ConsolidationGroup, members []ConsolidationGroupMember) error
+	GetByID(ctx context.Context, id string) (*ConsolidationGroup, []ConsolidationGroupMember, error)
+	List(ctx context.Context) ([]ConsolidationGroup, error)
 }
 
 // TransactionManager defines an interface for running operations within a database transaction
@@ -153,9 +411,16 @@ type DepreciationScheduleLineRepository interface {
 	GetByAssetID(ctx context.Context, assetID string) ([]DepreciationScheduleLine, error)
 	GetUnpostedByPeriod(ctx context.Context, fiscalYear, periodNumber int) ([]DepreciationScheduleLine, error)
 	Update(ctx context.Context, line *DepreciationScheduleLine) error
+	Delete(ctx context.Context, id string) error
 	List(ctx context.Context) ([]DepreciationScheduleLine, error)
 }
 
+// AssetUsageRecordRepository defines operations for the usage driving units-of-production depreciation
+type AssetUsageRecordRepository interface {
+	Create(ctx context.Context, record *AssetUsageRecord) error
+	ListByAsset(ctx context.Context, assetID string) ([]AssetUsageRecord, error)
+}
+
 // KafkaEventInboxRepository defines operations for the Kafka event inbox
 type KafkaEventInboxRepository interface {
 	Create(ctx context.Context, record *KafkaEventInbox) error
@@ -3,12 +3,20 @@ package domain
 
 import (
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 type TaxRate struct {
-	ID       string          `json:"id"`
-	Code     string          `json:"code"`
-	Name     string          `json:"name"`
-	Rate     decimal.Decimal `json:"rate"`
-	IsActive bool            `json:"is_active"`
+	ID                   string          `json:"id"`
+	Code                 string          `json:"code"`
+	Name                 string          `json:"name"`
+	Rate                 decimal.Decimal `json:"rate"`
+	IsActive             bool            `json:"is_active"`
+	JurisdictionID       *string         `json:"jurisdiction_id,omitempty"` // Flat rates without a jurisdiction are not determined
+	TaxCategory          string          `json:"tax_category"`              // Product tax category; empty is the standard rate
+	IsCompound           bool            `json:"is_compound"`               // Levied on the net amount plus the taxes before it
+	Sequence             int             `json:"sequence"`                  // Order of the rate within its jurisdiction
+	LiabilityAccountCode string          `json:"liability_account_code"`
+	ValidFrom            *time.Time      `json:"valid_from,omitempty"` // Open bounds do not limit the rate
+	ValidTo              *time.Time      `json:"valid_to,omitempty"`
 }
@@ -13,6 +13,9 @@ type UniversalJournalEntry struct {
 	PostingDate      time.Time   `json:"posting_date"`
 	FinancialPeriod  string      `json:"financial_period"` // Format: "YYYY-MM"
 	Status           LedgerState `json:"status"`
+	TemplateID       *string     `json:"template_id,omitempty"`   // Recurring journal template the entry was generated from
+	ReversalDate     *time.Time  `json:"reversal_date,omitempty"` // Accruals are reversed automatically on this date
+	ReversalOfID     *string     `json:"reversal_of_id,omitempty"`
 	CreatedAt        time.Time   `json:"created_at"`
 	UpdatedAt        time.Time   `json:"updated_at"`
 }
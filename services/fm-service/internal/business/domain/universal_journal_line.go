@@ -12,5 +12,6 @@ type UniversalJournalLine struct {
 	AmountFunctional      decimal.Decimal `json:"amount_functional"`      // Stored natively in LegalEntity.functional_currency
 	AmountTransactional   decimal.Decimal `json:"amount_transactional"`   // Original currency value before conversion
 	CurrencyTransactional string          `json:"currency_transactional"` // ISO 4217 code of origin transaction
+	ExchangeRate          decimal.Decimal `json:"exchange_rate"`          // Transactional -> functional rate applied at posting
 	TrackingDimensions    interface{}     `json:"tracking_dimensions"`
 }
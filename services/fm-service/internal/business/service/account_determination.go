@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+)
+
+// postingDefault is the account a posting key falls back to when a legal entity has not
+// configured one. It is created in the chart of accounts on first use.
+type postingDefault struct {
+	code    string
+	name    string
+	accType domain.AccountType
+}
+
+var defaultPostingAccounts = map[domain.PostingKey]postingDefault{
+	domain.PostingKeyAR_CONTROL:              {"1100-001", "Accounts Receivable - Control", domain.AccountTypeASSET},
+	domain.PostingKeyAP_CONTROL:              {"2110-001", "Accounts Payable - Control", domain.AccountTypeLIABILITY},
+	domain.PostingKeyCASH_AT_BANK:            {"1010-001", "Cash at Bank", domain.AccountTypeASSET},
+	domain.PostingKeyPAYMENT_CLEARING:        {"1090-001", "Outgoing Payments Clearing", domain.AccountTypeASSET},
+	domain.PostingKeyTAX_LIABILITY:           {"2200-001", "Tax Payable", domain.AccountTypeLIABILITY},
+	domain.PostingKeyFX_UNREALIZED_GAIN:      {"7910-001", "Unrealized FX Gain", domain.AccountTypeREVENUE},
+	domain.PostingKeyFX_UNREALIZED_LOSS:      {"8910-001", "Unrealized FX Loss", domain.AccountTypeEXPENSE},
+	domain.PostingKeyFX_REALIZED_GAIN:        {"7920-001", "Realized FX Gain", domain.AccountTypeREVENUE},
+	domain.PostingKeyFX_REALIZED_LOSS:        {"8920-001", "Realized FX Loss", domain.AccountTypeEXPENSE},
+	domain.PostingKeyDUNNING_FEE_INCOME:      {"7940-001", "Late Fee Income", domain.AccountTypeREVENUE},
+	domain.PostingKeyDUNNING_INTEREST_INCOME: {"7950-001", "Late Payment Interest Income", domain.AccountTypeREVENUE},
+}
+
+// DetermineAccount returns the account a legal entity posts a key to: the account configured for
+// the key, else the default account, created if missing.
+func (s *GeneralLedgerService) DetermineAccount(ctx context.Context, legalEntityID string, key domain.PostingKey) (*domain.ChartOfAccounts, error) {
+	def, ok := defaultPostingAccounts[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown posting key %s", domain.ErrInvalidAccountDetermination, key)
+	}
+	determination, err := s.determinations.GetByKey(ctx, legalEntityID, key)
+	if errors.Is(err, domain.ErrAccountDeterminationNotFound) {
+		return s.EnsureAccount(ctx, legalEntityID, def.code, def.name, def.accType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.accounts.GetByCode(ctx, legalEntityID, determination.AccountCode)
+	if err != nil {
+		return nil, fmt.Errorf("account %s determined for %s: %w", determination.AccountCode, key, err)
+	}
+	return acc, nil
+}
+
+// SetAccountDetermination configures the account a legal entity posts a key to. The account must
+// already exist in the legal entity's chart of accounts and be active.
+func (s *GeneralLedgerService) SetAccountDetermination(ctx context.Context, legalEntityID string, key domain.PostingKey, accountCode string) (*domain.AccountDetermination, error) {
+	if legalEntityID == "" || accountCode == "" {
+		return nil, fmt.Errorf("%w: legal entity and account code are required", domain.ErrInvalidAccountDetermination)
+	}
+	if !key.IsValid() {
+		return nil, fmt.Errorf("%w: unknown posting key %s", domain.ErrInvalidAccountDetermination, key)
+	}
+
+	determination := &domain.AccountDetermination{
+		ID:            utils.NewID("adet"),
+		LegalEntityID: legalEntityID,
+		PostingKey:    key,
+		AccountCode:   accountCode,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		acc, err := s.accounts.GetByCode(txCtx, legalEntityID, accountCode)
+		if errors.Is(err, domain.ErrAccountNotFound) {
+			return fmt.Errorf("%w: account %s does not exist", domain.ErrInvalidAccountDetermination, accountCode)
+		}
+		if err != nil {
+			return err
+		}
+		if !acc.IsActive {
+			return fmt.Errorf("%w: account %s is inactive", domain.ErrInvalidAccountDetermination, accountCode)
+		}
+		return s.determinations.Upsert(txCtx, determination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return determination, nil
+}
+
+// ListAccountDeterminations returns the account of every posting key for a legal entity. Keys the
+// legal entity has not configured are listed with their default account code and no ID.
+func (s *GeneralLedgerService) ListAccountDeterminations(ctx context.Context, legalEntityID string) ([]domain.AccountDetermination, error) {
+	configured, err := s.determinations.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[domain.PostingKey]domain.AccountDetermination, len(configured))
+	for _, d := range configured {
+		byKey[d.PostingKey] = d
+	}
+
+	list := make([]domain.AccountDetermination, 0, len(postingKeys))
+	for _, key := range postingKeys {
+		if d, ok := byKey[key]; ok {
+			list = append(list, d)
+			continue
+		}
+		list = append(list, domain.AccountDetermination{
+			LegalEntityID: legalEntityID,
+			PostingKey:    key,
+			AccountCode:   defaultPostingAccounts[key].code,
+		})
+	}
+	return list, nil
+}
+
+// postingKeys lists the posting keys in the order they are reported
+var postingKeys = []domain.PostingKey{
+	domain.PostingKeyAR_CONTROL,
+	domain.PostingKeyAP_CONTROL,
+	domain.PostingKeyCASH_AT_BANK,
+	domain.PostingKeyPAYMENT_CLEARING,
+	domain.PostingKeyTAX_LIABILITY,
+	domain.PostingKeyFX_UNREALIZED_GAIN,
+	domain.PostingKeyFX_UNREALIZED_LOSS,
+	domain.PostingKeyFX_REALIZED_GAIN,
+	domain.PostingKeyFX_REALIZED_LOSS,
+	domain.PostingKeyDUNNING_FEE_INCOME,
+	domain.PostingKeyDUNNING_INTEREST_INCOME,
+}
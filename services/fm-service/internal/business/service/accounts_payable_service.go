@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"erp-system/shared/utils"
-	"errors"
 	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
@@ -11,52 +10,170 @@ import (
 )
 
 type AccountsPayableService struct {
-	bills  domain.ApVendorBillRepository
-	outbox domain.TransactionalOutboxRepository
-	tm     domain.TransactionManager
+	bills        domain.ApVendorBillRepository
+	billLines    domain.ApVendorBillLineRepository
+	poLines      domain.PurchaseOrderLineRepository
+	receiptLines domain.GoodsReceiptLineRepository
+	tolerances   domain.MatchToleranceRepository
+	fx           *CurrencyConverter
+	tax          *TaxService
+	outbox       domain.TransactionalOutboxRepository
+	tm           domain.TransactionManager
 }
 
 func NewAccountsPayableService(
 	bills domain.ApVendorBillRepository,
+	billLines domain.ApVendorBillLineRepository,
+	poLines domain.PurchaseOrderLineRepository,
+	receiptLines domain.GoodsReceiptLineRepository,
+	tolerances domain.MatchToleranceRepository,
+	fx *CurrencyConverter,
+	tax *TaxService,
 	outbox domain.TransactionalOutboxRepository,
 	tm domain.TransactionManager,
 ) *AccountsPayableService {
 	return &AccountsPayableService{
-		bills:  bills,
-		outbox: outbox,
-		tm:     tm,
+		bills:        bills,
+		billLines:    billLines,
+		poLines:      poLines,
+		receiptLines: receiptLines,
+		tolerances:   tolerances,
+		fx:           fx,
+		tax:          tax,
+		outbox:       outbox,
+		tm:           tm,
 	}
 }
 
-func (s *AccountsPayableService) MatchPurchaseOrder(ctx context.Context, billID, poID, goodsReceiptID string) (bool, error) {
-	if billID == "" || poID == "" || goodsReceiptID == "" {
-		return false, errors.New("bill ID, PO ID, and Goods Receipt ID are required for 3-way matching")
+// VendorBillRequest is a vendor bill whose input tax the tax engine determines from its lines
+type VendorBillRequest struct {
+	LegalEntityID    string           `json:"legal_entity_id"`
+	VendorID         string           `json:"vendor_id"`
+	BillNumber       string           `json:"bill_number"`
+	PurchaseOrderID  string           `json:"purchase_order_id"`
+	Currency         string           `json:"currency"`
+	ShipFrom         string           `json:"ship_from"`
+	ShipTo           string           `json:"ship_to"`
+	PricesIncludeTax bool             `json:"prices_include_tax"`
+	BillDate         time.Time        `json:"bill_date"` // Tax point; defaults to today
+	DueDate          time.Time        `json:"due_date"`
+	Lines            []TaxLineRequest `json:"lines"`
+
+	// Items are the billed materials matched against the purchase order and its receipts
+	Items []VendorBillLineRequest `json:"items,omitempty"`
+}
+
+// CreateVendorBill books a bill in the given currency; an empty currency means the legal
+// entity's functional currency. The booking rate is kept for FX revaluation and settlement.
+func (s *AccountsPayableService) CreateVendorBill(ctx context.Context, legalEntityID, vendorID, billNum, poID, currency string, dueDate time.Time, total, tax decimal.Decimal) (*domain.ApVendorBill, error) {
+	return s.CreateVendorBillWithLines(ctx, legalEntityID, vendorID, billNum, poID, currency, dueDate, total, tax, nil)
+}
+
+// CreateVendorBillWithLines books a bill together with its billed materials, so that a bill
+// against a purchase order is three-way matched line by line rather than on its net amount.
+func (s *AccountsPayableService) CreateVendorBillWithLines(ctx context.Context, legalEntityID, vendorID, billNum, poID, currency string, dueDate time.Time, total, tax decimal.Decimal, items []VendorBillLineRequest) (*domain.ApVendorBill, error) {
+	bill, err := s.newVendorBill(ctx, legalEntityID, vendorID, billNum, poID, currency, time.Now(), dueDate)
+	if err != nil {
+		return nil, err
 	}
-	return true, nil
+	bill.TotalAmount = total
+	bill.TaxAmount = tax
+
+	if err := s.saveVendorBill(ctx, bill, items, nil); err != nil {
+		return nil, err
+	}
+	return bill, nil
 }
 
-func (s *AccountsPayableService) CreateVendorBill(ctx context.Context, legalEntityID, vendorID, billNum, poID string, dueDate time.Time, total, tax decimal.Decimal) (*domain.ApVendorBill, error) {
-	id := utils.NewID("bill")
+// CreateVendorBillWithTax determines the input tax of the bill lines, books the bill at its gross
+// amount and posts the recoverable tax in the same transaction, all as of the bill date.
+func (s *AccountsPayableService) CreateVendorBillWithTax(ctx context.Context, req VendorBillRequest) (*domain.ApVendorBill, *TaxDetermination, error) {
+	if req.BillDate.IsZero() {
+		req.BillDate = time.Now()
+	}
+	det, err := s.tax.DetermineTax(ctx, TaxRequest{
+		Direction:        domain.TaxDirectionPURCHASE,
+		ShipFrom:         req.ShipFrom,
+		ShipTo:           req.ShipTo,
+		Date:             req.BillDate,
+		PricesIncludeTax: req.PricesIncludeTax,
+		Lines:            req.Lines,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bill, err := s.newVendorBill(ctx, req.LegalEntityID, req.VendorID, req.BillNumber, req.PurchaseOrderID, req.Currency, req.BillDate, req.DueDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	bill.TotalAmount = det.GrossAmount
+	bill.TaxAmount = det.TaxAmount
 
-	bill := &domain.ApVendorBill{
-		ID:              id,
+	err = s.saveVendorBill(ctx, bill, req.Items, func(txCtx context.Context) error {
+		_, err := s.tax.PostTax(txCtx, TaxPostingRequest{
+			LegalEntityID:    bill.LegalEntityID,
+			SourceDocumentID: bill.ID,
+			PostingDate:      req.BillDate,
+			Currency:         bill.Currency,
+			ExchangeRate:     bill.ExchangeRate,
+			Determination:    det,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return bill, det, nil
+}
+
+func (s *AccountsPayableService) newVendorBill(ctx context.Context, legalEntityID, vendorID, billNum, poID, currency string, billDate, dueDate time.Time) (*domain.ApVendorBill, error) {
+	currency, rate, err := s.fx.DocumentRate(ctx, legalEntityID, currency, billDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ApVendorBill{
+		ID:              utils.NewID("bill"),
 		LegalEntityID:   legalEntityID,
 		BillNumber:      billNum,
 		VendorID:        vendorID,
 		PurchaseOrderID: poID,
-		TotalAmount:     total,
-		TaxAmount:       tax,
+		Currency:        currency,
+		ExchangeRate:    rate,
 		DueDate:         dueDate,
 		Status:          domain.PaymentStatusOPEN,
+		MatchStatus:     domain.BillMatchStatusNOT_REQUIRED,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
-	}
+	}, nil
+}
 
-	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+// saveVendorBill stores the bill, its items and its payment-due event and three-way matches a bill
+// against a purchase order; post, if set, runs in the same transaction
+func (s *AccountsPayableService) saveVendorBill(ctx context.Context, bill *domain.ApVendorBill, items []VendorBillLineRequest, post func(txCtx context.Context) error) error {
+	lines, err := newVendorBillLines(bill.ID, items)
+	if err != nil {
+		return err
+	}
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
 		err := s.bills.Create(txCtx, bill)
 		if err != nil {
 			return err
 		}
+		if len(lines) > 0 {
+			if err := s.billLines.ReplaceForBill(txCtx, bill.ID, lines); err != nil {
+				return err
+			}
+		}
+		if _, err := s.matchVendorBill(txCtx, bill); err != nil {
+			return err
+		}
+		if post != nil {
+			if err := post(txCtx); err != nil {
+				return err
+			}
+		}
 
 		// Write to outbox
 		outboxRec := &domain.TransactionalOutbox{
@@ -76,12 +193,6 @@ func (s *AccountsPayableService) CreateVendorBill(ctx context.Context, legalEnti
 		}
 		return s.outbox.Create(txCtx, outboxRec)
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return bill, nil
 }
 
 func (s *AccountsPayableService) ListVendorBills(ctx context.Context) ([]domain.ApVendorBill, error) {
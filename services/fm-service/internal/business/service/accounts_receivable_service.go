@@ -11,23 +11,35 @@ import (
 )
 
 type AccountsReceivableService struct {
-	invoices domain.ArInvoiceRepository
-	credits  domain.CustomerCreditRepository
-	outbox   domain.TransactionalOutboxRepository
-	tm       domain.TransactionManager
+	invoices  domain.ArInvoiceRepository
+	credits   domain.CustomerCreditRepository
+	exposures domain.SalesOrderExposureRepository
+	overrides domain.CreditOverrideRepository
+	fx        *CurrencyConverter
+	tax       *TaxService
+	outbox    domain.TransactionalOutboxRepository
+	tm        domain.TransactionManager
 }
 
 func NewAccountsReceivableService(
 	invoices domain.ArInvoiceRepository,
 	credits domain.CustomerCreditRepository,
+	exposures domain.SalesOrderExposureRepository,
+	overrides domain.CreditOverrideRepository,
+	fx *CurrencyConverter,
+	tax *TaxService,
 	outbox domain.TransactionalOutboxRepository,
 	tm domain.TransactionManager,
 ) *AccountsReceivableService {
 	return &AccountsReceivableService{
-		invoices: invoices,
-		credits:  credits,
-		outbox:   outbox,
-		tm:       tm,
+		invoices:  invoices,
+		credits:   credits,
+		exposures: exposures,
+		overrides: overrides,
+		fx:        fx,
+		tax:       tax,
+		outbox:    outbox,
+		tm:        tm,
 	}
 }
 
@@ -35,29 +47,116 @@ func (s *AccountsReceivableService) ListInvoices(ctx context.Context) ([]domain.
 	return s.invoices.List(ctx)
 }
 
-func (s *AccountsReceivableService) CreateInvoice(ctx context.Context, legalEntityID, customerID, salesOrderID string, totalAmount, taxAmount decimal.Decimal, dueDate time.Time) (*domain.ArInvoice, error) {
-	id := utils.NewID("inv")
-	invNum := fmt.Sprintf("INV-%d", time.Now().Unix())
+// InvoiceRequest is an invoice whose tax the tax engine determines from its lines
+type InvoiceRequest struct {
+	LegalEntityID    string           `json:"legal_entity_id"`
+	CustomerID       string           `json:"customer_id"`
+	SalesOrderID     string           `json:"sales_order_id"`
+	Currency         string           `json:"currency"`
+	ShipFrom         string           `json:"ship_from"`
+	ShipTo           string           `json:"ship_to"`
+	PricesIncludeTax bool             `json:"prices_include_tax"`
+	InvoiceDate      time.Time        `json:"invoice_date"` // Tax point; defaults to today
+	DueDate          time.Time        `json:"due_date"`
+	Lines            []TaxLineRequest `json:"lines"`
+}
+
+// CreateInvoice books an invoice in the given currency; an empty currency means the legal
+// entity's functional currency. The booking rate is kept for FX revaluation and settlement.
+func (s *AccountsReceivableService) CreateInvoice(ctx context.Context, legalEntityID, customerID, salesOrderID, currency string, totalAmount, taxAmount decimal.Decimal, dueDate time.Time) (*domain.ArInvoice, error) {
+	inv, err := s.newInvoice(ctx, legalEntityID, customerID, salesOrderID, currency, time.Now(), dueDate)
+	if err != nil {
+		return nil, err
+	}
+	inv.TotalAmount = totalAmount
+	inv.TaxAmount = taxAmount
+
+	if err := s.saveInvoice(ctx, inv, nil); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// CreateInvoiceWithTax determines the sales tax of the invoice lines, books the invoice at its
+// gross amount and posts the tax to the liability accounts in the same transaction. Rates,
+// exemptions, the exchange rate and the tax posting all take the invoice date.
+func (s *AccountsReceivableService) CreateInvoiceWithTax(ctx context.Context, req InvoiceRequest) (*domain.ArInvoice, *TaxDetermination, error) {
+	if req.InvoiceDate.IsZero() {
+		req.InvoiceDate = time.Now()
+	}
+	det, err := s.tax.DetermineTax(ctx, TaxRequest{
+		Direction:        domain.TaxDirectionSALES,
+		CustomerID:       req.CustomerID,
+		ShipFrom:         req.ShipFrom,
+		ShipTo:           req.ShipTo,
+		Date:             req.InvoiceDate,
+		PricesIncludeTax: req.PricesIncludeTax,
+		Lines:            req.Lines,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inv, err := s.newInvoice(ctx, req.LegalEntityID, req.CustomerID, req.SalesOrderID, req.Currency, req.InvoiceDate, req.DueDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	inv.TotalAmount = det.GrossAmount
+	inv.TaxAmount = det.TaxAmount
 
-	inv := &domain.ArInvoice{
-		ID:            id,
+	err = s.saveInvoice(ctx, inv, func(txCtx context.Context) error {
+		_, err := s.tax.PostTax(txCtx, TaxPostingRequest{
+			LegalEntityID:    inv.LegalEntityID,
+			SourceDocumentID: inv.ID,
+			PostingDate:      req.InvoiceDate,
+			Currency:         inv.Currency,
+			ExchangeRate:     inv.ExchangeRate,
+			Determination:    det,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return inv, det, nil
+}
+
+func (s *AccountsReceivableService) newInvoice(ctx context.Context, legalEntityID, customerID, salesOrderID, currency string, invoiceDate, dueDate time.Time) (*domain.ArInvoice, error) {
+	currency, rate, err := s.fx.DocumentRate(ctx, legalEntityID, currency, invoiceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ArInvoice{
+		ID:            utils.NewID("inv"),
 		LegalEntityID: legalEntityID,
-		InvoiceNumber: invNum,
+		InvoiceNumber: fmt.Sprintf("INV-%d", time.Now().Unix()),
 		CustomerID:    customerID,
 		SalesOrderID:  salesOrderID,
-		TotalAmount:   totalAmount,
-		TaxAmount:     taxAmount,
+		Currency:      currency,
+		ExchangeRate:  rate,
 		DueDate:       dueDate,
 		Status:        domain.PaymentStatusOPEN,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
-	}
+	}, nil
+}
 
-	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+// saveInvoice stores the invoice and its created event; post, if set, runs in the same transaction
+func (s *AccountsReceivableService) saveInvoice(ctx context.Context, inv *domain.ArInvoice, post func(txCtx context.Context) error) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
 		err := s.invoices.Create(txCtx, inv)
 		if err != nil {
 			return err
 		}
+		if err := s.invoiceSalesOrder(txCtx, inv); err != nil {
+			return err
+		}
+		if post != nil {
+			if err := post(txCtx); err != nil {
+				return err
+			}
+		}
 
 		// Write to outbox
 		outboxRec := &domain.TransactionalOutbox{
@@ -77,12 +176,6 @@ func (s *AccountsReceivableService) CreateInvoice(ctx context.Context, legalEnti
 		}
 		return s.outbox.Create(txCtx, outboxRec)
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return inv, nil
 }
 
 func (s *AccountsReceivableService) GetInvoice(ctx context.Context, id string) (*domain.ArInvoice, error) {
@@ -179,45 +272,45 @@ func (s *AccountsReceivableService) SendInvoice(ctx context.Context, id string)
 	return true, nil
 }
 
-func (s *AccountsReceivableService) CheckCustomerCredit(ctx context.Context, customerID string, orderValue decimal.Decimal) (bool, error) {
-	limit := decimal.NewFromInt(100000)
-	if orderValue.GreaterThan(limit) {
-		return false, nil
-	}
-	return true, nil
-}
-
+// MarkInvoiceOverdue moves an unpaid invoice into OVERDUE. Dunning runs do the same for every
+// invoice past its due date; payments later move it on to PARTIAL or PAID.
 func (s *AccountsReceivableService) MarkInvoiceOverdue(ctx context.Context, id string) error {
 	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
 		inv, err := s.invoices.GetByID(txCtx, id)
 		if err != nil {
 			return err
 		}
+		return s.markOverdue(txCtx, inv)
+	})
+}
 
-		inv.Status = domain.PaymentStatusOPEN // or define an overdue status if in domain. But in CDD PaymentStatus is OPEN, PARTIAL, PAID.
-		inv.UpdatedAt = time.Now()
-		err = s.invoices.Update(txCtx, inv)
-		if err != nil {
-			return err
-		}
+func (s *AccountsReceivableService) markOverdue(ctx context.Context, inv *domain.ArInvoice) error {
+	if inv.Status == domain.PaymentStatusPAID {
+		return fmt.Errorf("%w: %s", domain.ErrInvoiceAlreadyPaid, inv.InvoiceNumber)
+	}
+	inv.Status = domain.PaymentStatusOVERDUE
+	inv.UpdatedAt = time.Now()
+	if err := s.invoices.Update(ctx, inv); err != nil {
+		return err
+	}
+	return s.writeInvoiceEvent(ctx, domain.TopicFmInvoiceOverdue, inv)
+}
 
-		// Write to outbox
-		outboxRec := &domain.TransactionalOutbox{
-			ID:          utils.NewID("outbox"),
-			EventType:   string(domain.TopicFmInvoiceOverdue),
-			AggregateID: inv.ID,
-			Payload: domain.InvoiceEventPayload{
-				ID:            inv.ID,
-				CustomerID:    inv.CustomerID,
-				InvoiceNumber: inv.InvoiceNumber,
-				TotalAmount:   inv.TotalAmount,
-				Status:        string(inv.Status),
-				Timestamp:     time.Now(),
-			},
-			Status:    domain.OutboxStatusPENDING,
-			CreatedAt: time.Now(),
-		}
-		return s.outbox.Create(txCtx, outboxRec)
+func (s *AccountsReceivableService) writeInvoiceEvent(ctx context.Context, topic string, inv *domain.ArInvoice) error {
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: inv.ID,
+		Payload: domain.InvoiceEventPayload{
+			ID:            inv.ID,
+			CustomerID:    inv.CustomerID,
+			InvoiceNumber: inv.InvoiceNumber,
+			TotalAmount:   inv.TotalAmount,
+			Status:        string(inv.Status),
+			Timestamp:     time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
 	})
 }
 
@@ -239,4 +332,3 @@ func (s *AccountsReceivableService) GetCustomerCredit(ctx context.Context, custo
 	}
 	return cc, nil
 }
-
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// AgingBucket names a days-past-due band. Documents not yet due age in the first band.
+type AgingBucket string
+
+const (
+	AgingBucket0To30  AgingBucket = "0-30"
+	AgingBucket31To60 AgingBucket = "31-60"
+	AgingBucket61To90 AgingBucket = "61-90"
+	AgingBucketOver90 AgingBucket = "90+"
+)
+
+// AgingRequest selects the open items of one legal entity, optionally for a single customer
+// or vendor. AsOf defaults to today.
+type AgingRequest struct {
+	LegalEntityID  string
+	CounterpartyID string
+	AsOf           time.Time
+}
+
+// AgingTotals holds functional-currency open amounts per bucket.
+type AgingTotals struct {
+	Days0To30  decimal.Decimal `json:"days_0_30"`
+	Days31To60 decimal.Decimal `json:"days_31_60"`
+	Days61To90 decimal.Decimal `json:"days_61_90"`
+	Over90     decimal.Decimal `json:"over_90"`
+	Total      decimal.Decimal `json:"total"`
+}
+
+func (t *AgingTotals) add(bucket AgingBucket, amount decimal.Decimal) {
+	switch bucket {
+	case AgingBucket0To30:
+		t.Days0To30 = t.Days0To30.Add(amount)
+	case AgingBucket31To60:
+		t.Days31To60 = t.Days31To60.Add(amount)
+	case AgingBucket61To90:
+		t.Days61To90 = t.Days61To90.Add(amount)
+	default:
+		t.Over90 = t.Over90.Add(amount)
+	}
+	t.Total = t.Total.Add(amount)
+}
+
+// AgedDocument is one open invoice or bill in an aging report.
+type AgedDocument struct {
+	DocumentID       string          `json:"document_id"`
+	DocumentNumber   string          `json:"document_number"`
+	DueDate          time.Time       `json:"due_date"`
+	DaysPastDue      int             `json:"days_past_due"`
+	Bucket           AgingBucket     `json:"bucket"`
+	Currency         string          `json:"currency"`
+	OpenAmount       decimal.Decimal `json:"open_amount"`
+	FunctionalAmount decimal.Decimal `json:"functional_amount"`
+}
+
+// CounterpartyAging ages the open items of one customer or vendor. Unapplied credits are
+// reported next to the buckets, in document currency, rather than netted against them.
+type CounterpartyAging struct {
+	CounterpartyID   string          `json:"counterparty_id"`
+	Buckets          AgingTotals     `json:"buckets"`
+	UnappliedCredits decimal.Decimal `json:"unapplied_credits"`
+	Documents        []AgedDocument  `json:"documents"`
+}
+
+type AgingReport struct {
+	LegalEntityID    string                  `json:"legal_entity_id"`
+	CounterpartyType domain.CounterpartyType `json:"counterparty_type"`
+	AsOf             time.Time               `json:"as_of"`
+	Counterparties   []CounterpartyAging     `json:"counterparties"`
+	Totals           AgingTotals             `json:"totals"`
+	UnappliedCredits decimal.Decimal         `json:"unapplied_credits"`
+}
+
+// GetReceivablesAging buckets the open customer invoices of a legal entity by days past due.
+func (s *CashManagementService) GetReceivablesAging(ctx context.Context, req AgingRequest) (*AgingReport, error) {
+	report, err := s.newAgingReport(req, domain.CounterpartyTypeCUSTOMER)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := s.invoices.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parties := make(map[string]*CounterpartyAging)
+	for _, inv := range invoices {
+		if inv.LegalEntityID != req.LegalEntityID || (req.CounterpartyID != "" && inv.CustomerID != req.CounterpartyID) {
+			continue
+		}
+		open := openItem{invoice: &inv}.openAmount()
+		if !open.IsPositive() {
+			continue
+		}
+		agingParty(parties, inv.CustomerID).addDocument(report.AsOf, AgedDocument{
+			DocumentID:       inv.ID,
+			DocumentNumber:   inv.InvoiceNumber,
+			DueDate:          inv.DueDate,
+			Currency:         inv.Currency,
+			OpenAmount:       open,
+			FunctionalAmount: agingFunctionalAmount(open, inv.ExchangeRate),
+		})
+	}
+	if err := s.finishAgingReport(ctx, report, req, parties); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetPayablesAging buckets the open vendor bills of a legal entity by days past due.
+func (s *CashManagementService) GetPayablesAging(ctx context.Context, req AgingRequest) (*AgingReport, error) {
+	report, err := s.newAgingReport(req, domain.CounterpartyTypeVENDOR)
+	if err != nil {
+		return nil, err
+	}
+	bills, err := s.bills.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parties := make(map[string]*CounterpartyAging)
+	for _, bill := range bills {
+		if bill.LegalEntityID != req.LegalEntityID || (req.CounterpartyID != "" && bill.VendorID != req.CounterpartyID) {
+			continue
+		}
+		open := openItem{bill: &bill}.openAmount()
+		if !open.IsPositive() {
+			continue
+		}
+		agingParty(parties, bill.VendorID).addDocument(report.AsOf, AgedDocument{
+			DocumentID:       bill.ID,
+			DocumentNumber:   bill.BillNumber,
+			DueDate:          bill.DueDate,
+			Currency:         bill.Currency,
+			OpenAmount:       open,
+			FunctionalAmount: agingFunctionalAmount(open, bill.ExchangeRate),
+		})
+	}
+	if err := s.finishAgingReport(ctx, report, req, parties); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *CashManagementService) newAgingReport(req AgingRequest, counterpartyType domain.CounterpartyType) (*AgingReport, error) {
+	if req.LegalEntityID == "" {
+		return nil, fmt.Errorf("%w: legal entity is required", domain.ErrInvalidAgingRequest)
+	}
+	if req.AsOf.IsZero() {
+		req.AsOf = time.Now()
+	}
+	return &AgingReport{
+		LegalEntityID:    req.LegalEntityID,
+		CounterpartyType: counterpartyType,
+		AsOf:             time.Date(req.AsOf.Year(), req.AsOf.Month(), req.AsOf.Day(), 0, 0, 0, 0, time.UTC),
+		Counterparties:   []CounterpartyAging{},
+	}, nil
+}
+
+// finishAgingReport adds unapplied on-account credits, sorts the rows and totals the buckets.
+func (s *CashManagementService) finishAgingReport(ctx context.Context, report *AgingReport, req AgingRequest, parties map[string]*CounterpartyAging) error {
+	credits, err := s.ListOnAccountCredits(ctx, report.CounterpartyType, req.CounterpartyID, true)
+	if err != nil {
+		return err
+	}
+	for _, c := range credits {
+		if c.LegalEntityID != req.LegalEntityID {
+			continue
+		}
+		party := agingParty(parties, c.CounterpartyID)
+		party.UnappliedCredits = party.UnappliedCredits.Add(c.RemainingAmount)
+	}
+
+	for _, party := range parties {
+		sort.Slice(party.Documents, func(i, j int) bool { return party.Documents[i].DueDate.Before(party.Documents[j].DueDate) })
+		report.Counterparties = append(report.Counterparties, *party)
+		report.Totals.Days0To30 = report.Totals.Days0To30.Add(party.Buckets.Days0To30)
+		report.Totals.Days31To60 = report.Totals.Days31To60.Add(party.Buckets.Days31To60)
+		report.Totals.Days61To90 = report.Totals.Days61To90.Add(party.Buckets.Days61To90)
+		report.Totals.Over90 = report.Totals.Over90.Add(party.Buckets.Over90)
+		report.Totals.Total = report.Totals.Total.Add(party.Buckets.Total)
+		report.UnappliedCredits = report.UnappliedCredits.Add(party.UnappliedCredits)
+	}
+	sort.Slice(report.Counterparties, func(i, j int) bool {
+		return report.Counterparties[i].CounterpartyID < report.Counterparties[j].CounterpartyID
+	})
+	return nil
+}
+
+func agingParty(parties map[string]*CounterpartyAging, id string) *CounterpartyAging {
+	party, ok := parties[id]
+	if !ok {
+		party = &CounterpartyAging{CounterpartyID: id, Documents: []AgedDocument{}}
+		parties[id] = party
+	}
+	return party
+}
+
+func (p *CounterpartyAging) addDocument(asOf time.Time, doc AgedDocument) {
+	due := time.Date(doc.DueDate.Year(), doc.DueDate.Month(), doc.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+	if days := int(asOf.Sub(due).Hours() / 24); days > 0 {
+		doc.DaysPastDue = days
+	}
+	doc.Bucket = agingBucket(doc.DaysPastDue)
+	p.Buckets.add(doc.Bucket, doc.FunctionalAmount)
+	p.Documents = append(p.Documents, doc)
+}
+
+func agingBucket(daysPastDue int) AgingBucket {
+	switch {
+	case daysPastDue <= 30:
+		return AgingBucket0To30
+	case daysPastDue <= 60:
+		return AgingBucket31To60
+	case daysPastDue <= 90:
+		return AgingBucket61To90
+	default:
+		return AgingBucketOver90
+	}
+}
+
+// agingFunctionalAmount converts at the booking rate; documents without one are already functional.
+func agingFunctionalAmount(open, rate decimal.Decimal) decimal.Decimal {
+	if !rate.IsPositive() {
+		return open
+	}
+	return convertAmount(open, rate)
+}
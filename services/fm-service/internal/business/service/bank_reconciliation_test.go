@@ -0,0 +1,415 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func seedPayment(t *testing.T, payments *memory.MemoryPaymentRepo, id, number string, amount int64, date time.Time, invoiceID, billID string) {
+	t.Helper()
+	bank := "ba_1"
+	p := &domain.Payment{
+		ID: id, PaymentNumber: number, PaymentDate: date, Amount: decimal.NewFromInt(amount),
+		PaymentMethod: "WIRE", Status: "COMPLETED", BankAccountID: &bank,
+		CreatedAt: date, UpdatedAt: date,
+	}
+	if invoiceID != "" {
+		p.InvoiceID = &invoiceID
+	}
+	if billID != "" {
+		p.BillID = &billID
+	}
+	if err := payments.Create(context.Background(), p); err != nil {
+		t.Fatalf("seed payment: %v", err)
+	}
+}
+
+func TestReconcileBankStatement_MatchesOneToOneAndGroups(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	matches := memory.NewMemoryBankReconciliationMatchRepo()
+	exceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, statements, matches, exceptions, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		Matches:      matches,
+		Exceptions:   exceptions,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	// 1:1 incoming with reference, 1:1 outgoing bill payment
+	seedPayment(t, payments, "pay_1", "PAY-001", 500, day, "inv_1", "")
+	seedPayment(t, payments, "pay_2", "PAY-002", 200, day.AddDate(0, 0, 1), "", "bill_1")
+	// 1:N batched deposit settling two invoices
+	seedPayment(t, payments, "pay_3", "PAY-003", 70, day, "inv_2", "")
+	seedPayment(t, payments, "pay_4", "PAY-004", 30, day, "inv_3", "")
+	// N:1 payment split across two statement lines
+	seedPayment(t, payments, "pay_5", "PAY-005", 900, day, "inv_4", "")
+
+	stmt := &domain.BankStatement{ID: "stmt_1", BankAccountID: "ba_1", StatementDate: day}
+	lines := []domain.BankStatementLine{
+		{ID: "l1", StatementID: "stmt_1", TransactionDate: day, Description: "Transfer ref PAY-001", Amount: decimal.NewFromInt(500)},
+		{ID: "l2", StatementID: "stmt_1", TransactionDate: day.AddDate(0, 0, 2), Description: "Supplier bill_1", Amount: decimal.NewFromInt(-200)},
+		{ID: "l3", StatementID: "stmt_1", TransactionDate: day, Description: "Batch deposit", Amount: decimal.NewFromInt(100)},
+		{ID: "l4", StatementID: "stmt_1", TransactionDate: day, Description: "PAY-005 part 1", Amount: decimal.NewFromInt(600)},
+		{ID: "l5", StatementID: "stmt_1", TransactionDate: day, Description: "PAY-005 part 2", Amount: decimal.NewFromInt(300)},
+		{ID: "l6", StatementID: "stmt_1", TransactionDate: day, Description: "Bank fee", Amount: decimal.NewFromInt(-15)},
+	}
+	_ = statements.Create(ctx, stmt, lines)
+
+	result, err := svc.ReconcileBankStatement(ctx, "stmt_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MatchedLines != 5 || result.UnmatchedLines != 1 || result.IsReconciled {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	types := map[string]domain.ReconciliationMatchType{}
+	for _, m := range result.Matches {
+		types[m.StatementLineID] = m.MatchType
+		if m.ConfidenceScore.LessThan(decimal.NewFromFloat(0.6)) || m.ConfidenceScore.GreaterThan(decimal.NewFromInt(1)) {
+			t.Errorf("confidence out of range for %s: %s", m.StatementLineID, m.ConfidenceScore)
+		}
+	}
+	if types["l1"] != domain.ReconciliationMatchTypeONE_TO_ONE || types["l2"] != domain.ReconciliationMatchTypeONE_TO_ONE {
+		t.Errorf("expected 1:1 matches for l1/l2, got %v", types)
+	}
+	if types["l3"] != domain.ReconciliationMatchTypeONE_TO_MANY {
+		t.Errorf("expected 1:N match for l3, got %s", types["l3"])
+	}
+	if types["l4"] != domain.ReconciliationMatchTypeMANY_TO_ONE || types["l5"] != domain.ReconciliationMatchTypeMANY_TO_ONE {
+		t.Errorf("expected N:1 match for l4/l5, got %v", types)
+	}
+
+	if len(result.Exceptions) != 1 || result.Exceptions[0].StatementLineID != "l6" {
+		t.Fatalf("expected bank fee line queued as exception, got %+v", result.Exceptions)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 100)
+	published := false
+	for _, rec := range pending {
+		if rec.EventType == string(domain.TopicFmBankStatementReconciled) && rec.AggregateID == "stmt_1" {
+			published = true
+		}
+	}
+	if !published {
+		t.Error("expected bank statement reconciled event in outbox")
+	}
+
+	_, storedLines, _ := statements.GetByID(ctx, "stmt_1")
+	for _, l := range storedLines {
+		if l.IsMatched != (l.ID != "l6") {
+			t.Errorf("line %s matched=%v", l.ID, l.IsMatched)
+		}
+	}
+
+	// Re-running is idempotent: no duplicate exception, no new matches
+	again, err := svc.ReconcileBankStatement(ctx, "stmt_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(again.Matches) != 0 || len(again.Exceptions) != 1 {
+		t.Errorf("expected rerun to be a no-op, got %+v", again)
+	}
+	open, _ := svc.ListReconciliationExceptions(ctx, "", string(domain.ReconciliationExceptionStatusOPEN))
+	if len(open) != 1 {
+		t.Errorf("expected 1 open exception, got %d", len(open))
+	}
+}
+
+func TestReconcileBankStatement_DateWindowAndConfidence(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	matches := memory.NewMemoryBankReconciliationMatchRepo()
+	exceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, statements, matches, exceptions, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		Matches:      matches,
+		Exceptions:   exceptions,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	seedPayment(t, payments, "pay_far", "PAY-100", 250, day.AddDate(0, 0, -20), "", "")
+
+	_ = statements.Create(ctx, &domain.BankStatement{ID: "stmt_2", BankAccountID: "ba_1"}, []domain.BankStatementLine{
+		{ID: "l1", StatementID: "stmt_2", TransactionDate: day, Description: "Unknown", Amount: decimal.NewFromInt(250)},
+	})
+
+	result, err := svc.ReconcileBankStatement(ctx, "stmt_2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Matches) != 0 || len(result.Exceptions) != 1 {
+		t.Fatalf("expected the out-of-window payment to stay unmatched, got %+v", result)
+	}
+	if result.Exceptions[0].Reason != "payment with matching amount found outside date window" {
+		t.Errorf("unexpected reason: %s", result.Exceptions[0].Reason)
+	}
+
+	// Widening the window lets the engine match it, but without a reference the score is low
+	opts := service.DefaultReconciliationOptions()
+	opts.DateWindowDays = 30
+	opts.MinConfidence = decimal.NewFromFloat(0.9)
+	svc.SetReconciliationOptions(opts)
+	result, _ = svc.ReconcileBankStatement(ctx, "stmt_2")
+	if len(result.Matches) != 0 || result.Exceptions[0].Reason != "candidate payment found below confidence threshold" {
+		t.Errorf("expected low-confidence candidate to be rejected, got %+v", result)
+	}
+
+	opts.MinConfidence = decimal.NewFromFloat(0.5)
+	svc.SetReconciliationOptions(opts)
+	result, _ = svc.ReconcileBankStatement(ctx, "stmt_2")
+	if len(result.Matches) != 1 || !result.IsReconciled {
+		t.Errorf("expected match once threshold is lowered, got %+v", result)
+	}
+	resolved, _ := svc.ListReconciliationExceptions(ctx, "", string(domain.ReconciliationExceptionStatusRESOLVED))
+	if len(resolved) != 1 {
+		t.Errorf("expected exception to be resolved by the match, got %d", len(resolved))
+	}
+}
+
+func TestReconcileBankStatement_OnlyMatchesPaymentsOfTheAccount(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	matches := memory.NewMemoryBankReconciliationMatchRepo()
+	exceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, statements, matches, exceptions, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     invoices,
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		Matches:      matches,
+		Exceptions:   exceptions,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_own", LegalEntityID: "legal_123", InvoiceNumber: "INV-1", TotalAmount: decimal.NewFromInt(100), Currency: "EUR"})
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_other", LegalEntityID: "legal_999", InvoiceNumber: "INV-2", TotalAmount: decimal.NewFromInt(100), Currency: "EUR"})
+	other := "ba_2"
+	inOther, inOwn := "inv_other", "inv_own"
+	for _, p := range []domain.Payment{
+		// Same amount and date, but through another bank account or for another legal entity
+		{ID: "pay_1", PaymentNumber: "PAY-001", BankAccountID: &other},
+		{ID: "pay_2", PaymentNumber: "PAY-002", InvoiceID: &inOther},
+		// No bank account recorded, settles an invoice of the account's legal entity
+		{ID: "pay_3", PaymentNumber: "PAY-003", InvoiceID: &inOwn},
+	} {
+		p := p
+		p.PaymentDate, p.Amount, p.PaymentMethod, p.Status = day, decimal.NewFromInt(100), "WIRE", "COMPLETED"
+		_ = payments.Create(ctx, &p)
+	}
+	_ = statements.Create(ctx, &domain.BankStatement{ID: "stmt_5", BankAccountID: "ba_1"}, []domain.BankStatementLine{
+		{ID: "l1", StatementID: "stmt_5", TransactionDate: day, Description: "Incoming transfer", Amount: decimal.NewFromInt(100)},
+	})
+
+	result, err := svc.ReconcileBankStatement(ctx, "stmt_5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].PaymentID != "pay_3" {
+		t.Errorf("expected only pay_3 to match, got %+v", result.Matches)
+	}
+}
+
+func TestManualMatchAndUnmatch(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	matches := memory.NewMemoryBankReconciliationMatchRepo()
+	exceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, statements, matches, exceptions, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		Matches:      matches,
+		Exceptions:   exceptions,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	seedPayment(t, payments, "pay_1", "PAY-001", 100, day.AddDate(0, -1, 0), "", "")
+	seedPayment(t, payments, "pay_2", "PAY-002", 40, day.AddDate(0, -1, 0), "", "")
+	seedPayment(t, payments, "pay_3", "PAY-003", 50, day.AddDate(0, -1, 0), "", "")
+	other := "ba_2"
+	_ = payments.Create(ctx, &domain.Payment{ID: "pay_4", PaymentNumber: "PAY-004", PaymentDate: day, Amount: decimal.NewFromInt(100), PaymentMethod: "WIRE", Status: "COMPLETED", BankAccountID: &other})
+	_ = statements.Create(ctx, &domain.BankStatement{ID: "stmt_3", BankAccountID: "ba_1"}, []domain.BankStatementLine{
+		{ID: "l1", StatementID: "stmt_3", TransactionDate: day, Amount: decimal.NewFromInt(100)},
+	})
+
+	if _, err := svc.ManualMatch(ctx, "stmt_3", []string{"l1"}, []string{"pay_1", "pay_2"}); !errors.Is(err, domain.ErrReconciliationAmountMismatch) {
+		t.Fatalf("expected amount mismatch, got %v", err)
+	}
+	// A payment listed twice does not count twice
+	if _, err := svc.ManualMatch(ctx, "stmt_3", []string{"l1"}, []string{"pay_3", "pay_3"}); err == nil {
+		t.Error("expected error for a duplicate payment")
+	}
+	if _, err := svc.ManualMatch(ctx, "stmt_3", []string{"l1", "l1"}, []string{"pay_1"}); err == nil {
+		t.Error("expected error for a duplicate statement line")
+	}
+	// Payments made through another bank account cannot clear this statement
+	if _, err := svc.ManualMatch(ctx, "stmt_3", []string{"l1"}, []string{"pay_4"}); !errors.Is(err, domain.ErrPaymentNotOnBankAccount) {
+		t.Errorf("expected payment not on bank account, got %v", err)
+	}
+
+	rows, err := svc.ManualMatch(ctx, "stmt_3", []string{"l1"}, []string{"pay_1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0].MatchType != domain.ReconciliationMatchTypeMANUAL || !rows[0].ConfidenceScore.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("unexpected manual match rows: %+v", rows)
+	}
+	stmt, _, _ := statements.GetByID(ctx, "stmt_3")
+	if !stmt.IsReconciled {
+		t.Error("expected statement to be reconciled after manual match")
+	}
+
+	if _, err := svc.ManualMatch(ctx, "stmt_3", []string{"l1"}, []string{"pay_2"}); !errors.Is(err, domain.ErrStatementLineAlreadyMatched) {
+		t.Errorf("expected already matched error, got %v", err)
+	}
+
+	if err := svc.UnmatchGroup(ctx, "stmt_3", rows[0].MatchGroupID); err != nil {
+		t.Fatalf("unexpected error unmatching: %v", err)
+	}
+	stmt, lines, _ := statements.GetByID(ctx, "stmt_3")
+	if stmt.IsReconciled || lines[0].IsMatched {
+		t.Error("expected line to be released after unmatch")
+	}
+	open, _ := svc.ListReconciliationExceptions(ctx, "", string(domain.ReconciliationExceptionStatusOPEN))
+	if len(open) != 1 {
+		t.Errorf("expected released line in exceptions queue, got %d", len(open))
+	}
+
+	if err := svc.UnmatchGroup(ctx, "stmt_3", "missing"); err == nil {
+		t.Error("expected error unmatching unknown group")
+	}
+}
+
+func TestPostExceptionToLedger(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	matches := memory.NewMemoryBankReconciliationMatchRepo()
+	exceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, statements, matches, exceptions, accounts, entries, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), service.NewCurrencyConverter(legalEntities, rates), outbox, tm)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		Matches:      matches,
+		Exceptions:   exceptions,
+		GL:           gl,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	// The EUR bank account belongs to a USD legal entity
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "legal_123", CompanyCode: "US", FunctionalCurrency: "USD"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: day.AddDate(0, -1, 0)})
+
+	for _, acc := range []domain.ChartOfAccounts{
+		{ID: "acc_bank", AccountCode: "1010", AccountName: "Bank", Type: domain.AccountTypeASSET, IsActive: true},
+		{ID: "acc_fees", AccountCode: "6100", AccountName: "Bank Fees", Type: domain.AccountTypeEXPENSE, IsActive: true},
+	} {
+		a := acc
+		_ = accounts.Create(ctx, &a)
+	}
+
+	_ = statements.Create(ctx, &domain.BankStatement{ID: "stmt_4", BankAccountID: "ba_1"}, []domain.BankStatementLine{
+		{ID: "l1", StatementID: "stmt_4", TransactionDate: day, Description: "Monthly fee", Amount: decimal.NewFromInt(-15)},
+	})
+	result, err := svc.ReconcileBankStatement(ctx, "stmt_4")
+	if err != nil || len(result.Exceptions) != 1 {
+		t.Fatalf("expected one exception, got %+v, %v", result, err)
+	}
+	excID := result.Exceptions[0].ID
+
+	if _, err := svc.PostExceptionToLedger(ctx, excID, "", "acc_fees"); err == nil {
+		t.Error("expected error for a bank account without a GL account")
+	}
+
+	// The bank account's GL account takes the bank side
+	ba, _ := bankAccounts.GetByID(ctx, "ba_1")
+	glAccount := "acc_bank"
+	ba.GlAccountID = &glAccount
+	_ = bankAccounts.Update(ctx, ba)
+
+	exc, err := svc.PostExceptionToLedger(ctx, excID, "", "acc_fees")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exc.Status != domain.ReconciliationExceptionStatusRESOLVED || exc.JournalEntryID == nil || exc.ResolvedAt == nil {
+		t.Fatalf("unexpected exception state: %+v", exc)
+	}
+
+	entry, jeLines, err := entries.GetByID(ctx, *exc.JournalEntryID)
+	if err != nil {
+		t.Fatalf("journal entry not found: %v", err)
+	}
+	if entry.LegalEntityID != "legal_123" || entry.SourceDocumentID != "l1" || len(jeLines) != 2 {
+		t.Errorf("unexpected journal entry: %+v", entry)
+	}
+	for _, l := range jeLines {
+		// The statement amount is in the bank account's currency and is converted at the day's rate
+		if l.AccountID == "acc_bank" && (!l.AmountTransactional.Equal(decimal.NewFromInt(-15)) || !l.AmountFunctional.Equal(decimal.RequireFromString("-16.5"))) {
+			t.Errorf("expected bank credited by 15 EUR (16.50 USD), got %s / %s", l.AmountTransactional, l.AmountFunctional)
+		}
+		if l.CurrencyTransactional != "EUR" {
+			t.Errorf("expected bank account currency, got %s", l.CurrencyTransactional)
+		}
+	}
+
+	stmt, _, _ := statements.GetByID(ctx, "stmt_4")
+	if !stmt.IsReconciled {
+		t.Error("expected statement reconciled once the exception is cleared")
+	}
+
+	if _, err := svc.PostExceptionToLedger(ctx, excID, "acc_bank", "acc_fees"); !errors.Is(err, domain.ErrReconciliationExceptionClosed) {
+		t.Errorf("expected closed exception error, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+const importMT940 = `:20:STMT1
+:25:DE001
+:28C:7/1
+:60F:C260309EUR100,00
+:61:260310C40,00NTRFPAY-001
+:86:Customer transfer
+:61:260310D15,00NCHGNONREF
+:86:Bank fee
+:62F:C260310EUR125,00
+-`
+
+func TestImportBankStatements(t *testing.T) {
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	tm := memory.NewMemoryTransactionManager(statements)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+
+	res, err := svc.ImportBankStatements(ctx, "ba_1", "", []byte(importMT940))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Format != "MT940" || len(res.Statements) != 1 || res.LinesImported != 2 {
+		t.Fatalf("unexpected import result: %+v", res)
+	}
+
+	stmt, lines, err := statements.GetByID(ctx, res.Statements[0].ID)
+	if err != nil {
+		t.Fatalf("statement not stored: %v", err)
+	}
+	if stmt.BankAccountID != "ba_1" || stmt.StatementReference != "STMT1/7/1" || stmt.SourceFormat != "MT940" {
+		t.Errorf("unexpected statement: %+v", stmt)
+	}
+	if !stmt.OpeningBalance.Equal(decimal.NewFromInt(100)) || !stmt.EndingBalance.Equal(decimal.NewFromInt(125)) {
+		t.Errorf("unexpected balances: %s / %s", stmt.OpeningBalance, stmt.EndingBalance)
+	}
+	if len(lines) != 2 || lines[0].Reference != "PAY-001" || !lines[1].Amount.Equal(decimal.NewFromInt(-15)) {
+		t.Errorf("unexpected lines: %+v", lines)
+	}
+
+	// Importing the same file again is rejected
+	if _, err := svc.ImportBankStatements(ctx, "ba_1", "MT940", []byte(importMT940)); !errors.Is(err, domain.ErrDuplicateBankStatement) {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+}
+
+func TestImportBankStatements_Rejections(t *testing.T) {
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	tm := memory.NewMemoryTransactionManager(statements)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Statements:   statements,
+		BankAccounts: bankAccounts,
+		TM:           tm,
+	})
+	ctx := context.Background()
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR"})
+
+	// Per-line parse errors reject the file and are reported back
+	bad := ":20:STMT2\n:25:DE001\n:61:BROKEN\n:62F:C260310EUR0,\n-"
+	res, err := svc.ImportBankStatements(ctx, "ba_1", "MT940", []byte(bad))
+	if !errors.Is(err, domain.ErrInvalidBankStatementFile) {
+		t.Fatalf("expected invalid file error, got %v", err)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Line != 3 {
+		t.Errorf("expected error on line 3, got %+v", res.Errors)
+	}
+	if list, _ := statements.List(ctx); len(list) != 0 {
+		t.Errorf("expected nothing stored, got %d statements", len(list))
+	}
+
+	// Statement for another account
+	other := ":20:STMT3\n:25:XX999\n:62F:C260310EUR0,\n-"
+	if _, err := svc.ImportBankStatements(ctx, "ba_1", "MT940", []byte(other)); err == nil {
+		t.Error("expected account mismatch error")
+	}
+
+	// Currency mismatch
+	usd := ":20:STMT4\n:25:DE001\n:62F:C260310USD0,\n-"
+	if _, err := svc.ImportBankStatements(ctx, "ba_1", "MT940", []byte(usd)); err == nil {
+		t.Error("expected currency mismatch error")
+	}
+
+	// Unknown format, unknown bank account, empty file
+	if _, err := svc.ImportBankStatements(ctx, "ba_1", "CSV", []byte(importMT940)); err == nil {
+		t.Error("expected unsupported format error")
+	}
+	if _, err := svc.ImportBankStatements(ctx, "missing", "", []byte(importMT940)); err == nil {
+		t.Error("expected missing bank account error")
+	}
+	if _, err := svc.ImportBankStatements(ctx, "ba_1", "", nil); err == nil {
+		t.Error("expected empty file error")
+	}
+}
@@ -4,37 +4,91 @@ import (
 	"context"
 	"erp-system/shared/utils"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/shopspring/decimal"
 )
 
+// BudgetCheckRequest describes spend against the budget of an account and, optionally, a cost
+// center. Without a cost center any budget of the account in the period applies.
+type BudgetCheckRequest struct {
+	AccountID    string          `json:"account_id"`
+	CostCenterID string          `json:"cost_center_id,omitempty"`
+	FiscalYear   int             `json:"fiscal_year"`
+	Period       int             `json:"period"`
+	Amount       decimal.Decimal `json:"amount"`
+	// SourceDocumentID is the requisition or purchase order the spend settles; its open
+	// commitment is relieved instead of counting the amount twice.
+	SourceDocumentID string `json:"source_document_id,omitempty"`
+	// Approved records that the overrun was signed off under REQUIRE_APPROVAL enforcement.
+	Approved bool `json:"approved,omitempty"`
+}
+
+// BudgetCheckResult is the budget position before the checked spend. Allocated is the revised
+// budget where one exists, otherwise the original; Available includes the policy tolerance.
+type BudgetCheckResult struct {
+	BudgetID    string                   `json:"budget_id,omitempty"`
+	Enforcement domain.BudgetEnforcement `json:"enforcement"`
+	Allocated   decimal.Decimal          `json:"allocated"`
+	Committed   decimal.Decimal          `json:"committed"`
+	Spent       decimal.Decimal          `json:"spent"`
+	Available   decimal.Decimal          `json:"available"`
+	Exceeded    bool                     `json:"exceeded"`
+}
+
+// CommitmentRequest is one budgeted line of an approved requisition or purchase order.
+type CommitmentRequest struct {
+	SourceType       domain.BudgetCommitmentSource
+	SourceDocumentID string
+	// ReplacesDocumentID is the requisition a purchase order was raised from; its commitment
+	// is released once the order is committed.
+	ReplacesDocumentID string
+	AccountID          string
+	CostCenterID       string
+	FiscalYear         int
+	Period             int
+	Amount             decimal.Decimal
+}
+
 type BudgetingService struct {
-	budgets  domain.BudgetRepository
-	accounts domain.ChartOfAccountsRepository
-	entries  domain.UniversalJournalEntryRepository
-	outbox   domain.TransactionalOutboxRepository
-	tm       domain.TransactionManager
+	budgets     domain.BudgetRepository
+	commitments domain.BudgetCommitmentRepository
+	policies    domain.BudgetPolicyRepository
+	fiscalYears domain.FiscalYearRepository
+	accounts    domain.ChartOfAccountsRepository
+	entries     domain.UniversalJournalEntryRepository
+	outbox      domain.TransactionalOutboxRepository
+	tm          domain.TransactionManager
 }
 
 func NewBudgetingService(
 	budgets domain.BudgetRepository,
+	commitments domain.BudgetCommitmentRepository,
+	policies domain.BudgetPolicyRepository,
+	fiscalYears domain.FiscalYearRepository,
 	accounts domain.ChartOfAccountsRepository,
 	entries domain.UniversalJournalEntryRepository,
 	outbox domain.TransactionalOutboxRepository,
 	tm domain.TransactionManager,
 ) *BudgetingService {
 	return &BudgetingService{
-		budgets:  budgets,
-		accounts: accounts,
-		entries:  entries,
-		outbox:   outbox,
-		tm:       tm,
+		budgets:     budgets,
+		commitments: commitments,
+		policies:    policies,
+		fiscalYears: fiscalYears,
+		accounts:    accounts,
+		entries:     entries,
+		outbox:      outbox,
+		tm:          tm,
 	}
 }
 
-func (s *BudgetingService) calculateAccountBalance(ctx context.Context, accountID string) (decimal.Decimal, error) {
+// actualSpend sums the posted lines of an account within [from, to], on one cost center when
+// costCenterID is set. Year-end closing entries only move the balance to retained earnings
+// and are not spend.
+func (s *BudgetingService) actualSpend(ctx context.Context, accountID, costCenterID string, from, to time.Time) (decimal.Decimal, error) {
 	entries, err := s.entries.List(ctx)
 	if err != nil {
 		return decimal.Zero, err
@@ -45,23 +99,65 @@ func (s *BudgetingService) calculateAccountBalance(ctx context.Context, accountI
 		if entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED {
 			continue
 		}
+		if isYearEndClosing(entry) || !inReportRange(entry.PostingDate, &from, &to) {
+			continue
+		}
 		_, lines, err := s.entries.GetByID(ctx, entry.ID)
 		if err != nil {
 			return decimal.Zero, err
 		}
 		for _, line := range lines {
-			if line.AccountID == accountID {
-				balance = balance.Add(line.AmountFunctional)
+			if line.AccountID != accountID {
+				continue
+			}
+			if costCenterID != "" && dimensionValue(line.TrackingDimensions, costCenterDimension) != costCenterID {
+				continue
 			}
+			balance = balance.Add(line.AmountFunctional)
 		}
 	}
 	return balance, nil
 }
 
+// fiscalYearRange returns the first and last day of a fiscal year; years missing from the
+// fiscal calendar are calendar years.
+func (s *BudgetingService) fiscalYearRange(ctx context.Context, fiscalYear int) (time.Time, time.Time, error) {
+	years, err := s.fiscalYears.List(ctx)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	for _, fy := range years {
+		if fy.Year == fiscalYear {
+			return fy.StartDate, fy.EndDate, nil
+		}
+	}
+	return time.Date(fiscalYear, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(fiscalYear, 12, 31, 0, 0, 0, 0, time.UTC), nil
+}
+
+// FiscalPeriodOf places a date in the fiscal calendar: the fiscal year whose range holds its
+// month, and the month's 1-based position in that year. Months outside every defined fiscal
+// year fall back to the calendar year.
+func (s *BudgetingService) FiscalPeriodOf(ctx context.Context, date time.Time) (fiscalYear, period int, err error) {
+	years, err := s.fiscalYears.List(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	month := firstOfMonth(date)
+	for _, fy := range years {
+		start := firstOfMonth(fy.StartDate)
+		if month.Before(start) || month.After(firstOfMonth(fy.EndDate)) {
+			continue
+		}
+		return fy.Year, (month.Year()-start.Year())*12 + int(month.Month()-start.Month()) + 1, nil
+	}
+	return date.Year(), int(date.Month()), nil
+}
+
 func (s *BudgetingService) ListBudgets(ctx context.Context) ([]domain.Budget, error) {
 	return s.budgets.List(ctx)
 }
 
+// CreateBudget allocates the ORIGINAL budget of an account, cost center and month
 func (s *BudgetingService) CreateBudget(ctx context.Context, accountID, costCenterID string, fiscalYear, period int, allocatedAmount decimal.Decimal) (*domain.Budget, error) {
 	if accountID == "" || fiscalYear <= 0 || period < 1 || period > 12 {
 		return nil, errors.New("invalid budget inputs: account ID, year, and valid month period are required")
@@ -73,6 +169,7 @@ func (s *BudgetingService) CreateBudget(ctx context.Context, accountID, costCent
 		AccountID:       accountID,
 		FiscalYear:      fiscalYear,
 		Period:          period,
+		Version:         domain.BudgetVersionORIGINAL,
 		AllocatedAmount: allocatedAmount,
 		SpentAmount:     decimal.Zero,
 		CreatedAt:       time.Now(),
@@ -84,29 +181,21 @@ func (s *BudgetingService) CreateBudget(ctx context.Context, accountID, costCent
 	}
 
 	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
-		err := s.budgets.Create(txCtx, budget)
+		existing, err := s.versionOf(txCtx, budget, domain.BudgetVersionORIGINAL)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("%w: budget %s already covers this account, cost center and period", domain.ErrInvalidBudget, existing.ID)
+		}
+
+		err = s.budgets.Create(txCtx, budget)
 		if err != nil {
 			return err
 		}
 
 		// Write to outbox
-		outboxRec := &domain.TransactionalOutbox{
-			ID:          utils.NewID("outbox"),
-			EventType:   string(domain.TopicFmBudgetCreated),
-			AggregateID: budget.ID,
-			Payload: domain.BudgetEventPayload{
-				AccountID:       budget.AccountID,
-				CostCenterID:    budget.CostCenterID,
-				FiscalYear:      budget.FiscalYear,
-				Period:          budget.Period,
-				AllocatedAmount: budget.AllocatedAmount,
-				SpentAmount:     budget.SpentAmount,
-				Timestamp:       time.Now(),
-			},
-			Status:    domain.OutboxStatusPENDING,
-			CreatedAt: time.Now(),
-		}
-		return s.outbox.Create(txCtx, outboxRec)
+		return s.writeBudgetEvent(txCtx, domain.TopicFmBudgetCreated, budget)
 	})
 
 	if err != nil {
@@ -116,7 +205,119 @@ func (s *BudgetingService) CreateBudget(ctx context.Context, accountID, costCent
 	return budget, nil
 }
 
-func (s *BudgetingService) GetBudgetVsActualReport(ctx context.Context, accountID string, fiscalYear int) (map[string]interface{}, error) {
+// SetBudgetVersion records the REVISED or FORECAST allocation next to an original budget. A
+// revision supersedes the original allocation for enforcement; a forecast is reported only.
+func (s *BudgetingService) SetBudgetVersion(ctx context.Context, budgetID string, version domain.BudgetVersion, allocatedAmount decimal.Decimal) (*domain.Budget, error) {
+	if version != domain.BudgetVersionREVISED && version != domain.BudgetVersionFORECAST {
+		return nil, fmt.Errorf("%w: version must be REVISED or FORECAST", domain.ErrInvalidBudget)
+	}
+	if allocatedAmount.IsNegative() {
+		return nil, fmt.Errorf("%w: allocated amount cannot be negative", domain.ErrInvalidBudget)
+	}
+
+	var result *domain.Budget
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		original, err := s.budgets.GetByID(txCtx, budgetID)
+		if err != nil {
+			return err
+		}
+		if original.Version != domain.BudgetVersionORIGINAL {
+			return fmt.Errorf("%w: versions are set on the original budget", domain.ErrInvalidBudget)
+		}
+
+		result, err = s.versionOf(txCtx, original, version)
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			result = &domain.Budget{
+				ID:              utils.NewID("bud"),
+				AccountID:       original.AccountID,
+				CostCenterID:    original.CostCenterID,
+				FiscalYear:      original.FiscalYear,
+				Period:          original.Period,
+				Version:         version,
+				AllocatedAmount: allocatedAmount,
+				SpentAmount:     decimal.Zero,
+				CreatedAt:       time.Now(),
+				UpdatedAt:       time.Now(),
+			}
+			err = s.budgets.Create(txCtx, result)
+		} else {
+			result.AllocatedAmount = allocatedAmount
+			result.UpdatedAt = time.Now()
+			err = s.budgets.Update(txCtx, result)
+		}
+		if err != nil {
+			return err
+		}
+		return s.writeBudgetEvent(txCtx, domain.TopicFmBudgetUpdated, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetBudgetPolicy configures how a cost center's budgets are enforced when actual plus
+// committed spend would exceed the allocation. Cost centers without a policy only warn.
+func (s *BudgetingService) SetBudgetPolicy(ctx context.Context, costCenterID string, enforcement domain.BudgetEnforcement, tolerancePercent decimal.Decimal) (*domain.BudgetPolicy, error) {
+	if costCenterID == "" || !enforcement.IsValid() {
+		return nil, fmt.Errorf("%w: cost center and one of WARN, BLOCK or REQUIRE_APPROVAL are required", domain.ErrInvalidBudget)
+	}
+	if tolerancePercent.IsNegative() {
+		return nil, fmt.Errorf("%w: tolerance cannot be negative", domain.ErrInvalidBudget)
+	}
+
+	var policy *domain.BudgetPolicy
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.policies.GetByCostCenter(txCtx, costCenterID)
+		if err == nil {
+			existing.Enforcement = enforcement
+			existing.TolerancePercent = tolerancePercent
+			existing.UpdatedAt = time.Now()
+			policy = existing
+			return s.policies.Update(txCtx, existing)
+		}
+		policy = &domain.BudgetPolicy{
+			ID:               utils.NewID("bpol"),
+			CostCenterID:     costCenterID,
+			Enforcement:      enforcement,
+			TolerancePercent: tolerancePercent,
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		return s.policies.Create(txCtx, policy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *BudgetingService) ListBudgetPolicies(ctx context.Context) ([]domain.BudgetPolicy, error) {
+	return s.policies.List(ctx)
+}
+
+// ListCommitments returns the budget commitments, optionally only those in one status
+func (s *BudgetingService) ListCommitments(ctx context.Context, status domain.BudgetCommitmentStatus) ([]domain.BudgetCommitment, error) {
+	list, err := s.commitments.List(ctx)
+	if err != nil || status == "" {
+		return list, err
+	}
+	filtered := make([]domain.BudgetCommitment, 0, len(list))
+	for _, c := range list {
+		if c.Status == status {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// GetBudgetVsActualReport compares an account's budget for a fiscal year with its commitments
+// and the spend posted within the year. A cost center narrows every figure to that cost
+// center; without one the report covers the whole account.
+func (s *BudgetingService) GetBudgetVsActualReport(ctx context.Context, accountID, costCenterID string, fiscalYear int) (map[string]interface{}, error) {
 	acc, err := s.accounts.GetByID(ctx, accountID)
 	if err != nil {
 		return nil, err
@@ -127,14 +328,53 @@ func (s *BudgetingService) GetBudgetVsActualReport(ctx context.Context, accountI
 		return nil, err
 	}
 
-	var totalBudget decimal.Decimal
+	// The revised allocation of a budget line supersedes its original
+	var originalBudget, forecast decimal.Decimal
+	current := make(map[string]decimal.Decimal)
 	for _, b := range buds {
-		if b.AccountID == accountID && b.FiscalYear == fiscalYear {
-			totalBudget = totalBudget.Add(b.AllocatedAmount)
+		if b.AccountID != accountID || b.FiscalYear != fiscalYear {
+			continue
 		}
+		if costCenterID != "" && stringValue(b.CostCenterID) != costCenterID {
+			continue
+		}
+		key := budgetKey(b)
+		switch b.Version {
+		case domain.BudgetVersionFORECAST:
+			forecast = forecast.Add(b.AllocatedAmount)
+		case domain.BudgetVersionREVISED:
+			current[key] = b.AllocatedAmount
+		default:
+			originalBudget = originalBudget.Add(b.AllocatedAmount)
+			if _, revised := current[key]; !revised {
+				current[key] = b.AllocatedAmount
+			}
+		}
+	}
+	var totalBudget decimal.Decimal
+	for _, amount := range current {
+		totalBudget = totalBudget.Add(amount)
 	}
 
-	actualSpent, err := s.calculateAccountBalance(ctx, accountID)
+	commitments, err := s.commitments.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var committed decimal.Decimal
+	for _, c := range commitments {
+		if costCenterID != "" && stringValue(c.CostCenterID) != costCenterID {
+			continue
+		}
+		if c.AccountID == accountID && c.FiscalYear == fiscalYear && c.Status == domain.BudgetCommitmentStatusOPEN {
+			committed = committed.Add(c.Amount.Sub(c.RelievedAmount))
+		}
+	}
+
+	from, to, err := s.fiscalYearRange(ctx, fiscalYear)
+	if err != nil {
+		return nil, err
+	}
+	actualSpent, err := s.actualSpend(ctx, accountID, costCenterID, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -144,75 +384,493 @@ func (s *BudgetingService) GetBudgetVsActualReport(ctx context.Context, accountI
 	variance := totalBudget.Sub(actualSpent)
 
 	return map[string]interface{}{
-		"account_number": acc.AccountCode,
-		"account_name":   acc.AccountName,
-		"fiscal_year":    fiscalYear,
-		"budget_amount":  totalBudget,
-		"actual_spent":   actualSpent,
-		"variance":       variance,
+		"account_number":  acc.AccountCode,
+		"account_name":    acc.AccountName,
+		"cost_center_id":  costCenterID,
+		"fiscal_year":     fiscalYear,
+		"original_budget": originalBudget,
+		"revised_budget":  totalBudget,
+		"forecast":        forecast,
+		"budget_amount":   totalBudget,
+		"committed":       committed,
+		"actual_spent":    actualSpent,
+		"variance":        variance,
+		"remaining":       variance.Sub(committed),
 	}, nil
 }
 
+// CheckBudget returns the budget position for a planned spend without tracking it
+func (s *BudgetingService) CheckBudget(ctx context.Context, req BudgetCheckRequest) (*BudgetCheckResult, error) {
+	pos, err := s.position(ctx, req.AccountID, req.CostCenterID, req.FiscalYear, req.Period)
+	if err != nil {
+		return nil, err
+	}
+	relief, err := s.openCommitment(ctx, req.SourceDocumentID, pos.original)
+	if err != nil {
+		return nil, err
+	}
+	return pos.check(decimal.Max(req.Amount.Sub(relief), decimal.Zero)), nil
+}
+
 func (s *BudgetingService) CheckAndTrackBudgetExpense(ctx context.Context, accountID string, amount decimal.Decimal, year, period int) error {
-	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
-		bud, err := s.budgets.GetByAccountAndPeriod(txCtx, accountID, year, period)
+	_, err := s.TrackBudgetExpense(ctx, BudgetCheckRequest{AccountID: accountID, FiscalYear: year, Period: period, Amount: amount})
+	return err
+}
+
+// TrackBudgetExpense adds actual spend to the original budget and relieves the commitment of the
+// source document. When actual plus committed spend would exceed the budget, WARN tracks the spend
+// and publishes fm.budget.exceeded, BLOCK rejects it with ErrBudgetExceeded and REQUIRE_APPROVAL
+// rejects it with ErrBudgetApprovalRequired unless the request is approved. Spend without a
+// budget is not tracked.
+func (s *BudgetingService) TrackBudgetExpense(ctx context.Context, req BudgetCheckRequest) (*BudgetCheckResult, error) {
+	var result *BudgetCheckResult
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		result, err = s.CheckBudget(txCtx, req)
+		if err != nil || result.BudgetID == "" {
+			return err
+		}
+		if result.Exceeded {
+			switch {
+			case result.Enforcement == domain.BudgetEnforcementBLOCK:
+				return fmt.Errorf("%w: %s requested, %s available", domain.ErrBudgetExceeded, req.Amount, result.Available)
+			case result.Enforcement == domain.BudgetEnforcementREQUIRE_APPROVAL && !req.Approved:
+				return fmt.Errorf("%w: %s requested, %s available", domain.ErrBudgetApprovalRequired, req.Amount, result.Available)
+			}
+		}
+
+		bud, err := s.budgets.GetByID(txCtx, result.BudgetID)
 		if err != nil {
-			return nil
+			return err
 		}
+		if req.SourceDocumentID != "" {
+			if _, err := s.relieve(txCtx, req.SourceDocumentID, bud, req.Amount); err != nil {
+				return err
+			}
+		}
+		return s.addSpend(txCtx, bud, req.Amount, result.Exceeded)
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// addSpend increments the spent amount of an original budget and publishes the change
+func (s *BudgetingService) addSpend(ctx context.Context, bud *domain.Budget, amount decimal.Decimal, exceeded bool) error {
+	bud.SpentAmount = bud.SpentAmount.Add(amount)
+	bud.UpdatedAt = time.Now()
+
+	if err := s.budgets.Update(ctx, bud); err != nil {
+		return err
+	}
+
+	// Write budget updated to outbox
+	if err := s.writeBudgetEvent(ctx, domain.TopicFmBudgetUpdated, bud); err != nil {
+		return err
+	}
+	if exceeded {
+		// Write budget exceeded to outbox
+		return s.writeBudgetEvent(ctx, domain.TopicFmBudgetExceeded, bud)
+	}
+	return nil
+}
 
-		newSpent := bud.SpentAmount.Add(amount)
-		bud.SpentAmount = newSpent
-		bud.UpdatedAt = time.Now()
+// RecordCommitment commits budget for an approved requisition or purchase order line. If the
+// commitment would exceed the budget, WARN keeps it open and publishes fm.budget.exceeded, BLOCK
+// rejects it and REQUIRE_APPROVAL holds it for ApproveCommitment; both publish an event for SCM.
+// Recording the same document line again returns the existing commitment.
+func (s *BudgetingService) RecordCommitment(ctx context.Context, req CommitmentRequest) (*domain.BudgetCommitment, error) {
+	if !req.SourceType.IsValid() || req.SourceDocumentID == "" || req.AccountID == "" ||
+		req.FiscalYear <= 0 || req.Period < 1 || req.Period > 12 || !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: source, document, account, period and a positive amount are required", domain.ErrInvalidBudget)
+	}
 
-		err = s.budgets.Update(txCtx, bud)
+	var commitment *domain.BudgetCommitment
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.commitments.ListBySourceDocument(txCtx, req.SourceDocumentID)
 		if err != nil {
 			return err
 		}
+		for i := range existing {
+			if existing[i].AccountID == req.AccountID && stringValue(existing[i].CostCenterID) == req.CostCenterID {
+				commitment = &existing[i]
+				return nil
+			}
+		}
 
-		// Write budget updated to outbox
-		outboxRec := &domain.TransactionalOutbox{
-			ID:          utils.NewID("outbox"),
-			EventType:   string(domain.TopicFmBudgetUpdated),
-			AggregateID: bud.ID,
-			Payload: domain.BudgetEventPayload{
-				AccountID:       bud.AccountID,
-				CostCenterID:    bud.CostCenterID,
-				FiscalYear:      bud.FiscalYear,
-				Period:          bud.Period,
-				AllocatedAmount: bud.AllocatedAmount,
-				SpentAmount:     bud.SpentAmount,
-				Timestamp:       time.Now(),
-			},
-			Status:    domain.OutboxStatusPENDING,
-			CreatedAt: time.Now(),
-		}
-		if err := s.outbox.Create(txCtx, outboxRec); err != nil {
-			return err
-		}
-
-		if newSpent.GreaterThan(bud.AllocatedAmount) {
-			// Write budget exceeded to outbox
-			outboxRecExceeded := &domain.TransactionalOutbox{
-				ID:          utils.NewID("outbox"),
-				EventType:   string(domain.TopicFmBudgetExceeded),
-				AggregateID: bud.ID,
-				Payload: domain.BudgetEventPayload{
-					AccountID:       bud.AccountID,
-					CostCenterID:    bud.CostCenterID,
-					FiscalYear:      bud.FiscalYear,
-					Period:          bud.Period,
-					AllocatedAmount: bud.AllocatedAmount,
-					SpentAmount:     bud.SpentAmount,
-					Timestamp:       time.Now(),
-				},
-				Status:    domain.OutboxStatusPENDING,
-				CreatedAt: time.Now(),
-			}
-			if err := s.outbox.Create(txCtx, outboxRecExceeded); err != nil {
+		commitment = &domain.BudgetCommitment{
+			ID:               utils.NewID("bcom"),
+			SourceType:       req.SourceType,
+			SourceDocumentID: req.SourceDocumentID,
+			AccountID:        req.AccountID,
+			FiscalYear:       req.FiscalYear,
+			Period:           req.Period,
+			Amount:           req.Amount,
+			RelievedAmount:   decimal.Zero,
+			Status:           domain.BudgetCommitmentStatusOPEN,
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		if req.CostCenterID != "" {
+			commitment.CostCenterID = &req.CostCenterID
+		}
+		if req.ReplacesDocumentID != "" {
+			commitment.ReplacesDocumentID = &req.ReplacesDocumentID
+		}
+
+		pos, err := s.position(txCtx, req.AccountID, req.CostCenterID, req.FiscalYear, req.Period)
+		if err != nil {
+			return err
+		}
+		replaced, err := s.openCommitment(txCtx, req.ReplacesDocumentID, pos.original)
+		if err != nil {
+			return err
+		}
+		result := pos.check(decimal.Max(req.Amount.Sub(replaced), decimal.Zero))
+		if result.Exceeded {
+			switch result.Enforcement {
+			case domain.BudgetEnforcementBLOCK:
+				commitment.Status = domain.BudgetCommitmentStatusREJECTED
+			case domain.BudgetEnforcementREQUIRE_APPROVAL:
+				commitment.Status = domain.BudgetCommitmentStatusPENDING_APPROVAL
+			}
+		}
+		if err := s.commitments.Create(txCtx, commitment); err != nil {
+			return err
+		}
+
+		switch commitment.Status {
+		case domain.BudgetCommitmentStatusREJECTED:
+			return s.writeCommitmentEvent(txCtx, domain.TopicFmBudgetCommitmentRejected, commitment, result.Available)
+		case domain.BudgetCommitmentStatusPENDING_APPROVAL:
+			return s.writeCommitmentEvent(txCtx, domain.TopicFmBudgetApprovalRequired, commitment, result.Available)
+		}
+		if err := s.releaseDocument(txCtx, req.ReplacesDocumentID, pos.original); err != nil {
+			return err
+		}
+		if result.Exceeded {
+			bud, err := s.budgets.GetByID(txCtx, result.BudgetID)
+			if err != nil {
+				return err
+			}
+			return s.writeBudgetEvent(txCtx, domain.TopicFmBudgetExceeded, bud)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commitment, nil
+}
+
+// ApproveCommitment opens a commitment held for approval, counting it against the budget
+func (s *BudgetingService) ApproveCommitment(ctx context.Context, id string) (*domain.BudgetCommitment, error) {
+	return s.decideCommitment(ctx, id, domain.BudgetCommitmentStatusOPEN)
+}
+
+// RejectCommitment rejects a commitment held for approval
+func (s *BudgetingService) RejectCommitment(ctx context.Context, id string) (*domain.BudgetCommitment, error) {
+	return s.decideCommitment(ctx, id, domain.BudgetCommitmentStatusREJECTED)
+}
+
+func (s *BudgetingService) decideCommitment(ctx context.Context, id string, status domain.BudgetCommitmentStatus) (*domain.BudgetCommitment, error) {
+	var commitment *domain.BudgetCommitment
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		commitment, err = s.commitments.GetByID(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if commitment.Status != domain.BudgetCommitmentStatusPENDING_APPROVAL {
+			return fmt.Errorf("%w: %s is %s", domain.ErrCommitmentNotApprovable, commitment.ID, commitment.Status)
+		}
+
+		pos, err := s.position(txCtx, commitment.AccountID, stringValue(commitment.CostCenterID), commitment.FiscalYear, commitment.Period)
+		if err != nil {
+			return err
+		}
+		available := pos.check(decimal.Zero).Available
+
+		commitment.Status = status
+		commitment.UpdatedAt = time.Now()
+		if err := s.commitments.Update(txCtx, commitment); err != nil {
+			return err
+		}
+		if status == domain.BudgetCommitmentStatusREJECTED {
+			return s.writeCommitmentEvent(txCtx, domain.TopicFmBudgetCommitmentRejected, commitment, available)
+		}
+		if commitment.ReplacesDocumentID != nil {
+			if err := s.releaseDocument(txCtx, *commitment.ReplacesDocumentID, pos.original); err != nil {
+				return err
+			}
+		}
+		return s.writeCommitmentEvent(txCtx, domain.TopicFmBudgetCommitmentApproved, commitment, available)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commitment, nil
+}
+
+// ConsumeCommitments turns the open commitments of a purchase order into actual spend when it is
+// invoiced, in the order the lines were committed. Invoiced amounts beyond the commitments are
+// not tracked as their account is unknown.
+func (s *BudgetingService) ConsumeCommitments(ctx context.Context, sourceDocumentID string, amount decimal.Decimal) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		commitments, err := s.commitments.ListBySourceDocument(txCtx, sourceDocumentID)
+		if err != nil {
+			return err
+		}
+		remaining := amount
+		for i := range commitments {
+			c := &commitments[i]
+			if c.Status != domain.BudgetCommitmentStatusOPEN || !remaining.IsPositive() {
+				continue
+			}
+			portion := decimal.Min(c.Amount.Sub(c.RelievedAmount), remaining)
+			if err := s.relieveCommitment(txCtx, c, portion); err != nil {
+				return err
+			}
+			remaining = remaining.Sub(portion)
+
+			pos, err := s.position(txCtx, c.AccountID, stringValue(c.CostCenterID), c.FiscalYear, c.Period)
+			if err != nil {
+				return err
+			}
+			if pos.original == nil {
+				continue
+			}
+			if err := s.addSpend(txCtx, pos.original, portion, pos.check(portion).Exceeded); err != nil {
 				return err
 			}
 		}
+		return nil
+	})
+}
+
+// budgetPosition is the controlling budget of an account, cost center and month
+type budgetPosition struct {
+	original    *domain.Budget
+	allocated   decimal.Decimal
+	committed   decimal.Decimal
+	enforcement domain.BudgetEnforcement
+	tolerance   decimal.Decimal
+}
+
+func (p budgetPosition) check(amount decimal.Decimal) *BudgetCheckResult {
+	result := &BudgetCheckResult{Enforcement: p.enforcement, Allocated: p.allocated, Committed: p.committed}
+	if p.original == nil {
+		return result
+	}
+	limit := p.allocated.Mul(decimal.NewFromInt(100).Add(p.tolerance)).Div(decimal.NewFromInt(100))
+	result.BudgetID = p.original.ID
+	result.Spent = p.original.SpentAmount
+	result.Available = limit.Sub(p.committed).Sub(p.original.SpentAmount)
+	result.Exceeded = amount.GreaterThan(result.Available)
+	return result
+}
+
+func (s *BudgetingService) position(ctx context.Context, accountID, costCenterID string, fiscalYear, period int) (budgetPosition, error) {
+	pos := budgetPosition{enforcement: domain.BudgetEnforcementWARN, tolerance: decimal.Zero}
+
+	buds, err := s.budgets.List(ctx)
+	if err != nil {
+		return pos, err
+	}
+	for i := range buds {
+		b := &buds[i]
+		if b.AccountID != accountID || b.FiscalYear != fiscalYear || b.Period != period || b.Version != domain.BudgetVersionORIGINAL {
+			continue
+		}
+		if costCenterID != "" && stringValue(b.CostCenterID) != costCenterID {
+			continue
+		}
+		// Without a cost center prefer the account level budget
+		if pos.original == nil || (b.CostCenterID == nil && pos.original.CostCenterID != nil) {
+			pos.original = b
+		}
+	}
+	if pos.original == nil {
+		return pos, nil
+	}
+	pos.allocated = pos.original.AllocatedAmount
+	revised, err := s.versionOf(ctx, pos.original, domain.BudgetVersionREVISED)
+	if err != nil {
+		return pos, err
+	}
+	if revised != nil {
+		pos.allocated = revised.AllocatedAmount
+	}
+
+	commitments, err := s.commitments.List(ctx)
+	if err != nil {
+		return pos, err
+	}
+	for _, c := range commitments {
+		if c.Status == domain.BudgetCommitmentStatusOPEN && commitmentCovers(c, pos.original) {
+			pos.committed = pos.committed.Add(c.Amount.Sub(c.RelievedAmount))
+		}
+	}
+
+	if pos.original.CostCenterID != nil {
+		if policy, err := s.policies.GetByCostCenter(ctx, *pos.original.CostCenterID); err == nil {
+			pos.enforcement = policy.Enforcement
+			pos.tolerance = policy.TolerancePercent
+		}
+	}
+	return pos, nil
+}
+
+// versionOf returns the budget row of the given version sharing the key of bud, if any
+func (s *BudgetingService) versionOf(ctx context.Context, bud *domain.Budget, version domain.BudgetVersion) (*domain.Budget, error) {
+	buds, err := s.budgets.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range buds {
+		b := &buds[i]
+		if b.Version == version && b.AccountID == bud.AccountID && b.FiscalYear == bud.FiscalYear &&
+			b.Period == bud.Period && stringValue(b.CostCenterID) == stringValue(bud.CostCenterID) {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+// openCommitment returns the unrelieved amount the open commitments of a document hold on a budget
+func (s *BudgetingService) openCommitment(ctx context.Context, sourceDocumentID string, bud *domain.Budget) (decimal.Decimal, error) {
+	if sourceDocumentID == "" || bud == nil {
+		return decimal.Zero, nil
+	}
+	commitments, err := s.commitments.ListBySourceDocument(ctx, sourceDocumentID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	open := decimal.Zero
+	for _, c := range commitments {
+		if c.Status == domain.BudgetCommitmentStatusOPEN && commitmentCovers(c, bud) {
+			open = open.Add(c.Amount.Sub(c.RelievedAmount))
+		}
+	}
+	return open, nil
+}
+
+// relieve consumes up to amount of the open commitments a document holds on a budget
+func (s *BudgetingService) relieve(ctx context.Context, sourceDocumentID string, bud *domain.Budget, amount decimal.Decimal) (decimal.Decimal, error) {
+	commitments, err := s.commitments.ListBySourceDocument(ctx, sourceDocumentID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	relieved := decimal.Zero
+	for i := range commitments {
+		c := &commitments[i]
+		if c.Status != domain.BudgetCommitmentStatusOPEN || !commitmentCovers(*c, bud) {
+			continue
+		}
+		portion := decimal.Min(c.Amount.Sub(c.RelievedAmount), amount.Sub(relieved))
+		if !portion.IsPositive() {
+			break
+		}
+		if err := s.relieveCommitment(ctx, c, portion); err != nil {
+			return decimal.Zero, err
+		}
+		relieved = relieved.Add(portion)
+	}
+	return relieved, nil
+}
 
+func (s *BudgetingService) relieveCommitment(ctx context.Context, c *domain.BudgetCommitment, amount decimal.Decimal) error {
+	c.RelievedAmount = c.RelievedAmount.Add(amount)
+	if c.RelievedAmount.GreaterThanOrEqual(c.Amount) {
+		c.Status = domain.BudgetCommitmentStatusCONSUMED
+	}
+	c.UpdatedAt = time.Now()
+	return s.commitments.Update(ctx, c)
+}
+
+// releaseDocument releases what a superseded document still commits on a budget
+func (s *BudgetingService) releaseDocument(ctx context.Context, sourceDocumentID string, bud *domain.Budget) error {
+	if sourceDocumentID == "" {
 		return nil
+	}
+	commitments, err := s.commitments.ListBySourceDocument(ctx, sourceDocumentID)
+	if err != nil {
+		return err
+	}
+	for i := range commitments {
+		c := &commitments[i]
+		if bud != nil && !commitmentCovers(*c, bud) {
+			continue
+		}
+		if c.Status == domain.BudgetCommitmentStatusOPEN || c.Status == domain.BudgetCommitmentStatusPENDING_APPROVAL {
+			c.Status = domain.BudgetCommitmentStatusRELEASED
+			c.UpdatedAt = time.Now()
+			if err := s.commitments.Update(ctx, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// commitmentCovers reports whether a commitment draws on the given original budget. Commitments
+// without a cost center draw on the account level budget.
+func commitmentCovers(c domain.BudgetCommitment, bud *domain.Budget) bool {
+	if c.AccountID != bud.AccountID || c.FiscalYear != bud.FiscalYear || c.Period != bud.Period {
+		return false
+	}
+	return c.CostCenterID == nil || stringValue(c.CostCenterID) == stringValue(bud.CostCenterID)
+}
+
+// budgetKey identifies the budget line of an account, cost center and month across versions
+func budgetKey(b domain.Budget) string {
+	return fmt.Sprintf("%s/%s/%d/%d", b.AccountID, stringValue(b.CostCenterID), b.FiscalYear, b.Period)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (s *BudgetingService) writeBudgetEvent(ctx context.Context, topic string, bud *domain.Budget) error {
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: bud.ID,
+		Payload: domain.BudgetEventPayload{
+			AccountID:       bud.AccountID,
+			CostCenterID:    bud.CostCenterID,
+			FiscalYear:      bud.FiscalYear,
+			Period:          bud.Period,
+			AllocatedAmount: bud.AllocatedAmount,
+			SpentAmount:     bud.SpentAmount,
+			Timestamp:       time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *BudgetingService) writeCommitmentEvent(ctx context.Context, topic string, c *domain.BudgetCommitment, available decimal.Decimal) error {
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: c.ID,
+		Payload: domain.BudgetCommitmentEventPayload{
+			CommitmentID:     c.ID,
+			SourceType:       c.SourceType,
+			SourceDocumentID: c.SourceDocumentID,
+			AccountID:        c.AccountID,
+			CostCenterID:     c.CostCenterID,
+			Amount:           c.Amount,
+			Available:        available,
+			Status:           c.Status,
+			Timestamp:        time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
 	})
 }
@@ -0,0 +1,385 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func createBudget(t *testing.T, svc *service.BudgetingService, account *domain.ChartOfAccounts, costCenterID string, amount int64) *domain.Budget {
+	t.Helper()
+	bud, err := svc.CreateBudget(context.Background(), account.ID, costCenterID, 2026, 5, decimal.NewFromInt(amount))
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	return bud
+}
+
+func recordCommitment(t *testing.T, svc *service.BudgetingService, account *domain.ChartOfAccounts, source domain.BudgetCommitmentSource, documentID, replaces, costCenterID string, amount int64) *domain.BudgetCommitment {
+	t.Helper()
+	c, err := svc.RecordCommitment(context.Background(), service.CommitmentRequest{
+		SourceType:         source,
+		SourceDocumentID:   documentID,
+		ReplacesDocumentID: replaces,
+		AccountID:          account.ID,
+		CostCenterID:       costCenterID,
+		FiscalYear:         2026,
+		Period:             5,
+		Amount:             decimal.NewFromInt(amount),
+	})
+	if err != nil {
+		t.Fatalf("failed to record commitment: %v", err)
+	}
+	return c
+}
+
+func budgetCheck(account *domain.ChartOfAccounts, costCenterID string, amount int64) service.BudgetCheckRequest {
+	return service.BudgetCheckRequest{AccountID: account.ID, CostCenterID: costCenterID, FiscalYear: 2026, Period: 5, Amount: decimal.NewFromInt(amount)}
+}
+
+func TestBudgeting_RevisedVersionSupersedesOriginal(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, budgets, commitments, policies, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tm)
+	ctx := context.Background()
+
+	expense, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Office Supplies", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	bud := createBudget(t, svc, expense, "cc_ops", 1000)
+
+	if _, err := svc.CreateBudget(ctx, expense.ID, "cc_ops", 2026, 5, decimal.NewFromInt(500)); !errors.Is(err, domain.ErrInvalidBudget) {
+		t.Errorf("expected duplicate original budget to be rejected, got %v", err)
+	}
+	if _, err := svc.SetBudgetVersion(ctx, bud.ID, domain.BudgetVersionORIGINAL, decimal.NewFromInt(1)); !errors.Is(err, domain.ErrInvalidBudget) {
+		t.Errorf("expected ORIGINAL version to be rejected, got %v", err)
+	}
+
+	revised, err := svc.SetBudgetVersion(ctx, bud.ID, domain.BudgetVersionREVISED, decimal.NewFromInt(1500))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Setting a version again updates it in place
+	again, err := svc.SetBudgetVersion(ctx, bud.ID, domain.BudgetVersionREVISED, decimal.NewFromInt(1200))
+	if err != nil || again.ID != revised.ID {
+		t.Fatalf("expected revision %s to be updated, got %+v (%v)", revised.ID, again, err)
+	}
+	if _, err := svc.SetBudgetVersion(ctx, revised.ID, domain.BudgetVersionFORECAST, decimal.NewFromInt(1)); !errors.Is(err, domain.ErrInvalidBudget) {
+		t.Errorf("expected versions of a revision to be rejected, got %v", err)
+	}
+	if _, err := svc.SetBudgetVersion(ctx, bud.ID, domain.BudgetVersionFORECAST, decimal.NewFromInt(2000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The forecast is reported only, the revision controls enforcement
+	check, err := svc.CheckBudget(ctx, budgetCheck(expense, "cc_ops", 1300))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if check.BudgetID != bud.ID || !check.Allocated.Equal(decimal.NewFromInt(1200)) || !check.Exceeded {
+		t.Errorf("expected revised allocation of 1200 to be exceeded, got %+v", check)
+	}
+}
+
+func TestBudgeting_Enforcement(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, budgets, commitments, policies, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tm)
+	ctx := context.Background()
+
+	expense, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Office Supplies", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	blocked := createBudget(t, svc, expense, "cc_block", 1000)
+	createBudget(t, svc, expense, "cc_approve", 1000)
+	createBudget(t, svc, expense, "cc_warn", 1000)
+
+	if _, err := svc.SetBudgetPolicy(ctx, "cc_block", domain.BudgetEnforcementBLOCK, decimal.NewFromInt(10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.SetBudgetPolicy(ctx, "cc_approve", domain.BudgetEnforcementREQUIRE_APPROVAL, decimal.Zero); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.SetBudgetPolicy(ctx, "cc_warn", "IGNORE", decimal.Zero); !errors.Is(err, domain.ErrInvalidBudget) {
+		t.Errorf("expected invalid enforcement to be rejected, got %v", err)
+	}
+
+	// BLOCK allows the tolerance, then rejects without tracking
+	if _, err := svc.TrackBudgetExpense(ctx, budgetCheck(expense, "cc_block", 1050)); err != nil {
+		t.Fatalf("expected spend within tolerance to be tracked, got %v", err)
+	}
+	result, err := svc.TrackBudgetExpense(ctx, budgetCheck(expense, "cc_block", 100))
+	if !errors.Is(err, domain.ErrBudgetExceeded) {
+		t.Fatalf("expected budget exceeded, got %v", err)
+	}
+	if !result.Available.Equal(decimal.NewFromInt(50)) {
+		t.Errorf("expected 50 available, got %s", result.Available)
+	}
+	listed, _ := svc.ListBudgets(ctx)
+	for _, b := range listed {
+		if b.ID == blocked.ID && !b.SpentAmount.Equal(decimal.NewFromInt(1050)) {
+			t.Errorf("expected blocked spend not to be tracked, got %s", b.SpentAmount)
+		}
+	}
+
+	// REQUIRE_APPROVAL tracks the overrun only once approved
+	req := budgetCheck(expense, "cc_approve", 1200)
+	if _, err := svc.TrackBudgetExpense(ctx, req); !errors.Is(err, domain.ErrBudgetApprovalRequired) {
+		t.Fatalf("expected approval required, got %v", err)
+	}
+	req.Approved = true
+	if _, err := svc.TrackBudgetExpense(ctx, req); err != nil {
+		t.Fatalf("expected approved overrun to be tracked, got %v", err)
+	}
+
+	// Cost centers without a policy warn
+	result, err = svc.TrackBudgetExpense(ctx, budgetCheck(expense, "cc_warn", 1200))
+	if err != nil || result.Enforcement != domain.BudgetEnforcementWARN || !result.Exceeded {
+		t.Fatalf("expected warning overrun to be tracked, got %+v (%v)", result, err)
+	}
+	if n := countTopic(t, outbox, domain.TopicFmBudgetExceeded); n != 2 {
+		t.Errorf("expected 2 budget exceeded events, got %d", n)
+	}
+}
+
+func TestBudgeting_CommitmentsReplaceAndRelieve(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, budgets, commitments, policies, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tm)
+	ctx := context.Background()
+
+	expense, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Office Supplies", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	createBudget(t, svc, expense, "cc_ops", 1000)
+
+	pr := recordCommitment(t, svc, expense, domain.BudgetCommitmentSourcePURCHASE_REQUISITION, "pr_1", "", "cc_ops", 400)
+	// Redelivered requisitions are not committed twice
+	if again := recordCommitment(t, svc, expense, domain.BudgetCommitmentSourcePURCHASE_REQUISITION, "pr_1", "", "cc_ops", 400); again.ID != pr.ID {
+		t.Errorf("expected existing commitment %s, got %s", pr.ID, again.ID)
+	}
+
+	// The purchase order releases its requisition; only the increase is checked
+	po := recordCommitment(t, svc, expense, domain.BudgetCommitmentSourcePURCHASE_ORDER, "po_1", "pr_1", "cc_ops", 450)
+	if po.Status != domain.BudgetCommitmentStatusOPEN {
+		t.Fatalf("expected open purchase order commitment, got %s", po.Status)
+	}
+	released, _ := svc.ListCommitments(ctx, domain.BudgetCommitmentStatusRELEASED)
+	if len(released) != 1 || released[0].ID != pr.ID {
+		t.Errorf("expected requisition commitment to be released, got %+v", released)
+	}
+
+	check, _ := svc.CheckBudget(ctx, budgetCheck(expense, "cc_ops", 0))
+	if !check.Committed.Equal(decimal.NewFromInt(450)) || !check.Available.Equal(decimal.NewFromInt(550)) {
+		t.Errorf("expected 450 committed and 550 available, got %+v", check)
+	}
+
+	// Spend against the order relieves its commitment instead of counting twice
+	req := budgetCheck(expense, "cc_ops", 300)
+	req.SourceDocumentID = "po_1"
+	if _, err := svc.TrackBudgetExpense(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	check, _ = svc.CheckBudget(ctx, budgetCheck(expense, "cc_ops", 0))
+	if !check.Committed.Equal(decimal.NewFromInt(150)) || !check.Spent.Equal(decimal.NewFromInt(300)) || !check.Available.Equal(decimal.NewFromInt(550)) {
+		t.Errorf("expected 150 committed, 300 spent and 550 available, got %+v", check)
+	}
+
+	if err := svc.ConsumeCommitments(ctx, "po_1", decimal.NewFromInt(150)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	consumed, _ := svc.ListCommitments(ctx, domain.BudgetCommitmentStatusCONSUMED)
+	if len(consumed) != 1 || consumed[0].ID != po.ID {
+		t.Errorf("expected purchase order commitment to be consumed, got %+v", consumed)
+	}
+}
+
+func TestBudgeting_CommitmentApproval(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, budgets, commitments, policies, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tm)
+	ctx := context.Background()
+
+	expense, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Office Supplies", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	createBudget(t, svc, expense, "cc_block", 1000)
+	createBudget(t, svc, expense, "cc_approve", 1000)
+	_, _ = svc.SetBudgetPolicy(ctx, "cc_block", domain.BudgetEnforcementBLOCK, decimal.Zero)
+	_, _ = svc.SetBudgetPolicy(ctx, "cc_approve", domain.BudgetEnforcementREQUIRE_APPROVAL, decimal.Zero)
+
+	if c := recordCommitment(t, svc, expense, domain.BudgetCommitmentSourcePURCHASE_ORDER, "po_block", "", "cc_block", 1200); c.Status != domain.BudgetCommitmentStatusREJECTED {
+		t.Errorf("expected blocked commitment to be rejected, got %s", c.Status)
+	}
+
+	held := recordCommitment(t, svc, expense, domain.BudgetCommitmentSourcePURCHASE_ORDER, "po_approve", "", "cc_approve", 1200)
+	if held.Status != domain.BudgetCommitmentStatusPENDING_APPROVAL {
+		t.Fatalf("expected commitment held for approval, got %s", held.Status)
+	}
+	check, _ := svc.CheckBudget(ctx, budgetCheck(expense, "cc_approve", 0))
+	if !check.Committed.IsZero() {
+		t.Errorf("expected held commitment not to count, got %s committed", check.Committed)
+	}
+
+	approved, err := svc.ApproveCommitment(ctx, held.ID)
+	if err != nil || approved.Status != domain.BudgetCommitmentStatusOPEN {
+		t.Fatalf("expected commitment to open, got %+v (%v)", approved, err)
+	}
+	if _, err := svc.RejectCommitment(ctx, held.ID); !errors.Is(err, domain.ErrCommitmentNotApprovable) {
+		t.Errorf("expected decided commitment to be final, got %v", err)
+	}
+	check, _ = svc.CheckBudget(ctx, budgetCheck(expense, "cc_approve", 0))
+	if !check.Committed.Equal(decimal.NewFromInt(1200)) {
+		t.Errorf("expected approved commitment to count, got %s committed", check.Committed)
+	}
+
+	if n := countTopic(t, outbox, domain.TopicFmBudgetCommitmentRejected); n != 1 {
+		t.Errorf("expected 1 commitment rejected event, got %d", n)
+	}
+	if n := countTopic(t, outbox, domain.TopicFmBudgetApprovalRequired); n != 1 {
+		t.Errorf("expected 1 approval required event, got %d", n)
+	}
+	if n := countTopic(t, outbox, domain.TopicFmBudgetCommitmentApproved); n != 1 {
+		t.Errorf("expected 1 commitment approved event, got %d", n)
+	}
+}
+
+func TestBudgeting_BudgetVsActualReport(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, budgets, commitments, policies, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tm)
+	ctx := context.Background()
+
+	expense, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Office Supplies", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	bank, _ := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+	bud := createBudget(t, svc, expense, "cc_ops", 1000)
+	_, _ = svc.SetBudgetVersion(ctx, bud.ID, domain.BudgetVersionREVISED, decimal.NewFromInt(1200))
+	_, _ = svc.SetBudgetVersion(ctx, bud.ID, domain.BudgetVersionFORECAST, decimal.NewFromInt(1100))
+	recordCommitment(t, svc, expense, domain.BudgetCommitmentSourcePURCHASE_ORDER, "po_1", "", "cc_ops", 300)
+
+	// Only spend on the budget's cost center within the fiscal year counts; the closing entry
+	// sweeping the year into retained earnings is not spend
+	postings := []struct {
+		doc        string
+		date       time.Time
+		costCenter string
+		amount     int64
+	}{
+		{"bill_1", day(2026, 5, 12), "cc_ops", 400},
+		{"bill_2", day(2026, 6, 3), "cc_sales", 250},
+		{"bill_3", day(2025, 12, 20), "cc_ops", 90},
+		{"YEC-2026", day(2026, 12, 31), "cc_ops", -400},
+	}
+	for _, p := range postings {
+		_, err := gl.CreateJournalEntry(ctx, "le_1", "AP", p.doc, p.date, []domain.UniversalJournalLine{
+			{AccountID: expense.ID, AmountFunctional: decimal.NewFromInt(p.amount), TrackingDimensions: map[string]interface{}{"cost_center_id": p.costCenter}},
+			{AccountID: bank.ID, AmountFunctional: decimal.NewFromInt(-p.amount)},
+		})
+		if err != nil {
+			t.Fatalf("failed to post %s: %v", p.doc, err)
+		}
+	}
+
+	report, err := svc.GetBudgetVsActualReport(ctx, expense.ID, "cc_ops", 2026)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]int64{
+		"original_budget": 1000,
+		"revised_budget":  1200,
+		"forecast":        1100,
+		"budget_amount":   1200,
+		"committed":       300,
+		"actual_spent":    400,
+		"variance":        800,
+		"remaining":       500,
+	}
+	for key, amount := range expected {
+		if got := report[key].(decimal.Decimal); !got.Equal(decimal.NewFromInt(amount)) {
+			t.Errorf("expected %s of %d, got %s", key, amount, got)
+		}
+	}
+
+	report, err = svc.GetBudgetVsActualReport(ctx, expense.ID, "", 2026)
+	if err != nil || !report["actual_spent"].(decimal.Decimal).Equal(decimal.NewFromInt(650)) {
+		t.Errorf("expected 650 spent across cost centers in 2026, got %v (%v)", report["actual_spent"], err)
+	}
+}
+
+func TestBudgetingFiscalPeriodOf(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, budgets, commitments, policies, outbox)
+	svc := service.NewBudgetingService(budgets, commitments, policies, fiscalYears, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	_ = fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2027", Year: 2027, StartDate: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC)})
+
+	cases := []struct {
+		date         time.Time
+		year, period int
+	}{
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), 2027, 1},
+		{time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC), 2027, 9},
+		{time.Date(2027, 3, 31, 23, 0, 0, 0, time.UTC), 2027, 12},
+		{time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), 2026, 3}, // no fiscal year defined: calendar year
+	}
+	for _, tc := range cases {
+		year, period, err := svc.FiscalPeriodOf(ctx, tc.date)
+		if err != nil || year != tc.year || period != tc.period {
+			t.Errorf("FiscalPeriodOf(%s) = %d/%d (%v), want %d/%d", tc.date.Format("2006-01-02"), year, period, err, tc.year, tc.period)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"erp-system/shared/utils"
@@ -11,9 +12,45 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// GL accounts used by fixed asset accounting. Accumulated depreciation is a contra-asset.
+const (
+	fixedAssetAccount              = "1500-001"
+	accumulatedDepreciationAccount = "1590-001"
+	depreciationExpenseAccount     = "6040-001"
+	assetDisposalGainAccount       = "7930-001"
+	assetDisposalLossAccount       = "8930-001"
+	assetDisposalProceedsAccount   = "1010-001"
+)
+
+// CapitalizeAssetRequest describes a new fixed asset and how it depreciates. Method defaults to
+// straight-line, Convention to a full first month, DecliningBalanceRate to 2 (double-declining)
+// and CapitalizationDate to today.
+type CapitalizeAssetRequest struct {
+	LegalEntityID        string
+	AssetTag             string
+	AcquisitionCost      decimal.Decimal
+	UsefulLifeMonths     int
+	EquipmentID          *string
+	CapitalizationDate   time.Time
+	Method               domain.DepreciationMethod
+	Convention           domain.DepreciationConvention
+	SalvageValue         decimal.Decimal
+	DecliningBalanceRate decimal.Decimal
+	EstimatedTotalUnits  decimal.Decimal
+}
+
+// DisposeAssetRequest retires or sells an asset. ProceedsAccountID defaults to the bank account
+// of the legal entity; zero proceeds scrap the asset.
+type DisposeAssetRequest struct {
+	DisposalDate      time.Time
+	Proceeds          decimal.Decimal
+	ProceedsAccountID string
+}
+
 type CapitalAssetService struct {
 	assetRepo domain.CapitalAssetRepository
 	lineRepo  domain.DepreciationScheduleLineRepository
+	usageRepo domain.AssetUsageRecordRepository
 	accounts  domain.ChartOfAccountsRepository
 	entries   domain.UniversalJournalEntryRepository
 	outbox    domain.TransactionalOutboxRepository
@@ -23,6 +60,7 @@ type CapitalAssetService struct {
 func NewCapitalAssetService(
 	assetRepo domain.CapitalAssetRepository,
 	lineRepo domain.DepreciationScheduleLineRepository,
+	usageRepo domain.AssetUsageRecordRepository,
 	accounts domain.ChartOfAccountsRepository,
 	entries domain.UniversalJournalEntryRepository,
 	outbox domain.TransactionalOutboxRepository,
@@ -31,6 +69,7 @@ func NewCapitalAssetService(
 	return &CapitalAssetService{
 		assetRepo: assetRepo,
 		lineRepo:  lineRepo,
+		usageRepo: usageRepo,
 		accounts:  accounts,
 		entries:   entries,
 		outbox:    outbox,
@@ -62,31 +101,41 @@ func (s *CapitalAssetService) getOrCreateAccount(ctx context.Context, legalEntit
 	return newAcc, nil
 }
 
-func (s *CapitalAssetService) CapitalizeAsset(
-	ctx context.Context,
-	legalEntityID string,
-	assetTag string,
-	acquisitionCost decimal.Decimal,
-	usefulLifeMonths int,
-	equipmentID *string,
-) (*domain.CapitalAsset, error) {
-	if legalEntityID == "" || assetTag == "" || usefulLifeMonths <= 0 {
+func (s *CapitalAssetService) CapitalizeAsset(ctx context.Context, req CapitalizeAssetRequest) (*domain.CapitalAsset, error) {
+	if req.LegalEntityID == "" || req.AssetTag == "" || req.UsefulLifeMonths <= 0 {
 		return nil, errors.New("legal entity ID, asset tag, and a positive useful life are required")
 	}
-	if acquisitionCost.IsZero() || acquisitionCost.IsNegative() {
+	if req.AcquisitionCost.IsZero() || req.AcquisitionCost.IsNegative() {
 		return nil, errors.New("acquisition cost must be positive")
 	}
+	if req.Method == "" {
+		req.Method = domain.DepreciationMethodSTRAIGHT_LINE
+	}
+	if req.Convention == "" {
+		req.Convention = domain.DepreciationConventionFULL_MONTH
+	}
+	if req.CapitalizationDate.IsZero() {
+		req.CapitalizationDate = time.Now()
+	}
+	if err := validateDepreciationTerms(&req); err != nil {
+		return nil, err
+	}
 
 	asset := &domain.CapitalAsset{
 		ID:                      utils.NewID("asset"),
-		LegalEntityID:           legalEntityID,
-		AssetTag:                assetTag,
-		EamEquipmentID:          equipmentID,
-		AcquisitionCost:         acquisitionCost,
+		LegalEntityID:           req.LegalEntityID,
+		AssetTag:                req.AssetTag,
+		EamEquipmentID:          req.EquipmentID,
+		AcquisitionCost:         req.AcquisitionCost,
 		AccumulatedDepreciation: decimal.Zero,
-		UsefulLifeMonths:        usefulLifeMonths,
-		CapitalizationDate:      time.Now(),
+		UsefulLifeMonths:        req.UsefulLifeMonths,
+		CapitalizationDate:      req.CapitalizationDate,
 		Status:                  domain.AssetStateACTIVE,
+		DepreciationMethod:      req.Method,
+		DepreciationConvention:  req.Convention,
+		SalvageValue:            req.SalvageValue,
+		DecliningBalanceRate:    req.DecliningBalanceRate,
+		EstimatedTotalUnits:     req.EstimatedTotalUnits,
 		CreatedAt:               time.Now(),
 		UpdatedAt:               time.Now(),
 	}
@@ -99,50 +148,21 @@ func (s *CapitalAssetService) CapitalizeAsset(
 
 		// Create General Ledger entries for the capitalization
 		// Debit: Fixed Asset Account (1500-001)
-		assetAcc, err := s.getOrCreateAccount(txCtx, legalEntityID, "1500-001", "Fixed Assets - Equipment", "ASSET")
+		assetAcc, err := s.getOrCreateAccount(txCtx, req.LegalEntityID, fixedAssetAccount, "Fixed Assets - Equipment", "ASSET")
 		if err != nil {
 			return err
 		}
 		// Credit: Accounts Payable Clearing Account (2110-999)
-		offsetAcc, err := s.getOrCreateAccount(txCtx, legalEntityID, "2110-999", "AP Clearing - Fixed Assets", "LIABILITY")
+		offsetAcc, err := s.getOrCreateAccount(txCtx, req.LegalEntityID, "2110-999", "AP Clearing - Fixed Assets", "LIABILITY")
 		if err != nil {
 			return err
 		}
 
 		lines := []domain.UniversalJournalLine{
-			{
-				AccountID:             assetAcc.ID,
-				AmountFunctional:      acquisitionCost,
-				AmountTransactional:   acquisitionCost,
-				CurrencyTransactional: "USD",
-			},
-			{
-				AccountID:             offsetAcc.ID,
-				AmountFunctional:      acquisitionCost.Neg(),
-				AmountTransactional:   acquisitionCost.Neg(),
-				CurrencyTransactional: "USD",
-			},
+			{AccountID: assetAcc.ID, AmountFunctional: req.AcquisitionCost},
+			{AccountID: offsetAcc.ID, AmountFunctional: req.AcquisitionCost.Neg()},
 		}
-
-		entryID := utils.NewID("je")
-		entry := &domain.UniversalJournalEntry{
-			ID:               entryID,
-			LegalEntityID:    legalEntityID,
-			SourceModule:     "FM",
-			SourceDocumentID: asset.ID,
-			PostingDate:      time.Now(),
-			FinancialPeriod:  time.Now().Format("2006-01"),
-			Status:           domain.LedgerStatePOSTED,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-		}
-
-		for i := range lines {
-			lines[i].ID = utils.NewID("jel")
-			lines[i].JournalEntryID = entryID
-		}
-
-		if err := s.entries.Create(txCtx, entry, lines); err != nil {
+		if _, err := s.postEntry(txCtx, req.LegalEntityID, asset.ID, req.CapitalizationDate, lines); err != nil {
 			return err
 		}
 
@@ -170,30 +190,70 @@ func (s *CapitalAssetService) CapitalizeAsset(
 	return asset, nil
 }
 
+func validateDepreciationTerms(req *CapitalizeAssetRequest) error {
+	if !req.Method.IsValid() {
+		return fmt.Errorf("%w: unknown depreciation method %q", domain.ErrInvalidCapitalAsset, req.Method)
+	}
+	if !req.Convention.IsValid() {
+		return fmt.Errorf("%w: unknown depreciation convention %q", domain.ErrInvalidCapitalAsset, req.Convention)
+	}
+	if req.SalvageValue.IsNegative() || req.SalvageValue.GreaterThanOrEqual(req.AcquisitionCost) {
+		return fmt.Errorf("%w: salvage value must be between zero and the acquisition cost", domain.ErrInvalidCapitalAsset)
+	}
+	switch req.Method {
+	case domain.DepreciationMethodDECLINING_BALANCE:
+		if req.DecliningBalanceRate.IsZero() {
+			req.DecliningBalanceRate = decimal.NewFromInt(2)
+		}
+		if req.DecliningBalanceRate.IsNegative() {
+			return fmt.Errorf("%w: declining balance rate must be positive", domain.ErrInvalidCapitalAsset)
+		}
+	case domain.DepreciationMethodSUM_OF_YEARS_DIGITS:
+		if req.UsefulLifeMonths%12 != 0 {
+			return fmt.Errorf("%w: sum-of-years-digits needs a useful life in whole years", domain.ErrInvalidCapitalAsset)
+		}
+	case domain.DepreciationMethodUNITS_OF_PRODUCTION:
+		if !req.EstimatedTotalUnits.IsPositive() {
+			return fmt.Errorf("%w: units of production needs the estimated total units", domain.ErrInvalidCapitalAsset)
+		}
+	}
+	return nil
+}
+
+// GenerateDepreciationSchedule plans the monthly depreciation of an asset from its method and
+// convention. Units-of-production assets have no fixed plan; their lines are created from
+// recorded usage when the period is depreciated.
 func (s *CapitalAssetService) GenerateDepreciationSchedule(ctx context.Context, assetID string) ([]domain.DepreciationScheduleLine, error) {
 	asset, err := s.assetRepo.GetByID(ctx, assetID)
 	if err != nil {
 		return nil, err
 	}
+	if asset.DepreciationMethod == domain.DepreciationMethodUNITS_OF_PRODUCTION {
+		return nil, fmt.Errorf("%w: units-of-production assets are depreciated from recorded usage", domain.ErrInvalidCapitalAsset)
+	}
 
 	lines, err := s.lineRepo.GetByAssetID(ctx, assetID)
 	if err == nil && len(lines) > 0 {
+		sortScheduleLines(lines)
 		return lines, nil // Already generated
 	}
 
-	months := asset.UsefulLifeMonths
-	depAmount := asset.AcquisitionCost.Div(decimal.NewFromInt(int64(months)))
+	amounts := depreciationAmounts(asset)
+	start := asset.CapitalizationDate
+	if asset.DepreciationConvention == domain.DepreciationConventionNEXT_MONTH {
+		start = start.AddDate(0, 1, 0)
+	}
+	start = firstOfMonth(start)
 
-	scheduleLines := make([]domain.DepreciationScheduleLine, months)
-	startDate := asset.CapitalizationDate
-	for i := 0; i < months; i++ {
-		targetDate := startDate.AddDate(0, i, 0)
+	scheduleLines := make([]domain.DepreciationScheduleLine, len(amounts))
+	for i, amount := range amounts {
+		targetDate := start.AddDate(0, i, 0)
 		scheduleLines[i] = domain.DepreciationScheduleLine{
 			ID:                 utils.NewID("dsl"),
 			FixedAssetID:       assetID,
 			FiscalYear:         targetDate.Year(),
 			PeriodNumber:       int(targetDate.Month()),
-			DepreciationAmount: depAmount,
+			DepreciationAmount: amount,
 			IsPosted:           false,
 			CreatedAt:          time.Now(),
 			UpdatedAt:          time.Now(),
@@ -210,8 +270,73 @@ func (s *CapitalAssetService) GenerateDepreciationSchedule(ctx context.Context,
 	return scheduleLines, nil
 }
 
-func (s *CapitalAssetService) PostMonthlyStraightLineDepreciation(ctx context.Context, legalEntityID string, fiscalYear, periodNumber int) error {
+// RecordUsage adds the output of a units-of-production asset, e.g. machine hours or produced units
+func (s *CapitalAssetService) RecordUsage(ctx context.Context, assetID string, usageDate time.Time, units decimal.Decimal, source string) (*domain.AssetUsageRecord, error) {
+	asset, err := s.assetRepo.GetByID(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset.DepreciationMethod != domain.DepreciationMethodUNITS_OF_PRODUCTION {
+		return nil, fmt.Errorf("%w: asset %s is not depreciated by units of production", domain.ErrInvalidCapitalAsset, asset.AssetTag)
+	}
+	if asset.Status != domain.AssetStateACTIVE {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAssetNotActive, asset.AssetTag)
+	}
+	if !units.IsPositive() {
+		return nil, fmt.Errorf("%w: usage units must be positive", domain.ErrInvalidCapitalAsset)
+	}
+	if source == "" {
+		source = "MANUAL"
+	}
+
+	record := &domain.AssetUsageRecord{
+		ID:           utils.NewID("usage"),
+		FixedAssetID: asset.ID,
+		UsageDate:    usageDate,
+		Units:        units,
+		Source:       source,
+		CreatedAt:    time.Now(),
+	}
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.usageRepo.Create(txCtx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RecordEquipmentUsage books EAM telemetry against the active units-of-production asset linked
+// to the equipment. Usage of equipment without such an asset is ignored.
+func (s *CapitalAssetService) RecordEquipmentUsage(ctx context.Context, legalEntityID, equipmentID string, usageDate time.Time, units decimal.Decimal) error {
+	assets, err := s.assetRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range assets {
+		if a.EamEquipmentID == nil || *a.EamEquipmentID != equipmentID || a.LegalEntityID != legalEntityID ||
+			a.Status != domain.AssetStateACTIVE || a.DepreciationMethod != domain.DepreciationMethodUNITS_OF_PRODUCTION {
+			continue
+		}
+		_, err := s.RecordUsage(ctx, a.ID, usageDate, units, "EAM")
+		return err
+	}
+	return nil
+}
+
+func (s *CapitalAssetService) ListUsage(ctx context.Context, assetID string) ([]domain.AssetUsageRecord, error) {
+	return s.usageRepo.ListByAsset(ctx, assetID)
+}
+
+// PostMonthlyDepreciation posts the scheduled depreciation of a legal entity's active assets
+// for one period as a single journal entry. Units-of-production lines are created from the
+// usage recorded in the period first.
+func (s *CapitalAssetService) PostMonthlyDepreciation(ctx context.Context, legalEntityID string, fiscalYear, periodNumber int) error {
 	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.scheduleUsageDepreciation(txCtx, legalEntityID, fiscalYear, periodNumber); err != nil {
+			return err
+		}
+
 		lines, err := s.lineRepo.GetUnpostedByPeriod(txCtx, fiscalYear, periodNumber)
 		if err != nil {
 			return err
@@ -222,7 +347,6 @@ func (s *CapitalAssetService) PostMonthlyStraightLineDepreciation(ctx context.Co
 		}
 
 		var totalDepreciation decimal.Decimal
-		var processedLines []domain.DepreciationScheduleLine
 
 		for _, l := range lines {
 			asset, err := s.assetRepo.GetByID(txCtx, l.FixedAssetID)
@@ -230,93 +354,441 @@ func (s *CapitalAssetService) PostMonthlyStraightLineDepreciation(ctx context.Co
 				continue
 			}
 
-			if asset.LegalEntityID != legalEntityID {
+			if asset.LegalEntityID != legalEntityID || asset.Status != domain.AssetStateACTIVE {
 				continue
 			}
 
-			totalDepreciation = totalDepreciation.Add(l.DepreciationAmount)
-
-			// Update schedule line
-			l.IsPosted = true
-			l.UpdatedAt = time.Now()
-			if err := s.lineRepo.Update(txCtx, &l); err != nil {
+			if err := s.postScheduleLine(txCtx, asset, &l, l.DepreciationAmount); err != nil {
 				return err
 			}
+			totalDepreciation = totalDepreciation.Add(l.DepreciationAmount)
+		}
 
-			// Update asset accumulated depreciation
-			asset.AccumulatedDepreciation = asset.AccumulatedDepreciation.Add(l.DepreciationAmount)
-			if asset.AccumulatedDepreciation.GreaterThanOrEqual(asset.AcquisitionCost) {
-				asset.Status = domain.AssetStateDISPOSED // Mark fully depreciated/inactive
-			}
-			asset.UpdatedAt = time.Now()
-			if err := s.assetRepo.Update(txCtx, asset); err != nil {
-				return err
-			}
+		return s.postDepreciation(txCtx, legalEntityID, fiscalYear, periodNumber, totalDepreciation)
+	})
+}
 
-			processedLines = append(processedLines, l)
+// scheduleUsageDepreciation creates the period's schedule line of every active units-of-production
+// asset from its recorded usage, capped at the remaining depreciable amount.
+func (s *CapitalAssetService) scheduleUsageDepreciation(ctx context.Context, legalEntityID string, fiscalYear, periodNumber int) error {
+	assets, err := s.assetRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	var created []domain.DepreciationScheduleLine
+	for i := range assets {
+		asset := &assets[i]
+		if asset.LegalEntityID != legalEntityID || asset.Status != domain.AssetStateACTIVE ||
+			asset.DepreciationMethod != domain.DepreciationMethodUNITS_OF_PRODUCTION {
+			continue
+		}
+		line, err := s.usageLine(ctx, asset, fiscalYear, periodNumber)
+		if err != nil {
+			return err
 		}
+		if line != nil {
+			created = append(created, *line)
+		}
+	}
+	if len(created) == 0 {
+		return nil
+	}
+	return s.lineRepo.CreateMany(ctx, created)
+}
 
-		if totalDepreciation.IsZero() {
-			return nil
+// usageLine returns a new schedule line for the usage of a units-of-production asset in one
+// period, or nil if there is nothing to depreciate or the period already has a line.
+func (s *CapitalAssetService) usageLine(ctx context.Context, asset *domain.CapitalAsset, fiscalYear, periodNumber int) (*domain.DepreciationScheduleLine, error) {
+	existing, err := s.lineRepo.GetByAssetID(ctx, asset.ID)
+	if err != nil {
+		return nil, err
+	}
+	scheduled := decimal.Zero
+	for _, l := range existing {
+		if l.FiscalYear == fiscalYear && l.PeriodNumber == periodNumber {
+			return nil, nil
+		}
+		if !l.IsPosted {
+			scheduled = scheduled.Add(l.DepreciationAmount)
 		}
+	}
 
-		// Post consolidated depreciation journal entry
-		// Debit: Depreciation Expense (6040-001)
-		depExpenseAcc, err := s.getOrCreateAccount(txCtx, legalEntityID, "6040-001", "Depreciation Expense", "EXPENSE")
+	usage, err := s.usageRepo.ListByAsset(ctx, asset.ID)
+	if err != nil {
+		return nil, err
+	}
+	units := decimal.Zero
+	for _, u := range usage {
+		if u.UsageDate.Year() == fiscalYear && int(u.UsageDate.Month()) == periodNumber {
+			units = units.Add(u.Units)
+		}
+	}
+	if units.IsZero() {
+		return nil, nil
+	}
+
+	base := asset.AcquisitionCost.Sub(asset.SalvageValue)
+	remaining := base.Sub(asset.AccumulatedDepreciation).Sub(scheduled)
+	amount := decimal.Min(base.Mul(units).Div(asset.EstimatedTotalUnits).Round(2), remaining)
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+	return &domain.DepreciationScheduleLine{
+		ID:                 utils.NewID("dsl"),
+		FixedAssetID:       asset.ID,
+		FiscalYear:         fiscalYear,
+		PeriodNumber:       periodNumber,
+		DepreciationAmount: amount,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}, nil
+}
+
+// postScheduleLine marks a schedule line as posted with the given amount and adds it to the
+// asset's accumulated depreciation. The caller posts the journal entry.
+func (s *CapitalAssetService) postScheduleLine(ctx context.Context, asset *domain.CapitalAsset, line *domain.DepreciationScheduleLine, amount decimal.Decimal) error {
+	line.DepreciationAmount = amount
+	line.IsPosted = true
+	line.UpdatedAt = time.Now()
+	if err := s.lineRepo.Update(ctx, line); err != nil {
+		return err
+	}
+
+	asset.AccumulatedDepreciation = asset.AccumulatedDepreciation.Add(amount)
+	if asset.AccumulatedDepreciation.GreaterThanOrEqual(asset.AcquisitionCost.Sub(asset.SalvageValue)) {
+		asset.Status = domain.AssetStateFULLY_DEPRECIATED
+	}
+	asset.UpdatedAt = time.Now()
+	return s.assetRepo.Update(ctx, asset)
+}
+
+// postDepreciation books depreciation expense against accumulated depreciation at period end
+func (s *CapitalAssetService) postDepreciation(ctx context.Context, legalEntityID string, fiscalYear, periodNumber int, amount decimal.Decimal) error {
+	if amount.IsZero() {
+		return nil
+	}
+
+	// Debit: Depreciation Expense (6040-001)
+	depExpenseAcc, err := s.getOrCreateAccount(ctx, legalEntityID, depreciationExpenseAccount, "Depreciation Expense", "EXPENSE")
+	if err != nil {
+		return err
+	}
+	// Credit: Accumulated Depreciation (1590-001)
+	accumDepAcc, err := s.getOrCreateAccount(ctx, legalEntityID, accumulatedDepreciationAccount, "Accumulated Depreciation", "ASSET")
+	if err != nil {
+		return err
+	}
+
+	periodEnd := time.Date(fiscalYear, time.Month(periodNumber)+1, 0, 0, 0, 0, 0, time.UTC)
+	_, err = s.postEntry(ctx, legalEntityID, "", periodEnd, []domain.UniversalJournalLine{
+		{AccountID: depExpenseAcc.ID, AmountFunctional: amount},
+		{AccountID: accumDepAcc.ID, AmountFunctional: amount.Neg()},
+	})
+	return err
+}
+
+// DisposeAsset sells or scraps an asset. Depreciation still scheduled up to the disposal month is
+// posted first, honouring the asset's convention (a half month under HALF_MONTH, none in the
+// disposal month under NEXT_MONTH); later schedule lines are dropped. The asset's cost and
+// accumulated depreciation are then derecognized against the proceeds, and the difference is
+// posted as a gain or loss on disposal.
+func (s *CapitalAssetService) DisposeAsset(ctx context.Context, assetID string, req DisposeAssetRequest) (*domain.CapitalAsset, error) {
+	asset, err := s.assetRepo.GetByID(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Status == domain.AssetStateDISPOSED {
+		return nil, fmt.Errorf("%w: %s is already disposed", domain.ErrAssetNotActive, asset.AssetTag)
+	}
+	if req.DisposalDate.IsZero() {
+		req.DisposalDate = time.Now()
+	}
+	if req.DisposalDate.Before(firstOfMonth(asset.CapitalizationDate)) {
+		return nil, fmt.Errorf("%w: disposal date is before capitalization", domain.ErrInvalidCapitalAsset)
+	}
+	if req.Proceeds.IsNegative() {
+		return nil, fmt.Errorf("%w: proceeds cannot be negative", domain.ErrInvalidCapitalAsset)
+	}
+	if req.ProceedsAccountID != "" {
+		acc, err := s.accounts.GetByID(ctx, req.ProceedsAccountID)
+		if err != nil || acc.LegalEntityID != asset.LegalEntityID {
+			return nil, fmt.Errorf("%w: proceeds account %s does not belong to legal entity %s", domain.ErrInvalidCapitalAsset, req.ProceedsAccountID, asset.LegalEntityID)
+		}
+	}
+
+	year, period := req.DisposalDate.Year(), int(req.DisposalDate.Month())
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		catchUp, err := s.settleSchedule(txCtx, asset, year, period)
 		if err != nil {
 			return err
 		}
-		// Credit: Accumulated Depreciation (1590-001)
-		accumDepAcc, err := s.getOrCreateAccount(txCtx, legalEntityID, "1590-001", "Accumulated Depreciation", "ASSET")
+		if err := s.postDepreciation(txCtx, asset.LegalEntityID, year, period, catchUp); err != nil {
+			return err
+		}
+
+		lines, gainLoss, err := s.disposalLines(txCtx, asset, req)
+		if err != nil {
+			return err
+		}
+		entry, err := s.postEntry(txCtx, asset.LegalEntityID, asset.ID, req.DisposalDate, lines)
 		if err != nil {
 			return err
 		}
 
-		jeLines := []domain.UniversalJournalLine{
-			{
-				AccountID:             depExpenseAcc.ID,
-				AmountFunctional:      totalDepreciation,
-				AmountTransactional:   totalDepreciation,
-				CurrencyTransactional: "USD",
-			},
-			{
-				AccountID:             accumDepAcc.ID,
-				AmountFunctional:      totalDepreciation.Neg(),
-				AmountTransactional:   totalDepreciation.Neg(),
-				CurrencyTransactional: "USD",
+		disposalDate := req.DisposalDate
+		asset.Status = domain.AssetStateDISPOSED
+		asset.DisposalDate = &disposalDate
+		asset.DisposalProceeds = req.Proceeds
+		asset.DisposalEntryID = &entry.ID
+		asset.UpdatedAt = time.Now()
+		if err := s.assetRepo.Update(txCtx, asset); err != nil {
+			return err
+		}
+
+		outboxRec := &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   string(domain.TopicFmAssetDisposed),
+			AggregateID: asset.ID,
+			Payload: domain.AssetDisposedEventPayload{
+				AssetID:        asset.ID,
+				LegalEntityID:  asset.LegalEntityID,
+				Proceeds:       req.Proceeds,
+				GainLoss:       gainLoss,
+				JournalEntryID: entry.ID,
+				Timestamp:      time.Now(),
 			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: time.Now(),
 		}
+		return s.outbox.Create(txCtx, outboxRec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// settleSchedule posts the asset's outstanding schedule lines up to the disposal month and drops
+// the rest, returning the depreciation still to be booked.
+func (s *CapitalAssetService) settleSchedule(ctx context.Context, asset *domain.CapitalAsset, year, period int) (decimal.Decimal, error) {
+	if asset.Status == domain.AssetStateACTIVE && asset.DepreciationMethod == domain.DepreciationMethodUNITS_OF_PRODUCTION {
+		line, err := s.usageLine(ctx, asset, year, period)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if line != nil {
+			if err := s.lineRepo.CreateMany(ctx, []domain.DepreciationScheduleLine{*line}); err != nil {
+				return decimal.Zero, err
+			}
+		}
+	}
 
-		entryID := utils.NewID("je")
-		entry := &domain.UniversalJournalEntry{
-			ID:               entryID,
-			LegalEntityID:    legalEntityID,
-			SourceModule:     "FM",
-			SourceDocumentID: entryID, // Self reference
-			PostingDate:      time.Now(),
-			FinancialPeriod:  fmt.Sprintf("%04d-%02d", fiscalYear, periodNumber),
-			Status:           domain.LedgerStatePOSTED,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
+	lines, err := s.lineRepo.GetByAssetID(ctx, asset.ID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	disposalMonth := year*12 + period
+	catchUp := decimal.Zero
+	for _, l := range lines {
+		if l.IsPosted {
+			continue
+		}
+		amount := l.DepreciationAmount
+		switch month := l.FiscalYear*12 + l.PeriodNumber; {
+		case month > disposalMonth:
+			amount = decimal.Zero
+		case month == disposalMonth && asset.DepreciationConvention == domain.DepreciationConventionHALF_MONTH:
+			amount = amount.Div(decimal.NewFromInt(2)).Round(2)
+		case month == disposalMonth && asset.DepreciationConvention == domain.DepreciationConventionNEXT_MONTH:
+			amount = decimal.Zero
+		}
+		if amount.IsZero() {
+			if err := s.lineRepo.Delete(ctx, l.ID); err != nil {
+				return decimal.Zero, err
+			}
+			continue
 		}
+		if err := s.postScheduleLine(ctx, asset, &l, amount); err != nil {
+			return decimal.Zero, err
+		}
+		catchUp = catchUp.Add(amount)
+	}
+	return catchUp, nil
+}
 
-		for i := range jeLines {
-			jeLines[i].ID = utils.NewID("jel")
-			jeLines[i].JournalEntryID = entryID
+// disposalLines derecognizes cost and accumulated depreciation against the proceeds and returns
+// the gain (positive) or loss (negative) on disposal.
+func (s *CapitalAssetService) disposalLines(ctx context.Context, asset *domain.CapitalAsset, req DisposeAssetRequest) ([]domain.UniversalJournalLine, decimal.Decimal, error) {
+	le := asset.LegalEntityID
+	assetAcc, err := s.getOrCreateAccount(ctx, le, fixedAssetAccount, "Fixed Assets - Equipment", "ASSET")
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	accumDepAcc, err := s.getOrCreateAccount(ctx, le, accumulatedDepreciationAccount, "Accumulated Depreciation", "ASSET")
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	lines := []domain.UniversalJournalLine{{AccountID: assetAcc.ID, AmountFunctional: asset.AcquisitionCost.Neg()}}
+	if asset.AccumulatedDepreciation.IsPositive() {
+		lines = append(lines, domain.UniversalJournalLine{AccountID: accumDepAcc.ID, AmountFunctional: asset.AccumulatedDepreciation})
+	}
+	if req.Proceeds.IsPositive() {
+		proceedsAccountID := req.ProceedsAccountID
+		if proceedsAccountID == "" {
+			bank, err := s.getOrCreateAccount(ctx, le, assetDisposalProceedsAccount, "Bank", "ASSET")
+			if err != nil {
+				return nil, decimal.Zero, err
+			}
+			proceedsAccountID = bank.ID
 		}
+		lines = append(lines, domain.UniversalJournalLine{AccountID: proceedsAccountID, AmountFunctional: req.Proceeds})
+	}
 
-		if err := s.entries.Create(txCtx, entry, jeLines); err != nil {
-			return err
+	netBookValue := asset.AcquisitionCost.Sub(asset.AccumulatedDepreciation)
+	gainLoss := req.Proceeds.Sub(netBookValue)
+	switch {
+	case gainLoss.IsPositive():
+		gain, err := s.getOrCreateAccount(ctx, le, assetDisposalGainAccount, "Gain on Asset Disposal", "REVENUE")
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+		lines = append(lines, domain.UniversalJournalLine{AccountID: gain.ID, AmountFunctional: gainLoss.Neg()})
+	case gainLoss.IsNegative():
+		loss, err := s.getOrCreateAccount(ctx, le, assetDisposalLossAccount, "Loss on Asset Disposal", "EXPENSE")
+		if err != nil {
+			return nil, decimal.Zero, err
 		}
+		lines = append(lines, domain.UniversalJournalLine{AccountID: loss.ID, AmountFunctional: gainLoss.Neg()})
+	}
+	return lines, gainLoss, nil
+}
 
-		return nil
-	})
+// postEntry writes a posted FM journal entry in the functional currency. An empty
+// sourceDocumentID makes the entry reference itself.
+func (s *CapitalAssetService) postEntry(ctx context.Context, legalEntityID, sourceDocumentID string, postingDate time.Time, lines []domain.UniversalJournalLine) (*domain.UniversalJournalEntry, error) {
+	entryID := utils.NewID("je")
+	if sourceDocumentID == "" {
+		sourceDocumentID = entryID
+	}
+	entry := &domain.UniversalJournalEntry{
+		ID:               entryID,
+		LegalEntityID:    legalEntityID,
+		SourceModule:     "FM",
+		SourceDocumentID: sourceDocumentID,
+		PostingDate:      postingDate,
+		FinancialPeriod:  postingDate.Format("2006-01"),
+		Status:           domain.LedgerStatePOSTED,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	for i := range lines {
+		lines[i].ID = utils.NewID("jel")
+		lines[i].JournalEntryID = entryID
+		lines[i].AmountTransactional = lines[i].AmountFunctional
+		lines[i].CurrencyTransactional = "USD"
+	}
+
+	if err := s.entries.Create(ctx, entry, lines); err != nil {
+		return nil, err
+	}
+	return entry, nil
 }
 
 func (s *CapitalAssetService) GetAsset(ctx context.Context, id string) (*domain.CapitalAsset, error) {
 	return s.assetRepo.GetByID(ctx, id)
 }
 
-func (s *CapitalAssetService) ListAssets(ctx context.Context) ([]domain.CapitalAsset, error) {
-	return s.assetRepo.List(ctx)
+// ListAssets returns the capital assets, optionally of a single legal entity.
+func (s *CapitalAssetService) ListAssets(ctx context.Context, legalEntityID string) ([]domain.CapitalAsset, error) {
+	assets, err := s.assetRepo.List(ctx)
+	if err != nil || legalEntityID == "" {
+		return assets, err
+	}
+	list := make([]domain.CapitalAsset, 0, len(assets))
+	for _, asset := range assets {
+		if asset.LegalEntityID == legalEntityID {
+			list = append(list, asset)
+		}
+	}
+	return list, nil
+}
+
+// depreciationAmounts returns the monthly depreciation of an asset in calendar order, starting
+// with the first depreciated month. Amounts are rounded to cents and add up to cost less salvage.
+func depreciationAmounts(asset *domain.CapitalAsset) []decimal.Decimal {
+	n := asset.UsefulLifeMonths
+	base := asset.AcquisitionCost.Sub(asset.SalvageValue)
+	life := make([]decimal.Decimal, 0, n)
+
+	switch asset.DepreciationMethod {
+	case domain.DepreciationMethodDECLINING_BALANCE:
+		// Yearly declining balance on net book value, switching to straight-line once that is
+		// higher, spread evenly over the months of each year of life
+		nbv := asset.AcquisitionCost
+		for remaining := n; remaining > 0; {
+			months := min(12, remaining)
+			dep := nbv.Sub(asset.SalvageValue)
+			if remaining > months {
+				declining := nbv.Mul(asset.DecliningBalanceRate).Mul(decimal.NewFromInt(int64(months))).Div(decimal.NewFromInt(int64(n)))
+				straight := nbv.Sub(asset.SalvageValue).Mul(decimal.NewFromInt(int64(months))).Div(decimal.NewFromInt(int64(remaining)))
+				dep = decimal.Min(decimal.Max(declining, straight), dep)
+			}
+			for i := 0; i < months; i++ {
+				life = append(life, dep.Div(decimal.NewFromInt(int64(months))))
+			}
+			nbv = nbv.Sub(dep)
+			remaining -= months
+		}
+	case domain.DepreciationMethodSUM_OF_YEARS_DIGITS:
+		years := int64(n / 12)
+		digits := decimal.NewFromInt(years * (years + 1) / 2)
+		for y := years; y > 0; y-- {
+			monthly := base.Mul(decimal.NewFromInt(y)).Div(digits).Div(decimal.NewFromInt(12))
+			for i := 0; i < 12; i++ {
+				life = append(life, monthly)
+			}
+		}
+	default:
+		monthly := base.Div(decimal.NewFromInt(int64(n)))
+		for i := 0; i < n; i++ {
+			life = append(life, monthly)
+		}
+	}
+
+	// Half-month convention: half a month in the acquisition month, the other half after the last
+	amounts := life
+	if asset.DepreciationConvention == domain.DepreciationConventionHALF_MONTH {
+		half := decimal.NewFromFloat(0.5)
+		amounts = make([]decimal.Decimal, n+1)
+		for i := range amounts {
+			if i < n {
+				amounts[i] = amounts[i].Add(life[i].Mul(half))
+			}
+			if i > 0 {
+				amounts[i] = amounts[i].Add(life[i-1].Mul(half))
+			}
+		}
+	}
+
+	total := decimal.Zero
+	for i := range amounts {
+		if i == len(amounts)-1 {
+			amounts[i] = base.Sub(total)
+			break
+		}
+		amounts[i] = amounts[i].Round(2)
+		total = total.Add(amounts[i])
+	}
+	return amounts
+}
+
+func sortScheduleLines(lines []domain.DepreciationScheduleLine) {
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].FiscalYear != lines[j].FiscalYear {
+			return lines[i].FiscalYear < lines[j].FiscalYear
+		}
+		return lines[i].PeriodNumber < lines[j].PeriodNumber
+	})
 }
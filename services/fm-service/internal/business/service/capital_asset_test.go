@@ -0,0 +1,375 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+const assetLegalEntity = "legal_assets"
+
+// assetBalance sums the posted functional amounts on the legal entity's account with the given code
+func assetBalance(t *testing.T, accounts *memory.MemoryChartOfAccountsRepo, entries *memory.MemoryUniversalJournalEntryRepo, code string) decimal.Decimal {
+	t.Helper()
+	ctx := context.Background()
+	acc, err := accounts.GetByCode(ctx, assetLegalEntity, code)
+	if err != nil {
+		return decimal.Zero
+	}
+	list, _ := entries.List(ctx)
+	total := decimal.Zero
+	for _, entry := range list {
+		_, lines, _ := entries.GetByID(ctx, entry.ID)
+		for _, l := range lines {
+			if l.AccountID == acc.ID {
+				total = total.Add(l.AmountFunctional)
+			}
+		}
+	}
+	return total
+}
+
+func sumSchedule(lines []domain.DepreciationScheduleLine) decimal.Decimal {
+	total := decimal.Zero
+	for _, l := range lines {
+		total = total.Add(l.DepreciationAmount)
+	}
+	return total
+}
+
+func TestCapitalAsset_ValidatesDepreciationTerms(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	base := service.CapitalizeAssetRequest{LegalEntityID: assetLegalEntity, AssetTag: "EQ-1", AcquisitionCost: decimal.NewFromInt(1000), UsefulLifeMonths: 18}
+
+	cases := map[string]func(r *service.CapitalizeAssetRequest){
+		"salvage not below cost":     func(r *service.CapitalizeAssetRequest) { r.SalvageValue = decimal.NewFromInt(1000) },
+		"negative salvage":           func(r *service.CapitalizeAssetRequest) { r.SalvageValue = decimal.NewFromInt(-1) },
+		"unknown method":             func(r *service.CapitalizeAssetRequest) { r.Method = "ANNUITY" },
+		"unknown convention":         func(r *service.CapitalizeAssetRequest) { r.Convention = "MID_YEAR" },
+		"partial years for SYD":      func(r *service.CapitalizeAssetRequest) { r.Method = domain.DepreciationMethodSUM_OF_YEARS_DIGITS },
+		"units of production no cap": func(r *service.CapitalizeAssetRequest) { r.Method = domain.DepreciationMethodUNITS_OF_PRODUCTION },
+	}
+	for name, mutate := range cases {
+		req := base
+		mutate(&req)
+		if _, err := svc.CapitalizeAsset(ctx, req); !errors.Is(err, domain.ErrInvalidCapitalAsset) {
+			t.Errorf("%s: expected ErrInvalidCapitalAsset, got %v", name, err)
+		}
+	}
+}
+
+func TestCapitalAsset_DecliningBalanceSchedule(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	asset, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{
+		LegalEntityID:      assetLegalEntity,
+		AssetTag:           "TRUCK-1",
+		AcquisitionCost:    decimal.NewFromInt(10000),
+		SalvageValue:       decimal.NewFromInt(1000),
+		UsefulLifeMonths:   60,
+		CapitalizationDate: day(2026, 1, 10),
+		Method:             domain.DepreciationMethodDECLINING_BALANCE,
+	})
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	if !asset.DecliningBalanceRate.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("expected double-declining rate by default, got %s", asset.DecliningBalanceRate)
+	}
+
+	lines, err := svc.GenerateDepreciationSchedule(ctx, asset.ID)
+	if err != nil || len(lines) != 60 {
+		t.Fatalf("expected 60 schedule lines, got %d (%v)", len(lines), err)
+	}
+	// 40% of net book value per year: 4000, 2400, 1440, 864, then the rest down to salvage
+	if !lines[0].DepreciationAmount.Equal(decimal.RequireFromString("333.33")) {
+		t.Errorf("expected 333.33 in the first month, got %s", lines[0].DepreciationAmount)
+	}
+	assertAmount(t, "year 2 monthly", lines[12].DepreciationAmount, 200)
+	assertAmount(t, "year 3 monthly", lines[24].DepreciationAmount, 120)
+	assertAmount(t, "year 4 monthly", lines[36].DepreciationAmount, 72)
+	assertAmount(t, "total depreciation", sumSchedule(lines), 9000)
+	if lines[0].FiscalYear != 2026 || lines[0].PeriodNumber != 1 || lines[59].FiscalYear != 2030 || lines[59].PeriodNumber != 12 {
+		t.Errorf("unexpected schedule range %d-%d to %d-%d", lines[0].FiscalYear, lines[0].PeriodNumber, lines[59].FiscalYear, lines[59].PeriodNumber)
+	}
+}
+
+func TestCapitalAsset_SumOfYearsDigitsSchedule(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	asset, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{
+		LegalEntityID:      assetLegalEntity,
+		AssetTag:           "PRESS-1",
+		AcquisitionCost:    decimal.NewFromInt(3600),
+		UsefulLifeMonths:   36,
+		CapitalizationDate: day(2026, 1, 1),
+		Method:             domain.DepreciationMethodSUM_OF_YEARS_DIGITS,
+	})
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	lines, err := svc.GenerateDepreciationSchedule(ctx, asset.ID)
+	if err != nil || len(lines) != 36 {
+		t.Fatalf("expected 36 schedule lines, got %d (%v)", len(lines), err)
+	}
+	// Digits 3+2+1: 1800, 1200 and 600 per year
+	assertAmount(t, "year 1 monthly", lines[0].DepreciationAmount, 150)
+	assertAmount(t, "year 2 monthly", lines[12].DepreciationAmount, 100)
+	assertAmount(t, "year 3 monthly", lines[35].DepreciationAmount, 50)
+	assertAmount(t, "total depreciation", sumSchedule(lines), 3600)
+}
+
+func TestCapitalAsset_PartialMonthConventions(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	req := service.CapitalizeAssetRequest{
+		LegalEntityID:      assetLegalEntity,
+		AssetTag:           "LAPTOP-1",
+		AcquisitionCost:    decimal.NewFromInt(1300),
+		SalvageValue:       decimal.NewFromInt(100),
+		UsefulLifeMonths:   12,
+		CapitalizationDate: day(2026, 3, 15),
+		Convention:         domain.DepreciationConventionHALF_MONTH,
+	}
+	half, err := svc.CapitalizeAsset(ctx, req)
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	lines, err := svc.GenerateDepreciationSchedule(ctx, half.ID)
+	if err != nil || len(lines) != 13 {
+		t.Fatalf("expected 13 schedule lines for the half-month convention, got %d (%v)", len(lines), err)
+	}
+	assertAmount(t, "half first month", lines[0].DepreciationAmount, 50)
+	assertAmount(t, "full month", lines[1].DepreciationAmount, 100)
+	assertAmount(t, "half last month", lines[12].DepreciationAmount, 50)
+	assertAmount(t, "depreciable base", sumSchedule(lines), 1200)
+	if lines[12].FiscalYear != 2027 || lines[12].PeriodNumber != 3 {
+		t.Errorf("expected the last half month in 2027-03, got %d-%d", lines[12].FiscalYear, lines[12].PeriodNumber)
+	}
+
+	req.AssetTag = "LAPTOP-2"
+	req.Convention = domain.DepreciationConventionNEXT_MONTH
+	next, err := svc.CapitalizeAsset(ctx, req)
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	lines, err = svc.GenerateDepreciationSchedule(ctx, next.ID)
+	if err != nil || len(lines) != 12 {
+		t.Fatalf("expected 12 schedule lines, got %d (%v)", len(lines), err)
+	}
+	if lines[0].PeriodNumber != 4 {
+		t.Errorf("expected depreciation to start in April, got period %d", lines[0].PeriodNumber)
+	}
+}
+
+func TestCapitalAsset_UnitsOfProduction(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	equipmentID := "eq-crane-1"
+	asset, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{
+		LegalEntityID:       assetLegalEntity,
+		AssetTag:            "CRANE-1",
+		AcquisitionCost:     decimal.NewFromInt(10000),
+		UsefulLifeMonths:    120,
+		EquipmentID:         &equipmentID,
+		CapitalizationDate:  day(2026, 1, 5),
+		Method:              domain.DepreciationMethodUNITS_OF_PRODUCTION,
+		EstimatedTotalUnits: decimal.NewFromInt(1000),
+	})
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	if _, err := svc.GenerateDepreciationSchedule(ctx, asset.ID); !errors.Is(err, domain.ErrInvalidCapitalAsset) {
+		t.Errorf("expected no fixed schedule for units of production, got %v", err)
+	}
+
+	if _, err := svc.RecordUsage(ctx, asset.ID, day(2026, 1, 10), decimal.NewFromInt(100), ""); err != nil {
+		t.Fatalf("record usage failed: %v", err)
+	}
+	if err := svc.RecordEquipmentUsage(ctx, assetLegalEntity, equipmentID, day(2026, 1, 20), decimal.NewFromInt(50)); err != nil {
+		t.Fatalf("record equipment usage failed: %v", err)
+	}
+	if err := svc.RecordEquipmentUsage(ctx, assetLegalEntity, "eq-unknown", day(2026, 1, 20), decimal.NewFromInt(50)); err != nil {
+		t.Fatalf("usage of unlinked equipment should be ignored, got %v", err)
+	}
+	usage, _ := svc.ListUsage(ctx, asset.ID)
+	if len(usage) != 2 || usage[1].Source != "EAM" {
+		t.Fatalf("expected a manual and an EAM usage record, got %+v", usage)
+	}
+
+	// 150 of 1000 units
+	for i := 0; i < 2; i++ {
+		if err := svc.PostMonthlyDepreciation(ctx, assetLegalEntity, 2026, 1); err != nil {
+			t.Fatalf("post depreciation failed: %v", err)
+		}
+	}
+	got, _ := svc.GetAsset(ctx, asset.ID)
+	assertAmount(t, "accumulated after January", got.AccumulatedDepreciation, 1500)
+	assertAmount(t, "depreciation expense", assetBalance(t, accounts, entries, "6040-001"), 1500)
+
+	// 900 more units exceed the estimate; depreciation stops at the depreciable base
+	if _, err := svc.RecordUsage(ctx, asset.ID, day(2026, 2, 3), decimal.NewFromInt(900), ""); err != nil {
+		t.Fatalf("record usage failed: %v", err)
+	}
+	if err := svc.PostMonthlyDepreciation(ctx, assetLegalEntity, 2026, 2); err != nil {
+		t.Fatalf("post depreciation failed: %v", err)
+	}
+	got, _ = svc.GetAsset(ctx, asset.ID)
+	assertAmount(t, "accumulated after February", got.AccumulatedDepreciation, 10000)
+	if got.Status != domain.AssetStateFULLY_DEPRECIATED {
+		t.Errorf("expected FULLY_DEPRECIATED, got %s", got.Status)
+	}
+	if _, err := svc.RecordUsage(ctx, asset.ID, day(2026, 3, 1), decimal.NewFromInt(1), ""); !errors.Is(err, domain.ErrAssetNotActive) {
+		t.Errorf("expected ErrAssetNotActive for usage on a fully depreciated asset, got %v", err)
+	}
+}
+
+func TestCapitalAsset_DisposeWithGain(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	asset, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{
+		LegalEntityID:      assetLegalEntity,
+		AssetTag:           "VAN-1",
+		AcquisitionCost:    decimal.NewFromInt(1200),
+		UsefulLifeMonths:   12,
+		CapitalizationDate: day(2026, 1, 10),
+	})
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	if _, err := svc.GenerateDepreciationSchedule(ctx, asset.ID); err != nil {
+		t.Fatalf("schedule failed: %v", err)
+	}
+	for period := 1; period <= 2; period++ {
+		if err := svc.PostMonthlyDepreciation(ctx, assetLegalEntity, 2026, period); err != nil {
+			t.Fatalf("post depreciation failed: %v", err)
+		}
+	}
+
+	// March and April are caught up before the sale, leaving a net book value of 800
+	disposed, err := svc.DisposeAsset(ctx, asset.ID, service.DisposeAssetRequest{
+		DisposalDate: day(2026, 4, 20),
+		Proceeds:     decimal.NewFromInt(1000),
+	})
+	if err != nil {
+		t.Fatalf("dispose failed: %v", err)
+	}
+	if disposed.Status != domain.AssetStateDISPOSED || disposed.DisposalEntryID == nil || disposed.DisposalDate == nil {
+		t.Errorf("expected a disposed asset with its disposal entry, got %+v", disposed)
+	}
+	assertAmount(t, "accumulated depreciation", disposed.AccumulatedDepreciation, 400)
+	assertAmount(t, "fixed assets", assetBalance(t, accounts, entries, "1500-001"), 0)
+	assertAmount(t, "accumulated depreciation account", assetBalance(t, accounts, entries, "1590-001"), 0)
+	assertAmount(t, "bank", assetBalance(t, accounts, entries, "1010-001"), 1000)
+	assertAmount(t, "gain on disposal", assetBalance(t, accounts, entries, "7930-001"), -200)
+	assertAmount(t, "depreciation expense", assetBalance(t, accounts, entries, "6040-001"), 400)
+
+	lines, _ := scheduleLines.GetByAssetID(ctx, asset.ID)
+	if len(lines) != 4 {
+		t.Errorf("expected schedule lines after the disposal month to be dropped, got %d lines", len(lines))
+	}
+	for _, l := range lines {
+		if !l.IsPosted {
+			t.Errorf("expected line %d-%d to be posted", l.FiscalYear, l.PeriodNumber)
+		}
+	}
+
+	pending, _ := outbox.GetPending(ctx, 100)
+	var events int
+	for _, rec := range pending {
+		if rec.EventType == string(domain.TopicFmAssetDisposed) {
+			events++
+		}
+	}
+	if events != 1 {
+		t.Errorf("expected one %s event, got %d", domain.TopicFmAssetDisposed, events)
+	}
+
+	if _, err := svc.DisposeAsset(ctx, asset.ID, service.DisposeAssetRequest{DisposalDate: day(2026, 5, 1)}); !errors.Is(err, domain.ErrAssetNotActive) {
+		t.Errorf("expected ErrAssetNotActive disposing twice, got %v", err)
+	}
+}
+
+func TestCapitalAsset_ScrapWithLossUsesHalfMonth(t *testing.T) {
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRecords := memory.NewMemoryAssetUsageRecordRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(assets, scheduleLines, usageRecords, accounts, entries, outbox)
+	svc := service.NewCapitalAssetService(assets, scheduleLines, usageRecords, accounts, entries, outbox, tm)
+	ctx := context.Background()
+	asset, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{
+		LegalEntityID:      assetLegalEntity,
+		AssetTag:           "SERVER-1",
+		AcquisitionCost:    decimal.NewFromInt(1200),
+		UsefulLifeMonths:   12,
+		CapitalizationDate: day(2026, 1, 10),
+		Convention:         domain.DepreciationConventionHALF_MONTH,
+	})
+	if err != nil {
+		t.Fatalf("capitalize failed: %v", err)
+	}
+	if _, err := svc.GenerateDepreciationSchedule(ctx, asset.ID); err != nil {
+		t.Fatalf("schedule failed: %v", err)
+	}
+
+	// January 50, February 100 and half of March
+	disposed, err := svc.DisposeAsset(ctx, asset.ID, service.DisposeAssetRequest{DisposalDate: day(2026, 3, 15)})
+	if err != nil {
+		t.Fatalf("dispose failed: %v", err)
+	}
+	assertAmount(t, "accumulated depreciation", disposed.AccumulatedDepreciation, 200)
+	assertAmount(t, "loss on disposal", assetBalance(t, accounts, entries, "8930-001"), 1000)
+	assertAmount(t, "fixed assets", assetBalance(t, accounts, entries, "1500-001"), 0)
+	assertAmount(t, "bank", assetBalance(t, accounts, entries, "1010-001"), 0)
+}
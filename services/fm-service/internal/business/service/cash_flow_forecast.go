@@ -0,0 +1,271 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// ForecastGranularity selects the bucket size of a cash flow forecast
+type ForecastGranularity string
+
+const (
+	ForecastGranularityWEEK  ForecastGranularity = "WEEK"
+	ForecastGranularityMONTH ForecastGranularity = "MONTH"
+)
+
+// IsValid returns true if the ForecastGranularity is valid
+func (g ForecastGranularity) IsValid() bool {
+	return g == ForecastGranularityWEEK || g == ForecastGranularityMONTH
+}
+
+const (
+	defaultForecastMonths = 3
+	maxForecastMonths     = 24
+	// payrollHistoryRuns is how many recent payroll runs are averaged to project future payroll
+	payrollHistoryRuns = 3
+)
+
+// CashFlowForecastRequest scopes a forecast. Empty filters include every legal entity and bank account.
+type CashFlowForecastRequest struct {
+	MonthsAhead   int
+	Granularity   ForecastGranularity
+	LegalEntityID string
+	BankAccountID string
+	AsOf          time.Time
+}
+
+// CashFlowPeriod is one forecast bucket. Outflow fields are positive amounts.
+type CashFlowPeriod struct {
+	PeriodStart       time.Time       `json:"period_start"`
+	PeriodEnd         time.Time       `json:"period_end"`
+	OpeningBalance    decimal.Decimal `json:"opening_balance"`
+	Receivables       decimal.Decimal `json:"receivables"`
+	RecurringInflows  decimal.Decimal `json:"recurring_inflows"`
+	Payables          decimal.Decimal `json:"payables"`
+	Payroll           decimal.Decimal `json:"payroll"`
+	RecurringOutflows decimal.Decimal `json:"recurring_outflows"`
+	TotalInflows      decimal.Decimal `json:"total_inflows"`
+	TotalOutflows     decimal.Decimal `json:"total_outflows"`
+	NetCashFlow       decimal.Decimal `json:"net_cash_flow"`
+	ClosingBalance    decimal.Decimal `json:"closing_balance"`
+}
+
+// BankAccountCashFlowForecast projects a single bank account from its current liquid balance
+type BankAccountCashFlowForecast struct {
+	BankAccountID  string           `json:"bank_account_id"`
+	AccountNumber  string           `json:"account_number"`
+	Currency       string           `json:"currency"`
+	OpeningBalance decimal.Decimal  `json:"opening_balance"`
+	Periods        []CashFlowPeriod `json:"periods"`
+}
+
+// LegalEntityCashFlowForecast aggregates the bank accounts of one legal entity. Flows of an
+// entity without bank accounts are still projected, starting from a zero balance.
+type LegalEntityCashFlowForecast struct {
+	LegalEntityID  string                        `json:"legal_entity_id"`
+	OpeningBalance decimal.Decimal               `json:"opening_balance"`
+	Periods        []CashFlowPeriod              `json:"periods"`
+	BankAccounts   []BankAccountCashFlowForecast `json:"bank_accounts"`
+}
+
+// CashFlowForecast is the result of CashManagementService.GetCashFlowForecast
+type CashFlowForecast struct {
+	AsOf          time.Time                     `json:"as_of"`
+	MonthsAhead   int                           `json:"months_ahead"`
+	Granularity   ForecastGranularity           `json:"granularity"`
+	LegalEntities []LegalEntityCashFlowForecast `json:"legal_entities"`
+}
+
+type cashFlowCategory int
+
+const (
+	flowReceivable cashFlowCategory = iota
+	flowPayable
+	flowPayroll
+	flowRecurring
+)
+
+// cashFlow is a single expected receipt (positive) or disbursement (negative)
+type cashFlow struct {
+	legalEntityID string
+	bankAccountID string
+	date          time.Time
+	category      cashFlowCategory
+	amount        decimal.Decimal
+}
+
+type forecastBucket struct {
+	start time.Time
+	end   time.Time // exclusive
+}
+
+// forecastBuckets splits [asOf, asOf+monthsAhead) into weekly or monthly buckets.
+func forecastBuckets(asOf time.Time, monthsAhead int, granularity ForecastGranularity) []forecastBucket {
+	horizon := asOf.AddDate(0, monthsAhead, 0)
+	var buckets []forecastBucket
+	for start := asOf; start.Before(horizon); {
+		var end time.Time
+		if granularity == ForecastGranularityWEEK {
+			end = start.AddDate(0, 0, 7)
+		} else {
+			end = time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, start.Location())
+		}
+		if end.After(horizon) {
+			end = horizon
+		}
+		buckets = append(buckets, forecastBucket{start: start, end: end})
+		start = end
+	}
+	return buckets
+}
+
+// bucketIndex returns the bucket a flow falls into. Overdue flows are expected in the first bucket;
+// flows past the horizon return -1.
+func bucketIndex(buckets []forecastBucket, date time.Time) int {
+	if len(buckets) == 0 || !date.Before(buckets[len(buckets)-1].end) {
+		return -1
+	}
+	for i, b := range buckets {
+		if date.Before(b.end) {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildPeriods rolls flows through the buckets starting from the opening balance.
+func buildPeriods(buckets []forecastBucket, opening decimal.Decimal, flows []cashFlow) []CashFlowPeriod {
+	periods := make([]CashFlowPeriod, len(buckets))
+	for i, b := range buckets {
+		periods[i] = CashFlowPeriod{
+			PeriodStart: b.start,
+			PeriodEnd:   b.end.AddDate(0, 0, -1),
+		}
+	}
+	for _, f := range flows {
+		idx := bucketIndex(buckets, f.date)
+		if idx < 0 {
+			continue
+		}
+		p := &periods[idx]
+		switch f.category {
+		case flowReceivable:
+			p.Receivables = p.Receivables.Add(f.amount)
+		case flowPayable:
+			p.Payables = p.Payables.Add(f.amount.Neg())
+		case flowPayroll:
+			p.Payroll = p.Payroll.Add(f.amount.Neg())
+		case flowRecurring:
+			if f.amount.IsNegative() {
+				p.RecurringOutflows = p.RecurringOutflows.Add(f.amount.Neg())
+			} else {
+				p.RecurringInflows = p.RecurringInflows.Add(f.amount)
+			}
+		}
+	}
+
+	balance := opening
+	for i := range periods {
+		p := &periods[i]
+		p.OpeningBalance = balance
+		p.TotalInflows = p.Receivables.Add(p.RecurringInflows)
+		p.TotalOutflows = p.Payables.Add(p.Payroll).Add(p.RecurringOutflows)
+		p.NetCashFlow = p.TotalInflows.Sub(p.TotalOutflows)
+		p.ClosingBalance = balance.Add(p.NetCashFlow)
+		balance = p.ClosingBalance
+	}
+	return periods
+}
+
+// recurringOccurrences expands an item's schedule between from and horizon (exclusive).
+func recurringOccurrences(item domain.RecurringCashItem, from, horizon time.Time) []time.Time {
+	var dates []time.Time
+	for n, d := 0, item.NextOccurrence; d.Before(horizon); n++ {
+		if item.EndDate != nil && d.After(*item.EndDate) {
+			break
+		}
+		if !d.Before(from) {
+			dates = append(dates, d)
+		}
+		switch item.Frequency {
+		case domain.RecurrenceFrequencyWEEKLY:
+			d = item.NextOccurrence.AddDate(0, 0, 7*(n+1))
+		case domain.RecurrenceFrequencyMONTHLY:
+			d = item.NextOccurrence.AddDate(0, n+1, 0)
+		case domain.RecurrenceFrequencyQUARTERLY:
+			d = item.NextOccurrence.AddDate(0, 3*(n+1), 0)
+		case domain.RecurrenceFrequencyYEARLY:
+			d = item.NextOccurrence.AddDate(n+1, 0, 0)
+		default:
+			return dates
+		}
+	}
+	return dates
+}
+
+// payrollProjection averages the net pay of the most recent runs and expects it at every month
+// end after the last processed run, up to the horizon.
+func payrollProjection(runs []domain.PayrollRunSnapshot, asOf, horizon time.Time) []cashFlow {
+	if len(runs) == 0 {
+		return nil
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ProcessedAt.Before(runs[j].ProcessedAt) })
+	recent := runs
+	if len(recent) > payrollHistoryRuns {
+		recent = recent[len(recent)-payrollHistoryRuns:]
+	}
+	total := decimal.Zero
+	for _, r := range recent {
+		total = total.Add(r.TotalNetPay)
+	}
+	avg := total.Div(decimal.NewFromInt(int64(len(recent)))).Round(2)
+	if !avg.IsPositive() {
+		return nil
+	}
+
+	last := recent[len(recent)-1].ProcessedAt
+	month := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, asOf.Location())
+	if lastMonth := time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, asOf.Location()); !lastMonth.Before(month) {
+		month = lastMonth.AddDate(0, 1, 0)
+	}
+
+	var flows []cashFlow
+	for ; month.Before(horizon); month = month.AddDate(0, 1, 0) {
+		payDay := month.AddDate(0, 1, -1)
+		if payDay.Before(asOf) {
+			continue
+		}
+		if !payDay.Before(horizon) {
+			break
+		}
+		flows = append(flows, cashFlow{
+			legalEntityID: recent[0].LegalEntityID,
+			date:          payDay,
+			category:      flowPayroll,
+			amount:        avg.Neg(),
+		})
+	}
+	return flows
+}
+
+// operatingAccounts picks each legal entity's default bank account (the oldest one) for flows
+// that are not tied to a specific account.
+func operatingAccounts(accounts []domain.BankAccount) map[string]string {
+	sorted := append([]domain.BankAccount(nil), accounts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	res := make(map[string]string)
+	for _, ba := range sorted {
+		if _, ok := res[ba.LegalEntityID]; !ok {
+			res[ba.LegalEntityID] = ba.ID
+		}
+	}
+	return res
+}
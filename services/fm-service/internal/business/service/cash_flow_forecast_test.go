@@ -0,0 +1,257 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func assertAmount(t *testing.T, label string, got decimal.Decimal, want int64) {
+	t.Helper()
+	if !got.Equal(decimal.NewFromInt(want)) {
+		t.Errorf("%s: expected %d, got %s", label, want, got)
+	}
+}
+
+func TestGetCashFlowForecast_Monthly(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	payrollRuns := memory.NewMemoryPayrollRunSnapshotRepo()
+	recurringItems := memory.NewMemoryRecurringCashItemRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, recurringItems, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:       payments,
+		Invoices:       invoices,
+		Bills:          bills,
+		BankAccounts:   bankAccounts,
+		PayrollRuns:    payrollRuns,
+		RecurringItems: recurringItems,
+		Outbox:         outbox,
+		TM:             tm,
+	})
+	ctx := context.Background()
+
+	// ba_1 is the operating account (oldest), ba_2 carries the rent
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(10000), CreatedAt: day(2025, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_2", LegalEntityID: "legal_123", AccountNumber: "DE002", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(500), CreatedAt: day(2025, 6, 1)})
+
+	// 400 already received against inv_1 leaves 600 open
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_1", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(1000),
+		AmountPaid: decimal.NewFromInt(400), DueDate: day(2026, 3, 10), Status: domain.PaymentStatusPARTIAL})
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_overdue", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(200),
+		DueDate: day(2026, 2, 1), Status: domain.PaymentStatusOPEN})
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_paid", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(999),
+		DueDate: day(2026, 3, 20), Status: domain.PaymentStatusPAID})
+	_ = bills.Create(ctx, &domain.ApVendorBill{ID: "bill_1", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(300),
+		DueDate: day(2026, 4, 15), Status: domain.PaymentStatusOPEN})
+
+	_ = payrollRuns.Upsert(ctx, &domain.PayrollRunSnapshot{ID: "run_1", LegalEntityID: "legal_123", TotalNetPay: decimal.NewFromInt(6000), ProcessedAt: day(2026, 1, 31)})
+	_ = payrollRuns.Upsert(ctx, &domain.PayrollRunSnapshot{ID: "run_2", LegalEntityID: "legal_123", TotalNetPay: decimal.NewFromInt(7000), ProcessedAt: day(2026, 2, 28)})
+
+	rentAccount := "ba_2"
+	if err := svc.CreateRecurringCashItem(ctx, &domain.RecurringCashItem{LegalEntityID: "legal_123", BankAccountID: &rentAccount,
+		Description: "Office rent", Amount: decimal.NewFromInt(-1000), Frequency: domain.RecurrenceFrequencyMONTHLY, NextOccurrence: day(2026, 3, 5)}); err != nil {
+		t.Fatalf("failed to create recurring item: %v", err)
+	}
+
+	forecast, err := svc.GetCashFlowForecast(ctx, service.CashFlowForecastRequest{MonthsAhead: 2, AsOf: day(2026, 3, 2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(forecast.LegalEntities) != 1 {
+		t.Fatalf("expected 1 legal entity, got %d", len(forecast.LegalEntities))
+	}
+	le := forecast.LegalEntities[0]
+	assertAmount(t, "entity opening", le.OpeningBalance, 10500)
+	if len(le.Periods) != 3 || !le.Periods[0].PeriodEnd.Equal(day(2026, 3, 31)) || !le.Periods[2].PeriodEnd.Equal(day(2026, 5, 1)) {
+		t.Fatalf("unexpected periods: %+v", le.Periods)
+	}
+
+	// March: open and overdue receivables, payroll averaged from recent runs, rent
+	march := le.Periods[0]
+	assertAmount(t, "march receivables", march.Receivables, 800)
+	assertAmount(t, "march payroll", march.Payroll, 6500)
+	assertAmount(t, "march recurring outflows", march.RecurringOutflows, 1000)
+	assertAmount(t, "march closing", march.ClosingBalance, 3800)
+
+	april := le.Periods[1]
+	assertAmount(t, "april opening", april.OpeningBalance, 3800)
+	assertAmount(t, "april payables", april.Payables, 300)
+	assertAmount(t, "april outflows", april.TotalOutflows, 7800)
+	assertAmount(t, "april closing", april.ClosingBalance, -4000)
+
+	// Per bank account: unassigned flows land on the operating account
+	if len(le.BankAccounts) != 2 {
+		t.Fatalf("expected 2 bank accounts, got %d", len(le.BankAccounts))
+	}
+	assertAmount(t, "ba_1 march net", le.BankAccounts[0].Periods[0].NetCashFlow, -5700)
+	assertAmount(t, "ba_2 march closing", le.BankAccounts[1].Periods[0].ClosingBalance, -500)
+}
+
+func TestGetCashFlowForecast_WeeklyAndFilters(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	payrollRuns := memory.NewMemoryPayrollRunSnapshotRepo()
+	recurringItems := memory.NewMemoryRecurringCashItemRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, recurringItems, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:       payments,
+		Invoices:       invoices,
+		Bills:          bills,
+		BankAccounts:   bankAccounts,
+		PayrollRuns:    payrollRuns,
+		RecurringItems: recurringItems,
+		Outbox:         outbox,
+		TM:             tm,
+	})
+	ctx := context.Background()
+
+	// ba_1 is the operating account (oldest), ba_2 carries the rent
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(10000), CreatedAt: day(2025, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_2", LegalEntityID: "legal_123", AccountNumber: "DE002", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(500), CreatedAt: day(2025, 6, 1)})
+
+	// 400 already received against inv_1 leaves 600 open
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_1", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(1000),
+		AmountPaid: decimal.NewFromInt(400), DueDate: day(2026, 3, 10), Status: domain.PaymentStatusPARTIAL})
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_overdue", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(200),
+		DueDate: day(2026, 2, 1), Status: domain.PaymentStatusOPEN})
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_paid", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(999),
+		DueDate: day(2026, 3, 20), Status: domain.PaymentStatusPAID})
+	_ = bills.Create(ctx, &domain.ApVendorBill{ID: "bill_1", LegalEntityID: "legal_123", TotalAmount: decimal.NewFromInt(300),
+		DueDate: day(2026, 4, 15), Status: domain.PaymentStatusOPEN})
+
+	_ = payrollRuns.Upsert(ctx, &domain.PayrollRunSnapshot{ID: "run_1", LegalEntityID: "legal_123", TotalNetPay: decimal.NewFromInt(6000), ProcessedAt: day(2026, 1, 31)})
+	_ = payrollRuns.Upsert(ctx, &domain.PayrollRunSnapshot{ID: "run_2", LegalEntityID: "legal_123", TotalNetPay: decimal.NewFromInt(7000), ProcessedAt: day(2026, 2, 28)})
+
+	rentAccount := "ba_2"
+	if err := svc.CreateRecurringCashItem(ctx, &domain.RecurringCashItem{LegalEntityID: "legal_123", BankAccountID: &rentAccount,
+		Description: "Office rent", Amount: decimal.NewFromInt(-1000), Frequency: domain.RecurrenceFrequencyMONTHLY, NextOccurrence: day(2026, 3, 5)}); err != nil {
+		t.Fatalf("failed to create recurring item: %v", err)
+	}
+
+	weekly, err := svc.GetCashFlowForecast(ctx, service.CashFlowForecastRequest{MonthsAhead: 2, Granularity: service.ForecastGranularityWEEK, AsOf: day(2026, 3, 2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	periods := weekly.LegalEntities[0].Periods
+	if len(periods) != 9 {
+		t.Fatalf("expected 9 weekly periods, got %d", len(periods))
+	}
+	assertAmount(t, "week 1 receivables", periods[0].Receivables, 200)
+	assertAmount(t, "week 2 receivables", periods[1].Receivables, 600)
+
+	byAccount, err := svc.GetCashFlowForecast(ctx, service.CashFlowForecastRequest{MonthsAhead: 2, BankAccountID: "ba_2", AsOf: day(2026, 3, 2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	le := byAccount.LegalEntities[0]
+	if len(le.BankAccounts) != 1 || le.BankAccounts[0].BankAccountID != "ba_2" {
+		t.Fatalf("expected only ba_2, got %+v", le.BankAccounts)
+	}
+	assertAmount(t, "ba_2 entity opening", le.OpeningBalance, 500)
+	assertAmount(t, "ba_2 march receivables", le.Periods[0].Receivables, 0)
+
+	other, err := svc.GetCashFlowForecast(ctx, service.CashFlowForecastRequest{LegalEntityID: "legal_999", AsOf: day(2026, 3, 2)})
+	if err != nil || len(other.LegalEntities) != 0 {
+		t.Errorf("expected empty forecast for unknown entity, got %+v (%v)", other, err)
+	}
+
+	for _, req := range []service.CashFlowForecastRequest{
+		{MonthsAhead: 30},
+		{Granularity: "DAY"},
+		{BankAccountID: "missing"},
+		{BankAccountID: "ba_1", LegalEntityID: "legal_999"},
+	} {
+		if _, err := svc.GetCashFlowForecast(ctx, req); !errors.Is(err, domain.ErrInvalidForecastRequest) {
+			t.Errorf("expected invalid request error for %+v, got %v", req, err)
+		}
+	}
+}
+
+func TestRecurringCashItems(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	payrollRuns := memory.NewMemoryPayrollRunSnapshotRepo()
+	recurringItems := memory.NewMemoryRecurringCashItemRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, recurringItems, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:       payments,
+		Invoices:       invoices,
+		Bills:          bills,
+		BankAccounts:   bankAccounts,
+		PayrollRuns:    payrollRuns,
+		RecurringItems: recurringItems,
+		Outbox:         outbox,
+		TM:             tm,
+	})
+	ctx := context.Background()
+
+	// ba_1 is the operating account (oldest), ba_2 carries the rent
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "legal_123", AccountNumber: "DE001", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(10000), CreatedAt: day(2025, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_2", LegalEntityID: "legal_123", AccountNumber: "DE002", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(500), CreatedAt: day(2025, 6, 1)})
+
+	rentAccount := "ba_2"
+	if err := svc.CreateRecurringCashItem(ctx, &domain.RecurringCashItem{LegalEntityID: "legal_123", BankAccountID: &rentAccount,
+		Description: "Office rent", Amount: decimal.NewFromInt(-1000), Frequency: domain.RecurrenceFrequencyMONTHLY, NextOccurrence: day(2026, 3, 5)}); err != nil {
+		t.Fatalf("failed to create recurring item: %v", err)
+	}
+
+	foreign := "ba_1"
+	invalid := []domain.RecurringCashItem{
+		{LegalEntityID: "legal_123", Description: "Zero", Frequency: domain.RecurrenceFrequencyMONTHLY, NextOccurrence: day(2026, 3, 1)},
+		{LegalEntityID: "legal_123", Description: "Bad frequency", Amount: decimal.NewFromInt(10), Frequency: "DAILY", NextOccurrence: day(2026, 3, 1)},
+		{LegalEntityID: "legal_999", BankAccountID: &foreign, Description: "Other entity", Amount: decimal.NewFromInt(10), Frequency: domain.RecurrenceFrequencyWEEKLY, NextOccurrence: day(2026, 3, 1)},
+	}
+	for _, item := range invalid {
+		item := item
+		if err := svc.CreateRecurringCashItem(ctx, &item); err == nil {
+			t.Errorf("expected validation error for %q", item.Description)
+		}
+	}
+
+	items, err := svc.ListRecurringCashItems(ctx, "legal_123")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected 1 recurring item, got %d (%v)", len(items), err)
+	}
+
+	// Deactivated items drop out of the forecast
+	rent := items[0]
+	rent.IsActive = false
+	if err := svc.UpdateRecurringCashItem(ctx, &rent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	forecast, _ := svc.GetCashFlowForecast(ctx, service.CashFlowForecastRequest{MonthsAhead: 1, AsOf: day(2026, 3, 2)})
+	assertAmount(t, "recurring outflows after deactivation", forecast.LegalEntities[0].Periods[0].RecurringOutflows, 0)
+
+	if err := svc.DeleteRecurringCashItem(ctx, rent.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.DeleteRecurringCashItem(ctx, rent.ID); err == nil {
+		t.Error("expected error deleting missing item")
+	}
+}
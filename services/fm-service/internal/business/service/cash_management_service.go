@@ -3,200 +3,1058 @@ package service
 import (
 	"context"
 	"erp-system/shared/utils"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/statementparser"
 	"github.com/shopspring/decimal"
 )
 
 type CashManagementService struct {
-	payments   domain.PaymentRepository
-	invoices   domain.ArInvoiceRepository
-	statements domain.BankStatementRepository
-	outbox     domain.TransactionalOutboxRepository
-	tm         domain.TransactionManager
-}
-
-func NewCashManagementService(
-	payments domain.PaymentRepository,
-	invoices domain.ArInvoiceRepository,
-	statements domain.BankStatementRepository,
-	outbox domain.TransactionalOutboxRepository,
-	tm domain.TransactionManager,
-) *CashManagementService {
+	payments       domain.PaymentRepository
+	invoices       domain.ArInvoiceRepository
+	bills          domain.ApVendorBillRepository
+	allocations    domain.PaymentAllocationRepository
+	creditMemos    domain.ArCreditMemoRepository
+	credits        domain.OnAccountCreditRepository
+	statements     domain.BankStatementRepository
+	bankAccounts   domain.BankAccountRepository
+	matches        domain.BankReconciliationMatchRepository
+	exceptions     domain.BankReconciliationExceptionRepository
+	payrollRuns    domain.PayrollRunSnapshotRepository
+	recurringItems domain.RecurringCashItemRepository
+	gl             *GeneralLedgerService
+	fx             *ForeignExchangeService
+	outbox         domain.TransactionalOutboxRepository
+	tm             domain.TransactionManager
+	reconOpts      ReconciliationOptions
+}
+
+// CashManagementDeps lists the repositories and services CashManagementService
+// needs. GL and FX may be nil; ledger postings and foreign-currency settlement
+// are then unavailable.
+type CashManagementDeps struct {
+	Payments         domain.PaymentRepository
+	Invoices         domain.ArInvoiceRepository
+	Bills            domain.ApVendorBillRepository
+	Allocations      domain.PaymentAllocationRepository
+	CreditMemos      domain.ArCreditMemoRepository
+	OnAccountCredits domain.OnAccountCreditRepository
+	Statements       domain.BankStatementRepository
+	BankAccounts     domain.BankAccountRepository
+	Matches          domain.BankReconciliationMatchRepository
+	Exceptions       domain.BankReconciliationExceptionRepository
+	PayrollRuns      domain.PayrollRunSnapshotRepository
+	RecurringItems   domain.RecurringCashItemRepository
+	GL               *GeneralLedgerService
+	FX               *ForeignExchangeService
+	Outbox           domain.TransactionalOutboxRepository
+	TM               domain.TransactionManager
+}
+
+func NewCashManagementService(deps CashManagementDeps) *CashManagementService {
 	return &CashManagementService{
-		payments:   payments,
-		invoices:   invoices,
-		statements: statements,
-		outbox:     outbox,
-		tm:         tm,
+		payments:       deps.Payments,
+		invoices:       deps.Invoices,
+		bills:          deps.Bills,
+		allocations:    deps.Allocations,
+		creditMemos:    deps.CreditMemos,
+		credits:        deps.OnAccountCredits,
+		statements:     deps.Statements,
+		bankAccounts:   deps.BankAccounts,
+		matches:        deps.Matches,
+		exceptions:     deps.Exceptions,
+		payrollRuns:    deps.PayrollRuns,
+		recurringItems: deps.RecurringItems,
+		gl:             deps.GL,
+		fx:             deps.FX,
+		outbox:         deps.Outbox,
+		tm:             deps.TM,
+		reconOpts:      DefaultReconciliationOptions(),
 	}
 }
 
+// SetReconciliationOptions overrides the default matching tolerances.
+func (s *CashManagementService) SetReconciliationOptions(opts ReconciliationOptions) {
+	s.reconOpts = opts
+}
+
 func (s *CashManagementService) ListPayments(ctx context.Context) ([]domain.Payment, error) {
 	return s.payments.List(ctx)
 }
 
+// RecordPayment records a payment against a single invoice or bill (the invoice wins if both
+// are given). It settles as much of the document as is still open; anything beyond that is kept
+// as an on-account credit for the customer or vendor.
 func (s *CashManagementService) RecordPayment(ctx context.Context, invoiceID, billID, bankAccountID string, amount decimal.Decimal, method string) (*domain.Payment, error) {
-	id := utils.NewID("pay")
-	payNum := fmt.Sprintf("PAY-%d", time.Now().Unix())
+	req := PaymentRequest{BankAccountID: bankAccountID, Amount: amount, PaymentMethod: method}
+	switch {
+	case invoiceID != "":
+		req.Allocations = []AllocationRequest{{InvoiceID: invoiceID}}
+	case billID != "":
+		req.Allocations = []AllocationRequest{{BillID: billID}}
+	}
+	result, err := s.RecordAllocatedPayment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return result.Payment, nil
+}
+
+func (s *CashManagementService) GetPayment(ctx context.Context, id string) (*domain.Payment, error) {
+	return s.payments.GetByID(ctx, id)
+}
+
+// GetCashFlowForecast projects cash positions per legal entity and bank account, starting from
+// current liquid balances. Open receivables and payables are expected on their due date (overdue
+// items in the first period), payroll from recent hr-service runs and recurring items from their
+// schedule. Flows not tied to a bank account are assigned to the entity's operating account.
+func (s *CashManagementService) GetCashFlowForecast(ctx context.Context, req CashFlowForecastRequest) (*CashFlowForecast, error) {
+	if req.MonthsAhead == 0 {
+		req.MonthsAhead = defaultForecastMonths
+	}
+	if req.MonthsAhead < 1 || req.MonthsAhead > maxForecastMonths {
+		return nil, fmt.Errorf("%w: months ahead must be between 1 and %d", domain.ErrInvalidForecastRequest, maxForecastMonths)
+	}
+	if req.Granularity == "" {
+		req.Granularity = ForecastGranularityMONTH
+	}
+	if !req.Granularity.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported granularity %q", domain.ErrInvalidForecastRequest, req.Granularity)
+	}
+	if req.AsOf.IsZero() {
+		req.AsOf = time.Now()
+	}
+	asOf := time.Date(req.AsOf.Year(), req.AsOf.Month(), req.AsOf.Day(), 0, 0, 0, 0, time.UTC)
+	buckets := forecastBuckets(asOf, req.MonthsAhead, req.Granularity)
+	horizon := buckets[len(buckets)-1].end
+
+	accounts, err := s.bankAccounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.BankAccountID != "" {
+		ba, err := s.bankAccounts.GetByID(ctx, req.BankAccountID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bank account %s not found", domain.ErrInvalidForecastRequest, req.BankAccountID)
+		}
+		if req.LegalEntityID != "" && ba.LegalEntityID != req.LegalEntityID {
+			return nil, fmt.Errorf("%w: bank account %s does not belong to legal entity %s", domain.ErrInvalidForecastRequest, ba.ID, req.LegalEntityID)
+		}
+		req.LegalEntityID = ba.LegalEntityID
+	}
 
-	payment := &domain.Payment{
-		ID:            id,
-		PaymentNumber: payNum,
-		PaymentDate:   time.Now(),
-		Amount:        amount,
-		PaymentMethod: method,
-		Status:        "COMPLETED",
-		BankAccountID: nil,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+	flows, err := s.expectedCashFlows(ctx, asOf, horizon)
+	if err != nil {
+		return nil, err
+	}
+	operating := operatingAccounts(accounts)
+	for i := range flows {
+		if flows[i].bankAccountID == "" {
+			flows[i].bankAccountID = operating[flows[i].legalEntityID]
+		}
 	}
 
-	if bankAccountID != "" {
-		payment.BankAccountID = &bankAccountID
+	entityIDs := make(map[string]bool)
+	for _, ba := range accounts {
+		entityIDs[ba.LegalEntityID] = true
+	}
+	for _, f := range flows {
+		entityIDs[f.legalEntityID] = true
+	}
+	ids := make([]string, 0, len(entityIDs))
+	for id := range entityIDs {
+		if req.LegalEntityID == "" || id == req.LegalEntityID {
+			ids = append(ids, id)
+		}
 	}
-	if invoiceID != "" {
-		payment.InvoiceID = &invoiceID
+	sort.Strings(ids)
+
+	forecast := &CashFlowForecast{
+		AsOf:          asOf,
+		MonthsAhead:   req.MonthsAhead,
+		Granularity:   req.Granularity,
+		LegalEntities: []LegalEntityCashFlowForecast{},
 	}
-	if billID != "" {
-		payment.BillID = &billID
+	for _, entityID := range ids {
+		entity := LegalEntityCashFlowForecast{
+			LegalEntityID: entityID,
+			BankAccounts:  []BankAccountCashFlowForecast{},
+		}
+		var entityFlows []cashFlow
+		for _, f := range flows {
+			if f.legalEntityID != entityID || (req.BankAccountID != "" && f.bankAccountID != req.BankAccountID) {
+				continue
+			}
+			entityFlows = append(entityFlows, f)
+		}
+
+		for _, ba := range accounts {
+			if ba.LegalEntityID != entityID || (req.BankAccountID != "" && ba.ID != req.BankAccountID) {
+				continue
+			}
+			var accountFlows []cashFlow
+			for _, f := range entityFlows {
+				if f.bankAccountID == ba.ID {
+					accountFlows = append(accountFlows, f)
+				}
+			}
+			entity.OpeningBalance = entity.OpeningBalance.Add(ba.LiquidBalance)
+			entity.BankAccounts = append(entity.BankAccounts, BankAccountCashFlowForecast{
+				BankAccountID:  ba.ID,
+				AccountNumber:  ba.AccountNumber,
+				Currency:       ba.Currency,
+				OpeningBalance: ba.LiquidBalance,
+				Periods:        buildPeriods(buckets, ba.LiquidBalance, accountFlows),
+			})
+		}
+		sort.Slice(entity.BankAccounts, func(i, j int) bool {
+			return entity.BankAccounts[i].AccountNumber < entity.BankAccounts[j].AccountNumber
+		})
+		entity.Periods = buildPeriods(buckets, entity.OpeningBalance, entityFlows)
+		forecast.LegalEntities = append(forecast.LegalEntities, entity)
 	}
+	return forecast, nil
+}
 
+// expectedCashFlows collects open AR/AP balances, projected payroll and recurring items up to the horizon.
+func (s *CashManagementService) expectedCashFlows(ctx context.Context, asOf, horizon time.Time) ([]cashFlow, error) {
+	var flows []cashFlow
+	invoices, err := s.invoices.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range invoices {
+		open := inv.OpenAmount()
+		if inv.Status == domain.PaymentStatusPAID || !open.IsPositive() {
+			continue
+		}
+		flows = append(flows, cashFlow{legalEntityID: inv.LegalEntityID, date: inv.DueDate, category: flowReceivable, amount: open})
+	}
+
+	bills, err := s.bills.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, bill := range bills {
+		open := bill.OpenAmount()
+		if bill.Status == domain.PaymentStatusPAID || !open.IsPositive() {
+			continue
+		}
+		flows = append(flows, cashFlow{legalEntityID: bill.LegalEntityID, date: bill.DueDate, category: flowPayable, amount: open.Neg()})
+	}
+
+	items, err := s.recurringItems.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entities := make(map[string]bool)
+	for _, f := range flows {
+		entities[f.legalEntityID] = true
+	}
+	for _, item := range items {
+		if !item.IsActive {
+			continue
+		}
+		entities[item.LegalEntityID] = true
+		bankAccountID := ""
+		if item.BankAccountID != nil {
+			bankAccountID = *item.BankAccountID
+		}
+		for _, d := range recurringOccurrences(item, asOf, horizon) {
+			flows = append(flows, cashFlow{legalEntityID: item.LegalEntityID, bankAccountID: bankAccountID, date: d, category: flowRecurring, amount: item.Amount})
+		}
+	}
+
+	accounts, err := s.bankAccounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ba := range accounts {
+		entities[ba.LegalEntityID] = true
+	}
+	for entityID := range entities {
+		runs, err := s.payrollRuns.ListByLegalEntity(ctx, entityID)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, payrollProjection(runs, asOf, horizon)...)
+	}
+	return flows, nil
+}
+
+// RecordPayrollRun stores a processed payroll run from hr-service for payroll forecasting.
+// Redelivered events overwrite the same run.
+func (s *CashManagementService) RecordPayrollRun(ctx context.Context, run *domain.PayrollRunSnapshot) error {
+	if run.ID == "" || run.LegalEntityID == "" {
+		return errors.New("payroll run id and legal entity are required")
+	}
+	if run.ProcessedAt.IsZero() {
+		run.ProcessedAt = time.Now()
+	}
+	return s.payrollRuns.Upsert(ctx, run)
+}
+
+// ListRecurringCashItems returns scheduled recurring items, optionally for a single legal entity.
+func (s *CashManagementService) ListRecurringCashItems(ctx context.Context, legalEntityID string) ([]domain.RecurringCashItem, error) {
+	items, err := s.recurringItems.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]domain.RecurringCashItem, 0, len(items))
+	for _, item := range items {
+		if legalEntityID == "" || item.LegalEntityID == legalEntityID {
+			list = append(list, item)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].NextOccurrence.Before(list[j].NextOccurrence) })
+	return list, nil
+}
+
+func (s *CashManagementService) GetRecurringCashItem(ctx context.Context, id string) (*domain.RecurringCashItem, error) {
+	return s.recurringItems.GetByID(ctx, id)
+}
+
+func (s *CashManagementService) CreateRecurringCashItem(ctx context.Context, item *domain.RecurringCashItem) error {
+	if err := s.validateRecurringCashItem(ctx, item); err != nil {
+		return err
+	}
+	item.ID = utils.NewID("rci")
+	item.IsActive = true
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = item.CreatedAt
+	return s.recurringItems.Create(ctx, item)
+}
+
+func (s *CashManagementService) UpdateRecurringCashItem(ctx context.Context, item *domain.RecurringCashItem) error {
+	existing, err := s.recurringItems.GetByID(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateRecurringCashItem(ctx, item); err != nil {
+		return err
+	}
+	item.CreatedAt = existing.CreatedAt
+	item.UpdatedAt = time.Now()
+	return s.recurringItems.Update(ctx, item)
+}
+
+func (s *CashManagementService) DeleteRecurringCashItem(ctx context.Context, id string) error {
+	if _, err := s.recurringItems.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return s.recurringItems.Delete(ctx, id)
+}
+
+func (s *CashManagementService) validateRecurringCashItem(ctx context.Context, item *domain.RecurringCashItem) error {
+	if item.LegalEntityID == "" || strings.TrimSpace(item.Description) == "" {
+		return errors.New("legal entity and description are required")
+	}
+	if item.Amount.IsZero() {
+		return errors.New("amount must be non-zero")
+	}
+	if !item.Frequency.IsValid() {
+		return fmt.Errorf("unsupported frequency %q", item.Frequency)
+	}
+	if item.NextOccurrence.IsZero() {
+		return errors.New("next occurrence is required")
+	}
+	if item.EndDate != nil && item.EndDate.Before(item.NextOccurrence) {
+		return errors.New("end date must not be before the next occurrence")
+	}
+	if item.BankAccountID != nil {
+		ba, err := s.bankAccounts.GetByID(ctx, *item.BankAccountID)
+		if err != nil {
+			return err
+		}
+		if ba.LegalEntityID != item.LegalEntityID {
+			return fmt.Errorf("bank account %s does not belong to legal entity %s", ba.ID, item.LegalEntityID)
+		}
+	}
+	return nil
+}
+
+func (s *CashManagementService) GetBankStatement(ctx context.Context, id string) (*domain.BankStatement, []domain.BankStatementLine, error) {
+	if s.statements == nil {
+		return nil, nil, fmt.Errorf("bank statement repository not initialized")
+	}
+	return s.statements.GetByID(ctx, id)
+}
+
+// ReconciliationResult summarises one reconciliation run over a bank statement.
+type ReconciliationResult struct {
+	StatementID    string                               `json:"statement_id"`
+	Matches        []domain.BankReconciliationMatch     `json:"matches"`
+	Exceptions     []domain.BankReconciliationException `json:"exceptions"`
+	MatchedLines   int                                  `json:"matched_lines"`
+	UnmatchedLines int                                  `json:"unmatched_lines"`
+	IsReconciled   bool                                 `json:"is_reconciled"`
+}
+
+// ReconcileBankStatement matches the statement's open lines against payments on the
+// same bank account. Matched lines are flagged, everything else is queued as an exception.
+func (s *CashManagementService) ReconcileBankStatement(ctx context.Context, statementID string) (*ReconciliationResult, error) {
+	if s.statements == nil || s.matches == nil || s.exceptions == nil {
+		return nil, fmt.Errorf("bank reconciliation repositories not initialized")
+	}
+
+	var result *ReconciliationResult
 	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
-		if invoiceID != "" {
-			inv, err := s.invoices.GetByID(txCtx, invoiceID)
-			if err != nil {
-				return err
+		stmt, lines, err := s.statements.GetByID(txCtx, statementID)
+		if err != nil {
+			return err
+		}
+
+		bankAccount, err := s.bankAccounts.GetByID(txCtx, stmt.BankAccountID)
+		if err != nil {
+			return err
+		}
+		candidates, err := s.openPayments(txCtx, bankAccount)
+		if err != nil {
+			return err
+		}
+
+		var openLines []domain.BankStatementLine
+		for _, l := range lines {
+			if !l.IsMatched {
+				openLines = append(openLines, l)
 			}
-			inv.Status = domain.PaymentStatusPAID
-			err = s.invoices.Update(txCtx, inv)
-			if err != nil {
-				return err
+		}
+
+		proposed, unmatched := newReconciliationEngine(s.reconOpts).run(openLines, candidates)
+
+		var newMatches []domain.BankReconciliationMatch
+		matchedIDs := make(map[string]bool)
+		for _, pm := range proposed {
+			newMatches = append(newMatches, buildMatchRows(statementID, pm.lines, pm.payments, pm.matchType, pm.confidence)...)
+			for _, l := range pm.lines {
+				matchedIDs[l.ID] = true
 			}
+		}
+		if err := s.matches.CreateMany(txCtx, newMatches); err != nil {
+			return err
+		}
 
-			// Write invoice paid to outbox
-			outboxRec := &domain.TransactionalOutbox{
-				ID:          utils.NewID("outbox"),
-				EventType:   string(domain.TopicFmInvoicePaid),
-				AggregateID: inv.ID,
-				Payload: domain.InvoiceEventPayload{
-					ID:            inv.ID,
-					CustomerID:    inv.CustomerID,
-					InvoiceNumber: inv.InvoiceNumber,
-					TotalAmount:   inv.TotalAmount,
-					Status:        string(inv.Status),
-					Timestamp:     time.Now(),
-				},
-				Status:    domain.OutboxStatusPENDING,
-				CreatedAt: time.Now(),
-			}
-			if err := s.outbox.Create(txCtx, outboxRec); err != nil {
-				return err
+		for i := range lines {
+			if matchedIDs[lines[i].ID] {
+				lines[i].IsMatched = true
 			}
 		}
 
-		err := s.payments.Create(txCtx, payment)
+		excs, err := s.syncExceptions(txCtx, statementID, lines, unmatched)
 		if err != nil {
 			return err
 		}
 
-		// Write payment received to outbox
-		outboxRecReceived := &domain.TransactionalOutbox{
-			ID:          utils.NewID("outbox"),
-			EventType:   string(domain.TopicFmPaymentReceived),
-			AggregateID: payment.ID,
-			Payload: domain.PaymentEventPayload{
-				ID:            payment.ID,
-				InvoiceID:     payment.InvoiceID,
-				BillID:        payment.BillID,
-				PaymentNumber: payment.PaymentNumber,
-				Amount:        payment.Amount,
-				PaymentMethod: payment.PaymentMethod,
-				Status:        payment.Status,
-				Timestamp:     time.Now(),
-			},
-			Status:    domain.OutboxStatusPENDING,
-			CreatedAt: time.Now(),
-		}
-		if err := s.outbox.Create(txCtx, outboxRecReceived); err != nil {
+		stmt.IsReconciled = allLinesMatched(lines)
+		if err := s.statements.Update(txCtx, stmt, lines); err != nil {
 			return err
 		}
 
-		// Write payment processed to outbox
-		outboxRecProcessed := &domain.TransactionalOutbox{
+		matchedCount := 0
+		for _, l := range lines {
+			if l.IsMatched {
+				matchedCount++
+			}
+		}
+
+		outboxRec := &domain.TransactionalOutbox{
 			ID:          utils.NewID("outbox"),
-			EventType:   string(domain.TopicFmPaymentProcessed),
-			AggregateID: payment.ID,
-			Payload: domain.PaymentEventPayload{
-				ID:            payment.ID,
-				InvoiceID:     payment.InvoiceID,
-				BillID:        payment.BillID,
-				PaymentNumber: payment.PaymentNumber,
-				Amount:        payment.Amount,
-				PaymentMethod: payment.PaymentMethod,
-				Status:        payment.Status,
-				Timestamp:     time.Now(),
+			EventType:   string(domain.TopicFmBankStatementReconciled),
+			AggregateID: stmt.ID,
+			Payload: domain.BankStatementReconciledEventPayload{
+				StatementID:    stmt.ID,
+				BankAccountID:  stmt.BankAccountID,
+				MatchedLines:   matchedCount,
+				ExceptionLines: len(lines) - matchedCount,
+				IsReconciled:   stmt.IsReconciled,
+				Timestamp:      time.Now(),
 			},
 			Status:    domain.OutboxStatusPENDING,
 			CreatedAt: time.Now(),
 		}
-		if err := s.outbox.Create(txCtx, outboxRecProcessed); err != nil {
+		if err := s.outbox.Create(txCtx, outboxRec); err != nil {
 			return err
 		}
 
+		result = &ReconciliationResult{
+			StatementID:    stmt.ID,
+			Matches:        newMatches,
+			Exceptions:     excs,
+			MatchedLines:   matchedCount,
+			UnmatchedLines: len(lines) - matchedCount,
+			IsReconciled:   stmt.IsReconciled,
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ManualMatch lets a user pair statement lines with payments the engine could not resolve.
+// Both sides must balance within the configured amount tolerance.
+func (s *CashManagementService) ManualMatch(ctx context.Context, statementID string, lineIDs, paymentIDs []string) ([]domain.BankReconciliationMatch, error) {
+	if len(lineIDs) == 0 || len(paymentIDs) == 0 {
+		return nil, errors.New("at least one statement line and one payment are required")
+	}
+	if len(lineIDs) > 1 && len(paymentIDs) > 1 {
+		return nil, errors.New("a match must have either a single statement line or a single payment")
+	}
+
+	var rows []domain.BankReconciliationMatch
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		stmt, lines, err := s.statements.GetByID(txCtx, statementID)
+		if err != nil {
+			return err
+		}
+
+		bankAccount, err := s.bankAccounts.GetByID(txCtx, stmt.BankAccountID)
+		if err != nil {
+			return err
+		}
+
+		lineByID := make(map[string]int, len(lines))
+		for i, l := range lines {
+			lineByID[l.ID] = i
+		}
+		var selectedLines []domain.BankStatementLine
+		lineTotal := decimal.Zero
+		seenLines := make(map[string]bool, len(lineIDs))
+		for _, id := range lineIDs {
+			if seenLines[id] {
+				return fmt.Errorf("statement line listed more than once: %s", id)
+			}
+			seenLines[id] = true
+			idx, ok := lineByID[id]
+			if !ok {
+				return fmt.Errorf("statement line not found: %s", id)
+			}
+			if lines[idx].IsMatched {
+				return domain.ErrStatementLineAlreadyMatched
+			}
+			selectedLines = append(selectedLines, lines[idx])
+			lineTotal = lineTotal.Add(lines[idx].Amount)
+		}
+
+		existing, err := s.matches.List(txCtx)
+		if err != nil {
+			return err
+		}
+		matchedPayments := make(map[string]bool, len(existing))
+		for _, m := range existing {
+			matchedPayments[m.PaymentID] = true
+		}
+
+		var selectedPayments []domain.Payment
+		paymentTotal := decimal.Zero
+		seenPayments := make(map[string]bool, len(paymentIDs))
+		for _, id := range paymentIDs {
+			if seenPayments[id] {
+				return fmt.Errorf("payment listed more than once: %s", id)
+			}
+			seenPayments[id] = true
+			if matchedPayments[id] {
+				return domain.ErrPaymentAlreadyMatched
+			}
+			p, err := s.payments.GetByID(txCtx, id)
+			if err != nil {
+				return err
+			}
+			onAccount, err := s.paidThroughAccount(txCtx, *p, bankAccount)
+			if err != nil {
+				return err
+			}
+			if !onAccount {
+				return fmt.Errorf("%w: %s", domain.ErrPaymentNotOnBankAccount, id)
+			}
+			selectedPayments = append(selectedPayments, *p)
+			paymentTotal = paymentTotal.Add(p.SignedAmount())
+		}
+
+		if lineTotal.Sub(paymentTotal).Abs().GreaterThan(s.reconOpts.AmountTolerance) {
+			return domain.ErrReconciliationAmountMismatch
+		}
+
+		rows = buildMatchRows(statementID, selectedLines, selectedPayments, domain.ReconciliationMatchTypeMANUAL, decimal.NewFromInt(1))
+		if err := s.matches.CreateMany(txCtx, rows); err != nil {
+			return err
+		}
+
+		for _, id := range lineIDs {
+			lines[lineByID[id]].IsMatched = true
+		}
+		if _, err := s.syncExceptions(txCtx, statementID, lines, nil); err != nil {
+			return err
+		}
+
+		stmt.IsReconciled = allLinesMatched(lines)
+		return s.statements.Update(txCtx, stmt, lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UnmatchGroup undoes a match and returns its statement lines to the exceptions queue.
+func (s *CashManagementService) UnmatchGroup(ctx context.Context, statementID, groupID string) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		stmt, lines, err := s.statements.GetByID(txCtx, statementID)
+		if err != nil {
+			return err
+		}
+		existing, err := s.matches.ListByStatement(txCtx, statementID)
+		if err != nil {
+			return err
+		}
+
+		released := make(map[string]bool)
+		for _, m := range existing {
+			if m.MatchGroupID == groupID {
+				released[m.StatementLineID] = true
+			}
+		}
+		if len(released) == 0 {
+			return fmt.Errorf("reconciliation match not found: %s", groupID)
+		}
+		if err := s.matches.DeleteByGroup(txCtx, groupID); err != nil {
+			return err
+		}
+
+		unmatched := make(map[string]string)
+		for i := range lines {
+			if released[lines[i].ID] {
+				lines[i].IsMatched = false
+				unmatched[lines[i].ID] = "match removed manually"
+			}
+		}
+		if _, err := s.syncExceptions(txCtx, statementID, lines, unmatched); err != nil {
+			return err
+		}
+
+		stmt.IsReconciled = allLinesMatched(lines)
+		return s.statements.Update(txCtx, stmt, lines)
+	})
+}
+
+func (s *CashManagementService) ListReconciliationMatches(ctx context.Context, statementID string) ([]domain.BankReconciliationMatch, error) {
+	return s.matches.ListByStatement(ctx, statementID)
+}
 
+// ListReconciliationExceptions returns the exceptions queue, optionally for the
+// statements of a single legal entity and filtered by status.
+func (s *CashManagementService) ListReconciliationExceptions(ctx context.Context, legalEntityID, status string) ([]domain.BankReconciliationException, error) {
+	excs, err := s.exceptions.List(ctx)
 	if err != nil {
-		// Publish payment failed event in a separate transaction
-		_ = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
-			outboxRecFailed := &domain.TransactionalOutbox{
-				ID:          utils.NewID("outbox"),
-				EventType:   string(domain.TopicFmPaymentFailed),
-				AggregateID: payment.ID,
-				Payload: domain.PaymentEventPayload{
-					ID:            payment.ID,
-					InvoiceID:     payment.InvoiceID,
-					BillID:        payment.BillID,
-					PaymentNumber: payment.PaymentNumber,
-					Amount:        payment.Amount,
-					PaymentMethod: payment.PaymentMethod,
-					Status:        "FAILED",
-					Timestamp:     time.Now(),
-				},
-				Status:    domain.OutboxStatusPENDING,
-				CreatedAt: time.Now(),
-			}
-			return s.outbox.Create(txCtx, outboxRecFailed)
+		return nil, err
+	}
+	owners := make(map[string]string)
+	var filtered []domain.BankReconciliationException
+	for _, e := range excs {
+		if status != "" && string(e.Status) != status {
+			continue
+		}
+		if legalEntityID != "" {
+			owner, seen := owners[e.StatementID]
+			if !seen {
+				if owner, err = s.StatementLegalEntity(ctx, e.StatementID); err != nil {
+					return nil, err
+				}
+				owners[e.StatementID] = owner
+			}
+			if owner != legalEntityID {
+				continue
+			}
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered, nil
+}
+
+// StatementLegalEntity returns the legal entity owning the statement's bank account.
+func (s *CashManagementService) StatementLegalEntity(ctx context.Context, statementID string) (string, error) {
+	stmt, _, err := s.GetBankStatement(ctx, statementID)
+	if err != nil {
+		return "", err
+	}
+	bankAccount, err := s.bankAccounts.GetByID(ctx, stmt.BankAccountID)
+	if err != nil {
+		return "", err
+	}
+	return bankAccount.LegalEntityID, nil
+}
+
+// ExceptionLegalEntity returns the legal entity owning the exception's statement.
+func (s *CashManagementService) ExceptionLegalEntity(ctx context.Context, exceptionID string) (string, error) {
+	exc, err := s.exceptions.GetByID(ctx, exceptionID)
+	if err != nil {
+		return "", err
+	}
+	return s.StatementLegalEntity(ctx, exc.StatementID)
+}
+
+// PostExceptionToLedger clears an unmatched line (bank fees, interest, unknown deposits)
+// by posting it to the GL against the given offset account. The bank side goes to the GL
+// account of the statement's bank account unless bankGLAccountID overrides it.
+func (s *CashManagementService) PostExceptionToLedger(ctx context.Context, exceptionID, bankGLAccountID, offsetAccountID string) (*domain.BankReconciliationException, error) {
+	if s.gl == nil {
+		return nil, fmt.Errorf("general ledger service not initialized")
+	}
+	if offsetAccountID == "" {
+		return nil, errors.New("offset account is required")
+	}
+
+	var exc *domain.BankReconciliationException
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		exc, err = s.exceptions.GetByID(txCtx, exceptionID)
+		if err != nil {
+			return err
+		}
+		if exc.Status != domain.ReconciliationExceptionStatusOPEN {
+			return domain.ErrReconciliationExceptionClosed
+		}
+
+		stmt, lines, err := s.statements.GetByID(txCtx, exc.StatementID)
+		if err != nil {
+			return err
+		}
+		idx := -1
+		for i, l := range lines {
+			if l.ID == exc.StatementLineID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("statement line not found: %s", exc.StatementLineID)
+		}
+		line := lines[idx]
+
+		bankAccount, err := s.bankAccounts.GetByID(txCtx, stmt.BankAccountID)
+		if err != nil {
+			return err
+		}
+		if bankGLAccountID == "" {
+			if bankAccount.GlAccountID == nil || *bankAccount.GlAccountID == "" {
+				return fmt.Errorf("bank account %s has no GL account; a bank GL account is required", bankAccount.ID)
+			}
+			bankGLAccountID = *bankAccount.GlAccountID
+		}
+
+		entry, err := s.gl.CreateJournalEntry(txCtx, bankAccount.LegalEntityID, "FM", line.ID, line.TransactionDate, []domain.UniversalJournalLine{
+			{AccountID: bankGLAccountID, AmountTransactional: line.Amount, CurrencyTransactional: bankAccount.Currency},
+			{AccountID: offsetAccountID, AmountTransactional: line.Amount.Neg(), CurrencyTransactional: bankAccount.Currency},
 		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		exc.Status = domain.ReconciliationExceptionStatusRESOLVED
+		exc.JournalEntryID = &entry.ID
+		exc.ResolvedAt = &now
+		if err := s.exceptions.Update(txCtx, exc); err != nil {
+			return err
+		}
+
+		lines[idx].IsMatched = true
+		stmt.IsReconciled = allLinesMatched(lines)
+		return s.statements.Update(txCtx, stmt, lines)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return exc, nil
+}
 
-	return payment, nil
+// openPayments returns payments on the bank account that are not yet tied to a statement line.
+func (s *CashManagementService) openPayments(ctx context.Context, bankAccount *domain.BankAccount) ([]domain.Payment, error) {
+	payments, err := s.payments.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.matches.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool, len(existing))
+	for _, m := range existing {
+		matched[m.PaymentID] = true
+	}
+
+	var open []domain.Payment
+	for _, p := range payments {
+		if matched[p.ID] || p.Status == "FAILED" {
+			continue
+		}
+		onAccount, err := s.paidThroughAccount(ctx, p, bankAccount)
+		if err != nil {
+			return nil, err
+		}
+		if onAccount {
+			open = append(open, p)
+		}
+	}
+	return open, nil
 }
 
-func (s *CashManagementService) GetPayment(ctx context.Context, id string) (*domain.Payment, error) {
-	return s.payments.GetByID(ctx, id)
+// paidThroughAccount reports whether a payment can appear on the bank account's
+// statements: it names the account, or names none and was booked in the
+// account's legal entity.
+func (s *CashManagementService) paidThroughAccount(ctx context.Context, p domain.Payment, bankAccount *domain.BankAccount) (bool, error) {
+	if p.BankAccountID != nil {
+		return *p.BankAccountID == bankAccount.ID, nil
+	}
+	legalEntityID, err := s.paymentLegalEntity(ctx, p)
+	if err != nil {
+		return false, err
+	}
+	return legalEntityID == bankAccount.LegalEntityID, nil
 }
 
-func (s *CashManagementService) ReconcileBankStatement(ctx context.Context, statementID string) error {
-	return nil
+// paymentLegalEntity returns the legal entity of the invoice or bill a payment
+// settles, or "" when it settles none.
+func (s *CashManagementService) paymentLegalEntity(ctx context.Context, p domain.Payment) (string, error) {
+	invoiceID, billID := p.InvoiceID, p.BillID
+	if invoiceID == nil && billID == nil && s.allocations != nil {
+		allocs, err := s.allocations.ListBySource(ctx, p.ID)
+		if err != nil {
+			return "", err
+		}
+		for _, a := range allocs {
+			if a.InvoiceID != nil || a.BillID != nil {
+				invoiceID, billID = a.InvoiceID, a.BillID
+				break
+			}
+		}
+	}
+	switch {
+	case invoiceID != nil:
+		inv, err := s.invoices.GetByID(ctx, *invoiceID)
+		if err != nil {
+			return "", err
+		}
+		return inv.LegalEntityID, nil
+	case billID != nil:
+		bill, err := s.bills.GetByID(ctx, *billID)
+		if err != nil {
+			return "", err
+		}
+		return bill.LegalEntityID, nil
+	}
+	return "", nil
 }
 
-func (s *CashManagementService) GetCashFlowForecast(ctx context.Context, monthsAhead int) (map[string]interface{}, error) {
-	return map[string]interface{}{
-		"forecast_period_months": monthsAhead,
-		"projected_cash_inflow":  decimal.NewFromInt(125000),
-		"projected_cash_outflow": decimal.NewFromInt(80000),
-		"net_cash_flow":          decimal.NewFromInt(45000),
-	}, nil
+// syncExceptions keeps the exceptions queue in step with line state: unmatched lines get an
+// open exception (reasons refreshed), matched lines have their open exceptions resolved.
+func (s *CashManagementService) syncExceptions(ctx context.Context, statementID string, lines []domain.BankStatementLine, unmatched map[string]string) ([]domain.BankReconciliationException, error) {
+	existing, err := s.exceptions.ListByStatement(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+	openByLine := make(map[string]domain.BankReconciliationException)
+	for _, e := range existing {
+		if e.Status == domain.ReconciliationExceptionStatusOPEN {
+			openByLine[e.StatementLineID] = e
+		}
+	}
+
+	var queued []domain.BankReconciliationException
+	now := time.Now()
+	for _, l := range lines {
+		exc, hasOpen := openByLine[l.ID]
+		if l.IsMatched {
+			if hasOpen {
+				exc.Status = domain.ReconciliationExceptionStatusRESOLVED
+				exc.ResolvedAt = &now
+				if err := s.exceptions.Update(ctx, &exc); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		reason, ok := unmatched[l.ID]
+		if !ok {
+			if hasOpen {
+				queued = append(queued, exc)
+			}
+			continue
+		}
+		if hasOpen {
+			exc.Reason = reason
+			if err := s.exceptions.Update(ctx, &exc); err != nil {
+				return nil, err
+			}
+			queued = append(queued, exc)
+			continue
+		}
+		exc = domain.BankReconciliationException{
+			ID:              utils.NewID("rex"),
+			StatementID:     statementID,
+			StatementLineID: l.ID,
+			Reason:          reason,
+			Status:          domain.ReconciliationExceptionStatusOPEN,
+			CreatedAt:       now,
+		}
+		if err := s.exceptions.Create(ctx, &exc); err != nil {
+			return nil, err
+		}
+		queued = append(queued, exc)
+	}
+	return queued, nil
 }
 
-func (s *CashManagementService) GetBankStatement(ctx context.Context, id string) (*domain.BankStatement, []domain.BankStatementLine, error) {
-	if s.statements == nil {
-		return nil, nil, fmt.Errorf("bank statement repository not initialized")
+// buildMatchRows expands a logical match into one row per line/payment pair sharing a group ID.
+func buildMatchRows(statementID string, lines []domain.BankStatementLine, payments []domain.Payment, matchType domain.ReconciliationMatchType, confidence decimal.Decimal) []domain.BankReconciliationMatch {
+	groupID := utils.NewID("rmg")
+	now := time.Now()
+	var rows []domain.BankReconciliationMatch
+	for _, l := range lines {
+		for _, p := range payments {
+			// On a 1:N match each payment carries its own amount, on N:1 each line does
+			amount := l.Amount.Abs()
+			if len(payments) > 1 {
+				amount = p.Amount
+			}
+			rows = append(rows, domain.BankReconciliationMatch{
+				ID:              utils.NewID("rm"),
+				StatementID:     statementID,
+				MatchGroupID:    groupID,
+				StatementLineID: l.ID,
+				PaymentID:       p.ID,
+				MatchedAmount:   amount,
+				ConfidenceScore: confidence,
+				MatchType:       matchType,
+				CreatedAt:       now,
+			})
+		}
 	}
-	return s.statements.GetByID(ctx, id)
+	return rows
+}
+
+func allLinesMatched(lines []domain.BankStatementLine) bool {
+	for _, l := range lines {
+		if !l.IsMatched {
+			return false
+		}
+	}
+	return len(lines) > 0
+}
+
+// StatementImportResult describes the outcome of a statement file import.
+// Errors is populated when the file was rejected because of invalid entries.
+type StatementImportResult struct {
+	Format        string                      `json:"format"`
+	Statements    []domain.BankStatement      `json:"statements"`
+	LinesImported int                         `json:"lines_imported"`
+	Errors        []statementparser.LineError `json:"errors,omitempty"`
+}
+
+// ImportBankStatements parses a camt.053, MT940 or BAI2 file and stores every statement it
+// contains under the bank account. The import is all-or-nothing: any per-line parse error,
+// account mismatch or previously imported statement rejects the whole file.
+func (s *CashManagementService) ImportBankStatements(ctx context.Context, bankAccountID, format string, data []byte) (*StatementImportResult, error) {
+	if s.statements == nil || s.bankAccounts == nil {
+		return nil, fmt.Errorf("bank statement repositories not initialized")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("statement file is empty")
+	}
+	f := statementparser.Format(strings.ToUpper(format))
+	if f != "" && !f.IsValid() {
+		return nil, fmt.Errorf("unsupported statement format: %s", format)
+	}
+
+	bankAccount, err := s.bankAccounts.GetByID(ctx, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := statementparser.Parse(f, data)
+	if err != nil {
+		return nil, err
+	}
+	result := &StatementImportResult{Format: string(parsed.Format), Errors: parsed.Errors}
+	if len(parsed.Errors) > 0 {
+		return result, domain.ErrInvalidBankStatementFile
+	}
+
+	seen := make(map[string]bool, len(parsed.Statements))
+	for _, st := range parsed.Statements {
+		if !sameBankAccount(st.AccountID, bankAccount.AccountNumber) {
+			return nil, fmt.Errorf("statement %s is for account %s, not bank account %s", st.Reference, st.AccountID, bankAccount.AccountNumber)
+		}
+		if st.Currency != "" && bankAccount.Currency != "" && !strings.EqualFold(st.Currency, bankAccount.Currency) {
+			return nil, fmt.Errorf("statement %s currency %s does not match bank account currency %s", st.Reference, st.Currency, bankAccount.Currency)
+		}
+		if seen[st.Reference] {
+			return nil, fmt.Errorf("%w: %s appears twice in the file", domain.ErrDuplicateBankStatement, st.Reference)
+		}
+		seen[st.Reference] = true
+	}
+
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		for _, st := range parsed.Statements {
+			exists, err := s.statements.ExistsByReference(txCtx, bankAccount.ID, st.Reference)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("%w: %s", domain.ErrDuplicateBankStatement, st.Reference)
+			}
+
+			stmt := domain.BankStatement{
+				ID:                 utils.NewID("bs"),
+				BankAccountID:      bankAccount.ID,
+				StatementReference: st.Reference,
+				SourceFormat:       string(parsed.Format),
+				StatementDate:      st.StatementDate,
+				OpeningBalance:     st.OpeningBalance,
+				EndingBalance:      st.ClosingBalance,
+			}
+			lines := make([]domain.BankStatementLine, len(st.Lines))
+			for i, l := range st.Lines {
+				lines[i] = domain.BankStatementLine{
+					ID:              utils.NewID("bsl"),
+					StatementID:     stmt.ID,
+					TransactionDate: l.BookingDate,
+					Description:     l.Description,
+					Reference:       l.Reference,
+					Amount:          l.Amount,
+				}
+			}
+			if err := s.statements.Create(txCtx, &stmt, lines); err != nil {
+				return err
+			}
+			result.Statements = append(result.Statements, stmt)
+			result.LinesImported += len(lines)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sameBankAccount compares the account named in a statement file with the bank account on
+// record. Files often carry the IBAN while the record holds the domestic number, so one may
+// contain the other.
+func sameBankAccount(fileAccount, accountNumber string) bool {
+	normalize := func(s string) string {
+		return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	}
+	a, b := normalize(fileAccount), normalize(accountNumber)
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
 }
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// ConsolidationEntity describes how one member legal entity was translated
+type ConsolidationEntity struct {
+	LegalEntityID      string          `json:"legal_entity_id"`
+	CompanyCode        string          `json:"company_code"`
+	FunctionalCurrency string          `json:"functional_currency"`
+	ClosingRate        decimal.Decimal `json:"closing_rate"` // Functional -> reporting rate at the consolidation date
+}
+
+// ConsolidatedLine is the group balance of one account code in the reporting currency. Amounts
+// follow the statement sign: debit-normal for assets and expenses, credit-normal otherwise.
+type ConsolidatedLine struct {
+	AccountCode string             `json:"account_code"`
+	AccountName string             `json:"account_name"`
+	Type        domain.AccountType `json:"type"`
+	Amount      decimal.Decimal    `json:"amount"`
+}
+
+type ConsolidatedBalanceSheet struct {
+	Assets           []ConsolidatedLine `json:"assets"`
+	TotalAssets      decimal.Decimal    `json:"total_assets"`
+	Liabilities      []ConsolidatedLine `json:"liabilities"`
+	TotalLiabilities decimal.Decimal    `json:"total_liabilities"`
+	Equity           []ConsolidatedLine `json:"equity"`
+	TotalEquity      decimal.Decimal    `json:"total_equity"`
+}
+
+type ConsolidatedIncomeStatement struct {
+	Revenues     []ConsolidatedLine `json:"revenues"`
+	TotalRevenue decimal.Decimal    `json:"total_revenue"`
+	Expenses     []ConsolidatedLine `json:"expenses"`
+	TotalExpense decimal.Decimal    `json:"total_expense"`
+	NetIncome    decimal.Decimal    `json:"net_income"`
+}
+
+// ConsolidationResult is the result of ConsolidationService.RunConsolidation
+type ConsolidationResult struct {
+	ConsolidationGroupID  string                      `json:"consolidation_group_id"`
+	ReportingCurrency     string                      `json:"reporting_currency"`
+	AsOf                  time.Time                   `json:"as_of"`
+	Entities              []ConsolidationEntity       `json:"entities"`
+	BalanceSheet          ConsolidatedBalanceSheet    `json:"balance_sheet"`
+	IncomeStatement       ConsolidatedIncomeStatement `json:"income_statement"`
+	Eliminations          []ConsolidatedLine          `json:"eliminations"`
+	TranslationAdjustment decimal.Decimal             `json:"translation_adjustment"`
+}
+
+type ConsolidationService struct {
+	groups        domain.ConsolidationGroupRepository
+	legalEntities domain.LegalEntityRepository
+	accounts      domain.ChartOfAccountsRepository
+	entries       domain.UniversalJournalEntryRepository
+	fx            *CurrencyConverter
+	tm            domain.TransactionManager
+}
+
+func NewConsolidationService(
+	groups domain.ConsolidationGroupRepository,
+	legalEntities domain.LegalEntityRepository,
+	accounts domain.ChartOfAccountsRepository,
+	entries domain.UniversalJournalEntryRepository,
+	fx *CurrencyConverter,
+	tm domain.TransactionManager,
+) *ConsolidationService {
+	return &ConsolidationService{
+		groups:        groups,
+		legalEntities: legalEntities,
+		accounts:      accounts,
+		entries:       entries,
+		fx:            fx,
+		tm:            tm,
+	}
+}
+
+func (s *ConsolidationService) ListGroups(ctx context.Context) ([]domain.ConsolidationGroup, error) {
+	return s.groups.List(ctx)
+}
+
+func (s *ConsolidationService) GetGroup(ctx context.Context, id string) (*domain.ConsolidationGroup, []domain.ConsolidationGroupMember, error) {
+	group, members, err := s.groups.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", domain.ErrConsolidationGroupNotFound, id)
+	}
+	return group, members, nil
+}
+
+func (s *ConsolidationService) CreateGroup(ctx context.Context, code, name, reportingCurrency string, legalEntityIDs []string) (*domain.ConsolidationGroup, []domain.ConsolidationGroupMember, error) {
+	if code == "" || name == "" || reportingCurrency == "" || len(legalEntityIDs) == 0 {
+		return nil, nil, fmt.Errorf("%w: code, name, reporting currency and members are required", domain.ErrInvalidConsolidationGroup)
+	}
+	group := &domain.ConsolidationGroup{
+		ID:                utils.NewID("cg"),
+		Code:              code,
+		Name:              name,
+		ReportingCurrency: reportingCurrency,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+	seen := make(map[string]bool)
+	var members []domain.ConsolidationGroupMember
+	for _, id := range legalEntityIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := s.legalEntities.GetByID(ctx, id); err != nil {
+			return nil, nil, fmt.Errorf("%w: legal entity %s not found", domain.ErrInvalidConsolidationGroup, id)
+		}
+		members = append(members, domain.ConsolidationGroupMember{ID: utils.NewID("cgm"), ConsolidationGroupID: group.ID, LegalEntityID: id})
+	}
+
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.groups.Create(txCtx, group, members)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return group, members, nil
+}
+
+// RunConsolidation consolidates the posted ledgers of a group's legal entities up to asOf.
+// Balance sheet accounts are translated at the closing rate, revenue, expense and equity at the
+// rate of each posting date; the resulting difference is the currency translation adjustment.
+// Lines tagged with a trading partner inside the group are eliminated.
+func (s *ConsolidationService) RunConsolidation(ctx context.Context, groupID string, asOf time.Time) (*ConsolidationResult, error) {
+	group, members, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConsolidationResult{ConsolidationGroupID: group.ID, ReportingCurrency: group.ReportingCurrency, AsOf: asOf}
+	inGroup := make(map[string]bool, len(members))
+	closingRates := make(map[string]decimal.Decimal, len(members))
+	functionals := make(map[string]string, len(members))
+	for _, m := range members {
+		le, err := s.legalEntities.GetByID(ctx, m.LegalEntityID)
+		if err != nil {
+			return nil, fmt.Errorf("legal entity %s not found: %w", m.LegalEntityID, err)
+		}
+		rate, err := s.fx.Rate(ctx, le.FunctionalCurrency, group.ReportingCurrency, asOf)
+		if err != nil {
+			return nil, err
+		}
+		inGroup[le.ID] = true
+		closingRates[le.ID] = rate
+		functionals[le.ID] = le.FunctionalCurrency
+		result.Entities = append(result.Entities, ConsolidationEntity{
+			LegalEntityID:      le.ID,
+			CompanyCode:        le.CompanyCode,
+			FunctionalCurrency: le.FunctionalCurrency,
+			ClosingRate:        rate,
+		})
+	}
+
+	accs, err := s.accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[string]domain.ChartOfAccounts)
+	for _, a := range accs {
+		if inGroup[a.LegalEntityID] {
+			accounts[a.ID] = a
+		}
+	}
+
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	consolidated := make(map[string]*ConsolidatedLine)
+	eliminated := make(map[string]*ConsolidatedLine)
+	historicalRates := make(map[string]decimal.Decimal)
+	total := decimal.Zero
+	for _, entry := range entries {
+		if !inGroup[entry.LegalEntityID] || entry.PostingDate.After(asOf) ||
+			(entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED) {
+			continue
+		}
+		rate := closingRates[entry.LegalEntityID]
+		historical, ok := historicalRates[entry.LegalEntityID+entry.PostingDate.Format("2006-01-02")]
+		if !ok {
+			historical, err = s.fx.Rate(ctx, functionals[entry.LegalEntityID], group.ReportingCurrency, entry.PostingDate)
+			if err != nil {
+				return nil, err
+			}
+			historicalRates[entry.LegalEntityID+entry.PostingDate.Format("2006-01-02")] = historical
+		}
+
+		_, lines, err := s.entries.GetByID(ctx, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lines {
+			acc, ok := accounts[l.AccountID]
+			if !ok {
+				continue
+			}
+			lineRate := historical
+			if acc.Type == domain.AccountTypeASSET || acc.Type == domain.AccountTypeLIABILITY {
+				lineRate = rate
+			}
+			amount := convertAmount(l.AmountFunctional, lineRate)
+			total = total.Add(amount)
+
+			target := consolidated
+			if partner := tradingPartner(l.TrackingDimensions); partner != "" && partner != entry.LegalEntityID && inGroup[partner] {
+				target = eliminated
+			}
+			line, ok := target[acc.AccountCode]
+			if !ok {
+				line = &ConsolidatedLine{AccountCode: acc.AccountCode, AccountName: acc.AccountName, Type: acc.Type}
+				target[acc.AccountCode] = line
+			}
+			line.Amount = line.Amount.Add(amount)
+		}
+	}
+
+	// Whatever no longer balances after translation and elimination is the translation adjustment
+	remaining := total
+	for _, line := range eliminated {
+		remaining = remaining.Sub(line.Amount)
+	}
+	result.TranslationAdjustment = remaining
+
+	for _, line := range sortedLines(eliminated) {
+		if !line.Amount.IsZero() {
+			result.Eliminations = append(result.Eliminations, statementLine(line))
+		}
+	}
+
+	is := &result.IncomeStatement
+	bs := &result.BalanceSheet
+	for _, raw := range sortedLines(consolidated) {
+		if raw.Amount.IsZero() {
+			continue
+		}
+		line := statementLine(raw)
+		switch line.Type {
+		case domain.AccountTypeREVENUE:
+			is.Revenues = append(is.Revenues, line)
+			is.TotalRevenue = is.TotalRevenue.Add(line.Amount)
+		case domain.AccountTypeEXPENSE:
+			is.Expenses = append(is.Expenses, line)
+			is.TotalExpense = is.TotalExpense.Add(line.Amount)
+		case domain.AccountTypeASSET:
+			bs.Assets = append(bs.Assets, line)
+			bs.TotalAssets = bs.TotalAssets.Add(line.Amount)
+		case domain.AccountTypeLIABILITY:
+			bs.Liabilities = append(bs.Liabilities, line)
+			bs.TotalLiabilities = bs.TotalLiabilities.Add(line.Amount)
+		case domain.AccountTypeEQUITY:
+			bs.Equity = append(bs.Equity, line)
+			bs.TotalEquity = bs.TotalEquity.Add(line.Amount)
+		}
+	}
+	is.NetIncome = is.TotalRevenue.Sub(is.TotalExpense)
+
+	// Unclosed earnings and the translation adjustment complete group equity
+	if !is.NetIncome.IsZero() {
+		bs.Equity = append(bs.Equity, ConsolidatedLine{AccountName: "Current Period Earnings", Type: domain.AccountTypeEQUITY, Amount: is.NetIncome})
+		bs.TotalEquity = bs.TotalEquity.Add(is.NetIncome)
+	}
+	if !result.TranslationAdjustment.IsZero() {
+		bs.Equity = append(bs.Equity, ConsolidatedLine{AccountName: "Currency Translation Adjustment", Type: domain.AccountTypeEQUITY, Amount: result.TranslationAdjustment})
+		bs.TotalEquity = bs.TotalEquity.Add(result.TranslationAdjustment)
+	}
+	return result, nil
+}
+
+func sortedLines(lines map[string]*ConsolidatedLine) []ConsolidatedLine {
+	list := make([]ConsolidatedLine, 0, len(lines))
+	for _, l := range lines {
+		list = append(list, *l)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].AccountCode < list[j].AccountCode })
+	return list
+}
+
+// statementLine flips the raw ledger sign of credit-normal accounts
+func statementLine(line ConsolidatedLine) ConsolidatedLine {
+	if line.Type != domain.AccountTypeASSET && line.Type != domain.AccountTypeEXPENSE {
+		line.Amount = line.Amount.Neg()
+	}
+	return line
+}
@@ -0,0 +1,228 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func entityAccountID(t *testing.T, accounts *memory.MemoryChartOfAccountsRepo, le, code string) string {
+	t.Helper()
+	acc, err := accounts.GetByCode(context.Background(), le, code)
+	if err != nil {
+		t.Fatalf("account %s/%s not found: %v", le, code, err)
+	}
+	return acc.ID
+}
+
+func postEntityEntry(t *testing.T, gl *service.GeneralLedgerService, accounts *memory.MemoryChartOfAccountsRepo, le string, date time.Time, debit, credit string, amount int64) {
+	t.Helper()
+	_, err := gl.CreateJournalEntry(context.Background(), le, "FM", "doc", date, []domain.UniversalJournalLine{
+		{AccountID: entityAccountID(t, accounts, le, debit), AmountFunctional: decimal.NewFromInt(amount)},
+		{AccountID: entityAccountID(t, accounts, le, credit), AmountFunctional: decimal.NewFromInt(-amount)},
+	})
+	if err != nil {
+		t.Fatalf("failed to post: %v", err)
+	}
+}
+
+func TestIntercompany_MirroredEntries(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	transactions := memory.NewMemoryIntercompanyTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, transactions, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	ic := service.NewIntercompanyService(transactions, accounts, legalEntities, gl, outbox, tm)
+	ctx := context.Background()
+
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_de", CompanyCode: "DE", FunctionalCurrency: "EUR"})
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_uk", CompanyCode: "UK", FunctionalCurrency: "GBP"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: day(2025, 1, 1)})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r2", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.20"), EffectiveDate: day(2025, 6, 30)})
+
+	for _, a := range []struct{ le, code, name, typ string }{
+		{"le_us", "1010-001", "Bank", "ASSET"},
+		{"le_us", "4000-001", "Sales", "REVENUE"},
+		{"le_de", "1010-001", "Bank", "ASSET"},
+		{"le_de", "3000-001", "Share Capital", "EQUITY"},
+		{"le_de", "6000-001", "Services", "EXPENSE"},
+	} {
+		if _, err := gl.CreateAccount(ctx, a.le, a.code, a.name, a.typ); err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+	}
+
+	tx, err := ic.CreateTransaction(ctx, service.IntercompanyRequest{
+		FromLegalEntityID: "le_us",
+		ToLegalEntityID:   "le_de",
+		FromAccountID:     entityAccountID(t, accounts, "le_us", "4000-001"),
+		ToAccountID:       entityAccountID(t, accounts, "le_de", "6000-001"),
+		Amount:            decimal.NewFromInt(100),
+		PostingDate:       day(2025, 3, 1),
+		Description:       "Management fee",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Currency != "USD" {
+		t.Errorf("expected currency to default to the charging entity's USD, got %s", tx.Currency)
+	}
+
+	// The charging entity books a due-from receivable, the charged entity a due-to payable in EUR
+	_, fromLines, _ := gl.GetJournalEntry(ctx, tx.FromJournalEntryID)
+	_, toLines, _ := gl.GetJournalEntry(ctx, tx.ToJournalEntryID)
+	if len(fromLines) != 2 || fromLines[0].AccountID != entityAccountID(t, accounts, "le_us", "1150-001") {
+		t.Fatalf("expected due-from line in le_us, got %+v", fromLines)
+	}
+	assertAmount(t, "due-from", fromLines[0].AmountFunctional, 100)
+	if len(toLines) != 2 || toLines[1].AccountID != entityAccountID(t, accounts, "le_de", "2150-001") {
+		t.Fatalf("expected due-to line in le_de, got %+v", toLines)
+	}
+	if !toLines[1].AmountFunctional.Equal(decimal.RequireFromString("-90.91")) {
+		t.Errorf("expected due-to of -90.91 EUR, got %s", toLines[1].AmountFunctional)
+	}
+	for _, l := range append(fromLines, toLines...) {
+		dims, _ := l.TrackingDimensions.(map[string]interface{})
+		if dims["trading_partner"] == nil {
+			t.Errorf("expected trading partner on line %+v", l)
+		}
+	}
+
+	list, err := ic.ListTransactions(ctx, "le_de")
+	if err != nil || len(list) != 1 {
+		t.Errorf("expected 1 transaction for le_de, got %d (%v)", len(list), err)
+	}
+	pending, _ := outbox.GetPending(ctx, 100)
+	found := false
+	for _, rec := range pending {
+		if rec.EventType == string(domain.TopicFmIntercompanyPosted) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected intercompany posted event")
+	}
+
+	for _, req := range []service.IntercompanyRequest{
+		{FromLegalEntityID: "le_us", ToLegalEntityID: "le_us", FromAccountID: entityAccountID(t, accounts, "le_us", "4000-001"), ToAccountID: entityAccountID(t, accounts, "le_us", "1010-001"), Amount: decimal.NewFromInt(1)},
+		{FromLegalEntityID: "le_us", ToLegalEntityID: "le_de", FromAccountID: entityAccountID(t, accounts, "le_us", "4000-001"), ToAccountID: entityAccountID(t, accounts, "le_de", "6000-001")},
+		{FromLegalEntityID: "le_us", ToLegalEntityID: "le_de", FromAccountID: entityAccountID(t, accounts, "le_de", "6000-001"), ToAccountID: entityAccountID(t, accounts, "le_de", "6000-001"), Amount: decimal.NewFromInt(1)},
+		{FromLegalEntityID: "le_us", ToLegalEntityID: "le_xx", FromAccountID: entityAccountID(t, accounts, "le_us", "4000-001"), ToAccountID: entityAccountID(t, accounts, "le_de", "6000-001"), Amount: decimal.NewFromInt(1)},
+	} {
+		if _, err := ic.CreateTransaction(ctx, req); !errors.Is(err, domain.ErrInvalidIntercompanyTransaction) {
+			t.Errorf("expected invalid intercompany transaction for %+v, got %v", req, err)
+		}
+	}
+}
+
+func TestConsolidation_TranslatesAndEliminates(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	transactions := memory.NewMemoryIntercompanyTransactionRepo()
+	groups := memory.NewMemoryConsolidationGroupRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, transactions, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	ic := service.NewIntercompanyService(transactions, accounts, legalEntities, gl, outbox, tm)
+	svc := service.NewConsolidationService(groups, legalEntities, accounts, entries, converter, tm)
+	ctx := context.Background()
+
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_de", CompanyCode: "DE", FunctionalCurrency: "EUR"})
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_uk", CompanyCode: "UK", FunctionalCurrency: "GBP"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: day(2025, 1, 1)})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r2", FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.20"), EffectiveDate: day(2025, 6, 30)})
+
+	for _, a := range []struct{ le, code, name, typ string }{
+		{"le_us", "1010-001", "Bank", "ASSET"},
+		{"le_us", "4000-001", "Sales", "REVENUE"},
+		{"le_de", "1010-001", "Bank", "ASSET"},
+		{"le_de", "3000-001", "Share Capital", "EQUITY"},
+		{"le_de", "6000-001", "Services", "EXPENSE"},
+	} {
+		if _, err := gl.CreateAccount(ctx, a.le, a.code, a.name, a.typ); err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+	}
+
+	postEntityEntry(t, gl, accounts, "le_de", day(2025, 1, 15), "1010-001", "3000-001", 2000)
+	postEntityEntry(t, gl, accounts, "le_de", day(2025, 2, 1), "6000-001", "1010-001", 500)
+	postEntityEntry(t, gl, accounts, "le_us", day(2025, 2, 1), "1010-001", "4000-001", 1000)
+	// Posted after the consolidation date
+	postEntityEntry(t, gl, accounts, "le_us", day(2025, 7, 15), "1010-001", "4000-001", 999)
+	if _, err := ic.CreateTransaction(ctx, service.IntercompanyRequest{
+		FromLegalEntityID: "le_us", ToLegalEntityID: "le_de",
+		FromAccountID: entityAccountID(t, accounts, "le_us", "4000-001"), ToAccountID: entityAccountID(t, accounts, "le_de", "6000-001"),
+		Amount: decimal.NewFromInt(100), PostingDate: day(2025, 3, 1),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	group, members, err := svc.CreateGroup(ctx, "GRP", "Group", "USD", []string{"le_us", "le_de", "le_de"})
+	if err != nil || len(members) != 2 {
+		t.Fatalf("expected group with 2 members, got %d (%v)", len(members), err)
+	}
+
+	result, err := svc.RunConsolidation(ctx, group.ID, day(2025, 6, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Bank: 1000 USD + 1500 EUR at the 1.20 closing rate
+	bs := result.BalanceSheet
+	if len(bs.Assets) != 1 || len(bs.Liabilities) != 0 {
+		t.Fatalf("expected intercompany balances to be eliminated, got %+v", bs)
+	}
+	assertAmount(t, "total assets", bs.TotalAssets, 2800)
+
+	// Revenue and expense exclude the intercompany fee; DE expense is translated at 1.10
+	is := result.IncomeStatement
+	assertAmount(t, "total revenue", is.TotalRevenue, 1000)
+	assertAmount(t, "total expense", is.TotalExpense, 550)
+	assertAmount(t, "net income", is.NetIncome, 450)
+
+	// Capital at the historical 1.10 rate, the rest of the rate move is translation adjustment
+	assertAmount(t, "translation adjustment", result.TranslationAdjustment, 150)
+	assertAmount(t, "total equity", bs.TotalEquity, 2800)
+	if !bs.TotalAssets.Equal(bs.TotalLiabilities.Add(bs.TotalEquity)) {
+		t.Errorf("consolidated balance sheet does not balance: %s vs %s", bs.TotalAssets, bs.TotalLiabilities.Add(bs.TotalEquity))
+	}
+	if len(result.Eliminations) != 4 {
+		t.Errorf("expected 4 elimination lines, got %+v", result.Eliminations)
+	}
+
+	// Without the partner in the group nothing is eliminated
+	usOnly, _, _ := svc.CreateGroup(ctx, "US", "US only", "USD", []string{"le_us"})
+	single, err := svc.RunConsolidation(ctx, usOnly.ID, day(2025, 6, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "us total revenue", single.IncomeStatement.TotalRevenue, 1100)
+	assertAmount(t, "us total assets", single.BalanceSheet.TotalAssets, 1100)
+
+	// Entities without a rate into the reporting currency cannot be translated
+	withUK, _, _ := svc.CreateGroup(ctx, "ALL", "All", "USD", []string{"le_us", "le_uk"})
+	if _, err := svc.RunConsolidation(ctx, withUK.ID, day(2025, 6, 30)); !errors.Is(err, domain.ErrExchangeRateNotFound) {
+		t.Errorf("expected missing rate error, got %v", err)
+	}
+	if _, err := svc.RunConsolidation(ctx, "missing", day(2025, 6, 30)); !errors.Is(err, domain.ErrConsolidationGroupNotFound) {
+		t.Errorf("expected group not found, got %v", err)
+	}
+	if _, _, err := svc.CreateGroup(ctx, "BAD", "Bad", "USD", []string{"le_xx"}); !errors.Is(err, domain.ErrInvalidConsolidationGroup) {
+		t.Errorf("expected invalid group, got %v", err)
+	}
+}
@@ -0,0 +1,656 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// allocationCycleDimension tags allocation lines with the cycle that posted them
+const allocationCycleDimension = "allocation_cycle_id"
+
+var hundred = decimal.NewFromInt(100)
+
+type AllocationReceiverRequest struct {
+	CostCenterID string          `json:"cost_center_id"`
+	Percentage   decimal.Decimal `json:"percentage"` // Required for FIXED_PERCENT, ignored for STATISTICAL
+}
+
+type AllocationCycleRequest struct {
+	LegalEntityID string                      `json:"legal_entity_id"`
+	Name          string                      `json:"name"`
+	Description   string                      `json:"description"`
+	Method        domain.AllocationMethod     `json:"method"`
+	Driver        domain.AllocationDriver     `json:"driver"`
+	IsActive      *bool                       `json:"is_active"` // Only honoured on update; new cycles are active
+	Senders       []string                    `json:"senders"`   // Cost center IDs whose balances are moved
+	Receivers     []AllocationReceiverRequest `json:"receivers"`
+}
+
+// AllocationCycleDetail is a cycle with its senders and receivers and the runs posted for it.
+type AllocationCycleDetail struct {
+	Cycle   *domain.AllocationCycle        `json:"cycle"`
+	Members []domain.AllocationCycleMember `json:"members"`
+	Runs    []domain.AllocationRun         `json:"runs"`
+}
+
+// CostAllocationService distributes the expense balances of sender cost centers to receiver cost
+// centers, by fixed percentages or in proportion to a statistical driver such as headcount or
+// machine hours. Each run posts one journal entry for a period through the GeneralLedgerService.
+type CostAllocationService struct {
+	cycles      domain.AllocationCycleRepository
+	runs        domain.AllocationRunRepository
+	keyFigures  domain.StatisticalKeyFigureRepository
+	costCenters domain.CostCenterRepository
+	accounts    domain.ChartOfAccountsRepository
+	entries     domain.UniversalJournalEntryRepository
+	gl          *GeneralLedgerService
+	tm          domain.TransactionManager
+}
+
+func NewCostAllocationService(
+	cycles domain.AllocationCycleRepository,
+	runs domain.AllocationRunRepository,
+	keyFigures domain.StatisticalKeyFigureRepository,
+	costCenters domain.CostCenterRepository,
+	accounts domain.ChartOfAccountsRepository,
+	entries domain.UniversalJournalEntryRepository,
+	gl *GeneralLedgerService,
+	tm domain.TransactionManager,
+) *CostAllocationService {
+	return &CostAllocationService{
+		cycles:      cycles,
+		runs:        runs,
+		keyFigures:  keyFigures,
+		costCenters: costCenters,
+		accounts:    accounts,
+		entries:     entries,
+		gl:          gl,
+		tm:          tm,
+	}
+}
+
+func (s *CostAllocationService) ListCostCenters(ctx context.Context) ([]domain.CostCenter, error) {
+	centers, err := s.costCenters.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(centers, func(i, j int) bool { return centers[i].Code < centers[j].Code })
+	return centers, nil
+}
+
+// CreateCostCenter adds a cost center that journal lines can be booked to and cycles can allocate
+// between. Codes are unique.
+func (s *CostAllocationService) CreateCostCenter(ctx context.Context, code, name, description string, managerID *string) (*domain.CostCenter, error) {
+	code = strings.TrimSpace(code)
+	name = strings.TrimSpace(name)
+	if code == "" || name == "" {
+		return nil, fmt.Errorf("%w: code and name are required", domain.ErrInvalidCostCenter)
+	}
+	existing, err := s.costCenters.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cc := range existing {
+		if strings.EqualFold(cc.Code, code) {
+			return nil, fmt.Errorf("%w: code %s is already in use", domain.ErrInvalidCostCenter, code)
+		}
+	}
+	cc := &domain.CostCenter{
+		ID:          utils.NewID("cc"),
+		Code:        code,
+		Name:        name,
+		Description: description,
+		ManagerID:   managerID,
+		IsActive:    true,
+	}
+	if err := s.costCenters.Create(ctx, cc); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+func (s *CostAllocationService) CreateCycle(ctx context.Context, req AllocationCycleRequest) (*AllocationCycleDetail, error) {
+	if err := s.validateCycle(ctx, &req); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	cycle := &domain.AllocationCycle{
+		ID:            utils.NewID("alc"),
+		LegalEntityID: req.LegalEntityID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Method:        req.Method,
+		Driver:        req.Driver,
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	members := cycleMembers(cycle.ID, req)
+	if err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.cycles.Create(txCtx, cycle, members)
+	}); err != nil {
+		return nil, err
+	}
+	return &AllocationCycleDetail{Cycle: cycle, Members: members, Runs: []domain.AllocationRun{}}, nil
+}
+
+// UpdateCycle replaces a cycle's method, senders and receivers. Runs already posted keep the
+// amounts they were posted with until they are re-run.
+func (s *CostAllocationService) UpdateCycle(ctx context.Context, id string, req AllocationCycleRequest) (*AllocationCycleDetail, error) {
+	var detail *AllocationCycleDetail
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		cycle, _, err := s.cycles.GetByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrAllocationCycleNotFound, id)
+		}
+		req.LegalEntityID = cycle.LegalEntityID
+		if err := s.validateCycle(txCtx, &req); err != nil {
+			return err
+		}
+
+		cycle.Name = req.Name
+		cycle.Description = req.Description
+		cycle.Method = req.Method
+		cycle.Driver = req.Driver
+		if req.IsActive != nil {
+			cycle.IsActive = *req.IsActive
+		}
+		cycle.UpdatedAt = time.Now()
+
+		members := cycleMembers(cycle.ID, req)
+		if err := s.cycles.Update(txCtx, cycle, members); err != nil {
+			return err
+		}
+		runs, err := s.runs.ListByCycle(txCtx, cycle.ID)
+		if err != nil {
+			return err
+		}
+		detail = &AllocationCycleDetail{Cycle: cycle, Members: members, Runs: runs}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (s *CostAllocationService) ListCycles(ctx context.Context, legalEntityID string) ([]domain.AllocationCycle, error) {
+	cycles, err := s.cycles.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]domain.AllocationCycle, 0, len(cycles))
+	for _, c := range cycles {
+		if legalEntityID == "" || c.LegalEntityID == legalEntityID {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (s *CostAllocationService) GetCycle(ctx context.Context, id string) (*AllocationCycleDetail, error) {
+	cycle, members, err := s.cycles.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAllocationCycleNotFound, id)
+	}
+	runs, err := s.runs.ListByCycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []domain.AllocationRun{}
+	}
+	return &AllocationCycleDetail{Cycle: cycle, Members: members, Runs: runs}, nil
+}
+
+// RecordKeyFigures stores driver values per cost center and period, replacing values recorded
+// earlier for the same cost center, driver and period. All values are stored or none.
+func (s *CostAllocationService) RecordKeyFigures(ctx context.Context, figures []domain.StatisticalKeyFigure) ([]domain.StatisticalKeyFigure, error) {
+	if len(figures) == 0 {
+		return nil, fmt.Errorf("%w: no values given", domain.ErrInvalidKeyFigure)
+	}
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		for i := range figures {
+			kf := &figures[i]
+			if !kf.Driver.IsValid() {
+				return fmt.Errorf("%w: unknown driver %q", domain.ErrInvalidKeyFigure, kf.Driver)
+			}
+			if _, err := parsePeriod(kf.FinancialPeriod); err != nil {
+				return fmt.Errorf("%w: period %q must be YYYY-MM", domain.ErrInvalidKeyFigure, kf.FinancialPeriod)
+			}
+			if kf.Value.IsNegative() {
+				return fmt.Errorf("%w: value for %s must not be negative", domain.ErrInvalidKeyFigure, kf.CostCenterID)
+			}
+			if _, err := s.costCenters.GetByID(txCtx, kf.CostCenterID); err != nil {
+				return fmt.Errorf("%w: unknown cost center %q", domain.ErrInvalidKeyFigure, kf.CostCenterID)
+			}
+			kf.ID = utils.NewID("skf")
+			kf.Source = strings.ToUpper(strings.TrimSpace(kf.Source))
+			kf.UpdatedAt = time.Now()
+			if err := s.keyFigures.Upsert(txCtx, kf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return figures, nil
+}
+
+// RecordHeadcount replaces the HEADCOUNT driver of a period with the active employees per cost
+// center that hr-service reports when it approves a payroll run. Cost centers fm-service does not
+// know are skipped, so one missing master record does not hold up the payroll.
+func (s *CostAllocationService) RecordHeadcount(ctx context.Context, period string, headcount []domain.CostCenterHeadcount) error {
+	figures := make([]domain.StatisticalKeyFigure, 0, len(headcount))
+	for _, hc := range headcount {
+		if !s.knownCostCenter(ctx, hc.CostCenterID) {
+			log.Printf("[Allocation] Skipping headcount of unknown cost center %q for %s", hc.CostCenterID, period)
+			continue
+		}
+		figures = append(figures, domain.StatisticalKeyFigure{
+			CostCenterID:    hc.CostCenterID,
+			Driver:          domain.AllocationDriverHEADCOUNT,
+			FinancialPeriod: period,
+			Value:           decimal.NewFromInt(int64(hc.Headcount)),
+			Source:          "HR",
+		})
+	}
+	if len(figures) == 0 {
+		return nil
+	}
+	_, err := s.RecordKeyFigures(ctx, figures)
+	return err
+}
+
+// AddMachineHours adds the machine hours of a production yield reported by mfg-service to the
+// MACHINE_HOURS driver of the cost center for the period, so the driver grows with each yield.
+// Hours of cost centers fm-service does not know are skipped.
+func (s *CostAllocationService) AddMachineHours(ctx context.Context, costCenterID, period string, hours decimal.Decimal) error {
+	if hours.IsNegative() {
+		return fmt.Errorf("%w: machine hours for %s must not be negative", domain.ErrInvalidKeyFigure, costCenterID)
+	}
+	if hours.IsZero() {
+		return nil
+	}
+	if !s.knownCostCenter(ctx, costCenterID) {
+		log.Printf("[Allocation] Skipping %s machine hours of unknown cost center %q for %s", hours, costCenterID, period)
+		return nil
+	}
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.keyFigures.ListByPeriod(txCtx, domain.AllocationDriverMACHINE_HOURS, period)
+		if err != nil {
+			return err
+		}
+		total := hours
+		for _, kf := range existing {
+			if kf.CostCenterID == costCenterID {
+				total = total.Add(kf.Value)
+			}
+		}
+		_, err = s.RecordKeyFigures(txCtx, []domain.StatisticalKeyFigure{{
+			CostCenterID:    costCenterID,
+			Driver:          domain.AllocationDriverMACHINE_HOURS,
+			FinancialPeriod: period,
+			Value:           total,
+			Source:          "MFG",
+		}})
+		return err
+	})
+}
+
+func (s *CostAllocationService) knownCostCenter(ctx context.Context, id string) bool {
+	_, err := s.costCenters.GetByID(ctx, id)
+	return err == nil
+}
+
+func (s *CostAllocationService) ListKeyFigures(ctx context.Context, driver domain.AllocationDriver, period string) ([]domain.StatisticalKeyFigure, error) {
+	if !driver.IsValid() {
+		return nil, fmt.Errorf("%w: unknown driver %q", domain.ErrInvalidKeyFigure, driver)
+	}
+	return s.keyFigures.ListByPeriod(ctx, driver, period)
+}
+
+// RunCycle allocates the sender balances of a period (YYYY-MM) and posts them on the last day of
+// the period. A cycle that was already run for the period has that run reversed first, so running
+// again after late postings or new driver values replaces the earlier allocation.
+func (s *CostAllocationService) RunCycle(ctx context.Context, cycleID, period, postedBy string) (*domain.AllocationRun, error) {
+	start, err := parsePeriod(period)
+	if err != nil {
+		return nil, fmt.Errorf("%w: period %q must be YYYY-MM", domain.ErrInvalidAllocationCycle, period)
+	}
+	postingDate := start.AddDate(0, 1, -1)
+
+	var run *domain.AllocationRun
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		cycle, members, err := s.cycles.GetByID(txCtx, cycleID)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrAllocationCycleNotFound, cycleID)
+		}
+		if !cycle.IsActive {
+			return fmt.Errorf("%w: %s", domain.ErrAllocationCycleInactive, cycle.Name)
+		}
+
+		previous, err := s.runs.ListByCycle(txCtx, cycle.ID)
+		if err != nil {
+			return err
+		}
+		for i := range previous {
+			if previous[i].FinancialPeriod == period && previous[i].Status == domain.AllocationRunStatusPOSTED {
+				if err := s.reverseRun(txCtx, &previous[i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		shares, err := s.receiverShares(txCtx, cycle, members, period)
+		if err != nil {
+			return err
+		}
+		balances, err := s.senderBalances(txCtx, cycle.LegalEntityID, period, members)
+		if err != nil {
+			return err
+		}
+		lines, total := allocationLines(cycle.ID, balances, shares)
+		if len(lines) == 0 {
+			return fmt.Errorf("%w: senders of %s have no expense balances in %s", domain.ErrNothingToAllocate, cycle.Name, period)
+		}
+
+		entry, err := s.gl.PostJournalEntry(txCtx, &domain.UniversalJournalEntry{
+			LegalEntityID:    cycle.LegalEntityID,
+			SourceModule:     "FM",
+			SourceDocumentID: "ALLOC-" + cycle.ID + "-" + period,
+			PostingDate:      postingDate,
+		}, lines)
+		if err != nil {
+			return err
+		}
+
+		run = &domain.AllocationRun{
+			ID:              utils.NewID("alr"),
+			CycleID:         cycle.ID,
+			LegalEntityID:   cycle.LegalEntityID,
+			FinancialPeriod: period,
+			Status:          domain.AllocationRunStatusPOSTED,
+			JournalEntryID:  entry.ID,
+			TotalAllocated:  total,
+			PostedBy:        postedBy,
+			CreatedAt:       time.Now(),
+		}
+		return s.runs.Create(txCtx, run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// ReverseRun takes back an allocation. The reversal posts on the original posting date so the
+// period ends up as it was before the run; that period has to be open.
+func (s *CostAllocationService) ReverseRun(ctx context.Context, runID string) (*domain.AllocationRun, error) {
+	var run *domain.AllocationRun
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		run, err = s.runs.GetByID(txCtx, runID)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrAllocationRunNotFound, runID)
+		}
+		if run.Status == domain.AllocationRunStatusREVERSED {
+			return fmt.Errorf("%w: %s", domain.ErrAllocationRunReversed, runID)
+		}
+		return s.reverseRun(txCtx, run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *CostAllocationService) reverseRun(ctx context.Context, run *domain.AllocationRun) error {
+	entry, _, err := s.entries.GetByID(ctx, run.JournalEntryID)
+	if err != nil {
+		return err
+	}
+	reversal, err := s.gl.reverseJournalEntry(ctx, entry.ID, entry.PostingDate)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	run.Status = domain.AllocationRunStatusREVERSED
+	run.ReversalEntryID = &reversal.ID
+	run.ReversedAt = &now
+	return s.runs.Update(ctx, run)
+}
+
+// allocationShare is the fraction of every sender balance a receiver gets
+type allocationShare struct {
+	costCenterID string
+	weight       decimal.Decimal
+}
+
+// receiverShares weighs the receivers by their fixed percentage or by their driver values for the
+// period. Receivers without a driver value get nothing.
+func (s *CostAllocationService) receiverShares(ctx context.Context, cycle *domain.AllocationCycle, members []domain.AllocationCycleMember, period string) ([]allocationShare, error) {
+	var shares []allocationShare
+	if cycle.Method == domain.AllocationMethodFIXED_PERCENT {
+		for _, m := range members {
+			if m.Role == domain.AllocationRoleRECEIVER {
+				shares = append(shares, allocationShare{costCenterID: m.CostCenterID, weight: m.Percentage.Div(hundred)})
+			}
+		}
+		return shares, nil
+	}
+
+	figures, err := s.keyFigures.ListByPeriod(ctx, cycle.Driver, period)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]decimal.Decimal, len(figures))
+	for _, kf := range figures {
+		values[kf.CostCenterID] = kf.Value
+	}
+	total := decimal.Zero
+	for _, m := range members {
+		if m.Role == domain.AllocationRoleRECEIVER {
+			total = total.Add(values[m.CostCenterID])
+		}
+	}
+	if !total.IsPositive() {
+		return nil, fmt.Errorf("%w: %s for %s", domain.ErrMissingAllocationDriver, cycle.Driver, period)
+	}
+	for _, m := range members {
+		if m.Role == domain.AllocationRoleRECEIVER && values[m.CostCenterID].IsPositive() {
+			shares = append(shares, allocationShare{costCenterID: m.CostCenterID, weight: values[m.CostCenterID].Div(total)})
+		}
+	}
+	return shares, nil
+}
+
+// senderBalances sums the period's expense postings per sender cost center and account. Reversed
+// entries count together with their reversals, which cancels out an earlier allocation run.
+func (s *CostAllocationService) senderBalances(ctx context.Context, legalEntityID, period string, members []domain.AllocationCycleMember) (map[string]map[string]decimal.Decimal, error) {
+	balances := make(map[string]map[string]decimal.Decimal)
+	for _, m := range members {
+		if m.Role == domain.AllocationRoleSENDER {
+			balances[m.CostCenterID] = make(map[string]decimal.Decimal)
+		}
+	}
+
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accountTypes := make(map[string]domain.AccountType)
+	for _, entry := range entries {
+		if entry.LegalEntityID != legalEntityID || entry.FinancialPeriod != period {
+			continue
+		}
+		if entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED {
+			continue
+		}
+		_, lines, err := s.entries.GetByID(ctx, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lines {
+			byAccount, ok := balances[dimensionValue(l.TrackingDimensions, costCenterDimension)]
+			if !ok {
+				continue
+			}
+			accType, ok := accountTypes[l.AccountID]
+			if !ok {
+				acc, err := s.accounts.GetByID(ctx, l.AccountID)
+				if err != nil {
+					return nil, fmt.Errorf("account not found: %s", l.AccountID)
+				}
+				accType = acc.Type
+				accountTypes[l.AccountID] = accType
+			}
+			if accType == domain.AccountTypeEXPENSE {
+				byAccount[l.AccountID] = byAccount[l.AccountID].Add(l.AmountFunctional)
+			}
+		}
+	}
+	return balances, nil
+}
+
+// allocationLines credits each sender balance on its own account and debits the receivers' shares
+// to the same account, so the expense stays in its cost element. The last receiver takes the
+// rounding difference. The returned total is the sum of the sender balances moved.
+func allocationLines(cycleID string, balances map[string]map[string]decimal.Decimal, shares []allocationShare) ([]domain.UniversalJournalLine, decimal.Decimal) {
+	var lines []domain.UniversalJournalLine
+	total := decimal.Zero
+	if len(shares) == 0 {
+		return nil, total
+	}
+	line := func(accountID, costCenterID string, amount decimal.Decimal) domain.UniversalJournalLine {
+		return domain.UniversalJournalLine{
+			AccountID:          accountID,
+			AmountFunctional:   amount,
+			TrackingDimensions: map[string]interface{}{costCenterDimension: costCenterID, allocationCycleDimension: cycleID},
+		}
+	}
+
+	senders := make([]string, 0, len(balances))
+	for cc := range balances {
+		senders = append(senders, cc)
+	}
+	sort.Strings(senders)
+	for _, sender := range senders {
+		accountIDs := make([]string, 0, len(balances[sender]))
+		for accountID := range balances[sender] {
+			accountIDs = append(accountIDs, accountID)
+		}
+		sort.Strings(accountIDs)
+		for _, accountID := range accountIDs {
+			balance := balances[sender][accountID].Round(functionalAmountPlaces)
+			if balance.IsZero() {
+				continue
+			}
+			lines = append(lines, line(accountID, sender, balance.Neg()))
+			remaining := balance
+			for i, share := range shares {
+				amount := remaining
+				if i < len(shares)-1 {
+					amount = balance.Mul(share.weight).Round(functionalAmountPlaces)
+					remaining = remaining.Sub(amount)
+				}
+				if !amount.IsZero() {
+					lines = append(lines, line(accountID, share.costCenterID, amount))
+				}
+			}
+			total = total.Add(balance)
+		}
+	}
+	return lines, total
+}
+
+func (s *CostAllocationService) validateCycle(ctx context.Context, req *AllocationCycleRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.LegalEntityID == "" || req.Name == "" {
+		return fmt.Errorf("%w: legal entity and name are required", domain.ErrInvalidAllocationCycle)
+	}
+	switch req.Method {
+	case domain.AllocationMethodFIXED_PERCENT:
+		req.Driver = ""
+	case domain.AllocationMethodSTATISTICAL:
+		if !req.Driver.IsValid() {
+			return fmt.Errorf("%w: unknown driver %q", domain.ErrInvalidAllocationCycle, req.Driver)
+		}
+	default:
+		return fmt.Errorf("%w: unknown method %q", domain.ErrInvalidAllocationCycle, req.Method)
+	}
+	if len(req.Senders) == 0 || len(req.Receivers) == 0 {
+		return fmt.Errorf("%w: at least one sender and one receiver are required", domain.ErrInvalidAllocationCycle)
+	}
+
+	seen := make(map[string]bool)
+	check := func(id string) error {
+		if seen[id] {
+			return fmt.Errorf("%w: cost center %q is listed more than once", domain.ErrInvalidAllocationCycle, id)
+		}
+		seen[id] = true
+		if _, err := s.costCenters.GetByID(ctx, id); err != nil {
+			return fmt.Errorf("%w: unknown cost center %q", domain.ErrInvalidAllocationCycle, id)
+		}
+		return nil
+	}
+	for _, id := range req.Senders {
+		if err := check(id); err != nil {
+			return err
+		}
+	}
+	sum := decimal.Zero
+	for i, r := range req.Receivers {
+		if err := check(r.CostCenterID); err != nil {
+			return err
+		}
+		if req.Method == domain.AllocationMethodSTATISTICAL {
+			req.Receivers[i].Percentage = decimal.Zero
+			continue
+		}
+		if !r.Percentage.IsPositive() {
+			return fmt.Errorf("%w: receiver %s needs a positive percentage", domain.ErrInvalidAllocationCycle, r.CostCenterID)
+		}
+		sum = sum.Add(r.Percentage)
+	}
+	if req.Method == domain.AllocationMethodFIXED_PERCENT && !sum.Equal(hundred) {
+		return fmt.Errorf("%w: receiver percentages add up to %s, not 100", domain.ErrInvalidAllocationCycle, sum)
+	}
+	return nil
+}
+
+func cycleMembers(cycleID string, req AllocationCycleRequest) []domain.AllocationCycleMember {
+	members := make([]domain.AllocationCycleMember, 0, len(req.Senders)+len(req.Receivers))
+	for _, id := range req.Senders {
+		members = append(members, domain.AllocationCycleMember{
+			ID:           utils.NewID("alm"),
+			CycleID:      cycleID,
+			CostCenterID: id,
+			Role:         domain.AllocationRoleSENDER,
+		})
+	}
+	for _, r := range req.Receivers {
+		members = append(members, domain.AllocationCycleMember{
+			ID:           utils.NewID("alm"),
+			CycleID:      cycleID,
+			CostCenterID: r.CostCenterID,
+			Role:         domain.AllocationRoleRECEIVER,
+			Percentage:   r.Percentage,
+		})
+	}
+	return members
+}
+
+func parsePeriod(period string) (time.Time, error) {
+	return time.Parse("2006-01", period)
+}
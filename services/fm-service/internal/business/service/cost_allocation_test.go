@@ -0,0 +1,387 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+// bookExpense books an expense on a cost center against cash
+func bookExpense(t *testing.T, gl *service.GeneralLedgerService, cash, account *domain.ChartOfAccounts, costCenterID string, amount int64, on time.Time) {
+	t.Helper()
+	dims := map[string]interface{}{"cost_center_id": costCenterID}
+	_, err := gl.CreateJournalEntry(context.Background(), "le_1", "FM", "exp", on, []domain.UniversalJournalLine{
+		{AccountID: account.ID, AmountFunctional: decimal.NewFromInt(amount), TrackingDimensions: dims},
+		{AccountID: cash.ID, AmountFunctional: decimal.NewFromInt(-amount)},
+	})
+	if err != nil {
+		t.Fatalf("failed to post expense: %v", err)
+	}
+}
+
+// costCenterBalance is the posted balance of an account on a cost center
+func costCenterBalance(t *testing.T, entries *memory.MemoryUniversalJournalEntryRepo, accountID, costCenterID string) decimal.Decimal {
+	t.Helper()
+	ctx := context.Background()
+	list, _ := entries.List(ctx)
+	balance := decimal.Zero
+	for _, e := range list {
+		_, lines, _ := entries.GetByID(ctx, e.ID)
+		for _, l := range lines {
+			dims, _ := l.TrackingDimensions.(map[string]interface{})
+			if l.AccountID == accountID && dims != nil && dims["cost_center_id"] == costCenterID {
+				balance = balance.Add(l.AmountFunctional)
+			}
+		}
+	}
+	return balance
+}
+
+func fixedCycle() service.AllocationCycleRequest {
+	return service.AllocationCycleRequest{
+		LegalEntityID: "le_1",
+		Name:          "Admin overhead",
+		Method:        domain.AllocationMethodFIXED_PERCENT,
+		Senders:       []string{"cc_admin"},
+		Receivers: []service.AllocationReceiverRequest{
+			{CostCenterID: "cc_assembly", Percentage: decimal.NewFromInt(60)},
+			{CostCenterID: "cc_paint", Percentage: decimal.NewFromInt(40)},
+		},
+	}
+}
+
+func TestCreateAllocationCycle_Validation(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	cycles := memory.NewMemoryAllocationCycleRepo()
+	runs := memory.NewMemoryAllocationRunRepo()
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	costCenters := memory.NewMemoryCostCenterRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, cycles, runs, keyFigures, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewCostAllocationService(cycles, runs, keyFigures, costCenters, accounts, entries, gl, tm)
+	ctx := context.Background()
+
+	for _, id := range []string{"cc_admin", "cc_it", "cc_assembly", "cc_paint", "cc_packing"} {
+		_ = costCenters.Create(ctx, &domain.CostCenter{ID: id, Code: id, Name: id, IsActive: true})
+	}
+
+	cases := map[string]func(r *service.AllocationCycleRequest){
+		"percentages short of 100": func(r *service.AllocationCycleRequest) { r.Receivers[1].Percentage = decimal.NewFromInt(30) },
+		"sender also receives":     func(r *service.AllocationCycleRequest) { r.Receivers[0].CostCenterID = "cc_admin" },
+		"unknown cost center":      func(r *service.AllocationCycleRequest) { r.Senders = []string{"cc_nope"} },
+		"no receivers":             func(r *service.AllocationCycleRequest) { r.Receivers = nil },
+		"unknown method":           func(r *service.AllocationCycleRequest) { r.Method = "ACTIVITY" },
+		"statistical no driver":    func(r *service.AllocationCycleRequest) { r.Method = domain.AllocationMethodSTATISTICAL },
+		"missing legal entity":     func(r *service.AllocationCycleRequest) { r.LegalEntityID = "" },
+	}
+	for name, mutate := range cases {
+		req := fixedCycle()
+		mutate(&req)
+		if _, err := svc.CreateCycle(ctx, req); !errors.Is(err, domain.ErrInvalidAllocationCycle) {
+			t.Errorf("%s: expected invalid cycle error, got %v", name, err)
+		}
+	}
+}
+
+func TestAllocationCycle_FixedPercentRunIsReRunnable(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	cycles := memory.NewMemoryAllocationCycleRepo()
+	runs := memory.NewMemoryAllocationRunRepo()
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	costCenters := memory.NewMemoryCostCenterRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, cycles, runs, keyFigures, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewCostAllocationService(cycles, runs, keyFigures, costCenters, accounts, entries, gl, tm)
+	ctx := context.Background()
+
+	for _, id := range []string{"cc_admin", "cc_it", "cc_assembly", "cc_paint", "cc_packing"} {
+		_ = costCenters.Create(ctx, &domain.CostCenter{ID: id, Code: id, Name: id, IsActive: true})
+	}
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	utilities, _ := gl.CreateAccount(ctx, "le_1", "6200-001", "Utilities", string(domain.AccountTypeEXPENSE))
+	cash, _ := gl.CreateAccount(ctx, "le_1", "1000-001", "Cash", string(domain.AccountTypeASSET))
+	bookExpense(t, gl, cash, rent, "cc_admin", 1000, day(2025, 3, 5))
+	bookExpense(t, gl, cash, utilities, "cc_admin", 300, day(2025, 3, 12))
+	bookExpense(t, gl, cash, rent, "cc_admin", 500, day(2025, 4, 5)) // Other period
+
+	detail, err := svc.CreateCycle(ctx, fixedCycle())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-03", "controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !first.TotalAllocated.Equal(decimal.NewFromInt(1300)) {
+		t.Errorf("expected 1300 allocated, got %s", first.TotalAllocated)
+	}
+	entry, _, _ := entries.GetByID(ctx, first.JournalEntryID)
+	if !entry.PostingDate.Equal(day(2025, 3, 31)) {
+		t.Errorf("expected the allocation to post on 2025-03-31, got %s", entry.PostingDate.Format("2006-01-02"))
+	}
+	checkRent := func(admin, assembly, paint int64) {
+		t.Helper()
+		for cc, want := range map[string]int64{"cc_admin": admin, "cc_assembly": assembly, "cc_paint": paint} {
+			if got := costCenterBalance(t, entries, rent.ID, cc); !got.Equal(decimal.NewFromInt(want)) {
+				t.Errorf("expected rent of %d on %s, got %s", want, cc, got)
+			}
+		}
+	}
+	checkRent(500, 600, 400)
+	if got := costCenterBalance(t, entries, utilities.ID, "cc_paint"); !got.Equal(decimal.NewFromInt(120)) {
+		t.Errorf("expected utilities of 120 on cc_paint, got %s", got)
+	}
+
+	// A late posting is picked up by running the period again, which replaces the first run
+	bookExpense(t, gl, cash, rent, "cc_admin", 200, day(2025, 3, 28))
+	second, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-03", "controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.TotalAllocated.Equal(decimal.NewFromInt(1500)) {
+		t.Errorf("expected 1500 allocated on the re-run, got %s", second.TotalAllocated)
+	}
+	checkRent(500, 720, 480)
+
+	got, _ := svc.GetCycle(ctx, detail.Cycle.ID)
+	if len(got.Runs) != 2 || got.Runs[0].Status != domain.AllocationRunStatusREVERSED || got.Runs[0].ReversalEntryID == nil {
+		t.Fatalf("expected the first run to be reversed, got %+v", got.Runs)
+	}
+
+	// Nothing left on the senders once the whole period is allocated elsewhere
+	req := fixedCycle()
+	req.Name = "Second pass"
+	other, _ := svc.CreateCycle(ctx, req)
+	if _, err := svc.RunCycle(ctx, other.Cycle.ID, "2025-03", "controller"); !errors.Is(err, domain.ErrNothingToAllocate) {
+		t.Errorf("expected nothing to allocate, got %v", err)
+	}
+}
+
+func TestAllocationCycle_StatisticalDriverSplitsByKeyFigures(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	cycles := memory.NewMemoryAllocationCycleRepo()
+	runs := memory.NewMemoryAllocationRunRepo()
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	costCenters := memory.NewMemoryCostCenterRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, cycles, runs, keyFigures, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewCostAllocationService(cycles, runs, keyFigures, costCenters, accounts, entries, gl, tm)
+	ctx := context.Background()
+
+	for _, id := range []string{"cc_admin", "cc_it", "cc_assembly", "cc_paint", "cc_packing"} {
+		_ = costCenters.Create(ctx, &domain.CostCenter{ID: id, Code: id, Name: id, IsActive: true})
+	}
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	cash, _ := gl.CreateAccount(ctx, "le_1", "1000-001", "Cash", string(domain.AccountTypeASSET))
+	bookExpense(t, gl, cash, rent, "cc_it", 1000, day(2025, 3, 5))
+
+	req := service.AllocationCycleRequest{
+		LegalEntityID: "le_1",
+		Name:          "IT by headcount",
+		Method:        domain.AllocationMethodSTATISTICAL,
+		Driver:        domain.AllocationDriverHEADCOUNT,
+		Senders:       []string{"cc_it"},
+		Receivers: []service.AllocationReceiverRequest{
+			{CostCenterID: "cc_assembly"}, {CostCenterID: "cc_paint"}, {CostCenterID: "cc_packing"},
+		},
+	}
+	detail, err := svc.CreateCycle(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-03", ""); !errors.Is(err, domain.ErrMissingAllocationDriver) {
+		t.Fatalf("expected missing driver error, got %v", err)
+	}
+
+	headcount := func(cc string, n int64) domain.StatisticalKeyFigure {
+		return domain.StatisticalKeyFigure{CostCenterID: cc, Driver: domain.AllocationDriverHEADCOUNT, FinancialPeriod: "2025-03", Value: decimal.NewFromInt(n), Source: "hr"}
+	}
+	if _, err := svc.RecordKeyFigures(ctx, []domain.StatisticalKeyFigure{headcount("cc_assembly", 1), headcount("cc_paint", 1), headcount("cc_packing", 1)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-03", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Even thirds round to cents with the remainder on the last receiver
+	for cc, want := range map[string]string{"cc_assembly": "333.33", "cc_paint": "333.33", "cc_packing": "333.34"} {
+		if got := costCenterBalance(t, entries, rent.ID, cc); !got.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("expected %s on %s, got %s", want, cc, got)
+		}
+	}
+
+	// Updated headcount replaces the earlier values; a receiver with none gets nothing
+	if _, err := svc.RecordKeyFigures(ctx, []domain.StatisticalKeyFigure{headcount("cc_assembly", 3), headcount("cc_packing", 0)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if figures, _ := svc.ListKeyFigures(ctx, domain.AllocationDriverHEADCOUNT, "2025-03"); len(figures) != 3 || figures[0].Source != "HR" {
+		t.Errorf("expected 3 key figures sourced from HR, got %+v", figures)
+	}
+	if _, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-03", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for cc, want := range map[string]int64{"cc_assembly": 750, "cc_paint": 250, "cc_packing": 0, "cc_it": 0} {
+		if got := costCenterBalance(t, entries, rent.ID, cc); !got.Equal(decimal.NewFromInt(want)) {
+			t.Errorf("expected %d on %s, got %s", want, cc, got)
+		}
+	}
+
+	bad := headcount("cc_unknown", 4)
+	if _, err := svc.RecordKeyFigures(ctx, []domain.StatisticalKeyFigure{bad}); !errors.Is(err, domain.ErrInvalidKeyFigure) {
+		t.Errorf("expected invalid key figure error, got %v", err)
+	}
+}
+
+func TestAllocationCycle_DriversFedByHrAndMfg(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	cycles := memory.NewMemoryAllocationCycleRepo()
+	runs := memory.NewMemoryAllocationRunRepo()
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	costCenters := memory.NewMemoryCostCenterRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, cycles, runs, keyFigures, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewCostAllocationService(cycles, runs, keyFigures, costCenters, accounts, entries, gl, tm)
+	ctx := context.Background()
+
+	for _, id := range []string{"cc_admin", "cc_it", "cc_assembly", "cc_paint", "cc_packing"} {
+		_ = costCenters.Create(ctx, &domain.CostCenter{ID: id, Code: id, Name: id, IsActive: true})
+	}
+	utilities, err := gl.CreateAccount(ctx, "le_1", "6200-001", "Utilities", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	cash, _ := gl.CreateAccount(ctx, "le_1", "1000-001", "Cash", string(domain.AccountTypeASSET))
+	bookExpense(t, gl, cash, utilities, "cc_admin", 900, day(2025, 4, 10))
+
+	detail, err := svc.CreateCycle(ctx, service.AllocationCycleRequest{
+		LegalEntityID: "le_1",
+		Name:          "Utilities by machine hours",
+		Method:        domain.AllocationMethodSTATISTICAL,
+		Driver:        domain.AllocationDriverMACHINE_HOURS,
+		Senders:       []string{"cc_admin"},
+		Receivers:     []service.AllocationReceiverRequest{{CostCenterID: "cc_assembly"}, {CostCenterID: "cc_paint"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Each production yield adds to the machine hours of its cost center
+	for _, y := range []struct {
+		cc    string
+		hours string
+	}{{"cc_assembly", "1.5"}, {"cc_assembly", "0.5"}, {"cc_paint", "1"}, {"cc_paint", "0"}} {
+		if err := svc.AddMachineHours(ctx, y.cc, "2025-04", decimal.RequireFromString(y.hours)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	figures, _ := svc.ListKeyFigures(ctx, domain.AllocationDriverMACHINE_HOURS, "2025-04")
+	if len(figures) != 2 || !figures[0].Value.Equal(decimal.NewFromInt(2)) || figures[0].Source != "MFG" {
+		t.Fatalf("expected 2 machine hours on cc_assembly from MFG, got %+v", figures)
+	}
+	if _, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-04", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for cc, want := range map[string]int64{"cc_assembly": 600, "cc_paint": 300} {
+		if got := costCenterBalance(t, entries, utilities.ID, cc); !got.Equal(decimal.NewFromInt(want)) {
+			t.Errorf("expected %d on %s, got %s", want, cc, got)
+		}
+	}
+	// Hours of a cost center fm-service does not know are skipped, not failed
+	if err := svc.AddMachineHours(ctx, "cc_unknown", "2025-04", decimal.NewFromInt(1)); err != nil {
+		t.Errorf("expected unknown cost centers to be skipped, got %v", err)
+	}
+	if figures, _ = svc.ListKeyFigures(ctx, domain.AllocationDriverMACHINE_HOURS, "2025-04"); len(figures) != 2 {
+		t.Errorf("expected no machine hours on the unknown cost center, got %+v", figures)
+	}
+
+	// Headcount from an approved payroll run replaces the period's values
+	if err := svc.RecordHeadcount(ctx, "2025-04", []domain.CostCenterHeadcount{{CostCenterID: "cc_assembly", Headcount: 4}, {CostCenterID: "cc_paint", Headcount: 2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.RecordHeadcount(ctx, "2025-04", []domain.CostCenterHeadcount{{CostCenterID: "cc_assembly", Headcount: 5}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	figures, _ = svc.ListKeyFigures(ctx, domain.AllocationDriverHEADCOUNT, "2025-04")
+	if len(figures) != 2 || !figures[0].Value.Equal(decimal.NewFromInt(5)) || figures[0].Source != "HR" {
+		t.Errorf("expected headcount 5 on cc_assembly from HR, got %+v", figures)
+	}
+}
+
+func TestReverseAllocationRun(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	cycles := memory.NewMemoryAllocationCycleRepo()
+	runs := memory.NewMemoryAllocationRunRepo()
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	costCenters := memory.NewMemoryCostCenterRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, cycles, runs, keyFigures, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewCostAllocationService(cycles, runs, keyFigures, costCenters, accounts, entries, gl, tm)
+	ctx := context.Background()
+
+	for _, id := range []string{"cc_admin", "cc_it", "cc_assembly", "cc_paint", "cc_packing"} {
+		_ = costCenters.Create(ctx, &domain.CostCenter{ID: id, Code: id, Name: id, IsActive: true})
+	}
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	cash, _ := gl.CreateAccount(ctx, "le_1", "1000-001", "Cash", string(domain.AccountTypeASSET))
+	bookExpense(t, gl, cash, rent, "cc_admin", 1000, day(2025, 3, 5))
+
+	detail, _ := svc.CreateCycle(ctx, fixedCycle())
+	run, err := svc.RunCycle(ctx, detail.Cycle.ID, "2025-03", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The reversal posts into the allocated period, which has to be open
+	_ = periods.Upsert(ctx, &domain.FiscalPeriod{ID: "fp_1", LegalEntityID: "le_1", FinancialPeriod: "2025-03", State: domain.PeriodStateCLOSED})
+	if _, err := svc.ReverseRun(ctx, run.ID); !errors.Is(err, domain.ErrPeriodClosed) {
+		t.Fatalf("expected period closed error, got %v", err)
+	}
+	_ = periods.Upsert(ctx, &domain.FiscalPeriod{ID: "fp_1", LegalEntityID: "le_1", FinancialPeriod: "2025-03", State: domain.PeriodStateOPEN})
+
+	reversed, err := svc.ReverseRun(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reversed.Status != domain.AllocationRunStatusREVERSED || reversed.ReversalEntryID == nil {
+		t.Errorf("expected a reversed run with its reversal entry, got %+v", reversed)
+	}
+	rev, _, _ := entries.GetByID(ctx, *reversed.ReversalEntryID)
+	if rev.FinancialPeriod != "2025-03" {
+		t.Errorf("expected the reversal in 2025-03, got %s", rev.FinancialPeriod)
+	}
+	if got := costCenterBalance(t, entries, rent.ID, "cc_admin"); !got.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("expected the rent back on cc_admin, got %s", got)
+	}
+	if _, err := svc.ReverseRun(ctx, run.ID); !errors.Is(err, domain.ErrAllocationRunReversed) {
+		t.Errorf("expected already reversed error, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Precision of stored exchange rates and of converted functional amounts
+const (
+	exchangeRatePlaces     = 8
+	functionalAmountPlaces = 2
+)
+
+// CurrencyConverter resolves legal entity functional currencies and the CurrencyRate
+// effective on a given date.
+type CurrencyConverter struct {
+	legalEntities domain.LegalEntityRepository
+	rates         domain.CurrencyRateRepository
+}
+
+func NewCurrencyConverter(legalEntities domain.LegalEntityRepository, rates domain.CurrencyRateRepository) *CurrencyConverter {
+	return &CurrencyConverter{
+		legalEntities: legalEntities,
+		rates:         rates,
+	}
+}
+
+func (c *CurrencyConverter) FunctionalCurrency(ctx context.Context, legalEntityID string) (string, error) {
+	le, err := c.legalEntities.GetByID(ctx, legalEntityID)
+	if err != nil {
+		return "", fmt.Errorf("legal entity %s not found: %w", legalEntityID, err)
+	}
+	return le.FunctionalCurrency, nil
+}
+
+// Rate returns the from->to rate effective at the given time. A missing direct rate falls back
+// to the inverse of the to->from rate.
+func (c *CurrencyConverter) Rate(ctx context.Context, from, to string, at time.Time) (decimal.Decimal, error) {
+	if from == to || from == "" || to == "" {
+		return decimal.NewFromInt(1), nil
+	}
+	if r, err := c.rates.GetEffective(ctx, from, to, at); err == nil && r.Rate.IsPositive() {
+		return r.Rate, nil
+	}
+	if r, err := c.rates.GetEffective(ctx, to, from, at); err == nil && r.Rate.IsPositive() {
+		return decimal.NewFromInt(1).DivRound(r.Rate, exchangeRatePlaces), nil
+	}
+	return decimal.Zero, fmt.Errorf("%w: %s/%s on %s", domain.ErrExchangeRateNotFound, from, to, at.Format("2006-01-02"))
+}
+
+// ToFunctional converts an amount in currency into the legal entity's functional currency at the
+// rate effective on the given date. An empty currency means the functional currency.
+func (c *CurrencyConverter) ToFunctional(ctx context.Context, legalEntityID, currency string, amount decimal.Decimal, at time.Time) (decimal.Decimal, decimal.Decimal, error) {
+	functional, err := c.FunctionalCurrency(ctx, legalEntityID)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	rate, err := c.Rate(ctx, currency, functional, at)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return convertAmount(amount, rate), rate, nil
+}
+
+// DocumentRate resolves the currency and booking rate of an AR/AP document. An empty currency
+// means the functional currency; documents of unknown legal entities keep it empty at rate 1.
+func (c *CurrencyConverter) DocumentRate(ctx context.Context, legalEntityID, currency string, at time.Time) (string, decimal.Decimal, error) {
+	functional, err := c.FunctionalCurrency(ctx, legalEntityID)
+	if err != nil {
+		if currency == "" {
+			return "", decimal.NewFromInt(1), nil
+		}
+		return "", decimal.Zero, err
+	}
+	if currency == "" {
+		currency = functional
+	}
+	rate, err := c.Rate(ctx, currency, functional, at)
+	if err != nil {
+		return "", decimal.Zero, err
+	}
+	return currency, rate, nil
+}
+
+func convertAmount(amount, rate decimal.Decimal) decimal.Decimal {
+	return amount.Mul(rate).Round(functionalAmountPlaces)
+}
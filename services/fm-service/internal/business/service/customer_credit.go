@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// CreditExposure is what a customer owes and has on order, in functional currency.
+type CreditExposure struct {
+	CustomerID       string          `json:"customer_id"`
+	CreditLimit      decimal.Decimal `json:"credit_limit"`
+	OpenReceivables  decimal.Decimal `json:"open_receivables"`
+	UninvoicedOrders decimal.Decimal `json:"uninvoiced_orders"`
+	Exposure         decimal.Decimal `json:"exposure"`
+	Available        decimal.Decimal `json:"available"`
+	IsOnHold         bool            `json:"is_on_hold"`
+	HoldReason       string          `json:"hold_reason,omitempty"`
+	Version          int             `json:"version"`
+}
+
+// CreditCheckRequest asks whether a customer may take on an order. SalesOrderID lets an
+// override approve that order and keeps a re-check from counting the order twice; an
+// approved order is reserved under it in LegalEntityID until confirmed or cancelled.
+type CreditCheckRequest struct {
+	CustomerID    string          `json:"customer_id"`
+	LegalEntityID string          `json:"legal_entity_id"`
+	SalesOrderID  string          `json:"sales_order_id"`
+	OrderValue    decimal.Decimal `json:"order_value"`
+}
+
+type CreditCheckResult struct {
+	CreditExposure
+	SalesOrderID string          `json:"sales_order_id,omitempty"`
+	OrderValue   decimal.Decimal `json:"order_value"`
+	Approved     bool            `json:"approved"`
+	Reason       string          `json:"reason,omitempty"`
+	OverrideID   string          `json:"override_id,omitempty"`
+}
+
+// CreditOverrideRequest approves one sales order past a failed credit check.
+type CreditOverrideRequest struct {
+	CustomerID   string          `json:"customer_id"`
+	SalesOrderID string          `json:"sales_order_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	ApprovedBy   string          `json:"approved_by"`
+	Reason       string          `json:"reason"`
+}
+
+// creditCheckAttempts bounds the retries of a credit check that lost the version race
+// against a concurrent check or credit change.
+const creditCheckAttempts = 3
+
+// CheckCredit compares the customer's exposure plus the order value with the credit limit.
+// Customers on hold are refused. A refused order is still approved when an override covers
+// it. An approved sales order is reserved at the order value, so concurrent orders cannot
+// all pass against the same available credit; the reservation bumps the customer's credit
+// version, and a check that loses that race is retried. Checks without a sales order, and
+// re-checks of a recorded order, change nothing.
+func (s *AccountsReceivableService) CheckCredit(ctx context.Context, req CreditCheckRequest) (*CreditCheckResult, error) {
+	if req.CustomerID == "" || req.OrderValue.IsNegative() {
+		return nil, fmt.Errorf("%w: customer and a non-negative order value are required", domain.ErrInvalidCreditRequest)
+	}
+
+	var result *CreditCheckResult
+	var err error
+	for attempt := 0; attempt < creditCheckAttempts; attempt++ {
+		err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			result, err = s.checkCredit(txCtx, req)
+			return err
+		})
+		if !errors.Is(err, domain.ErrOptimisticLock) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *AccountsReceivableService) checkCredit(ctx context.Context, req CreditCheckRequest) (*CreditCheckResult, error) {
+	credit, err := s.GetCustomerCredit(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	exposure, err := s.creditExposure(ctx, credit, req.SalesOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CreditCheckResult{
+		CreditExposure: *exposure,
+		SalesOrderID:   req.SalesOrderID,
+		OrderValue:     req.OrderValue,
+		Approved:       true,
+	}
+	switch {
+	case credit.IsOnHold:
+		result.Approved = false
+		result.Reason = "customer is on credit hold"
+		if credit.HoldReason != "" {
+			result.Reason += ": " + credit.HoldReason
+		}
+	case req.OrderValue.GreaterThan(exposure.Available):
+		result.Approved = false
+		result.Reason = fmt.Sprintf("order value %s exceeds available credit %s", req.OrderValue.StringFixed(2), exposure.Available.StringFixed(2))
+	}
+	if !result.Approved && req.SalesOrderID != "" {
+		override, err := s.coveringOverride(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if override != nil {
+			result.Approved = true
+			result.OverrideID = override.ID
+		}
+	}
+
+	result.Version = credit.Version
+	if !result.Approved || req.SalesOrderID == "" {
+		return result, nil
+	}
+	reserved, err := s.reserveSalesOrder(ctx, req)
+	if err != nil || !reserved {
+		return result, err
+	}
+
+	// The reservation goes through the credit's version lock; the stored balance is the
+	// full exposure including it
+	full, err := s.creditExposure(ctx, credit, "")
+	if err != nil {
+		return nil, err
+	}
+	credit.CurrentBalance = full.Exposure
+	credit.UpdatedAt = time.Now()
+	if err := s.credits.Update(ctx, credit); err != nil {
+		return nil, err
+	}
+	result.Version = credit.Version
+	return result, nil
+}
+
+// reserveSalesOrder counts an approved order towards the exposure at the checked value
+// until it is confirmed or cancelled. Orders already recorded as confirmed, and
+// reservations at the same value, are left alone; it then reports false.
+func (s *AccountsReceivableService) reserveSalesOrder(ctx context.Context, req CreditCheckRequest) (bool, error) {
+	order, err := s.exposures.GetBySalesOrderID(ctx, req.SalesOrderID)
+	if err != nil {
+		return true, s.exposures.Create(ctx, &domain.SalesOrderExposure{
+			ID:            utils.NewID("soe"),
+			LegalEntityID: req.LegalEntityID,
+			SalesOrderID:  req.SalesOrderID,
+			CustomerID:    req.CustomerID,
+			OrderAmount:   req.OrderValue,
+			Status:        domain.SalesOrderExposureStatusOPEN,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		})
+	}
+	if !order.ConfirmedAt.IsZero() || order.Status != domain.SalesOrderExposureStatusOPEN || order.OrderAmount.Equal(req.OrderValue) {
+		return false, nil
+	}
+	order.OrderAmount = req.OrderValue
+	if req.LegalEntityID != "" {
+		order.LegalEntityID = req.LegalEntityID
+	}
+	order.UpdatedAt = time.Now()
+	return true, s.exposures.Update(ctx, order)
+}
+
+// CheckCustomerCredit reports whether an order of the given value passes the credit check.
+func (s *AccountsReceivableService) CheckCustomerCredit(ctx context.Context, customerID string, orderValue decimal.Decimal) (bool, error) {
+	result, err := s.CheckCredit(ctx, CreditCheckRequest{CustomerID: customerID, OrderValue: orderValue})
+	if err != nil {
+		return false, err
+	}
+	return result.Approved, nil
+}
+
+func (s *AccountsReceivableService) GetCreditExposure(ctx context.Context, customerID string) (*CreditExposure, error) {
+	credit, err := s.GetCustomerCredit(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.creditExposure(ctx, credit, "")
+}
+
+// creditExposure adds the open receivables of the customer to its confirmed orders that have
+// not been invoiced yet. excludeOrderID leaves out the order being checked.
+func (s *AccountsReceivableService) creditExposure(ctx context.Context, credit *domain.CustomerCredit, excludeOrderID string) (*CreditExposure, error) {
+	exposure := &CreditExposure{
+		CustomerID:  credit.CustomerID,
+		CreditLimit: credit.CreditLimit,
+		IsOnHold:    credit.IsOnHold,
+		HoldReason:  credit.HoldReason,
+		Version:     credit.Version,
+	}
+
+	invoices, err := s.invoices.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range invoices {
+		if inv.CustomerID != credit.CustomerID || inv.Status == domain.PaymentStatusPAID {
+			continue
+		}
+		if open := inv.OpenAmount(); open.IsPositive() {
+			exposure.OpenReceivables = exposure.OpenReceivables.Add(agingFunctionalAmount(open, inv.ExchangeRate))
+		}
+	}
+
+	orders, err := s.exposures.ListOpenByCustomer(ctx, credit.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		if o.SalesOrderID == excludeOrderID {
+			continue
+		}
+		if uninvoiced := o.OrderAmount.Sub(o.InvoicedAmount); uninvoiced.IsPositive() {
+			exposure.UninvoicedOrders = exposure.UninvoicedOrders.Add(uninvoiced)
+		}
+	}
+
+	exposure.Exposure = exposure.OpenReceivables.Add(exposure.UninvoicedOrders)
+	exposure.Available = decimal.Max(credit.CreditLimit.Sub(exposure.Exposure), decimal.Zero)
+	return exposure, nil
+}
+
+// coveringOverride returns an override of the order that approves at least the order value,
+// stamping it as used the first time it lets the order through.
+func (s *AccountsReceivableService) coveringOverride(ctx context.Context, req CreditCheckRequest) (*domain.CreditOverride, error) {
+	overrides, err := s.overrides.ListByCustomer(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range overrides {
+		o := &overrides[i]
+		if o.SalesOrderID != req.SalesOrderID || o.Amount.LessThan(req.OrderValue) {
+			continue
+		}
+		if o.UsedAt == nil {
+			now := time.Now()
+			o.UsedAt = &now
+			if err := s.overrides.Update(ctx, o); err != nil {
+				return nil, err
+			}
+		}
+		return o, nil
+	}
+	return nil, nil
+}
+
+// SetCreditLimit changes a customer's limit. version must be the version the caller read;
+// a concurrent change makes the update fail with ErrOptimisticLock.
+func (s *AccountsReceivableService) SetCreditLimit(ctx context.Context, customerID string, limit decimal.Decimal, version int, changedBy string) (*domain.CustomerCredit, error) {
+	if limit.IsNegative() {
+		return nil, fmt.Errorf("%w: credit limit cannot be negative", domain.ErrInvalidCreditRequest)
+	}
+
+	var credit *domain.CustomerCredit
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		credit, err = s.GetCustomerCredit(txCtx, customerID)
+		if err != nil {
+			return err
+		}
+		if credit.Version != version {
+			return fmt.Errorf("%w: customer credit is at version %d", domain.ErrOptimisticLock, credit.Version)
+		}
+		credit.CreditLimit = limit
+		credit.UpdatedAt = time.Now()
+		if err := s.credits.Update(txCtx, credit); err != nil {
+			return err
+		}
+		return s.writeCreditStatusEvent(txCtx, credit, "credit limit changed", changedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+// HoldCustomerCredit puts a customer on credit hold by hand.
+func (s *AccountsReceivableService) HoldCustomerCredit(ctx context.Context, customerID, reason, heldBy string) (*domain.CustomerCredit, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", domain.ErrInvalidCreditRequest)
+	}
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		_, err := s.PlaceCreditHold(txCtx, customerID, reason, heldBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCustomerCredit(ctx, customerID)
+}
+
+// PlaceCreditHold puts a customer on credit hold and publishes the new credit status. It
+// reports false when the customer was already on hold.
+func (s *AccountsReceivableService) PlaceCreditHold(ctx context.Context, customerID, reason, heldBy string) (bool, error) {
+	credit, err := s.GetCustomerCredit(ctx, customerID)
+	if err != nil {
+		return false, err
+	}
+	if credit.IsOnHold {
+		return false, nil
+	}
+	credit.IsOnHold = true
+	credit.HoldReason = reason
+	credit.UpdatedAt = time.Now()
+	if err := s.credits.Update(ctx, credit); err != nil {
+		return false, err
+	}
+	return true, s.writeCreditStatusEvent(ctx, credit, reason, heldBy)
+}
+
+// ReleaseCreditHold lifts a credit hold. Who released it and why is published with the status.
+func (s *AccountsReceivableService) ReleaseCreditHold(ctx context.Context, customerID, reason, releasedBy string) (*domain.CustomerCredit, error) {
+	if strings.TrimSpace(reason) == "" || strings.TrimSpace(releasedBy) == "" {
+		return nil, fmt.Errorf("%w: releasing a hold needs a reason and the releasing user", domain.ErrInvalidCreditRequest)
+	}
+
+	var credit *domain.CustomerCredit
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		credit, err = s.GetCustomerCredit(txCtx, customerID)
+		if err != nil {
+			return err
+		}
+		if !credit.IsOnHold {
+			return fmt.Errorf("%w: %s", domain.ErrCustomerNotOnHold, customerID)
+		}
+		credit.IsOnHold = false
+		credit.HoldReason = ""
+		credit.UpdatedAt = time.Now()
+		if err := s.credits.Update(txCtx, credit); err != nil {
+			return err
+		}
+		return s.writeCreditStatusEvent(txCtx, credit, reason, releasedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+// OverrideCreditCheck lets one sales order pass the credit check up to the approved amount,
+// even while the customer is on hold or over its limit.
+func (s *AccountsReceivableService) OverrideCreditCheck(ctx context.Context, req CreditOverrideRequest) (*domain.CreditOverride, error) {
+	if req.CustomerID == "" || req.SalesOrderID == "" || !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: customer, sales order and a positive amount are required", domain.ErrInvalidCreditRequest)
+	}
+	if strings.TrimSpace(req.Reason) == "" || strings.TrimSpace(req.ApprovedBy) == "" {
+		return nil, fmt.Errorf("%w: an override needs a reason and the approving user", domain.ErrInvalidCreditRequest)
+	}
+
+	override := &domain.CreditOverride{
+		ID:           utils.NewID("cov"),
+		CustomerID:   req.CustomerID,
+		SalesOrderID: req.SalesOrderID,
+		Amount:       req.Amount,
+		ApprovedBy:   req.ApprovedBy,
+		Reason:       req.Reason,
+		CreatedAt:    time.Now(),
+	}
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.overrides.Create(txCtx, override)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+func (s *AccountsReceivableService) ListCreditOverrides(ctx context.Context, customerID string) ([]domain.CreditOverride, error) {
+	return s.overrides.ListByCustomer(ctx, customerID)
+}
+
+// RecordSalesOrder counts a confirmed sales order towards the customer's exposure until it
+// is invoiced, replacing the reservation its credit check made. Recording the same order
+// again changes nothing.
+func (s *AccountsReceivableService) RecordSalesOrder(ctx context.Context, legalEntityID, salesOrderID, customerID string, amount decimal.Decimal, confirmedAt time.Time) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if order, err := s.exposures.GetBySalesOrderID(txCtx, salesOrderID); err == nil {
+			if !order.ConfirmedAt.IsZero() || order.Status != domain.SalesOrderExposureStatusOPEN {
+				return nil
+			}
+			// The check knew the order's legal entity; keep it
+			if order.LegalEntityID == "" {
+				order.LegalEntityID = legalEntityID
+			}
+			order.OrderAmount = amount
+			order.ConfirmedAt = confirmedAt
+			order.UpdatedAt = time.Now()
+			return s.exposures.Update(txCtx, order)
+		}
+		return s.exposures.Create(txCtx, &domain.SalesOrderExposure{
+			ID:            utils.NewID("soe"),
+			LegalEntityID: legalEntityID,
+			SalesOrderID:  salesOrderID,
+			CustomerID:    customerID,
+			OrderAmount:   amount,
+			Status:        domain.SalesOrderExposureStatusOPEN,
+			ConfirmedAt:   confirmedAt,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		})
+	})
+}
+
+// CancelSalesOrder drops a cancelled order from the exposure. Unknown orders are ignored.
+func (s *AccountsReceivableService) CancelSalesOrder(ctx context.Context, salesOrderID string) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		order, err := s.exposures.GetBySalesOrderID(txCtx, salesOrderID)
+		if err != nil || order.Status != domain.SalesOrderExposureStatusOPEN {
+			return nil
+		}
+		order.Status = domain.SalesOrderExposureStatusCANCELLED
+		order.UpdatedAt = time.Now()
+		return s.exposures.Update(txCtx, order)
+	})
+}
+
+// invoiceSalesOrder moves the invoiced part of an order out of the uninvoiced exposure; the
+// invoice itself now counts as open receivable.
+func (s *AccountsReceivableService) invoiceSalesOrder(ctx context.Context, inv *domain.ArInvoice) error {
+	if inv.SalesOrderID == "" {
+		return nil
+	}
+	order, err := s.exposures.GetBySalesOrderID(ctx, inv.SalesOrderID)
+	if err != nil || order.Status != domain.SalesOrderExposureStatusOPEN {
+		return nil
+	}
+	order.InvoicedAmount = order.InvoicedAmount.Add(agingFunctionalAmount(inv.TotalAmount, inv.ExchangeRate))
+	if order.InvoicedAmount.GreaterThanOrEqual(order.OrderAmount) {
+		order.Status = domain.SalesOrderExposureStatusINVOICED
+	}
+	order.UpdatedAt = time.Now()
+	return s.exposures.Update(ctx, order)
+}
+
+func (s *AccountsReceivableService) writeCreditStatusEvent(ctx context.Context, credit *domain.CustomerCredit, reason, changedBy string) error {
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   string(domain.TopicFmCustomerCreditStatusUpdated),
+		AggregateID: credit.CustomerID,
+		Payload: domain.CustomerCreditStatusEventPayload{
+			CustomerID:     credit.CustomerID,
+			CreditLimit:    credit.CreditLimit,
+			CurrentBalance: credit.CurrentBalance,
+			IsOnHold:       credit.IsOnHold,
+			Reason:         reason,
+			ChangedBy:      changedBy,
+			Timestamp:      time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
+	})
+}
@@ -0,0 +1,357 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+// setCreditLimit sets the customer's credit limit starting from the lazily created default.
+func setCreditLimit(t *testing.T, svc *service.AccountsReceivableService, customerID string, limit int64) {
+	t.Helper()
+	ctx := context.Background()
+	cc, err := svc.GetCustomerCredit(ctx, customerID)
+	if err != nil {
+		t.Fatalf("failed to read customer credit: %v", err)
+	}
+	if _, err := svc.SetCreditLimit(ctx, customerID, decimal.NewFromInt(limit), cc.Version, "controller"); err != nil {
+		t.Fatalf("failed to set credit limit: %v", err)
+	}
+}
+
+func checkCredit(t *testing.T, svc *service.AccountsReceivableService, customerID, salesOrderID string, value int64) *service.CreditCheckResult {
+	t.Helper()
+	result, err := svc.CheckCredit(context.Background(), service.CreditCheckRequest{
+		CustomerID: customerID, SalesOrderID: salesOrderID, OrderValue: decimal.NewFromInt(value),
+	})
+	if err != nil {
+		t.Fatalf("credit check failed: %v", err)
+	}
+	return result
+}
+
+func TestCreditCheck_CountsOpenInvoicesAndUninvoicedOrders(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, credits, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+	setCreditLimit(t, svc, "cust_1", 10000)
+
+	// 4000 open on a foreign invoice at 1.25 plus 2500 still to be invoiced on a confirmed order
+	err := invoices.Create(ctx, &domain.ArInvoice{
+		ID: "inv_1", LegalEntityID: "le_1", InvoiceNumber: "INV-1", CustomerID: "cust_1",
+		TotalAmount: decimal.NewFromInt(4000), AmountPaid: decimal.NewFromInt(800), Currency: "EUR",
+		ExchangeRate: decimal.NewFromFloat(1.25), DueDate: day(2026, 4, 30), Status: domain.PaymentStatusPARTIAL,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed invoice: %v", err)
+	}
+	if err := svc.RecordSalesOrder(ctx, "le_1", "so_1", "cust_1", decimal.NewFromInt(2500), day(2026, 4, 1)); err != nil {
+		t.Fatalf("failed to record sales order: %v", err)
+	}
+
+	exposure, err := svc.GetCreditExposure(ctx, "cust_1")
+	if err != nil {
+		t.Fatalf("failed to get exposure: %v", err)
+	}
+	if !exposure.OpenReceivables.Equal(decimal.NewFromInt(4000)) || !exposure.UninvoicedOrders.Equal(decimal.NewFromInt(2500)) ||
+		!exposure.Available.Equal(decimal.NewFromInt(3500)) {
+		t.Errorf("expected 4000 open, 2500 on order and 3500 available, got %+v", exposure)
+	}
+
+	if result := checkCredit(t, svc, "cust_1", "so_2", 3500); !result.Approved {
+		t.Errorf("expected an order using exactly the available credit to pass, got %+v", result)
+	}
+	result := checkCredit(t, svc, "cust_1", "so_2", 3501)
+	if result.Approved || result.Reason == "" {
+		t.Errorf("expected an order above the available credit to be refused with a reason, got %+v", result)
+	}
+	if !result.Exposure.Equal(decimal.NewFromInt(6500)) {
+		t.Errorf("expected the refused check to report 6500 exposure, got %s", result.Exposure)
+	}
+
+	// Re-checking an order that is already recorded does not count it twice; so_2 is
+	// reserved at the 3500 it was approved for
+	if result := checkCredit(t, svc, "cust_1", "so_1", 2500); !result.Approved {
+		t.Errorf("expected so_1 to be checked against the exposure without itself, got %+v", result)
+	}
+
+	// The reservation keeps the stored balance at the full exposure
+	cc, _ := svc.GetCustomerCredit(ctx, "cust_1")
+	if !cc.CurrentBalance.Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("expected current balance to include the reservation, got %s", cc.CurrentBalance)
+	}
+}
+
+func TestCreditCheck_ReservesApprovedOrders(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, credits, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+	setCreditLimit(t, svc, "cust_1", 1000)
+
+	// A check without a sales order reserves nothing and leaves the version alone
+	before, _ := svc.GetCustomerCredit(ctx, "cust_1")
+	result := checkCredit(t, svc, "cust_1", "", 600)
+	if !result.Approved || result.Version != before.Version {
+		t.Errorf("expected an approved check at version %d, got %+v", before.Version, result)
+	}
+
+	// The first order takes the credit; the second no longer fits
+	first, err := svc.CheckCredit(ctx, service.CreditCheckRequest{
+		CustomerID: "cust_1", LegalEntityID: "le_1", SalesOrderID: "so_1", OrderValue: decimal.NewFromInt(600),
+	})
+	if err != nil || !first.Approved || first.Version != before.Version+1 {
+		t.Fatalf("expected so_1 approved at version %d, got %+v (%v)", before.Version+1, first, err)
+	}
+	if result := checkCredit(t, svc, "cust_1", "so_2", 600); result.Approved {
+		t.Errorf("expected so_2 to be refused against the reservation of so_1, got %+v", result)
+	}
+	order, _ := exposures.GetBySalesOrderID(ctx, "so_1")
+	if order.LegalEntityID != "le_1" || !order.OrderAmount.Equal(decimal.NewFromInt(600)) || !order.ConfirmedAt.IsZero() {
+		t.Errorf("expected so_1 reserved in le_1 at 600, got %+v", order)
+	}
+
+	// Re-checking at the same value changes nothing; confirming replaces the reservation
+	if result := checkCredit(t, svc, "cust_1", "so_1", 600); !result.Approved || result.Version != first.Version {
+		t.Errorf("expected the re-check to keep version %d, got %+v", first.Version, result)
+	}
+	if err := svc.RecordSalesOrder(ctx, "le_1", "so_1", "cust_1", decimal.NewFromInt(550), day(2026, 4, 1)); err != nil {
+		t.Fatalf("failed to record sales order: %v", err)
+	}
+	order, _ = exposures.GetBySalesOrderID(ctx, "so_1")
+	if !order.OrderAmount.Equal(decimal.NewFromInt(550)) || order.ConfirmedAt.IsZero() {
+		t.Errorf("expected the confirmed order at 550, got %+v", order)
+	}
+
+	// Cancelling releases the credit
+	if err := svc.CancelSalesOrder(ctx, "so_1"); err != nil {
+		t.Fatalf("failed to cancel sales order: %v", err)
+	}
+	if result := checkCredit(t, svc, "cust_1", "so_2", 600); !result.Approved {
+		t.Errorf("expected so_2 to pass once so_1 is cancelled, got %+v", result)
+	}
+}
+
+// racingCreditRepo loses the version race on the next lost updates, as when a
+// concurrent check reserves first
+type racingCreditRepo struct {
+	*memory.MemoryCustomerCreditRepo
+	lost int
+}
+
+func (r *racingCreditRepo) Update(ctx context.Context, cc *domain.CustomerCredit) error {
+	if r.lost > 0 {
+		r.lost--
+		return domain.ErrOptimisticLock
+	}
+	return r.MemoryCustomerCreditRepo.Update(ctx, cc)
+}
+
+func TestCreditCheck_RetriesLostReservation(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	racing := &racingCreditRepo{MemoryCustomerCreditRepo: credits}
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, racing, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+	setCreditLimit(t, svc, "cust_1", 1000)
+	before, _ := svc.GetCustomerCredit(ctx, "cust_1")
+
+	// The first attempt's reservation is rolled back and made again
+	racing.lost = 1
+	result := checkCredit(t, svc, "cust_1", "so_1", 600)
+	if !result.Approved || result.Version != before.Version+1 {
+		t.Errorf("expected the retried check approved at version %d, got %+v", before.Version+1, result)
+	}
+	exposure, _ := svc.GetCreditExposure(ctx, "cust_1")
+	if !exposure.UninvoicedOrders.Equal(decimal.NewFromInt(600)) {
+		t.Errorf("expected one reservation of 600, got %s", exposure.UninvoicedOrders)
+	}
+
+	// A check that keeps losing gives up with the conflict
+	racing.lost = 10
+	_, err := svc.CheckCredit(ctx, service.CreditCheckRequest{CustomerID: "cust_1", SalesOrderID: "so_2", OrderValue: decimal.NewFromInt(100)})
+	if !errors.Is(err, domain.ErrOptimisticLock) {
+		t.Errorf("expected ErrOptimisticLock after the retries, got %v", err)
+	}
+	if _, err := exposures.GetBySalesOrderID(ctx, "so_2"); err == nil {
+		t.Error("expected no reservation for the failed check")
+	}
+}
+func TestCreditCheck_InvoicingAndCancellingMoveExposure(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, credits, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+	setCreditLimit(t, svc, "cust_1", 10000)
+
+	if err := svc.RecordSalesOrder(ctx, "le_1", "so_1", "cust_1", decimal.NewFromInt(3000), day(2026, 4, 1)); err != nil {
+		t.Fatalf("failed to record sales order: %v", err)
+	}
+	// Recording the same order again is a no-op
+	if err := svc.RecordSalesOrder(ctx, "le_1", "so_1", "cust_1", decimal.NewFromInt(3000), day(2026, 4, 1)); err != nil {
+		t.Fatalf("expected re-recording to be ignored, got %v", err)
+	}
+
+	if _, err := svc.CreateInvoice(ctx, "le_1", "cust_1", "so_1", "", decimal.NewFromInt(1000), decimal.Zero, day(2026, 5, 1)); err != nil {
+		t.Fatalf("failed to invoice order: %v", err)
+	}
+	exposure, _ := svc.GetCreditExposure(ctx, "cust_1")
+	if !exposure.OpenReceivables.Equal(decimal.NewFromInt(1000)) || !exposure.UninvoicedOrders.Equal(decimal.NewFromInt(2000)) {
+		t.Errorf("expected a partial invoice to move 1000 from orders to receivables, got %+v", exposure)
+	}
+
+	if _, err := svc.CreateInvoice(ctx, "le_1", "cust_1", "so_1", "", decimal.NewFromInt(2000), decimal.Zero, day(2026, 5, 1)); err != nil {
+		t.Fatalf("failed to invoice order: %v", err)
+	}
+	order, _ := exposures.GetBySalesOrderID(ctx, "so_1")
+	if order.Status != domain.SalesOrderExposureStatusINVOICED {
+		t.Errorf("expected fully invoiced order to be INVOICED, got %s", order.Status)
+	}
+
+	if err := svc.RecordSalesOrder(ctx, "le_1", "so_2", "cust_1", decimal.NewFromInt(4000), day(2026, 4, 2)); err != nil {
+		t.Fatalf("failed to record sales order: %v", err)
+	}
+	if err := svc.CancelSalesOrder(ctx, "so_2"); err != nil {
+		t.Fatalf("failed to cancel sales order: %v", err)
+	}
+	exposure, _ = svc.GetCreditExposure(ctx, "cust_1")
+	if !exposure.Exposure.Equal(decimal.NewFromInt(3000)) || !exposure.UninvoicedOrders.IsZero() {
+		t.Errorf("expected only the 3000 invoiced to remain exposed, got %+v", exposure)
+	}
+}
+
+func TestCreditHold_RefusesUntilReleased(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, credits, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+
+	cc, err := svc.HoldCustomerCredit(ctx, "cust_1", "disputed payments", "controller")
+	if err != nil || !cc.IsOnHold || cc.HoldReason != "disputed payments" {
+		t.Fatalf("expected customer on hold with reason, got %+v (%v)", cc, err)
+	}
+	if result := checkCredit(t, svc, "cust_1", "so_1", 10); result.Approved {
+		t.Errorf("expected a customer on hold to be refused, got %+v", result)
+	}
+
+	if _, err := svc.ReleaseCreditHold(ctx, "cust_1", "payments received", ""); !errors.Is(err, domain.ErrInvalidCreditRequest) {
+		t.Errorf("expected releasing without a user to be rejected, got %v", err)
+	}
+	cc, err = svc.ReleaseCreditHold(ctx, "cust_1", "payments received", "cfo")
+	if err != nil || cc.IsOnHold || cc.HoldReason != "" {
+		t.Fatalf("expected hold to be released, got %+v (%v)", cc, err)
+	}
+	if _, err := svc.ReleaseCreditHold(ctx, "cust_1", "again", "cfo"); !errors.Is(err, domain.ErrCustomerNotOnHold) {
+		t.Errorf("expected ErrCustomerNotOnHold, got %v", err)
+	}
+	if result := checkCredit(t, svc, "cust_1", "so_1", 10); !result.Approved {
+		t.Errorf("expected released customer to pass, got %+v", result)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 100)
+	var releasedBy string
+	for _, ev := range pending {
+		if payload, ok := ev.Payload.(domain.CustomerCreditStatusEventPayload); ok && !payload.IsOnHold {
+			releasedBy = payload.ChangedBy
+		}
+	}
+	if countTopic(t, outbox, string(domain.TopicFmCustomerCreditStatusUpdated)) != 2 || releasedBy != "cfo" {
+		t.Errorf("expected hold and release status events naming the releasing user, got %q", releasedBy)
+	}
+}
+
+func TestCreditOverride_ApprovesOnlyTheCoveredOrder(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, credits, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+	setCreditLimit(t, svc, "cust_1", 1000)
+
+	if result := checkCredit(t, svc, "cust_1", "so_big", 5000); result.Approved {
+		t.Fatalf("expected the order above the limit to be refused, got %+v", result)
+	}
+	if _, err := svc.OverrideCreditCheck(ctx, service.CreditOverrideRequest{
+		CustomerID: "cust_1", SalesOrderID: "so_big", Amount: decimal.NewFromInt(5000), Reason: "strategic account",
+	}); !errors.Is(err, domain.ErrInvalidCreditRequest) {
+		t.Errorf("expected an override without an approver to be rejected, got %v", err)
+	}
+	override, err := svc.OverrideCreditCheck(ctx, service.CreditOverrideRequest{
+		CustomerID: "cust_1", SalesOrderID: "so_big", Amount: decimal.NewFromInt(5000), ApprovedBy: "cfo", Reason: "strategic account",
+	})
+	if err != nil {
+		t.Fatalf("failed to override credit check: %v", err)
+	}
+
+	result := checkCredit(t, svc, "cust_1", "so_big", 5000)
+	if !result.Approved || result.OverrideID != override.ID {
+		t.Errorf("expected the override to approve the order, got %+v", result)
+	}
+	if result := checkCredit(t, svc, "cust_1", "so_big", 5001); result.Approved {
+		t.Errorf("expected a value above the approved amount to be refused, got %+v", result)
+	}
+	if result := checkCredit(t, svc, "cust_1", "so_other", 5000); result.Approved {
+		t.Errorf("expected the override not to cover another order, got %+v", result)
+	}
+
+	listed, _ := svc.ListCreditOverrides(ctx, "cust_1")
+	if len(listed) != 1 || listed[0].UsedAt == nil {
+		t.Errorf("expected the override to be stamped as used, got %+v", listed)
+	}
+}
+
+func TestSetCreditLimit_RejectsStaleVersion(t *testing.T) {
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	overrides := memory.NewMemoryCreditOverrideRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(invoices, credits, exposures, overrides, outbox)
+	svc := service.NewAccountsReceivableService(invoices, credits, exposures, overrides, testConverter(), nil, outbox, tm)
+	ctx := context.Background()
+
+	cc, _ := svc.GetCustomerCredit(ctx, "cust_1")
+	stale := cc.Version
+	if _, err := svc.SetCreditLimit(ctx, "cust_1", decimal.NewFromInt(8000), stale, "controller"); err != nil {
+		t.Fatalf("failed to set credit limit: %v", err)
+	}
+	if _, err := svc.SetCreditLimit(ctx, "cust_1", decimal.NewFromInt(9000), stale, "controller"); !errors.Is(err, domain.ErrOptimisticLock) {
+		t.Errorf("expected a stale version to fail with ErrOptimisticLock, got %v", err)
+	}
+	if _, err := svc.SetCreditLimit(ctx, "cust_1", decimal.NewFromInt(-1), stale+1, "controller"); !errors.Is(err, domain.ErrInvalidCreditRequest) {
+		t.Errorf("expected a negative limit to be rejected, got %v", err)
+	}
+	cc, _ = svc.GetCustomerCredit(ctx, "cust_1")
+	if !cc.CreditLimit.Equal(decimal.NewFromInt(8000)) {
+		t.Errorf("expected the first change to stick, got %s", cc.CreditLimit)
+	}
+}
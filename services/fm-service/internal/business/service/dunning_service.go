@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DunningLevelRequest configures one level of a legal entity's dunning procedure.
+type DunningLevelRequest struct {
+	LegalEntityID       string          `json:"legal_entity_id"`
+	Level               int             `json:"level"`
+	Name                string          `json:"name"`
+	DaysOverdue         int             `json:"days_overdue"`
+	FeeAmount           decimal.Decimal `json:"fee_amount"`
+	InterestRatePercent decimal.Decimal `json:"interest_rate_percent"`
+}
+
+// DunningRunResult is one dunning run with the letters it issued.
+type DunningRunResult struct {
+	Run     *domain.DunningRun     `json:"run"`
+	Notices []domain.DunningNotice `json:"notices"`
+}
+
+type DunningService struct {
+	levels   domain.DunningLevelRepository
+	runs     domain.DunningRunRepository
+	notices  domain.DunningNoticeRepository
+	invoices domain.ArInvoiceRepository
+	ar       *AccountsReceivableService
+	gl       *GeneralLedgerService
+	outbox   domain.TransactionalOutboxRepository
+	tm       domain.TransactionManager
+}
+
+func NewDunningService(
+	levels domain.DunningLevelRepository,
+	runs domain.DunningRunRepository,
+	notices domain.DunningNoticeRepository,
+	invoices domain.ArInvoiceRepository,
+	ar *AccountsReceivableService,
+	gl *GeneralLedgerService,
+	outbox domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
+) *DunningService {
+	return &DunningService{
+		levels:   levels,
+		runs:     runs,
+		notices:  notices,
+		invoices: invoices,
+		ar:       ar,
+		gl:       gl,
+		outbox:   outbox,
+		tm:       tm,
+	}
+}
+
+// SetDunningLevel creates or replaces a level. Thresholds must rise with the level so that
+// invoices move through the levels in order.
+func (s *DunningService) SetDunningLevel(ctx context.Context, req DunningLevelRequest) (*domain.DunningLevel, error) {
+	if req.LegalEntityID == "" || req.Level < 1 || strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: legal entity, a level from 1 and a name are required", domain.ErrInvalidDunningLevel)
+	}
+	if req.DaysOverdue < 0 || req.FeeAmount.IsNegative() || req.InterestRatePercent.IsNegative() {
+		return nil, fmt.Errorf("%w: days, fee and interest cannot be negative", domain.ErrInvalidDunningLevel)
+	}
+
+	var level *domain.DunningLevel
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.levels.ListByLegalEntity(txCtx, req.LegalEntityID)
+		if err != nil {
+			return err
+		}
+		for _, l := range existing {
+			if (l.Level < req.Level && l.DaysOverdue >= req.DaysOverdue) || (l.Level > req.Level && l.DaysOverdue <= req.DaysOverdue) {
+				return fmt.Errorf("%w: level %d at %d days conflicts with level %d at %d days",
+					domain.ErrInvalidDunningLevel, req.Level, req.DaysOverdue, l.Level, l.DaysOverdue)
+			}
+		}
+
+		if current, err := s.levels.GetByLevel(txCtx, req.LegalEntityID, req.Level); err == nil {
+			current.Name = req.Name
+			current.DaysOverdue = req.DaysOverdue
+			current.FeeAmount = req.FeeAmount
+			current.InterestRatePercent = req.InterestRatePercent
+			current.UpdatedAt = time.Now()
+			level = current
+			return s.levels.Update(txCtx, current)
+		}
+		level = &domain.DunningLevel{
+			ID:                  utils.NewID("dlvl"),
+			LegalEntityID:       req.LegalEntityID,
+			Level:               req.Level,
+			Name:                req.Name,
+			DaysOverdue:         req.DaysOverdue,
+			FeeAmount:           req.FeeAmount,
+			InterestRatePercent: req.InterestRatePercent,
+			CreatedAt:           time.Now(),
+			UpdatedAt:           time.Now(),
+		}
+		return s.levels.Create(txCtx, level)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+func (s *DunningService) ListDunningLevels(ctx context.Context, legalEntityID string) ([]domain.DunningLevel, error) {
+	return s.levels.ListByLegalEntity(ctx, legalEntityID)
+}
+
+func (s *DunningService) DeleteDunningLevel(ctx context.Context, legalEntityID string, level int) error {
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.levels.GetByLevel(txCtx, legalEntityID, level)
+		if err != nil {
+			return fmt.Errorf("%w: level %d is not configured", domain.ErrInvalidDunningLevel, level)
+		}
+		return s.levels.Delete(txCtx, existing.ID)
+	})
+}
+
+// RunDunning promotes the overdue invoices of a legal entity to the highest level their days
+// past due have reached. Each promotion charges the level's fee plus interest accrued since the
+// last letter, posts both to the GL and issues a dunning letter. Customers reaching the final
+// level are put on credit hold. Running again on the same day issues nothing new.
+func (s *DunningService) RunDunning(ctx context.Context, legalEntityID string, asOf time.Time) (*DunningRunResult, error) {
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+
+	levels, err := s.levels.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%w: legal entity %s", domain.ErrNoDunningLevels, legalEntityID)
+	}
+	final := levels[len(levels)-1].Level
+
+	result := &DunningRunResult{
+		Run: &domain.DunningRun{
+			ID:            utils.NewID("drun"),
+			LegalEntityID: legalEntityID,
+			AsOf:          asOf,
+			CreatedAt:     time.Now(),
+		},
+		Notices: []domain.DunningNotice{},
+	}
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		invoices, err := s.invoices.List(txCtx)
+		if err != nil {
+			return err
+		}
+
+		onHold := make(map[string]bool)
+		for i := range invoices {
+			inv := &invoices[i]
+			if inv.LegalEntityID != legalEntityID || inv.Status == domain.PaymentStatusPAID || !inv.OpenAmount().IsPositive() {
+				continue
+			}
+			days := daysPastDue(inv.DueDate, asOf)
+			if days <= 0 {
+				continue
+			}
+			result.Run.InvoicesEvaluated++
+			if inv.Status != domain.PaymentStatusOVERDUE {
+				if err := s.ar.markOverdue(txCtx, inv); err != nil {
+					return err
+				}
+			}
+
+			level := reachedDunningLevel(levels, days)
+			if level == nil || level.Level <= inv.DunningLevel {
+				continue
+			}
+
+			notice, err := s.dunInvoice(txCtx, result.Run, inv, level, days, level.Level == final)
+			if err != nil {
+				return err
+			}
+			result.Notices = append(result.Notices, *notice)
+
+			if notice.IsFinal && !onHold[inv.CustomerID] {
+				placed, err := s.ar.PlaceCreditHold(txCtx, inv.CustomerID, fmt.Sprintf("final dunning level reached on invoice %s", inv.InvoiceNumber), "dunning")
+				if err != nil {
+					return err
+				}
+				if placed {
+					result.Run.CustomersOnHold++
+				}
+				onHold[inv.CustomerID] = true
+			}
+		}
+
+		result.Run.NoticesIssued = len(result.Notices)
+		if err := s.runs.Create(txCtx, result.Run); err != nil {
+			return err
+		}
+		return s.notices.CreateMany(txCtx, result.Notices)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *DunningService) ListDunningRuns(ctx context.Context) ([]domain.DunningRun, error) {
+	return s.runs.List(ctx)
+}
+
+func (s *DunningService) GetDunningRun(ctx context.Context, id string) (*DunningRunResult, error) {
+	run, err := s.runs.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	notices, err := s.notices.ListByRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &DunningRunResult{Run: run, Notices: notices}, nil
+}
+
+func (s *DunningService) ListInvoiceNotices(ctx context.Context, invoiceID string) ([]domain.DunningNotice, error) {
+	return s.notices.ListByInvoice(ctx, invoiceID)
+}
+
+// dunInvoice raises the invoice to the level, charging fee and interest and writing the letter event.
+func (s *DunningService) dunInvoice(ctx context.Context, run *domain.DunningRun, inv *domain.ArInvoice, level *domain.DunningLevel, days int, final bool) (*domain.DunningNotice, error) {
+	open := inv.OpenAmount()
+	interestFrom := inv.DueDate
+	if inv.LastDunnedAt != nil && inv.LastDunnedAt.After(interestFrom) {
+		interestFrom = *inv.LastDunnedAt
+	}
+	// Interest accrues on the unpaid principal only, never on fees and interest already charged
+	principal := decimal.Max(inv.TotalAmount.Sub(inv.AmountPaid), decimal.Zero)
+	interest := decimal.Zero
+	if interestDays := daysPastDue(interestFrom, run.AsOf); interestDays > 0 && level.InterestRatePercent.IsPositive() {
+		interest = principal.Mul(level.InterestRatePercent).Div(decimal.NewFromInt(100)).
+			Mul(decimal.NewFromInt(int64(interestDays))).Div(decimal.NewFromInt(365)).Round(2)
+	}
+
+	notice := &domain.DunningNotice{
+		ID:             utils.NewID("dnot"),
+		RunID:          run.ID,
+		LegalEntityID:  inv.LegalEntityID,
+		InvoiceID:      inv.ID,
+		CustomerID:     inv.CustomerID,
+		Level:          level.Level,
+		DaysOverdue:    days,
+		Currency:       inv.Currency,
+		OpenAmount:     open,
+		FeeAmount:      level.FeeAmount,
+		InterestAmount: interest,
+		TotalDue:       open.Add(level.FeeAmount).Add(interest),
+		IsFinal:        final,
+		CreatedAt:      time.Now(),
+	}
+	if s.gl != nil {
+		entryID, err := s.postCharges(ctx, inv, notice, run.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		notice.JournalEntryID = entryID
+	}
+
+	asOf := run.AsOf
+	inv.FeesCharged = inv.FeesCharged.Add(notice.FeeAmount).Add(notice.InterestAmount)
+	inv.DunningLevel = level.Level
+	inv.LastDunnedAt = &asOf
+	inv.UpdatedAt = time.Now()
+	if err := s.invoices.Update(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	err := s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   string(domain.TopicFmDunningLetterIssued),
+		AggregateID: inv.ID,
+		Payload: domain.DunningLetterEventPayload{
+			NoticeID:       notice.ID,
+			RunID:          run.ID,
+			LegalEntityID:  inv.LegalEntityID,
+			InvoiceID:      inv.ID,
+			InvoiceNumber:  inv.InvoiceNumber,
+			CustomerID:     inv.CustomerID,
+			Level:          level.Level,
+			LevelName:      level.Name,
+			DaysOverdue:    days,
+			Currency:       inv.Currency,
+			OpenAmount:     notice.OpenAmount,
+			FeeAmount:      notice.FeeAmount,
+			InterestAmount: notice.InterestAmount,
+			TotalDue:       notice.TotalDue,
+			IsFinal:        final,
+			Timestamp:      time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notice, nil
+}
+
+// postCharges debits the receivable and credits fee and interest income. Nothing is posted when
+// the level charges neither.
+func (s *DunningService) postCharges(ctx context.Context, inv *domain.ArInvoice, notice *domain.DunningNotice, postingDate time.Time) (*string, error) {
+	charged := notice.FeeAmount.Add(notice.InterestAmount)
+	if !charged.IsPositive() {
+		return nil, nil
+	}
+	rate := inv.ExchangeRate
+	if !rate.IsPositive() {
+		rate = decimal.NewFromInt(1)
+	}
+
+	// The receivable grows by the fee and interest billed
+	receivable, err := s.gl.DetermineAccount(ctx, inv.LegalEntityID, domain.PostingKeyAR_CONTROL)
+	if err != nil {
+		return nil, err
+	}
+	lines := []domain.UniversalJournalLine{{
+		AccountID:             receivable.ID,
+		AmountTransactional:   charged,
+		AmountFunctional:      convertAmount(charged, rate),
+		CurrencyTransactional: inv.Currency,
+		ExchangeRate:          rate,
+	}}
+	for _, charge := range []struct {
+		amount decimal.Decimal
+		key    domain.PostingKey
+	}{
+		{notice.FeeAmount, domain.PostingKeyDUNNING_FEE_INCOME},
+		{notice.InterestAmount, domain.PostingKeyDUNNING_INTEREST_INCOME},
+	} {
+		if !charge.amount.IsPositive() {
+			continue
+		}
+		acc, err := s.gl.DetermineAccount(ctx, inv.LegalEntityID, charge.key)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, domain.UniversalJournalLine{
+			AccountID:             acc.ID,
+			AmountTransactional:   charge.amount.Neg(),
+			AmountFunctional:      convertAmount(charge.amount, rate).Neg(),
+			CurrencyTransactional: inv.Currency,
+			ExchangeRate:          rate,
+		})
+	}
+	// Rounding of the converted parts must not unbalance the entry
+	if len(lines) == 3 {
+		lines[2].AmountFunctional = lines[0].AmountFunctional.Neg().Sub(lines[1].AmountFunctional)
+	}
+
+	entry, err := s.gl.CreateJournalEntry(ctx, inv.LegalEntityID, "AR", inv.ID, postingDate, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &entry.ID, nil
+}
+
+// reachedDunningLevel returns the highest level whose threshold the days past due have met.
+// Levels are sorted by level number.
+func reachedDunningLevel(levels []domain.DunningLevel, days int) *domain.DunningLevel {
+	var reached *domain.DunningLevel
+	for i := range levels {
+		if levels[i].DaysOverdue <= days {
+			reached = &levels[i]
+		}
+	}
+	return reached
+}
+
+func daysPastDue(due, asOf time.Time) int {
+	due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+	return int(asOf.Sub(due).Hours() / 24)
+}
+
+// DunningScheduler runs dunning once per interval for every legal entity with dunning levels.
+type DunningScheduler struct {
+	svc           *DunningService
+	legalEntities domain.LegalEntityRepository
+	interval      time.Duration
+}
+
+func NewDunningScheduler(svc *DunningService, legalEntities domain.LegalEntityRepository, interval time.Duration) *DunningScheduler {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &DunningScheduler{
+		svc:           svc,
+		legalEntities: legalEntities,
+		interval:      interval,
+	}
+}
+
+func (d *DunningScheduler) Start(ctx context.Context) {
+	log.Println("Starting background Dunning Scheduler...")
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping Dunning Scheduler...")
+			return
+		case <-ticker.C:
+			d.RunOnce(ctx, time.Now())
+		}
+	}
+}
+
+// RunOnce runs dunning for each legal entity; entities without levels are skipped and a
+// failing entity does not stop the others.
+func (d *DunningScheduler) RunOnce(ctx context.Context, asOf time.Time) {
+	entities, err := d.legalEntities.List(ctx)
+	if err != nil {
+		log.Printf("[Dunning] Error listing legal entities: %v", err)
+		return
+	}
+	for _, le := range entities {
+		result, err := d.svc.RunDunning(ctx, le.ID, asOf)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNoDunningLevels) {
+				log.Printf("[Dunning] Run for legal entity %s failed: %v", le.ID, err)
+			}
+			continue
+		}
+		if result.Run.NoticesIssued > 0 {
+			log.Printf("[Dunning] Legal entity %s: %d letters issued, %d customers put on hold", le.ID, result.Run.NoticesIssued, result.Run.CustomersOnHold)
+		}
+	}
+}
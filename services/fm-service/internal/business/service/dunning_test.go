@@ -0,0 +1,253 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+// setDunningLevels configures a reminder at 7 days, a fee-bearing second notice at 30 and a final notice
+// with interest at 60.
+func setDunningLevels(t *testing.T, svc *service.DunningService) {
+	t.Helper()
+	for _, req := range []service.DunningLevelRequest{
+		{LegalEntityID: "le_1", Level: 1, Name: "Reminder", DaysOverdue: 7},
+		{LegalEntityID: "le_1", Level: 2, Name: "Second Notice", DaysOverdue: 30, FeeAmount: decimal.NewFromInt(15)},
+		{LegalEntityID: "le_1", Level: 3, Name: "Final Notice", DaysOverdue: 60, FeeAmount: decimal.NewFromInt(40), InterestRatePercent: decimal.NewFromInt(10)},
+	} {
+		if _, err := svc.SetDunningLevel(context.Background(), req); err != nil {
+			t.Fatalf("failed to set dunning level %d: %v", req.Level, err)
+		}
+	}
+}
+
+func runDunning(t *testing.T, svc *service.DunningService, asOf time.Time) *service.DunningRunResult {
+	t.Helper()
+	result, err := svc.RunDunning(context.Background(), "le_1", asOf)
+	if err != nil {
+		t.Fatalf("dunning run failed: %v", err)
+	}
+	return result
+}
+
+func TestSetDunningLevel_Validation(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	runs := memory.NewMemoryDunningRunRepo()
+	notices := memory.NewMemoryDunningNoticeRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, credits, runs, notices, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	ar := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
+	svc := service.NewDunningService(memory.NewMemoryDunningLevelRepo(), runs, notices, invoices, ar, gl, outbox, tm)
+	ctx := context.Background()
+	setDunningLevels(t, svc)
+
+	cases := map[string]service.DunningLevelRequest{
+		"missing name":         {LegalEntityID: "le_1", Level: 4, DaysOverdue: 90},
+		"level zero":           {LegalEntityID: "le_1", Level: 0, Name: "Zero"},
+		"negative fee":         {LegalEntityID: "le_1", Level: 4, Name: "Collections", DaysOverdue: 90, FeeAmount: decimal.NewFromInt(-1)},
+		"threshold below prev": {LegalEntityID: "le_1", Level: 4, Name: "Collections", DaysOverdue: 45},
+		"threshold above next": {LegalEntityID: "le_1", Level: 2, Name: "Second Notice", DaysOverdue: 75},
+	}
+	for name, req := range cases {
+		if _, err := svc.SetDunningLevel(ctx, req); !errors.Is(err, domain.ErrInvalidDunningLevel) {
+			t.Errorf("%s: expected ErrInvalidDunningLevel, got %v", name, err)
+		}
+	}
+
+	// Updating a level in place keeps a single row per level
+	if _, err := svc.SetDunningLevel(ctx, service.DunningLevelRequest{LegalEntityID: "le_1", Level: 2, Name: "Second Reminder", DaysOverdue: 21}); err != nil {
+		t.Fatalf("failed to update level: %v", err)
+	}
+	levels, _ := svc.ListDunningLevels(ctx, "le_1")
+	if len(levels) != 3 || levels[1].Name != "Second Reminder" || levels[1].DaysOverdue != 21 {
+		t.Errorf("expected level 2 to be updated in place, got %+v", levels)
+	}
+
+	if _, err := svc.RunDunning(ctx, "le_2", day(2026, 3, 1)); !errors.Is(err, domain.ErrNoDunningLevels) {
+		t.Errorf("expected ErrNoDunningLevels, got %v", err)
+	}
+}
+
+func TestRunDunning_PromotesAndChargesFees(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	runs := memory.NewMemoryDunningRunRepo()
+	notices := memory.NewMemoryDunningNoticeRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, credits, runs, notices, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	ar := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
+	svc := service.NewDunningService(memory.NewMemoryDunningLevelRepo(), runs, notices, invoices, ar, gl, outbox, tm)
+	ctx := context.Background()
+	setDunningLevels(t, svc)
+	seedOpenInvoice(t, invoices, "a", "cust_1", 1000, day(2026, 1, 1))
+	seedOpenInvoice(t, invoices, "b", "cust_2", 500, day(2026, 2, 25))
+	seedOpenInvoice(t, invoices, "c", "cust_2", 200, day(2026, 3, 10))
+
+	// 35 days late: straight to level 2, the fee is charged but level 2 accrues no interest
+	result := runDunning(t, svc, day(2026, 2, 5))
+	if result.Run.InvoicesEvaluated != 1 || len(result.Notices) != 1 {
+		t.Fatalf("expected one evaluated invoice and one notice, got %+v", result.Run)
+	}
+	notice := result.Notices[0]
+	if notice.Level != 2 || !notice.FeeAmount.Equal(decimal.NewFromInt(15)) || !notice.InterestAmount.IsZero() || notice.JournalEntryID == nil {
+		t.Errorf("unexpected notice: %+v", notice)
+	}
+	inv, _ := invoices.GetByID(ctx, "a")
+	if inv.Status != domain.PaymentStatusOVERDUE || inv.DunningLevel != 2 || !inv.OpenAmount().Equal(decimal.NewFromInt(1015)) {
+		t.Errorf("expected an overdue level 2 invoice owing 1015, got %s, %d, %s", inv.Status, inv.DunningLevel, inv.OpenAmount())
+	}
+
+	// Running again on the same day issues nothing new
+	if again := runDunning(t, svc, day(2026, 2, 5)); len(again.Notices) != 0 {
+		t.Errorf("expected a same-day rerun to issue no notices, got %d", len(again.Notices))
+	}
+
+	// 68 days late: final level, 40 fee plus 10% interest for the 33 days since the last letter,
+	// on the 1000 principal only: the 15 fee already charged bears no interest
+	result = runDunning(t, svc, day(2026, 3, 10))
+	if len(result.Notices) != 2 {
+		t.Fatalf("expected notices for INV-a and INV-b, got %d", len(result.Notices))
+	}
+	var final domain.DunningNotice
+	for _, n := range result.Notices {
+		if n.InvoiceID == "a" {
+			final = n
+		}
+	}
+	if !final.IsFinal || final.Level != 3 || !final.InterestAmount.Equal(decimal.RequireFromString("9.04")) {
+		t.Errorf("expected a final notice with 9.04 interest, got %+v", final)
+	}
+	if !final.TotalDue.Equal(decimal.RequireFromString("1064.04")) {
+		t.Errorf("expected 1064.04 due, got %s", final.TotalDue)
+	}
+	if n := countTopic(t, outbox, string(domain.TopicFmDunningLetterIssued)); n != 3 {
+		t.Errorf("expected three letter events, got %d", n)
+	}
+	if n := countTopic(t, outbox, domain.TopicFmInvoiceOverdue); n != 2 {
+		t.Errorf("expected two overdue events, got %d", n)
+	}
+
+	// Only the customer on the final level goes on hold
+	if result.Run.CustomersOnHold != 1 {
+		t.Errorf("expected one customer on hold, got %d", result.Run.CustomersOnHold)
+	}
+	credit, err := credits.GetByCustomerID(ctx, "cust_1")
+	if err != nil || !credit.IsOnHold {
+		t.Errorf("expected cust_1 to be on credit hold, got %+v (%v)", credit, err)
+	}
+	if n := countTopic(t, outbox, string(domain.TopicFmCustomerCreditStatusUpdated)); n != 1 {
+		t.Errorf("expected one credit status event, got %d", n)
+	}
+
+	// Fee and interest postings balance
+	posted, _ := entries.List(ctx)
+	if len(posted) != 2 {
+		t.Fatalf("expected two charge postings, got %d", len(posted))
+	}
+	for _, e := range posted {
+		_, lines, _ := entries.GetByID(ctx, e.ID)
+		sum := decimal.Zero
+		for _, l := range lines {
+			sum = sum.Add(l.AmountFunctional)
+		}
+		if !sum.IsZero() {
+			t.Errorf("expected entry %s to balance, got %s", e.ID, sum)
+		}
+	}
+
+	issued, _ := svc.ListInvoiceNotices(ctx, "a")
+	if len(issued) != 2 {
+		t.Errorf("expected two notices on INV-a, got %d", len(issued))
+	}
+}
+
+func TestRunDunning_PartialPaymentKeepsOverdue(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	runs := memory.NewMemoryDunningRunRepo()
+	notices := memory.NewMemoryDunningNoticeRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, credits, runs, notices, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	ar := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
+	svc := service.NewDunningService(memory.NewMemoryDunningLevelRepo(), runs, notices, invoices, ar, gl, outbox, tm)
+	payments := memory.NewMemoryPaymentRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:    payments,
+		Invoices:    invoices,
+		Bills:       memory.NewMemoryApVendorBillRepo(),
+		Allocations: allocations,
+		Outbox:      outbox,
+		TM:          memory.NewMemoryTransactionManager(payments, invoices, allocations, outbox),
+	})
+	ctx := context.Background()
+	setDunningLevels(t, svc)
+	seedOpenInvoice(t, invoices, "a", "cust_1", 1000, day(2026, 1, 1))
+	runDunning(t, svc, day(2026, 2, 5))
+
+	req := customerPayment(400, toInvoice("a", 400))
+	if _, err := cash.RecordAllocatedPayment(ctx, req); err != nil {
+		t.Fatalf("payment failed: %v", err)
+	}
+	inv, _ := invoices.GetByID(ctx, "a")
+	if inv.Status != domain.PaymentStatusOVERDUE || !inv.OpenAmount().Equal(decimal.NewFromInt(615)) {
+		t.Errorf("expected the invoice to stay overdue with 615 open, got %s and %s", inv.Status, inv.OpenAmount())
+	}
+
+	// Settling the fee as well pays the invoice off
+	if _, err := cash.RecordAllocatedPayment(ctx, customerPayment(615, toInvoice("a", 615))); err != nil {
+		t.Fatalf("payment failed: %v", err)
+	}
+	inv, _ = invoices.GetByID(ctx, "a")
+	if inv.Status != domain.PaymentStatusPAID {
+		t.Errorf("expected the invoice to be paid, got %s", inv.Status)
+	}
+	if result := runDunning(t, svc, day(2026, 3, 10)); result.Run.InvoicesEvaluated != 0 {
+		t.Errorf("expected paid invoices to be skipped, got %d evaluated", result.Run.InvoicesEvaluated)
+	}
+}
+
+func TestDunningScheduler_RunOnce(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	credits := memory.NewMemoryCustomerCreditRepo()
+	runs := memory.NewMemoryDunningRunRepo()
+	notices := memory.NewMemoryDunningNoticeRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, credits, runs, notices, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	ar := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
+	svc := service.NewDunningService(memory.NewMemoryDunningLevelRepo(), runs, notices, invoices, ar, gl, outbox, tm)
+	ctx := context.Background()
+	setDunningLevels(t, svc)
+	seedOpenInvoice(t, invoices, "a", "cust_1", 1000, day(2026, 1, 1))
+
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	for _, id := range []string{"le_1", "le_2"} {
+		_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: id, CompanyCode: id, CompanyName: id, FunctionalCurrency: "USD"})
+	}
+
+	// le_2 has no levels and is skipped without stopping le_1
+	service.NewDunningScheduler(svc, legalEntities, 0).RunOnce(ctx, day(2026, 1, 10))
+	dunningRuns, _ := svc.ListDunningRuns(ctx)
+	if len(dunningRuns) != 1 || dunningRuns[0].LegalEntityID != "le_1" || dunningRuns[0].NoticesIssued != 1 {
+		t.Errorf("expected one le_1 run with one notice, got %+v", dunningRuns)
+	}
+}
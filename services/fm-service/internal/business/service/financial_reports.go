@@ -0,0 +1,568 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// ReportComparison names a comparative column of a financial report
+type ReportComparison string
+
+const (
+	ComparePriorPeriod ReportComparison = "PRIOR_PERIOD"
+	ComparePriorYear   ReportComparison = "PRIOR_YEAR"
+	CompareBudget      ReportComparison = "BUDGET"
+)
+
+// costCenterDimension is the tracking dimension naming the cost center a journal line was booked to
+const costCenterDimension = "cost_center_id"
+
+// ReportFilter narrows a financial report. From and To are inclusive posting dates; FromPeriod and
+// ToPeriod ("YYYY-MM") are an alternative that expands to whole months. Balance sheet and trial
+// balance are cumulative up to To, income statement and cash flow cover the activity within the range.
+type ReportFilter struct {
+	LegalEntityID string             `json:"legal_entity_id,omitempty"`
+	From          *time.Time         `json:"from,omitempty"`
+	To            *time.Time         `json:"to,omitempty"`
+	FromPeriod    string             `json:"from_period,omitempty"`
+	ToPeriod      string             `json:"to_period,omitempty"`
+	CostCenterID  string             `json:"cost_center_id,omitempty"`
+	SourceModule  string             `json:"source_module,omitempty"`
+	Compare       []ReportComparison `json:"compare,omitempty"`
+}
+
+// ReportLine is one account of a report. Amounts follow the statement sign: debit-normal for
+// assets and expenses, credit-normal otherwise.
+type ReportLine struct {
+	AccountID    string                               `json:"account_id"`
+	AccountCode  string                               `json:"account_code"`
+	AccountName  string                               `json:"account_name"`
+	Type         domain.AccountType                   `json:"type"`
+	Amount       decimal.Decimal                      `json:"amount"`
+	Comparatives map[ReportComparison]decimal.Decimal `json:"comparatives,omitempty"`
+}
+
+type ReportSection struct {
+	Lines        []ReportLine                         `json:"lines"`
+	Total        decimal.Decimal                      `json:"total"`
+	Comparatives map[ReportComparison]decimal.Decimal `json:"comparatives,omitempty"`
+}
+
+type TrialBalanceLine struct {
+	AccountID   string             `json:"account_id"`
+	AccountCode string             `json:"account_code"`
+	AccountName string             `json:"account_name"`
+	Type        domain.AccountType `json:"type"`
+	Debit       decimal.Decimal    `json:"debit"`
+	Credit      decimal.Decimal    `json:"credit"`
+}
+
+type TrialBalanceReport struct {
+	Filter       ReportFilter       `json:"filter"`
+	Lines        []TrialBalanceLine `json:"lines"`
+	TotalDebits  decimal.Decimal    `json:"total_debits"`
+	TotalCredits decimal.Decimal    `json:"total_credits"`
+}
+
+type BalanceSheetReport struct {
+	Filter      ReportFilter  `json:"filter"`
+	Assets      ReportSection `json:"assets"`
+	Liabilities ReportSection `json:"liabilities"`
+	Equity      ReportSection `json:"equity"`
+}
+
+type IncomeStatementReport struct {
+	Filter                ReportFilter                         `json:"filter"`
+	Revenues              ReportSection                        `json:"revenues"`
+	Expenses              ReportSection                        `json:"expenses"`
+	NetIncome             decimal.Decimal                      `json:"net_income"`
+	NetIncomeComparatives map[ReportComparison]decimal.Decimal `json:"net_income_comparatives,omitempty"`
+}
+
+// CashFlowReport lists the net change of every cash and bank account within the range
+type CashFlowReport struct {
+	Filter        ReportFilter    `json:"filter"`
+	Accounts      ReportSection   `json:"accounts"`
+	TotalInflows  decimal.Decimal `json:"total_inflows"`
+	TotalOutflows decimal.Decimal `json:"total_outflows"`
+	NetCashFlow   decimal.Decimal `json:"net_cash_flow"`
+}
+
+// ReportDrillDownLine is a journal line behind a report line, with its entry header
+type ReportDrillDownLine struct {
+	JournalEntryID   string                      `json:"journal_entry_id"`
+	LegalEntityID    string                      `json:"legal_entity_id"`
+	SourceModule     string                      `json:"source_module"`
+	SourceDocumentID string                      `json:"source_document_id"`
+	PostingDate      time.Time                   `json:"posting_date"`
+	FinancialPeriod  string                      `json:"financial_period"`
+	Status           domain.LedgerState          `json:"status"`
+	Line             domain.UniversalJournalLine `json:"line"`
+}
+
+type FinancialReportService struct {
+	accounts domain.ChartOfAccountsRepository
+	entries  domain.UniversalJournalEntryRepository
+	budgets  domain.BudgetRepository
+}
+
+func NewFinancialReportService(
+	accounts domain.ChartOfAccountsRepository,
+	entries domain.UniversalJournalEntryRepository,
+	budgets domain.BudgetRepository,
+) *FinancialReportService {
+	return &FinancialReportService{
+		accounts: accounts,
+		entries:  entries,
+		budgets:  budgets,
+	}
+}
+
+// GetTrialBalance reports the cumulative balance of every active account up to filter.To.
+// Comparisons are not applied to the trial balance.
+func (s *FinancialReportService) GetTrialBalance(ctx context.Context, filter ReportFilter) (*TrialBalanceReport, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	accs, err := s.reportAccounts(ctx, filter.LegalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.balances(ctx, filter, nil, filter.To, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TrialBalanceReport{Filter: filter}
+	for _, a := range accs {
+		bal := balances[a.ID]
+		if bal.IsZero() {
+			continue
+		}
+		line := TrialBalanceLine{AccountID: a.ID, AccountCode: a.AccountCode, AccountName: a.AccountName, Type: a.Type}
+		if bal.IsPositive() {
+			line.Debit = bal
+			report.TotalDebits = report.TotalDebits.Add(bal)
+		} else {
+			line.Credit = bal.Neg()
+			report.TotalCredits = report.TotalCredits.Add(line.Credit)
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	return report, nil
+}
+
+// GetBalanceSheet reports asset, liability and equity balances as of filter.To
+func (s *FinancialReportService) GetBalanceSheet(ctx context.Context, filter ReportFilter) (*BalanceSheetReport, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	accs, err := s.reportAccounts(ctx, filter.LegalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	current, comparatives, err := s.columns(ctx, filter, accs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BalanceSheetReport{
+		Filter:      filter,
+		Assets:      buildSection(accs, domain.AccountTypeASSET, current, comparatives),
+		Liabilities: buildSection(accs, domain.AccountTypeLIABILITY, current, comparatives),
+		Equity:      buildSection(accs, domain.AccountTypeEQUITY, current, comparatives),
+	}, nil
+}
+
+// GetIncomeStatement reports revenue and expense activity within the filter's range
+func (s *FinancialReportService) GetIncomeStatement(ctx context.Context, filter ReportFilter) (*IncomeStatementReport, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	accs, err := s.reportAccounts(ctx, filter.LegalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	current, comparatives, err := s.columns(ctx, filter, accs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IncomeStatementReport{
+		Filter:   filter,
+		Revenues: buildSection(accs, domain.AccountTypeREVENUE, current, comparatives),
+		Expenses: buildSection(accs, domain.AccountTypeEXPENSE, current, comparatives),
+	}
+	report.NetIncome = report.Revenues.Total.Sub(report.Expenses.Total)
+	if len(comparatives) > 0 {
+		report.NetIncomeComparatives = make(map[ReportComparison]decimal.Decimal, len(comparatives))
+		for c := range comparatives {
+			report.NetIncomeComparatives[c] = report.Revenues.Comparatives[c].Sub(report.Expenses.Comparatives[c])
+		}
+	}
+	return report, nil
+}
+
+// GetCashFlow reports the net change of cash and bank accounts within the filter's range
+func (s *FinancialReportService) GetCashFlow(ctx context.Context, filter ReportFilter) (*CashFlowReport, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	accs, err := s.reportAccounts(ctx, filter.LegalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	var cash []domain.ChartOfAccounts
+	for _, a := range accs {
+		nameLower := strings.ToLower(a.AccountName)
+		if a.Type == domain.AccountTypeASSET && (strings.Contains(nameLower, "cash") || strings.Contains(nameLower, "bank")) {
+			cash = append(cash, a)
+		}
+	}
+	current, comparatives, err := s.columns(ctx, filter, cash, false)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CashFlowReport{Filter: filter, Accounts: buildSection(cash, domain.AccountTypeASSET, current, comparatives)}
+	for _, line := range report.Accounts.Lines {
+		if line.Amount.IsPositive() {
+			report.TotalInflows = report.TotalInflows.Add(line.Amount)
+		} else {
+			report.TotalOutflows = report.TotalOutflows.Add(line.Amount.Abs())
+		}
+	}
+	report.NetCashFlow = report.Accounts.Total
+	return report, nil
+}
+
+// DrillDown lists the posted journal lines of an account that match the filter, i.e. the
+// records behind a report line. For balance sheet lines leave From empty to get the full balance.
+// Revenue and expense lines leave out year-end closing entries, as the income statement does.
+func (s *FinancialReportService) DrillDown(ctx context.Context, accountID string, filter ReportFilter) ([]ReportDrillDownLine, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	acc, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: account %s not found", domain.ErrInvalidReportRequest, accountID)
+	}
+	withClosing := acc.Type != domain.AccountTypeREVENUE && acc.Type != domain.AccountTypeEXPENSE
+
+	result := []ReportDrillDownLine{}
+	err = s.eachLine(ctx, filter, filter.From, filter.To, withClosing, func(entry domain.UniversalJournalEntry, line domain.UniversalJournalLine) {
+		if line.AccountID != accountID {
+			return
+		}
+		result = append(result, ReportDrillDownLine{
+			JournalEntryID:   entry.ID,
+			LegalEntityID:    entry.LegalEntityID,
+			SourceModule:     entry.SourceModule,
+			SourceDocumentID: entry.SourceDocumentID,
+			PostingDate:      entry.PostingDate,
+			FinancialPeriod:  entry.FinancialPeriod,
+			Status:           entry.Status,
+			Line:             line,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].PostingDate.Equal(result[j].PostingDate) {
+			return result[i].PostingDate.Before(result[j].PostingDate)
+		}
+		return result[i].JournalEntryID < result[j].JournalEntryID
+	})
+	return result, nil
+}
+
+// columns computes the raw ledger amount per account for the filter's range and for each
+// requested comparison. asOf reports are cumulative, so only the end of each range applies;
+// activity reports leave out year-end closing entries, which would zero the year's result.
+func (s *FinancialReportService) columns(ctx context.Context, filter ReportFilter, accs []domain.ChartOfAccounts, asOf bool) (map[string]decimal.Decimal, map[ReportComparison]map[string]decimal.Decimal, error) {
+	from := filter.From
+	if asOf {
+		from = nil
+	}
+	current, err := s.balances(ctx, filter, from, filter.To, asOf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comparatives := make(map[ReportComparison]map[string]decimal.Decimal, len(filter.Compare))
+	for _, c := range filter.Compare {
+		var values map[string]decimal.Decimal
+		if c == CompareBudget {
+			values, err = s.budgetAmounts(ctx, filter, accs, from, filter.To)
+		} else {
+			cFrom, cTo := filter.comparisonRange(c)
+			if asOf {
+				cFrom = nil
+			}
+			values, err = s.balances(ctx, filter, cFrom, cTo, asOf)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		comparatives[c] = values
+	}
+	return current, comparatives, nil
+}
+
+// balances sums the functional amounts of posted lines per account within [from, to]
+func (s *FinancialReportService) balances(ctx context.Context, filter ReportFilter, from, to *time.Time, withClosing bool) (map[string]decimal.Decimal, error) {
+	balances := make(map[string]decimal.Decimal)
+	err := s.eachLine(ctx, filter, from, to, withClosing, func(_ domain.UniversalJournalEntry, line domain.UniversalJournalLine) {
+		balances[line.AccountID] = balances[line.AccountID].Add(line.AmountFunctional)
+	})
+	return balances, err
+}
+
+func (s *FinancialReportService) eachLine(ctx context.Context, filter ReportFilter, from, to *time.Time, withClosing bool, fn func(domain.UniversalJournalEntry, domain.UniversalJournalLine)) error {
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED {
+			continue
+		}
+		if (filter.LegalEntityID != "" && entry.LegalEntityID != filter.LegalEntityID) ||
+			(filter.SourceModule != "" && entry.SourceModule != filter.SourceModule) ||
+			!inReportRange(entry.PostingDate, from, to) || (!withClosing && isYearEndClosing(entry)) {
+			continue
+		}
+		_, lines, err := s.entries.GetByID(ctx, entry.ID)
+		if err != nil {
+			return err
+		}
+		for _, l := range lines {
+			if filter.CostCenterID != "" && dimensionValue(l.TrackingDimensions, costCenterDimension) != filter.CostCenterID {
+				continue
+			}
+			fn(entry, l)
+		}
+	}
+	return nil
+}
+
+// budgetAmounts returns the allocated budget of the months within [from, to] per account, in
+// the raw ledger sign so it compares like any other column.
+func (s *FinancialReportService) budgetAmounts(ctx context.Context, filter ReportFilter, accs []domain.ChartOfAccounts, from, to *time.Time) (map[string]decimal.Decimal, error) {
+	budgets, err := s.budgets.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]domain.AccountType, len(accs))
+	for _, a := range accs {
+		types[a.ID] = a.Type
+	}
+
+	var monthFrom *time.Time
+	if from != nil {
+		m := firstOfMonth(*from)
+		monthFrom = &m
+	}
+	// The revised allocation of a budget line supersedes its original; forecasts are not budget
+	revised := make(map[string]bool)
+	for _, b := range budgets {
+		if b.Version == domain.BudgetVersionREVISED {
+			revised[budgetKey(b)] = true
+		}
+	}
+	amounts := make(map[string]decimal.Decimal)
+	for _, b := range budgets {
+		accType, ok := types[b.AccountID]
+		if !ok || b.Version == domain.BudgetVersionFORECAST {
+			continue
+		}
+		if b.Version != domain.BudgetVersionREVISED && revised[budgetKey(b)] {
+			continue
+		}
+		if filter.CostCenterID != "" && (b.CostCenterID == nil || *b.CostCenterID != filter.CostCenterID) {
+			continue
+		}
+		if !inReportRange(time.Date(b.FiscalYear, time.Month(b.Period), 1, 0, 0, 0, 0, time.UTC), monthFrom, to) {
+			continue
+		}
+		amount := b.AllocatedAmount
+		if !isDebitNormal(accType) {
+			amount = amount.Neg()
+		}
+		amounts[b.AccountID] = amounts[b.AccountID].Add(amount)
+	}
+	return amounts, nil
+}
+
+func (s *FinancialReportService) reportAccounts(ctx context.Context, legalEntityID string) ([]domain.ChartOfAccounts, error) {
+	accs, err := s.accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []domain.ChartOfAccounts
+	for _, a := range accs {
+		if a.IsActive && (legalEntityID == "" || a.LegalEntityID == legalEntityID) {
+			result = append(result, a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AccountCode != result[j].AccountCode {
+			return result[i].AccountCode < result[j].AccountCode
+		}
+		return result[i].LegalEntityID < result[j].LegalEntityID
+	})
+	return result, nil
+}
+
+// buildSection turns raw ledger amounts of one account type into statement lines. Accounts
+// without any amount in any column are left out.
+func buildSection(accs []domain.ChartOfAccounts, accType domain.AccountType, current map[string]decimal.Decimal, comparatives map[ReportComparison]map[string]decimal.Decimal) ReportSection {
+	section := ReportSection{Lines: []ReportLine{}}
+	if len(comparatives) > 0 {
+		section.Comparatives = make(map[ReportComparison]decimal.Decimal, len(comparatives))
+		for c := range comparatives {
+			section.Comparatives[c] = decimal.Zero
+		}
+	}
+	sign := decimal.NewFromInt(1)
+	if !isDebitNormal(accType) {
+		sign = sign.Neg()
+	}
+
+	for _, a := range accs {
+		if a.Type != accType {
+			continue
+		}
+		line := ReportLine{AccountID: a.ID, AccountCode: a.AccountCode, AccountName: a.AccountName, Type: a.Type,
+			Amount: current[a.ID].Mul(sign)}
+		empty := line.Amount.IsZero()
+		for c, values := range comparatives {
+			if line.Comparatives == nil {
+				line.Comparatives = make(map[ReportComparison]decimal.Decimal, len(comparatives))
+			}
+			amount := values[a.ID].Mul(sign)
+			line.Comparatives[c] = amount
+			section.Comparatives[c] = section.Comparatives[c].Add(amount)
+			empty = empty && amount.IsZero()
+		}
+		if empty {
+			continue
+		}
+		section.Lines = append(section.Lines, line)
+		section.Total = section.Total.Add(line.Amount)
+	}
+	return section
+}
+
+// normalize expands periods into dates and validates the filter
+func (f *ReportFilter) normalize() error {
+	if f.FromPeriod != "" {
+		if f.From != nil {
+			return fmt.Errorf("%w: from and from_period are mutually exclusive", domain.ErrInvalidReportRequest)
+		}
+		start, err := time.Parse("2006-01", f.FromPeriod)
+		if err != nil {
+			return fmt.Errorf("%w: invalid period %q, expected YYYY-MM", domain.ErrInvalidReportRequest, f.FromPeriod)
+		}
+		f.From = &start
+	}
+	if f.ToPeriod != "" {
+		if f.To != nil {
+			return fmt.Errorf("%w: to and to_period are mutually exclusive", domain.ErrInvalidReportRequest)
+		}
+		start, err := time.Parse("2006-01", f.ToPeriod)
+		if err != nil {
+			return fmt.Errorf("%w: invalid period %q, expected YYYY-MM", domain.ErrInvalidReportRequest, f.ToPeriod)
+		}
+		end := start.AddDate(0, 1, -1)
+		f.To = &end
+	}
+	if f.From != nil && f.To != nil && f.From.After(*f.To) {
+		return fmt.Errorf("%w: from is after to", domain.ErrInvalidReportRequest)
+	}
+	for _, c := range f.Compare {
+		switch c {
+		case ComparePriorPeriod:
+			if f.From == nil || f.To == nil {
+				return fmt.Errorf("%w: %s comparison needs a closed date range", domain.ErrInvalidReportRequest, c)
+			}
+		case ComparePriorYear, CompareBudget:
+		default:
+			return fmt.Errorf("%w: unknown comparison %q", domain.ErrInvalidReportRequest, c)
+		}
+	}
+	return nil
+}
+
+// comparisonRange shifts the filter's range to the prior period or prior year. Month-aligned
+// ranges shift by whole months, anything else by its length in days.
+func (f ReportFilter) comparisonRange(c ReportComparison) (*time.Time, *time.Time) {
+	switch c {
+	case ComparePriorYear:
+		var from, to *time.Time
+		if f.From != nil {
+			t := shiftMonths(*f.From, -12)
+			from = &t
+		}
+		if f.To != nil {
+			t := shiftMonths(*f.To, -12)
+			to = &t
+		}
+		return from, to
+	case ComparePriorPeriod:
+		start, end := *f.From, *f.To
+		to := start.AddDate(0, 0, -1)
+		if start.Day() == 1 && end.AddDate(0, 0, 1).Day() == 1 {
+			months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+			from := start.AddDate(0, -months, 0)
+			return &from, &to
+		}
+		days := int(end.Sub(start).Hours()/24) + 1
+		from := start.AddDate(0, 0, -days)
+		return &from, &to
+	}
+	return f.From, f.To
+}
+
+// shiftMonths moves t by n months, keeping month-end dates at the end of the target month
+func shiftMonths(t time.Time, n int) time.Time {
+	if t.AddDate(0, 0, 1).Day() == 1 {
+		return firstOfMonth(t).AddDate(0, n+1, -1)
+	}
+	return t.AddDate(0, n, 0)
+}
+
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// inReportRange reports whether d falls on or between the from and to dates; nil bounds are open
+func inReportRange(d time.Time, from, to *time.Time) bool {
+	if from != nil && d.Before(*from) {
+		return false
+	}
+	return to == nil || d.Before(to.AddDate(0, 0, 1))
+}
+
+func isDebitNormal(t domain.AccountType) bool {
+	return t == domain.AccountTypeASSET || t == domain.AccountTypeEXPENSE
+}
+
+// dimensionValue returns a tracking dimension of a journal line, if set
+func dimensionValue(dims interface{}, key string) string {
+	switch d := dims.(type) {
+	case map[string]interface{}:
+		value, _ := d[key].(string)
+		return value
+	case map[string]string:
+		return d[key]
+	}
+	return ""
+}
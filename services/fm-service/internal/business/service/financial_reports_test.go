@@ -0,0 +1,286 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func postReportEntry(t *testing.T, gl *service.GeneralLedgerService, le, sourceModule string, date time.Time, debit, credit *domain.ChartOfAccounts, amount int64, costCenter string) *domain.UniversalJournalEntry {
+	t.Helper()
+	var dims interface{}
+	if costCenter != "" {
+		dims = map[string]interface{}{"cost_center_id": costCenter}
+	}
+	entry, err := gl.CreateJournalEntry(context.Background(), le, sourceModule, "doc", date, []domain.UniversalJournalLine{
+		{AccountID: debit.ID, AmountFunctional: decimal.NewFromInt(amount), TrackingDimensions: dims},
+		{AccountID: credit.ID, AmountFunctional: decimal.NewFromInt(-amount), TrackingDimensions: dims},
+	})
+	if err != nil {
+		t.Fatalf("failed to post: %v", err)
+	}
+	return entry
+}
+
+func dateRef(t time.Time) *time.Time {
+	return &t
+}
+
+func TestFinancialReports_Filters(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewFinancialReportService(accounts, entries, budgets)
+	ctx := context.Background()
+
+	bank, err := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	revenue, _ := gl.CreateAccount(ctx, "le_1", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	expense, _ := gl.CreateAccount(ctx, "le_1", "6000-001", "Marketing", string(domain.AccountTypeEXPENSE))
+	_, _ = gl.CreateAccount(ctx, "le_2", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+
+	postReportEntry(t, gl, "le_1", "AR", day(2025, 1, 10), bank, revenue, 1000, "")
+	postReportEntry(t, gl, "le_1", "AR", day(2025, 2, 10), bank, revenue, 500, "")
+	postReportEntry(t, gl, "le_1", "AP", day(2025, 2, 20), expense, bank, 300, "cc_sales")
+	postReportEntry(t, gl, "le_1", "AP", day(2025, 3, 5), expense, bank, 200, "cc_it")
+	le2Revenue, _ := gl.GetAccountByCode(ctx, "le_2", "4000-001")
+	le2Bank, _ := gl.CreateAccount(ctx, "le_2", "1010-001", "Bank", string(domain.AccountTypeASSET))
+	postReportEntry(t, gl, "le_2", "AR", day(2025, 2, 1), le2Bank, le2Revenue, 700, "")
+
+	all, err := svc.GetIncomeStatement(ctx, service.ReportFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "all revenue", all.Revenues.Total, 2200)
+	assertAmount(t, "all net income", all.NetIncome, 1700)
+
+	le1, _ := svc.GetIncomeStatement(ctx, service.ReportFilter{LegalEntityID: "le_1"})
+	assertAmount(t, "le_1 revenue", le1.Revenues.Total, 1500)
+	if len(le1.Revenues.Lines) != 1 || le1.Revenues.Lines[0].AccountID != revenue.ID {
+		t.Errorf("expected only le_1 sales line, got %+v", le1.Revenues.Lines)
+	}
+
+	feb, _ := svc.GetIncomeStatement(ctx, service.ReportFilter{LegalEntityID: "le_1", FromPeriod: "2025-02", ToPeriod: "2025-02"})
+	assertAmount(t, "february revenue", feb.Revenues.Total, 500)
+	assertAmount(t, "february expense", feb.Expenses.Total, 300)
+
+	dates, _ := svc.GetIncomeStatement(ctx, service.ReportFilter{LegalEntityID: "le_1", From: dateRef(day(2025, 2, 15)), To: dateRef(day(2025, 3, 5))})
+	assertAmount(t, "date range expense", dates.Expenses.Total, 500)
+	assertAmount(t, "date range revenue", dates.Revenues.Total, 0)
+
+	cc, _ := svc.GetIncomeStatement(ctx, service.ReportFilter{CostCenterID: "cc_it"})
+	assertAmount(t, "cost center expense", cc.Expenses.Total, 200)
+	assertAmount(t, "cost center revenue", cc.Revenues.Total, 0)
+
+	ap, _ := svc.GetCashFlow(ctx, service.ReportFilter{LegalEntityID: "le_1", SourceModule: "AP"})
+	assertAmount(t, "ap outflows", ap.TotalOutflows, 500)
+	assertAmount(t, "ap net cash flow", ap.NetCashFlow, -500)
+
+	// The balance sheet is cumulative up to the end of the range
+	bs, _ := svc.GetBalanceSheet(ctx, service.ReportFilter{LegalEntityID: "le_1", FromPeriod: "2025-02", ToPeriod: "2025-02"})
+	assertAmount(t, "bank at end of february", bs.Assets.Total, 1200)
+
+	tb, _ := svc.GetTrialBalance(ctx, service.ReportFilter{LegalEntityID: "le_1", ToPeriod: "2025-02"})
+	assertAmount(t, "tb debits", tb.TotalDebits, 1500)
+	assertAmount(t, "tb credits", tb.TotalCredits, 1500)
+
+	for _, filter := range []service.ReportFilter{
+		{FromPeriod: "2025-13"},
+		{From: dateRef(day(2025, 3, 1)), To: dateRef(day(2025, 2, 1))},
+		{From: dateRef(day(2025, 1, 1)), FromPeriod: "2025-01"},
+		{Compare: []service.ReportComparison{"LAST_WEEK"}},
+		{ToPeriod: "2025-02", Compare: []service.ReportComparison{service.ComparePriorPeriod}},
+	} {
+		if _, err := svc.GetIncomeStatement(ctx, filter); !errors.Is(err, domain.ErrInvalidReportRequest) {
+			t.Errorf("expected invalid report request for %+v, got %v", filter, err)
+		}
+	}
+}
+
+func TestFinancialReports_Comparatives(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewFinancialReportService(accounts, entries, budgets)
+	ctx := context.Background()
+
+	bank, err := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	revenue, _ := gl.CreateAccount(ctx, "le_1", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	expense, _ := gl.CreateAccount(ctx, "le_1", "6000-001", "Marketing", string(domain.AccountTypeEXPENSE))
+	_, _ = gl.CreateAccount(ctx, "le_2", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+
+	postReportEntry(t, gl, "le_1", "AR", day(2024, 3, 10), bank, revenue, 800, "")
+	postReportEntry(t, gl, "le_1", "AR", day(2025, 2, 10), bank, revenue, 900, "")
+	postReportEntry(t, gl, "le_1", "AR", day(2025, 3, 10), bank, revenue, 1000, "")
+	postReportEntry(t, gl, "le_1", "AP", day(2025, 3, 20), expense, bank, 400, "cc_sales")
+	costCenter := "cc_sales"
+	_ = budgets.Create(ctx, &domain.Budget{ID: "b1", AccountID: revenue.ID, FiscalYear: 2025, Period: 3, AllocatedAmount: decimal.NewFromInt(1200)})
+	_ = budgets.Create(ctx, &domain.Budget{ID: "b2", AccountID: expense.ID, CostCenterID: &costCenter, FiscalYear: 2025, Period: 3, AllocatedAmount: decimal.NewFromInt(350)})
+	_ = budgets.Create(ctx, &domain.Budget{ID: "b3", AccountID: expense.ID, FiscalYear: 2025, Period: 4, AllocatedAmount: decimal.NewFromInt(999)})
+
+	report, err := svc.GetIncomeStatement(ctx, service.ReportFilter{
+		LegalEntityID: "le_1",
+		FromPeriod:    "2025-03",
+		ToPeriod:      "2025-03",
+		Compare:       []service.ReportComparison{service.ComparePriorPeriod, service.ComparePriorYear, service.CompareBudget},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "revenue", report.Revenues.Total, 1000)
+	assertAmount(t, "prior period revenue", report.Revenues.Comparatives[service.ComparePriorPeriod], 900)
+	assertAmount(t, "prior year revenue", report.Revenues.Comparatives[service.ComparePriorYear], 800)
+	assertAmount(t, "budget revenue", report.Revenues.Comparatives[service.CompareBudget], 1200)
+	assertAmount(t, "budget expense", report.Expenses.Comparatives[service.CompareBudget], 350)
+	assertAmount(t, "net income", report.NetIncome, 600)
+	assertAmount(t, "prior period net income", report.NetIncomeComparatives[service.ComparePriorPeriod], 900)
+	assertAmount(t, "budget net income", report.NetIncomeComparatives[service.CompareBudget], 850)
+	line := report.Revenues.Lines[0]
+	assertAmount(t, "line prior year", line.Comparatives[service.ComparePriorYear], 800)
+
+	// Balance sheet comparatives are as of the end of the shifted range
+	bs, _ := svc.GetBalanceSheet(ctx, service.ReportFilter{
+		LegalEntityID: "le_1",
+		FromPeriod:    "2025-03",
+		ToPeriod:      "2025-03",
+		Compare:       []service.ReportComparison{service.ComparePriorPeriod, service.ComparePriorYear},
+	})
+	assertAmount(t, "bank", bs.Assets.Total, 2300)
+	assertAmount(t, "bank prior period", bs.Assets.Comparatives[service.ComparePriorPeriod], 1700)
+	assertAmount(t, "bank prior year", bs.Assets.Comparatives[service.ComparePriorYear], 800)
+
+	// Budgets follow the cost center filter
+	cc, _ := svc.GetIncomeStatement(ctx, service.ReportFilter{
+		CostCenterID: "cc_sales",
+		FromPeriod:   "2025-01",
+		ToPeriod:     "2025-06",
+		Compare:      []service.ReportComparison{service.CompareBudget},
+	})
+	assertAmount(t, "cost center expense", cc.Expenses.Total, 400)
+	assertAmount(t, "cost center budget", cc.Expenses.Comparatives[service.CompareBudget], 350)
+	assertAmount(t, "cost center revenue budget", cc.Revenues.Comparatives[service.CompareBudget], 0)
+
+	// Day ranges shift by their length
+	days, _ := svc.GetIncomeStatement(ctx, service.ReportFilter{
+		From:    dateRef(day(2025, 3, 5)),
+		To:      dateRef(day(2025, 3, 14)),
+		Compare: []service.ReportComparison{service.ComparePriorPeriod},
+	})
+	assertAmount(t, "ten day revenue", days.Revenues.Total, 1000)
+	assertAmount(t, "prior ten days revenue", days.Revenues.Comparatives[service.ComparePriorPeriod], 0)
+}
+
+func TestFinancialReports_DrillDown(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewFinancialReportService(accounts, entries, budgets)
+	ctx := context.Background()
+
+	bank, err := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	revenue, _ := gl.CreateAccount(ctx, "le_1", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	expense, _ := gl.CreateAccount(ctx, "le_1", "6000-001", "Marketing", string(domain.AccountTypeEXPENSE))
+	_, _ = gl.CreateAccount(ctx, "le_2", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+
+	jan := postReportEntry(t, gl, "le_1", "AR", day(2025, 1, 10), bank, revenue, 1000, "")
+	feb := postReportEntry(t, gl, "le_1", "AP", day(2025, 2, 20), expense, bank, 300, "cc_sales")
+
+	lines, err := svc.DrillDown(ctx, bank.ID, service.ReportFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 || lines[0].JournalEntryID != jan.ID || lines[1].JournalEntryID != feb.ID {
+		t.Fatalf("expected both bank lines in posting order, got %+v", lines)
+	}
+	assertAmount(t, "credit line", lines[1].Line.AmountFunctional, -300)
+	if lines[1].SourceModule != "AP" || lines[1].FinancialPeriod != "2025-02" {
+		t.Errorf("expected entry header on drill-down line, got %+v", lines[1])
+	}
+
+	// The drill-down adds up to the report line it was opened from
+	filter := service.ReportFilter{FromPeriod: "2025-02", ToPeriod: "2025-02", CostCenterID: "cc_sales"}
+	report, _ := svc.GetIncomeStatement(ctx, filter)
+	lines, _ = svc.DrillDown(ctx, report.Expenses.Lines[0].AccountID, filter)
+	if len(lines) != 1 || !lines[0].Line.AmountFunctional.Equal(report.Expenses.Lines[0].Amount) {
+		t.Errorf("expected drill-down to match report line, got %+v", lines)
+	}
+
+	if _, err := svc.DrillDown(ctx, "acc_missing", service.ReportFilter{}); !errors.Is(err, domain.ErrInvalidReportRequest) {
+		t.Errorf("expected invalid report request, got %v", err)
+	}
+}
+
+func TestFinancialReports_YearEndClosingEntry(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	budgets := memory.NewMemoryBudgetRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewFinancialReportService(accounts, entries, budgets)
+	ctx := context.Background()
+
+	bank, err := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	revenue, _ := gl.CreateAccount(ctx, "le_1", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	expense, _ := gl.CreateAccount(ctx, "le_1", "6000-001", "Marketing", string(domain.AccountTypeEXPENSE))
+	_, _ = gl.CreateAccount(ctx, "le_2", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	retained, _ := gl.CreateAccount(ctx, "le_1", "3200-001", "Retained Earnings", string(domain.AccountTypeEQUITY))
+
+	postReportEntry(t, gl, "le_1", "AR", day(2025, 6, 10), bank, revenue, 1000, "")
+	postReportEntry(t, gl, "le_1", "AP", day(2025, 7, 20), expense, bank, 300, "")
+	closing, err := gl.CreateJournalEntry(ctx, "le_1", "FM", "YEC-2025", day(2025, 12, 31), []domain.UniversalJournalLine{
+		{AccountID: revenue.ID, AmountFunctional: decimal.NewFromInt(1000)},
+		{AccountID: expense.ID, AmountFunctional: decimal.NewFromInt(-300)},
+		{AccountID: retained.ID, AmountFunctional: decimal.NewFromInt(-700)},
+	})
+	if err != nil {
+		t.Fatalf("failed to post closing entry: %v", err)
+	}
+
+	// The closing entry moves the result to retained earnings; it is not the year's activity
+	filter := service.ReportFilter{LegalEntityID: "le_1", FromPeriod: "2025-01", ToPeriod: "2025-12"}
+	is, err := svc.GetIncomeStatement(ctx, filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "revenue after close", is.Revenues.Total, 1000)
+	assertAmount(t, "net income after close", is.NetIncome, 700)
+	lines, _ := svc.DrillDown(ctx, revenue.ID, filter)
+	if len(lines) != 1 || lines[0].JournalEntryID == closing.ID {
+		t.Errorf("expected the revenue drill-down without the closing entry, got %+v", lines)
+	}
+
+	bs, _ := svc.GetBalanceSheet(ctx, filter)
+	assertAmount(t, "retained earnings", bs.Equity.Total, 700)
+	tb, _ := svc.GetTrialBalance(ctx, filter)
+	assertAmount(t, "post-closing debits", tb.TotalDebits, 700)
+	if len(tb.Lines) != 2 {
+		t.Errorf("expected only bank and retained earnings after close, got %+v", tb.Lines)
+	}
+}
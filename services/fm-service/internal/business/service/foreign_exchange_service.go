@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// FX postings adjust the AR, AP or bank control account by the gain or loss and post the offset
+// to the (un)realized FX gain or loss account, all resolved through account determination.
+const fxRevaluationDocument = "FXREV-"
+
+// FxRevaluationRun is the result of one period-end revaluation of a legal entity
+type FxRevaluationRun struct {
+	LegalEntityID   string                 `json:"legal_entity_id"`
+	FinancialPeriod string                 `json:"financial_period"`
+	RevaluationDate time.Time              `json:"revaluation_date"`
+	JournalEntryID  *string                `json:"journal_entry_id,omitempty"`
+	NetGainLoss     decimal.Decimal        `json:"net_gain_loss"`
+	Items           []domain.FxRevaluation `json:"items"`
+}
+
+type ForeignExchangeService struct {
+	fx           *CurrencyConverter
+	invoices     domain.ArInvoiceRepository
+	bills        domain.ApVendorBillRepository
+	bankAccounts domain.BankAccountRepository
+	revaluations domain.FxRevaluationRepository
+	gl           *GeneralLedgerService
+	outbox       domain.TransactionalOutboxRepository
+	tm           domain.TransactionManager
+}
+
+func NewForeignExchangeService(
+	fx *CurrencyConverter,
+	invoices domain.ArInvoiceRepository,
+	bills domain.ApVendorBillRepository,
+	bankAccounts domain.BankAccountRepository,
+	revaluations domain.FxRevaluationRepository,
+	gl *GeneralLedgerService,
+	outbox domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
+) *ForeignExchangeService {
+	return &ForeignExchangeService{
+		fx:           fx,
+		invoices:     invoices,
+		bills:        bills,
+		bankAccounts: bankAccounts,
+		revaluations: revaluations,
+		gl:           gl,
+		outbox:       outbox,
+		tm:           tm,
+	}
+}
+
+func (s *ForeignExchangeService) ListRevaluations(ctx context.Context, legalEntityID string) ([]domain.FxRevaluation, error) {
+	return s.revaluations.ListByLegalEntity(ctx, legalEntityID)
+}
+
+// RunRevaluation revalues the open foreign-currency invoices, bills and bank balances of a legal
+// entity at the rates effective on revaluationDate. The difference to the carrying value (the last
+// revaluation rate, else the booking rate; see bankCarryingValue for banks) is posted as
+// unrealized FX gain or loss in a single journal entry. Each period can only be revalued once.
+func (s *ForeignExchangeService) RunRevaluation(ctx context.Context, legalEntityID string, revaluationDate time.Time) (*FxRevaluationRun, error) {
+	functional, err := s.fx.FunctionalCurrency(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	period := revaluationDate.Format("2006-01")
+
+	previous, err := s.revaluations.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	carrying := make(map[string]decimal.Decimal)
+	for _, r := range previous {
+		if r.FinancialPeriod == period {
+			return nil, fmt.Errorf("%w: %s %s", domain.ErrFxRevaluationAlreadyRun, legalEntityID, period)
+		}
+		// ListByLegalEntity is ordered by date, so the latest run wins
+		carrying[revaluationKey(r.SourceType, r.SourceID)] = r.RevaluationRate
+	}
+
+	run := &FxRevaluationRun{
+		LegalEntityID:   legalEntityID,
+		FinancialPeriod: period,
+		RevaluationDate: revaluationDate,
+		NetGainLoss:     decimal.Zero,
+	}
+	controlTotals := make(map[domain.FxRevaluationSource]decimal.Decimal)
+	// Bank differences post to the ledger account of each bank; "" is the CASH_AT_BANK account
+	bankTotals := make(map[string]decimal.Decimal)
+	gains, losses := decimal.Zero, decimal.Zero
+
+	// record books the difference between the value of open at rate and its carried functional value
+	record := func(sourceType domain.FxRevaluationSource, sourceID, currency string, open, prev, carried, rate decimal.Decimal) domain.FxRevaluation {
+		delta := convertAmount(open, rate).Sub(carried)
+		gain := delta
+		if sourceType == domain.FxRevaluationSourceAP_BILL {
+			// A stronger foreign currency increases the liability
+			gain = delta.Neg()
+		}
+		run.Items = append(run.Items, domain.FxRevaluation{
+			ID:                 utils.NewID("fxr"),
+			LegalEntityID:      legalEntityID,
+			FinancialPeriod:    period,
+			RevaluationDate:    revaluationDate,
+			SourceType:         sourceType,
+			SourceID:           sourceID,
+			Currency:           currency,
+			OpenAmount:         open,
+			PreviousRate:       prev,
+			RevaluationRate:    rate,
+			UnrealizedGainLoss: gain,
+			CreatedAt:          time.Now(),
+		})
+		controlTotals[sourceType] = controlTotals[sourceType].Add(gain)
+		if gain.IsPositive() {
+			gains = gains.Add(gain)
+		} else {
+			losses = losses.Add(gain.Neg())
+		}
+		return run.Items[len(run.Items)-1]
+	}
+
+	revalue := func(sourceType domain.FxRevaluationSource, sourceID, currency string, open, bookingRate decimal.Decimal) error {
+		rate, err := s.fx.Rate(ctx, currency, functional, revaluationDate)
+		if err != nil {
+			return err
+		}
+		prev, ok := carrying[revaluationKey(sourceType, sourceID)]
+		if !ok {
+			prev = bookingRate
+		}
+		if !prev.IsPositive() {
+			prev = rate
+		}
+		record(sourceType, sourceID, currency, open, prev, convertAmount(open, prev), rate)
+		return nil
+	}
+
+	invoices, err := s.invoices.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range invoices {
+		open := inv.OpenAmount()
+		if inv.LegalEntityID != legalEntityID || !isForeign(inv.Currency, functional) || inv.Status == domain.PaymentStatusPAID || !open.IsPositive() {
+			continue
+		}
+		if err := revalue(domain.FxRevaluationSourceAR_INVOICE, inv.ID, inv.Currency, open, inv.ExchangeRate); err != nil {
+			return nil, err
+		}
+	}
+
+	bills, err := s.bills.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, bill := range bills {
+		open := bill.OpenAmount()
+		if bill.LegalEntityID != legalEntityID || !isForeign(bill.Currency, functional) || bill.Status == domain.PaymentStatusPAID || !open.IsPositive() {
+			continue
+		}
+		if err := revalue(domain.FxRevaluationSourceAP_BILL, bill.ID, bill.Currency, open, bill.ExchangeRate); err != nil {
+			return nil, err
+		}
+	}
+
+	accounts, err := s.bankAccounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ba := range accounts {
+		if ba.LegalEntityID != legalEntityID || !isForeign(ba.Currency, functional) || ba.LiquidBalance.IsZero() {
+			continue
+		}
+		rate, err := s.fx.Rate(ctx, ba.Currency, functional, revaluationDate)
+		if err != nil {
+			return nil, err
+		}
+		prev, carried, err := s.bankCarryingValue(ctx, ba, carrying, rate, revaluationDate)
+		if err != nil {
+			return nil, err
+		}
+		item := record(domain.FxRevaluationSourceBANK_ACCOUNT, ba.ID, ba.Currency, ba.LiquidBalance, prev, carried, rate)
+		glAccountID := ""
+		if ba.GlAccountID != nil {
+			glAccountID = *ba.GlAccountID
+		}
+		bankTotals[glAccountID] = bankTotals[glAccountID].Add(item.UnrealizedGainLoss)
+	}
+
+	run.NetGainLoss = gains.Sub(losses)
+	if len(run.Items) == 0 {
+		return run, nil
+	}
+
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var lines []domain.UniversalJournalLine
+		add := func(key domain.PostingKey, amount decimal.Decimal) error {
+			if amount.IsZero() {
+				return nil
+			}
+			acc, err := s.gl.DetermineAccount(txCtx, legalEntityID, key)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, domain.UniversalJournalLine{AccountID: acc.ID, AmountFunctional: amount, CurrencyTransactional: functional})
+			return nil
+		}
+		if err := add(domain.PostingKeyAR_CONTROL, controlTotals[domain.FxRevaluationSourceAR_INVOICE]); err != nil {
+			return err
+		}
+		if err := add(domain.PostingKeyAP_CONTROL, controlTotals[domain.FxRevaluationSourceAP_BILL]); err != nil {
+			return err
+		}
+		bankLedgers := make([]string, 0, len(bankTotals))
+		for glAccountID := range bankTotals {
+			bankLedgers = append(bankLedgers, glAccountID)
+		}
+		sort.Strings(bankLedgers)
+		for _, glAccountID := range bankLedgers {
+			amount := bankTotals[glAccountID]
+			if glAccountID == "" {
+				if err := add(domain.PostingKeyCASH_AT_BANK, amount); err != nil {
+					return err
+				}
+				continue
+			}
+			if amount.IsZero() {
+				continue
+			}
+			acc, err := s.gl.GetAccount(txCtx, glAccountID)
+			if err != nil {
+				return fmt.Errorf("bank ledger account %s: %w", glAccountID, err)
+			}
+			lines = append(lines, domain.UniversalJournalLine{AccountID: acc.ID, AmountFunctional: amount, CurrencyTransactional: functional})
+		}
+		if err := add(domain.PostingKeyFX_UNREALIZED_GAIN, gains.Neg()); err != nil {
+			return err
+		}
+		if err := add(domain.PostingKeyFX_UNREALIZED_LOSS, losses); err != nil {
+			return err
+		}
+
+		var entryID string
+		if len(lines) > 0 {
+			entry, err := s.gl.CreateJournalEntry(txCtx, legalEntityID, "FM", fxRevaluationDocument+period, revaluationDate, lines)
+			if err != nil {
+				return err
+			}
+			entryID = entry.ID
+			run.JournalEntryID = &entryID
+			for i := range run.Items {
+				run.Items[i].JournalEntryID = &entryID
+			}
+		}
+
+		if err := s.revaluations.CreateMany(txCtx, run.Items); err != nil {
+			return err
+		}
+
+		outboxRec := &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   string(domain.TopicFmFxRevaluationPosted),
+			AggregateID: legalEntityID,
+			Payload: domain.FxRevaluationPostedEventPayload{
+				LegalEntityID:   legalEntityID,
+				FinancialPeriod: period,
+				JournalEntryID:  entryID,
+				NetGainLoss:     run.NetGainLoss,
+				ItemCount:       len(run.Items),
+				Timestamp:       time.Now(),
+			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: time.Now(),
+		}
+		return s.outbox.Create(txCtx, outboxRec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// SettlePayment stamps a payment against a foreign-currency invoice or bill with the settlement
+// rate and posts the realized FX gain or loss against the carrying rate of the document.
+// It is meant to run inside the caller's transaction, before the payment is stored.
+func (s *ForeignExchangeService) SettlePayment(ctx context.Context, payment *domain.Payment) error {
+	var (
+		legalEntityID string
+		currency      string
+		carryingRate  decimal.Decimal
+		sourceType    domain.FxRevaluationSource
+		sourceID      string
+	)
+	switch {
+	case payment.InvoiceID != nil:
+		inv, err := s.invoices.GetByID(ctx, *payment.InvoiceID)
+		if err != nil {
+			return err
+		}
+		legalEntityID, currency, carryingRate = inv.LegalEntityID, inv.Currency, inv.ExchangeRate
+		sourceType, sourceID = domain.FxRevaluationSourceAR_INVOICE, inv.ID
+	case payment.BillID != nil:
+		bill, err := s.bills.GetByID(ctx, *payment.BillID)
+		if err != nil {
+			return err
+		}
+		legalEntityID, currency, carryingRate = bill.LegalEntityID, bill.Currency, bill.ExchangeRate
+		sourceType, sourceID = domain.FxRevaluationSourceAP_BILL, bill.ID
+	default:
+		return nil
+	}
+
+	payment.Currency = currency
+	payment.ExchangeRate = decimal.NewFromInt(1)
+	functional, err := s.fx.FunctionalCurrency(ctx, legalEntityID)
+	if err != nil || !isForeign(currency, functional) {
+		// Functional-currency documents settle without FX differences
+		return nil
+	}
+
+	rate, err := s.fx.Rate(ctx, currency, functional, payment.PaymentDate)
+	if err != nil {
+		return err
+	}
+	payment.ExchangeRate = rate
+
+	previous, err := s.revaluations.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return err
+	}
+	for _, r := range previous {
+		if r.SourceType == sourceType && r.SourceID == sourceID {
+			carryingRate = r.RevaluationRate
+		}
+	}
+	if !carryingRate.IsPositive() {
+		return nil
+	}
+
+	realized := convertAmount(payment.Amount, rate).Sub(convertAmount(payment.Amount, carryingRate))
+	if sourceType == domain.FxRevaluationSourceAP_BILL {
+		realized = realized.Neg()
+	}
+	payment.RealizedFxGainLoss = realized
+	if realized.IsZero() || s.gl == nil {
+		return nil
+	}
+
+	controlKey := domain.PostingKeyAR_CONTROL
+	if sourceType == domain.FxRevaluationSourceAP_BILL {
+		controlKey = domain.PostingKeyAP_CONTROL
+	}
+	control, err := s.gl.DetermineAccount(ctx, legalEntityID, controlKey)
+	if err != nil {
+		return err
+	}
+	offsetKey := domain.PostingKeyFX_REALIZED_GAIN
+	if realized.IsNegative() {
+		offsetKey = domain.PostingKeyFX_REALIZED_LOSS
+	}
+	offset, err := s.gl.DetermineAccount(ctx, legalEntityID, offsetKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.gl.CreateJournalEntry(ctx, legalEntityID, "FM", payment.PaymentNumber, payment.PaymentDate, []domain.UniversalJournalLine{
+		{AccountID: control.ID, AmountFunctional: realized, CurrencyTransactional: functional},
+		{AccountID: offset.ID, AmountFunctional: realized.Neg(), CurrencyTransactional: functional},
+	})
+	return err
+}
+
+// bankCarryingValue returns the rate and functional value a bank balance is carried at. A bank
+// with its own ledger account is carried at that account's balance, which holds every deposit at
+// its booking rate and every earlier revaluation. Otherwise the balance is carried at the last
+// revaluation rate; a first revaluation only sets that rate as the baseline.
+func (s *ForeignExchangeService) bankCarryingValue(ctx context.Context, ba domain.BankAccount, carrying map[string]decimal.Decimal, rate decimal.Decimal, revaluationDate time.Time) (decimal.Decimal, decimal.Decimal, error) {
+	if ba.GlAccountID != nil {
+		carried, err := s.gl.GetAccountBalanceAsOf(ctx, *ba.GlAccountID, revaluationDate)
+		if err != nil {
+			return decimal.Zero, decimal.Zero, err
+		}
+		// A ledger account without postings does not carry the balance yet
+		if !carried.IsZero() {
+			return carried.Div(ba.LiquidBalance).Round(6), carried, nil
+		}
+	}
+	prev, ok := carrying[revaluationKey(domain.FxRevaluationSourceBANK_ACCOUNT, ba.ID)]
+	if !ok || !prev.IsPositive() {
+		prev = rate
+	}
+	return prev, convertAmount(ba.LiquidBalance, prev), nil
+}
+
+func revaluationKey(sourceType domain.FxRevaluationSource, sourceID string) string {
+	return string(sourceType) + "/" + sourceID
+}
+
+func isForeign(currency, functional string) bool {
+	return currency != "" && functional != "" && currency != functional
+}
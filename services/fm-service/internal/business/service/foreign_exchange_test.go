@@ -0,0 +1,298 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+// testConverter returns a converter without legal entities or rates; postings then keep the
+// functional amounts they were given.
+func testConverter() *service.CurrencyConverter {
+	return service.NewCurrencyConverter(memory.NewMemoryLegalEntityRepo(), memory.NewMemoryCurrencyRateRepo())
+}
+
+func accountByCode(t *testing.T, accounts *memory.MemoryChartOfAccountsRepo, code string) *domain.ChartOfAccounts {
+	t.Helper()
+	acc, err := accounts.GetByCode(context.Background(), "le_us", code)
+	if err != nil {
+		t.Fatalf("account %s not found: %v", code, err)
+	}
+	return acc
+}
+
+func TestGeneralLedger_MultiCurrencyPosting(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), service.NewCurrencyConverter(legalEntities, rates), outbox, tm)
+	ctx := context.Background()
+
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	for i, r := range []domain.CurrencyRate{
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: day(2025, 1, 1)},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.20"), EffectiveDate: day(2025, 3, 31)},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.25"), EffectiveDate: day(2025, 6, 1)},
+		{FromCurrency: "USD", ToCurrency: "CHF", Rate: decimal.RequireFromString("0.80"), EffectiveDate: day(2025, 1, 1)},
+	} {
+		r := r
+		r.ID = string(rune('a' + i))
+		_ = rates.Create(ctx, &r)
+	}
+
+	cash, _ := gl.CreateAccount(ctx, "le_us", "1000", "Cash", "ASSET")
+	revenue, _ := gl.CreateAccount(ctx, "le_us", "4000", "Revenue", "REVENUE")
+
+	// EUR lines are converted at the rate effective on the posting date
+	entry, err := gl.CreateJournalEntry(ctx, "le_us", "FM", "DOC-1", day(2025, 2, 15), []domain.UniversalJournalLine{
+		{AccountID: cash.ID, AmountTransactional: decimal.NewFromInt(100), CurrencyTransactional: "EUR"},
+		{AccountID: revenue.ID, AmountTransactional: decimal.NewFromInt(-100), CurrencyTransactional: "EUR"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, lines, _ := gl.GetJournalEntry(ctx, entry.ID)
+	if !lines[0].AmountFunctional.Equal(decimal.NewFromInt(110)) || !lines[0].ExchangeRate.Equal(decimal.RequireFromString("1.1")) {
+		t.Errorf("expected 110 USD at 1.1, got %s at %s", lines[0].AmountFunctional, lines[0].ExchangeRate)
+	}
+
+	// Only the inverse USD/CHF rate exists; functional-only lines default to the functional currency
+	if _, err := gl.CreateJournalEntry(ctx, "le_us", "FM", "DOC-2", day(2025, 2, 15), []domain.UniversalJournalLine{
+		{AccountID: cash.ID, AmountTransactional: decimal.NewFromInt(80), CurrencyTransactional: "CHF"},
+		{AccountID: revenue.ID, AmountFunctional: decimal.NewFromInt(-100)},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	balance, _ := gl.GetAccountBalance(ctx, cash.ID)
+	assertAmount(t, "functional cash balance", balance, 210)
+	byCurrency, err := gl.GetAccountBalancesByCurrency(ctx, cash.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "EUR cash balance", byCurrency["EUR"], 100)
+	assertAmount(t, "CHF cash balance", byCurrency["CHF"], 80)
+	revenueByCurrency, _ := gl.GetAccountBalancesByCurrency(ctx, revenue.ID)
+	assertAmount(t, "USD revenue balance", revenueByCurrency["USD"], 100)
+
+	_, err = gl.CreateJournalEntry(ctx, "le_us", "FM", "DOC-3", day(2025, 2, 15), []domain.UniversalJournalLine{
+		{AccountID: cash.ID, AmountTransactional: decimal.NewFromInt(50), CurrencyTransactional: "GBP"},
+		{AccountID: revenue.ID, AmountTransactional: decimal.NewFromInt(-50), CurrencyTransactional: "GBP"},
+	})
+	if !errors.Is(err, domain.ErrExchangeRateNotFound) {
+		t.Errorf("expected missing rate error, got %v", err)
+	}
+}
+
+func TestForeignExchange_RevaluationAndSettlement(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	revaluations := memory.NewMemoryFxRevaluationRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, bills, payments, revaluations, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, invoices, bills, bankAccounts, revaluations, gl, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     invoices,
+		Bills:        bills,
+		Allocations:  memory.NewMemoryPaymentAllocationRepo(),
+		BankAccounts: bankAccounts,
+		GL:           gl,
+		FX:           fx,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	ctx := context.Background()
+
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	for i, r := range []domain.CurrencyRate{
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: day(2025, 1, 1)},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.20"), EffectiveDate: day(2025, 3, 31)},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.25"), EffectiveDate: day(2025, 6, 1)},
+		{FromCurrency: "USD", ToCurrency: "CHF", Rate: decimal.RequireFromString("0.80"), EffectiveDate: day(2025, 1, 1)},
+	} {
+		r := r
+		r.ID = string(rune('a' + i))
+		_ = rates.Create(ctx, &r)
+	}
+
+	booked := decimal.RequireFromString("1.10")
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_eur", LegalEntityID: "le_us", Currency: "EUR", ExchangeRate: booked,
+		TotalAmount: decimal.NewFromInt(1000), Status: domain.PaymentStatusOPEN})
+	_ = invoices.Create(ctx, &domain.ArInvoice{ID: "inv_usd", LegalEntityID: "le_us", Currency: "USD", ExchangeRate: decimal.NewFromInt(1),
+		TotalAmount: decimal.NewFromInt(700), Status: domain.PaymentStatusOPEN})
+	_ = bills.Create(ctx, &domain.ApVendorBill{ID: "bill_eur", LegalEntityID: "le_us", Currency: "EUR", ExchangeRate: booked,
+		TotalAmount: decimal.NewFromInt(500), Status: domain.PaymentStatusOPEN})
+	// The bank's ledger account carries the EUR deposit at its booking rate of 1.10
+	bankLedger, _ := gl.CreateAccount(ctx, "le_us", "1020-001", "Bank EUR", "ASSET")
+	equity, _ := gl.CreateAccount(ctx, "le_us", "3000-001", "Opening Balances", "EQUITY")
+	if _, err := gl.CreateJournalEntry(ctx, "le_us", "FM", "OPEN-EUR", day(2025, 1, 5), []domain.UniversalJournalLine{
+		{AccountID: bankLedger.ID, AmountTransactional: decimal.NewFromInt(2000), CurrencyTransactional: "EUR"},
+		{AccountID: equity.ID, AmountTransactional: decimal.NewFromInt(-2000), CurrencyTransactional: "EUR"},
+	}); err != nil {
+		t.Fatalf("failed to book the opening deposit: %v", err)
+	}
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_us", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(2000), GlAccountID: &bankLedger.ID, CreatedAt: day(2025, 1, 5)})
+
+	run, err := fx.RunRevaluation(ctx, "le_us", day(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.FinancialPeriod != "2025-03" || len(run.Items) != 3 || run.JournalEntryID == nil {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	// AR +100, AP -50, bank +200 at 1.10 -> 1.20
+	assertAmount(t, "net unrealized gain", run.NetGainLoss, 250)
+	ar, _ := gl.GetAccountBalance(ctx, accountByCode(t, accounts, "1100-001").ID)
+	assertAmount(t, "AR control adjustment", ar, 100)
+	ap, _ := gl.GetAccountBalance(ctx, accountByCode(t, accounts, "2110-001").ID)
+	assertAmount(t, "AP control adjustment", ap, 50)
+	gain, _ := gl.GetAccountBalance(ctx, accountByCode(t, accounts, "7910-001").ID)
+	assertAmount(t, "unrealized gain", gain, 300)
+	loss, _ := gl.GetAccountBalance(ctx, accountByCode(t, accounts, "8910-001").ID)
+	assertAmount(t, "unrealized loss", loss, 50)
+	bank, _ := gl.GetAccountBalance(ctx, bankLedger.ID)
+	assertAmount(t, "bank ledger revalued", bank, 2400)
+	if _, err := accounts.GetByCode(ctx, "le_us", "1010-001"); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Errorf("expected no posting to the default cash at bank account, got %v", err)
+	}
+
+	if _, err := fx.RunRevaluation(ctx, "le_us", day(2025, 3, 31)); !errors.Is(err, domain.ErrFxRevaluationAlreadyRun) {
+		t.Errorf("expected already run error, got %v", err)
+	}
+	pending, _ := outbox.GetPending(ctx, 100)
+	found := false
+	for _, p := range pending {
+		if p.EventType == string(domain.TopicFmFxRevaluationPosted) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected fx revaluation posted event in outbox")
+	}
+
+	// Settled at 1.25 against the revalued 1.20: AR gains, AP loses
+	pay, err := cash.RecordPayment(ctx, "inv_eur", "", "ba_eur", decimal.NewFromInt(1000), "WIRE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pay.Currency != "EUR" || !pay.ExchangeRate.Equal(decimal.RequireFromString("1.25")) {
+		t.Errorf("expected EUR settlement at 1.25, got %s at %s", pay.Currency, pay.ExchangeRate)
+	}
+	assertAmount(t, "realized gain on invoice", pay.RealizedFxGainLoss, 50)
+
+	billPay, err := cash.RecordPayment(ctx, "", "bill_eur", "ba_eur", decimal.NewFromInt(500), "WIRE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "realized loss on bill", billPay.RealizedFxGainLoss, -25)
+	realizedGain, _ := gl.GetAccountBalance(ctx, accountByCode(t, accounts, "7920-001").ID)
+	assertAmount(t, "realized gain account", realizedGain, 50)
+	realizedLoss, _ := gl.GetAccountBalance(ctx, accountByCode(t, accounts, "8920-001").ID)
+	assertAmount(t, "realized loss account", realizedLoss, 25)
+
+	usdPay, _ := cash.RecordPayment(ctx, "inv_usd", "", "", decimal.NewFromInt(700), "WIRE")
+	if !usdPay.RealizedFxGainLoss.IsZero() || !usdPay.ExchangeRate.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("expected no FX on functional-currency payment, got %+v", usdPay)
+	}
+}
+
+func TestForeignExchange_BankRevaluationWithoutLedgerAccount(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	revaluations := memory.NewMemoryFxRevaluationRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, revaluations, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, memory.NewMemoryArInvoiceRepo(), memory.NewMemoryApVendorBillRepo(), bankAccounts, revaluations, gl, outbox, tm)
+	ctx := context.Background()
+
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", CompanyCode: "US", FunctionalCurrency: "USD"})
+	for i, r := range []domain.CurrencyRate{
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.10"), EffectiveDate: day(2025, 1, 1)},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.20"), EffectiveDate: day(2025, 3, 31)},
+		{FromCurrency: "EUR", ToCurrency: "USD", Rate: decimal.RequireFromString("1.25"), EffectiveDate: day(2025, 6, 1)},
+		{FromCurrency: "USD", ToCurrency: "CHF", Rate: decimal.RequireFromString("0.80"), EffectiveDate: day(2025, 1, 1)},
+	} {
+		r := r
+		r.ID = string(rune('a' + i))
+		_ = rates.Create(ctx, &r)
+	}
+
+	cashAtBank, _ := gl.CreateAccount(ctx, "le_us", "1020-100", "Banks - Foreign Currency", "ASSET")
+	if _, err := gl.SetAccountDetermination(ctx, "le_us", domain.PostingKeyCASH_AT_BANK, "1020-100"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_us", Currency: "EUR",
+		LiquidBalance: decimal.NewFromInt(2000), CreatedAt: day(2025, 1, 5)})
+
+	// Nothing carries the balance yet: the first run sets the baseline rate without a posting
+	first, err := fx.RunRevaluation(ctx, "le_us", day(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Items) != 1 || first.JournalEntryID != nil || !first.Items[0].PreviousRate.Equal(decimal.RequireFromString("1.20")) {
+		t.Fatalf("expected an unposted baseline at 1.20, got %+v", first)
+	}
+	assertAmount(t, "baseline gain", first.NetGainLoss, 0)
+
+	// The next run revalues from the baseline rate to the configured cash at bank account
+	second, err := fx.RunRevaluation(ctx, "le_us", day(2025, 6, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "gain 1.20 -> 1.25", second.NetGainLoss, 100)
+	bank, _ := gl.GetAccountBalance(ctx, cashAtBank.ID)
+	assertAmount(t, "cash at bank adjustment", bank, 100)
+}
+
+func TestAccountsReceivable_CreateInvoiceInForeignCurrency(t *testing.T) {
+	ctx := context.Background()
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_us", FunctionalCurrency: "USD"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "GBP", ToCurrency: "USD", Rate: decimal.RequireFromString("1.30"), EffectiveDate: day(2020, 1, 1)})
+
+	invoices := memory.NewMemoryArInvoiceRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	svc := service.NewAccountsReceivableService(invoices, memory.NewMemoryCustomerCreditRepo(), memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), service.NewCurrencyConverter(legalEntities, rates), nil,
+		outbox, memory.NewMemoryTransactionManager(invoices, outbox))
+
+	inv, err := svc.CreateInvoice(ctx, "le_us", "cust_1", "so_1", "GBP", decimal.NewFromInt(100), decimal.Zero, day(2030, 1, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.Currency != "GBP" || !inv.ExchangeRate.Equal(decimal.RequireFromString("1.3")) {
+		t.Errorf("expected GBP booked at 1.3, got %s at %s", inv.Currency, inv.ExchangeRate)
+	}
+
+	local, _ := svc.CreateInvoice(ctx, "le_us", "cust_1", "so_2", "", decimal.NewFromInt(100), decimal.Zero, day(2030, 1, 1))
+	if local.Currency != "USD" || !local.ExchangeRate.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("expected functional currency invoice, got %s at %s", local.Currency, local.ExchangeRate)
+	}
+
+	if _, err := svc.CreateInvoice(ctx, "le_us", "cust_1", "so_3", "JPY", decimal.NewFromInt(100), decimal.Zero, day(2030, 1, 1)); !errors.Is(err, domain.ErrExchangeRateNotFound) {
+		t.Errorf("expected missing rate error, got %v", err)
+	}
+}
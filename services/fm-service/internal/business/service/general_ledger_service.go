@@ -5,7 +5,6 @@ import (
 	"erp-system/shared/utils"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
@@ -13,27 +12,42 @@ import (
 )
 
 type GeneralLedgerService struct {
-	accounts domain.ChartOfAccountsRepository
-	entries  domain.UniversalJournalEntryRepository
-	outbox   domain.TransactionalOutboxRepository
-	tm       domain.TransactionManager
+	accounts       domain.ChartOfAccountsRepository
+	determinations domain.AccountDeterminationRepository
+	entries        domain.UniversalJournalEntryRepository
+	periods        domain.FiscalPeriodRepository
+	fx             *CurrencyConverter
+	outbox         domain.TransactionalOutboxRepository
+	tm             domain.TransactionManager
 }
 
 func NewGeneralLedgerService(
 	accounts domain.ChartOfAccountsRepository,
+	determinations domain.AccountDeterminationRepository,
 	entries domain.UniversalJournalEntryRepository,
+	periods domain.FiscalPeriodRepository,
+	fx *CurrencyConverter,
 	outbox domain.TransactionalOutboxRepository,
 	tm domain.TransactionManager,
 ) *GeneralLedgerService {
 	return &GeneralLedgerService{
-		accounts: accounts,
-		entries:  entries,
-		outbox:   outbox,
-		tm:       tm,
+		accounts:       accounts,
+		determinations: determinations,
+		entries:        entries,
+		periods:        periods,
+		fx:             fx,
+		outbox:         outbox,
+		tm:             tm,
 	}
 }
 
 func (s *GeneralLedgerService) calculateAccountBalance(ctx context.Context, accountID string) (decimal.Decimal, error) {
+	return s.GetAccountBalanceAsOf(ctx, accountID, time.Time{})
+}
+
+// GetAccountBalanceAsOf returns the functional balance of an account from the entries posted up
+// to asOf; a zero asOf includes every entry.
+func (s *GeneralLedgerService) GetAccountBalanceAsOf(ctx context.Context, accountID string, asOf time.Time) (decimal.Decimal, error) {
 	entries, err := s.entries.List(ctx)
 	if err != nil {
 		return decimal.Zero, err
@@ -44,6 +58,9 @@ func (s *GeneralLedgerService) calculateAccountBalance(ctx context.Context, acco
 		if entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED {
 			continue
 		}
+		if !asOf.IsZero() && entry.PostingDate.After(asOf) {
+			continue
+		}
 		_, lines, err := s.entries.GetByID(ctx, entry.ID)
 		if err != nil {
 			return decimal.Zero, err
@@ -199,9 +216,26 @@ func (s *GeneralLedgerService) ListJournalEntries(ctx context.Context) ([]domain
 }
 
 func (s *GeneralLedgerService) CreateJournalEntry(ctx context.Context, legalEntityID, sourceModule, sourceDocID string, postingDate time.Time, lines []domain.UniversalJournalLine) (*domain.UniversalJournalEntry, error) {
+	return s.PostJournalEntry(ctx, &domain.UniversalJournalEntry{
+		LegalEntityID:    legalEntityID,
+		SourceModule:     sourceModule,
+		SourceDocumentID: sourceDocID,
+		PostingDate:      postingDate,
+	}, lines)
+}
+
+// PostJournalEntry posts an entry whose header the caller has filled in. Template and reversal
+// links set on the header are kept; ID, period, status and timestamps are assigned here.
+func (s *GeneralLedgerService) PostJournalEntry(ctx context.Context, entry *domain.UniversalJournalEntry, lines []domain.UniversalJournalLine) (*domain.UniversalJournalEntry, error) {
 	if len(lines) < 2 {
 		return nil, errors.New("a journal entry must have at least 2 lines")
 	}
+	if err := s.ensurePeriodOpen(ctx, entry.LegalEntityID, entry.SourceModule, entry.PostingDate.Format("2006-01")); err != nil {
+		return nil, err
+	}
+	if err := s.applyExchangeRates(ctx, entry.LegalEntityID, entry.PostingDate, lines); err != nil {
+		return nil, err
+	}
 
 	sum := decimal.Zero
 	for _, l := range lines {
@@ -212,17 +246,11 @@ func (s *GeneralLedgerService) CreateJournalEntry(ctx context.Context, legalEnti
 	}
 
 	id := utils.NewID("je")
-	entry := &domain.UniversalJournalEntry{
-		ID:               id,
-		LegalEntityID:    legalEntityID,
-		SourceModule:     sourceModule,
-		SourceDocumentID: sourceDocID,
-		PostingDate:      postingDate,
-		FinancialPeriod:  postingDate.Format("2006-01"),
-		Status:           domain.LedgerStatePOSTED,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
-	}
+	entry.ID = id
+	entry.FinancialPeriod = entry.PostingDate.Format("2006-01")
+	entry.Status = domain.LedgerStatePOSTED
+	entry.CreatedAt = time.Now()
+	entry.UpdatedAt = time.Now()
 
 	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
 		for i := range lines {
@@ -268,11 +296,135 @@ func (s *GeneralLedgerService) CreateJournalEntry(ctx context.Context, legalEnti
 	return entry, nil
 }
 
+// applyExchangeRates fills in the currency side of each line. Lines that carry only a transactional
+// amount are converted at the CurrencyRate effective on the posting date; lines in the functional
+// currency get a rate of 1. Rounding differences from conversion are absorbed by the largest
+// converted line so the entry still balances.
+func (s *GeneralLedgerService) applyExchangeRates(ctx context.Context, legalEntityID string, postingDate time.Time, lines []domain.UniversalJournalLine) error {
+	needsConversion := false
+	for _, l := range lines {
+		if l.AmountFunctional.IsZero() && !l.AmountTransactional.IsZero() {
+			needsConversion = true
+			break
+		}
+	}
+	functional, err := s.fx.FunctionalCurrency(ctx, legalEntityID)
+	if err != nil {
+		if needsConversion {
+			return err
+		}
+		// Without a known functional currency the functional amounts are taken as posted
+		functional = ""
+	}
+
+	converted := -1
+	for i := range lines {
+		l := &lines[i]
+		if l.CurrencyTransactional == "" {
+			l.CurrencyTransactional = functional
+		}
+		switch {
+		case l.AmountFunctional.IsZero() && !l.AmountTransactional.IsZero():
+			rate, err := s.fx.Rate(ctx, l.CurrencyTransactional, functional, postingDate)
+			if err != nil {
+				return err
+			}
+			l.ExchangeRate = rate
+			l.AmountFunctional = convertAmount(l.AmountTransactional, rate)
+			if converted < 0 || l.AmountFunctional.Abs().GreaterThan(lines[converted].AmountFunctional.Abs()) {
+				converted = i
+			}
+		case l.AmountTransactional.IsZero() && l.CurrencyTransactional == functional:
+			l.AmountTransactional = l.AmountFunctional
+			l.ExchangeRate = decimal.NewFromInt(1)
+		case !l.AmountTransactional.IsZero() && l.ExchangeRate.IsZero():
+			l.ExchangeRate = l.AmountFunctional.DivRound(l.AmountTransactional, exchangeRatePlaces).Abs()
+		}
+	}
+
+	if converted >= 0 {
+		sum := decimal.Zero
+		for _, l := range lines {
+			sum = sum.Add(l.AmountFunctional)
+		}
+		tolerance := decimal.New(1, -functionalAmountPlaces).Mul(decimal.NewFromInt(int64(len(lines))))
+		if !sum.IsZero() && sum.Abs().LessThanOrEqual(tolerance) {
+			lines[converted].AmountFunctional = lines[converted].AmountFunctional.Sub(sum)
+		}
+	}
+	return nil
+}
+
+// GetAccountBalancesByCurrency returns the account's posted balance per transaction currency.
+func (s *GeneralLedgerService) GetAccountBalancesByCurrency(ctx context.Context, id string) (map[string]decimal.Decimal, error) {
+	acc, err := s.accounts.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]decimal.Decimal)
+	for _, entry := range entries {
+		if entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED {
+			continue
+		}
+		_, lines, err := s.entries.GetByID(ctx, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			if line.AccountID == acc.ID && line.CurrencyTransactional != "" {
+				balances[line.CurrencyTransactional] = balances[line.CurrencyTransactional].Add(line.AmountTransactional)
+			}
+		}
+	}
+	if acc.Type == domain.AccountTypeLIABILITY || acc.Type == domain.AccountTypeEQUITY || acc.Type == domain.AccountTypeREVENUE {
+		for cur, bal := range balances {
+			balances[cur] = bal.Neg()
+		}
+	}
+	return balances, nil
+}
+
+// EnsureAccount returns the legal entity's account with the given code, creating it if missing.
+// Lookup failures other than a missing account are returned rather than masked by a create.
+func (s *GeneralLedgerService) EnsureAccount(ctx context.Context, legalEntityID, code, name string, accType domain.AccountType) (*domain.ChartOfAccounts, error) {
+	acc, err := s.accounts.GetByCode(ctx, legalEntityID, code)
+	if err == nil {
+		return acc, nil
+	}
+	if !errors.Is(err, domain.ErrAccountNotFound) {
+		return nil, err
+	}
+	acc = &domain.ChartOfAccounts{
+		ID:            utils.NewID("acc"),
+		LegalEntityID: legalEntityID,
+		AccountCode:   code,
+		AccountName:   name,
+		Type:          accType,
+		IsActive:      true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.accounts.Create(ctx, acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
 func (s *GeneralLedgerService) GetJournalEntry(ctx context.Context, id string) (*domain.UniversalJournalEntry, []domain.UniversalJournalLine, error) {
 	return s.entries.GetByID(ctx, id)
 }
 
 func (s *GeneralLedgerService) ReverseJournalEntry(ctx context.Context, id string) (*domain.UniversalJournalEntry, error) {
+	return s.reverseJournalEntry(ctx, id, time.Now())
+}
+
+// reverseJournalEntry posts the mirror image of an entry on postingDate and marks the original
+// REVERSED. The reversing entry points back to the original and keeps its template link.
+func (s *GeneralLedgerService) reverseJournalEntry(ctx context.Context, id string, postingDate time.Time) (*domain.UniversalJournalEntry, error) {
 	var revEntry *domain.UniversalJournalEntry
 	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
 		entry, lines, err := s.entries.GetByID(txCtx, id)
@@ -291,11 +443,21 @@ func (s *GeneralLedgerService) ReverseJournalEntry(ctx context.Context, id strin
 				AmountFunctional:      l.AmountFunctional.Neg(),
 				AmountTransactional:   l.AmountTransactional.Neg(),
 				CurrencyTransactional: l.CurrencyTransactional,
+				ExchangeRate:          l.ExchangeRate,
 				TrackingDimensions:    l.TrackingDimensions,
 			}
 		}
 
-		revEntry, err = s.CreateJournalEntry(txCtx, entry.LegalEntityID, entry.SourceModule, entry.SourceDocumentID, time.Now(), revLines)
+		// The reversal posts into the period of postingDate, which has to be open
+		originalID := entry.ID
+		revEntry, err = s.PostJournalEntry(txCtx, &domain.UniversalJournalEntry{
+			LegalEntityID:    entry.LegalEntityID,
+			SourceModule:     entry.SourceModule,
+			SourceDocumentID: entry.SourceDocumentID,
+			PostingDate:      postingDate,
+			TemplateID:       entry.TemplateID,
+			ReversalOfID:     &originalID,
+		}, revLines)
 		if err != nil {
 			return fmt.Errorf("failed to create reversing entry: %w", err)
 		}
@@ -316,98 +478,16 @@ func (s *GeneralLedgerService) ReverseJournalEntry(ctx context.Context, id strin
 	return revEntry, nil
 }
 
-func (s *GeneralLedgerService) GetTrialBalance(ctx context.Context) (map[string]interface{}, error) {
-	accs, err := s.accounts.List(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	var totalDebits, totalCredits decimal.Decimal
-	balances := make(map[string]map[string]decimal.Decimal)
-
-	for _, a := range accs {
-		if !a.IsActive {
-			continue
-		}
-		rawBal, err := s.calculateAccountBalance(ctx, a.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		isDebitType := a.Type == domain.AccountTypeASSET || a.Type == domain.AccountTypeEXPENSE
-		if isDebitType {
-			totalDebits = totalDebits.Add(rawBal)
-			if _, ok := balances[a.AccountName]; !ok {
-				balances[a.AccountName] = make(map[string]decimal.Decimal)
-			}
-			balances[a.AccountName]["debit"] = rawBal
-		} else {
-			reportedBal := rawBal.Neg()
-			totalCredits = totalCredits.Add(reportedBal)
-			if _, ok := balances[a.AccountName]; !ok {
-				balances[a.AccountName] = make(map[string]decimal.Decimal)
-			}
-			balances[a.AccountName]["credit"] = reportedBal
-		}
-	}
-
-	return map[string]interface{}{
-		"balances":      balances,
-		"total_debits":  totalDebits,
-		"total_credits": totalCredits,
-	}, nil
-}
-
-func (s *GeneralLedgerService) GetBalanceSheet(ctx context.Context) (map[string]interface{}, error) {
-	accs, err := s.accounts.List(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	assets := make(map[string]decimal.Decimal)
-	liabilities := make(map[string]decimal.Decimal)
-	equity := make(map[string]decimal.Decimal)
-
-	var totalAssets, totalLiabilities, totalEquity decimal.Decimal
-
-	for _, a := range accs {
-		if !a.IsActive {
-			continue
-		}
-		rawBal, err := s.calculateAccountBalance(ctx, a.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		switch a.Type {
-		case domain.AccountTypeASSET:
-			assets[a.AccountName] = rawBal
-			totalAssets = totalAssets.Add(rawBal)
-		case domain.AccountTypeLIABILITY:
-			reportedBal := rawBal.Neg()
-			liabilities[a.AccountName] = reportedBal
-			totalLiabilities = totalLiabilities.Add(reportedBal)
-		case domain.AccountTypeEQUITY:
-			reportedBal := rawBal.Neg()
-			equity[a.AccountName] = reportedBal
-			totalEquity = totalEquity.Add(reportedBal)
-		}
-	}
-
-	return map[string]interface{}{
-		"assets":            assets,
-		"total_assets":      totalAssets,
-		"liabilities":       liabilities,
-		"total_liabilities": totalLiabilities,
-		"equity":            equity,
-		"total_equity":      totalEquity,
-	}, nil
-}
-
 func (s *GeneralLedgerService) UpdateJournalEntry(ctx context.Context, id string, legalEntityID, sourceModule, sourceDocID string, postingDate time.Time, lines []domain.UniversalJournalLine) (*domain.UniversalJournalEntry, error) {
 	if len(lines) < 2 {
 		return nil, errors.New("a journal entry must have at least 2 lines")
 	}
+	if err := s.ensurePeriodOpen(ctx, legalEntityID, sourceModule, postingDate.Format("2006-01")); err != nil {
+		return nil, err
+	}
+	if err := s.applyExchangeRates(ctx, legalEntityID, postingDate, lines); err != nil {
+		return nil, err
+	}
 
 	sum := decimal.Zero
 	for _, l := range lines {
@@ -428,6 +508,10 @@ func (s *GeneralLedgerService) UpdateJournalEntry(ctx context.Context, id string
 		if entry.Status != domain.LedgerStateDRAFT {
 			return domain.ErrJournalEntryNotMutable
 		}
+		// The entry must not be moved out of a closed period either
+		if err := s.ensurePeriodOpen(txCtx, entry.LegalEntityID, entry.SourceModule, entry.FinancialPeriod); err != nil {
+			return err
+		}
 
 		for i := range lines {
 			lines[i].ID = utils.NewID("jel")
@@ -482,91 +566,28 @@ func (s *GeneralLedgerService) UpdateJournalEntry(ctx context.Context, id string
 
 func (s *GeneralLedgerService) DeleteJournalEntry(ctx context.Context, id string) error {
 	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		entry, _, err := s.entries.GetByID(txCtx, id)
+		if err == nil {
+			if err := s.ensurePeriodOpen(txCtx, entry.LegalEntityID, entry.SourceModule, entry.FinancialPeriod); err != nil {
+				return err
+			}
+		}
 		return s.entries.Delete(txCtx, id)
 	})
 }
 
-func (s *GeneralLedgerService) GetIncomeStatement(ctx context.Context) (map[string]interface{}, error) {
-	accs, err := s.accounts.List(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	revenues := make(map[string]decimal.Decimal)
-	expenses := make(map[string]decimal.Decimal)
-
-	var totalRevenue, totalExpense decimal.Decimal
-
-	for _, a := range accs {
-		if !a.IsActive {
-			continue
-		}
-		rawBal, err := s.calculateAccountBalance(ctx, a.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		switch a.Type {
-		case domain.AccountTypeREVENUE:
-			reportedBal := rawBal.Neg()
-			revenues[a.AccountName] = reportedBal
-			totalRevenue = totalRevenue.Add(reportedBal)
-		case domain.AccountTypeEXPENSE:
-			expenses[a.AccountName] = rawBal
-			totalExpense = totalExpense.Add(rawBal)
-		}
-	}
-
-	netIncome := totalRevenue.Sub(totalExpense)
-
-	return map[string]interface{}{
-		"revenues":      revenues,
-		"total_revenue": totalRevenue,
-		"expenses":      expenses,
-		"total_expense": totalExpense,
-		"net_income":    netIncome,
-	}, nil
-}
-
-func (s *GeneralLedgerService) GetCashFlow(ctx context.Context) (map[string]interface{}, error) {
-	accs, err := s.accounts.List(ctx)
+// ensurePeriodOpen rejects postings into closed periods. Soft-closed periods only accept FM
+// adjustments; postings from other modules have to wait for the next open period.
+func (s *GeneralLedgerService) ensurePeriodOpen(ctx context.Context, legalEntityID, sourceModule, period string) error {
+	state, _, err := periodState(ctx, s.periods, legalEntityID, period)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	inflows := make(map[string]decimal.Decimal)
-	outflows := make(map[string]decimal.Decimal)
-
-	var totalInflow, totalOutflow decimal.Decimal
-
-	for _, a := range accs {
-		if !a.IsActive {
-			continue
-		}
-		nameLower := strings.ToLower(a.AccountName)
-		if a.Type == domain.AccountTypeASSET && (strings.Contains(nameLower, "cash") || strings.Contains(nameLower, "bank")) {
-			rawBal, err := s.calculateAccountBalance(ctx, a.ID)
-			if err != nil {
-				return nil, err
-			}
-			if rawBal.IsPositive() {
-				inflows[a.AccountName] = rawBal
-				totalInflow = totalInflow.Add(rawBal)
-			} else if rawBal.IsNegative() {
-				absBal := rawBal.Abs()
-				outflows[a.AccountName] = absBal
-				totalOutflow = totalOutflow.Add(absBal)
-			}
-		}
+	switch {
+	case state == domain.PeriodStateCLOSED:
+		return fmt.Errorf("%w: %s %s", domain.ErrPeriodClosed, legalEntityID, period)
+	case state == domain.PeriodStateSOFT_CLOSED && sourceModule != "FM":
+		return fmt.Errorf("%w: %s %s is soft-closed to %s postings", domain.ErrPeriodClosed, legalEntityID, period, sourceModule)
 	}
-
-	netCashFlow := totalInflow.Sub(totalOutflow)
-
-	return map[string]interface{}{
-		"operating_inflows":  inflows,
-		"total_inflows":      totalInflow,
-		"operating_outflows": outflows,
-		"total_outflows":     totalOutflow,
-		"net_cash_flow":      netCashFlow,
-	}, nil
+	return nil
 }
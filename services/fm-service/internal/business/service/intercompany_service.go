@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// GL accounts holding intercompany balances, and the tracking dimension naming the partner entity
+// of an intercompany line. Consolidation eliminates every line whose trading partner is in the group.
+const (
+	intercompanyReceivableAccount = "1150-001"
+	intercompanyPayableAccount    = "2150-001"
+	tradingPartnerDimension       = "trading_partner"
+)
+
+// IntercompanyRequest charges an amount from one legal entity to another. FromAccountID is the
+// offset of the due-from receivable in the charging entity (e.g. revenue), ToAccountID the offset
+// of the due-to payable in the charged entity (e.g. expense).
+type IntercompanyRequest struct {
+	FromLegalEntityID string
+	ToLegalEntityID   string
+	FromAccountID     string
+	ToAccountID       string
+	Currency          string
+	Amount            decimal.Decimal
+	PostingDate       time.Time
+	Description       string
+}
+
+type IntercompanyService struct {
+	transactions  domain.IntercompanyTransactionRepository
+	accounts      domain.ChartOfAccountsRepository
+	legalEntities domain.LegalEntityRepository
+	gl            *GeneralLedgerService
+	outbox        domain.TransactionalOutboxRepository
+	tm            domain.TransactionManager
+}
+
+func NewIntercompanyService(
+	transactions domain.IntercompanyTransactionRepository,
+	accounts domain.ChartOfAccountsRepository,
+	legalEntities domain.LegalEntityRepository,
+	gl *GeneralLedgerService,
+	outbox domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
+) *IntercompanyService {
+	return &IntercompanyService{
+		transactions:  transactions,
+		accounts:      accounts,
+		legalEntities: legalEntities,
+		gl:            gl,
+		outbox:        outbox,
+		tm:            tm,
+	}
+}
+
+func (s *IntercompanyService) ListTransactions(ctx context.Context, legalEntityID string) ([]domain.IntercompanyTransaction, error) {
+	return s.transactions.ListByLegalEntity(ctx, legalEntityID)
+}
+
+func (s *IntercompanyService) GetTransaction(ctx context.Context, id string) (*domain.IntercompanyTransaction, error) {
+	return s.transactions.GetByID(ctx, id)
+}
+
+// CreateTransaction posts mirrored journal entries in both legal entities: the charging entity
+// debits its due-from receivable, the charged entity credits its due-to payable. Every line is
+// tagged with the partner entity so consolidation can eliminate it.
+func (s *IntercompanyService) CreateTransaction(ctx context.Context, req IntercompanyRequest) (*domain.IntercompanyTransaction, error) {
+	if req.FromLegalEntityID == "" || req.ToLegalEntityID == "" || req.FromLegalEntityID == req.ToLegalEntityID {
+		return nil, fmt.Errorf("%w: two different legal entities are required", domain.ErrInvalidIntercompanyTransaction)
+	}
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", domain.ErrInvalidIntercompanyTransaction)
+	}
+	from, err := s.legalEntities.GetByID(ctx, req.FromLegalEntityID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: legal entity %s not found", domain.ErrInvalidIntercompanyTransaction, req.FromLegalEntityID)
+	}
+	if _, err := s.legalEntities.GetByID(ctx, req.ToLegalEntityID); err != nil {
+		return nil, fmt.Errorf("%w: legal entity %s not found", domain.ErrInvalidIntercompanyTransaction, req.ToLegalEntityID)
+	}
+	if err := s.ensureAccountOf(ctx, req.FromAccountID, req.FromLegalEntityID); err != nil {
+		return nil, err
+	}
+	if err := s.ensureAccountOf(ctx, req.ToAccountID, req.ToLegalEntityID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = from.FunctionalCurrency
+	}
+
+	tx := &domain.IntercompanyTransaction{
+		ID:                utils.NewID("ic"),
+		FromLegalEntityID: req.FromLegalEntityID,
+		ToLegalEntityID:   req.ToLegalEntityID,
+		Description:       req.Description,
+		PostingDate:       req.PostingDate,
+		Currency:          req.Currency,
+		Amount:            req.Amount,
+		CreatedAt:         time.Now(),
+	}
+
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		receivable, err := s.gl.EnsureAccount(txCtx, req.FromLegalEntityID, intercompanyReceivableAccount, "Intercompany Receivable", domain.AccountTypeASSET)
+		if err != nil {
+			return err
+		}
+		payable, err := s.gl.EnsureAccount(txCtx, req.ToLegalEntityID, intercompanyPayableAccount, "Intercompany Payable", domain.AccountTypeLIABILITY)
+		if err != nil {
+			return err
+		}
+
+		fromEntry, err := s.gl.CreateJournalEntry(txCtx, req.FromLegalEntityID, "FM", tx.ID, req.PostingDate,
+			intercompanyLines(receivable.ID, req.FromAccountID, req.ToLegalEntityID, req.Currency, req.Amount))
+		if err != nil {
+			return fmt.Errorf("posting in %s: %w", req.FromLegalEntityID, err)
+		}
+		toEntry, err := s.gl.CreateJournalEntry(txCtx, req.ToLegalEntityID, "FM", tx.ID, req.PostingDate,
+			intercompanyLines(req.ToAccountID, payable.ID, req.FromLegalEntityID, req.Currency, req.Amount))
+		if err != nil {
+			return fmt.Errorf("posting in %s: %w", req.ToLegalEntityID, err)
+		}
+		tx.FromJournalEntryID = fromEntry.ID
+		tx.ToJournalEntryID = toEntry.ID
+
+		if err := s.transactions.Create(txCtx, tx); err != nil {
+			return err
+		}
+
+		outboxRec := &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   string(domain.TopicFmIntercompanyPosted),
+			AggregateID: tx.ID,
+			Payload: domain.IntercompanyPostedEventPayload{
+				IntercompanyTransactionID: tx.ID,
+				FromLegalEntityID:         tx.FromLegalEntityID,
+				ToLegalEntityID:           tx.ToLegalEntityID,
+				Currency:                  tx.Currency,
+				Amount:                    tx.Amount,
+				Timestamp:                 time.Now(),
+			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: time.Now(),
+		}
+		return s.outbox.Create(txCtx, outboxRec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (s *IntercompanyService) ensureAccountOf(ctx context.Context, accountID, legalEntityID string) error {
+	acc, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil || acc.LegalEntityID != legalEntityID {
+		return fmt.Errorf("%w: account %s does not belong to legal entity %s", domain.ErrInvalidIntercompanyTransaction, accountID, legalEntityID)
+	}
+	return nil
+}
+
+// intercompanyLines debits one account and credits the other in the transaction currency; the
+// general ledger converts them into the entity's functional currency.
+func intercompanyLines(debitAccountID, creditAccountID, partnerID, currency string, amount decimal.Decimal) []domain.UniversalJournalLine {
+	dims := map[string]interface{}{tradingPartnerDimension: partnerID}
+	return []domain.UniversalJournalLine{
+		{AccountID: debitAccountID, AmountTransactional: amount, CurrencyTransactional: currency, TrackingDimensions: dims},
+		{AccountID: creditAccountID, AmountTransactional: amount.Neg(), CurrencyTransactional: currency, TrackingDimensions: dims},
+	}
+}
+
+// tradingPartner returns the partner legal entity a journal line is tagged with, if any.
+func tradingPartner(dims interface{}) string {
+	return dimensionValue(dims, tradingPartnerDimension)
+}
@@ -65,7 +65,7 @@ func TestJournalEntry_Create_SetsPostedStatus(t *testing.T) {
 	entries := memory.NewMemoryUniversalJournalEntryRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 
 	accA, _ := svc.CreateAccount(context.Background(), "legal_123", "1000", "Cash", "ASSET")
 	accB, _ := svc.CreateAccount(context.Background(), "legal_123", "2000", "Revenue", "REVENUE")
@@ -89,7 +89,7 @@ func TestJournalEntry_Update_BlocksPosted(t *testing.T) {
 	entries := memory.NewMemoryUniversalJournalEntryRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 
 	id := newPostedEntry(t, entries, accounts)
 
@@ -108,7 +108,7 @@ func TestJournalEntry_Update_BlocksReversed(t *testing.T) {
 	entries := memory.NewMemoryUniversalJournalEntryRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 
 	id := newPostedEntry(t, entries, accounts)
 	entry, lines, _ := entries.GetByID(context.Background(), id)
@@ -130,7 +130,7 @@ func TestJournalEntry_Update_AllowsDraft(t *testing.T) {
 	entries := memory.NewMemoryUniversalJournalEntryRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 
 	id := newDraftEntry(t, entries, accounts)
 
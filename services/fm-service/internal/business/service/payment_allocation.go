@@ -0,0 +1,728 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// AllocationRequest applies part of a payment or credit to one invoice or bill.
+// A zero amount takes whatever is still open on the document, up to what is left to apply.
+type AllocationRequest struct {
+	InvoiceID string          `json:"invoice_id"`
+	BillID    string          `json:"bill_id"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+// PaymentRequest records one customer receipt or vendor disbursement. The counterparty is taken
+// from the allocated documents when not given; it is required for unallocated payments and for
+// AutoAllocate, which applies the payment to the counterparty's open documents oldest due first.
+type PaymentRequest struct {
+	LegalEntityID    string                  `json:"legal_entity_id"`
+	CounterpartyType domain.CounterpartyType `json:"counterparty_type"`
+	CounterpartyID   string                  `json:"counterparty_id"`
+	BankAccountID    string                  `json:"bank_account_id"`
+	Amount           decimal.Decimal         `json:"amount"`
+	Currency         string                  `json:"currency"`
+	PaymentMethod    string                  `json:"payment_method"`
+	PaymentDate      time.Time               `json:"payment_date"`
+	AutoAllocate     bool                    `json:"auto_allocate"`
+	Allocations      []AllocationRequest     `json:"allocations"`
+}
+
+// PaymentResult is a recorded payment with its allocations and the on-account credit created
+// from any overpayment.
+type PaymentResult struct {
+	Payment         *domain.Payment            `json:"payment"`
+	Allocations     []domain.PaymentAllocation `json:"allocations"`
+	OnAccountCredit *domain.OnAccountCredit    `json:"on_account_credit,omitempty"`
+}
+
+// CreditApplication is the outcome of applying an on-account credit to open documents.
+type CreditApplication struct {
+	Credit      *domain.OnAccountCredit    `json:"credit"`
+	Allocations []domain.PaymentAllocation `json:"allocations"`
+}
+
+// CreditMemoResult is an issued credit memo, the part applied to its invoice and the
+// on-account credit holding whatever exceeded the invoice's open amount.
+type CreditMemoResult struct {
+	CreditMemo      *domain.ArCreditMemo      `json:"credit_memo"`
+	Allocation      *domain.PaymentAllocation `json:"allocation,omitempty"`
+	OnAccountCredit *domain.OnAccountCredit   `json:"on_account_credit,omitempty"`
+}
+
+// RecordAllocatedPayment records a payment and applies it to one or more invoices or bills of a
+// single counterparty. Documents move to PARTIAL or PAID, an overpayment becomes an on-account
+// credit. Bills on payment hold cannot be allocated to.
+func (s *CashManagementService) RecordAllocatedPayment(ctx context.Context, req PaymentRequest) (*PaymentResult, error) {
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: payment amount must be positive", domain.ErrInvalidPaymentAllocation)
+	}
+	if req.CounterpartyType != "" && !req.CounterpartyType.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported counterparty type %q", domain.ErrInvalidPaymentAllocation, req.CounterpartyType)
+	}
+	if req.AutoAllocate && (req.CounterpartyType == "" || req.CounterpartyID == "") {
+		return nil, fmt.Errorf("%w: auto allocation needs a counterparty", domain.ErrInvalidPaymentAllocation)
+	}
+	if req.PaymentDate.IsZero() {
+		req.PaymentDate = time.Now()
+	}
+
+	payment := &domain.Payment{
+		ID:               utils.NewID("pay"),
+		PaymentNumber:    documentNumber("PAY"),
+		PaymentDate:      req.PaymentDate,
+		Amount:           req.Amount,
+		PaymentMethod:    req.PaymentMethod,
+		Status:           "COMPLETED",
+		CounterpartyType: req.CounterpartyType,
+		CounterpartyID:   req.CounterpartyID,
+		Currency:         req.Currency,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	if req.BankAccountID != "" {
+		payment.BankAccountID = &req.BankAccountID
+	}
+	if len(req.Allocations) == 1 {
+		payment.InvoiceID, payment.BillID = optionalID(req.Allocations[0].InvoiceID), optionalID(req.Allocations[0].BillID)
+	}
+
+	result := &PaymentResult{Payment: payment, Allocations: []domain.PaymentAllocation{}}
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		scope := &allocationScope{
+			counterpartyType: req.CounterpartyType,
+			counterpartyID:   req.CounterpartyID,
+			legalEntityID:    req.LegalEntityID,
+			currency:         req.Currency,
+		}
+		requests := req.Allocations
+		if req.AutoAllocate && len(requests) == 0 {
+			var err error
+			if requests, err = s.openItemRequests(txCtx, scope); err != nil {
+				return err
+			}
+		}
+		planned, err := s.planAllocations(txCtx, requests, scope, payment.Amount)
+		if err != nil {
+			return err
+		}
+		payment.CounterpartyType, payment.CounterpartyID = scope.counterpartyType, scope.counterpartyID
+		if payment.Currency == "" {
+			payment.Currency = scope.currency
+		}
+
+		if s.fx != nil {
+			if err := s.settleAllocations(txCtx, payment, planned); err != nil {
+				return err
+			}
+		}
+
+		applied := decimal.Zero
+		for _, p := range planned {
+			alloc, err := s.applyToItem(txCtx, p.item, domain.AllocationSourcePAYMENT, payment.ID, p.amount)
+			if err != nil {
+				return err
+			}
+			result.Allocations = append(result.Allocations, alloc)
+			applied = applied.Add(p.amount)
+		}
+		if err := s.allocations.CreateMany(txCtx, result.Allocations); err != nil {
+			return err
+		}
+		if err := s.payments.Create(txCtx, payment); err != nil {
+			return err
+		}
+
+		unapplied := payment.Amount.Sub(applied)
+		if unapplied.IsPositive() && scope.counterpartyID != "" {
+			if scope.legalEntityID == "" {
+				return fmt.Errorf("%w: legal entity is required to keep an overpayment on account", domain.ErrInvalidPaymentAllocation)
+			}
+			credit, err := s.createOnAccountCredit(txCtx, scope, domain.AllocationSourcePAYMENT, payment.ID, unapplied)
+			if err != nil {
+				return err
+			}
+			result.OnAccountCredit = credit
+		}
+
+		if err := s.writePaymentEvent(txCtx, domain.TopicFmPaymentReceived, payment, payment.Status, result.Allocations, unapplied); err != nil {
+			return err
+		}
+		return s.writePaymentEvent(txCtx, domain.TopicFmPaymentProcessed, payment, payment.Status, result.Allocations, unapplied)
+	})
+
+	if err != nil {
+		// Publish payment failed event in a separate transaction
+		_ = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			return s.writePaymentEvent(txCtx, domain.TopicFmPaymentFailed, payment, "FAILED", nil, decimal.Zero)
+		})
+		return nil, err
+	}
+	return result, nil
+}
+
+// ApplyOnAccountCredit applies an unapplied customer or vendor credit to open documents of the
+// same counterparty and currency.
+func (s *CashManagementService) ApplyOnAccountCredit(ctx context.Context, creditID string, requests []AllocationRequest) (*CreditApplication, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: at least one invoice or bill is required", domain.ErrInvalidPaymentAllocation)
+	}
+
+	var application *CreditApplication
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		credit, err := s.credits.GetByID(txCtx, creditID)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrOnAccountCreditNotFound, creditID)
+		}
+		if !credit.RemainingAmount.IsPositive() {
+			return fmt.Errorf("%w: credit %s is fully applied", domain.ErrInvalidPaymentAllocation, credit.ID)
+		}
+
+		scope := &allocationScope{
+			counterpartyType: credit.CounterpartyType,
+			counterpartyID:   credit.CounterpartyID,
+			legalEntityID:    credit.LegalEntityID,
+			currency:         credit.Currency,
+		}
+		planned, err := s.planAllocations(txCtx, requests, scope, credit.RemainingAmount)
+		if err != nil {
+			return err
+		}
+
+		application = &CreditApplication{Credit: credit, Allocations: []domain.PaymentAllocation{}}
+		for _, p := range planned {
+			alloc, err := s.applyToItem(txCtx, p.item, domain.AllocationSourceON_ACCOUNT_CREDIT, credit.ID, p.amount)
+			if err != nil {
+				return err
+			}
+			application.Allocations = append(application.Allocations, alloc)
+			credit.RemainingAmount = credit.RemainingAmount.Sub(p.amount)
+		}
+		if err := s.allocations.CreateMany(txCtx, application.Allocations); err != nil {
+			return err
+		}
+		credit.UpdatedAt = time.Now()
+		return s.credits.Update(txCtx, credit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return application, nil
+}
+
+// IssueCreditMemo credits a customer invoice. The memo reduces what is open on the invoice;
+// any amount beyond that is kept as an on-account credit for the customer.
+func (s *CashManagementService) IssueCreditMemo(ctx context.Context, invoiceID string, amount decimal.Decimal, reason string) (*CreditMemoResult, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: credit memo amount must be positive", domain.ErrInvalidPaymentAllocation)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required for a credit memo", domain.ErrInvalidPaymentAllocation)
+	}
+
+	var result *CreditMemoResult
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		item, err := s.loadOpenItem(txCtx, AllocationRequest{InvoiceID: invoiceID})
+		if err != nil {
+			return err
+		}
+		inv := item.invoice
+
+		memo := &domain.ArCreditMemo{
+			ID:               utils.NewID("cm"),
+			LegalEntityID:    inv.LegalEntityID,
+			CreditMemoNumber: documentNumber("CM"),
+			CustomerID:       inv.CustomerID,
+			InvoiceID:        inv.ID,
+			Amount:           amount,
+			Currency:         inv.Currency,
+			Reason:           reason,
+			CreatedAt:        time.Now(),
+		}
+		if err := s.creditMemos.Create(txCtx, memo); err != nil {
+			return err
+		}
+		result = &CreditMemoResult{CreditMemo: memo}
+
+		applied := decimal.Min(amount, decimal.Max(inv.OpenAmount(), decimal.Zero))
+		if applied.IsPositive() {
+			alloc, err := s.applyToItem(txCtx, item, domain.AllocationSourceCREDIT_MEMO, memo.ID, applied)
+			if err != nil {
+				return err
+			}
+			if err := s.allocations.CreateMany(txCtx, []domain.PaymentAllocation{alloc}); err != nil {
+				return err
+			}
+			result.Allocation = &alloc
+		}
+		if excess := amount.Sub(applied); excess.IsPositive() {
+			scope := &allocationScope{
+				counterpartyType: domain.CounterpartyTypeCUSTOMER,
+				counterpartyID:   inv.CustomerID,
+				legalEntityID:    inv.LegalEntityID,
+				currency:         inv.Currency,
+			}
+			credit, err := s.createOnAccountCredit(txCtx, scope, domain.AllocationSourceCREDIT_MEMO, memo.ID, excess)
+			if err != nil {
+				return err
+			}
+			result.OnAccountCredit = credit
+		}
+
+		outboxRec := &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   string(domain.TopicFmCreditMemoIssued),
+			AggregateID: memo.ID,
+			Payload: domain.CreditMemoEventPayload{
+				ID:               memo.ID,
+				CreditMemoNumber: memo.CreditMemoNumber,
+				CustomerID:       memo.CustomerID,
+				InvoiceID:        memo.InvoiceID,
+				Amount:           memo.Amount,
+				AppliedAmount:    applied,
+				Reason:           memo.Reason,
+				Timestamp:        time.Now(),
+			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: time.Now(),
+		}
+		return s.outbox.Create(txCtx, outboxRec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *CashManagementService) ListPaymentAllocations(ctx context.Context, paymentID string) ([]domain.PaymentAllocation, error) {
+	if _, err := s.payments.GetByID(ctx, paymentID); err != nil {
+		return nil, err
+	}
+	return s.allocations.ListBySource(ctx, paymentID)
+}
+
+// ListDocumentAllocations returns the payments, credit memos and credits applied to an invoice or bill.
+func (s *CashManagementService) ListDocumentAllocations(ctx context.Context, invoiceID, billID string) ([]domain.PaymentAllocation, error) {
+	if _, err := s.loadOpenItem(ctx, AllocationRequest{InvoiceID: invoiceID, BillID: billID}); err != nil {
+		return nil, err
+	}
+	if invoiceID != "" {
+		return s.allocations.ListByInvoice(ctx, invoiceID)
+	}
+	return s.allocations.ListByBill(ctx, billID)
+}
+
+// ListCreditMemos returns credit memos, optionally only those issued against one invoice.
+func (s *CashManagementService) ListCreditMemos(ctx context.Context, invoiceID string) ([]domain.ArCreditMemo, error) {
+	memos, err := s.creditMemos.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]domain.ArCreditMemo, 0, len(memos))
+	for _, m := range memos {
+		if invoiceID == "" || m.InvoiceID == invoiceID {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+// ListOnAccountCredits returns on-account credits filtered by counterparty; openOnly drops
+// credits that have been fully applied.
+func (s *CashManagementService) ListOnAccountCredits(ctx context.Context, counterpartyType domain.CounterpartyType, counterpartyID string, openOnly bool) ([]domain.OnAccountCredit, error) {
+	credits, err := s.credits.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]domain.OnAccountCredit, 0, len(credits))
+	for _, c := range credits {
+		if (counterpartyType != "" && c.CounterpartyType != counterpartyType) || (counterpartyID != "" && c.CounterpartyID != counterpartyID) {
+			continue
+		}
+		if openOnly && !c.RemainingAmount.IsPositive() {
+			continue
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// openItem is an invoice or a vendor bill that payments and credits can be applied to.
+type openItem struct {
+	invoice *domain.ArInvoice
+	bill    *domain.ApVendorBill
+}
+
+func (d openItem) counterparty() (domain.CounterpartyType, string) {
+	if d.invoice != nil {
+		return domain.CounterpartyTypeCUSTOMER, d.invoice.CustomerID
+	}
+	return domain.CounterpartyTypeVENDOR, d.bill.VendorID
+}
+
+func (d openItem) legalEntityID() string {
+	if d.invoice != nil {
+		return d.invoice.LegalEntityID
+	}
+	return d.bill.LegalEntityID
+}
+
+func (d openItem) currency() string {
+	if d.invoice != nil {
+		return d.invoice.Currency
+	}
+	return d.bill.Currency
+}
+
+// openAmount is zero for settled documents, including ones marked PAID before amounts paid were tracked.
+func (d openItem) openAmount() decimal.Decimal {
+	if d.invoice != nil {
+		if d.invoice.Status == domain.PaymentStatusPAID {
+			return decimal.Zero
+		}
+		return d.invoice.OpenAmount()
+	}
+	if d.bill.Status == domain.PaymentStatusPAID {
+		return decimal.Zero
+	}
+	return d.bill.OpenAmount()
+}
+
+func (d openItem) number() string {
+	if d.invoice != nil {
+		return d.invoice.InvoiceNumber
+	}
+	return d.bill.BillNumber
+}
+
+// allocationScope pins the counterparty, legal entity and currency all documents of one payment
+// or credit must share. Empty fields are taken from the first document.
+type allocationScope struct {
+	counterpartyType domain.CounterpartyType
+	counterpartyID   string
+	legalEntityID    string
+	currency         string
+}
+
+func (sc *allocationScope) admit(item openItem) error {
+	partyType, partyID := item.counterparty()
+	if sc.counterpartyType == "" {
+		sc.counterpartyType = partyType
+	}
+	if sc.counterpartyID == "" {
+		sc.counterpartyID = partyID
+	}
+	if sc.counterpartyType != partyType || sc.counterpartyID != partyID {
+		return fmt.Errorf("%w: %s belongs to %s %s, not %s %s", domain.ErrInvalidPaymentAllocation,
+			item.number(), strings.ToLower(string(partyType)), partyID, strings.ToLower(string(sc.counterpartyType)), sc.counterpartyID)
+	}
+	if sc.legalEntityID == "" {
+		sc.legalEntityID = item.legalEntityID()
+	}
+	if sc.legalEntityID != item.legalEntityID() {
+		return fmt.Errorf("%w: %s belongs to legal entity %s", domain.ErrInvalidPaymentAllocation, item.number(), item.legalEntityID())
+	}
+	if sc.currency == "" {
+		sc.currency = item.currency()
+	}
+	if item.currency() != "" && sc.currency != item.currency() {
+		return fmt.Errorf("%w: %s is in %s, not %s", domain.ErrInvalidPaymentAllocation, item.number(), item.currency(), sc.currency)
+	}
+	return nil
+}
+
+type plannedAllocation struct {
+	item   openItem
+	amount decimal.Decimal
+}
+
+// planAllocations loads the requested documents, checks they fit the scope and splits available
+// across them. Documents with nothing left to apply are skipped, so an overpaid or already
+// settled document leaves the amount unapplied instead of failing.
+func (s *CashManagementService) planAllocations(ctx context.Context, requests []AllocationRequest, scope *allocationScope, available decimal.Decimal) ([]plannedAllocation, error) {
+	seen := make(map[string]bool, len(requests))
+	remaining := available
+	var planned []plannedAllocation
+	for _, r := range requests {
+		if (r.InvoiceID == "") == (r.BillID == "") {
+			return nil, fmt.Errorf("%w: each allocation needs exactly one of invoice or bill", domain.ErrInvalidPaymentAllocation)
+		}
+		if r.Amount.IsNegative() {
+			return nil, fmt.Errorf("%w: allocation amounts must not be negative", domain.ErrInvalidPaymentAllocation)
+		}
+		key := r.InvoiceID + "/" + r.BillID
+		if seen[key] {
+			return nil, fmt.Errorf("%w: document allocated twice", domain.ErrInvalidPaymentAllocation)
+		}
+		seen[key] = true
+
+		item, err := s.loadOpenItem(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		// Bills failing the three-way match stay unpaid until matched or overridden
+		if item.bill != nil && item.bill.PaymentHold {
+			return nil, fmt.Errorf("%w: %s", domain.ErrBillOnPaymentHold, item.bill.BillNumber)
+		}
+		if err := scope.admit(item); err != nil {
+			return nil, err
+		}
+
+		open := decimal.Max(item.openAmount(), decimal.Zero)
+		amount := r.Amount
+		if amount.IsZero() {
+			amount = decimal.Min(open, remaining)
+		} else if amount.GreaterThan(open) {
+			return nil, fmt.Errorf("%w: %s exceeds the %s open on %s", domain.ErrInvalidPaymentAllocation, amount, open, item.number())
+		}
+		if amount.GreaterThan(remaining) {
+			return nil, fmt.Errorf("%w: allocations exceed the %s available", domain.ErrInvalidPaymentAllocation, available)
+		}
+		if !amount.IsPositive() {
+			continue
+		}
+		remaining = remaining.Sub(amount)
+		planned = append(planned, plannedAllocation{item: item, amount: amount})
+	}
+	return planned, nil
+}
+
+func (s *CashManagementService) loadOpenItem(ctx context.Context, r AllocationRequest) (openItem, error) {
+	if r.InvoiceID != "" {
+		inv, err := s.invoices.GetByID(ctx, r.InvoiceID)
+		if err != nil {
+			return openItem{}, fmt.Errorf("%w: %s", domain.ErrInvoiceNotFound, r.InvoiceID)
+		}
+		return openItem{invoice: inv}, nil
+	}
+	bill, err := s.bills.GetByID(ctx, r.BillID)
+	if err != nil {
+		return openItem{}, fmt.Errorf("%w: %s", domain.ErrVendorBillNotFound, r.BillID)
+	}
+	return openItem{bill: bill}, nil
+}
+
+// openItemRequests lists the counterparty's open documents, oldest due first, for auto allocation.
+func (s *CashManagementService) openItemRequests(ctx context.Context, scope *allocationScope) ([]AllocationRequest, error) {
+	type candidate struct {
+		req     AllocationRequest
+		dueDate time.Time
+	}
+	var candidates []candidate
+	keep := func(legalEntityID, currency string) bool {
+		return (scope.legalEntityID == "" || legalEntityID == scope.legalEntityID) && (scope.currency == "" || currency == scope.currency)
+	}
+
+	if scope.counterpartyType == domain.CounterpartyTypeCUSTOMER {
+		invoices, err := s.invoices.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, inv := range invoices {
+			if inv.CustomerID == scope.counterpartyID && inv.OpenAmount().IsPositive() && keep(inv.LegalEntityID, inv.Currency) {
+				candidates = append(candidates, candidate{req: AllocationRequest{InvoiceID: inv.ID}, dueDate: inv.DueDate})
+			}
+		}
+	} else {
+		bills, err := s.bills.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, bill := range bills {
+			if bill.VendorID == scope.counterpartyID && !bill.PaymentHold && bill.OpenAmount().IsPositive() && keep(bill.LegalEntityID, bill.Currency) {
+				candidates = append(candidates, candidate{req: AllocationRequest{BillID: bill.ID}, dueDate: bill.DueDate})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].dueDate.Before(candidates[j].dueDate) })
+
+	// Auto allocation never mixes currencies: the oldest document decides when none was given
+	requests := make([]AllocationRequest, 0, len(candidates))
+	currency := scope.currency
+	for _, c := range candidates {
+		item, err := s.loadOpenItem(ctx, c.req)
+		if err != nil {
+			return nil, err
+		}
+		if currency == "" {
+			currency = item.currency()
+		}
+		if item.currency() == currency {
+			requests = append(requests, c.req)
+		}
+	}
+	return requests, nil
+}
+
+// settleAllocations runs the FX settlement per allocated document, since each carries its own
+// booking rate, and totals the realized gain or loss on the payment.
+func (s *CashManagementService) settleAllocations(ctx context.Context, payment *domain.Payment, planned []plannedAllocation) error {
+	realized := decimal.Zero
+	for _, p := range planned {
+		part := *payment
+		part.Amount = p.amount
+		part.InvoiceID, part.BillID = nil, nil
+		if p.item.invoice != nil {
+			part.InvoiceID = &p.item.invoice.ID
+		} else {
+			part.BillID = &p.item.bill.ID
+		}
+		if err := s.fx.SettlePayment(ctx, &part); err != nil {
+			return err
+		}
+		payment.Currency, payment.ExchangeRate = part.Currency, part.ExchangeRate
+		realized = realized.Add(part.RealizedFxGainLoss)
+	}
+	payment.RealizedFxGainLoss = realized
+	return nil
+}
+
+// applyToItem adds amount to what has been paid on the document, moves it to PARTIAL or PAID
+// and returns the allocation row to store.
+func (s *CashManagementService) applyToItem(ctx context.Context, item openItem, source domain.AllocationSource, sourceID string, amount decimal.Decimal) (domain.PaymentAllocation, error) {
+	alloc := domain.PaymentAllocation{
+		ID:         utils.NewID("alloc"),
+		SourceType: source,
+		SourceID:   sourceID,
+		Amount:     amount,
+		CreatedAt:  time.Now(),
+	}
+
+	if inv := item.invoice; inv != nil {
+		alloc.InvoiceID = &inv.ID
+		inv.AmountPaid = inv.AmountPaid.Add(amount)
+		// A partial payment does not bring an invoice past its due date back into terms
+		if status := settlementStatus(inv.AmountDue(), inv.AmountPaid); status != domain.PaymentStatusPARTIAL || inv.Status != domain.PaymentStatusOVERDUE {
+			inv.Status = status
+		}
+		inv.UpdatedAt = time.Now()
+		if err := s.invoices.Update(ctx, inv); err != nil {
+			return alloc, err
+		}
+		if inv.Status != domain.PaymentStatusPAID {
+			return alloc, nil
+		}
+		return alloc, s.outbox.Create(ctx, &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   string(domain.TopicFmInvoicePaid),
+			AggregateID: inv.ID,
+			Payload: domain.InvoiceEventPayload{
+				ID:            inv.ID,
+				CustomerID:    inv.CustomerID,
+				InvoiceNumber: inv.InvoiceNumber,
+				TotalAmount:   inv.TotalAmount,
+				Status:        string(inv.Status),
+				Timestamp:     time.Now(),
+			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	bill := item.bill
+	alloc.BillID = &bill.ID
+	bill.AmountPaid = bill.AmountPaid.Add(amount)
+	bill.Status = settlementStatus(bill.TotalAmount, bill.AmountPaid)
+	bill.UpdatedAt = time.Now()
+	if err := s.bills.Update(ctx, bill); err != nil {
+		return alloc, err
+	}
+	if bill.Status != domain.PaymentStatusPAID {
+		return alloc, nil
+	}
+	return alloc, s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   string(domain.TopicFmVendorPaid),
+		AggregateID: bill.ID,
+		Payload: domain.VendorPaidEventPayload{
+			BillID:        bill.ID,
+			LegalEntityID: bill.LegalEntityID,
+			VendorID:      bill.VendorID,
+			BillNumber:    bill.BillNumber,
+			TotalAmount:   bill.TotalAmount,
+			Timestamp:     time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *CashManagementService) createOnAccountCredit(ctx context.Context, scope *allocationScope, source domain.AllocationSource, sourceID string, amount decimal.Decimal) (*domain.OnAccountCredit, error) {
+	credit := &domain.OnAccountCredit{
+		ID:               utils.NewID("oac"),
+		LegalEntityID:    scope.legalEntityID,
+		CounterpartyType: scope.counterpartyType,
+		CounterpartyID:   scope.counterpartyID,
+		SourceType:       source,
+		SourceID:         sourceID,
+		Currency:         scope.currency,
+		OriginalAmount:   amount,
+		RemainingAmount:  amount,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	if err := s.credits.Create(ctx, credit); err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+func (s *CashManagementService) writePaymentEvent(ctx context.Context, topic string, payment *domain.Payment, status string, allocations []domain.PaymentAllocation, unapplied decimal.Decimal) error {
+	lines := make([]domain.AllocationEventLine, len(allocations))
+	for i, a := range allocations {
+		lines[i] = domain.AllocationEventLine{InvoiceID: a.InvoiceID, BillID: a.BillID, Amount: a.Amount}
+	}
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: payment.ID,
+		Payload: domain.PaymentEventPayload{
+			ID:              payment.ID,
+			InvoiceID:       payment.InvoiceID,
+			BillID:          payment.BillID,
+			PaymentNumber:   payment.PaymentNumber,
+			Amount:          payment.Amount,
+			PaymentMethod:   payment.PaymentMethod,
+			Status:          status,
+			Allocations:     lines,
+			UnappliedAmount: unapplied,
+			Timestamp:       time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
+	})
+}
+
+// settlementStatus derives the payment status of a document from what has been applied to it.
+func settlementStatus(total, paid decimal.Decimal) domain.PaymentStatus {
+	switch {
+	case !paid.IsPositive():
+		return domain.PaymentStatusOPEN
+	case paid.GreaterThanOrEqual(total):
+		return domain.PaymentStatusPAID
+	default:
+		return domain.PaymentStatusPARTIAL
+	}
+}
+
+// documentNumber returns a document number such as PAY-3F2A9C1D0B7E4A65 from the random part of
+// a new ID, so documents created in the same second do not share a number.
+func documentNumber(prefix string) string {
+	parts := strings.Split(utils.NewID(prefix), "_")
+	return prefix + "-" + strings.ToUpper(parts[1])
+}
+
+func optionalID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
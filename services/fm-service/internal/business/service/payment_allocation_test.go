@@ -0,0 +1,385 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func seedOpenInvoice(t *testing.T, invoices *memory.MemoryArInvoiceRepo, id, customerID string, total int64, due time.Time) {
+	t.Helper()
+	err := invoices.Create(context.Background(), &domain.ArInvoice{
+		ID: id, LegalEntityID: "le_1", InvoiceNumber: "INV-" + id, CustomerID: customerID,
+		TotalAmount: decimal.NewFromInt(total), Currency: "USD", DueDate: due, Status: domain.PaymentStatusOPEN,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed invoice: %v", err)
+	}
+}
+
+func seedOpenBill(t *testing.T, bills *memory.MemoryApVendorBillRepo, id, vendorID string, total int64, due time.Time, held bool) {
+	t.Helper()
+	err := bills.Create(context.Background(), &domain.ApVendorBill{
+		ID: id, LegalEntityID: "le_1", BillNumber: "BILL-" + id, VendorID: vendorID,
+		TotalAmount: decimal.NewFromInt(total), Currency: "USD", DueDate: due, Status: domain.PaymentStatusOPEN, PaymentHold: held,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed bill: %v", err)
+	}
+}
+
+func expectInvoicePaid(t *testing.T, invoices *memory.MemoryArInvoiceRepo, id string, paid int64, status domain.PaymentStatus) {
+	t.Helper()
+	inv, err := invoices.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to load invoice: %v", err)
+	}
+	if !inv.AmountPaid.Equal(decimal.NewFromInt(paid)) || inv.Status != status {
+		t.Errorf("expected %s to have %d paid and status %s, got %s and %s", id, paid, status, inv.AmountPaid, inv.Status)
+	}
+}
+
+func customerPayment(amount int64, allocations ...service.AllocationRequest) service.PaymentRequest {
+	return service.PaymentRequest{
+		LegalEntityID:    "le_1",
+		CounterpartyType: domain.CounterpartyTypeCUSTOMER,
+		CounterpartyID:   "cust_1",
+		Amount:           decimal.NewFromInt(amount),
+		Currency:         "USD",
+		PaymentMethod:    "WIRE",
+		Allocations:      allocations,
+	}
+}
+
+func toInvoice(id string, amount int64) service.AllocationRequest {
+	return service.AllocationRequest{InvoiceID: id, Amount: decimal.NewFromInt(amount)}
+}
+
+func TestPaymentAllocation_PartialPaymentsSettleInvoice(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	credits := memory.NewMemoryOnAccountCreditRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, credits, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: credits,
+		Outbox:           outbox,
+		TM:               tm,
+	})
+	ctx := context.Background()
+	seedOpenInvoice(t, invoices, "inv_1", "cust_1", 1000, day(2026, 6, 30))
+
+	first, err := svc.RecordAllocatedPayment(ctx, customerPayment(400, toInvoice("inv_1", 400)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectInvoicePaid(t, invoices, "inv_1", 400, domain.PaymentStatusPARTIAL)
+
+	// A zero amount settles whatever is still open
+	result, err := svc.RecordAllocatedPayment(ctx, customerPayment(600, toInvoice("inv_1", 0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Allocations) != 1 || !result.Allocations[0].Amount.Equal(decimal.NewFromInt(600)) || result.OnAccountCredit != nil {
+		t.Errorf("expected 600 allocated and nothing on account, got %+v", result)
+	}
+	expectInvoicePaid(t, invoices, "inv_1", 1000, domain.PaymentStatusPAID)
+	// Payments recorded within the same second still get their own number
+	if first.Payment.PaymentNumber == result.Payment.PaymentNumber {
+		t.Errorf("expected distinct payment numbers, both are %s", first.Payment.PaymentNumber)
+	}
+
+	allocs, _ := svc.ListDocumentAllocations(ctx, "inv_1", "")
+	if len(allocs) != 2 {
+		t.Errorf("expected two allocations on the invoice, got %d", len(allocs))
+	}
+	if n := countTopic(t, outbox, domain.TopicFmInvoicePaid); n != 1 {
+		t.Errorf("expected one invoice paid event, got %d", n)
+	}
+}
+
+func TestPaymentAllocation_OnePaymentCoversSeveralInvoices(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	credits := memory.NewMemoryOnAccountCreditRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, credits, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: credits,
+		Outbox:           outbox,
+		TM:               tm,
+	})
+	ctx := context.Background()
+	seedOpenInvoice(t, invoices, "inv_1", "cust_1", 300, day(2026, 6, 1))
+	seedOpenInvoice(t, invoices, "inv_2", "cust_1", 500, day(2026, 6, 15))
+	seedOpenInvoice(t, invoices, "inv_3", "cust_2", 200, day(2026, 6, 15))
+
+	result, err := svc.RecordAllocatedPayment(ctx, customerPayment(500, toInvoice("inv_1", 300), toInvoice("inv_2", 200)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Allocations) != 2 || result.Payment.InvoiceID != nil {
+		t.Errorf("expected two allocations and no single invoice link, got %+v", result)
+	}
+	expectInvoicePaid(t, invoices, "inv_1", 300, domain.PaymentStatusPAID)
+	expectInvoicePaid(t, invoices, "inv_2", 200, domain.PaymentStatusPARTIAL)
+
+	// Another customer's invoice and over-allocation are both rejected
+	if _, err := svc.RecordAllocatedPayment(ctx, customerPayment(200, toInvoice("inv_3", 200))); !errors.Is(err, domain.ErrInvalidPaymentAllocation) {
+		t.Errorf("expected foreign invoice to be rejected, got %v", err)
+	}
+	if _, err := svc.RecordAllocatedPayment(ctx, customerPayment(100, toInvoice("inv_2", 200))); !errors.Is(err, domain.ErrInvalidPaymentAllocation) {
+		t.Errorf("expected allocations above the payment to be rejected, got %v", err)
+	}
+	expectInvoicePaid(t, invoices, "inv_2", 200, domain.PaymentStatusPARTIAL)
+}
+
+func TestPaymentAllocation_OverpaymentKeptOnAccountAndApplied(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	credits := memory.NewMemoryOnAccountCreditRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, credits, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: credits,
+		Outbox:           outbox,
+		TM:               tm,
+	})
+	ctx := context.Background()
+	seedOpenInvoice(t, invoices, "inv_1", "cust_1", 300, day(2026, 6, 1))
+
+	result, err := svc.RecordAllocatedPayment(ctx, customerPayment(500, toInvoice("inv_1", 0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	credit := result.OnAccountCredit
+	if credit == nil || !credit.RemainingAmount.Equal(decimal.NewFromInt(200)) || credit.SourceType != domain.AllocationSourcePAYMENT {
+		t.Fatalf("expected 200 kept on account, got %+v", credit)
+	}
+
+	seedOpenInvoice(t, invoices, "inv_2", "cust_1", 150, day(2026, 7, 1))
+	application, err := svc.ApplyOnAccountCredit(ctx, credit.ID, []service.AllocationRequest{{InvoiceID: "inv_2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !application.Credit.RemainingAmount.Equal(decimal.NewFromInt(50)) {
+		t.Errorf("expected 50 left on the credit, got %s", application.Credit.RemainingAmount)
+	}
+	expectInvoicePaid(t, invoices, "inv_2", 150, domain.PaymentStatusPAID)
+
+	open, _ := svc.ListOnAccountCredits(ctx, domain.CounterpartyTypeCUSTOMER, "cust_1", true)
+	if len(open) != 1 {
+		t.Errorf("expected the partly used credit to stay open, got %d", len(open))
+	}
+	if _, err := svc.ApplyOnAccountCredit(ctx, "missing", []service.AllocationRequest{{InvoiceID: "inv_2"}}); !errors.Is(err, domain.ErrOnAccountCreditNotFound) {
+		t.Errorf("expected credit not found, got %v", err)
+	}
+}
+
+func TestPaymentAllocation_CreditMemoExcessGoesOnAccount(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	credits := memory.NewMemoryOnAccountCreditRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, credits, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: credits,
+		Outbox:           outbox,
+		TM:               tm,
+	})
+	ctx := context.Background()
+	seedOpenInvoice(t, invoices, "inv_1", "cust_1", 400, day(2026, 6, 1))
+	if _, err := svc.RecordAllocatedPayment(ctx, customerPayment(300, toInvoice("inv_1", 300))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := svc.IssueCreditMemo(ctx, "inv_1", decimal.NewFromInt(250), "damaged goods")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Allocation == nil || !result.Allocation.Amount.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("expected 100 applied to the invoice, got %+v", result.Allocation)
+	}
+	if result.OnAccountCredit == nil || !result.OnAccountCredit.RemainingAmount.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("expected 150 kept on account, got %+v", result.OnAccountCredit)
+	}
+	expectInvoicePaid(t, invoices, "inv_1", 400, domain.PaymentStatusPAID)
+
+	memos, _ := svc.ListCreditMemos(ctx, "inv_1")
+	if len(memos) != 1 || countTopic(t, outbox, domain.TopicFmCreditMemoIssued) != 1 {
+		t.Errorf("expected one credit memo and one issued event, got %d memos", len(memos))
+	}
+	if _, err := svc.IssueCreditMemo(ctx, "missing", decimal.NewFromInt(10), "x"); !errors.Is(err, domain.ErrInvoiceNotFound) {
+		t.Errorf("expected invoice not found, got %v", err)
+	}
+}
+
+func TestPaymentAllocation_AutoAllocatePaysOldestBillsFirst(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	credits := memory.NewMemoryOnAccountCreditRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, credits, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: credits,
+		Outbox:           outbox,
+		TM:               tm,
+	})
+	ctx := context.Background()
+	seedOpenBill(t, bills, "bill_new", "vendor_1", 300, day(2026, 7, 1), false)
+	seedOpenBill(t, bills, "bill_old", "vendor_1", 300, day(2026, 6, 1), false)
+	seedOpenBill(t, bills, "bill_held", "vendor_1", 100, day(2026, 5, 1), true)
+
+	result, err := svc.RecordAllocatedPayment(ctx, service.PaymentRequest{
+		LegalEntityID:    "le_1",
+		CounterpartyType: domain.CounterpartyTypeVENDOR,
+		CounterpartyID:   "vendor_1",
+		Amount:           decimal.NewFromInt(400),
+		PaymentMethod:    "ACH",
+		AutoAllocate:     true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Allocations) != 2 || *result.Allocations[0].BillID != "bill_old" || !result.Allocations[1].Amount.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("expected the oldest unheld bill settled first, got %+v", result.Allocations)
+	}
+	if !result.Payment.IsOutgoing() {
+		t.Errorf("expected a vendor payment to be outgoing")
+	}
+	if n := countTopic(t, outbox, domain.TopicFmVendorPaid); n != 1 {
+		t.Errorf("expected one vendor paid event, got %d", n)
+	}
+
+	_, err = svc.RecordAllocatedPayment(ctx, service.PaymentRequest{
+		CounterpartyType: domain.CounterpartyTypeVENDOR,
+		Amount:           decimal.NewFromInt(100),
+		Allocations:      []service.AllocationRequest{{BillID: "bill_held"}},
+	})
+	if !errors.Is(err, domain.ErrBillOnPaymentHold) {
+		t.Errorf("expected held bill to be rejected, got %v", err)
+	}
+}
+
+func TestAgingReport_BucketsOpenItemsByDaysPastDue(t *testing.T) {
+	payments := memory.NewMemoryPaymentRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	credits := memory.NewMemoryOnAccountCreditRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(payments, invoices, bills, allocations, creditMemos, credits, outbox)
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: credits,
+		Outbox:           outbox,
+		TM:               tm,
+	})
+	ctx := context.Background()
+	asOf := day(2026, 6, 30)
+	seedOpenInvoice(t, invoices, "inv_current", "cust_1", 100, day(2026, 7, 15))
+	seedOpenInvoice(t, invoices, "inv_45", "cust_1", 200, asOf.AddDate(0, 0, -45))
+	seedOpenInvoice(t, invoices, "inv_75", "cust_2", 300, asOf.AddDate(0, 0, -75))
+	seedOpenInvoice(t, invoices, "inv_120", "cust_2", 400, asOf.AddDate(0, 0, -120))
+	seedOpenInvoice(t, invoices, "inv_paid", "cust_1", 500, asOf.AddDate(0, 0, -10))
+
+	if _, err := svc.RecordAllocatedPayment(ctx, customerPayment(550, toInvoice("inv_paid", 500))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	partial := customerPayment(150, toInvoice("inv_120", 150))
+	partial.CounterpartyID = "cust_2"
+	if _, err := svc.RecordAllocatedPayment(ctx, partial); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := svc.GetReceivablesAging(ctx, service.AgingRequest{LegalEntityID: "le_1", AsOf: asOf})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	totals := report.Totals
+	if !totals.Days0To30.Equal(decimal.NewFromInt(100)) || !totals.Days31To60.Equal(decimal.NewFromInt(200)) ||
+		!totals.Days61To90.Equal(decimal.NewFromInt(300)) || !totals.Over90.Equal(decimal.NewFromInt(250)) || !totals.Total.Equal(decimal.NewFromInt(850)) {
+		t.Errorf("unexpected bucket totals: %+v", totals)
+	}
+	if len(report.Counterparties) != 2 || report.Counterparties[0].CounterpartyID != "cust_1" {
+		t.Fatalf("expected two customers sorted by id, got %+v", report.Counterparties)
+	}
+	if !report.Counterparties[0].UnappliedCredits.Equal(decimal.NewFromInt(50)) || len(report.Counterparties[0].Documents) != 2 {
+		t.Errorf("expected cust_1 with two open invoices and 50 unapplied, got %+v", report.Counterparties[0])
+	}
+
+	seedOpenBill(t, bills, "bill_1", "vendor_1", 700, asOf.AddDate(0, 0, -31), false)
+	payables, err := svc.GetPayablesAging(ctx, service.AgingRequest{LegalEntityID: "le_1", AsOf: asOf})
+	if err != nil || !payables.Totals.Days31To60.Equal(decimal.NewFromInt(700)) {
+		t.Errorf("expected the bill in 31-60, got %+v (%v)", payables, err)
+	}
+	if _, err := svc.GetPayablesAging(ctx, service.AgingRequest{}); !errors.Is(err, domain.ErrInvalidAgingRequest) {
+		t.Errorf("expected a legal entity to be required, got %v", err)
+	}
+}
+
+func countTopic(t *testing.T, outbox *memory.MemoryTransactionalOutboxRepo, topic string) int {
+	t.Helper()
+	pending, err := outbox.GetPending(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("failed to read outbox: %v", err)
+	}
+	n := 0
+	for _, ev := range pending {
+		if ev.EventType == topic {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// creditTransfer is one vendor payment inside a bank payment file
+type creditTransfer struct {
+	PaymentID   string
+	VendorID    string
+	Beneficiary domain.VendorBankAccount
+	Amount      decimal.Decimal
+	Currency    string
+	Reference   string // Bill numbers settled by the transfer
+}
+
+// paymentFileInput is everything a payment file for one paying bank account is written from
+type paymentFileInput struct {
+	FileID        string
+	Payer         domain.LegalEntity
+	Account       domain.BankAccount
+	ExecutionDate time.Time
+	CreatedAt     time.Time
+	Transfers     []creditTransfer
+}
+
+// paymentFileFormat returns the file format a paying bank account can issue. EUR accounts
+// send SEPA credit transfers from a valid IBAN; USD accounts need a valid ABA routing number
+// for ACH, whose first eight digits identify the originating bank in the NACHA file.
+func paymentFileFormat(ba domain.BankAccount) (domain.PaymentFileFormat, bool) {
+	switch {
+	case ba.Currency == "EUR" && validIBAN(ba.AccountNumber):
+		return domain.PaymentFileFormatSEPA_PAIN_001, true
+	case ba.Currency == "USD" && validRoutingNumber(ba.RoutingNumber) && ba.AccountNumber != "":
+		return domain.PaymentFileFormatNACHA, true
+	}
+	return "", false
+}
+
+// missingBeneficiaryDetails explains why a vendor cannot be paid in the given format, or
+// returns "" when the bank details on file are sufficient.
+func missingBeneficiaryDetails(format domain.PaymentFileFormat, vba *domain.VendorBankAccount) string {
+	switch {
+	case vba == nil:
+		return "vendor has no bank details on file"
+	case format == domain.PaymentFileFormatSEPA_PAIN_001 && vba.Iban == "":
+		return "vendor has no IBAN for SEPA transfers"
+	case format == domain.PaymentFileFormatNACHA && (vba.RoutingNumber == "" || vba.AccountNumber == ""):
+		return "vendor has no routing and account number for ACH"
+	}
+	return ""
+}
+
+func paymentMethod(format domain.PaymentFileFormat) string {
+	if format == domain.PaymentFileFormatNACHA {
+		return "ACH"
+	}
+	return "SEPA_CREDIT_TRANSFER"
+}
+
+func paymentFileName(runID, bankAccountID string, format domain.PaymentFileFormat) string {
+	ext := "xml"
+	if format == domain.PaymentFileFormatNACHA {
+		ext = "ach"
+	}
+	return fmt.Sprintf("%s_%s.%s", runID, bankAccountID, ext)
+}
+
+func writePaymentFile(format domain.PaymentFileFormat, in paymentFileInput) (string, error) {
+	switch format {
+	case domain.PaymentFileFormatSEPA_PAIN_001:
+		return writeSEPACreditTransfer(in)
+	case domain.PaymentFileFormatNACHA:
+		return writeNACHACredits(in)
+	}
+	return "", fmt.Errorf("%w: unsupported payment file format %q", domain.ErrInvalidPaymentRun, format)
+}
+
+// validIBAN checks the length and the ISO 13616 mod-97 check digits of a compact IBAN
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validBIC accepts 8 or 11 character SWIFT codes
+func validBIC(bic string) bool {
+	if len(bic) != 8 && len(bic) != 11 {
+		return false
+	}
+	for i, r := range bic {
+		letter, alnum := r >= 'A' && r <= 'Z', (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if (i < 6 && !letter) || !alnum {
+			return false
+		}
+	}
+	return true
+}
+
+// validRoutingNumber checks the ABA checksum of a nine digit routing number
+func validRoutingNumber(rtn string) bool {
+	if len(rtn) != 9 {
+		return false
+	}
+	weights := []int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i, r := range rtn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += int(r-'0') * weights[i]
+	}
+	return sum%10 == 0
+}
+
+// --- SEPA pain.001.001.03 ---
+
+type sepaDocument struct {
+	XMLName    xml.Name       `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+	Initiation sepaInitiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type sepaInitiation struct {
+	GroupHeader sepaGroupHeader `xml:"GrpHdr"`
+	PaymentInfo sepaPaymentInfo `xml:"PmtInf"`
+}
+
+type sepaGroupHeader struct {
+	MessageID        string    `xml:"MsgId"`
+	CreationDateTime string    `xml:"CreDtTm"`
+	NumberOfTxs      int       `xml:"NbOfTxs"`
+	ControlSum       string    `xml:"CtrlSum"`
+	InitiatingParty  sepaParty `xml:"InitgPty"`
+}
+
+type sepaPaymentInfo struct {
+	PaymentInfoID          string             `xml:"PmtInfId"`
+	PaymentMethod          string             `xml:"PmtMtd"`
+	NumberOfTxs            int                `xml:"NbOfTxs"`
+	ControlSum             string             `xml:"CtrlSum"`
+	ServiceLevel           string             `xml:"PmtTpInf>SvcLvl>Cd"`
+	RequestedExecutionDate string             `xml:"ReqdExctnDt"`
+	Debtor                 sepaParty          `xml:"Dbtr"`
+	DebtorAccount          sepaAccount        `xml:"DbtrAcct"`
+	DebtorAgent            sepaAgent          `xml:"DbtrAgt"`
+	ChargeBearer           string             `xml:"ChrgBr"`
+	Transfers              []sepaTransferInfo `xml:"CdtTrfTxInf"`
+}
+
+type sepaTransferInfo struct {
+	EndToEndID      string      `xml:"PmtId>EndToEndId"`
+	Amount          sepaAmount  `xml:"Amt>InstdAmt"`
+	CreditorAgent   *sepaAgent  `xml:"CdtrAgt,omitempty"`
+	Creditor        sepaParty   `xml:"Cdtr"`
+	CreditorAccount sepaAccount `xml:"CdtrAcct"`
+	Remittance      string      `xml:"RmtInf>Ustrd"`
+}
+
+type sepaParty struct {
+	Name string `xml:"Nm"`
+}
+
+type sepaAccount struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+// sepaAgent names a bank by BIC; without one the bank derives it from the IBAN (NOTPROVIDED)
+type sepaAgent struct {
+	BIC   string `xml:"FinInstnId>BIC,omitempty"`
+	Other string `xml:"FinInstnId>Othr>Id,omitempty"`
+}
+
+type sepaAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+func writeSEPACreditTransfer(in paymentFileInput) (string, error) {
+	total := decimal.Zero
+	transfers := make([]sepaTransferInfo, 0, len(in.Transfers))
+	for _, t := range in.Transfers {
+		total = total.Add(t.Amount)
+		tx := sepaTransferInfo{
+			EndToEndID:      sepaIdentifier(t.PaymentID),
+			Amount:          sepaAmount{Currency: t.Currency, Value: t.Amount.StringFixed(2)},
+			Creditor:        sepaParty{Name: truncate(t.Beneficiary.AccountName, 70)},
+			CreditorAccount: sepaAccount{IBAN: t.Beneficiary.Iban},
+			Remittance:      truncate(t.Reference, 140),
+		}
+		if t.Beneficiary.Bic != "" {
+			tx.CreditorAgent = &sepaAgent{BIC: t.Beneficiary.Bic}
+		}
+		transfers = append(transfers, tx)
+	}
+
+	debtorAgent := sepaAgent{BIC: in.Account.Bic}
+	if in.Account.Bic == "" {
+		debtorAgent = sepaAgent{Other: "NOTPROVIDED"}
+	}
+	payer := sepaParty{Name: truncate(in.Payer.CompanyName, 70)}
+	doc := sepaDocument{Initiation: sepaInitiation{
+		GroupHeader: sepaGroupHeader{
+			MessageID:        sepaIdentifier(in.FileID),
+			CreationDateTime: in.CreatedAt.UTC().Format("2006-01-02T15:04:05"),
+			NumberOfTxs:      len(transfers),
+			ControlSum:       total.StringFixed(2),
+			InitiatingParty:  payer,
+		},
+		PaymentInfo: sepaPaymentInfo{
+			PaymentInfoID:          sepaIdentifier(in.FileID),
+			PaymentMethod:          "TRF",
+			NumberOfTxs:            len(transfers),
+			ControlSum:             total.StringFixed(2),
+			ServiceLevel:           "SEPA",
+			RequestedExecutionDate: in.ExecutionDate.Format("2006-01-02"),
+			Debtor:                 payer,
+			DebtorAccount:          sepaAccount{IBAN: in.Account.AccountNumber},
+			DebtorAgent:            debtorAgent,
+			ChargeBearer:           "SLEV",
+			Transfers:              transfers,
+		},
+	}}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out) + "\n", nil
+}
+
+// sepaIdentifier fits an internal ID into the 35 character Max35Text of pain.001
+func sepaIdentifier(id string) string {
+	return truncate(strings.ReplaceAll(id, "_", ""), 35)
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// --- NACHA (CCD credits) ---
+
+const (
+	nachaRecordLength   = 94
+	nachaBlockingFactor = 10
+	nachaCreditService  = "220" // Credits only
+	nachaCheckingCredit = "22"
+	nachaMaxEntryCents  = 9999999999
+)
+
+func writeNACHACredits(in paymentFileInput) (string, error) {
+	if !validRoutingNumber(in.Account.RoutingNumber) {
+		return "", fmt.Errorf("%w: %s is not a valid ABA routing number", domain.ErrInvalidPaymentRun, in.Account.RoutingNumber)
+	}
+	odfi := in.Account.RoutingNumber[:8]
+	companyID := nachaCompanyID(in.Payer.TaxRegistrationNumber)
+	var records []string
+
+	records = append(records, "101"+
+		" "+in.Account.RoutingNumber+
+		alpha(companyID, 10)+
+		in.CreatedAt.Format("060102")+in.CreatedAt.Format("1504")+
+		"A094101"+
+		alpha("", 23)+
+		alpha(in.Payer.CompanyName, 23)+
+		alpha("", 8))
+	records = append(records, "5"+nachaCreditService+
+		alpha(in.Payer.CompanyName, 16)+
+		alpha("", 20)+
+		alpha(companyID, 10)+
+		"CCD"+
+		alpha("VENDOR PAY", 10)+
+		in.ExecutionDate.Format("060102")+
+		in.ExecutionDate.Format("060102")+
+		"   "+
+		"1"+odfi+
+		numeric(1, 7))
+
+	var hash, credits int64
+	for i, t := range in.Transfers {
+		cents := t.Amount.Shift(2).Round(0).IntPart()
+		if cents > nachaMaxEntryCents {
+			return "", fmt.Errorf("%w: ACH entry of %s %s exceeds the NACHA amount field", domain.ErrInvalidPaymentRun, t.Amount.StringFixed(2), t.Currency)
+		}
+		rdfi := t.Beneficiary.RoutingNumber
+		dfi, _ := strconv.ParseInt(rdfi[:8], 10, 64)
+		hash += dfi
+		credits += cents
+		records = append(records, "6"+nachaCheckingCredit+
+			rdfi+
+			alpha(t.Beneficiary.AccountNumber, 17)+
+			numeric(cents, 10)+
+			alpha(t.VendorID, 15)+
+			alpha(t.Beneficiary.AccountName, 22)+
+			"  "+
+			"0"+
+			odfi+numeric(int64(i+1), 7))
+	}
+	hash %= 10000000000
+	entries := int64(len(in.Transfers))
+
+	records = append(records, "8"+nachaCreditService+
+		numeric(entries, 6)+
+		numeric(hash, 10)+
+		numeric(0, 12)+
+		numeric(credits, 12)+
+		alpha(companyID, 10)+
+		alpha("", 19)+
+		alpha("", 6)+
+		odfi+
+		numeric(1, 7))
+	blocks := (len(records) + 1 + nachaBlockingFactor - 1) / nachaBlockingFactor
+	records = append(records, "9"+
+		numeric(1, 6)+
+		numeric(int64(blocks), 6)+
+		numeric(entries, 8)+
+		numeric(hash, 10)+
+		numeric(0, 12)+
+		numeric(credits, 12)+
+		alpha("", 39))
+	for len(records)%nachaBlockingFactor != 0 {
+		records = append(records, strings.Repeat("9", nachaRecordLength))
+	}
+	return strings.Join(records, "\n") + "\n", nil
+}
+
+// nachaCompanyID derives the ten character originator ID from the company's EIN, prefixed
+// with the customary "1" when the registration number holds exactly nine digits.
+func nachaCompanyID(taxRegistration string) string {
+	var digits strings.Builder
+	for _, r := range taxRegistration {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	if digits.Len() == 9 {
+		return "1" + digits.String()
+	}
+	return digits.String()
+}
+
+// alpha left-justifies s in an upper-case, space-padded field of n characters. NACHA files
+// are plain ASCII, so anything else is blanked out.
+func alpha(s string, n int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return ' '
+		}
+		return r
+	}, strings.ToUpper(s))
+	s = truncate(s, n)
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+// numeric right-justifies v in a zero-padded field of n digits
+func numeric(v int64, n int) string {
+	return fmt.Sprintf("%0*d", n, v)
+}
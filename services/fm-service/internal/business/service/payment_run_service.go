@@ -0,0 +1,682 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentProposalRequest selects the bills a payment run proposes to pay.
+type PaymentProposalRequest struct {
+	LegalEntityID  string    `json:"legal_entity_id"`
+	DueBy          time.Time `json:"due_by"`
+	PaymentDate    time.Time `json:"payment_date"`
+	BankAccountIDs []string  `json:"bank_account_ids"` // Accounts to pay from in order of preference; all when empty
+}
+
+// VendorBankAccountRequest holds the bank details a vendor is paid to: an IBAN for SEPA
+// transfers and/or a routing and account number for ACH.
+type VendorBankAccountRequest struct {
+	AccountName   string `json:"account_name"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+	RoutingNumber string `json:"routing_number"`
+	AccountNumber string `json:"account_number"`
+}
+
+// PaymentRunDetail is a payment run with its bill lines and, once executed, its bank files.
+// File contents are left out; they are downloaded one file at a time.
+type PaymentRunDetail struct {
+	Run   *domain.PaymentRun      `json:"run"`
+	Lines []domain.PaymentRunLine `json:"lines"`
+	Files []domain.PaymentFile    `json:"files"`
+}
+
+// paymentBatch is the proposed lines of one vendor paid from one bank account in one payment
+type paymentBatch struct {
+	account     domain.BankAccount
+	format      domain.PaymentFileFormat
+	beneficiary domain.VendorBankAccount
+	vendorID    string
+	currency    string
+	amount      decimal.Decimal
+	lines       []*domain.PaymentRunLine
+	payment     *domain.Payment
+}
+
+type PaymentRunService struct {
+	runs           domain.PaymentRunRepository
+	lines          domain.PaymentRunLineRepository
+	files          domain.PaymentFileRepository
+	vendorAccounts domain.VendorBankAccountRepository
+	bills          domain.ApVendorBillRepository
+	bankAccounts   domain.BankAccountRepository
+	legalEntities  domain.LegalEntityRepository
+	cash           *CashManagementService
+	fx             *CurrencyConverter
+	gl             *GeneralLedgerService
+	outbox         domain.TransactionalOutboxRepository
+	tm             domain.TransactionManager
+}
+
+func NewPaymentRunService(
+	runs domain.PaymentRunRepository,
+	lines domain.PaymentRunLineRepository,
+	files domain.PaymentFileRepository,
+	vendorAccounts domain.VendorBankAccountRepository,
+	bills domain.ApVendorBillRepository,
+	bankAccounts domain.BankAccountRepository,
+	legalEntities domain.LegalEntityRepository,
+	cash *CashManagementService,
+	fx *CurrencyConverter,
+	gl *GeneralLedgerService,
+	outbox domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
+) *PaymentRunService {
+	return &PaymentRunService{
+		runs:           runs,
+		lines:          lines,
+		files:          files,
+		vendorAccounts: vendorAccounts,
+		bills:          bills,
+		bankAccounts:   bankAccounts,
+		legalEntities:  legalEntities,
+		cash:           cash,
+		fx:             fx,
+		gl:             gl,
+		outbox:         outbox,
+		tm:             tm,
+	}
+}
+
+// SetVendorBankAccount creates or replaces the bank details a vendor is paid to
+func (s *PaymentRunService) SetVendorBankAccount(ctx context.Context, vendorID string, req VendorBankAccountRequest) (*domain.VendorBankAccount, error) {
+	iban := strings.ToUpper(strings.ReplaceAll(req.IBAN, " ", ""))
+	bic := strings.ToUpper(strings.TrimSpace(req.BIC))
+	routing, accountNumber := strings.TrimSpace(req.RoutingNumber), strings.TrimSpace(req.AccountNumber)
+	switch {
+	case vendorID == "" || strings.TrimSpace(req.AccountName) == "":
+		return nil, fmt.Errorf("%w: vendor and account name are required", domain.ErrInvalidVendorBankAccount)
+	case iban == "" && routing == "":
+		return nil, fmt.Errorf("%w: an IBAN or an ACH routing number is required", domain.ErrInvalidVendorBankAccount)
+	case iban != "" && !validIBAN(iban):
+		return nil, fmt.Errorf("%w: %s is not a valid IBAN", domain.ErrInvalidVendorBankAccount, iban)
+	case bic != "" && !validBIC(bic):
+		return nil, fmt.Errorf("%w: %s is not a valid BIC", domain.ErrInvalidVendorBankAccount, bic)
+	case routing != "" && !validRoutingNumber(routing):
+		return nil, fmt.Errorf("%w: %s is not a valid ABA routing number", domain.ErrInvalidVendorBankAccount, routing)
+	case routing != "" && (accountNumber == "" || len(accountNumber) > 17):
+		return nil, fmt.Errorf("%w: ACH payments need an account number of up to 17 characters", domain.ErrInvalidVendorBankAccount)
+	}
+
+	var account *domain.VendorBankAccount
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.vendorAccounts.GetByVendorID(txCtx, vendorID)
+		if err != nil {
+			existing = &domain.VendorBankAccount{ID: utils.NewID("vba"), VendorID: vendorID, CreatedAt: time.Now()}
+		}
+		existing.AccountName = strings.TrimSpace(req.AccountName)
+		existing.Iban, existing.Bic = iban, bic
+		existing.RoutingNumber, existing.AccountNumber = routing, accountNumber
+		existing.UpdatedAt = time.Now()
+		account = existing
+		if err != nil {
+			return s.vendorAccounts.Create(txCtx, existing)
+		}
+		return s.vendorAccounts.Update(txCtx, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *PaymentRunService) GetVendorBankAccount(ctx context.Context, vendorID string) (*domain.VendorBankAccount, error) {
+	account, err := s.vendorAccounts.GetByVendorID(ctx, vendorID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrVendorBankAccountNotFound, vendorID)
+	}
+	return account, nil
+}
+
+// ProposePaymentRun proposes the open bills of a legal entity due by the horizon. Every bill
+// becomes a line: payable bills are routed to a bank account in their currency, the others
+// are kept as excluded lines with the reason, so AP sees why a due bill is not being paid.
+func (s *PaymentRunService) ProposePaymentRun(ctx context.Context, req PaymentProposalRequest, proposedBy string) (*PaymentRunDetail, error) {
+	if req.LegalEntityID == "" || req.DueBy.IsZero() {
+		return nil, fmt.Errorf("%w: legal entity and due-by date are required", domain.ErrInvalidPaymentRun)
+	}
+	if req.PaymentDate.IsZero() {
+		req.PaymentDate = time.Now()
+	}
+	functional, err := s.fx.FunctionalCurrency(ctx, req.LegalEntityID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPaymentRun, err)
+	}
+
+	run := &domain.PaymentRun{
+		ID:            utils.NewID("prun"),
+		LegalEntityID: req.LegalEntityID,
+		PaymentDate:   req.PaymentDate,
+		DueBy:         req.DueBy,
+		Status:        domain.PaymentRunStatusPROPOSED,
+		ProposedBy:    proposedBy,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	detail := &PaymentRunDetail{Run: run, Lines: []domain.PaymentRunLine{}, Files: []domain.PaymentFile{}}
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		payers, err := s.payingAccounts(txCtx, req.LegalEntityID, req.BankAccountIDs)
+		if err != nil {
+			return err
+		}
+		bills, err := s.dueBills(txCtx, req.LegalEntityID, req.DueBy)
+		if err != nil {
+			return err
+		}
+		onOpenRuns, err := s.billsOnOpenRuns(txCtx, req.LegalEntityID)
+		if err != nil {
+			return err
+		}
+
+		beneficiaries := map[string]*domain.VendorBankAccount{}
+		for _, bill := range bills {
+			line := domain.PaymentRunLine{
+				ID:         utils.NewID("prl"),
+				RunID:      run.ID,
+				BillID:     bill.ID,
+				BillNumber: bill.BillNumber,
+				VendorID:   bill.VendorID,
+				Currency:   bill.Currency,
+				Amount:     bill.OpenAmount(),
+				DueDate:    bill.DueDate,
+				Status:     domain.PaymentRunLineStatusPROPOSED,
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
+			}
+			if line.Currency == "" {
+				line.Currency = functional
+			}
+			payer, payable := payers[line.Currency]
+
+			if _, ok := beneficiaries[bill.VendorID]; !ok {
+				beneficiaries[bill.VendorID], _ = s.vendorAccounts.GetByVendorID(txCtx, bill.VendorID)
+			}
+			var format domain.PaymentFileFormat
+			if payable {
+				format, _ = paymentFileFormat(payer)
+			}
+
+			switch otherRun, onRun := onOpenRuns[bill.ID]; {
+			case bill.PaymentHold:
+				line.ExclusionReason = "bill is on payment hold"
+			case onRun:
+				line.ExclusionReason = fmt.Sprintf("bill is already proposed in payment run %s", otherRun)
+			case !payable:
+				line.ExclusionReason = fmt.Sprintf("no bank account in %s can issue SEPA or NACHA payments", line.Currency)
+			default:
+				line.ExclusionReason = missingBeneficiaryDetails(format, beneficiaries[bill.VendorID])
+			}
+			if line.ExclusionReason != "" {
+				line.Status = domain.PaymentRunLineStatusEXCLUDED
+			} else {
+				line.BankAccountID = payer.ID
+				run.BillCount++
+			}
+			detail.Lines = append(detail.Lines, line)
+		}
+
+		if err := s.runs.Create(txCtx, run); err != nil {
+			return err
+		}
+		return s.lines.CreateMany(txCtx, detail.Lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// payingAccounts picks, per currency, the bank account the legal entity pays from: the first
+// requested account that can issue payment files, or the first such account by ID.
+func (s *PaymentRunService) payingAccounts(ctx context.Context, legalEntityID string, requested []string) (map[string]domain.BankAccount, error) {
+	all, err := s.bankAccounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []domain.BankAccount
+	if len(requested) > 0 {
+		byID := make(map[string]domain.BankAccount, len(all))
+		for _, ba := range all {
+			byID[ba.ID] = ba
+		}
+		for _, id := range requested {
+			ba, ok := byID[id]
+			if !ok || ba.LegalEntityID != legalEntityID {
+				return nil, fmt.Errorf("%w: bank account %s does not belong to %s", domain.ErrInvalidPaymentRun, id, legalEntityID)
+			}
+			if _, ok := paymentFileFormat(ba); !ok {
+				return nil, fmt.Errorf("%w: bank account %s cannot issue SEPA or NACHA payments", domain.ErrInvalidPaymentRun, id)
+			}
+			candidates = append(candidates, ba)
+		}
+	} else {
+		for _, ba := range all {
+			if ba.LegalEntityID == legalEntityID {
+				candidates = append(candidates, ba)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	}
+
+	payers := map[string]domain.BankAccount{}
+	for _, ba := range candidates {
+		if _, taken := payers[ba.Currency]; taken {
+			continue
+		}
+		if _, ok := paymentFileFormat(ba); ok {
+			payers[ba.Currency] = ba
+		}
+	}
+	return payers, nil
+}
+
+// dueBills returns the unpaid bills of a legal entity due on or before dueBy, grouped by
+// vendor and oldest due first.
+func (s *PaymentRunService) dueBills(ctx context.Context, legalEntityID string, dueBy time.Time) ([]domain.ApVendorBill, error) {
+	all, err := s.bills.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var due []domain.ApVendorBill
+	for _, b := range all {
+		if b.LegalEntityID == legalEntityID && b.Status != domain.PaymentStatusPAID && !b.DueDate.After(dueBy) && b.OpenAmount().IsPositive() {
+			due = append(due, b)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].VendorID != due[j].VendorID {
+			return due[i].VendorID < due[j].VendorID
+		}
+		if !due[i].DueDate.Equal(due[j].DueDate) {
+			return due[i].DueDate.Before(due[j].DueDate)
+		}
+		return due[i].BillNumber < due[j].BillNumber
+	})
+	return due, nil
+}
+
+// billsOnOpenRuns maps the bills proposed in runs still awaiting approval to their run, so
+// that two runs never pay the same bill.
+func (s *PaymentRunService) billsOnOpenRuns(ctx context.Context, legalEntityID string) (map[string]string, error) {
+	runs, err := s.runs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposed := map[string]string{}
+	for _, run := range runs {
+		if run.LegalEntityID != legalEntityID || run.Status != domain.PaymentRunStatusPROPOSED {
+			continue
+		}
+		lines, err := s.lines.ListByRun(ctx, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lines {
+			if l.Status == domain.PaymentRunLineStatusPROPOSED {
+				proposed[l.BillID] = run.ID
+			}
+		}
+	}
+	return proposed, nil
+}
+
+// ApprovePaymentRun approves and executes a proposed run in one transaction: one payment per
+// vendor and bank account settles the bills, the payables are relieved in the GL and a SEPA
+// or NACHA file is written per bank account. Bills held or settled since the proposal are
+// excluded rather than paid.
+func (s *PaymentRunService) ApprovePaymentRun(ctx context.Context, id, approvedBy string) (*PaymentRunDetail, error) {
+	if strings.TrimSpace(approvedBy) == "" {
+		return nil, fmt.Errorf("%w: an approver is required", domain.ErrInvalidPaymentRun)
+	}
+
+	var detail *PaymentRunDetail
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		run, err := s.proposedRun(txCtx, id)
+		if err != nil {
+			return err
+		}
+		payer, err := s.legalEntities.GetByID(txCtx, run.LegalEntityID)
+		if err != nil {
+			return err
+		}
+		lines, err := s.lines.ListByRun(txCtx, run.ID)
+		if err != nil {
+			return err
+		}
+		batches, err := s.payableBatches(txCtx, lines)
+		if err != nil {
+			return err
+		}
+		if len(batches) == 0 {
+			return fmt.Errorf("%w: no bills left to pay in run %s", domain.ErrInvalidPaymentRun, run.ID)
+		}
+
+		run.BillCount = 0
+		for _, b := range batches {
+			if err := s.pay(txCtx, run, b); err != nil {
+				return err
+			}
+			run.BillCount += len(b.lines)
+		}
+		fileIDs, err := s.writeFiles(txCtx, run, *payer, batches)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		run.Status = domain.PaymentRunStatusEXECUTED
+		run.PaymentCount = len(batches)
+		run.ApprovedBy = &approvedBy
+		run.ExecutedAt = &now
+		run.UpdatedAt = now
+		if err := s.runs.Update(txCtx, run); err != nil {
+			return err
+		}
+
+		if err := s.outbox.Create(txCtx, &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   domain.TopicFmPaymentRunExecuted,
+			AggregateID: run.ID,
+			Payload: domain.PaymentRunEventPayload{
+				RunID:         run.ID,
+				LegalEntityID: run.LegalEntityID,
+				PaymentDate:   run.PaymentDate,
+				PaymentCount:  run.PaymentCount,
+				BillCount:     run.BillCount,
+				FileIDs:       fileIDs,
+				ApprovedBy:    approvedBy,
+				Timestamp:     now,
+			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: now,
+		}); err != nil {
+			return err
+		}
+
+		detail, err = s.runDetail(txCtx, run)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// payableBatches re-checks the proposed lines against the current bills and groups what is
+// still payable by bank account and vendor. Lines that can no longer be paid are excluded.
+func (s *PaymentRunService) payableBatches(ctx context.Context, lines []domain.PaymentRunLine) ([]*paymentBatch, error) {
+	var batches []*paymentBatch
+	byKey := map[string]*paymentBatch{}
+	accounts := map[string]*domain.BankAccount{}
+	beneficiaries := map[string]*domain.VendorBankAccount{}
+
+	for i := range lines {
+		line := &lines[i]
+		if line.Status != domain.PaymentRunLineStatusPROPOSED {
+			continue
+		}
+		bill, err := s.bills.GetByID(ctx, line.BillID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrVendorBillNotFound, line.BillID)
+		}
+		if _, ok := accounts[line.BankAccountID]; !ok {
+			if accounts[line.BankAccountID], err = s.bankAccounts.GetByID(ctx, line.BankAccountID); err != nil {
+				return nil, err
+			}
+		}
+		account := accounts[line.BankAccountID]
+		format, payable := paymentFileFormat(*account)
+		if _, ok := beneficiaries[line.VendorID]; !ok {
+			beneficiaries[line.VendorID], _ = s.vendorAccounts.GetByVendorID(ctx, line.VendorID)
+		}
+
+		open := bill.OpenAmount()
+		switch {
+		case bill.PaymentHold:
+			line.ExclusionReason = "bill was put on payment hold after the proposal"
+		case !open.IsPositive():
+			line.ExclusionReason = "bill was settled after the proposal"
+		case !payable:
+			line.ExclusionReason = "paying bank account no longer has a valid IBAN or routing number"
+		default:
+			line.ExclusionReason = missingBeneficiaryDetails(format, beneficiaries[line.VendorID])
+		}
+		if line.ExclusionReason != "" {
+			line.Status = domain.PaymentRunLineStatusEXCLUDED
+			line.UpdatedAt = time.Now()
+			if err := s.lines.Update(ctx, line); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if open.LessThan(line.Amount) {
+			line.Amount = open
+		}
+
+		key := line.BankAccountID + "/" + line.VendorID
+		b, ok := byKey[key]
+		if !ok {
+			b = &paymentBatch{
+				account:     *account,
+				format:      format,
+				beneficiary: *beneficiaries[line.VendorID],
+				vendorID:    line.VendorID,
+				currency:    line.Currency,
+				amount:      decimal.Zero,
+			}
+			byKey[key] = b
+			batches = append(batches, b)
+		}
+		b.amount = b.amount.Add(line.Amount)
+		b.lines = append(b.lines, line)
+	}
+
+	sort.SliceStable(batches, func(i, j int) bool {
+		if batches[i].account.ID != batches[j].account.ID {
+			return batches[i].account.ID < batches[j].account.ID
+		}
+		return batches[i].vendorID < batches[j].vendorID
+	})
+	return batches, nil
+}
+
+// pay records the payment of one batch against its bills, relieves the payables against the
+// outgoing payments clearing account and marks the lines paid.
+func (s *PaymentRunService) pay(ctx context.Context, run *domain.PaymentRun, b *paymentBatch) error {
+	allocations := make([]AllocationRequest, 0, len(b.lines))
+	for _, l := range b.lines {
+		allocations = append(allocations, AllocationRequest{BillID: l.BillID, Amount: l.Amount})
+	}
+	result, err := s.cash.RecordAllocatedPayment(ctx, PaymentRequest{
+		LegalEntityID:    run.LegalEntityID,
+		CounterpartyType: domain.CounterpartyTypeVENDOR,
+		CounterpartyID:   b.vendorID,
+		BankAccountID:    b.account.ID,
+		Amount:           b.amount,
+		Currency:         b.currency,
+		PaymentMethod:    paymentMethod(b.format),
+		PaymentDate:      run.PaymentDate,
+		Allocations:      allocations,
+	})
+	if err != nil {
+		return err
+	}
+	b.payment = result.Payment
+
+	// Realized FX differences are already posted by the settlement; the payables are relieved
+	// at the payment date rate against the clearing account that bank reconciliation empties
+	// once the bank has debited the payment file.
+	_, rate, err := s.fx.DocumentRate(ctx, run.LegalEntityID, b.currency, run.PaymentDate)
+	if err != nil {
+		return err
+	}
+	payables, err := s.gl.DetermineAccount(ctx, run.LegalEntityID, domain.PostingKeyAP_CONTROL)
+	if err != nil {
+		return err
+	}
+	clearing, err := s.gl.DetermineAccount(ctx, run.LegalEntityID, domain.PostingKeyPAYMENT_CLEARING)
+	if err != nil {
+		return err
+	}
+	functional := convertAmount(b.amount, rate)
+	if _, err := s.gl.CreateJournalEntry(ctx, run.LegalEntityID, "AP", b.payment.ID, run.PaymentDate, []domain.UniversalJournalLine{
+		{AccountID: payables.ID, AmountTransactional: b.amount, AmountFunctional: functional, CurrencyTransactional: b.currency, ExchangeRate: rate},
+		{AccountID: clearing.ID, AmountTransactional: b.amount.Neg(), AmountFunctional: functional.Neg(), CurrencyTransactional: b.currency, ExchangeRate: rate},
+	}); err != nil {
+		return err
+	}
+
+	for _, l := range b.lines {
+		l.Status = domain.PaymentRunLineStatusPAID
+		l.PaymentID = &b.payment.ID
+		l.UpdatedAt = time.Now()
+		if err := s.lines.Update(ctx, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFiles writes one payment file per paying bank account and returns the file IDs
+func (s *PaymentRunService) writeFiles(ctx context.Context, run *domain.PaymentRun, payer domain.LegalEntity, batches []*paymentBatch) ([]string, error) {
+	var fileIDs []string
+	for start := 0; start < len(batches); {
+		end := start
+		for end < len(batches) && batches[end].account.ID == batches[start].account.ID {
+			end++
+		}
+		account, format := batches[start].account, batches[start].format
+
+		file := &domain.PaymentFile{
+			ID:            utils.NewID("pfile"),
+			RunID:         run.ID,
+			BankAccountID: account.ID,
+			Format:        format,
+			FileName:      paymentFileName(run.ID, account.ID, format),
+			TotalAmount:   decimal.Zero,
+			CreatedAt:     time.Now(),
+		}
+		in := paymentFileInput{FileID: file.ID, Payer: payer, Account: account, ExecutionDate: run.PaymentDate, CreatedAt: file.CreatedAt}
+		for _, b := range batches[start:end] {
+			refs := make([]string, 0, len(b.lines))
+			for _, l := range b.lines {
+				refs = append(refs, l.BillNumber)
+			}
+			in.Transfers = append(in.Transfers, creditTransfer{
+				PaymentID:   b.payment.ID,
+				VendorID:    b.vendorID,
+				Beneficiary: b.beneficiary,
+				Amount:      b.amount,
+				Currency:    b.currency,
+				Reference:   strings.Join(refs, ", "),
+			})
+			file.TotalAmount = file.TotalAmount.Add(b.amount)
+		}
+		file.PaymentCount = len(in.Transfers)
+
+		content, err := writePaymentFile(format, in)
+		if err != nil {
+			return nil, err
+		}
+		file.Content = content
+		if err := s.files.Create(ctx, file); err != nil {
+			return nil, err
+		}
+		fileIDs = append(fileIDs, file.ID)
+		start = end
+	}
+	return fileIDs, nil
+}
+
+// CancelPaymentRun discards a proposal, which releases its bills for later runs
+func (s *PaymentRunService) CancelPaymentRun(ctx context.Context, id string) (*domain.PaymentRun, error) {
+	var run *domain.PaymentRun
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if run, err = s.proposedRun(txCtx, id); err != nil {
+			return err
+		}
+		run.Status = domain.PaymentRunStatusCANCELLED
+		run.UpdatedAt = time.Now()
+		return s.runs.Update(txCtx, run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *PaymentRunService) proposedRun(ctx context.Context, id string) (*domain.PaymentRun, error) {
+	run, err := s.runs.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentRunNotFound, id)
+	}
+	if run.Status != domain.PaymentRunStatusPROPOSED {
+		return nil, fmt.Errorf("%w: run %s is %s", domain.ErrPaymentRunNotProposed, id, run.Status)
+	}
+	return run, nil
+}
+
+func (s *PaymentRunService) GetPaymentRun(ctx context.Context, id string) (*PaymentRunDetail, error) {
+	run, err := s.runs.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentRunNotFound, id)
+	}
+	return s.runDetail(ctx, run)
+}
+
+func (s *PaymentRunService) runDetail(ctx context.Context, run *domain.PaymentRun) (*PaymentRunDetail, error) {
+	lines, err := s.lines.ListByRun(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.files.ListByRun(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		files[i].Content = ""
+	}
+	return &PaymentRunDetail{Run: run, Lines: lines, Files: files}, nil
+}
+
+// ListPaymentRuns returns the payment runs, optionally of a single legal entity.
+func (s *PaymentRunService) ListPaymentRuns(ctx context.Context, legalEntityID string) ([]domain.PaymentRun, error) {
+	runs, err := s.runs.List(ctx)
+	if err != nil || legalEntityID == "" {
+		return runs, err
+	}
+	list := make([]domain.PaymentRun, 0, len(runs))
+	for _, run := range runs {
+		if run.LegalEntityID == legalEntityID {
+			list = append(list, run)
+		}
+	}
+	return list, nil
+}
+
+// GetPaymentFile returns a file of a run including its content
+func (s *PaymentRunService) GetPaymentFile(ctx context.Context, runID, fileID string) (*domain.PaymentFile, error) {
+	file, err := s.files.GetByID(ctx, fileID)
+	if err != nil || file.RunID != runID {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPaymentFileNotFound, fileID)
+	}
+	return file, nil
+}
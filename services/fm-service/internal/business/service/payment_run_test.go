@@ -0,0 +1,541 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func seedVendorBill(t *testing.T, bills *memory.MemoryApVendorBillRepo, id, vendorID, currency string, total, paid int64, due time.Time, hold bool) {
+	t.Helper()
+	status, rate := domain.PaymentStatusOPEN, decimal.NewFromInt(1)
+	if paid >= total {
+		status = domain.PaymentStatusPAID
+	}
+	if currency == "USD" {
+		rate = decimal.RequireFromString("0.95")
+	}
+	err := bills.Create(context.Background(), &domain.ApVendorBill{
+		ID: id, LegalEntityID: "le_1", BillNumber: "BILL-" + id, VendorID: vendorID, Currency: currency,
+		TotalAmount: decimal.NewFromInt(total), AmountPaid: decimal.NewFromInt(paid), ExchangeRate: rate,
+		DueDate: due, Status: status, PaymentHold: hold,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed bill: %v", err)
+	}
+}
+
+func proposePaymentRun(t *testing.T, svc *service.PaymentRunService) *service.PaymentRunDetail {
+	t.Helper()
+	detail, err := svc.ProposePaymentRun(context.Background(), service.PaymentProposalRequest{
+		LegalEntityID: "le_1", DueBy: day(2026, 3, 10), PaymentDate: day(2026, 3, 12),
+	}, "ap.clerk")
+	if err != nil {
+		t.Fatalf("failed to propose payment run: %v", err)
+	}
+	return detail
+}
+
+func ledgerBalance(t *testing.T, accounts *memory.MemoryChartOfAccountsRepo, gl *service.GeneralLedgerService, code string) decimal.Decimal {
+	t.Helper()
+	acc, err := accounts.GetByCode(context.Background(), "le_1", code)
+	if err != nil {
+		t.Fatalf("account %s not found: %v", code, err)
+	}
+	balance, _ := gl.GetAccountBalance(context.Background(), acc.ID)
+	return balance
+}
+
+func linesByBill(detail *service.PaymentRunDetail) map[string]domain.PaymentRunLine {
+	byBill := map[string]domain.PaymentRunLine{}
+	for _, l := range detail.Lines {
+		byBill[l.BillID] = l
+	}
+	return byBill
+}
+
+func TestSetVendorBankAccount_Validation(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	runs := memory.NewMemoryPaymentRunRepo()
+	runLines := memory.NewMemoryPaymentRunLineRepo()
+	files := memory.NewMemoryPaymentFileRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, bills, payments, allocations, runs, runLines, files, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, memory.NewMemoryArInvoiceRepo(), bills, bankAccounts, memory.NewMemoryFxRevaluationRepo(), gl, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     memory.NewMemoryArInvoiceRepo(),
+		Bills:        bills,
+		Allocations:  allocations,
+		BankAccounts: bankAccounts,
+		GL:           gl,
+		FX:           fx,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	svc := service.NewPaymentRunService(runs, runLines, files, memory.NewMemoryVendorBankAccountRepo(), bills, bankAccounts, legalEntities, cash, converter, gl, outbox, tm)
+	ctx := context.Background()
+
+	// A EUR legal entity pays from a SEPA-capable EUR account and an ACH-capable USD account.
+	// Vendor v1 is paid by IBAN, v2 by ACH and v3 has no bank details.
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme Handels GmbH", FunctionalCurrency: "EUR", TaxRegistrationNumber: "12-3456789"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "USD", ToCurrency: "EUR", Rate: decimal.RequireFromString("0.90"), EffectiveDate: day(2026, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_1", AccountNumber: "DE89370400440532013000", Bic: "COBADEFFXXX", Currency: "EUR"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_usd", LegalEntityID: "le_1", AccountNumber: "4400123", RoutingNumber: "021000021", Currency: "USD"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_gbp", LegalEntityID: "le_1", AccountNumber: "GB001", Currency: "GBP"})
+	if _, err := svc.SetVendorBankAccount(ctx, "v1", service.VendorBankAccountRequest{AccountName: "Fournitures SARL", IBAN: "FR14 2004 1010 0505 0001 3M02 606", BIC: "psstfrppxxx"}); err != nil {
+		t.Fatalf("failed to set SEPA vendor details: %v", err)
+	}
+	if _, err := svc.SetVendorBankAccount(ctx, "v2", service.VendorBankAccountRequest{AccountName: "Widgets Inc", RoutingNumber: "011000015", AccountNumber: "987654"}); err != nil {
+		t.Fatalf("failed to set ACH vendor details: %v", err)
+	}
+
+	cases := map[string]service.VendorBankAccountRequest{
+		"missing name":        {IBAN: "DE89370400440532013000"},
+		"no account":          {AccountName: "Vendor"},
+		"bad IBAN checksum":   {AccountName: "Vendor", IBAN: "DE89370400440532013001"},
+		"bad BIC":             {AccountName: "Vendor", IBAN: "DE89370400440532013000", BIC: "12ABCDEF"},
+		"bad routing number":  {AccountName: "Vendor", RoutingNumber: "021000022", AccountNumber: "1"},
+		"ACH without account": {AccountName: "Vendor", RoutingNumber: "021000021"},
+	}
+	for name, req := range cases {
+		if _, err := svc.SetVendorBankAccount(ctx, "v9", req); !errors.Is(err, domain.ErrInvalidVendorBankAccount) {
+			t.Errorf("%s: expected ErrInvalidVendorBankAccount, got %v", name, err)
+		}
+	}
+
+	// Details are normalised and replaced in place
+	account, err := svc.GetVendorBankAccount(ctx, "v1")
+	if err != nil || account.Iban != "FR1420041010050500013M02606" || account.Bic != "PSSTFRPPXXX" {
+		t.Fatalf("expected a compact IBAN and upper-case BIC, got %+v, %v", account, err)
+	}
+	updated, err := svc.SetVendorBankAccount(ctx, "v1", service.VendorBankAccountRequest{AccountName: "Fournitures SA", IBAN: "DE89370400440532013000"})
+	if err != nil || updated.ID != account.ID || updated.AccountName != "Fournitures SA" || updated.Bic != "" {
+		t.Errorf("expected the vendor's details to be replaced, got %+v, %v", updated, err)
+	}
+	if _, err := svc.GetVendorBankAccount(ctx, "v9"); !errors.Is(err, domain.ErrVendorBankAccountNotFound) {
+		t.Errorf("expected ErrVendorBankAccountNotFound, got %v", err)
+	}
+}
+
+func TestProposePaymentRun_RoutesAndExcludesBills(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	runs := memory.NewMemoryPaymentRunRepo()
+	runLines := memory.NewMemoryPaymentRunLineRepo()
+	files := memory.NewMemoryPaymentFileRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, bills, payments, allocations, runs, runLines, files, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, memory.NewMemoryArInvoiceRepo(), bills, bankAccounts, memory.NewMemoryFxRevaluationRepo(), gl, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     memory.NewMemoryArInvoiceRepo(),
+		Bills:        bills,
+		Allocations:  allocations,
+		BankAccounts: bankAccounts,
+		GL:           gl,
+		FX:           fx,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	svc := service.NewPaymentRunService(runs, runLines, files, memory.NewMemoryVendorBankAccountRepo(), bills, bankAccounts, legalEntities, cash, converter, gl, outbox, tm)
+	ctx := context.Background()
+
+	// A EUR legal entity pays from a SEPA-capable EUR account and an ACH-capable USD account.
+	// Vendor v1 is paid by IBAN, v2 by ACH and v3 has no bank details.
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme Handels GmbH", FunctionalCurrency: "EUR", TaxRegistrationNumber: "12-3456789"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "USD", ToCurrency: "EUR", Rate: decimal.RequireFromString("0.90"), EffectiveDate: day(2026, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_1", AccountNumber: "DE89370400440532013000", Bic: "COBADEFFXXX", Currency: "EUR"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_usd", LegalEntityID: "le_1", AccountNumber: "4400123", RoutingNumber: "021000021", Currency: "USD"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_gbp", LegalEntityID: "le_1", AccountNumber: "GB001", Currency: "GBP"})
+	if _, err := svc.SetVendorBankAccount(ctx, "v1", service.VendorBankAccountRequest{AccountName: "Fournitures SARL", IBAN: "FR14 2004 1010 0505 0001 3M02 606", BIC: "psstfrppxxx"}); err != nil {
+		t.Fatalf("failed to set SEPA vendor details: %v", err)
+	}
+	if _, err := svc.SetVendorBankAccount(ctx, "v2", service.VendorBankAccountRequest{AccountName: "Widgets Inc", RoutingNumber: "011000015", AccountNumber: "987654"}); err != nil {
+		t.Fatalf("failed to set ACH vendor details: %v", err)
+	}
+	seedVendorBill(t, bills, "b1", "v1", "EUR", 1000, 0, day(2026, 3, 1), false)
+	seedVendorBill(t, bills, "b2", "v1", "", 500, 100, day(2026, 3, 5), false)
+	seedVendorBill(t, bills, "b3", "v1", "EUR", 300, 0, day(2026, 3, 20), false)
+	seedVendorBill(t, bills, "b4", "v2", "USD", 2000, 0, day(2026, 3, 2), false)
+	seedVendorBill(t, bills, "b5", "v2", "EUR", 700, 0, day(2026, 3, 3), false)
+	seedVendorBill(t, bills, "b6", "v3", "EUR", 50, 0, day(2026, 3, 3), false)
+	seedVendorBill(t, bills, "b7", "v1", "EUR", 80, 0, day(2026, 3, 3), true)
+	seedVendorBill(t, bills, "b8", "v1", "GBP", 90, 0, day(2026, 3, 3), false)
+	seedVendorBill(t, bills, "b9", "v1", "EUR", 60, 60, day(2026, 3, 3), false)
+
+	detail := proposePaymentRun(t, svc)
+	lines := linesByBill(detail)
+	if len(lines) != 7 || detail.Run.BillCount != 3 || detail.Run.Status != domain.PaymentRunStatusPROPOSED || detail.Run.ProposedBy != "ap.clerk" {
+		t.Fatalf("expected 7 lines with 3 bills proposed, got %d lines and %+v", len(lines), detail.Run)
+	}
+	if _, ok := lines["b3"]; ok {
+		t.Error("a bill due after the horizon must not be proposed")
+	}
+	if _, ok := lines["b9"]; ok {
+		t.Error("a paid bill must not be proposed")
+	}
+
+	for bill, want := range map[string]struct {
+		account  string
+		currency string
+		amount   int64
+	}{"b1": {"ba_eur", "EUR", 1000}, "b2": {"ba_eur", "EUR", 400}, "b4": {"ba_usd", "USD", 2000}} {
+		l := lines[bill]
+		if l.Status != domain.PaymentRunLineStatusPROPOSED || l.BankAccountID != want.account || l.Currency != want.currency || !l.Amount.Equal(decimal.NewFromInt(want.amount)) {
+			t.Errorf("%s: expected %d %s from %s, got %+v", bill, want.amount, want.currency, want.account, l)
+		}
+	}
+	for bill, reason := range map[string]string{"b5": "IBAN", "b6": "no bank details", "b7": "payment hold", "b8": "no bank account in GBP"} {
+		if l := lines[bill]; l.Status != domain.PaymentRunLineStatusEXCLUDED || !strings.Contains(l.ExclusionReason, reason) {
+			t.Errorf("%s: expected exclusion mentioning %q, got %s %q", bill, reason, l.Status, l.ExclusionReason)
+		}
+	}
+
+	// A second proposal does not pick up bills still awaiting approval
+	second := proposePaymentRun(t, svc)
+	if l := linesByBill(second)["b1"]; l.Status != domain.PaymentRunLineStatusEXCLUDED || !strings.Contains(l.ExclusionReason, detail.Run.ID) {
+		t.Errorf("expected b1 to be excluded as already proposed, got %+v", l)
+	}
+
+	// Cancelling the first run releases its bills
+	cancelled, err := svc.CancelPaymentRun(ctx, detail.Run.ID)
+	if err != nil || cancelled.Status != domain.PaymentRunStatusCANCELLED {
+		t.Fatalf("failed to cancel run: %+v, %v", cancelled, err)
+	}
+	_, _ = svc.CancelPaymentRun(ctx, second.Run.ID)
+	if l := linesByBill(proposePaymentRun(t, svc))["b1"]; l.Status != domain.PaymentRunLineStatusPROPOSED {
+		t.Errorf("expected b1 to be proposed again once the runs are cancelled, got %+v", l)
+	}
+	if _, err := svc.CancelPaymentRun(ctx, detail.Run.ID); !errors.Is(err, domain.ErrPaymentRunNotProposed) {
+		t.Errorf("expected ErrPaymentRunNotProposed when cancelling twice, got %v", err)
+	}
+
+	if _, err := svc.ProposePaymentRun(ctx, service.PaymentProposalRequest{LegalEntityID: "le_1", DueBy: day(2026, 3, 10), BankAccountIDs: []string{"ba_gbp"}}, "ap.clerk"); !errors.Is(err, domain.ErrInvalidPaymentRun) {
+		t.Errorf("expected ErrInvalidPaymentRun for an account that cannot issue payment files, got %v", err)
+	}
+	if _, err := svc.ProposePaymentRun(ctx, service.PaymentProposalRequest{LegalEntityID: "le_1"}, "ap.clerk"); !errors.Is(err, domain.ErrInvalidPaymentRun) {
+		t.Errorf("expected ErrInvalidPaymentRun without a horizon, got %v", err)
+	}
+}
+
+func TestApprovePaymentRun_PaysBillsAndWritesFiles(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	runs := memory.NewMemoryPaymentRunRepo()
+	runLines := memory.NewMemoryPaymentRunLineRepo()
+	files := memory.NewMemoryPaymentFileRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, bills, payments, allocations, runs, runLines, files, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, memory.NewMemoryArInvoiceRepo(), bills, bankAccounts, memory.NewMemoryFxRevaluationRepo(), gl, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     memory.NewMemoryArInvoiceRepo(),
+		Bills:        bills,
+		Allocations:  allocations,
+		BankAccounts: bankAccounts,
+		GL:           gl,
+		FX:           fx,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	svc := service.NewPaymentRunService(runs, runLines, files, memory.NewMemoryVendorBankAccountRepo(), bills, bankAccounts, legalEntities, cash, converter, gl, outbox, tm)
+	ctx := context.Background()
+
+	// A EUR legal entity pays from a SEPA-capable EUR account and an ACH-capable USD account.
+	// Vendor v1 is paid by IBAN, v2 by ACH and v3 has no bank details.
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme Handels GmbH", FunctionalCurrency: "EUR", TaxRegistrationNumber: "12-3456789"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "USD", ToCurrency: "EUR", Rate: decimal.RequireFromString("0.90"), EffectiveDate: day(2026, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_1", AccountNumber: "DE89370400440532013000", Bic: "COBADEFFXXX", Currency: "EUR"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_usd", LegalEntityID: "le_1", AccountNumber: "4400123", RoutingNumber: "021000021", Currency: "USD"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_gbp", LegalEntityID: "le_1", AccountNumber: "GB001", Currency: "GBP"})
+	if _, err := svc.SetVendorBankAccount(ctx, "v1", service.VendorBankAccountRequest{AccountName: "Fournitures SARL", IBAN: "FR14 2004 1010 0505 0001 3M02 606", BIC: "psstfrppxxx"}); err != nil {
+		t.Fatalf("failed to set SEPA vendor details: %v", err)
+	}
+	if _, err := svc.SetVendorBankAccount(ctx, "v2", service.VendorBankAccountRequest{AccountName: "Widgets Inc", RoutingNumber: "011000015", AccountNumber: "987654"}); err != nil {
+		t.Fatalf("failed to set ACH vendor details: %v", err)
+	}
+	seedVendorBill(t, bills, "b1", "v1", "EUR", 1000, 0, day(2026, 3, 1), false)
+	seedVendorBill(t, bills, "b2", "v1", "EUR", 500, 100, day(2026, 3, 5), false)
+	seedVendorBill(t, bills, "b3", "v1", "EUR", 250, 0, day(2026, 3, 6), false)
+	seedVendorBill(t, bills, "b4", "v2", "USD", 2000, 0, day(2026, 3, 2), false)
+	proposal := proposePaymentRun(t, svc)
+
+	// A bill put on hold between proposal and approval is dropped, not paid
+	held, _ := bills.GetByID(ctx, "b3")
+	held.PaymentHold = true
+	_ = bills.Update(ctx, held)
+
+	if _, err := svc.ApprovePaymentRun(ctx, proposal.Run.ID, ""); !errors.Is(err, domain.ErrInvalidPaymentRun) {
+		t.Errorf("expected ErrInvalidPaymentRun without an approver, got %v", err)
+	}
+	detail, err := svc.ApprovePaymentRun(ctx, proposal.Run.ID, "ap.manager")
+	if err != nil {
+		t.Fatalf("failed to approve run: %v", err)
+	}
+	run := detail.Run
+	if run.Status != domain.PaymentRunStatusEXECUTED || run.ApprovedBy == nil || *run.ApprovedBy != "ap.manager" || run.PaymentCount != 2 || run.BillCount != 3 {
+		t.Fatalf("expected an executed run with 2 payments for 3 bills, got %+v", run)
+	}
+
+	lines := linesByBill(detail)
+	if l := lines["b3"]; l.Status != domain.PaymentRunLineStatusEXCLUDED || !strings.Contains(l.ExclusionReason, "hold") {
+		t.Errorf("expected the held bill to be excluded, got %+v", l)
+	}
+	if lines["b1"].PaymentID == nil || lines["b2"].PaymentID == nil || *lines["b1"].PaymentID != *lines["b2"].PaymentID {
+		t.Errorf("expected b1 and b2 to share one payment, got %+v and %+v", lines["b1"], lines["b2"])
+	}
+	for _, id := range []string{"b1", "b2", "b4"} {
+		if b, _ := bills.GetByID(ctx, id); b.Status != domain.PaymentStatusPAID {
+			t.Errorf("%s: expected PAID, got %s", id, b.Status)
+		}
+	}
+	recorded, _ := payments.List(ctx)
+	if len(recorded) != 2 {
+		t.Fatalf("expected 2 payments, got %d", len(recorded))
+	}
+	for _, p := range recorded {
+		if p.CounterpartyID == "v1" && (p.PaymentMethod != "SEPA_CREDIT_TRANSFER" || !p.Amount.Equal(decimal.NewFromInt(1400))) {
+			t.Errorf("unexpected SEPA payment: %+v", p)
+		}
+		if p.CounterpartyID == "v2" && (p.PaymentMethod != "ACH" || p.Currency != "USD" || !p.Amount.Equal(decimal.NewFromInt(2000))) {
+			t.Errorf("unexpected ACH payment: %+v", p)
+		}
+	}
+
+	// The USD bill was booked at 0.95 and is paid at 0.90: the payables carried at 1400 + 1900
+	// are cleared in full, 1400 + 1800 leaves through clearing and 100 is a realized gain
+	assertAmount(t, "accounts payable", ledgerBalance(t, accounts, gl, "2110-001"), -3300)
+	assertAmount(t, "outgoing payments clearing", ledgerBalance(t, accounts, gl, "1090-001"), -3200)
+	assertAmount(t, "realized FX gain", ledgerBalance(t, accounts, gl, "7920-001"), 100)
+
+	if len(detail.Files) != 2 {
+		t.Fatalf("expected one file per bank account, got %d", len(detail.Files))
+	}
+	for _, f := range detail.Files {
+		if f.Content != "" {
+			t.Error("run details must not carry file contents")
+		}
+		file, err := svc.GetPaymentFile(ctx, run.ID, f.ID)
+		if err != nil {
+			t.Fatalf("failed to load file: %v", err)
+		}
+		switch file.Format {
+		case domain.PaymentFileFormatSEPA_PAIN_001:
+			for _, want := range []string{
+				`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">`,
+				"<NbOfTxs>1</NbOfTxs>", "<CtrlSum>1400.00</CtrlSum>", "<ReqdExctnDt>2026-03-12</ReqdExctnDt>",
+				"<Nm>Acme Handels GmbH</Nm>", "<IBAN>DE89370400440532013000</IBAN>", "<BIC>COBADEFFXXX</BIC>",
+				`<InstdAmt Ccy="EUR">1400.00</InstdAmt>`, "<IBAN>FR1420041010050500013M02606</IBAN>", "<Ustrd>BILL-b1, BILL-b2</Ustrd>",
+			} {
+				if !strings.Contains(file.Content, want) {
+					t.Errorf("SEPA file is missing %s:\n%s", want, file.Content)
+				}
+			}
+		case domain.PaymentFileFormatNACHA:
+			records := strings.Split(strings.TrimSuffix(file.Content, "\n"), "\n")
+			if len(records) != 10 {
+				t.Fatalf("expected one block of 10 records, got %d", len(records))
+			}
+			for i, r := range records {
+				if len(r) != 94 {
+					t.Errorf("record %d is %d characters long", i+1, len(r))
+				}
+			}
+			entry := records[2]
+			if !strings.HasPrefix(entry, "622011000015987654") || entry[29:39] != "0000200000" || !strings.Contains(entry, "WIDGETS INC") {
+				t.Errorf("unexpected entry detail record: %q", entry)
+			}
+			if !strings.HasPrefix(records[1], "5220ACME HANDELS GMB ") || records[1][40:50] != "1123456789" {
+				t.Errorf("unexpected batch header: %q", records[1])
+			}
+			if control := records[4]; !strings.HasPrefix(control, "9000001000001000000010001100001") {
+				t.Errorf("unexpected file control record: %q", control)
+			}
+		default:
+			t.Errorf("unexpected file format %s", file.Format)
+		}
+	}
+	if n := countTopic(t, outbox, domain.TopicFmPaymentRunExecuted); n != 1 {
+		t.Errorf("expected one payment run executed event, got %d", n)
+	}
+
+	if _, err := svc.ApprovePaymentRun(ctx, run.ID, "ap.manager"); !errors.Is(err, domain.ErrPaymentRunNotProposed) {
+		t.Errorf("expected ErrPaymentRunNotProposed on a second approval, got %v", err)
+	}
+	if _, err := svc.GetPaymentFile(ctx, "prun_other", detail.Files[0].ID); !errors.Is(err, domain.ErrPaymentFileNotFound) {
+		t.Errorf("expected ErrPaymentFileNotFound for a file of another run, got %v", err)
+	}
+}
+
+func TestPaymentRun_RequiresValidPayingAccount(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	runs := memory.NewMemoryPaymentRunRepo()
+	runLines := memory.NewMemoryPaymentRunLineRepo()
+	files := memory.NewMemoryPaymentFileRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, bills, payments, allocations, runs, runLines, files, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, memory.NewMemoryArInvoiceRepo(), bills, bankAccounts, memory.NewMemoryFxRevaluationRepo(), gl, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     memory.NewMemoryArInvoiceRepo(),
+		Bills:        bills,
+		Allocations:  allocations,
+		BankAccounts: bankAccounts,
+		GL:           gl,
+		FX:           fx,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	svc := service.NewPaymentRunService(runs, runLines, files, memory.NewMemoryVendorBankAccountRepo(), bills, bankAccounts, legalEntities, cash, converter, gl, outbox, tm)
+	ctx := context.Background()
+
+	// A EUR legal entity pays from a SEPA-capable EUR account and an ACH-capable USD account.
+	// Vendor v1 is paid by IBAN, v2 by ACH and v3 has no bank details.
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme Handels GmbH", FunctionalCurrency: "EUR", TaxRegistrationNumber: "12-3456789"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "USD", ToCurrency: "EUR", Rate: decimal.RequireFromString("0.90"), EffectiveDate: day(2026, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_1", AccountNumber: "DE89370400440532013000", Bic: "COBADEFFXXX", Currency: "EUR"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_usd", LegalEntityID: "le_1", AccountNumber: "4400123", RoutingNumber: "021000021", Currency: "USD"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_gbp", LegalEntityID: "le_1", AccountNumber: "GB001", Currency: "GBP"})
+	if _, err := svc.SetVendorBankAccount(ctx, "v1", service.VendorBankAccountRequest{AccountName: "Fournitures SARL", IBAN: "FR14 2004 1010 0505 0001 3M02 606", BIC: "psstfrppxxx"}); err != nil {
+		t.Fatalf("failed to set SEPA vendor details: %v", err)
+	}
+	if _, err := svc.SetVendorBankAccount(ctx, "v2", service.VendorBankAccountRequest{AccountName: "Widgets Inc", RoutingNumber: "011000015", AccountNumber: "987654"}); err != nil {
+		t.Fatalf("failed to set ACH vendor details: %v", err)
+	}
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_short", LegalEntityID: "le_1", AccountNumber: "4400999", RoutingNumber: "0210", Currency: "USD"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_badiban", LegalEntityID: "le_1", AccountNumber: "DE00370400440532013000", Currency: "EUR"})
+	seedVendorBill(t, bills, "b1", "v1", "EUR", 1000, 0, day(2026, 3, 1), false)
+	seedVendorBill(t, bills, "b2", "v2", "USD", 2000, 0, day(2026, 3, 2), false)
+
+	for _, id := range []string{"ba_short", "ba_badiban"} {
+		_, err := svc.ProposePaymentRun(ctx, service.PaymentProposalRequest{
+			LegalEntityID: "le_1", DueBy: day(2026, 3, 10), PaymentDate: day(2026, 3, 12), BankAccountIDs: []string{id},
+		}, "ap.clerk")
+		if !errors.Is(err, domain.ErrInvalidPaymentRun) {
+			t.Errorf("%s: expected ErrInvalidPaymentRun, got %v", id, err)
+		}
+	}
+
+	// The USD account loses its routing number between proposal and approval
+	proposal := proposePaymentRun(t, svc)
+	usd, _ := bankAccounts.GetByID(ctx, "ba_usd")
+	usd.RoutingNumber = "0210"
+	_ = bankAccounts.Update(ctx, usd)
+
+	detail, err := svc.ApprovePaymentRun(ctx, proposal.Run.ID, "ap.manager")
+	if err != nil {
+		t.Fatalf("failed to approve run: %v", err)
+	}
+	lines := linesByBill(detail)
+	if l := lines["b2"]; l.Status != domain.PaymentRunLineStatusEXCLUDED || !strings.Contains(l.ExclusionReason, "routing number") {
+		t.Errorf("expected the ACH bill to be excluded, got %+v", l)
+	}
+	if lines["b1"].PaymentID == nil || len(detail.Files) != 1 || detail.Files[0].Format != domain.PaymentFileFormatSEPA_PAIN_001 {
+		t.Errorf("expected only the SEPA payment to be issued, got %+v", detail)
+	}
+}
+
+func TestApprovePaymentRun_RollsBackWhenNothingIsPayable(t *testing.T) {
+	legalEntities := memory.NewMemoryLegalEntityRepo()
+	rates := memory.NewMemoryCurrencyRateRepo()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	runs := memory.NewMemoryPaymentRunRepo()
+	runLines := memory.NewMemoryPaymentRunLineRepo()
+	files := memory.NewMemoryPaymentFileRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, bills, payments, allocations, runs, runLines, files, outbox)
+	converter := service.NewCurrencyConverter(legalEntities, rates)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tm)
+	fx := service.NewForeignExchangeService(converter, memory.NewMemoryArInvoiceRepo(), bills, bankAccounts, memory.NewMemoryFxRevaluationRepo(), gl, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:     payments,
+		Invoices:     memory.NewMemoryArInvoiceRepo(),
+		Bills:        bills,
+		Allocations:  allocations,
+		BankAccounts: bankAccounts,
+		GL:           gl,
+		FX:           fx,
+		Outbox:       outbox,
+		TM:           tm,
+	})
+	svc := service.NewPaymentRunService(runs, runLines, files, memory.NewMemoryVendorBankAccountRepo(), bills, bankAccounts, legalEntities, cash, converter, gl, outbox, tm)
+	ctx := context.Background()
+
+	// A EUR legal entity pays from a SEPA-capable EUR account and an ACH-capable USD account.
+	// Vendor v1 is paid by IBAN, v2 by ACH and v3 has no bank details.
+	_ = legalEntities.Create(ctx, &domain.LegalEntity{ID: "le_1", CompanyCode: "DE", CompanyName: "Acme Handels GmbH", FunctionalCurrency: "EUR", TaxRegistrationNumber: "12-3456789"})
+	_ = rates.Create(ctx, &domain.CurrencyRate{ID: "r1", FromCurrency: "USD", ToCurrency: "EUR", Rate: decimal.RequireFromString("0.90"), EffectiveDate: day(2026, 1, 1)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_eur", LegalEntityID: "le_1", AccountNumber: "DE89370400440532013000", Bic: "COBADEFFXXX", Currency: "EUR"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_usd", LegalEntityID: "le_1", AccountNumber: "4400123", RoutingNumber: "021000021", Currency: "USD"})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_gbp", LegalEntityID: "le_1", AccountNumber: "GB001", Currency: "GBP"})
+	if _, err := svc.SetVendorBankAccount(ctx, "v1", service.VendorBankAccountRequest{AccountName: "Fournitures SARL", IBAN: "FR14 2004 1010 0505 0001 3M02 606", BIC: "psstfrppxxx"}); err != nil {
+		t.Fatalf("failed to set SEPA vendor details: %v", err)
+	}
+	if _, err := svc.SetVendorBankAccount(ctx, "v2", service.VendorBankAccountRequest{AccountName: "Widgets Inc", RoutingNumber: "011000015", AccountNumber: "987654"}); err != nil {
+		t.Fatalf("failed to set ACH vendor details: %v", err)
+	}
+	seedVendorBill(t, bills, "b1", "v1", "EUR", 1000, 0, day(2026, 3, 1), false)
+	proposal := proposePaymentRun(t, svc)
+
+	bill, _ := bills.GetByID(ctx, "b1")
+	bill.PaymentHold = true
+	_ = bills.Update(ctx, bill)
+
+	if _, err := svc.ApprovePaymentRun(ctx, proposal.Run.ID, "ap.manager"); !errors.Is(err, domain.ErrInvalidPaymentRun) {
+		t.Fatalf("expected ErrInvalidPaymentRun, got %v", err)
+	}
+	detail, _ := svc.GetPaymentRun(ctx, proposal.Run.ID)
+	if detail.Run.Status != domain.PaymentRunStatusPROPOSED || detail.Lines[0].Status != domain.PaymentRunLineStatusPROPOSED {
+		t.Errorf("expected the failed approval to leave the run untouched, got %+v", detail)
+	}
+	if _, err := svc.ApprovePaymentRun(ctx, "prun_missing", "ap.manager"); !errors.Is(err, domain.ErrPaymentRunNotFound) {
+		t.Errorf("expected ErrPaymentRunNotFound, got %v", err)
+	}
+}
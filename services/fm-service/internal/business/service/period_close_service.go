@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Close checklist items
+const (
+	CloseCheckUnpostedDrafts     = "UNPOSTED_DRAFTS"
+	CloseCheckUnreconciledBank   = "UNRECONCILED_BANK_STATEMENTS"
+	CloseCheckDepreciationNotRun = "DEPRECIATION_NOT_RUN"
+	retainedEarningsAccount      = "3200-001"
+	yearEndClosingDocumentPrefix = "YEC-"
+	financialPeriodLayout        = "2006-01"
+)
+
+// PeriodCloseCheck is one item of the close checklist. OpenItems lists the ids blocking it.
+type PeriodCloseCheck struct {
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Passed      bool     `json:"passed"`
+	OpenItems   []string `json:"open_items"`
+}
+
+// PeriodCloseChecklist is the result of PeriodCloseService.GetCloseChecklist
+type PeriodCloseChecklist struct {
+	LegalEntityID   string             `json:"legal_entity_id"`
+	FinancialPeriod string             `json:"financial_period"`
+	State           domain.PeriodState `json:"state"`
+	ReadyToClose    bool               `json:"ready_to_close"`
+	Checks          []PeriodCloseCheck `json:"checks"`
+}
+
+// YearEndCloseResult is the result of PeriodCloseService.CloseFiscalYear
+type YearEndCloseResult struct {
+	LegalEntityID  string                `json:"legal_entity_id"`
+	FiscalYear     int                   `json:"fiscal_year"`
+	ClosingEntryID *string               `json:"closing_entry_id,omitempty"`
+	NetIncome      decimal.Decimal       `json:"net_income"`
+	Periods        []domain.FiscalPeriod `json:"periods"`
+}
+
+type PeriodCloseService struct {
+	periods      domain.FiscalPeriodRepository
+	fiscalYears  domain.FiscalYearRepository
+	accounts     domain.ChartOfAccountsRepository
+	entries      domain.UniversalJournalEntryRepository
+	statements   domain.BankStatementRepository
+	bankAccounts domain.BankAccountRepository
+	assets       domain.CapitalAssetRepository
+	depreciation domain.DepreciationScheduleLineRepository
+	gl           *GeneralLedgerService
+	outbox       domain.TransactionalOutboxRepository
+	tm           domain.TransactionManager
+}
+
+func NewPeriodCloseService(
+	periods domain.FiscalPeriodRepository,
+	fiscalYears domain.FiscalYearRepository,
+	accounts domain.ChartOfAccountsRepository,
+	entries domain.UniversalJournalEntryRepository,
+	statements domain.BankStatementRepository,
+	bankAccounts domain.BankAccountRepository,
+	assets domain.CapitalAssetRepository,
+	depreciation domain.DepreciationScheduleLineRepository,
+	gl *GeneralLedgerService,
+	outbox domain.TransactionalOutboxRepository,
+	tm domain.TransactionManager,
+) *PeriodCloseService {
+	return &PeriodCloseService{
+		periods:      periods,
+		fiscalYears:  fiscalYears,
+		accounts:     accounts,
+		entries:      entries,
+		statements:   statements,
+		bankAccounts: bankAccounts,
+		assets:       assets,
+		depreciation: depreciation,
+		gl:           gl,
+		outbox:       outbox,
+		tm:           tm,
+	}
+}
+
+// periodState returns the state of a legal entity's period; periods without a row are OPEN.
+func periodState(ctx context.Context, periods domain.FiscalPeriodRepository, legalEntityID, period string) (domain.PeriodState, *domain.FiscalPeriod, error) {
+	list, err := periods.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return "", nil, err
+	}
+	for i := range list {
+		if list[i].FinancialPeriod == period {
+			return list[i].State, &list[i], nil
+		}
+	}
+	return domain.PeriodStateOPEN, nil, nil
+}
+
+// isYearEndClosing reports whether an entry is the closing entry of a fiscal year
+func isYearEndClosing(entry domain.UniversalJournalEntry) bool {
+	return strings.HasPrefix(entry.SourceDocumentID, yearEndClosingDocumentPrefix)
+}
+
+func parseFinancialPeriod(period string) (time.Time, error) {
+	start, err := time.Parse(financialPeriodLayout, period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: financial period must be YYYY-MM", domain.ErrInvalidPeriodTransition)
+	}
+	return start, nil
+}
+
+func (s *PeriodCloseService) ListPeriods(ctx context.Context, legalEntityID string) ([]domain.FiscalPeriod, error) {
+	return s.periods.ListByLegalEntity(ctx, legalEntityID)
+}
+
+// GetCloseChecklist reports what still blocks closing a period: unposted draft entries,
+// unreconciled bank statements and depreciation that has not been posted.
+func (s *PeriodCloseService) GetCloseChecklist(ctx context.Context, legalEntityID, period string) (*PeriodCloseChecklist, error) {
+	start, err := parseFinancialPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	state, _, err := periodState(ctx, s.periods, legalEntityID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := PeriodCloseCheck{Code: CloseCheckUnpostedDrafts, Description: "Draft journal entries must be posted or deleted"}
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.LegalEntityID == legalEntityID && e.FinancialPeriod == period && e.Status == domain.LedgerStateDRAFT {
+			drafts.OpenItems = append(drafts.OpenItems, e.ID)
+		}
+	}
+
+	bank := PeriodCloseCheck{Code: CloseCheckUnreconciledBank, Description: "Bank statements of the period must be reconciled"}
+	accounts, err := s.bankAccounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entityAccounts := make(map[string]bool)
+	for _, ba := range accounts {
+		if ba.LegalEntityID == legalEntityID {
+			entityAccounts[ba.ID] = true
+		}
+	}
+	statements, err := s.statements.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range statements {
+		if entityAccounts[st.BankAccountID] && st.StatementDate.Format(financialPeriodLayout) == period && !st.IsReconciled {
+			bank.OpenItems = append(bank.OpenItems, st.ID)
+		}
+	}
+
+	depreciation := PeriodCloseCheck{Code: CloseCheckDepreciationNotRun, Description: "Monthly depreciation must be posted"}
+	unposted, err := s.depreciation.GetUnpostedByPeriod(ctx, start.Year(), int(start.Month()))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range unposted {
+		asset, err := s.assets.GetByID(ctx, line.FixedAssetID)
+		if err != nil || asset.LegalEntityID != legalEntityID || asset.Status == domain.AssetStateDISPOSED {
+			continue
+		}
+		depreciation.OpenItems = append(depreciation.OpenItems, line.ID)
+	}
+
+	checklist := &PeriodCloseChecklist{
+		LegalEntityID:   legalEntityID,
+		FinancialPeriod: period,
+		State:           state,
+		ReadyToClose:    true,
+	}
+	for _, check := range []PeriodCloseCheck{drafts, bank, depreciation} {
+		check.Passed = len(check.OpenItems) == 0
+		if !check.Passed {
+			checklist.ReadyToClose = false
+		}
+		checklist.Checks = append(checklist.Checks, check)
+	}
+	return checklist, nil
+}
+
+// SoftClosePeriod stops sub-ledger postings into an open period while still accepting FM adjustments.
+func (s *PeriodCloseService) SoftClosePeriod(ctx context.Context, legalEntityID, period string) (*domain.FiscalPeriod, error) {
+	if _, err := parseFinancialPeriod(period); err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, legalEntityID, period, domain.PeriodStateSOFT_CLOSED, func(current domain.PeriodState) error {
+		if current != domain.PeriodStateOPEN {
+			return fmt.Errorf("%w: cannot soft-close a %s period", domain.ErrInvalidPeriodTransition, current)
+		}
+		return nil
+	})
+}
+
+// ClosePeriod locks a period for all postings. Every checklist item has to pass.
+func (s *PeriodCloseService) ClosePeriod(ctx context.Context, legalEntityID, period string) (*domain.FiscalPeriod, error) {
+	checklist, err := s.GetCloseChecklist(ctx, legalEntityID, period)
+	if err != nil {
+		return nil, err
+	}
+	if checklist.State == domain.PeriodStateCLOSED {
+		return nil, fmt.Errorf("%w: period %s is already closed", domain.ErrInvalidPeriodTransition, period)
+	}
+	if err := checklistError(checklist); err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, legalEntityID, period, domain.PeriodStateCLOSED, nil)
+}
+
+// ReopenPeriod returns a soft-closed or closed period to OPEN unless its fiscal year was closed.
+func (s *PeriodCloseService) ReopenPeriod(ctx context.Context, legalEntityID, period string) (*domain.FiscalPeriod, error) {
+	start, err := parseFinancialPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.periods.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		if p.ClosingEntryID != nil && strings.HasPrefix(p.FinancialPeriod, fmt.Sprintf("%04d-", start.Year())) {
+			return nil, fmt.Errorf("%w: %d", domain.ErrFiscalYearClosed, start.Year())
+		}
+	}
+	return s.transition(ctx, legalEntityID, period, domain.PeriodStateOPEN, func(current domain.PeriodState) error {
+		if current == domain.PeriodStateOPEN {
+			return fmt.Errorf("%w: period %s is already open", domain.ErrInvalidPeriodTransition, period)
+		}
+		return nil
+	})
+}
+
+func (s *PeriodCloseService) transition(ctx context.Context, legalEntityID, period string, target domain.PeriodState, guard func(domain.PeriodState) error) (*domain.FiscalPeriod, error) {
+	var result *domain.FiscalPeriod
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		state, fp, err := periodState(txCtx, s.periods, legalEntityID, period)
+		if err != nil {
+			return err
+		}
+		if guard != nil {
+			if err := guard(state); err != nil {
+				return err
+			}
+		}
+		if fp == nil {
+			fp = &domain.FiscalPeriod{ID: utils.NewID("fp"), LegalEntityID: legalEntityID, FinancialPeriod: period}
+		}
+		result, err = s.setState(txCtx, fp, target)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *PeriodCloseService) setState(ctx context.Context, fp *domain.FiscalPeriod, target domain.PeriodState) (*domain.FiscalPeriod, error) {
+	now := time.Now()
+	fp.State = target
+	fp.UpdatedAt = now
+	fp.ClosedAt = nil
+	if target != domain.PeriodStateOPEN {
+		fp.ClosedAt = &now
+	}
+	if err := s.periods.Upsert(ctx, fp); err != nil {
+		return nil, err
+	}
+
+	outboxRec := &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   string(domain.TopicFmPeriodClosed),
+		AggregateID: fp.ID,
+		Payload: domain.PeriodStateChangedEventPayload{
+			LegalEntityID:   fp.LegalEntityID,
+			FinancialPeriod: fp.FinancialPeriod,
+			State:           string(fp.State),
+			Timestamp:       now,
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: now,
+	}
+	if err := s.outbox.Create(ctx, outboxRec); err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+// CloseFiscalYear runs the year-end close of a legal entity: every period of the fiscal year has
+// to pass the close checklist, revenue and expense balances are swept into retained earnings
+// with a closing entry on the last day of the year, and all periods of the year are closed.
+func (s *PeriodCloseService) CloseFiscalYear(ctx context.Context, legalEntityID, fiscalYearID string) (*YearEndCloseResult, error) {
+	fy, err := s.fiscalYears.GetByID(ctx, fiscalYearID)
+	if err != nil {
+		return nil, err
+	}
+
+	var periods []string
+	for m := time.Date(fy.StartDate.Year(), fy.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC); !m.After(fy.EndDate); m = m.AddDate(0, 1, 0) {
+		periods = append(periods, m.Format(financialPeriodLayout))
+	}
+	if len(periods) == 0 {
+		return nil, fmt.Errorf("%w: fiscal year %d has no periods", domain.ErrInvalidPeriodTransition, fy.Year)
+	}
+	inYear := make(map[string]bool, len(periods))
+	for _, p := range periods {
+		inYear[p] = true
+	}
+
+	existing, err := s.periods.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+	lastPeriod := periods[len(periods)-1]
+	for _, p := range existing {
+		if !inYear[p.FinancialPeriod] {
+			continue
+		}
+		if p.ClosingEntryID != nil {
+			return nil, fmt.Errorf("%w: %d", domain.ErrFiscalYearClosed, fy.Year)
+		}
+		if p.FinancialPeriod == lastPeriod && p.State == domain.PeriodStateCLOSED {
+			return nil, fmt.Errorf("%w: period %s must be open or soft-closed to take the closing entry", domain.ErrInvalidPeriodTransition, lastPeriod)
+		}
+	}
+	for _, p := range periods {
+		checklist, err := s.GetCloseChecklist(ctx, legalEntityID, p)
+		if err != nil {
+			return nil, err
+		}
+		if err := checklistError(checklist); err != nil {
+			return nil, err
+		}
+	}
+
+	balances, err := s.profitAndLossBalances(ctx, legalEntityID, inYear)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &YearEndCloseResult{LegalEntityID: legalEntityID, FiscalYear: fy.Year, NetIncome: decimal.Zero}
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		accountIDs := make([]string, 0, len(balances))
+		for id := range balances {
+			accountIDs = append(accountIDs, id)
+		}
+		sort.Strings(accountIDs)
+
+		var lines []domain.UniversalJournalLine
+		total := decimal.Zero
+		for _, id := range accountIDs {
+			lines = append(lines, domain.UniversalJournalLine{AccountID: id, AmountFunctional: balances[id].Neg()})
+			total = total.Add(balances[id])
+		}
+		// Revenue carries credit (negative) balances, so net income is the negated P&L total
+		result.NetIncome = total.Neg()
+
+		var closingEntryID *string
+		if len(lines) > 0 && !total.IsZero() {
+			retained, err := s.gl.EnsureAccount(txCtx, legalEntityID, retainedEarningsAccount, "Retained Earnings", domain.AccountTypeEQUITY)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, domain.UniversalJournalLine{AccountID: retained.ID, AmountFunctional: total})
+		}
+		if len(lines) >= 2 {
+			entry, err := s.gl.CreateJournalEntry(txCtx, legalEntityID, "FM", fmt.Sprintf("%s%d", yearEndClosingDocumentPrefix, fy.Year), fy.EndDate, lines)
+			if err != nil {
+				return err
+			}
+			closingEntryID = &entry.ID
+			result.ClosingEntryID = closingEntryID
+		}
+
+		for _, p := range periods {
+			_, fp, err := periodState(txCtx, s.periods, legalEntityID, p)
+			if err != nil {
+				return err
+			}
+			if fp == nil {
+				fp = &domain.FiscalPeriod{ID: utils.NewID("fp"), LegalEntityID: legalEntityID, FinancialPeriod: p}
+			}
+			if p == lastPeriod {
+				// The closing entry marks the year as closed; without P&L balances there is nothing to protect
+				fp.ClosingEntryID = closingEntryID
+			}
+			if fp.State == domain.PeriodStateCLOSED && p != lastPeriod {
+				result.Periods = append(result.Periods, *fp)
+				continue
+			}
+			updated, err := s.setState(txCtx, fp, domain.PeriodStateCLOSED)
+			if err != nil {
+				return err
+			}
+			result.Periods = append(result.Periods, *updated)
+		}
+
+		outboxRec := &domain.TransactionalOutbox{
+			ID:          utils.NewID("outbox"),
+			EventType:   string(domain.TopicFmFiscalYearClosed),
+			AggregateID: fy.ID,
+			Payload: domain.FiscalYearClosedEventPayload{
+				LegalEntityID:  legalEntityID,
+				FiscalYear:     fy.Year,
+				ClosingEntryID: result.ClosingEntryID,
+				NetIncome:      result.NetIncome,
+				Timestamp:      time.Now(),
+			},
+			Status:    domain.OutboxStatusPENDING,
+			CreatedAt: time.Now(),
+		}
+		return s.outbox.Create(txCtx, outboxRec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// profitAndLossBalances sums the posted revenue and expense balances per account for the given periods.
+func (s *PeriodCloseService) profitAndLossBalances(ctx context.Context, legalEntityID string, periods map[string]bool) (map[string]decimal.Decimal, error) {
+	accounts, err := s.accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pnl := make(map[string]bool)
+	for _, acc := range accounts {
+		if acc.LegalEntityID == legalEntityID && (acc.Type == domain.AccountTypeREVENUE || acc.Type == domain.AccountTypeEXPENSE) {
+			pnl[acc.ID] = true
+		}
+	}
+
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]decimal.Decimal)
+	for _, entry := range entries {
+		if entry.LegalEntityID != legalEntityID || !periods[entry.FinancialPeriod] ||
+			(entry.Status != domain.LedgerStatePOSTED && entry.Status != domain.LedgerStateREVERSED) {
+			continue
+		}
+		_, lines, err := s.entries.GetByID(ctx, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lines {
+			if pnl[l.AccountID] {
+				balances[l.AccountID] = balances[l.AccountID].Add(l.AmountFunctional)
+			}
+		}
+	}
+	for id, bal := range balances {
+		if bal.IsZero() {
+			delete(balances, id)
+		}
+	}
+	return balances, nil
+}
+
+func checklistError(checklist *PeriodCloseChecklist) error {
+	if checklist.ReadyToClose {
+		return nil
+	}
+	var failed []string
+	for _, c := range checklist.Checks {
+		if !c.Passed {
+			failed = append(failed, fmt.Sprintf("%s (%d)", c.Code, len(c.OpenItems)))
+		}
+	}
+	return fmt.Errorf("%w: %s %s", domain.ErrPeriodCloseBlocked, checklist.FinancialPeriod, strings.Join(failed, ", "))
+}
@@ -0,0 +1,262 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func postInPeriod(t *testing.T, gl *service.GeneralLedgerService, sourceModule string, debit, credit *domain.ChartOfAccounts, amount int64, month time.Month) (*domain.UniversalJournalEntry, error) {
+	t.Helper()
+	return gl.CreateJournalEntry(context.Background(), "le_1", sourceModule, "doc", day(2025, month, 15), []domain.UniversalJournalLine{
+		{AccountID: debit.ID, AmountFunctional: decimal.NewFromInt(amount)},
+		{AccountID: credit.ID, AmountFunctional: decimal.NewFromInt(-amount)},
+	})
+}
+
+func TestPeriodClose_SoftCloseAndLock(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	fiscalPeriods := memory.NewMemoryFiscalPeriodRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, fiscalPeriods, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, fiscalPeriods, testConverter(), outbox, tm)
+	svc := service.NewPeriodCloseService(fiscalPeriods, fiscalYears, accounts, entries, statements, bankAccounts, assets, scheduleLines, gl, outbox, tm)
+	ctx := context.Background()
+
+	_ = fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2025", Year: 2025, StartDate: day(2025, 1, 1), EndDate: day(2025, 12, 31)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "le_1", AccountNumber: "DE001", Currency: "EUR"})
+	_ = assets.Create(ctx, &domain.CapitalAsset{ID: "asset_1", LegalEntityID: "le_1", AssetTag: "TRUCK-1", Status: domain.AssetStateACTIVE})
+	revenueAccount, err := gl.CreateAccount(ctx, "le_1", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	bankAccount, _ := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+
+	if _, err := svc.SoftClosePeriod(ctx, "le_1", "2025-03"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Sub-ledgers are locked out of a soft-closed period, FM adjustments still post
+	if _, err := postInPeriod(t, gl, "AR", bankAccount, revenueAccount, 100, 3); !errors.Is(err, domain.ErrPeriodClosed) {
+		t.Errorf("expected period closed error for AR posting, got %v", err)
+	}
+	if _, err := postInPeriod(t, gl, "FM", bankAccount, revenueAccount, 100, 3); err != nil {
+		t.Errorf("expected FM adjustment to post, got %v", err)
+	}
+	if _, err := svc.SoftClosePeriod(ctx, "le_1", "2025-03"); !errors.Is(err, domain.ErrInvalidPeriodTransition) {
+		t.Errorf("expected invalid transition, got %v", err)
+	}
+
+	if _, err := svc.ClosePeriod(ctx, "le_1", "2025-03"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := postInPeriod(t, gl, "FM", bankAccount, revenueAccount, 100, 3); !errors.Is(err, domain.ErrPeriodClosed) {
+		t.Errorf("expected period closed error in closed period, got %v", err)
+	}
+	// Other legal entities and periods are unaffected
+	if _, err := postInPeriod(t, gl, "AR", bankAccount, revenueAccount, 100, 4); err != nil {
+		t.Errorf("expected posting into open period, got %v", err)
+	}
+
+	periods, err := svc.ListPeriods(ctx, "le_1")
+	if err != nil || len(periods) != 1 || periods[0].State != domain.PeriodStateCLOSED || periods[0].ClosedAt == nil {
+		t.Fatalf("expected one closed period, got %+v (%v)", periods, err)
+	}
+
+	if _, err := svc.ReopenPeriod(ctx, "le_1", "2025-03"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := postInPeriod(t, gl, "AR", bankAccount, revenueAccount, 100, 3); err != nil {
+		t.Errorf("expected posting after reopen, got %v", err)
+	}
+	if _, err := svc.ReopenPeriod(ctx, "le_1", "2025-03"); !errors.Is(err, domain.ErrInvalidPeriodTransition) {
+		t.Errorf("expected invalid transition reopening an open period, got %v", err)
+	}
+	if _, err := svc.SoftClosePeriod(ctx, "le_1", "March"); !errors.Is(err, domain.ErrInvalidPeriodTransition) {
+		t.Errorf("expected invalid period format error, got %v", err)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 100)
+	events := 0
+	for _, rec := range pending {
+		if rec.EventType == string(domain.TopicFmPeriodClosed) {
+			events++
+		}
+	}
+	if events != 3 {
+		t.Errorf("expected 3 period state events, got %d", events)
+	}
+}
+
+func TestPeriodClose_Checklist(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	fiscalPeriods := memory.NewMemoryFiscalPeriodRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, fiscalPeriods, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, fiscalPeriods, testConverter(), outbox, tm)
+	svc := service.NewPeriodCloseService(fiscalPeriods, fiscalYears, accounts, entries, statements, bankAccounts, assets, scheduleLines, gl, outbox, tm)
+	ctx := context.Background()
+
+	_ = fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2025", Year: 2025, StartDate: day(2025, 1, 1), EndDate: day(2025, 12, 31)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "le_1", AccountNumber: "DE001", Currency: "EUR"})
+	_ = assets.Create(ctx, &domain.CapitalAsset{ID: "asset_1", LegalEntityID: "le_1", AssetTag: "TRUCK-1", Status: domain.AssetStateACTIVE})
+
+	_ = entries.Create(ctx, &domain.UniversalJournalEntry{ID: "je_draft", LegalEntityID: "le_1", SourceModule: "FM",
+		PostingDate: day(2025, 5, 10), FinancialPeriod: "2025-05", Status: domain.LedgerStateDRAFT}, nil)
+	_ = statements.Create(ctx, &domain.BankStatement{ID: "bs_1", BankAccountID: "ba_1", StatementDate: day(2025, 5, 31)}, nil)
+	_ = scheduleLines.CreateMany(ctx, []domain.DepreciationScheduleLine{{ID: "dsl_1", FixedAssetID: "asset_1", FiscalYear: 2025, PeriodNumber: 5}})
+
+	checklist, err := svc.GetCloseChecklist(ctx, "le_1", "2025-05")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if checklist.ReadyToClose || len(checklist.Checks) != 3 {
+		t.Fatalf("expected 3 failing checks, got %+v", checklist)
+	}
+	for _, check := range checklist.Checks {
+		if check.Passed || len(check.OpenItems) != 1 {
+			t.Errorf("expected %s to fail with one open item, got %+v", check.Code, check)
+		}
+	}
+	if _, err := svc.ClosePeriod(ctx, "le_1", "2025-05"); !errors.Is(err, domain.ErrPeriodCloseBlocked) {
+		t.Errorf("expected close to be blocked, got %v", err)
+	}
+
+	// Another legal entity's period is not blocked by le_1's open items
+	other, err := svc.GetCloseChecklist(ctx, "le_2", "2025-05")
+	if err != nil || !other.ReadyToClose {
+		t.Errorf("expected le_2 to be ready to close, got %+v (%v)", other, err)
+	}
+}
+
+func TestPeriodClose_CloseFiscalYear(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	fiscalPeriods := memory.NewMemoryFiscalPeriodRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, fiscalPeriods, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, fiscalPeriods, testConverter(), outbox, tm)
+	svc := service.NewPeriodCloseService(fiscalPeriods, fiscalYears, accounts, entries, statements, bankAccounts, assets, scheduleLines, gl, outbox, tm)
+	ctx := context.Background()
+
+	_ = fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2025", Year: 2025, StartDate: day(2025, 1, 1), EndDate: day(2025, 12, 31)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "le_1", AccountNumber: "DE001", Currency: "EUR"})
+	_ = assets.Create(ctx, &domain.CapitalAsset{ID: "asset_1", LegalEntityID: "le_1", AssetTag: "TRUCK-1", Status: domain.AssetStateACTIVE})
+	revenueAccount, err := gl.CreateAccount(ctx, "le_1", "4000-001", "Sales", string(domain.AccountTypeREVENUE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	expenseAccount, _ := gl.CreateAccount(ctx, "le_1", "6000-001", "Rent", string(domain.AccountTypeEXPENSE))
+	bankAccount, _ := gl.CreateAccount(ctx, "le_1", "1010-001", "Bank", string(domain.AccountTypeASSET))
+
+	if _, err := postInPeriod(t, gl, "AR", bankAccount, revenueAccount, 1000, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := postInPeriod(t, gl, "AP", expenseAccount, bankAccount, 400, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.ClosePeriod(ctx, "le_1", "2025-02"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := svc.CloseFiscalYear(ctx, "le_1", "fy_2025")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAmount(t, "net income", result.NetIncome, 600)
+	if result.ClosingEntryID == nil || len(result.Periods) != 12 {
+		t.Fatalf("expected closing entry and 12 periods, got %+v", result)
+	}
+	for _, p := range result.Periods {
+		if p.State != domain.PeriodStateCLOSED {
+			t.Errorf("expected period %s closed, got %s", p.FinancialPeriod, p.State)
+		}
+	}
+
+	// Revenue and expense are swept to zero, the result lands on retained earnings
+	revenue, _ := gl.GetAccountBalance(ctx, revenueAccount.ID)
+	expense, _ := gl.GetAccountBalance(ctx, expenseAccount.ID)
+	assertAmount(t, "revenue after close", revenue, 0)
+	assertAmount(t, "expense after close", expense, 0)
+	retained, err := gl.GetAccountByCode(ctx, "le_1", "3200-001")
+	if err != nil {
+		t.Fatalf("expected retained earnings account: %v", err)
+	}
+	retainedBalance, _ := gl.GetAccountBalance(ctx, retained.ID)
+	assertAmount(t, "retained earnings", retainedBalance, 600)
+
+	closing, _, err := gl.GetJournalEntry(ctx, *result.ClosingEntryID)
+	if err != nil || closing.SourceDocumentID != "YEC-2025" || closing.FinancialPeriod != "2025-12" {
+		t.Errorf("unexpected closing entry %+v (%v)", closing, err)
+	}
+
+	if _, err := svc.CloseFiscalYear(ctx, "le_1", "fy_2025"); !errors.Is(err, domain.ErrFiscalYearClosed) {
+		t.Errorf("expected fiscal year closed error, got %v", err)
+	}
+	if _, err := svc.ReopenPeriod(ctx, "le_1", "2025-06"); !errors.Is(err, domain.ErrFiscalYearClosed) {
+		t.Errorf("expected reopen to be rejected after year-end, got %v", err)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 1000)
+	found := false
+	for _, rec := range pending {
+		if rec.EventType == string(domain.TopicFmFiscalYearClosed) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected fiscal year closed event")
+	}
+}
+
+func TestPeriodClose_CloseFiscalYearBlocked(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	fiscalPeriods := memory.NewMemoryFiscalPeriodRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	assets := memory.NewMemoryCapitalAssetRepo()
+	scheduleLines := memory.NewMemoryDepreciationScheduleLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, fiscalPeriods, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, fiscalPeriods, testConverter(), outbox, tm)
+	svc := service.NewPeriodCloseService(fiscalPeriods, fiscalYears, accounts, entries, statements, bankAccounts, assets, scheduleLines, gl, outbox, tm)
+	ctx := context.Background()
+
+	_ = fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2025", Year: 2025, StartDate: day(2025, 1, 1), EndDate: day(2025, 12, 31)})
+	_ = bankAccounts.Create(ctx, &domain.BankAccount{ID: "ba_1", LegalEntityID: "le_1", AccountNumber: "DE001", Currency: "EUR"})
+	_ = assets.Create(ctx, &domain.CapitalAsset{ID: "asset_1", LegalEntityID: "le_1", AssetTag: "TRUCK-1", Status: domain.AssetStateACTIVE})
+
+	_ = entries.Create(ctx, &domain.UniversalJournalEntry{ID: "je_draft", LegalEntityID: "le_1", SourceModule: "FM",
+		PostingDate: day(2025, 9, 10), FinancialPeriod: "2025-09", Status: domain.LedgerStateDRAFT}, nil)
+	if _, err := svc.CloseFiscalYear(ctx, "le_1", "fy_2025"); !errors.Is(err, domain.ErrPeriodCloseBlocked) {
+		t.Errorf("expected year-end close to be blocked, got %v", err)
+	}
+	periods, _ := svc.ListPeriods(ctx, "le_1")
+	if len(periods) != 0 {
+		t.Errorf("expected no period changes, got %+v", periods)
+	}
+}
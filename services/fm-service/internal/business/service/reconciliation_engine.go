@@ -0,0 +1,314 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// ReconciliationOptions tunes how bank statement lines are matched to payments.
+type ReconciliationOptions struct {
+	DateWindowDays  int             // Max days between transaction date and payment date
+	AmountTolerance decimal.Decimal // Absolute difference still treated as an exact amount
+	MinConfidence   decimal.Decimal // Matches scoring below this go to the exceptions queue
+	MaxGroupSize    int             // Max payments (or lines) combined into one 1:N / N:1 match
+}
+
+func DefaultReconciliationOptions() ReconciliationOptions {
+	return ReconciliationOptions{
+		DateWindowDays:  5,
+		AmountTolerance: decimal.NewFromFloat(0.01),
+		MinConfidence:   decimal.NewFromFloat(0.6),
+		MaxGroupSize:    4,
+	}
+}
+
+// maxGroupCandidates caps the candidate set for subset matching so the
+// combinatorial search stays cheap on large statements.
+const maxGroupCandidates = 12
+
+// Score weights: amount is mandatory, date proximity and reference text add confidence.
+var (
+	amountWeight    = decimal.NewFromFloat(0.5)
+	dateWeight      = decimal.NewFromFloat(0.3)
+	referenceWeight = decimal.NewFromFloat(0.2)
+)
+
+type proposedMatch struct {
+	lines      []domain.BankStatementLine
+	payments   []domain.Payment
+	matchType  domain.ReconciliationMatchType
+	confidence decimal.Decimal
+}
+
+type reconciliationEngine struct {
+	opts ReconciliationOptions
+}
+
+func newReconciliationEngine(opts ReconciliationOptions) *reconciliationEngine {
+	return &reconciliationEngine{opts: opts}
+}
+
+// run matches unmatched statement lines against open payments in three passes:
+// one-to-one, one line to many payments, then many lines to one payment.
+// It returns the proposed matches and a reason for every line left unmatched.
+func (e *reconciliationEngine) run(lines []domain.BankStatementLine, payments []domain.Payment) ([]proposedMatch, map[string]string) {
+	usedLines := make(map[string]bool)
+	usedPayments := make(map[string]bool)
+	var matches []proposedMatch
+
+	// Pass 1: one-to-one, best scoring pairs first
+	type pair struct {
+		line    domain.BankStatementLine
+		payment domain.Payment
+		score   decimal.Decimal
+	}
+	var pairs []pair
+	for _, l := range lines {
+		for _, p := range payments {
+			if !e.amountMatches(l.Amount, p.SignedAmount()) || !e.withinWindow(l.TransactionDate, p.PaymentDate) {
+				continue
+			}
+			pairs = append(pairs, pair{line: l, payment: p, score: e.score(l, []domain.Payment{p})})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].score.GreaterThan(pairs[j].score) })
+	for _, pr := range pairs {
+		if usedLines[pr.line.ID] || usedPayments[pr.payment.ID] || pr.score.LessThan(e.opts.MinConfidence) {
+			continue
+		}
+		usedLines[pr.line.ID] = true
+		usedPayments[pr.payment.ID] = true
+		matches = append(matches, proposedMatch{
+			lines:      []domain.BankStatementLine{pr.line},
+			payments:   []domain.Payment{pr.payment},
+			matchType:  domain.ReconciliationMatchTypeONE_TO_ONE,
+			confidence: pr.score,
+		})
+	}
+
+	// Pass 2: one statement line settles several payments (e.g. a batched deposit)
+	for _, l := range lines {
+		if usedLines[l.ID] {
+			continue
+		}
+		var candidates []domain.Payment
+		for _, p := range payments {
+			if !usedPayments[p.ID] && e.withinWindow(l.TransactionDate, p.PaymentDate) && sameSign(l.Amount, p.SignedAmount()) {
+				candidates = append(candidates, p)
+			}
+		}
+		candidates = closestPayments(candidates, l.TransactionDate, maxGroupCandidates)
+		subset := e.findSubset(len(candidates), l.Amount, func(i int) decimal.Decimal { return candidates[i].SignedAmount() })
+		if subset == nil {
+			continue
+		}
+		group := make([]domain.Payment, len(subset))
+		for i, idx := range subset {
+			group[i] = candidates[idx]
+		}
+		score := e.score(l, group)
+		if score.LessThan(e.opts.MinConfidence) {
+			continue
+		}
+		usedLines[l.ID] = true
+		for _, p := range group {
+			usedPayments[p.ID] = true
+		}
+		matches = append(matches, proposedMatch{
+			lines:      []domain.BankStatementLine{l},
+			payments:   group,
+			matchType:  domain.ReconciliationMatchTypeONE_TO_MANY,
+			confidence: score,
+		})
+	}
+
+	// Pass 3: several statement lines settle one payment (e.g. a split transfer)
+	for _, p := range payments {
+		if usedPayments[p.ID] {
+			continue
+		}
+		var candidates []domain.BankStatementLine
+		for _, l := range lines {
+			if !usedLines[l.ID] && e.withinWindow(l.TransactionDate, p.PaymentDate) && sameSign(l.Amount, p.SignedAmount()) {
+				candidates = append(candidates, l)
+			}
+		}
+		candidates = closestLines(candidates, p.PaymentDate, maxGroupCandidates)
+		subset := e.findSubset(len(candidates), p.SignedAmount(), func(i int) decimal.Decimal { return candidates[i].Amount })
+		if subset == nil {
+			continue
+		}
+		group := make([]domain.BankStatementLine, len(subset))
+		for i, idx := range subset {
+			group[i] = candidates[idx]
+		}
+		// Score by the weakest line so one poor reference drags the group down
+		score := decimal.NewFromInt(1)
+		for _, l := range group {
+			if s := e.score(l, []domain.Payment{p}); s.LessThan(score) {
+				score = s
+			}
+		}
+		if score.LessThan(e.opts.MinConfidence) {
+			continue
+		}
+		usedPayments[p.ID] = true
+		for _, l := range group {
+			usedLines[l.ID] = true
+		}
+		matches = append(matches, proposedMatch{
+			lines:      group,
+			payments:   []domain.Payment{p},
+			matchType:  domain.ReconciliationMatchTypeMANY_TO_ONE,
+			confidence: score,
+		})
+	}
+
+	unmatched := make(map[string]string)
+	for _, l := range lines {
+		if usedLines[l.ID] {
+			continue
+		}
+		unmatched[l.ID] = e.unmatchedReason(l, payments, usedPayments)
+	}
+	return matches, unmatched
+}
+
+// score returns a 0-1 confidence for a line settling the given payments.
+// Amount agreement is assumed; this adds date proximity and reference text.
+func (e *reconciliationEngine) score(line domain.BankStatementLine, payments []domain.Payment) decimal.Decimal {
+	score := amountWeight
+
+	maxDays := 0
+	for _, p := range payments {
+		if d := daysBetween(line.TransactionDate, p.PaymentDate); d > maxDays {
+			maxDays = d
+		}
+	}
+	proximity := decimal.NewFromInt(1).Sub(decimal.NewFromInt(int64(maxDays)).Div(decimal.NewFromInt(int64(e.opts.DateWindowDays + 1))))
+	score = score.Add(dateWeight.Mul(proximity))
+
+	referenced := 0
+	desc := strings.ToUpper(line.Description + " " + line.Reference)
+	for _, p := range payments {
+		if referencesPayment(desc, p) {
+			referenced++
+		}
+	}
+	if referenced > 0 {
+		share := decimal.NewFromInt(int64(referenced)).Div(decimal.NewFromInt(int64(len(payments))))
+		score = score.Add(referenceWeight.Mul(share))
+	}
+	return score.Round(4)
+}
+
+func (e *reconciliationEngine) amountMatches(a, b decimal.Decimal) bool {
+	return a.Sub(b).Abs().LessThanOrEqual(e.opts.AmountTolerance)
+}
+
+func (e *reconciliationEngine) withinWindow(a, b time.Time) bool {
+	return daysBetween(a, b) <= e.opts.DateWindowDays
+}
+
+// findSubset searches for 2..MaxGroupSize items whose amounts sum to target.
+// Smaller groups are preferred; it returns nil when no combination fits.
+func (e *reconciliationEngine) findSubset(n int, target decimal.Decimal, amount func(int) decimal.Decimal) []int {
+	for size := 2; size <= e.opts.MaxGroupSize && size <= n; size++ {
+		idx := make([]int, 0, size)
+		var found []int
+		var walk func(start int, sum decimal.Decimal) bool
+		walk = func(start int, sum decimal.Decimal) bool {
+			if len(idx) == size {
+				if e.amountMatches(sum, target) {
+					found = append([]int(nil), idx...)
+					return true
+				}
+				return false
+			}
+			for i := start; i < n; i++ {
+				idx = append(idx, i)
+				if walk(i+1, sum.Add(amount(i))) {
+					return true
+				}
+				idx = idx[:len(idx)-1]
+			}
+			return false
+		}
+		if walk(0, decimal.Zero) {
+			return found
+		}
+	}
+	return nil
+}
+
+func (e *reconciliationEngine) unmatchedReason(line domain.BankStatementLine, payments []domain.Payment, usedPayments map[string]bool) string {
+	amountSeen := false
+	for _, p := range payments {
+		if !e.amountMatches(line.Amount, p.SignedAmount()) {
+			continue
+		}
+		amountSeen = true
+		if usedPayments[p.ID] {
+			continue
+		}
+		if e.withinWindow(line.TransactionDate, p.PaymentDate) {
+			return "candidate payment found below confidence threshold"
+		}
+	}
+	if amountSeen {
+		return "payment with matching amount found outside date window"
+	}
+	return "no payment with matching amount"
+}
+
+func referencesPayment(upperDesc string, p domain.Payment) bool {
+	refs := []string{p.PaymentNumber}
+	if p.InvoiceID != nil {
+		refs = append(refs, *p.InvoiceID)
+	}
+	if p.BillID != nil {
+		refs = append(refs, *p.BillID)
+	}
+	for _, ref := range refs {
+		if ref != "" && strings.Contains(upperDesc, strings.ToUpper(ref)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSign(a, b decimal.Decimal) bool {
+	return a.Sign() == b.Sign()
+}
+
+func daysBetween(a, b time.Time) int {
+	d := a.Sub(b)
+	if d < 0 {
+		d = -d
+	}
+	return int(d.Hours() / 24)
+}
+
+func closestPayments(payments []domain.Payment, date time.Time, limit int) []domain.Payment {
+	sort.SliceStable(payments, func(i, j int) bool {
+		return daysBetween(payments[i].PaymentDate, date) < daysBetween(payments[j].PaymentDate, date)
+	})
+	if len(payments) > limit {
+		return payments[:limit]
+	}
+	return payments
+}
+
+func closestLines(lines []domain.BankStatementLine, date time.Time, limit int) []domain.BankStatementLine {
+	sort.SliceStable(lines, func(i, j int) bool {
+		return daysBetween(lines[i].TransactionDate, date) < daysBetween(lines[j].TransactionDate, date)
+	})
+	if len(lines) > limit {
+		return lines[:limit]
+	}
+	return lines
+}
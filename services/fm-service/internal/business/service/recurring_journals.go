@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// recurringJournalSourceModule is the source module of entries generated from templates.
+const recurringJournalSourceModule = "FM"
+
+type JournalTemplateLineRequest struct {
+	AccountID   string          `json:"account_id"`
+	Amount      decimal.Decimal `json:"amount"` // Positive debits, negative credits
+	Description string          `json:"description"`
+}
+
+type JournalTemplateRequest struct {
+	LegalEntityID string                       `json:"legal_entity_id"`
+	Name          string                       `json:"name"`
+	Description   string                       `json:"description"`
+	Currency      string                       `json:"currency"`
+	Frequency     domain.RecurrenceFrequency   `json:"frequency"`
+	StartDate     time.Time                    `json:"start_date"`
+	EndDate       *time.Time                   `json:"end_date"`
+	AutoReverse   bool                         `json:"auto_reverse"`
+	IsActive      *bool                        `json:"is_active"` // Only honoured on update; new templates are active
+	Lines         []JournalTemplateLineRequest `json:"lines"`
+}
+
+// JournalTemplateDetail is a template with its lines and the entries generated from it.
+type JournalTemplateDetail struct {
+	Template *domain.JournalTemplate        `json:"template"`
+	Lines    []domain.JournalTemplateLine   `json:"lines"`
+	Entries  []domain.UniversalJournalEntry `json:"entries"`
+}
+
+// RecurringJournalService keeps journal templates and generates their entries on schedule.
+// Posting, accruals and reversals go through the GeneralLedgerService so period locks and
+// currency conversion apply as for any other entry.
+type RecurringJournalService struct {
+	templates domain.JournalTemplateRepository
+	entries   domain.UniversalJournalEntryRepository
+	accounts  domain.ChartOfAccountsRepository
+	gl        *GeneralLedgerService
+	tm        domain.TransactionManager
+}
+
+func NewRecurringJournalService(
+	templates domain.JournalTemplateRepository,
+	entries domain.UniversalJournalEntryRepository,
+	accounts domain.ChartOfAccountsRepository,
+	gl *GeneralLedgerService,
+	tm domain.TransactionManager,
+) *RecurringJournalService {
+	return &RecurringJournalService{
+		templates: templates,
+		entries:   entries,
+		accounts:  accounts,
+		gl:        gl,
+		tm:        tm,
+	}
+}
+
+func (s *RecurringJournalService) CreateTemplate(ctx context.Context, req JournalTemplateRequest) (*JournalTemplateDetail, error) {
+	if err := s.validateTemplate(ctx, &req); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &domain.JournalTemplate{
+		ID:            utils.NewID("jtpl"),
+		LegalEntityID: req.LegalEntityID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Currency:      req.Currency,
+		Frequency:     req.Frequency,
+		StartDate:     req.StartDate,
+		NextRunDate:   req.StartDate,
+		EndDate:       req.EndDate,
+		AutoReverse:   req.AutoReverse,
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	lines := templateLines(template.ID, req.Lines)
+	if err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.templates.Create(txCtx, template, lines)
+	}); err != nil {
+		return nil, err
+	}
+	return &JournalTemplateDetail{Template: template, Lines: lines, Entries: []domain.UniversalJournalEntry{}}, nil
+}
+
+// UpdateTemplate replaces a template's schedule and lines. Entries already generated are left
+// alone and the next run is the first date of the new schedule after the last one generated.
+func (s *RecurringJournalService) UpdateTemplate(ctx context.Context, id string, req JournalTemplateRequest) (*JournalTemplateDetail, error) {
+	var detail *JournalTemplateDetail
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		template, _, err := s.templates.GetByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrJournalTemplateNotFound, id)
+		}
+		req.LegalEntityID = template.LegalEntityID
+		if req.StartDate.IsZero() {
+			req.StartDate = template.StartDate
+		}
+		if err := s.validateTemplate(txCtx, &req); err != nil {
+			return err
+		}
+
+		template.Name = req.Name
+		template.Description = req.Description
+		template.Currency = req.Currency
+		template.Frequency = req.Frequency
+		template.StartDate = req.StartDate
+		template.NextRunDate = s.nextRunAfter(template, template.LastRunDate)
+		template.EndDate = req.EndDate
+		template.AutoReverse = req.AutoReverse
+		if req.IsActive != nil {
+			template.IsActive = *req.IsActive
+		}
+		template.UpdatedAt = time.Now()
+
+		lines := templateLines(template.ID, req.Lines)
+		if err := s.templates.Update(txCtx, template, lines); err != nil {
+			return err
+		}
+		detail = &JournalTemplateDetail{Template: template, Lines: lines}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if detail.Entries, err = s.generatedEntries(ctx, id); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (s *RecurringJournalService) ListTemplates(ctx context.Context, legalEntityID string) ([]domain.JournalTemplate, error) {
+	templates, err := s.templates.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]domain.JournalTemplate, 0, len(templates))
+	for _, t := range templates {
+		if legalEntityID == "" || t.LegalEntityID == legalEntityID {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+func (s *RecurringJournalService) GetTemplate(ctx context.Context, id string) (*JournalTemplateDetail, error) {
+	template, lines, err := s.templates.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrJournalTemplateNotFound, id)
+	}
+	entries, err := s.generatedEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &JournalTemplateDetail{Template: template, Lines: lines, Entries: entries}, nil
+}
+
+// GenerateEntries posts every occurrence of one template that is due by asOf, catching up on
+// missed dates. Each occurrence is posted together with the template's new next run date, so a
+// failure (e.g. a closed period) keeps the entries already generated and is retried next time.
+func (s *RecurringJournalService) GenerateEntries(ctx context.Context, id string, asOf time.Time) ([]domain.UniversalJournalEntry, error) {
+	template, _, err := s.templates.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrJournalTemplateNotFound, id)
+	}
+	if !template.IsActive {
+		return nil, fmt.Errorf("%w: %s", domain.ErrJournalTemplateInactive, id)
+	}
+
+	generated := []domain.UniversalJournalEntry{}
+	for {
+		var entry *domain.UniversalJournalEntry
+		err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			var err error
+			entry, err = s.generateNext(txCtx, id, asOf)
+			return err
+		})
+		if err != nil {
+			return generated, err
+		}
+		if entry == nil {
+			return generated, nil
+		}
+		generated = append(generated, *entry)
+	}
+}
+
+// generateNext posts the template's next occurrence if it is due and advances the schedule. It
+// returns nil when nothing is due; a template past its end date is deactivated.
+func (s *RecurringJournalService) generateNext(ctx context.Context, id string, asOf time.Time) (*domain.UniversalJournalEntry, error) {
+	template, lines, err := s.templates.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsActive || template.NextRunDate.After(asOf) {
+		return nil, nil
+	}
+	if template.EndDate != nil && template.NextRunDate.After(*template.EndDate) {
+		template.IsActive = false
+		template.UpdatedAt = time.Now()
+		return nil, s.templates.Update(ctx, template, lines)
+	}
+
+	postingDate := template.NextRunDate
+	templateID := template.ID
+	header := &domain.UniversalJournalEntry{
+		LegalEntityID:    template.LegalEntityID,
+		SourceModule:     recurringJournalSourceModule,
+		SourceDocumentID: template.ID,
+		PostingDate:      postingDate,
+		TemplateID:       &templateID,
+	}
+	if template.AutoReverse {
+		reversal := nextPeriodStart(postingDate)
+		header.ReversalDate = &reversal
+	}
+	entry, err := s.gl.PostJournalEntry(ctx, header, templateJournalLines(template, lines))
+	if err != nil {
+		return nil, fmt.Errorf("template %s on %s: %w", template.ID, postingDate.Format("2006-01-02"), err)
+	}
+
+	template.LastRunDate = &postingDate
+	template.NextRunDate = s.nextRunAfter(template, &postingDate)
+	template.UpdatedAt = time.Now()
+	if err := s.templates.Update(ctx, template, lines); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RunDueTemplates generates the due entries of every active template and then reverses the
+// accruals whose reversal date has come. A failing template does not stop the others; all
+// failures are returned together.
+func (s *RecurringJournalService) RunDueTemplates(ctx context.Context, asOf time.Time) ([]domain.UniversalJournalEntry, []domain.UniversalJournalEntry, error) {
+	templates, err := s.templates.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var errs []error
+	generated := []domain.UniversalJournalEntry{}
+	for _, t := range templates {
+		if !t.IsActive || t.NextRunDate.After(asOf) {
+			continue
+		}
+		entries, err := s.GenerateEntries(ctx, t.ID, asOf)
+		generated = append(generated, entries...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	reversed, err := s.gl.ReverseDueAccruals(ctx, asOf)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return generated, reversed, errors.Join(errs...)
+}
+
+func (s *RecurringJournalService) generatedEntries(ctx context.Context, templateID string) ([]domain.UniversalJournalEntry, error) {
+	all, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := []domain.UniversalJournalEntry{}
+	for _, e := range all {
+		if e.TemplateID != nil && *e.TemplateID == templateID {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].PostingDate.Equal(entries[j].PostingDate) {
+			return entries[i].PostingDate.Before(entries[j].PostingDate)
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// nextRunAfter is the first occurrence of the template's schedule after the given date, or the
+// start date when nothing has run yet. Occurrences are counted from the start date so that a
+// schedule starting on the 31st posts on the last day of shorter months without drifting.
+func (s *RecurringJournalService) nextRunAfter(t *domain.JournalTemplate, after *time.Time) time.Time {
+	if after == nil {
+		return t.StartDate
+	}
+	for n := 1; ; n++ {
+		next := scheduleOccurrence(t.Frequency, t.StartDate, n)
+		if next.After(*after) {
+			return next
+		}
+	}
+}
+
+func (s *RecurringJournalService) validateTemplate(ctx context.Context, req *JournalTemplateRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.LegalEntityID == "" || req.Name == "" {
+		return fmt.Errorf("%w: legal entity and name are required", domain.ErrInvalidJournalTemplate)
+	}
+	if !req.Frequency.IsValid() {
+		return fmt.Errorf("%w: unknown frequency %q", domain.ErrInvalidJournalTemplate, req.Frequency)
+	}
+	if req.StartDate.IsZero() {
+		return fmt.Errorf("%w: start date is required", domain.ErrInvalidJournalTemplate)
+	}
+	if req.EndDate != nil && req.EndDate.Before(req.StartDate) {
+		return fmt.Errorf("%w: end date is before the start date", domain.ErrInvalidJournalTemplate)
+	}
+	if req.Currency != "" && len(req.Currency) != 3 {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", domain.ErrInvalidJournalTemplate)
+	}
+	if len(req.Lines) < 2 {
+		return fmt.Errorf("%w: at least 2 lines are required", domain.ErrInvalidJournalTemplate)
+	}
+
+	sum := decimal.Zero
+	for i, l := range req.Lines {
+		if l.Amount.IsZero() {
+			return fmt.Errorf("%w: line %d has no amount", domain.ErrInvalidJournalTemplate, i+1)
+		}
+		acc, err := s.accounts.GetByID(ctx, l.AccountID)
+		if err != nil || acc.LegalEntityID != req.LegalEntityID {
+			return fmt.Errorf("%w: account %q on line %d does not belong to %s", domain.ErrInvalidJournalTemplate, l.AccountID, i+1, req.LegalEntityID)
+		}
+		if !acc.IsActive {
+			return fmt.Errorf("%w: account %s on line %d is inactive", domain.ErrInvalidJournalTemplate, acc.AccountCode, i+1)
+		}
+		sum = sum.Add(l.Amount)
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("%w: lines do not balance (sum %s)", domain.ErrInvalidJournalTemplate, sum)
+	}
+	return nil
+}
+
+func templateLines(templateID string, reqLines []JournalTemplateLineRequest) []domain.JournalTemplateLine {
+	lines := make([]domain.JournalTemplateLine, len(reqLines))
+	for i, l := range reqLines {
+		lines[i] = domain.JournalTemplateLine{
+			ID:          utils.NewID("jtl"),
+			TemplateID:  templateID,
+			AccountID:   l.AccountID,
+			Amount:      l.Amount,
+			Description: l.Description,
+		}
+	}
+	return lines
+}
+
+// templateJournalLines turns template lines into journal lines. Templates without a currency
+// post functional amounts; otherwise the GL converts at the rate of the posting date.
+func templateJournalLines(t *domain.JournalTemplate, lines []domain.JournalTemplateLine) []domain.UniversalJournalLine {
+	journalLines := make([]domain.UniversalJournalLine, len(lines))
+	for i, l := range lines {
+		jl := domain.UniversalJournalLine{AccountID: l.AccountID}
+		if t.Currency == "" {
+			jl.AmountFunctional = l.Amount
+		} else {
+			jl.AmountTransactional = l.Amount
+			jl.CurrencyTransactional = t.Currency
+		}
+		journalLines[i] = jl
+	}
+	return journalLines
+}
+
+// scheduleOccurrence is the n-th occurrence after start. Monthly steps that land past the end of
+// a shorter month fall on its last day.
+func scheduleOccurrence(freq domain.RecurrenceFrequency, start time.Time, n int) time.Time {
+	switch freq {
+	case domain.RecurrenceFrequencyWEEKLY:
+		return start.AddDate(0, 0, 7*n)
+	case domain.RecurrenceFrequencyQUARTERLY:
+		return addMonthsClamped(start, 3*n)
+	case domain.RecurrenceFrequencyYEARLY:
+		return addMonthsClamped(start, 12*n)
+	default:
+		return addMonthsClamped(start, n)
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// nextPeriodStart is the first day of the financial period after the one containing date.
+func nextPeriodStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, date.Location())
+}
+
+// CreateAccrual posts an entry that is reversed automatically on the first day of the next
+// period by ReverseDueAccruals.
+func (s *GeneralLedgerService) CreateAccrual(ctx context.Context, legalEntityID, sourceModule, sourceDocID string, postingDate time.Time, lines []domain.UniversalJournalLine) (*domain.UniversalJournalEntry, error) {
+	reversal := nextPeriodStart(postingDate)
+	return s.PostJournalEntry(ctx, &domain.UniversalJournalEntry{
+		LegalEntityID:    legalEntityID,
+		SourceModule:     sourceModule,
+		SourceDocumentID: sourceDocID,
+		PostingDate:      postingDate,
+		ReversalDate:     &reversal,
+	}, lines)
+}
+
+// ReverseDueAccruals reverses the posted accruals whose reversal date is on or before asOf. Each
+// reversal is dated on the accrual's reversal date rather than today, so a late run still lands
+// in the right period. Accruals that cannot be reversed are skipped and reported in the error.
+func (s *GeneralLedgerService) ReverseDueAccruals(ctx context.Context, asOf time.Time) ([]domain.UniversalJournalEntry, error) {
+	entries, err := s.entries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].PostingDate.Before(entries[j].PostingDate) })
+
+	var errs []error
+	reversed := []domain.UniversalJournalEntry{}
+	for _, e := range entries {
+		if e.Status != domain.LedgerStatePOSTED || e.ReversalDate == nil || e.ReversalDate.After(asOf) {
+			continue
+		}
+		rev, err := s.reverseJournalEntry(ctx, e.ID, *e.ReversalDate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("accrual %s: %w", e.ID, err))
+			continue
+		}
+		reversed = append(reversed, *rev)
+	}
+	return reversed, errors.Join(errs...)
+}
+
+// RecurringJournalScheduler generates due template entries and reverses due accruals once per
+// interval.
+type RecurringJournalScheduler struct {
+	svc      *RecurringJournalService
+	interval time.Duration
+}
+
+func NewRecurringJournalScheduler(svc *RecurringJournalService, interval time.Duration) *RecurringJournalScheduler {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &RecurringJournalScheduler{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+func (r *RecurringJournalScheduler) Start(ctx context.Context) {
+	log.Println("Starting background Recurring Journal Scheduler...")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping Recurring Journal Scheduler...")
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx, time.Now())
+		}
+	}
+}
+
+func (r *RecurringJournalScheduler) RunOnce(ctx context.Context, asOf time.Time) {
+	generated, reversed, err := r.svc.RunDueTemplates(ctx, asOf)
+	if err != nil {
+		log.Printf("[RecurringJournals] Run failed in part: %v", err)
+	}
+	if len(generated) > 0 || len(reversed) > 0 {
+		log.Printf("[RecurringJournals] %d entries generated, %d accruals reversed", len(generated), len(reversed))
+	}
+}
@@ -0,0 +1,222 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+func rentTemplate(rent, accrued *domain.ChartOfAccounts, start time.Time, autoReverse bool) service.JournalTemplateRequest {
+	return service.JournalTemplateRequest{
+		LegalEntityID: "le_1",
+		Name:          "Office rent",
+		Frequency:     domain.RecurrenceFrequencyMONTHLY,
+		StartDate:     start,
+		AutoReverse:   autoReverse,
+		Lines: []service.JournalTemplateLineRequest{
+			{AccountID: rent.ID, Amount: decimal.NewFromInt(1000)},
+			{AccountID: accrued.ID, Amount: decimal.NewFromInt(-1000)},
+		},
+	}
+}
+
+func TestCreateJournalTemplate_Validation(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	templates := memory.NewMemoryJournalTemplateRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, templates, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewRecurringJournalService(templates, entries, accounts, gl, tm)
+	ctx := context.Background()
+
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	accrued, _ := gl.CreateAccount(ctx, "le_1", "2300-001", "Accrued Liabilities", string(domain.AccountTypeLIABILITY))
+	other, _ := gl.CreateAccount(ctx, "le_2", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	end := day(2024, 12, 31)
+
+	cases := map[string]func(r *service.JournalTemplateRequest){
+		"unbalanced":           func(r *service.JournalTemplateRequest) { r.Lines[1].Amount = decimal.NewFromInt(-900) },
+		"other legal entity":   func(r *service.JournalTemplateRequest) { r.Lines[0].AccountID = other.ID },
+		"unknown frequency":    func(r *service.JournalTemplateRequest) { r.Frequency = "DAILY" },
+		"end before start":     func(r *service.JournalTemplateRequest) { r.EndDate = &end },
+		"missing start date":   func(r *service.JournalTemplateRequest) { r.StartDate = time.Time{} },
+		"single line":          func(r *service.JournalTemplateRequest) { r.Lines = r.Lines[:1] },
+		"invalid currency":     func(r *service.JournalTemplateRequest) { r.Currency = "EURO" },
+		"missing legal entity": func(r *service.JournalTemplateRequest) { r.LegalEntityID = "" },
+	}
+	for name, mutate := range cases {
+		req := rentTemplate(rent, accrued, day(2025, 1, 31), false)
+		mutate(&req)
+		if _, err := svc.CreateTemplate(ctx, req); !errors.Is(err, domain.ErrInvalidJournalTemplate) {
+			t.Errorf("%s: expected invalid template error, got %v", name, err)
+		}
+	}
+}
+
+func TestRecurringJournal_GeneratesMissedOccurrencesAtMonthEnd(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	templates := memory.NewMemoryJournalTemplateRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, templates, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewRecurringJournalService(templates, entries, accounts, gl, tm)
+	ctx := context.Background()
+
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	accrued, _ := gl.CreateAccount(ctx, "le_1", "2300-001", "Accrued Liabilities", string(domain.AccountTypeLIABILITY))
+
+	detail, err := svc.CreateTemplate(ctx, rentTemplate(rent, accrued, day(2025, 1, 31), false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id := detail.Template.ID
+
+	generated, err := svc.GenerateEntries(ctx, id, day(2025, 4, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []time.Time{day(2025, 1, 31), day(2025, 2, 28), day(2025, 3, 31), day(2025, 4, 30)}
+	if len(generated) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(generated))
+	}
+	for i, e := range generated {
+		if !e.PostingDate.Equal(want[i]) {
+			t.Errorf("entry %d: expected posting date %s, got %s", i, want[i].Format("2006-01-02"), e.PostingDate.Format("2006-01-02"))
+		}
+		if e.TemplateID == nil || *e.TemplateID != id || e.ReversalDate != nil {
+			t.Errorf("entry %d: expected a template link and no reversal, got %+v", i, e)
+		}
+	}
+
+	// Nothing is posted twice
+	if again, err := svc.GenerateEntries(ctx, id, day(2025, 4, 30)); err != nil || len(again) != 0 {
+		t.Errorf("expected no new entries, got %d (%v)", len(again), err)
+	}
+	got, _ := svc.GetTemplate(ctx, id)
+	if !got.Template.NextRunDate.Equal(day(2025, 5, 31)) || len(got.Entries) != 4 {
+		t.Errorf("expected next run on 2025-05-31 and 4 linked entries, got %s and %d",
+			got.Template.NextRunDate.Format("2006-01-02"), len(got.Entries))
+	}
+	if bal, _ := gl.GetAccountBalance(ctx, rent.ID); !bal.Equal(decimal.NewFromInt(4000)) {
+		t.Errorf("expected rent expense of 4000, got %s", bal)
+	}
+
+	// Past the end date the template is deactivated
+	req := rentTemplate(rent, accrued, day(2025, 1, 31), false)
+	end := day(2025, 5, 15)
+	req.EndDate = &end
+	if _, err := svc.UpdateTemplate(ctx, id, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if more, err := svc.GenerateEntries(ctx, id, day(2025, 6, 30)); err != nil || len(more) != 0 {
+		t.Errorf("expected nothing after the end date, got %d (%v)", len(more), err)
+	}
+	if _, err := svc.GenerateEntries(ctx, id, day(2025, 6, 30)); !errors.Is(err, domain.ErrJournalTemplateInactive) {
+		t.Errorf("expected inactive template error, got %v", err)
+	}
+}
+
+func TestRecurringJournal_AccrualsReverseOnFirstDayOfNextPeriod(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	templates := memory.NewMemoryJournalTemplateRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, templates, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	svc := service.NewRecurringJournalService(templates, entries, accounts, gl, tm)
+	ctx := context.Background()
+
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	accrued, _ := gl.CreateAccount(ctx, "le_1", "2300-001", "Accrued Liabilities", string(domain.AccountTypeLIABILITY))
+
+	detail, _ := svc.CreateTemplate(ctx, rentTemplate(rent, accrued, day(2025, 1, 31), true))
+	generated, reversed, err := svc.RunDueTemplates(ctx, day(2025, 2, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(generated) != 1 || len(reversed) != 1 {
+		t.Fatalf("expected one accrual and one reversal, got %d and %d", len(generated), len(reversed))
+	}
+	accrual, rev := generated[0], reversed[0]
+	if accrual.ReversalDate == nil || !accrual.ReversalDate.Equal(day(2025, 2, 1)) {
+		t.Errorf("expected reversal scheduled for 2025-02-01, got %v", accrual.ReversalDate)
+	}
+	if !rev.PostingDate.Equal(day(2025, 2, 1)) || rev.FinancialPeriod != "2025-02" {
+		t.Errorf("expected the reversal to post on 2025-02-01, got %s", rev.PostingDate.Format("2006-01-02"))
+	}
+	if rev.ReversalOfID == nil || *rev.ReversalOfID != accrual.ID || rev.TemplateID == nil || *rev.TemplateID != detail.Template.ID {
+		t.Errorf("expected the reversal to link to the accrual and template, got %+v", rev)
+	}
+	original, _, _ := entries.GetByID(ctx, accrual.ID)
+	if original.Status != domain.LedgerStateREVERSED {
+		t.Errorf("expected the accrual to be REVERSED, got %s", original.Status)
+	}
+	if bal, _ := gl.GetAccountBalance(ctx, accrued.ID); !bal.IsZero() {
+		t.Errorf("expected accrued liabilities to net to zero, got %s", bal)
+	}
+
+	// A reversed accrual is not reversed again
+	if _, again, err := svc.RunDueTemplates(ctx, day(2025, 2, 10)); err != nil || len(again) != 0 {
+		t.Errorf("expected no further reversals, got %d (%v)", len(again), err)
+	}
+}
+
+func TestCreateAccrual_WaitsForReversalDateAndClosedPeriods(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	periods := memory.NewMemoryFiscalPeriodRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, periods, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, periods, testConverter(), outbox, tm)
+	ctx := context.Background()
+
+	rent, err := gl.CreateAccount(ctx, "le_1", "6100-001", "Rent", string(domain.AccountTypeEXPENSE))
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	accrued, _ := gl.CreateAccount(ctx, "le_1", "2300-001", "Accrued Liabilities", string(domain.AccountTypeLIABILITY))
+
+	accrual, err := gl.CreateAccrual(ctx, "le_1", "FM", "utility-estimate", day(2025, 3, 20), []domain.UniversalJournalLine{
+		{AccountID: rent.ID, AmountFunctional: decimal.NewFromInt(250)},
+		{AccountID: accrued.ID, AmountFunctional: decimal.NewFromInt(-250)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accrual.ReversalDate == nil || !accrual.ReversalDate.Equal(day(2025, 4, 1)) {
+		t.Fatalf("expected reversal on 2025-04-01, got %v", accrual.ReversalDate)
+	}
+	if reversed, err := gl.ReverseDueAccruals(ctx, day(2025, 3, 31)); err != nil || len(reversed) != 0 {
+		t.Errorf("expected nothing to reverse before the reversal date, got %d (%v)", len(reversed), err)
+	}
+
+	// A closed target period holds the reversal back until it is reopened
+	_ = periods.Upsert(ctx, &domain.FiscalPeriod{ID: "fp_1", LegalEntityID: "le_1", FinancialPeriod: "2025-04", State: domain.PeriodStateCLOSED})
+	if reversed, err := gl.ReverseDueAccruals(ctx, day(2025, 4, 2)); !errors.Is(err, domain.ErrPeriodClosed) || len(reversed) != 0 {
+		t.Errorf("expected period closed error, got %d (%v)", len(reversed), err)
+	}
+	_ = periods.Upsert(ctx, &domain.FiscalPeriod{ID: "fp_1", LegalEntityID: "le_1", FinancialPeriod: "2025-04", State: domain.PeriodStateOPEN})
+	if reversed, err := gl.ReverseDueAccruals(ctx, day(2025, 4, 2)); err != nil || len(reversed) != 1 {
+		t.Errorf("expected the accrual to be reversed, got %d (%v)", len(reversed), err)
+	}
+}
@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,7 +14,8 @@ import (
 
 func TestTaxService_All(t *testing.T) {
 	repo := memory.NewMemoryTaxRateRepo()
-	svc := service.NewTaxService(repo)
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	svc := service.NewTaxService(repo, memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, nil, memory.NewMemoryTransactionManager(taxTransactions))
 	ctx := context.Background()
 
 	// 1. Validation error: empty code
@@ -58,12 +60,14 @@ func TestTaxService_All(t *testing.T) {
 
 func TestBudgetingService_All(t *testing.T) {
 	budgets := memory.NewMemoryBudgetRepo()
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	policies := memory.NewMemoryBudgetPolicyRepo()
 	accounts := memory.NewMemoryChartOfAccountsRepo()
 	entries := memory.NewMemoryUniversalJournalEntryRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
-	tm := memory.NewMemoryTransactionManager(budgets, outbox)
+	tm := memory.NewMemoryTransactionManager(budgets, commitments, policies, outbox)
 
-	svc := service.NewBudgetingService(budgets, accounts, entries, outbox, tm)
+	svc := service.NewBudgetingService(budgets, commitments, policies, memory.NewMemoryFiscalYearRepo(), accounts, entries, outbox, tm)
 	ctx := context.Background()
 
 	// 1. Input validations
@@ -134,7 +138,7 @@ func TestBudgetingService_All(t *testing.T) {
 	}
 
 	// GetBudgetVsActualReport - Account not found
-	_, err = svc.GetBudgetVsActualReport(ctx, "acc_1", 2026)
+	_, err = svc.GetBudgetVsActualReport(ctx, "acc_1", "", 2026)
 	if err == nil {
 		t.Error("expected error for non-existent account in report, got nil")
 	}
@@ -156,7 +160,7 @@ func TestBudgetingService_All(t *testing.T) {
 	draftEntry := &domain.UniversalJournalEntry{
 		ID:            "je_budget_spent",
 		LegalEntityID: "legal_123",
-		PostingDate:   time.Now(),
+		PostingDate:   time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
 		Status:        domain.LedgerStatePOSTED,
 	}
 	lines := []domain.UniversalJournalLine{
@@ -165,7 +169,7 @@ func TestBudgetingService_All(t *testing.T) {
 	}
 	_ = entries.Create(ctx, draftEntry, lines)
 
-	report, err := svc.GetBudgetVsActualReport(ctx, "acc_1", 2026)
+	report, err := svc.GetBudgetVsActualReport(ctx, "acc_1", "", 2026)
 	if err != nil {
 		t.Fatalf("unexpected error getting report: %v", err)
 	}
@@ -176,32 +180,56 @@ func TestBudgetingService_All(t *testing.T) {
 
 func TestAccountsPayableService_All(t *testing.T) {
 	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
-	tm := memory.NewMemoryTransactionManager(bills, outbox)
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, outbox)
 
-	svc := service.NewAccountsPayableService(bills, outbox, tm)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
 	ctx := context.Background()
 
-	// MatchPurchaseOrder
-	matched, err := svc.MatchPurchaseOrder(ctx, "b1", "po1", "gr1")
-	if err != nil || !matched {
-		t.Errorf("expected PO match to return true, nil; got %t, %v", matched, err)
-	}
-
-	_, err = svc.MatchPurchaseOrder(ctx, "", "po1", "gr1")
-	if err == nil {
-		t.Error("expected error for empty match IDs, got nil")
-	}
-
 	// CreateVendorBill
 	poID := "po_123"
-	bill, err := svc.CreateVendorBill(ctx, "legal_123", "supplier_1", "BILL-100", poID, time.Now().AddDate(0, 0, 30), decimal.NewFromInt(150), decimal.Zero)
+	bill, err := svc.CreateVendorBill(ctx, "legal_123", "supplier_1", "BILL-100", poID, "", time.Now().AddDate(0, 0, 30), decimal.NewFromInt(150), decimal.Zero)
 	if err != nil {
 		t.Fatalf("unexpected error creating bill: %v", err)
 	}
 	if bill.VendorID != "supplier_1" || bill.PurchaseOrderID != poID || bill.BillNumber != "BILL-100" || bill.Status != domain.PaymentStatusOPEN {
 		t.Errorf("unexpected bill values: %+v", bill)
 	}
+	if bill.MatchStatus != domain.BillMatchStatusPENDING || bill.PaymentHold {
+		t.Errorf("expected a bill against an unknown PO to await matching, got %s hold=%t", bill.MatchStatus, bill.PaymentHold)
+	}
+
+	// MatchPurchaseOrder
+	if err := svc.RecordPurchaseOrder(ctx, poID, "supplier_1", []service.PurchaseOrderLineRequest{
+		{MaterialID: "mat_1", QuantityOrdered: decimal.NewFromInt(10), UnitPrice: decimal.NewFromInt(15)},
+	}); err != nil {
+		t.Fatalf("unexpected error recording PO: %v", err)
+	}
+	if err := svc.RecordGoodsReceipt(ctx, "gr1", poID, time.Now(), []service.GoodsReceiptLineRequest{
+		{MaterialID: "mat_1", QuantityReceived: decimal.NewFromInt(10)},
+	}); err != nil {
+		t.Fatalf("unexpected error recording receipt: %v", err)
+	}
+	matched, err := svc.MatchPurchaseOrder(ctx, bill.ID, poID, "gr1")
+	if err != nil || !matched {
+		t.Errorf("expected PO match to return true, nil; got %t, %v", matched, err)
+	}
+
+	_, err = svc.MatchPurchaseOrder(ctx, "", poID, "gr1")
+	if !errors.Is(err, domain.ErrInvalidMatchRequest) {
+		t.Errorf("expected invalid match request for empty match IDs, got %v", err)
+	}
+	_, err = svc.MatchPurchaseOrder(ctx, bill.ID, "po_other", "gr1")
+	if !errors.Is(err, domain.ErrInvalidMatchRequest) {
+		t.Errorf("expected invalid match request for a PO the bill was not raised against, got %v", err)
+	}
+	_, err = svc.MatchPurchaseOrder(ctx, bill.ID, poID, "gr_other")
+	if !errors.Is(err, domain.ErrInvalidMatchRequest) {
+		t.Errorf("expected invalid match request for a receipt of another PO, got %v", err)
+	}
 
 	// ListVendorBills
 	list, err := svc.ListVendorBills(ctx)
@@ -217,8 +245,8 @@ func TestAccountsPayableService_All(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error getting bill: %v", err)
 	}
-	if retrieved.ID != bill.ID {
-		t.Errorf("unexpected retrieved bill ID: %s", retrieved.ID)
+	if retrieved.ID != bill.ID || retrieved.MatchStatus != domain.BillMatchStatusMATCHED {
+		t.Errorf("unexpected retrieved bill: %+v", retrieved)
 	}
 }
 
@@ -228,7 +256,7 @@ func TestAccountsReceivableService_All(t *testing.T) {
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(invoices, outbox)
 
-	svc := service.NewAccountsReceivableService(invoices, credits, outbox, tm)
+	svc := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
 	ctx := context.Background()
 
 	// CheckCustomerCredit
@@ -242,7 +270,7 @@ func TestAccountsReceivableService_All(t *testing.T) {
 	}
 
 	// CreateInvoice
-	inv, err := svc.CreateInvoice(ctx, "legal_123", "cust_1", "so_123", decimal.NewFromInt(200), decimal.Zero, time.Now().AddDate(0, 0, 14))
+	inv, err := svc.CreateInvoice(ctx, "legal_123", "cust_1", "so_123", "", decimal.NewFromInt(200), decimal.Zero, time.Now().AddDate(0, 0, 14))
 	if err != nil {
 		t.Fatalf("unexpected error creating invoice: %v", err)
 	}
@@ -308,8 +336,8 @@ func TestAccountsReceivableService_All(t *testing.T) {
 		t.Fatalf("unexpected error marking overdue: %v", err)
 	}
 	overdueInv, _ := svc.GetInvoice(ctx, inv.ID)
-	if overdueInv.Status != domain.PaymentStatusOPEN {
-		t.Errorf("expected status OPEN, got %s", overdueInv.Status)
+	if overdueInv.Status != domain.PaymentStatusOVERDUE {
+		t.Errorf("expected status OVERDUE, got %s", overdueInv.Status)
 	}
 
 	// MarkInvoiceOverdue non-existent
@@ -329,10 +357,34 @@ func TestCashManagementService_All(t *testing.T) {
 	payments := memory.NewMemoryPaymentRepo()
 	invoices := memory.NewMemoryArInvoiceRepo()
 	statements := memory.NewMemoryBankStatementRepo()
+	bankAccounts := memory.NewMemoryBankAccountRepo()
+	matches := memory.NewMemoryBankReconciliationMatchRepo()
+	exceptions := memory.NewMemoryBankReconciliationExceptionRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	payrollRuns := memory.NewMemoryPayrollRunSnapshotRepo()
+	recurringItems := memory.NewMemoryRecurringCashItemRepo()
+	allocations := memory.NewMemoryPaymentAllocationRepo()
+	creditMemos := memory.NewMemoryArCreditMemoRepo()
+	onAccount := memory.NewMemoryOnAccountCreditRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
-	tm := memory.NewMemoryTransactionManager(payments, invoices, outbox)
-
-	svc := service.NewCashManagementService(payments, invoices, statements, outbox, tm)
+	tm := memory.NewMemoryTransactionManager(payments, invoices, matches, exceptions, allocations, creditMemos, onAccount, outbox)
+
+	svc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: onAccount,
+		Statements:       statements,
+		BankAccounts:     bankAccounts,
+		Matches:          matches,
+		Exceptions:       exceptions,
+		PayrollRuns:      payrollRuns,
+		RecurringItems:   recurringItems,
+		Outbox:           outbox,
+		TM:               tm,
+	})
 	ctx := context.Background()
 
 	// GetBankStatement - missing stmt
@@ -344,11 +396,26 @@ func TestCashManagementService_All(t *testing.T) {
 	// RecordPayment - Successful with InvoiceID
 	invRepo := memory.NewMemoryArInvoiceRepo()
 	credits := memory.NewMemoryCustomerCreditRepo()
-	invSvc := service.NewAccountsReceivableService(invRepo, credits, outbox, tm)
-	inv, _ := invSvc.CreateInvoice(ctx, "legal_123", "cust_1", "so_123", decimal.NewFromInt(100), decimal.Zero, time.Now().AddDate(0, 0, 10))
+	invSvc := service.NewAccountsReceivableService(invRepo, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
+	inv, _ := invSvc.CreateInvoice(ctx, "legal_123", "cust_1", "so_123", "", decimal.NewFromInt(100), decimal.Zero, time.Now().AddDate(0, 0, 10))
 
 	// Update svc with the same invoice repo
-	svc = service.NewCashManagementService(payments, invRepo, statements, outbox, tm)
+	svc = service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invRepo,
+		Bills:            bills,
+		Allocations:      allocations,
+		CreditMemos:      creditMemos,
+		OnAccountCredits: onAccount,
+		Statements:       statements,
+		BankAccounts:     bankAccounts,
+		Matches:          matches,
+		Exceptions:       exceptions,
+		PayrollRuns:      payrollRuns,
+		RecurringItems:   recurringItems,
+		Outbox:           outbox,
+		TM:               tm,
+	})
 
 	pay, err := svc.RecordPayment(ctx, inv.ID, "bill_1", "bank_1", decimal.NewFromInt(100), "WIRE")
 	if err != nil {
@@ -382,20 +449,27 @@ func TestCashManagementService_All(t *testing.T) {
 		t.Errorf("retrieved payment ID mismatch")
 	}
 
-	// ReconcileBankStatement
-	err = svc.ReconcileBankStatement(ctx, "stmt_123")
-	if err != nil {
-		t.Errorf("expected nil error for reconcile statement, got %v", err)
+	// ReconcileBankStatement - missing stmt
+	_, err = svc.ReconcileBankStatement(ctx, "stmt_123")
+	if err == nil {
+		t.Error("expected error reconciling missing statement, got nil")
 	}
 
-	// GetCashFlowForecast
-	forecast, err := svc.GetCashFlowForecast(ctx, 3)
+	// GetCashFlowForecast - the only invoice is paid, so nothing is projected
+	forecast, err := svc.GetCashFlowForecast(ctx, service.CashFlowForecastRequest{MonthsAhead: 3})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if forecast["forecast_period_months"] != 3 || !forecast["net_cash_flow"].(decimal.Decimal).Equal(decimal.NewFromInt(45000)) {
+	if forecast.MonthsAhead != 3 || forecast.Granularity != service.ForecastGranularityMONTH {
 		t.Errorf("unexpected forecast values: %+v", forecast)
 	}
+	for _, le := range forecast.LegalEntities {
+		for _, p := range le.Periods {
+			if !p.NetCashFlow.IsZero() {
+				t.Errorf("expected no projected flows, got %+v", p)
+			}
+		}
+	}
 
 	// RecordPayment failing - invalid invoice ID triggers error inside transaction, verify outbox failed event
 	_, err = svc.RecordPayment(ctx, "non_existent_inv", "", "", decimal.NewFromInt(100), "CASH")
@@ -423,7 +497,7 @@ func TestGeneralLedgerService_All(t *testing.T) {
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
 
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 	ctx := context.Background()
 
 	// 1. CreateAccount validation
@@ -521,39 +595,37 @@ func TestGeneralLedgerService_All(t *testing.T) {
 		t.Errorf("balances incorrect. Cash: %s, Revenue: %s", balA, balB)
 	}
 
-	// GetTrialBalance
-	tb, err := svc.GetTrialBalance(ctx)
+	// Financial reports over the same ledger
+	reports := service.NewFinancialReportService(accounts, entries, memory.NewMemoryBudgetRepo())
+	tb, err := reports.GetTrialBalance(ctx, service.ReportFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !tb["total_debits"].(decimal.Decimal).Equal(decimal.NewFromInt(100)) || !tb["total_credits"].(decimal.Decimal).Equal(decimal.NewFromInt(100)) {
+	if !tb.TotalDebits.Equal(decimal.NewFromInt(100)) || !tb.TotalCredits.Equal(decimal.NewFromInt(100)) {
 		t.Errorf("TB unbalanced: %+v", tb)
 	}
 
-	// GetBalanceSheet
-	bs, err := svc.GetBalanceSheet(ctx)
+	bs, err := reports.GetBalanceSheet(ctx, service.ReportFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !bs["total_assets"].(decimal.Decimal).Equal(decimal.NewFromInt(100)) {
+	if !bs.Assets.Total.Equal(decimal.NewFromInt(100)) {
 		t.Errorf("BS assets wrong: %+v", bs)
 	}
 
-	// GetIncomeStatement
-	is, err := svc.GetIncomeStatement(ctx)
+	is, err := reports.GetIncomeStatement(ctx, service.ReportFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !is["total_revenue"].(decimal.Decimal).Equal(decimal.NewFromInt(100)) {
+	if !is.Revenues.Total.Equal(decimal.NewFromInt(100)) {
 		t.Errorf("IS revenue wrong: %+v", is)
 	}
 
-	// GetCashFlow
-	cf, err := svc.GetCashFlow(ctx)
+	cf, err := reports.GetCashFlow(ctx, service.ReportFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !cf["total_inflows"].(decimal.Decimal).Equal(decimal.NewFromInt(100)) {
+	if !cf.TotalInflows.Equal(decimal.NewFromInt(100)) {
 		t.Errorf("CF inflow wrong: %+v", cf)
 	}
 
@@ -610,7 +682,7 @@ func TestGeneralLedgerService_UpdateJournalEntry_ValidationsAndErrors(t *testing
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
 
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 	ctx := context.Background()
 
 	// Create accounts
@@ -692,26 +764,27 @@ func TestLegalEntityService_All(t *testing.T) {
 func TestCapitalAssetService_All(t *testing.T) {
 	assetRepo := memory.NewMemoryCapitalAssetRepo()
 	lineRepo := memory.NewMemoryDepreciationScheduleLineRepo()
+	usageRepo := memory.NewMemoryAssetUsageRecordRepo()
 	accounts := memory.NewMemoryChartOfAccountsRepo()
 	entries := memory.NewMemoryUniversalJournalEntryRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
-	tm := memory.NewMemoryTransactionManager(assetRepo, lineRepo, accounts, entries, outbox)
+	tm := memory.NewMemoryTransactionManager(assetRepo, lineRepo, usageRepo, accounts, entries, outbox)
 
-	svc := service.NewCapitalAssetService(assetRepo, lineRepo, accounts, entries, outbox, tm)
+	svc := service.NewCapitalAssetService(assetRepo, lineRepo, usageRepo, accounts, entries, outbox, tm)
 	ctx := context.Background()
 
 	// 1. Capitalization validations
-	_, err := svc.CapitalizeAsset(ctx, "", "EQ-001", decimal.NewFromInt(1200), 12, nil)
+	_, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{AssetTag: "EQ-001", AcquisitionCost: decimal.NewFromInt(1200), UsefulLifeMonths: 12})
 	if err == nil {
 		t.Error("expected error for empty legal entity ID")
 	}
-	_, err = svc.CapitalizeAsset(ctx, "legal_123", "EQ-001", decimal.Zero, 12, nil)
+	_, err = svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{LegalEntityID: "legal_123", AssetTag: "EQ-001", AcquisitionCost: decimal.Zero, UsefulLifeMonths: 12})
 	if err == nil {
 		t.Error("expected error for zero acquisition cost")
 	}
 
 	// 2. Capitalize success
-	asset, err := svc.CapitalizeAsset(ctx, "legal_123", "EQ-001", decimal.NewFromInt(1200), 12, nil)
+	asset, err := svc.CapitalizeAsset(ctx, service.CapitalizeAssetRequest{LegalEntityID: "legal_123", AssetTag: "EQ-001", AcquisitionCost: decimal.NewFromInt(1200), UsefulLifeMonths: 12})
 	if err != nil {
 		t.Fatalf("unexpected error capitalizing asset: %v", err)
 	}
@@ -730,7 +803,7 @@ func TestCapitalAssetService_All(t *testing.T) {
 	}
 
 	// 4. Post Monthly Depreciation
-	err = svc.PostMonthlyStraightLineDepreciation(ctx, "legal_123", lines[0].FiscalYear, lines[0].PeriodNumber)
+	err = svc.PostMonthlyDepreciation(ctx, "legal_123", lines[0].FiscalYear, lines[0].PeriodNumber)
 	if err != nil {
 		t.Fatalf("failed to post monthly depreciation: %v", err)
 	}
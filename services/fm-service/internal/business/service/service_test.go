@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -17,7 +18,7 @@ func TestGeneralLedgerService_CreateAccount_PublishesEvent(t *testing.T) {
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
 
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 
 	acc, err := svc.CreateAccount(context.Background(), "legal_123", "1000", "Cash", "ASSET")
 	if err != nil {
@@ -52,15 +53,109 @@ func TestGeneralLedgerService_CreateAccount_PublishesEvent(t *testing.T) {
 	}
 }
 
+// unreachableAccountsRepo fails every lookup the way an unavailable database would
+type unreachableAccountsRepo struct {
+	*memory.MemoryChartOfAccountsRepo
+}
+
+func (r unreachableAccountsRepo) GetByCode(ctx context.Context, legalEntityID, accountCode string) (*domain.ChartOfAccounts, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestGeneralLedgerService_EnsureAccount(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+
+	created, err := svc.EnsureAccount(ctx, "legal_123", "7910-001", "Unrealized FX Gain", domain.AccountTypeREVENUE)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	again, err := svc.EnsureAccount(ctx, "legal_123", "7910-001", "Unrealized FX Gain", domain.AccountTypeREVENUE)
+	if err != nil || again.ID != created.ID {
+		t.Fatalf("expected the existing account %s, got %+v (%v)", created.ID, again, err)
+	}
+
+	// A failed lookup must not be taken for a missing account and create a duplicate
+	broken := service.NewGeneralLedgerService(unreachableAccountsRepo{accounts}, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	if _, err := broken.EnsureAccount(ctx, "legal_123", "7910-001", "Unrealized FX Gain", domain.AccountTypeREVENUE); err == nil || err.Error() != "connection refused" {
+		t.Errorf("expected the lookup error, got %v", err)
+	}
+	if list, _ := accounts.List(ctx); len(list) != 1 {
+		t.Errorf("expected 1 account, got %d", len(list))
+	}
+}
+
+func TestGeneralLedgerService_AccountDetermination(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+
+	// Unconfigured keys post to their default account
+	def, err := svc.DetermineAccount(ctx, "legal_123", domain.PostingKeyDUNNING_FEE_INCOME)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if def.AccountCode != "7940-001" || def.Type != domain.AccountTypeREVENUE {
+		t.Errorf("expected default revenue account 7940-001, got %s %s", def.AccountCode, def.Type)
+	}
+
+	if _, err := svc.SetAccountDetermination(ctx, "legal_123", domain.PostingKeyDUNNING_FEE_INCOME, "4900-001"); !errors.Is(err, domain.ErrInvalidAccountDetermination) {
+		t.Errorf("expected ErrInvalidAccountDetermination for a missing account, got %v", err)
+	}
+	if _, err := svc.SetAccountDetermination(ctx, "legal_123", domain.PostingKey("ROUNDING"), "7940-001"); !errors.Is(err, domain.ErrInvalidAccountDetermination) {
+		t.Errorf("expected ErrInvalidAccountDetermination for an unknown key, got %v", err)
+	}
+
+	fees, _ := svc.CreateAccount(ctx, "legal_123", "4900-001", "Other Operating Income", "REVENUE")
+	first, err := svc.SetAccountDetermination(ctx, "legal_123", domain.PostingKeyDUNNING_FEE_INCOME, "4900-001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	acc, err := svc.DetermineAccount(ctx, "legal_123", domain.PostingKeyDUNNING_FEE_INCOME)
+	if err != nil || acc.ID != fees.ID {
+		t.Fatalf("expected the configured account %s, got %+v (%v)", fees.ID, acc, err)
+	}
+	// Other legal entities keep the default
+	if other, _ := svc.DetermineAccount(ctx, "legal_456", domain.PostingKeyDUNNING_FEE_INCOME); other == nil || other.AccountCode != "7940-001" {
+		t.Errorf("expected legal_456 to post to 7940-001, got %+v", other)
+	}
+
+	// Reconfiguring a key replaces its account
+	_, _ = svc.CreateAccount(ctx, "legal_123", "4910-001", "Late Fees", "REVENUE")
+	again, err := svc.SetAccountDetermination(ctx, "legal_123", domain.PostingKeyDUNNING_FEE_INCOME, "4910-001")
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("expected determination %s to be updated, got %+v (%v)", first.ID, again, err)
+	}
+
+	list, err := svc.ListAccountDeterminations(ctx, "legal_123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codes := make(map[domain.PostingKey]string)
+	for _, d := range list {
+		codes[d.PostingKey] = d.AccountCode
+	}
+	if len(list) != 11 || codes[domain.PostingKeyDUNNING_FEE_INCOME] != "4910-001" || codes[domain.PostingKeyAR_CONTROL] != "1100-001" {
+		t.Errorf("expected every key with the configured fee account and default AR control, got %v", codes)
+	}
+}
+
 func TestAccountsReceivableService_CreateInvoice_PublishesEvent(t *testing.T) {
 	invoices := memory.NewMemoryArInvoiceRepo()
 	credits := memory.NewMemoryCustomerCreditRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(invoices, outbox)
 
-	svc := service.NewAccountsReceivableService(invoices, credits, outbox, tm)
+	svc := service.NewAccountsReceivableService(invoices, credits, memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), nil, outbox, tm)
 
-	inv, err := svc.CreateInvoice(context.Background(), "legal_123", "cust_123", "so_123", decimal.NewFromInt(750), decimal.NewFromInt(50), time.Now().AddDate(0, 0, 30))
+	inv, err := svc.CreateInvoice(context.Background(), "legal_123", "cust_123", "so_123", "", decimal.NewFromInt(750), decimal.NewFromInt(50), time.Now().AddDate(0, 0, 30))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -90,7 +185,7 @@ func TestGeneralLedgerService_UpdateJournalEntry(t *testing.T) {
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	tm := memory.NewMemoryTransactionManager(accounts, entries, outbox)
 
-	svc := service.NewGeneralLedgerService(accounts, entries, outbox, tm)
+	svc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
 
 	ctx := context.Background()
 
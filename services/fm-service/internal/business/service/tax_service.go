@@ -2,19 +2,155 @@ package service
 
 import (
 	"context"
+	"erp-system/shared/utils"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/shopspring/decimal"
 )
 
+const (
+	taxAmountPlaces          = 2
+	taxJurisdictionDimension = "tax_jurisdiction"
+	taxCodeDimension         = "tax_code"
+)
+
+// TaxRateRequest defines a rate levied by a jurisdiction. TaxCategory limits it to products of
+// that category; rates without one are the jurisdiction's standard rates. Compound rates are
+// levied on the net amount plus the taxes sequenced before them.
+type TaxRateRequest struct {
+	Code                 string          `json:"code"`
+	Name                 string          `json:"name"`
+	Rate                 decimal.Decimal `json:"rate"`
+	JurisdictionCode     string          `json:"jurisdiction_code"`
+	TaxCategory          string          `json:"tax_category,omitempty"`
+	IsCompound           bool            `json:"is_compound"`
+	Sequence             int             `json:"sequence"`
+	LiabilityAccountCode string          `json:"liability_account_code,omitempty"`
+	ValidFrom            *time.Time      `json:"valid_from,omitempty"`
+	ValidTo              *time.Time      `json:"valid_to,omitempty"`
+}
+
+// TaxLineRequest is one line of a document to determine tax for. Amount is net of tax unless the
+// request prices include tax.
+type TaxLineRequest struct {
+	Description string          `json:"description,omitempty"`
+	TaxCategory string          `json:"tax_category,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// TaxRequest describes a sale or purchase shipped between two jurisdictions. CustomerID selects
+// the exemption certificates of a sale. Without a ship-from jurisdiction the sale is taxed at
+// the destination; without a ship-to jurisdiction, at the origin.
+type TaxRequest struct {
+	Direction        domain.TaxDirection `json:"direction"`
+	CustomerID       string              `json:"customer_id,omitempty"`
+	ShipFrom         string              `json:"ship_from,omitempty"`
+	ShipTo           string              `json:"ship_to,omitempty"`
+	Date             time.Time           `json:"date"`
+	PricesIncludeTax bool                `json:"prices_include_tax"`
+	Lines            []TaxLineRequest    `json:"lines"`
+}
+
+// TaxComponent is one rate applied to a line, or the total of a rate across a document.
+type TaxComponent struct {
+	TaxRateID            string          `json:"tax_rate_id"`
+	TaxCode              string          `json:"tax_code"`
+	JurisdictionID       string          `json:"jurisdiction_id"`
+	JurisdictionCode     string          `json:"jurisdiction_code"`
+	Rate                 decimal.Decimal `json:"rate"`
+	IsCompound           bool            `json:"is_compound"`
+	IsExempt             bool            `json:"is_exempt"`
+	TaxableAmount        decimal.Decimal `json:"taxable_amount"`
+	TaxAmount            decimal.Decimal `json:"tax_amount"`
+	LiabilityAccountCode string          `json:"liability_account_code,omitempty"` // Empty posts to the TAX_LIABILITY account
+}
+
+type TaxDeterminationLine struct {
+	Description string          `json:"description,omitempty"`
+	TaxCategory string          `json:"tax_category,omitempty"`
+	NetAmount   decimal.Decimal `json:"net_amount"`
+	TaxAmount   decimal.Decimal `json:"tax_amount"`
+	GrossAmount decimal.Decimal `json:"gross_amount"`
+	Taxes       []TaxComponent  `json:"taxes"`
+}
+
+// TaxDetermination is the tax of a document per line and per rate. TaxingJurisdiction is empty
+// for cross-border supplies, which are zero-rated.
+type TaxDetermination struct {
+	Direction          domain.TaxDirection    `json:"direction"`
+	TaxingJurisdiction string                 `json:"taxing_jurisdiction,omitempty"`
+	NetAmount          decimal.Decimal        `json:"net_amount"`
+	TaxAmount          decimal.Decimal        `json:"tax_amount"`
+	GrossAmount        decimal.Decimal        `json:"gross_amount"`
+	Lines              []TaxDeterminationLine `json:"lines"`
+	Taxes              []TaxComponent         `json:"taxes"`
+}
+
+// TaxPostingRequest posts a determination for an AR invoice or AP bill. Amounts are in the
+// document currency and converted at its booking rate.
+type TaxPostingRequest struct {
+	LegalEntityID    string
+	SourceDocumentID string
+	PostingDate      time.Time
+	Currency         string
+	ExchangeRate     decimal.Decimal
+	Determination    *TaxDetermination
+}
+
+// TaxReturnLine totals the posted tax of one rate in functional currency.
+type TaxReturnLine struct {
+	JurisdictionCode string          `json:"jurisdiction_code"`
+	TaxCode          string          `json:"tax_code"`
+	Rate             decimal.Decimal `json:"rate"`
+	TaxableSales     decimal.Decimal `json:"taxable_sales"`
+	ExemptSales      decimal.Decimal `json:"exempt_sales"`
+	OutputTax        decimal.Decimal `json:"output_tax"`
+	TaxablePurchases decimal.Decimal `json:"taxable_purchases"`
+	InputTax         decimal.Decimal `json:"input_tax"`
+	NetTax           decimal.Decimal `json:"net_tax"`
+}
+
+// TaxReturn summarizes the VAT or sales tax of a legal entity over a range of periods. A positive
+// NetTaxPayable is owed to the tax authorities, a negative one is refundable.
+type TaxReturn struct {
+	LegalEntityID  string          `json:"legal_entity_id"`
+	FromPeriod     string          `json:"from_period"`
+	ToPeriod       string          `json:"to_period"`
+	Lines          []TaxReturnLine `json:"lines"`
+	TotalOutputTax decimal.Decimal `json:"total_output_tax"`
+	TotalInputTax  decimal.Decimal `json:"total_input_tax"`
+	NetTaxPayable  decimal.Decimal `json:"net_tax_payable"`
+}
+
 type TaxService struct {
-	repo domain.TaxRateRepository
+	repo          domain.TaxRateRepository
+	jurisdictions domain.TaxJurisdictionRepository
+	exemptions    domain.TaxExemptionRepository
+	transactions  domain.TaxTransactionRepository
+	gl            *GeneralLedgerService
+	tm            domain.TransactionManager
 }
 
-func NewTaxService(repo domain.TaxRateRepository) *TaxService {
-	return &TaxService{repo: repo}
+func NewTaxService(
+	repo domain.TaxRateRepository,
+	jurisdictions domain.TaxJurisdictionRepository,
+	exemptions domain.TaxExemptionRepository,
+	transactions domain.TaxTransactionRepository,
+	gl *GeneralLedgerService,
+	tm domain.TransactionManager,
+) *TaxService {
+	return &TaxService{
+		repo:          repo,
+		jurisdictions: jurisdictions,
+		exemptions:    exemptions,
+		transactions:  transactions,
+		gl:            gl,
+		tm:            tm,
+	}
 }
 
 func (s *TaxService) CreateTaxRate(ctx context.Context, code, name string, rate decimal.Decimal) (*domain.TaxRate, error) {
@@ -39,6 +175,41 @@ func (s *TaxService) CreateTaxRate(ctx context.Context, code, name string, rate
 	return taxRate, nil
 }
 
+// CreateJurisdictionRate creates a rate the tax engine determines for its jurisdiction
+func (s *TaxService) CreateJurisdictionRate(ctx context.Context, req TaxRateRequest) (*domain.TaxRate, error) {
+	if req.Code == "" || req.Name == "" || req.JurisdictionCode == "" {
+		return nil, fmt.Errorf("%w: code, name and jurisdiction are required", domain.ErrInvalidTaxRequest)
+	}
+	if req.Rate.IsNegative() {
+		return nil, fmt.Errorf("%w: rate cannot be negative", domain.ErrInvalidTaxRequest)
+	}
+	if req.ValidFrom != nil && req.ValidTo != nil && req.ValidTo.Before(*req.ValidFrom) {
+		return nil, fmt.Errorf("%w: valid_to is before valid_from", domain.ErrInvalidTaxRequest)
+	}
+	j, err := s.jurisdictions.GetByCode(ctx, req.JurisdictionCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTaxJurisdictionNotFound, req.JurisdictionCode)
+	}
+	taxRate := &domain.TaxRate{
+		ID:                   fmt.Sprintf("tax_%s", req.Code),
+		Code:                 req.Code,
+		Name:                 req.Name,
+		Rate:                 req.Rate,
+		IsActive:             true,
+		JurisdictionID:       &j.ID,
+		TaxCategory:          req.TaxCategory,
+		IsCompound:           req.IsCompound,
+		Sequence:             req.Sequence,
+		LiabilityAccountCode: req.LiabilityAccountCode,
+		ValidFrom:            req.ValidFrom,
+		ValidTo:              req.ValidTo,
+	}
+	if err := s.repo.Create(ctx, taxRate); err != nil {
+		return nil, err
+	}
+	return taxRate, nil
+}
+
 func (s *TaxService) ListTaxRates(ctx context.Context) ([]domain.TaxRate, error) {
 	return s.repo.List(ctx)
 }
@@ -46,3 +217,490 @@ func (s *TaxService) ListTaxRates(ctx context.Context) ([]domain.TaxRate, error)
 func (s *TaxService) GetTaxRate(ctx context.Context, id string) (*domain.TaxRate, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+// CreateJurisdiction adds a jurisdiction below parentCode, or a country when parentCode is empty.
+// ORIGIN sourcing taxes supplies within the jurisdiction at the ship-from address.
+func (s *TaxService) CreateJurisdiction(ctx context.Context, code, name, parentCode string, sourcing domain.TaxSourcing) (*domain.TaxJurisdiction, error) {
+	if code == "" || name == "" {
+		return nil, fmt.Errorf("%w: jurisdiction code and name are required", domain.ErrInvalidTaxRequest)
+	}
+	if sourcing == "" {
+		sourcing = domain.TaxSourcingDESTINATION
+	}
+	if !sourcing.IsValid() {
+		return nil, fmt.Errorf("%w: sourcing must be DESTINATION or ORIGIN", domain.ErrInvalidTaxRequest)
+	}
+
+	j := &domain.TaxJurisdiction{
+		ID:        utils.NewID("txj"),
+		Code:      code,
+		Name:      name,
+		Sourcing:  sourcing,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if parentCode != "" {
+		parent, err := s.jurisdictions.GetByCode(ctx, parentCode)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTaxJurisdictionNotFound, parentCode)
+		}
+		j.ParentID = &parent.ID
+	}
+	if err := s.jurisdictions.Create(ctx, j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (s *TaxService) ListJurisdictions(ctx context.Context) ([]domain.TaxJurisdiction, error) {
+	return s.jurisdictions.List(ctx)
+}
+
+// CreateExemption records a customer's exemption certificate for a jurisdiction and those below it
+func (s *TaxService) CreateExemption(ctx context.Context, customerID, jurisdictionCode, certificateNumber string, validFrom time.Time, validTo *time.Time) (*domain.TaxExemption, error) {
+	if customerID == "" || certificateNumber == "" {
+		return nil, fmt.Errorf("%w: customer and certificate number are required", domain.ErrInvalidTaxRequest)
+	}
+	if validTo != nil && validTo.Before(validFrom) {
+		return nil, fmt.Errorf("%w: exemption ends before it starts", domain.ErrInvalidTaxRequest)
+	}
+	j, err := s.jurisdictions.GetByCode(ctx, jurisdictionCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTaxJurisdictionNotFound, jurisdictionCode)
+	}
+
+	exemption := &domain.TaxExemption{
+		ID:                utils.NewID("txe"),
+		CustomerID:        customerID,
+		JurisdictionID:    j.ID,
+		CertificateNumber: certificateNumber,
+		ValidFrom:         validFrom,
+		ValidTo:           validTo,
+		CreatedAt:         time.Now(),
+	}
+	if err := s.exemptions.Create(ctx, exemption); err != nil {
+		return nil, err
+	}
+	return exemption, nil
+}
+
+func (s *TaxService) ListExemptions(ctx context.Context, customerID string) ([]domain.TaxExemption, error) {
+	return s.exemptions.ListByCustomer(ctx, customerID)
+}
+
+// applicableRate is a rate levied on a line, in the order it is computed
+type applicableRate struct {
+	rate         domain.TaxRate
+	jurisdiction domain.TaxJurisdiction
+	exempt       bool
+}
+
+// DetermineTax picks the rates of the taxing jurisdiction and its parents for each line and
+// computes the tax. Supplies within an ORIGIN-sourced jurisdiction are taxed at the ship-from
+// address, all others at the ship-to address; supplies between countries are zero-rated. A line
+// uses the rates of its tax category where a jurisdiction defines them, otherwise the standard
+// rates. Only rates in effect on the request date apply. Rates of jurisdictions the customer
+// holds a valid exemption for are exempt.
+func (s *TaxService) DetermineTax(ctx context.Context, req TaxRequest) (*TaxDetermination, error) {
+	if !req.Direction.IsValid() {
+		return nil, fmt.Errorf("%w: direction must be SALES or PURCHASE", domain.ErrInvalidTaxRequest)
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidTaxRequest)
+	}
+	if req.ShipFrom == "" && req.ShipTo == "" {
+		return nil, fmt.Errorf("%w: a ship-from or ship-to jurisdiction is required", domain.ErrInvalidTaxRequest)
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+
+	chain, err := s.taxingChain(ctx, req.ShipFrom, req.ShipTo)
+	if err != nil {
+		return nil, err
+	}
+	det := &TaxDetermination{Direction: req.Direction}
+	if len(chain) > 0 {
+		det.TaxingJurisdiction = chain[len(chain)-1].Code
+	}
+
+	rates, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exemptFrom, err := s.exemptFrom(ctx, req, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int)
+	for _, l := range req.Lines {
+		apps := applicableRates(rates, chain, l.TaxCategory, exemptFrom, req.Date)
+		line := computeLine(l, apps, req.PricesIncludeTax)
+		det.Lines = append(det.Lines, line)
+		det.NetAmount = det.NetAmount.Add(line.NetAmount)
+		det.TaxAmount = det.TaxAmount.Add(line.TaxAmount)
+		det.GrossAmount = det.GrossAmount.Add(line.GrossAmount)
+
+		for _, c := range line.Taxes {
+			i, ok := totals[c.TaxRateID]
+			if !ok {
+				total := c
+				total.TaxableAmount = decimal.Zero
+				total.TaxAmount = decimal.Zero
+				i = len(det.Taxes)
+				totals[c.TaxRateID] = i
+				det.Taxes = append(det.Taxes, total)
+			}
+			det.Taxes[i].TaxableAmount = det.Taxes[i].TaxableAmount.Add(c.TaxableAmount)
+			det.Taxes[i].TaxAmount = det.Taxes[i].TaxAmount.Add(c.TaxAmount)
+		}
+	}
+	return det, nil
+}
+
+// taxingChain returns the taxing jurisdiction and its parents, country first. It is empty for
+// supplies between countries.
+func (s *TaxService) taxingChain(ctx context.Context, shipFrom, shipTo string) ([]domain.TaxJurisdiction, error) {
+	from, err := s.chain(ctx, shipFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.chain(ctx, shipTo)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(to) == 0:
+		return from, nil
+	case len(from) == 0:
+		return to, nil
+	case from[0].ID != to[0].ID:
+		return nil, nil
+	}
+	for _, j := range to {
+		if j.Sourcing != domain.TaxSourcingORIGIN {
+			continue
+		}
+		for _, f := range from {
+			if f.ID == j.ID {
+				return from, nil
+			}
+		}
+	}
+	return to, nil
+}
+
+func (s *TaxService) chain(ctx context.Context, code string) ([]domain.TaxJurisdiction, error) {
+	if code == "" {
+		return nil, nil
+	}
+	j, err := s.jurisdictions.GetByCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTaxJurisdictionNotFound, code)
+	}
+	chain := []domain.TaxJurisdiction{*j}
+	for j.ParentID != nil {
+		if j, err = s.jurisdictions.GetByID(ctx, *j.ParentID); err != nil {
+			return nil, err
+		}
+		chain = append([]domain.TaxJurisdiction{*j}, chain...)
+	}
+	return chain, nil
+}
+
+// exemptFrom returns the position in the chain from which the customer's certificates exempt the
+// sale, or the chain length if none applies
+func (s *TaxService) exemptFrom(ctx context.Context, req TaxRequest, chain []domain.TaxJurisdiction) (int, error) {
+	if req.Direction != domain.TaxDirectionSALES || req.CustomerID == "" {
+		return len(chain), nil
+	}
+	exemptions, err := s.exemptions.ListByCustomer(ctx, req.CustomerID)
+	if err != nil {
+		return 0, err
+	}
+	from := len(chain)
+	for _, e := range exemptions {
+		if req.Date.Before(e.ValidFrom) || (e.ValidTo != nil && req.Date.After(*e.ValidTo)) {
+			continue
+		}
+		for i, j := range chain {
+			if j.ID == e.JurisdictionID && i < from {
+				from = i
+			}
+		}
+	}
+	return from, nil
+}
+
+func applicableRates(rates []domain.TaxRate, chain []domain.TaxJurisdiction, category string, exemptFrom int, date time.Time) []applicableRate {
+	var apps []applicableRate
+	for i, j := range chain {
+		var standard, categorized []domain.TaxRate
+		for _, r := range rates {
+			if !r.IsActive || r.JurisdictionID == nil || *r.JurisdictionID != j.ID || !rateInEffect(r, date) {
+				continue
+			}
+			switch r.TaxCategory {
+			case "":
+				standard = append(standard, r)
+			case category:
+				categorized = append(categorized, r)
+			}
+		}
+		selected := standard
+		if category != "" && len(categorized) > 0 {
+			selected = categorized
+		}
+		sort.SliceStable(selected, func(a, b int) bool { return selected[a].Sequence < selected[b].Sequence })
+		for _, r := range selected {
+			apps = append(apps, applicableRate{rate: r, jurisdiction: j, exempt: i >= exemptFrom})
+		}
+	}
+	return apps
+}
+
+// rateInEffect reports whether a rate applies on a date; open bounds do not limit it
+func rateInEffect(r domain.TaxRate, date time.Time) bool {
+	if r.ValidFrom != nil && date.Before(*r.ValidFrom) {
+		return false
+	}
+	return r.ValidTo == nil || !date.After(*r.ValidTo)
+}
+
+// computeLine applies the rates to a line. Tax-inclusive amounts are split into net and tax so
+// that they add back up to the gross amount, with any rounding difference on the last tax.
+func computeLine(l TaxLineRequest, apps []applicableRate, pricesIncludeTax bool) TaxDeterminationLine {
+	line := TaxDeterminationLine{Description: l.Description, TaxCategory: l.TaxCategory, NetAmount: l.Amount}
+	if pricesIncludeTax {
+		_, perUnit := computeTaxes(decimal.NewFromInt(1), apps, false)
+		line.NetAmount = l.Amount.DivRound(decimal.NewFromInt(1).Add(perUnit), taxAmountPlaces)
+	}
+	line.Taxes, line.TaxAmount = computeTaxes(line.NetAmount, apps, true)
+
+	if pricesIncludeTax {
+		if diff := l.Amount.Sub(line.NetAmount).Sub(line.TaxAmount); !diff.IsZero() {
+			last := -1
+			for i, c := range line.Taxes {
+				if !c.TaxAmount.IsZero() {
+					last = i
+				}
+			}
+			if last >= 0 {
+				line.Taxes[last].TaxAmount = line.Taxes[last].TaxAmount.Add(diff)
+				line.TaxAmount = line.TaxAmount.Add(diff)
+			} else {
+				line.NetAmount = line.NetAmount.Add(diff)
+			}
+		}
+	}
+	line.GrossAmount = line.NetAmount.Add(line.TaxAmount)
+	return line
+}
+
+func computeTaxes(net decimal.Decimal, apps []applicableRate, round bool) ([]TaxComponent, decimal.Decimal) {
+	components := make([]TaxComponent, 0, len(apps))
+	total := decimal.Zero
+	for _, a := range apps {
+		taxable := net
+		if a.rate.IsCompound {
+			taxable = net.Add(total)
+		}
+		tax := decimal.Zero
+		if !a.exempt {
+			tax = taxable.Mul(a.rate.Rate)
+			if round {
+				tax = tax.Round(taxAmountPlaces)
+			}
+		}
+		total = total.Add(tax)
+		components = append(components, TaxComponent{
+			TaxRateID:            a.rate.ID,
+			TaxCode:              a.rate.Code,
+			JurisdictionID:       a.jurisdiction.ID,
+			JurisdictionCode:     a.jurisdiction.Code,
+			Rate:                 a.rate.Rate,
+			IsCompound:           a.rate.IsCompound,
+			IsExempt:             a.exempt,
+			TaxableAmount:        taxable,
+			TaxAmount:            tax,
+			LiabilityAccountCode: a.rate.LiabilityAccountCode,
+		})
+	}
+	return components, total
+}
+
+// liabilityAccount returns the rate's own liability account, created if missing, or the legal
+// entity's TAX_LIABILITY account for rates without one
+func (s *TaxService) liabilityAccount(ctx context.Context, legalEntityID, code string) (*domain.ChartOfAccounts, error) {
+	if code == "" {
+		return s.gl.DetermineAccount(ctx, legalEntityID, domain.PostingKeyTAX_LIABILITY)
+	}
+	return s.gl.EnsureAccount(ctx, legalEntityID, code, "Tax Payable", domain.AccountTypeLIABILITY)
+}
+
+// PostTax posts the tax of a determination to the liability account of each rate, against the
+// receivable for sales and the payable for purchases, and records it in the tax ledger behind
+// tax returns. Exempt taxes are recorded without posting.
+func (s *TaxService) PostTax(ctx context.Context, req TaxPostingRequest) (*domain.UniversalJournalEntry, error) {
+	det := req.Determination
+	if req.LegalEntityID == "" || det == nil || !det.Direction.IsValid() {
+		return nil, fmt.Errorf("%w: legal entity and determination are required", domain.ErrInvalidTaxRequest)
+	}
+	rate := req.ExchangeRate
+	if rate.IsZero() {
+		rate = decimal.NewFromInt(1)
+	}
+	sign, sourceModule := decimal.NewFromInt(-1), "AR"
+	if det.Direction == domain.TaxDirectionPURCHASE {
+		sign, sourceModule = decimal.NewFromInt(1), "AP"
+	}
+
+	var entry *domain.UniversalJournalEntry
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var lines []domain.UniversalJournalLine
+		var txs []domain.TaxTransaction
+		totalTransactional, totalFunctional := decimal.Zero, decimal.Zero
+		for _, c := range det.Taxes {
+			taxFunctional := convertAmount(c.TaxAmount, rate)
+			txs = append(txs, domain.TaxTransaction{
+				ID:               utils.NewID("ttx"),
+				LegalEntityID:    req.LegalEntityID,
+				Direction:        det.Direction,
+				SourceDocumentID: req.SourceDocumentID,
+				TaxRateID:        c.TaxRateID,
+				JurisdictionID:   c.JurisdictionID,
+				FinancialPeriod:  req.PostingDate.Format("2006-01"),
+				PostingDate:      req.PostingDate,
+				Currency:         req.Currency,
+				TaxableAmount:    convertAmount(c.TaxableAmount, rate),
+				TaxAmount:        taxFunctional,
+				IsExempt:         c.IsExempt,
+				CreatedAt:        time.Now(),
+			})
+			if c.TaxAmount.IsZero() {
+				continue
+			}
+
+			acc, err := s.liabilityAccount(txCtx, req.LegalEntityID, c.LiabilityAccountCode)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, domain.UniversalJournalLine{
+				AccountID:             acc.ID,
+				AmountTransactional:   c.TaxAmount.Mul(sign),
+				AmountFunctional:      taxFunctional.Mul(sign),
+				CurrencyTransactional: req.Currency,
+				ExchangeRate:          rate,
+				TrackingDimensions:    map[string]interface{}{taxJurisdictionDimension: c.JurisdictionCode, taxCodeDimension: c.TaxCode},
+			})
+			totalTransactional = totalTransactional.Add(c.TaxAmount)
+			totalFunctional = totalFunctional.Add(taxFunctional)
+		}
+
+		if len(lines) > 0 {
+			offsetKey := domain.PostingKeyAR_CONTROL
+			if det.Direction == domain.TaxDirectionPURCHASE {
+				offsetKey = domain.PostingKeyAP_CONTROL
+			}
+			offset, err := s.gl.DetermineAccount(txCtx, req.LegalEntityID, offsetKey)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, domain.UniversalJournalLine{
+				AccountID:             offset.ID,
+				AmountTransactional:   totalTransactional.Mul(sign).Neg(),
+				AmountFunctional:      totalFunctional.Mul(sign).Neg(),
+				CurrencyTransactional: req.Currency,
+				ExchangeRate:          rate,
+			})
+			if entry, err = s.gl.CreateJournalEntry(txCtx, req.LegalEntityID, sourceModule, req.SourceDocumentID, req.PostingDate, lines); err != nil {
+				return err
+			}
+			for i := range txs {
+				txs[i].JournalEntryID = &entry.ID
+			}
+		}
+		return s.transactions.CreateMany(txCtx, txs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GenerateTaxReturn totals the tax ledger of a legal entity per rate for the periods from
+// fromPeriod to toPeriod ("YYYY-MM"); an empty toPeriod covers fromPeriod only.
+func (s *TaxService) GenerateTaxReturn(ctx context.Context, legalEntityID, fromPeriod, toPeriod string) (*TaxReturn, error) {
+	if toPeriod == "" {
+		toPeriod = fromPeriod
+	}
+	from, errFrom := time.Parse("2006-01", fromPeriod)
+	to, errTo := time.Parse("2006-01", toPeriod)
+	if legalEntityID == "" || errFrom != nil || errTo != nil || to.Before(from) {
+		return nil, fmt.Errorf("%w: legal entity and a YYYY-MM period range are required", domain.ErrInvalidTaxRequest)
+	}
+
+	txs, err := s.transactions.ListByLegalEntity(ctx, legalEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &TaxReturn{LegalEntityID: legalEntityID, FromPeriod: fromPeriod, ToPeriod: toPeriod, Lines: []TaxReturnLine{}}
+	byRate := make(map[string]int)
+	for _, tx := range txs {
+		if tx.FinancialPeriod < fromPeriod || tx.FinancialPeriod > toPeriod {
+			continue
+		}
+		i, ok := byRate[tx.TaxRateID]
+		if !ok {
+			line, err := s.returnLine(ctx, tx)
+			if err != nil {
+				return nil, err
+			}
+			byRate[tx.TaxRateID] = len(ret.Lines)
+			ret.Lines = append(ret.Lines, line)
+			i = len(ret.Lines) - 1
+		}
+		line := &ret.Lines[i]
+		switch {
+		case tx.Direction == domain.TaxDirectionPURCHASE:
+			line.TaxablePurchases = line.TaxablePurchases.Add(tx.TaxableAmount)
+			line.InputTax = line.InputTax.Add(tx.TaxAmount)
+		case tx.IsExempt:
+			line.ExemptSales = line.ExemptSales.Add(tx.TaxableAmount)
+		default:
+			line.TaxableSales = line.TaxableSales.Add(tx.TaxableAmount)
+			line.OutputTax = line.OutputTax.Add(tx.TaxAmount)
+		}
+	}
+
+	for i := range ret.Lines {
+		line := &ret.Lines[i]
+		line.NetTax = line.OutputTax.Sub(line.InputTax)
+		ret.TotalOutputTax = ret.TotalOutputTax.Add(line.OutputTax)
+		ret.TotalInputTax = ret.TotalInputTax.Add(line.InputTax)
+	}
+	ret.NetTaxPayable = ret.TotalOutputTax.Sub(ret.TotalInputTax)
+	sort.Slice(ret.Lines, func(i, j int) bool {
+		if ret.Lines[i].JurisdictionCode != ret.Lines[j].JurisdictionCode {
+			return ret.Lines[i].JurisdictionCode < ret.Lines[j].JurisdictionCode
+		}
+		return ret.Lines[i].TaxCode < ret.Lines[j].TaxCode
+	})
+	return ret, nil
+}
+
+func (s *TaxService) returnLine(ctx context.Context, tx domain.TaxTransaction) (TaxReturnLine, error) {
+	line := TaxReturnLine{TaxCode: tx.TaxRateID}
+	if r, err := s.repo.GetByID(ctx, tx.TaxRateID); err == nil {
+		line.TaxCode = r.Code
+		line.Rate = r.Rate
+	}
+	j, err := s.jurisdictions.GetByID(ctx, tx.JurisdictionID)
+	if err != nil {
+		return line, err
+	}
+	line.JurisdictionCode = j.Code
+	return line, nil
+}
@@ -0,0 +1,335 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+// seedTaxJurisdictions creates Canada with a compound Quebec tax and a zero-rated Ontario food category,
+// and the origin-sourced state of Texas with two cities.
+func seedTaxJurisdictions(t *testing.T, svc *service.TaxService) {
+	t.Helper()
+	ctx := context.Background()
+	jurisdictions := []struct {
+		code, parent string
+		sourcing     domain.TaxSourcing
+	}{
+		{"CA", "", ""},
+		{"CA-ON", "CA", ""},
+		{"CA-QC", "CA", ""},
+		{"US", "", ""},
+		{"US-TX", "US", domain.TaxSourcingORIGIN},
+		{"US-TX-AUS", "US-TX", ""},
+		{"US-TX-DAL", "US-TX", ""},
+	}
+	for _, j := range jurisdictions {
+		if _, err := svc.CreateJurisdiction(ctx, j.code, j.code, j.parent, j.sourcing); err != nil {
+			t.Fatalf("failed to create jurisdiction %s: %v", j.code, err)
+		}
+	}
+
+	rates := []service.TaxRateRequest{
+		{Code: "GST", Name: "GST", Rate: decimal.RequireFromString("0.05"), JurisdictionCode: "CA"},
+		{Code: "QST", Name: "QST", Rate: decimal.RequireFromString("0.09975"), JurisdictionCode: "CA-QC", IsCompound: true, Sequence: 2},
+		{Code: "ON-PST", Name: "Ontario PST", Rate: decimal.RequireFromString("0.08"), JurisdictionCode: "CA-ON"},
+		{Code: "ON-FOOD", Name: "Ontario Food", Rate: decimal.Zero, JurisdictionCode: "CA-ON", TaxCategory: "FOOD"},
+		{Code: "TX", Name: "Texas", Rate: decimal.RequireFromString("0.0625"), JurisdictionCode: "US-TX"},
+		{Code: "AUS", Name: "Austin", Rate: decimal.RequireFromString("0.02"), JurisdictionCode: "US-TX-AUS", Sequence: 1},
+		{Code: "DAL", Name: "Dallas", Rate: decimal.RequireFromString("0.01"), JurisdictionCode: "US-TX-DAL", Sequence: 1},
+	}
+	for _, r := range rates {
+		if _, err := svc.CreateJurisdictionRate(ctx, r); err != nil {
+			t.Fatalf("failed to create rate %s: %v", r.Code, err)
+		}
+	}
+}
+
+func determineTax(t *testing.T, svc *service.TaxService, req service.TaxRequest) *service.TaxDetermination {
+	t.Helper()
+	if req.Direction == "" {
+		req.Direction = domain.TaxDirectionSALES
+	}
+	if req.Date.IsZero() {
+		req.Date = day(2026, 5, 15)
+	}
+	det, err := svc.DetermineTax(context.Background(), req)
+	if err != nil {
+		t.Fatalf("failed to determine tax: %v", err)
+	}
+	return det
+}
+
+func taxLine(amount string) []service.TaxLineRequest {
+	return []service.TaxLineRequest{{Amount: decimal.RequireFromString(amount)}}
+}
+
+func TestTaxService_DestinationCompoundTax(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	seedTaxJurisdictions(t, svc)
+
+	det := determineTax(t, svc, service.TaxRequest{ShipFrom: "CA-ON", ShipTo: "CA-QC", Lines: taxLine("100")})
+
+	// GST 5.00 and QST on 105.00: 10.47
+	if det.TaxingJurisdiction != "CA-QC" || len(det.Taxes) != 2 {
+		t.Fatalf("expected GST and QST of CA-QC, got %+v", det)
+	}
+	// The per-rate total keeps the compound base, not the line's net amount
+	if !det.Taxes[0].TaxableAmount.Equal(decimal.NewFromInt(100)) || !det.Taxes[1].TaxableAmount.Equal(decimal.NewFromInt(105)) ||
+		!det.Lines[0].Taxes[1].TaxableAmount.Equal(decimal.NewFromInt(105)) {
+		t.Errorf("expected compound QST levied on 105, got %+v", det.Taxes)
+	}
+	if !det.TaxAmount.Equal(decimal.RequireFromString("15.47")) || !det.GrossAmount.Equal(decimal.RequireFromString("115.47")) {
+		t.Errorf("expected tax 15.47 and gross 115.47, got %s and %s", det.TaxAmount, det.GrossAmount)
+	}
+}
+
+func TestTaxService_ProductCategoryRates(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	seedTaxJurisdictions(t, svc)
+
+	det := determineTax(t, svc, service.TaxRequest{ShipFrom: "CA-ON", ShipTo: "CA-ON", Lines: []service.TaxLineRequest{
+		{TaxCategory: "FOOD", Amount: decimal.NewFromInt(100)},
+		{Amount: decimal.NewFromInt(100)},
+	}})
+
+	// Food uses the federal standard rate and the zero-rated provincial category
+	if !det.Lines[0].TaxAmount.Equal(decimal.NewFromInt(5)) || det.Lines[0].Taxes[1].TaxCode != "ON-FOOD" {
+		t.Errorf("expected food taxed at GST only, got %+v", det.Lines[0])
+	}
+	if !det.Lines[1].TaxAmount.Equal(decimal.NewFromInt(13)) {
+		t.Errorf("expected standard line taxed at 13, got %s", det.Lines[1].TaxAmount)
+	}
+	if len(det.Taxes) != 3 || !det.TaxAmount.Equal(decimal.NewFromInt(18)) {
+		t.Errorf("expected GST, ON-FOOD and ON-PST totalling 18, got %+v", det.Taxes)
+	}
+}
+
+func TestTaxService_OriginAndCrossBorderSourcing(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	seedTaxJurisdictions(t, svc)
+
+	// Texas is origin-sourced: a shipment from Austin to Dallas bears Austin's local tax
+	det := determineTax(t, svc, service.TaxRequest{ShipFrom: "US-TX-AUS", ShipTo: "US-TX-DAL", Lines: taxLine("100")})
+	if det.TaxingJurisdiction != "US-TX-AUS" || !det.TaxAmount.Equal(decimal.RequireFromString("8.25")) {
+		t.Errorf("expected 8.25 Austin tax, got %s at %s", det.TaxAmount, det.TaxingJurisdiction)
+	}
+
+	// Exports are zero-rated
+	det = determineTax(t, svc, service.TaxRequest{ShipFrom: "CA-ON", ShipTo: "US-TX-DAL", Lines: taxLine("100")})
+	if det.TaxingJurisdiction != "" || len(det.Taxes) != 0 || !det.GrossAmount.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("expected zero-rated export, got %+v", det)
+	}
+
+	_, err := svc.DetermineTax(context.Background(), service.TaxRequest{Direction: domain.TaxDirectionSALES, ShipTo: "XX", Lines: taxLine("100")})
+	if !errors.Is(err, domain.ErrTaxJurisdictionNotFound) {
+		t.Errorf("expected ErrTaxJurisdictionNotFound, got %v", err)
+	}
+}
+
+func TestTaxService_CustomerExemption(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	seedTaxJurisdictions(t, svc)
+	ctx := context.Background()
+	validTo := day(2026, 12, 31)
+	if _, err := svc.CreateExemption(ctx, "cust_1", "CA-QC", "QC-123", day(2026, 1, 1), &validTo); err != nil {
+		t.Fatalf("failed to create exemption: %v", err)
+	}
+
+	det := determineTax(t, svc, service.TaxRequest{CustomerID: "cust_1", ShipTo: "CA-QC", Lines: taxLine("100")})
+	if !det.TaxAmount.Equal(decimal.NewFromInt(5)) || !det.Taxes[1].IsExempt || !det.Taxes[1].TaxAmount.IsZero() {
+		t.Errorf("expected QST exempt and GST charged, got %+v", det.Taxes)
+	}
+
+	// The certificate has expired
+	det = determineTax(t, svc, service.TaxRequest{CustomerID: "cust_1", ShipTo: "CA-QC", Date: day(2027, 1, 5), Lines: taxLine("100")})
+	if !det.TaxAmount.Equal(decimal.RequireFromString("15.47")) {
+		t.Errorf("expected expired exemption to be ignored, got tax %s", det.TaxAmount)
+	}
+
+	// Exemptions do not apply to purchases
+	det = determineTax(t, svc, service.TaxRequest{Direction: domain.TaxDirectionPURCHASE, CustomerID: "cust_1", ShipTo: "CA-QC", Lines: taxLine("100")})
+	if !det.TaxAmount.Equal(decimal.RequireFromString("15.47")) {
+		t.Errorf("expected purchase to be taxed in full, got tax %s", det.TaxAmount)
+	}
+}
+
+func TestTaxService_InclusivePrices(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	seedTaxJurisdictions(t, svc)
+
+	det := determineTax(t, svc, service.TaxRequest{ShipTo: "CA-QC", PricesIncludeTax: true, Lines: taxLine("115.47")})
+	if !det.NetAmount.Equal(decimal.NewFromInt(100)) || !det.TaxAmount.Equal(decimal.RequireFromString("15.47")) {
+		t.Errorf("expected net 100 and tax 15.47, got %s and %s", det.NetAmount, det.TaxAmount)
+	}
+
+	for _, gross := range []string{"9.99", "10.00", "0.01", "1234.56"} {
+		det = determineTax(t, svc, service.TaxRequest{ShipTo: "CA-QC", PricesIncludeTax: true, Lines: taxLine(gross)})
+		if !det.GrossAmount.Equal(decimal.RequireFromString(gross)) || !det.NetAmount.Add(det.TaxAmount).Equal(det.GrossAmount) {
+			t.Errorf("expected %s to split into net and tax exactly, got %+v", gross, det)
+		}
+	}
+}
+
+func TestTaxService_PostingAndTaxReturn(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, bills, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	ar := service.NewAccountsReceivableService(invoices, memory.NewMemoryCustomerCreditRepo(), memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), svc, outbox, tm)
+	ap := service.NewAccountsPayableService(bills, memory.NewMemoryApVendorBillLineRepo(), memory.NewMemoryPurchaseOrderLineRepo(), memory.NewMemoryGoodsReceiptLineRepo(), memory.NewMemoryMatchToleranceRepo(), testConverter(), svc, outbox, tm)
+	seedTaxJurisdictions(t, svc)
+	ctx := context.Background()
+	period := time.Now().Format("2006-01")
+
+	inv, det, err := ar.CreateInvoiceWithTax(ctx, service.InvoiceRequest{
+		LegalEntityID: "le_1", CustomerID: "cust_1", ShipFrom: "CA-ON", ShipTo: "CA-QC", Lines: taxLine("100"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create invoice: %v", err)
+	}
+	if !inv.TotalAmount.Equal(decimal.RequireFromString("115.47")) || !inv.TaxAmount.Equal(det.TaxAmount) {
+		t.Errorf("expected invoice booked at gross 115.47, got %+v", inv)
+	}
+
+	if _, _, err := ap.CreateVendorBillWithTax(ctx, service.VendorBillRequest{
+		LegalEntityID: "le_1", VendorID: "vend_1", BillNumber: "B-1", ShipTo: "CA-QC", Lines: taxLine("200"),
+	}); err != nil {
+		t.Fatalf("failed to create bill: %v", err)
+	}
+
+	// An exempt sale is reported without tax
+	validTo := time.Now().AddDate(1, 0, 0)
+	_, _ = svc.CreateExemption(ctx, "cust_2", "CA-QC", "QC-9", time.Now().AddDate(-1, 0, 0), &validTo)
+	if _, _, err := ar.CreateInvoiceWithTax(ctx, service.InvoiceRequest{
+		LegalEntityID: "le_1", CustomerID: "cust_2", ShipTo: "CA-QC", Lines: taxLine("50"),
+	}); err != nil {
+		t.Fatalf("failed to create exempt invoice: %v", err)
+	}
+
+	posted, _ := entries.List(ctx)
+	if len(posted) != 3 {
+		t.Fatalf("expected 3 tax postings, got %d", len(posted))
+	}
+	for _, e := range posted {
+		_, lines, _ := entries.GetByID(ctx, e.ID)
+		sum := decimal.Zero
+		for _, l := range lines {
+			sum = sum.Add(l.AmountFunctional)
+		}
+		if !sum.IsZero() {
+			t.Errorf("expected balanced tax posting, got %+v", lines)
+		}
+	}
+
+	ret, err := svc.GenerateTaxReturn(ctx, "le_1", period, "")
+	if err != nil {
+		t.Fatalf("failed to generate return: %v", err)
+	}
+	// Output: GST 5.00 + 2.50, QST 10.47. Input: GST 10.00, QST 20.95.
+	if !ret.TotalOutputTax.Equal(decimal.RequireFromString("17.97")) || !ret.TotalInputTax.Equal(decimal.RequireFromString("30.95")) {
+		t.Errorf("expected output 17.97 and input 30.95, got %s and %s", ret.TotalOutputTax, ret.TotalInputTax)
+	}
+	if !ret.NetTaxPayable.Equal(decimal.RequireFromString("-12.98")) || len(ret.Lines) != 2 {
+		t.Errorf("expected refund of 12.98 over 2 lines, got %+v", ret)
+	}
+	qst := ret.Lines[1]
+	// QST is compound, so its base includes the GST
+	if qst.TaxCode != "QST" || !qst.TaxableSales.Equal(decimal.NewFromInt(105)) || !qst.ExemptSales.Equal(decimal.RequireFromString("52.5")) {
+		t.Errorf("expected QST taxable sales 105 and exempt sales 52.5, got %+v", qst)
+	}
+
+	if _, err := svc.GenerateTaxReturn(ctx, "le_1", "2026-13", ""); !errors.Is(err, domain.ErrInvalidTaxRequest) {
+		t.Errorf("expected ErrInvalidTaxRequest for invalid period, got %v", err)
+	}
+}
+
+func TestTaxService_DocumentDate(t *testing.T) {
+	accounts := memory.NewMemoryChartOfAccountsRepo()
+	entries := memory.NewMemoryUniversalJournalEntryRepo()
+	invoices := memory.NewMemoryArInvoiceRepo()
+	bills := memory.NewMemoryApVendorBillRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(accounts, entries, invoices, bills, taxTransactions, outbox)
+	gl := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), testConverter(), outbox, tm)
+	svc := service.NewTaxService(memory.NewMemoryTaxRateRepo(), memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, gl, tm)
+	ar := service.NewAccountsReceivableService(invoices, memory.NewMemoryCustomerCreditRepo(), memory.NewMemorySalesOrderExposureRepo(), memory.NewMemoryCreditOverrideRepo(), testConverter(), svc, outbox, tm)
+	ap := service.NewAccountsPayableService(bills, memory.NewMemoryApVendorBillLineRepo(), memory.NewMemoryPurchaseOrderLineRepo(), memory.NewMemoryGoodsReceiptLineRepo(), memory.NewMemoryMatchToleranceRepo(), testConverter(), svc, outbox, tm)
+	seedTaxJurisdictions(t, svc)
+	ctx := context.Background()
+
+	// Ontario PST changes on 2026-07-01
+	oldTo := day(2026, 6, 30)
+	newFrom := day(2026, 7, 1)
+	_, _ = svc.CreateJurisdictionRate(ctx, service.TaxRateRequest{Code: "ON-PST-OLD", Name: "Ontario PST", Rate: decimal.RequireFromString("0.07"), JurisdictionCode: "CA-ON", ValidTo: &oldTo})
+	_, _ = svc.CreateJurisdictionRate(ctx, service.TaxRateRequest{Code: "ON-PST-NEW", Name: "Ontario PST", Rate: decimal.RequireFromString("0.09"), JurisdictionCode: "CA-ON", ValidFrom: &newFrom})
+	if _, err := svc.CreateJurisdictionRate(ctx, service.TaxRateRequest{Code: "BAD", Name: "Bad", Rate: decimal.Zero, JurisdictionCode: "CA-ON", ValidFrom: &newFrom, ValidTo: &oldTo}); !errors.Is(err, domain.ErrInvalidTaxRequest) {
+		t.Errorf("expected ErrInvalidTaxRequest for an empty validity, got %v", err)
+	}
+
+	// GST 5 + PST 8 + the rate in effect on the invoice date
+	inv, _, err := ar.CreateInvoiceWithTax(ctx, service.InvoiceRequest{
+		LegalEntityID: "le_1", CustomerID: "cust_1", ShipTo: "CA-ON", InvoiceDate: day(2026, 6, 20), Lines: taxLine("100"),
+	})
+	if err != nil || !inv.TaxAmount.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("expected 20 tax at the June rates, got %+v (%v)", inv, err)
+	}
+	bill, _, err := ap.CreateVendorBillWithTax(ctx, service.VendorBillRequest{
+		LegalEntityID: "le_1", VendorID: "vend_1", BillNumber: "B-1", ShipTo: "CA-ON", BillDate: day(2026, 7, 2), Lines: taxLine("100"),
+	})
+	if err != nil || !bill.TaxAmount.Equal(decimal.NewFromInt(22)) {
+		t.Fatalf("expected 22 tax at the July rates, got %+v (%v)", bill, err)
+	}
+
+	// The tax is posted on the document date, not the day it was entered
+	posted, _ := entries.List(ctx)
+	dates := map[string]bool{}
+	for _, e := range posted {
+		dates[e.PostingDate.Format("2006-01-02")] = true
+	}
+	if len(posted) != 2 || !dates["2026-06-20"] || !dates["2026-07-02"] {
+		t.Errorf("expected tax postings on 2026-06-20 and 2026-07-02, got %+v", posted)
+	}
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"context"
+	"erp-system/shared/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Variance types of a three-way match
+const (
+	MatchVariancePrice      = "PRICE"
+	MatchVarianceQuantity   = "QUANTITY"
+	MatchVarianceNotOnOrder = "NOT_ON_ORDER"
+	MatchVarianceAmount     = "AMOUNT"
+)
+
+// VendorBillLineRequest is a billed material of a vendor bill
+type VendorBillLineRequest struct {
+	MaterialID string          `json:"material_id"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	UnitPrice  decimal.Decimal `json:"unit_price"`
+}
+
+// PurchaseOrderLineRequest is an ordered material of an scm-service purchase order
+type PurchaseOrderLineRequest struct {
+	LineID          string          `json:"line_id"`
+	MaterialID      string          `json:"material_id"`
+	QuantityOrdered decimal.Decimal `json:"quantity_ordered"`
+	UnitPrice       decimal.Decimal `json:"unit_price"`
+}
+
+// GoodsReceiptLineRequest is a received material of an scm-service goods receipt
+type GoodsReceiptLineRequest struct {
+	MaterialID       string          `json:"material_id"`
+	QuantityReceived decimal.Decimal `json:"quantity_received"`
+}
+
+// VendorBillMatch is the outcome of matching a vendor bill against its purchase order and receipts
+type VendorBillMatch struct {
+	Bill      *domain.ApVendorBill   `json:"bill"`
+	Variances []domain.MatchVariance `json:"variances"`
+}
+
+// orderedMaterial is the ordered quantity of a material at its quantity-weighted unit price
+type orderedMaterial struct {
+	quantity decimal.Decimal
+	value    decimal.Decimal
+}
+
+func (o orderedMaterial) unitPrice() decimal.Decimal {
+	if o.quantity.IsZero() {
+		return decimal.Zero
+	}
+	return o.value.Div(o.quantity)
+}
+
+// RecordPurchaseOrder keeps the lines of an scm-service purchase order for matching and
+// re-matches the bills already booked against it.
+func (s *AccountsPayableService) RecordPurchaseOrder(ctx context.Context, poID, vendorID string, lines []PurchaseOrderLineRequest) error {
+	if poID == "" {
+		return fmt.Errorf("%w: purchase order ID is required", domain.ErrInvalidMatchRequest)
+	}
+	poLines := make([]domain.PurchaseOrderLine, 0, len(lines))
+	for _, l := range lines {
+		if l.MaterialID == "" || !l.QuantityOrdered.IsPositive() || l.UnitPrice.IsNegative() {
+			return fmt.Errorf("%w: order lines need a material, a positive quantity and a price", domain.ErrInvalidMatchRequest)
+		}
+		id := l.LineID
+		if id == "" {
+			id = utils.NewID("pol")
+		}
+		poLines = append(poLines, domain.PurchaseOrderLine{
+			ID:              id,
+			PurchaseOrderID: poID,
+			VendorID:        vendorID,
+			MaterialID:      l.MaterialID,
+			QuantityOrdered: l.QuantityOrdered,
+			UnitPrice:       l.UnitPrice,
+			CreatedAt:       time.Now(),
+		})
+	}
+
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.poLines.ReplaceForPurchaseOrder(txCtx, poID, poLines); err != nil {
+			return err
+		}
+		return s.rematchPurchaseOrder(txCtx, poID)
+	})
+}
+
+// RecordGoodsReceipt keeps the lines of an scm-service goods receipt for matching. Bills of the
+// order waiting on the receipt are re-matched and released from payment hold when they now match.
+func (s *AccountsPayableService) RecordGoodsReceipt(ctx context.Context, receiptID, poID string, receivedDate time.Time, lines []GoodsReceiptLineRequest) error {
+	if receiptID == "" || poID == "" {
+		return fmt.Errorf("%w: receipt and purchase order IDs are required", domain.ErrInvalidMatchRequest)
+	}
+	receiptLines := make([]domain.GoodsReceiptLine, 0, len(lines))
+	for _, l := range lines {
+		if l.MaterialID == "" || !l.QuantityReceived.IsPositive() {
+			return fmt.Errorf("%w: receipt lines need a material and a positive quantity", domain.ErrInvalidMatchRequest)
+		}
+		receiptLines = append(receiptLines, domain.GoodsReceiptLine{
+			ID:               utils.NewID("grl"),
+			ReceiptID:        receiptID,
+			PurchaseOrderID:  poID,
+			MaterialID:       l.MaterialID,
+			QuantityReceived: l.QuantityReceived,
+			ReceivedDate:     receivedDate,
+			CreatedAt:        time.Now(),
+		})
+	}
+
+	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.receiptLines.CreateMany(txCtx, receiptLines); err != nil {
+			return err
+		}
+		return s.rematchPurchaseOrder(txCtx, poID)
+	})
+}
+
+// SetVendorBillLines replaces the billed materials of an unpaid bill and matches it again
+func (s *AccountsPayableService) SetVendorBillLines(ctx context.Context, billID string, items []VendorBillLineRequest) (*VendorBillMatch, error) {
+	lines, err := newVendorBillLines(billID, items)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *VendorBillMatch
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		bill, err := s.getVendorBill(txCtx, billID)
+		if err != nil {
+			return err
+		}
+		if bill.Status == domain.PaymentStatusPAID {
+			return fmt.Errorf("%w: bill %s is already paid", domain.ErrInvalidMatchRequest, bill.BillNumber)
+		}
+		if err := s.billLines.ReplaceForBill(txCtx, billID, lines); err != nil {
+			return err
+		}
+		match, err = s.matchVendorBill(txCtx, bill)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func (s *AccountsPayableService) ListVendorBillLines(ctx context.Context, billID string) ([]domain.ApVendorBillLine, error) {
+	if _, err := s.getVendorBill(ctx, billID); err != nil {
+		return nil, err
+	}
+	return s.billLines.ListByBill(ctx, billID)
+}
+
+// MatchVendorBill matches a bill against the purchase order and goods receipts known so far
+func (s *AccountsPayableService) MatchVendorBill(ctx context.Context, billID string) (*VendorBillMatch, error) {
+	var match *VendorBillMatch
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		bill, err := s.getVendorBill(txCtx, billID)
+		if err != nil {
+			return err
+		}
+		match, err = s.matchVendorBill(txCtx, bill)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// MatchPurchaseOrder checks that the bill was raised against the purchase order, that the goods
+// receipt belongs to that order and then matches the bill. It reports whether the bill may be paid.
+func (s *AccountsPayableService) MatchPurchaseOrder(ctx context.Context, billID, poID, goodsReceiptID string) (bool, error) {
+	if billID == "" || poID == "" || goodsReceiptID == "" {
+		return false, fmt.Errorf("%w: bill ID, PO ID, and Goods Receipt ID are required for 3-way matching", domain.ErrInvalidMatchRequest)
+	}
+	bill, err := s.getVendorBill(ctx, billID)
+	if err != nil {
+		return false, err
+	}
+	if bill.PurchaseOrderID != poID {
+		return false, fmt.Errorf("%w: bill %s was not raised against purchase order %s", domain.ErrInvalidMatchRequest, bill.BillNumber, poID)
+	}
+	received, err := s.receiptLines.ListByPurchaseOrder(ctx, poID)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, l := range received {
+		if l.ReceiptID == goodsReceiptID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("%w: goods receipt %s is not a receipt of purchase order %s", domain.ErrInvalidMatchRequest, goodsReceiptID, poID)
+	}
+
+	match, err := s.MatchVendorBill(ctx, billID)
+	if err != nil {
+		return false, err
+	}
+	return !match.Bill.PaymentHold, nil
+}
+
+// ListMatchExceptions is the review queue of bills held for match variances, oldest first
+func (s *AccountsPayableService) ListMatchExceptions(ctx context.Context) ([]VendorBillMatch, error) {
+	bills, err := s.bills.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bills, func(i, j int) bool { return bills[i].CreatedAt.Before(bills[j].CreatedAt) })
+
+	queue := make([]VendorBillMatch, 0)
+	for i := range bills {
+		if bills[i].MatchStatus != domain.BillMatchStatusEXCEPTION {
+			continue
+		}
+		_, variances, err := s.evaluateMatch(ctx, &bills[i])
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, VendorBillMatch{Bill: &bills[i], Variances: variances})
+	}
+	return queue, nil
+}
+
+// OverrideMatch lets an AP clerk accept a bill with match variances and release its payment hold
+func (s *AccountsPayableService) OverrideMatch(ctx context.Context, billID, reason, overriddenBy string) (*domain.ApVendorBill, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: an override reason is required", domain.ErrInvalidMatchRequest)
+	}
+
+	var bill *domain.ApVendorBill
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		bill, err = s.getVendorBill(txCtx, billID)
+		if err != nil {
+			return err
+		}
+		if bill.MatchStatus != domain.BillMatchStatusEXCEPTION {
+			return fmt.Errorf("%w: bill %s is %s", domain.ErrBillNotInReview, bill.BillNumber, bill.MatchStatus)
+		}
+
+		now := time.Now()
+		bill.MatchStatus = domain.BillMatchStatusOVERRIDDEN
+		bill.PaymentHold = false
+		bill.OverrideReason = &reason
+		if overriddenBy != "" {
+			bill.OverriddenBy = &overriddenBy
+		}
+		bill.MatchedAt = &now
+		bill.UpdatedAt = now
+		if err := s.bills.Update(txCtx, bill); err != nil {
+			return err
+		}
+		return s.writeMatchEvent(txCtx, domain.TopicFmVendorBillReleased, bill, nil, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
+
+// SetMatchTolerance configures the price and quantity variances accepted for a vendor's bills.
+// An empty vendor sets the default for vendors without their own tolerance.
+func (s *AccountsPayableService) SetMatchTolerance(ctx context.Context, vendorID string, pricePercent, quantityPercent decimal.Decimal) (*domain.MatchTolerance, error) {
+	if pricePercent.IsNegative() || quantityPercent.IsNegative() {
+		return nil, fmt.Errorf("%w: tolerances cannot be negative", domain.ErrInvalidMatchRequest)
+	}
+
+	var tolerance *domain.MatchTolerance
+	err := s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.tolerances.GetByVendor(txCtx, vendorID)
+		if err == nil {
+			existing.PriceTolerancePercent = pricePercent
+			existing.QuantityTolerancePercent = quantityPercent
+			existing.UpdatedAt = time.Now()
+			tolerance = existing
+			return s.tolerances.Update(txCtx, existing)
+		}
+		tolerance = &domain.MatchTolerance{
+			ID:                       utils.NewID("mtol"),
+			VendorID:                 vendorID,
+			PriceTolerancePercent:    pricePercent,
+			QuantityTolerancePercent: quantityPercent,
+			CreatedAt:                time.Now(),
+			UpdatedAt:                time.Now(),
+		}
+		return s.tolerances.Create(txCtx, tolerance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tolerance, nil
+}
+
+func (s *AccountsPayableService) ListMatchTolerances(ctx context.Context) ([]domain.MatchTolerance, error) {
+	return s.tolerances.List(ctx)
+}
+
+func (s *AccountsPayableService) getVendorBill(ctx context.Context, billID string) (*domain.ApVendorBill, error) {
+	bill, err := s.bills.GetByID(ctx, billID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrVendorBillNotFound, billID)
+	}
+	return bill, nil
+}
+
+// rematchPurchaseOrder matches the unpaid bills of an order again after its lines or receipts changed
+func (s *AccountsPayableService) rematchPurchaseOrder(ctx context.Context, poID string) error {
+	bills, err := s.bills.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range bills {
+		b := &bills[i]
+		if b.PurchaseOrderID != poID || b.Status == domain.PaymentStatusPAID {
+			continue
+		}
+		if _, err := s.matchVendorBill(ctx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchVendorBill stores the match status of the bill and holds it from payment on variances.
+// An override by an AP clerk stands until the bill is paid.
+func (s *AccountsPayableService) matchVendorBill(ctx context.Context, bill *domain.ApVendorBill) (*VendorBillMatch, error) {
+	status, variances, err := s.evaluateMatch(ctx, bill)
+	if err != nil {
+		return nil, err
+	}
+	match := &VendorBillMatch{Bill: bill, Variances: variances}
+	if bill.MatchStatus == domain.BillMatchStatusOVERRIDDEN || bill.MatchStatus == status {
+		return match, nil
+	}
+
+	wasHeld := bill.PaymentHold
+	bill.MatchStatus = status
+	bill.PaymentHold = status == domain.BillMatchStatusEXCEPTION
+	bill.MatchedAt = nil
+	if status == domain.BillMatchStatusMATCHED {
+		now := time.Now()
+		bill.MatchedAt = &now
+	}
+	bill.UpdatedAt = time.Now()
+	if err := s.bills.Update(ctx, bill); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bill.PaymentHold && !wasHeld:
+		err = s.writeMatchEvent(ctx, domain.TopicFmVendorBillHeld, bill, variances, "")
+	case !bill.PaymentHold && wasHeld:
+		err = s.writeMatchEvent(ctx, domain.TopicFmVendorBillReleased, bill, nil, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// evaluateMatch compares the bill with the order lines and goods receipts of its purchase order.
+// Billed lines are matched per material on unit price and on quantity, counting what other bills
+// of the order already billed against what was received; a bill without lines is matched on its
+// net amount against the value received less the net amount other bills of the order already
+// billed. Until the order lines are known the bill stays pending.
+func (s *AccountsPayableService) evaluateMatch(ctx context.Context, bill *domain.ApVendorBill) (domain.BillMatchStatus, []domain.MatchVariance, error) {
+	if bill.PurchaseOrderID == "" {
+		return domain.BillMatchStatusNOT_REQUIRED, nil, nil
+	}
+	poLines, err := s.poLines.ListByPurchaseOrder(ctx, bill.PurchaseOrderID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(poLines) == 0 {
+		return domain.BillMatchStatusPENDING, nil, nil
+	}
+	receipts, err := s.receiptLines.ListByPurchaseOrder(ctx, bill.PurchaseOrderID)
+	if err != nil {
+		return "", nil, err
+	}
+	lines, err := s.billLines.ListByBill(ctx, bill.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	tolerance := s.toleranceFor(ctx, bill.VendorID)
+	priceFactor := tolerance.PriceTolerancePercent.Div(decimal.NewFromInt(100))
+	quantityFactor := decimal.NewFromInt(1).Add(tolerance.QuantityTolerancePercent.Div(decimal.NewFromInt(100)))
+
+	ordered := make(map[string]orderedMaterial)
+	for _, l := range poLines {
+		o := ordered[l.MaterialID]
+		o.quantity = o.quantity.Add(l.QuantityOrdered)
+		o.value = o.value.Add(l.QuantityOrdered.Mul(l.UnitPrice))
+		ordered[l.MaterialID] = o
+	}
+	received := make(map[string]decimal.Decimal)
+	for _, l := range receipts {
+		received[l.MaterialID] = received[l.MaterialID].Add(l.QuantityReceived)
+	}
+
+	billed, billedAmount, err := s.billedByOtherBills(ctx, bill)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var variances []domain.MatchVariance
+	if len(lines) == 0 {
+		expected := billedAmount.Neg()
+		for material, qty := range received {
+			if o, ok := ordered[material]; ok {
+				expected = expected.Add(qty.Mul(o.unitPrice()))
+			}
+		}
+		expected = expected.Round(2)
+		actual := bill.TotalAmount.Sub(bill.TaxAmount)
+		if actual.GreaterThan(expected.Add(expected.Mul(priceFactor))) {
+			variances = append(variances, domain.MatchVariance{Type: MatchVarianceAmount, Expected: expected, Actual: actual})
+		}
+	} else {
+		quantities := make(map[string]decimal.Decimal)
+		var materials []string
+		for _, l := range lines {
+			if _, seen := quantities[l.MaterialID]; !seen {
+				materials = append(materials, l.MaterialID)
+			}
+			quantities[l.MaterialID] = quantities[l.MaterialID].Add(l.Quantity)
+
+			o, ok := ordered[l.MaterialID]
+			if !ok {
+				continue
+			}
+			price := o.unitPrice()
+			if l.UnitPrice.Sub(price).Abs().GreaterThan(price.Mul(priceFactor)) {
+				variances = append(variances, domain.MatchVariance{MaterialID: l.MaterialID, Type: MatchVariancePrice, Expected: price, Actual: l.UnitPrice})
+			}
+		}
+		for _, material := range materials {
+			if _, ok := ordered[material]; !ok {
+				variances = append(variances, domain.MatchVariance{MaterialID: material, Type: MatchVarianceNotOnOrder, Expected: decimal.Zero, Actual: quantities[material]})
+				continue
+			}
+			total := billed[material].Add(quantities[material])
+			if total.GreaterThan(received[material].Mul(quantityFactor)) {
+				variances = append(variances, domain.MatchVariance{MaterialID: material, Type: MatchVarianceQuantity, Expected: received[material], Actual: total})
+			}
+		}
+	}
+
+	if len(variances) > 0 {
+		return domain.BillMatchStatusEXCEPTION, variances, nil
+	}
+	return domain.BillMatchStatusMATCHED, nil, nil
+}
+
+// billedByOtherBills sums per material what the order's other bills already billed, and their
+// net amount
+func (s *AccountsPayableService) billedByOtherBills(ctx context.Context, bill *domain.ApVendorBill) (map[string]decimal.Decimal, decimal.Decimal, error) {
+	bills, err := s.bills.List(ctx)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	billed := make(map[string]decimal.Decimal)
+	amount := decimal.Zero
+	for _, other := range bills {
+		if other.ID == bill.ID || other.PurchaseOrderID != bill.PurchaseOrderID {
+			continue
+		}
+		amount = amount.Add(other.TotalAmount.Sub(other.TaxAmount))
+		lines, err := s.billLines.ListByBill(ctx, other.ID)
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+		for _, l := range lines {
+			billed[l.MaterialID] = billed[l.MaterialID].Add(l.Quantity)
+		}
+	}
+	return billed, amount, nil
+}
+
+// toleranceFor returns the vendor's own tolerance, else the default; without either bills must match exactly
+func (s *AccountsPayableService) toleranceFor(ctx context.Context, vendorID string) domain.MatchTolerance {
+	if vendorID != "" {
+		if t, err := s.tolerances.GetByVendor(ctx, vendorID); err == nil {
+			return *t
+		}
+	}
+	if t, err := s.tolerances.GetByVendor(ctx, ""); err == nil {
+		return *t
+	}
+	return domain.MatchTolerance{}
+}
+
+func (s *AccountsPayableService) writeMatchEvent(ctx context.Context, topic string, bill *domain.ApVendorBill, variances []domain.MatchVariance, reason string) error {
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: bill.ID,
+		Payload: domain.VendorBillMatchEventPayload{
+			BillID:          bill.ID,
+			VendorID:        bill.VendorID,
+			PurchaseOrderID: bill.PurchaseOrderID,
+			MatchStatus:     bill.MatchStatus,
+			Variances:       variances,
+			Reason:          reason,
+			Timestamp:       time.Now(),
+		},
+		Status:    domain.OutboxStatusPENDING,
+		CreatedAt: time.Now(),
+	})
+}
+
+func newVendorBillLines(billID string, items []VendorBillLineRequest) ([]domain.ApVendorBillLine, error) {
+	lines := make([]domain.ApVendorBillLine, 0, len(items))
+	for _, item := range items {
+		if item.MaterialID == "" || !item.Quantity.IsPositive() || item.UnitPrice.IsNegative() {
+			return nil, fmt.Errorf("%w: bill lines need a material, a positive quantity and a price", domain.ErrInvalidMatchRequest)
+		}
+		lines = append(lines, domain.ApVendorBillLine{
+			ID:         utils.NewID("bline"),
+			BillID:     billID,
+			MaterialID: item.MaterialID,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			LineAmount: item.Quantity.Mul(item.UnitPrice).Round(2),
+			CreatedAt:  time.Now(),
+		})
+	}
+	return lines, nil
+}
@@ -0,0 +1,304 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/domain"
+	"github.com/erp-system/fm-service/internal/business/service"
+	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
+)
+
+// recordPurchaseOrder records purchase order po_1 of vendor_1 for 10 units of mat_1 at 20 and 4 units of mat_2 at 50
+func recordPurchaseOrder(t *testing.T, svc *service.AccountsPayableService) {
+	t.Helper()
+	err := svc.RecordPurchaseOrder(context.Background(), "po_1", "vendor_1", []service.PurchaseOrderLineRequest{
+		{LineID: "pol_1", MaterialID: "mat_1", QuantityOrdered: decimal.NewFromInt(10), UnitPrice: decimal.NewFromInt(20)},
+		{LineID: "pol_2", MaterialID: "mat_2", QuantityOrdered: decimal.NewFromInt(4), UnitPrice: decimal.NewFromInt(50)},
+	})
+	if err != nil {
+		t.Fatalf("failed to record purchase order: %v", err)
+	}
+}
+
+func receiveGoods(t *testing.T, svc *service.AccountsPayableService, receiptID, materialID string, qty int64) {
+	t.Helper()
+	err := svc.RecordGoodsReceipt(context.Background(), receiptID, "po_1", day(2026, 5, 10), []service.GoodsReceiptLineRequest{
+		{MaterialID: materialID, QuantityReceived: decimal.NewFromInt(qty)},
+	})
+	if err != nil {
+		t.Fatalf("failed to record goods receipt: %v", err)
+	}
+}
+
+func createPOBill(t *testing.T, svc *service.AccountsPayableService, number string, total int64, items ...service.VendorBillLineRequest) *domain.ApVendorBill {
+	t.Helper()
+	bill, err := svc.CreateVendorBillWithLines(context.Background(), "le_1", "vendor_1", number, "po_1", "", day(2026, 6, 10), decimal.NewFromInt(total), decimal.Zero, items)
+	if err != nil {
+		t.Fatalf("failed to create bill: %v", err)
+	}
+	return bill
+}
+
+func item(materialID string, qty, price string) service.VendorBillLineRequest {
+	return service.VendorBillLineRequest{MaterialID: materialID, Quantity: decimal.RequireFromString(qty), UnitPrice: decimal.RequireFromString(price)}
+}
+
+func TestThreeWayMatch_BillWithinReceiptsMatches(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, payments, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:    payments,
+		Invoices:    memory.NewMemoryArInvoiceRepo(),
+		Bills:       bills,
+		Allocations: memory.NewMemoryPaymentAllocationRepo(),
+		Outbox:      outbox,
+		TM:          tm,
+	})
+	recordPurchaseOrder(t, svc)
+	receiveGoods(t, svc, "gr_1", "mat_1", 10)
+
+	bill := createPOBill(t, svc, "B-1", 200, item("mat_1", "10", "20"))
+	if bill.MatchStatus != domain.BillMatchStatusMATCHED || bill.PaymentHold || bill.MatchedAt == nil {
+		t.Errorf("expected a matched bill, got %+v", bill)
+	}
+	if _, err := cash.RecordPayment(context.Background(), "", bill.ID, "", bill.TotalAmount, "ACH"); err != nil {
+		t.Errorf("expected the matched bill to be payable, got %v", err)
+	}
+}
+
+func TestThreeWayMatch_BillBeforeReceiptIsHeldUntilGoodsArrive(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, payments, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:    payments,
+		Invoices:    memory.NewMemoryArInvoiceRepo(),
+		Bills:       bills,
+		Allocations: memory.NewMemoryPaymentAllocationRepo(),
+		Outbox:      outbox,
+		TM:          tm,
+	})
+	recordPurchaseOrder(t, svc)
+	ctx := context.Background()
+
+	bill := createPOBill(t, svc, "B-1", 200, item("mat_1", "10", "20"))
+	if bill.MatchStatus != domain.BillMatchStatusEXCEPTION || !bill.PaymentHold {
+		t.Fatalf("expected a bill for unreceived goods to be held, got %+v", bill)
+	}
+	if _, err := cash.RecordPayment(ctx, "", bill.ID, "", bill.TotalAmount, "ACH"); !errors.Is(err, domain.ErrBillOnPaymentHold) {
+		t.Errorf("expected payment hold error, got %v", err)
+	}
+
+	// A partial receipt is not enough, the full receipt releases the bill
+	receiveGoods(t, svc, "gr_1", "mat_1", 6)
+	if got, _ := svc.GetVendorBill(ctx, bill.ID); !got.PaymentHold {
+		t.Errorf("expected the bill to stay held after a partial receipt")
+	}
+	receiveGoods(t, svc, "gr_2", "mat_1", 4)
+	got, _ := svc.GetVendorBill(ctx, bill.ID)
+	if got.MatchStatus != domain.BillMatchStatusMATCHED || got.PaymentHold {
+		t.Errorf("expected the receipt to release the bill, got %+v", got)
+	}
+	if countTopic(t, outbox, domain.TopicFmVendorBillHeld) != 1 || countTopic(t, outbox, domain.TopicFmVendorBillReleased) != 1 {
+		t.Errorf("expected one held and one released event")
+	}
+}
+
+func TestThreeWayMatch_PriceVarianceUsesVendorTolerance(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	recordPurchaseOrder(t, svc)
+	ctx := context.Background()
+	receiveGoods(t, svc, "gr_1", "mat_2", 4)
+
+	if _, err := svc.SetMatchTolerance(ctx, "", decimal.NewFromInt(10), decimal.Zero); err != nil {
+		t.Fatalf("failed to set default tolerance: %v", err)
+	}
+	if _, err := svc.SetMatchTolerance(ctx, "vendor_1", decimal.NewFromInt(2), decimal.Zero); err != nil {
+		t.Fatalf("failed to set vendor tolerance: %v", err)
+	}
+
+	// 52 is 4% above the ordered price: inside the default but outside the vendor's own tolerance
+	bill := createPOBill(t, svc, "B-1", 208, item("mat_2", "4", "52"))
+	match, err := svc.MatchVendorBill(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if match.Bill.MatchStatus != domain.BillMatchStatusEXCEPTION || len(match.Variances) != 1 || match.Variances[0].Type != service.MatchVariancePrice {
+		t.Fatalf("expected a price variance, got %+v", match)
+	}
+	if !match.Variances[0].Expected.Equal(decimal.NewFromInt(50)) || !match.Variances[0].Actual.Equal(decimal.NewFromInt(52)) {
+		t.Errorf("unexpected variance amounts: %+v", match.Variances[0])
+	}
+
+	// Correcting the billed price clears the exception
+	match, err = svc.SetVendorBillLines(ctx, bill.ID, []service.VendorBillLineRequest{item("mat_2", "4", "51")})
+	if err != nil || match.Bill.MatchStatus != domain.BillMatchStatusMATCHED || match.Bill.PaymentHold {
+		t.Errorf("expected corrected lines to match, got %+v (%v)", match, err)
+	}
+}
+
+func TestThreeWayMatch_QuantityCountsEarlierBills(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	recordPurchaseOrder(t, svc)
+	ctx := context.Background()
+	receiveGoods(t, svc, "gr_1", "mat_1", 10)
+	if _, err := svc.SetMatchTolerance(ctx, "", decimal.Zero, decimal.NewFromInt(10)); err != nil {
+		t.Fatalf("failed to set tolerance: %v", err)
+	}
+
+	first := createPOBill(t, svc, "B-1", 120, item("mat_1", "6", "20"))
+	if first.MatchStatus != domain.BillMatchStatusMATCHED {
+		t.Fatalf("expected the first bill to match, got %s", first.MatchStatus)
+	}
+	// 6 + 6 exceeds the 10 received plus 10%
+	second := createPOBill(t, svc, "B-2", 121, item("mat_1", "6", "20"), item("mat_9", "1", "1"))
+	if second.MatchStatus != domain.BillMatchStatusEXCEPTION {
+		t.Fatalf("expected the second bill to be held, got %s", second.MatchStatus)
+	}
+
+	queue, err := svc.ListMatchExceptions(ctx)
+	if err != nil || len(queue) != 1 || queue[0].Bill.ID != second.ID {
+		t.Fatalf("expected the second bill in the review queue, got %+v (%v)", queue, err)
+	}
+	types := map[string]bool{}
+	for _, v := range queue[0].Variances {
+		types[v.Type] = true
+	}
+	if !types[service.MatchVarianceQuantity] || !types[service.MatchVarianceNotOnOrder] || len(queue[0].Variances) != 2 {
+		t.Errorf("expected quantity and not-on-order variances, got %+v", queue[0].Variances)
+	}
+}
+
+func TestThreeWayMatch_BillWithoutLinesMatchesReceivedValue(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	recordPurchaseOrder(t, svc)
+	ctx := context.Background()
+	receiveGoods(t, svc, "gr_1", "mat_1", 10)
+	receiveGoods(t, svc, "gr_2", "mat_2", 2)
+
+	// Received value is 10 x 20 + 2 x 50 = 300; tax is not part of the match
+	bill, err := svc.CreateVendorBill(ctx, "le_1", "vendor_1", "B-1", "po_1", "", day(2026, 6, 10), decimal.NewFromInt(220), decimal.NewFromInt(20))
+	if err != nil || bill.MatchStatus != domain.BillMatchStatusMATCHED {
+		t.Fatalf("expected the net amount to match, got %+v (%v)", bill, err)
+	}
+
+	// Only the 100 not billed yet remains for the next bill of the order
+	if bill := createPOBill(t, svc, "B-2", 130); bill.MatchStatus != domain.BillMatchStatusEXCEPTION {
+		t.Errorf("expected an amount variance, got %s", bill.MatchStatus)
+	}
+	queue, err := svc.ListMatchExceptions(ctx)
+	if err != nil || len(queue) != 1 || len(queue[0].Variances) != 1 {
+		t.Fatalf("expected one held bill, got %+v (%v)", queue, err)
+	}
+	if v := queue[0].Variances[0]; v.Type != service.MatchVarianceAmount || !v.Expected.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("expected 100 left to bill, got %+v", v)
+	}
+}
+
+func TestThreeWayMatch_OverrideReleasesHold(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	payments := memory.NewMemoryPaymentRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, payments, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	cash := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:    payments,
+		Invoices:    memory.NewMemoryArInvoiceRepo(),
+		Bills:       bills,
+		Allocations: memory.NewMemoryPaymentAllocationRepo(),
+		Outbox:      outbox,
+		TM:          tm,
+	})
+	recordPurchaseOrder(t, svc)
+	ctx := context.Background()
+
+	bill := createPOBill(t, svc, "B-1", 200, item("mat_1", "10", "20"))
+	if _, err := svc.OverrideMatch(ctx, bill.ID, "  ", "clerk_1"); !errors.Is(err, domain.ErrInvalidMatchRequest) {
+		t.Errorf("expected a reason to be required, got %v", err)
+	}
+	got, err := svc.OverrideMatch(ctx, bill.ID, "Goods accepted at the dock", "clerk_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.MatchStatus != domain.BillMatchStatusOVERRIDDEN || got.PaymentHold || got.OverrideReason == nil || *got.OverriddenBy != "clerk_1" {
+		t.Errorf("unexpected overridden bill: %+v", got)
+	}
+	if _, err := svc.OverrideMatch(ctx, bill.ID, "again", "clerk_1"); !errors.Is(err, domain.ErrBillNotInReview) {
+		t.Errorf("expected a released bill not to be in review, got %v", err)
+	}
+
+	// The override stands when the bill is matched again
+	if match, err := svc.MatchVendorBill(ctx, bill.ID); err != nil || match.Bill.MatchStatus != domain.BillMatchStatusOVERRIDDEN {
+		t.Errorf("expected the override to stand, got %+v (%v)", match, err)
+	}
+	if _, err := cash.RecordPayment(ctx, "", bill.ID, "", bill.TotalAmount, "ACH"); err != nil {
+		t.Errorf("expected the overridden bill to be payable, got %v", err)
+	}
+}
+
+func TestThreeWayMatch_UnknownOrderAndRequestValidation(t *testing.T) {
+	bills := memory.NewMemoryApVendorBillRepo()
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	tm := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, outbox)
+	svc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), testConverter(), nil, outbox, tm)
+	recordPurchaseOrder(t, svc)
+	ctx := context.Background()
+
+	bill, err := svc.CreateVendorBill(ctx, "le_1", "vendor_1", "B-1", "po_unknown", "", time.Now(), decimal.NewFromInt(100), decimal.Zero)
+	if err != nil || bill.MatchStatus != domain.BillMatchStatusPENDING || bill.PaymentHold {
+		t.Errorf("expected a bill against an unknown order to await matching, got %+v (%v)", bill, err)
+	}
+	noPO, _ := svc.CreateVendorBill(ctx, "le_1", "vendor_1", "B-2", "", "", time.Now(), decimal.NewFromInt(100), decimal.Zero)
+	if noPO.MatchStatus != domain.BillMatchStatusNOT_REQUIRED {
+		t.Errorf("expected no match for a bill without order, got %s", noPO.MatchStatus)
+	}
+
+	if _, err := svc.SetVendorBillLines(ctx, bill.ID, []service.VendorBillLineRequest{item("mat_1", "0", "20")}); !errors.Is(err, domain.ErrInvalidMatchRequest) {
+		t.Errorf("expected invalid lines to be rejected, got %v", err)
+	}
+	if _, err := svc.MatchVendorBill(ctx, "bill_missing"); !errors.Is(err, domain.ErrVendorBillNotFound) {
+		t.Errorf("expected vendor bill not found, got %v", err)
+	}
+	if _, err := svc.SetMatchTolerance(ctx, "vendor_1", decimal.NewFromInt(-1), decimal.Zero); !errors.Is(err, domain.ErrInvalidMatchRequest) {
+		t.Errorf("expected negative tolerance to be rejected, got %v", err)
+	}
+}
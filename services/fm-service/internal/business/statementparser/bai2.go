@@ -0,0 +1,242 @@
+package statementparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BAI2 summary type codes carried on the 03 account record
+const (
+	bai2OpeningLedger = "010"
+	bai2ClosingLedger = "015"
+)
+
+type bai2Record struct {
+	code   string
+	fields []string
+	line   int
+}
+
+func parseBAI2(data []byte) (*Result, error) {
+	records, err := readBAI2Records(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Format: FormatBAI2}
+	var (
+		fileID   string
+		asOf     time.Time
+		currency string
+		stmt     *Statement
+	)
+	closeAccount := func() {
+		if stmt != nil {
+			res.Statements = append(res.Statements, *stmt)
+			stmt = nil
+		}
+	}
+
+	for _, rec := range records {
+		switch rec.code {
+		case "01":
+			if len(rec.fields) < 4 {
+				return nil, fmt.Errorf("line %d: incomplete file header", rec.line)
+			}
+			// sender, file ID and creation date identify the file
+			fileID = strings.Join([]string{field(rec.fields, 0), field(rec.fields, 4), field(rec.fields, 2)}, "-")
+		case "02":
+			d, err := time.Parse("060102", field(rec.fields, 3))
+			if err != nil {
+				res.addError(rec.line, "invalid as-of date %q", field(rec.fields, 3))
+				continue
+			}
+			asOf = d
+			currency = field(rec.fields, 5)
+		case "03":
+			closeAccount()
+			acct := field(rec.fields, 0)
+			if acct == "" {
+				res.addError(rec.line, "account identifier is missing the account number")
+				continue
+			}
+			stmt = &Statement{
+				Reference:     fmt.Sprintf("%s-%s-%s", fileID, acct, asOf.Format("20060102")),
+				AccountID:     acct,
+				Currency:      currency,
+				StatementDate: asOf,
+			}
+			if c := field(rec.fields, 1); c != "" {
+				stmt.Currency = c
+			}
+			if err := applyBAI2Summaries(stmt, rec.fields[2:]); err != nil {
+				res.addError(rec.line, "%v", err)
+			}
+		case "16":
+			if stmt == nil {
+				res.addError(rec.line, "transaction detail outside an account")
+				continue
+			}
+			line, err := parseBAI2Detail(rec.fields, asOf)
+			if err != nil {
+				res.addError(rec.line, "%v", err)
+				continue
+			}
+			stmt.Lines = append(stmt.Lines, line)
+		case "49", "98", "99":
+			closeAccount()
+		default:
+			res.addError(rec.line, "unknown record type %q", rec.code)
+		}
+	}
+	closeAccount()
+
+	if len(res.Statements) == 0 && len(res.Errors) == 0 {
+		return nil, fmt.Errorf("BAI2 file contains no accounts")
+	}
+	return res, nil
+}
+
+// readBAI2Records joins 88 continuation records onto their parent and strips
+// the trailing "/" delimiter from each record.
+func readBAI2Records(data []byte) ([]bai2Record, error) {
+	var records []bai2Record
+	for i, raw := range splitLines(data) {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		line = strings.TrimSuffix(line, "/")
+		code, rest, _ := strings.Cut(line, ",")
+		if code == "88" {
+			if len(records) == 0 {
+				return nil, fmt.Errorf("line %d: continuation record without a parent", i+1)
+			}
+			prev := &records[len(records)-1]
+			prev.fields = append(prev.fields, strings.Split(rest, ",")...)
+			continue
+		}
+		records = append(records, bai2Record{code: code, fields: strings.Split(rest, ","), line: i + 1})
+	}
+	if len(records) == 0 || records[0].code != "01" {
+		return nil, fmt.Errorf("BAI2 file must start with a 01 file header")
+	}
+	return records, nil
+}
+
+// applyBAI2Summaries reads the repeating type code, amount, item count, funds type groups.
+func applyBAI2Summaries(stmt *Statement, fields []string) error {
+	for i := 0; i+1 < len(fields); {
+		code, amount := fields[i], fields[i+1]
+		next, err := skipBAI2FundsType(fields, i+3)
+		if err != nil {
+			return err
+		}
+		if amount != "" {
+			amt, err := parseBAI2Amount(amount)
+			if err != nil {
+				return fmt.Errorf("summary %s: %v", code, err)
+			}
+			switch code {
+			case bai2OpeningLedger:
+				stmt.OpeningBalance = amt
+			case bai2ClosingLedger:
+				stmt.ClosingBalance = amt
+			}
+		}
+		i = next
+	}
+	return nil
+}
+
+// parseBAI2Detail reads a 16 record: type code, amount, funds type (with its
+// variable extra fields), bank reference, customer reference, free text.
+func parseBAI2Detail(fields []string, asOf time.Time) (Line, error) {
+	if len(fields) < 2 {
+		return Line{}, fmt.Errorf("incomplete transaction detail")
+	}
+	typeCode, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid type code %q", fields[0])
+	}
+	amt, err := parseBAI2Amount(fields[1])
+	if err != nil {
+		return Line{}, err
+	}
+	// 100-399 are credits, 400-699 debits
+	switch {
+	case typeCode >= 100 && typeCode < 400:
+	case typeCode >= 400 && typeCode < 700:
+		amt = amt.Neg()
+	default:
+		return Line{}, fmt.Errorf("type code %d is not a transaction detail code", typeCode)
+	}
+
+	i, err := skipBAI2FundsType(fields, 2)
+	if err != nil {
+		return Line{}, err
+	}
+	bankRef := field(fields, i)
+	custRef := field(fields, i+1)
+	text := ""
+	if i+2 < len(fields) {
+		// Free text may itself contain commas
+		text = strings.TrimSpace(strings.Join(fields[i+2:], ","))
+	}
+
+	ref := custRef
+	if ref == "" {
+		ref = bankRef
+	}
+	return Line{
+		BookingDate: asOf,
+		Amount:      amt,
+		Description: text,
+		Reference:   ref,
+	}, nil
+}
+
+// skipBAI2FundsType returns the index after the funds type at position i and its extra fields.
+func skipBAI2FundsType(fields []string, i int) (int, error) {
+	switch strings.ToUpper(field(fields, i)) {
+	case "", "0", "1", "2", "Z":
+		return i + 1, nil
+	case "S":
+		return i + 4, nil
+	case "V":
+		return i + 3, nil
+	case "D":
+		n, err := strconv.Atoi(field(fields, i+1))
+		if err != nil {
+			return 0, fmt.Errorf("invalid distribution count %q", field(fields, i+1))
+		}
+		return i + 2 + 2*n, nil
+	}
+	return 0, fmt.Errorf("invalid funds type %q", field(fields, i))
+}
+
+// parseBAI2Amount reads an amount with two implied decimal places.
+func parseBAI2Amount(s string) (decimal.Decimal, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	v, err := strconv.ParseInt(strings.TrimLeft(s, "+-"), 10, 64)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", s)
+	}
+	amt := decimal.New(v, -2)
+	if neg {
+		amt = amt.Neg()
+	}
+	return amt, nil
+}
+
+func field(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
@@ -0,0 +1,191 @@
+package statementparser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// camt.053 elements are matched by local name so any schema version
+// (camt.053.001.02 through .08) is accepted.
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	CreDtTm  string        `xml:"CreDtTm"`
+	IBAN     string        `xml:"Acct>Id>IBAN"`
+	Other    string        `xml:"Acct>Id>Othr>Id"`
+	Currency string        `xml:"Acct>Ccy"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code   string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount camtAmount `xml:"Amt"`
+	Sign   string     `xml:"CdtDbtInd"`
+	Date   camtDate   `xml:"Dt"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtEntry struct {
+	Amount      camtAmount  `xml:"Amt"`
+	Sign        string      `xml:"CdtDbtInd"`
+	BookingDate camtDate    `xml:"BookgDt"`
+	ValueDate   camtDate    `xml:"ValDt"`
+	ServicerRef string      `xml:"AcctSvcrRef"`
+	AddtlInfo   string      `xml:"AddtlNtryInf"`
+	Details     []camtTxDtl `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTxDtl struct {
+	EndToEndID   string   `xml:"Refs>EndToEndId"`
+	Unstructured []string `xml:"RmtInf>Ustrd"`
+	CreditorRef  string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+func parseCAMT053(data []byte) (*Result, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 document: %w", err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("camt.053 document contains no statements")
+	}
+
+	res := &Result{Format: FormatCAMT053}
+	entryNo := 0
+	for _, st := range doc.Statements {
+		stmt := Statement{
+			Reference: strings.TrimSpace(st.ID),
+			AccountID: strings.TrimSpace(st.IBAN),
+			Currency:  st.Currency,
+		}
+		if stmt.AccountID == "" {
+			stmt.AccountID = strings.TrimSpace(st.Other)
+		}
+		if stmt.Reference == "" {
+			res.addError(entryNo+1, "statement is missing Id")
+			continue
+		}
+		if t, err := parseCAMTDateTime(st.CreDtTm); err == nil {
+			stmt.StatementDate = t
+		}
+
+		for _, bal := range st.Balances {
+			amt, err := signedCAMTAmount(bal.Amount.Value, bal.Sign)
+			if err != nil {
+				res.addError(entryNo+1, "balance %s: %v", bal.Code, err)
+				continue
+			}
+			switch bal.Code {
+			case "OPBD", "PRCD":
+				stmt.OpeningBalance = amt
+			case "CLBD":
+				stmt.ClosingBalance = amt
+				if d, err := bal.Date.time(); err == nil {
+					stmt.StatementDate = d
+				}
+			}
+			if stmt.Currency == "" {
+				stmt.Currency = bal.Amount.Currency
+			}
+		}
+
+		for _, ntry := range st.Entries {
+			entryNo++
+			line, err := ntry.toLine()
+			if err != nil {
+				res.addError(entryNo, "%v", err)
+				continue
+			}
+			stmt.Lines = append(stmt.Lines, line)
+		}
+		res.Statements = append(res.Statements, stmt)
+	}
+	return res, nil
+}
+
+func (e camtEntry) toLine() (Line, error) {
+	amt, err := signedCAMTAmount(e.Amount.Value, e.Sign)
+	if err != nil {
+		return Line{}, err
+	}
+	date, err := e.BookingDate.time()
+	if err != nil {
+		date, err = e.ValueDate.time()
+		if err != nil {
+			return Line{}, fmt.Errorf("missing booking date")
+		}
+	}
+
+	var desc []string
+	ref := e.ServicerRef
+	for _, d := range e.Details {
+		desc = append(desc, d.Unstructured...)
+		if d.CreditorRef != "" {
+			desc = append(desc, d.CreditorRef)
+		}
+		if d.EndToEndID != "" && d.EndToEndID != "NOTPROVIDED" {
+			ref = d.EndToEndID
+		}
+	}
+	if len(desc) == 0 && e.AddtlInfo != "" {
+		desc = append(desc, e.AddtlInfo)
+	}
+
+	return Line{
+		BookingDate: date,
+		Amount:      amt,
+		Description: strings.TrimSpace(strings.Join(desc, " ")),
+		Reference:   strings.TrimSpace(ref),
+	}, nil
+}
+
+func (d camtDate) time() (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+	}
+	if d.DateTime != "" {
+		return parseCAMTDateTime(d.DateTime)
+	}
+	return time.Time{}, fmt.Errorf("missing date")
+}
+
+func parseCAMTDateTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date time %q", s)
+}
+
+func signedCAMTAmount(value, sign string) (decimal.Decimal, error) {
+	amt, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", value)
+	}
+	switch strings.TrimSpace(sign) {
+	case "CRDT":
+		return amt, nil
+	case "DBIT":
+		return amt.Neg(), nil
+	}
+	return decimal.Zero, fmt.Errorf("invalid credit/debit indicator %q", sign)
+}
@@ -0,0 +1,234 @@
+package statementparser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	mt940TagRe = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	// :61: value date, optional entry date, (reversal) debit/credit mark, optional funds code,
+	// amount, transaction type, customer reference, optional //bank reference
+	mt940LineRe    = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([A-Z][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+	mt940BalanceRe = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+)
+
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+func parseMT940(data []byte) (*Result, error) {
+	res := &Result{Format: FormatMT940}
+
+	var (
+		fields  []mt940Field
+		current *mt940Field
+	)
+	flushMessage := func() {
+		if current != nil {
+			fields = append(fields, *current)
+			current = nil
+		}
+		if len(fields) > 0 {
+			if stmt, ok := buildMT940Statement(fields, res); ok {
+				res.Statements = append(res.Statements, stmt)
+			}
+		}
+		fields = nil
+	}
+
+	for i, raw := range splitLines(data) {
+		lineNo := i + 1
+		line := strings.TrimRight(raw, " \t")
+
+		// Strip SWIFT envelope blocks ({1:...}{2:...}{4:) that precede the text block
+		if strings.HasPrefix(line, "{") {
+			idx := strings.Index(line, "{4:")
+			if idx < 0 {
+				continue
+			}
+			line = line[idx+3:]
+			if line == "" {
+				continue
+			}
+		}
+		if line == "-" || strings.HasPrefix(line, "-}") {
+			flushMessage()
+			continue
+		}
+
+		if m := mt940TagRe.FindStringSubmatch(line); m != nil {
+			if current != nil {
+				fields = append(fields, *current)
+			}
+			if m[1] == "20" && len(fields) > 0 {
+				// A new :20: without a terminator starts the next message
+				current = nil
+				flushMessage()
+			}
+			current = &mt940Field{tag: m[1], value: m[2], line: lineNo}
+			continue
+		}
+		if current != nil && line != "" {
+			current.value += "\n" + line
+		}
+	}
+	flushMessage()
+
+	if len(res.Statements) == 0 && len(res.Errors) == 0 {
+		return nil, fmt.Errorf("MT940 file contains no statements")
+	}
+	return res, nil
+}
+
+func buildMT940Statement(fields []mt940Field, res *Result) (Statement, bool) {
+	var (
+		stmt      Statement
+		txRef     string
+		stmtNo    string
+		lastLine  = -1
+		firstLine = fields[0].line
+	)
+
+	for _, f := range fields {
+		switch f.tag {
+		case "20":
+			txRef = strings.TrimSpace(f.value)
+		case "25":
+			stmt.AccountID = strings.TrimSpace(f.value)
+		case "28C", "28":
+			stmtNo = strings.TrimSpace(f.value)
+		case "60F", "60M":
+			amt, date, ccy, err := parseMT940Balance(f.value)
+			if err != nil {
+				res.addError(f.line, "opening balance: %v", err)
+				continue
+			}
+			stmt.OpeningBalance = amt
+			stmt.Currency = ccy
+			if stmt.StatementDate.IsZero() {
+				stmt.StatementDate = date
+			}
+		case "62F", "62M":
+			amt, date, ccy, err := parseMT940Balance(f.value)
+			if err != nil {
+				res.addError(f.line, "closing balance: %v", err)
+				continue
+			}
+			stmt.ClosingBalance = amt
+			stmt.StatementDate = date
+			if stmt.Currency == "" {
+				stmt.Currency = ccy
+			}
+		case "61":
+			line, err := parseMT940Line(f.value)
+			if err != nil {
+				res.addError(f.line, "%v", err)
+				lastLine = -1
+				continue
+			}
+			stmt.Lines = append(stmt.Lines, line)
+			lastLine = len(stmt.Lines) - 1
+		case "86":
+			if lastLine >= 0 {
+				info := strings.Join(strings.Fields(strings.ReplaceAll(f.value, "\n", "")), " ")
+				stmt.Lines[lastLine].Description = strings.TrimSpace(strings.TrimSpace(stmt.Lines[lastLine].Description + " " + info))
+			}
+		}
+	}
+
+	if txRef == "" {
+		res.addError(firstLine, "statement is missing :20: transaction reference")
+		return Statement{}, false
+	}
+	stmt.Reference = txRef
+	if stmtNo != "" {
+		stmt.Reference = txRef + "/" + stmtNo
+	}
+	return stmt, true
+}
+
+func parseMT940Balance(v string) (decimal.Decimal, time.Time, string, error) {
+	m := mt940BalanceRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return decimal.Zero, time.Time{}, "", fmt.Errorf("invalid balance %q", v)
+	}
+	date, err := time.Parse("060102", m[2])
+	if err != nil {
+		return decimal.Zero, time.Time{}, "", fmt.Errorf("invalid date %q", m[2])
+	}
+	amt, err := parseMT940Amount(m[4])
+	if err != nil {
+		return decimal.Zero, time.Time{}, "", err
+	}
+	if m[1] == "D" {
+		amt = amt.Neg()
+	}
+	return amt, date, m[3], nil
+}
+
+func parseMT940Line(v string) (Line, error) {
+	first, rest, _ := strings.Cut(v, "\n")
+	m := mt940LineRe.FindStringSubmatch(strings.TrimSpace(first))
+	if m == nil {
+		return Line{}, fmt.Errorf("invalid :61: statement line %q", first)
+	}
+
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid value date %q", m[1])
+	}
+	bookingDate := valueDate
+	if m[2] != "" {
+		// Entry date is MMDD in the value date's year, rolling over at year end
+		entry, err := time.Parse("20060102", fmt.Sprintf("%04d%s", valueDate.Year(), m[2]))
+		if err != nil {
+			return Line{}, fmt.Errorf("invalid entry date %q", m[2])
+		}
+		if entry.Sub(valueDate) > 180*24*time.Hour {
+			entry = entry.AddDate(-1, 0, 0)
+		} else if valueDate.Sub(entry) > 180*24*time.Hour {
+			entry = entry.AddDate(1, 0, 0)
+		}
+		bookingDate = entry
+	}
+
+	amt, err := parseMT940Amount(m[5])
+	if err != nil {
+		return Line{}, err
+	}
+	// D and RC (reversal of credit) reduce the balance
+	if m[3] == "D" || m[3] == "RC" {
+		amt = amt.Neg()
+	}
+
+	ref := strings.TrimSpace(m[7])
+	if ref == "NONREF" {
+		ref = ""
+	}
+	if ref == "" {
+		ref = strings.TrimSpace(m[8])
+	}
+
+	return Line{
+		BookingDate: bookingDate,
+		Amount:      amt,
+		Description: strings.TrimSpace(rest),
+		Reference:   ref,
+	}, nil
+}
+
+func parseMT940Amount(s string) (decimal.Decimal, error) {
+	// SWIFT amounts use a decimal comma and may end with it ("100,")
+	amt, err := decimal.NewFromString(strings.TrimSuffix(strings.Replace(s, ",", ".", 1), "."))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", s)
+	}
+	return amt, nil
+}
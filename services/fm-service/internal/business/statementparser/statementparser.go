@@ -0,0 +1,121 @@
+// Package statementparser reads bank statement files (ISO 20022 camt.053,
+// SWIFT MT940 and BAI2) into a format-neutral representation that the cash
+// management service turns into BankStatement and BankStatementLine records.
+package statementparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Format identifies a supported statement file format.
+type Format string
+
+const (
+	FormatCAMT053 Format = "CAMT053"
+	FormatMT940   Format = "MT940"
+	FormatBAI2    Format = "BAI2"
+)
+
+func (f Format) IsValid() bool {
+	switch f {
+	case FormatCAMT053, FormatMT940, FormatBAI2:
+		return true
+	}
+	return false
+}
+
+var ErrUnknownFormat = errors.New("unrecognised bank statement format")
+
+// Statement is one account statement found in a file. A single file may carry
+// several (multiple camt.053 Stmt blocks, MT940 messages or BAI2 accounts).
+type Statement struct {
+	Reference      string
+	AccountID      string // IBAN or bank account number as stated in the file
+	Currency       string
+	StatementDate  time.Time
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	Lines          []Line
+}
+
+// Line is a single booked transaction; Amount is positive for credits and
+// negative for debits.
+type Line struct {
+	BookingDate time.Time
+	Amount      decimal.Decimal
+	Description string
+	Reference   string
+}
+
+// LineError reports a problem with one entry. Line is the physical line
+// number for MT940 and BAI2 and the 1-based entry position for camt.053.
+type LineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Result holds the parsed statements and any per-line errors. Entries with
+// errors are left out of Statements.
+type Result struct {
+	Format     Format
+	Statements []Statement
+	Errors     []LineError
+}
+
+func (r *Result) addError(line int, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, LineError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// Parse reads data in the given format. An empty format is auto-detected.
+// The returned error is only set when the file as a whole cannot be read;
+// problems with individual entries are collected in Result.Errors.
+func Parse(format Format, data []byte) (*Result, error) {
+	if format == "" {
+		detected, err := DetectFormat(data)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+
+	switch format {
+	case FormatCAMT053:
+		return parseCAMT053(data)
+	case FormatMT940:
+		return parseMT940(data)
+	case FormatBAI2:
+		return parseBAI2(data)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// DetectFormat sniffs the file header.
+func DetectFormat(data []byte) (Format, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")) && bytes.Contains(trimmed, []byte("BkToCstmrStmt")):
+		return FormatCAMT053, nil
+	case bytes.HasPrefix(trimmed, []byte("01,")):
+		return FormatBAI2, nil
+	case bytes.Contains(trimmed, []byte(":20:")) && bytes.Contains(trimmed, []byte(":61:")) || bytes.Contains(trimmed, []byte(":60F:")):
+		return FormatMT940, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// splitLines normalises line endings and returns the file's lines.
+func splitLines(data []byte) []string {
+	s := strings.ReplaceAll(string(data), "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Split(s, "\n")
+}
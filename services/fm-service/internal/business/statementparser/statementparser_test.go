@@ -0,0 +1,212 @@
+package statementparser_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erp-system/fm-service/internal/business/statementparser"
+	"github.com/shopspring/decimal"
+)
+
+const camtSample = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG-1</MsgId><CreDtTm>2026-03-11T06:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT-2026-03-10</Id>
+      <CreDtTm>2026-03-11T06:00:00</CreDtTm>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2026-03-09</Dt></Dt>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">1350.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2026-03-10</Dt></Dt>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="EUR">500.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><Dt>2026-03-10</Dt></BookgDt>
+        <AcctSvcrRef>BANKREF1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>PAY-001</EndToEndId></Refs>
+          <RmtInf><Ustrd>Invoice INV-42</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">150.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><DtTm>2026-03-10T09:30:00</DtTm></BookgDt>
+        <AddtlNtryInf>Supplier payment</AddtlNtryInf>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+const mt940Sample = `{1:F01BANKDEFFXXXX0000000000}{2:O9400000000000BANKDEFFXXXX00000000000000000000N}{4:
+:20:STARTUMSE
+:25:37040044/0532013000
+:28C:00042/001
+:60F:C260309EUR1000,00
+:61:2603100310C500,00NTRFPAY-001//BANKREF1
+:86:166?00GUTSCHRIFT?20Invoice INV-42
+:61:2603100310D150,NCHGNONREF//FEE-7
+:86:Account maintenance fee
+:62F:C260310EUR1350,
+-}`
+
+const bai2Sample = `01,BANKUS,ERPCO,260311,0600,FILE7,,,2/
+02,ERPCO,BANKUS,1,260310,,USD,2/
+03,000123456,USD,010,100000,,,015,135000,,/
+16,195,50000,Z,BREF1,PAY-001,Incoming wire/
+88,invoice INV-42
+16,475,15000,V,260310,0900,BREF2,,Check 1001/
+49,370000,4/
+98,370000,1,6/
+99,370000,1,8/`
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]statementparser.Format{
+		camtSample:  statementparser.FormatCAMT053,
+		mt940Sample: statementparser.FormatMT940,
+		bai2Sample:  statementparser.FormatBAI2,
+	}
+	for data, want := range cases {
+		got, err := statementparser.DetectFormat([]byte(data))
+		if err != nil || got != want {
+			t.Errorf("expected %s, got %s (%v)", want, got, err)
+		}
+	}
+	if _, err := statementparser.DetectFormat([]byte("hello")); !errors.Is(err, statementparser.ErrUnknownFormat) {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
+
+func TestParseCAMT053(t *testing.T) {
+	res, err := statementparser.Parse("", []byte(camtSample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 0 || len(res.Statements) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	st := res.Statements[0]
+	if st.Reference != "STMT-2026-03-10" || st.AccountID != "DE89370400440532013000" || st.Currency != "EUR" {
+		t.Errorf("unexpected header: %+v", st)
+	}
+	if !st.OpeningBalance.Equal(decimal.NewFromInt(1000)) || !st.ClosingBalance.Equal(decimal.NewFromInt(1350)) {
+		t.Errorf("unexpected balances: %s / %s", st.OpeningBalance, st.ClosingBalance)
+	}
+	if !st.StatementDate.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected statement date: %s", st.StatementDate)
+	}
+	if len(st.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(st.Lines))
+	}
+	if !st.Lines[0].Amount.Equal(decimal.NewFromInt(500)) || st.Lines[0].Reference != "PAY-001" || st.Lines[0].Description != "Invoice INV-42" {
+		t.Errorf("unexpected credit line: %+v", st.Lines[0])
+	}
+	if !st.Lines[1].Amount.Equal(decimal.NewFromInt(-150)) || st.Lines[1].Description != "Supplier payment" {
+		t.Errorf("unexpected debit line: %+v", st.Lines[1])
+	}
+}
+
+func TestParseCAMT053_LineErrors(t *testing.T) {
+	bad := `<Document><BkToCstmrStmt><Stmt><Id>S1</Id>
+<Ntry><Amt Ccy="EUR">abc</Amt><CdtDbtInd>CRDT</CdtDbtInd><BookgDt><Dt>2026-03-10</Dt></BookgDt></Ntry>
+<Ntry><Amt Ccy="EUR">10.00</Amt><CdtDbtInd>XXXX</CdtDbtInd><BookgDt><Dt>2026-03-10</Dt></BookgDt></Ntry>
+<Ntry><Amt Ccy="EUR">10.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Ntry>
+</Stmt></BkToCstmrStmt></Document>`
+	res, err := statementparser.Parse(statementparser.FormatCAMT053, []byte(bad))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 3 || res.Errors[0].Line != 1 || res.Errors[2].Line != 3 {
+		t.Errorf("expected one error per entry, got %+v", res.Errors)
+	}
+
+	if _, err := statementparser.Parse(statementparser.FormatCAMT053, []byte("<Document><broken")); err == nil {
+		t.Error("expected error for malformed XML")
+	}
+}
+
+func TestParseMT940(t *testing.T) {
+	res, err := statementparser.Parse("", []byte(mt940Sample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 0 || len(res.Statements) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	st := res.Statements[0]
+	if st.Reference != "STARTUMSE/00042/001" || st.AccountID != "37040044/0532013000" || st.Currency != "EUR" {
+		t.Errorf("unexpected header: %+v", st)
+	}
+	if !st.OpeningBalance.Equal(decimal.NewFromInt(1000)) || !st.ClosingBalance.Equal(decimal.NewFromInt(1350)) {
+		t.Errorf("unexpected balances: %s / %s", st.OpeningBalance, st.ClosingBalance)
+	}
+	if len(st.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(st.Lines))
+	}
+	if !st.Lines[0].Amount.Equal(decimal.NewFromInt(500)) || st.Lines[0].Reference != "PAY-001" {
+		t.Errorf("unexpected credit line: %+v", st.Lines[0])
+	}
+	if !st.Lines[1].Amount.Equal(decimal.NewFromInt(-150)) || st.Lines[1].Reference != "FEE-7" || st.Lines[1].Description != "Account maintenance fee" {
+		t.Errorf("unexpected debit line: %+v", st.Lines[1])
+	}
+	if !st.Lines[0].BookingDate.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected booking date: %s", st.Lines[0].BookingDate)
+	}
+}
+
+func TestParseMT940_LineErrors(t *testing.T) {
+	bad := ":20:REF1\n:25:123\n:60F:C260309EUR100,00\n:61:26XX10C5,00NTRFREF\n:61:260310C5,00NTRFREF\n:62F:X\n-"
+	res, err := statementparser.Parse(statementparser.FormatMT940, []byte(bad))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 2 || res.Errors[0].Line != 4 || res.Errors[1].Line != 6 {
+		t.Errorf("expected errors on lines 4 and 6, got %+v", res.Errors)
+	}
+}
+
+func TestParseBAI2(t *testing.T) {
+	res, err := statementparser.Parse("", []byte(bai2Sample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 0 || len(res.Statements) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	st := res.Statements[0]
+	if st.Reference != "BANKUS-FILE7-260311-000123456-20260310" || st.AccountID != "000123456" || st.Currency != "USD" {
+		t.Errorf("unexpected header: %+v", st)
+	}
+	if !st.OpeningBalance.Equal(decimal.NewFromInt(1000)) || !st.ClosingBalance.Equal(decimal.NewFromInt(1350)) {
+		t.Errorf("unexpected balances: %s / %s", st.OpeningBalance, st.ClosingBalance)
+	}
+	if len(st.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(st.Lines))
+	}
+	if !st.Lines[0].Amount.Equal(decimal.NewFromInt(500)) || st.Lines[0].Reference != "PAY-001" || st.Lines[0].Description != "Incoming wire,invoice INV-42" {
+		t.Errorf("unexpected credit line: %+v", st.Lines[0])
+	}
+	if !st.Lines[1].Amount.Equal(decimal.NewFromInt(-150)) || st.Lines[1].Reference != "BREF2" || st.Lines[1].Description != "Check 1001" {
+		t.Errorf("unexpected debit line: %+v", st.Lines[1])
+	}
+}
+
+func TestParseBAI2_LineErrors(t *testing.T) {
+	bad := "01,BANKUS,ERPCO,260311,0600,FILE8/\n02,ERPCO,BANKUS,1,260310,,USD/\n03,000123456,USD/\n16,195,abc,Z/\n16,900,100,Z/\n16,195,100,Q/\n49,0,4/"
+	res, err := statementparser.Parse(statementparser.FormatBAI2, []byte(bad))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 3 || res.Errors[0].Line != 4 || res.Errors[2].Line != 6 {
+		t.Errorf("expected errors on lines 4-6, got %+v", res.Errors)
+	}
+
+	if _, err := statementparser.Parse(statementparser.FormatBAI2, []byte("02,missing header/")); err == nil {
+		t.Error("expected error for missing file header")
+	}
+}
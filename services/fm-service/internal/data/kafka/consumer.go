@@ -27,32 +27,41 @@ type DeadLetterMessage struct {
 }
 
 const (
-	TopicHrEmployeeCreatedDeadLetter       = domain.TopicHrEmployeeCreated + ".dead-letter"
-	TopicHrPayrollProcessedDeadLetter      = domain.TopicHrPayrollProcessed + ".dead-letter"
-	TopicHrExpenseSubmittedDeadLetter      = domain.TopicHrExpenseSubmitted + ".dead-letter"
-	TopicScmPurchaseOrderCreatedDeadLetter = domain.TopicScmPurchaseOrderCreated + ".dead-letter"
-	TopicScmInventoryValuedDeadLetter      = domain.TopicScmInventoryValued + ".dead-letter"
-	TopicCrmOrderConfirmedDeadLetter       = domain.TopicCrmOrderConfirmed + ".dead-letter"
-	TopicCrmCustomerCreatedDeadLetter      = domain.TopicCrmCustomerCreated + ".dead-letter"
-	TopicMfgProductionCompletedDeadLetter  = domain.TopicMfgProductionCompleted + ".dead-letter"
-	TopicMfgMaterialConsumedDeadLetter     = domain.TopicMfgMaterialConsumed + ".dead-letter"
-	TopicPrjProjectCreatedDeadLetter       = domain.TopicPrjProjectCreated + ".dead-letter"
-	TopicPrjTimeLoggedDeadLetter           = domain.TopicPrjTimeLogged + ".dead-letter"
-	TopicPrjExpenseIncurredDeadLetter      = domain.TopicPrjExpenseIncurred + ".dead-letter"
+	TopicHrEmployeeCreatedDeadLetter        = domain.TopicHrEmployeeCreated + ".dead-letter"
+	TopicHrPayrollProcessedDeadLetter       = domain.TopicHrPayrollProcessed + ".dead-letter"
+	TopicHrExpenseSubmittedDeadLetter       = domain.TopicHrExpenseSubmitted + ".dead-letter"
+	TopicScmPurchaseOrderCreatedDeadLetter  = domain.TopicScmPurchaseOrderCreated + ".dead-letter"
+	TopicScmInventoryValuedDeadLetter       = domain.TopicScmInventoryValued + ".dead-letter"
+	TopicCrmOrderConfirmedDeadLetter        = domain.TopicCrmOrderConfirmed + ".dead-letter"
+	TopicCrmOrderCancelledDeadLetter        = domain.TopicCrmOrderCancelled + ".dead-letter"
+	TopicCrmCustomerCreatedDeadLetter       = domain.TopicCrmCustomerCreated + ".dead-letter"
+	TopicMfgProductionCompletedDeadLetter   = domain.TopicMfgProductionCompleted + ".dead-letter"
+	TopicMfgMaterialConsumedDeadLetter      = domain.TopicMfgMaterialConsumed + ".dead-letter"
+	TopicMfgYieldProducedDeadLetter         = domain.TopicMfgYieldProduced + ".dead-letter"
+	TopicPrjProjectCreatedDeadLetter        = domain.TopicPrjProjectCreated + ".dead-letter"
+	TopicPrjTimeLoggedDeadLetter            = domain.TopicPrjTimeLogged + ".dead-letter"
+	TopicPrjExpenseIncurredDeadLetter       = domain.TopicPrjExpenseIncurred + ".dead-letter"
+	TopicEamEquipmentUsageDeadLetter        = domain.TopicEamEquipmentUsageRecorded + ".dead-letter"
+	TopicScmRequisitionApprovedDeadLetter   = domain.TopicScmPurchaseRequisitionApproved + ".dead-letter"
+	TopicScmPurchaseOrderApprovedDeadLetter = domain.TopicScmPurchaseOrderApproved + ".dead-letter"
+	TopicScmReceiptStagedDeadLetter         = domain.TopicScmReceiptStaged + ".dead-letter"
 
 	defaultLegalEntityID = "00000000-0000-0000-0000-000000000000"
 )
 
 // KafkaConsumer listens to external microservice events and updates the financial records
 type KafkaConsumer struct {
-	reader    *kafka.Reader
-	publisher domain.EventPublisher
-	gl        *service.GeneralLedgerService
-	ap        *service.AccountsPayableService
-	ar        *service.AccountsReceivableService
-	cash      *service.CashManagementService
-	budget    *service.BudgetingService
-	inbox     domain.KafkaEventInboxRepository
+	reader     *kafka.Reader
+	publisher  domain.EventPublisher
+	gl         *service.GeneralLedgerService
+	ap         *service.AccountsPayableService
+	ar         *service.AccountsReceivableService
+	cash       *service.CashManagementService
+	budget     *service.BudgetingService
+	assets     *service.CapitalAssetService
+	allocation *service.CostAllocationService
+	inbox      domain.KafkaEventInboxRepository
+	tm         domain.TransactionManager
 }
 
 // NewKafkaConsumer initializes the Kafka consumer with a list of topics
@@ -65,7 +74,10 @@ func NewKafkaConsumer(
 	ar *service.AccountsReceivableService,
 	cash *service.CashManagementService,
 	budget *service.BudgetingService,
+	assets *service.CapitalAssetService,
+	allocation *service.CostAllocationService,
 	inbox domain.KafkaEventInboxRepository,
+	tm domain.TransactionManager,
 ) *KafkaConsumer {
 	topics := []string{
 		domain.TopicHrEmployeeCreated,
@@ -75,12 +87,18 @@ func NewKafkaConsumer(
 		domain.TopicScmInvoiceReceived,
 		domain.TopicScmInventoryValued,
 		domain.TopicCrmOrderConfirmed,
+		domain.TopicCrmOrderCancelled,
 		domain.TopicCrmCustomerCreated,
 		domain.TopicMfgProductionCompleted,
 		domain.TopicMfgMaterialConsumed,
+		domain.TopicMfgYieldProduced,
 		domain.TopicPrjProjectCreated,
 		domain.TopicPrjTimeLogged,
 		domain.TopicPrjExpenseIncurred,
+		domain.TopicEamEquipmentUsageRecorded,
+		domain.TopicScmPurchaseRequisitionApproved,
+		domain.TopicScmPurchaseOrderApproved,
+		domain.TopicScmReceiptStaged,
 	}
 
 	reader := kafka.NewReader(kafka.ReaderConfig{
@@ -90,14 +108,17 @@ func NewKafkaConsumer(
 	})
 
 	return &KafkaConsumer{
-		reader:    reader,
-		publisher: publisher,
-		gl:        gl,
-		ap:        ap,
-		ar:        ar,
-		cash:      cash,
-		budget:    budget,
-		inbox:     inbox,
+		reader:     reader,
+		publisher:  publisher,
+		gl:         gl,
+		ap:         ap,
+		ar:         ar,
+		cash:       cash,
+		budget:     budget,
+		assets:     assets,
+		allocation: allocation,
+		inbox:      inbox,
+		tm:         tm,
 	}
 }
 
@@ -232,6 +253,10 @@ func (c *KafkaConsumer) processEvent(ctx context.Context, topic string, value []
 		if err := json.Unmarshal(value, &ev); err != nil {
 			return err
 		}
+		legalEntityID := ev.LegalEntityID
+		if legalEntityID == "" {
+			legalEntityID = defaultLegalEntityID
+		}
 		// Debit Salaries Expense, Credit Payroll Liability Control
 		salariesExpenseAcc, err := c.getOrCreateAccount(ctx, "6010-001", "Salaries Expense", "EXPENSE")
 		if err != nil {
@@ -245,19 +270,41 @@ func (c *KafkaConsumer) processEvent(ctx context.Context, topic string, value []
 		lines := []domain.UniversalJournalLine{
 			{
 				AccountID:             salariesExpenseAcc.ID,
-				AmountFunctional:      ev.TotalGross,
-				AmountTransactional:   ev.TotalGross,
+				AmountFunctional:      ev.TotalGrossPay,
+				AmountTransactional:   ev.TotalGrossPay,
 				CurrencyTransactional: "USD",
 			},
 			{
 				AccountID:             payrollLiabilityAcc.ID,
-				AmountFunctional:      ev.TotalGross.Neg(),
-				AmountTransactional:   ev.TotalGross.Neg(),
+				AmountFunctional:      ev.TotalGrossPay.Neg(),
+				AmountTransactional:   ev.TotalGrossPay.Neg(),
 				CurrencyTransactional: "USD",
 			},
 		}
-		_, err = c.gl.CreateJournalEntry(ctx, defaultLegalEntityID, "HR", "PAY-"+ev.PayrollID, ev.Timestamp, lines)
-		return err
+		// The journal, the snapshot and the headcount are stored together, so a failed event
+		// leaves nothing behind to be posted twice when it is replayed
+		return c.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+			if _, err := c.gl.CreateJournalEntry(txCtx, legalEntityID, "HR", "PAY-"+ev.PayrollRunID, ev.Timestamp, lines); err != nil {
+				return err
+			}
+
+			// Keep the run so the cash flow forecast can project future payroll
+			if err := c.cash.RecordPayrollRun(txCtx, &domain.PayrollRunSnapshot{
+				ID:            ev.PayrollRunID,
+				LegalEntityID: legalEntityID,
+				FiscalYear:    ev.FiscalYear,
+				PeriodNumber:  ev.PeriodNumber,
+				TotalGrossPay: ev.TotalGrossPay,
+				TotalNetPay:   ev.TotalNetPay,
+				ProcessedAt:   ev.Timestamp,
+			}); err != nil {
+				return err
+			}
+
+			// Unknown cost centers are skipped rather than holding up the payroll posting
+			period := fmt.Sprintf("%04d-%02d", ev.FiscalYear, ev.PeriodNumber)
+			return c.allocation.RecordHeadcount(txCtx, period, ev.Headcount)
+		})
 
 	case domain.TopicHrExpenseSubmitted:
 		var ev domain.ExpenseSubmittedEvent
@@ -320,16 +367,55 @@ func (c *KafkaConsumer) processEvent(ctx context.Context, topic string, value []
 				CurrencyTransactional: "USD",
 			},
 		}
-		_, err = c.gl.CreateJournalEntry(ctx, defaultLegalEntityID, "SCM", "PO-LIAB-"+ev.PurchaseOrderID, ev.Timestamp, lines)
-		return err
+		if _, err := c.gl.CreateJournalEntry(ctx, defaultLegalEntityID, "SCM", "PO-LIAB-"+ev.PurchaseOrderID, ev.Timestamp, lines); err != nil || len(ev.Lines) == 0 {
+			return err
+		}
+
+		// Keep the ordered materials and prices for the three-way match of the vendor bills
+		poLines := make([]service.PurchaseOrderLineRequest, len(ev.Lines))
+		for i, l := range ev.Lines {
+			poLines[i] = service.PurchaseOrderLineRequest{
+				LineID:          l.LineID,
+				MaterialID:      l.MaterialID,
+				QuantityOrdered: l.QuantityOrdered,
+				UnitPrice:       l.UnitPrice,
+			}
+		}
+		return c.ap.RecordPurchaseOrder(ctx, ev.PurchaseOrderID, ev.SupplierID, poLines)
+
+	case domain.TopicScmReceiptStaged:
+		var ev domain.ReceiptStagedEvent
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return err
+		}
+		receiptLines := make([]service.GoodsReceiptLineRequest, len(ev.Lines))
+		for i, l := range ev.Lines {
+			receiptLines[i] = service.GoodsReceiptLineRequest{
+				MaterialID:       l.MaterialID,
+				QuantityReceived: l.QuantityReceived,
+			}
+		}
+		return c.ap.RecordGoodsReceipt(ctx, ev.ReceiptID, ev.PurchaseOrderID, ev.ReceivedDate, receiptLines)
 
 	case domain.TopicScmInvoiceReceived:
 		var ev domain.InvoiceReceivedEvent
 		if err := json.Unmarshal(value, &ev); err != nil {
 			return err
 		}
-		_, err := c.ap.CreateVendorBill(ctx, defaultLegalEntityID, ev.VendorID, ev.InvoiceNo, ev.POID, ev.DueDate, ev.TotalAmount, decimal.Zero)
-		return err
+		items := make([]service.VendorBillLineRequest, len(ev.Lines))
+		for i, l := range ev.Lines {
+			items[i] = service.VendorBillLineRequest{
+				MaterialID: l.MaterialID,
+				Quantity:   l.Quantity,
+				UnitPrice:  l.UnitPrice,
+			}
+		}
+		_, err := c.ap.CreateVendorBillWithLines(ctx, defaultLegalEntityID, ev.VendorID, ev.InvoiceNo, ev.POID, "", ev.DueDate, ev.TotalAmount, decimal.Zero, items)
+		if err != nil || ev.POID == "" {
+			return err
+		}
+		// The invoiced order turns its budget commitment into actual spend
+		return c.budget.ConsumeCommitments(ctx, ev.POID, ev.TotalAmount)
 
 	case domain.TopicScmInventoryValued:
 		var ev domain.InventoryValuedEvent
@@ -368,10 +454,20 @@ func (c *KafkaConsumer) processEvent(ctx context.Context, topic string, value []
 		if err := json.Unmarshal(value, &ev); err != nil {
 			return err
 		}
-		// Generate customer invoice
-		_, err := c.ar.CreateInvoice(ctx, defaultLegalEntityID, ev.CustomerID, ev.SalesOrderID, ev.TotalAmount, decimal.Zero, ev.Timestamp.AddDate(0, 1, 0))
+		// Count the order towards credit exposure, then generate customer invoice
+		if err := c.ar.RecordSalesOrder(ctx, defaultLegalEntityID, ev.SalesOrderID, ev.CustomerID, ev.TotalAmount, ev.Timestamp); err != nil {
+			return err
+		}
+		_, err := c.ar.CreateInvoice(ctx, defaultLegalEntityID, ev.CustomerID, ev.SalesOrderID, "", ev.TotalAmount, decimal.Zero, ev.Timestamp.AddDate(0, 1, 0))
 		return err
 
+	case domain.TopicCrmOrderCancelled:
+		var ev domain.SalesOrderCancelledEvent
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return err
+		}
+		return c.ar.CancelSalesOrder(ctx, ev.SalesOrderID)
+
 	case domain.TopicCrmCustomerCreated:
 		var ev domain.CustomerCreatedEvent
 		if err := json.Unmarshal(value, &ev); err != nil {
@@ -527,11 +623,80 @@ func (c *KafkaConsumer) processEvent(ctx context.Context, topic string, value []
 		}
 		_, err = c.gl.CreateJournalEntry(ctx, defaultLegalEntityID, "PRJ", "PRJ-EXP-"+ev.ExpenseID, ev.Timestamp, lines)
 		return err
+
+	case domain.TopicMfgYieldProduced:
+		var ev domain.YieldProducedEvent
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return err
+		}
+		// Work centers without a cost center have nowhere to book their machine hours
+		if ev.CostCenterID == "" {
+			return nil
+		}
+		return c.allocation.AddMachineHours(ctx, ev.CostCenterID, ev.Timestamp.Format("2006-01"), ev.MachineHours)
+
+	case domain.TopicEamEquipmentUsageRecorded:
+		var ev domain.EquipmentUsageRecordedEvent
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return err
+		}
+		// Usage drives units-of-production depreciation of the linked asset
+		legalEntityID := ev.LegalEntityID
+		if legalEntityID == "" {
+			legalEntityID = defaultLegalEntityID
+		}
+		usageDate := ev.UsageDate
+		if usageDate.IsZero() {
+			usageDate = ev.Timestamp
+		}
+		return c.assets.RecordEquipmentUsage(ctx, legalEntityID, ev.EquipmentID, usageDate, ev.Units)
+
+	case domain.TopicScmPurchaseRequisitionApproved:
+		var ev domain.PurchaseRequisitionApprovedEvent
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return err
+		}
+		return c.commitBudget(ctx, domain.BudgetCommitmentSourcePURCHASE_REQUISITION, ev.RequisitionID, "", ev.NeedByDate, ev.Timestamp, ev.Lines)
+
+	case domain.TopicScmPurchaseOrderApproved:
+		var ev domain.PurchaseOrderApprovedEvent
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return err
+		}
+		return c.commitBudget(ctx, domain.BudgetCommitmentSourcePURCHASE_ORDER, ev.PurchaseOrderID, ev.RequisitionID, ev.DeliveryDate, ev.Timestamp, ev.Lines)
 	}
 
 	return nil
 }
 
+// commitBudget records a budget commitment per line of an approved requisition or purchase order,
+// in the fiscal period the goods are needed. Overruns are reported back to SCM by the budgeting service.
+func (c *KafkaConsumer) commitBudget(ctx context.Context, source domain.BudgetCommitmentSource, documentID, replacesDocumentID string, budgetDate, timestamp time.Time, lines []domain.PurchaseCommitmentLine) error {
+	if budgetDate.IsZero() {
+		budgetDate = timestamp
+	}
+	fiscalYear, period, err := c.budget.FiscalPeriodOf(ctx, budgetDate)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		_, err := c.budget.RecordCommitment(ctx, service.CommitmentRequest{
+			SourceType:         source,
+			SourceDocumentID:   documentID,
+			ReplacesDocumentID: replacesDocumentID,
+			AccountID:          line.AccountID,
+			CostCenterID:       line.CostCenterID,
+			FiscalYear:         fiscalYear,
+			Period:             period,
+			Amount:             line.Amount,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close stops the reader
 func (c *KafkaConsumer) Close() error {
 	return c.reader.Close()
@@ -9,6 +9,7 @@ import (
 	"github.com/erp-system/fm-service/internal/business/domain"
 	"github.com/erp-system/fm-service/internal/business/service"
 	"github.com/erp-system/fm-service/internal/data/memory"
+	"github.com/shopspring/decimal"
 )
 
 type mockEventPublisher struct{}
@@ -27,21 +28,61 @@ func TestKafkaConsumer_Idempotency(t *testing.T) {
 	bills := memory.NewMemoryApVendorBillRepo()
 	outbox := memory.NewMemoryTransactionalOutboxRepo()
 	inbox := memory.NewMemoryKafkaEventInboxRepo()
+	payrollRuns := memory.NewMemoryPayrollRunSnapshotRepo()
+
+	converter := service.NewCurrencyConverter(memory.NewMemoryLegalEntityRepo(), memory.NewMemoryCurrencyRateRepo())
 
 	tmGL := memory.NewMemoryTransactionManager(accounts, entries, outbox)
-	glSvc := service.NewGeneralLedgerService(accounts, entries, outbox, tmGL)
+	glSvc := service.NewGeneralLedgerService(accounts, memory.NewMemoryAccountDeterminationRepo(), entries, memory.NewMemoryFiscalPeriodRepo(), converter, outbox, tmGL)
 
 	credits := memory.NewMemoryCustomerCreditRepo()
-	tmAR := memory.NewMemoryTransactionManager(invoices, outbox)
-	arSvc := service.NewAccountsReceivableService(invoices, credits, outbox, tmAR)
+	taxRates := memory.NewMemoryTaxRateRepo()
+	taxTransactions := memory.NewMemoryTaxTransactionRepo()
+	tmTax := memory.NewMemoryTransactionManager(taxTransactions, accounts, entries, outbox)
+	taxSvc := service.NewTaxService(taxRates, memory.NewMemoryTaxJurisdictionRepo(), memory.NewMemoryTaxExemptionRepo(), taxTransactions, glSvc, tmTax)
+
+	tmAR := memory.NewMemoryTransactionManager(invoices, taxTransactions, accounts, entries, outbox)
+	exposures := memory.NewMemorySalesOrderExposureRepo()
+	arSvc := service.NewAccountsReceivableService(invoices, credits, exposures, memory.NewMemoryCreditOverrideRepo(), converter, taxSvc, outbox, tmAR)
 
-	tmAP := memory.NewMemoryTransactionManager(bills, outbox)
-	apSvc := service.NewAccountsPayableService(bills, outbox, tmAP)
+	billLines := memory.NewMemoryApVendorBillLineRepo()
+	poLines := memory.NewMemoryPurchaseOrderLineRepo()
+	receiptLines := memory.NewMemoryGoodsReceiptLineRepo()
+	tmAP := memory.NewMemoryTransactionManager(bills, billLines, poLines, receiptLines, taxTransactions, accounts, entries, outbox)
+	apSvc := service.NewAccountsPayableService(bills, billLines, poLines, receiptLines, memory.NewMemoryMatchToleranceRepo(), converter, taxSvc, outbox, tmAP)
 
 	tmCM := memory.NewMemoryTransactionManager(payments, invoices, outbox)
-	cmSvc := service.NewCashManagementService(payments, invoices, statements, outbox, tmCM)
+	cmSvc := service.NewCashManagementService(service.CashManagementDeps{
+		Payments:         payments,
+		Invoices:         invoices,
+		Bills:            bills,
+		Allocations:      memory.NewMemoryPaymentAllocationRepo(),
+		CreditMemos:      memory.NewMemoryArCreditMemoRepo(),
+		OnAccountCredits: memory.NewMemoryOnAccountCreditRepo(),
+		Statements:       statements,
+		BankAccounts:     memory.NewMemoryBankAccountRepo(),
+		Matches:          memory.NewMemoryBankReconciliationMatchRepo(),
+		Exceptions:       memory.NewMemoryBankReconciliationExceptionRepo(),
+		PayrollRuns:      payrollRuns,
+		RecurringItems:   memory.NewMemoryRecurringCashItemRepo(),
+		GL:               glSvc,
+		Outbox:           outbox,
+		TM:               tmCM,
+	})
+
+	commitments := memory.NewMemoryBudgetCommitmentRepo()
+	fiscalYears := memory.NewMemoryFiscalYearRepo()
+	tmBudget := memory.NewMemoryTransactionManager(commitments, outbox)
+	budgetSvc := service.NewBudgetingService(memory.NewMemoryBudgetRepo(), commitments, memory.NewMemoryBudgetPolicyRepo(), fiscalYears, accounts, entries, outbox, tmBudget)
 
-	budgetSvc := service.NewBudgetingService(memory.NewMemoryBudgetRepo(), accounts, entries, outbox, tmGL)
+	assets := memory.NewMemoryCapitalAssetRepo()
+	assetSvc := service.NewCapitalAssetService(assets, memory.NewMemoryDepreciationScheduleLineRepo(), memory.NewMemoryAssetUsageRecordRepo(), accounts, entries, outbox, tmGL)
+
+	keyFigures := memory.NewMemoryStatisticalKeyFigureRepo()
+	costCenters := memory.NewMemoryCostCenterRepo()
+	_ = costCenters.Create(context.Background(), &domain.CostCenter{ID: "cc_assembly", Code: "ASSEMBLY", Name: "Assembly", IsActive: true})
+	tmAllocation := memory.NewMemoryTransactionManager(keyFigures)
+	allocationSvc := service.NewCostAllocationService(memory.NewMemoryAllocationCycleRepo(), memory.NewMemoryAllocationRunRepo(), keyFigures, costCenters, accounts, entries, glSvc, tmAllocation)
 
 	publisher := &mockEventPublisher{}
 
@@ -54,7 +95,10 @@ func TestKafkaConsumer_Idempotency(t *testing.T) {
 		arSvc,
 		cmSvc,
 		budgetSvc,
+		assetSvc,
+		allocationSvc,
 		inbox,
+		memory.NewMemoryTransactionManager(accounts, entries, outbox, payrollRuns, keyFigures),
 	)
 
 	ctx := context.Background()
@@ -99,4 +143,175 @@ func TestKafkaConsumer_Idempotency(t *testing.T) {
 	if err != nil || inboxRec.ProcessingStatus != domain.EventProcessingStatusSUCCESS {
 		t.Errorf("expected successful inbox record, got status %s, err: %v", inboxRec.ProcessingStatus, err)
 	}
+
+	// Processed payroll is posted to the ledger and kept for cash forecasting
+	payrollEvent := map[string]interface{}{
+		"event_id":        "evt_pay_1",
+		"legal_entity_id": "legal_123",
+		"payroll_run_id":  "run_2026_03",
+		"fiscal_year":     2026,
+		"period_number":   3,
+		"total_net_pay":   "7000",
+		"total_gross_pay": "10000",
+		"headcount":       []map[string]interface{}{{"cost_center_id": "cc_assembly", "headcount": 12}, {"cost_center_id": "cc_unknown", "headcount": 3}},
+		"timestamp":       time.Now().Format(time.RFC3339),
+	}
+	payloadBytes, _ = json.Marshal(payrollEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicHrPayrollProcessed, payloadBytes); err != nil {
+		t.Fatalf("failed to process payroll processed event: %v", err)
+	}
+	runs, _ := payrollRuns.ListByLegalEntity(ctx, "legal_123")
+	if len(runs) != 1 || runs[0].ID != "run_2026_03" || runs[0].TotalNetPay.String() != "7000" {
+		t.Errorf("expected payroll run snapshot, got %+v", runs)
+	}
+	if posted, _ := entries.List(ctx); len(posted) != 1 {
+		t.Errorf("expected the payroll journal despite the unknown cost center, got %d entries", len(posted))
+	}
+	// The run's headcount becomes the HEADCOUNT driver of its period; unknown cost centers are skipped
+	headcount, _ := allocationSvc.ListKeyFigures(ctx, domain.AllocationDriverHEADCOUNT, "2026-03")
+	if len(headcount) != 1 || !headcount[0].Value.Equal(decimal.NewFromInt(12)) {
+		t.Errorf("expected headcount 12 on cc_assembly, got %+v", headcount)
+	}
+
+	// Machine hours of production yields add up per cost center and month
+	for _, y := range []struct{ id, costCenterID string }{
+		{"evt_yield_1", "cc_assembly"}, {"evt_yield_2", "cc_assembly"}, {"evt_yield_2", "cc_assembly"}, {"evt_yield_3", "cc_unknown"},
+	} {
+		yieldEvent := map[string]interface{}{
+			"event_id":           y.id,
+			"work_order_id":      "wo_1",
+			"routing_station_id": "rs_1",
+			"cost_center_id":     y.costCenterID,
+			"quantity_good":      "10",
+			"machine_hours":      "2.5",
+			"timestamp":          time.Date(2026, 3, 12, 8, 0, 0, 0, time.UTC),
+		}
+		payloadBytes, _ = json.Marshal(yieldEvent)
+		if err := consumer.handleMessage(ctx, domain.TopicMfgYieldProduced, payloadBytes); err != nil {
+			t.Fatalf("failed to process yield produced event: %v", err)
+		}
+	}
+	machineHours, _ := allocationSvc.ListKeyFigures(ctx, domain.AllocationDriverMACHINE_HOURS, "2026-03")
+	if len(machineHours) != 1 || !machineHours[0].Value.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("expected 5 machine hours on cc_assembly once the duplicate is skipped, got %+v", machineHours)
+	}
+
+	// Approved requisitions and orders commit budget in the fiscal period of the need-by date
+	// (May is period 11 of a July fiscal year); the order replaces its requisition
+	_ = fiscalYears.Create(ctx, &domain.FiscalYear{ID: "fy_2026", Year: 2026, StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)})
+	if _, err := budgetSvc.CreateBudget(ctx, "acc_supplies", "", 2026, 11, decimal.NewFromInt(1000)); err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	requisitionEvent := map[string]interface{}{
+		"event_id":       "evt_pr_1",
+		"requisition_id": "pr_1",
+		"need_by_date":   time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC),
+		"lines":          []map[string]interface{}{{"account_id": "acc_supplies", "amount": "400"}},
+	}
+	payloadBytes, _ = json.Marshal(requisitionEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicScmPurchaseRequisitionApproved, payloadBytes); err != nil {
+		t.Fatalf("failed to process requisition approved event: %v", err)
+	}
+	orderEvent := map[string]interface{}{
+		"event_id":          "evt_po_1",
+		"purchase_order_id": "po_1",
+		"requisition_id":    "pr_1",
+		"delivery_date":     time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+		"lines":             []map[string]interface{}{{"account_id": "acc_supplies", "amount": "450"}},
+	}
+	payloadBytes, _ = json.Marshal(orderEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicScmPurchaseOrderApproved, payloadBytes); err != nil {
+		t.Fatalf("failed to process purchase order approved event: %v", err)
+	}
+	open, _ := budgetSvc.ListCommitments(ctx, domain.BudgetCommitmentStatusOPEN)
+	if len(open) != 1 || open[0].SourceDocumentID != "po_1" || !open[0].Amount.Equal(decimal.NewFromInt(450)) || open[0].Period != 11 {
+		t.Errorf("expected only the purchase order commitment to be open, got %+v", open)
+	}
+
+	// Invoicing the order turns the commitment into spend
+	invoiceEvent := map[string]interface{}{
+		"event_id":     "evt_inv_po_1",
+		"vendor_id":    "vendor_1",
+		"invoice_no":   "INV-PO-1",
+		"po_id":        "po_1",
+		"total_amount": "450",
+		"due_date":     time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC),
+	}
+	payloadBytes, _ = json.Marshal(invoiceEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicScmInvoiceReceived, payloadBytes); err != nil {
+		t.Fatalf("failed to process invoice received event: %v", err)
+	}
+	check, err := budgetSvc.CheckBudget(ctx, service.BudgetCheckRequest{AccountID: "acc_supplies", FiscalYear: 2026, Period: 11})
+	if err != nil || !check.Committed.IsZero() || !check.Spent.Equal(decimal.NewFromInt(450)) {
+		t.Errorf("expected the commitment to be consumed into spend, got %+v (%v)", check, err)
+	}
+
+	// Order and receipt lines are kept for the three-way match of the supplier invoice
+	poEvent := map[string]interface{}{
+		"event_id":          "evt_po_created_2",
+		"purchase_order_id": "po_2",
+		"supplier_id":       "vendor_1",
+		"total_amount":      "200",
+		"lines":             []map[string]interface{}{{"line_id": "pol_1", "material_id": "mat_1", "quantity_ordered": "10", "unit_price": "20"}},
+	}
+	payloadBytes, _ = json.Marshal(poEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicScmPurchaseOrderCreated, payloadBytes); err != nil {
+		t.Fatalf("failed to process purchase order created event: %v", err)
+	}
+	receiptEvent := map[string]interface{}{
+		"event_id":          "evt_gr_2",
+		"receipt_id":        "gr_2",
+		"purchase_order_id": "po_2",
+		"received_date":     time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		"lines":             []map[string]interface{}{{"material_id": "mat_1", "quantity_received": "8"}},
+	}
+	payloadBytes, _ = json.Marshal(receiptEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicScmReceiptStaged, payloadBytes); err != nil {
+		t.Fatalf("failed to process receipt staged event: %v", err)
+	}
+	invoiceEvent = map[string]interface{}{
+		"event_id":     "evt_inv_po_2",
+		"vendor_id":    "vendor_1",
+		"invoice_no":   "INV-PO-2",
+		"po_id":        "po_2",
+		"total_amount": "200",
+		"due_date":     time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		"lines":        []map[string]interface{}{{"material_id": "mat_1", "quantity": "10", "unit_price": "20"}},
+	}
+	payloadBytes, _ = json.Marshal(invoiceEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicScmInvoiceReceived, payloadBytes); err != nil {
+		t.Fatalf("failed to process invoice received event: %v", err)
+	}
+	billed, err := bills.GetByNumber(ctx, "INV-PO-2")
+	if err != nil || billed.MatchStatus != domain.BillMatchStatusEXCEPTION || !billed.PaymentHold {
+		t.Errorf("expected the invoice billing more than received to be held, got %+v (%v)", billed, err)
+	}
+
+	// Confirmed sales orders count towards credit exposure until invoiced; cancelled ones drop out
+	salesOrderEvent := map[string]interface{}{
+		"event_id":       "evt_so_1",
+		"customer_id":    "cust_12345678",
+		"sales_order_id": "so_1",
+		"total_amount":   "800",
+		"timestamp":      time.Now().Format(time.RFC3339),
+	}
+	payloadBytes, _ = json.Marshal(salesOrderEvent)
+	if err := consumer.handleMessage(ctx, domain.TopicCrmOrderConfirmed, payloadBytes); err != nil {
+		t.Fatalf("failed to process sales order confirmed event: %v", err)
+	}
+	invoiced, err := exposures.GetBySalesOrderID(ctx, "so_1")
+	if err != nil || invoiced.Status != domain.SalesOrderExposureStatusINVOICED || !invoiced.InvoicedAmount.Equal(decimal.NewFromInt(800)) {
+		t.Errorf("expected the confirmed order to be recorded and invoiced, got %+v (%v)", invoiced, err)
+	}
+	if err := arSvc.RecordSalesOrder(ctx, "legal_123", "so_2", "cust_12345678", decimal.NewFromInt(300), time.Now()); err != nil {
+		t.Fatalf("failed to record sales order: %v", err)
+	}
+	payloadBytes, _ = json.Marshal(map[string]interface{}{"event_id": "evt_so_2_cancel", "sales_order_id": "so_2", "reason": "customer withdrew"})
+	if err := consumer.handleMessage(ctx, domain.TopicCrmOrderCancelled, payloadBytes); err != nil {
+		t.Fatalf("failed to process sales order cancelled event: %v", err)
+	}
+	cancelled, err := exposures.GetBySalesOrderID(ctx, "so_2")
+	if err != nil || cancelled.Status != domain.SalesOrderExposureStatusCANCELLED {
+		t.Errorf("expected the cancelled order to leave the exposure, got %+v (%v)", cancelled, err)
+	}
 }
@@ -3,7 +3,9 @@ package memory
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
 )
@@ -70,7 +72,7 @@ func (r *MemoryChartOfAccountsRepo) GetByID(ctx context.Context, id string) (*do
 	defer r.mu.RUnlock()
 	coa, ok := r.accounts[id]
 	if !ok {
-		return nil, errors.New("chart of accounts not found")
+		return nil, domain.ErrAccountNotFound
 	}
 	return &coa, nil
 }
@@ -83,7 +85,7 @@ func (r *MemoryChartOfAccountsRepo) GetByCode(ctx context.Context, legalEntityID
 			return &coa, nil
 		}
 	}
-	return nil, errors.New("chart of accounts not found")
+	return nil, domain.ErrAccountNotFound
 }
 
 func (r *MemoryChartOfAccountsRepo) Update(ctx context.Context, coa *domain.ChartOfAccounts) error {
@@ -121,6 +123,54 @@ type universalJournalEntryRepoSnapshot struct {
 	lines   map[string][]domain.UniversalJournalLine
 }
 
+// MemoryAccountDeterminationRepo implements domain.AccountDeterminationRepository in-memory.
+// Entries are keyed by legal entity and posting key so an upsert replaces the earlier account.
+type MemoryAccountDeterminationRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.AccountDetermination
+}
+
+func NewMemoryAccountDeterminationRepo() *MemoryAccountDeterminationRepo {
+	return &MemoryAccountDeterminationRepo{
+		data: make(map[string]domain.AccountDetermination),
+	}
+}
+
+func (r *MemoryAccountDeterminationRepo) Upsert(ctx context.Context, determination *domain.AccountDetermination) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := determination.LegalEntityID + "|" + string(determination.PostingKey)
+	if existing, ok := r.data[key]; ok {
+		determination.ID = existing.ID
+		determination.CreatedAt = existing.CreatedAt
+	}
+	r.data[key] = *determination
+	return nil
+}
+
+func (r *MemoryAccountDeterminationRepo) GetByKey(ctx context.Context, legalEntityID string, key domain.PostingKey) (*domain.AccountDetermination, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.data[legalEntityID+"|"+string(key)]
+	if !ok {
+		return nil, domain.ErrAccountDeterminationNotFound
+	}
+	return &d, nil
+}
+
+func (r *MemoryAccountDeterminationRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.AccountDetermination, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.AccountDetermination
+	for _, d := range r.data {
+		if d.LegalEntityID == legalEntityID {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PostingKey < list[j].PostingKey })
+	return list, nil
+}
+
 // MemoryUniversalJournalEntryRepo implements domain.UniversalJournalEntryRepository in-memory
 type MemoryUniversalJournalEntryRepo struct {
 	mu        sync.RWMutex
@@ -222,6 +272,102 @@ func (r *MemoryUniversalJournalEntryRepo) List(ctx context.Context) ([]domain.Un
 	return list, nil
 }
 
+type journalTemplateRepoSnapshot struct {
+	templates map[string]domain.JournalTemplate
+	lines     map[string][]domain.JournalTemplateLine
+}
+
+// MemoryJournalTemplateRepo implements domain.JournalTemplateRepository in-memory
+type MemoryJournalTemplateRepo struct {
+	mu        sync.RWMutex
+	templates map[string]domain.JournalTemplate
+	lines     map[string][]domain.JournalTemplateLine
+	snapshots []journalTemplateRepoSnapshot
+}
+
+func NewMemoryJournalTemplateRepo() *MemoryJournalTemplateRepo {
+	return &MemoryJournalTemplateRepo{
+		templates: make(map[string]domain.JournalTemplate),
+		lines:     make(map[string][]domain.JournalTemplateLine),
+	}
+}
+
+func (r *MemoryJournalTemplateRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snapTemplates := make(map[string]domain.JournalTemplate, len(r.templates))
+	for k, v := range r.templates {
+		snapTemplates[k] = v
+	}
+	snapLines := make(map[string][]domain.JournalTemplateLine, len(r.lines))
+	for k, v := range r.lines {
+		snapLines[k] = append([]domain.JournalTemplateLine(nil), v...)
+	}
+	r.snapshots = append(r.snapshots, journalTemplateRepoSnapshot{
+		templates: snapTemplates,
+		lines:     snapLines,
+	})
+	r.mu.Unlock()
+}
+
+func (r *MemoryJournalTemplateRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		snap := r.snapshots[len(r.snapshots)-1]
+		r.templates = snap.templates
+		r.lines = snap.lines
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryJournalTemplateRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryJournalTemplateRepo) Create(ctx context.Context, template *domain.JournalTemplate, lines []domain.JournalTemplateLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[template.ID] = *template
+	r.lines[template.ID] = append([]domain.JournalTemplateLine(nil), lines...)
+	return nil
+}
+
+func (r *MemoryJournalTemplateRepo) GetByID(ctx context.Context, id string) (*domain.JournalTemplate, []domain.JournalTemplateLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, nil, errors.New("journal template not found")
+	}
+	return &template, append([]domain.JournalTemplateLine(nil), r.lines[id]...), nil
+}
+
+func (r *MemoryJournalTemplateRepo) Update(ctx context.Context, template *domain.JournalTemplate, lines []domain.JournalTemplateLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.templates[template.ID]; !ok {
+		return errors.New("journal template not found")
+	}
+	r.templates[template.ID] = *template
+	r.lines[template.ID] = append([]domain.JournalTemplateLine(nil), lines...)
+	return nil
+}
+
+func (r *MemoryJournalTemplateRepo) List(ctx context.Context) ([]domain.JournalTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.JournalTemplate, 0, len(r.templates))
+	for _, template := range r.templates {
+		list = append(list, template)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
 // MemoryArInvoiceRepo implements domain.ArInvoiceRepository
 type MemoryArInvoiceRepo struct {
 	mu        sync.RWMutex
@@ -479,13 +625,187 @@ func (r *MemoryBudgetRepo) GetByAccountAndPeriod(ctx context.Context, accountID
 	defer r.mu.RUnlock()
 
 	for _, bud := range r.budgets {
-		if bud.AccountID == accountID && bud.FiscalYear == fiscalYear && bud.Period == period {
+		if bud.AccountID == accountID && bud.FiscalYear == fiscalYear && bud.Period == period && bud.Version == domain.BudgetVersionORIGINAL {
 			return &bud, nil
 		}
 	}
 	return nil, errors.New("budget not found")
 }
 
+// MemoryBudgetCommitmentRepo implements domain.BudgetCommitmentRepository
+type MemoryBudgetCommitmentRepo struct {
+	mu          sync.RWMutex
+	commitments map[string]domain.BudgetCommitment
+	snapshots   []map[string]domain.BudgetCommitment
+}
+
+func NewMemoryBudgetCommitmentRepo() *MemoryBudgetCommitmentRepo {
+	return &MemoryBudgetCommitmentRepo{
+		commitments: make(map[string]domain.BudgetCommitment),
+	}
+}
+
+func (r *MemoryBudgetCommitmentRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.BudgetCommitment, len(r.commitments))
+	for k, v := range r.commitments {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryBudgetCommitmentRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.commitments = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryBudgetCommitmentRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryBudgetCommitmentRepo) Create(ctx context.Context, commitment *domain.BudgetCommitment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commitments[commitment.ID] = *commitment
+	return nil
+}
+
+func (r *MemoryBudgetCommitmentRepo) GetByID(ctx context.Context, id string) (*domain.BudgetCommitment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.commitments[id]
+	if !ok {
+		return nil, errors.New("budget commitment not found")
+	}
+	return &c, nil
+}
+
+func (r *MemoryBudgetCommitmentRepo) Update(ctx context.Context, commitment *domain.BudgetCommitment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.commitments[commitment.ID]; !ok {
+		return errors.New("budget commitment not found")
+	}
+	r.commitments[commitment.ID] = *commitment
+	return nil
+}
+
+func (r *MemoryBudgetCommitmentRepo) List(ctx context.Context) ([]domain.BudgetCommitment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.BudgetCommitment, 0, len(r.commitments))
+	for _, c := range r.commitments {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (r *MemoryBudgetCommitmentRepo) ListBySourceDocument(ctx context.Context, sourceDocumentID string) ([]domain.BudgetCommitment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.BudgetCommitment
+	for _, c := range r.commitments {
+		if c.SourceDocumentID == sourceDocumentID {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryBudgetPolicyRepo implements domain.BudgetPolicyRepository
+type MemoryBudgetPolicyRepo struct {
+	mu        sync.RWMutex
+	policies  map[string]domain.BudgetPolicy
+	snapshots []map[string]domain.BudgetPolicy
+}
+
+func NewMemoryBudgetPolicyRepo() *MemoryBudgetPolicyRepo {
+	return &MemoryBudgetPolicyRepo{
+		policies: make(map[string]domain.BudgetPolicy),
+	}
+}
+
+func (r *MemoryBudgetPolicyRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.BudgetPolicy, len(r.policies))
+	for k, v := range r.policies {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryBudgetPolicyRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.policies = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryBudgetPolicyRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryBudgetPolicyRepo) Create(ctx context.Context, policy *domain.BudgetPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+func (r *MemoryBudgetPolicyRepo) Update(ctx context.Context, policy *domain.BudgetPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[policy.ID]; !ok {
+		return errors.New("budget policy not found")
+	}
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+func (r *MemoryBudgetPolicyRepo) GetByCostCenter(ctx context.Context, costCenterID string) (*domain.BudgetPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.policies {
+		if p.CostCenterID == costCenterID {
+			return &p, nil
+		}
+	}
+	return nil, errors.New("budget policy not found")
+}
+
+func (r *MemoryBudgetPolicyRepo) List(ctx context.Context) ([]domain.BudgetPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.BudgetPolicy, 0, len(r.policies))
+	for _, p := range r.policies {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CostCenterID < list[j].CostCenterID })
+	return list, nil
+}
+
 // MemoryApVendorBillRepo implements domain.ApVendorBillRepository in-memory
 type MemoryApVendorBillRepo struct {
 	mu        sync.RWMutex
@@ -578,351 +898,2384 @@ func (r *MemoryApVendorBillRepo) List(ctx context.Context) ([]domain.ApVendorBil
 	return list, nil
 }
 
-// MemoryTaxRateRepo implements domain.TaxRateRepository
-type MemoryTaxRateRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.TaxRate
+// MemoryApVendorBillLineRepo implements domain.ApVendorBillLineRepository
+type MemoryApVendorBillLineRepo struct {
+	mu        sync.RWMutex
+	lines     map[string][]domain.ApVendorBillLine
+	snapshots []map[string][]domain.ApVendorBillLine
 }
 
-func NewMemoryTaxRateRepo() *MemoryTaxRateRepo {
-	return &MemoryTaxRateRepo{
-		data: make(map[string]domain.TaxRate),
+func NewMemoryApVendorBillLineRepo() *MemoryApVendorBillLineRepo {
+	return &MemoryApVendorBillLineRepo{
+		lines: make(map[string][]domain.ApVendorBillLine),
 	}
 }
 
-func (r *MemoryTaxRateRepo) Create(ctx context.Context, tr *domain.TaxRate) error {
+func (r *MemoryApVendorBillLineRepo) TakeSnapshot() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.data[tr.ID] = *tr
-	return nil
-}
-
-func (r *MemoryTaxRateRepo) GetByID(ctx context.Context, id string) (*domain.TaxRate, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	tr, ok := r.data[id]
-	if !ok {
-		return nil, errors.New("tax rate not found")
+	snap := make(map[string][]domain.ApVendorBillLine, len(r.lines))
+	for k, v := range r.lines {
+		snap[k] = v
 	}
-	return &tr, nil
+	r.snapshots = append(r.snapshots, snap)
 }
 
-func (r *MemoryTaxRateRepo) List(ctx context.Context) ([]domain.TaxRate, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	list := make([]domain.TaxRate, 0, len(r.data))
-	for _, tr := range r.data {
-		list = append(list, tr)
+func (r *MemoryApVendorBillLineRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
 	}
-	return list, nil
-}
-
-// MemoryCurrencyRateRepo implements domain.CurrencyRateRepository in-memory
-type MemoryCurrencyRateRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.CurrencyRate
+	r.lines = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
 }
 
-func NewMemoryCurrencyRateRepo() *MemoryCurrencyRateRepo {
-	return &MemoryCurrencyRateRepo{
-		data: make(map[string]domain.CurrencyRate),
+func (r *MemoryApVendorBillLineRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
 	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
 }
 
-func (r *MemoryCurrencyRateRepo) Create(ctx context.Context, rate *domain.CurrencyRate) error {
+func (r *MemoryApVendorBillLineRepo) ReplaceForBill(ctx context.Context, billID string, lines []domain.ApVendorBillLine) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.data[rate.ID] = *rate
+	r.lines[billID] = append([]domain.ApVendorBillLine(nil), lines...)
 	return nil
 }
 
-func (r *MemoryCurrencyRateRepo) GetByID(ctx context.Context, id string) (*domain.CurrencyRate, error) {
+func (r *MemoryApVendorBillLineRepo) ListByBill(ctx context.Context, billID string) ([]domain.ApVendorBillLine, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	rate, ok := r.data[id]
-	if !ok {
-		return nil, errors.New("currency rate not found")
+	return append([]domain.ApVendorBillLine(nil), r.lines[billID]...), nil
+}
+
+// MemoryPurchaseOrderLineRepo implements domain.PurchaseOrderLineRepository
+type MemoryPurchaseOrderLineRepo struct {
+	mu        sync.RWMutex
+	lines     map[string][]domain.PurchaseOrderLine
+	snapshots []map[string][]domain.PurchaseOrderLine
+}
+
+func NewMemoryPurchaseOrderLineRepo() *MemoryPurchaseOrderLineRepo {
+	return &MemoryPurchaseOrderLineRepo{
+		lines: make(map[string][]domain.PurchaseOrderLine),
 	}
-	return &rate, nil
 }
 
-func (r *MemoryCurrencyRateRepo) List(ctx context.Context) ([]domain.CurrencyRate, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	list := make([]domain.CurrencyRate, 0, len(r.data))
+func (r *MemoryPurchaseOrderLineRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string][]domain.PurchaseOrderLine, len(r.lines))
+	for k, v := range r.lines {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryPurchaseOrderLineRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.lines = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPurchaseOrderLineRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPurchaseOrderLineRepo) ReplaceForPurchaseOrder(ctx context.Context, purchaseOrderID string, lines []domain.PurchaseOrderLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines[purchaseOrderID] = append([]domain.PurchaseOrderLine(nil), lines...)
+	return nil
+}
+
+func (r *MemoryPurchaseOrderLineRepo) ListByPurchaseOrder(ctx context.Context, purchaseOrderID string) ([]domain.PurchaseOrderLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.PurchaseOrderLine(nil), r.lines[purchaseOrderID]...), nil
+}
+
+// MemoryGoodsReceiptLineRepo implements domain.GoodsReceiptLineRepository
+type MemoryGoodsReceiptLineRepo struct {
+	mu        sync.RWMutex
+	lines     []domain.GoodsReceiptLine
+	snapshots [][]domain.GoodsReceiptLine
+}
+
+func NewMemoryGoodsReceiptLineRepo() *MemoryGoodsReceiptLineRepo {
+	return &MemoryGoodsReceiptLineRepo{}
+}
+
+func (r *MemoryGoodsReceiptLineRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots = append(r.snapshots, append([]domain.GoodsReceiptLine(nil), r.lines...))
+}
+
+func (r *MemoryGoodsReceiptLineRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.lines = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryGoodsReceiptLineRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryGoodsReceiptLineRepo) CreateMany(ctx context.Context, lines []domain.GoodsReceiptLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, lines...)
+	return nil
+}
+
+func (r *MemoryGoodsReceiptLineRepo) ListByPurchaseOrder(ctx context.Context, purchaseOrderID string) ([]domain.GoodsReceiptLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.GoodsReceiptLine
+	for _, l := range r.lines {
+		if l.PurchaseOrderID == purchaseOrderID {
+			list = append(list, l)
+		}
+	}
+	return list, nil
+}
+
+// MemoryMatchToleranceRepo implements domain.MatchToleranceRepository
+type MemoryMatchToleranceRepo struct {
+	mu         sync.RWMutex
+	tolerances map[string]domain.MatchTolerance
+}
+
+func NewMemoryMatchToleranceRepo() *MemoryMatchToleranceRepo {
+	return &MemoryMatchToleranceRepo{
+		tolerances: make(map[string]domain.MatchTolerance),
+	}
+}
+
+func (r *MemoryMatchToleranceRepo) Create(ctx context.Context, tolerance *domain.MatchTolerance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tolerances[tolerance.ID] = *tolerance
+	return nil
+}
+
+func (r *MemoryMatchToleranceRepo) Update(ctx context.Context, tolerance *domain.MatchTolerance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tolerances[tolerance.ID]; !ok {
+		return errors.New("match tolerance not found")
+	}
+	r.tolerances[tolerance.ID] = *tolerance
+	return nil
+}
+
+func (r *MemoryMatchToleranceRepo) GetByVendor(ctx context.Context, vendorID string) (*domain.MatchTolerance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tolerances {
+		if t.VendorID == vendorID {
+			return &t, nil
+		}
+	}
+	return nil, errors.New("match tolerance not found")
+}
+
+func (r *MemoryMatchToleranceRepo) List(ctx context.Context) ([]domain.MatchTolerance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.MatchTolerance, 0, len(r.tolerances))
+	for _, t := range r.tolerances {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].VendorID < list[j].VendorID })
+	return list, nil
+}
+
+// MemoryPaymentAllocationRepo implements domain.PaymentAllocationRepository
+type MemoryPaymentAllocationRepo struct {
+	mu          sync.RWMutex
+	allocations []domain.PaymentAllocation
+	snapshots   [][]domain.PaymentAllocation
+}
+
+func NewMemoryPaymentAllocationRepo() *MemoryPaymentAllocationRepo {
+	return &MemoryPaymentAllocationRepo{}
+}
+
+func (r *MemoryPaymentAllocationRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots = append(r.snapshots, append([]domain.PaymentAllocation(nil), r.allocations...))
+}
+
+func (r *MemoryPaymentAllocationRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.allocations = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentAllocationRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentAllocationRepo) CreateMany(ctx context.Context, allocations []domain.PaymentAllocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.allocations = append(r.allocations, allocations...)
+	return nil
+}
+
+func (r *MemoryPaymentAllocationRepo) ListBySource(ctx context.Context, sourceID string) ([]domain.PaymentAllocation, error) {
+	return r.filter(func(a domain.PaymentAllocation) bool { return a.SourceID == sourceID }), nil
+}
+
+func (r *MemoryPaymentAllocationRepo) ListByInvoice(ctx context.Context, invoiceID string) ([]domain.PaymentAllocation, error) {
+	return r.filter(func(a domain.PaymentAllocation) bool { return a.InvoiceID != nil && *a.InvoiceID == invoiceID }), nil
+}
+
+func (r *MemoryPaymentAllocationRepo) ListByBill(ctx context.Context, billID string) ([]domain.PaymentAllocation, error) {
+	return r.filter(func(a domain.PaymentAllocation) bool { return a.BillID != nil && *a.BillID == billID }), nil
+}
+
+func (r *MemoryPaymentAllocationRepo) filter(keep func(domain.PaymentAllocation) bool) []domain.PaymentAllocation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.PaymentAllocation, 0)
+	for _, a := range r.allocations {
+		if keep(a) {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// MemoryArCreditMemoRepo implements domain.ArCreditMemoRepository
+type MemoryArCreditMemoRepo struct {
+	mu        sync.RWMutex
+	memos     map[string]domain.ArCreditMemo
+	snapshots []map[string]domain.ArCreditMemo
+}
+
+func NewMemoryArCreditMemoRepo() *MemoryArCreditMemoRepo {
+	return &MemoryArCreditMemoRepo{
+		memos: make(map[string]domain.ArCreditMemo),
+	}
+}
+
+func (r *MemoryArCreditMemoRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.ArCreditMemo, len(r.memos))
+	for k, v := range r.memos {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryArCreditMemoRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.memos = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryArCreditMemoRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryArCreditMemoRepo) Create(ctx context.Context, memo *domain.ArCreditMemo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.memos[memo.ID] = *memo
+	return nil
+}
+
+func (r *MemoryArCreditMemoRepo) GetByID(ctx context.Context, id string) (*domain.ArCreditMemo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	memo, ok := r.memos[id]
+	if !ok {
+		return nil, errors.New("credit memo not found")
+	}
+	return &memo, nil
+}
+
+func (r *MemoryArCreditMemoRepo) List(ctx context.Context) ([]domain.ArCreditMemo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.ArCreditMemo, 0, len(r.memos))
+	for _, m := range r.memos {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryOnAccountCreditRepo implements domain.OnAccountCreditRepository
+type MemoryOnAccountCreditRepo struct {
+	mu        sync.RWMutex
+	credits   map[string]domain.OnAccountCredit
+	snapshots []map[string]domain.OnAccountCredit
+}
+
+func NewMemoryOnAccountCreditRepo() *MemoryOnAccountCreditRepo {
+	return &MemoryOnAccountCreditRepo{
+		credits: make(map[string]domain.OnAccountCredit),
+	}
+}
+
+func (r *MemoryOnAccountCreditRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.OnAccountCredit, len(r.credits))
+	for k, v := range r.credits {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryOnAccountCreditRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.credits = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryOnAccountCreditRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryOnAccountCreditRepo) Create(ctx context.Context, credit *domain.OnAccountCredit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.credits[credit.ID] = *credit
+	return nil
+}
+
+func (r *MemoryOnAccountCreditRepo) GetByID(ctx context.Context, id string) (*domain.OnAccountCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	credit, ok := r.credits[id]
+	if !ok {
+		return nil, errors.New("on-account credit not found")
+	}
+	return &credit, nil
+}
+
+func (r *MemoryOnAccountCreditRepo) Update(ctx context.Context, credit *domain.OnAccountCredit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.credits[credit.ID]; !ok {
+		return errors.New("on-account credit not found")
+	}
+	r.credits[credit.ID] = *credit
+	return nil
+}
+
+func (r *MemoryOnAccountCreditRepo) List(ctx context.Context) ([]domain.OnAccountCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.OnAccountCredit, 0, len(r.credits))
+	for _, c := range r.credits {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryDunningLevelRepo implements domain.DunningLevelRepository
+type MemoryDunningLevelRepo struct {
+	mu     sync.RWMutex
+	levels map[string]domain.DunningLevel
+}
+
+func NewMemoryDunningLevelRepo() *MemoryDunningLevelRepo {
+	return &MemoryDunningLevelRepo{
+		levels: make(map[string]domain.DunningLevel),
+	}
+}
+
+func (r *MemoryDunningLevelRepo) Create(ctx context.Context, level *domain.DunningLevel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels[level.ID] = *level
+	return nil
+}
+
+func (r *MemoryDunningLevelRepo) Update(ctx context.Context, level *domain.DunningLevel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.levels[level.ID]; !ok {
+		return errors.New("dunning level not found")
+	}
+	r.levels[level.ID] = *level
+	return nil
+}
+
+func (r *MemoryDunningLevelRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.levels, id)
+	return nil
+}
+
+func (r *MemoryDunningLevelRepo) GetByLevel(ctx context.Context, legalEntityID string, level int) (*domain.DunningLevel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, l := range r.levels {
+		if l.LegalEntityID == legalEntityID && l.Level == level {
+			return &l, nil
+		}
+	}
+	return nil, errors.New("dunning level not found")
+}
+
+func (r *MemoryDunningLevelRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.DunningLevel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.DunningLevel
+	for _, l := range r.levels {
+		if l.LegalEntityID == legalEntityID {
+			list = append(list, l)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Level < list[j].Level })
+	return list, nil
+}
+
+// MemoryDunningRunRepo implements domain.DunningRunRepository
+type MemoryDunningRunRepo struct {
+	mu        sync.RWMutex
+	runs      map[string]domain.DunningRun
+	snapshots []map[string]domain.DunningRun
+}
+
+func NewMemoryDunningRunRepo() *MemoryDunningRunRepo {
+	return &MemoryDunningRunRepo{
+		runs: make(map[string]domain.DunningRun),
+	}
+}
+
+func (r *MemoryDunningRunRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.DunningRun, len(r.runs))
+	for k, v := range r.runs {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryDunningRunRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.runs = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryDunningRunRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryDunningRunRepo) Create(ctx context.Context, run *domain.DunningRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *MemoryDunningRunRepo) GetByID(ctx context.Context, id string) (*domain.DunningRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, errors.New("dunning run not found")
+	}
+	return &run, nil
+}
+
+func (r *MemoryDunningRunRepo) List(ctx context.Context) ([]domain.DunningRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.DunningRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		list = append(list, run)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryDunningNoticeRepo implements domain.DunningNoticeRepository
+type MemoryDunningNoticeRepo struct {
+	mu        sync.RWMutex
+	notices   []domain.DunningNotice
+	snapshots [][]domain.DunningNotice
+}
+
+func NewMemoryDunningNoticeRepo() *MemoryDunningNoticeRepo {
+	return &MemoryDunningNoticeRepo{}
+}
+
+func (r *MemoryDunningNoticeRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots = append(r.snapshots, append([]domain.DunningNotice(nil), r.notices...))
+}
+
+func (r *MemoryDunningNoticeRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.notices = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryDunningNoticeRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryDunningNoticeRepo) CreateMany(ctx context.Context, notices []domain.DunningNotice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notices = append(r.notices, notices...)
+	return nil
+}
+
+func (r *MemoryDunningNoticeRepo) ListByRun(ctx context.Context, runID string) ([]domain.DunningNotice, error) {
+	return r.filter(func(n domain.DunningNotice) bool { return n.RunID == runID }), nil
+}
+
+func (r *MemoryDunningNoticeRepo) ListByInvoice(ctx context.Context, invoiceID string) ([]domain.DunningNotice, error) {
+	return r.filter(func(n domain.DunningNotice) bool { return n.InvoiceID == invoiceID }), nil
+}
+
+func (r *MemoryDunningNoticeRepo) filter(keep func(domain.DunningNotice) bool) []domain.DunningNotice {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := []domain.DunningNotice{}
+	for _, n := range r.notices {
+		if keep(n) {
+			list = append(list, n)
+		}
+	}
+	return list
+}
+
+// MemoryPaymentRunRepo implements domain.PaymentRunRepository
+type MemoryPaymentRunRepo struct {
+	mu        sync.RWMutex
+	runs      map[string]domain.PaymentRun
+	snapshots []map[string]domain.PaymentRun
+}
+
+func NewMemoryPaymentRunRepo() *MemoryPaymentRunRepo {
+	return &MemoryPaymentRunRepo{
+		runs: make(map[string]domain.PaymentRun),
+	}
+}
+
+func (r *MemoryPaymentRunRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.PaymentRun, len(r.runs))
+	for k, v := range r.runs {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryPaymentRunRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.runs = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentRunRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentRunRepo) Create(ctx context.Context, run *domain.PaymentRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *MemoryPaymentRunRepo) Update(ctx context.Context, run *domain.PaymentRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.runs[run.ID]; !ok {
+		return errors.New("payment run not found")
+	}
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *MemoryPaymentRunRepo) GetByID(ctx context.Context, id string) (*domain.PaymentRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, errors.New("payment run not found")
+	}
+	return &run, nil
+}
+
+func (r *MemoryPaymentRunRepo) List(ctx context.Context) ([]domain.PaymentRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.PaymentRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		list = append(list, run)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryPaymentRunLineRepo implements domain.PaymentRunLineRepository
+type MemoryPaymentRunLineRepo struct {
+	mu        sync.RWMutex
+	lines     []domain.PaymentRunLine
+	snapshots [][]domain.PaymentRunLine
+}
+
+func NewMemoryPaymentRunLineRepo() *MemoryPaymentRunLineRepo {
+	return &MemoryPaymentRunLineRepo{}
+}
+
+func (r *MemoryPaymentRunLineRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots = append(r.snapshots, append([]domain.PaymentRunLine(nil), r.lines...))
+}
+
+func (r *MemoryPaymentRunLineRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.lines = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentRunLineRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentRunLineRepo) CreateMany(ctx context.Context, lines []domain.PaymentRunLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, lines...)
+	return nil
+}
+
+func (r *MemoryPaymentRunLineRepo) Update(ctx context.Context, line *domain.PaymentRunLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.lines {
+		if r.lines[i].ID == line.ID {
+			r.lines[i] = *line
+			return nil
+		}
+	}
+	return errors.New("payment run line not found")
+}
+
+func (r *MemoryPaymentRunLineRepo) ListByRun(ctx context.Context, runID string) ([]domain.PaymentRunLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := []domain.PaymentRunLine{}
+	for _, l := range r.lines {
+		if l.RunID == runID {
+			list = append(list, l)
+		}
+	}
+	return list, nil
+}
+
+// MemoryPaymentFileRepo implements domain.PaymentFileRepository
+type MemoryPaymentFileRepo struct {
+	mu        sync.RWMutex
+	files     map[string]domain.PaymentFile
+	snapshots []map[string]domain.PaymentFile
+}
+
+func NewMemoryPaymentFileRepo() *MemoryPaymentFileRepo {
+	return &MemoryPaymentFileRepo{
+		files: make(map[string]domain.PaymentFile),
+	}
+}
+
+func (r *MemoryPaymentFileRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.PaymentFile, len(r.files))
+	for k, v := range r.files {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryPaymentFileRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.files = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentFileRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryPaymentFileRepo) Create(ctx context.Context, file *domain.PaymentFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[file.ID] = *file
+	return nil
+}
+
+func (r *MemoryPaymentFileRepo) GetByID(ctx context.Context, id string) (*domain.PaymentFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	file, ok := r.files[id]
+	if !ok {
+		return nil, errors.New("payment file not found")
+	}
+	return &file, nil
+}
+
+func (r *MemoryPaymentFileRepo) ListByRun(ctx context.Context, runID string) ([]domain.PaymentFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := []domain.PaymentFile{}
+	for _, f := range r.files {
+		if f.RunID == runID {
+			list = append(list, f)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FileName < list[j].FileName })
+	return list, nil
+}
+
+// MemoryVendorBankAccountRepo implements domain.VendorBankAccountRepository
+type MemoryVendorBankAccountRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.VendorBankAccount
+}
+
+func NewMemoryVendorBankAccountRepo() *MemoryVendorBankAccountRepo {
+	return &MemoryVendorBankAccountRepo{
+		data: make(map[string]domain.VendorBankAccount),
+	}
+}
+
+func (r *MemoryVendorBankAccountRepo) Create(ctx context.Context, account *domain.VendorBankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[account.ID] = *account
+	return nil
+}
+
+func (r *MemoryVendorBankAccountRepo) Update(ctx context.Context, account *domain.VendorBankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.data[account.ID]; !ok {
+		return errors.New("vendor bank account not found")
+	}
+	r.data[account.ID] = *account
+	return nil
+}
+
+func (r *MemoryVendorBankAccountRepo) GetByVendorID(ctx context.Context, vendorID string) (*domain.VendorBankAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range r.data {
+		if a.VendorID == vendorID {
+			return &a, nil
+		}
+	}
+	return nil, errors.New("vendor bank account not found")
+}
+
+// MemoryTaxRateRepo implements domain.TaxRateRepository
+type MemoryTaxRateRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.TaxRate
+}
+
+func NewMemoryTaxRateRepo() *MemoryTaxRateRepo {
+	return &MemoryTaxRateRepo{
+		data: make(map[string]domain.TaxRate),
+	}
+}
+
+func (r *MemoryTaxRateRepo) Create(ctx context.Context, tr *domain.TaxRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[tr.ID] = *tr
+	return nil
+}
+
+func (r *MemoryTaxRateRepo) GetByID(ctx context.Context, id string) (*domain.TaxRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tr, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("tax rate not found")
+	}
+	return &tr, nil
+}
+
+func (r *MemoryTaxRateRepo) List(ctx context.Context) ([]domain.TaxRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.TaxRate, 0, len(r.data))
+	for _, tr := range r.data {
+		list = append(list, tr)
+	}
+	return list, nil
+}
+
+// MemoryTaxJurisdictionRepo implements domain.TaxJurisdictionRepository
+type MemoryTaxJurisdictionRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.TaxJurisdiction
+}
+
+func NewMemoryTaxJurisdictionRepo() *MemoryTaxJurisdictionRepo {
+	return &MemoryTaxJurisdictionRepo{
+		data: make(map[string]domain.TaxJurisdiction),
+	}
+}
+
+func (r *MemoryTaxJurisdictionRepo) Create(ctx context.Context, j *domain.TaxJurisdiction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.data {
+		if existing.Code == j.Code {
+			return errors.New("tax jurisdiction code already exists")
+		}
+	}
+	r.data[j.ID] = *j
+	return nil
+}
+
+func (r *MemoryTaxJurisdictionRepo) GetByID(ctx context.Context, id string) (*domain.TaxJurisdiction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	j, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("tax jurisdiction not found")
+	}
+	return &j, nil
+}
+
+func (r *MemoryTaxJurisdictionRepo) GetByCode(ctx context.Context, code string) (*domain.TaxJurisdiction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, j := range r.data {
+		if j.Code == code {
+			return &j, nil
+		}
+	}
+	return nil, errors.New("tax jurisdiction not found")
+}
+
+func (r *MemoryTaxJurisdictionRepo) List(ctx context.Context) ([]domain.TaxJurisdiction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.TaxJurisdiction, 0, len(r.data))
+	for _, j := range r.data {
+		list = append(list, j)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list, nil
+}
+
+// MemoryTaxExemptionRepo implements domain.TaxExemptionRepository
+type MemoryTaxExemptionRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.TaxExemption
+}
+
+func NewMemoryTaxExemptionRepo() *MemoryTaxExemptionRepo {
+	return &MemoryTaxExemptionRepo{
+		data: make(map[string]domain.TaxExemption),
+	}
+}
+
+func (r *MemoryTaxExemptionRepo) Create(ctx context.Context, e *domain.TaxExemption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[e.ID] = *e
+	return nil
+}
+
+func (r *MemoryTaxExemptionRepo) ListByCustomer(ctx context.Context, customerID string) ([]domain.TaxExemption, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.TaxExemption
+	for _, e := range r.data {
+		if e.CustomerID == customerID {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ValidFrom.Before(list[j].ValidFrom) })
+	return list, nil
+}
+
+// MemoryTaxTransactionRepo implements domain.TaxTransactionRepository
+type MemoryTaxTransactionRepo struct {
+	mu           sync.RWMutex
+	transactions map[string]domain.TaxTransaction
+	snapshots    []map[string]domain.TaxTransaction
+}
+
+func NewMemoryTaxTransactionRepo() *MemoryTaxTransactionRepo {
+	return &MemoryTaxTransactionRepo{
+		transactions: make(map[string]domain.TaxTransaction),
+	}
+}
+
+func (r *MemoryTaxTransactionRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.TaxTransaction, len(r.transactions))
+	for k, v := range r.transactions {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryTaxTransactionRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.transactions = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryTaxTransactionRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryTaxTransactionRepo) CreateMany(ctx context.Context, txs []domain.TaxTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, tx := range txs {
+		r.transactions[tx.ID] = tx
+	}
+	return nil
+}
+
+func (r *MemoryTaxTransactionRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.TaxTransaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.TaxTransaction
+	for _, tx := range r.transactions {
+		if tx.LegalEntityID == legalEntityID {
+			list = append(list, tx)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PostingDate.Before(list[j].PostingDate) })
+	return list, nil
+}
+
+// MemoryCurrencyRateRepo implements domain.CurrencyRateRepository in-memory
+type MemoryCurrencyRateRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.CurrencyRate
+}
+
+func NewMemoryCurrencyRateRepo() *MemoryCurrencyRateRepo {
+	return &MemoryCurrencyRateRepo{
+		data: make(map[string]domain.CurrencyRate),
+	}
+}
+
+func (r *MemoryCurrencyRateRepo) Create(ctx context.Context, rate *domain.CurrencyRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[rate.ID] = *rate
+	return nil
+}
+
+func (r *MemoryCurrencyRateRepo) GetByID(ctx context.Context, id string) (*domain.CurrencyRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rate, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("currency rate not found")
+	}
+	return &rate, nil
+}
+
+func (r *MemoryCurrencyRateRepo) List(ctx context.Context) ([]domain.CurrencyRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.CurrencyRate, 0, len(r.data))
 	for _, rate := range r.data {
 		list = append(list, rate)
 	}
 	return list, nil
 }
 
-// MemoryFiscalYearRepo implements domain.FiscalYearRepository in-memory
-type MemoryFiscalYearRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.FiscalYear
+func (r *MemoryCurrencyRateRepo) GetEffective(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*domain.CurrencyRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var best *domain.CurrencyRate
+	for _, rate := range r.data {
+		if rate.FromCurrency != fromCurrency || rate.ToCurrency != toCurrency || rate.EffectiveDate.After(at) {
+			continue
+		}
+		if best == nil || rate.EffectiveDate.After(best.EffectiveDate) {
+			rate := rate
+			best = &rate
+		}
+	}
+	if best == nil {
+		return nil, errors.New("currency rate not found")
+	}
+	return best, nil
+}
+
+// MemoryFiscalYearRepo implements domain.FiscalYearRepository in-memory
+type MemoryFiscalYearRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.FiscalYear
+}
+
+func NewMemoryFiscalYearRepo() *MemoryFiscalYearRepo {
+	return &MemoryFiscalYearRepo{
+		data: make(map[string]domain.FiscalYear),
+	}
+}
+
+func (r *MemoryFiscalYearRepo) Create(ctx context.Context, fy *domain.FiscalYear) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[fy.ID] = *fy
+	return nil
+}
+
+func (r *MemoryFiscalYearRepo) GetByID(ctx context.Context, id string) (*domain.FiscalYear, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fy, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("fiscal year not found")
+	}
+	return &fy, nil
+}
+
+func (r *MemoryFiscalYearRepo) Update(ctx context.Context, fy *domain.FiscalYear) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[fy.ID] = *fy
+	return nil
+}
+
+func (r *MemoryFiscalYearRepo) List(ctx context.Context) ([]domain.FiscalYear, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.FiscalYear, 0, len(r.data))
+	for _, fy := range r.data {
+		list = append(list, fy)
+	}
+	return list, nil
+}
+
+// MemoryCostCenterRepo implements domain.CostCenterRepository in-memory
+type MemoryCostCenterRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.CostCenter
+}
+
+func NewMemoryCostCenterRepo() *MemoryCostCenterRepo {
+	return &MemoryCostCenterRepo{
+		data: make(map[string]domain.CostCenter),
+	}
+}
+
+func (r *MemoryCostCenterRepo) Create(ctx context.Context, cc *domain.CostCenter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[cc.ID] = *cc
+	return nil
+}
+
+func (r *MemoryCostCenterRepo) GetByID(ctx context.Context, id string) (*domain.CostCenter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cc, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("cost center not found")
+	}
+	return &cc, nil
+}
+
+func (r *MemoryCostCenterRepo) Update(ctx context.Context, cc *domain.CostCenter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[cc.ID] = *cc
+	return nil
+}
+
+func (r *MemoryCostCenterRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.data, id)
+	return nil
+}
+
+func (r *MemoryCostCenterRepo) List(ctx context.Context) ([]domain.CostCenter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.CostCenter, 0, len(r.data))
+	for _, cc := range r.data {
+		list = append(list, cc)
+	}
+	return list, nil
+}
+
+type allocationCycleRepoSnapshot struct {
+	cycles  map[string]domain.AllocationCycle
+	members map[string][]domain.AllocationCycleMember
+}
+
+// MemoryAllocationCycleRepo implements domain.AllocationCycleRepository in-memory
+type MemoryAllocationCycleRepo struct {
+	mu        sync.RWMutex
+	cycles    map[string]domain.AllocationCycle
+	members   map[string][]domain.AllocationCycleMember
+	snapshots []allocationCycleRepoSnapshot
+}
+
+func NewMemoryAllocationCycleRepo() *MemoryAllocationCycleRepo {
+	return &MemoryAllocationCycleRepo{
+		cycles:  make(map[string]domain.AllocationCycle),
+		members: make(map[string][]domain.AllocationCycleMember),
+	}
+}
+
+func (r *MemoryAllocationCycleRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snapCycles := make(map[string]domain.AllocationCycle, len(r.cycles))
+	for k, v := range r.cycles {
+		snapCycles[k] = v
+	}
+	snapMembers := make(map[string][]domain.AllocationCycleMember, len(r.members))
+	for k, v := range r.members {
+		snapMembers[k] = append([]domain.AllocationCycleMember(nil), v...)
+	}
+	r.snapshots = append(r.snapshots, allocationCycleRepoSnapshot{
+		cycles:  snapCycles,
+		members: snapMembers,
+	})
+	r.mu.Unlock()
+}
+
+func (r *MemoryAllocationCycleRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		snap := r.snapshots[len(r.snapshots)-1]
+		r.cycles = snap.cycles
+		r.members = snap.members
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryAllocationCycleRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryAllocationCycleRepo) Create(ctx context.Context, cycle *domain.AllocationCycle, members []domain.AllocationCycleMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cycles[cycle.ID] = *cycle
+	r.members[cycle.ID] = append([]domain.AllocationCycleMember(nil), members...)
+	return nil
+}
+
+func (r *MemoryAllocationCycleRepo) GetByID(ctx context.Context, id string) (*domain.AllocationCycle, []domain.AllocationCycleMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cycle, ok := r.cycles[id]
+	if !ok {
+		return nil, nil, errors.New("allocation cycle not found")
+	}
+	return &cycle, append([]domain.AllocationCycleMember(nil), r.members[id]...), nil
+}
+
+func (r *MemoryAllocationCycleRepo) Update(ctx context.Context, cycle *domain.AllocationCycle, members []domain.AllocationCycleMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cycles[cycle.ID]; !ok {
+		return errors.New("allocation cycle not found")
+	}
+	r.cycles[cycle.ID] = *cycle
+	r.members[cycle.ID] = append([]domain.AllocationCycleMember(nil), members...)
+	return nil
+}
+
+func (r *MemoryAllocationCycleRepo) List(ctx context.Context) ([]domain.AllocationCycle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.AllocationCycle, 0, len(r.cycles))
+	for _, cycle := range r.cycles {
+		list = append(list, cycle)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryAllocationRunRepo implements domain.AllocationRunRepository in-memory
+type MemoryAllocationRunRepo struct {
+	mu        sync.RWMutex
+	data      map[string]domain.AllocationRun
+	snapshots []map[string]domain.AllocationRun
+}
+
+func NewMemoryAllocationRunRepo() *MemoryAllocationRunRepo {
+	return &MemoryAllocationRunRepo{
+		data: make(map[string]domain.AllocationRun),
+	}
+}
+
+func (r *MemoryAllocationRunRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.AllocationRun, len(r.data))
+	for k, v := range r.data {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryAllocationRunRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.data = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryAllocationRunRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryAllocationRunRepo) Create(ctx context.Context, run *domain.AllocationRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[run.ID] = *run
+	return nil
+}
+
+func (r *MemoryAllocationRunRepo) GetByID(ctx context.Context, id string) (*domain.AllocationRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	run, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("allocation run not found")
+	}
+	return &run, nil
+}
+
+func (r *MemoryAllocationRunRepo) Update(ctx context.Context, run *domain.AllocationRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.data[run.ID]; !ok {
+		return errors.New("allocation run not found")
+	}
+	r.data[run.ID] = *run
+	return nil
+}
+
+func (r *MemoryAllocationRunRepo) ListByCycle(ctx context.Context, cycleID string) ([]domain.AllocationRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.AllocationRun
+	for _, run := range r.data {
+		if run.CycleID == cycleID {
+			list = append(list, run)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryStatisticalKeyFigureRepo implements domain.StatisticalKeyFigureRepository in-memory.
+// Values are keyed by cost center, driver and period so an upsert replaces the earlier value.
+type MemoryStatisticalKeyFigureRepo struct {
+	mu        sync.RWMutex
+	data      map[string]domain.StatisticalKeyFigure
+	snapshots []map[string]domain.StatisticalKeyFigure
+}
+
+func NewMemoryStatisticalKeyFigureRepo() *MemoryStatisticalKeyFigureRepo {
+	return &MemoryStatisticalKeyFigureRepo{
+		data: make(map[string]domain.StatisticalKeyFigure),
+	}
+}
+
+func (r *MemoryStatisticalKeyFigureRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.StatisticalKeyFigure, len(r.data))
+	for k, v := range r.data {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryStatisticalKeyFigureRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.data = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryStatisticalKeyFigureRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryStatisticalKeyFigureRepo) Upsert(ctx context.Context, kf *domain.StatisticalKeyFigure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := kf.CostCenterID + "|" + string(kf.Driver) + "|" + kf.FinancialPeriod
+	if existing, ok := r.data[key]; ok {
+		kf.ID = existing.ID
+	}
+	r.data[key] = *kf
+	return nil
+}
+
+func (r *MemoryStatisticalKeyFigureRepo) ListByPeriod(ctx context.Context, driver domain.AllocationDriver, period string) ([]domain.StatisticalKeyFigure, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.StatisticalKeyFigure
+	for _, kf := range r.data {
+		if kf.Driver == driver && kf.FinancialPeriod == period {
+			list = append(list, kf)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CostCenterID < list[j].CostCenterID })
+	return list, nil
+}
+
+// MemoryBankAccountRepo implements domain.BankAccountRepository in-memory
+type MemoryBankAccountRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.BankAccount
+}
+
+func NewMemoryBankAccountRepo() *MemoryBankAccountRepo {
+	return &MemoryBankAccountRepo{
+		data: make(map[string]domain.BankAccount),
+	}
+}
+
+func (r *MemoryBankAccountRepo) Create(ctx context.Context, ba *domain.BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ba.Version = 0
+	r.data[ba.ID] = *ba
+	return nil
+}
+
+func (r *MemoryBankAccountRepo) GetByID(ctx context.Context, id string) (*domain.BankAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ba, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("bank account not found")
+	}
+	return &ba, nil
+}
+
+func (r *MemoryBankAccountRepo) Update(ctx context.Context, ba *domain.BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.data[ba.ID]
+	if !ok {
+		return errors.New("bank account not found")
+	}
+	if ba.Version != current.Version {
+		return domain.ErrOptimisticLock
+	}
+	ba.Version++
+	r.data[ba.ID] = *ba
+	return nil
+}
+
+func (r *MemoryBankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.BankAccount, 0, len(r.data))
+	for _, ba := range r.data {
+		list = append(list, ba)
+	}
+	return list, nil
+}
+
+// MemoryCustomerCreditRepo implements domain.CustomerCreditRepository in-memory
+type MemoryCustomerCreditRepo struct {
+	mu   sync.RWMutex
+	data map[string]domain.CustomerCredit
+}
+
+func NewMemoryCustomerCreditRepo() *MemoryCustomerCreditRepo {
+	return &MemoryCustomerCreditRepo{
+		data: make(map[string]domain.CustomerCredit),
+	}
+}
+
+func (r *MemoryCustomerCreditRepo) Create(ctx context.Context, cc *domain.CustomerCredit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cc.Version = 0
+	r.data[cc.ID] = *cc
+	return nil
+}
+
+func (r *MemoryCustomerCreditRepo) GetByID(ctx context.Context, id string) (*domain.CustomerCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cc, ok := r.data[id]
+	if !ok {
+		return nil, errors.New("customer credit not found")
+	}
+	return &cc, nil
+}
+
+func (r *MemoryCustomerCreditRepo) GetByCustomerID(ctx context.Context, customerID string) (*domain.CustomerCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, cc := range r.data {
+		if cc.CustomerID == customerID {
+			return &cc, nil
+		}
+	}
+	return nil, errors.New("customer credit not found")
+}
+
+func (r *MemoryCustomerCreditRepo) Update(ctx context.Context, cc *domain.CustomerCredit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.data[cc.ID]
+	if !ok {
+		return errors.New("customer credit not found")
+	}
+	if cc.Version != current.Version {
+		return domain.ErrOptimisticLock
+	}
+	cc.Version++
+	r.data[cc.ID] = *cc
+	return nil
+}
+
+func (r *MemoryCustomerCreditRepo) List(ctx context.Context) ([]domain.CustomerCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.CustomerCredit, 0, len(r.data))
+	for _, cc := range r.data {
+		list = append(list, cc)
+	}
+	return list, nil
+}
+
+// MemorySalesOrderExposureRepo implements domain.SalesOrderExposureRepository in-memory
+type MemorySalesOrderExposureRepo struct {
+	mu        sync.RWMutex
+	data      map[string]domain.SalesOrderExposure
+	snapshots []map[string]domain.SalesOrderExposure
+}
+
+func NewMemorySalesOrderExposureRepo() *MemorySalesOrderExposureRepo {
+	return &MemorySalesOrderExposureRepo{
+		data: make(map[string]domain.SalesOrderExposure),
+	}
+}
+
+func (r *MemorySalesOrderExposureRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.SalesOrderExposure, len(r.data))
+	for k, v := range r.data {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemorySalesOrderExposureRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.data = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemorySalesOrderExposureRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemorySalesOrderExposureRepo) Create(ctx context.Context, e *domain.SalesOrderExposure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.data {
+		if existing.SalesOrderID == e.SalesOrderID {
+			return errors.New("sales order exposure already exists")
+		}
+	}
+	r.data[e.ID] = *e
+	return nil
+}
+
+func (r *MemorySalesOrderExposureRepo) Update(ctx context.Context, e *domain.SalesOrderExposure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.data[e.ID]; !ok {
+		return errors.New("sales order exposure not found")
+	}
+	r.data[e.ID] = *e
+	return nil
+}
+
+func (r *MemorySalesOrderExposureRepo) GetBySalesOrderID(ctx context.Context, salesOrderID string) (*domain.SalesOrderExposure, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, e := range r.data {
+		if e.SalesOrderID == salesOrderID {
+			return &e, nil
+		}
+	}
+	return nil, errors.New("sales order exposure not found")
+}
+
+func (r *MemorySalesOrderExposureRepo) ListOpenByCustomer(ctx context.Context, customerID string) ([]domain.SalesOrderExposure, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.SalesOrderExposure
+	for _, e := range r.data {
+		if e.CustomerID == customerID && e.Status == domain.SalesOrderExposureStatusOPEN {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ConfirmedAt.Before(list[j].ConfirmedAt) })
+	return list, nil
+}
+
+// MemoryCreditOverrideRepo implements domain.CreditOverrideRepository in-memory
+type MemoryCreditOverrideRepo struct {
+	mu        sync.RWMutex
+	data      map[string]domain.CreditOverride
+	snapshots []map[string]domain.CreditOverride
+}
+
+func NewMemoryCreditOverrideRepo() *MemoryCreditOverrideRepo {
+	return &MemoryCreditOverrideRepo{
+		data: make(map[string]domain.CreditOverride),
+	}
+}
+
+func (r *MemoryCreditOverrideRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.CreditOverride, len(r.data))
+	for k, v := range r.data {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryCreditOverrideRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.data = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryCreditOverrideRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryCreditOverrideRepo) Create(ctx context.Context, o *domain.CreditOverride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[o.ID] = *o
+	return nil
+}
+
+func (r *MemoryCreditOverrideRepo) Update(ctx context.Context, o *domain.CreditOverride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.data[o.ID]; !ok {
+		return errors.New("credit override not found")
+	}
+	r.data[o.ID] = *o
+	return nil
+}
+
+func (r *MemoryCreditOverrideRepo) ListByCustomer(ctx context.Context, customerID string) ([]domain.CreditOverride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.CreditOverride
+	for _, o := range r.data {
+		if o.CustomerID == customerID {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// MemoryBankStatementRepo implements domain.BankStatementRepository in-memory
+type MemoryBankStatementRepo struct {
+	mu    sync.RWMutex
+	data  map[string]domain.BankStatement
+	lines map[string][]domain.BankStatementLine
 }
 
-func NewMemoryFiscalYearRepo() *MemoryFiscalYearRepo {
-	return &MemoryFiscalYearRepo{
-		data: make(map[string]domain.FiscalYear),
+func NewMemoryBankStatementRepo() *MemoryBankStatementRepo {
+	return &MemoryBankStatementRepo{
+		data:  make(map[string]domain.BankStatement),
+		lines: make(map[string][]domain.BankStatementLine),
+	}
+}
+
+func (r *MemoryBankStatementRepo) Create(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[bs.ID] = *bs
+	r.lines[bs.ID] = lines
+	return nil
+}
+
+func (r *MemoryBankStatementRepo) GetByID(ctx context.Context, id string) (*domain.BankStatement, []domain.BankStatementLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bs, ok := r.data[id]
+	if !ok {
+		return nil, nil, errors.New("bank statement not found")
+	}
+	return &bs, r.lines[id], nil
+}
+
+func (r *MemoryBankStatementRepo) Update(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[bs.ID] = *bs
+	r.lines[bs.ID] = lines
+	return nil
+}
+
+func (r *MemoryBankStatementRepo) List(ctx context.Context) ([]domain.BankStatement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.BankStatement, 0, len(r.data))
+	for _, bs := range r.data {
+		list = append(list, bs)
+	}
+	return list, nil
+}
+
+func (r *MemoryBankStatementRepo) ExistsByReference(ctx context.Context, bankAccountID, reference string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, bs := range r.data {
+		if bs.BankAccountID == bankAccountID && bs.StatementReference == reference {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MemoryBankReconciliationMatchRepo implements domain.BankReconciliationMatchRepository in-memory
+type MemoryBankReconciliationMatchRepo struct {
+	mu        sync.RWMutex
+	matches   map[string]domain.BankReconciliationMatch
+	snapshots []map[string]domain.BankReconciliationMatch
+}
+
+func NewMemoryBankReconciliationMatchRepo() *MemoryBankReconciliationMatchRepo {
+	return &MemoryBankReconciliationMatchRepo{
+		matches: make(map[string]domain.BankReconciliationMatch),
+	}
+}
+
+func (r *MemoryBankReconciliationMatchRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.BankReconciliationMatch, len(r.matches))
+	for k, v := range r.matches {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryBankReconciliationMatchRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.matches = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryBankReconciliationMatchRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryBankReconciliationMatchRepo) CreateMany(ctx context.Context, matches []domain.BankReconciliationMatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range matches {
+		r.matches[m.ID] = m
+	}
+	return nil
+}
+
+func (r *MemoryBankReconciliationMatchRepo) ListByStatement(ctx context.Context, statementID string) ([]domain.BankReconciliationMatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.BankReconciliationMatch
+	for _, m := range r.matches {
+		if m.StatementID == statementID {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+func (r *MemoryBankReconciliationMatchRepo) DeleteByGroup(ctx context.Context, groupID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := false
+	for id, m := range r.matches {
+		if m.MatchGroupID == groupID {
+			delete(r.matches, id)
+			found = true
+		}
+	}
+	if !found {
+		return errors.New("reconciliation match not found")
+	}
+	return nil
+}
+
+func (r *MemoryBankReconciliationMatchRepo) List(ctx context.Context) ([]domain.BankReconciliationMatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.BankReconciliationMatch, 0, len(r.matches))
+	for _, m := range r.matches {
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// MemoryBankReconciliationExceptionRepo implements domain.BankReconciliationExceptionRepository in-memory
+type MemoryBankReconciliationExceptionRepo struct {
+	mu         sync.RWMutex
+	exceptions map[string]domain.BankReconciliationException
+	snapshots  []map[string]domain.BankReconciliationException
+}
+
+func NewMemoryBankReconciliationExceptionRepo() *MemoryBankReconciliationExceptionRepo {
+	return &MemoryBankReconciliationExceptionRepo{
+		exceptions: make(map[string]domain.BankReconciliationException),
+	}
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.BankReconciliationException, len(r.exceptions))
+	for k, v := range r.exceptions {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.exceptions = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) Create(ctx context.Context, exc *domain.BankReconciliationException) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exceptions[exc.ID] = *exc
+	return nil
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) GetByID(ctx context.Context, id string) (*domain.BankReconciliationException, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	exc, ok := r.exceptions[id]
+	if !ok {
+		return nil, errors.New("reconciliation exception not found")
+	}
+	return &exc, nil
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) Update(ctx context.Context, exc *domain.BankReconciliationException) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.exceptions[exc.ID]; !ok {
+		return errors.New("reconciliation exception not found")
+	}
+	r.exceptions[exc.ID] = *exc
+	return nil
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) ListByStatement(ctx context.Context, statementID string) ([]domain.BankReconciliationException, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.BankReconciliationException
+	for _, exc := range r.exceptions {
+		if exc.StatementID == statementID {
+			list = append(list, exc)
+		}
+	}
+	return list, nil
+}
+
+func (r *MemoryBankReconciliationExceptionRepo) List(ctx context.Context) ([]domain.BankReconciliationException, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]domain.BankReconciliationException, 0, len(r.exceptions))
+	for _, exc := range r.exceptions {
+		list = append(list, exc)
+	}
+	return list, nil
+}
+
+// MemoryPayrollRunSnapshotRepo implements domain.PayrollRunSnapshotRepository in-memory
+type MemoryPayrollRunSnapshotRepo struct {
+	mu        sync.RWMutex
+	runs      map[string]domain.PayrollRunSnapshot
+	snapshots []map[string]domain.PayrollRunSnapshot
+}
+
+func NewMemoryPayrollRunSnapshotRepo() *MemoryPayrollRunSnapshotRepo {
+	return &MemoryPayrollRunSnapshotRepo{
+		runs: make(map[string]domain.PayrollRunSnapshot),
+	}
+}
+
+func (r *MemoryPayrollRunSnapshotRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.PayrollRunSnapshot, len(r.runs))
+	for k, v := range r.runs {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryPayrollRunSnapshotRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.runs = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryPayrollRunSnapshotRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryPayrollRunSnapshotRepo) Upsert(ctx context.Context, snap *domain.PayrollRunSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[snap.ID] = *snap
+	return nil
+}
+
+func (r *MemoryPayrollRunSnapshotRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.PayrollRunSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.PayrollRunSnapshot
+	for _, run := range r.runs {
+		if run.LegalEntityID == legalEntityID {
+			list = append(list, run)
+		}
+	}
+	return list, nil
+}
+
+// MemoryRecurringCashItemRepo implements domain.RecurringCashItemRepository in-memory
+type MemoryRecurringCashItemRepo struct {
+	mu        sync.RWMutex
+	items     map[string]domain.RecurringCashItem
+	snapshots []map[string]domain.RecurringCashItem
+}
+
+func NewMemoryRecurringCashItemRepo() *MemoryRecurringCashItemRepo {
+	return &MemoryRecurringCashItemRepo{
+		items: make(map[string]domain.RecurringCashItem),
+	}
+}
+
+func (r *MemoryRecurringCashItemRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.RecurringCashItem, len(r.items))
+	for k, v := range r.items {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
+}
+
+func (r *MemoryRecurringCashItemRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.items = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryRecurringCashItemRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
 	}
+	r.mu.Unlock()
 }
 
-func (r *MemoryFiscalYearRepo) Create(ctx context.Context, fy *domain.FiscalYear) error {
+func (r *MemoryRecurringCashItemRepo) Create(ctx context.Context, item *domain.RecurringCashItem) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.data[fy.ID] = *fy
+	r.items[item.ID] = *item
 	return nil
 }
 
-func (r *MemoryFiscalYearRepo) GetByID(ctx context.Context, id string) (*domain.FiscalYear, error) {
+func (r *MemoryRecurringCashItemRepo) GetByID(ctx context.Context, id string) (*domain.RecurringCashItem, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	fy, ok := r.data[id]
+	item, ok := r.items[id]
 	if !ok {
-		return nil, errors.New("fiscal year not found")
+		return nil, errors.New("recurring cash item not found")
 	}
-	return &fy, nil
+	return &item, nil
 }
 
-func (r *MemoryFiscalYearRepo) Update(ctx context.Context, fy *domain.FiscalYear) error {
+func (r *MemoryRecurringCashItemRepo) Update(ctx context.Context, item *domain.RecurringCashItem) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.data[fy.ID] = *fy
+	r.items[item.ID] = *item
 	return nil
 }
 
-func (r *MemoryFiscalYearRepo) List(ctx context.Context) ([]domain.FiscalYear, error) {
+func (r *MemoryRecurringCashItemRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	return nil
+}
+
+func (r *MemoryRecurringCashItemRepo) List(ctx context.Context) ([]domain.RecurringCashItem, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	list := make([]domain.FiscalYear, 0, len(r.data))
-	for _, fy := range r.data {
-		list = append(list, fy)
+	list := make([]domain.RecurringCashItem, 0, len(r.items))
+	for _, item := range r.items {
+		list = append(list, item)
 	}
 	return list, nil
 }
 
-// MemoryCostCenterRepo implements domain.CostCenterRepository in-memory
-type MemoryCostCenterRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.CostCenter
+// MemoryFiscalPeriodRepo implements domain.FiscalPeriodRepository in-memory
+type MemoryFiscalPeriodRepo struct {
+	mu        sync.RWMutex
+	periods   map[string]domain.FiscalPeriod
+	snapshots []map[string]domain.FiscalPeriod
 }
 
-func NewMemoryCostCenterRepo() *MemoryCostCenterRepo {
-	return &MemoryCostCenterRepo{
-		data: make(map[string]domain.CostCenter),
+func NewMemoryFiscalPeriodRepo() *MemoryFiscalPeriodRepo {
+	return &MemoryFiscalPeriodRepo{
+		periods: make(map[string]domain.FiscalPeriod),
 	}
 }
 
-func (r *MemoryCostCenterRepo) Create(ctx context.Context, cc *domain.CostCenter) error {
+func (r *MemoryFiscalPeriodRepo) TakeSnapshot() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.data[cc.ID] = *cc
-	return nil
+	snap := make(map[string]domain.FiscalPeriod, len(r.periods))
+	for k, v := range r.periods {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
 }
 
-func (r *MemoryCostCenterRepo) GetByID(ctx context.Context, id string) (*domain.CostCenter, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	cc, ok := r.data[id]
-	if !ok {
-		return nil, errors.New("cost center not found")
+func (r *MemoryFiscalPeriodRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.periods = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
 	}
-	return &cc, nil
+	r.mu.Unlock()
 }
 
-func (r *MemoryCostCenterRepo) Update(ctx context.Context, cc *domain.CostCenter) error {
+func (r *MemoryFiscalPeriodRepo) CommitSnapshot() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.data[cc.ID] = *cc
-	return nil
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
 }
 
-func (r *MemoryCostCenterRepo) Delete(ctx context.Context, id string) error {
+func (r *MemoryFiscalPeriodRepo) Upsert(ctx context.Context, period *domain.FiscalPeriod) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	delete(r.data, id)
+	for id, existing := range r.periods {
+		if existing.LegalEntityID == period.LegalEntityID && existing.FinancialPeriod == period.FinancialPeriod && id != period.ID {
+			return errors.New("fiscal period already exists for legal entity")
+		}
+	}
+	r.periods[period.ID] = *period
 	return nil
 }
 
-func (r *MemoryCostCenterRepo) List(ctx context.Context) ([]domain.CostCenter, error) {
+func (r *MemoryFiscalPeriodRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.FiscalPeriod, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	list := make([]domain.CostCenter, 0, len(r.data))
-	for _, cc := range r.data {
-		list = append(list, cc)
+	var list []domain.FiscalPeriod
+	for _, p := range r.periods {
+		if p.LegalEntityID == legalEntityID {
+			list = append(list, p)
+		}
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FinancialPeriod < list[j].FinancialPeriod })
 	return list, nil
 }
 
-// MemoryBankAccountRepo implements domain.BankAccountRepository in-memory
-type MemoryBankAccountRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.BankAccount
+// MemoryIntercompanyTransactionRepo implements domain.IntercompanyTransactionRepository in-memory
+type MemoryIntercompanyTransactionRepo struct {
+	mu           sync.RWMutex
+	transactions map[string]domain.IntercompanyTransaction
+	snapshots    []map[string]domain.IntercompanyTransaction
 }
 
-func NewMemoryBankAccountRepo() *MemoryBankAccountRepo {
-	return &MemoryBankAccountRepo{
-		data: make(map[string]domain.BankAccount),
+func NewMemoryIntercompanyTransactionRepo() *MemoryIntercompanyTransactionRepo {
+	return &MemoryIntercompanyTransactionRepo{
+		transactions: make(map[string]domain.IntercompanyTransaction),
 	}
 }
 
-func (r *MemoryBankAccountRepo) Create(ctx context.Context, ba *domain.BankAccount) error {
+func (r *MemoryIntercompanyTransactionRepo) TakeSnapshot() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	ba.Version = 0
-	r.data[ba.ID] = *ba
-	return nil
+	snap := make(map[string]domain.IntercompanyTransaction, len(r.transactions))
+	for k, v := range r.transactions {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
 }
 
-func (r *MemoryBankAccountRepo) GetByID(ctx context.Context, id string) (*domain.BankAccount, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	ba, ok := r.data[id]
-	if !ok {
-		return nil, errors.New("bank account not found")
+func (r *MemoryIntercompanyTransactionRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.transactions = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
 	}
-	return &ba, nil
+	r.mu.Unlock()
 }
 
-func (r *MemoryBankAccountRepo) Update(ctx context.Context, ba *domain.BankAccount) error {
+func (r *MemoryIntercompanyTransactionRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
+}
+
+func (r *MemoryIntercompanyTransactionRepo) Create(ctx context.Context, tx *domain.IntercompanyTransaction) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	current, ok := r.data[ba.ID]
+	r.transactions[tx.ID] = *tx
+	return nil
+}
+
+func (r *MemoryIntercompanyTransactionRepo) GetByID(ctx context.Context, id string) (*domain.IntercompanyTransaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tx, ok := r.transactions[id]
 	if !ok {
-		return errors.New("bank account not found")
+		return nil, errors.New("intercompany transaction not found")
 	}
-	if ba.Version != current.Version {
-		return domain.ErrOptimisticLock
-	}
-	ba.Version++
-	r.data[ba.ID] = *ba
-	return nil
+	return &tx, nil
 }
 
-func (r *MemoryBankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error) {
+func (r *MemoryIntercompanyTransactionRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.IntercompanyTransaction, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	list := make([]domain.BankAccount, 0, len(r.data))
-	for _, ba := range r.data {
-		list = append(list, ba)
+	var list []domain.IntercompanyTransaction
+	for _, tx := range r.transactions {
+		if tx.FromLegalEntityID == legalEntityID || tx.ToLegalEntityID == legalEntityID {
+			list = append(list, tx)
+		}
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PostingDate.Before(list[j].PostingDate) })
 	return list, nil
 }
 
-// MemoryCustomerCreditRepo implements domain.CustomerCreditRepository in-memory
-type MemoryCustomerCreditRepo struct {
-	mu   sync.RWMutex
-	data map[string]domain.CustomerCredit
+// MemoryConsolidationGroupRepo implements domain.ConsolidationGroupRepository in-memory
+type MemoryConsolidationGroupRepo struct {
+	mu      sync.RWMutex
+	groups  map[string]domain.ConsolidationGroup
+	members map[string][]domain.ConsolidationGroupMember
 }
 
-func NewMemoryCustomerCreditRepo() *MemoryCustomerCreditRepo {
-	return &MemoryCustomerCreditRepo{
-		data: make(map[string]domain.CustomerCredit),
+func NewMemoryConsolidationGroupRepo() *MemoryConsolidationGroupRepo {
+	return &MemoryConsolidationGroupRepo{
+		groups:  make(map[string]domain.ConsolidationGroup),
+		members: make(map[string][]domain.ConsolidationGroupMember),
 	}
 }
 
-func (r *MemoryCustomerCreditRepo) Create(ctx context.Context, cc *domain.CustomerCredit) error {
+func (r *MemoryConsolidationGroupRepo) Create(ctx context.Context, group *domain.ConsolidationGroup, members []domain.ConsolidationGroupMember) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	cc.Version = 0
-	r.data[cc.ID] = *cc
+	for _, g := range r.groups {
+		if g.Code == group.Code {
+			return errors.New("consolidation group code already exists")
+		}
+	}
+	r.groups[group.ID] = *group
+	r.members[group.ID] = members
 	return nil
 }
 
-func (r *MemoryCustomerCreditRepo) GetByID(ctx context.Context, id string) (*domain.CustomerCredit, error) {
+func (r *MemoryConsolidationGroupRepo) GetByID(ctx context.Context, id string) (*domain.ConsolidationGroup, []domain.ConsolidationGroupMember, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	cc, ok := r.data[id]
+	g, ok := r.groups[id]
 	if !ok {
-		return nil, errors.New("customer credit not found")
+		return nil, nil, errors.New("consolidation group not found")
 	}
-	return &cc, nil
+	return &g, r.members[id], nil
 }
 
-func (r *MemoryCustomerCreditRepo) GetByCustomerID(ctx context.Context, customerID string) (*domain.CustomerCredit, error) {
+func (r *MemoryConsolidationGroupRepo) List(ctx context.Context) ([]domain.ConsolidationGroup, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	for _, cc := range r.data {
-		if cc.CustomerID == customerID {
-			return &cc, nil
-		}
+	list := make([]domain.ConsolidationGroup, 0, len(r.groups))
+	for _, g := range r.groups {
+		list = append(list, g)
 	}
-	return nil, errors.New("customer credit not found")
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list, nil
 }
 
-func (r *MemoryCustomerCreditRepo) Update(ctx context.Context, cc *domain.CustomerCredit) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	current, ok := r.data[cc.ID]
-	if !ok {
-		return errors.New("customer credit not found")
-	}
-	if cc.Version != current.Version {
-		return domain.ErrOptimisticLock
-	}
-	cc.Version++
-	r.data[cc.ID] = *cc
-	return nil
+// MemoryFxRevaluationRepo implements domain.FxRevaluationRepository in-memory
+type MemoryFxRevaluationRepo struct {
+	mu           sync.RWMutex
+	revaluations map[string]domain.FxRevaluation
+	snapshots    []map[string]domain.FxRevaluation
 }
 
-func (r *MemoryCustomerCreditRepo) List(ctx context.Context) ([]domain.CustomerCredit, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	list := make([]domain.CustomerCredit, 0, len(r.data))
-	for _, cc := range r.data {
-		list = append(list, cc)
+func NewMemoryFxRevaluationRepo() *MemoryFxRevaluationRepo {
+	return &MemoryFxRevaluationRepo{
+		revaluations: make(map[string]domain.FxRevaluation),
 	}
-	return list, nil
-}
-
-// MemoryBankStatementRepo implements domain.BankStatementRepository in-memory
-type MemoryBankStatementRepo struct {
-	mu    sync.RWMutex
-	data  map[string]domain.BankStatement
-	lines map[string][]domain.BankStatementLine
 }
 
-func NewMemoryBankStatementRepo() *MemoryBankStatementRepo {
-	return &MemoryBankStatementRepo{
-		data:  make(map[string]domain.BankStatement),
-		lines: make(map[string][]domain.BankStatementLine),
+func (r *MemoryFxRevaluationRepo) TakeSnapshot() {
+	r.mu.Lock()
+	snap := make(map[string]domain.FxRevaluation, len(r.revaluations))
+	for k, v := range r.revaluations {
+		snap[k] = v
 	}
+	r.snapshots = append(r.snapshots, snap)
+	r.mu.Unlock()
 }
 
-func (r *MemoryBankStatementRepo) Create(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+func (r *MemoryFxRevaluationRepo) RollbackSnapshot() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.data[bs.ID] = *bs
-	r.lines[bs.ID] = lines
-	return nil
+	if len(r.snapshots) > 0 {
+		r.revaluations = r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+	}
+	r.mu.Unlock()
 }
 
-func (r *MemoryBankStatementRepo) GetByID(ctx context.Context, id string) (*domain.BankStatement, []domain.BankStatementLine, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	bs, ok := r.data[id]
-	if !ok {
-		return nil, nil, errors.New("bank statement not found")
+func (r *MemoryFxRevaluationRepo) CommitSnapshot() {
+	r.mu.Lock()
+	if len(r.snapshots) > 0 {
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
 	}
-	return &bs, r.lines[id], nil
+	r.mu.Unlock()
 }
 
-func (r *MemoryBankStatementRepo) Update(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+func (r *MemoryFxRevaluationRepo) CreateMany(ctx context.Context, revaluations []domain.FxRevaluation) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.data[bs.ID] = *bs
-	r.lines[bs.ID] = lines
+	for _, rv := range revaluations {
+		for _, existing := range r.revaluations {
+			if existing.LegalEntityID == rv.LegalEntityID && existing.FinancialPeriod == rv.FinancialPeriod &&
+				existing.SourceType == rv.SourceType && existing.SourceID == rv.SourceID {
+				return errors.New("fx revaluation already exists for source and period")
+			}
+		}
+		r.revaluations[rv.ID] = rv
+	}
 	return nil
 }
 
-func (r *MemoryBankStatementRepo) List(ctx context.Context) ([]domain.BankStatement, error) {
+func (r *MemoryFxRevaluationRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.FxRevaluation, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	list := make([]domain.BankStatement, 0, len(r.data))
-	for _, bs := range r.data {
-		list = append(list, bs)
+	var list []domain.FxRevaluation
+	for _, rv := range r.revaluations {
+		if rv.LegalEntityID == legalEntityID {
+			list = append(list, rv)
+		}
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].RevaluationDate.Before(list[j].RevaluationDate) })
 	return list, nil
 }
 
@@ -1276,6 +3629,13 @@ func (r *MemoryDepreciationScheduleLineRepo) Update(ctx context.Context, line *d
 	return nil
 }
 
+func (r *MemoryDepreciationScheduleLineRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.lines, id)
+	return nil
+}
+
 func (r *MemoryDepreciationScheduleLineRepo) List(ctx context.Context) ([]domain.DepreciationScheduleLine, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -1286,6 +3646,68 @@ func (r *MemoryDepreciationScheduleLineRepo) List(ctx context.Context) ([]domain
 	return list, nil
 }
 
+// MemoryAssetUsageRecordRepo implements domain.AssetUsageRecordRepository
+type MemoryAssetUsageRecordRepo struct {
+	mu        sync.RWMutex
+	records   map[string]domain.AssetUsageRecord
+	snapshots []map[string]domain.AssetUsageRecord
+}
+
+func NewMemoryAssetUsageRecordRepo() *MemoryAssetUsageRecordRepo {
+	return &MemoryAssetUsageRecordRepo{
+		records: make(map[string]domain.AssetUsageRecord),
+	}
+}
+
+func (r *MemoryAssetUsageRecordRepo) TakeSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make(map[string]domain.AssetUsageRecord, len(r.records))
+	for k, v := range r.records {
+		snap[k] = v
+	}
+	r.snapshots = append(r.snapshots, snap)
+}
+
+func (r *MemoryAssetUsageRecordRepo) RollbackSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.records = r.snapshots[len(r.snapshots)-1]
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryAssetUsageRecordRepo) CommitSnapshot() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.snapshots) == 0 {
+		return
+	}
+	r.snapshots = r.snapshots[:len(r.snapshots)-1]
+}
+
+func (r *MemoryAssetUsageRecordRepo) Create(ctx context.Context, record *domain.AssetUsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.ID] = *record
+	return nil
+}
+
+func (r *MemoryAssetUsageRecordRepo) ListByAsset(ctx context.Context, assetID string) ([]domain.AssetUsageRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var list []domain.AssetUsageRecord
+	for _, rec := range r.records {
+		if rec.FixedAssetID == assetID {
+			list = append(list, rec)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UsageDate.Before(list[j].UsageDate) })
+	return list, nil
+}
+
 // MemoryKafkaEventInboxRepo implements domain.KafkaEventInboxRepository
 type MemoryKafkaEventInboxRepo struct {
 	mu        sync.RWMutex
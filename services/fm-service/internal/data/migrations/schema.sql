@@ -22,6 +22,15 @@ CREATE TABLE IF NOT EXISTS chart_of_accountss (
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS account_determinations (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    posting_key VARCHAR(255) NOT NULL,
+    account_code VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS universal_journal_entries (
     id UUID PRIMARY KEY NOT NULL,
     legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
@@ -30,10 +39,38 @@ CREATE TABLE IF NOT EXISTS universal_journal_entries (
     posting_date DATE NOT NULL,
     financial_period VARCHAR(255) NOT NULL,
     status VARCHAR(255) NOT NULL,
+    template_id UUID REFERENCES journal_templates(id),
+    reversal_date DATE,
+    reversal_of_id UUID REFERENCES universal_journal_entries(id),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS journal_templates (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    frequency VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    next_run_date DATE NOT NULL,
+    end_date DATE,
+    auto_reverse BOOLEAN NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    last_run_date DATE,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS journal_template_lines (
+    id UUID PRIMARY KEY NOT NULL,
+    template_id UUID NOT NULL REFERENCES journal_templates(id),
+    account_id UUID NOT NULL REFERENCES chart_of_accountss(id),
+    amount NUMERIC(15, 4) NOT NULL,
+    description TEXT NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS universal_journal_lines (
     id UUID PRIMARY KEY NOT NULL,
     journal_entry_id UUID NOT NULL REFERENCES universal_journal_entries(id),
@@ -41,6 +78,7 @@ CREATE TABLE IF NOT EXISTS universal_journal_lines (
     amount_functional NUMERIC(15, 4) NOT NULL,
     amount_transactional NUMERIC(15, 4) NOT NULL,
     currency_transactional VARCHAR(255) NOT NULL,
+    exchange_rate NUMERIC(15, 4) NOT NULL,
     tracking_dimensions VARCHAR(255) NOT NULL
 );
 
@@ -52,12 +90,30 @@ CREATE TABLE IF NOT EXISTS ar_invoices (
     sales_order_id UUID NOT NULL,
     total_amount NUMERIC(15, 4) NOT NULL,
     tax_amount NUMERIC(15, 4) NOT NULL,
+    amount_paid NUMERIC(15, 4) NOT NULL,
+    fees_charged NUMERIC(15, 4) NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    exchange_rate NUMERIC(15, 4) NOT NULL,
     due_date DATE NOT NULL,
     status VARCHAR(255) NOT NULL,
+    dunning_level VARCHAR(255) NOT NULL,
+    last_dunned_at TIMESTAMP,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS ar_credit_memos (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    credit_memo_number VARCHAR(255) NOT NULL,
+    customer_id UUID NOT NULL,
+    invoice_id UUID NOT NULL REFERENCES ar_invoices(id),
+    amount NUMERIC(15, 4) NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS ap_vendor_bills (
     id UUID PRIMARY KEY NOT NULL,
     legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
@@ -66,8 +122,55 @@ CREATE TABLE IF NOT EXISTS ap_vendor_bills (
     purchase_order_id UUID NOT NULL,
     total_amount NUMERIC(15, 4) NOT NULL,
     tax_amount NUMERIC(15, 4) NOT NULL,
+    amount_paid NUMERIC(15, 4) NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    exchange_rate NUMERIC(15, 4) NOT NULL,
     due_date DATE NOT NULL,
     status VARCHAR(255) NOT NULL,
+    match_status VARCHAR(255) NOT NULL,
+    payment_hold BOOLEAN NOT NULL,
+    override_reason VARCHAR(255),
+    overridden_by VARCHAR(255),
+    matched_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ap_vendor_bill_lines (
+    id UUID PRIMARY KEY NOT NULL,
+    bill_id UUID NOT NULL REFERENCES ap_vendor_bills(id),
+    material_id UUID NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL,
+    unit_price NUMERIC(15, 4) NOT NULL,
+    line_amount NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id UUID PRIMARY KEY NOT NULL,
+    purchase_order_id UUID NOT NULL,
+    vendor_id UUID NOT NULL,
+    material_id UUID NOT NULL,
+    quantity_ordered NUMERIC(15, 4) NOT NULL,
+    unit_price NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_lines (
+    id UUID PRIMARY KEY NOT NULL,
+    receipt_id UUID NOT NULL,
+    purchase_order_id UUID NOT NULL,
+    material_id UUID NOT NULL,
+    quantity_received NUMERIC(15, 4) NOT NULL,
+    received_date TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS match_tolerances (
+    id UUID PRIMARY KEY NOT NULL,
+    vendor_id UUID UNIQUE NOT NULL,
+    price_tolerance_percent NUMERIC(15, 4) NOT NULL,
+    quantity_tolerance_percent NUMERIC(15, 4) NOT NULL,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
@@ -82,10 +185,27 @@ CREATE TABLE IF NOT EXISTS capital_assets (
     useful_life_months VARCHAR(255) NOT NULL,
     capitalization_date DATE NOT NULL,
     status VARCHAR(255) NOT NULL,
+    depreciation_method VARCHAR(255) NOT NULL,
+    depreciation_convention VARCHAR(255) NOT NULL,
+    salvage_value NUMERIC(15, 4) NOT NULL,
+    declining_balance_rate NUMERIC(15, 4) NOT NULL,
+    estimated_total_units NUMERIC(15, 4) NOT NULL,
+    disposal_date DATE,
+    disposal_proceeds NUMERIC(15, 4) NOT NULL,
+    disposal_entry_id UUID REFERENCES universal_journal_entries(id),
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS asset_usage_records (
+    id UUID PRIMARY KEY NOT NULL,
+    fixed_asset_id UUID NOT NULL REFERENCES capital_assets(id),
+    usage_date DATE NOT NULL,
+    units NUMERIC(15, 4) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS depreciation_schedule_lines (
     id UUID PRIMARY KEY NOT NULL,
     fixed_asset_id UUID NOT NULL REFERENCES capital_assets(id),
@@ -101,8 +221,11 @@ CREATE TABLE IF NOT EXISTS bank_accounts (
     id UUID PRIMARY KEY NOT NULL,
     legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
     account_number VARCHAR(255) NOT NULL,
+    bic VARCHAR(255) NOT NULL,
+    routing_number VARCHAR(255) NOT NULL,
     currency VARCHAR(255) NOT NULL,
     liquid_balance NUMERIC(15, 4) NOT NULL,
+    gl_account_id UUID REFERENCES chart_of_accountss(id),
     version VARCHAR(255) NOT NULL,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
@@ -113,19 +236,147 @@ CREATE TABLE IF NOT EXISTS payments (
     invoice_id UUID REFERENCES ar_invoices(id),
     bill_id UUID REFERENCES ap_vendor_bills(id),
     bank_account_id UUID REFERENCES bank_accounts(id),
+    counterparty_type VARCHAR(255) NOT NULL,
+    counterparty_id UUID NOT NULL,
     payment_number VARCHAR(255) NOT NULL,
     payment_date TIMESTAMP NOT NULL,
     amount NUMERIC(15, 4) NOT NULL,
     payment_method VARCHAR(255) NOT NULL,
     status VARCHAR(255) NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    exchange_rate NUMERIC(15, 4) NOT NULL,
+    realized_fx_gain_loss NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payment_allocations (
+    id UUID PRIMARY KEY NOT NULL,
+    source_type VARCHAR(255) NOT NULL,
+    source_id UUID NOT NULL,
+    invoice_id UUID REFERENCES ar_invoices(id),
+    bill_id UUID REFERENCES ap_vendor_bills(id),
+    amount NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS on_account_credits (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    counterparty_type VARCHAR(255) NOT NULL,
+    counterparty_id UUID NOT NULL,
+    source_type VARCHAR(255) NOT NULL,
+    source_id UUID NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    original_amount NUMERIC(15, 4) NOT NULL,
+    remaining_amount NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS vendor_bank_accounts (
+    id UUID PRIMARY KEY NOT NULL,
+    vendor_id UUID UNIQUE NOT NULL,
+    account_name VARCHAR(255) NOT NULL,
+    iban VARCHAR(255) NOT NULL,
+    bic VARCHAR(255) NOT NULL,
+    routing_number VARCHAR(255) NOT NULL,
+    account_number VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payment_runs (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    payment_date DATE NOT NULL,
+    due_by DATE NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    bill_count VARCHAR(255) NOT NULL,
+    payment_count VARCHAR(255) NOT NULL,
+    proposed_by VARCHAR(255) NOT NULL,
+    approved_by VARCHAR(255),
+    executed_at TIMESTAMP,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS payment_run_lines (
+    id UUID PRIMARY KEY NOT NULL,
+    run_id UUID NOT NULL REFERENCES payment_runs(id),
+    bill_id UUID NOT NULL REFERENCES ap_vendor_bills(id),
+    bill_number VARCHAR(255) NOT NULL,
+    vendor_id UUID NOT NULL,
+    bank_account_id UUID NOT NULL REFERENCES bank_accounts(id),
+    currency VARCHAR(255) NOT NULL,
+    amount NUMERIC(15, 4) NOT NULL,
+    due_date DATE NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    exclusion_reason VARCHAR(255) NOT NULL,
+    payment_id UUID REFERENCES payments(id),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS payment_files (
+    id UUID PRIMARY KEY NOT NULL,
+    run_id UUID NOT NULL REFERENCES payment_runs(id),
+    bank_account_id UUID NOT NULL REFERENCES bank_accounts(id),
+    format VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content VARCHAR(255) NOT NULL,
+    payment_count VARCHAR(255) NOT NULL,
+    total_amount NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dunning_levels (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    level VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    days_overdue VARCHAR(255) NOT NULL,
+    fee_amount NUMERIC(15, 4) NOT NULL,
+    interest_rate_percent NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dunning_runs (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    as_of DATE NOT NULL,
+    invoices_evaluated VARCHAR(255) NOT NULL,
+    notices_issued VARCHAR(255) NOT NULL,
+    customers_on_hold VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dunning_notices (
+    id UUID PRIMARY KEY NOT NULL,
+    run_id UUID NOT NULL REFERENCES dunning_runs(id),
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    invoice_id UUID NOT NULL REFERENCES ar_invoices(id),
+    customer_id UUID NOT NULL,
+    level VARCHAR(255) NOT NULL,
+    days_overdue VARCHAR(255) NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    open_amount NUMERIC(15, 4) NOT NULL,
+    fee_amount NUMERIC(15, 4) NOT NULL,
+    interest_amount NUMERIC(15, 4) NOT NULL,
+    total_due NUMERIC(15, 4) NOT NULL,
+    is_final BOOLEAN NOT NULL,
+    journal_entry_id UUID REFERENCES universal_journal_entries(id),
+    created_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS bank_statements (
     id UUID PRIMARY KEY NOT NULL,
     bank_account_id UUID NOT NULL REFERENCES bank_accounts(id),
+    statement_reference VARCHAR(255) NOT NULL,
+    source_format VARCHAR(255) NOT NULL,
     statement_date TIMESTAMP NOT NULL,
+    opening_balance NUMERIC(15, 4) NOT NULL,
     ending_balance NUMERIC(15, 4) NOT NULL,
     is_reconciled BOOLEAN NOT NULL
 );
@@ -135,16 +386,253 @@ CREATE TABLE IF NOT EXISTS bank_statement_lines (
     statement_id UUID NOT NULL REFERENCES bank_statements(id),
     transaction_date TIMESTAMP NOT NULL,
     description TEXT NOT NULL,
+    reference VARCHAR(255) NOT NULL,
     amount NUMERIC(15, 4) NOT NULL,
     is_matched BOOLEAN NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS bank_reconciliation_matchs (
+    id UUID PRIMARY KEY NOT NULL,
+    statement_id UUID NOT NULL REFERENCES bank_statements(id),
+    match_group_id UUID NOT NULL,
+    statement_line_id UUID NOT NULL REFERENCES bank_statement_lines(id),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    matched_amount NUMERIC(15, 4) NOT NULL,
+    confidence_score NUMERIC(15, 4) NOT NULL,
+    match_type VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bank_reconciliation_exceptions (
+    id UUID PRIMARY KEY NOT NULL,
+    statement_id UUID NOT NULL REFERENCES bank_statements(id),
+    statement_line_id UUID NOT NULL REFERENCES bank_statement_lines(id),
+    reason VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    journal_entry_id UUID REFERENCES universal_journal_entries(id),
+    created_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payroll_run_snapshots (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL,
+    fiscal_year VARCHAR(255) NOT NULL,
+    period_number VARCHAR(255) NOT NULL,
+    total_gross_pay NUMERIC(15, 4) NOT NULL,
+    total_net_pay NUMERIC(15, 4) NOT NULL,
+    processed_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recurring_cash_items (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    bank_account_id UUID REFERENCES bank_accounts(id),
+    description TEXT NOT NULL,
+    amount NUMERIC(15, 4) NOT NULL,
+    frequency VARCHAR(255) NOT NULL,
+    next_occurrence TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    is_active BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fx_revaluations (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    financial_period VARCHAR(255) NOT NULL,
+    revaluation_date TIMESTAMP NOT NULL,
+    source_type VARCHAR(255) NOT NULL,
+    source_id UUID NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    open_amount NUMERIC(15, 4) NOT NULL,
+    previous_rate NUMERIC(15, 4) NOT NULL,
+    revaluation_rate NUMERIC(15, 4) NOT NULL,
+    unrealized_gain_loss NUMERIC(15, 4) NOT NULL,
+    journal_entry_id UUID REFERENCES universal_journal_entries(id),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fiscal_periods (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    financial_period VARCHAR(255) NOT NULL,
+    state VARCHAR(255) NOT NULL,
+    closing_entry_id UUID REFERENCES universal_journal_entries(id),
+    closed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS intercompany_transactions (
+    id UUID PRIMARY KEY NOT NULL,
+    from_legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    to_legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    description TEXT NOT NULL,
+    posting_date DATE NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    amount NUMERIC(15, 4) NOT NULL,
+    from_journal_entry_id UUID NOT NULL REFERENCES universal_journal_entries(id),
+    to_journal_entry_id UUID NOT NULL REFERENCES universal_journal_entries(id),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS consolidation_groups (
+    id UUID PRIMARY KEY NOT NULL,
+    code VARCHAR(255) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    reporting_currency VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS consolidation_group_members (
+    id UUID PRIMARY KEY NOT NULL,
+    consolidation_group_id UUID NOT NULL REFERENCES consolidation_groups(id),
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id)
+);
+
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY NOT NULL,
+    account_id UUID NOT NULL,
+    cost_center_id UUID,
+    fiscal_year VARCHAR(255) NOT NULL,
+    period VARCHAR(255) NOT NULL,
+    version VARCHAR(255) NOT NULL,
+    allocated_amount NUMERIC(15, 4) NOT NULL,
+    spent_amount NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS budget_commitments (
+    id UUID PRIMARY KEY NOT NULL,
+    source_type VARCHAR(255) NOT NULL,
+    source_document_id UUID NOT NULL,
+    replaces_document_id UUID,
+    account_id UUID NOT NULL REFERENCES chart_of_accountss(id),
+    cost_center_id UUID,
+    fiscal_year VARCHAR(255) NOT NULL,
+    period VARCHAR(255) NOT NULL,
+    amount NUMERIC(15, 4) NOT NULL,
+    relieved_amount NUMERIC(15, 4) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS budget_policies (
+    id UUID PRIMARY KEY NOT NULL,
+    cost_center_id UUID UNIQUE NOT NULL,
+    enforcement VARCHAR(255) NOT NULL,
+    tolerance_percent NUMERIC(15, 4) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS cost_centers (
+    id UUID PRIMARY KEY NOT NULL,
+    code VARCHAR(255) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    manager_id UUID,
+    is_active BOOLEAN NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS allocation_cycles (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    driver VARCHAR(255) NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS allocation_cycle_members (
+    id UUID PRIMARY KEY NOT NULL,
+    cycle_id UUID NOT NULL REFERENCES allocation_cycles(id),
+    cost_center_id UUID NOT NULL REFERENCES cost_centers(id),
+    role VARCHAR(255) NOT NULL,
+    percentage NUMERIC(15, 4) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS allocation_runs (
+    id UUID PRIMARY KEY NOT NULL,
+    cycle_id UUID NOT NULL REFERENCES allocation_cycles(id),
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    financial_period VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    journal_entry_id UUID NOT NULL REFERENCES universal_journal_entries(id),
+    reversal_entry_id UUID REFERENCES universal_journal_entries(id),
+    total_allocated NUMERIC(15, 4) NOT NULL,
+    posted_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    reversed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS statistical_key_figures (
+    id UUID PRIMARY KEY NOT NULL,
+    cost_center_id UUID NOT NULL REFERENCES cost_centers(id),
+    driver VARCHAR(255) NOT NULL,
+    financial_period VARCHAR(255) NOT NULL,
+    value NUMERIC(15, 4) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS tax_rates (
     id UUID PRIMARY KEY NOT NULL,
     code VARCHAR(255) NOT NULL,
     name VARCHAR(255) NOT NULL,
     rate NUMERIC(15, 4) NOT NULL,
-    is_active BOOLEAN NOT NULL
+    is_active BOOLEAN NOT NULL,
+    jurisdiction_id UUID REFERENCES tax_jurisdictions(id),
+    tax_category VARCHAR(255) NOT NULL,
+    is_compound BOOLEAN NOT NULL,
+    sequence VARCHAR(255) NOT NULL,
+    liability_account_code VARCHAR(255) NOT NULL,
+    valid_from TIMESTAMP,
+    valid_to TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tax_jurisdictions (
+    id UUID PRIMARY KEY NOT NULL,
+    code VARCHAR(255) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    parent_id UUID REFERENCES tax_jurisdictions(id),
+    sourcing VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tax_exemptions (
+    id UUID PRIMARY KEY NOT NULL,
+    customer_id UUID NOT NULL,
+    jurisdiction_id UUID NOT NULL REFERENCES tax_jurisdictions(id),
+    certificate_number VARCHAR(255) NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tax_transactions (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    direction VARCHAR(255) NOT NULL,
+    source_document_id UUID NOT NULL,
+    journal_entry_id UUID REFERENCES universal_journal_entries(id),
+    tax_rate_id UUID NOT NULL REFERENCES tax_rates(id),
+    jurisdiction_id UUID NOT NULL REFERENCES tax_jurisdictions(id),
+    financial_period VARCHAR(255) NOT NULL,
+    posting_date TIMESTAMP NOT NULL,
+    currency VARCHAR(255) NOT NULL,
+    taxable_amount NUMERIC(15, 4) NOT NULL,
+    tax_amount NUMERIC(15, 4) NOT NULL,
+    is_exempt BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL
 );
 
 CREATE TABLE IF NOT EXISTS currency_rates (
@@ -161,10 +649,35 @@ CREATE TABLE IF NOT EXISTS customer_credits (
     credit_limit NUMERIC(15, 4) NOT NULL,
     current_balance NUMERIC(15, 4) NOT NULL,
     is_on_hold BOOLEAN NOT NULL,
+    hold_reason VARCHAR(255) NOT NULL,
     version VARCHAR(255) NOT NULL,
     updated_at TIMESTAMP NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS sales_order_exposures (
+    id UUID PRIMARY KEY NOT NULL,
+    legal_entity_id UUID NOT NULL REFERENCES legal_entities(id),
+    sales_order_id UUID UNIQUE NOT NULL,
+    customer_id UUID NOT NULL,
+    order_amount NUMERIC(15, 4) NOT NULL,
+    invoiced_amount NUMERIC(15, 4) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    confirmed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS credit_overrides (
+    id UUID PRIMARY KEY NOT NULL,
+    customer_id UUID NOT NULL,
+    sales_order_id UUID NOT NULL,
+    amount NUMERIC(15, 4) NOT NULL,
+    approved_by VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS transactional_outboxs (
     id UUID PRIMARY KEY NOT NULL,
     event_type VARCHAR(255) NOT NULL,
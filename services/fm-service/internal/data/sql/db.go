@@ -55,7 +55,8 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -65,24 +66,62 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 	err = db.AutoMigrate(
 		&LegalEntity{},
 		&CostCenter{},
+		&TaxJurisdiction{},
 		&TaxRate{},
+		&TaxExemption{},
 		&CurrencyRate{},
 		&FiscalYear{},
 		&BankAccount{},
 		&CustomerCredit{},
 		&Payment{},
 		&Budget{},
+		&BudgetCommitment{},
+		&BudgetPolicy{},
 		&BankStatement{},
 		&BankStatementLine{},
 		&ChartOfAccounts{},
+		&AccountDetermination{},
+		&JournalTemplate{},
+		&JournalTemplateLine{},
 		&UniversalJournalEntry{},
 		&UniversalJournalLine{},
+		&AllocationCycle{},
+		&AllocationCycleMember{},
+		&AllocationRun{},
+		&StatisticalKeyFigure{},
 		&CapitalAsset{},
 		&DepreciationScheduleLine{},
+		&AssetUsageRecord{},
 		&KafkaEventInbox{},
 		&TransactionalOutbox{},
 		&ApVendorBill{},
+		&ApVendorBillLine{},
+		&PurchaseOrderLine{},
+		&GoodsReceiptLine{},
+		&MatchTolerance{},
 		&ArInvoice{},
+		&PaymentAllocation{},
+		&ArCreditMemo{},
+		&OnAccountCredit{},
+		&DunningLevel{},
+		&DunningRun{},
+		&DunningNotice{},
+		&PaymentRun{},
+		&PaymentRunLine{},
+		&PaymentFile{},
+		&VendorBankAccount{},
+		&SalesOrderExposure{},
+		&CreditOverride{},
+		&BankReconciliationMatch{},
+		&BankReconciliationException{},
+		&PayrollRunSnapshot{},
+		&RecurringCashItem{},
+		&FxRevaluation{},
+		&FiscalPeriod{},
+		&IntercompanyTransaction{},
+		&ConsolidationGroup{},
+		&ConsolidationGroupMember{},
+		&TaxTransaction{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto migrate database: %w", err)
@@ -10,17 +10,22 @@ import (
 
 // Payment GORM struct
 type Payment struct {
-	ID            string  `gorm:"primaryKey"`
-	InvoiceID     *string `gorm:"index"`
-	BillID        *string `gorm:"index"`
-	BankAccountID *string `gorm:"index"`
-	PaymentNumber string
-	PaymentDate   time.Time
-	Amount        decimal.Decimal `gorm:"type:numeric(18,4)"`
-	PaymentMethod string
-	Status        string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	ID                 string                  `gorm:"primaryKey"`
+	InvoiceID          *string                 `gorm:"index"`
+	BillID             *string                 `gorm:"index"`
+	BankAccountID      *string                 `gorm:"index"`
+	CounterpartyType   domain.CounterpartyType `gorm:"type:varchar(20)"`
+	CounterpartyID     string                  `gorm:"index"`
+	PaymentNumber      string
+	PaymentDate        time.Time
+	Amount             decimal.Decimal `gorm:"type:numeric(18,4)"`
+	PaymentMethod      string
+	Status             string
+	Currency           string          `gorm:"type:varchar(3)"`
+	ExchangeRate       decimal.Decimal `gorm:"type:numeric(18,8)"`
+	RealizedFxGainLoss decimal.Decimal `gorm:"type:numeric(18,4)"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
 
 	Invoice     *ArInvoice    `gorm:"foreignKey:InvoiceID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 	VendorBill  *ApVendorBill `gorm:"foreignKey:BillID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
@@ -32,17 +37,22 @@ func FromDomainPayment(d *domain.Payment) *Payment {
 		return nil
 	}
 	return &Payment{
-		ID:            d.ID,
-		InvoiceID:     d.InvoiceID,
-		BillID:        d.BillID,
-		BankAccountID: d.BankAccountID,
-		PaymentNumber: d.PaymentNumber,
-		PaymentDate:   d.PaymentDate,
-		Amount:        d.Amount,
-		PaymentMethod: d.PaymentMethod,
-		Status:        d.Status,
-		CreatedAt:     d.CreatedAt,
-		UpdatedAt:     d.UpdatedAt,
+		ID:                 d.ID,
+		InvoiceID:          d.InvoiceID,
+		BillID:             d.BillID,
+		BankAccountID:      d.BankAccountID,
+		CounterpartyType:   d.CounterpartyType,
+		CounterpartyID:     d.CounterpartyID,
+		PaymentNumber:      d.PaymentNumber,
+		PaymentDate:        d.PaymentDate,
+		Amount:             d.Amount,
+		PaymentMethod:      d.PaymentMethod,
+		Currency:           d.Currency,
+		ExchangeRate:       d.ExchangeRate,
+		RealizedFxGainLoss: d.RealizedFxGainLoss,
+		Status:             d.Status,
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
 	}
 }
 
@@ -51,17 +61,22 @@ func ToDomainPayment(dbModel *Payment) *domain.Payment {
 		return nil
 	}
 	return &domain.Payment{
-		ID:            dbModel.ID,
-		InvoiceID:     dbModel.InvoiceID,
-		BillID:        dbModel.BillID,
-		BankAccountID: dbModel.BankAccountID,
-		PaymentNumber: dbModel.PaymentNumber,
-		PaymentDate:   dbModel.PaymentDate,
-		Amount:        dbModel.Amount,
-		PaymentMethod: dbModel.PaymentMethod,
-		Status:        dbModel.Status,
-		CreatedAt:     dbModel.CreatedAt,
-		UpdatedAt:     dbModel.UpdatedAt,
+		ID:                 dbModel.ID,
+		InvoiceID:          dbModel.InvoiceID,
+		BillID:             dbModel.BillID,
+		BankAccountID:      dbModel.BankAccountID,
+		CounterpartyType:   dbModel.CounterpartyType,
+		CounterpartyID:     dbModel.CounterpartyID,
+		PaymentNumber:      dbModel.PaymentNumber,
+		PaymentDate:        dbModel.PaymentDate,
+		Amount:             dbModel.Amount,
+		PaymentMethod:      dbModel.PaymentMethod,
+		Currency:           dbModel.Currency,
+		ExchangeRate:       dbModel.ExchangeRate,
+		RealizedFxGainLoss: dbModel.RealizedFxGainLoss,
+		Status:             dbModel.Status,
+		CreatedAt:          dbModel.CreatedAt,
+		UpdatedAt:          dbModel.UpdatedAt,
 	}
 }
 
@@ -72,8 +87,9 @@ type Budget struct {
 	CostCenterID    *string `gorm:"index"`
 	FiscalYear      int
 	Period          int
-	AllocatedAmount decimal.Decimal `gorm:"type:numeric(18,4)"`
-	SpentAmount     decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Version         domain.BudgetVersion `gorm:"type:varchar(50);default:ORIGINAL"`
+	AllocatedAmount decimal.Decimal      `gorm:"type:numeric(18,4)"`
+	SpentAmount     decimal.Decimal      `gorm:"type:numeric(18,4)"`
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 
@@ -91,6 +107,7 @@ func FromDomainBudget(d *domain.Budget) *Budget {
 		CostCenterID:    d.CostCenterID,
 		FiscalYear:      d.FiscalYear,
 		Period:          d.Period,
+		Version:         d.Version,
 		AllocatedAmount: d.AllocatedAmount,
 		SpentAmount:     d.SpentAmount,
 		CreatedAt:       d.CreatedAt,
@@ -108,6 +125,7 @@ func ToDomainBudget(dbModel *Budget) *domain.Budget {
 		CostCenterID:    dbModel.CostCenterID,
 		FiscalYear:      dbModel.FiscalYear,
 		Period:          dbModel.Period,
+		Version:         dbModel.Version,
 		AllocatedAmount: dbModel.AllocatedAmount,
 		SpentAmount:     dbModel.SpentAmount,
 		CreatedAt:       dbModel.CreatedAt,
@@ -115,13 +133,123 @@ func ToDomainBudget(dbModel *Budget) *domain.Budget {
 	}
 }
 
+// BudgetCommitment GORM struct
+type BudgetCommitment struct {
+	ID                 string                        `gorm:"primaryKey"`
+	SourceType         domain.BudgetCommitmentSource `gorm:"type:varchar(50)"`
+	SourceDocumentID   string                        `gorm:"index"`
+	ReplacesDocumentID *string
+	AccountID          string  `gorm:"index"`
+	CostCenterID       *string `gorm:"index"`
+	FiscalYear         int
+	Period             int
+	Amount             decimal.Decimal               `gorm:"type:numeric(18,4)"`
+	RelievedAmount     decimal.Decimal               `gorm:"type:numeric(18,4)"`
+	Status             domain.BudgetCommitmentStatus `gorm:"type:varchar(50);index"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+
+	Account ChartOfAccounts `gorm:"foreignKey:AccountID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainBudgetCommitment(d *domain.BudgetCommitment) *BudgetCommitment {
+	if d == nil {
+		return nil
+	}
+	return &BudgetCommitment{
+		ID:                 d.ID,
+		SourceType:         d.SourceType,
+		SourceDocumentID:   d.SourceDocumentID,
+		ReplacesDocumentID: d.ReplacesDocumentID,
+		AccountID:          d.AccountID,
+		CostCenterID:       d.CostCenterID,
+		FiscalYear:         d.FiscalYear,
+		Period:             d.Period,
+		Amount:             d.Amount,
+		RelievedAmount:     d.RelievedAmount,
+		Status:             d.Status,
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
+	}
+}
+
+func ToDomainBudgetCommitment(dbModel *BudgetCommitment) *domain.BudgetCommitment {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.BudgetCommitment{
+		ID:                 dbModel.ID,
+		SourceType:         dbModel.SourceType,
+		SourceDocumentID:   dbModel.SourceDocumentID,
+		ReplacesDocumentID: dbModel.ReplacesDocumentID,
+		AccountID:          dbModel.AccountID,
+		CostCenterID:       dbModel.CostCenterID,
+		FiscalYear:         dbModel.FiscalYear,
+		Period:             dbModel.Period,
+		Amount:             dbModel.Amount,
+		RelievedAmount:     dbModel.RelievedAmount,
+		Status:             dbModel.Status,
+		CreatedAt:          dbModel.CreatedAt,
+		UpdatedAt:          dbModel.UpdatedAt,
+	}
+}
+
+// BudgetPolicy GORM struct
+type BudgetPolicy struct {
+	ID               string                   `gorm:"primaryKey"`
+	CostCenterID     string                   `gorm:"uniqueIndex"`
+	Enforcement      domain.BudgetEnforcement `gorm:"type:varchar(50)"`
+	TolerancePercent decimal.Decimal          `gorm:"type:numeric(9,4)"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+
+	CostCenter CostCenter `gorm:"foreignKey:CostCenterID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func FromDomainBudgetPolicy(d *domain.BudgetPolicy) *BudgetPolicy {
+	if d == nil {
+		return nil
+	}
+	return &BudgetPolicy{
+		ID:               d.ID,
+		CostCenterID:     d.CostCenterID,
+		Enforcement:      d.Enforcement,
+		TolerancePercent: d.TolerancePercent,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
+
+func ToDomainBudgetPolicy(dbModel *BudgetPolicy) *domain.BudgetPolicy {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.BudgetPolicy{
+		ID:               dbModel.ID,
+		CostCenterID:     dbModel.CostCenterID,
+		Enforcement:      dbModel.Enforcement,
+		TolerancePercent: dbModel.TolerancePercent,
+		CreatedAt:        dbModel.CreatedAt,
+		UpdatedAt:        dbModel.UpdatedAt,
+	}
+}
+
 // TaxRate GORM struct
 type TaxRate struct {
-	ID       string `gorm:"primaryKey"`
-	Code     string `gorm:"uniqueIndex"`
-	Name     string
-	Rate     decimal.Decimal `gorm:"type:numeric(18,4)"`
-	IsActive bool
+	ID                   string `gorm:"primaryKey"`
+	Code                 string `gorm:"uniqueIndex"`
+	Name                 string
+	Rate                 decimal.Decimal `gorm:"type:numeric(18,4)"`
+	IsActive             bool
+	JurisdictionID       *string `gorm:"index"`
+	TaxCategory          string  `gorm:"type:varchar(50)"`
+	IsCompound           bool
+	Sequence             int
+	LiabilityAccountCode string `gorm:"type:varchar(50)"`
+	ValidFrom            *time.Time
+	ValidTo              *time.Time
+
+	Jurisdiction *TaxJurisdiction `gorm:"foreignKey:JurisdictionID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 }
 
 func FromDomainTaxRate(d *domain.TaxRate) *TaxRate {
@@ -129,11 +257,18 @@ func FromDomainTaxRate(d *domain.TaxRate) *TaxRate {
 		return nil
 	}
 	return &TaxRate{
-		ID:       d.ID,
-		Code:     d.Code,
-		Name:     d.Name,
-		Rate:     d.Rate,
-		IsActive: d.IsActive,
+		ID:                   d.ID,
+		Code:                 d.Code,
+		Name:                 d.Name,
+		Rate:                 d.Rate,
+		IsActive:             d.IsActive,
+		JurisdictionID:       d.JurisdictionID,
+		TaxCategory:          d.TaxCategory,
+		IsCompound:           d.IsCompound,
+		Sequence:             d.Sequence,
+		LiabilityAccountCode: d.LiabilityAccountCode,
+		ValidFrom:            d.ValidFrom,
+		ValidTo:              d.ValidTo,
 	}
 }
 
@@ -142,11 +277,171 @@ func ToDomainTaxRate(dbModel *TaxRate) *domain.TaxRate {
 		return nil
 	}
 	return &domain.TaxRate{
-		ID:       dbModel.ID,
-		Code:     dbModel.Code,
-		Name:     dbModel.Name,
-		Rate:     dbModel.Rate,
-		IsActive: dbModel.IsActive,
+		ID:                   dbModel.ID,
+		Code:                 dbModel.Code,
+		Name:                 dbModel.Name,
+		Rate:                 dbModel.Rate,
+		IsActive:             dbModel.IsActive,
+		JurisdictionID:       dbModel.JurisdictionID,
+		TaxCategory:          dbModel.TaxCategory,
+		IsCompound:           dbModel.IsCompound,
+		Sequence:             dbModel.Sequence,
+		LiabilityAccountCode: dbModel.LiabilityAccountCode,
+		ValidFrom:            dbModel.ValidFrom,
+		ValidTo:              dbModel.ValidTo,
+	}
+}
+
+// TaxJurisdiction GORM struct
+type TaxJurisdiction struct {
+	ID        string `gorm:"primaryKey"`
+	Code      string `gorm:"uniqueIndex"`
+	Name      string
+	ParentID  *string            `gorm:"index"`
+	Sourcing  domain.TaxSourcing `gorm:"type:varchar(50)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Parent *TaxJurisdiction `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainTaxJurisdiction(d *domain.TaxJurisdiction) *TaxJurisdiction {
+	if d == nil {
+		return nil
+	}
+	return &TaxJurisdiction{
+		ID:        d.ID,
+		Code:      d.Code,
+		Name:      d.Name,
+		ParentID:  d.ParentID,
+		Sourcing:  d.Sourcing,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+func ToDomainTaxJurisdiction(dbModel *TaxJurisdiction) *domain.TaxJurisdiction {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.TaxJurisdiction{
+		ID:        dbModel.ID,
+		Code:      dbModel.Code,
+		Name:      dbModel.Name,
+		ParentID:  dbModel.ParentID,
+		Sourcing:  dbModel.Sourcing,
+		CreatedAt: dbModel.CreatedAt,
+		UpdatedAt: dbModel.UpdatedAt,
+	}
+}
+
+// TaxExemption GORM struct
+type TaxExemption struct {
+	ID                string `gorm:"primaryKey"`
+	CustomerID        string `gorm:"index"`
+	JurisdictionID    string `gorm:"index"`
+	CertificateNumber string
+	ValidFrom         time.Time
+	ValidTo           *time.Time
+	CreatedAt         time.Time
+
+	Jurisdiction TaxJurisdiction `gorm:"foreignKey:JurisdictionID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainTaxExemption(d *domain.TaxExemption) *TaxExemption {
+	if d == nil {
+		return nil
+	}
+	return &TaxExemption{
+		ID:                d.ID,
+		CustomerID:        d.CustomerID,
+		JurisdictionID:    d.JurisdictionID,
+		CertificateNumber: d.CertificateNumber,
+		ValidFrom:         d.ValidFrom,
+		ValidTo:           d.ValidTo,
+		CreatedAt:         d.CreatedAt,
+	}
+}
+
+func ToDomainTaxExemption(dbModel *TaxExemption) *domain.TaxExemption {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.TaxExemption{
+		ID:                dbModel.ID,
+		CustomerID:        dbModel.CustomerID,
+		JurisdictionID:    dbModel.JurisdictionID,
+		CertificateNumber: dbModel.CertificateNumber,
+		ValidFrom:         dbModel.ValidFrom,
+		ValidTo:           dbModel.ValidTo,
+		CreatedAt:         dbModel.CreatedAt,
+	}
+}
+
+// TaxTransaction GORM struct
+type TaxTransaction struct {
+	ID               string              `gorm:"primaryKey"`
+	LegalEntityID    string              `gorm:"index:idx_tax_tx_period"`
+	Direction        domain.TaxDirection `gorm:"type:varchar(50)"`
+	SourceDocumentID string              `gorm:"index"`
+	JournalEntryID   *string             `gorm:"index"`
+	TaxRateID        string              `gorm:"index"`
+	JurisdictionID   string              `gorm:"index"`
+	FinancialPeriod  string              `gorm:"type:varchar(7);index:idx_tax_tx_period"`
+	PostingDate      time.Time
+	Currency         string          `gorm:"type:varchar(3)"`
+	TaxableAmount    decimal.Decimal `gorm:"type:numeric(18,4)"`
+	TaxAmount        decimal.Decimal `gorm:"type:numeric(18,4)"`
+	IsExempt         bool
+	CreatedAt        time.Time
+
+	LegalEntity  LegalEntity            `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	JournalEntry *UniversalJournalEntry `gorm:"foreignKey:JournalEntryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	TaxRate      TaxRate                `gorm:"foreignKey:TaxRateID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Jurisdiction TaxJurisdiction        `gorm:"foreignKey:JurisdictionID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainTaxTransaction(d *domain.TaxTransaction) *TaxTransaction {
+	if d == nil {
+		return nil
+	}
+	return &TaxTransaction{
+		ID:               d.ID,
+		LegalEntityID:    d.LegalEntityID,
+		Direction:        d.Direction,
+		SourceDocumentID: d.SourceDocumentID,
+		JournalEntryID:   d.JournalEntryID,
+		TaxRateID:        d.TaxRateID,
+		JurisdictionID:   d.JurisdictionID,
+		FinancialPeriod:  d.FinancialPeriod,
+		PostingDate:      d.PostingDate,
+		Currency:         d.Currency,
+		TaxableAmount:    d.TaxableAmount,
+		TaxAmount:        d.TaxAmount,
+		IsExempt:         d.IsExempt,
+		CreatedAt:        d.CreatedAt,
+	}
+}
+
+func ToDomainTaxTransaction(dbModel *TaxTransaction) *domain.TaxTransaction {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.TaxTransaction{
+		ID:               dbModel.ID,
+		LegalEntityID:    dbModel.LegalEntityID,
+		Direction:        dbModel.Direction,
+		SourceDocumentID: dbModel.SourceDocumentID,
+		JournalEntryID:   dbModel.JournalEntryID,
+		TaxRateID:        dbModel.TaxRateID,
+		JurisdictionID:   dbModel.JurisdictionID,
+		FinancialPeriod:  dbModel.FinancialPeriod,
+		PostingDate:      dbModel.PostingDate,
+		Currency:         dbModel.Currency,
+		TaxableAmount:    dbModel.TaxableAmount,
+		TaxAmount:        dbModel.TaxAmount,
+		IsExempt:         dbModel.IsExempt,
+		CreatedAt:        dbModel.CreatedAt,
 	}
 }
 
@@ -263,8 +558,11 @@ type BankAccount struct {
 	ID            string `gorm:"primaryKey"`
 	LegalEntityID string `gorm:"index"`
 	AccountNumber string `gorm:"uniqueIndex"`
+	BIC           string
+	RoutingNumber string
 	Currency      string
 	LiquidBalance decimal.Decimal `gorm:"type:numeric(18,4)"`
+	GLAccountID   *string         `gorm:"index"`
 	Version       int
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -280,8 +578,11 @@ func FromDomainBankAccount(d *domain.BankAccount) *BankAccount {
 		ID:            d.ID,
 		LegalEntityID: d.LegalEntityID,
 		AccountNumber: d.AccountNumber,
+		BIC:           d.Bic,
+		RoutingNumber: d.RoutingNumber,
 		Currency:      d.Currency,
 		LiquidBalance: d.LiquidBalance,
+		GLAccountID:   d.GlAccountID,
 		Version:       d.Version,
 		CreatedAt:     d.CreatedAt,
 		UpdatedAt:     d.UpdatedAt,
@@ -296,8 +597,11 @@ func ToDomainBankAccount(dbModel *BankAccount) *domain.BankAccount {
 		ID:            dbModel.ID,
 		LegalEntityID: dbModel.LegalEntityID,
 		AccountNumber: dbModel.AccountNumber,
+		Bic:           dbModel.BIC,
+		RoutingNumber: dbModel.RoutingNumber,
 		Currency:      dbModel.Currency,
 		LiquidBalance: dbModel.LiquidBalance,
+		GlAccountID:   dbModel.GLAccountID,
 		Version:       dbModel.Version,
 		CreatedAt:     dbModel.CreatedAt,
 		UpdatedAt:     dbModel.UpdatedAt,
@@ -311,6 +615,7 @@ type CustomerCredit struct {
 	CreditLimit    decimal.Decimal `gorm:"type:numeric(18,4)"`
 	CurrentBalance decimal.Decimal `gorm:"type:numeric(18,4)"`
 	IsOnHold       bool
+	HoldReason     string
 	Version        int
 	UpdatedAt      time.Time
 }
@@ -325,6 +630,7 @@ func FromDomainCustomerCredit(d *domain.CustomerCredit) *CustomerCredit {
 		CreditLimit:    d.CreditLimit,
 		CurrentBalance: d.CurrentBalance,
 		IsOnHold:       d.IsOnHold,
+		HoldReason:     d.HoldReason,
 		Version:        d.Version,
 		UpdatedAt:      d.UpdatedAt,
 	}
@@ -340,18 +646,116 @@ func ToDomainCustomerCredit(dbModel *CustomerCredit) *domain.CustomerCredit {
 		CreditLimit:    dbModel.CreditLimit,
 		CurrentBalance: dbModel.CurrentBalance,
 		IsOnHold:       dbModel.IsOnHold,
+		HoldReason:     dbModel.HoldReason,
 		Version:        dbModel.Version,
 		UpdatedAt:      dbModel.UpdatedAt,
 	}
 }
 
+// SalesOrderExposure GORM struct
+type SalesOrderExposure struct {
+	ID             string          `gorm:"primaryKey"`
+	LegalEntityID  string          `gorm:"index"`
+	SalesOrderID   string          `gorm:"uniqueIndex"`
+	CustomerID     string          `gorm:"index"`
+	OrderAmount    decimal.Decimal `gorm:"type:numeric(18,4)"`
+	InvoicedAmount decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Status         string          `gorm:"type:varchar(20);index"`
+	ConfirmedAt    time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func FromDomainSalesOrderExposure(d *domain.SalesOrderExposure) *SalesOrderExposure {
+	if d == nil {
+		return nil
+	}
+	return &SalesOrderExposure{
+		ID:             d.ID,
+		LegalEntityID:  d.LegalEntityID,
+		SalesOrderID:   d.SalesOrderID,
+		CustomerID:     d.CustomerID,
+		OrderAmount:    d.OrderAmount,
+		InvoicedAmount: d.InvoicedAmount,
+		Status:         string(d.Status),
+		ConfirmedAt:    d.ConfirmedAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func ToDomainSalesOrderExposure(dbModel *SalesOrderExposure) *domain.SalesOrderExposure {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.SalesOrderExposure{
+		ID:             dbModel.ID,
+		LegalEntityID:  dbModel.LegalEntityID,
+		SalesOrderID:   dbModel.SalesOrderID,
+		CustomerID:     dbModel.CustomerID,
+		OrderAmount:    dbModel.OrderAmount,
+		InvoicedAmount: dbModel.InvoicedAmount,
+		Status:         domain.SalesOrderExposureStatus(dbModel.Status),
+		ConfirmedAt:    dbModel.ConfirmedAt,
+		CreatedAt:      dbModel.CreatedAt,
+		UpdatedAt:      dbModel.UpdatedAt,
+	}
+}
+
+// CreditOverride GORM struct
+type CreditOverride struct {
+	ID           string          `gorm:"primaryKey"`
+	CustomerID   string          `gorm:"index"`
+	SalesOrderID string          `gorm:"index"`
+	Amount       decimal.Decimal `gorm:"type:numeric(18,4)"`
+	ApprovedBy   string
+	Reason       string
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
+
+func FromDomainCreditOverride(d *domain.CreditOverride) *CreditOverride {
+	if d == nil {
+		return nil
+	}
+	return &CreditOverride{
+		ID:           d.ID,
+		CustomerID:   d.CustomerID,
+		SalesOrderID: d.SalesOrderID,
+		Amount:       d.Amount,
+		ApprovedBy:   d.ApprovedBy,
+		Reason:       d.Reason,
+		UsedAt:       d.UsedAt,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+func ToDomainCreditOverride(dbModel *CreditOverride) *domain.CreditOverride {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.CreditOverride{
+		ID:           dbModel.ID,
+		CustomerID:   dbModel.CustomerID,
+		SalesOrderID: dbModel.SalesOrderID,
+		Amount:       dbModel.Amount,
+		ApprovedBy:   dbModel.ApprovedBy,
+		Reason:       dbModel.Reason,
+		UsedAt:       dbModel.UsedAt,
+		CreatedAt:    dbModel.CreatedAt,
+	}
+}
+
 // BankStatement GORM struct
 type BankStatement struct {
-	ID            string `gorm:"primaryKey"`
-	BankAccountID string `gorm:"index"`
-	StatementDate time.Time
-	EndingBalance decimal.Decimal `gorm:"type:numeric(18,4)"`
-	IsReconciled  bool
+	ID                 string `gorm:"primaryKey"`
+	BankAccountID      string `gorm:"index;uniqueIndex:idx_account_statement_ref"`
+	StatementReference string `gorm:"uniqueIndex:idx_account_statement_ref"`
+	SourceFormat       string `gorm:"type:varchar(50)"`
+	StatementDate      time.Time
+	OpeningBalance     decimal.Decimal `gorm:"type:numeric(18,4)"`
+	EndingBalance      decimal.Decimal `gorm:"type:numeric(18,4)"`
+	IsReconciled       bool
 
 	BankAccount BankAccount `gorm:"foreignKey:BankAccountID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 }
@@ -361,11 +765,14 @@ func FromDomainBankStatement(d *domain.BankStatement) *BankStatement {
 		return nil
 	}
 	return &BankStatement{
-		ID:            d.ID,
-		BankAccountID: d.BankAccountID,
-		StatementDate: d.StatementDate,
-		EndingBalance: d.EndingBalance,
-		IsReconciled:  d.IsReconciled,
+		ID:                 d.ID,
+		BankAccountID:      d.BankAccountID,
+		StatementReference: d.StatementReference,
+		SourceFormat:       d.SourceFormat,
+		StatementDate:      d.StatementDate,
+		OpeningBalance:     d.OpeningBalance,
+		EndingBalance:      d.EndingBalance,
+		IsReconciled:       d.IsReconciled,
 	}
 }
 
@@ -374,11 +781,14 @@ func ToDomainBankStatement(dbModel *BankStatement) *domain.BankStatement {
 		return nil
 	}
 	return &domain.BankStatement{
-		ID:            dbModel.ID,
-		BankAccountID: dbModel.BankAccountID,
-		StatementDate: dbModel.StatementDate,
-		EndingBalance: dbModel.EndingBalance,
-		IsReconciled:  dbModel.IsReconciled,
+		ID:                 dbModel.ID,
+		BankAccountID:      dbModel.BankAccountID,
+		StatementReference: dbModel.StatementReference,
+		SourceFormat:       dbModel.SourceFormat,
+		StatementDate:      dbModel.StatementDate,
+		OpeningBalance:     dbModel.OpeningBalance,
+		EndingBalance:      dbModel.EndingBalance,
+		IsReconciled:       dbModel.IsReconciled,
 	}
 }
 
@@ -388,6 +798,7 @@ type BankStatementLine struct {
 	StatementID     string `gorm:"index"`
 	TransactionDate time.Time
 	Description     string
+	Reference       string
 	Amount          decimal.Decimal `gorm:"type:numeric(18,4)"`
 	IsMatched       bool
 
@@ -403,6 +814,7 @@ func FromDomainBankStatementLine(d *domain.BankStatementLine) *BankStatementLine
 		StatementID:     d.StatementID,
 		TransactionDate: d.TransactionDate,
 		Description:     d.Description,
+		Reference:       d.Reference,
 		Amount:          d.Amount,
 		IsMatched:       d.IsMatched,
 	}
@@ -417,111 +829,585 @@ func ToDomainBankStatementLine(dbModel *BankStatementLine) *domain.BankStatement
 		StatementID:     dbModel.StatementID,
 		TransactionDate: dbModel.TransactionDate,
 		Description:     dbModel.Description,
+		Reference:       dbModel.Reference,
 		Amount:          dbModel.Amount,
 		IsMatched:       dbModel.IsMatched,
 	}
 }
 
-// ChartOfAccounts GORM struct
-type ChartOfAccounts struct {
-	ID            string `gorm:"primaryKey"`
-	LegalEntityID string `gorm:"uniqueIndex:idx_entity_account"`
-	AccountCode   string `gorm:"uniqueIndex:idx_entity_account"`
-	AccountName   string
-	Type          domain.AccountType `gorm:"type:varchar(50)"`
-	IsActive      bool
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+// BankReconciliationMatch GORM struct
+type BankReconciliationMatch struct {
+	ID              string                         `gorm:"primaryKey"`
+	StatementID     string                         `gorm:"index"`
+	MatchGroupID    string                         `gorm:"index"`
+	StatementLineID string                         `gorm:"index"`
+	PaymentID       string                         `gorm:"index"`
+	MatchedAmount   decimal.Decimal                `gorm:"type:numeric(18,4)"`
+	ConfidenceScore decimal.Decimal                `gorm:"type:numeric(5,4)"`
+	MatchType       domain.ReconciliationMatchType `gorm:"type:varchar(50)"`
+	CreatedAt       time.Time
 
-	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	BankStatement     BankStatement     `gorm:"foreignKey:StatementID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	BankStatementLine BankStatementLine `gorm:"foreignKey:StatementLineID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Payment           Payment           `gorm:"foreignKey:PaymentID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 }
 
-func FromDomainChartOfAccounts(d *domain.ChartOfAccounts) *ChartOfAccounts {
+func FromDomainBankReconciliationMatch(d *domain.BankReconciliationMatch) *BankReconciliationMatch {
 	if d == nil {
 		return nil
 	}
-	return &ChartOfAccounts{
-		ID:            d.ID,
-		LegalEntityID: d.LegalEntityID,
-		AccountCode:   d.AccountCode,
-		AccountName:   d.AccountName,
-		Type:          d.Type,
-		IsActive:      d.IsActive,
-		CreatedAt:     d.CreatedAt,
-		UpdatedAt:     d.UpdatedAt,
+	return &BankReconciliationMatch{
+		ID:              d.ID,
+		StatementID:     d.StatementID,
+		MatchGroupID:    d.MatchGroupID,
+		StatementLineID: d.StatementLineID,
+		PaymentID:       d.PaymentID,
+		MatchedAmount:   d.MatchedAmount,
+		ConfidenceScore: d.ConfidenceScore,
+		MatchType:       d.MatchType,
+		CreatedAt:       d.CreatedAt,
 	}
 }
 
-func ToDomainChartOfAccounts(dbModel *ChartOfAccounts) *domain.ChartOfAccounts {
+func ToDomainBankReconciliationMatch(dbModel *BankReconciliationMatch) *domain.BankReconciliationMatch {
 	if dbModel == nil {
 		return nil
 	}
-	return &domain.ChartOfAccounts{
-		ID:            dbModel.ID,
-		LegalEntityID: dbModel.LegalEntityID,
-		AccountCode:   dbModel.AccountCode,
-		AccountName:   dbModel.AccountName,
-		Type:          dbModel.Type,
-		IsActive:      dbModel.IsActive,
-		CreatedAt:     dbModel.CreatedAt,
-		UpdatedAt:     dbModel.UpdatedAt,
+	return &domain.BankReconciliationMatch{
+		ID:              dbModel.ID,
+		StatementID:     dbModel.StatementID,
+		MatchGroupID:    dbModel.MatchGroupID,
+		StatementLineID: dbModel.StatementLineID,
+		PaymentID:       dbModel.PaymentID,
+		MatchedAmount:   dbModel.MatchedAmount,
+		ConfidenceScore: dbModel.ConfidenceScore,
+		MatchType:       dbModel.MatchType,
+		CreatedAt:       dbModel.CreatedAt,
 	}
 }
 
-// LegalEntity GORM struct
-type LegalEntity struct {
-	ID                    string `gorm:"primaryKey"`
-	CompanyCode           string `gorm:"uniqueIndex"`
-	CompanyName           string
-	FunctionalCurrency    string
-	TaxRegistrationNumber string
-	CreatedAt             time.Time
-	UpdatedAt             time.Time
+// BankReconciliationException GORM struct
+type BankReconciliationException struct {
+	ID              string `gorm:"primaryKey"`
+	StatementID     string `gorm:"index"`
+	StatementLineID string `gorm:"index"`
+	Reason          string
+	Status          domain.ReconciliationExceptionStatus `gorm:"type:varchar(50);index"`
+	JournalEntryID  *string                              `gorm:"index"`
+	CreatedAt       time.Time
+	ResolvedAt      *time.Time
+
+	BankStatement     BankStatement          `gorm:"foreignKey:StatementID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	BankStatementLine BankStatementLine      `gorm:"foreignKey:StatementLineID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	JournalEntry      *UniversalJournalEntry `gorm:"foreignKey:JournalEntryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 }
 
-func FromDomainLegalEntity(d *domain.LegalEntity) *LegalEntity {
+func FromDomainBankReconciliationException(d *domain.BankReconciliationException) *BankReconciliationException {
 	if d == nil {
 		return nil
 	}
-	return &LegalEntity{
-		ID:                    d.ID,
-		CompanyCode:           d.CompanyCode,
-		CompanyName:           d.CompanyName,
-		FunctionalCurrency:    d.FunctionalCurrency,
-		TaxRegistrationNumber: d.TaxRegistrationNumber,
-		CreatedAt:             d.CreatedAt,
-		UpdatedAt:             d.UpdatedAt,
+	return &BankReconciliationException{
+		ID:              d.ID,
+		StatementID:     d.StatementID,
+		StatementLineID: d.StatementLineID,
+		Reason:          d.Reason,
+		Status:          d.Status,
+		JournalEntryID:  d.JournalEntryID,
+		CreatedAt:       d.CreatedAt,
+		ResolvedAt:      d.ResolvedAt,
 	}
 }
 
-func ToDomainLegalEntity(dbModel *LegalEntity) *domain.LegalEntity {
+func ToDomainBankReconciliationException(dbModel *BankReconciliationException) *domain.BankReconciliationException {
 	if dbModel == nil {
 		return nil
 	}
-	return &domain.LegalEntity{
-		ID:                    dbModel.ID,
-		CompanyCode:           dbModel.CompanyCode,
-		CompanyName:           dbModel.CompanyName,
-		FunctionalCurrency:    dbModel.FunctionalCurrency,
-		TaxRegistrationNumber: dbModel.TaxRegistrationNumber,
-		CreatedAt:             dbModel.CreatedAt,
-		UpdatedAt:             dbModel.UpdatedAt,
+	return &domain.BankReconciliationException{
+		ID:              dbModel.ID,
+		StatementID:     dbModel.StatementID,
+		StatementLineID: dbModel.StatementLineID,
+		Reason:          dbModel.Reason,
+		Status:          dbModel.Status,
+		JournalEntryID:  dbModel.JournalEntryID,
+		CreatedAt:       dbModel.CreatedAt,
+		ResolvedAt:      dbModel.ResolvedAt,
 	}
 }
 
-// UniversalJournalEntry GORM struct
-type UniversalJournalEntry struct {
-	ID               string `gorm:"primaryKey"`
+// PayrollRunSnapshot GORM struct
+type PayrollRunSnapshot struct {
+	ID            string `gorm:"primaryKey"`
+	LegalEntityID string `gorm:"index"`
+	FiscalYear    int
+	PeriodNumber  int
+	TotalGrossPay decimal.Decimal `gorm:"type:numeric(18,4)"`
+	TotalNetPay   decimal.Decimal `gorm:"type:numeric(18,4)"`
+	ProcessedAt   time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainPayrollRunSnapshot(d *domain.PayrollRunSnapshot) *PayrollRunSnapshot {
+	if d == nil {
+		return nil
+	}
+	return &PayrollRunSnapshot{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		FiscalYear:    d.FiscalYear,
+		PeriodNumber:  d.PeriodNumber,
+		TotalGrossPay: d.TotalGrossPay,
+		TotalNetPay:   d.TotalNetPay,
+		ProcessedAt:   d.ProcessedAt,
+	}
+}
+
+func ToDomainPayrollRunSnapshot(dbModel *PayrollRunSnapshot) *domain.PayrollRunSnapshot {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.PayrollRunSnapshot{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		FiscalYear:    dbModel.FiscalYear,
+		PeriodNumber:  dbModel.PeriodNumber,
+		TotalGrossPay: dbModel.TotalGrossPay,
+		TotalNetPay:   dbModel.TotalNetPay,
+		ProcessedAt:   dbModel.ProcessedAt,
+	}
+}
+
+// RecurringCashItem GORM struct
+type RecurringCashItem struct {
+	ID             string  `gorm:"primaryKey"`
+	LegalEntityID  string  `gorm:"index"`
+	BankAccountID  *string `gorm:"index"`
+	Description    string
+	Amount         decimal.Decimal            `gorm:"type:numeric(18,4)"`
+	Frequency      domain.RecurrenceFrequency `gorm:"type:varchar(50)"`
+	NextOccurrence time.Time
+	EndDate        *time.Time
+	IsActive       bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	LegalEntity LegalEntity  `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	BankAccount *BankAccount `gorm:"foreignKey:BankAccountID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+func FromDomainRecurringCashItem(d *domain.RecurringCashItem) *RecurringCashItem {
+	if d == nil {
+		return nil
+	}
+	return &RecurringCashItem{
+		ID:             d.ID,
+		LegalEntityID:  d.LegalEntityID,
+		BankAccountID:  d.BankAccountID,
+		Description:    d.Description,
+		Amount:         d.Amount,
+		Frequency:      d.Frequency,
+		NextOccurrence: d.NextOccurrence,
+		EndDate:        d.EndDate,
+		IsActive:       d.IsActive,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func ToDomainRecurringCashItem(dbModel *RecurringCashItem) *domain.RecurringCashItem {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.RecurringCashItem{
+		ID:             dbModel.ID,
+		LegalEntityID:  dbModel.LegalEntityID,
+		BankAccountID:  dbModel.BankAccountID,
+		Description:    dbModel.Description,
+		Amount:         dbModel.Amount,
+		Frequency:      dbModel.Frequency,
+		NextOccurrence: dbModel.NextOccurrence,
+		EndDate:        dbModel.EndDate,
+		IsActive:       dbModel.IsActive,
+		CreatedAt:      dbModel.CreatedAt,
+		UpdatedAt:      dbModel.UpdatedAt,
+	}
+}
+
+// FxRevaluation GORM struct
+type FxRevaluation struct {
+	ID                 string `gorm:"primaryKey"`
+	LegalEntityID      string `gorm:"index;uniqueIndex:idx_fx_reval_source"`
+	FinancialPeriod    string `gorm:"type:varchar(7);uniqueIndex:idx_fx_reval_source"`
+	RevaluationDate    time.Time
+	SourceType         domain.FxRevaluationSource `gorm:"type:varchar(50);uniqueIndex:idx_fx_reval_source"`
+	SourceID           string                     `gorm:"uniqueIndex:idx_fx_reval_source"`
+	Currency           string                     `gorm:"type:varchar(3)"`
+	OpenAmount         decimal.Decimal            `gorm:"type:numeric(18,4)"`
+	PreviousRate       decimal.Decimal            `gorm:"type:numeric(18,8)"`
+	RevaluationRate    decimal.Decimal            `gorm:"type:numeric(18,8)"`
+	UnrealizedGainLoss decimal.Decimal            `gorm:"type:numeric(18,4)"`
+	JournalEntryID     *string                    `gorm:"index"`
+	CreatedAt          time.Time
+
+	LegalEntity  LegalEntity            `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	JournalEntry *UniversalJournalEntry `gorm:"foreignKey:JournalEntryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainFxRevaluation(d *domain.FxRevaluation) *FxRevaluation {
+	if d == nil {
+		return nil
+	}
+	return &FxRevaluation{
+		ID:                 d.ID,
+		LegalEntityID:      d.LegalEntityID,
+		FinancialPeriod:    d.FinancialPeriod,
+		RevaluationDate:    d.RevaluationDate,
+		SourceType:         d.SourceType,
+		SourceID:           d.SourceID,
+		Currency:           d.Currency,
+		OpenAmount:         d.OpenAmount,
+		PreviousRate:       d.PreviousRate,
+		RevaluationRate:    d.RevaluationRate,
+		UnrealizedGainLoss: d.UnrealizedGainLoss,
+		JournalEntryID:     d.JournalEntryID,
+		CreatedAt:          d.CreatedAt,
+	}
+}
+
+func ToDomainFxRevaluation(dbModel *FxRevaluation) *domain.FxRevaluation {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.FxRevaluation{
+		ID:                 dbModel.ID,
+		LegalEntityID:      dbModel.LegalEntityID,
+		FinancialPeriod:    dbModel.FinancialPeriod,
+		RevaluationDate:    dbModel.RevaluationDate,
+		SourceType:         dbModel.SourceType,
+		SourceID:           dbModel.SourceID,
+		Currency:           dbModel.Currency,
+		OpenAmount:         dbModel.OpenAmount,
+		PreviousRate:       dbModel.PreviousRate,
+		RevaluationRate:    dbModel.RevaluationRate,
+		UnrealizedGainLoss: dbModel.UnrealizedGainLoss,
+		JournalEntryID:     dbModel.JournalEntryID,
+		CreatedAt:          dbModel.CreatedAt,
+	}
+}
+
+// FiscalPeriod GORM struct
+type FiscalPeriod struct {
+	ID              string             `gorm:"primaryKey"`
+	LegalEntityID   string             `gorm:"uniqueIndex:idx_fiscal_period_entity"`
+	FinancialPeriod string             `gorm:"type:varchar(7);uniqueIndex:idx_fiscal_period_entity"`
+	State           domain.PeriodState `gorm:"type:varchar(20)"`
+	ClosingEntryID  *string
+	ClosedAt        *time.Time
+	UpdatedAt       time.Time
+
+	LegalEntity  LegalEntity            `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	ClosingEntry *UniversalJournalEntry `gorm:"foreignKey:ClosingEntryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainFiscalPeriod(d *domain.FiscalPeriod) *FiscalPeriod {
+	if d == nil {
+		return nil
+	}
+	return &FiscalPeriod{
+		ID:              d.ID,
+		LegalEntityID:   d.LegalEntityID,
+		FinancialPeriod: d.FinancialPeriod,
+		State:           d.State,
+		ClosingEntryID:  d.ClosingEntryID,
+		ClosedAt:        d.ClosedAt,
+		UpdatedAt:       d.UpdatedAt,
+	}
+}
+
+func ToDomainFiscalPeriod(dbModel *FiscalPeriod) *domain.FiscalPeriod {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.FiscalPeriod{
+		ID:              dbModel.ID,
+		LegalEntityID:   dbModel.LegalEntityID,
+		FinancialPeriod: dbModel.FinancialPeriod,
+		State:           dbModel.State,
+		ClosingEntryID:  dbModel.ClosingEntryID,
+		ClosedAt:        dbModel.ClosedAt,
+		UpdatedAt:       dbModel.UpdatedAt,
+	}
+}
+
+// IntercompanyTransaction GORM struct
+type IntercompanyTransaction struct {
+	ID                 string `gorm:"primaryKey"`
+	FromLegalEntityID  string `gorm:"index"`
+	ToLegalEntityID    string `gorm:"index"`
+	Description        string
+	PostingDate        time.Time
+	Currency           string          `gorm:"type:varchar(3)"`
+	Amount             decimal.Decimal `gorm:"type:numeric(18,4)"`
+	FromJournalEntryID string
+	ToJournalEntryID   string
+	CreatedAt          time.Time
+
+	FromLegalEntity  LegalEntity           `gorm:"foreignKey:FromLegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	ToLegalEntity    LegalEntity           `gorm:"foreignKey:ToLegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	FromJournalEntry UniversalJournalEntry `gorm:"foreignKey:FromJournalEntryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	ToJournalEntry   UniversalJournalEntry `gorm:"foreignKey:ToJournalEntryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainIntercompanyTransaction(d *domain.IntercompanyTransaction) *IntercompanyTransaction {
+	if d == nil {
+		return nil
+	}
+	return &IntercompanyTransaction{
+		ID:                 d.ID,
+		FromLegalEntityID:  d.FromLegalEntityID,
+		ToLegalEntityID:    d.ToLegalEntityID,
+		Description:        d.Description,
+		PostingDate:        d.PostingDate,
+		Currency:           d.Currency,
+		Amount:             d.Amount,
+		FromJournalEntryID: d.FromJournalEntryID,
+		ToJournalEntryID:   d.ToJournalEntryID,
+		CreatedAt:          d.CreatedAt,
+	}
+}
+
+func ToDomainIntercompanyTransaction(dbModel *IntercompanyTransaction) *domain.IntercompanyTransaction {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.IntercompanyTransaction{
+		ID:                 dbModel.ID,
+		FromLegalEntityID:  dbModel.FromLegalEntityID,
+		ToLegalEntityID:    dbModel.ToLegalEntityID,
+		Description:        dbModel.Description,
+		PostingDate:        dbModel.PostingDate,
+		Currency:           dbModel.Currency,
+		Amount:             dbModel.Amount,
+		FromJournalEntryID: dbModel.FromJournalEntryID,
+		ToJournalEntryID:   dbModel.ToJournalEntryID,
+		CreatedAt:          dbModel.CreatedAt,
+	}
+}
+
+// ConsolidationGroup GORM struct
+type ConsolidationGroup struct {
+	ID                string `gorm:"primaryKey"`
+	Code              string `gorm:"uniqueIndex"`
+	Name              string
+	ReportingCurrency string `gorm:"type:varchar(3)"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func FromDomainConsolidationGroup(d *domain.ConsolidationGroup) *ConsolidationGroup {
+	if d == nil {
+		return nil
+	}
+	return &ConsolidationGroup{
+		ID:                d.ID,
+		Code:              d.Code,
+		Name:              d.Name,
+		ReportingCurrency: d.ReportingCurrency,
+		CreatedAt:         d.CreatedAt,
+		UpdatedAt:         d.UpdatedAt,
+	}
+}
+
+func ToDomainConsolidationGroup(dbModel *ConsolidationGroup) *domain.ConsolidationGroup {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ConsolidationGroup{
+		ID:                dbModel.ID,
+		Code:              dbModel.Code,
+		Name:              dbModel.Name,
+		ReportingCurrency: dbModel.ReportingCurrency,
+		CreatedAt:         dbModel.CreatedAt,
+		UpdatedAt:         dbModel.UpdatedAt,
+	}
+}
+
+// ConsolidationGroupMember GORM struct
+type ConsolidationGroupMember struct {
+	ID                   string `gorm:"primaryKey"`
+	ConsolidationGroupID string `gorm:"uniqueIndex:idx_consolidation_member"`
+	LegalEntityID        string `gorm:"uniqueIndex:idx_consolidation_member"`
+
+	ConsolidationGroup ConsolidationGroup `gorm:"foreignKey:ConsolidationGroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	LegalEntity        LegalEntity        `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainConsolidationGroupMember(d *domain.ConsolidationGroupMember) *ConsolidationGroupMember {
+	if d == nil {
+		return nil
+	}
+	return &ConsolidationGroupMember{
+		ID:                   d.ID,
+		ConsolidationGroupID: d.ConsolidationGroupID,
+		LegalEntityID:        d.LegalEntityID,
+	}
+}
+
+func ToDomainConsolidationGroupMember(dbModel *ConsolidationGroupMember) *domain.ConsolidationGroupMember {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ConsolidationGroupMember{
+		ID:                   dbModel.ID,
+		ConsolidationGroupID: dbModel.ConsolidationGroupID,
+		LegalEntityID:        dbModel.LegalEntityID,
+	}
+}
+
+// AccountDetermination GORM struct
+type AccountDetermination struct {
+	ID            string            `gorm:"primaryKey"`
+	LegalEntityID string            `gorm:"uniqueIndex:idx_entity_posting_key"`
+	PostingKey    domain.PostingKey `gorm:"type:varchar(50);uniqueIndex:idx_entity_posting_key"`
+	AccountCode   string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainAccountDetermination(d *domain.AccountDetermination) *AccountDetermination {
+	if d == nil {
+		return nil
+	}
+	return &AccountDetermination{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		PostingKey:    d.PostingKey,
+		AccountCode:   d.AccountCode,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func ToDomainAccountDetermination(dbModel *AccountDetermination) *domain.AccountDetermination {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.AccountDetermination{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		PostingKey:    dbModel.PostingKey,
+		AccountCode:   dbModel.AccountCode,
+		CreatedAt:     dbModel.CreatedAt,
+		UpdatedAt:     dbModel.UpdatedAt,
+	}
+}
+
+// ChartOfAccounts GORM struct
+type ChartOfAccounts struct {
+	ID            string `gorm:"primaryKey"`
+	LegalEntityID string `gorm:"uniqueIndex:idx_entity_account"`
+	AccountCode   string `gorm:"uniqueIndex:idx_entity_account"`
+	AccountName   string
+	Type          domain.AccountType `gorm:"type:varchar(50)"`
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainChartOfAccounts(d *domain.ChartOfAccounts) *ChartOfAccounts {
+	if d == nil {
+		return nil
+	}
+	return &ChartOfAccounts{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		AccountCode:   d.AccountCode,
+		AccountName:   d.AccountName,
+		Type:          d.Type,
+		IsActive:      d.IsActive,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func ToDomainChartOfAccounts(dbModel *ChartOfAccounts) *domain.ChartOfAccounts {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ChartOfAccounts{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		AccountCode:   dbModel.AccountCode,
+		AccountName:   dbModel.AccountName,
+		Type:          dbModel.Type,
+		IsActive:      dbModel.IsActive,
+		CreatedAt:     dbModel.CreatedAt,
+		UpdatedAt:     dbModel.UpdatedAt,
+	}
+}
+
+// LegalEntity GORM struct
+type LegalEntity struct {
+	ID                    string `gorm:"primaryKey"`
+	CompanyCode           string `gorm:"uniqueIndex"`
+	CompanyName           string
+	FunctionalCurrency    string
+	TaxRegistrationNumber string
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+func FromDomainLegalEntity(d *domain.LegalEntity) *LegalEntity {
+	if d == nil {
+		return nil
+	}
+	return &LegalEntity{
+		ID:                    d.ID,
+		CompanyCode:           d.CompanyCode,
+		CompanyName:           d.CompanyName,
+		FunctionalCurrency:    d.FunctionalCurrency,
+		TaxRegistrationNumber: d.TaxRegistrationNumber,
+		CreatedAt:             d.CreatedAt,
+		UpdatedAt:             d.UpdatedAt,
+	}
+}
+
+func ToDomainLegalEntity(dbModel *LegalEntity) *domain.LegalEntity {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.LegalEntity{
+		ID:                    dbModel.ID,
+		CompanyCode:           dbModel.CompanyCode,
+		CompanyName:           dbModel.CompanyName,
+		FunctionalCurrency:    dbModel.FunctionalCurrency,
+		TaxRegistrationNumber: dbModel.TaxRegistrationNumber,
+		CreatedAt:             dbModel.CreatedAt,
+		UpdatedAt:             dbModel.UpdatedAt,
+	}
+}
+
+// UniversalJournalEntry GORM struct
+type UniversalJournalEntry struct {
+	ID               string `gorm:"primaryKey"`
 	LegalEntityID    string `gorm:"index"`
 	SourceModule     string
 	SourceDocumentID string
 	PostingDate      time.Time
 	FinancialPeriod  string
 	Status           domain.LedgerState `gorm:"type:varchar(50)"`
+	TemplateID       *string            `gorm:"index"`
+	ReversalDate     *time.Time         `gorm:"index"`
+	ReversalOfID     *string            `gorm:"index"`
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 
-	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	LegalEntity LegalEntity      `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Template    *JournalTemplate `gorm:"foreignKey:TemplateID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 }
 
 func FromDomainUniversalJournalEntry(d *domain.UniversalJournalEntry) *UniversalJournalEntry {
@@ -536,6 +1422,9 @@ func FromDomainUniversalJournalEntry(d *domain.UniversalJournalEntry) *Universal
 		PostingDate:      d.PostingDate,
 		FinancialPeriod:  d.FinancialPeriod,
 		Status:           d.Status,
+		TemplateID:       d.TemplateID,
+		ReversalDate:     d.ReversalDate,
+		ReversalOfID:     d.ReversalOfID,
 		CreatedAt:        d.CreatedAt,
 		UpdatedAt:        d.UpdatedAt,
 	}
@@ -553,20 +1442,311 @@ func ToDomainUniversalJournalEntry(dbModel *UniversalJournalEntry) *domain.Unive
 		PostingDate:      dbModel.PostingDate,
 		FinancialPeriod:  dbModel.FinancialPeriod,
 		Status:           dbModel.Status,
+		TemplateID:       dbModel.TemplateID,
+		ReversalDate:     dbModel.ReversalDate,
+		ReversalOfID:     dbModel.ReversalOfID,
 		CreatedAt:        dbModel.CreatedAt,
 		UpdatedAt:        dbModel.UpdatedAt,
 	}
 }
 
-// UniversalJournalLine GORM struct
-type UniversalJournalLine struct {
+// AllocationCycle GORM struct
+type AllocationCycle struct {
+	ID            string `gorm:"primaryKey"`
+	LegalEntityID string `gorm:"index"`
+	Name          string
+	Description   string
+	Method        domain.AllocationMethod `gorm:"type:varchar(50)"`
+	Driver        domain.AllocationDriver `gorm:"type:varchar(50)"`
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainAllocationCycle(d *domain.AllocationCycle) *AllocationCycle {
+	if d == nil {
+		return nil
+	}
+	return &AllocationCycle{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		Name:          d.Name,
+		Description:   d.Description,
+		Method:        d.Method,
+		Driver:        d.Driver,
+		IsActive:      d.IsActive,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func ToDomainAllocationCycle(dbModel *AllocationCycle) *domain.AllocationCycle {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.AllocationCycle{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		Name:          dbModel.Name,
+		Description:   dbModel.Description,
+		Method:        dbModel.Method,
+		Driver:        dbModel.Driver,
+		IsActive:      dbModel.IsActive,
+		CreatedAt:     dbModel.CreatedAt,
+		UpdatedAt:     dbModel.UpdatedAt,
+	}
+}
+
+// AllocationCycleMember GORM struct
+type AllocationCycleMember struct {
+	ID           string                `gorm:"primaryKey"`
+	CycleID      string                `gorm:"index"`
+	CostCenterID string                `gorm:"index"`
+	Role         domain.AllocationRole `gorm:"type:varchar(50)"`
+	Percentage   decimal.Decimal       `gorm:"type:numeric(9,4)"`
+
+	Cycle      AllocationCycle `gorm:"foreignKey:CycleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CostCenter CostCenter      `gorm:"foreignKey:CostCenterID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainAllocationCycleMember(d *domain.AllocationCycleMember) *AllocationCycleMember {
+	if d == nil {
+		return nil
+	}
+	return &AllocationCycleMember{
+		ID:           d.ID,
+		CycleID:      d.CycleID,
+		CostCenterID: d.CostCenterID,
+		Role:         d.Role,
+		Percentage:   d.Percentage,
+	}
+}
+
+func ToDomainAllocationCycleMember(dbModel *AllocationCycleMember) *domain.AllocationCycleMember {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.AllocationCycleMember{
+		ID:           dbModel.ID,
+		CycleID:      dbModel.CycleID,
+		CostCenterID: dbModel.CostCenterID,
+		Role:         dbModel.Role,
+		Percentage:   dbModel.Percentage,
+	}
+}
+
+// AllocationRun GORM struct
+type AllocationRun struct {
+	ID              string                     `gorm:"primaryKey"`
+	CycleID         string                     `gorm:"index"`
+	LegalEntityID   string                     `gorm:"index"`
+	FinancialPeriod string                     `gorm:"type:varchar(7);index"`
+	Status          domain.AllocationRunStatus `gorm:"type:varchar(50)"`
+	JournalEntryID  string
+	ReversalEntryID *string
+	TotalAllocated  decimal.Decimal `gorm:"type:numeric(18,4)"`
+	PostedBy        string
+	CreatedAt       time.Time
+	ReversedAt      *time.Time
+
+	Cycle AllocationCycle `gorm:"foreignKey:CycleID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainAllocationRun(d *domain.AllocationRun) *AllocationRun {
+	if d == nil {
+		return nil
+	}
+	return &AllocationRun{
+		ID:              d.ID,
+		CycleID:         d.CycleID,
+		LegalEntityID:   d.LegalEntityID,
+		FinancialPeriod: d.FinancialPeriod,
+		Status:          d.Status,
+		JournalEntryID:  d.JournalEntryID,
+		ReversalEntryID: d.ReversalEntryID,
+		TotalAllocated:  d.TotalAllocated,
+		PostedBy:        d.PostedBy,
+		CreatedAt:       d.CreatedAt,
+		ReversedAt:      d.ReversedAt,
+	}
+}
+
+func ToDomainAllocationRun(dbModel *AllocationRun) *domain.AllocationRun {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.AllocationRun{
+		ID:              dbModel.ID,
+		CycleID:         dbModel.CycleID,
+		LegalEntityID:   dbModel.LegalEntityID,
+		FinancialPeriod: dbModel.FinancialPeriod,
+		Status:          dbModel.Status,
+		JournalEntryID:  dbModel.JournalEntryID,
+		ReversalEntryID: dbModel.ReversalEntryID,
+		TotalAllocated:  dbModel.TotalAllocated,
+		PostedBy:        dbModel.PostedBy,
+		CreatedAt:       dbModel.CreatedAt,
+		ReversedAt:      dbModel.ReversedAt,
+	}
+}
+
+// StatisticalKeyFigure GORM struct
+type StatisticalKeyFigure struct {
+	ID              string                  `gorm:"primaryKey"`
+	CostCenterID    string                  `gorm:"uniqueIndex:idx_key_figure_cc_driver_period"`
+	Driver          domain.AllocationDriver `gorm:"type:varchar(50);uniqueIndex:idx_key_figure_cc_driver_period"`
+	FinancialPeriod string                  `gorm:"type:varchar(7);uniqueIndex:idx_key_figure_cc_driver_period"`
+	Value           decimal.Decimal         `gorm:"type:numeric(18,4)"`
+	Source          string
+	UpdatedAt       time.Time
+
+	CostCenter CostCenter `gorm:"foreignKey:CostCenterID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func FromDomainStatisticalKeyFigure(d *domain.StatisticalKeyFigure) *StatisticalKeyFigure {
+	if d == nil {
+		return nil
+	}
+	return &StatisticalKeyFigure{
+		ID:              d.ID,
+		CostCenterID:    d.CostCenterID,
+		Driver:          d.Driver,
+		FinancialPeriod: d.FinancialPeriod,
+		Value:           d.Value,
+		Source:          d.Source,
+		UpdatedAt:       d.UpdatedAt,
+	}
+}
+
+func ToDomainStatisticalKeyFigure(dbModel *StatisticalKeyFigure) *domain.StatisticalKeyFigure {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.StatisticalKeyFigure{
+		ID:              dbModel.ID,
+		CostCenterID:    dbModel.CostCenterID,
+		Driver:          dbModel.Driver,
+		FinancialPeriod: dbModel.FinancialPeriod,
+		Value:           dbModel.Value,
+		Source:          dbModel.Source,
+		UpdatedAt:       dbModel.UpdatedAt,
+	}
+}
+
+// JournalTemplate GORM struct
+type JournalTemplate struct {
+	ID            string `gorm:"primaryKey"`
+	LegalEntityID string `gorm:"index"`
+	Name          string
+	Description   string
+	Currency      string                     `gorm:"type:varchar(3)"`
+	Frequency     domain.RecurrenceFrequency `gorm:"type:varchar(50)"`
+	StartDate     time.Time
+	NextRunDate   time.Time `gorm:"index"`
+	EndDate       *time.Time
+	AutoReverse   bool
+	IsActive      bool
+	LastRunDate   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainJournalTemplate(d *domain.JournalTemplate) *JournalTemplate {
+	if d == nil {
+		return nil
+	}
+	return &JournalTemplate{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		Name:          d.Name,
+		Description:   d.Description,
+		Currency:      d.Currency,
+		Frequency:     d.Frequency,
+		StartDate:     d.StartDate,
+		NextRunDate:   d.NextRunDate,
+		EndDate:       d.EndDate,
+		AutoReverse:   d.AutoReverse,
+		IsActive:      d.IsActive,
+		LastRunDate:   d.LastRunDate,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func ToDomainJournalTemplate(dbModel *JournalTemplate) *domain.JournalTemplate {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.JournalTemplate{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		Name:          dbModel.Name,
+		Description:   dbModel.Description,
+		Currency:      dbModel.Currency,
+		Frequency:     dbModel.Frequency,
+		StartDate:     dbModel.StartDate,
+		NextRunDate:   dbModel.NextRunDate,
+		EndDate:       dbModel.EndDate,
+		AutoReverse:   dbModel.AutoReverse,
+		IsActive:      dbModel.IsActive,
+		LastRunDate:   dbModel.LastRunDate,
+		CreatedAt:     dbModel.CreatedAt,
+		UpdatedAt:     dbModel.UpdatedAt,
+	}
+}
+
+// JournalTemplateLine GORM struct
+type JournalTemplateLine struct {
+	ID          string          `gorm:"primaryKey"`
+	TemplateID  string          `gorm:"index"`
+	AccountID   string          `gorm:"index"`
+	Amount      decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Description string
+
+	Template JournalTemplate `gorm:"foreignKey:TemplateID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Account  ChartOfAccounts `gorm:"foreignKey:AccountID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainJournalTemplateLine(d *domain.JournalTemplateLine) *JournalTemplateLine {
+	if d == nil {
+		return nil
+	}
+	return &JournalTemplateLine{
+		ID:          d.ID,
+		TemplateID:  d.TemplateID,
+		AccountID:   d.AccountID,
+		Amount:      d.Amount,
+		Description: d.Description,
+	}
+}
+
+func ToDomainJournalTemplateLine(dbModel *JournalTemplateLine) *domain.JournalTemplateLine {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.JournalTemplateLine{
+		ID:          dbModel.ID,
+		TemplateID:  dbModel.TemplateID,
+		AccountID:   dbModel.AccountID,
+		Amount:      dbModel.Amount,
+		Description: dbModel.Description,
+	}
+}
+
+// UniversalJournalLine GORM struct
+type UniversalJournalLine struct {
 	ID                    string          `gorm:"primaryKey"`
 	JournalEntryID        string          `gorm:"index"`
 	AccountID             string          `gorm:"index"`
 	AmountFunctional      decimal.Decimal `gorm:"type:numeric(18,4)"`
 	AmountTransactional   decimal.Decimal `gorm:"type:numeric(18,4)"`
 	CurrencyTransactional string
-	TrackingDimensions    []byte `gorm:"type:jsonb"` // Marshalled json of interface{}
+	ExchangeRate          decimal.Decimal `gorm:"type:numeric(18,8)"`
+	TrackingDimensions    []byte          `gorm:"type:jsonb"` // Marshalled json of interface{}
 
 	JournalEntry UniversalJournalEntry `gorm:"foreignKey:JournalEntryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Account      ChartOfAccounts       `gorm:"foreignKey:AccountID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
@@ -584,320 +1764,1109 @@ func FromDomainUniversalJournalLine(d *domain.UniversalJournalLine) *UniversalJo
 		AmountFunctional:      d.AmountFunctional,
 		AmountTransactional:   d.AmountTransactional,
 		CurrencyTransactional: d.CurrencyTransactional,
+		ExchangeRate:          d.ExchangeRate,
 		TrackingDimensions:    tdBytes,
 	}
 }
 
-func ToDomainUniversalJournalLine(dbModel *UniversalJournalLine) *domain.UniversalJournalLine {
+func ToDomainUniversalJournalLine(dbModel *UniversalJournalLine) *domain.UniversalJournalLine {
+	if dbModel == nil {
+		return nil
+	}
+	var td interface{}
+	if len(dbModel.TrackingDimensions) > 0 {
+		_ = json.Unmarshal(dbModel.TrackingDimensions, &td)
+	}
+	return &domain.UniversalJournalLine{
+		ID:                    dbModel.ID,
+		JournalEntryID:        dbModel.JournalEntryID,
+		AccountID:             dbModel.AccountID,
+		AmountFunctional:      dbModel.AmountFunctional,
+		AmountTransactional:   dbModel.AmountTransactional,
+		CurrencyTransactional: dbModel.CurrencyTransactional,
+		ExchangeRate:          dbModel.ExchangeRate,
+		TrackingDimensions:    td,
+	}
+}
+
+// DepreciationScheduleLine GORM struct
+type DepreciationScheduleLine struct {
+	ID                 string `gorm:"primaryKey"`
+	FixedAssetID       string `gorm:"index"`
+	FiscalYear         int
+	PeriodNumber       int
+	DepreciationAmount decimal.Decimal `gorm:"type:numeric(18,4)"`
+	IsPosted           bool
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+
+	FixedAsset CapitalAsset `gorm:"foreignKey:FixedAssetID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainDepreciationScheduleLine(d *domain.DepreciationScheduleLine) *DepreciationScheduleLine {
+	if d == nil {
+		return nil
+	}
+	return &DepreciationScheduleLine{
+		ID:                 d.ID,
+		FixedAssetID:       d.FixedAssetID,
+		FiscalYear:         d.FiscalYear,
+		PeriodNumber:       d.PeriodNumber,
+		DepreciationAmount: d.DepreciationAmount,
+		IsPosted:           d.IsPosted,
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
+	}
+}
+
+func ToDomainDepreciationScheduleLine(dbModel *DepreciationScheduleLine) *domain.DepreciationScheduleLine {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.DepreciationScheduleLine{
+		ID:                 dbModel.ID,
+		FixedAssetID:       dbModel.FixedAssetID,
+		FiscalYear:         dbModel.FiscalYear,
+		PeriodNumber:       dbModel.PeriodNumber,
+		DepreciationAmount: dbModel.DepreciationAmount,
+		IsPosted:           dbModel.IsPosted,
+		CreatedAt:          dbModel.CreatedAt,
+		UpdatedAt:          dbModel.UpdatedAt,
+	}
+}
+
+// CapitalAsset GORM struct
+type CapitalAsset struct {
+	ID                      string `gorm:"primaryKey"`
+	LegalEntityID           string `gorm:"index"`
+	AssetTag                string
+	EamEquipmentID          *string         `gorm:"index"`
+	AcquisitionCost         decimal.Decimal `gorm:"type:numeric(18,4)"`
+	AccumulatedDepreciation decimal.Decimal `gorm:"type:numeric(18,4)"`
+	UsefulLifeMonths        int
+	CapitalizationDate      time.Time
+	Status                  domain.AssetState             `gorm:"type:varchar(50)"`
+	DepreciationMethod      domain.DepreciationMethod     `gorm:"type:varchar(50)"`
+	DepreciationConvention  domain.DepreciationConvention `gorm:"type:varchar(50)"`
+	SalvageValue            decimal.Decimal               `gorm:"type:numeric(18,4)"`
+	DecliningBalanceRate    decimal.Decimal               `gorm:"type:numeric(9,4)"`
+	EstimatedTotalUnits     decimal.Decimal               `gorm:"type:numeric(18,4)"`
+	DisposalDate            *time.Time
+	DisposalProceeds        decimal.Decimal `gorm:"type:numeric(18,4)"`
+	DisposalEntryID         *string         `gorm:"index"`
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainCapitalAsset(d *domain.CapitalAsset) *CapitalAsset {
+	if d == nil {
+		return nil
+	}
+	return &CapitalAsset{
+		ID:                      d.ID,
+		LegalEntityID:           d.LegalEntityID,
+		AssetTag:                d.AssetTag,
+		EamEquipmentID:          d.EamEquipmentID,
+		AcquisitionCost:         d.AcquisitionCost,
+		AccumulatedDepreciation: d.AccumulatedDepreciation,
+		UsefulLifeMonths:        d.UsefulLifeMonths,
+		CapitalizationDate:      d.CapitalizationDate,
+		Status:                  d.Status,
+		DepreciationMethod:      d.DepreciationMethod,
+		DepreciationConvention:  d.DepreciationConvention,
+		SalvageValue:            d.SalvageValue,
+		DecliningBalanceRate:    d.DecliningBalanceRate,
+		EstimatedTotalUnits:     d.EstimatedTotalUnits,
+		DisposalDate:            d.DisposalDate,
+		DisposalProceeds:        d.DisposalProceeds,
+		DisposalEntryID:         d.DisposalEntryID,
+		CreatedAt:               d.CreatedAt,
+		UpdatedAt:               d.UpdatedAt,
+	}
+}
+
+func ToDomainCapitalAsset(dbModel *CapitalAsset) *domain.CapitalAsset {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.CapitalAsset{
+		ID:                      dbModel.ID,
+		LegalEntityID:           dbModel.LegalEntityID,
+		AssetTag:                dbModel.AssetTag,
+		EamEquipmentID:          dbModel.EamEquipmentID,
+		AcquisitionCost:         dbModel.AcquisitionCost,
+		AccumulatedDepreciation: dbModel.AccumulatedDepreciation,
+		UsefulLifeMonths:        dbModel.UsefulLifeMonths,
+		CapitalizationDate:      dbModel.CapitalizationDate,
+		Status:                  dbModel.Status,
+		DepreciationMethod:      dbModel.DepreciationMethod,
+		DepreciationConvention:  dbModel.DepreciationConvention,
+		SalvageValue:            dbModel.SalvageValue,
+		DecliningBalanceRate:    dbModel.DecliningBalanceRate,
+		EstimatedTotalUnits:     dbModel.EstimatedTotalUnits,
+		DisposalDate:            dbModel.DisposalDate,
+		DisposalProceeds:        dbModel.DisposalProceeds,
+		DisposalEntryID:         dbModel.DisposalEntryID,
+		CreatedAt:               dbModel.CreatedAt,
+		UpdatedAt:               dbModel.UpdatedAt,
+	}
+}
+
+// AssetUsageRecord GORM struct
+type AssetUsageRecord struct {
+	ID           string `gorm:"primaryKey"`
+	FixedAssetID string `gorm:"index"`
+	UsageDate    time.Time
+	Units        decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Source       string
+	CreatedAt    time.Time
+
+	FixedAsset CapitalAsset `gorm:"foreignKey:FixedAssetID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainAssetUsageRecord(d *domain.AssetUsageRecord) *AssetUsageRecord {
+	if d == nil {
+		return nil
+	}
+	return &AssetUsageRecord{
+		ID:           d.ID,
+		FixedAssetID: d.FixedAssetID,
+		UsageDate:    d.UsageDate,
+		Units:        d.Units,
+		Source:       d.Source,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+func ToDomainAssetUsageRecord(dbModel *AssetUsageRecord) *domain.AssetUsageRecord {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.AssetUsageRecord{
+		ID:           dbModel.ID,
+		FixedAssetID: dbModel.FixedAssetID,
+		UsageDate:    dbModel.UsageDate,
+		Units:        dbModel.Units,
+		Source:       dbModel.Source,
+		CreatedAt:    dbModel.CreatedAt,
+	}
+}
+
+// KafkaEventInbox GORM struct
+type KafkaEventInbox struct {
+	AttemptCount     int    `gorm:"type:integer;default:0;not null"`
+	EventID          string `gorm:"primaryKey"`
+	EventType        string
+	ProcessedAt      time.Time
+	ProcessingStatus domain.EventProcessingStatus `gorm:"type:varchar(50)"`
+	Payload          []byte                       `gorm:"type:jsonb"` // Marshalled json of interface{}
+}
+
+func FromDomainKafkaEventInbox(d *domain.KafkaEventInbox) *KafkaEventInbox {
+	if d == nil {
+		return nil
+	}
+	pBytes, _ := json.Marshal(d.Payload)
+	return &KafkaEventInbox{
+		AttemptCount:     0,
+		EventID:          d.EventID,
+		EventType:        d.EventType,
+		ProcessedAt:      d.ProcessedAt,
+		ProcessingStatus: d.ProcessingStatus,
+		Payload:          pBytes,
+	}
+}
+
+func ToDomainKafkaEventInbox(dbModel *KafkaEventInbox) *domain.KafkaEventInbox {
+	if dbModel == nil {
+		return nil
+	}
+	var p interface{}
+	if len(dbModel.Payload) > 0 {
+		_ = json.Unmarshal(dbModel.Payload, &p)
+	}
+	return &domain.KafkaEventInbox{
+		EventID:          dbModel.EventID,
+		EventType:        dbModel.EventType,
+		ProcessedAt:      dbModel.ProcessedAt,
+		ProcessingStatus: dbModel.ProcessingStatus,
+		Payload:          p,
+	}
+}
+
+// TransactionalOutbox GORM struct
+type TransactionalOutbox struct {
+	ID          string `gorm:"primaryKey"`
+	EventType   string
+	AggregateID string
+	Payload     []byte              `gorm:"type:jsonb"` // Marshalled json of interface{}
+	Status      domain.OutboxStatus `gorm:"type:varchar(50)"`
+	CreatedAt   time.Time
+}
+
+func FromDomainTransactionalOutbox(d *domain.TransactionalOutbox) *TransactionalOutbox {
+	if d == nil {
+		return nil
+	}
+	pBytes, _ := json.Marshal(d.Payload)
+	return &TransactionalOutbox{
+		ID:          d.ID,
+		EventType:   d.EventType,
+		AggregateID: d.AggregateID,
+		Payload:     pBytes,
+		Status:      d.Status,
+		CreatedAt:   d.CreatedAt,
+	}
+}
+
+func ToDomainTransactionalOutbox(dbModel *TransactionalOutbox) *domain.TransactionalOutbox {
+	if dbModel == nil {
+		return nil
+	}
+	var p interface{}
+	if len(dbModel.Payload) > 0 {
+		_ = json.Unmarshal(dbModel.Payload, &p)
+	}
+	return &domain.TransactionalOutbox{
+		ID:          dbModel.ID,
+		EventType:   dbModel.EventType,
+		AggregateID: dbModel.AggregateID,
+		Payload:     p,
+		Status:      dbModel.Status,
+		CreatedAt:   dbModel.CreatedAt,
+	}
+}
+
+// ApVendorBill GORM struct
+type ApVendorBill struct {
+	ID              string `gorm:"primaryKey"`
+	LegalEntityID   string `gorm:"index"`
+	BillNumber      string
+	VendorID        string
+	PurchaseOrderID string
+	TotalAmount     decimal.Decimal `gorm:"type:numeric(18,4)"`
+	TaxAmount       decimal.Decimal `gorm:"type:numeric(18,4)"`
+	AmountPaid      decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Currency        string          `gorm:"type:varchar(3)"`
+	ExchangeRate    decimal.Decimal `gorm:"type:numeric(18,8)"`
+	DueDate         time.Time
+	Status          domain.PaymentStatus   `gorm:"type:varchar(50)"`
+	MatchStatus     domain.BillMatchStatus `gorm:"type:varchar(50);index"`
+	PaymentHold     bool
+	OverrideReason  *string
+	OverriddenBy    *string
+	MatchedAt       *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainApVendorBill(d *domain.ApVendorBill) *ApVendorBill {
+	if d == nil {
+		return nil
+	}
+	return &ApVendorBill{
+		ID:              d.ID,
+		LegalEntityID:   d.LegalEntityID,
+		BillNumber:      d.BillNumber,
+		VendorID:        d.VendorID,
+		PurchaseOrderID: d.PurchaseOrderID,
+		TotalAmount:     d.TotalAmount,
+		TaxAmount:       d.TaxAmount,
+		AmountPaid:      d.AmountPaid,
+		Currency:        d.Currency,
+		ExchangeRate:    d.ExchangeRate,
+		DueDate:         d.DueDate,
+		Status:          d.Status,
+		MatchStatus:     d.MatchStatus,
+		PaymentHold:     d.PaymentHold,
+		OverrideReason:  d.OverrideReason,
+		OverriddenBy:    d.OverriddenBy,
+		MatchedAt:       d.MatchedAt,
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
+	}
+}
+
+func ToDomainApVendorBill(dbModel *ApVendorBill) *domain.ApVendorBill {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ApVendorBill{
+		ID:              dbModel.ID,
+		LegalEntityID:   dbModel.LegalEntityID,
+		BillNumber:      dbModel.BillNumber,
+		VendorID:        dbModel.VendorID,
+		PurchaseOrderID: dbModel.PurchaseOrderID,
+		TotalAmount:     dbModel.TotalAmount,
+		TaxAmount:       dbModel.TaxAmount,
+		AmountPaid:      dbModel.AmountPaid,
+		Currency:        dbModel.Currency,
+		ExchangeRate:    dbModel.ExchangeRate,
+		DueDate:         dbModel.DueDate,
+		Status:          dbModel.Status,
+		MatchStatus:     dbModel.MatchStatus,
+		PaymentHold:     dbModel.PaymentHold,
+		OverrideReason:  dbModel.OverrideReason,
+		OverriddenBy:    dbModel.OverriddenBy,
+		MatchedAt:       dbModel.MatchedAt,
+		CreatedAt:       dbModel.CreatedAt,
+		UpdatedAt:       dbModel.UpdatedAt,
+	}
+}
+
+// ApVendorBillLine GORM struct
+type ApVendorBillLine struct {
+	ID         string `gorm:"primaryKey"`
+	BillID     string `gorm:"index"`
+	MaterialID string
+	Quantity   decimal.Decimal `gorm:"type:numeric(18,4)"`
+	UnitPrice  decimal.Decimal `gorm:"type:numeric(18,4)"`
+	LineAmount decimal.Decimal `gorm:"type:numeric(18,4)"`
+	CreatedAt  time.Time
+
+	Bill ApVendorBill `gorm:"foreignKey:BillID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func FromDomainApVendorBillLine(d *domain.ApVendorBillLine) *ApVendorBillLine {
+	if d == nil {
+		return nil
+	}
+	return &ApVendorBillLine{
+		ID:         d.ID,
+		BillID:     d.BillID,
+		MaterialID: d.MaterialID,
+		Quantity:   d.Quantity,
+		UnitPrice:  d.UnitPrice,
+		LineAmount: d.LineAmount,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+func ToDomainApVendorBillLine(dbModel *ApVendorBillLine) *domain.ApVendorBillLine {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ApVendorBillLine{
+		ID:         dbModel.ID,
+		BillID:     dbModel.BillID,
+		MaterialID: dbModel.MaterialID,
+		Quantity:   dbModel.Quantity,
+		UnitPrice:  dbModel.UnitPrice,
+		LineAmount: dbModel.LineAmount,
+		CreatedAt:  dbModel.CreatedAt,
+	}
+}
+
+// PurchaseOrderLine GORM struct
+type PurchaseOrderLine struct {
+	ID              string `gorm:"primaryKey"`
+	PurchaseOrderID string `gorm:"index"`
+	VendorID        string
+	MaterialID      string
+	QuantityOrdered decimal.Decimal `gorm:"type:numeric(18,4)"`
+	UnitPrice       decimal.Decimal `gorm:"type:numeric(18,4)"`
+	CreatedAt       time.Time
+}
+
+func FromDomainPurchaseOrderLine(d *domain.PurchaseOrderLine) *PurchaseOrderLine {
+	if d == nil {
+		return nil
+	}
+	return &PurchaseOrderLine{
+		ID:              d.ID,
+		PurchaseOrderID: d.PurchaseOrderID,
+		VendorID:        d.VendorID,
+		MaterialID:      d.MaterialID,
+		QuantityOrdered: d.QuantityOrdered,
+		UnitPrice:       d.UnitPrice,
+		CreatedAt:       d.CreatedAt,
+	}
+}
+
+func ToDomainPurchaseOrderLine(dbModel *PurchaseOrderLine) *domain.PurchaseOrderLine {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.PurchaseOrderLine{
+		ID:              dbModel.ID,
+		PurchaseOrderID: dbModel.PurchaseOrderID,
+		VendorID:        dbModel.VendorID,
+		MaterialID:      dbModel.MaterialID,
+		QuantityOrdered: dbModel.QuantityOrdered,
+		UnitPrice:       dbModel.UnitPrice,
+		CreatedAt:       dbModel.CreatedAt,
+	}
+}
+
+// GoodsReceiptLine GORM struct
+type GoodsReceiptLine struct {
+	ID               string `gorm:"primaryKey"`
+	ReceiptID        string `gorm:"index"`
+	PurchaseOrderID  string `gorm:"index"`
+	MaterialID       string
+	QuantityReceived decimal.Decimal `gorm:"type:numeric(18,4)"`
+	ReceivedDate     time.Time
+	CreatedAt        time.Time
+}
+
+func FromDomainGoodsReceiptLine(d *domain.GoodsReceiptLine) *GoodsReceiptLine {
+	if d == nil {
+		return nil
+	}
+	return &GoodsReceiptLine{
+		ID:               d.ID,
+		ReceiptID:        d.ReceiptID,
+		PurchaseOrderID:  d.PurchaseOrderID,
+		MaterialID:       d.MaterialID,
+		QuantityReceived: d.QuantityReceived,
+		ReceivedDate:     d.ReceivedDate,
+		CreatedAt:        d.CreatedAt,
+	}
+}
+
+func ToDomainGoodsReceiptLine(dbModel *GoodsReceiptLine) *domain.GoodsReceiptLine {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.GoodsReceiptLine{
+		ID:               dbModel.ID,
+		ReceiptID:        dbModel.ReceiptID,
+		PurchaseOrderID:  dbModel.PurchaseOrderID,
+		MaterialID:       dbModel.MaterialID,
+		QuantityReceived: dbModel.QuantityReceived,
+		ReceivedDate:     dbModel.ReceivedDate,
+		CreatedAt:        dbModel.CreatedAt,
+	}
+}
+
+// MatchTolerance GORM struct
+type MatchTolerance struct {
+	ID                       string          `gorm:"primaryKey"`
+	VendorID                 string          `gorm:"uniqueIndex"`
+	PriceTolerancePercent    decimal.Decimal `gorm:"type:numeric(9,4)"`
+	QuantityTolerancePercent decimal.Decimal `gorm:"type:numeric(9,4)"`
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+}
+
+func FromDomainMatchTolerance(d *domain.MatchTolerance) *MatchTolerance {
+	if d == nil {
+		return nil
+	}
+	return &MatchTolerance{
+		ID:                       d.ID,
+		VendorID:                 d.VendorID,
+		PriceTolerancePercent:    d.PriceTolerancePercent,
+		QuantityTolerancePercent: d.QuantityTolerancePercent,
+		CreatedAt:                d.CreatedAt,
+		UpdatedAt:                d.UpdatedAt,
+	}
+}
+
+func ToDomainMatchTolerance(dbModel *MatchTolerance) *domain.MatchTolerance {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.MatchTolerance{
+		ID:                       dbModel.ID,
+		VendorID:                 dbModel.VendorID,
+		PriceTolerancePercent:    dbModel.PriceTolerancePercent,
+		QuantityTolerancePercent: dbModel.QuantityTolerancePercent,
+		CreatedAt:                dbModel.CreatedAt,
+		UpdatedAt:                dbModel.UpdatedAt,
+	}
+}
+
+// ArInvoice GORM struct
+type ArInvoice struct {
+	ID            string `gorm:"primaryKey"`
+	LegalEntityID string `gorm:"index"`
+	InvoiceNumber string
+	CustomerID    string
+	SalesOrderID  string
+	TotalAmount   decimal.Decimal `gorm:"type:numeric(18,4)"`
+	TaxAmount     decimal.Decimal `gorm:"type:numeric(18,4)"`
+	AmountPaid    decimal.Decimal `gorm:"type:numeric(18,4)"`
+	FeesCharged   decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Currency      string          `gorm:"type:varchar(3)"`
+	ExchangeRate  decimal.Decimal `gorm:"type:numeric(18,8)"`
+	DueDate       time.Time
+	Status        domain.PaymentStatus `gorm:"type:varchar(50)"`
+	DunningLevel  int
+	LastDunnedAt  *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainArInvoice(d *domain.ArInvoice) *ArInvoice {
+	if d == nil {
+		return nil
+	}
+	return &ArInvoice{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		InvoiceNumber: d.InvoiceNumber,
+		CustomerID:    d.CustomerID,
+		SalesOrderID:  d.SalesOrderID,
+		TotalAmount:   d.TotalAmount,
+		TaxAmount:     d.TaxAmount,
+		AmountPaid:    d.AmountPaid,
+		FeesCharged:   d.FeesCharged,
+		Currency:      d.Currency,
+		ExchangeRate:  d.ExchangeRate,
+		DueDate:       d.DueDate,
+		Status:        d.Status,
+		DunningLevel:  d.DunningLevel,
+		LastDunnedAt:  d.LastDunnedAt,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func ToDomainArInvoice(dbModel *ArInvoice) *domain.ArInvoice {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ArInvoice{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		InvoiceNumber: dbModel.InvoiceNumber,
+		CustomerID:    dbModel.CustomerID,
+		SalesOrderID:  dbModel.SalesOrderID,
+		TotalAmount:   dbModel.TotalAmount,
+		TaxAmount:     dbModel.TaxAmount,
+		AmountPaid:    dbModel.AmountPaid,
+		FeesCharged:   dbModel.FeesCharged,
+		Currency:      dbModel.Currency,
+		ExchangeRate:  dbModel.ExchangeRate,
+		DueDate:       dbModel.DueDate,
+		Status:        dbModel.Status,
+		DunningLevel:  dbModel.DunningLevel,
+		LastDunnedAt:  dbModel.LastDunnedAt,
+		CreatedAt:     dbModel.CreatedAt,
+		UpdatedAt:     dbModel.UpdatedAt,
+	}
+}
+
+// PaymentAllocation GORM struct
+type PaymentAllocation struct {
+	ID         string                  `gorm:"primaryKey"`
+	SourceType domain.AllocationSource `gorm:"type:varchar(30)"`
+	SourceID   string                  `gorm:"index"`
+	InvoiceID  *string                 `gorm:"index"`
+	BillID     *string                 `gorm:"index"`
+	Amount     decimal.Decimal         `gorm:"type:numeric(18,4)"`
+	CreatedAt  time.Time
+}
+
+func FromDomainPaymentAllocation(d *domain.PaymentAllocation) *PaymentAllocation {
+	if d == nil {
+		return nil
+	}
+	return &PaymentAllocation{
+		ID:         d.ID,
+		SourceType: d.SourceType,
+		SourceID:   d.SourceID,
+		InvoiceID:  d.InvoiceID,
+		BillID:     d.BillID,
+		Amount:     d.Amount,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+func ToDomainPaymentAllocation(dbModel *PaymentAllocation) *domain.PaymentAllocation {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.PaymentAllocation{
+		ID:         dbModel.ID,
+		SourceType: dbModel.SourceType,
+		SourceID:   dbModel.SourceID,
+		InvoiceID:  dbModel.InvoiceID,
+		BillID:     dbModel.BillID,
+		Amount:     dbModel.Amount,
+		CreatedAt:  dbModel.CreatedAt,
+	}
+}
+
+// ArCreditMemo GORM struct
+type ArCreditMemo struct {
+	ID               string          `gorm:"primaryKey"`
+	LegalEntityID    string          `gorm:"index"`
+	CreditMemoNumber string          `gorm:"uniqueIndex"`
+	CustomerID       string          `gorm:"index"`
+	InvoiceID        string          `gorm:"index"`
+	Amount           decimal.Decimal `gorm:"type:numeric(18,4)"`
+	Currency         string          `gorm:"type:varchar(3)"`
+	Reason           string
+	CreatedAt        time.Time
+
+	Invoice ArInvoice `gorm:"foreignKey:InvoiceID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func FromDomainArCreditMemo(d *domain.ArCreditMemo) *ArCreditMemo {
+	if d == nil {
+		return nil
+	}
+	return &ArCreditMemo{
+		ID:               d.ID,
+		LegalEntityID:    d.LegalEntityID,
+		CreditMemoNumber: d.CreditMemoNumber,
+		CustomerID:       d.CustomerID,
+		InvoiceID:        d.InvoiceID,
+		Amount:           d.Amount,
+		Currency:         d.Currency,
+		Reason:           d.Reason,
+		CreatedAt:        d.CreatedAt,
+	}
+}
+
+func ToDomainArCreditMemo(dbModel *ArCreditMemo) *domain.ArCreditMemo {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.ArCreditMemo{
+		ID:               dbModel.ID,
+		LegalEntityID:    dbModel.LegalEntityID,
+		CreditMemoNumber: dbModel.CreditMemoNumber,
+		CustomerID:       dbModel.CustomerID,
+		InvoiceID:        dbModel.InvoiceID,
+		Amount:           dbModel.Amount,
+		Currency:         dbModel.Currency,
+		Reason:           dbModel.Reason,
+		CreatedAt:        dbModel.CreatedAt,
+	}
+}
+
+// OnAccountCredit GORM struct
+type OnAccountCredit struct {
+	ID               string                  `gorm:"primaryKey"`
+	LegalEntityID    string                  `gorm:"index"`
+	CounterpartyType domain.CounterpartyType `gorm:"type:varchar(20);index:idx_on_account_party"`
+	CounterpartyID   string                  `gorm:"index:idx_on_account_party"`
+	SourceType       domain.AllocationSource `gorm:"type:varchar(30)"`
+	SourceID         string
+	Currency         string          `gorm:"type:varchar(3)"`
+	OriginalAmount   decimal.Decimal `gorm:"type:numeric(18,4)"`
+	RemainingAmount  decimal.Decimal `gorm:"type:numeric(18,4)"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func FromDomainOnAccountCredit(d *domain.OnAccountCredit) *OnAccountCredit {
+	if d == nil {
+		return nil
+	}
+	return &OnAccountCredit{
+		ID:               d.ID,
+		LegalEntityID:    d.LegalEntityID,
+		CounterpartyType: d.CounterpartyType,
+		CounterpartyID:   d.CounterpartyID,
+		SourceType:       d.SourceType,
+		SourceID:         d.SourceID,
+		Currency:         d.Currency,
+		OriginalAmount:   d.OriginalAmount,
+		RemainingAmount:  d.RemainingAmount,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
+
+func ToDomainOnAccountCredit(dbModel *OnAccountCredit) *domain.OnAccountCredit {
 	if dbModel == nil {
 		return nil
 	}
-	var td interface{}
-	if len(dbModel.TrackingDimensions) > 0 {
-		_ = json.Unmarshal(dbModel.TrackingDimensions, &td)
-	}
-	return &domain.UniversalJournalLine{
-		ID:                    dbModel.ID,
-		JournalEntryID:        dbModel.JournalEntryID,
-		AccountID:             dbModel.AccountID,
-		AmountFunctional:      dbModel.AmountFunctional,
-		AmountTransactional:   dbModel.AmountTransactional,
-		CurrencyTransactional: dbModel.CurrencyTransactional,
-		TrackingDimensions:    td,
+	return &domain.OnAccountCredit{
+		ID:               dbModel.ID,
+		LegalEntityID:    dbModel.LegalEntityID,
+		CounterpartyType: dbModel.CounterpartyType,
+		CounterpartyID:   dbModel.CounterpartyID,
+		SourceType:       dbModel.SourceType,
+		SourceID:         dbModel.SourceID,
+		Currency:         dbModel.Currency,
+		OriginalAmount:   dbModel.OriginalAmount,
+		RemainingAmount:  dbModel.RemainingAmount,
+		CreatedAt:        dbModel.CreatedAt,
+		UpdatedAt:        dbModel.UpdatedAt,
 	}
 }
 
-// DepreciationScheduleLine GORM struct
-type DepreciationScheduleLine struct {
-	ID                 string `gorm:"primaryKey"`
-	FixedAssetID       string `gorm:"index"`
-	FiscalYear         int
-	PeriodNumber       int
-	DepreciationAmount decimal.Decimal `gorm:"type:numeric(18,4)"`
-	IsPosted           bool
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
-
-	FixedAsset CapitalAsset `gorm:"foreignKey:FixedAssetID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+// DunningLevel GORM struct
+type DunningLevel struct {
+	ID                  string `gorm:"primaryKey"`
+	LegalEntityID       string `gorm:"uniqueIndex:idx_dunning_level"`
+	Level               int    `gorm:"uniqueIndex:idx_dunning_level"`
+	Name                string
+	DaysOverdue         int
+	FeeAmount           decimal.Decimal `gorm:"type:numeric(18,4)"`
+	InterestRatePercent decimal.Decimal `gorm:"type:numeric(9,4)"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
 }
 
-func FromDomainDepreciationScheduleLine(d *domain.DepreciationScheduleLine) *DepreciationScheduleLine {
+func FromDomainDunningLevel(d *domain.DunningLevel) *DunningLevel {
 	if d == nil {
 		return nil
 	}
-	return &DepreciationScheduleLine{
-		ID:                 d.ID,
-		FixedAssetID:       d.FixedAssetID,
-		FiscalYear:         d.FiscalYear,
-		PeriodNumber:       d.PeriodNumber,
-		DepreciationAmount: d.DepreciationAmount,
-		IsPosted:           d.IsPosted,
-		CreatedAt:          d.CreatedAt,
-		UpdatedAt:          d.UpdatedAt,
+	return &DunningLevel{
+		ID:                  d.ID,
+		LegalEntityID:       d.LegalEntityID,
+		Level:               d.Level,
+		Name:                d.Name,
+		DaysOverdue:         d.DaysOverdue,
+		FeeAmount:           d.FeeAmount,
+		InterestRatePercent: d.InterestRatePercent,
+		CreatedAt:           d.CreatedAt,
+		UpdatedAt:           d.UpdatedAt,
 	}
 }
 
-func ToDomainDepreciationScheduleLine(dbModel *DepreciationScheduleLine) *domain.DepreciationScheduleLine {
+func ToDomainDunningLevel(dbModel *DunningLevel) *domain.DunningLevel {
 	if dbModel == nil {
 		return nil
 	}
-	return &domain.DepreciationScheduleLine{
-		ID:                 dbModel.ID,
-		FixedAssetID:       dbModel.FixedAssetID,
-		FiscalYear:         dbModel.FiscalYear,
-		PeriodNumber:       dbModel.PeriodNumber,
-		DepreciationAmount: dbModel.DepreciationAmount,
-		IsPosted:           dbModel.IsPosted,
-		CreatedAt:          dbModel.CreatedAt,
-		UpdatedAt:          dbModel.UpdatedAt,
+	return &domain.DunningLevel{
+		ID:                  dbModel.ID,
+		LegalEntityID:       dbModel.LegalEntityID,
+		Level:               dbModel.Level,
+		Name:                dbModel.Name,
+		DaysOverdue:         dbModel.DaysOverdue,
+		FeeAmount:           dbModel.FeeAmount,
+		InterestRatePercent: dbModel.InterestRatePercent,
+		CreatedAt:           dbModel.CreatedAt,
+		UpdatedAt:           dbModel.UpdatedAt,
 	}
 }
 
-// CapitalAsset GORM struct
-type CapitalAsset struct {
-	ID                      string `gorm:"primaryKey"`
-	LegalEntityID           string `gorm:"index"`
-	AssetTag                string
-	EamEquipmentID          *string         `gorm:"index"`
-	AcquisitionCost         decimal.Decimal `gorm:"type:numeric(18,4)"`
-	AccumulatedDepreciation decimal.Decimal `gorm:"type:numeric(18,4)"`
-	UsefulLifeMonths        int
-	CapitalizationDate      time.Time
-	Status                  domain.AssetState `gorm:"type:varchar(50)"`
-	CreatedAt               time.Time
-	UpdatedAt               time.Time
-
-	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+// DunningRun GORM struct
+type DunningRun struct {
+	ID                string `gorm:"primaryKey"`
+	LegalEntityID     string `gorm:"index"`
+	AsOf              time.Time
+	InvoicesEvaluated int
+	NoticesIssued     int
+	CustomersOnHold   int
+	CreatedAt         time.Time
 }
 
-func FromDomainCapitalAsset(d *domain.CapitalAsset) *CapitalAsset {
+func FromDomainDunningRun(d *domain.DunningRun) *DunningRun {
 	if d == nil {
 		return nil
 	}
-	return &CapitalAsset{
-		ID:                      d.ID,
-		LegalEntityID:           d.LegalEntityID,
-		AssetTag:                d.AssetTag,
-		EamEquipmentID:          d.EamEquipmentID,
-		AcquisitionCost:         d.AcquisitionCost,
-		AccumulatedDepreciation: d.AccumulatedDepreciation,
-		UsefulLifeMonths:        d.UsefulLifeMonths,
-		CapitalizationDate:      d.CapitalizationDate,
-		Status:                  d.Status,
-		CreatedAt:               d.CreatedAt,
-		UpdatedAt:               d.UpdatedAt,
+	return &DunningRun{
+		ID:                d.ID,
+		LegalEntityID:     d.LegalEntityID,
+		AsOf:              d.AsOf,
+		InvoicesEvaluated: d.InvoicesEvaluated,
+		NoticesIssued:     d.NoticesIssued,
+		CustomersOnHold:   d.CustomersOnHold,
+		CreatedAt:         d.CreatedAt,
 	}
 }
 
-func ToDomainCapitalAsset(dbModel *CapitalAsset) *domain.CapitalAsset {
+func ToDomainDunningRun(dbModel *DunningRun) *domain.DunningRun {
 	if dbModel == nil {
 		return nil
 	}
-	return &domain.CapitalAsset{
-		ID:                      dbModel.ID,
-		LegalEntityID:           dbModel.LegalEntityID,
-		AssetTag:                dbModel.AssetTag,
-		EamEquipmentID:          dbModel.EamEquipmentID,
-		AcquisitionCost:         dbModel.AcquisitionCost,
-		AccumulatedDepreciation: dbModel.AccumulatedDepreciation,
-		UsefulLifeMonths:        dbModel.UsefulLifeMonths,
-		CapitalizationDate:      dbModel.CapitalizationDate,
-		Status:                  dbModel.Status,
-		CreatedAt:               dbModel.CreatedAt,
-		UpdatedAt:               dbModel.UpdatedAt,
+	return &domain.DunningRun{
+		ID:                dbModel.ID,
+		LegalEntityID:     dbModel.LegalEntityID,
+		AsOf:              dbModel.AsOf,
+		InvoicesEvaluated: dbModel.InvoicesEvaluated,
+		NoticesIssued:     dbModel.NoticesIssued,
+		CustomersOnHold:   dbModel.CustomersOnHold,
+		CreatedAt:         dbModel.CreatedAt,
 	}
 }
 
-// KafkaEventInbox GORM struct
-type KafkaEventInbox struct {
-	AttemptCount     int    `gorm:"type:integer;default:0;not null"`
-	EventID          string `gorm:"primaryKey"`
-	EventType        string
-	ProcessedAt      time.Time
-	ProcessingStatus domain.EventProcessingStatus `gorm:"type:varchar(50)"`
-	Payload          []byte                       `gorm:"type:jsonb"` // Marshalled json of interface{}
+// DunningNotice GORM struct
+type DunningNotice struct {
+	ID             string `gorm:"primaryKey"`
+	RunID          string `gorm:"index"`
+	LegalEntityID  string
+	InvoiceID      string `gorm:"index"`
+	CustomerID     string `gorm:"index"`
+	Level          int
+	DaysOverdue    int
+	Currency       string          `gorm:"type:varchar(3)"`
+	OpenAmount     decimal.Decimal `gorm:"type:numeric(18,4)"`
+	FeeAmount      decimal.Decimal `gorm:"type:numeric(18,4)"`
+	InterestAmount decimal.Decimal `gorm:"type:numeric(18,4)"`
+	TotalDue       decimal.Decimal `gorm:"type:numeric(18,4)"`
+	IsFinal        bool
+	JournalEntryID *string
+	CreatedAt      time.Time
+
+	Run     DunningRun `gorm:"foreignKey:RunID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Invoice ArInvoice  `gorm:"foreignKey:InvoiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
-func FromDomainKafkaEventInbox(d *domain.KafkaEventInbox) *KafkaEventInbox {
+func FromDomainDunningNotice(d *domain.DunningNotice) *DunningNotice {
 	if d == nil {
 		return nil
 	}
-	pBytes, _ := json.Marshal(d.Payload)
-	return &KafkaEventInbox{
-		AttemptCount:     0,
-		EventID:          d.EventID,
-		EventType:        d.EventType,
-		ProcessedAt:      d.ProcessedAt,
-		ProcessingStatus: d.ProcessingStatus,
-		Payload:          pBytes,
+	return &DunningNotice{
+		ID:             d.ID,
+		RunID:          d.RunID,
+		LegalEntityID:  d.LegalEntityID,
+		InvoiceID:      d.InvoiceID,
+		CustomerID:     d.CustomerID,
+		Level:          d.Level,
+		DaysOverdue:    d.DaysOverdue,
+		Currency:       d.Currency,
+		OpenAmount:     d.OpenAmount,
+		FeeAmount:      d.FeeAmount,
+		InterestAmount: d.InterestAmount,
+		TotalDue:       d.TotalDue,
+		IsFinal:        d.IsFinal,
+		JournalEntryID: d.JournalEntryID,
+		CreatedAt:      d.CreatedAt,
 	}
 }
 
-func ToDomainKafkaEventInbox(dbModel *KafkaEventInbox) *domain.KafkaEventInbox {
+func ToDomainDunningNotice(dbModel *DunningNotice) *domain.DunningNotice {
 	if dbModel == nil {
 		return nil
 	}
-	var p interface{}
-	if len(dbModel.Payload) > 0 {
-		_ = json.Unmarshal(dbModel.Payload, &p)
-	}
-	return &domain.KafkaEventInbox{
-		EventID:          dbModel.EventID,
-		EventType:        dbModel.EventType,
-		ProcessedAt:      dbModel.ProcessedAt,
-		ProcessingStatus: dbModel.ProcessingStatus,
-		Payload:          p,
+	return &domain.DunningNotice{
+		ID:             dbModel.ID,
+		RunID:          dbModel.RunID,
+		LegalEntityID:  dbModel.LegalEntityID,
+		InvoiceID:      dbModel.InvoiceID,
+		CustomerID:     dbModel.CustomerID,
+		Level:          dbModel.Level,
+		DaysOverdue:    dbModel.DaysOverdue,
+		Currency:       dbModel.Currency,
+		OpenAmount:     dbModel.OpenAmount,
+		FeeAmount:      dbModel.FeeAmount,
+		InterestAmount: dbModel.InterestAmount,
+		TotalDue:       dbModel.TotalDue,
+		IsFinal:        dbModel.IsFinal,
+		JournalEntryID: dbModel.JournalEntryID,
+		CreatedAt:      dbModel.CreatedAt,
 	}
 }
 
-// TransactionalOutbox GORM struct
-type TransactionalOutbox struct {
-	ID          string `gorm:"primaryKey"`
-	EventType   string
-	AggregateID string
-	Payload     []byte              `gorm:"type:jsonb"` // Marshalled json of interface{}
-	Status      domain.OutboxStatus `gorm:"type:varchar(50)"`
-	CreatedAt   time.Time
+// PaymentRun GORM struct
+type PaymentRun struct {
+	ID            string `gorm:"primaryKey"`
+	LegalEntityID string `gorm:"index"`
+	PaymentDate   time.Time
+	DueBy         time.Time
+	Status        string `gorm:"index"`
+	BillCount     int
+	PaymentCount  int
+	ProposedBy    string
+	ApprovedBy    *string
+	ExecutedAt    *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 }
 
-func FromDomainTransactionalOutbox(d *domain.TransactionalOutbox) *TransactionalOutbox {
+func FromDomainPaymentRun(d *domain.PaymentRun) *PaymentRun {
 	if d == nil {
 		return nil
 	}
-	pBytes, _ := json.Marshal(d.Payload)
-	return &TransactionalOutbox{
-		ID:          d.ID,
-		EventType:   d.EventType,
-		AggregateID: d.AggregateID,
-		Payload:     pBytes,
-		Status:      d.Status,
-		CreatedAt:   d.CreatedAt,
+	return &PaymentRun{
+		ID:            d.ID,
+		LegalEntityID: d.LegalEntityID,
+		PaymentDate:   d.PaymentDate,
+		DueBy:         d.DueBy,
+		Status:        string(d.Status),
+		BillCount:     d.BillCount,
+		PaymentCount:  d.PaymentCount,
+		ProposedBy:    d.ProposedBy,
+		ApprovedBy:    d.ApprovedBy,
+		ExecutedAt:    d.ExecutedAt,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
 	}
 }
 
-func ToDomainTransactionalOutbox(dbModel *TransactionalOutbox) *domain.TransactionalOutbox {
+func ToDomainPaymentRun(dbModel *PaymentRun) *domain.PaymentRun {
 	if dbModel == nil {
 		return nil
 	}
-	var p interface{}
-	if len(dbModel.Payload) > 0 {
-		_ = json.Unmarshal(dbModel.Payload, &p)
-	}
-	return &domain.TransactionalOutbox{
-		ID:          dbModel.ID,
-		EventType:   dbModel.EventType,
-		AggregateID: dbModel.AggregateID,
-		Payload:     p,
-		Status:      dbModel.Status,
-		CreatedAt:   dbModel.CreatedAt,
+	return &domain.PaymentRun{
+		ID:            dbModel.ID,
+		LegalEntityID: dbModel.LegalEntityID,
+		PaymentDate:   dbModel.PaymentDate,
+		DueBy:         dbModel.DueBy,
+		Status:        domain.PaymentRunStatus(dbModel.Status),
+		BillCount:     dbModel.BillCount,
+		PaymentCount:  dbModel.PaymentCount,
+		ProposedBy:    dbModel.ProposedBy,
+		ApprovedBy:    dbModel.ApprovedBy,
+		ExecutedAt:    dbModel.ExecutedAt,
+		CreatedAt:     dbModel.CreatedAt,
+		UpdatedAt:     dbModel.UpdatedAt,
 	}
 }
 
-// ApVendorBill GORM struct
-type ApVendorBill struct {
+// PaymentRunLine GORM struct
+type PaymentRunLine struct {
 	ID              string `gorm:"primaryKey"`
-	LegalEntityID   string `gorm:"index"`
+	RunID           string `gorm:"index"`
+	BillID          string `gorm:"index"`
 	BillNumber      string
-	VendorID        string
-	PurchaseOrderID string
-	TotalAmount     decimal.Decimal `gorm:"type:numeric(18,4)"`
-	TaxAmount       decimal.Decimal `gorm:"type:numeric(18,4)"`
+	VendorID        string `gorm:"index"`
+	BankAccountID   string
+	Currency        string          `gorm:"type:varchar(3)"`
+	Amount          decimal.Decimal `gorm:"type:numeric(18,4)"`
 	DueDate         time.Time
-	Status          domain.PaymentStatus `gorm:"type:varchar(50)"`
+	Status          string
+	ExclusionReason string
+	PaymentID       *string
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 
-	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Run  PaymentRun   `gorm:"foreignKey:RunID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Bill ApVendorBill `gorm:"foreignKey:BillID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
-func FromDomainApVendorBill(d *domain.ApVendorBill) *ApVendorBill {
+func FromDomainPaymentRunLine(d *domain.PaymentRunLine) *PaymentRunLine {
 	if d == nil {
 		return nil
 	}
-	return &ApVendorBill{
+	return &PaymentRunLine{
 		ID:              d.ID,
-		LegalEntityID:   d.LegalEntityID,
+		RunID:           d.RunID,
+		BillID:          d.BillID,
 		BillNumber:      d.BillNumber,
 		VendorID:        d.VendorID,
-		PurchaseOrderID: d.PurchaseOrderID,
-		TotalAmount:     d.TotalAmount,
-		TaxAmount:       d.TaxAmount,
+		BankAccountID:   d.BankAccountID,
+		Currency:        d.Currency,
+		Amount:          d.Amount,
 		DueDate:         d.DueDate,
-		Status:          d.Status,
+		Status:          string(d.Status),
+		ExclusionReason: d.ExclusionReason,
+		PaymentID:       d.PaymentID,
 		CreatedAt:       d.CreatedAt,
 		UpdatedAt:       d.UpdatedAt,
 	}
 }
 
-func ToDomainApVendorBill(dbModel *ApVendorBill) *domain.ApVendorBill {
+func ToDomainPaymentRunLine(dbModel *PaymentRunLine) *domain.PaymentRunLine {
 	if dbModel == nil {
 		return nil
 	}
-	return &domain.ApVendorBill{
+	return &domain.PaymentRunLine{
 		ID:              dbModel.ID,
-		LegalEntityID:   dbModel.LegalEntityID,
+		RunID:           dbModel.RunID,
+		BillID:          dbModel.BillID,
 		BillNumber:      dbModel.BillNumber,
 		VendorID:        dbModel.VendorID,
-		PurchaseOrderID: dbModel.PurchaseOrderID,
-		TotalAmount:     dbModel.TotalAmount,
-		TaxAmount:       dbModel.TaxAmount,
+		BankAccountID:   dbModel.BankAccountID,
+		Currency:        dbModel.Currency,
+		Amount:          dbModel.Amount,
 		DueDate:         dbModel.DueDate,
-		Status:          dbModel.Status,
+		Status:          domain.PaymentRunLineStatus(dbModel.Status),
+		ExclusionReason: dbModel.ExclusionReason,
+		PaymentID:       dbModel.PaymentID,
 		CreatedAt:       dbModel.CreatedAt,
 		UpdatedAt:       dbModel.UpdatedAt,
 	}
 }
 
-// ArInvoice GORM struct
-type ArInvoice struct {
+// PaymentFile GORM struct
+type PaymentFile struct {
 	ID            string `gorm:"primaryKey"`
-	LegalEntityID string `gorm:"index"`
-	InvoiceNumber string
-	CustomerID    string
-	SalesOrderID  string
+	RunID         string `gorm:"index"`
+	BankAccountID string
+	Format        string
+	FileName      string
+	Content       string `gorm:"type:text"`
+	PaymentCount  int
 	TotalAmount   decimal.Decimal `gorm:"type:numeric(18,4)"`
-	TaxAmount     decimal.Decimal `gorm:"type:numeric(18,4)"`
-	DueDate       time.Time
-	Status        domain.PaymentStatus `gorm:"type:varchar(50)"`
 	CreatedAt     time.Time
-	UpdatedAt     time.Time
 
-	LegalEntity LegalEntity `gorm:"foreignKey:LegalEntityID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Run PaymentRun `gorm:"foreignKey:RunID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
-func FromDomainArInvoice(d *domain.ArInvoice) *ArInvoice {
+func FromDomainPaymentFile(d *domain.PaymentFile) *PaymentFile {
 	if d == nil {
 		return nil
 	}
-	return &ArInvoice{
+	return &PaymentFile{
 		ID:            d.ID,
-		LegalEntityID: d.LegalEntityID,
-		InvoiceNumber: d.InvoiceNumber,
-		CustomerID:    d.CustomerID,
-		SalesOrderID:  d.SalesOrderID,
+		RunID:         d.RunID,
+		BankAccountID: d.BankAccountID,
+		Format:        string(d.Format),
+		FileName:      d.FileName,
+		Content:       d.Content,
+		PaymentCount:  d.PaymentCount,
 		TotalAmount:   d.TotalAmount,
-		TaxAmount:     d.TaxAmount,
-		DueDate:       d.DueDate,
-		Status:        d.Status,
 		CreatedAt:     d.CreatedAt,
-		UpdatedAt:     d.UpdatedAt,
 	}
 }
 
-func ToDomainArInvoice(dbModel *ArInvoice) *domain.ArInvoice {
+func ToDomainPaymentFile(dbModel *PaymentFile) *domain.PaymentFile {
 	if dbModel == nil {
 		return nil
 	}
-	return &domain.ArInvoice{
+	return &domain.PaymentFile{
 		ID:            dbModel.ID,
-		LegalEntityID: dbModel.LegalEntityID,
-		InvoiceNumber: dbModel.InvoiceNumber,
-		CustomerID:    dbModel.CustomerID,
-		SalesOrderID:  dbModel.SalesOrderID,
+		RunID:         dbModel.RunID,
+		BankAccountID: dbModel.BankAccountID,
+		Format:        domain.PaymentFileFormat(dbModel.Format),
+		FileName:      dbModel.FileName,
+		Content:       dbModel.Content,
+		PaymentCount:  dbModel.PaymentCount,
 		TotalAmount:   dbModel.TotalAmount,
-		TaxAmount:     dbModel.TaxAmount,
-		DueDate:       dbModel.DueDate,
-		Status:        dbModel.Status,
+		CreatedAt:     dbModel.CreatedAt,
+	}
+}
+
+// VendorBankAccount GORM struct
+type VendorBankAccount struct {
+	ID            string `gorm:"primaryKey"`
+	VendorID      string `gorm:"uniqueIndex"`
+	AccountName   string
+	IBAN          string
+	BIC           string
+	RoutingNumber string
+	AccountNumber string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func FromDomainVendorBankAccount(d *domain.VendorBankAccount) *VendorBankAccount {
+	if d == nil {
+		return nil
+	}
+	return &VendorBankAccount{
+		ID:            d.ID,
+		VendorID:      d.VendorID,
+		AccountName:   d.AccountName,
+		IBAN:          d.Iban,
+		BIC:           d.Bic,
+		RoutingNumber: d.RoutingNumber,
+		AccountNumber: d.AccountNumber,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func ToDomainVendorBankAccount(dbModel *VendorBankAccount) *domain.VendorBankAccount {
+	if dbModel == nil {
+		return nil
+	}
+	return &domain.VendorBankAccount{
+		ID:            dbModel.ID,
+		VendorID:      dbModel.VendorID,
+		AccountName:   dbModel.AccountName,
+		Iban:          dbModel.IBAN,
+		Bic:           dbModel.BIC,
+		RoutingNumber: dbModel.RoutingNumber,
+		AccountNumber: dbModel.AccountNumber,
 		CreatedAt:     dbModel.CreatedAt,
 		UpdatedAt:     dbModel.UpdatedAt,
 	}
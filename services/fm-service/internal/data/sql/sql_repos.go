@@ -2,6 +2,8 @@ package sql
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/erp-system/fm-service/internal/business/domain"
@@ -30,6 +32,9 @@ func (r *SQLChartOfAccountsRepo) Create(ctx context.Context, coa *domain.ChartOf
 func (r *SQLChartOfAccountsRepo) GetByID(ctx context.Context, id string) (*domain.ChartOfAccounts, error) {
 	var dbModel ChartOfAccounts
 	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAccountNotFound
+		}
 		return nil, err
 	}
 	return ToDomainChartOfAccounts(&dbModel), nil
@@ -38,6 +43,9 @@ func (r *SQLChartOfAccountsRepo) GetByID(ctx context.Context, id string) (*domai
 func (r *SQLChartOfAccountsRepo) GetByCode(ctx context.Context, legalEntityID, accountCode string) (*domain.ChartOfAccounts, error) {
 	var dbModel ChartOfAccounts
 	if err := GetDB(ctx, r.db).First(&dbModel, "legal_entity_id = ? AND account_code = ?", legalEntityID, accountCode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAccountNotFound
+		}
 		return nil, err
 	}
 	return ToDomainChartOfAccounts(&dbModel), nil
@@ -70,6 +78,53 @@ func (r *SQLChartOfAccountsRepo) List(ctx context.Context) ([]domain.ChartOfAcco
 	return res, nil
 }
 
+// SQLAccountDeterminationRepo implements domain.AccountDeterminationRepository
+type SQLAccountDeterminationRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLAccountDeterminationRepo(db *gorm.DB) *SQLAccountDeterminationRepo {
+	return &SQLAccountDeterminationRepo{db: db}
+}
+
+// Upsert replaces the account configured for the same legal entity and posting key
+func (r *SQLAccountDeterminationRepo) Upsert(ctx context.Context, determination *domain.AccountDetermination) error {
+	tx := GetDB(ctx, r.db)
+	var existing []AccountDetermination
+	if err := tx.Where("legal_entity_id = ? AND posting_key = ?", determination.LegalEntityID, determination.PostingKey).
+		Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		determination.ID = existing[0].ID
+		determination.CreatedAt = existing[0].CreatedAt
+	}
+	return tx.Save(FromDomainAccountDetermination(determination)).Error
+}
+
+func (r *SQLAccountDeterminationRepo) GetByKey(ctx context.Context, legalEntityID string, key domain.PostingKey) (*domain.AccountDetermination, error) {
+	var dbModel AccountDetermination
+	if err := GetDB(ctx, r.db).First(&dbModel, "legal_entity_id = ? AND posting_key = ?", legalEntityID, key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAccountDeterminationNotFound
+		}
+		return nil, err
+	}
+	return ToDomainAccountDetermination(&dbModel), nil
+}
+
+func (r *SQLAccountDeterminationRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.AccountDetermination, error) {
+	var dbModels []AccountDetermination
+	if err := GetDB(ctx, r.db).Where("legal_entity_id = ?", legalEntityID).Order("posting_key").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.AccountDetermination, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainAccountDetermination(&m)
+	}
+	return res, nil
+}
+
 // SQLUniversalJournalEntryRepo implements domain.UniversalJournalEntryRepository
 type SQLUniversalJournalEntryRepo struct {
 	db *gorm.DB
@@ -160,6 +215,81 @@ func (r *SQLUniversalJournalEntryRepo) List(ctx context.Context) ([]domain.Unive
 	return res, nil
 }
 
+// SQLJournalTemplateRepo implements domain.JournalTemplateRepository
+type SQLJournalTemplateRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLJournalTemplateRepo(db *gorm.DB) *SQLJournalTemplateRepo {
+	return &SQLJournalTemplateRepo{db: db}
+}
+
+func (r *SQLJournalTemplateRepo) Create(ctx context.Context, template *domain.JournalTemplate, lines []domain.JournalTemplateLine) error {
+	tx := GetDB(ctx, r.db)
+	return tx.Transaction(func(txDb *gorm.DB) error {
+		if err := txDb.Create(FromDomainJournalTemplate(template)).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			dbLine := FromDomainJournalTemplateLine(&lines[i])
+			dbLine.TemplateID = template.ID
+			if err := txDb.Create(dbLine).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLJournalTemplateRepo) GetByID(ctx context.Context, id string) (*domain.JournalTemplate, []domain.JournalTemplateLine, error) {
+	tx := GetDB(ctx, r.db)
+	var dbTemplate JournalTemplate
+	if err := tx.First(&dbTemplate, "id = ?", id).Error; err != nil {
+		return nil, nil, err
+	}
+	var dbLines []JournalTemplateLine
+	if err := tx.Find(&dbLines, "template_id = ?", id).Error; err != nil {
+		return nil, nil, err
+	}
+	lines := make([]domain.JournalTemplateLine, len(dbLines))
+	for i, m := range dbLines {
+		lines[i] = *ToDomainJournalTemplateLine(&m)
+	}
+	return ToDomainJournalTemplate(&dbTemplate), lines, nil
+}
+
+func (r *SQLJournalTemplateRepo) Update(ctx context.Context, template *domain.JournalTemplate, lines []domain.JournalTemplateLine) error {
+	tx := GetDB(ctx, r.db)
+	return tx.Transaction(func(txDb *gorm.DB) error {
+		if err := txDb.Save(FromDomainJournalTemplate(template)).Error; err != nil {
+			return err
+		}
+		if err := txDb.Delete(&JournalTemplateLine{}, "template_id = ?", template.ID).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			dbLine := FromDomainJournalTemplateLine(&lines[i])
+			dbLine.TemplateID = template.ID
+			if err := txDb.Create(dbLine).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLJournalTemplateRepo) List(ctx context.Context) ([]domain.JournalTemplate, error) {
+	var dbModels []JournalTemplate
+	if err := GetDB(ctx, r.db).Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	list := make([]domain.JournalTemplate, len(dbModels))
+	for i, m := range dbModels {
+		list[i] = *ToDomainJournalTemplate(&m)
+	}
+	return list, nil
+}
+
 // SQLArInvoiceRepo implements domain.ArInvoiceRepository
 type SQLArInvoiceRepo struct {
 	db *gorm.DB
@@ -317,12 +447,94 @@ func (r *SQLBudgetRepo) List(ctx context.Context) ([]domain.Budget, error) {
 
 func (r *SQLBudgetRepo) GetByAccountAndPeriod(ctx context.Context, accountID string, fiscalYear int, period int) (*domain.Budget, error) {
 	var dbModel Budget
-	if err := GetDB(ctx, r.db).First(&dbModel, "account_id = ? AND fiscal_year = ? AND period = ?", accountID, fiscalYear, period).Error; err != nil {
+	if err := GetDB(ctx, r.db).First(&dbModel, "account_id = ? AND fiscal_year = ? AND period = ? AND version = ?", accountID, fiscalYear, period, domain.BudgetVersionORIGINAL).Error; err != nil {
 		return nil, err
 	}
 	return ToDomainBudget(&dbModel), nil
 }
 
+// SQLBudgetCommitmentRepo implements domain.BudgetCommitmentRepository
+type SQLBudgetCommitmentRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLBudgetCommitmentRepo(db *gorm.DB) *SQLBudgetCommitmentRepo {
+	return &SQLBudgetCommitmentRepo{db: db}
+}
+
+func (r *SQLBudgetCommitmentRepo) Create(ctx context.Context, commitment *domain.BudgetCommitment) error {
+	return GetDB(ctx, r.db).Create(FromDomainBudgetCommitment(commitment)).Error
+}
+
+func (r *SQLBudgetCommitmentRepo) GetByID(ctx context.Context, id string) (*domain.BudgetCommitment, error) {
+	var dbModel BudgetCommitment
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainBudgetCommitment(&dbModel), nil
+}
+
+func (r *SQLBudgetCommitmentRepo) Update(ctx context.Context, commitment *domain.BudgetCommitment) error {
+	return GetDB(ctx, r.db).Save(FromDomainBudgetCommitment(commitment)).Error
+}
+
+func (r *SQLBudgetCommitmentRepo) List(ctx context.Context) ([]domain.BudgetCommitment, error) {
+	return r.find(GetDB(ctx, r.db))
+}
+
+func (r *SQLBudgetCommitmentRepo) ListBySourceDocument(ctx context.Context, sourceDocumentID string) ([]domain.BudgetCommitment, error) {
+	return r.find(GetDB(ctx, r.db).Where("source_document_id = ?", sourceDocumentID))
+}
+
+func (r *SQLBudgetCommitmentRepo) find(query *gorm.DB) ([]domain.BudgetCommitment, error) {
+	var dbModels []BudgetCommitment
+	if err := query.Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BudgetCommitment, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBudgetCommitment(&m)
+	}
+	return res, nil
+}
+
+// SQLBudgetPolicyRepo implements domain.BudgetPolicyRepository
+type SQLBudgetPolicyRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLBudgetPolicyRepo(db *gorm.DB) *SQLBudgetPolicyRepo {
+	return &SQLBudgetPolicyRepo{db: db}
+}
+
+func (r *SQLBudgetPolicyRepo) Create(ctx context.Context, policy *domain.BudgetPolicy) error {
+	return GetDB(ctx, r.db).Create(FromDomainBudgetPolicy(policy)).Error
+}
+
+func (r *SQLBudgetPolicyRepo) Update(ctx context.Context, policy *domain.BudgetPolicy) error {
+	return GetDB(ctx, r.db).Save(FromDomainBudgetPolicy(policy)).Error
+}
+
+func (r *SQLBudgetPolicyRepo) GetByCostCenter(ctx context.Context, costCenterID string) (*domain.BudgetPolicy, error) {
+	var dbModel BudgetPolicy
+	if err := GetDB(ctx, r.db).First(&dbModel, "cost_center_id = ?", costCenterID).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainBudgetPolicy(&dbModel), nil
+}
+
+func (r *SQLBudgetPolicyRepo) List(ctx context.Context) ([]domain.BudgetPolicy, error) {
+	var dbModels []BudgetPolicy
+	if err := GetDB(ctx, r.db).Order("cost_center_id").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BudgetPolicy, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBudgetPolicy(&m)
+	}
+	return res, nil
+}
+
 // SQLApVendorBillRepo implements domain.ApVendorBillRepository
 type SQLApVendorBillRepo struct {
 	db *gorm.DB
@@ -385,345 +597,1537 @@ func (r *SQLApVendorBillRepo) List(ctx context.Context) ([]domain.ApVendorBill,
 	return res, nil
 }
 
-// SQLTaxRateRepo implements domain.TaxRateRepository
-type SQLTaxRateRepo struct {
+// SQLApVendorBillLineRepo implements domain.ApVendorBillLineRepository
+type SQLApVendorBillLineRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLTaxRateRepo(db *gorm.DB) *SQLTaxRateRepo {
-	return &SQLTaxRateRepo{db: db}
+func NewSQLApVendorBillLineRepo(db *gorm.DB) *SQLApVendorBillLineRepo {
+	return &SQLApVendorBillLineRepo{db: db}
 }
 
-func (r *SQLTaxRateRepo) Create(ctx context.Context, tr *domain.TaxRate) error {
-	dbModel := FromDomainTaxRate(tr)
-	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
-		return err
-	}
-	return nil
+func (r *SQLApVendorBillLineRepo) ReplaceForBill(ctx context.Context, billID string, lines []domain.ApVendorBillLine) error {
+	return GetDB(ctx, r.db).Transaction(func(txDb *gorm.DB) error {
+		if err := txDb.Delete(&ApVendorBillLine{}, "bill_id = ?", billID).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			dbLine := FromDomainApVendorBillLine(&lines[i])
+			dbLine.BillID = billID
+			if err := txDb.Create(dbLine).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *SQLTaxRateRepo) GetByID(ctx context.Context, id string) (*domain.TaxRate, error) {
-	var dbModel TaxRate
-	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+func (r *SQLApVendorBillLineRepo) ListByBill(ctx context.Context, billID string) ([]domain.ApVendorBillLine, error) {
+	var dbModels []ApVendorBillLine
+	if err := GetDB(ctx, r.db).Where("bill_id = ?", billID).Order("created_at").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	return ToDomainTaxRate(&dbModel), nil
+	res := make([]domain.ApVendorBillLine, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainApVendorBillLine(&m)
+	}
+	return res, nil
 }
 
-func (r *SQLTaxRateRepo) List(ctx context.Context) ([]domain.TaxRate, error) {
-	var dbModels []TaxRate
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+// SQLPurchaseOrderLineRepo implements domain.PurchaseOrderLineRepository
+type SQLPurchaseOrderLineRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLPurchaseOrderLineRepo(db *gorm.DB) *SQLPurchaseOrderLineRepo {
+	return &SQLPurchaseOrderLineRepo{db: db}
+}
+
+func (r *SQLPurchaseOrderLineRepo) ReplaceForPurchaseOrder(ctx context.Context, purchaseOrderID string, lines []domain.PurchaseOrderLine) error {
+	return GetDB(ctx, r.db).Transaction(func(txDb *gorm.DB) error {
+		if err := txDb.Delete(&PurchaseOrderLine{}, "purchase_order_id = ?", purchaseOrderID).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			dbLine := FromDomainPurchaseOrderLine(&lines[i])
+			dbLine.PurchaseOrderID = purchaseOrderID
+			if err := txDb.Create(dbLine).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLPurchaseOrderLineRepo) ListByPurchaseOrder(ctx context.Context, purchaseOrderID string) ([]domain.PurchaseOrderLine, error) {
+	var dbModels []PurchaseOrderLine
+	if err := GetDB(ctx, r.db).Where("purchase_order_id = ?", purchaseOrderID).Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.TaxRate, len(dbModels))
+	res := make([]domain.PurchaseOrderLine, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainTaxRate(&m)
+		res[i] = *ToDomainPurchaseOrderLine(&m)
 	}
 	return res, nil
 }
 
-// SQLCurrencyRateRepo implements domain.CurrencyRateRepository
-type SQLCurrencyRateRepo struct {
+// SQLGoodsReceiptLineRepo implements domain.GoodsReceiptLineRepository
+type SQLGoodsReceiptLineRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLCurrencyRateRepo(db *gorm.DB) *SQLCurrencyRateRepo {
-	return &SQLCurrencyRateRepo{db: db}
+func NewSQLGoodsReceiptLineRepo(db *gorm.DB) *SQLGoodsReceiptLineRepo {
+	return &SQLGoodsReceiptLineRepo{db: db}
 }
 
-func (r *SQLCurrencyRateRepo) Create(ctx context.Context, rate *domain.CurrencyRate) error {
-	dbModel := FromDomainCurrencyRate(rate)
-	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
-		return err
+func (r *SQLGoodsReceiptLineRepo) CreateMany(ctx context.Context, lines []domain.GoodsReceiptLine) error {
+	if len(lines) == 0 {
+		return nil
 	}
-	return nil
-}
-
-func (r *SQLCurrencyRateRepo) GetByID(ctx context.Context, id string) (*domain.CurrencyRate, error) {
-	var dbModel CurrencyRate
-	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
-		return nil, err
+	dbModels := make([]GoodsReceiptLine, len(lines))
+	for i := range lines {
+		dbModels[i] = *FromDomainGoodsReceiptLine(&lines[i])
 	}
-	return ToDomainCurrencyRate(&dbModel), nil
+	return GetDB(ctx, r.db).Create(&dbModels).Error
 }
 
-func (r *SQLCurrencyRateRepo) List(ctx context.Context) ([]domain.CurrencyRate, error) {
-	var dbModels []CurrencyRate
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+func (r *SQLGoodsReceiptLineRepo) ListByPurchaseOrder(ctx context.Context, purchaseOrderID string) ([]domain.GoodsReceiptLine, error) {
+	var dbModels []GoodsReceiptLine
+	if err := GetDB(ctx, r.db).Where("purchase_order_id = ?", purchaseOrderID).Order("received_date").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.CurrencyRate, len(dbModels))
+	res := make([]domain.GoodsReceiptLine, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainCurrencyRate(&m)
+		res[i] = *ToDomainGoodsReceiptLine(&m)
 	}
 	return res, nil
 }
 
-// SQLFiscalYearRepo implements domain.FiscalYearRepository
-type SQLFiscalYearRepo struct {
+// SQLMatchToleranceRepo implements domain.MatchToleranceRepository
+type SQLMatchToleranceRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLFiscalYearRepo(db *gorm.DB) *SQLFiscalYearRepo {
-	return &SQLFiscalYearRepo{db: db}
+func NewSQLMatchToleranceRepo(db *gorm.DB) *SQLMatchToleranceRepo {
+	return &SQLMatchToleranceRepo{db: db}
 }
 
-func (r *SQLFiscalYearRepo) Create(ctx context.Context, fy *domain.FiscalYear) error {
-	dbModel := FromDomainFiscalYear(fy)
-	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
-		return err
-	}
-	return nil
+func (r *SQLMatchToleranceRepo) Create(ctx context.Context, tolerance *domain.MatchTolerance) error {
+	return GetDB(ctx, r.db).Create(FromDomainMatchTolerance(tolerance)).Error
 }
 
-func (r *SQLFiscalYearRepo) GetByID(ctx context.Context, id string) (*domain.FiscalYear, error) {
-	var dbModel FiscalYear
-	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
-		return nil, err
-	}
-	return ToDomainFiscalYear(&dbModel), nil
+func (r *SQLMatchToleranceRepo) Update(ctx context.Context, tolerance *domain.MatchTolerance) error {
+	return GetDB(ctx, r.db).Save(FromDomainMatchTolerance(tolerance)).Error
 }
 
-func (r *SQLFiscalYearRepo) Update(ctx context.Context, fy *domain.FiscalYear) error {
-	dbModel := FromDomainFiscalYear(fy)
-	if err := GetDB(ctx, r.db).Save(dbModel).Error; err != nil {
-		return err
+func (r *SQLMatchToleranceRepo) GetByVendor(ctx context.Context, vendorID string) (*domain.MatchTolerance, error) {
+	var dbModel MatchTolerance
+	if err := GetDB(ctx, r.db).First(&dbModel, "vendor_id = ?", vendorID).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return ToDomainMatchTolerance(&dbModel), nil
 }
 
-func (r *SQLFiscalYearRepo) List(ctx context.Context) ([]domain.FiscalYear, error) {
-	var dbModels []FiscalYear
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+func (r *SQLMatchToleranceRepo) List(ctx context.Context) ([]domain.MatchTolerance, error) {
+	var dbModels []MatchTolerance
+	if err := GetDB(ctx, r.db).Order("vendor_id").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.FiscalYear, len(dbModels))
+	res := make([]domain.MatchTolerance, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainFiscalYear(&m)
+		res[i] = *ToDomainMatchTolerance(&m)
 	}
 	return res, nil
 }
 
-// SQLCostCenterRepo implements domain.CostCenterRepository
-type SQLCostCenterRepo struct {
+// SQLPaymentAllocationRepo implements domain.PaymentAllocationRepository
+type SQLPaymentAllocationRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLCostCenterRepo(db *gorm.DB) *SQLCostCenterRepo {
-	return &SQLCostCenterRepo{db: db}
+func NewSQLPaymentAllocationRepo(db *gorm.DB) *SQLPaymentAllocationRepo {
+	return &SQLPaymentAllocationRepo{db: db}
 }
 
-func (r *SQLCostCenterRepo) Create(ctx context.Context, cc *domain.CostCenter) error {
-	dbModel := FromDomainCostCenter(cc)
-	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
-		return err
+func (r *SQLPaymentAllocationRepo) CreateMany(ctx context.Context, allocations []domain.PaymentAllocation) error {
+	if len(allocations) == 0 {
+		return nil
 	}
-	return nil
+	dbModels := make([]PaymentAllocation, len(allocations))
+	for i := range allocations {
+		dbModels[i] = *FromDomainPaymentAllocation(&allocations[i])
+	}
+	return GetDB(ctx, r.db).Create(&dbModels).Error
 }
 
-func (r *SQLCostCenterRepo) GetByID(ctx context.Context, id string) (*domain.CostCenter, error) {
-	var dbModel CostCenter
-	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
-		return nil, err
-	}
-	return ToDomainCostCenter(&dbModel), nil
+func (r *SQLPaymentAllocationRepo) ListBySource(ctx context.Context, sourceID string) ([]domain.PaymentAllocation, error) {
+	return r.list(ctx, "source_id = ?", sourceID)
 }
 
-func (r *SQLCostCenterRepo) Update(ctx context.Context, cc *domain.CostCenter) error {
-	dbModel := FromDomainCostCenter(cc)
-	if err := GetDB(ctx, r.db).Save(dbModel).Error; err != nil {
-		return err
-	}
-	return nil
+func (r *SQLPaymentAllocationRepo) ListByInvoice(ctx context.Context, invoiceID string) ([]domain.PaymentAllocation, error) {
+	return r.list(ctx, "invoice_id = ?", invoiceID)
 }
 
-func (r *SQLCostCenterRepo) Delete(ctx context.Context, id string) error {
-	if err := GetDB(ctx, r.db).Delete(&CostCenter{}, "id = ?", id).Error; err != nil {
-		return err
-	}
-	return nil
+func (r *SQLPaymentAllocationRepo) ListByBill(ctx context.Context, billID string) ([]domain.PaymentAllocation, error) {
+	return r.list(ctx, "bill_id = ?", billID)
 }
 
-func (r *SQLCostCenterRepo) List(ctx context.Context) ([]domain.CostCenter, error) {
-	var dbModels []CostCenter
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+func (r *SQLPaymentAllocationRepo) list(ctx context.Context, query string, arg string) ([]domain.PaymentAllocation, error) {
+	var dbModels []PaymentAllocation
+	if err := GetDB(ctx, r.db).Where(query, arg).Order("created_at").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.CostCenter, len(dbModels))
+	res := make([]domain.PaymentAllocation, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainCostCenter(&m)
+		res[i] = *ToDomainPaymentAllocation(&m)
 	}
 	return res, nil
 }
 
-// SQLBankAccountRepo implements domain.BankAccountRepository
-type SQLBankAccountRepo struct {
+// SQLArCreditMemoRepo implements domain.ArCreditMemoRepository
+type SQLArCreditMemoRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLBankAccountRepo(db *gorm.DB) *SQLBankAccountRepo {
-	return &SQLBankAccountRepo{db: db}
+func NewSQLArCreditMemoRepo(db *gorm.DB) *SQLArCreditMemoRepo {
+	return &SQLArCreditMemoRepo{db: db}
 }
 
-func (r *SQLBankAccountRepo) Create(ctx context.Context, ba *domain.BankAccount) error {
-	dbModel := FromDomainBankAccount(ba)
-	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
-		return err
-	}
+func (r *SQLArCreditMemoRepo) Create(ctx context.Context, memo *domain.ArCreditMemo) error {
+	return GetDB(ctx, r.db).Create(FromDomainArCreditMemo(memo)).Error
+}
+
+func (r *SQLArCreditMemoRepo) GetByID(ctx context.Context, id string) (*domain.ArCreditMemo, error) {
+	var dbModel ArCreditMemo
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainArCreditMemo(&dbModel), nil
+}
+
+func (r *SQLArCreditMemoRepo) List(ctx context.Context) ([]domain.ArCreditMemo, error) {
+	var dbModels []ArCreditMemo
+	if err := GetDB(ctx, r.db).Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.ArCreditMemo, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainArCreditMemo(&m)
+	}
+	return res, nil
+}
+
+// SQLOnAccountCreditRepo implements domain.OnAccountCreditRepository
+type SQLOnAccountCreditRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLOnAccountCreditRepo(db *gorm.DB) *SQLOnAccountCreditRepo {
+	return &SQLOnAccountCreditRepo{db: db}
+}
+
+func (r *SQLOnAccountCreditRepo) Create(ctx context.Context, credit *domain.OnAccountCredit) error {
+	return GetDB(ctx, r.db).Create(FromDomainOnAccountCredit(credit)).Error
+}
+
+func (r *SQLOnAccountCreditRepo) GetByID(ctx context.Context, id string) (*domain.OnAccountCredit, error) {
+	var dbModel OnAccountCredit
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainOnAccountCredit(&dbModel), nil
+}
+
+func (r *SQLOnAccountCreditRepo) Update(ctx context.Context, credit *domain.OnAccountCredit) error {
+	return GetDB(ctx, r.db).Save(FromDomainOnAccountCredit(credit)).Error
+}
+
+func (r *SQLOnAccountCreditRepo) List(ctx context.Context) ([]domain.OnAccountCredit, error) {
+	var dbModels []OnAccountCredit
+	if err := GetDB(ctx, r.db).Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.OnAccountCredit, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainOnAccountCredit(&m)
+	}
+	return res, nil
+}
+
+// SQLDunningLevelRepo implements domain.DunningLevelRepository
+type SQLDunningLevelRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLDunningLevelRepo(db *gorm.DB) *SQLDunningLevelRepo {
+	return &SQLDunningLevelRepo{db: db}
+}
+
+func (r *SQLDunningLevelRepo) Create(ctx context.Context, level *domain.DunningLevel) error {
+	return GetDB(ctx, r.db).Create(FromDomainDunningLevel(level)).Error
+}
+
+func (r *SQLDunningLevelRepo) Update(ctx context.Context, level *domain.DunningLevel) error {
+	return GetDB(ctx, r.db).Save(FromDomainDunningLevel(level)).Error
+}
+
+func (r *SQLDunningLevelRepo) Delete(ctx context.Context, id string) error {
+	return GetDB(ctx, r.db).Delete(&DunningLevel{}, "id = ?", id).Error
+}
+
+func (r *SQLDunningLevelRepo) GetByLevel(ctx context.Context, legalEntityID string, level int) (*domain.DunningLevel, error) {
+	var dbModel DunningLevel
+	if err := GetDB(ctx, r.db).First(&dbModel, "legal_entity_id = ? AND level = ?", legalEntityID, level).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainDunningLevel(&dbModel), nil
+}
+
+func (r *SQLDunningLevelRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.DunningLevel, error) {
+	var dbModels []DunningLevel
+	if err := GetDB(ctx, r.db).Where("legal_entity_id = ?", legalEntityID).Order("level").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.DunningLevel, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainDunningLevel(&m)
+	}
+	return res, nil
+}
+
+// SQLSalesOrderExposureRepo implements domain.SalesOrderExposureRepository
+type SQLSalesOrderExposureRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLSalesOrderExposureRepo(db *gorm.DB) *SQLSalesOrderExposureRepo {
+	return &SQLSalesOrderExposureRepo{db: db}
+}
+
+func (r *SQLSalesOrderExposureRepo) Create(ctx context.Context, e *domain.SalesOrderExposure) error {
+	return GetDB(ctx, r.db).Create(FromDomainSalesOrderExposure(e)).Error
+}
+
+func (r *SQLSalesOrderExposureRepo) Update(ctx context.Context, e *domain.SalesOrderExposure) error {
+	return GetDB(ctx, r.db).Save(FromDomainSalesOrderExposure(e)).Error
+}
+
+func (r *SQLSalesOrderExposureRepo) GetBySalesOrderID(ctx context.Context, salesOrderID string) (*domain.SalesOrderExposure, error) {
+	var dbModel SalesOrderExposure
+	if err := GetDB(ctx, r.db).First(&dbModel, "sales_order_id = ?", salesOrderID).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainSalesOrderExposure(&dbModel), nil
+}
+
+func (r *SQLSalesOrderExposureRepo) ListOpenByCustomer(ctx context.Context, customerID string) ([]domain.SalesOrderExposure, error) {
+	var dbModels []SalesOrderExposure
+	err := GetDB(ctx, r.db).
+		Where("customer_id = ? AND status = ?", customerID, string(domain.SalesOrderExposureStatusOPEN)).
+		Order("confirmed_at").Find(&dbModels).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.SalesOrderExposure, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainSalesOrderExposure(&m)
+	}
+	return res, nil
+}
+
+// SQLCreditOverrideRepo implements domain.CreditOverrideRepository
+type SQLCreditOverrideRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLCreditOverrideRepo(db *gorm.DB) *SQLCreditOverrideRepo {
+	return &SQLCreditOverrideRepo{db: db}
+}
+
+func (r *SQLCreditOverrideRepo) Create(ctx context.Context, o *domain.CreditOverride) error {
+	return GetDB(ctx, r.db).Create(FromDomainCreditOverride(o)).Error
+}
+
+func (r *SQLCreditOverrideRepo) Update(ctx context.Context, o *domain.CreditOverride) error {
+	return GetDB(ctx, r.db).Save(FromDomainCreditOverride(o)).Error
+}
+
+func (r *SQLCreditOverrideRepo) ListByCustomer(ctx context.Context, customerID string) ([]domain.CreditOverride, error) {
+	var dbModels []CreditOverride
+	if err := GetDB(ctx, r.db).Where("customer_id = ?", customerID).Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.CreditOverride, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainCreditOverride(&m)
+	}
+	return res, nil
+}
+
+// SQLDunningRunRepo implements domain.DunningRunRepository
+type SQLDunningRunRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLDunningRunRepo(db *gorm.DB) *SQLDunningRunRepo {
+	return &SQLDunningRunRepo{db: db}
+}
+
+func (r *SQLDunningRunRepo) Create(ctx context.Context, run *domain.DunningRun) error {
+	return GetDB(ctx, r.db).Create(FromDomainDunningRun(run)).Error
+}
+
+func (r *SQLDunningRunRepo) GetByID(ctx context.Context, id string) (*domain.DunningRun, error) {
+	var dbModel DunningRun
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainDunningRun(&dbModel), nil
+}
+
+func (r *SQLDunningRunRepo) List(ctx context.Context) ([]domain.DunningRun, error) {
+	var dbModels []DunningRun
+	if err := GetDB(ctx, r.db).Order("created_at DESC").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.DunningRun, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainDunningRun(&m)
+	}
+	return res, nil
+}
+
+// SQLDunningNoticeRepo implements domain.DunningNoticeRepository
+type SQLDunningNoticeRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLDunningNoticeRepo(db *gorm.DB) *SQLDunningNoticeRepo {
+	return &SQLDunningNoticeRepo{db: db}
+}
+
+func (r *SQLDunningNoticeRepo) CreateMany(ctx context.Context, notices []domain.DunningNotice) error {
+	if len(notices) == 0 {
+		return nil
+	}
+	dbModels := make([]DunningNotice, len(notices))
+	for i := range notices {
+		dbModels[i] = *FromDomainDunningNotice(&notices[i])
+	}
+	return GetDB(ctx, r.db).Create(&dbModels).Error
+}
+
+func (r *SQLDunningNoticeRepo) ListByRun(ctx context.Context, runID string) ([]domain.DunningNotice, error) {
+	return r.list(ctx, "run_id = ?", runID)
+}
+
+func (r *SQLDunningNoticeRepo) ListByInvoice(ctx context.Context, invoiceID string) ([]domain.DunningNotice, error) {
+	return r.list(ctx, "invoice_id = ?", invoiceID)
+}
+
+func (r *SQLDunningNoticeRepo) list(ctx context.Context, query string, arg string) ([]domain.DunningNotice, error) {
+	var dbModels []DunningNotice
+	if err := GetDB(ctx, r.db).Where(query, arg).Order("created_at, level").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.DunningNotice, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainDunningNotice(&m)
+	}
+	return res, nil
+}
+
+// SQLPaymentRunRepo implements domain.PaymentRunRepository
+type SQLPaymentRunRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLPaymentRunRepo(db *gorm.DB) *SQLPaymentRunRepo {
+	return &SQLPaymentRunRepo{db: db}
+}
+
+func (r *SQLPaymentRunRepo) Create(ctx context.Context, run *domain.PaymentRun) error {
+	return GetDB(ctx, r.db).Create(FromDomainPaymentRun(run)).Error
+}
+
+func (r *SQLPaymentRunRepo) Update(ctx context.Context, run *domain.PaymentRun) error {
+	return GetDB(ctx, r.db).Save(FromDomainPaymentRun(run)).Error
+}
+
+func (r *SQLPaymentRunRepo) GetByID(ctx context.Context, id string) (*domain.PaymentRun, error) {
+	var dbModel PaymentRun
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainPaymentRun(&dbModel), nil
+}
+
+func (r *SQLPaymentRunRepo) List(ctx context.Context) ([]domain.PaymentRun, error) {
+	var dbModels []PaymentRun
+	if err := GetDB(ctx, r.db).Order("created_at DESC").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.PaymentRun, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainPaymentRun(&m)
+	}
+	return res, nil
+}
+
+// SQLPaymentRunLineRepo implements domain.PaymentRunLineRepository
+type SQLPaymentRunLineRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLPaymentRunLineRepo(db *gorm.DB) *SQLPaymentRunLineRepo {
+	return &SQLPaymentRunLineRepo{db: db}
+}
+
+func (r *SQLPaymentRunLineRepo) CreateMany(ctx context.Context, lines []domain.PaymentRunLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	dbModels := make([]PaymentRunLine, len(lines))
+	for i := range lines {
+		dbModels[i] = *FromDomainPaymentRunLine(&lines[i])
+	}
+	return GetDB(ctx, r.db).Create(&dbModels).Error
+}
+
+func (r *SQLPaymentRunLineRepo) Update(ctx context.Context, line *domain.PaymentRunLine) error {
+	return GetDB(ctx, r.db).Save(FromDomainPaymentRunLine(line)).Error
+}
+
+func (r *SQLPaymentRunLineRepo) ListByRun(ctx context.Context, runID string) ([]domain.PaymentRunLine, error) {
+	var dbModels []PaymentRunLine
+	if err := GetDB(ctx, r.db).Where("run_id = ?", runID).Order("vendor_id, due_date").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.PaymentRunLine, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainPaymentRunLine(&m)
+	}
+	return res, nil
+}
+
+// SQLPaymentFileRepo implements domain.PaymentFileRepository
+type SQLPaymentFileRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLPaymentFileRepo(db *gorm.DB) *SQLPaymentFileRepo {
+	return &SQLPaymentFileRepo{db: db}
+}
+
+func (r *SQLPaymentFileRepo) Create(ctx context.Context, file *domain.PaymentFile) error {
+	return GetDB(ctx, r.db).Create(FromDomainPaymentFile(file)).Error
+}
+
+func (r *SQLPaymentFileRepo) GetByID(ctx context.Context, id string) (*domain.PaymentFile, error) {
+	var dbModel PaymentFile
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainPaymentFile(&dbModel), nil
+}
+
+func (r *SQLPaymentFileRepo) ListByRun(ctx context.Context, runID string) ([]domain.PaymentFile, error) {
+	var dbModels []PaymentFile
+	if err := GetDB(ctx, r.db).Where("run_id = ?", runID).Order("file_name").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.PaymentFile, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainPaymentFile(&m)
+	}
+	return res, nil
+}
+
+// SQLVendorBankAccountRepo implements domain.VendorBankAccountRepository
+type SQLVendorBankAccountRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLVendorBankAccountRepo(db *gorm.DB) *SQLVendorBankAccountRepo {
+	return &SQLVendorBankAccountRepo{db: db}
+}
+
+func (r *SQLVendorBankAccountRepo) Create(ctx context.Context, account *domain.VendorBankAccount) error {
+	return GetDB(ctx, r.db).Create(FromDomainVendorBankAccount(account)).Error
+}
+
+func (r *SQLVendorBankAccountRepo) Update(ctx context.Context, account *domain.VendorBankAccount) error {
+	return GetDB(ctx, r.db).Save(FromDomainVendorBankAccount(account)).Error
+}
+
+func (r *SQLVendorBankAccountRepo) GetByVendorID(ctx context.Context, vendorID string) (*domain.VendorBankAccount, error) {
+	var dbModel VendorBankAccount
+	if err := GetDB(ctx, r.db).First(&dbModel, "vendor_id = ?", vendorID).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainVendorBankAccount(&dbModel), nil
+}
+
+// SQLTaxRateRepo implements domain.TaxRateRepository
+type SQLTaxRateRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLTaxRateRepo(db *gorm.DB) *SQLTaxRateRepo {
+	return &SQLTaxRateRepo{db: db}
+}
+
+func (r *SQLTaxRateRepo) Create(ctx context.Context, tr *domain.TaxRate) error {
+	dbModel := FromDomainTaxRate(tr)
+	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLTaxRateRepo) GetByID(ctx context.Context, id string) (*domain.TaxRate, error) {
+	var dbModel TaxRate
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainTaxRate(&dbModel), nil
+}
+
+func (r *SQLTaxRateRepo) List(ctx context.Context) ([]domain.TaxRate, error) {
+	var dbModels []TaxRate
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.TaxRate, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainTaxRate(&m)
+	}
+	return res, nil
+}
+
+// SQLCurrencyRateRepo implements domain.CurrencyRateRepository
+type SQLCurrencyRateRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLCurrencyRateRepo(db *gorm.DB) *SQLCurrencyRateRepo {
+	return &SQLCurrencyRateRepo{db: db}
+}
+
+func (r *SQLCurrencyRateRepo) Create(ctx context.Context, rate *domain.CurrencyRate) error {
+	dbModel := FromDomainCurrencyRate(rate)
+	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLCurrencyRateRepo) GetByID(ctx context.Context, id string) (*domain.CurrencyRate, error) {
+	var dbModel CurrencyRate
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainCurrencyRate(&dbModel), nil
+}
+
+func (r *SQLCurrencyRateRepo) List(ctx context.Context) ([]domain.CurrencyRate, error) {
+	var dbModels []CurrencyRate
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.CurrencyRate, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainCurrencyRate(&m)
+	}
+	return res, nil
+}
+
+func (r *SQLCurrencyRateRepo) GetEffective(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*domain.CurrencyRate, error) {
+	var dbModel CurrencyRate
+	err := GetDB(ctx, r.db).
+		Where("from_currency = ? AND to_currency = ? AND effective_date <= ?", fromCurrency, toCurrency, at).
+		Order("effective_date desc").
+		First(&dbModel).Error
+	if err != nil {
+		return nil, err
+	}
+	return ToDomainCurrencyRate(&dbModel), nil
+}
+
+// SQLFiscalYearRepo implements domain.FiscalYearRepository
+type SQLFiscalYearRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLFiscalYearRepo(db *gorm.DB) *SQLFiscalYearRepo {
+	return &SQLFiscalYearRepo{db: db}
+}
+
+func (r *SQLFiscalYearRepo) Create(ctx context.Context, fy *domain.FiscalYear) error {
+	dbModel := FromDomainFiscalYear(fy)
+	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLFiscalYearRepo) GetByID(ctx context.Context, id string) (*domain.FiscalYear, error) {
+	var dbModel FiscalYear
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainFiscalYear(&dbModel), nil
+}
+
+func (r *SQLFiscalYearRepo) Update(ctx context.Context, fy *domain.FiscalYear) error {
+	dbModel := FromDomainFiscalYear(fy)
+	if err := GetDB(ctx, r.db).Save(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLFiscalYearRepo) List(ctx context.Context) ([]domain.FiscalYear, error) {
+	var dbModels []FiscalYear
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.FiscalYear, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainFiscalYear(&m)
+	}
+	return res, nil
+}
+
+// SQLCostCenterRepo implements domain.CostCenterRepository
+type SQLCostCenterRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLCostCenterRepo(db *gorm.DB) *SQLCostCenterRepo {
+	return &SQLCostCenterRepo{db: db}
+}
+
+func (r *SQLCostCenterRepo) Create(ctx context.Context, cc *domain.CostCenter) error {
+	dbModel := FromDomainCostCenter(cc)
+	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLCostCenterRepo) GetByID(ctx context.Context, id string) (*domain.CostCenter, error) {
+	var dbModel CostCenter
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainCostCenter(&dbModel), nil
+}
+
+func (r *SQLCostCenterRepo) Update(ctx context.Context, cc *domain.CostCenter) error {
+	dbModel := FromDomainCostCenter(cc)
+	if err := GetDB(ctx, r.db).Save(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLCostCenterRepo) Delete(ctx context.Context, id string) error {
+	if err := GetDB(ctx, r.db).Delete(&CostCenter{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLCostCenterRepo) List(ctx context.Context) ([]domain.CostCenter, error) {
+	var dbModels []CostCenter
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.CostCenter, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainCostCenter(&m)
+	}
+	return res, nil
+}
+
+// SQLAllocationCycleRepo implements domain.AllocationCycleRepository
+type SQLAllocationCycleRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLAllocationCycleRepo(db *gorm.DB) *SQLAllocationCycleRepo {
+	return &SQLAllocationCycleRepo{db: db}
+}
+
+func (r *SQLAllocationCycleRepo) Create(ctx context.Context, cycle *domain.AllocationCycle, members []domain.AllocationCycleMember) error {
+	tx := GetDB(ctx, r.db)
+	return tx.Transaction(func(txDb *gorm.DB) error {
+		if err := txDb.Create(FromDomainAllocationCycle(cycle)).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			dbMember := FromDomainAllocationCycleMember(&members[i])
+			dbMember.CycleID = cycle.ID
+			if err := txDb.Create(dbMember).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLAllocationCycleRepo) GetByID(ctx context.Context, id string) (*domain.AllocationCycle, []domain.AllocationCycleMember, error) {
+	tx := GetDB(ctx, r.db)
+	var dbCycle AllocationCycle
+	if err := tx.First(&dbCycle, "id = ?", id).Error; err != nil {
+		return nil, nil, err
+	}
+	var dbMembers []AllocationCycleMember
+	if err := tx.Find(&dbMembers, "cycle_id = ?", id).Error; err != nil {
+		return nil, nil, err
+	}
+	members := make([]domain.AllocationCycleMember, len(dbMembers))
+	for i, m := range dbMembers {
+		members[i] = *ToDomainAllocationCycleMember(&m)
+	}
+	return ToDomainAllocationCycle(&dbCycle), members, nil
+}
+
+func (r *SQLAllocationCycleRepo) Update(ctx context.Context, cycle *domain.AllocationCycle, members []domain.AllocationCycleMember) error {
+	tx := GetDB(ctx, r.db)
+	return tx.Transaction(func(txDb *gorm.DB) error {
+		if err := txDb.Save(FromDomainAllocationCycle(cycle)).Error; err != nil {
+			return err
+		}
+		if err := txDb.Delete(&AllocationCycleMember{}, "cycle_id = ?", cycle.ID).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			dbMember := FromDomainAllocationCycleMember(&members[i])
+			dbMember.CycleID = cycle.ID
+			if err := txDb.Create(dbMember).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLAllocationCycleRepo) List(ctx context.Context) ([]domain.AllocationCycle, error) {
+	var dbModels []AllocationCycle
+	if err := GetDB(ctx, r.db).Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	list := make([]domain.AllocationCycle, len(dbModels))
+	for i, m := range dbModels {
+		list[i] = *ToDomainAllocationCycle(&m)
+	}
+	return list, nil
+}
+
+// SQLAllocationRunRepo implements domain.AllocationRunRepository
+type SQLAllocationRunRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLAllocationRunRepo(db *gorm.DB) *SQLAllocationRunRepo {
+	return &SQLAllocationRunRepo{db: db}
+}
+
+func (r *SQLAllocationRunRepo) Create(ctx context.Context, run *domain.AllocationRun) error {
+	return GetDB(ctx, r.db).Create(FromDomainAllocationRun(run)).Error
+}
+
+func (r *SQLAllocationRunRepo) GetByID(ctx context.Context, id string) (*domain.AllocationRun, error) {
+	var dbModel AllocationRun
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainAllocationRun(&dbModel), nil
+}
+
+func (r *SQLAllocationRunRepo) Update(ctx context.Context, run *domain.AllocationRun) error {
+	return GetDB(ctx, r.db).Save(FromDomainAllocationRun(run)).Error
+}
+
+func (r *SQLAllocationRunRepo) ListByCycle(ctx context.Context, cycleID string) ([]domain.AllocationRun, error) {
+	var dbModels []AllocationRun
+	if err := GetDB(ctx, r.db).Where("cycle_id = ?", cycleID).Order("created_at").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.AllocationRun, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainAllocationRun(&m)
+	}
+	return res, nil
+}
+
+// SQLStatisticalKeyFigureRepo implements domain.StatisticalKeyFigureRepository
+type SQLStatisticalKeyFigureRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLStatisticalKeyFigureRepo(db *gorm.DB) *SQLStatisticalKeyFigureRepo {
+	return &SQLStatisticalKeyFigureRepo{db: db}
+}
+
+// Upsert replaces the value recorded for the same cost center, driver and period
+func (r *SQLStatisticalKeyFigureRepo) Upsert(ctx context.Context, kf *domain.StatisticalKeyFigure) error {
+	tx := GetDB(ctx, r.db)
+	var existing []StatisticalKeyFigure
+	if err := tx.Where("cost_center_id = ? AND driver = ? AND financial_period = ?", kf.CostCenterID, kf.Driver, kf.FinancialPeriod).
+		Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		kf.ID = existing[0].ID
+	}
+	return tx.Save(FromDomainStatisticalKeyFigure(kf)).Error
+}
+
+func (r *SQLStatisticalKeyFigureRepo) ListByPeriod(ctx context.Context, driver domain.AllocationDriver, period string) ([]domain.StatisticalKeyFigure, error) {
+	var dbModels []StatisticalKeyFigure
+	if err := GetDB(ctx, r.db).Where("driver = ? AND financial_period = ?", driver, period).Order("cost_center_id").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.StatisticalKeyFigure, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainStatisticalKeyFigure(&m)
+	}
+	return res, nil
+}
+
+// SQLBankAccountRepo implements domain.BankAccountRepository
+type SQLBankAccountRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLBankAccountRepo(db *gorm.DB) *SQLBankAccountRepo {
+	return &SQLBankAccountRepo{db: db}
+}
+
+func (r *SQLBankAccountRepo) Create(ctx context.Context, ba *domain.BankAccount) error {
+	dbModel := FromDomainBankAccount(ba)
+	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
+		return err
+	}
 	ba.CreatedAt = dbModel.CreatedAt
 	ba.UpdatedAt = dbModel.UpdatedAt
 	return nil
 }
 
-func (r *SQLBankAccountRepo) GetByID(ctx context.Context, id string) (*domain.BankAccount, error) {
-	var dbModel BankAccount
+func (r *SQLBankAccountRepo) GetByID(ctx context.Context, id string) (*domain.BankAccount, error) {
+	var dbModel BankAccount
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainBankAccount(&dbModel), nil
+}
+
+func (r *SQLBankAccountRepo) Update(ctx context.Context, ba *domain.BankAccount) error {
+	tx := GetDB(ctx, r.db)
+
+	var dbModel BankAccount
+	if err := tx.First(&dbModel, "id = ?", ba.ID).Error; err != nil {
+		return err
+	}
+
+	expectedVersion := dbModel.Version
+	newVersion := expectedVersion + 1
+
+	res := tx.Model(&BankAccount{}).
+		Where("id = ? AND version = ?", ba.ID, expectedVersion).
+		Updates(map[string]interface{}{
+			"liquid_balance": ba.LiquidBalance,
+			"updated_at":     time.Now(),
+			"version":        newVersion,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return domain.ErrOptimisticLock
+	}
+
+	ba.Version = newVersion
+	return nil
+}
+
+func (r *SQLBankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error) {
+	var dbModels []BankAccount
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BankAccount, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBankAccount(&m)
+	}
+	return res, nil
+}
+
+// SQLCustomerCreditRepo implements domain.CustomerCreditRepository
+type SQLCustomerCreditRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLCustomerCreditRepo(db *gorm.DB) *SQLCustomerCreditRepo {
+	return &SQLCustomerCreditRepo{db: db}
+}
+
+func (r *SQLCustomerCreditRepo) Create(ctx context.Context, cc *domain.CustomerCredit) error {
+	dbModel := FromDomainCustomerCredit(cc)
+	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SQLCustomerCreditRepo) GetByID(ctx context.Context, id string) (*domain.CustomerCredit, error) {
+	var dbModel CustomerCredit
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainCustomerCredit(&dbModel), nil
+}
+
+func (r *SQLCustomerCreditRepo) GetByCustomerID(ctx context.Context, customerID string) (*domain.CustomerCredit, error) {
+	var dbModel CustomerCredit
+	if err := GetDB(ctx, r.db).First(&dbModel, "customer_id = ?", customerID).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainCustomerCredit(&dbModel), nil
+}
+
+func (r *SQLCustomerCreditRepo) Update(ctx context.Context, cc *domain.CustomerCredit) error {
+	tx := GetDB(ctx, r.db)
+
+	var dbModel CustomerCredit
+	if err := tx.First(&dbModel, "id = ?", cc.ID).Error; err != nil {
+		return err
+	}
+
+	// The caller's version must still be current, otherwise someone else changed the record
+	if dbModel.Version != cc.Version {
+		return domain.ErrOptimisticLock
+	}
+	newVersion := cc.Version + 1
+
+	res := tx.Model(&CustomerCredit{}).
+		Where("id = ? AND version = ?", cc.ID, cc.Version).
+		Updates(map[string]interface{}{
+			"credit_limit":    cc.CreditLimit,
+			"current_balance": cc.CurrentBalance,
+			"is_on_hold":      cc.IsOnHold,
+			"hold_reason":     cc.HoldReason,
+			"updated_at":      time.Now(),
+			"version":         newVersion,
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return domain.ErrOptimisticLock
+	}
+
+	cc.Version = newVersion
+	return nil
+}
+
+func (r *SQLCustomerCreditRepo) List(ctx context.Context) ([]domain.CustomerCredit, error) {
+	var dbModels []CustomerCredit
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.CustomerCredit, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainCustomerCredit(&m)
+	}
+	return res, nil
+}
+
+// SQLBankStatementRepo implements domain.BankStatementRepository
+type SQLBankStatementRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLBankStatementRepo(db *gorm.DB) *SQLBankStatementRepo {
+	return &SQLBankStatementRepo{db: db}
+}
+
+func (r *SQLBankStatementRepo) Create(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+	tx := GetDB(ctx, r.db)
+	return tx.Transaction(func(txDb *gorm.DB) error {
+		dbBs := FromDomainBankStatement(bs)
+		if err := txDb.Create(dbBs).Error; err != nil {
+			// A concurrent import of the same statement loses on the unique index
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return fmt.Errorf("%w: %s", domain.ErrDuplicateBankStatement, bs.StatementReference)
+			}
+			return err
+		}
+		for i := range lines {
+			dbLine := FromDomainBankStatementLine(&lines[i])
+			dbLine.StatementID = bs.ID
+			if err := txDb.Create(dbLine).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLBankStatementRepo) GetByID(ctx context.Context, id string) (*domain.BankStatement, []domain.BankStatementLine, error) {
+	tx := GetDB(ctx, r.db)
+	var dbBs BankStatement
+	if err := tx.First(&dbBs, "id = ?", id).Error; err != nil {
+		return nil, nil, err
+	}
+	var dbLines []BankStatementLine
+	if err := tx.Find(&dbLines, "statement_id = ?", id).Error; err != nil {
+		return nil, nil, err
+	}
+	lines := make([]domain.BankStatementLine, len(dbLines))
+	for i, m := range dbLines {
+		lines[i] = *ToDomainBankStatementLine(&m)
+	}
+	return ToDomainBankStatement(&dbBs), lines, nil
+}
+
+func (r *SQLBankStatementRepo) Update(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+	tx := GetDB(ctx, r.db)
+	return tx.Transaction(func(txDb *gorm.DB) error {
+		dbBs := FromDomainBankStatement(bs)
+		if err := txDb.Save(dbBs).Error; err != nil {
+			return err
+		}
+		// Upsert lines in place: reconciliation matches and exceptions cascade on line deletion
+		keep := make([]string, 0, len(lines))
+		for i := range lines {
+			dbLine := FromDomainBankStatementLine(&lines[i])
+			dbLine.StatementID = bs.ID
+			if err := txDb.Save(dbLine).Error; err != nil {
+				return err
+			}
+			keep = append(keep, dbLine.ID)
+		}
+		stale := txDb.Where("statement_id = ?", bs.ID)
+		if len(keep) > 0 {
+			stale = stale.Where("id NOT IN ?", keep)
+		}
+		return stale.Delete(&BankStatementLine{}).Error
+	})
+}
+
+func (r *SQLBankStatementRepo) List(ctx context.Context) ([]domain.BankStatement, error) {
+	var dbModels []BankStatement
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BankStatement, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBankStatement(&m)
+	}
+	return res, nil
+}
+
+func (r *SQLBankStatementRepo) ExistsByReference(ctx context.Context, bankAccountID, reference string) (bool, error) {
+	var count int64
+	err := GetDB(ctx, r.db).Model(&BankStatement{}).
+		Where("bank_account_id = ? AND statement_reference = ?", bankAccountID, reference).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SQLBankReconciliationMatchRepo implements domain.BankReconciliationMatchRepository
+type SQLBankReconciliationMatchRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLBankReconciliationMatchRepo(db *gorm.DB) *SQLBankReconciliationMatchRepo {
+	return &SQLBankReconciliationMatchRepo{db: db}
+}
+
+func (r *SQLBankReconciliationMatchRepo) CreateMany(ctx context.Context, matches []domain.BankReconciliationMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	dbModels := make([]BankReconciliationMatch, len(matches))
+	for i := range matches {
+		dbModels[i] = *FromDomainBankReconciliationMatch(&matches[i])
+	}
+	return GetDB(ctx, r.db).Create(&dbModels).Error
+}
+
+func (r *SQLBankReconciliationMatchRepo) ListByStatement(ctx context.Context, statementID string) ([]domain.BankReconciliationMatch, error) {
+	var dbModels []BankReconciliationMatch
+	if err := GetDB(ctx, r.db).Where("statement_id = ?", statementID).Order("created_at asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BankReconciliationMatch, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBankReconciliationMatch(&m)
+	}
+	return res, nil
+}
+
+func (r *SQLBankReconciliationMatchRepo) DeleteByGroup(ctx context.Context, groupID string) error {
+	res := GetDB(ctx, r.db).Delete(&BankReconciliationMatch{}, "match_group_id = ?", groupID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *SQLBankReconciliationMatchRepo) List(ctx context.Context) ([]domain.BankReconciliationMatch, error) {
+	var dbModels []BankReconciliationMatch
+	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BankReconciliationMatch, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBankReconciliationMatch(&m)
+	}
+	return res, nil
+}
+
+// SQLBankReconciliationExceptionRepo implements domain.BankReconciliationExceptionRepository
+type SQLBankReconciliationExceptionRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLBankReconciliationExceptionRepo(db *gorm.DB) *SQLBankReconciliationExceptionRepo {
+	return &SQLBankReconciliationExceptionRepo{db: db}
+}
+
+func (r *SQLBankReconciliationExceptionRepo) Create(ctx context.Context, exc *domain.BankReconciliationException) error {
+	dbModel := FromDomainBankReconciliationException(exc)
+	return GetDB(ctx, r.db).Create(dbModel).Error
+}
+
+func (r *SQLBankReconciliationExceptionRepo) GetByID(ctx context.Context, id string) (*domain.BankReconciliationException, error) {
+	var dbModel BankReconciliationException
 	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
-	return ToDomainBankAccount(&dbModel), nil
+	return ToDomainBankReconciliationException(&dbModel), nil
 }
 
-func (r *SQLBankAccountRepo) Update(ctx context.Context, ba *domain.BankAccount) error {
-	tx := GetDB(ctx, r.db)
+func (r *SQLBankReconciliationExceptionRepo) Update(ctx context.Context, exc *domain.BankReconciliationException) error {
+	dbModel := FromDomainBankReconciliationException(exc)
+	return GetDB(ctx, r.db).Save(dbModel).Error
+}
 
-	var dbModel BankAccount
-	if err := tx.First(&dbModel, "id = ?", ba.ID).Error; err != nil {
-		return err
+func (r *SQLBankReconciliationExceptionRepo) ListByStatement(ctx context.Context, statementID string) ([]domain.BankReconciliationException, error) {
+	var dbModels []BankReconciliationException
+	if err := GetDB(ctx, r.db).Where("statement_id = ?", statementID).Order("created_at asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.BankReconciliationException, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBankReconciliationException(&m)
+	}
+	return res, nil
+}
+
+func (r *SQLBankReconciliationExceptionRepo) List(ctx context.Context) ([]domain.BankReconciliationException, error) {
+	var dbModels []BankReconciliationException
+	if err := GetDB(ctx, r.db).Order("created_at asc").Find(&dbModels).Error; err != nil {
+		return nil, err
 	}
+	res := make([]domain.BankReconciliationException, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainBankReconciliationException(&m)
+	}
+	return res, nil
+}
 
-	expectedVersion := dbModel.Version
-	newVersion := expectedVersion + 1
+// SQLPayrollRunSnapshotRepo implements domain.PayrollRunSnapshotRepository
+type SQLPayrollRunSnapshotRepo struct {
+	db *gorm.DB
+}
 
-	res := tx.Model(&BankAccount{}).
-		Where("id = ? AND version = ?", ba.ID, expectedVersion).
-		Updates(map[string]interface{}{
-			"liquid_balance": ba.LiquidBalance,
-			"updated_at":     time.Now(),
-			"version":        newVersion,
-		})
+func NewSQLPayrollRunSnapshotRepo(db *gorm.DB) *SQLPayrollRunSnapshotRepo {
+	return &SQLPayrollRunSnapshotRepo{db: db}
+}
 
-	if res.Error != nil {
-		return res.Error
+func (r *SQLPayrollRunSnapshotRepo) Upsert(ctx context.Context, snap *domain.PayrollRunSnapshot) error {
+	dbModel := FromDomainPayrollRunSnapshot(snap)
+	return GetDB(ctx, r.db).Save(dbModel).Error
+}
+
+func (r *SQLPayrollRunSnapshotRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.PayrollRunSnapshot, error) {
+	var dbModels []PayrollRunSnapshot
+	if err := GetDB(ctx, r.db).Where("legal_entity_id = ?", legalEntityID).Order("processed_at asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.PayrollRunSnapshot, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainPayrollRunSnapshot(&m)
 	}
+	return res, nil
+}
 
-	if res.RowsAffected == 0 {
-		return domain.ErrOptimisticLock
+// SQLRecurringCashItemRepo implements domain.RecurringCashItemRepository
+type SQLRecurringCashItemRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLRecurringCashItemRepo(db *gorm.DB) *SQLRecurringCashItemRepo {
+	return &SQLRecurringCashItemRepo{db: db}
+}
+
+func (r *SQLRecurringCashItemRepo) Create(ctx context.Context, item *domain.RecurringCashItem) error {
+	dbModel := FromDomainRecurringCashItem(item)
+	return GetDB(ctx, r.db).Create(dbModel).Error
+}
+
+func (r *SQLRecurringCashItemRepo) GetByID(ctx context.Context, id string) (*domain.RecurringCashItem, error) {
+	var dbModel RecurringCashItem
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
 	}
+	return ToDomainRecurringCashItem(&dbModel), nil
+}
 
-	ba.Version = newVersion
-	return nil
+func (r *SQLRecurringCashItemRepo) Update(ctx context.Context, item *domain.RecurringCashItem) error {
+	dbModel := FromDomainRecurringCashItem(item)
+	return GetDB(ctx, r.db).Save(dbModel).Error
 }
 
-func (r *SQLBankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error) {
-	var dbModels []BankAccount
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+func (r *SQLRecurringCashItemRepo) Delete(ctx context.Context, id string) error {
+	return GetDB(ctx, r.db).Delete(&RecurringCashItem{}, "id = ?", id).Error
+}
+
+func (r *SQLRecurringCashItemRepo) List(ctx context.Context) ([]domain.RecurringCashItem, error) {
+	var dbModels []RecurringCashItem
+	if err := GetDB(ctx, r.db).Order("next_occurrence asc").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.BankAccount, len(dbModels))
+	res := make([]domain.RecurringCashItem, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainBankAccount(&m)
+		res[i] = *ToDomainRecurringCashItem(&m)
 	}
 	return res, nil
 }
 
-// SQLCustomerCreditRepo implements domain.CustomerCreditRepository
-type SQLCustomerCreditRepo struct {
+// SQLTaxJurisdictionRepo implements domain.TaxJurisdictionRepository
+type SQLTaxJurisdictionRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLCustomerCreditRepo(db *gorm.DB) *SQLCustomerCreditRepo {
-	return &SQLCustomerCreditRepo{db: db}
+func NewSQLTaxJurisdictionRepo(db *gorm.DB) *SQLTaxJurisdictionRepo {
+	return &SQLTaxJurisdictionRepo{db: db}
 }
 
-func (r *SQLCustomerCreditRepo) Create(ctx context.Context, cc *domain.CustomerCredit) error {
-	dbModel := FromDomainCustomerCredit(cc)
-	if err := GetDB(ctx, r.db).Create(dbModel).Error; err != nil {
-		return err
-	}
-	return nil
+func (r *SQLTaxJurisdictionRepo) Create(ctx context.Context, j *domain.TaxJurisdiction) error {
+	return GetDB(ctx, r.db).Create(FromDomainTaxJurisdiction(j)).Error
 }
 
-func (r *SQLCustomerCreditRepo) GetByID(ctx context.Context, id string) (*domain.CustomerCredit, error) {
-	var dbModel CustomerCredit
+func (r *SQLTaxJurisdictionRepo) GetByID(ctx context.Context, id string) (*domain.TaxJurisdiction, error) {
+	var dbModel TaxJurisdiction
 	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
-	return ToDomainCustomerCredit(&dbModel), nil
+	return ToDomainTaxJurisdiction(&dbModel), nil
 }
 
-func (r *SQLCustomerCreditRepo) GetByCustomerID(ctx context.Context, customerID string) (*domain.CustomerCredit, error) {
-	var dbModel CustomerCredit
-	if err := GetDB(ctx, r.db).First(&dbModel, "customer_id = ?", customerID).Error; err != nil {
+func (r *SQLTaxJurisdictionRepo) GetByCode(ctx context.Context, code string) (*domain.TaxJurisdiction, error) {
+	var dbModel TaxJurisdiction
+	if err := GetDB(ctx, r.db).First(&dbModel, "code = ?", code).Error; err != nil {
 		return nil, err
 	}
-	return ToDomainCustomerCredit(&dbModel), nil
+	return ToDomainTaxJurisdiction(&dbModel), nil
 }
 
-func (r *SQLCustomerCreditRepo) Update(ctx context.Context, cc *domain.CustomerCredit) error {
-	tx := GetDB(ctx, r.db)
+func (r *SQLTaxJurisdictionRepo) List(ctx context.Context) ([]domain.TaxJurisdiction, error) {
+	var dbModels []TaxJurisdiction
+	if err := GetDB(ctx, r.db).Order("code asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.TaxJurisdiction, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainTaxJurisdiction(&m)
+	}
+	return res, nil
+}
 
-	var dbModel CustomerCredit
-	if err := tx.First(&dbModel, "id = ?", cc.ID).Error; err != nil {
-		return err
+// SQLTaxExemptionRepo implements domain.TaxExemptionRepository
+type SQLTaxExemptionRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLTaxExemptionRepo(db *gorm.DB) *SQLTaxExemptionRepo {
+	return &SQLTaxExemptionRepo{db: db}
+}
+
+func (r *SQLTaxExemptionRepo) Create(ctx context.Context, e *domain.TaxExemption) error {
+	return GetDB(ctx, r.db).Create(FromDomainTaxExemption(e)).Error
+}
+
+func (r *SQLTaxExemptionRepo) ListByCustomer(ctx context.Context, customerID string) ([]domain.TaxExemption, error) {
+	var dbModels []TaxExemption
+	if err := GetDB(ctx, r.db).Where("customer_id = ?", customerID).Order("valid_from asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.TaxExemption, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainTaxExemption(&m)
 	}
+	return res, nil
+}
 
-	expectedVersion := dbModel.Version
-	newVersion := expectedVersion + 1
+// SQLTaxTransactionRepo implements domain.TaxTransactionRepository
+type SQLTaxTransactionRepo struct {
+	db *gorm.DB
+}
 
-	res := tx.Model(&CustomerCredit{}).
-		Where("id = ? AND version = ?", cc.ID, expectedVersion).
-		Updates(map[string]interface{}{
-			"credit_limit":    cc.CreditLimit,
-			"current_balance": cc.CurrentBalance,
-			"is_on_hold":      cc.IsOnHold,
-			"updated_at":      time.Now(),
-			"version":         newVersion,
-		})
+func NewSQLTaxTransactionRepo(db *gorm.DB) *SQLTaxTransactionRepo {
+	return &SQLTaxTransactionRepo{db: db}
+}
 
-	if res.Error != nil {
-		return res.Error
+func (r *SQLTaxTransactionRepo) CreateMany(ctx context.Context, txs []domain.TaxTransaction) error {
+	if len(txs) == 0 {
+		return nil
 	}
+	dbModels := make([]TaxTransaction, len(txs))
+	for i := range txs {
+		dbModels[i] = *FromDomainTaxTransaction(&txs[i])
+	}
+	return GetDB(ctx, r.db).Create(&dbModels).Error
+}
 
-	if res.RowsAffected == 0 {
-		return domain.ErrOptimisticLock
+func (r *SQLTaxTransactionRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.TaxTransaction, error) {
+	var dbModels []TaxTransaction
+	if err := GetDB(ctx, r.db).Where("legal_entity_id = ?", legalEntityID).Order("posting_date asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.TaxTransaction, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainTaxTransaction(&m)
 	}
+	return res, nil
+}
 
-	cc.Version = newVersion
-	return nil
+// SQLFxRevaluationRepo implements domain.FxRevaluationRepository
+type SQLFxRevaluationRepo struct {
+	db *gorm.DB
 }
 
-func (r *SQLCustomerCreditRepo) List(ctx context.Context) ([]domain.CustomerCredit, error) {
-	var dbModels []CustomerCredit
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+func NewSQLFxRevaluationRepo(db *gorm.DB) *SQLFxRevaluationRepo {
+	return &SQLFxRevaluationRepo{db: db}
+}
+
+func (r *SQLFxRevaluationRepo) CreateMany(ctx context.Context, revaluations []domain.FxRevaluation) error {
+	if len(revaluations) == 0 {
+		return nil
+	}
+	dbModels := make([]FxRevaluation, len(revaluations))
+	for i := range revaluations {
+		dbModels[i] = *FromDomainFxRevaluation(&revaluations[i])
+	}
+	return GetDB(ctx, r.db).Create(&dbModels).Error
+}
+
+func (r *SQLFxRevaluationRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.FxRevaluation, error) {
+	var dbModels []FxRevaluation
+	if err := GetDB(ctx, r.db).Where("legal_entity_id = ?", legalEntityID).Order("revaluation_date asc").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.CustomerCredit, len(dbModels))
+	res := make([]domain.FxRevaluation, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainCustomerCredit(&m)
+		res[i] = *ToDomainFxRevaluation(&m)
 	}
 	return res, nil
 }
 
-// SQLBankStatementRepo implements domain.BankStatementRepository
-type SQLBankStatementRepo struct {
+// SQLFiscalPeriodRepo implements domain.FiscalPeriodRepository
+type SQLFiscalPeriodRepo struct {
 	db *gorm.DB
 }
 
-func NewSQLBankStatementRepo(db *gorm.DB) *SQLBankStatementRepo {
-	return &SQLBankStatementRepo{db: db}
+func NewSQLFiscalPeriodRepo(db *gorm.DB) *SQLFiscalPeriodRepo {
+	return &SQLFiscalPeriodRepo{db: db}
 }
 
-func (r *SQLBankStatementRepo) Create(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
+func (r *SQLFiscalPeriodRepo) Upsert(ctx context.Context, period *domain.FiscalPeriod) error {
+	dbModel := FromDomainFiscalPeriod(period)
+	return GetDB(ctx, r.db).Save(dbModel).Error
+}
+
+func (r *SQLFiscalPeriodRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.FiscalPeriod, error) {
+	var dbModels []FiscalPeriod
+	if err := GetDB(ctx, r.db).Where("legal_entity_id = ?", legalEntityID).Order("financial_period asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.FiscalPeriod, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainFiscalPeriod(&m)
+	}
+	return res, nil
+}
+
+// SQLIntercompanyTransactionRepo implements domain.IntercompanyTransactionRepository
+type SQLIntercompanyTransactionRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLIntercompanyTransactionRepo(db *gorm.DB) *SQLIntercompanyTransactionRepo {
+	return &SQLIntercompanyTransactionRepo{db: db}
+}
+
+func (r *SQLIntercompanyTransactionRepo) Create(ctx context.Context, tx *domain.IntercompanyTransaction) error {
+	dbModel := FromDomainIntercompanyTransaction(tx)
+	return GetDB(ctx, r.db).Create(dbModel).Error
+}
+
+func (r *SQLIntercompanyTransactionRepo) GetByID(ctx context.Context, id string) (*domain.IntercompanyTransaction, error) {
+	var dbModel IntercompanyTransaction
+	if err := GetDB(ctx, r.db).First(&dbModel, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return ToDomainIntercompanyTransaction(&dbModel), nil
+}
+
+func (r *SQLIntercompanyTransactionRepo) ListByLegalEntity(ctx context.Context, legalEntityID string) ([]domain.IntercompanyTransaction, error) {
+	var dbModels []IntercompanyTransaction
+	if err := GetDB(ctx, r.db).Where("from_legal_entity_id = ? OR to_legal_entity_id = ?", legalEntityID, legalEntityID).
+		Order("posting_date asc").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.IntercompanyTransaction, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainIntercompanyTransaction(&m)
+	}
+	return res, nil
+}
+
+// SQLConsolidationGroupRepo implements domain.ConsolidationGroupRepository
+type SQLConsolidationGroupRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLConsolidationGroupRepo(db *gorm.DB) *SQLConsolidationGroupRepo {
+	return &SQLConsolidationGroupRepo{db: db}
+}
+
+func (r *SQLConsolidationGroupRepo) Create(ctx context.Context, group *domain.ConsolidationGroup, members []domain.ConsolidationGroupMember) error {
 	tx := GetDB(ctx, r.db)
 	return tx.Transaction(func(txDb *gorm.DB) error {
-		dbBs := FromDomainBankStatement(bs)
-		if err := txDb.Create(dbBs).Error; err != nil {
+		if err := txDb.Create(FromDomainConsolidationGroup(group)).Error; err != nil {
 			return err
 		}
-		for i := range lines {
-			dbLine := FromDomainBankStatementLine(&lines[i])
-			dbLine.StatementID = bs.ID
-			if err := txDb.Create(dbLine).Error; err != nil {
+		for i := range members {
+			dbMember := FromDomainConsolidationGroupMember(&members[i])
+			dbMember.ConsolidationGroupID = group.ID
+			if err := txDb.Create(dbMember).Error; err != nil {
 				return err
 			}
 		}
@@ -731,52 +2135,31 @@ func (r *SQLBankStatementRepo) Create(ctx context.Context, bs *domain.BankStatem
 	})
 }
 
-func (r *SQLBankStatementRepo) GetByID(ctx context.Context, id string) (*domain.BankStatement, []domain.BankStatementLine, error) {
+func (r *SQLConsolidationGroupRepo) GetByID(ctx context.Context, id string) (*domain.ConsolidationGroup, []domain.ConsolidationGroupMember, error) {
 	tx := GetDB(ctx, r.db)
-	var dbBs BankStatement
-	if err := tx.First(&dbBs, "id = ?", id).Error; err != nil {
+	var dbGroup ConsolidationGroup
+	if err := tx.First(&dbGroup, "id = ?", id).Error; err != nil {
 		return nil, nil, err
 	}
-	var dbLines []BankStatementLine
-	if err := tx.Find(&dbLines, "statement_id = ?", id).Error; err != nil {
+	var dbMembers []ConsolidationGroupMember
+	if err := tx.Find(&dbMembers, "consolidation_group_id = ?", id).Error; err != nil {
 		return nil, nil, err
 	}
-	lines := make([]domain.BankStatementLine, len(dbLines))
-	for i, m := range dbLines {
-		lines[i] = *ToDomainBankStatementLine(&m)
+	members := make([]domain.ConsolidationGroupMember, len(dbMembers))
+	for i, m := range dbMembers {
+		members[i] = *ToDomainConsolidationGroupMember(&m)
 	}
-	return ToDomainBankStatement(&dbBs), lines, nil
-}
-
-func (r *SQLBankStatementRepo) Update(ctx context.Context, bs *domain.BankStatement, lines []domain.BankStatementLine) error {
-	tx := GetDB(ctx, r.db)
-	return tx.Transaction(func(txDb *gorm.DB) error {
-		dbBs := FromDomainBankStatement(bs)
-		if err := txDb.Save(dbBs).Error; err != nil {
-			return err
-		}
-		if err := txDb.Delete(&BankStatementLine{}, "statement_id = ?", bs.ID).Error; err != nil {
-			return err
-		}
-		for i := range lines {
-			dbLine := FromDomainBankStatementLine(&lines[i])
-			dbLine.StatementID = bs.ID
-			if err := txDb.Create(dbLine).Error; err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	return ToDomainConsolidationGroup(&dbGroup), members, nil
 }
 
-func (r *SQLBankStatementRepo) List(ctx context.Context) ([]domain.BankStatement, error) {
-	var dbModels []BankStatement
-	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
+func (r *SQLConsolidationGroupRepo) List(ctx context.Context) ([]domain.ConsolidationGroup, error) {
+	var dbModels []ConsolidationGroup
+	if err := GetDB(ctx, r.db).Order("code asc").Find(&dbModels).Error; err != nil {
 		return nil, err
 	}
-	res := make([]domain.BankStatement, len(dbModels))
+	res := make([]domain.ConsolidationGroup, len(dbModels))
 	for i, m := range dbModels {
-		res[i] = *ToDomainBankStatement(&m)
+		res[i] = *ToDomainConsolidationGroup(&m)
 	}
 	return res, nil
 }
@@ -959,6 +2342,10 @@ func (r *SQLDepreciationScheduleLineRepo) Update(ctx context.Context, line *doma
 	return nil
 }
 
+func (r *SQLDepreciationScheduleLineRepo) Delete(ctx context.Context, id string) error {
+	return GetDB(ctx, r.db).Delete(&DepreciationScheduleLine{}, "id = ?", id).Error
+}
+
 func (r *SQLDepreciationScheduleLineRepo) List(ctx context.Context) ([]domain.DepreciationScheduleLine, error) {
 	var dbModels []DepreciationScheduleLine
 	if err := GetDB(ctx, r.db).Find(&dbModels).Error; err != nil {
@@ -995,3 +2382,28 @@ func (r *SQLKafkaEventInboxRepo) GetByID(ctx context.Context, eventID string) (*
 	}
 	return ToDomainKafkaEventInbox(&dbModel), nil
 }
+
+// SQLAssetUsageRecordRepo implements domain.AssetUsageRecordRepository
+type SQLAssetUsageRecordRepo struct {
+	db *gorm.DB
+}
+
+func NewSQLAssetUsageRecordRepo(db *gorm.DB) *SQLAssetUsageRecordRepo {
+	return &SQLAssetUsageRecordRepo{db: db}
+}
+
+func (r *SQLAssetUsageRecordRepo) Create(ctx context.Context, record *domain.AssetUsageRecord) error {
+	return GetDB(ctx, r.db).Create(FromDomainAssetUsageRecord(record)).Error
+}
+
+func (r *SQLAssetUsageRecordRepo) ListByAsset(ctx context.Context, assetID string) ([]domain.AssetUsageRecord, error) {
+	var dbModels []AssetUsageRecord
+	if err := GetDB(ctx, r.db).Where("fixed_asset_id = ?", assetID).Order("usage_date").Find(&dbModels).Error; err != nil {
+		return nil, err
+	}
+	res := make([]domain.AssetUsageRecord, len(dbModels))
+	for i, m := range dbModels {
+		res[i] = *ToDomainAssetUsageRecord(&m)
+	}
+	return res, nil
+}
@@ -48,7 +48,7 @@ func main() {
 
 	// 4. Initialize Services
 	empSvc := service.NewEmployeeService(db, empRepo, deptRepo, outboxRepo)
-	payrollSvc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	payrollSvc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	expenseSvc := service.NewExpenseService(db, expenseClaimRepo, expenseClaimLineRepo, empRepo, outboxRepo)
 	reliableSvc := service.NewReliableMessagingService(db, inboxRepo)
 
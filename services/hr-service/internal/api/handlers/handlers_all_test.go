@@ -57,7 +57,7 @@ func setupTestEnv(t *testing.T) *testEnv {
 	outboxRepo := sql.NewSQLTransactionalOutboxRepository(db)
 
 	empService := service.NewEmployeeService(db, empRepo, deptRepo, outboxRepo)
-	payrollSvc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	payrollSvc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	expenseSvc := service.NewExpenseService(db, expenseRepo, lineRepo, empRepo, outboxRepo)
 
 	hrHandler := handlers.NewHrHandler(empService, payrollSvc, expenseSvc, deptRepo, empRepo, payrollRepo, expenseRepo, lineRepo)
@@ -51,8 +51,9 @@ func (h *HrHandler) CreateDepartment(c *gin.Context) {
 	var req struct {
 		ID             string `json:"id"`
 		LegalEntityID  string `json:"legal_entity_id" binding:"required"`
-		DepartmentCode string `json:"department_code" binding:"required"`
-		Name           string `json:"name" binding:"required"`
+		DepartmentCode string  `json:"department_code" binding:"required"`
+		Name           string  `json:"name" binding:"required"`
+		CostCenterID   *string `json:"cost_center_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -70,6 +71,7 @@ func (h *HrHandler) CreateDepartment(c *gin.Context) {
 		LegalEntityID:  req.LegalEntityID,
 		DepartmentCode: req.DepartmentCode,
 		Name:           req.Name,
+		CostCenterID:   req.CostCenterID,
 		IsActive:       true,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
@@ -111,8 +113,9 @@ func (h *HrHandler) UpdateDepartment(c *gin.Context) {
 	}
 
 	var req struct {
-		Name     string `json:"name" binding:"required"`
-		IsActive bool   `json:"is_active"`
+		Name         string  `json:"name" binding:"required"`
+		CostCenterID *string `json:"cost_center_id"`
+		IsActive     bool    `json:"is_active"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -120,6 +123,7 @@ func (h *HrHandler) UpdateDepartment(c *gin.Context) {
 	}
 
 	dept.Name = req.Name
+	dept.CostCenterID = req.CostCenterID
 	dept.IsActive = req.IsActive
 	dept.UpdatedAt = time.Now()
 
@@ -10,6 +10,7 @@ type Department struct {
 	LegalEntityID  string    `json:"legal_entity_id"` // Primitive Ref -> FM.LegalEntity (Loose Link)
 	DepartmentCode string    `json:"department_code"` // Unique within tenant boundary (e.g., "DEPT_ENG")
 	Name           string    `json:"name"`
+	CostCenterID   *string   `json:"cost_center_id,omitempty"` // Primitive Ref -> FM.CostCenter; headcount reported per cost center
 	IsActive       bool      `json:"is_active"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
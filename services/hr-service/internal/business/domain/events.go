@@ -44,7 +44,14 @@ type PayrollProcessedEvent struct {
 	PeriodNumber  int             `json:"period_number"`
 	TotalNetPay   decimal.Decimal `json:"total_net_pay"`
 	TotalGrossPay decimal.Decimal `json:"total_gross_pay"`
-	Timestamp     time.Time       `json:"timestamp"`
+	// Headcount lets FM allocate overhead by the active employees of each cost center
+	Headcount []CostCenterHeadcount `json:"headcount,omitempty"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+type CostCenterHeadcount struct {
+	CostCenterID string `json:"cost_center_id"`
+	Headcount    int    `json:"headcount"`
 }
 
 type ExpenseApprovedEvent struct {
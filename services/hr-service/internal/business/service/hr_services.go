@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/erp-system/hr-service/internal/business/domain"
@@ -241,6 +242,7 @@ type PayrollServiceImpl struct {
 	db          *gorm.DB
 	payrollRepo domain.PayrollRunRepository
 	empRepo     domain.EmployeeMasterRepository
+	deptRepo    domain.DepartmentRepository
 	outboxRepo  domain.TransactionalOutboxRepository
 }
 
@@ -248,12 +250,14 @@ func NewPayrollService(
 	db *gorm.DB,
 	payrollRepo domain.PayrollRunRepository,
 	empRepo domain.EmployeeMasterRepository,
+	deptRepo domain.DepartmentRepository,
 	outboxRepo domain.TransactionalOutboxRepository,
 ) PayrollService {
 	return &PayrollServiceImpl{
 		db:          db,
 		payrollRepo: payrollRepo,
 		empRepo:     empRepo,
+		deptRepo:    deptRepo,
 		outboxRepo:  outboxRepo,
 	}
 }
@@ -345,6 +349,11 @@ func (s *PayrollServiceImpl) CloseAndApprovePayroll(
 		return nil, errors.New("only DRAFT payroll runs can be approved")
 	}
 
+	headcount, err := s.headcountByCostCenter(ctx, run.LegalEntityID)
+	if err != nil {
+		return nil, err
+	}
+
 	run.Status = domain.PayrollStatusAPPROVED
 	run.UpdatedAt = time.Now()
 
@@ -362,6 +371,7 @@ func (s *PayrollServiceImpl) CloseAndApprovePayroll(
 			PeriodNumber:  run.PeriodNumber,
 			TotalNetPay:   run.TotalNetPay,
 			TotalGrossPay: run.TotalGrossPay,
+			Headcount:     headcount,
 			Timestamp:     time.Now(),
 		}
 
@@ -384,6 +394,44 @@ func (s *PayrollServiceImpl) CloseAndApprovePayroll(
 	return run, nil
 }
 
+// headcountByCostCenter counts the active employees of the legal entity per cost center of their
+// department. Departments without a cost center are left out; those without staff report zero.
+func (s *PayrollServiceImpl) headcountByCostCenter(ctx context.Context, legalEntityId string) ([]domain.CostCenterHeadcount, error) {
+	depts, err := s.deptRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	costCenterOf := make(map[string]string)
+	for _, d := range depts {
+		if d.LegalEntityID != legalEntityId || d.CostCenterID == nil || *d.CostCenterID == "" {
+			continue
+		}
+		costCenterOf[d.ID] = *d.CostCenterID
+		counts[*d.CostCenterID] += 0
+	}
+
+	emps, err := s.empRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range emps {
+		if e.LegalEntityID != legalEntityId || e.Status != domain.EmployeeStatusACTIVE {
+			continue
+		}
+		if cc, ok := costCenterOf[e.DepartmentID]; ok {
+			counts[cc]++
+		}
+	}
+
+	headcount := make([]domain.CostCenterHeadcount, 0, len(counts))
+	for cc, n := range counts {
+		headcount = append(headcount, domain.CostCenterHeadcount{CostCenterID: cc, Headcount: n})
+	}
+	sort.Slice(headcount, func(i, j int) bool { return headcount[i].CostCenterID < headcount[j].CostCenterID })
+	return headcount, nil
+}
+
 // ==========================================
 // ExpenseService Interface & Implementation
 // ==========================================
@@ -646,9 +646,9 @@ func TestEmployeeService_FetchManagementChain_EmployeeNotFound(t *testing.T) {
 }
 
 func TestPayrollService_InitiatePeriodRun_Success(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	run, err := svc.InitiatePeriodRun(context.Background(), "tenant-1", 2026, 6)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -659,7 +659,7 @@ func TestPayrollService_InitiatePeriodRun_Success(t *testing.T) {
 }
 
 func TestPayrollService_InitiatePeriodRun_AlreadyExists(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	existing := &domain.PayrollRun{
 		ID:            "pay-existing",
@@ -669,7 +669,7 @@ func TestPayrollService_InitiatePeriodRun_AlreadyExists(t *testing.T) {
 	}
 	_ = payrollRepo.Create(context.Background(), existing)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.InitiatePeriodRun(context.Background(), "tenant-1", 2026, 6)
 	if err == nil || err.Error() != "payroll run for this period already exists" {
 		t.Fatalf("Expected 'payroll run for this period already exists', got %v", err)
@@ -677,11 +677,11 @@ func TestPayrollService_InitiatePeriodRun_AlreadyExists(t *testing.T) {
 }
 
 func TestPayrollService_InitiatePeriodRun_CreateFails(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	payrollRepo.createError = errors.New("db create error")
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.InitiatePeriodRun(context.Background(), "tenant-1", 2026, 6)
 	if err == nil || err.Error() != "db create error" {
 		t.Fatalf("Expected 'db create error', got %v", err)
@@ -689,7 +689,7 @@ func TestPayrollService_InitiatePeriodRun_CreateFails(t *testing.T) {
 }
 
 func TestPayrollService_ExecuteCalculations_Success(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:            "pay-1",
@@ -720,7 +720,7 @@ func TestPayrollService_ExecuteCalculations_Success(t *testing.T) {
 	_ = empRepo.Create(context.Background(), emp2)
 	_ = empRepo.Create(context.Background(), emp3)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	res, err := svc.ExecuteCalculations(context.Background(), "pay-1")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -742,9 +742,9 @@ func TestPayrollService_ExecuteCalculations_Success(t *testing.T) {
 }
 
 func TestPayrollService_ExecuteCalculations_NotFound(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.ExecuteCalculations(context.Background(), "pay-1")
 	if err == nil {
 		t.Fatal("Expected error payroll run not found, got nil")
@@ -752,7 +752,7 @@ func TestPayrollService_ExecuteCalculations_NotFound(t *testing.T) {
 }
 
 func TestPayrollService_ExecuteCalculations_NotDraft(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:     "pay-1",
@@ -760,7 +760,7 @@ func TestPayrollService_ExecuteCalculations_NotDraft(t *testing.T) {
 	}
 	_ = payrollRepo.Create(context.Background(), run)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.ExecuteCalculations(context.Background(), "pay-1")
 	if err == nil || err.Error() != "calculations can only be executed on DRAFT payroll runs" {
 		t.Fatalf("Expected 'calculations can only be executed on DRAFT payroll runs', got %v", err)
@@ -768,7 +768,7 @@ func TestPayrollService_ExecuteCalculations_NotDraft(t *testing.T) {
 }
 
 func TestPayrollService_ExecuteCalculations_EmployeeListFails(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:     "pay-1",
@@ -778,7 +778,7 @@ func TestPayrollService_ExecuteCalculations_EmployeeListFails(t *testing.T) {
 
 	empRepo.listError = errors.New("employee list error")
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.ExecuteCalculations(context.Background(), "pay-1")
 	if err == nil || err.Error() != "employee list error" {
 		t.Fatalf("Expected 'employee list error', got %v", err)
@@ -786,7 +786,7 @@ func TestPayrollService_ExecuteCalculations_EmployeeListFails(t *testing.T) {
 }
 
 func TestPayrollService_ExecuteCalculations_UpdateFails(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:     "pay-1",
@@ -796,7 +796,7 @@ func TestPayrollService_ExecuteCalculations_UpdateFails(t *testing.T) {
 
 	payrollRepo.updateError = errors.New("db update error")
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.ExecuteCalculations(context.Background(), "pay-1")
 	if err == nil || err.Error() != "db update error" {
 		t.Fatalf("Expected 'db update error', got %v", err)
@@ -804,7 +804,7 @@ func TestPayrollService_ExecuteCalculations_UpdateFails(t *testing.T) {
 }
 
 func TestPayrollService_CloseAndApprovePayroll_Success(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:            "pay-1",
@@ -813,7 +813,7 @@ func TestPayrollService_CloseAndApprovePayroll_Success(t *testing.T) {
 	}
 	_ = payrollRepo.Create(context.Background(), run)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	res, err := svc.CloseAndApprovePayroll(context.Background(), "pay-1")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -829,9 +829,9 @@ func TestPayrollService_CloseAndApprovePayroll_Success(t *testing.T) {
 }
 
 func TestPayrollService_CloseAndApprovePayroll_NotFound(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.CloseAndApprovePayroll(context.Background(), "pay-1")
 	if err == nil {
 		t.Fatal("Expected error payroll not found, got nil")
@@ -839,7 +839,7 @@ func TestPayrollService_CloseAndApprovePayroll_NotFound(t *testing.T) {
 }
 
 func TestPayrollService_CloseAndApprovePayroll_NotDraft(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:     "pay-1",
@@ -847,7 +847,7 @@ func TestPayrollService_CloseAndApprovePayroll_NotDraft(t *testing.T) {
 	}
 	_ = payrollRepo.Create(context.Background(), run)
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.CloseAndApprovePayroll(context.Background(), "pay-1")
 	if err == nil || err.Error() != "only DRAFT payroll runs can be approved" {
 		t.Fatalf("Expected 'only DRAFT payroll runs can be approved', got %v", err)
@@ -855,7 +855,7 @@ func TestPayrollService_CloseAndApprovePayroll_NotDraft(t *testing.T) {
 }
 
 func TestPayrollService_CloseAndApprovePayroll_UpdateFails(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:     "pay-1",
@@ -865,7 +865,7 @@ func TestPayrollService_CloseAndApprovePayroll_UpdateFails(t *testing.T) {
 
 	payrollRepo.updateError = errors.New("db update error")
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.CloseAndApprovePayroll(context.Background(), "pay-1")
 	if err == nil || err.Error() != "db update error" {
 		t.Fatalf("Expected 'db update error', got %v", err)
@@ -873,7 +873,7 @@ func TestPayrollService_CloseAndApprovePayroll_UpdateFails(t *testing.T) {
 }
 
 func TestPayrollService_CloseAndApprovePayroll_OutboxFails(t *testing.T) {
-	db, empRepo, _, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
 
 	run := &domain.PayrollRun{
 		ID:     "pay-1",
@@ -883,13 +883,58 @@ func TestPayrollService_CloseAndApprovePayroll_OutboxFails(t *testing.T) {
 
 	outboxRepo.createError = errors.New("db outbox create error")
 
-	svc := service.NewPayrollService(db, payrollRepo, empRepo, outboxRepo)
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
 	_, err := svc.CloseAndApprovePayroll(context.Background(), "pay-1")
 	if err == nil || err.Error() != "db outbox create error" {
 		t.Fatalf("Expected 'db outbox create error', got %v", err)
 	}
 }
 
+func TestPayrollService_CloseAndApprovePayroll_ReportsHeadcount(t *testing.T) {
+	db, empRepo, deptRepo, outboxRepo, payrollRepo, _, _, _ := setupTest(t)
+	ctx := context.Background()
+
+	ccAssembly, ccIdle := "cc-assembly", "cc-idle"
+	_ = deptRepo.Create(ctx, &domain.Department{ID: "dept-line", LegalEntityID: "tenant-1", CostCenterID: &ccAssembly, IsActive: true})
+	_ = deptRepo.Create(ctx, &domain.Department{ID: "dept-qa", LegalEntityID: "tenant-1", CostCenterID: &ccAssembly, IsActive: true})
+	_ = deptRepo.Create(ctx, &domain.Department{ID: "dept-spare", LegalEntityID: "tenant-1", CostCenterID: &ccIdle, IsActive: true})
+	_ = deptRepo.Create(ctx, &domain.Department{ID: "dept-board", LegalEntityID: "tenant-1", IsActive: true})
+	for _, e := range []domain.EmployeeMaster{
+		{ID: "emp-1", LegalEntityID: "tenant-1", DepartmentID: "dept-line", Email: "e1@example.com", Status: domain.EmployeeStatusACTIVE},
+		{ID: "emp-2", LegalEntityID: "tenant-1", DepartmentID: "dept-qa", Email: "e2@example.com", Status: domain.EmployeeStatusACTIVE},
+		{ID: "emp-3", LegalEntityID: "tenant-1", DepartmentID: "dept-line", Email: "e3@example.com", Status: domain.EmployeeStatusTERMINATED},
+		{ID: "emp-4", LegalEntityID: "tenant-1", DepartmentID: "dept-board", Email: "e4@example.com", Status: domain.EmployeeStatusACTIVE},
+		{ID: "emp-5", LegalEntityID: "tenant-2", DepartmentID: "dept-line", Email: "e5@example.com", Status: domain.EmployeeStatusACTIVE},
+	} {
+		emp := e
+		_ = empRepo.Create(ctx, &emp)
+	}
+	_ = payrollRepo.Create(ctx, &domain.PayrollRun{ID: "pay-1", LegalEntityID: "tenant-1", FiscalYear: 2026, PeriodNumber: 3, Status: domain.PayrollStatusDRAFT})
+
+	svc := service.NewPayrollService(db, payrollRepo, empRepo, deptRepo, outboxRepo)
+	if _, err := svc.CloseAndApprovePayroll(ctx, "pay-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	unsent, _ := outboxRepo.GetUnsent(ctx, 10)
+	if len(unsent) != 1 {
+		t.Fatalf("Expected 1 unsent outbox, got %d", len(unsent))
+	}
+	evt, ok := unsent[0].Payload.(domain.PayrollProcessedEvent)
+	if !ok {
+		t.Fatalf("Expected PayrollProcessedEvent payload, got %T", unsent[0].Payload)
+	}
+	want := []domain.CostCenterHeadcount{{CostCenterID: "cc-assembly", Headcount: 2}, {CostCenterID: "cc-idle", Headcount: 0}}
+	if len(evt.Headcount) != len(want) {
+		t.Fatalf("Expected headcount %+v, got %+v", want, evt.Headcount)
+	}
+	for i := range want {
+		if evt.Headcount[i] != want[i] {
+			t.Errorf("Expected headcount %+v, got %+v", want, evt.Headcount)
+		}
+	}
+}
+
 func TestExpenseService_SubmitClaim_Success(t *testing.T) {
 	db, empRepo, _, _, _, claimRepo, lineRepo, _ := setupTest(t)
 
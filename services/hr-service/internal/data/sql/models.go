@@ -15,6 +15,7 @@ type Department struct {
 	LegalEntityID string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_hr_dept_code_tenant"`
 	DepartmentCode string   `gorm:"type:varchar(100);not null;uniqueIndex:idx_hr_dept_code_tenant"`
 	Name          string    `gorm:"type:varchar(255);not null"`
+	CostCenterID  *string   `gorm:"type:varchar(255);default:null"`
 	IsActive      bool      `gorm:"type:boolean;default:true"`
 	CreatedAt     time.Time `gorm:"not null"`
 	UpdatedAt     time.Time `gorm:"not null"`
@@ -33,6 +34,7 @@ func ToDepartmentDomain(d *Department) *domain.Department {
 		LegalEntityID: d.LegalEntityID,
 		DepartmentCode: d.DepartmentCode,
 		Name:          d.Name,
+		CostCenterID:  d.CostCenterID,
 		IsActive:      d.IsActive,
 		CreatedAt:     d.CreatedAt,
 		UpdatedAt:     d.UpdatedAt,
@@ -48,6 +50,7 @@ func FromDepartmentDomain(d *domain.Department) *Department {
 		LegalEntityID: d.LegalEntityID,
 		DepartmentCode: d.DepartmentCode,
 		Name:          d.Name,
+		CostCenterID:  d.CostCenterID,
 		IsActive:      d.IsActive,
 		CreatedAt:     d.CreatedAt,
 		UpdatedAt:     d.UpdatedAt,
@@ -50,7 +50,7 @@ func main() {
 	// 4. Initialize Services (Split Components)
 	floorSvc := service.NewFloorConfigurationService(wcRepo, stationRepo)
 	execSvc := service.NewWorkOrderExecutionService(db, woRepo, stateRepo, stationRepo, outboxRepo)
-	teleSvc := service.NewShopFloorTelemetryService(db, woRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
+	teleSvc := service.NewShopFloorTelemetryService(db, woRepo, wcRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
 	reliableSvc := service.NewReliableMessagingService(db, inboxRepo)
 
 	// 5. Initialize Handlers
@@ -60,7 +60,7 @@ func setupTestEnv(t *testing.T) *testEnv {
 
 	floorSvc := service.NewFloorConfigurationService(wcRepo, stationRepo)
 	execSvc := service.NewWorkOrderExecutionService(db, woRepo, stateRepo, stationRepo, outboxRepo)
-	teleSvc := service.NewShopFloorTelemetryService(db, woRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
+	teleSvc := service.NewShopFloorTelemetryService(db, woRepo, wcRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
 
 	mfgHandler := handlers.NewMfgHandler(floorSvc, execSvc, teleSvc)
 
@@ -287,7 +287,7 @@ type mockFloorService struct {
 	appendStationToCenterFunc func(ctx context.Context, workCenterID, routingCode string, stationType domain.StationType, equipmentID *string, setupTime, runTime int) (*domain.RoutingStation, error)
 }
 
-func (m *mockFloorService) EstablishWorkCenter(ctx context.Context, legalEntityID, code, name string) (*domain.WorkCenter, error) {
+func (m *mockFloorService) EstablishWorkCenter(ctx context.Context, legalEntityID, code, name string, costCenterID *string) (*domain.WorkCenter, error) {
 	if m.establishWorkCenterFunc != nil {
 		return m.establishWorkCenterFunc(ctx, legalEntityID, code, name)
 	}
@@ -30,9 +30,10 @@ func NewMfgHandler(
 
 // 1. EstablishWorkCenter
 type EstablishWorkCenterInput struct {
-	LegalEntityID string `json:"legal_entity_id" binding:"required"`
-	Code          string `json:"code" binding:"required"`
-	Name          string `json:"name" binding:"required"`
+	LegalEntityID string  `json:"legal_entity_id" binding:"required"`
+	Code          string  `json:"code" binding:"required"`
+	Name          string  `json:"name" binding:"required"`
+	CostCenterID  *string `json:"cost_center_id"`
 }
 
 func (h *MfgHandler) EstablishWorkCenter(c *gin.Context) {
@@ -42,7 +43,7 @@ func (h *MfgHandler) EstablishWorkCenter(c *gin.Context) {
 		return
 	}
 
-	wc, err := h.floorSvc.EstablishWorkCenter(c.Request.Context(), input.LegalEntityID, input.Code, input.Name)
+	wc, err := h.floorSvc.EstablishWorkCenter(c.Request.Context(), input.LegalEntityID, input.Code, input.Name, input.CostCenterID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -43,6 +43,8 @@ type MfgYieldProducedEvent struct {
 	QuantityGood     decimal.Decimal `json:"quantity_good"`
 	QuantityScrap    decimal.Decimal `json:"quantity_scrap"`
 	OperatorHrID     string          `json:"operator_hr_id"`
+	CostCenterID     string          `json:"cost_center_id,omitempty"` // Cost center of the station's work center
+	MachineHours     decimal.Decimal `json:"machine_hours"`            // Standard run time of the good and scrapped units
 	Timestamp        time.Time       `json:"timestamp"`
 }
 
@@ -10,6 +10,7 @@ type WorkCenter struct {
 	LegalEntityID  string    `json:"legal_entity_id"`  // Primitive Ref -> FM.LegalEntity
 	WorkCenterCode string    `json:"work_center_code"` // e.g., "WC-MILLING-01"
 	Name           string    `json:"name"`
+	CostCenterID   *string   `json:"cost_center_id,omitempty"` // Primitive Ref -> FM.CostCenter charged with machine hours
 	IsActive       bool      `json:"is_active"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
@@ -26,7 +26,7 @@ func GetDB(ctx context.Context, defaultDB *gorm.DB) *gorm.DB {
 // ==========================================
 
 type FloorConfigurationService interface {
-	EstablishWorkCenter(ctx context.Context, legalEntityID, code, name string) (*domain.WorkCenter, error)
+	EstablishWorkCenter(ctx context.Context, legalEntityID, code, name string, costCenterID *string) (*domain.WorkCenter, error)
 	AppendStationToCenter(ctx context.Context, workCenterID, routingCode string, stationType domain.StationType, equipmentID *string, setupTime, runTime int) (*domain.RoutingStation, error)
 }
 
@@ -42,7 +42,7 @@ func NewFloorConfigurationService(wcRepo domain.WorkCenterRepository, stationRep
 	}
 }
 
-func (s *FloorConfigurationServiceImpl) EstablishWorkCenter(ctx context.Context, legalEntityID, code, name string) (*domain.WorkCenter, error) {
+func (s *FloorConfigurationServiceImpl) EstablishWorkCenter(ctx context.Context, legalEntityID, code, name string, costCenterID *string) (*domain.WorkCenter, error) {
 	wc, err := s.wcRepo.GetByCode(ctx, legalEntityID, code)
 	if err == nil && wc != nil {
 		return wc, nil
@@ -54,6 +54,7 @@ func (s *FloorConfigurationServiceImpl) EstablishWorkCenter(ctx context.Context,
 		LegalEntityID:  legalEntityID,
 		WorkCenterCode: code,
 		Name:           name,
+		CostCenterID:   costCenterID,
 		IsActive:       true,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
@@ -307,6 +308,7 @@ type ShopFloorTelemetryService interface {
 type ShopFloorTelemetryServiceImpl struct {
 	db          *gorm.DB
 	woRepo      domain.WorkOrderRepository
+	wcRepo      domain.WorkCenterRepository
 	stationRepo domain.RoutingStationRepository
 	consumeRepo domain.MaterialConsumptionLogRepository
 	yieldRepo   domain.ProductionYieldLogRepository
@@ -316,6 +318,7 @@ type ShopFloorTelemetryServiceImpl struct {
 func NewShopFloorTelemetryService(
 	db *gorm.DB,
 	woRepo domain.WorkOrderRepository,
+	wcRepo domain.WorkCenterRepository,
 	stationRepo domain.RoutingStationRepository,
 	consumeRepo domain.MaterialConsumptionLogRepository,
 	yieldRepo domain.ProductionYieldLogRepository,
@@ -324,6 +327,7 @@ func NewShopFloorTelemetryService(
 	return &ShopFloorTelemetryServiceImpl{
 		db:          db,
 		woRepo:      woRepo,
+		wcRepo:      wcRepo,
 		stationRepo: stationRepo,
 		consumeRepo: consumeRepo,
 		yieldRepo:   yieldRepo,
@@ -405,11 +409,25 @@ func (s *ShopFloorTelemetryServiceImpl) CommitProductionYield(ctx context.Contex
 			return fmt.Errorf("work order not found: %w", err)
 		}
 
-		_, err = s.stationRepo.GetByID(txCtx, stationID)
+		station, err := s.stationRepo.GetByID(txCtx, stationID)
 		if err != nil {
 			return fmt.Errorf("routing station not found: %w", err)
 		}
 
+		wc, err := s.wcRepo.GetByID(txCtx, station.WorkCenterID)
+		if err != nil {
+			return fmt.Errorf("work center not found: %w", err)
+		}
+		costCenterID := ""
+		if wc.CostCenterID != nil {
+			costCenterID = *wc.CostCenterID
+		}
+		// FM allocates overhead by machine hours, taken at the station's standard run time
+		machineHours := decimal.NewFromInt(int64(station.StandardRunTimeMins)).
+			Mul(qtyGood.Add(qtyScrap)).
+			Div(decimal.NewFromInt(60)).
+			Round(4)
+
 		log := &domain.ProductionYieldLog{
 			ID:               utils.NewID("pyl"),
 			LegalEntityID:    legalEntityID,
@@ -440,6 +458,8 @@ func (s *ShopFloorTelemetryServiceImpl) CommitProductionYield(ctx context.Contex
 			QuantityGood:     qtyGood,
 			QuantityScrap:    qtyScrap,
 			OperatorHrID:     operatorHrID,
+			CostCenterID:     costCenterID,
+			MachineHours:     machineHours,
 			Timestamp:        time.Now(),
 		}
 
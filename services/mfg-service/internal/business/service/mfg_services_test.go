@@ -283,7 +283,7 @@ func TestFloorConfigurationService_EstablishWorkCenter(t *testing.T) {
 		},
 	}
 	svc := service.NewFloorConfigurationService(wcRepo, &mockStationRepo{})
-	wc, err := svc.EstablishWorkCenter(ctx, "legal-1", "WC-1", "Name 1")
+	wc, err := svc.EstablishWorkCenter(ctx, "legal-1", "WC-1", "Name 1", nil)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -301,7 +301,7 @@ func TestFloorConfigurationService_EstablishWorkCenter(t *testing.T) {
 		},
 	}
 	svc = service.NewFloorConfigurationService(wcRepo, &mockStationRepo{})
-	wc, err = svc.EstablishWorkCenter(ctx, "legal-1", "WC-1", "Name 1")
+	wc, err = svc.EstablishWorkCenter(ctx, "legal-1", "WC-1", "Name 1", nil)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -319,7 +319,7 @@ func TestFloorConfigurationService_EstablishWorkCenter(t *testing.T) {
 		},
 	}
 	svc = service.NewFloorConfigurationService(wcRepo, &mockStationRepo{})
-	_, err = svc.EstablishWorkCenter(ctx, "legal-1", "WC-1", "Name 1")
+	_, err = svc.EstablishWorkCenter(ctx, "legal-1", "WC-1", "Name 1", nil)
 	if err == nil || err.Error() != "create error" {
 		t.Errorf("expected create error, got %v", err)
 	}
@@ -640,12 +640,13 @@ func TestShopFloorTelemetryService_RecordBulkMaterialConsumption(t *testing.T) {
 	ctx := context.Background()
 
 	woRepo := &mockWORepo{}
+	wcRepo := &mockWCRepo{}
 	stationRepo := &mockStationRepo{}
 	consumeRepo := &mockConsumeRepo{}
 	yieldRepo := &mockYieldRepo{}
 	outboxRepo := &mockOutboxRepo{}
 
-	svc := service.NewShopFloorTelemetryService(db, woRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
+	svc := service.NewShopFloorTelemetryService(db, woRepo, wcRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
 
 	lines := []domain.ConsumptionSubmissionInput{
 		{MaterialID: "mat-1", RoutingStationID: "st-1", QuantityConsumed: decimal.NewFromInt(5), WarehouseID: "wh-1"},
@@ -714,12 +715,18 @@ func TestShopFloorTelemetryService_CommitProductionYield(t *testing.T) {
 	ctx := context.Background()
 
 	woRepo := &mockWORepo{}
+	costCenterID := "cc-assembly"
+	wcRepo := &mockWCRepo{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.WorkCenter, error) {
+			return &domain.WorkCenter{ID: id, CostCenterID: &costCenterID}, nil
+		},
+	}
 	stationRepo := &mockStationRepo{}
 	consumeRepo := &mockConsumeRepo{}
 	yieldRepo := &mockYieldRepo{}
 	outboxRepo := &mockOutboxRepo{}
 
-	svc := service.NewShopFloorTelemetryService(db, woRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
+	svc := service.NewShopFloorTelemetryService(db, woRepo, wcRepo, stationRepo, consumeRepo, yieldRepo, outboxRepo)
 
 	// Case 1: WorkOrder not found
 	woRepo.getByIDFunc = func(ctx context.Context, id string) (*domain.WorkOrder, error) {
@@ -778,14 +785,30 @@ func TestShopFloorTelemetryService_CommitProductionYield(t *testing.T) {
 		t.Error("expected error, got nil")
 	}
 
-	// Case 6: Happy path
+	// Case 6: Happy path reports the standard run time of good and scrapped units
+	var emitted *domain.TransactionalOutbox
 	outboxRepo.createFunc = func(ctx context.Context, msg *domain.TransactionalOutbox) error {
+		emitted = msg
 		return nil
 	}
+	stationRepo.getByIDFunc = func(ctx context.Context, id string) (*domain.RoutingStation, error) {
+		return &domain.RoutingStation{ID: id, WorkCenterID: "wc-1", StandardSetupTimeMins: 30, StandardRunTimeMins: 6}, nil
+	}
 	err = svc.CommitProductionYield(ctx, "tenant-1", "wo-1", "st-1", decimal.NewFromInt(10), decimal.NewFromInt(1), "op-1")
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
+	evt, ok := emitted.Payload.(domain.MfgYieldProducedEvent)
+	if !ok || evt.CostCenterID != "cc-assembly" || !evt.MachineHours.Equal(decimal.RequireFromString("1.1")) {
+		t.Errorf("expected 1.1 machine hours on cc-assembly, got %+v", emitted.Payload)
+	}
+
+	// Case 7: Work center not found
+	wcRepo.getByIDFunc = nil
+	err = svc.CommitProductionYield(ctx, "tenant-1", "wo-1", "st-1", decimal.NewFromInt(10), decimal.NewFromInt(1), "op-1")
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
 }
 
 func TestOutboxRelayWorker(t *testing.T) {
@@ -1081,7 +1104,7 @@ func TestFloorConfigurationService_EstablishWorkCenter_MemoryRepo(t *testing.T)
 	svc := service.NewFloorConfigurationService(wcRepo, stationRepo)
 
 	// Test EstablishWorkCenter with MemoryWorkCenterRepo
-	wc, err := svc.EstablishWorkCenter(ctx, "tenant-1", "WC-MEM-01", "Memory WC")
+	wc, err := svc.EstablishWorkCenter(ctx, "tenant-1", "WC-MEM-01", "Memory WC", nil)
 	if err != nil {
 		t.Fatalf("failed: %v", err)
 	}
@@ -1090,7 +1113,7 @@ func TestFloorConfigurationService_EstablishWorkCenter_MemoryRepo(t *testing.T)
 	}
 
 	// Repeated call returns same
-	wc2, err := svc.EstablishWorkCenter(ctx, "tenant-1", "WC-MEM-01", "Memory WC")
+	wc2, err := svc.EstablishWorkCenter(ctx, "tenant-1", "WC-MEM-01", "Memory WC", nil)
 	if err != nil {
 		t.Fatalf("failed: %v", err)
 	}
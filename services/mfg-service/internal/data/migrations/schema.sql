@@ -6,6 +6,7 @@ CREATE TABLE IF NOT EXISTS work_centers (
     legal_entity_id UUID NOT NULL,
     work_center_code VARCHAR(255) NOT NULL,
     name VARCHAR(255) NOT NULL,
+    cost_center_id UUID,
     is_active BOOLEAN NOT NULL,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL
@@ -14,6 +14,7 @@ type WorkCenter struct {
 	LegalEntityID  string    `gorm:"type:varchar(255);not null;index"`
 	WorkCenterCode string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_mfg_wc_code_tenant"`
 	Name           string    `gorm:"type:varchar(255);not null"`
+	CostCenterID   *string   `gorm:"type:varchar(255);default:null"`
 	IsActive       bool      `gorm:"type:boolean;default:true"`
 	CreatedAt      time.Time `gorm:"not null"`
 	UpdatedAt      time.Time `gorm:"not null"`
@@ -32,6 +33,7 @@ func ToWorkCenterDomain(w *WorkCenter) *domain.WorkCenter {
 		LegalEntityID:  w.LegalEntityID,
 		WorkCenterCode: w.WorkCenterCode,
 		Name:           w.Name,
+		CostCenterID:   w.CostCenterID,
 		IsActive:       w.IsActive,
 		CreatedAt:      w.CreatedAt,
 		UpdatedAt:      w.UpdatedAt,
@@ -47,6 +49,7 @@ func FromWorkCenterDomain(w *domain.WorkCenter) *WorkCenter {
 		LegalEntityID:  w.LegalEntityID,
 		WorkCenterCode: w.WorkCenterCode,
 		Name:           w.Name,
+		CostCenterID:   w.CostCenterID,
 		IsActive:       w.IsActive,
 		CreatedAt:      w.CreatedAt,
 		UpdatedAt:      w.UpdatedAt,
@@ -79,7 +79,7 @@ func main() {
 	// 5. Initialize Services
 	prodSvc := service.NewProductManagementService(prodRepo, catRepo, locRepo, publisher)
 	supSvc := service.NewSupplierManagementService(supRepo, contRepo, publisher)
-	poSvc := service.NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, publisher, tm)
+	poSvc := service.NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, publisher, outboxRepo, tm)
 	invSvc := service.NewInventoryService(invRepo, moveRepo, transferRepo, publisher, tm)
 	whSvc := service.NewWarehouseService(recRepo, recLRepo, shipRepo, shipLRepo, poRepo, lineRepo, invSvc, publisher, tm)
 	demandSvc := service.NewDemandPlanningService(forecastRepo)
@@ -34,12 +34,15 @@ func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
 func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
 	var req struct {
 		SupplierID       string `json:"supplier_id"`
+		RequisitionID    string `json:"requisition_id"`
 		ExpectedDelivery string `json:"expected_delivery"`
 		Notes            string `json:"notes"`
 		Lines            []struct {
 			MaterialID      string          `json:"material_id"`
 			QuantityOrdered decimal.Decimal `json:"quantity_ordered"`
 			UnitPrice       string          `json:"unit_price"`
+			AccountID       string          `json:"account_id"`
+			CostCenterID    string          `json:"cost_center_id"`
 		} `json:"lines"`
 	}
 
@@ -53,6 +56,16 @@ func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
 		deliveryTime = time.Now().AddDate(0, 0, 7) // default to 7 days out
 	}
 
+	if req.RequisitionID != "" {
+		po, err := h.svc.CreatePurchaseOrderFromRequisition(c.Request.Context(), req.RequisitionID, req.SupplierID, deliveryTime)
+		if err != nil {
+			h.response.BadRequest(c, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": po})
+		return
+	}
+
 	linesInput := make([]service.POLineInput, 0, len(req.Lines))
 	for _, l := range req.Lines {
 		priceDec, err := decimal.NewFromString(l.UnitPrice)
@@ -63,6 +76,8 @@ func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
 			MaterialID:      l.MaterialID,
 			QuantityOrdered: l.QuantityOrdered,
 			UnitPrice:       priceDec,
+			AccountID:       l.AccountID,
+			CostCenterID:    l.CostCenterID,
 		})
 	}
 
@@ -152,6 +167,8 @@ func (h *PurchaseOrderHandler) CreatePurchaseRequisition(c *gin.Context) {
 			MaterialID         string          `json:"material_id"`
 			QuantityRequested  decimal.Decimal `json:"quantity_requested"`
 			EstimatedUnitPrice string          `json:"estimated_unit_price"`
+			AccountID          string          `json:"account_id"`
+			CostCenterID       string          `json:"cost_center_id"`
 		} `json:"lines"`
 	}
 
@@ -175,6 +192,8 @@ func (h *PurchaseOrderHandler) CreatePurchaseRequisition(c *gin.Context) {
 			MaterialID:         l.MaterialID,
 			QuantityRequested:  l.QuantityRequested,
 			EstimatedUnitPrice: priceDec,
+			AccountID:          l.AccountID,
+			CostCenterID:       l.CostCenterID,
 		})
 	}
 
@@ -3,10 +3,12 @@ package domain
 
 const (
 	// Producer Events
-	TopicScmReceiptStaged        = "scm.receipt.staged"
-	TopicScmOrderShipped         = "scm.order.shipped"
-	TopicScmPurchaseOrderCreated = "scm.purchase.order.created"
-	TopicScmShipmentDispatched   = "scm.shipment.dispatched"
+	TopicScmReceiptStaged               = "scm.receipt.staged"
+	TopicScmOrderShipped                = "scm.order.shipped"
+	TopicScmPurchaseOrderCreated        = "scm.purchase.order.created"
+	TopicScmShipmentDispatched          = "scm.shipment.dispatched"
+	TopicScmPurchaseRequisitionApproved = "scm.purchase.requisition.approved"
+	TopicScmPurchaseOrderApproved       = "scm.purchase.order.approved"
 
 	// Consumer Events
 	TopicPlmMaterialReleased               = "plm.material.released"
@@ -12,11 +12,62 @@ type EventPublisher interface {
 }
 
 type PurchaseOrderCreatedEvent struct {
-	PurchaseOrderID string          `json:"purchase_order_id"`
-	PONumber        string          `json:"po_number"`
-	SupplierID      string          `json:"supplier_id"`
-	TotalAmount     decimal.Decimal `json:"total_amount"`
-	Timestamp       time.Time       `json:"timestamp"`
+	PurchaseOrderID string                   `json:"purchase_order_id"`
+	PONumber        string                   `json:"po_number"`
+	SupplierID      string                   `json:"supplier_id"`
+	TotalAmount     decimal.Decimal          `json:"total_amount"`
+	Lines           []PurchaseOrderLineEvent `json:"lines,omitempty"`
+	Timestamp       time.Time                `json:"timestamp"`
+}
+
+// PurchaseOrderLineEvent carries the ordered quantity and price fm-service matches vendor bills against
+type PurchaseOrderLineEvent struct {
+	LineID          string          `json:"line_id"`
+	MaterialID      string          `json:"material_id"`
+	QuantityOrdered decimal.Decimal `json:"quantity_ordered"`
+	UnitPrice       decimal.Decimal `json:"unit_price"`
+}
+
+// PurchaseCommitmentLine is the spend of an approved document on one account
+// and cost center
+type PurchaseCommitmentLine struct {
+	AccountID    string          `json:"account_id"`
+	CostCenterID string          `json:"cost_center_id,omitempty"`
+	Amount       decimal.Decimal `json:"amount"`
+}
+
+// PurchaseRequisitionApprovedEvent announces approved requisition spend
+type PurchaseRequisitionApprovedEvent struct {
+	EventID       string                   `json:"event_id"`
+	RequisitionID string                   `json:"requisition_id"`
+	NeedByDate    time.Time                `json:"need_by_date"`
+	Lines         []PurchaseCommitmentLine `json:"lines"`
+	Timestamp     time.Time                `json:"timestamp"`
+}
+
+// PurchaseOrderApprovedEvent announces approved order spend; it replaces the
+// commitment of the requisition it fulfils
+type PurchaseOrderApprovedEvent struct {
+	EventID         string                   `json:"event_id"`
+	PurchaseOrderID string                   `json:"purchase_order_id"`
+	RequisitionID   string                   `json:"requisition_id,omitempty"`
+	DeliveryDate    time.Time                `json:"delivery_date"`
+	Lines           []PurchaseCommitmentLine `json:"lines"`
+	Timestamp       time.Time                `json:"timestamp"`
+}
+
+// ReceiptStagedEvent announces a goods receipt against a purchase order
+type ReceiptStagedEvent struct {
+	ReceiptID       string             `json:"receipt_id"`
+	PurchaseOrderID string             `json:"purchase_order_id"`
+	ReceivedDate    time.Time          `json:"received_date"`
+	Lines           []ReceiptLineEvent `json:"lines"`
+	Timestamp       time.Time          `json:"timestamp"`
+}
+
+type ReceiptLineEvent struct {
+	MaterialID       string          `json:"material_id"`
+	QuantityReceived decimal.Decimal `json:"quantity_received"`
 }
 
 type InventoryValuedEvent struct {
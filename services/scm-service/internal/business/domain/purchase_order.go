@@ -11,6 +11,7 @@ type PurchaseOrder struct {
 	LegalEntityID    string              `json:"legal_entity_id"`
 	PoNumber         string              `json:"po_number"`
 	SupplierID       string              `json:"supplier_id"`
+	RequisitionID    *string             `json:"requisition_id,omitempty"`
 	OrderDate        time.Time           `json:"order_date"`
 	ExpectedDelivery time.Time           `json:"expected_delivery"`
 	Status           PurchaseOrderStatus `json:"status"`
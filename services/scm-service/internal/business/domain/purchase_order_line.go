@@ -14,5 +14,7 @@ type PurchaseOrderLine struct {
 	QuantityReceived decimal.Decimal `json:"quantity_received"`
 	UnitPrice        decimal.Decimal `json:"unit_price"`
 	LineTotal        decimal.Decimal `json:"line_total"`
+	AccountID        *string         `json:"account_id,omitempty"`     // Primitive Ref -> FM.Account
+	CostCenterID     *string         `json:"cost_center_id,omitempty"` // Primitive Ref -> FM.CostCenter
 	CreatedAt        time.Time       `json:"created_at"`
 }
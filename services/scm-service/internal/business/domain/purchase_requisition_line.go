@@ -12,4 +12,6 @@ type PurchaseRequisitionLine struct {
 	QuantityRequested     decimal.Decimal `json:"quantity_requested"`
 	EstimatedUnitPrice    decimal.Decimal `json:"estimated_unit_price"`
 	LineTotal             decimal.Decimal `json:"line_total"`
+	AccountID             *string         `json:"account_id,omitempty"`     // Primitive Ref -> FM.Account
+	CostCenterID          *string         `json:"cost_center_id,omitempty"` // Primitive Ref -> FM.CostCenter
 }
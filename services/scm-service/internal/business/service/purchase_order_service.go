@@ -4,6 +4,7 @@ import (
 	"context"
 	"erp-system/shared/utils"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/erp-system/scm-service/internal/business/domain"
@@ -16,6 +17,7 @@ type PurchaseOrderService struct {
 	reqRepo     domain.PurchaseRequisitionRepository
 	reqLineRepo domain.PurchaseRequisitionLineRepository
 	publisher   domain.EventPublisher
+	outbox      domain.TransactionalOutboxRepository
 	tm          domain.TransactionManager
 }
 
@@ -25,6 +27,7 @@ func NewPurchaseOrderService(
 	reqRepo domain.PurchaseRequisitionRepository,
 	reqLineRepo domain.PurchaseRequisitionLineRepository,
 	publisher domain.EventPublisher,
+	outbox domain.TransactionalOutboxRepository,
 	tm domain.TransactionManager,
 ) *PurchaseOrderService {
 	return &PurchaseOrderService{
@@ -33,6 +36,7 @@ func NewPurchaseOrderService(
 		reqRepo:     reqRepo,
 		reqLineRepo: reqLineRepo,
 		publisher:   publisher,
+		outbox:      outbox,
 		tm:          tm,
 	}
 }
@@ -41,6 +45,8 @@ type POLineInput struct {
 	MaterialID      string          `json:"material_id"`
 	QuantityOrdered decimal.Decimal `json:"quantity_ordered"`
 	UnitPrice       decimal.Decimal `json:"unit_price"`
+	AccountID       string          `json:"account_id,omitempty"`
+	CostCenterID    string          `json:"cost_center_id,omitempty"`
 }
 
 type PurchaseOrderDetails struct {
@@ -53,6 +59,39 @@ func (s *PurchaseOrderService) ListPurchaseOrders(ctx context.Context) ([]domain
 }
 
 func (s *PurchaseOrderService) CreatePurchaseOrder(ctx context.Context, supplierID string, expectedDelivery time.Time, notes string, lines []POLineInput) (*PurchaseOrderDetails, error) {
+	return s.createPurchaseOrder(ctx, "", supplierID, expectedDelivery, lines)
+}
+
+// CreatePurchaseOrderFromRequisition orders an approved requisition's lines,
+// keeping their accounts and cost centers so the order's budget commitment
+// replaces the requisition's.
+func (s *PurchaseOrderService) CreatePurchaseOrderFromRequisition(ctx context.Context, requisitionID, supplierID string, expectedDelivery time.Time) (*PurchaseOrderDetails, error) {
+	pr, err := s.reqRepo.GetByID(ctx, requisitionID)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Status != "APPROVED" {
+		return nil, fmt.Errorf("requisition %s is %s, only approved requisitions can be ordered", pr.ReqNumber, pr.Status)
+	}
+	reqLines, err := s.reqLineRepo.ListByRequisitionID(ctx, requisitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]POLineInput, 0, len(reqLines))
+	for _, l := range reqLines {
+		lines = append(lines, POLineInput{
+			MaterialID:      l.MaterialID,
+			QuantityOrdered: l.QuantityRequested,
+			UnitPrice:       l.EstimatedUnitPrice,
+			AccountID:       refValue(l.AccountID),
+			CostCenterID:    refValue(l.CostCenterID),
+		})
+	}
+	return s.createPurchaseOrder(ctx, pr.ID, supplierID, expectedDelivery, lines)
+}
+
+func (s *PurchaseOrderService) createPurchaseOrder(ctx context.Context, requisitionID, supplierID string, expectedDelivery time.Time, lines []POLineInput) (*PurchaseOrderDetails, error) {
 	poID := utils.NewID("po")
 	poNum := fmt.Sprintf("PO-%d", time.Now().Unix())
 
@@ -72,6 +111,8 @@ func (s *PurchaseOrderService) CreatePurchaseOrder(ctx context.Context, supplier
 			QuantityReceived: decimal.Zero,
 			UnitPrice:        l.UnitPrice,
 			LineTotal:        lineTotal,
+			AccountID:        optionalRef(l.AccountID),
+			CostCenterID:     optionalRef(l.CostCenterID),
 			CreatedAt:        time.Now(),
 		}
 
@@ -83,6 +124,7 @@ func (s *PurchaseOrderService) CreatePurchaseOrder(ctx context.Context, supplier
 		LegalEntityID:    "00000000-0000-0000-0000-000000000000",
 		PoNumber:         poNum,
 		SupplierID:       supplierID,
+		RequisitionID:    optionalRef(requisitionID),
 		OrderDate:        time.Now(),
 		ExpectedDelivery: expectedDelivery,
 		Status:           domain.PurchaseOrderStatusDRAFT,
@@ -144,7 +186,16 @@ func (s *PurchaseOrderService) UpdatePurchaseOrder(ctx context.Context, id strin
 	po.Status = domain.PurchaseOrderStatus(status)
 	po.UpdatedAt = time.Now()
 
-	err = s.poRepo.Update(ctx, po)
+	// The approval's spend is committed with the status change or not at all
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.poRepo.Update(txCtx, po); err != nil {
+			return err
+		}
+		if po.Status == domain.PurchaseOrderStatusAPPROVED && oldStatus != domain.PurchaseOrderStatusAPPROVED {
+			return s.writeOrderApproved(txCtx, po)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -175,6 +226,81 @@ func (s *PurchaseOrderService) UpdatePurchaseOrder(ctx context.Context, id strin
 	return po, nil
 }
 
+// writeOrderApproved queues the order's spend in the outbox so finance can
+// commit it
+func (s *PurchaseOrderService) writeOrderApproved(ctx context.Context, po *domain.PurchaseOrder) error {
+	lines, err := s.lineRepo.ListByPOID(ctx, po.ID)
+	if err != nil {
+		return err
+	}
+	var spend []spendLine
+	for _, l := range lines {
+		spend = append(spend, spendLine{l.ID, refValue(l.AccountID), refValue(l.CostCenterID), l.LineTotal})
+	}
+	return s.writeOutbox(ctx, domain.TopicScmPurchaseOrderApproved, po.ID, domain.PurchaseOrderApprovedEvent{
+		EventID:         utils.NewID("evt"),
+		PurchaseOrderID: po.ID,
+		RequisitionID:   refValue(po.RequisitionID),
+		DeliveryDate:    po.ExpectedDelivery,
+		Lines:           commitmentLines("purchase order "+po.PoNumber, spend),
+		Timestamp:       time.Now(),
+	})
+}
+
+func (s *PurchaseOrderService) writeOutbox(ctx context.Context, topic, aggregateID string, payload interface{}) error {
+	return s.outbox.Create(ctx, &domain.TransactionalOutbox{
+		ID:          utils.NewID("outbox"),
+		EventType:   topic,
+		AggregateID: aggregateID,
+		Payload:     payload,
+		Status:      domain.OutboxStatusPENDING,
+		CreatedAt:   time.Now(),
+	})
+}
+
+type spendLine struct {
+	lineID       string
+	accountID    string
+	costCenterID string
+	amount       decimal.Decimal
+}
+
+// commitmentLines totals spend per account and cost center. Lines without an
+// account are not budgeted; they are left out and logged against document.
+func commitmentLines(document string, spend []spendLine) []domain.PurchaseCommitmentLine {
+	var out []domain.PurchaseCommitmentLine
+	index := map[[2]string]int{}
+	for _, l := range spend {
+		if l.accountID == "" {
+			log.Printf("[SCM-Budget] %s line %s has no account; its %s is not committed", document, l.lineID, l.amount)
+			continue
+		}
+		key := [2]string{l.accountID, l.costCenterID}
+		if i, ok := index[key]; ok {
+			out[i].Amount = out[i].Amount.Add(l.amount)
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, domain.PurchaseCommitmentLine{AccountID: l.accountID, CostCenterID: l.costCenterID, Amount: l.amount})
+	}
+	return out
+}
+
+// optionalRef stores an empty reference as unset.
+func optionalRef(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+func refValue(id *string) string {
+	if id == nil {
+		return ""
+	}
+	return *id
+}
+
 func (s *PurchaseOrderService) DeletePurchaseOrder(ctx context.Context, id string) error {
 	return s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
 		if err := s.lineRepo.DeleteByPOID(txCtx, id); err != nil {
@@ -190,20 +316,42 @@ func (s *PurchaseOrderService) SendPurchaseOrder(ctx context.Context, id string)
 		return nil, err
 	}
 
+	wasApproved := po.Status == domain.PurchaseOrderStatusAPPROVED
 	po.Status = domain.PurchaseOrderStatus("SUBMITTED")
 	po.UpdatedAt = time.Now()
 
-	err = s.poRepo.Update(ctx, po)
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.poRepo.Update(txCtx, po); err != nil {
+			return err
+		}
+		// Sending an order that skipped approval still commits its spend
+		if !wasApproved {
+			return s.writeOrderApproved(txCtx, po)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Publish PO Created/Submitted event to Kafka
+	// Publish PO Created/Submitted event to Kafka, with the lines finance matches vendor bills against
+	var eventLines []domain.PurchaseOrderLineEvent
+	if poLines, err := s.lineRepo.ListByPOID(ctx, po.ID); err == nil {
+		for _, l := range poLines {
+			eventLines = append(eventLines, domain.PurchaseOrderLineEvent{
+				LineID:          l.ID,
+				MaterialID:      l.MaterialID,
+				QuantityOrdered: l.QuantityOrdered,
+				UnitPrice:       l.UnitPrice,
+			})
+		}
+	}
 	if err := s.publisher.Publish(ctx, domain.TopicScmPurchaseOrderCreated, po.ID, domain.PurchaseOrderCreatedEvent{
 		PurchaseOrderID: po.ID,
 		PONumber:        po.PoNumber,
 		SupplierID:      po.SupplierID,
 		TotalAmount:     po.TotalAmount,
+		Lines:           eventLines,
 		Timestamp:       time.Now(),
 	}); err != nil {
 		utils.LogPublishErr("scm-service", domain.TopicScmPurchaseOrderCreated, err)
@@ -225,6 +373,8 @@ type RequisitionLineInput struct {
 	MaterialID         string          `json:"material_id"`
 	QuantityRequested  decimal.Decimal `json:"quantity_requested"`
 	EstimatedUnitPrice decimal.Decimal `json:"estimated_unit_price"`
+	AccountID          string          `json:"account_id,omitempty"`
+	CostCenterID       string          `json:"cost_center_id,omitempty"`
 }
 
 type PurchaseRequisitionDetails struct {
@@ -254,6 +404,8 @@ func (s *PurchaseOrderService) CreatePurchaseRequisition(ctx context.Context, re
 			QuantityRequested:     l.QuantityRequested,
 			EstimatedUnitPrice:    l.EstimatedUnitPrice,
 			LineTotal:             lineTotal,
+			AccountID:             optionalRef(l.AccountID),
+			CostCenterID:          optionalRef(l.CostCenterID),
 		}
 		reqLines = append(reqLines, line)
 	}
@@ -347,10 +499,19 @@ func (s *PurchaseOrderService) ApprovePurchaseRequisition(ctx context.Context, i
 		return nil, err
 	}
 
+	wasApproved := pr.Status == "APPROVED"
 	pr.Status = "APPROVED"
 	pr.UpdatedAt = time.Now()
 
-	err = s.reqRepo.Update(ctx, pr)
+	err = s.tm.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.reqRepo.Update(txCtx, pr); err != nil {
+			return err
+		}
+		if !wasApproved {
+			return s.writeRequisitionApproved(txCtx, pr)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -358,6 +519,26 @@ func (s *PurchaseOrderService) ApprovePurchaseRequisition(ctx context.Context, i
 	return pr, nil
 }
 
+// writeRequisitionApproved queues the requisition's spend in the outbox so
+// finance can commit it
+func (s *PurchaseOrderService) writeRequisitionApproved(ctx context.Context, pr *domain.PurchaseRequisition) error {
+	lines, err := s.reqLineRepo.ListByRequisitionID(ctx, pr.ID)
+	if err != nil {
+		return err
+	}
+	var spend []spendLine
+	for _, l := range lines {
+		spend = append(spend, spendLine{l.ID, refValue(l.AccountID), refValue(l.CostCenterID), l.LineTotal})
+	}
+	return s.writeOutbox(ctx, domain.TopicScmPurchaseRequisitionApproved, pr.ID, domain.PurchaseRequisitionApprovedEvent{
+		EventID:       utils.NewID("evt"),
+		RequisitionID: pr.ID,
+		NeedByDate:    pr.RequestDate,
+		Lines:         commitmentLines("requisition "+pr.ReqNumber, spend),
+		Timestamp:     time.Now(),
+	})
+}
+
 func (s *PurchaseOrderService) RejectPurchaseRequisition(ctx context.Context, id string) (*domain.PurchaseRequisition, error) {
 	pr, err := s.reqRepo.GetByID(ctx, id)
 	if err != nil {
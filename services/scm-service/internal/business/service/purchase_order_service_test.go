@@ -150,7 +150,7 @@ func TestPurchaseOrderService_PurchaseOrders(t *testing.T) {
 		pub := &MockPublisher{}
 		tm := memory.NewMemoryTransactionManager()
 
-		svc := NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, pub, tm)
+		svc := NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, pub, memory.NewMemoryTransactionalOutboxRepo(), tm)
 		return svc, poRepo, lineRepo
 	}
 
@@ -374,7 +374,7 @@ func TestPurchaseOrderService_PurchaseRequisitions(t *testing.T) {
 		pub := &MockPublisher{}
 		tm := memory.NewMemoryTransactionManager()
 
-		svc := NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, pub, tm)
+		svc := NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, pub, memory.NewMemoryTransactionalOutboxRepo(), tm)
 		return svc, reqRepo, reqLineRepo
 	}
 
@@ -601,3 +601,77 @@ func TestPurchaseOrderService_PurchaseRequisitions(t *testing.T) {
 		}
 	})
 }
+
+func TestPurchaseOrderService_ApprovalCommitsSpend(t *testing.T) {
+	ctx := context.Background()
+	outbox := memory.NewMemoryTransactionalOutboxRepo()
+	svc := NewPurchaseOrderService(memory.NewMemoryPurchaseOrderRepo(), memory.NewMemoryPurchaseOrderLineRepo(),
+		memory.NewMemoryPurchaseRequisitionRepo(), memory.NewMemoryPurchaseRequisitionLineRepo(), &MockPublisher{}, outbox, memory.NewMemoryTransactionManager())
+	// queued returns the approvals waiting in the outbox for the relay
+	queued := func(topic string) []interface{} {
+		records, _ := outbox.GetUnsent(ctx, 100)
+		var payloads []interface{}
+		for _, r := range records {
+			if r.EventType == topic {
+				payloads = append(payloads, r.Payload)
+			}
+		}
+		return payloads
+	}
+
+	pr, err := svc.CreatePurchaseRequisition(ctx, "req-1", time.Now(), "", []RequisitionLineInput{
+		{MaterialID: "m-1", QuantityRequested: decimal.NewFromInt(2), EstimatedUnitPrice: decimal.NewFromInt(10), AccountID: "acc-6100", CostCenterID: "cc-1"},
+		{MaterialID: "m-2", QuantityRequested: decimal.NewFromInt(1), EstimatedUnitPrice: decimal.NewFromInt(5), AccountID: "acc-6100", CostCenterID: "cc-1"},
+		{MaterialID: "m-3", QuantityRequested: decimal.NewFromInt(1), EstimatedUnitPrice: decimal.NewFromInt(7)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CreatePurchaseOrderFromRequisition(ctx, pr.ID, "supp-1", time.Now()); err == nil {
+		t.Error("expected an unapproved requisition to be refused")
+	}
+	if _, err := svc.ApprovePurchaseRequisition(ctx, pr.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reqEvents := queued(domain.TopicScmPurchaseRequisitionApproved)
+	if len(reqEvents) != 1 {
+		t.Fatalf("expected one queued requisition approval, got %+v", reqEvents)
+	}
+	reqEvent, ok := reqEvents[0].(domain.PurchaseRequisitionApprovedEvent)
+	if !ok || reqEvent.RequisitionID != pr.ID || len(reqEvent.Lines) != 1 {
+		t.Fatalf("unexpected requisition event %+v", reqEvents[0])
+	}
+	if l := reqEvent.Lines[0]; l.AccountID != "acc-6100" || l.CostCenterID != "cc-1" || !l.Amount.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("expected 25 on acc-6100/cc-1, got %+v", l)
+	}
+
+	po, err := svc.CreatePurchaseOrderFromRequisition(ctx, pr.ID, "supp-1", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refValue(po.RequisitionID) != pr.ID || refValue(po.Lines[0].AccountID) != "acc-6100" {
+		t.Errorf("order did not keep the requisition's accounts: %+v", po)
+	}
+	if _, err := svc.UpdatePurchaseOrder(ctx, po.ID, time.Now(), string(domain.PurchaseOrderStatusAPPROVED), ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	poEvents := queued(domain.TopicScmPurchaseOrderApproved)
+	if len(poEvents) != 1 {
+		t.Fatalf("expected one queued order approval, got %+v", poEvents)
+	}
+	poEvent, ok := poEvents[0].(domain.PurchaseOrderApprovedEvent)
+	if !ok || poEvent.PurchaseOrderID != po.ID || poEvent.RequisitionID != pr.ID {
+		t.Fatalf("unexpected order event %+v", poEvents[0])
+	}
+	if len(poEvent.Lines) != 1 || !poEvent.Lines[0].Amount.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("expected one 25 commitment line, got %+v", poEvent.Lines)
+	}
+
+	if _, err := svc.SendPurchaseOrder(ctx, po.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(queued(domain.TopicScmPurchaseOrderApproved)); n != 1 {
+		t.Errorf("sending an approved order must not commit its spend again, got %d approvals", n)
+	}
+}
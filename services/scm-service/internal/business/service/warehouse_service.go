@@ -172,6 +172,26 @@ func (s *WarehouseService) CreateReceipt(ctx context.Context, poID string, notes
 		return nil, err
 	}
 
+	// Receipts against a purchase order feed the three-way match of vendor bills in finance
+	if poID != "" {
+		stagedLines := make([]domain.ReceiptLineEvent, len(savedLines))
+		for i, l := range savedLines {
+			stagedLines[i] = domain.ReceiptLineEvent{
+				MaterialID:       l.ProductID,
+				QuantityReceived: decimal.NewFromInt(int64(l.QuantityReceived)),
+			}
+		}
+		if err := s.publisher.Publish(ctx, domain.TopicScmReceiptStaged, rec.ID, domain.ReceiptStagedEvent{
+			ReceiptID:       rec.ID,
+			PurchaseOrderID: poID,
+			ReceivedDate:    rec.ReceivedDate,
+			Lines:           stagedLines,
+			Timestamp:       time.Now(),
+		}); err != nil {
+			utils.LogPublishErr("scm-service", domain.TopicScmReceiptStaged, err)
+		}
+	}
+
 	// Publish material delivered event for each line outside of transaction
 	for _, l := range savedLines {
 		if err := s.publisher.Publish(ctx, domain.TopicScmMaterialDelivered, l.ProductID, domain.MaterialDeliveredEvent{
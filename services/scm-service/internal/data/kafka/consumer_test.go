@@ -103,7 +103,7 @@ func setupTestEnv(t *testing.T) *testEnv {
 		IsActive:    true,
 	})
 
-	poSvc := service.NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, publisher, tm)
+	poSvc := service.NewPurchaseOrderService(poRepo, lineRepo, reqRepo, reqLineRepo, publisher, sql.NewSQLTransactionalOutboxRepo(db), tm)
 	invSvc := service.NewInventoryService(invRepo, moveRepo, transferRepo, publisher, tm)
 	demandSvc := service.NewDemandPlanningService(forecastRepo)
 
@@ -89,7 +89,9 @@ CREATE TABLE IF NOT EXISTS purchase_requisition_lines (
     material_id UUID NOT NULL,
     quantity_requested NUMERIC(15, 4) NOT NULL,
     estimated_unit_price NUMERIC(15, 4) NOT NULL,
-    line_total NUMERIC(15, 4) NOT NULL
+    line_total NUMERIC(15, 4) NOT NULL,
+    account_id UUID,
+    cost_center_id UUID
 );
 
 CREATE TABLE IF NOT EXISTS stock_transfers (
@@ -111,6 +113,7 @@ CREATE TABLE IF NOT EXISTS purchase_orders (
     legal_entity_id UUID NOT NULL,
     po_number VARCHAR(255) NOT NULL,
     supplier_id UUID NOT NULL,
+    requisition_id UUID,
     order_date TIMESTAMP NOT NULL,
     expected_delivery TIMESTAMP NOT NULL,
     status VARCHAR(255) NOT NULL,
@@ -127,6 +130,8 @@ CREATE TABLE IF NOT EXISTS purchase_order_lines (
     quantity_received NUMERIC(15, 4) NOT NULL,
     unit_price NUMERIC(15, 4) NOT NULL,
     line_total NUMERIC(15, 4) NOT NULL,
+    account_id UUID,
+    cost_center_id UUID,
     created_at TIMESTAMP NOT NULL
 );
 
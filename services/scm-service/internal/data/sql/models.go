@@ -458,6 +458,8 @@ type PurchaseRequisitionLine struct {
 	QuantityRequested     decimal.Decimal `gorm:"type:numeric(18,4)"`
 	EstimatedUnitPrice    decimal.Decimal `gorm:"type:numeric(18,4)"`
 	LineTotal             decimal.Decimal `gorm:"type:numeric(18,4)"`
+	AccountID             *string
+	CostCenterID          *string
 
 	PurchaseRequisition PurchaseRequisition `gorm:"foreignKey:PurchaseRequisitionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Product             Product             `gorm:"foreignKey:MaterialID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
@@ -474,6 +476,8 @@ func FromDomainPurchaseRequisitionLine(d *domain.PurchaseRequisitionLine) *Purch
 		QuantityRequested:     d.QuantityRequested,
 		EstimatedUnitPrice:    d.EstimatedUnitPrice,
 		LineTotal:             d.LineTotal,
+		AccountID:             d.AccountID,
+		CostCenterID:          d.CostCenterID,
 	}
 }
 
@@ -488,15 +492,18 @@ func ToDomainPurchaseRequisitionLine(dbModel *PurchaseRequisitionLine) *domain.P
 		QuantityRequested:     dbModel.QuantityRequested,
 		EstimatedUnitPrice:    dbModel.EstimatedUnitPrice,
 		LineTotal:             dbModel.LineTotal,
+		AccountID:             dbModel.AccountID,
+		CostCenterID:          dbModel.CostCenterID,
 	}
 }
 
 // PurchaseOrder GORM struct
 type PurchaseOrder struct {
-	ID               string `gorm:"primaryKey"`
-	LegalEntityID    string `gorm:"type:uuid;not null;index;default:'00000000-0000-0000-0000-000000000000'"`
-	PoNumber         string `gorm:"uniqueIndex"`
-	SupplierID       string `gorm:"index"`
+	ID               string  `gorm:"primaryKey"`
+	LegalEntityID    string  `gorm:"type:uuid;not null;index;default:'00000000-0000-0000-0000-000000000000'"`
+	PoNumber         string  `gorm:"uniqueIndex"`
+	SupplierID       string  `gorm:"index"`
+	RequisitionID    *string `gorm:"index"`
 	OrderDate        time.Time
 	ExpectedDelivery time.Time
 	Status           string
@@ -517,6 +524,7 @@ func FromDomainPurchaseOrder(d *domain.PurchaseOrder) *PurchaseOrder {
 		LegalEntityID:    DefaultLegalEntityID,
 		PoNumber:         d.PoNumber,
 		SupplierID:       d.SupplierID,
+		RequisitionID:    d.RequisitionID,
 		OrderDate:        d.OrderDate,
 		ExpectedDelivery: d.ExpectedDelivery,
 		Status:           string(d.Status),
@@ -535,6 +543,7 @@ func ToDomainPurchaseOrder(dbModel *PurchaseOrder) *domain.PurchaseOrder {
 		ID:               dbModel.ID,
 		PoNumber:         dbModel.PoNumber,
 		SupplierID:       dbModel.SupplierID,
+		RequisitionID:    dbModel.RequisitionID,
 		OrderDate:        dbModel.OrderDate,
 		ExpectedDelivery: dbModel.ExpectedDelivery,
 		Status:           domain.PurchaseOrderStatus(dbModel.Status),
@@ -553,6 +562,8 @@ type PurchaseOrderLine struct {
 	QuantityReceived decimal.Decimal `gorm:"type:numeric(18,4)"`
 	UnitPrice        decimal.Decimal `gorm:"type:numeric(18,4)"`
 	LineTotal        decimal.Decimal `gorm:"type:numeric(18,4)"`
+	AccountID        *string
+	CostCenterID     *string
 	Description      string
 	CreatedAt        time.Time
 
@@ -572,6 +583,8 @@ func FromDomainPurchaseOrderLine(d *domain.PurchaseOrderLine) *PurchaseOrderLine
 		QuantityReceived: d.QuantityReceived,
 		UnitPrice:        d.UnitPrice,
 		LineTotal:        d.LineTotal,
+		AccountID:        d.AccountID,
+		CostCenterID:     d.CostCenterID,
 		Description:      "",
 		CreatedAt:        d.CreatedAt,
 	}
@@ -589,6 +602,8 @@ func ToDomainPurchaseOrderLine(dbModel *PurchaseOrderLine) *domain.PurchaseOrder
 		QuantityReceived: dbModel.QuantityReceived,
 		UnitPrice:        dbModel.UnitPrice,
 		LineTotal:        dbModel.LineTotal,
+		AccountID:        dbModel.AccountID,
+		CostCenterID:     dbModel.CostCenterID,
 		CreatedAt:        dbModel.CreatedAt,
 	}
 }
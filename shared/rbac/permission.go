@@ -0,0 +1,156 @@
+// Package rbac evaluates permission grants against required permissions. The
+// API gateway and auth-service both decide access with Allows, so a route
+// check and a /validate-permission call always agree.
+//
+// A permission is "service:resource:action". In a grant any segment may be
+// "*", and a trailing "*" also covers the segments after it ("fm:*" grants
+// everything in fm). Actions imply others: a "write" grant satisfies "read".
+// A grant may be limited to a legal entity or a store with a scope suffix,
+// "fm:invoices:write@le:<id>" or "scm:stock:write@store:<id>".
+package rbac
+
+import "strings"
+
+const (
+	Wildcard = "*"
+
+	// Scope kinds of a grant suffix
+	ScopeLegalEntity = "le"
+	ScopeStore       = "store"
+)
+
+// impliedActions lists, per action, the actions a grant of it also covers.
+// Keep the closure explicit: implications are not followed transitively.
+var impliedActions = map[string][]string{
+	"write": {"read"},
+}
+
+// Scope is where a request acts. Empty fields are unknown, and scoped grants
+// never match an unknown scope.
+type Scope struct {
+	LegalEntityID string
+	StoreID       string
+}
+
+// Grant is a parsed permission grant
+type Grant struct {
+	Segments  []string
+	ScopeKind string // "", ScopeLegalEntity or ScopeStore
+	ScopeID   string
+}
+
+// ParseGrant splits "code@kind:id" into the code's segments and its scope
+func ParseGrant(grant string) Grant {
+	var g Grant
+	code := grant
+	if at := strings.LastIndex(grant, "@"); at >= 0 {
+		code = grant[:at]
+		g.ScopeKind, g.ScopeID, _ = strings.Cut(grant[at+1:], ":")
+	}
+	g.Segments = strings.Split(code, ":")
+	return g
+}
+
+// CodeOf strips the scope suffix from a grant
+func CodeOf(grant string) string {
+	if at := strings.LastIndex(grant, "@"); at >= 0 {
+		return grant[:at]
+	}
+	return grant
+}
+
+// Scoped appends a scope suffix to a permission code
+func Scoped(code, kind, id string) string {
+	return code + "@" + kind + ":" + id
+}
+
+// HasScope reports whether any of the grants is limited to the scope kind
+// and id, e.g. whether a caller holds anything in legal entity le-2.
+func HasScope(grants []string, kind, id string) bool {
+	for _, grant := range grants {
+		g := ParseGrant(grant)
+		if g.ScopeKind == kind && g.ScopeID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether any of the grants covers required in scope.
+// A "*" in required asks for every value of that segment, so only a granted
+// "*" covers it: "fm:*:read" needs "fm:*:read", "fm:*" or "*", never a grant
+// on a single fm resource.
+func Allows(grants []string, required string, scope Scope) bool {
+	want := strings.Split(required, ":")
+	for _, grant := range grants {
+		if ParseGrant(grant).Covers(want, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAny reports whether any of the grants allows action on at least one
+// resource of service in scope, e.g. whether a caller may enter a service's
+// routes at all. Unlike Allows with "service:*:action", a grant on a single
+// resource is enough.
+func AllowsAny(grants []string, service, action string, scope Scope) bool {
+	for _, grant := range grants {
+		g := ParseGrant(grant)
+		resource := Wildcard
+		if len(g.Segments) > 1 {
+			resource = g.Segments[1]
+		}
+		if g.Covers([]string{service, resource, action}, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the grant satisfies the required segments in scope
+func (g Grant) Covers(required []string, scope Scope) bool {
+	if !g.inScope(scope) {
+		return false
+	}
+	for i, seg := range g.Segments {
+		if i >= len(required) {
+			return false
+		}
+		if seg == Wildcard && i == len(g.Segments)-1 {
+			return true
+		}
+		last := i == len(required)-1
+		if !segmentCovers(seg, required[i], last) {
+			return false
+		}
+	}
+	return len(g.Segments) == len(required)
+}
+
+func (g Grant) inScope(scope Scope) bool {
+	switch g.ScopeKind {
+	case "":
+		return true
+	case ScopeLegalEntity:
+		return g.ScopeID != "" && g.ScopeID == scope.LegalEntityID
+	case ScopeStore:
+		return g.ScopeID != "" && g.ScopeID == scope.StoreID
+	default:
+		return false
+	}
+}
+
+func segmentCovers(granted, required string, isAction bool) bool {
+	if granted == Wildcard || granted == required {
+		return true
+	}
+	if isAction {
+		for _, implied := range impliedActions[granted] {
+			if implied == required {
+				return true
+			}
+		}
+	}
+	return false
+}
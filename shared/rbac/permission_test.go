@@ -0,0 +1,88 @@
+package rbac
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		name     string
+		grants   []string
+		required string
+		scope    Scope
+		want     bool
+	}{
+		{"exact", []string{"fm:accounts:read"}, "fm:accounts:read", Scope{}, true},
+		{"other action", []string{"fm:accounts:read"}, "fm:accounts:write", Scope{}, false},
+		{"write implies read", []string{"fm:accounts:write"}, "fm:accounts:read", Scope{}, true},
+		{"read does not imply write", []string{"fm:accounts:read"}, "fm:accounts:write", Scope{}, false},
+		{"resource wildcard grant", []string{"fm:*:read"}, "fm:invoices:read", Scope{}, true},
+		{"trailing wildcard grant", []string{"fm:*"}, "fm:invoices:delete", Scope{}, true},
+		{"superuser", []string{"*"}, "hcm:employees:delete", Scope{}, true},
+		{"other service", []string{"fm:*"}, "scm:stock:read", Scope{}, false},
+		{"required wildcard needs a wildcard grant", []string{"fm:accounts:write"}, "fm:*:read", Scope{}, false},
+		{"required wildcard by wildcard grant", []string{"fm:*:write"}, "fm:*:read", Scope{}, true},
+		{"required wildcard by service grant", []string{"fm:*"}, "fm:*:read", Scope{}, true},
+		{"segment count", []string{"fm:accounts"}, "fm:accounts:read", Scope{}, false},
+		{"flat codes", []string{"users.create"}, "users.create", Scope{}, true},
+		{"flat codes differ", []string{"users.create"}, "users.delete", Scope{}, false},
+		{"legal entity", []string{"fm:invoices:write@le:le-1"}, "fm:invoices:read", Scope{LegalEntityID: "le-1"}, true},
+		{"other legal entity", []string{"fm:invoices:write@le:le-1"}, "fm:invoices:read", Scope{LegalEntityID: "le-2"}, false},
+		{"unknown scope fails closed", []string{"fm:invoices:write@le:le-1"}, "fm:invoices:read", Scope{}, false},
+		{"store", []string{"scm:stock:write@store:s-1"}, "scm:stock:write", Scope{StoreID: "s-1"}, true},
+		{"other store", []string{"scm:stock:write@store:s-1"}, "scm:stock:write", Scope{StoreID: "s-2", LegalEntityID: "s-1"}, false},
+		{"unknown scope kind", []string{"scm:stock:write@region:eu"}, "scm:stock:write", Scope{}, false},
+		{"any grant", []string{"fm:invoices:read", "scm:*@store:s-1"}, "scm:stock:write", Scope{StoreID: "s-1"}, true},
+		{"no grants", nil, "fm:accounts:read", Scope{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Allows(tc.grants, tc.required, tc.scope); got != tc.want {
+				t.Errorf("Allows(%v, %q, %+v) = %v, want %v", tc.grants, tc.required, tc.scope, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAllowsAny(t *testing.T) {
+	cases := []struct {
+		name   string
+		grants []string
+		scope  Scope
+		want   bool
+	}{
+		{"single resource", []string{"fm:invoices:read"}, Scope{}, true},
+		{"implied action", []string{"fm:invoices:write"}, Scope{}, true},
+		{"resource wildcard", []string{"fm:*:read"}, Scope{}, true},
+		{"service grant", []string{"fm:*"}, Scope{}, true},
+		{"superuser", []string{"*"}, Scope{}, true},
+		{"other action", []string{"fm:payments:approve"}, Scope{}, false},
+		{"other service", []string{"scm:stock:write"}, Scope{}, false},
+		{"legal entity", []string{"fm:invoices:write@le:le-1"}, Scope{LegalEntityID: "le-1"}, true},
+		{"other legal entity", []string{"fm:invoices:write@le:le-1"}, Scope{LegalEntityID: "le-2"}, false},
+		{"flat codes", []string{"users.create"}, Scope{}, false},
+		{"no grants", nil, Scope{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AllowsAny(tc.grants, "fm", "read", tc.scope); got != tc.want {
+				t.Errorf("AllowsAny(%v, fm, read, %+v) = %v, want %v", tc.grants, tc.scope, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseGrant(t *testing.T) {
+	g := ParseGrant(Scoped("scm:stock:write", ScopeStore, "s-1"))
+	if len(g.Segments) != 3 || g.ScopeKind != ScopeStore || g.ScopeID != "s-1" {
+		t.Errorf("unexpected grant %+v", g)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	grants := []string{"fm:invoices:read", "fm:invoices:write@le:le-2", "scm:stock:write@store:s-1"}
+	if !HasScope(grants, ScopeLegalEntity, "le-2") || !HasScope(grants, ScopeStore, "s-1") {
+		t.Error("expected the scoped grants to be found")
+	}
+	if HasScope(grants, ScopeLegalEntity, "le-3") || HasScope(grants, ScopeLegalEntity, "s-1") {
+		t.Error("expected no grant in le-3 or in a legal entity named s-1")
+	}
+}
@@ -17,6 +17,7 @@ type Config struct {
 	GatewayURL string
 	Username   string
 	Password   string
+	APIKey     string
 }
 
 type AuthResponse struct {
@@ -38,6 +39,7 @@ func main() {
 	flag.StringVar(&cfg.GatewayURL, "gateway", "http://localhost:8080", "API Gateway URL")
 	flag.StringVar(&cfg.Username, "username", "admin", "Admin username")
 	flag.StringVar(&cfg.Password, "password", "admin123", "Admin password")
+	flag.StringVar(&cfg.APIKey, "api-key", "", "Service account API key; used instead of -username/-password")
 	flag.Parse()
 
 	log.Println("🌱 Starting Day 2 Operations Data Seeder...")
@@ -45,10 +47,10 @@ func main() {
 
 	client := &http.Client{Timeout: 10 * time.Second}
 
-	// 1. Authenticate with Auth Service
+	// 1. Authenticate with Auth Service, unless running as a service account
 	var token string
 	var err error
-	for i := 1; i <= 5; i++ {
+	for i := 1; i <= 5 && cfg.APIKey == ""; i++ {
 		token, err = login(client, cfg)
 		if err == nil {
 			break
@@ -59,7 +61,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("❌ Authentication failed: %v", err)
 	}
-	log.Println("🔑 Authenticated successfully. Obtained JWT token.")
+	if cfg.APIKey != "" {
+		log.Println("🔑 Using service account API key.")
+	} else {
+		log.Println("🔑 Authenticated successfully. Obtained JWT token.")
+	}
 
 	// 2. Create Legal Entity (FM)
 	leID, err := createLegalEntity(client, cfg, token)
@@ -122,7 +128,9 @@ func newRequest(method, urlPath string, body interface{}, token string, cfg Conf
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if token != "" {
+	if cfg.APIKey != "" {
+		req.Header.Set("X-API-Key", cfg.APIKey)
+	} else if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 	return req, nil